@@ -0,0 +1,201 @@
+package client
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// APICalls counts the Kubernetes API calls per tester and per verb and resource,
+// and enforces an optional QPS budget shared by all clients of the run.
+// Unlike "ClientQPS" that limits each client, the budget limits the whole run,
+// which matters for shared clusters with strict API budgets.
+type APICalls struct {
+	mu sync.Mutex
+	// tester is the name of the tester making the calls.
+	tester string
+	// counts is keyed by tester name, and then by "[VERB] [RESOURCE]" (e.g., "list pods").
+	counts map[string]map[string]int64
+	// throttled is the total time the calls waited for the budget.
+	throttled time.Duration
+
+	limiter flowcontrol.RateLimiter
+}
+
+// NewAPICalls returns a new API call counter.
+// Zero "qps" disables the QPS budget.
+// Zero "burst" defaults to the QPS budget, rounded up.
+func NewAPICalls(qps float32, burst int) *APICalls {
+	ac := &APICalls{counts: make(map[string]map[string]int64)}
+	if qps > 0 {
+		if burst < 1 {
+			burst = int(qps)
+			if float32(burst) < qps {
+				burst++
+			}
+		}
+		ac.limiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	}
+	return ac
+}
+
+// SetTester sets the name of the tester for the following calls.
+func (ac *APICalls) SetTester(name string) {
+	ac.mu.Lock()
+	ac.tester = name
+	ac.mu.Unlock()
+}
+
+// Counts returns the copy of the API call counts,
+// keyed by tester name, and then by "[VERB] [RESOURCE]".
+func (ac *APICalls) Counts() map[string]map[string]int64 {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	counts := make(map[string]map[string]int64, len(ac.counts))
+	for tester, cs := range ac.counts {
+		counts[tester] = make(map[string]int64, len(cs))
+		for k, v := range cs {
+			counts[tester][k] = v
+		}
+	}
+	return counts
+}
+
+// Total returns the total number of the API calls of the tester.
+// Empty name returns the total of all testers.
+func (ac *APICalls) Total(tester string) (total int64) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	for name, cs := range ac.counts {
+		if tester != "" && name != tester {
+			continue
+		}
+		for _, v := range cs {
+			total += v
+		}
+	}
+	return total
+}
+
+// Throttled returns the total time the calls waited for the QPS budget.
+func (ac *APICalls) Throttled() time.Duration {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	return ac.throttled
+}
+
+// WrapTransport wraps the client transport to count the calls and
+// to wait for the QPS budget.
+// ref. https://pkg.go.dev/k8s.io/client-go/rest#Config.Wrap
+func (ac *APICalls) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &apiCallsRoundTripper{ac: ac, rt: rt}
+}
+
+type apiCallsRoundTripper struct {
+	ac *APICalls
+	rt http.RoundTripper
+}
+
+func (r *apiCallsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.ac.limiter != nil {
+		start := time.Now()
+		if err := r.ac.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		if took := time.Since(start); took > time.Millisecond {
+			r.ac.mu.Lock()
+			r.ac.throttled += took
+			r.ac.mu.Unlock()
+		}
+	}
+
+	verb, resource := parseAPICall(req)
+	key := verb + " " + resource
+	r.ac.mu.Lock()
+	cs, ok := r.ac.counts[r.ac.tester]
+	if !ok {
+		cs = make(map[string]int64)
+		r.ac.counts[r.ac.tester] = cs
+	}
+	cs[key]++
+	r.ac.mu.Unlock()
+
+	return r.rt.RoundTrip(req)
+}
+
+// parseAPICall returns the Kubernetes API verb and resource of the request
+// (e.g., "list" and "pods" for "GET /api/v1/namespaces/default/pods").
+// The resource of a sub-resource request is "[RESOURCE]/[SUBRESOURCE]" (e.g., "pods/log").
+// Non-resource requests (e.g., "/healthz", "/version") return the lower-case
+// HTTP method and the path.
+// ref. https://kubernetes.io/docs/reference/access-authn-authz/authorization/#determine-the-request-verb
+func parseAPICall(req *http.Request) (verb string, resource string) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return strings.ToLower(req.Method), req.URL.Path
+	}
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		// keep the namespace object itself and its sub-resources
+		// (e.g., "/api/v1/namespaces/default", "/api/v1/namespaces/default/finalize")
+		if len(parts) > 3 || (len(parts) == 3 && parts[2] != "status" && parts[2] != "finalize") {
+			parts = parts[2:]
+		}
+	}
+	resource = parts[0]
+	named := len(parts) >= 2
+	if len(parts) >= 3 {
+		resource += "/" + parts[2]
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		switch {
+		case req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1":
+			verb = "watch"
+		case named:
+			verb = "get"
+		default:
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		if named {
+			verb = "delete"
+		} else {
+			verb = "deletecollection"
+		}
+	default:
+		verb = strings.ToLower(req.Method)
+	}
+	return verb, resource
+}
+
+// SortedAPICallKeys returns the "[VERB] [RESOURCE]" keys of the counts,
+// sorted by the number of calls in descending order.
+func SortedAPICallKeys(counts map[string]int64) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
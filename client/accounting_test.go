@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_client "k8s.io/client-go/kubernetes"
+	k8s_client_rest "k8s.io/client-go/rest"
+)
+
+func TestParseAPICall(t *testing.T) {
+	tests := []struct {
+		method string
+		url    string
+
+		expectedVerb     string
+		expectedResource string
+	}{
+		{http.MethodGet, "/api/v1/namespaces/default/pods", "list", "pods"},
+		{http.MethodGet, "/api/v1/namespaces/default/pods?watch=true", "watch", "pods"},
+		{http.MethodGet, "/api/v1/namespaces/default/pods/hello", "get", "pods"},
+		{http.MethodGet, "/api/v1/namespaces/default/pods/hello/log", "get", "pods/log"},
+		{http.MethodGet, "/api/v1/nodes", "list", "nodes"},
+		{http.MethodPut, "/api/v1/nodes/hello/status", "update", "nodes/status"},
+		{http.MethodGet, "/api/v1/namespaces/default", "get", "namespaces"},
+		{http.MethodPut, "/api/v1/namespaces/default/finalize", "update", "namespaces/finalize"},
+		{http.MethodDelete, "/api/v1/namespaces/default", "delete", "namespaces"},
+		{http.MethodPost, "/apis/apps/v1/namespaces/default/deployments", "create", "deployments"},
+		{http.MethodPatch, "/apis/apps/v1/namespaces/default/deployments/hello/scale", "patch", "deployments/scale"},
+		{http.MethodDelete, "/apis/networking.k8s.io/v1/namespaces/default/networkpolicies", "deletecollection", "networkpolicies"},
+		{http.MethodGet, "/version", "get", "/version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			verb, resource := parseAPICall(req)
+			if verb != tt.expectedVerb || resource != tt.expectedResource {
+				t.Fatalf("expected %q %q, got %q %q", tt.expectedVerb, tt.expectedResource, verb, resource)
+			}
+		})
+	}
+}
+
+func TestAPICalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"apiVersion":"v1","kind":"PodList","items":[]}`))
+	}))
+	defer srv.Close()
+
+	// 10 QPS with 1 burst allows the first call, and throttles the next calls
+	ac := NewAPICalls(10, 1)
+	ccfg := &k8s_client_rest.Config{Host: srv.URL, QPS: 1000, Burst: 1000}
+	ccfg.Wrap(ac.WrapTransport)
+	cli, err := k8s_client.NewForConfig(ccfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	ac.SetTester("a")
+	for i := 0; i < 3; i++ {
+		if _, err = cli.CoreV1().Pods("default").List(context.Background(), meta_v1.ListOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ac.SetTester("b")
+	if _, err = cli.CoreV1().Pods("default").List(context.Background(), meta_v1.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < 250*time.Millisecond {
+		t.Fatalf("expected the QPS budget to throttle the calls, took %v", took)
+	}
+
+	counts := ac.Counts()
+	if counts["a"]["list pods"] != 3 {
+		t.Fatalf("unexpected counts %v", counts)
+	}
+	if counts["b"]["list pods"] != 1 {
+		t.Fatalf("unexpected counts %v", counts)
+	}
+	if total := ac.Total(""); total != 4 {
+		t.Fatalf("expected 4 calls, got %d", total)
+	}
+	if total := ac.Total("a"); total != 3 {
+		t.Fatalf("expected 3 calls, got %d", total)
+	}
+	if ac.Throttled() == 0 {
+		t.Fatal("expected throttled calls")
+	}
+}
@@ -10,8 +10,12 @@ import (
 	"sync"
 	"time"
 
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
@@ -24,6 +28,7 @@ import (
 	k8s_client_rest "k8s.io/client-go/rest"
 	clientcmd "k8s.io/client-go/tools/clientcmd"
 	clientcmd_api "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 // Config defines Kubernetes configuration.
@@ -38,6 +43,10 @@ type Config struct {
 	KubeconfigPath string
 	// KubeconfigContext is the kubeconfig context.
 	KubeconfigContext string
+	// ConfigMode is the source of the client configuration.
+	// Empty or "ConfigModeAuto" to load "KubeconfigPath" if set, and then
+	// to fall back to "EKS" and the in-cluster configuration.
+	ConfigMode string
 
 	// EKS defines EKS-specific configuration.
 	EKS *EKS
@@ -73,6 +82,30 @@ type Config struct {
 	ClientBurst int
 	// ClientTimeout is the client timeout.
 	ClientTimeout time.Duration
+
+	// APICalls counts the API calls of all clients, and enforces the QPS budget of the run.
+	// Leave nil to disable.
+	APICalls *APICalls
+
+	// RateLimiter is the client-side rate limiter shared by all clients.
+	// If set, "ClientQPS" and "ClientBurst" are ignored.
+	// Leave nil to create a limiter per client.
+	RateLimiter flowcontrol.RateLimiter
+	// RequestTimeout is the timeout of each API request, excluding the watch
+	// and streaming requests. Unlike "ClientTimeout", it does not cut watches.
+	// Zero disables the timeout.
+	RequestTimeout time.Duration
+	// RequestObserver is called with the result of each API request (e.g., to record latencies).
+	// Leave nil to disable.
+	RequestObserver func(RequestInfo)
+	// WrapTransport wraps the client transport for custom instrumentation.
+	// ref. https://pkg.go.dev/k8s.io/client-go/rest#Config.Wrap
+	// Leave nil to disable.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+	// RetryBackoff is the backoff of "RetryWithExponentialBackOff" with the clients
+	// of this configuration. It does not change the backoff of the other clients.
+	// Leave nil to use "DefaultRetryBackoff".
+	RetryBackoff *wait.Backoff
 }
 
 // EKS defines EKS-specific client configuration and its states.
@@ -88,6 +121,18 @@ type EKS struct {
 	// ClusterCADecoded is the cluster CA base64-decoded.
 	// Use for kubeconfig.
 	ClusterCADecoded string
+
+	// EKSAPI is used to describe the cluster when "ClusterAPIServerEndpoint"
+	// or "ClusterCADecoded" is empty, so that the client can be created
+	// from the cluster name and region without the kubeconfig file.
+	// Leave nil to create one from the default credentials.
+	EKSAPI eksiface.EKSAPI
+
+	// AssumeRoleARN is the IAM role to assume with the default credentials
+	// for the EKS API and token, if not empty.
+	AssumeRoleARN string
+	// AssumeRoleDuration is the duration of the assumed role credentials (default 15 minutes).
+	AssumeRoleDuration time.Duration
 }
 
 // Client defines Kubernetes client interface.
@@ -148,6 +193,11 @@ func New(cfg *Config) (Client, error) {
 	if cfg.KubectlDownloadURL == "" {
 		cfg.KubectlDownloadURL = defaultKubectlDownloadURL
 	}
+	if cfg.RetryBackoff != nil {
+		if err := validateRetryBackoff(*cfg.RetryBackoff); err != nil {
+			return nil, err
+		}
+	}
 	if err := installKubectl(cfg.Logger, cfg.KubectlPath, cfg.KubectlDownloadURL); err != nil {
 		return nil, err
 	}
@@ -156,6 +206,7 @@ func New(cfg *Config) (Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	configureRestConfig(cfg, ccfg)
 
 	cli := &client{
 		cfg:              cfg,
@@ -163,7 +214,11 @@ func New(cfg *Config) (Client, error) {
 		extensionClients: make([]apiextensions_apiserver_client.Interface, cfg.Clients),
 	}
 	for i := 0; i < cfg.Clients; i++ {
-		cli.clients[i], err = k8s_client.NewForConfig(ccfg)
+		cs, err := k8s_client.NewForConfig(ccfg)
+		if err != nil {
+			return nil, err
+		}
+		cli.clients[i], err = withRetryBackoff(cfg, cs)
 		if err != nil {
 			return nil, err
 		}
@@ -175,36 +230,53 @@ func New(cfg *Config) (Client, error) {
 	return cli, nil
 }
 
-// createRestConfig creates the Kubernetes client configuration.
-func createRestConfig(cfg *Config) (kcfg *k8s_client_rest.Config, err error) {
-	if kcfg, err = createRestConfigFromKubeconfig(cfg); err != nil {
-		cfg.Logger.Warn("failed to create config using KUBECONFIG", zap.Error(err))
+// NewKubernetesClient returns the Kubernetes clientset of the configuration,
+// without installing kubectl, for the read-only callers that never run kubectl.
+func NewKubernetesClient(cfg *Config) (k8s_client.Interface, error) {
+	ccfg, err := createRestConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
-
-	if kcfg == nil && cfg.EKS != nil {
-		kcfg, err = createRestConfigFromEKS(cfg)
-		if kcfg == nil || err != nil {
-			cfg.Logger.Warn("failed to create config previous EKS cluster state")
-			kcfg = nil
-		}
+	configureRestConfig(cfg, ccfg)
+	cs, err := k8s_client.NewForConfig(ccfg)
+	if err != nil {
+		return nil, err
 	}
+	return withRetryBackoff(cfg, cs)
+}
 
-	if kcfg == nil {
-		// https://github.com/kubernetes/client-go/blob/master/examples/in-cluster-client-configuration/main.go
-		kcfg, err = k8s_client_rest.InClusterConfig()
-		if kcfg == nil || err != nil {
-			cfg.Logger.Warn("failed to create config from in-cluster config", zap.Error(err))
-			kcfg = nil
-		}
-	}
+const (
+	// ConfigModeAuto loads the kubeconfig if "KubeconfigPath" is set, and otherwise
+	// (or on failure) falls back to "EKS", the in-cluster configuration (e.g.,
+	// running in a Pod with its service account), and the default kubeconfig.
+	ConfigModeAuto = "auto"
+	// ConfigModeKubeconfig only loads the kubeconfig of "KubeconfigPath".
+	ConfigModeKubeconfig = "kubeconfig"
+	// ConfigModeInCluster only uses the in-cluster configuration,
+	// ignoring "KubeconfigPath".
+	ConfigModeInCluster = "in-cluster"
+)
 
-	if kcfg == nil {
-		defaultConfig := clientcmd.DefaultClientConfig
-		kcfg, err = defaultConfig.ClientConfig()
-		if kcfg == nil || err != nil {
-			cfg.Logger.Warn("failed to create config from defaults", zap.Error(err))
-			kcfg = nil
+// inClusterConfig is the in-cluster configuration loader, replaced in tests.
+// ref. https://github.com/kubernetes/client-go/blob/master/examples/in-cluster-client-configuration/main.go
+var inClusterConfig = k8s_client_rest.InClusterConfig
+
+// createRestConfig creates the Kubernetes client configuration.
+func createRestConfig(cfg *Config) (kcfg *k8s_client_rest.Config, err error) {
+	switch cfg.ConfigMode {
+	case "", ConfigModeAuto:
+		kcfg = createRestConfigAuto(cfg)
+	case ConfigModeKubeconfig:
+		if kcfg, err = createRestConfigFromKubeconfig(cfg); err != nil {
+			return nil, err
 		}
+	case ConfigModeInCluster:
+		cfg.Logger.Info("creating config from in-cluster config")
+		if kcfg, err = inClusterConfig(); err != nil {
+			return nil, fmt.Errorf("failed to create config from in-cluster config (%v)", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown ConfigMode %q", cfg.ConfigMode)
 	}
 
 	if kcfg == nil {
@@ -285,6 +357,48 @@ func createRestConfig(cfg *Config) (kcfg *k8s_client_rest.Config, err error) {
 	return kcfg, nil
 }
 
+// createRestConfigAuto returns the first configuration created from
+// the kubeconfig, the EKS cluster states, the in-cluster configuration,
+// and the default kubeconfig, or nil if none.
+func createRestConfigAuto(cfg *Config) (kcfg *k8s_client_rest.Config) {
+	var err error
+	if cfg.KubeconfigPath != "" {
+		if kcfg, err = createRestConfigFromKubeconfig(cfg); err != nil {
+			cfg.Logger.Warn("failed to create config using KUBECONFIG", zap.Error(err))
+		}
+	} else {
+		cfg.Logger.Info("empty KUBECONFIG; falling back to EKS or in-cluster config")
+	}
+
+	if kcfg == nil && cfg.EKS != nil {
+		kcfg, err = createRestConfigFromEKS(cfg)
+		if kcfg == nil || err != nil {
+			cfg.Logger.Warn("failed to create config previous EKS cluster state", zap.Error(err))
+			kcfg = nil
+		}
+	}
+
+	if kcfg == nil {
+		kcfg, err = inClusterConfig()
+		if kcfg == nil || err != nil {
+			cfg.Logger.Warn("failed to create config from in-cluster config", zap.Error(err))
+			kcfg = nil
+		} else {
+			cfg.Logger.Info("created config from in-cluster config")
+		}
+	}
+
+	if kcfg == nil {
+		defaultConfig := clientcmd.DefaultClientConfig
+		kcfg, err = defaultConfig.ClientConfig()
+		if kcfg == nil || err != nil {
+			cfg.Logger.Warn("failed to create config from defaults", zap.Error(err))
+			kcfg = nil
+		}
+	}
+	return kcfg
+}
+
 func createRestConfigFromKubeconfig(cfg *Config) (kcfg *k8s_client_rest.Config, err error) {
 	if cfg.KubeconfigPath == "" {
 		return nil, errors.New("empty KUBECONFIG")
@@ -336,6 +450,11 @@ func createRestConfigFromEKS(cfg *Config) (kcfg *k8s_client_rest.Config, err err
 	if cfg.EKS.ClusterName == "" {
 		return nil, errors.New("empty ClusterName")
 	}
+	if cfg.EKS.ClusterAPIServerEndpoint == "" || cfg.EKS.ClusterCADecoded == "" {
+		if err = describeClusterEKS(cfg); err != nil {
+			return nil, err
+		}
+	}
 	if cfg.EKS.ClusterAPIServerEndpoint == "" {
 		return nil, errors.New("empty ClusterAPIServerEndpoint")
 	}
@@ -343,21 +462,65 @@ func createRestConfigFromEKS(cfg *Config) (kcfg *k8s_client_rest.Config, err err
 		return nil, errors.New("empty ClusterCADecoded")
 	}
 
+	authConfig := map[string]string{
+		"region":       cfg.EKS.Region,
+		"cluster-name": cfg.EKS.ClusterName,
+	}
+	if cfg.EKS.AssumeRoleARN != "" {
+		authConfig["role-arn"] = cfg.EKS.AssumeRoleARN
+	}
+	if cfg.EKS.AssumeRoleDuration > 0 {
+		authConfig["role-duration"] = cfg.EKS.AssumeRoleDuration.String()
+	}
 	return &k8s_client_rest.Config{
 		Host: cfg.EKS.ClusterAPIServerEndpoint,
 		TLSClientConfig: k8s_client_rest.TLSClientConfig{
 			CAData: []byte(cfg.EKS.ClusterCADecoded),
 		},
 		AuthProvider: &clientcmd_api.AuthProviderConfig{
-			Name: authProviderName,
-			Config: map[string]string{
-				"region":       cfg.EKS.Region,
-				"cluster-name": cfg.EKS.ClusterName,
-			},
+			Name:   authProviderName,
+			Config: authConfig,
 		},
 	}, nil
 }
 
+// describeClusterEKS updates the EKS apiserver endpoint and the cluster CA
+// with the EKS DescribeCluster API.
+func describeClusterEKS(cfg *Config) error {
+	if cfg.EKS.EKSAPI == nil {
+		sess, err := newSessionEKS(cfg.EKS.Region, cfg.EKS.AssumeRoleARN, cfg.EKS.AssumeRoleDuration)
+		if err != nil {
+			return fmt.Errorf("failed to create aws session (%v)", err)
+		}
+		cfg.EKS.EKSAPI = eks.New(sess)
+	}
+	out, err := cfg.EKS.EKSAPI.DescribeCluster(&eks.DescribeClusterInput{
+		Name: aws.String(cfg.EKS.ClusterName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe cluster %q (%v)", cfg.EKS.ClusterName, err)
+	}
+	if out.Cluster == nil {
+		return fmt.Errorf("cluster %q not found", cfg.EKS.ClusterName)
+	}
+	if cfg.EKS.ClusterAPIServerEndpoint == "" {
+		cfg.EKS.ClusterAPIServerEndpoint = aws.StringValue(out.Cluster.Endpoint)
+	}
+	if cfg.EKS.ClusterCADecoded == "" && out.Cluster.CertificateAuthority != nil {
+		ca, err := base64.StdEncoding.DecodeString(aws.StringValue(out.Cluster.CertificateAuthority.Data))
+		if err != nil {
+			return fmt.Errorf("failed to decode cluster CA (%v)", err)
+		}
+		cfg.EKS.ClusterCADecoded = string(ca)
+	}
+	cfg.Logger.Info("described EKS cluster",
+		zap.String("cluster-name", cfg.EKS.ClusterName),
+		zap.String("apiserver-endpoint", cfg.EKS.ClusterAPIServerEndpoint),
+		zap.String("status", aws.StringValue(out.Cluster.Status)),
+	)
+	return nil
+}
+
 const authProviderName = "eks"
 
 func init() {
@@ -374,32 +537,87 @@ func newAuthProviderEKS(_ string, config map[string]string, _ k8s_client_rest.Au
 		return nil, fmt.Errorf("'clientcmdapi.AuthProviderConfig' does not include 'cluster-name' key %+v", config)
 	}
 
-	sess := session.Must(session.NewSession(aws.NewConfig().WithRegion(awsRegion)))
+	var roleDuration time.Duration
+	if v, ok := config["role-duration"]; ok {
+		var err error
+		if roleDuration, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid 'role-duration' %q (%v)", v, err)
+		}
+	}
+	sess, err := newSessionEKS(awsRegion, config["role-arn"], roleDuration)
+	if err != nil {
+		return nil, err
+	}
 	return &eksAuthProvider{ts: newTokenSourceEKS(sess, clusterName)}, nil
 }
 
+// newSessionEKS creates the AWS session for the EKS API and token,
+// with the credentials of "roleARN" if not empty.
+func newSessionEKS(region string, roleARN string, roleDuration time.Duration) (*session.Session, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	creds := sess.Config.Credentials
+	if roleARN != "" {
+		creds = stscreds.NewCredentials(sess, roleARN, func(p *stscreds.AssumeRoleProvider) {
+			if roleDuration > 0 {
+				p.Duration = roleDuration
+			}
+		})
+	}
+	// refresh the temporary credentials (e.g., assumed role) before the expiry,
+	// since the token expires with its signing credentials
+	sess.Config.Credentials = aws_v1.NewRefreshingCredentials(zap.L(), creds, aws_v1.DefaultCredentialsExpiryWindow)
+	return sess, nil
+}
+
 type eksAuthProvider struct {
-	ts oauth2.TokenSource
+	ts *eksTokenSource
 }
 
 func (p *eksAuthProvider) WrapTransport(rt http.RoundTripper) http.RoundTripper {
-	return &oauth2.Transport{
-		Source: p.ts,
-		Base:   rt,
-	}
+	return &eksTransport{ts: p.ts, base: rt}
 }
 
 func (p *eksAuthProvider) Login() error {
 	return nil
 }
 
-func newTokenSourceEKS(sess *session.Session, clusterName string) oauth2.TokenSource {
+// eksTransport sets the EKS token, and drops the cached token on "Unauthorized",
+// so that the retried request gets a new token.
+type eksTransport struct {
+	ts   *eksTokenSource
+	base http.RoundTripper
+}
+
+func (t *eksTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.ts.Token()
+	if err != nil {
+		return nil, err
+	}
+	req2 := k8s_util_net.CloneRequest(req)
+	tok.SetAuthHeader(req2)
+	resp, err := t.base.RoundTrip(req2)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.ts.invalidate(tok)
+	}
+	return resp, err
+}
+
+func newTokenSourceEKS(sess *session.Session, clusterName string) *eksTokenSource {
 	return &eksTokenSource{sess: sess, clusterName: clusterName}
 }
 
+// eksTokenSource caches the EKS token until its expiry.
 type eksTokenSource struct {
 	sess        *session.Session
 	clusterName string
+
+	mu    sync.Mutex
+	token *oauth2.Token
+	// issued is the number of the tokens issued, including the first
+	issued int
 }
 
 // Reference
@@ -408,24 +626,67 @@ type eksTokenSource struct {
 const (
 	v1Prefix        = "k8s-aws-v1."
 	clusterIDHeader = "x-k8s-aws-id"
+	// presignedURLExpiration is the maximum expiration accepted by aws-iam-authenticator.
+	presignedURLExpiration = 15 * time.Minute
+	// tokenExpiration is shorter than the presigned URL expiration,
+	// to refresh the token before the server rejects it.
+	tokenExpiration = 14 * time.Minute
 )
 
 func (s *eksTokenSource) Token() (*oauth2.Token, error) {
-	stsAPI := sts.New(s.sess)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.Valid() {
+		return s.token, nil
+	}
+	tok, err := GetToken(s.sess, s.clusterName)
+	if err != nil {
+		zap.L().Warn("failed to get EKS token", zap.String("cluster-name", s.clusterName), zap.Error(err))
+		return nil, err
+	}
+	s.issued++
+	if s.issued > 1 {
+		zap.L().Info("refreshed EKS token",
+			zap.String("cluster-name", s.clusterName),
+			zap.Time("expiry", tok.Expiry),
+			zap.Int("refreshes", s.issued-1),
+		)
+	}
+	s.token = tok
+	return tok, nil
+}
+
+// invalidate drops the cached token, if not refreshed yet.
+func (s *eksTokenSource) invalidate(tok *oauth2.Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == tok {
+		zap.L().Warn("EKS token rejected; dropping cached token", zap.String("cluster-name", s.clusterName))
+		s.token = nil
+	}
+}
+
+// GetToken returns the EKS bearer token of the cluster, the presigned STS
+// GetCallerIdentity URL with the cluster name header (aws-iam-authenticator logic).
+func GetToken(sess *session.Session, clusterName string) (*oauth2.Token, error) {
+	stsAPI := sts.New(sess)
 	request, _ := stsAPI.GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
-	request.HTTPRequest.Header.Add(clusterIDHeader, s.clusterName)
+	request.HTTPRequest.Header.Add(clusterIDHeader, clusterName)
 
-	payload, err := request.Presign(60)
+	payload, err := request.Presign(presignedURLExpiration)
 	if err != nil {
 		return nil, err
 	}
-	token := v1Prefix + base64.RawURLEncoding.EncodeToString([]byte(payload))
-	tokenExpiration := time.Now().Local().Add(14 * time.Minute)
-	return &oauth2.Token{
-		AccessToken: token,
+	tok := &oauth2.Token{
+		AccessToken: v1Prefix + base64.RawURLEncoding.EncodeToString([]byte(payload)),
 		TokenType:   "Bearer",
-		Expiry:      tokenExpiration,
-	}, nil
+		Expiry:      time.Now().Local().Add(tokenExpiration),
+	}
+	// the presigned URL is rejected once the signing credentials expire
+	if exp, err := sess.Config.Credentials.ExpiresAt(); err == nil && !exp.IsZero() && exp.Before(tok.Expiry) {
+		tok.Expiry = exp
+	}
+	return tok, nil
 }
 
 // Reference
@@ -456,14 +717,9 @@ const (
 )
 
 // RetryWithExponentialBackOff a utility for retrying the given function with exponential backoff.
-func RetryWithExponentialBackOff(fn wait.ConditionFunc) error {
-	backoff := wait.Backoff{
-		Duration: retryBackoffInitialDuration,
-		Factor:   retryBackoffFactor,
-		Jitter:   retryBackoffJitter,
-		Steps:    retryBackoffSteps,
-	}
-	return wait.ExponentialBackoff(backoff, fn)
+// The backoff is the "RetryBackoff" of the Kubernetes client "c".
+func RetryWithExponentialBackOff(c k8s_client.Interface, fn wait.ConditionFunc) error {
+	return wait.ExponentialBackoff(RetryBackoff(c), fn)
 }
 
 // IsRetryableAPIError verifies whether the error is retryable.
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	k8s_client_rest "k8s.io/client-go/rest"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://kubeconfig.example.com
+  name: hello
+contexts:
+- context:
+    cluster: hello
+    user: hello
+  name: hello
+current-context: hello
+users:
+- name: hello
+  user:
+    token: hello
+`
+
+func TestCreateRestConfigMode(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "client-config-mode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	kubeconfigPath := filepath.Join(dir, "kubeconfig")
+	if err = ioutil.WriteFile(kubeconfigPath, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := inClusterConfig
+	defer func() { inClusterConfig = orig }()
+	inCluster := true
+	inClusterConfig = func() (*k8s_client_rest.Config, error) {
+		if !inCluster {
+			return nil, errors.New("not in cluster")
+		}
+		return &k8s_client_rest.Config{Host: "https://in-cluster.example.com"}, nil
+	}
+
+	tests := []struct {
+		name           string
+		mode           string
+		kubeconfigPath string
+		inCluster      bool
+		expectedHost   string
+		expectedErr    bool
+	}{
+		{"auto with kubeconfig", "", kubeconfigPath, true, "https://kubeconfig.example.com", false},
+		{"auto without kubeconfig", ConfigModeAuto, "", true, "https://in-cluster.example.com", false},
+		{"auto with invalid kubeconfig", ConfigModeAuto, filepath.Join(dir, "missing"), true, "https://in-cluster.example.com", false},
+		{"kubeconfig", ConfigModeKubeconfig, kubeconfigPath, true, "https://kubeconfig.example.com", false},
+		{"kubeconfig without path", ConfigModeKubeconfig, "", true, "", true},
+		{"in-cluster ignores kubeconfig", ConfigModeInCluster, kubeconfigPath, true, "https://in-cluster.example.com", false},
+		{"in-cluster outside cluster", ConfigModeInCluster, "", false, "", true},
+		{"unknown", "hello", kubeconfigPath, true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inCluster = tt.inCluster
+			kcfg, err := createRestConfig(&Config{
+				Logger:         zap.NewExample(),
+				KubeconfigPath: tt.kubeconfigPath,
+				ConfigMode:     tt.mode,
+			})
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil && kcfg.Host != tt.expectedHost {
+				t.Fatalf("expected host %q, got %q", tt.expectedHost, kcfg.Host)
+			}
+		})
+	}
+}
+
+func TestNewKubernetesClient(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "client-kubernetes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	kubeconfigPath := filepath.Join(dir, "kubeconfig")
+	if err = ioutil.WriteFile(kubeconfigPath, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kubectlPath := filepath.Join(dir, "kubectl")
+	c, err := NewKubernetesClient(&Config{
+		Logger:         zap.NewExample(),
+		KubectlPath:    kubectlPath,
+		KubeconfigPath: kubeconfigPath,
+		ConfigMode:     ConfigModeKubeconfig,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == nil {
+		t.Fatal("unexpected nil client")
+	}
+	if _, err = os.Stat(kubectlPath); !os.IsNotExist(err) {
+		t.Fatalf("unexpected kubectl installed %q (%v)", kubectlPath, err)
+	}
+}
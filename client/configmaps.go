@@ -36,5 +36,5 @@ func DeleteConfigmap(lg *zap.Logger, c k8s_client.Interface, namespace string, n
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
@@ -104,7 +104,7 @@ func DeleteDaemonSet(lg *zap.Logger, c k8s_client.Interface, namespace string, n
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
 
 // WaitForDaemonSetCompletes waits till target replicas are ready in the Deployment.
@@ -12,11 +12,16 @@ import (
 	core_v1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	k8s_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 )
 
 // WaitForDeploymentAvailables waits till target replicas are ready in the Deployment.
+// It watches the Deployment instead of polling, and calls the query function
+// every poll interval.
 func WaitForDeploymentAvailables(
 	ctx context.Context,
 	lg *zap.Logger,
@@ -55,25 +60,29 @@ func WaitForDeploymentAvailables(
 		sp.Stop()
 	}
 
-	retryWaitFunc := func() (done bool, err error) {
-		select {
-		case <-stopc:
-			return true, errors.New("wait aborted")
-		default:
-		}
+	stopQuery := startQueryFunc(ret.queryFunc, pollInterval)
+	defer stopQuery()
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-		dp, err = c.AppsV1().
-			Deployments(namespace).
-			Get(ctx, deploymentName, meta_v1.GetOptions{})
-		cancel()
-		if ret.queryFunc != nil {
-			ret.queryFunc()
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", deploymentName).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return c.AppsV1().Deployments(namespace).List(ctx, options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return c.AppsV1().Deployments(namespace).Watch(ctx, options)
+		},
+	}
+	_, err = untilWithSync(ctx, stopc, lw, &apps_v1.Deployment{}, nil, func(ev watch.Event) (bool, error) {
+		obj, ok := ev.Object.(*apps_v1.Deployment)
+		if !ok || obj.Name != deploymentName {
+			return false, nil
 		}
-		if err != nil {
-			lg.Warn("failed to get Deployment", zap.Bool("retriable-error", IsRetryableAPIError(err)), zap.Error(err))
-			return false, err
+		if ev.Type == watch.Deleted {
+			return false, fmt.Errorf("deployment %q deleted", deploymentName)
 		}
+		dp = obj
 
 		var dpCond apps_v1.DeploymentCondition
 		for _, cond := range dp.Status.Conditions {
@@ -109,8 +118,7 @@ func WaitForDeploymentAvailables(
 			return true, nil
 		}
 		return false, nil
-	}
-	err = wait.PollImmediate(pollInterval, durationTillDeadline(ctx), retryWaitFunc)
+	})
 	return dp, err
 }
 
@@ -141,5 +149,5 @@ func DeleteDeployment(lg *zap.Logger, c k8s_client.Interface, namespace string,
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"go.uber.org/zap"
+)
+
+type fakeEKS struct {
+	eksiface.EKSAPI
+	described int
+}
+
+func (f *fakeEKS) DescribeCluster(input *eks.DescribeClusterInput) (*eks.DescribeClusterOutput, error) {
+	f.described++
+	return &eks.DescribeClusterOutput{
+		Cluster: &eks.Cluster{
+			Name:     input.Name,
+			Endpoint: aws.String("https://hello.eks.amazonaws.com"),
+			Status:   aws.String(eks.ClusterStatusActive),
+			CertificateAuthority: &eks.Certificate{
+				Data: aws.String(base64.StdEncoding.EncodeToString([]byte("hello-ca"))),
+			},
+		},
+	}, nil
+}
+
+func TestCreateRestConfigFromEKS(t *testing.T) {
+	fake := &fakeEKS{}
+	cfg := &Config{
+		Logger: zap.NewExample(),
+		EKS: &EKS{
+			Region:      "us-west-2",
+			ClusterName: "hello",
+			EKSAPI:      fake,
+		},
+	}
+	kcfg, err := createRestConfigFromEKS(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.described != 1 {
+		t.Fatalf("expected 1 DescribeCluster call, got %d", fake.described)
+	}
+	if kcfg.Host != "https://hello.eks.amazonaws.com" {
+		t.Fatalf("unexpected host %q", kcfg.Host)
+	}
+	if string(kcfg.TLSClientConfig.CAData) != "hello-ca" {
+		t.Fatalf("unexpected CA %q", string(kcfg.TLSClientConfig.CAData))
+	}
+	if kcfg.AuthProvider == nil || kcfg.AuthProvider.Name != authProviderName || kcfg.AuthProvider.Config["cluster-name"] != "hello" {
+		t.Fatalf("unexpected auth provider %+v", kcfg.AuthProvider)
+	}
+
+	// the described states are reused
+	if _, err = createRestConfigFromEKS(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if fake.described != 1 {
+		t.Fatalf("expected no more DescribeCluster call, got %d", fake.described)
+	}
+	if _, ok := kcfg.AuthProvider.Config["role-arn"]; ok {
+		t.Fatalf("unexpected role in auth provider %+v", kcfg.AuthProvider)
+	}
+
+	cfg.EKS.AssumeRoleARN = "arn:aws:iam::123:role/hello"
+	cfg.EKS.AssumeRoleDuration = time.Hour
+	if kcfg, err = createRestConfigFromEKS(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if kcfg.AuthProvider.Config["role-arn"] != "arn:aws:iam::123:role/hello" || kcfg.AuthProvider.Config["role-duration"] != "1h0m0s" {
+		t.Fatalf("unexpected auth provider %+v", kcfg.AuthProvider)
+	}
+	if _, err = newAuthProviderEKS(authProviderName, kcfg.AuthProvider.Config, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetToken(t *testing.T) {
+	sess := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("us-west-2").
+		WithCredentials(credentials.NewStaticCredentials("AKID", "SECRET", ""))))
+	tok, err := GetToken(sess, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(tok.AccessToken, v1Prefix) {
+		t.Fatalf("unexpected token %q", tok.AccessToken)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(tok.AccessToken, v1Prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("Action") != "GetCallerIdentity" {
+		t.Fatalf("unexpected action %q", q.Get("Action"))
+	}
+	if q.Get("X-Amz-Expires") != "900" {
+		t.Fatalf("unexpected expiration %q", q.Get("X-Amz-Expires"))
+	}
+	if !strings.Contains(q.Get("X-Amz-SignedHeaders"), clusterIDHeader) {
+		t.Fatalf("expected the cluster ID header to be signed, got %q", q.Get("X-Amz-SignedHeaders"))
+	}
+}
+
+// expiringProvider returns new credentials that expire in "ttl".
+type expiringProvider struct {
+	ttl       time.Duration
+	retrieved int
+	credentials.Expiry
+}
+
+func (p *expiringProvider) Retrieve() (credentials.Value, error) {
+	p.retrieved++
+	p.SetExpiration(time.Now().Add(p.ttl), 0)
+	return credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN", ProviderName: "expiring"}, nil
+}
+
+func TestEKSTokenSource(t *testing.T) {
+	sess := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("us-west-2").
+		WithCredentials(credentials.NewStaticCredentials("AKID", "SECRET", ""))))
+	ts := newTokenSourceEKS(sess, "hello")
+	tok1, err := ts.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok2, err := ts.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok1 != tok2 {
+		t.Fatal("expected cached token")
+	}
+
+	// rejected token is refreshed
+	ts.invalidate(tok1)
+	tok3, err := ts.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok3 == tok1 || ts.issued != 2 {
+		t.Fatalf("expected refreshed token, got %d tokens issued", ts.issued)
+	}
+	// stale invalidation does not drop the refreshed token
+	ts.invalidate(tok1)
+	if tok4, _ := ts.Token(); tok4 != tok3 {
+		t.Fatal("expected cached token after stale invalidation")
+	}
+}
+
+func TestGetTokenCredentialsExpiry(t *testing.T) {
+	sess := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("us-west-2").
+		WithCredentials(credentials.NewCredentials(&expiringProvider{ttl: 3 * time.Minute}))))
+	if _, err := sess.Config.Credentials.Get(); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := GetToken(sess, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(tok.Expiry) > 3*time.Minute {
+		t.Fatalf("expected token expiry capped by credentials expiry, got %v", tok.Expiry)
+	}
+}
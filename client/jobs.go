@@ -15,8 +15,11 @@ import (
 	v1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	k8s_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 )
 
 // DeleteJob deletes Job with given name.
@@ -46,7 +49,7 @@ func DeleteJob(lg *zap.Logger, c k8s_client.Interface, namespace string, name st
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
 
 // DeleteCronJob deletes CronJob with given name.
@@ -76,11 +79,11 @@ func DeleteCronJob(lg *zap.Logger, c k8s_client.Interface, namespace string, nam
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
 
 // WaitForJobCompletes waits for all Job completion,
-// by counting the number of pods in the namespace.
+// by watching the Job until it is complete with the target succeeded Pods.
 func WaitForJobCompletes(
 	ctx context.Context,
 	lg *zap.Logger,
@@ -98,7 +101,7 @@ func WaitForJobCompletes(
 }
 
 // WaitForCronJobCompletes waits for all CronJob completion,
-// by counting the number of pods in the namespace.
+// by watching the Pods in the namespace until the target number of the Job Pods succeeded.
 func WaitForCronJobCompletes(
 	ctx context.Context,
 	lg *zap.Logger,
@@ -170,122 +173,153 @@ func waitForJobCompletes(
 		sp.Stop()
 	}
 
-	retryWaitFunc := func() (done bool, err error) {
-		select {
-		case <-stopc:
-			return true, errors.New("wait aborted")
-		default:
-		}
+	stopQuery := startQueryFunc(ret.queryFunc, pollInterval)
+	defer stopQuery()
 
-		lg.Info("listing job pods to check Job completion")
-		pods, err = ListPods(lg, c, namespace, 3000, 3*time.Second)
-		if err != nil {
-			lg.Warn("failed to list Pod", zap.Bool("retriable-error", IsRetryableAPIError(err)), zap.Error(err))
-			return false, err
-		}
-		if len(pods) == 0 {
-			lg.Warn("got an empty list of Pod")
-			if ret.queryFunc != nil {
-				ret.queryFunc()
+	switch isCronJob {
+	case false:
+		job, err = waitForJob(ctx, lg, stopc, c, namespace, jobName, targetCompletes)
+	case true:
+		err = waitForJobPods(ctx, lg, stopc, c, namespace, jobName, targetCompletes)
+		if err == nil {
+			lg.Info("checking CronJob object", zap.String("namespace", namespace))
+			gctx, gcancel := context.WithTimeout(context.Background(), time.Minute)
+			cronJob, err = c.
+				BatchV1beta1().
+				CronJobs(namespace).
+				Get(gctx, jobName, meta_v1.GetOptions{})
+			gcancel()
+			if err != nil {
+				lg.Warn("failed to check CronJob", zap.Bool("retriable-error", IsRetryableAPIError(err)), zap.Error(err))
+			} else {
+				lg.Info("checked CronJob object", zap.Int("active-jobs", len(cronJob.Status.Active)))
 			}
-			return false, nil
 		}
-		podSucceededCnt := 0
+	}
+
+	// list once at the end, for the callers and the pod function
+	var lerr error
+	pods, lerr = ListPods(lg, c, namespace, 3000, 3*time.Second)
+	if lerr != nil {
+		lg.Warn("failed to list Pod", zap.Bool("retriable-error", IsRetryableAPIError(lerr)), zap.Error(lerr))
+	}
+	if ret.podFunc != nil {
 		for _, pod := range pods {
-			jv, ok := pod.Labels["job-name"]
-			match := ok && jv == jobName
-			if !match {
-				// CronJob
-				match = strings.HasPrefix(pod.Name, jobName)
-			}
-			lg.Info("pod",
-				zap.String("job-name", jobName),
-				zap.String("job-name-from-label", jv),
-				zap.String("pod-name", pod.Name),
-				zap.String("pod-status-phase", fmt.Sprintf("%v", pod.Status.Phase)),
-				zap.Bool("label-match", match),
-			)
-			if ret.podFunc != nil {
+			if jobPodMatch(pod, jobName) {
 				ret.podFunc(pod)
 			}
-			if !match {
-				continue
-			}
-			if pod.Status.Phase == core_v1.PodSucceeded {
-				podSucceededCnt++
-			}
 		}
-		if podSucceededCnt < targetCompletes {
-			lg.Warn("poll job pods but not succeeded yet",
-				zap.String("namespace", namespace),
-				zap.String("job-name", jobName),
-				zap.Int("total-pods", len(pods)),
-				zap.Int("pod-succeeded-count", podSucceededCnt),
-				zap.Int("target-completes", targetCompletes),
-				zap.String("ctx-time-left", timeLeftTillDeadline(ctx)),
-			)
-			if ret.queryFunc != nil {
-				ret.queryFunc()
-			}
+	}
+	return job, cronJob, pods, err
+}
+
+// jobPodMatch returns true if the Pod belongs to the Job, or to a Job of the CronJob.
+func jobPodMatch(pod core_v1.Pod, jobName string) bool {
+	if jv, ok := pod.Labels["job-name"]; ok && jv == jobName {
+		return true
+	}
+	// CronJob
+	return strings.HasPrefix(pod.Name, jobName)
+}
+
+// waitForJob watches the Job until it is complete with the target succeeded Pods.
+func waitForJob(
+	ctx context.Context,
+	lg *zap.Logger,
+	stopc chan struct{},
+	c k8s_client.Interface,
+	namespace string,
+	jobName string,
+	targetCompletes int) (job *batch_v1.Job, err error) {
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", jobName).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return c.BatchV1().Jobs(namespace).List(ctx, options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return c.BatchV1().Jobs(namespace).Watch(ctx, options)
+		},
+	}
+	_, err = untilWithSync(ctx, stopc, lw, &batch_v1.Job{}, nil, func(ev watch.Event) (bool, error) {
+		obj, ok := ev.Object.(*batch_v1.Job)
+		if !ok || obj.Name != jobName {
 			return false, nil
 		}
-		lg.Info("job pods",
+		if ev.Type == watch.Deleted {
+			return false, fmt.Errorf("Job %q deleted", jobName)
+		}
+		job = obj
+
+		lg.Info("fetched Job",
 			zap.String("namespace", namespace),
 			zap.String("job-name", jobName),
-			zap.Int("pod-succeeded-count", podSucceededCnt),
+			zap.Int32("active", job.Status.Active),
+			zap.Int32("succeeded", job.Status.Succeeded),
+			zap.Int32("failed", job.Status.Failed),
 			zap.Int("target-completes", targetCompletes),
 			zap.String("ctx-time-left", timeLeftTillDeadline(ctx)),
 		)
-
-		switch isCronJob {
-		case false:
-			lg.Info("checking Job object", zap.String("namespace", namespace))
-			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-			job, err = c.
-				BatchV1().
-				Jobs(namespace).
-				Get(ctx, jobName, meta_v1.GetOptions{})
-			cancel()
-			if err != nil {
-				lg.Warn("failed to check Job", zap.Bool("retriable-error", IsRetryableAPIError(err)), zap.Error(err))
-				return false, err
+		for _, cond := range job.Status.Conditions {
+			if cond.Status != v1.ConditionTrue {
+				continue
 			}
-			for _, cond := range job.Status.Conditions {
-				if cond.Status != v1.ConditionTrue {
-					continue
-				}
-				if cond.Type == batch_v1.JobFailed {
-					lg.Warn("job failed", zap.String("condition-type", fmt.Sprintf("%s", cond.Type)))
-					return true, fmt.Errorf("Job %q status %q", jobName, cond.Type)
-				}
-				if cond.Type == batch_v1.JobComplete {
-					lg.Info("job complete", zap.String("condition-type", fmt.Sprintf("%s", cond.Type)))
-					return true, nil
-				}
-				lg.Warn("job not complete", zap.String("condition-type", fmt.Sprintf("%s", cond.Type)))
+			if cond.Type == batch_v1.JobFailed {
+				lg.Warn("job failed", zap.String("condition-type", fmt.Sprintf("%s", cond.Type)))
+				return true, fmt.Errorf("Job %q status %q", jobName, cond.Type)
 			}
-
-		case true:
-			lg.Info("checking CronJob object", zap.String("namespace", namespace))
-			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-			cronJob, err = c.
-				BatchV1beta1().
-				CronJobs(namespace).
-				Get(ctx, jobName, meta_v1.GetOptions{})
-			cancel()
-			if err != nil {
-				lg.Warn("failed to check CronJob", zap.Bool("retriable-error", IsRetryableAPIError(err)), zap.Error(err))
-				return false, err
+			if cond.Type == batch_v1.JobComplete {
+				if int(job.Status.Succeeded) < targetCompletes {
+					return true, fmt.Errorf("Job %q complete with %d succeeded Pods, expected %d", jobName, job.Status.Succeeded, targetCompletes)
+				}
+				lg.Info("job complete", zap.String("condition-type", fmt.Sprintf("%s", cond.Type)))
+				return true, nil
 			}
-			lg.Info("checked CronJob object", zap.Int("active-jobs", len(cronJob.Status.Active)))
-			return true, nil
-		}
-
-		if ret.queryFunc != nil {
-			ret.queryFunc()
+			lg.Warn("job not complete", zap.String("condition-type", fmt.Sprintf("%s", cond.Type)))
 		}
 		return false, nil
+	})
+	return job, err
+}
+
+// waitForJobPods watches the Pods in the namespace until the target number of the Job Pods succeeded.
+func waitForJobPods(
+	ctx context.Context,
+	lg *zap.Logger,
+	stopc chan struct{},
+	c k8s_client.Interface,
+	namespace string,
+	jobName string,
+	targetCompletes int) error {
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return c.CoreV1().Pods(namespace).List(ctx, options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return c.CoreV1().Pods(namespace).Watch(ctx, options)
+		},
 	}
-	err = wait.PollImmediate(pollInterval, durationTillDeadline(ctx), retryWaitFunc)
-	return job, cronJob, pods, err
+	succeeded := make(map[string]struct{})
+	_, err := untilWithSync(ctx, stopc, lw, &core_v1.Pod{}, nil, func(ev watch.Event) (bool, error) {
+		pod, ok := ev.Object.(*core_v1.Pod)
+		if !ok || !jobPodMatch(*pod, jobName) {
+			return false, nil
+		}
+		if ev.Type == watch.Deleted || pod.Status.Phase != core_v1.PodSucceeded {
+			delete(succeeded, pod.Name)
+			return false, nil
+		}
+		succeeded[pod.Name] = struct{}{}
+		lg.Info("job pod succeeded",
+			zap.String("namespace", namespace),
+			zap.String("job-name", jobName),
+			zap.String("pod-name", pod.Name),
+			zap.Int("pod-succeeded-count", len(succeeded)),
+			zap.Int("target-completes", targetCompletes),
+			zap.String("ctx-time-left", timeLeftTillDeadline(ctx)),
+		)
+		return len(succeeded) >= targetCompletes, nil
+	})
+	return err
 }
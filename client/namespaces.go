@@ -26,7 +26,7 @@ func ListNamespaces(c k8s_client.Interface) ([]core_v1.Namespace, error) {
 		namespaces = namespacesList.Items
 		return nil
 	}
-	if err := RetryWithExponentialBackOff(RetryFunction(listFunc)); err != nil {
+	if err := RetryWithExponentialBackOff(c, RetryFunction(listFunc)); err != nil {
 		return namespaces, err
 	}
 	return namespaces, nil
@@ -50,7 +50,7 @@ func CreateNamespace(lg *zap.Logger, c k8s_client.Interface, namespace string) e
 		lg.Warn("failed to create namespace", zap.String("namespace", namespace), zap.Error(err))
 		return err
 	}
-	return RetryWithExponentialBackOff(RetryFunction(createFunc, Allow(k8s_errors.IsAlreadyExists)))
+	return RetryWithExponentialBackOff(c, RetryFunction(createFunc, Allow(k8s_errors.IsAlreadyExists)))
 }
 
 // DeleteNamespaceAndWait deletes namespace with given name and waits for its deletion.
@@ -92,7 +92,7 @@ func deleteNamespace(lg *zap.Logger, c k8s_client.Interface, namespace string) e
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
 
 func waitForDeleteNamespace(lg *zap.Logger, cli k8s_client.Interface, namespace string, pollInterval time.Duration, timeout time.Duration, opts ...OpOption) error {
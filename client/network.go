@@ -0,0 +1,226 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_client "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ServiceCIDRSourceKubeadm is the service CIDR read from the "kube-system/kubeadm-config" ConfigMap.
+	ServiceCIDRSourceKubeadm = "kubeadm-config"
+	// ServiceCIDRSourceInferred is the service CIDR inferred from the "default/kubernetes" Service,
+	// assuming the EKS default prefix lengths ("/16" for IPv4, "/108" for IPv6).
+	ServiceCIDRSourceInferred = "inferred"
+)
+
+// ClusterNetwork is the IP family and service CIDR of the cluster.
+type ClusterNetwork struct {
+	// IPFamilies is the list of IP families of the cluster,
+	// with the primary family first (e.g., "IPv4", or "IPv6" and "IPv4").
+	IPFamilies []core_v1.IPFamily `json:"ip_families"`
+	// ServiceCIDR is the service IP range of the primary IP family.
+	ServiceCIDR string `json:"service_cidr"`
+	// ServiceCIDRSource is where the service CIDR was found.
+	ServiceCIDRSource string `json:"service_cidr_source"`
+}
+
+// PrimaryIPFamily returns the primary IP family, defaults to "IPv4".
+func (n ClusterNetwork) PrimaryIPFamily() core_v1.IPFamily {
+	if len(n.IPFamilies) == 0 {
+		return core_v1.IPv4Protocol
+	}
+	return n.IPFamilies[0]
+}
+
+// IPv6 returns true if the primary IP family is IPv6
+// (e.g., EKS clusters created with "ipFamily: ipv6").
+func (n ClusterNetwork) IPv6() bool {
+	return n.PrimaryIPFamily() == core_v1.IPv6Protocol
+}
+
+// DualStack returns true if the cluster has both IPv4 and IPv6 families.
+func (n ClusterNetwork) DualStack() bool {
+	return len(n.IPFamilies) > 1
+}
+
+// DetectClusterNetwork detects the IP families and the service CIDR of the cluster.
+// The IP families are read from the "default/kubernetes" Service, which is
+// allocated from the primary service CIDR. The service CIDR is read from the
+// kubeadm cluster configuration, if any, otherwise inferred from the Service ClusterIP
+// since EKS does not expose the kube-apiserver flags.
+func DetectClusterNetwork(lg *zap.Logger, c k8s_client.Interface) (ClusterNetwork, error) {
+	var n ClusterNetwork
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	svc, err := c.CoreV1().Services("default").Get(ctx, "kubernetes", meta_v1.GetOptions{})
+	cancel()
+	if err != nil {
+		return n, fmt.Errorf("failed to get 'default/kubernetes' service (%v)", err)
+	}
+	clusterIPs := svc.Spec.ClusterIPs
+	if len(clusterIPs) == 0 && svc.Spec.ClusterIP != "" {
+		clusterIPs = []string{svc.Spec.ClusterIP}
+	}
+	n.IPFamilies = svc.Spec.IPFamilies
+	if len(n.IPFamilies) == 0 {
+		for _, ip := range clusterIPs {
+			n.IPFamilies = append(n.IPFamilies, IPFamilyOf(ip))
+		}
+	}
+
+	subnets, err := getKubeadmServiceSubnets(c)
+	if err != nil {
+		lg.Warn("failed to read kubeadm cluster configuration; inferring service CIDR", zap.Error(err))
+	}
+	for _, subnet := range subnets {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			continue
+		}
+		if IPFamilyOf(ipNet.IP.String()) == n.PrimaryIPFamily() {
+			n.ServiceCIDR, n.ServiceCIDRSource = ipNet.String(), ServiceCIDRSourceKubeadm
+			break
+		}
+	}
+	if n.ServiceCIDR == "" && len(clusterIPs) > 0 {
+		n.ServiceCIDR, n.ServiceCIDRSource = inferServiceCIDR(clusterIPs[0]), ServiceCIDRSourceInferred
+	}
+
+	lg.Info("detected cluster network",
+		zap.Any("ip-families", n.IPFamilies),
+		zap.Strings("kubernetes-service-cluster-ips", clusterIPs),
+		zap.String("service-cidr", n.ServiceCIDR),
+		zap.String("service-cidr-source", n.ServiceCIDRSource),
+	)
+	return n, nil
+}
+
+// getKubeadmServiceSubnets returns the "networking.serviceSubnet" of the
+// kubeadm ClusterConfiguration, which is comma-separated for dual-stack.
+// Returns no error if the cluster is not created by kubeadm.
+func getKubeadmServiceSubnets(c k8s_client.Interface) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	cm, err := c.CoreV1().ConfigMaps("kube-system").Get(ctx, "kubeadm-config", meta_v1.GetOptions{})
+	cancel()
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var clusterCfg struct {
+		Networking struct {
+			ServiceSubnet string `json:"serviceSubnet"`
+		} `json:"networking"`
+	}
+	if err = yaml.Unmarshal([]byte(cm.Data["ClusterConfiguration"]), &clusterCfg); err != nil {
+		return nil, err
+	}
+	if clusterCfg.Networking.ServiceSubnet == "" {
+		return nil, nil
+	}
+	return strings.Split(clusterCfg.Networking.ServiceSubnet, ","), nil
+}
+
+// inferServiceCIDR returns the service CIDR of the given "kubernetes" Service ClusterIP,
+// using the EKS default prefix lengths.
+// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_KubernetesNetworkConfigRequest.html
+func inferServiceCIDR(clusterIP string) string {
+	ip := net.ParseIP(clusterIP)
+	if ip == nil {
+		return ""
+	}
+	bits, ones := 32, 16
+	if ip.To4() == nil {
+		bits, ones = 128, 108
+	}
+	ipNet := net.IPNet{IP: ip.Mask(net.CIDRMask(ones, bits)), Mask: net.CIDRMask(ones, bits)}
+	return ipNet.String()
+}
+
+// IPFamilyOf returns the IP family of the IP address.
+func IPFamilyOf(ip string) core_v1.IPFamily {
+	parsed := net.ParseIP(ip)
+	if parsed != nil && parsed.To4() == nil {
+		return core_v1.IPv6Protocol
+	}
+	return core_v1.IPv4Protocol
+}
+
+// SelectIP returns the first IP address of the given family,
+// or the first address if none matches.
+func SelectIP(ips []string, family core_v1.IPFamily) string {
+	for _, ip := range ips {
+		if IPFamilyOf(ip) == family {
+			return ip
+		}
+	}
+	if len(ips) > 0 {
+		return ips[0]
+	}
+	return ""
+}
+
+// GetPodIP returns the Pod IP of the given family.
+func GetPodIP(pod *core_v1.Pod, family core_v1.IPFamily) string {
+	ips := make([]string, 0, len(pod.Status.PodIPs)+1)
+	for _, ip := range pod.Status.PodIPs {
+		ips = append(ips, ip.IP)
+	}
+	if len(ips) == 0 && pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	return SelectIP(ips, family)
+}
+
+// GetNodeInternalIP returns the node internal IP of the given family, without the port.
+func GetNodeInternalIP(node *core_v1.Node, family core_v1.IPFamily) (string, error) {
+	ips := make([]string, 0, len(node.Status.Addresses))
+	for _, address := range node.Status.Addresses {
+		if address.Type == core_v1.NodeInternalIP && address.Address != "" {
+			ips = append(ips, address.Address)
+		}
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("Couldn't get the internal IP of host %s with addresses %v", node.Name, node.Status.Addresses)
+	}
+	return SelectIP(ips, family), nil
+}
+
+// PingCommand returns the busybox "ping" command for the IP address,
+// with "-6" for IPv6 addresses, since busybox does not pick the family from the address.
+func PingCommand(ip string) string {
+	if IPFamilyOf(ip) == core_v1.IPv6Protocol {
+		return "ping -6 -c 3 -w 30 " + ip
+	}
+	return "ping -c 3 -w 30 " + ip
+}
+
+// WithLoadBalancerIPFamily returns the Service annotations for the AWS Load Balancer Controller
+// to provision a dual-stack NLB with IP targets on IPv6 clusters, since
+// the in-tree cloud provider only supports IPv4 load balancers and instance targets.
+// The annotations are returned as is on IPv4 clusters.
+// ref. https://kubernetes-sigs.github.io/aws-load-balancer-controller/latest/guide/service/annotations/
+func WithLoadBalancerIPFamily(n ClusterNetwork, annotations map[string]string) map[string]string {
+	if !n.IPv6() {
+		return annotations
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations["service.beta.kubernetes.io/aws-load-balancer-type"] = "external"
+	annotations["service.beta.kubernetes.io/aws-load-balancer-nlb-target-type"] = "ip"
+	annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"] = "internet-facing"
+	annotations["service.beta.kubernetes.io/aws-load-balancer-ip-address-type"] = "dualstack"
+	return annotations
+}
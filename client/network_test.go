@@ -0,0 +1,29 @@
+package client
+
+import "testing"
+
+func TestInferServiceCIDR(t *testing.T) {
+	tt := []struct {
+		clusterIP string
+		expected  string
+	}{
+		{"10.100.0.1", "10.100.0.0/16"},
+		{"172.20.0.1", "172.20.0.0/16"},
+		{"fd4b:2c7a:1e5b::1", "fd4b:2c7a:1e5b::/108"},
+		{"invalid", ""},
+	}
+	for i, tv := range tt {
+		if cidr := inferServiceCIDR(tv.clusterIP); cidr != tv.expected {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.expected, cidr)
+		}
+	}
+}
+
+func TestPingCommand(t *testing.T) {
+	if cmd := PingCommand("192.168.1.10"); cmd != "ping -c 3 -w 30 192.168.1.10" {
+		t.Fatalf("unexpected %q", cmd)
+	}
+	if cmd := PingCommand("2600:1f14::10"); cmd != "ping -6 -c 3 -w 30 2600:1f14::10" {
+		t.Fatalf("unexpected %q", cmd)
+	}
+}
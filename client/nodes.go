@@ -4,13 +4,18 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sort"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	"go.uber.org/zap"
 	core_v1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	k8s_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 )
 
 const (
@@ -36,7 +41,7 @@ func ListNodesWithOptions(cli k8s_client.Interface, listOpts meta_v1.ListOptions
 		nodes = nodesList.Items
 		return nil
 	}
-	if err := RetryWithExponentialBackOff(RetryFunction(listFunc)); err != nil {
+	if err := RetryWithExponentialBackOff(cli, RetryFunction(listFunc)); err != nil {
 		return nodes, err
 	}
 	return nodes, nil
@@ -67,3 +72,95 @@ func GetRandomReadySchedulableNode(cli k8s_client.Interface) (*core_v1.Node, err
 
 	return &nodes[rand.Intn(len(nodes))], nil
 }
+
+// IsNodeReady returns true if the node has the "Ready" condition.
+func IsNodeReady(node *core_v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == core_v1.NodeReady {
+			return cond.Status == core_v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// WaitForNodes waits until "condition" returns true for the nodes,
+// by watching the nodes instead of polling. The nodes are sorted by name.
+// Use "WithLabelSelector" to select the nodes.
+func WaitForNodes(
+	ctx context.Context,
+	lg *zap.Logger,
+	stopc chan struct{},
+	c k8s_client.Interface,
+	condition func(nodes []core_v1.Node) (bool, error),
+	opts ...OpOption) (nodes []core_v1.Node, err error) {
+	ret := Op{}
+	ret.applyOpts(opts)
+
+	lg.Info("waiting for nodes",
+		zap.String("label-selector", ret.labelSelector),
+		zap.String("ctx-time-left", timeLeftTillDeadline(ctx)),
+	)
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = ret.labelSelector
+			return c.CoreV1().Nodes().List(ctx, options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = ret.labelSelector
+			return c.CoreV1().Nodes().Watch(ctx, options)
+		},
+	}
+	// evaluate the condition on the synced store, not only on the events,
+	// so that the condition is met without any event (e.g., no node)
+	var store cache.Store
+	check := func() (bool, error) {
+		objs := store.List()
+		nodes = make([]core_v1.Node, 0, len(objs))
+		for _, obj := range objs {
+			if node, ok := obj.(*core_v1.Node); ok {
+				nodes = append(nodes, *node)
+			}
+		}
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+		return condition(nodes)
+	}
+	_, err = untilWithSync(ctx, stopc, lw, &core_v1.Node{},
+		func(s cache.Store) (bool, error) {
+			store = s
+			return check()
+		},
+		func(watch.Event) (bool, error) { return check() },
+	)
+	return nodes, err
+}
+
+// WaitForNodesReady waits until at least "targetReady" nodes are ready,
+// by watching the nodes instead of polling.
+// Use "WithLabelSelector" to select the nodes.
+func WaitForNodesReady(
+	ctx context.Context,
+	lg *zap.Logger,
+	stopc chan struct{},
+	c k8s_client.Interface,
+	targetReady int,
+	opts ...OpOption) ([]core_v1.Node, error) {
+	prev := -1
+	return WaitForNodes(ctx, lg, stopc, c, func(nodes []core_v1.Node) (bool, error) {
+		ready := 0
+		for i := range nodes {
+			if IsNodeReady(&nodes[i]) {
+				ready++
+			}
+		}
+		if ready != prev {
+			lg.Info("nodes ready",
+				zap.Int("nodes", len(nodes)),
+				zap.Int("ready", ready),
+				zap.Int("target-ready", targetReady),
+				zap.String("ctx-time-left", timeLeftTillDeadline(ctx)),
+			)
+			prev = ready
+		}
+		return ready >= targetReady, nil
+	}, opts...)
+}
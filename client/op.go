@@ -1,6 +1,8 @@
 package client
 
 import (
+	"time"
+
 	core_v1 "k8s.io/api/core/v1"
 )
 
@@ -12,6 +14,8 @@ type Op struct {
 	podFunc         func(core_v1.Pod)
 	forceDelete     bool
 	forceDeleteFunc func()
+	pollInterval    time.Duration
+	stopc           chan struct{}
 }
 
 // OpOption configures Kubernetes client operations.
@@ -51,6 +55,16 @@ func WithForceDeleteFunc(forceDeleteFunc func()) OpOption {
 	return func(op *Op) { op.forceDeleteFunc = forceDeleteFunc }
 }
 
+// WithPollInterval configures the poll interval of the waiters.
+func WithPollInterval(d time.Duration) OpOption {
+	return func(op *Op) { op.pollInterval = d }
+}
+
+// WithStopc configures the channel to abort the waiters.
+func WithStopc(stopc chan struct{}) OpOption {
+	return func(op *Op) { op.stopc = stopc }
+}
+
 func (op *Op) applyOpts(opts []OpOption) {
 	for _, opt := range opts {
 		opt(op)
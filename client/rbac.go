@@ -38,7 +38,7 @@ func DeleteRBACClusterRole(lg *zap.Logger, c k8s_client.Interface, name string)
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
 
 // DeleteRBACClusterRole deletes ClusterRole with given name.
@@ -70,7 +70,7 @@ func DeleteRBACClusterRoleBinding(lg *zap.Logger, c k8s_client.Interface, name s
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
 
 // DeleteRBACRole deletes Role with given name.
@@ -102,7 +102,7 @@ func DeleteRBACRole(lg *zap.Logger, c k8s_client.Interface, namespace string, na
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
 
 // DeleteRBACRoleBinding deletes RoleBinding with given name.
@@ -132,5 +132,5 @@ func DeleteRBACRoleBinding(lg *zap.Logger, c k8s_client.Interface, namespace str
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8s_client "k8s.io/client-go/kubernetes"
+	k8s_client_rest "k8s.io/client-go/rest"
+)
+
+// DefaultRetryBackoff returns the default backoff of "RetryWithExponentialBackOff".
+func DefaultRetryBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: retryBackoffInitialDuration,
+		Factor:   retryBackoffFactor,
+		Jitter:   retryBackoffJitter,
+		Steps:    retryBackoffSteps,
+	}
+}
+
+// retryBackoffClient is the Kubernetes client with its own backoff,
+// so that the helpers (e.g., "DeleteNamespaceAndWait") given the client
+// retry with the backoff of its configuration.
+type retryBackoffClient struct {
+	k8s_client.Interface
+	backoff wait.Backoff
+}
+
+// withRetryBackoff returns the Kubernetes client with the "RetryBackoff" of the configuration.
+func withRetryBackoff(cfg *Config, c k8s_client.Interface) (k8s_client.Interface, error) {
+	if cfg.RetryBackoff == nil {
+		return c, nil
+	}
+	if err := validateRetryBackoff(*cfg.RetryBackoff); err != nil {
+		return nil, err
+	}
+	return &retryBackoffClient{Interface: c, backoff: *cfg.RetryBackoff}, nil
+}
+
+// RetryBackoff returns the backoff of "RetryWithExponentialBackOff" of the Kubernetes client,
+// which is the "RetryBackoff" of the client configuration, or "DefaultRetryBackoff".
+func RetryBackoff(c k8s_client.Interface) wait.Backoff {
+	if rc, ok := c.(*retryBackoffClient); ok {
+		return rc.backoff
+	}
+	return DefaultRetryBackoff()
+}
+
+func validateRetryBackoff(b wait.Backoff) error {
+	if b.Duration <= 0 {
+		return errors.New("retry backoff duration must be >0")
+	}
+	if b.Steps < 1 {
+		return errors.New("retry backoff steps must be >=1")
+	}
+	if b.Factor < 0 {
+		return errors.New("retry backoff factor must be >=0")
+	}
+	if b.Jitter < 0 {
+		return errors.New("retry backoff jitter must be >=0")
+	}
+	return nil
+}
+
+// RequestInfo is the result of a Kubernetes API request,
+// reported to the "RequestObserver".
+type RequestInfo struct {
+	// Verb is the Kubernetes API verb (e.g., "list").
+	Verb string
+	// Resource is the Kubernetes API resource (e.g., "pods").
+	Resource string
+	// Code is the HTTP response status code, zero if the request failed.
+	Code int
+	// Took is the time until the response headers are received.
+	Took time.Duration
+	// Err is the transport error, if any.
+	Err error
+}
+
+// configureRestConfig applies the rate limiter and the request-level
+// wrappers of the configuration to the Kubernetes client configuration.
+// The request wrapper is applied before the API call budget, so that
+// the timeout and the observed latency exclude the time waiting for the budget.
+func configureRestConfig(cfg *Config, kcfg *k8s_client_rest.Config) {
+	if cfg.RateLimiter != nil {
+		// shared by all clients, overrides "ClientQPS" and "ClientBurst"
+		kcfg.RateLimiter = cfg.RateLimiter
+	}
+	if cfg.RequestTimeout > 0 || cfg.RequestObserver != nil {
+		timeout, observer := cfg.RequestTimeout, cfg.RequestObserver
+		kcfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &requestRoundTripper{timeout: timeout, observer: observer, rt: rt}
+		})
+	}
+	if cfg.WrapTransport != nil {
+		kcfg.Wrap(cfg.WrapTransport)
+	}
+	if cfg.APICalls != nil {
+		kcfg.Wrap(cfg.APICalls.WrapTransport)
+	}
+}
+
+type requestRoundTripper struct {
+	timeout  time.Duration
+	observer func(RequestInfo)
+	rt       http.RoundTripper
+}
+
+func (r *requestRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var cancel context.CancelFunc
+	if r.timeout > 0 && !isLongRunning(req) {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), r.timeout)
+		req = req.WithContext(ctx)
+	}
+
+	start := time.Now()
+	resp, err := r.rt.RoundTrip(req)
+	if r.observer != nil {
+		verb, resource := parseAPICall(req)
+		info := RequestInfo{Verb: verb, Resource: resource, Took: time.Since(start), Err: err}
+		if resp != nil {
+			info.Code = resp.StatusCode
+		}
+		r.observer(info)
+	}
+
+	if cancel != nil {
+		if err != nil || resp == nil || resp.Body == nil {
+			cancel()
+		} else {
+			// the body is read after the round trip, so cancel on close
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		}
+	}
+	return resp, err
+}
+
+// isLongRunning returns true for the watch and streaming requests
+// (e.g., "kubectl logs -f"), which must not be cut by the per-request timeout.
+func isLongRunning(req *http.Request) bool {
+	q := req.URL.Query()
+	for _, k := range []string{"watch", "follow"} {
+		if v := q.Get(k); v == "true" || v == "1" {
+			return true
+		}
+	}
+	return false
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
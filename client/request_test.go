@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8s_client "k8s.io/client-go/kubernetes"
+	k8s_fake "k8s.io/client-go/kubernetes/fake"
+	k8s_client_rest "k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	if _, err := withRetryBackoff(&Config{RetryBackoff: &wait.Backoff{Duration: time.Millisecond}}, k8s_fake.NewSimpleClientset()); err == nil {
+		t.Fatal("expected error for zero steps")
+	}
+	if _, err := withRetryBackoff(&Config{RetryBackoff: &wait.Backoff{Steps: 3}}, k8s_fake.NewSimpleClientset()); err == nil {
+		t.Fatal("expected error for zero duration")
+	}
+
+	c1, err := withRetryBackoff(&Config{RetryBackoff: &wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}}, k8s_fake.NewSimpleClientset())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := withRetryBackoff(&Config{RetryBackoff: &wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 2}}, k8s_fake.NewSimpleClientset())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c3, err := withRetryBackoff(&Config{}, k8s_fake.NewSimpleClientset())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := RetryBackoff(c3); b != DefaultRetryBackoff() {
+		t.Fatalf("expected the default backoff, got %+v", b)
+	}
+
+	// each client retries with its own backoff
+	for c, expected := range map[k8s_client.Interface]int{c1: 3, c2: 2} {
+		calls := 0
+		err = RetryWithExponentialBackOff(c, func() (bool, error) {
+			calls++
+			return false, nil
+		})
+		if !errors.Is(err, wait.ErrWaitTimeout) {
+			t.Fatalf("expected wait timeout, got %v", err)
+		}
+		if calls != expected {
+			t.Fatalf("expected %d calls, got %d", expected, calls)
+		}
+	}
+}
+
+func TestIsLongRunning(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"/api/v1/namespaces/default/pods", false},
+		{"/api/v1/namespaces/default/pods?watch=true", true},
+		{"/api/v1/namespaces/default/pods?watch=1", true},
+		{"/api/v1/namespaces/default/pods?watch=false", false},
+		{"/api/v1/namespaces/default/pods/hello/log?follow=true", true},
+		{"/api/v1/namespaces/default/pods/hello/log", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := isLongRunning(httptest.NewRequest(http.MethodGet, tt.url, nil)); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConfigureRestConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("labelSelector") == "slow" {
+			time.Sleep(500 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"apiVersion":"v1","kind":"PodList","items":[]}`))
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var infos []RequestInfo
+	cfg := &Config{
+		// 10 QPS with 1 burst allows the first call, and throttles the next calls
+		RateLimiter:    flowcontrol.NewTokenBucketRateLimiter(10, 1),
+		RequestTimeout: 100 * time.Millisecond,
+		RequestObserver: func(info RequestInfo) {
+			mu.Lock()
+			infos = append(infos, info)
+			mu.Unlock()
+		},
+	}
+	ccfg := &k8s_client_rest.Config{Host: srv.URL, QPS: 1000, Burst: 1000}
+	configureRestConfig(cfg, ccfg)
+	cli, err := k8s_client.NewForConfig(ccfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err = cli.CoreV1().Pods("default").List(context.Background(), meta_v1.ListOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if took := time.Since(start); took < 150*time.Millisecond {
+		t.Fatalf("expected the rate limiter to throttle the calls, took %v", took)
+	}
+
+	if _, err = cli.CoreV1().Pods("default").List(context.Background(), meta_v1.ListOptions{LabelSelector: "slow"}); err == nil {
+		t.Fatal("expected the request timeout")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(infos) < 4 {
+		t.Fatalf("expected at least 4 observed requests, got %d", len(infos))
+	}
+	for _, info := range infos[:3] {
+		if info.Verb != "list" || info.Resource != "pods" || info.Code != http.StatusOK || info.Err != nil {
+			t.Fatalf("unexpected request info %+v", info)
+		}
+	}
+	if last := infos[len(infos)-1]; last.Err == nil || last.Code != 0 {
+		t.Fatalf("expected the timed out request, got %+v", last)
+	}
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	apiextensions_v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensions_apiserver_client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_client "k8s.io/client-go/kubernetes"
+)
+
+// DefaultRolloutPollInterval is the default poll interval of the rollout waiters
+// (e.g., "WaitForDeployment"). Configure with "WithPollInterval".
+const DefaultRolloutPollInterval = 5 * time.Second
+
+// WaitForDeployment waits until the Deployment rollout is complete,
+// as "kubectl rollout status": the latest spec is observed, and all replicas
+// are updated and available, with no old replica left.
+// It returns an error if the rollout exceeds its progress deadline.
+// The wait ends when "ctx" is done, or when the "WithStopc" channel is closed.
+func WaitForDeployment(ctx context.Context, lg *zap.Logger, c k8s_client.Interface, namespace string, name string, opts ...OpOption) (dp *apps_v1.Deployment, err error) {
+	err = pollRollout(ctx, lg, "Deployment", namespace, name, opts, func(ctx context.Context) (bool, string, error, error) {
+		dp, err = c.AppsV1().Deployments(namespace).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return false, "", nil, err
+		}
+		done, status, failure := deploymentRolloutStatus(dp)
+		return done, status, failure, nil
+	})
+	return dp, err
+}
+
+// WaitForDaemonSet waits until the DaemonSet rollout is complete,
+// as "kubectl rollout status": the latest spec is observed, and the Pods
+// on all scheduled nodes are updated and available.
+// The wait ends when "ctx" is done, or when the "WithStopc" channel is closed.
+func WaitForDaemonSet(ctx context.Context, lg *zap.Logger, c k8s_client.Interface, namespace string, name string, opts ...OpOption) (ds *apps_v1.DaemonSet, err error) {
+	err = pollRollout(ctx, lg, "DaemonSet", namespace, name, opts, func(ctx context.Context) (bool, string, error, error) {
+		ds, err = c.AppsV1().DaemonSets(namespace).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return false, "", nil, err
+		}
+		done, status := daemonSetRolloutStatus(ds)
+		return done, status, nil, nil
+	})
+	return ds, err
+}
+
+// WaitForJob waits until the Job is complete.
+// It returns an error as soon as the Job fails (e.g., exceeds its backoff limit).
+// The wait ends when "ctx" is done, or when the "WithStopc" channel is closed.
+func WaitForJob(ctx context.Context, lg *zap.Logger, c k8s_client.Interface, namespace string, name string, opts ...OpOption) (job *batch_v1.Job, err error) {
+	err = pollRollout(ctx, lg, "Job", namespace, name, opts, func(ctx context.Context) (bool, string, error, error) {
+		job, err = c.BatchV1().Jobs(namespace).Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return false, "", nil, err
+		}
+		done, status, failure := jobStatus(job)
+		return done, status, failure, nil
+	})
+	return job, err
+}
+
+// WaitForCRDEstablished waits until the CustomResourceDefinition is established,
+// so that the custom resources can be created.
+// It returns an error if the names of the CustomResourceDefinition are not accepted.
+// The wait ends when "ctx" is done, or when the "WithStopc" channel is closed.
+func WaitForCRDEstablished(ctx context.Context, lg *zap.Logger, c apiextensions_apiserver_client.Interface, name string, opts ...OpOption) (crd *apiextensions_v1.CustomResourceDefinition, err error) {
+	err = pollRollout(ctx, lg, "CustomResourceDefinition", "", name, opts, func(ctx context.Context) (bool, string, error, error) {
+		crd, err = c.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, name, meta_v1.GetOptions{})
+		if err != nil {
+			return false, "", nil, err
+		}
+		done, status, failure := crdStatus(crd)
+		return done, status, failure, nil
+	})
+	return crd, err
+}
+
+// pollRollout calls "check" every poll interval, until it returns true or a failure
+// (e.g., Job failed). The API errors (e.g., not found right after create) are logged and retried.
+func pollRollout(
+	ctx context.Context,
+	lg *zap.Logger,
+	kind string,
+	namespace string,
+	name string,
+	opts []OpOption,
+	check func(ctx context.Context) (done bool, status string, failure error, err error)) error {
+	ret := Op{}
+	ret.applyOpts(opts)
+	if ret.pollInterval == 0 {
+		ret.pollInterval = DefaultRolloutPollInterval
+	}
+
+	lg.Info("waiting for "+kind,
+		zap.String("namespace", namespace),
+		zap.String("name", name),
+		zap.String("poll-interval", ret.pollInterval.String()),
+		zap.String("ctx-time-left", timeLeftTillDeadline(ctx)),
+	)
+	start := time.Now()
+	lastStatus := ""
+	var lastErr error
+	for {
+		gctx, gcancel := context.WithTimeout(ctx, time.Minute)
+		done, status, failure, err := check(gctx)
+		gcancel()
+		if ret.queryFunc != nil {
+			ret.queryFunc()
+		}
+		switch {
+		case err != nil:
+			lg.Warn("failed to get "+kind+"; retrying", zap.String("name", name), zap.Bool("retriable-error", IsRetryableAPIError(err)), zap.Error(err))
+			lastErr = err
+		case failure != nil:
+			lg.Warn(kind+" failed", zap.String("namespace", namespace), zap.String("name", name), zap.Error(failure))
+			return fmt.Errorf("%s %q failed (%v)", kind, name, failure)
+		case done:
+			lg.Info(kind+" ready", zap.String("namespace", namespace), zap.String("name", name), zap.String("took", time.Since(start).String()))
+			return nil
+		default:
+			lastErr = nil
+			if status != lastStatus {
+				lg.Info("checked "+kind, zap.String("name", name), zap.String("status", status), zap.String("elapsed", time.Since(start).String()))
+				lastStatus = status
+			}
+		}
+
+		select {
+		case <-ret.stopc:
+			return fmt.Errorf("wait for %s %q aborted", kind, name)
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("%s %q not ready (%v, last error %v)", kind, name, ctx.Err(), lastErr)
+			}
+			return fmt.Errorf("%s %q not ready (%v, last status %q)", kind, name, ctx.Err(), lastStatus)
+		case <-time.After(ret.pollInterval):
+		}
+	}
+}
+
+// deploymentRolloutStatus returns true if the Deployment rollout is complete,
+// the status message otherwise, and an error if the rollout exceeded its progress deadline.
+// ref. https://github.com/kubernetes/kubectl/blob/master/pkg/polymorphichelpers/rollout_status.go
+func deploymentRolloutStatus(dp *apps_v1.Deployment) (bool, string, error) {
+	if dp.Generation > dp.Status.ObservedGeneration {
+		return false, "waiting for the spec update to be observed", nil
+	}
+	for _, cond := range dp.Status.Conditions {
+		if cond.Type == apps_v1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return false, "", fmt.Errorf("exceeded its progress deadline (%s)", cond.Message)
+		}
+	}
+	replicas := int32(1)
+	if dp.Spec.Replicas != nil {
+		replicas = *dp.Spec.Replicas
+	}
+	switch {
+	case dp.Status.UpdatedReplicas < replicas:
+		return false, fmt.Sprintf("%d of %d replicas updated", dp.Status.UpdatedReplicas, replicas), nil
+	case dp.Status.Replicas > dp.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d old replicas pending termination", dp.Status.Replicas-dp.Status.UpdatedReplicas), nil
+	case dp.Status.AvailableReplicas < dp.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d of %d updated replicas available", dp.Status.AvailableReplicas, dp.Status.UpdatedReplicas), nil
+	}
+	return true, fmt.Sprintf("%d replicas available", dp.Status.AvailableReplicas), nil
+}
+
+// daemonSetRolloutStatus returns true if the DaemonSet rollout is complete,
+// and the status message otherwise.
+func daemonSetRolloutStatus(ds *apps_v1.DaemonSet) (bool, string) {
+	if ds.Generation > ds.Status.ObservedGeneration {
+		return false, "waiting for the spec update to be observed"
+	}
+	switch {
+	case ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled:
+		return false, fmt.Sprintf("%d of %d Pods updated", ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled)
+	case ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled:
+		return false, fmt.Sprintf("%d of %d updated Pods available", ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled)
+	}
+	return true, fmt.Sprintf("%d Pods available", ds.Status.NumberAvailable)
+}
+
+// jobStatus returns true if the Job is complete, the status message otherwise,
+// and an error if the Job failed.
+func jobStatus(job *batch_v1.Job) (bool, string, error) {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != core_v1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batch_v1.JobComplete:
+			return true, fmt.Sprintf("%d Pods succeeded", job.Status.Succeeded), nil
+		case batch_v1.JobFailed:
+			return false, "", fmt.Errorf("%s (%s)", cond.Reason, cond.Message)
+		}
+	}
+	return false, fmt.Sprintf("%d active, %d succeeded, %d failed Pods", job.Status.Active, job.Status.Succeeded, job.Status.Failed), nil
+}
+
+// crdStatus returns true if the CustomResourceDefinition is established,
+// the status message otherwise, and an error if its names are not accepted.
+func crdStatus(crd *apiextensions_v1.CustomResourceDefinition) (bool, string, error) {
+	for _, cond := range crd.Status.Conditions {
+		switch {
+		case cond.Type == apiextensions_v1.Established && cond.Status == apiextensions_v1.ConditionTrue:
+			return true, "established", nil
+		case cond.Type == apiextensions_v1.NamesAccepted && cond.Status == apiextensions_v1.ConditionFalse:
+			return false, "", fmt.Errorf("names not accepted (%s)", cond.Message)
+		}
+	}
+	return false, "waiting for the CustomResourceDefinition to be established", nil
+}
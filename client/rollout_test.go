@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	apiextensions_v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensions_fake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDeploymentRolloutStatus(t *testing.T) {
+	replicas := int32(3)
+	tests := []struct {
+		name         string
+		dp           apps_v1.Deployment
+		expectedDone bool
+		expectedErr  bool
+	}{
+		{
+			name: "spec not observed",
+			dp: apps_v1.Deployment{
+				ObjectMeta: meta_v1.ObjectMeta{Generation: 2},
+				Status:     apps_v1.DeploymentStatus{ObservedGeneration: 1},
+			},
+		},
+		{
+			name: "progress deadline exceeded",
+			dp: apps_v1.Deployment{
+				Status: apps_v1.DeploymentStatus{Conditions: []apps_v1.DeploymentCondition{
+					{Type: apps_v1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"},
+				}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "old replicas pending termination",
+			dp: apps_v1.Deployment{
+				Spec:   apps_v1.DeploymentSpec{Replicas: &replicas},
+				Status: apps_v1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 3},
+			},
+		},
+		{
+			name: "updated replicas unavailable",
+			dp: apps_v1.Deployment{
+				Spec:   apps_v1.DeploymentSpec{Replicas: &replicas},
+				Status: apps_v1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2},
+			},
+		},
+		{
+			name: "complete",
+			dp: apps_v1.Deployment{
+				Spec:   apps_v1.DeploymentSpec{Replicas: &replicas},
+				Status: apps_v1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			},
+			expectedDone: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, _, err := deploymentRolloutStatus(&tt.dp)
+			if done != tt.expectedDone || (err != nil) != tt.expectedErr {
+				t.Fatalf("expected done %v, error %v, got done %v, error %v", tt.expectedDone, tt.expectedErr, done, err)
+			}
+		})
+	}
+}
+
+func TestDaemonSetRolloutStatus(t *testing.T) {
+	tests := []struct {
+		status       apps_v1.DaemonSetStatus
+		expectedDone bool
+	}{
+		{apps_v1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 2, NumberAvailable: 3}, false},
+		{apps_v1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2}, false},
+		{apps_v1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}, true},
+	}
+	for i, tt := range tests {
+		if done, status := daemonSetRolloutStatus(&apps_v1.DaemonSet{Status: tt.status}); done != tt.expectedDone {
+			t.Fatalf("#%d: expected done %v, got %v (%s)", i, tt.expectedDone, done, status)
+		}
+	}
+}
+
+func TestWaitForJob(t *testing.T) {
+	job := &batch_v1.Job{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "default", Name: "hello"},
+		Status: batch_v1.JobStatus{
+			Failed: 3,
+			Conditions: []batch_v1.JobCondition{
+				{Type: batch_v1.JobFailed, Status: core_v1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+			},
+		},
+	}
+	cli := fake.NewSimpleClientset(job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	_, err := WaitForJob(ctx, zap.NewExample(), cli, "default", "hello", WithPollInterval(10*time.Millisecond))
+	cancel()
+	if err == nil || !strings.Contains(err.Error(), "BackoffLimitExceeded") {
+		t.Fatalf("expected the Job failure, got %v", err)
+	}
+
+	// not found errors are retried until the context is done
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	_, err = WaitForJob(ctx, zap.NewExample(), cli, "default", "missing", WithPollInterval(10*time.Millisecond))
+	cancel()
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected the last not found error, got %v", err)
+	}
+
+	stopc := make(chan struct{})
+	close(stopc)
+	job.Name, job.Status = "running", batch_v1.JobStatus{Active: 1}
+	if _, err = cli.BatchV1().Jobs("default").Create(context.Background(), job, meta_v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = WaitForJob(context.Background(), zap.NewExample(), cli, "default", "running", WithStopc(stopc))
+	if err == nil || !strings.Contains(err.Error(), "aborted") {
+		t.Fatalf("expected the wait to be aborted, got %v", err)
+	}
+}
+
+func TestWaitForCRDEstablished(t *testing.T) {
+	cli := apiextensions_fake.NewSimpleClientset(&apiextensions_v1.CustomResourceDefinition{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "hellos.example.com"},
+		Status: apiextensions_v1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensions_v1.CustomResourceDefinitionCondition{
+				{Type: apiextensions_v1.NamesAccepted, Status: apiextensions_v1.ConditionTrue},
+				{Type: apiextensions_v1.Established, Status: apiextensions_v1.ConditionTrue},
+			},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	crd, err := WaitForCRDEstablished(ctx, zap.NewExample(), cli, "hellos.example.com")
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crd.Name != "hellos.example.com" {
+		t.Fatalf("unexpected CustomResourceDefinition %q", crd.Name)
+	}
+}
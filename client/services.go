@@ -40,7 +40,7 @@ func DeleteService(lg *zap.Logger, c k8s_client.Interface, namespace string, svc
 	}
 	// requires "k8s_errors.IsNotFound"
 	// ref. https://github.com/aws/aws-k8s-tester/issues/79
-	return RetryWithExponentialBackOff(RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
+	return RetryWithExponentialBackOff(c, RetryFunction(deleteFunc, Allow(k8s_errors.IsNotFound)))
 }
 
 // WaitForServiceIngressHostname waits for Service's Ingress Hostname to be updated
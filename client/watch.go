@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// untilWithSync waits until "precondition" returns true for the synced store,
+// or until "condition" returns true for an event of the objects listed and
+// watched by "lw". A nil "precondition" only waits for the events. It uses an informer that re-lists and re-watches
+// when the watch expires, so the waiters do not poll the API server.
+// It returns when "ctx" is done (or after an hour if "ctx" has no deadline),
+// or when "stopc" is closed.
+func untilWithSync(
+	ctx context.Context,
+	stopc chan struct{},
+	lw cache.ListerWatcher,
+	objType runtime.Object,
+	precondition watchtools.PreconditionFunc,
+	condition watchtools.ConditionFunc) (*watch.Event, error) {
+	ctx, cancel := context.WithTimeout(ctx, durationTillDeadline(ctx))
+	defer cancel()
+	go func() {
+		select {
+		case <-stopc:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ev, err := watchtools.UntilWithSync(ctx, lw, objType, precondition, condition)
+	if err != nil {
+		select {
+		case <-stopc:
+			return ev, errors.New("wait aborted")
+		default:
+		}
+	}
+	return ev, err
+}
+
+// startQueryFunc calls the query function every interval in the background,
+// since the watch-based waiters do not have a poll loop to call it from.
+// The returned function stops the calls.
+func startQueryFunc(queryFunc func(), interval time.Duration) (stop func()) {
+	if queryFunc == nil {
+		return func() {}
+	}
+	donec := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-donec:
+				return
+			case <-ticker.C:
+				queryFunc()
+			}
+		}
+	}()
+	return func() { close(donec) }
+}
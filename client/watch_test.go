@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_fake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForDeploymentAvailables(t *testing.T) {
+	dp := &apps_v1.Deployment{ObjectMeta: meta_v1.ObjectMeta{Name: "hello", Namespace: "test"}}
+	c := k8s_fake.NewSimpleClientset(dp)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		dp := dp.DeepCopy()
+		dp.Status.AvailableReplicas = 2
+		dp.Status.Conditions = []apps_v1.DeploymentCondition{{Type: apps_v1.DeploymentAvailable, Status: core_v1.ConditionTrue}}
+		if _, err := c.AppsV1().Deployments("test").UpdateStatus(context.Background(), dp, meta_v1.UpdateOptions{}); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	got, err := WaitForDeploymentAvailables(ctx, zap.NewNop(), nil, nil, c, 0, time.Second, "test", "hello", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.AvailableReplicas != 2 {
+		t.Fatalf("expected 2 available replicas, got %d", got.Status.AvailableReplicas)
+	}
+}
+
+func TestWaitForJobCompletes(t *testing.T) {
+	tests := []struct {
+		name        string
+		succeeded   int32
+		condition   batch_v1.JobConditionType
+		expectedErr string
+	}{
+		{name: "complete", succeeded: 3, condition: batch_v1.JobComplete},
+		{name: "failed", condition: batch_v1.JobFailed, expectedErr: `status "Failed"`},
+		{name: "complete-short", succeeded: 1, condition: batch_v1.JobComplete, expectedErr: "expected 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &batch_v1.Job{ObjectMeta: meta_v1.ObjectMeta{Name: "hello", Namespace: "test"}}
+			c := k8s_fake.NewSimpleClientset(job)
+
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				job := job.DeepCopy()
+				job.Status.Succeeded = tt.succeeded
+				job.Status.Conditions = []batch_v1.JobCondition{{Type: tt.condition, Status: core_v1.ConditionTrue}}
+				if _, err := c.BatchV1().Jobs("test").UpdateStatus(context.Background(), job, meta_v1.UpdateOptions{}); err != nil {
+					t.Error(err)
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_, _, err := WaitForJobCompletes(ctx, zap.NewNop(), nil, nil, c, 0, time.Second, "test", "hello", 3)
+			if tt.expectedErr == "" && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedErr)) {
+				t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestWaitForNodesReady(t *testing.T) {
+	ready := &core_v1.Node{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "a"},
+		Status:     core_v1.NodeStatus{Conditions: []core_v1.NodeCondition{{Type: core_v1.NodeReady, Status: core_v1.ConditionTrue}}},
+	}
+	notReady := &core_v1.Node{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "b"},
+		Status:     core_v1.NodeStatus{Conditions: []core_v1.NodeCondition{{Type: core_v1.NodeReady, Status: core_v1.ConditionFalse}}},
+	}
+	c := k8s_fake.NewSimpleClientset(ready, notReady)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		node := notReady.DeepCopy()
+		node.Status.Conditions[0].Status = core_v1.ConditionTrue
+		if _, err := c.CoreV1().Nodes().UpdateStatus(context.Background(), node, meta_v1.UpdateOptions{}); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	nodes, err := WaitForNodesReady(ctx, zap.NewNop(), nil, c, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "a" || nodes[1].Name != "b" {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+}
+
+func TestWaitForNodesAborted(t *testing.T) {
+	c := k8s_fake.NewSimpleClientset()
+	stopc := make(chan struct{})
+	close(stopc)
+
+	_, err := WaitForNodesReady(context.Background(), zap.NewNop(), stopc, c, 1)
+	if err == nil || err.Error() != "wait aborted" {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+}
+
+func TestWaitForNodesEmpty(t *testing.T) {
+	c := k8s_fake.NewSimpleClientset()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	nodes, err := WaitForNodes(ctx, zap.NewNop(), nil, c, func(nodes []core_v1.Node) (bool, error) {
+		return len(nodes) == 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+}
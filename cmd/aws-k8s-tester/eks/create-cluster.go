@@ -23,9 +23,16 @@ func newCreateCluster() *cobra.Command {
 		Long:  "Configuration values are overwritten by environment variables.",
 		Run:   createClusterFunc,
 	}
+	cmd.PersistentFlags().BoolVar(&exportInfra, "export-infra", false, "'true' to export the inventory of the created resources and their CloudFormation templates")
+	cmd.PersistentFlags().StringVar(&assumeRoleARN, "assume-role-arn", "", "IAM role to assume for the AWS API calls, overwrites the configuration if set")
 	return cmd
 }
 
+var (
+	exportInfra   bool
+	assumeRoleARN string
+)
+
 func createClusterFunc(cmd *cobra.Command, args []string) {
 	if !autoPath && path == "" {
 		fmt.Fprintln(os.Stderr, "'--path' flag is not specified")
@@ -60,6 +67,12 @@ func createClusterFunc(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "failed to load configuration from environment variables: %v\n", err)
 		os.Exit(1)
 	}
+	if exportInfra {
+		cfg.ExportInfra = true
+	}
+	if assumeRoleARN != "" {
+		cfg.AssumeRoleARN = assumeRoleARN
+	}
 
 	if err = cfg.ValidateAndSetDefaults(); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to validate configuration %q (%v)\n", path, err)
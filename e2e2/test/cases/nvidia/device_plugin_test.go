@@ -0,0 +1,366 @@
+package nvidia
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	fwext "github.com/aws/aws-k8s-tester/e2e2/internal/framework_extensions"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	//go:embed manifests/deployment-gpu-sharing.yaml
+	deploymentGpuSharingManifest         []byte
+	renderedDeploymentGpuSharingManifest []byte
+)
+
+type gpuSharingManifestTplVars struct {
+	Replicas       int
+	NodeName       string
+	ResourceName   string
+	TestLabelKey   string
+	TestLabelValue string
+}
+
+// gpusByInstanceType is the number of NVIDIA GPUs of the known GPU instance types.
+var gpusByInstanceType = map[string]int{
+	"p3.2xlarge":    1,
+	"p3.8xlarge":    4,
+	"p3.16xlarge":   8,
+	"p3dn.24xlarge": 8,
+	"p4d.24xlarge":  8,
+	"p4de.24xlarge": 8,
+	"p5.48xlarge":   8,
+	"g4dn.xlarge":   1,
+	"g4dn.2xlarge":  1,
+	"g4dn.4xlarge":  1,
+	"g4dn.8xlarge":  1,
+	"g4dn.12xlarge": 4,
+	"g4dn.16xlarge": 1,
+	"g4dn.metal":    8,
+	"g5.xlarge":     1,
+	"g5.2xlarge":    1,
+	"g5.4xlarge":    1,
+	"g5.8xlarge":    1,
+	"g5.12xlarge":   4,
+	"g5.16xlarge":   1,
+	"g5.24xlarge":   4,
+	"g5.48xlarge":   8,
+	"g6.xlarge":     1,
+	"g6.2xlarge":    1,
+	"g6.4xlarge":    1,
+	"g6.8xlarge":    1,
+	"g6.12xlarge":   4,
+	"g6.16xlarge":   1,
+	"g6.24xlarge":   4,
+	"g6.48xlarge":   8,
+}
+
+const (
+	gpuResourceName = v1.ResourceName("nvidia.com/gpu")
+
+	// labels managed by the NVIDIA MIG manager
+	// https://github.com/NVIDIA/mig-parted/tree/main/deployments/gpu-operator
+	migConfigLabel      = "nvidia.com/mig.config"
+	migConfigStateLabel = "nvidia.com/mig.config.state"
+
+	// label of the GPU sharing test Pods, so that only the Pods of the test are counted
+	gpuSharingTestLabelKey   = "aws-k8s-tester/test"
+	gpuSharingTestLabelValue = "device-plugin-gpu-sharing"
+)
+
+// expectedGpusPerNode returns the number of GPUs the nodes of the instance type should advertise,
+// which is the override if set, or the known GPU count of the instance type.
+func expectedGpusPerNode(instanceType string, override int) (int, bool) {
+	if override > 0 {
+		return override, true
+	}
+	gpus, ok := gpusByInstanceType[instanceType]
+	return gpus, ok
+}
+
+// migInstancesPerGPU returns the number of MIG instances a single GPU is partitioned into
+// with the given profile (e.g. "1g.10gb" yields 7 on A100 and H100, which have 7 compute slices).
+func migInstancesPerGPU(profile string) (int, error) {
+	slices, err := strconv.Atoi(strings.SplitN(profile, "g.", 2)[0])
+	if err != nil || slices < 1 || slices > 7 {
+		return 0, fmt.Errorf("invalid MIG profile %q", profile)
+	}
+	return 7 / slices, nil
+}
+
+// migResourceName returns the extended resource name the device plugin advertises
+// for the MIG profile under the configured strategy.
+func migResourceName(profile string) v1.ResourceName {
+	if *migStrategy == "single" {
+		return gpuResourceName
+	}
+	return v1.ResourceName("nvidia.com/mig-" + profile)
+}
+
+func listGpuNodes(ctx context.Context, cfg *envconf.Config) ([]v1.Node, error) {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: "node.kubernetes.io/instance-type=" + *nodeType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes.Items) == 0 {
+		return nil, fmt.Errorf("no node of type %s", *nodeType)
+	}
+	return nodes.Items, nil
+}
+
+func labelNodes(ctx context.Context, cfg *envconf.Config, nodes []v1.Node, key, value string) error {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return err
+	}
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, key, value))
+	for _, node := range nodes {
+		if _, err := clientset.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to label node %s: %v", node.Name, err)
+		}
+	}
+	return nil
+}
+
+func TestDevicePlugin(t *testing.T) {
+	capacityTest := features.New("device-plugin-capacity").
+		WithLabel("suite", "nvidia").
+		WithLabel("hardware", "gpu").
+		Assess("Nodes advertise the expected GPU capacity", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			expected, ok := expectedGpusPerNode(*nodeType, *expectedGpuPerNode)
+			if !ok {
+				t.Skipf("unknown GPU count for node type %s, use -expectedGpuPerNode to set it", *nodeType)
+			}
+			nodes, err := listGpuNodes(ctx, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, node := range nodes {
+				capacity := node.Status.Capacity[gpuResourceName]
+				allocatable := node.Status.Allocatable[gpuResourceName]
+				if int(capacity.Value()) != expected {
+					t.Errorf("node %s (%s) advertises %d GPUs in capacity, expected %d", node.Name, *nodeType, capacity.Value(), expected)
+				}
+				if allocatable.Value() != capacity.Value() {
+					t.Errorf("node %s advertises %d allocatable GPUs, expected %d", node.Name, allocatable.Value(), capacity.Value())
+				}
+			}
+			return ctx
+		}).
+		Feature()
+
+	var sharingNode string
+	sharingTest := features.New("gpu-sharing-limits").
+		WithLabel("suite", "nvidia").
+		WithLabel("hardware", "gpu").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if gpuPerNode == 0 {
+				t.Skip("no GPU advertised on the nodes")
+			}
+			nodes, err := listGpuNodes(ctx, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sharingNode = nodes[0].Name
+			// one more Pod than the node has GPUs, each requesting a whole GPU
+			renderedDeploymentGpuSharingManifest, err = fwext.RenderManifests(deploymentGpuSharingManifest, gpuSharingManifestTplVars{
+				Replicas:       gpuPerNode + 1,
+				NodeName:       sharingNode,
+				ResourceName:   string(gpuResourceName),
+				TestLabelKey:   gpuSharingTestLabelKey,
+				TestLabelValue: gpuSharingTestLabelValue,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = fwext.ApplyManifests(cfg.Client().RESTConfig(), renderedDeploymentGpuSharingManifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ctx
+		}).
+		Assess("GPUs are not oversubscribed", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var running, unschedulable int
+			err = wait.For(func(ctx context.Context) (bool, error) {
+				pods, err := clientset.CoreV1().Pods("default").List(ctx, metav1.ListOptions{
+					LabelSelector: gpuSharingTestLabelKey + "=" + gpuSharingTestLabelValue,
+				})
+				if err != nil {
+					return false, err
+				}
+				running, unschedulable = 0, 0
+				for _, pod := range pods.Items {
+					if pod.Status.Phase == v1.PodRunning {
+						running++
+						continue
+					}
+					for _, cond := range pod.Status.Conditions {
+						if cond.Type == v1.PodScheduled && cond.Status == v1.ConditionFalse && cond.Reason == v1.PodReasonUnschedulable {
+							unschedulable++
+						}
+					}
+				}
+				return running == gpuPerNode && unschedulable == 1, nil
+			}, wait.WithContext(ctx), wait.WithTimeout(10*time.Minute))
+			if err != nil {
+				t.Fatalf("expected %d running and 1 unschedulable Pods on node %s, got %d running and %d unschedulable: %v", gpuPerNode, sharingNode, running, unschedulable, err)
+			}
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if renderedDeploymentGpuSharingManifest == nil {
+				return ctx
+			}
+			err := fwext.DeleteManifests(cfg.Client().RESTConfig(), renderedDeploymentGpuSharingManifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ctx
+		}).
+		Feature()
+
+	migTest := features.New("mig-partitioning").
+		WithLabel("suite", "nvidia").
+		WithLabel("hardware", "gpu").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if *migProfile == "" {
+				t.Skip("no MIG profile specified, use -migProfile to enable MIG partitioning test")
+			}
+			nodes, err := listGpuNodes(ctx, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = labelNodes(ctx, cfg, nodes, migConfigLabel, "all-"+*migProfile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ctx
+		}).
+		Assess("Nodes advertise the MIG partitions", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			instances, err := migInstancesPerGPU(*migProfile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := int64(gpuPerNode * instances)
+			resourceName := migResourceName(*migProfile)
+			err = wait.For(func(ctx context.Context) (bool, error) {
+				nodes, err := listGpuNodes(ctx, cfg)
+				if err != nil {
+					return false, err
+				}
+				for _, node := range nodes {
+					if state := node.Labels[migConfigStateLabel]; state == "failed" {
+						return false, fmt.Errorf("MIG manager failed to configure node %s", node.Name)
+					} else if state != "success" {
+						return false, nil
+					}
+					allocatable := node.Status.Allocatable[resourceName]
+					if allocatable.Value() != expected {
+						t.Logf("node %s advertises %d %s, expecting %d", node.Name, allocatable.Value(), resourceName, expected)
+						return false, nil
+					}
+				}
+				return true, nil
+			}, wait.WithContext(ctx), wait.WithTimeout(15*time.Minute), wait.WithInterval(30*time.Second))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if *migProfile == "" {
+				return ctx
+			}
+			nodes, err := listGpuNodes(ctx, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = labelNodes(ctx, cfg, nodes, migConfigLabel, "all-disabled")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ctx
+		}).
+		Feature()
+
+	testenv.Test(t, capacityTest, sharingTest, migTest)
+}
+
+func TestMigInstancesPerGPU(t *testing.T) {
+	tests := []struct {
+		profile  string
+		expected int
+		err      bool
+	}{
+		{"1g.10gb", 7, false},
+		{"1g.5gb", 7, false},
+		{"2g.20gb", 3, false},
+		{"3g.40gb", 2, false},
+		{"4g.40gb", 1, false},
+		{"7g.80gb", 1, false},
+		{"0g.5gb", 0, true},
+		{"8g.80gb", 0, true},
+		{"g.10gb", 0, true},
+		{"1g", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			instances, err := migInstancesPerGPU(tt.profile)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if instances != tt.expected {
+				t.Fatalf("expected %d instances, got %d", tt.expected, instances)
+			}
+		})
+	}
+}
+
+func TestExpectedGpusPerNode(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		override     int
+		expected     int
+		ok           bool
+	}{
+		{"p4d.24xlarge", 0, 8, true},
+		{"g5.12xlarge", 0, 4, true},
+		{"g4dn.xlarge", 0, 1, true},
+		{"g4dn.xlarge", 2, 2, true},
+		{"p5e.48xlarge", 0, 0, false},
+		{"p5e.48xlarge", 8, 8, true},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.instanceType, tt.override), func(t *testing.T) {
+			gpus, ok := expectedGpusPerNode(tt.instanceType, tt.override)
+			if ok != tt.ok || gpus != tt.expected {
+				t.Fatalf("expected %d GPUs (%v), got %d (%v)", tt.expected, tt.ok, gpus, ok)
+			}
+		})
+	}
+}
@@ -30,6 +30,9 @@ var (
 	efaEnabled             *bool
 	nvidiaTestImage        *string
 	skipUnitTestSubcommand *string
+	expectedGpuPerNode     *int
+	migProfile             *string
+	migStrategy            *string
 	nodeCount              int
 	gpuPerNode             int
 	efaPerNode             int
@@ -136,6 +139,9 @@ func TestMain(m *testing.M) {
 	efaEnabled = flag.Bool("efaEnabled", false, "enable efa tests")
 	installDevicePlugin = flag.Bool("installDevicePlugin", true, "install nvidia device plugin")
 	skipUnitTestSubcommand = flag.String("skipUnitTestSubcommand", "", "optional command to skip specified unit test, `-s test1|test2|...`")
+	expectedGpuPerNode = flag.Int("expectedGpuPerNode", 0, "expected number of GPUs advertised per node, defaults to the known GPU count of the node type")
+	migProfile = flag.String("migProfile", "", "optional MIG profile to partition the GPUs with (e.g. 1g.10gb), requires the NVIDIA MIG manager")
+	migStrategy = flag.String("migStrategy", "mixed", "MIG strategy of the device plugin, single or mixed")
 	cfg, err := envconf.NewFromFlags()
 	if err != nil {
 		log.Fatalf("failed to initialize test environment: %v", err)
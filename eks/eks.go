@@ -341,10 +341,12 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 	defer ts.cfg.Sync()
 
 	awsCfg := pkg_aws.Config{
-		Logger:        ts.lg,
-		DebugAPICalls: ts.cfg.LogLevel == "debug",
-		Partition:     ts.cfg.Partition,
-		Region:        ts.cfg.Region,
+		Logger:             ts.lg,
+		DebugAPICalls:      ts.cfg.LogLevel == "debug",
+		Partition:          ts.cfg.Partition,
+		Region:             ts.cfg.Region,
+		AssumeRoleARN:      ts.cfg.AssumeRoleARN,
+		AssumeRoleDuration: ts.cfg.AssumeRoleDuration,
 	}
 	var stsOutput *sts.GetCallerIdentityOutput
 	ts.awsSession, stsOutput, ts.cfg.Status.AWSCredentialPath, err = pkg_aws.New(&awsCfg)
@@ -545,12 +547,14 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 	// create a separate session for EKS (for resolver endpoint)
 	var eksSessionForCluster *session.Session
 	eksSessionForCluster, _, ts.cfg.Status.AWSCredentialPath, err = pkg_aws.New(&pkg_aws.Config{
-		Logger:        ts.lg,
-		DebugAPICalls: ts.cfg.LogLevel == "debug",
-		Partition:     ts.cfg.Partition,
-		Region:        ts.cfg.Region,
-		ResolverURL:   ts.cfg.ResolverURL,
-		SigningName:   ts.cfg.SigningName,
+		Logger:             ts.lg,
+		DebugAPICalls:      ts.cfg.LogLevel == "debug",
+		Partition:          ts.cfg.Partition,
+		Region:             ts.cfg.Region,
+		AssumeRoleARN:      ts.cfg.AssumeRoleARN,
+		AssumeRoleDuration: ts.cfg.AssumeRoleDuration,
+		ResolverURL:        ts.cfg.ResolverURL,
+		SigningName:        ts.cfg.SigningName,
 	})
 	if err != nil {
 		return nil, err
@@ -558,12 +562,14 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 	ts.eksAPIForCluster = aws_eks.New(eksSessionForCluster)
 
 	awsCfgV2EKS, err := pkg_aws.NewV2(&pkg_aws.Config{
-		Logger:        ts.lg,
-		DebugAPICalls: ts.cfg.LogLevel == "debug",
-		Partition:     ts.cfg.Partition,
-		Region:        ts.cfg.Region,
-		ResolverURL:   ts.cfg.ResolverURL,
-		SigningName:   ts.cfg.SigningName,
+		Logger:             ts.lg,
+		DebugAPICalls:      ts.cfg.LogLevel == "debug",
+		Partition:          ts.cfg.Partition,
+		Region:             ts.cfg.Region,
+		AssumeRoleARN:      ts.cfg.AssumeRoleARN,
+		AssumeRoleDuration: ts.cfg.AssumeRoleDuration,
+		ResolverURL:        ts.cfg.ResolverURL,
+		SigningName:        ts.cfg.SigningName,
 	})
 	if err != nil {
 		return nil, err
@@ -573,12 +579,14 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 	if ts.cfg.IsEnabledAddOnManagedNodeGroups() {
 		var eksSessionForMNG *session.Session
 		eksSessionForMNG, _, ts.cfg.Status.AWSCredentialPath, err = pkg_aws.New(&pkg_aws.Config{
-			Logger:        ts.lg,
-			DebugAPICalls: ts.cfg.LogLevel == "debug",
-			Partition:     ts.cfg.Partition,
-			Region:        ts.cfg.Region,
-			ResolverURL:   ts.cfg.AddOnManagedNodeGroups.ResolverURL,
-			SigningName:   ts.cfg.AddOnManagedNodeGroups.SigningName,
+			Logger:             ts.lg,
+			DebugAPICalls:      ts.cfg.LogLevel == "debug",
+			Partition:          ts.cfg.Partition,
+			Region:             ts.cfg.Region,
+			AssumeRoleARN:      ts.cfg.AssumeRoleARN,
+			AssumeRoleDuration: ts.cfg.AssumeRoleDuration,
+			ResolverURL:        ts.cfg.AddOnManagedNodeGroups.ResolverURL,
+			SigningName:        ts.cfg.AddOnManagedNodeGroups.SigningName,
 		})
 		if err != nil {
 			return nil, err
@@ -586,12 +594,14 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 		ts.eksAPIForMNG = aws_eks.New(eksSessionForMNG)
 
 		awsCfgV2EKS, err := pkg_aws.NewV2(&pkg_aws.Config{
-			Logger:        ts.lg,
-			DebugAPICalls: ts.cfg.LogLevel == "debug",
-			Partition:     ts.cfg.Partition,
-			Region:        ts.cfg.Region,
-			ResolverURL:   ts.cfg.AddOnManagedNodeGroups.ResolverURL,
-			SigningName:   ts.cfg.AddOnManagedNodeGroups.SigningName,
+			Logger:             ts.lg,
+			DebugAPICalls:      ts.cfg.LogLevel == "debug",
+			Partition:          ts.cfg.Partition,
+			Region:             ts.cfg.Region,
+			AssumeRoleARN:      ts.cfg.AssumeRoleARN,
+			AssumeRoleDuration: ts.cfg.AssumeRoleDuration,
+			ResolverURL:        ts.cfg.AddOnManagedNodeGroups.ResolverURL,
+			SigningName:        ts.cfg.AddOnManagedNodeGroups.SigningName,
 		})
 		if err != nil {
 			return nil, err
@@ -1100,6 +1110,9 @@ func (ts *Tester) Up() (err error) {
 		fmt.Fprintf(ts.logWriter, "\n\n# to delete cluster\naws-k8s-tester eks delete cluster --path %s\n\n", ts.cfg.ConfigPath)
 		ts.logFile.Sync()
 
+		if xerr := ts.exportInfra(); xerr != nil {
+			ts.lg.Warn("failed to export infrastructure", zap.Error(xerr))
+		}
 		if serr := ts.uploadToS3(); serr != nil {
 			ts.lg.Warn("failed to upload artifacts to S3", zap.Error(serr))
 		} else {
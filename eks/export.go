@@ -0,0 +1,180 @@
+package eks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"go.uber.org/zap"
+)
+
+// infraInventory is the inventory of the resources created by the tester,
+// written to "ExportInfraDir/inventory.json" with the CloudFormation templates.
+type infraInventory struct {
+	Name     string       `json:"name"`
+	Region   string       `json:"region"`
+	Exported time.Time    `json:"exported"`
+	ARNs     []infraField `json:"arns"`
+	Stacks   []infraStack `json:"stacks"`
+}
+
+// infraField is a configuration field of a created resource,
+// keyed by its JSON path (e.g., "add-on-fargate.role-arn").
+type infraField struct {
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+type infraStack struct {
+	infraField
+	// Template is the template file name under "ExportInfraDir".
+	Template  string          `json:"template,omitempty"`
+	Resources []infraResource `json:"resources,omitempty"`
+}
+
+type infraResource struct {
+	LogicalID  string `json:"logical-id"`
+	PhysicalID string `json:"physical-id"`
+	Type       string `json:"type"`
+	Status     string `json:"status"`
+}
+
+// collectInfra walks the configuration for the non-empty CloudFormation stack IDs
+// ("*-cfn-stack-id") and ARNs ("*-arn"), sorted by the JSON path.
+func collectInfra(v reflect.Value, prefix string) (stacks []infraField, arns []infraField) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return collectInfra(v.Elem(), prefix)
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			tag := strings.Split(sf.Tag.Get("json"), ",")[0]
+			if tag == "-" {
+				continue
+			}
+			p := tag
+			if sf.Anonymous || tag == "" {
+				p = ""
+			}
+			if prefix != "" && p != "" {
+				p = prefix + "." + p
+			} else if p == "" {
+				p = prefix
+			}
+			s, a := collectInfra(v.Field(i), p)
+			stacks, arns = append(stacks, s...), append(arns, a...)
+		}
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			s, a := collectInfra(v.MapIndex(k), fmt.Sprintf("%s.%v", prefix, k))
+			stacks, arns = append(stacks, s...), append(arns, a...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.String {
+			return nil, nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			s, a := collectInfra(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i))
+			stacks, arns = append(stacks, s...), append(arns, a...)
+		}
+
+	case reflect.String:
+		if v.String() == "" {
+			return nil, nil
+		}
+		f := infraField{Path: prefix, Value: v.String()}
+		switch {
+		case strings.HasSuffix(prefix, "cfn-stack-id"):
+			stacks = append(stacks, f)
+		case strings.HasSuffix(prefix, "-arn") || strings.HasSuffix(prefix, ".arn") || prefix == "arn":
+			arns = append(arns, f)
+		}
+	}
+	return stacks, arns
+}
+
+// exportInfra writes the inventory of the created resources and the
+// CloudFormation templates to "ExportInfraDir". Missing stacks are recorded
+// without resources, so that a partially created environment is still exported.
+func (ts *Tester) exportInfra() error {
+	if !ts.cfg.ExportInfra {
+		return nil
+	}
+	if err := os.MkdirAll(ts.cfg.ExportInfraDir, 0700); err != nil {
+		return err
+	}
+
+	stacks, arns := collectInfra(reflect.ValueOf(ts.cfg), "")
+	inv := infraInventory{
+		Name:     ts.cfg.Name,
+		Region:   ts.cfg.Region,
+		Exported: time.Now().UTC(),
+		ARNs:     arns,
+	}
+	for _, f := range stacks {
+		st := infraStack{infraField: f}
+		out, err := ts.cfnAPI.DescribeStackResources(&cloudformation.DescribeStackResourcesInput{
+			StackName: aws.String(f.Value),
+		})
+		if err != nil {
+			ts.lg.Warn("failed to describe stack resources", zap.String("stack-id", f.Value), zap.Error(err))
+			inv.Stacks = append(inv.Stacks, st)
+			continue
+		}
+		for _, r := range out.StackResources {
+			st.Resources = append(st.Resources, infraResource{
+				LogicalID:  aws.StringValue(r.LogicalResourceId),
+				PhysicalID: aws.StringValue(r.PhysicalResourceId),
+				Type:       aws.StringValue(r.ResourceType),
+				Status:     aws.StringValue(r.ResourceStatus),
+			})
+		}
+		tout, err := ts.cfnAPI.GetTemplate(&cloudformation.GetTemplateInput{
+			StackName:     aws.String(f.Value),
+			TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+		})
+		if err != nil {
+			ts.lg.Warn("failed to get stack template", zap.String("stack-id", f.Value), zap.Error(err))
+		} else {
+			st.Template = strings.NewReplacer(".", "-", "[", "-", "]", "").Replace(f.Path) + ".template"
+			if err = ioutil.WriteFile(filepath.Join(ts.cfg.ExportInfraDir, st.Template), []byte(aws.StringValue(tout.TemplateBody)), 0600); err != nil {
+				return err
+			}
+		}
+		inv.Stacks = append(inv.Stacks, st)
+	}
+
+	b, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(ts.cfg.ExportInfraDir, "inventory.json")
+	if err = ioutil.WriteFile(p, b, 0600); err != nil {
+		return err
+	}
+	ts.lg.Info("exported infrastructure",
+		zap.String("path", p),
+		zap.Int("stacks", len(inv.Stacks)),
+		zap.Int("arns", len(inv.ARNs)),
+	)
+	return nil
+}
@@ -0,0 +1,49 @@
+package eks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollectInfra(t *testing.T) {
+	type role struct {
+		ARN        string `json:"arn"`
+		CFNStackID string `json:"role-cfn-stack-id"`
+	}
+	type mng struct {
+		Name    string `json:"name"`
+		RoleARN string `json:"role-arn"`
+	}
+	cfg := struct {
+		Name     string          `json:"name"`
+		Role     *role           `json:"role"`
+		Skipped  *role           `json:"-"`
+		Empty    *role           `json:"empty"`
+		MNGs     map[string]mng  `json:"mngs"`
+		Subnets  []string        `json:"subnet-arn"`
+		Internal map[string]*int `json:"internal"`
+	}{
+		Name:    "hello",
+		Role:    &role{ARN: "arn:aws:iam::123:role/a", CFNStackID: "stack-a"},
+		Skipped: &role{ARN: "arn:aws:iam::123:role/b"},
+		MNGs: map[string]mng{
+			"ng-b": {Name: "ng-b", RoleARN: "arn:aws:iam::123:role/ng-b"},
+			"ng-a": {Name: "ng-a", RoleARN: "arn:aws:iam::123:role/ng-a"},
+		},
+		Subnets: []string{"ignored"},
+	}
+
+	stacks, arns := collectInfra(reflect.ValueOf(&cfg), "")
+	expectedStacks := []infraField{{Path: "role.role-cfn-stack-id", Value: "stack-a"}}
+	if !reflect.DeepEqual(stacks, expectedStacks) {
+		t.Fatalf("expected %+v, got %+v", expectedStacks, stacks)
+	}
+	expectedARNs := []infraField{
+		{Path: "role.arn", Value: "arn:aws:iam::123:role/a"},
+		{Path: "mngs.ng-a.role-arn", Value: "arn:aws:iam::123:role/ng-a"},
+		{Path: "mngs.ng-b.role-arn", Value: "arn:aws:iam::123:role/ng-b"},
+	}
+	if !reflect.DeepEqual(arns, expectedARNs) {
+		t.Fatalf("expected %+v, got %+v", expectedARNs, arns)
+	}
+}
@@ -2,20 +2,34 @@ package eks
 
 import (
 	"errors"
-	"path"
 	"path/filepath"
 
-	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	utils_s3 "github.com/aws/aws-k8s-tester/utils/aws/s3"
 	"go.uber.org/zap"
 )
 
+func (ts *Tester) s3Manager() (*utils_s3.Manager, error) {
+	return utils_s3.New(utils_s3.Config{
+		Logger:          ts.lg,
+		S3API:           ts.s3API,
+		Region:          ts.cfg.Region,
+		BucketName:      ts.cfg.S3.BucketName,
+		RunPrefix:       ts.cfg.Name,
+		ComponentLayout: ts.cfg.S3.ComponentLayout,
+	})
+}
+
 func (ts *Tester) createS3() (err error) {
 	if ts.cfg.S3.BucketCreate {
 		if ts.cfg.S3.BucketName == "" {
 			return errors.New("empty S3 bucket name")
 		}
-		if err = aws_s3.CreateBucket(ts.lg, ts.s3API, ts.cfg.S3.BucketName, ts.cfg.Region, ts.cfg.Name, ts.cfg.S3.BucketLifecycleExpirationDays); err != nil {
+		m, err := ts.s3Manager()
+		if err != nil {
+			return err
+		}
+		if err = m.CreateBucket(ts.cfg.S3.BucketLifecycleExpirationDays); err != nil {
 			return err
 		}
 	} else {
@@ -37,26 +51,27 @@ func (ts *Tester) deleteS3() error {
 		ts.lg.Info("skipping S3 bucket deletion", zap.String("s3-bucket-name", ts.cfg.S3.BucketName), zap.Bool("s3-bucket-create-keep", ts.cfg.S3.BucketCreateKeep))
 		return nil
 	}
-	if err := aws_s3.EmptyBucket(ts.lg, ts.s3API, ts.cfg.S3.BucketName); err != nil {
+	m, err := ts.s3Manager()
+	if err != nil {
 		return err
 	}
-	return aws_s3.DeleteBucket(ts.lg, ts.s3API, ts.cfg.S3.BucketName)
+	return m.DeleteBucket()
 }
 
+// uploadToS3 uploads the configuration and the log file,
+// with the manifest index.
 func (ts *Tester) uploadToS3() (err error) {
 	if ts.cfg.S3.BucketName == "" {
 		ts.lg.Info("skipping s3 uploads; s3 bucket name is empty")
 		return nil
 	}
+	m, err := ts.s3Manager()
+	if err != nil {
+		return err
+	}
 
 	if fileutil.Exist(ts.cfg.ConfigPath) {
-		if err = aws_s3.Upload(
-			ts.lg,
-			ts.s3API,
-			ts.cfg.S3.BucketName,
-			path.Join(ts.cfg.Name, "aws-k8s-tester-eks.config.yaml"),
-			ts.cfg.ConfigPath,
-		); err != nil {
+		if _, err = m.Upload(utils_s3.ComponentConfig, "aws-k8s-tester-eks.config.yaml", ts.cfg.ConfigPath); err != nil {
 			return err
 		}
 	}
@@ -69,16 +84,17 @@ func (ts *Tester) uploadToS3() (err error) {
 		}
 	}
 	if fileutil.Exist(logFilePath) {
-		if err = aws_s3.Upload(
-			ts.lg,
-			ts.s3API,
-			ts.cfg.S3.BucketName,
-			path.Join(ts.cfg.Name, "aws-k8s-tester-eks.log"),
-			logFilePath,
-		); err != nil {
+		if _, err = m.Upload(utils_s3.ComponentLogs, "aws-k8s-tester-eks.log", logFilePath); err != nil {
+			return err
+		}
+	}
+
+	if ts.cfg.ExportInfra && fileutil.Exist(ts.cfg.ExportInfraDir) {
+		if _, err = m.UploadDir(utils_s3.ComponentInfra, ts.cfg.ExportInfraDir); err != nil {
 			return err
 		}
 	}
 
+	_, err = m.WriteManifest()
 	return err
 }
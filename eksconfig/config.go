@@ -23,6 +23,7 @@ import (
 	"github.com/aws/aws-k8s-tester/pkg/logutil"
 	"github.com/aws/aws-k8s-tester/pkg/randutil"
 	"github.com/aws/aws-k8s-tester/pkg/terminal"
+	utils_s3 "github.com/aws/aws-k8s-tester/utils/aws/s3"
 	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/mitchellh/colorstring"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +47,12 @@ type Config struct {
 	// Region is the AWS geographic area for EKS deployment.
 	// If empty, set default region.
 	Region string `json:"region"`
+	// AssumeRoleARN is the IAM role to assume with the default credentials
+	// for all AWS API calls, if not empty.
+	AssumeRoleARN string `json:"assume-role-arn"`
+	// AssumeRoleDuration is the duration of the assumed role credentials.
+	// If zero, set default 15 minutes of the AWS SDK.
+	AssumeRoleDuration time.Duration `json:"assume-role-duration"`
 	// AvailabilityZoneNames lists the availability zones for the specified region.
 	// ref. https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeAvailabilityZones.html
 	AvailabilityZoneNames []string `json:"availability-zone-names,omitempty" read-only:"true"`
@@ -57,6 +64,12 @@ type Config struct {
 	KubectlCommandsOutputPath string `json:"kubectl-commands-output-path,omitempty"`
 	// RemoteAccessCommandsOutputPath is the output path for ssh commands.
 	RemoteAccessCommandsOutputPath string `json:"remote-access-commands-output-path,omitempty"`
+	// ExportInfra is true to export the inventory of the created resources
+	// and their CloudFormation templates, for audits and for reproducing
+	// a failed environment manually.
+	ExportInfra bool `json:"export-infra"`
+	// ExportInfraDir is the output directory for the exported infrastructure.
+	ExportInfraDir string `json:"export-infra-dir,omitempty"`
 
 	// LogColor is true to output logs in color.
 	LogColor bool `json:"log-color"`
@@ -423,6 +436,9 @@ type S3 struct {
 	BucketName string `json:"bucket-name"`
 	// BucketLifecycleExpirationDays is expiration in days for the lifecycle of the object.
 	BucketLifecycleExpirationDays int64 `json:"bucket-lifecycle-expiration-days"`
+	// ComponentLayout is true to upload the artifacts under "[CLUSTER_NAME]/[COMPONENT]/"
+	// (e.g., "config/", "logs/"), instead of directly under "[CLUSTER_NAME]/".
+	ComponentLayout bool `json:"component-layout"`
 }
 
 func getDefaultS3() *S3 {
@@ -1196,6 +1212,10 @@ func (cfg *Config) validateConfig() error {
 		return errors.New("LogOutputs is not empty")
 	}
 
+	if cfg.AssumeRoleDuration < 0 {
+		return fmt.Errorf("invalid AssumeRoleDuration %v", cfg.AssumeRoleDuration)
+	}
+
 	if cfg.Clients == 0 {
 		cfg.Clients = DefaultClients
 	}
@@ -1376,6 +1396,10 @@ func (cfg *Config) validateConfig() error {
 		return err
 	}
 
+	if cfg.ExportInfraDir == "" {
+		cfg.ExportInfraDir = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".infra"
+	}
+
 	if cfg.CommandAfterCreateClusterOutputPath == "" {
 		cfg.CommandAfterCreateClusterOutputPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".after-create-cluster.out.log"
 	}
@@ -1427,8 +1451,8 @@ func (cfg *Config) validateConfig() error {
 		if cfg.S3.BucketName == "" {
 			cfg.S3.BucketName = cfg.Name + "-s3-bucket"
 		}
-		if cfg.S3.BucketLifecycleExpirationDays > 0 && cfg.S3.BucketLifecycleExpirationDays < 3 {
-			cfg.S3.BucketLifecycleExpirationDays = 3
+		if cfg.S3.BucketLifecycleExpirationDays > 0 && cfg.S3.BucketLifecycleExpirationDays < utils_s3.MinLifecycleExpirationDays {
+			cfg.S3.BucketLifecycleExpirationDays = utils_s3.MinLifecycleExpirationDays
 		}
 	case false: // use existing one
 		if cfg.S3.BucketName == "" {
@@ -44,6 +44,10 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_COMMANDS_OUTPUT_PATH")
 	os.Setenv("AWS_K8S_TESTER_EKS_REGION", "us-east-1")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REGION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_ARN", "arn:aws:iam::123:role/hello")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_ARN")
+	os.Setenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_DURATION", "1h")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_DURATION")
 	os.Setenv("AWS_K8S_TESTER_EKS_LOG_LEVEL", "debug")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_LOG_LEVEL")
 	os.Setenv("AWS_K8S_TESTER_EKS_KUBECTL_DOWNLOAD_URL", "https://amazon-eks.s3-us-west-2.amazonaws.com/1.11.5/2018-12-06/bin/linux/amd64/kubectl")
@@ -72,6 +76,8 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_BUCKET_NAME")
 	os.Setenv("AWS_K8S_TESTER_EKS_S3_BUCKET_LIFECYCLE_EXPIRATION_DAYS", `10`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_BUCKET_LIFECYCLE_EXPIRATION_DAYS")
+	os.Setenv("AWS_K8S_TESTER_EKS_S3_COMPONENT_LAYOUT", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_COMPONENT_LAYOUT")
 	os.Setenv("AWS_K8S_TESTER_EKS_CLIENTS", `333`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CLIENTS")
 	os.Setenv("AWS_K8S_TESTER_EKS_CLIENT_TIMEOUT", `10m`)
@@ -620,6 +626,12 @@ spec:
 	if cfg.Region != "us-east-1" {
 		t.Fatalf("unexpected %q", cfg.Region)
 	}
+	if cfg.AssumeRoleARN != "arn:aws:iam::123:role/hello" {
+		t.Fatalf("unexpected %q", cfg.AssumeRoleARN)
+	}
+	if cfg.AssumeRoleDuration != time.Hour {
+		t.Fatalf("unexpected %v", cfg.AssumeRoleDuration)
+	}
 	if cfg.LogLevel != "debug" {
 		t.Fatalf("unexpected %q", cfg.LogLevel)
 	}
@@ -662,6 +674,9 @@ spec:
 	if cfg.S3.BucketLifecycleExpirationDays != 10 {
 		t.Fatalf("unexpected cfg.S3.BucketLifecycleExpirationDays %d", cfg.S3.BucketLifecycleExpirationDays)
 	}
+	if !cfg.S3.ComponentLayout {
+		t.Fatalf("unexpected cfg.S3.ComponentLayout %v", cfg.S3.ComponentLayout)
+	}
 	if cfg.Clients != 333 {
 		t.Fatalf("unexpected cfg.Clients %d", cfg.Clients)
 	}
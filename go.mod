@@ -8,6 +8,7 @@ require (
 	github.com/aws/aws-sdk-go v1.43.16
 	github.com/aws/aws-sdk-go-v2 v1.18.0
 	github.com/aws/aws-sdk-go-v2/config v1.18.23
+	github.com/aws/aws-sdk-go-v2/credentials v1.13.22
 	github.com/aws/aws-sdk-go-v2/internal/ini v1.3.34 // indirect
 	github.com/aws/aws-sdk-go-v2/service/autoscaling v1.0.0
 	github.com/aws/aws-sdk-go-v2/service/cloudformation v1.0.0
@@ -67,6 +68,7 @@ require (
 	github.com/crowdstrike/falcon-operator v0.9.5
 	github.com/octago/sflags v0.2.0
 	go.etcd.io/etcd/client/v3 v3.5.10
+	sigs.k8s.io/controller-runtime v0.17.2
 )
 
 require (
@@ -83,7 +85,6 @@ require (
 	github.com/Microsoft/hcsshim v0.11.4 // indirect
 	github.com/andybalholm/brotli v1.0.4 // indirect
 	github.com/asaskevich/govalidator v0.0.0-20230301143203-a9d515a09cc2 // indirect
-	github.com/aws/aws-sdk-go-v2/credentials v1.13.22 // indirect
 	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.13.3 // indirect
 	github.com/aws/aws-sdk-go-v2/internal/configsources v1.1.33 // indirect
 	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.4.27 // indirect
@@ -235,7 +236,6 @@ require (
 	k8s.io/kubectl v0.29.0 // indirect
 	k8s.io/kubernetes v1.24.3 // indirect
 	oras.land/oras-go v1.2.4 // indirect
-	sigs.k8s.io/controller-runtime v0.17.2 // indirect
 	sigs.k8s.io/json v0.0.0-20221116044647-bc3834ca7abd // indirect
 	sigs.k8s.io/kustomize/api v0.13.5-0.20230601165947-6ce0bf390ce3 // indirect
 	sigs.k8s.io/kustomize/kyaml v0.14.3-0.20230601165947-6ce0bf390ce3 // indirect
@@ -0,0 +1,182 @@
+// k8s-tester-adot installs Kubernetes ADOT collector tester.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/adot"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-adot",
+	Short:      "Kubernetes ADOT collector tester",
+	SuggestFor: []string{"adot"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", adot.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-adot failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	partition         string
+	region            string
+	collectorImage    string
+	telemetryGenImage string
+	traces            int
+	metrics           int
+	deliveryTimeout   time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&partition, "partition", adot.DefaultPartition, "partition")
+	cmd.PersistentFlags().StringVar(&region, "region", "", "region")
+	cmd.PersistentFlags().StringVar(&collectorImage, "collector-image", adot.DefaultCollectorImage, "ADOT collector image")
+	cmd.PersistentFlags().StringVar(&telemetryGenImage, "telemetry-gen-image", adot.DefaultTelemetryGenImage, "sample app image that emits the OTLP traces and metrics")
+	cmd.PersistentFlags().IntVar(&traces, "traces", adot.DefaultTraces, "number of traces to send")
+	cmd.PersistentFlags().IntVar(&metrics, "metrics", adot.DefaultMetrics, "number of metric data points to send")
+	cmd.PersistentFlags().DurationVar(&deliveryTimeout, "delivery-timeout", adot.DefaultDeliveryTimeout, "timeout for the traces and metrics to land in X-Ray and CloudWatch")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &adot.Config{
+		Prompt:            prompt,
+		Logger:            lg,
+		LogWriter:         logWriter,
+		MinimumNodes:      minimumNodes,
+		Namespace:         namespace,
+		Client:            cli,
+		Partition:         partition,
+		Region:            region,
+		CollectorImage:    collectorImage,
+		TelemetryGenImage: telemetryGenImage,
+		Traces:            traces,
+		Metrics:           metrics,
+		DeliveryTimeout:   deliveryTimeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := adot.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-adot apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	cmd.PersistentFlags().StringVar(&partition, "partition", adot.DefaultPartition, "partition")
+	cmd.PersistentFlags().StringVar(&region, "region", "", "region")
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &adot.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+		Partition: partition,
+		Region:    region,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := adot.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-adot delete' success\n")
+}
@@ -0,0 +1,190 @@
+package adot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/xray"
+	"go.uber.org/zap"
+)
+
+// collectorConfigTmpl receives the OTLP traces and metrics from the sample app,
+// and exports the traces to X-Ray and the metrics to CloudWatch in the embedded metric format.
+// ref. https://aws-otel.github.io/docs/getting-started/x-ray
+// ref. https://aws-otel.github.io/docs/getting-started/cloudwatch-metrics
+const collectorConfigTmpl = `extensions:
+  health_check:
+    endpoint: 0.0.0.0:{{ .HealthCheckPort }}
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:{{ .OTLPPort }}
+processors:
+  batch/traces:
+    timeout: 1s
+    send_batch_size: 50
+  batch/metrics:
+    timeout: 10s
+exporters:
+  awsxray:
+    region: {{ .Region }}
+  awsemf:
+    region: {{ .Region }}
+    namespace: {{ .MetricNamespace }}
+    log_group_name: {{ .LogGroupName }}
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch/traces]
+      exporters: [awsxray]
+    metrics:
+      receivers: [otlp]
+      processors: [batch/metrics]
+      exporters: [awsemf]
+`
+
+type collectorConfig struct {
+	HealthCheckPort int
+	OTLPPort        int
+	Region          string
+	MetricNamespace string
+	LogGroupName    string
+}
+
+func (c collectorConfig) render() (string, error) {
+	tpl := template.Must(template.New("collectorConfigTmpl").Parse(collectorConfigTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, c); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// telemetryGenArgs returns the "telemetrygen" arguments to send the signal ("traces" or "metrics")
+// to the collector, with the service name to find the data in X-Ray and CloudWatch.
+// ref. https://github.com/open-telemetry/opentelemetry-collector-contrib/tree/main/cmd/telemetrygen
+func telemetryGenArgs(signal string, endpoint string, serviceName string, count int) []string {
+	return []string{
+		signal,
+		"--otlp-endpoint=" + endpoint,
+		"--otlp-insecure",
+		"--service=" + serviceName,
+		fmt.Sprintf("--%s=%d", signal, count),
+		"--rate=1",
+	}
+}
+
+// traceFilterExpression returns the X-Ray filter expression for the traces of the service.
+// ref. https://docs.aws.amazon.com/xray/latest/devguide/xray-console-filters.html
+func traceFilterExpression(serviceName string) string {
+	return fmt.Sprintf("service(%q)", serviceName)
+}
+
+// waitForTraces waits for the traces of the sample app to be indexed in X-Ray.
+func (ts *tester) waitForTraces(start time.Time) error {
+	ts.cfg.Logger.Info("waiting for traces in X-Ray", zap.String("service-name", ts.cfg.ServiceName))
+	deadline := time.Now().Add(ts.cfg.DeliveryTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("traces check aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		found := 0
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := ts.cfg.XRayAPI.GetTraceSummariesPagesWithContext(
+			ctx,
+			&xray.GetTraceSummariesInput{
+				StartTime:        aws.Time(start.Add(-time.Minute)),
+				EndTime:          aws.Time(time.Now()),
+				FilterExpression: aws.String(traceFilterExpression(ts.cfg.ServiceName)),
+			},
+			func(out *xray.GetTraceSummariesOutput, lastPage bool) bool {
+				found += len(out.TraceSummaries)
+				return true
+			},
+		)
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get trace summaries", zap.Error(err))
+			continue
+		}
+		ts.cfg.TracesFound = found
+		if found > 0 {
+			fmt.Fprintf(ts.cfg.LogWriter, "\nfound %d traces of the service %q in X-Ray in %v\n\n", found, ts.cfg.ServiceName, time.Since(start))
+			return nil
+		}
+		ts.cfg.Logger.Info("no trace found yet", zap.String("elapsed", time.Since(start).String()))
+	}
+	return fmt.Errorf("no trace of the service %q found in X-Ray within %v", ts.cfg.ServiceName, ts.cfg.DeliveryTimeout)
+}
+
+// waitForMetrics waits for the metrics of the sample app to be published in CloudWatch.
+func (ts *tester) waitForMetrics(start time.Time) error {
+	ts.cfg.Logger.Info("waiting for metrics in CloudWatch", zap.String("metric-namespace", ts.cfg.MetricNamespace))
+	deadline := time.Now().Add(ts.cfg.DeliveryTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("metrics check aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		found := 0
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := ts.cfg.CWAPI.ListMetricsPagesWithContext(
+			ctx,
+			&cloudwatch.ListMetricsInput{
+				Namespace: aws.String(ts.cfg.MetricNamespace),
+			},
+			func(out *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+				found += len(out.Metrics)
+				return true
+			},
+		)
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list metrics", zap.Error(err))
+			continue
+		}
+		ts.cfg.MetricsFound = found
+		if found > 0 {
+			fmt.Fprintf(ts.cfg.LogWriter, "\nfound %d metrics in the namespace %q in CloudWatch in %v\n\n", found, ts.cfg.MetricNamespace, time.Since(start))
+			return nil
+		}
+		ts.cfg.Logger.Info("no metric found yet", zap.String("elapsed", time.Since(start).String()))
+	}
+	return fmt.Errorf("no metric found in the namespace %q in CloudWatch within %v", ts.cfg.MetricNamespace, ts.cfg.DeliveryTimeout)
+}
+
+// deleteLogGroup deletes the log group of the embedded metric format logs.
+// The metrics themselves expire in CloudWatch.
+func (ts *tester) deleteLogGroup() error {
+	ts.cfg.Logger.Info("deleting log group", zap.String("log-group-name", ts.cfg.LogGroupName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.CWLogsAPI.DeleteLogGroupWithContext(ctx, &cloudwatchlogs.DeleteLogGroupInput{
+		LogGroupName: aws.String(ts.cfg.LogGroupName),
+	})
+	cancel()
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException {
+			ts.cfg.Logger.Info("log group already deleted", zap.String("log-group-name", ts.cfg.LogGroupName))
+			return nil
+		}
+		return err
+	}
+	ts.cfg.Logger.Info("deleted log group", zap.String("log-group-name", ts.cfg.LogGroupName))
+	return nil
+}
@@ -0,0 +1,54 @@
+package adot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectorConfig(t *testing.T) {
+	s, err := collectorConfig{
+		HealthCheckPort: 13133,
+		OTLPPort:        4317,
+		Region:          "us-west-2",
+		MetricNamespace: "aws-k8s-tester/adot",
+		LogGroupName:    "/aws-k8s-tester/adot",
+	}.render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"endpoint: 0.0.0.0:13133",
+		"endpoint: 0.0.0.0:4317",
+		"region: us-west-2",
+		"namespace: aws-k8s-tester/adot",
+		"log_group_name: /aws-k8s-tester/adot",
+		"exporters: [awsxray]",
+		"exporters: [awsemf]",
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("expected %q in\n%s", want, s)
+		}
+	}
+}
+
+func TestTelemetryGenArgs(t *testing.T) {
+	args := telemetryGenArgs("traces", "adot-collector.test:4317", "svc", 10)
+	expected := []string{
+		"traces",
+		"--otlp-endpoint=adot-collector.test:4317",
+		"--otlp-insecure",
+		"--service=svc",
+		"--traces=10",
+		"--rate=1",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("expected %q, got %q", expected, args)
+	}
+}
+
+func TestTraceFilterExpression(t *testing.T) {
+	if s := traceFilterExpression("adot-abc"); s != `service("adot-abc")` {
+		t.Fatalf("unexpected filter expression %q", s)
+	}
+}
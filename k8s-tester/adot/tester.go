@@ -0,0 +1,659 @@
+// Package adot installs the AWS Distro for OpenTelemetry (ADOT) collector as a DaemonSet,
+// sends the traces and metrics from a sample app, and validates the data lands
+// in X-Ray and CloudWatch.
+// The node instance role requires "xray:PutTraceSegments", "xray:PutTelemetryRecords",
+// "logs:CreateLogGroup", "logs:CreateLogStream", "logs:DescribeLogStreams", and "logs:PutLogEvents".
+// ref. https://aws-otel.github.io/docs/getting-started/collector
+// ref. https://gallery.ecr.aws/aws-observability/aws-otel-collector
+package adot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/aws/aws-sdk-go/service/xray"
+	"github.com/aws/aws-sdk-go/service/xray/xrayiface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	XRayAPI   xrayiface.XRayAPI                     `json:"-"`
+	CWAPI     cloudwatchiface.CloudWatchAPI         `json:"-"`
+	CWLogsAPI cloudwatchlogsiface.CloudWatchLogsAPI `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// CollectorImage is the ADOT collector image.
+	CollectorImage string `json:"collector_image"`
+	// TelemetryGenImage is the sample app image that emits the OTLP traces and metrics.
+	TelemetryGenImage string `json:"telemetry_gen_image"`
+	// Traces is the number of traces to send.
+	Traces int `json:"traces"`
+	// Metrics is the number of metric data points to send.
+	Metrics int `json:"metrics"`
+	// MetricNamespace is the CloudWatch namespace of the metrics.
+	// If empty, "aws-k8s-tester/[Namespace]" is used.
+	MetricNamespace string `json:"metric_namespace"`
+	// LogGroupName is the log group name of the embedded metric format logs.
+	// If empty, "/aws-k8s-tester/[Namespace]" is used.
+	// The log group is deleted on "delete".
+	LogGroupName string `json:"log_group_name"`
+	// DeliveryTimeout is the timeout for the traces and metrics to land in X-Ray and CloudWatch.
+	DeliveryTimeout       time.Duration `json:"delivery_timeout"`
+	DeliveryTimeoutString string        `json:"delivery_timeout_string" read-only:"true"`
+
+	// ServiceName is the service name of the sample app, to find its traces in X-Ray.
+	ServiceName string `json:"service_name" read-only:"true"`
+	// TracesFound is the number of traces found in X-Ray.
+	TracesFound int `json:"traces_found" read-only:"true"`
+	// MetricsFound is the number of metrics found in CloudWatch.
+	MetricsFound int `json:"metrics_found" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.CollectorImage == "" {
+		cfg.CollectorImage = DefaultCollectorImage
+	}
+	if cfg.TelemetryGenImage == "" {
+		cfg.TelemetryGenImage = DefaultTelemetryGenImage
+	}
+	if cfg.Traces <= 0 {
+		cfg.Traces = DefaultTraces
+	}
+	if cfg.Metrics <= 0 {
+		cfg.Metrics = DefaultMetrics
+	}
+	if cfg.MetricNamespace == "" {
+		cfg.MetricNamespace = "aws-k8s-tester/" + cfg.Namespace
+	}
+	if cfg.LogGroupName == "" {
+		cfg.LogGroupName = "/aws-k8s-tester/" + cfg.Namespace
+	}
+	if cfg.DeliveryTimeout == time.Duration(0) {
+		cfg.DeliveryTimeout = DefaultDeliveryTimeout
+	}
+	cfg.DeliveryTimeoutString = cfg.DeliveryTimeout.String()
+	cfg.ServiceName = cfg.Namespace
+	return nil
+}
+
+const (
+	DefaultMinimumNodes      int = 1
+	DefaultPartition             = "aws"
+	DefaultCollectorImage        = "public.ecr.aws/aws-observability/aws-otel-collector:v0.40.0"
+	DefaultTelemetryGenImage     = "ghcr.io/open-telemetry/opentelemetry-collector-contrib/telemetrygen:v0.96.0"
+	DefaultTraces            int = 10
+	DefaultMetrics           int = 60
+	DefaultDeliveryTimeout       = 10 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:            false,
+		Prompt:            false,
+		MinimumNodes:      DefaultMinimumNodes,
+		Namespace:         pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Partition:         DefaultPartition,
+		CollectorImage:    DefaultCollectorImage,
+		TelemetryGenImage: DefaultTelemetryGenImage,
+		Traces:            DefaultTraces,
+		Metrics:           DefaultMetrics,
+		DeliveryTimeout:   DefaultDeliveryTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.XRayAPI = xray.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	cfg.CWAPI = cloudwatch.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	cfg.CWLogsAPI = cloudwatchlogs.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	collectorName          = "adot-collector"
+	collectorConfigMapName = "adot-collector-config"
+	collectorConfigFile    = "collector.yaml"
+	collectorOTLPPort      = 4317
+	collectorHealthPort    = 13133
+
+	tracesJobName  = "adot-traces-gen"
+	metricsJobName = "adot-metrics-gen"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createConfigMap(); err != nil {
+		return err
+	}
+	if err := ts.createDaemonSet(); err != nil {
+		return err
+	}
+	if err := ts.checkDaemonSet(); err != nil {
+		return err
+	}
+	if err := ts.createService(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	endpoint := fmt.Sprintf("%s.%s.svc.cluster.local:%d", collectorName, ts.cfg.Namespace, collectorOTLPPort)
+	if err := ts.createJob(tracesJobName, telemetryGenArgs("traces", endpoint, ts.cfg.ServiceName, ts.cfg.Traces)); err != nil {
+		return err
+	}
+	if err := ts.createJob(metricsJobName, telemetryGenArgs("metrics", endpoint, ts.cfg.ServiceName, ts.cfg.Metrics)); err != nil {
+		return err
+	}
+	for _, jobName := range []string{tracesJobName, metricsJobName} {
+		if err := ts.waitForJob(jobName); err != nil {
+			return err
+		}
+	}
+
+	if err := ts.waitForTraces(start); err != nil {
+		return err
+	}
+	return ts.waitForMetrics(start)
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	for _, jobName := range []string{tracesJobName, metricsJobName} {
+		if err := client.DeleteJob(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			jobName,
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+		}
+	}
+
+	if err := client.DeleteService(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		collectorName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Service (%v)", err))
+	}
+
+	if err := client.DeleteDaemonSet(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		collectorName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete DaemonSet (%v)", err))
+	}
+
+	if err := client.DeleteConfigmap(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		collectorConfigMapName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ConfigMap (%v)", err))
+	}
+
+	if err := ts.deleteLogGroup(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete log group (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createConfigMap() error {
+	conf, err := collectorConfig{
+		HealthCheckPort: collectorHealthPort,
+		OTLPPort:        collectorOTLPPort,
+		Region:          ts.cfg.Region,
+		MetricNamespace: ts.cfg.MetricNamespace,
+		LogGroupName:    ts.cfg.LogGroupName,
+	}.render()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("creating collector ConfigMap")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ConfigMaps(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ConfigMap{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      collectorConfigMapName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": collectorName,
+					},
+				},
+				Data: map[string]string{
+					collectorConfigFile: conf,
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("collector ConfigMap already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create collector ConfigMap (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created collector ConfigMap")
+	return nil
+}
+
+func (ts *tester) createDaemonSet() error {
+	ts.cfg.Logger.Info("creating collector DaemonSet", zap.String("image", ts.cfg.CollectorImage))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      collectorName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": collectorName,
+					},
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": collectorName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": collectorName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							Containers: []core_v1.Container{
+								{
+									Name:            collectorName,
+									Image:           ts.cfg.CollectorImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Args:            []string{"--config=/conf/" + collectorConfigFile},
+									Ports: []core_v1.ContainerPort{
+										{Name: "otlp-grpc", ContainerPort: collectorOTLPPort, Protocol: core_v1.ProtocolTCP},
+									},
+									ReadinessProbe: &core_v1.Probe{
+										ProbeHandler: core_v1.ProbeHandler{
+											HTTPGet: &core_v1.HTTPGetAction{
+												Path: "/",
+												Port: intstr.FromInt(collectorHealthPort),
+											},
+										},
+										PeriodSeconds: 10,
+									},
+									VolumeMounts: []core_v1.VolumeMount{
+										{Name: collectorConfigMapName, MountPath: "/conf"},
+									},
+								},
+							},
+							Volumes: []core_v1.Volume{
+								{
+									Name: collectorConfigMapName,
+									VolumeSource: core_v1.VolumeSource{
+										ConfigMap: &core_v1.ConfigMapVolumeSource{
+											LocalObjectReference: core_v1.LocalObjectReference{Name: collectorConfigMapName},
+										},
+									},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("collector DaemonSet already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create collector DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created collector DaemonSet")
+	return nil
+}
+
+func (ts *tester) checkDaemonSet() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	_, err := client.WaitForDaemonSetCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		10*time.Second,
+		ts.cfg.Namespace,
+		collectorName,
+		client.WithQueryFunc(func() {
+			descArgs := []string{
+				ts.cfg.Client.Config().KubectlPath,
+				"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+				"--namespace=" + ts.cfg.Namespace,
+				"describe",
+				"daemonset",
+				collectorName,
+			}
+			descCmd := strings.Join(descArgs, " ")
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, descArgs[0], descArgs[1:]...).CombinedOutput()
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl describe daemonset' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n\"%s\" output:\n%s\n\n", descCmd, string(output))
+		}),
+	)
+	cancel()
+	return err
+}
+
+func (ts *tester) createService() error {
+	ts.cfg.Logger.Info("creating collector Service")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Services(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Service{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      collectorName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: core_v1.ServiceSpec{
+					Selector: map[string]string{
+						"app.kubernetes.io/name": collectorName,
+					},
+					Ports: []core_v1.ServicePort{
+						{
+							Name:       "otlp-grpc",
+							Protocol:   core_v1.ProtocolTCP,
+							Port:       collectorOTLPPort,
+							TargetPort: intstr.FromInt(collectorOTLPPort),
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("collector Service already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create collector Service (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created collector Service")
+	return nil
+}
+
+func (ts *tester) createJob(jobName string, args []string) error {
+	ts.cfg.Logger.Info("creating sample app Job", zap.String("name", jobName), zap.Strings("args", args))
+	backoffLimit := int32(2)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           ts.cfg.TelemetryGenImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Args:            args,
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("sample app Job already exists", zap.String("name", jobName))
+			return nil
+		}
+		return fmt.Errorf("failed to create sample app Job %q (%v)", jobName, err)
+	}
+
+	ts.cfg.Logger.Info("created sample app Job", zap.String("name", jobName))
+	return nil
+}
+
+func (ts *tester) waitForJob(jobName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	_, _, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		10*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("sample app Job %q not completed (%v)", jobName, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package k8s_tester
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aws/aws-k8s-tester/client"
+)
+
+// setAPICallsTester attributes the following API calls to the tester,
+// or to "k8s-tester" itself if the name is empty.
+func (ts *tester) setAPICallsTester(name string) {
+	if ts.apiCalls == nil {
+		return
+	}
+	if name == "" {
+		name = pkgName
+	}
+	ts.apiCalls.SetTester(name)
+}
+
+// syncAPICalls copies the API call counts to the config results.
+func (ts *tester) syncAPICalls() {
+	if ts.apiCalls == nil {
+		return
+	}
+	ts.cfg.APICalls = ts.apiCalls.Counts()
+	ts.cfg.APICallsTotal = ts.apiCalls.Total("")
+	ts.cfg.APIThrottled = ts.apiCalls.Throttled()
+	ts.cfg.APIThrottledString = ts.cfg.APIThrottled.String()
+}
+
+// writeAPICalls writes the API call counts of each tester,
+// with the most frequent calls first.
+func writeAPICalls(w io.Writer, calls map[string]map[string]int64, total int64) {
+	fmt.Fprintf(w, "\n%d Kubernetes API calls\n", total)
+	for _, name := range sortedKeys(calls) {
+		var sum int64
+		for _, v := range calls[name] {
+			sum += v
+		}
+		fmt.Fprintf(w, "%s: %d calls\n", name, sum)
+		for _, k := range client.SortedAPICallKeys(calls[name]) {
+			fmt.Fprintf(w, "  %-40s %d\n", k, calls[name][k])
+		}
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func sortedKeys(m map[string]map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
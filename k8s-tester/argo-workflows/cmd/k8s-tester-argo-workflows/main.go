@@ -0,0 +1,175 @@
+// k8s-tester-argo-workflows installs Argo Workflows and tests DAG workflow throughput.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	argo_workflows "github.com/aws/aws-k8s-tester/k8s-tester/argo-workflows"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-argo-workflows",
+	Short:      "Kubernetes Argo Workflows tester",
+	SuggestFor: []string{"argo-workflows"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", argo_workflows.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-argo-workflows failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	helmChartRepoURL string
+	helmChartVersion string
+	workflows        int
+	dagWidth         int
+	dagDepth         int
+	stepDuration     time.Duration
+	workflowsTimeout time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&helmChartRepoURL, "helm-chart-repo-url", argo_workflows.DefaultHelmChartRepoURL, "helm chart repo URL")
+	cmd.PersistentFlags().StringVar(&helmChartVersion, "helm-chart-version", "", "Argo Workflows helm chart version (empty for latest)")
+	cmd.PersistentFlags().IntVar(&workflows, "workflows", argo_workflows.DefaultWorkflows, "number of workflows to submit")
+	cmd.PersistentFlags().IntVar(&dagWidth, "dag-width", argo_workflows.DefaultDAGWidth, "number of parallel steps in each DAG level")
+	cmd.PersistentFlags().IntVar(&dagDepth, "dag-depth", argo_workflows.DefaultDAGDepth, "number of DAG levels")
+	cmd.PersistentFlags().DurationVar(&stepDuration, "step-duration", argo_workflows.DefaultStepDuration, "duration of each step")
+	cmd.PersistentFlags().DurationVar(&workflowsTimeout, "workflows-timeout", argo_workflows.DefaultWorkflowsTimeout, "timeout to wait for all workflows to complete")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &argo_workflows.Config{
+		Prompt:           prompt,
+		Logger:           lg,
+		LogWriter:        logWriter,
+		MinimumNodes:     minimumNodes,
+		Namespace:        namespace,
+		Client:           cli,
+		HelmChartRepoURL: helmChartRepoURL,
+		HelmChartVersion: helmChartVersion,
+		Workflows:        workflows,
+		DAGWidth:         dagWidth,
+		DAGDepth:         dagDepth,
+		StepDuration:     stepDuration,
+		WorkflowsTimeout: workflowsTimeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := argo_workflows.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-argo-workflows apply' success (%.2f workflows/minute)\n", cfg.ThroughputPerMinute)
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &argo_workflows.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := argo_workflows.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-argo-workflows delete' success\n")
+}
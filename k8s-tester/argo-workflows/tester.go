@@ -0,0 +1,628 @@
+// Package argo_workflows installs Argo Workflows, submits DAG workflows at scale,
+// and measures the workflow scheduling throughput and completion latency,
+// representative of CI/ML pipeline workloads.
+// ref. https://argo-workflows.readthedocs.io/en/latest/walk-through/dag
+package argo_workflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-k8s-tester/utils/latency"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// HelmChartRepoURL is the Argo helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+	// HelmChartVersion is the Argo Workflows helm chart version.
+	// Leave empty to install the latest.
+	HelmChartVersion string `json:"helm_chart_version"`
+
+	// Workflows is the number of workflows to submit at once.
+	Workflows int `json:"workflows"`
+	// DAGWidth is the number of parallel steps in each level of the DAG.
+	DAGWidth int `json:"dag_width"`
+	// DAGDepth is the number of levels of the DAG,
+	// where each step depends on all steps in the previous level.
+	DAGDepth int `json:"dag_depth"`
+	// StepDuration is how long each step runs.
+	StepDuration       time.Duration `json:"step_duration"`
+	StepDurationString string        `json:"step_duration_string" read-only:"true"`
+	// WorkflowsTimeout is the timeout to wait for all workflows to complete.
+	WorkflowsTimeout       time.Duration `json:"workflows_timeout"`
+	WorkflowsTimeoutString string        `json:"workflows_timeout_string" read-only:"true"`
+
+	// SucceededWorkflows is the number of succeeded workflows.
+	SucceededWorkflows int `json:"succeeded_workflows" read-only:"true"`
+	// FailedWorkflows is the number of failed or errored workflows.
+	FailedWorkflows int `json:"failed_workflows" read-only:"true"`
+	// ThroughputPerMinute is the number of workflows completed per minute,
+	// from the first submission to the last completion.
+	ThroughputPerMinute float64 `json:"throughput_per_minute" read-only:"true"`
+	// SchedulingLatencyP50 is the p50 latency from the workflow creation to its start.
+	SchedulingLatencyP50 time.Duration `json:"scheduling_latency_p50" read-only:"true"`
+	// SchedulingLatencyP99 is the p99 latency from the workflow creation to its start.
+	SchedulingLatencyP99 time.Duration `json:"scheduling_latency_p99" read-only:"true"`
+	// CompletionLatencyP50 is the p50 latency from the workflow creation to its completion.
+	CompletionLatencyP50 time.Duration `json:"completion_latency_p50" read-only:"true"`
+	// CompletionLatencyP99 is the p99 latency from the workflow creation to its completion.
+	CompletionLatencyP99 time.Duration `json:"completion_latency_p99" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.Workflows == 0 {
+		cfg.Workflows = DefaultWorkflows
+	}
+	if cfg.DAGWidth == 0 {
+		cfg.DAGWidth = DefaultDAGWidth
+	}
+	if cfg.DAGDepth == 0 {
+		cfg.DAGDepth = DefaultDAGDepth
+	}
+	if cfg.StepDuration == time.Duration(0) {
+		cfg.StepDuration = DefaultStepDuration
+	}
+	cfg.StepDurationString = cfg.StepDuration.String()
+	if cfg.WorkflowsTimeout == time.Duration(0) {
+		cfg.WorkflowsTimeout = DefaultWorkflowsTimeout
+	}
+	cfg.WorkflowsTimeoutString = cfg.WorkflowsTimeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes     int = 1
+	DefaultHelmChartRepoURL     = "https://argoproj.github.io/argo-helm"
+	DefaultWorkflows        int = 20
+	DefaultDAGWidth         int = 5
+	DefaultDAGDepth         int = 3
+	DefaultStepDuration         = time.Second
+	DefaultWorkflowsTimeout     = 30 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL: DefaultHelmChartRepoURL,
+		Workflows:        DefaultWorkflows,
+		DAGWidth:         DefaultDAGWidth,
+		DAGDepth:         DefaultDAGDepth,
+		StepDuration:     DefaultStepDuration,
+		WorkflowsTimeout: DefaultWorkflowsTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	chartName          = "argo-workflows"
+	serviceAccountName = "argo-workflow"
+	stepImageName      = "public.ecr.aws/docker/library/busybox:1.36"
+	workflowLabelKey   = "k8s-tester"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createHelmArgoWorkflows(); err != nil {
+		return err
+	}
+
+	if err := ts.submitWorkflows(); err != nil {
+		return err
+	}
+
+	if err := ts.checkWorkflows(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := ts.deleteWorkflows(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := ts.deleteHelmArgoWorkflows(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// https://github.com/argoproj/argo-helm/blob/main/charts/argo-workflows/values.yaml
+func (ts *tester) createHelmArgoWorkflows() error {
+	values := map[string]interface{}{
+		"controller": map[string]interface{}{
+			"workflowNamespaces": []string{ts.cfg.Namespace},
+		},
+		// the executor service account to run workflows with
+		"workflow": map[string]interface{}{
+			"serviceAccount": map[string]interface{}{
+				"create": true,
+				"name":   serviceAccountName,
+			},
+			"rbac": map[string]interface{}{
+				"create": true,
+			},
+		},
+		"server": map[string]interface{}{
+			"enabled": false,
+		},
+	}
+
+	getAllArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"get",
+		"all",
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        10 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.HelmChartVersion,
+		ReleaseName:    chartName,
+		Values:         values,
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmArgoWorkflows() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+	})
+}
+
+const workflowTmpl = `
+{{- range $name := .Names }}
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: {{ $name }}
+  namespace: {{ $.Namespace }}
+  labels:
+    {{ $.LabelKey }}: {{ $.LabelValue }}
+spec:
+  entrypoint: dag
+  serviceAccountName: {{ $.ServiceAccountName }}
+  podGC:
+    strategy: OnPodSuccess
+  templates:
+  - name: dag
+    dag:
+      tasks:
+{{- range $task := $.Tasks }}
+      - name: {{ $task.Name }}
+        template: step
+{{- if $task.Dependencies }}
+        dependencies: [{{ $task.Dependencies }}]
+{{- end }}
+{{- end }}
+  - name: step
+    container:
+      image: {{ $.Image }}
+      command: ["sleep", "{{ $.StepSeconds }}"]
+{{- end }}
+`
+
+type dagTask struct {
+	Name         string
+	Dependencies string
+}
+
+// dagTasks returns the tasks of a "depth" levels DAG with "width" tasks per level,
+// where each task depends on all tasks in the previous level.
+func dagTasks(width int, depth int) []dagTask {
+	tasks := make([]dagTask, 0, width*depth)
+	var prev []string
+	for l := 0; l < depth; l++ {
+		cur := make([]string, 0, width)
+		for i := 0; i < width; i++ {
+			name := fmt.Sprintf("l%d-t%d", l, i)
+			tasks = append(tasks, dagTask{Name: name, Dependencies: strings.Join(prev, ", ")})
+			cur = append(cur, name)
+		}
+		prev = cur
+	}
+	return tasks
+}
+
+func (ts *tester) submitWorkflows() error {
+	names := make([]string, ts.cfg.Workflows)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s-%05d", pkgName, i)
+	}
+
+	tpl := template.Must(template.New("workflowTmpl").Parse(workflowTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, struct {
+		Names              []string
+		Namespace          string
+		LabelKey           string
+		LabelValue         string
+		ServiceAccountName string
+		Tasks              []dagTask
+		Image              string
+		StepSeconds        int
+	}{
+		Names:              names,
+		Namespace:          ts.cfg.Namespace,
+		LabelKey:           workflowLabelKey,
+		LabelValue:         pkgName,
+		ServiceAccountName: serviceAccountName,
+		Tasks:              dagTasks(ts.cfg.DAGWidth, ts.cfg.DAGDepth),
+		Image:              stepImageName,
+		StepSeconds:        int(ts.cfg.StepDuration.Seconds()),
+	}); err != nil {
+		return err
+	}
+	fpath, err := file.WriteTempFile(buf.Bytes())
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to write Workflow YAML", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("submitting Workflows",
+		zap.String("path", fpath),
+		zap.Int("workflows", ts.cfg.Workflows),
+		zap.Int("dag-width", ts.cfg.DAGWidth),
+		zap.Int("dag-depth", ts.cfg.DAGDepth),
+	)
+	applyArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"apply",
+		"--filename=" + fpath,
+	}
+	applyCmd := strings.Join(applyArgs, " ")
+
+	var output []byte
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("submit Workflows aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		output, err = exec.New().CommandContext(ctx, applyArgs[0], applyArgs[1:]...).CombinedOutput()
+		cancel()
+		out := string(output)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", applyCmd, out)
+		if err == nil {
+			break
+		}
+
+		ts.cfg.Logger.Warn("submit Workflows failed", zap.Error(err))
+	}
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+	}
+
+	ts.cfg.Logger.Info("submitted Workflows")
+	return nil
+}
+
+func (ts *tester) deleteWorkflows() error {
+	ts.cfg.Logger.Info("deleting Workflows")
+	deleteArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"delete",
+		"workflows.argoproj.io",
+		"--selector=" + workflowLabelKey + "=" + pkgName,
+		"--ignore-not-found=true",
+	}
+	deleteCmd := strings.Join(deleteArgs, " ")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	output, err := exec.New().CommandContext(ctx, deleteArgs[0], deleteArgs[1:]...).CombinedOutput()
+	cancel()
+	out := string(output)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", deleteCmd, out)
+	if err != nil {
+		// CRD may have been removed already
+		if strings.Contains(out, "the server doesn't have a resource type") {
+			return nil
+		}
+		return fmt.Errorf("'kubectl delete' failed %v (output %q)", err, out)
+	}
+
+	ts.cfg.Logger.Info("deleted Workflows")
+	return nil
+}
+
+// workflowList is the subset of the Workflow list fields to check.
+type workflowList struct {
+	Items []struct {
+		Metadata struct {
+			Name              string    `json:"name"`
+			CreationTimestamp time.Time `json:"creationTimestamp"`
+		} `json:"metadata"`
+		Status struct {
+			Phase      string    `json:"phase"`
+			StartedAt  time.Time `json:"startedAt"`
+			FinishedAt time.Time `json:"finishedAt"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+func (ts *tester) listWorkflows() (workflowList, error) {
+	getArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"get",
+		"workflows.argoproj.io",
+		"--selector=" + workflowLabelKey + "=" + pkgName,
+		"--output=json",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(ctx, getArgs[0], getArgs[1:]...).Output()
+	cancel()
+	if err != nil {
+		return workflowList{}, fmt.Errorf("'kubectl get' failed %v", err)
+	}
+	var ls workflowList
+	if err = json.Unmarshal(output, &ls); err != nil {
+		return workflowList{}, err
+	}
+	return ls, nil
+}
+
+// checkWorkflows waits until all workflows complete,
+// and computes the throughput and latency from the workflow statuses.
+func (ts *tester) checkWorkflows() error {
+	ts.cfg.Logger.Info("waiting for Workflows", zap.Duration("timeout", ts.cfg.WorkflowsTimeout))
+	var ls workflowList
+	completed := 0
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.WorkflowsTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check Workflows aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		var err error
+		ls, err = ts.listWorkflows()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Workflows; retrying", zap.Error(err))
+			continue
+		}
+		phases := make(map[string]int)
+		completed = 0
+		for _, wf := range ls.Items {
+			phases[wf.Status.Phase]++
+			if !wf.Status.FinishedAt.IsZero() {
+				completed++
+			}
+		}
+		ts.cfg.Logger.Info("checked Workflows",
+			zap.Int("completed", completed),
+			zap.Int("total", ts.cfg.Workflows),
+			zap.Any("phases", phases),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+		if completed >= ts.cfg.Workflows {
+			break
+		}
+	}
+	if completed < ts.cfg.Workflows {
+		return fmt.Errorf("%d of %d Workflows completed within %v", completed, ts.cfg.Workflows, ts.cfg.WorkflowsTimeout)
+	}
+
+	var firstCreated, lastFinished time.Time
+	scheduling := make(latency.Durations, 0, len(ls.Items))
+	completion := make(latency.Durations, 0, len(ls.Items))
+	ts.cfg.SucceededWorkflows, ts.cfg.FailedWorkflows = 0, 0
+	for _, wf := range ls.Items {
+		if wf.Status.Phase == "Succeeded" {
+			ts.cfg.SucceededWorkflows++
+		} else {
+			ts.cfg.FailedWorkflows++
+		}
+		created := wf.Metadata.CreationTimestamp
+		if firstCreated.IsZero() || created.Before(firstCreated) {
+			firstCreated = created
+		}
+		if wf.Status.FinishedAt.After(lastFinished) {
+			lastFinished = wf.Status.FinishedAt
+		}
+		scheduling = append(scheduling, wf.Status.StartedAt.Sub(created))
+		completion = append(completion, wf.Status.FinishedAt.Sub(created))
+	}
+	sort.Sort(scheduling)
+	sort.Sort(completion)
+	ts.cfg.SchedulingLatencyP50, ts.cfg.SchedulingLatencyP99 = scheduling.PickP50(), scheduling.PickP99()
+	ts.cfg.CompletionLatencyP50, ts.cfg.CompletionLatencyP99 = completion.PickP50(), completion.PickP99()
+	if took := lastFinished.Sub(firstCreated); took > 0 {
+		ts.cfg.ThroughputPerMinute = float64(len(ls.Items)) / took.Minutes()
+	}
+
+	ts.cfg.Logger.Info("completed Workflows",
+		zap.Int("succeeded", ts.cfg.SucceededWorkflows),
+		zap.Int("failed", ts.cfg.FailedWorkflows),
+		zap.Float64("throughput-per-minute", ts.cfg.ThroughputPerMinute),
+		zap.Duration("scheduling-latency-p50", ts.cfg.SchedulingLatencyP50),
+		zap.Duration("scheduling-latency-p99", ts.cfg.SchedulingLatencyP99),
+		zap.Duration("completion-latency-p50", ts.cfg.CompletionLatencyP50),
+		zap.Duration("completion-latency-p99", ts.cfg.CompletionLatencyP99),
+	)
+	if ts.cfg.FailedWorkflows > 0 {
+		return fmt.Errorf("%d of %d Workflows failed", ts.cfg.FailedWorkflows, ts.cfg.Workflows)
+	}
+	return nil
+}
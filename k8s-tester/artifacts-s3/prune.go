@@ -0,0 +1,15 @@
+package artifacts_s3
+
+import (
+	"github.com/aws/aws-k8s-tester/utils/artifacts"
+)
+
+// Prune deletes the uploaded runs under "Prefix" (i.e., "Prefix/[CLUSTER_NAME]/[RUN_ID]/")
+// by the retention policy, and returns the pruned runs.
+// Set "dryRun" to only return the runs to prune.
+func Prune(cfg *Config, policy artifacts.Policy, dryRun bool) ([]artifacts.Run, error) {
+	if err := cfg.createS3API(); err != nil {
+		return nil, err
+	}
+	return artifacts.PruneS3(cfg.Logger, cfg.S3API, cfg.BucketName, cfg.Prefix, RunDepth, policy, dryRun)
+}
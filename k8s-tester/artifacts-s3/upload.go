@@ -0,0 +1,211 @@
+// Package artifacts_s3 uploads the k8s-tester run artifacts to Amazon S3,
+// such as the log files, the final configuration with the tester results,
+// the sonobuoy results, and the clusterloader reports.
+package artifacts_s3
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	utils_s3 "github.com/aws/aws-k8s-tester/utils/aws/s3"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+
+	Logger *zap.Logger `json:"-"`
+
+	S3API s3iface.S3API `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+	// AssumeRoleARN is the IAM role to assume to upload the artifacts, if not empty.
+	// Defaults to the k8s-tester "AWSAssumeRoleARN".
+	AssumeRoleARN string `json:"assume_role_arn"`
+	// AssumeRoleDuration is the duration of the assumed role credentials.
+	AssumeRoleDuration time.Duration `json:"assume_role_duration"`
+
+	// BucketName is the S3 bucket to upload the artifacts to.
+	BucketName string `json:"bucket_name"`
+	// BucketCreate is true to create the bucket if it does not exist.
+	BucketCreate bool `json:"bucket_create"`
+	// BucketLifecycleExpirationDays is the expiration in days of the uploaded
+	// artifacts, when the bucket is created. Zero to never expire.
+	BucketLifecycleExpirationDays int64 `json:"bucket_lifecycle_expiration_days"`
+	// Prefix is the S3 key prefix, followed by the cluster name, the run ID and the file name
+	// (e.g., "k8s-tester/my-cluster/abcd1234/my-cluster.k8s-tester.yaml").
+	Prefix string `json:"prefix"`
+	// ComponentLayout is true to upload the artifacts under the component directories
+	// (e.g., "k8s-tester/my-cluster/abcd1234/config/my-cluster.k8s-tester.yaml").
+	ComponentLayout bool `json:"component_layout"`
+	// KMSKeyID is the KMS key ID or ARN to encrypt the artifacts with.
+	// Leave empty to use the bucket default encryption.
+	KMSKeyID string `json:"kms_key_id"`
+
+	// UploadedKeys is the list of S3 keys uploaded in the last run.
+	UploadedKeys []string `json:"uploaded_keys" read-only:"true"`
+	// ManifestKey is the S3 key of the manifest index of the last run.
+	ManifestKey string `json:"manifest_key" read-only:"true"`
+}
+
+const (
+	DefaultPartition = "aws"
+	DefaultPrefix    = "k8s-tester"
+
+	// RunDepth is the depth of the runs under "Prefix" (i.e., "[CLUSTER_NAME]/[RUN_ID]/").
+	RunDepth = 2
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:    false,
+		Partition: DefaultPartition,
+		Prefix:    DefaultPrefix,
+	}
+}
+
+func Env() string {
+	return "ARTIFACTS_S3"
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.BucketName == "" {
+		return errors.New("empty BucketName")
+	}
+	if cfg.BucketLifecycleExpirationDays < 0 {
+		return fmt.Errorf("negative BucketLifecycleExpirationDays %d", cfg.BucketLifecycleExpirationDays)
+	}
+	if cfg.BucketLifecycleExpirationDays > 0 && cfg.BucketLifecycleExpirationDays < utils_s3.MinLifecycleExpirationDays {
+		cfg.BucketLifecycleExpirationDays = utils_s3.MinLifecycleExpirationDays
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	return nil
+}
+
+func (cfg *Config) createS3API() error {
+	if cfg.S3API != nil {
+		return nil
+	}
+	awsCfg := aws_v1.Config{
+		Logger:             cfg.Logger,
+		DebugAPICalls:      cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:          cfg.Partition,
+		Region:             cfg.Region,
+		AssumeRoleARN:      cfg.AssumeRoleARN,
+		AssumeRoleDuration: cfg.AssumeRoleDuration,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.S3API = s3.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	return nil
+}
+
+// Upload uploads the artifacts to S3 under "Prefix/clusterName/runID",
+// or "Prefix/clusterName/runID/[COMPONENT]" with "ComponentLayout", with the "manifest.json" index.
+// Each run of the same cluster is uploaded under its own prefix, to be pruned as a run.
+// The "artifacts" map is keyed by the component (e.g., "logs", "reports").
+// A directory is uploaded recursively, keeping the paths relative to its parent.
+// Missing paths are skipped, since some testers only write artifacts on success.
+func Upload(cfg *Config, clusterName string, runID string, artifacts map[string][]string) error {
+	if clusterName == "" || runID == "" {
+		return fmt.Errorf("empty cluster name %q or run ID %q", clusterName, runID)
+	}
+	if err := cfg.createS3API(); err != nil {
+		return err
+	}
+
+	clusterPrefix := path.Join(cfg.Prefix, clusterName)
+	runPrefix := path.Join(clusterPrefix, runID)
+
+	m, err := utils_s3.New(utils_s3.Config{
+		Logger:          cfg.Logger,
+		S3API:           cfg.S3API,
+		Region:          cfg.Region,
+		BucketName:      cfg.BucketName,
+		RunPrefix:       runPrefix,
+		KMSKeyID:        cfg.KMSKeyID,
+		ComponentLayout: cfg.ComponentLayout,
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.BucketCreate {
+		// expire all the runs of the cluster, not only the one creating the bucket
+		lifecyclePrefix := clusterPrefix
+		if cfg.ComponentLayout {
+			lifecyclePrefix += "/"
+		}
+		if err = aws_s3.CreateBucket(cfg.Logger, cfg.S3API, cfg.BucketName, cfg.Region, lifecyclePrefix, cfg.BucketLifecycleExpirationDays); err != nil {
+			return fmt.Errorf("failed to create bucket %q (%v)", cfg.BucketName, err)
+		}
+	}
+
+	components := make([]string, 0, len(artifacts))
+	for c := range artifacts {
+		components = append(components, c)
+	}
+	sort.Strings(components)
+
+	cfg.UploadedKeys = nil
+	var errs []string
+	for _, c := range components {
+		for _, p := range artifacts[c] {
+			if p == "" {
+				continue
+			}
+			info, err := os.Stat(p)
+			if err != nil {
+				cfg.Logger.Warn("skipping artifact", zap.String("path", p), zap.Error(err))
+				continue
+			}
+			if info.IsDir() {
+				if _, err = m.UploadDir(c, p); err != nil {
+					errs = append(errs, fmt.Sprintf("failed to upload %q (%v)", p, err))
+				}
+				continue
+			}
+			if _, err = m.Upload(c, filepath.Base(p), p); err != nil {
+				errs = append(errs, fmt.Sprintf("failed to upload %q (%v)", p, err))
+			}
+		}
+	}
+	for _, e := range m.Manifest().Entries {
+		cfg.UploadedKeys = append(cfg.UploadedKeys, e.Key)
+	}
+	cfg.ManifestKey, err = m.WriteManifest()
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	cfg.Logger.Info("uploaded artifacts",
+		zap.String("bucket", cfg.BucketName),
+		zap.String("prefix", runPrefix),
+		zap.Int("uploaded", len(cfg.UploadedKeys)),
+		zap.Int("errors", len(errs)),
+	)
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
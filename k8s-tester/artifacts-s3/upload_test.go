@@ -0,0 +1,122 @@
+package artifacts_s3
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/utils/artifacts"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+type object struct {
+	size     int64
+	modified time.Time
+}
+
+type fakeS3 struct {
+	s3iface.S3API
+	now     time.Time
+	objects map[string]object
+}
+
+func (f *fakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	b, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.now = f.now.Add(time.Second)
+	f.objects[aws.StringValue(input.Key)] = object{size: int64(len(b)), modified: f.now}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error {
+	out := &s3.ListObjectsV2Output{}
+	for k, obj := range f.objects {
+		if strings.HasPrefix(k, aws.StringValue(input.Prefix)) {
+			out.Contents = append(out.Contents, &s3.Object{Key: aws.String(k), LastModified: aws.Time(obj.modified), Size: aws.Int64(obj.size)})
+		}
+	}
+	fn(out, true)
+	return nil
+}
+
+func (f *fakeS3) DeleteObjects(input *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error) {
+	for _, obj := range input.Delete.Objects {
+		delete(f.objects, aws.StringValue(obj.Key))
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+func TestUploadPruneRepeatedRuns(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "artifacts-s3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logPath := filepath.Join(dir, "k8s-tester.log")
+	if err = ioutil.WriteFile(logPath, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeS3{now: time.Now(), objects: make(map[string]object)}
+	cfg := NewDefault()
+	cfg.Logger = zap.NewExample()
+	cfg.S3API = fake
+	cfg.Region = "us-west-2"
+	cfg.BucketName = "hello"
+	cfg.ComponentLayout = true
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a long-lived cluster runs the tests repeatedly
+	for _, runID := range []string{"run-a", "run-b", "run-c"} {
+		if err = Upload(cfg, "soak", runID, map[string][]string{"logs": {logPath}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = Upload(cfg, "other", "run-d", map[string][]string{"logs": {logPath}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ManifestKey != "k8s-tester/other/run-d/manifest.json" {
+		t.Fatalf("unexpected ManifestKey %q", cfg.ManifestKey)
+	}
+	if err = Upload(cfg, "soak", "", nil); err == nil {
+		t.Fatal("expected error for empty run ID")
+	}
+
+	pruned, err := Prune(cfg, artifacts.Policy{KeepCount: 2}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range pruned {
+		names = append(names, r.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"soak/run-b", "soak/run-a"}) {
+		t.Fatalf("unexpected pruned runs %v", names)
+	}
+	var remaining []string
+	for k := range fake.objects {
+		remaining = append(remaining, k)
+	}
+	sort.Strings(remaining)
+	expected := []string{
+		"k8s-tester/other/run-d/logs/k8s-tester.log",
+		"k8s-tester/other/run-d/manifest.json",
+		"k8s-tester/soak/run-c/logs/k8s-tester.log",
+		"k8s-tester/soak/run-c/manifest.json",
+	}
+	if !reflect.DeepEqual(remaining, expected) {
+		t.Fatalf("unexpected remaining objects %v", remaining)
+	}
+}
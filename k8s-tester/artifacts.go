@@ -0,0 +1,41 @@
+package k8s_tester
+
+import (
+	utils_s3 "github.com/aws/aws-k8s-tester/utils/aws/s3"
+)
+
+// artifactPaths returns the local files to upload to S3 after "apply",
+// keyed by the component of the standard artifact layout,
+// including the files that the testers may not have written.
+func (ts *tester) artifactPaths() map[string][]string {
+	paths := map[string][]string{
+		utils_s3.ComponentConfig:  {ts.cfg.ConfigPath},
+		utils_s3.ComponentReports: {ts.cfg.ReportJUnitPath, ts.cfg.ReportTAPPath, ts.cfg.ReportResultsPath, ts.cfg.ReportHTMLPath},
+	}
+	for _, p := range ts.cfg.LogOutputs {
+		if p != "default" && p != "stderr" && p != "stdout" {
+			paths[utils_s3.ComponentLogs] = append(paths[utils_s3.ComponentLogs], p)
+		}
+	}
+	if ts.cfg.IAMPreflight != nil && ts.cfg.IAMPreflight.Enable {
+		paths[utils_s3.ComponentReports] = append(paths[utils_s3.ComponentReports], ts.cfg.IAMPreflight.PolicyPath)
+	}
+	if ts.cfg.AddOnConformance != nil && ts.cfg.AddOnConformance.Enable {
+		paths[utils_s3.ComponentResults] = append(paths[utils_s3.ComponentResults],
+			ts.cfg.AddOnConformance.SonobuoyResultsTarGzPath,
+			ts.cfg.AddOnConformance.SonobuoyResultsE2ELogPath,
+			ts.cfg.AddOnConformance.SonobuoyResultsJunitXMLPath,
+			ts.cfg.AddOnConformance.BaselineDiffPath,
+		)
+	}
+	if ts.cfg.AddOnClusterloader != nil && ts.cfg.AddOnClusterloader.Enable {
+		paths[utils_s3.ComponentResults] = append(paths[utils_s3.ComponentResults],
+			ts.cfg.AddOnClusterloader.TestReportDirTarGzPath,
+			ts.cfg.AddOnClusterloader.TestLogPath,
+			ts.cfg.AddOnClusterloader.PodStartupLatencyPath,
+			ts.cfg.AddOnClusterloader.SLIsPath,
+		)
+		paths[utils_s3.ComponentResults] = append(paths[utils_s3.ComponentResults], ts.cfg.AddOnClusterloader.MeasurementPaths...)
+	}
+	return paths
+}
@@ -0,0 +1,162 @@
+// k8s-tester-auth-refresh runs long-lived clients with expiring credentials, and validates the credential refresh.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	auth_refresh "github.com/aws/aws-k8s-tester/k8s-tester/auth-refresh"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-auth-refresh",
+	Short:      "Kubernetes credential expiry and refresh tester",
+	SuggestFor: []string{"auth-refresh"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	kubeconfigContext  string
+	region             string
+	clusterName        string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", auth_refresh.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "namespace to create the ServiceAccount of the tokens")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigContext, "kubeconfig-context", "", "KUBECONFIG context")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for EKS cluster, required for the 'eks' mode")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster-name", "", "EKS cluster name, required for the 'eks' mode")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-auth-refresh failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	modes           []string
+	tokenDuration   time.Duration
+	duration        time.Duration
+	requestInterval time.Duration
+	maxFailureRatio float64
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringSliceVar(&modes, "modes", auth_refresh.DefaultModes(), "credential modes to test, 'exec', 'token-file', or 'eks'")
+	cmd.PersistentFlags().DurationVar(&tokenDuration, "token-duration", auth_refresh.DefaultTokenDuration, "ServiceAccount token duration of the 'exec' and the 'token-file' modes")
+	cmd.PersistentFlags().DurationVar(&duration, "duration", auth_refresh.DefaultDuration, "duration of the requests, longer than the token duration")
+	cmd.PersistentFlags().DurationVar(&requestInterval, "request-interval", auth_refresh.DefaultRequestInterval, "interval between the requests of each client")
+	cmd.PersistentFlags().Float64Var(&maxFailureRatio, "max-failure-ratio", auth_refresh.DefaultMaxFailureRatio, "maximum ratio of the failed requests other than 'Unauthorized'")
+	return cmd
+}
+
+func newConfig() *auth_refresh.Config {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+		KubeconfigContext:  kubeconfigContext,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	return &auth_refresh.Config{
+		Prompt:          prompt,
+		Logger:          lg,
+		LogWriter:       logWriter,
+		MinimumNodes:    minimumNodes,
+		Namespace:       namespace,
+		Client:          cli,
+		Region:          region,
+		Modes:           modes,
+		TokenDuration:   tokenDuration,
+		Duration:        duration,
+		RequestInterval: requestInterval,
+		MaxFailureRatio: maxFailureRatio,
+	}
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	cfg := newConfig()
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		cfg.Logger.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := auth_refresh.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-auth-refresh apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	cfg := newConfig()
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		cfg.Logger.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := auth_refresh.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-auth-refresh delete' success\n")
+}
@@ -0,0 +1,416 @@
+package auth_refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"go.uber.org/zap"
+	authentication_v1 "k8s.io/api/authentication/v1"
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_client "k8s.io/client-go/kubernetes"
+	k8s_client_rest "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmd_api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	serviceAccountName = "auth-refresh"
+	roleName           = "auth-refresh"
+	roleBindingName    = "auth-refresh"
+)
+
+// run is the long-lived client of a credential mode.
+type run struct {
+	mode string
+	cli  client.Client
+
+	// rotate rotates the credential if due, or nil if the client refreshes by itself.
+	rotate func() error
+	// refreshes returns the number of the credentials issued after the first.
+	refreshes func() int
+	// firstToken returns the first token issued, or nil if unknown.
+	firstToken func() (string, error)
+
+	mu              sync.Mutex
+	requests        int64
+	failures        int64
+	unauthorized    int64
+	lastErr         string
+	expiredRejected bool
+}
+
+func (r *run) observe(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if err == nil {
+		return
+	}
+	if k8s_errors.IsUnauthorized(err) {
+		r.unauthorized++
+	} else {
+		r.failures++
+	}
+	r.lastErr = err.Error()
+}
+
+func (r *run) result() ModeResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := ModeResult{
+		Mode:                 r.mode,
+		Requests:             r.requests,
+		Failures:             r.failures,
+		Unauthorized:         r.unauthorized,
+		ExpiredTokenRejected: r.expiredRejected,
+		LastError:            r.lastErr,
+	}
+	if r.refreshes != nil {
+		res.Refreshes = r.refreshes()
+	}
+	return res
+}
+
+// newRun creates the client of the mode, with its generated kubeconfig if any.
+func (ts *tester) newRun(mode string) (*run, error) {
+	ccfg := ts.cfg.Client.Config()
+	if mode == ModeEKS {
+		cli, err := client.New(&client.Config{
+			Logger:             ts.cfg.Logger,
+			KubectlDownloadURL: ccfg.KubectlDownloadURL,
+			KubectlPath:        ccfg.KubectlPath,
+			EKS: &client.EKS{
+				Region:      ts.cfg.Region,
+				ClusterName: ts.cfg.ClusterName,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &run{mode: mode, cli: cli}, nil
+	}
+
+	base, err := ts.baseRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	r := &run{mode: mode}
+	kubeconfigPath := filepath.Join(ts.cfg.WorkDir, mode+".kubeconfig")
+	switch mode {
+	case ModeExec:
+		tokensPath := filepath.Join(ts.cfg.WorkDir, "exec-tokens")
+		scriptPath := filepath.Join(ts.cfg.WorkDir, "exec-credential.sh")
+		script := execCredentialScript(ccfg.KubectlPath, ccfg.KubeconfigPath, ccfg.KubeconfigContext, ts.cfg.Namespace, ts.cfg.TokenDuration, tokensPath)
+		if err = ioutil.WriteFile(scriptPath, []byte(script), 0700); err != nil {
+			return nil, err
+		}
+		err = writeKubeconfig(kubeconfigPath, base, ts.cfg.Namespace, &clientcmd_api.AuthInfo{
+			Exec: &clientcmd_api.ExecConfig{
+				APIVersion:      "client.authentication.k8s.io/v1beta1",
+				Command:         scriptPath,
+				InteractiveMode: clientcmd_api.NeverExecInteractiveMode,
+			},
+		})
+		r.refreshes = func() int {
+			tokens, _ := readLines(tokensPath)
+			if len(tokens) == 0 {
+				return 0
+			}
+			return len(tokens) - 1
+		}
+		r.firstToken = func() (string, error) {
+			tokens, err := readLines(tokensPath)
+			if err != nil {
+				return "", err
+			}
+			if len(tokens) == 0 {
+				return "", errors.New("credential plugin never ran")
+			}
+			return strings.Fields(tokens[0])[0], nil
+		}
+
+	case ModeTokenFile:
+		tokenPath := filepath.Join(ts.cfg.WorkDir, "token")
+		first, err := ts.requestToken()
+		if err != nil {
+			return nil, err
+		}
+		if err = writeFileAtomic(tokenPath, first); err != nil {
+			return nil, err
+		}
+		err = writeKubeconfig(kubeconfigPath, base, ts.cfg.Namespace, &clientcmd_api.AuthInfo{TokenFile: tokenPath})
+		if err != nil {
+			return nil, err
+		}
+		// rotates at the half of the token duration, since the client caches
+		// the token file for a minute
+		rotated, rotations := time.Now(), 0
+		r.rotate = func() error {
+			if time.Since(rotated) < ts.cfg.TokenDuration/2 {
+				return nil
+			}
+			token, err := ts.requestToken()
+			if err != nil {
+				return err
+			}
+			if err = writeFileAtomic(tokenPath, token); err != nil {
+				return err
+			}
+			rotated = time.Now()
+			r.mu.Lock()
+			rotations++
+			r.mu.Unlock()
+			ts.cfg.Logger.Info("rotated token file", zap.String("path", tokenPath), zap.Int("rotations", rotations))
+			return nil
+		}
+		r.refreshes = func() int { return rotations }
+		r.firstToken = func() (string, error) { return first, nil }
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.cli, err = client.New(&client.Config{
+		Logger:             ts.cfg.Logger,
+		KubectlDownloadURL: ccfg.KubectlDownloadURL,
+		KubectlPath:        ccfg.KubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+		ConfigMode:         client.ConfigModeKubeconfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// baseRestConfig returns the cluster configuration of the tester client.
+func (ts *tester) baseRestConfig() (*k8s_client_rest.Config, error) {
+	ccfg := ts.cfg.Client.Config()
+	if ccfg.KubeconfigPath == "" {
+		return nil, errors.New("empty KUBECONFIG of the tester client, required for the 'exec' and 'token-file' modes")
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: ccfg.KubeconfigPath},
+		&clientcmd.ConfigOverrides{CurrentContext: ccfg.KubeconfigContext},
+	).ClientConfig()
+}
+
+// writeKubeconfig writes the kubeconfig of the cluster, with the user credential.
+func writeKubeconfig(p string, base *k8s_client_rest.Config, namespace string, authInfo *clientcmd_api.AuthInfo) error {
+	kcfg := clientcmd_api.NewConfig()
+	kcfg.Clusters[pkgName] = &clientcmd_api.Cluster{
+		Server:                   base.Host,
+		TLSServerName:            base.ServerName,
+		InsecureSkipTLSVerify:    base.Insecure,
+		CertificateAuthority:     base.CAFile,
+		CertificateAuthorityData: base.CAData,
+	}
+	kcfg.AuthInfos[pkgName] = authInfo
+	kcfg.Contexts[pkgName] = &clientcmd_api.Context{
+		Cluster:   pkgName,
+		AuthInfo:  pkgName,
+		Namespace: namespace,
+	}
+	kcfg.CurrentContext = pkgName
+	return clientcmd.WriteToFile(*kcfg, p)
+}
+
+// execCredentialScript returns the kubeconfig credential plugin, that requests
+// the ServiceAccount token with the tester kubeconfig, appends the token to "tokensPath",
+// and prints the ExecCredential with the token expiration, for the client to refresh on expiry.
+// ref. https://kubernetes.io/docs/reference/access-authn-authz/authentication/#client-go-credential-plugins
+func execCredentialScript(kubectlPath string, kubeconfigPath string, kubeconfigContext string, namespace string, duration time.Duration, tokensPath string) string {
+	contextFlag := ""
+	if kubeconfigContext != "" {
+		contextFlag = fmt.Sprintf(" --context='%s'", kubeconfigContext)
+	}
+	return fmt.Sprintf(`#!/bin/sh
+set -e
+out=$('%s' --kubeconfig='%s'%s --namespace='%s' create token %s --duration=%ds -o go-template='{{.status.token}} {{.status.expirationTimestamp}}')
+echo "$out" >> '%s'
+set -- $out
+printf '{"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential","status":{"token":"%%s","expirationTimestamp":"%%s"}}\n' "$1" "$2"
+`, kubectlPath, kubeconfigPath, contextFlag, namespace, serviceAccountName, int(duration.Seconds()), tokensPath)
+}
+
+// requestToken returns a new ServiceAccount token of "TokenDuration".
+func (ts *tester) requestToken() (string, error) {
+	exp := int64(ts.cfg.TokenDuration.Seconds())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	tr, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ServiceAccounts(ts.cfg.Namespace).
+		CreateToken(
+			ctx,
+			serviceAccountName,
+			&authentication_v1.TokenRequest{
+				Spec: authentication_v1.TokenRequestSpec{ExpirationSeconds: &exp},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to request ServiceAccount token (%v)", err)
+	}
+	return tr.Status.Token, nil
+}
+
+// checkExpiredToken requests with the first token of the run, after its expiry,
+// and records if the server rejected it.
+func (ts *tester) checkExpiredToken(r *run) {
+	if r.firstToken == nil {
+		return
+	}
+	token, err := r.firstToken()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to get first token", zap.String("mode", r.mode), zap.Error(err))
+		return
+	}
+	base, err := ts.baseRestConfig()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to load cluster config", zap.Error(err))
+		return
+	}
+	kcfg := k8s_client_rest.AnonymousClientConfig(base)
+	kcfg.BearerToken = token
+	cli, err := k8s_client.NewForConfig(kcfg)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to create client with first token", zap.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	_, err = cli.CoreV1().ConfigMaps(ts.cfg.Namespace).List(ctx, meta_v1.ListOptions{Limit: 1})
+	cancel()
+	r.mu.Lock()
+	r.expiredRejected = k8s_errors.IsUnauthorized(err)
+	r.mu.Unlock()
+	ts.cfg.Logger.Info("requested with expired token", zap.String("mode", r.mode), zap.Bool("rejected", k8s_errors.IsUnauthorized(err)), zap.Error(err))
+}
+
+// createRBAC creates the ServiceAccount of the tokens, allowed to list the ConfigMaps of the namespace.
+func (ts *tester) createRBAC() error {
+	ts.cfg.Logger.Info("creating ServiceAccount and RBAC Role")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ServiceAccounts(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ServiceAccount{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serviceAccountName,
+					Namespace: ts.cfg.Namespace,
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create ServiceAccount (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		Roles(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&rbac_v1.Role{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "Role",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      roleName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Rules: []rbac_v1.PolicyRule{
+					{
+						APIGroups: []string{""},
+						Resources: []string{"configmaps"},
+						Verbs:     []string{"get", "list"},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create RBAC Role (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		RoleBindings(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&rbac_v1.RoleBinding{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "RoleBinding",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      roleBindingName,
+					Namespace: ts.cfg.Namespace,
+				},
+				RoleRef: rbac_v1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "Role",
+					Name:     roleName,
+				},
+				Subjects: []rbac_v1.Subject{
+					{
+						Kind:      "ServiceAccount",
+						Name:      serviceAccountName,
+						Namespace: ts.cfg.Namespace,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create RBAC RoleBinding (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created ServiceAccount and RBAC Role")
+	return nil
+}
+
+func writeFileAtomic(p string, data string) error {
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(data), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func readLines(p string) ([]string, error) {
+	d, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(d), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,392 @@
+// Package auth_refresh runs long-lived clients with expiring credentials,
+// and validates that the client package refreshes them without failing the requests,
+// since the soak runs must survive the credential expiry:
+//
+//	"exec": the kubeconfig exec credential plugin, that issues the ServiceAccount
+//	        tokens of "TokenDuration" with their expiration timestamps.
+//	"token-file": the kubeconfig token file, rotated before the token expires.
+//	"eks": the EKS auth provider of the client package (presigned STS tokens).
+//
+// The client certificates are not tested, since EKS does not sign the client certificates
+// (the "kubernetes.io/kube-apiserver-client" signer).
+package auth_refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// Region and ClusterName are the EKS cluster for the "eks" mode.
+	Region      string `json:"region"`
+	ClusterName string `json:"cluster_name" read-only:"true"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Modes is the list of the credential modes to test concurrently,
+	// "exec", "token-file", or "eks".
+	Modes []string `json:"modes"`
+	// TokenDuration is the ServiceAccount token duration of the "exec" and the "token-file" modes.
+	// The minimum is 10 minutes.
+	TokenDuration       time.Duration `json:"token_duration"`
+	TokenDurationString string        `json:"token_duration_string" read-only:"true"`
+	// Duration is the duration of the requests, longer than the token duration
+	// for the tokens to expire during the run.
+	Duration       time.Duration `json:"duration"`
+	DurationString string        `json:"duration_string" read-only:"true"`
+	// RequestInterval is the interval between the requests of each client.
+	RequestInterval       time.Duration `json:"request_interval"`
+	RequestIntervalString string        `json:"request_interval_string" read-only:"true"`
+	// MaxFailureRatio is the maximum ratio of the failed requests other than
+	// "Unauthorized" (e.g., network errors). Any "Unauthorized" request fails the test.
+	MaxFailureRatio float64 `json:"max_failure_ratio"`
+
+	// WorkDir is the directory of the generated kubeconfigs, the credential plugin, and the token file.
+	WorkDir string `json:"work_dir" read-only:"true"`
+	// Results is the result of each mode.
+	Results []ModeResult `json:"results" read-only:"true"`
+}
+
+// ModeResult is the result of the long-lived client of a credential mode.
+type ModeResult struct {
+	Mode         string `json:"mode"`
+	Requests     int64  `json:"requests"`
+	Failures     int64  `json:"failures"`
+	Unauthorized int64  `json:"unauthorized"`
+	// Refreshes is the number of the credentials issued after the first
+	// (e.g., the credential plugin runs, the token file rotations).
+	Refreshes int `json:"refreshes"`
+	// ExpiredTokenRejected is true if the first token was rejected after its expiry,
+	// that is the client did refresh the expired credential.
+	ExpiredTokenRejected bool   `json:"expired_token_rejected"`
+	LastError            string `json:"last_error"`
+}
+
+const (
+	ModeExec      = "exec"
+	ModeTokenFile = "token-file"
+	ModeEKS       = "eks"
+)
+
+func (cfg *Config) ValidateAndSetDefaults(clusterName string) error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if len(cfg.Modes) == 0 {
+		cfg.Modes = DefaultModes()
+	}
+	for _, mode := range cfg.Modes {
+		switch mode {
+		case ModeExec, ModeTokenFile:
+		case ModeEKS:
+			if cfg.Region == "" {
+				return errors.New("empty Region for the 'eks' mode")
+			}
+			if clusterName == "" {
+				return errors.New("empty ClusterName for the 'eks' mode")
+			}
+		default:
+			return fmt.Errorf("unknown mode %q (expected %q, %q, or %q)", mode, ModeExec, ModeTokenFile, ModeEKS)
+		}
+	}
+	cfg.ClusterName = clusterName
+	if cfg.TokenDuration == time.Duration(0) {
+		cfg.TokenDuration = DefaultTokenDuration
+	}
+	if cfg.TokenDuration < MinTokenDuration {
+		return fmt.Errorf("TokenDuration %v too short (< %v)", cfg.TokenDuration, MinTokenDuration)
+	}
+	cfg.TokenDurationString = cfg.TokenDuration.String()
+	if cfg.Duration == time.Duration(0) {
+		cfg.Duration = DefaultDuration
+	}
+	if cfg.Duration < cfg.TokenDuration+2*time.Minute {
+		return fmt.Errorf("Duration %v too short for the tokens to expire (< TokenDuration %v + 2m)", cfg.Duration, cfg.TokenDuration)
+	}
+	cfg.DurationString = cfg.Duration.String()
+	if cfg.RequestInterval == time.Duration(0) {
+		cfg.RequestInterval = DefaultRequestInterval
+	}
+	cfg.RequestIntervalString = cfg.RequestInterval.String()
+	if cfg.MaxFailureRatio < 0 || cfg.MaxFailureRatio > 1 {
+		return fmt.Errorf("invalid MaxFailureRatio %v", cfg.MaxFailureRatio)
+	}
+	return nil
+}
+
+const (
+	DefaultMinimumNodes int = 1
+	// MinTokenDuration is the minimum ServiceAccount token duration of the TokenRequest API.
+	MinTokenDuration       = 10 * time.Minute
+	DefaultTokenDuration   = MinTokenDuration
+	DefaultDuration        = 25 * time.Minute
+	DefaultRequestInterval = 5 * time.Second
+	DefaultMaxFailureRatio = 0.01
+)
+
+// DefaultModes returns the default modes, that do not require the AWS credentials.
+func DefaultModes() []string {
+	return []string{ModeExec, ModeTokenFile}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:          false,
+		Prompt:          false,
+		MinimumNodes:    DefaultMinimumNodes,
+		Namespace:       pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Modes:           DefaultModes(),
+		TokenDuration:   DefaultTokenDuration,
+		Duration:        DefaultDuration,
+		RequestInterval: DefaultRequestInterval,
+		MaxFailureRatio: DefaultMaxFailureRatio,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config, clusterName string) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+	if err := ts.createRBAC(); err != nil {
+		return err
+	}
+	dir, err := ioutil.TempDir(os.TempDir(), pkgName)
+	if err != nil {
+		return err
+	}
+	ts.cfg.WorkDir = dir
+
+	runs := make([]*run, 0, len(ts.cfg.Modes))
+	for _, mode := range ts.cfg.Modes {
+		r, err := ts.newRun(mode)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %q mode (%v)", mode, err)
+		}
+		runs = append(runs, r)
+	}
+
+	ts.cfg.Logger.Info("running long-lived clients", zap.Strings("modes", ts.cfg.Modes), zap.String("duration", ts.cfg.DurationString))
+	var wg sync.WaitGroup
+	for _, r := range runs {
+		wg.Add(1)
+		go func(r *run) {
+			defer wg.Done()
+			ts.runRequests(r)
+		}(r)
+	}
+	wg.Wait()
+
+	var errs []string
+	ts.cfg.Results = make([]ModeResult, 0, len(runs))
+	for _, r := range runs {
+		ts.checkExpiredToken(r)
+		res := r.result()
+		ts.cfg.Results = append(ts.cfg.Results, res)
+		fmt.Fprintf(ts.cfg.LogWriter, "\nauth-refresh %q requests: %d, failures: %d, unauthorized: %d, refreshes: %d, expired token rejected: %v\n",
+			res.Mode, res.Requests, res.Failures, res.Unauthorized, res.Refreshes, res.ExpiredTokenRejected)
+		if err := ts.checkResult(r, res); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	fmt.Fprintln(ts.cfg.LogWriter)
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// checkResult returns an error if the client of the mode failed on the credential expiry.
+func (ts *tester) checkResult(r *run, res ModeResult) error {
+	if res.Requests == 0 {
+		return fmt.Errorf("%q no request sent", res.Mode)
+	}
+	if res.Unauthorized > 0 {
+		return fmt.Errorf("%q %d of %d requests unauthorized (last error %q)", res.Mode, res.Unauthorized, res.Requests, res.LastError)
+	}
+	if ratio := float64(res.Failures) / float64(res.Requests); ratio > ts.cfg.MaxFailureRatio {
+		return fmt.Errorf("%q failure ratio %.4f > %.4f (last error %q)", res.Mode, ratio, ts.cfg.MaxFailureRatio, res.LastError)
+	}
+	if r.firstToken != nil {
+		if res.Refreshes == 0 {
+			return fmt.Errorf("%q credential never refreshed in %v", res.Mode, ts.cfg.Duration)
+		}
+		if !res.ExpiredTokenRejected {
+			return fmt.Errorf("%q expired token still accepted; the credential expiry was not exercised", res.Mode)
+		}
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if ts.cfg.WorkDir != "" {
+		if err := os.RemoveAll(ts.cfg.WorkDir); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to remove %q (%v)", ts.cfg.WorkDir, err))
+		}
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// runRequests lists the ConfigMaps with the client of the mode every "RequestInterval"
+// for "Duration", while the tokens of the mode expire and refresh.
+func (ts *tester) runRequests(r *run) {
+	ts.cfg.Logger.Info("starting long-lived client", zap.String("mode", r.mode))
+	start := time.Now()
+	for time.Since(start) < ts.cfg.Duration {
+		select {
+		case <-ts.cfg.Stopc:
+			ts.cfg.Logger.Warn("long-lived client aborted", zap.String("mode", r.mode))
+			return
+		case <-time.After(ts.cfg.RequestInterval):
+		}
+
+		if r.rotate != nil {
+			if err := r.rotate(); err != nil {
+				ts.cfg.Logger.Warn("failed to rotate token", zap.String("mode", r.mode), zap.Error(err))
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		_, err := r.cli.KubernetesClient().
+			CoreV1().
+			ConfigMaps(ts.cfg.Namespace).
+			List(ctx, meta_v1.ListOptions{Limit: 1})
+		cancel()
+		r.observe(err)
+		if err != nil {
+			ts.cfg.Logger.Warn("long-lived client request failed",
+				zap.String("mode", r.mode),
+				zap.Bool("unauthorized", k8s_errors.IsUnauthorized(err)),
+				zap.String("elapsed", time.Since(start).String()),
+				zap.Error(err),
+			)
+		}
+	}
+	ts.cfg.Logger.Info("finished long-lived client", zap.String("mode", r.mode))
+}
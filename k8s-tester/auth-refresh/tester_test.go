@@ -0,0 +1,127 @@
+package auth_refresh
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	k8s_client_rest "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmd_api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestValidateAndSetDefaults(t *testing.T) {
+	cfg := NewDefault()
+	if err := cfg.ValidateAndSetDefaults(""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TokenDurationString != "10m0s" || cfg.DurationString != "25m0s" {
+		t.Fatalf("unexpected durations %q, %q", cfg.TokenDurationString, cfg.DurationString)
+	}
+
+	cfg = NewDefault()
+	cfg.Modes = []string{ModeEKS}
+	if err := cfg.ValidateAndSetDefaults("test-cluster"); err == nil {
+		t.Fatal("expected error for 'eks' mode without region")
+	}
+	cfg.Region = "us-west-2"
+	if err := cfg.ValidateAndSetDefaults("test-cluster"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg = NewDefault()
+	cfg.TokenDuration = 5 * time.Minute
+	if err := cfg.ValidateAndSetDefaults(""); err == nil {
+		t.Fatal("expected error for short token duration")
+	}
+
+	cfg = NewDefault()
+	cfg.TokenDuration = 24 * time.Minute
+	if err := cfg.ValidateAndSetDefaults(""); err == nil {
+		t.Fatal("expected error for duration shorter than token duration")
+	}
+}
+
+func TestWriteKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "auth-refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "token-file.kubeconfig")
+	base := &k8s_client_rest.Config{
+		Host:            "https://test.example.com",
+		TLSClientConfig: k8s_client_rest.TLSClientConfig{CAData: []byte("test-ca")},
+	}
+	if err = writeKubeconfig(p, base, "test-namespace", &clientcmd_api.AuthInfo{TokenFile: filepath.Join(dir, "token")}); err != nil {
+		t.Fatal(err)
+	}
+
+	kcfg, err := clientcmd.LoadFromFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur := kcfg.Contexts[kcfg.CurrentContext]
+	if cur == nil || cur.Namespace != "test-namespace" {
+		t.Fatalf("unexpected context %+v", cur)
+	}
+	if cl := kcfg.Clusters[cur.Cluster]; cl.Server != base.Host || string(cl.CertificateAuthorityData) != "test-ca" {
+		t.Fatalf("unexpected cluster %+v", cl)
+	}
+	if ai := kcfg.AuthInfos[cur.AuthInfo]; ai.TokenFile != filepath.Join(dir, "token") {
+		t.Fatalf("unexpected user %+v", ai)
+	}
+}
+
+func TestExecCredentialScript(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "auth-refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// fake kubectl, that prints its arguments after the token and the expiration
+	kubectlPath := filepath.Join(dir, "kubectl")
+	if err = ioutil.WriteFile(kubectlPath, []byte("#!/bin/sh\necho \"test-token 2021-01-01T00:00:00Z $*\"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	tokensPath := filepath.Join(dir, "exec-tokens")
+	script := execCredentialScript(kubectlPath, "/tmp/kubeconfig", "", "test-namespace", 10*time.Minute, tokensPath)
+
+	for i := 0; i < 2; i++ {
+		out, err := exec.Command("sh", "-c", script).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v (%s)", err, out)
+		}
+		var cred struct {
+			Kind   string `json:"kind"`
+			Status struct {
+				Token               string `json:"token"`
+				ExpirationTimestamp string `json:"expirationTimestamp"`
+			} `json:"status"`
+		}
+		if err = json.Unmarshal(out, &cred); err != nil {
+			t.Fatalf("%v (%s)", err, out)
+		}
+		if cred.Kind != "ExecCredential" || cred.Status.Token != "test-token" || cred.Status.ExpirationTimestamp != "2021-01-01T00:00:00Z" {
+			t.Fatalf("unexpected credential %+v", cred)
+		}
+	}
+
+	tokens, err := readLines(tokensPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("expected 2 tokens, got %q", tokens)
+	}
+	if !strings.Contains(tokens[0], "--namespace=test-namespace create token auth-refresh --duration=600s") {
+		t.Fatalf("unexpected kubectl args %q", tokens[0])
+	}
+}
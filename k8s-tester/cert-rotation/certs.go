@@ -0,0 +1,158 @@
+package cert_rotation
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	certificates_v1 "k8s.io/api/certificates/v1"
+	core_v1 "k8s.io/api/core/v1"
+)
+
+// Kubelet certificates that can be rotated.
+const (
+	// CertificateClient is the kubelet client certificate to the API server,
+	// issued via TLS bootstrapping. Nodes authenticating with tokens
+	// (e.g., aws-iam-authenticator) do not have one.
+	CertificateClient = "client"
+	// CertificateServing is the kubelet serving certificate,
+	// issued when the kubelet "serverTLSBootstrap" is enabled.
+	CertificateServing = "serving"
+)
+
+// validCertificate returns true if the certificate kind is supported.
+func validCertificate(c string) bool {
+	return c == CertificateClient || c == CertificateServing
+}
+
+const kubeletPKIDir = "/var/lib/kubelet/pki"
+
+// certificatePath returns the symlink to the current kubelet certificate.
+// ref. https://kubernetes.io/docs/reference/access-authn-authz/kubelet-tls-bootstrapping/
+func certificatePath(kind string) string {
+	if kind == CertificateServing {
+		return filepath.Join(kubeletPKIDir, "kubelet-server-current.pem")
+	}
+	return filepath.Join(kubeletPKIDir, "kubelet-client-current.pem")
+}
+
+// signerName returns the CSR signer of the kubelet certificate.
+// ref. https://kubernetes.io/docs/reference/access-authn-authz/certificate-signing-requests/#kubernetes-signers
+func signerName(kind string) string {
+	if kind == CertificateServing {
+		return certificates_v1.KubeletServingSignerName
+	}
+	return certificates_v1.KubeAPIServerClientKubeletSignerName
+}
+
+// readCertificateScript prints the certificate file that the symlink points to,
+// or nothing if the certificate does not exist.
+func readCertificateScript(kind string) string {
+	return "readlink -f " + certificatePath(kind) + " 2>/dev/null || true"
+}
+
+// rotateScript removes the current certificate symlink and restarts kubelet,
+// so that kubelet requests a new certificate. "--no-block" returns
+// before the kubelet restart breaks the exec session.
+func rotateScript(kinds []string) string {
+	s := ""
+	for _, kind := range kinds {
+		s += "rm -f " + certificatePath(kind) + " && "
+	}
+	return s + "systemctl --no-block restart kubelet"
+}
+
+// hostCommand returns the command that runs the script in the host namespaces
+// from the privileged Pod with "hostPID".
+func hostCommand(script string) []string {
+	return []string{"nsenter", "--target", "1", "--mount", "--uts", "--ipc", "--net", "--pid", "--", "sh", "-c", script}
+}
+
+// nodeCSR returns true if the CSR is requested by the node kubelet for the signer after "since".
+// The client certificate requested with the bootstrap token has the "system:bootstrap:" user,
+// so only the signer and the creation time are matched for the client certificates.
+func nodeCSR(csr certificates_v1.CertificateSigningRequest, node string, signer string, since time.Time) bool {
+	if csr.Spec.SignerName != signer || csr.CreationTimestamp.Time.Before(since) {
+		return false
+	}
+	if signer == certificates_v1.KubeletServingSignerName {
+		return csr.Spec.Username == "system:node:"+node
+	}
+	return csr.Spec.Username == "system:node:"+node || strings.HasPrefix(csr.Spec.Username, "system:bootstrap:")
+}
+
+// csrStatus returns whether the CSR is approved, denied, and issued.
+func csrStatus(csr certificates_v1.CertificateSigningRequest) (approved bool, denied bool, issued bool) {
+	for _, cond := range csr.Status.Conditions {
+		if cond.Status != core_v1.ConditionTrue && cond.Status != "" {
+			continue
+		}
+		switch cond.Type {
+		case certificates_v1.CertificateApproved:
+			approved = true
+		case certificates_v1.CertificateDenied, certificates_v1.CertificateFailed:
+			denied = true
+		}
+	}
+	return approved, denied, len(csr.Status.Certificate) > 0
+}
+
+// RotationResult is the certificate rotation result of a node.
+type RotationResult struct {
+	// Node is the node name.
+	Node string `json:"node"`
+	// Certificate is the rotated certificate kind.
+	Certificate string `json:"certificate"`
+	// Skipped is true if the node does not have the certificate.
+	Skipped bool `json:"skipped"`
+	// OldPath is the certificate file before the rotation.
+	OldPath string `json:"old_path"`
+	// NewPath is the certificate file after the rotation.
+	NewPath string `json:"new_path"`
+	// CSRName is the CSR issued for the new certificate.
+	CSRName string `json:"csr_name"`
+	// Took is the duration from the rotation to the new certificate.
+	Took       time.Duration `json:"took"`
+	TookString string        `json:"took_string"`
+}
+
+const fargateComputeTypeLabel = "eks.amazonaws.com/compute-type"
+
+// rotatableNodes returns the names of the ready Linux EC2 nodes, excluding Fargate nodes.
+func rotatableNodes(nodes []core_v1.Node) (names []string) {
+	for _, node := range nodes {
+		if node.Spec.Unschedulable ||
+			node.Labels[fargateComputeTypeLabel] == "fargate" ||
+			node.Labels["kubernetes.io/os"] == "windows" {
+			continue
+		}
+		if nodeReady(node) {
+			names = append(names, node.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func nodeReady(node core_v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == core_v1.NodeReady {
+			return cond.Status == core_v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// workloadImpact returns the workload Pods on the node that were recreated or restarted,
+// comparing the Pod UIDs and container restart counts before and after the rotation.
+func workloadImpact(before map[string]int32, after map[string]int32) (impacted []string) {
+	for uid, restarts := range before {
+		v, ok := after[uid]
+		if !ok || v != restarts {
+			impacted = append(impacted, uid)
+		}
+	}
+	sort.Strings(impacted)
+	return impacted
+}
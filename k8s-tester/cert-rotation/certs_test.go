@@ -0,0 +1,83 @@
+package cert_rotation
+
+import (
+	"testing"
+	"time"
+
+	certificates_v1 "k8s.io/api/certificates/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScripts(t *testing.T) {
+	if p := certificatePath(CertificateServing); p != "/var/lib/kubelet/pki/kubelet-server-current.pem" {
+		t.Fatalf("unexpected path %q", p)
+	}
+	if p := certificatePath(CertificateClient); p != "/var/lib/kubelet/pki/kubelet-client-current.pem" {
+		t.Fatalf("unexpected path %q", p)
+	}
+	s := rotateScript([]string{CertificateClient, CertificateServing})
+	expected := "rm -f " + certificatePath(CertificateClient) + " && rm -f " + certificatePath(CertificateServing) + " && systemctl --no-block restart kubelet"
+	if s != expected {
+		t.Fatalf("expected %q, got %q", expected, s)
+	}
+	if !validCertificate(CertificateClient) || validCertificate("ca") {
+		t.Fatal("unexpected validCertificate")
+	}
+}
+
+func TestNodeCSR(t *testing.T) {
+	since := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	csr := func(user string, signer string, created time.Time) certificates_v1.CertificateSigningRequest {
+		return certificates_v1.CertificateSigningRequest{
+			ObjectMeta: meta_v1.ObjectMeta{CreationTimestamp: meta_v1.NewTime(created)},
+			Spec:       certificates_v1.CertificateSigningRequestSpec{Username: user, SignerName: signer},
+		}
+	}
+	tests := []struct {
+		csr      certificates_v1.CertificateSigningRequest
+		signer   string
+		expected bool
+	}{
+		{csr("system:node:a", certificates_v1.KubeletServingSignerName, since.Add(time.Second)), certificates_v1.KubeletServingSignerName, true},
+		{csr("system:node:b", certificates_v1.KubeletServingSignerName, since.Add(time.Second)), certificates_v1.KubeletServingSignerName, false},
+		{csr("system:node:a", certificates_v1.KubeletServingSignerName, since.Add(-time.Second)), certificates_v1.KubeletServingSignerName, false},
+		{csr("system:node:a", certificates_v1.KubeletServingSignerName, since.Add(time.Second)), certificates_v1.KubeAPIServerClientKubeletSignerName, false},
+		{csr("system:bootstrap:abcdef", certificates_v1.KubeAPIServerClientKubeletSignerName, since.Add(time.Second)), certificates_v1.KubeAPIServerClientKubeletSignerName, true},
+		{csr("system:node:a", certificates_v1.KubeAPIServerClientKubeletSignerName, since.Add(time.Second)), certificates_v1.KubeAPIServerClientKubeletSignerName, true},
+		{csr("admin", certificates_v1.KubeAPIServerClientKubeletSignerName, since.Add(time.Second)), certificates_v1.KubeAPIServerClientKubeletSignerName, false},
+	}
+	for i, tt := range tests {
+		if v := nodeCSR(tt.csr, "a", tt.signer, since); v != tt.expected {
+			t.Fatalf("#%d: expected %v, got %v", i, tt.expected, v)
+		}
+	}
+}
+
+func TestCSRStatus(t *testing.T) {
+	csr := certificates_v1.CertificateSigningRequest{}
+	if approved, denied, issued := csrStatus(csr); approved || denied || issued {
+		t.Fatalf("unexpected status %v %v %v", approved, denied, issued)
+	}
+	csr.Status.Conditions = []certificates_v1.CertificateSigningRequestCondition{{Type: certificates_v1.CertificateApproved, Status: core_v1.ConditionTrue}}
+	csr.Status.Certificate = []byte("cert")
+	if approved, denied, issued := csrStatus(csr); !approved || denied || !issued {
+		t.Fatalf("unexpected status %v %v %v", approved, denied, issued)
+	}
+	csr.Status.Conditions = []certificates_v1.CertificateSigningRequestCondition{{Type: certificates_v1.CertificateDenied}}
+	if _, denied, _ := csrStatus(csr); !denied {
+		t.Fatal("expected denied")
+	}
+}
+
+func TestWorkloadImpact(t *testing.T) {
+	before := map[string]int32{"uid-1": 0, "uid-2": 1, "uid-3": 0}
+	after := map[string]int32{"uid-1": 0, "uid-2": 2, "uid-4": 0}
+	impacted := workloadImpact(before, after)
+	if len(impacted) != 2 || impacted[0] != "uid-2" || impacted[1] != "uid-3" {
+		t.Fatalf("unexpected impacted %v", impacted)
+	}
+	if impacted = workloadImpact(before, before); len(impacted) != 0 {
+		t.Fatalf("unexpected impacted %v", impacted)
+	}
+}
@@ -0,0 +1,175 @@
+// k8s-tester-cert-rotation installs Kubernetes kubelet certificate rotation tester.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	cert_rotation "github.com/aws/aws-k8s-tester/k8s-tester/cert-rotation"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-cert-rotation",
+	Short:      "Kubernetes kubelet certificate rotation tester",
+	SuggestFor: []string{"cert-rotation"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", cert_rotation.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-cert-rotation failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	certificates     []string
+	nodes            int
+	approveCSRs      bool
+	rotatorImage     string
+	workloadImage    string
+	workloadReplicas int32
+	rotationTimeout  time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringSliceVar(&certificates, "certificates", cert_rotation.DefaultCertificates(), "kubelet certificates to rotate (client, serving)")
+	cmd.PersistentFlags().IntVar(&nodes, "nodes", cert_rotation.DefaultNodes, "number of nodes to rotate the certificates")
+	cmd.PersistentFlags().BoolVar(&approveCSRs, "approve-csrs", cert_rotation.DefaultApproveCSRs, "true to approve the kubelet CSRs from the rotated nodes")
+	cmd.PersistentFlags().StringVar(&rotatorImage, "rotator-image", cert_rotation.DefaultRotatorImage, "privileged DaemonSet image with nsenter")
+	cmd.PersistentFlags().StringVar(&workloadImage, "workload-image", cert_rotation.DefaultWorkloadImage, "workload Pod image")
+	cmd.PersistentFlags().Int32Var(&workloadReplicas, "workload-replicas", cert_rotation.DefaultWorkloadReplicas, "number of workload Pods running during the rotation")
+	cmd.PersistentFlags().DurationVar(&rotationTimeout, "rotation-timeout", cert_rotation.DefaultRotationTimeout, "timeout for the new certificates to be issued and the nodes to be ready")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &cert_rotation.Config{
+		Prompt:           prompt,
+		Logger:           lg,
+		LogWriter:        logWriter,
+		MinimumNodes:     minimumNodes,
+		Namespace:        namespace,
+		Client:           cli,
+		Certificates:     certificates,
+		Nodes:            nodes,
+		ApproveCSRs:      approveCSRs,
+		RotatorImage:     rotatorImage,
+		WorkloadImage:    workloadImage,
+		WorkloadReplicas: workloadReplicas,
+		RotationTimeout:  rotationTimeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := cert_rotation.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-cert-rotation apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &cert_rotation.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := cert_rotation.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-cert-rotation delete' success\n")
+}
@@ -0,0 +1,723 @@
+// Package cert_rotation rotates the kubelet client and serving certificates
+// on a subset of self-managed EC2 nodes via a privileged DaemonSet, and validates
+// the nodes re-register with the newly issued certificates without workload impact.
+// ref. https://kubernetes.io/docs/tasks/tls/certificate-rotation/
+package cert_rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	certificates_v1 "k8s.io/api/certificates/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Certificates is the list of kubelet certificates to rotate ("client", "serving").
+	// Nodes without the certificate are skipped.
+	Certificates []string `json:"certificates"`
+	// Nodes is the number of nodes to rotate the certificates.
+	Nodes int `json:"nodes"`
+	// ApproveCSRs is true to approve the kubelet CSRs from the rotated nodes,
+	// for the clusters without the CSR approver (e.g., kubelet serving certificates
+	// on self-managed clusters).
+	ApproveCSRs bool `json:"approve_csrs"`
+
+	// RotatorImage is the privileged DaemonSet image with "nsenter".
+	RotatorImage string `json:"rotator_image"`
+	// WorkloadImage is the workload Pod image.
+	WorkloadImage string `json:"workload_image"`
+	// WorkloadReplicas is the number of workload Pods running during the rotation.
+	WorkloadReplicas int32 `json:"workload_replicas"`
+
+	// RotationTimeout is the timeout for the new certificates to be issued
+	// and the nodes to be ready after the rotation.
+	RotationTimeout       time.Duration `json:"rotation_timeout"`
+	RotationTimeoutString string        `json:"rotation_timeout_string" read-only:"true"`
+
+	// Results is the list of the rotation results per node and certificate.
+	Results []RotationResult `json:"results" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if len(cfg.Certificates) == 0 {
+		cfg.Certificates = DefaultCertificates()
+	}
+	for _, c := range cfg.Certificates {
+		if !validCertificate(c) {
+			return fmt.Errorf("invalid certificate %q", c)
+		}
+	}
+	if cfg.Nodes <= 0 {
+		cfg.Nodes = DefaultNodes
+	}
+	if cfg.RotatorImage == "" {
+		cfg.RotatorImage = DefaultRotatorImage
+	}
+	if cfg.WorkloadImage == "" {
+		cfg.WorkloadImage = DefaultWorkloadImage
+	}
+	if cfg.WorkloadReplicas <= 0 {
+		cfg.WorkloadReplicas = DefaultWorkloadReplicas
+	}
+	if cfg.RotationTimeout == time.Duration(0) {
+		cfg.RotationTimeout = DefaultRotationTimeout
+	}
+	cfg.RotationTimeoutString = cfg.RotationTimeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes     int   = 1
+	DefaultNodes            int   = 1
+	DefaultApproveCSRs            = false
+	DefaultRotatorImage           = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultWorkloadImage          = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	DefaultWorkloadReplicas int32 = 5
+	DefaultRotationTimeout        = 5 * time.Minute
+)
+
+// DefaultCertificates returns the kubelet certificates rotated by default.
+func DefaultCertificates() []string {
+	return []string{CertificateClient, CertificateServing}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Certificates:     DefaultCertificates(),
+		Nodes:            DefaultNodes,
+		ApproveCSRs:      DefaultApproveCSRs,
+		RotatorImage:     DefaultRotatorImage,
+		WorkloadImage:    DefaultWorkloadImage,
+		WorkloadReplicas: DefaultWorkloadReplicas,
+		RotationTimeout:  DefaultRotationTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	rotatorName  = "rotator"
+	workloadName = "workload"
+	appLabel     = "app.kubernetes.io/name"
+)
+
+func (ts *tester) Apply() (err error) {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	all := rotatableNodes(nodes)
+	selected := all
+	if ts.cfg.Nodes < len(all) {
+		selected = all[:ts.cfg.Nodes]
+	}
+	if len(selected) == 0 {
+		return errors.New("no rotatable node found")
+	}
+	ts.cfg.Logger.Info("selected nodes to rotate certificates", zap.Strings("nodes", selected), zap.Strings("certificates", ts.cfg.Certificates))
+
+	if err = client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+	if err = ts.createWorkload(); err != nil {
+		return err
+	}
+	if err = ts.waitForWorkload(); err != nil {
+		return err
+	}
+	if err = ts.createRotator(selected); err != nil {
+		return err
+	}
+	rotators, err := ts.waitForRotators(selected)
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Results = make([]RotationResult, 0, len(selected)*len(ts.cfg.Certificates))
+	var errs []string
+	for _, node := range selected {
+		if err = ts.rotate(node, rotators[node]); err != nil {
+			errs = append(errs, err.Error())
+		}
+		// the rotator Pod may be recreated after the kubelet restart
+		if rotators, err = ts.waitForRotators(selected); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nkubelet certificate rotation results:\n\n")
+	for _, rs := range ts.cfg.Results {
+		if rs.Skipped {
+			fmt.Fprintf(ts.cfg.LogWriter, "  %-50s %-8s skipped (no certificate)\n", rs.Node, rs.Certificate)
+			continue
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "  %-50s %-8s %q -> %q (CSR %q, took %s)\n", rs.Node, rs.Certificate, rs.OldPath, rs.NewPath, rs.CSRName, rs.TookString)
+	}
+	fmt.Fprintln(ts.cfg.LogWriter)
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createWorkload() error {
+	ts.cfg.Logger.Info("creating workload Deployment", zap.Int32("replicas", ts.cfg.WorkloadReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      workloadName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &ts.cfg.WorkloadReplicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{appLabel: workloadName},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{appLabel: workloadName},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            workloadName,
+									Image:           ts.cfg.WorkloadImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+							// spread the workloads, so that the rotated nodes run some of them
+							TopologySpreadConstraints: []core_v1.TopologySpreadConstraint{
+								{
+									MaxSkew:           1,
+									TopologyKey:       "kubernetes.io/hostname",
+									WhenUnsatisfiable: core_v1.ScheduleAnyway,
+									LabelSelector: &meta_v1.LabelSelector{
+										MatchLabels: map[string]string{appLabel: workloadName},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("Deployment already exists", zap.String("name", workloadName))
+			return nil
+		}
+		return fmt.Errorf("failed to create Deployment %q (%v)", workloadName, err)
+	}
+	return nil
+}
+
+func (ts *tester) waitForWorkload() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.RotationTimeout)
+	_, err := client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		10*time.Second,
+		ts.cfg.Namespace,
+		workloadName,
+		ts.cfg.WorkloadReplicas,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("Deployment %q not available (%v)", workloadName, err)
+	}
+	return nil
+}
+
+// createRotator creates the privileged DaemonSet with "hostPID"
+// on the selected nodes, to run the commands in the host namespaces.
+func (ts *tester) createRotator(nodes []string) error {
+	ts.cfg.Logger.Info("creating rotator DaemonSet", zap.Strings("nodes", nodes))
+	privileged := true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      rotatorName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{appLabel: rotatorName},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{appLabel: rotatorName},
+						},
+						Spec: core_v1.PodSpec{
+							HostPID:       true,
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            rotatorName,
+									Image:           ts.cfg.RotatorImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"sleep", "2147483647"},
+									SecurityContext: &core_v1.SecurityContext{
+										Privileged: &privileged,
+									},
+								},
+							},
+							Tolerations: []core_v1.Toleration{
+								{Operator: core_v1.TolerationOpExists},
+							},
+							Affinity: &core_v1.Affinity{
+								NodeAffinity: &core_v1.NodeAffinity{
+									RequiredDuringSchedulingIgnoredDuringExecution: &core_v1.NodeSelector{
+										NodeSelectorTerms: []core_v1.NodeSelectorTerm{
+											{
+												MatchFields: []core_v1.NodeSelectorRequirement{
+													{
+														Key:      "metadata.name",
+														Operator: core_v1.NodeSelectorOpIn,
+														Values:   nodes,
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("DaemonSet already exists", zap.String("name", rotatorName))
+			return nil
+		}
+		return fmt.Errorf("failed to create DaemonSet %q (%v)", rotatorName, err)
+	}
+	return nil
+}
+
+// waitForRotators returns the ready rotator Pod names by node name.
+func (ts *tester) waitForRotators(nodes []string) (map[string]string, error) {
+	deadline := time.Now().Add(ts.cfg.RotationTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, errors.New("waiting for rotator Pods aborted")
+		case <-time.After(5 * time.Second):
+		}
+		pods, err := client.ListPods(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, 1000, 5*time.Second)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Pods", zap.Error(err))
+			continue
+		}
+		rotators := make(map[string]string)
+		for _, pod := range pods {
+			if pod.Labels[appLabel] != rotatorName || pod.DeletionTimestamp != nil || !podReady(pod) {
+				continue
+			}
+			rotators[pod.Spec.NodeName] = pod.Name
+		}
+		ready := 0
+		for _, node := range nodes {
+			if _, ok := rotators[node]; ok {
+				ready++
+			}
+		}
+		if ready == len(nodes) {
+			return rotators, nil
+		}
+		ts.cfg.Logger.Info("waiting for rotator Pods", zap.Int("ready", ready), zap.Int("nodes", len(nodes)))
+	}
+	return nil, fmt.Errorf("rotator Pods not ready within %v", ts.cfg.RotationTimeout)
+}
+
+func podReady(pod core_v1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == core_v1.PodReady {
+			return cond.Status == core_v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// waitForNodes waits for the rotated nodes to be ready.
+func (ts *tester) waitForNodes(nodes []string) error {
+	deadline := time.Now().Add(ts.cfg.RotationTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("waiting for nodes aborted")
+		case <-time.After(5 * time.Second):
+		}
+		var notReady []string
+		for _, name := range nodes {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			node, err := ts.cfg.Client.KubernetesClient().CoreV1().Nodes().Get(ctx, name, meta_v1.GetOptions{})
+			cancel()
+			if err != nil || !nodeReady(*node) {
+				notReady = append(notReady, name)
+			}
+		}
+		if len(notReady) == 0 {
+			return nil
+		}
+		ts.cfg.Logger.Info("waiting for nodes to be ready", zap.Strings("not-ready", notReady))
+	}
+	return fmt.Errorf("nodes %q not ready within %v", nodes, ts.cfg.RotationTimeout)
+}
+
+// hostExec runs the script in the host namespaces via the rotator Pod.
+func (ts *tester) hostExec(podName string, script string) (string, error) {
+	args := append([]string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"exec",
+		podName,
+		"--",
+	}, hostCommand(script)...)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	out, err := exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	cancel()
+	if err != nil {
+		return string(out), fmt.Errorf("failed to exec %q on Pod %q (%v, output %q)", script, podName, err, string(out))
+	}
+	return string(out), nil
+}
+
+// readCertificate returns the certificate file that the symlink points to,
+// or an empty string if the certificate does not exist.
+func (ts *tester) readCertificate(podName string, kind string) (string, error) {
+	out, err := ts.hostExec(podName, readCertificateScript(kind))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// workloadRestarts returns the container restart counts of the workload Pods on the node by Pod UID.
+func (ts *tester) workloadRestarts(node string) (map[string]int32, error) {
+	pods, err := client.ListPods(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, 1000, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Pods (%v)", err)
+	}
+	restarts := make(map[string]int32)
+	for _, pod := range pods {
+		if pod.Labels[appLabel] != workloadName || pod.Spec.NodeName != node {
+			continue
+		}
+		var n int32
+		for _, cs := range pod.Status.ContainerStatuses {
+			n += cs.RestartCount
+		}
+		restarts[string(pod.UID)] = n
+	}
+	return restarts, nil
+}
+
+// rotate removes the current certificates on the node and restarts kubelet,
+// waits for the new certificates to be issued, and checks the workload impact.
+func (ts *tester) rotate(node string, podName string) error {
+	var (
+		kinds   []string
+		results = make(map[string]*RotationResult)
+	)
+	for _, kind := range ts.cfg.Certificates {
+		old, err := ts.readCertificate(podName, kind)
+		if err != nil {
+			return err
+		}
+		if old == "" {
+			ts.cfg.Logger.Info("no kubelet certificate; skipping", zap.String("node", node), zap.String("certificate", kind))
+			ts.cfg.Results = append(ts.cfg.Results, RotationResult{Node: node, Certificate: kind, Skipped: true})
+			continue
+		}
+		kinds = append(kinds, kind)
+		results[kind] = &RotationResult{Node: node, Certificate: kind, OldPath: old}
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+
+	before, err := ts.workloadRestarts(node)
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("rotating kubelet certificates", zap.String("node", node), zap.Strings("certificates", kinds))
+	start := time.Now()
+	// allow the clock skew between the tester and the API server
+	since := start.Add(-30 * time.Second)
+	if _, err = ts.hostExec(podName, rotateScript(kinds)); err != nil {
+		return err
+	}
+
+	pending := make(map[string]struct{}, len(kinds))
+	for _, kind := range kinds {
+		pending[kind] = struct{}{}
+	}
+	deadline := start.Add(ts.cfg.RotationTimeout)
+	for len(pending) > 0 && time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("certificate rotation aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		csrs, err := ts.cfg.Client.KubernetesClient().CertificatesV1().CertificateSigningRequests().List(ctx, meta_v1.ListOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list CSRs", zap.Error(err))
+			continue
+		}
+		for kind := range pending {
+			rs := results[kind]
+			for _, csr := range csrs.Items {
+				if !nodeCSR(csr, node, signerName(kind), since) {
+					continue
+				}
+				approved, denied, issued := csrStatus(csr)
+				if denied {
+					return fmt.Errorf("CSR %q for the node %q %s certificate denied or failed", csr.Name, node, kind)
+				}
+				if !approved && ts.cfg.ApproveCSRs {
+					if err = ts.approve(csr); err != nil {
+						ts.cfg.Logger.Warn("failed to approve CSR", zap.String("name", csr.Name), zap.Error(err))
+					}
+				}
+				if issued {
+					rs.CSRName = csr.Name
+				}
+			}
+			if rs.CSRName == "" {
+				continue
+			}
+			cur, err := ts.readCertificate(podName, kind)
+			if err != nil {
+				ts.cfg.Logger.Info("failed to read kubelet certificate; retrying", zap.String("node", node), zap.Error(err))
+				continue
+			}
+			if cur == "" || cur == rs.OldPath {
+				continue
+			}
+			rs.NewPath = cur
+			rs.Took = time.Since(start)
+			rs.TookString = rs.Took.String()
+			ts.cfg.Logger.Info("rotated kubelet certificate",
+				zap.String("node", node),
+				zap.String("certificate", kind),
+				zap.String("csr", rs.CSRName),
+				zap.String("took", rs.TookString),
+			)
+			delete(pending, kind)
+		}
+	}
+	for _, kind := range kinds {
+		ts.cfg.Results = append(ts.cfg.Results, *results[kind])
+	}
+	if len(pending) > 0 {
+		var ss []string
+		for kind := range pending {
+			ss = append(ss, kind)
+		}
+		return fmt.Errorf("kubelet %q certificates on the node %q not rotated within %v", ss, node, ts.cfg.RotationTimeout)
+	}
+
+	if err = ts.waitForNodes([]string{node}); err != nil {
+		return err
+	}
+	if err = ts.waitForWorkload(); err != nil {
+		return err
+	}
+	after, err := ts.workloadRestarts(node)
+	if err != nil {
+		return err
+	}
+	if impacted := workloadImpact(before, after); len(impacted) > 0 {
+		return fmt.Errorf("%d workload Pod(s) on the node %q recreated or restarted by the rotation (UIDs %q)", len(impacted), node, impacted)
+	}
+	return nil
+}
+
+func (ts *tester) approve(csr certificates_v1.CertificateSigningRequest) error {
+	ts.cfg.Logger.Info("approving CSR", zap.String("name", csr.Name), zap.String("username", csr.Spec.Username))
+	csr.Status.Conditions = append(csr.Status.Conditions, certificates_v1.CertificateSigningRequestCondition{
+		Type:           certificates_v1.CertificateApproved,
+		Status:         core_v1.ConditionTrue,
+		Reason:         "K8sTesterApprove",
+		Message:        "approved by k8s-tester cert-rotation",
+		LastUpdateTime: meta_v1.Now(),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	_, err := ts.cfg.Client.KubernetesClient().CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, &csr, meta_v1.UpdateOptions{})
+	cancel()
+	return err
+}
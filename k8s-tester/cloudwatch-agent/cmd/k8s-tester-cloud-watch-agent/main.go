@@ -4,6 +4,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/client"
 	cloudwatch_agent "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-agent"
@@ -58,8 +59,12 @@ func main() {
 }
 
 var (
-	region      string
-	clusterName string
+	partition                string
+	region                   string
+	clusterName              string
+	checkMetrics             bool
+	containerInsightsMetrics []string
+	metricsTimeout           time.Duration
 )
 
 func newApply() *cobra.Command {
@@ -69,8 +74,12 @@ func newApply() *cobra.Command {
 		Run:   createApplyFunc,
 	}
 
+	cmd.PersistentFlags().StringVar(&partition, "partition", "aws", "partition")
 	cmd.PersistentFlags().StringVar(&region, "region", "", "region")
 	cmd.PersistentFlags().StringVar(&clusterName, "cluster-name", "", "cluster name")
+	cmd.PersistentFlags().BoolVar(&checkMetrics, "check-metrics", false, "'true' to verify the Container Insights metrics arrive in CloudWatch")
+	cmd.PersistentFlags().StringSliceVar(&containerInsightsMetrics, "container-insights-metrics", cloudwatch_agent.DefaultContainerInsightsMetrics(), "Container Insights metric names to check")
+	cmd.PersistentFlags().DurationVar(&metricsTimeout, "metrics-timeout", cloudwatch_agent.DefaultMetricsTimeout, "timeout for the Container Insights metrics to arrive")
 
 	return cmd
 }
@@ -101,8 +110,18 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		MinimumNodes: minimumNodes,
 		Namespace:    namespace,
 		Client:       cli,
+		Partition:    partition,
 		Region:       region,
 		ClusterName:  clusterName,
+
+		CheckMetrics:             checkMetrics,
+		ContainerInsightsMetrics: containerInsightsMetrics,
+		MetricsTimeout:           metricsTimeout,
+	}
+	if cfg.CheckMetrics {
+		if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+			lg.Panic("failed to validate config", zap.Error(err))
+		}
 	}
 
 	ts := cloudwatch_agent.New(cfg)
@@ -0,0 +1,107 @@
+package cloudwatch_agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"go.uber.org/zap"
+)
+
+// containerInsightsNamespace is the CloudWatch namespace of the Container Insights metrics.
+// ref. https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/Container-Insights-metrics-EKS.html
+const containerInsightsNamespace = "ContainerInsights"
+
+// metricDataQueries returns the queries for the cluster-level Container Insights metrics,
+// with the query IDs "m0", "m1", ... in the order of the metric names.
+func metricDataQueries(clusterName string, metricNames []string) []*cloudwatch.MetricDataQuery {
+	queries := make([]*cloudwatch.MetricDataQuery, 0, len(metricNames))
+	for i, name := range metricNames {
+		queries = append(queries, &cloudwatch.MetricDataQuery{
+			Id: aws.String(fmt.Sprintf("m%d", i)),
+			MetricStat: &cloudwatch.MetricStat{
+				Metric: &cloudwatch.Metric{
+					Namespace:  aws.String(containerInsightsNamespace),
+					MetricName: aws.String(name),
+					Dimensions: []*cloudwatch.Dimension{
+						{Name: aws.String("ClusterName"), Value: aws.String(clusterName)},
+					},
+				},
+				Period: aws.Int64(60),
+				Stat:   aws.String(cloudwatch.StatisticAverage),
+			},
+			ReturnData: aws.Bool(true),
+		})
+	}
+	return queries
+}
+
+// missingMetrics returns the metric names without any datapoint in the results.
+func missingMetrics(results []*cloudwatch.MetricDataResult, metricNames []string) (missing []string) {
+	found := make(map[string]bool, len(results))
+	for _, rs := range results {
+		if len(rs.Values) > 0 {
+			found[aws.StringValue(rs.Id)] = true
+		}
+	}
+	for i, name := range metricNames {
+		if !found[fmt.Sprintf("m%d", i)] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// checkMetrics waits for the Container Insights metrics of the cluster to arrive in CloudWatch.
+func (ts *tester) checkMetrics() error {
+	ts.cfg.Logger.Info("checking Container Insights metrics",
+		zap.String("cluster-name", ts.cfg.ClusterName),
+		zap.Strings("metrics", ts.cfg.ContainerInsightsMetrics),
+		zap.String("timeout", ts.cfg.MetricsTimeoutString),
+	)
+	start := time.Now()
+	queries := metricDataQueries(ts.cfg.ClusterName, ts.cfg.ContainerInsightsMetrics)
+	missing := ts.cfg.ContainerInsightsMetrics
+	for time.Since(start) < ts.cfg.MetricsTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Container Insights metrics check aborted")
+		case <-time.After(30 * time.Second):
+		}
+
+		now := time.Now()
+		var results []*cloudwatch.MetricDataResult
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := ts.cfg.CWAPI.GetMetricDataPagesWithContext(
+			ctx,
+			&cloudwatch.GetMetricDataInput{
+				MetricDataQueries: queries,
+				StartTime:         aws.Time(start.Add(-5 * time.Minute)),
+				EndTime:           aws.Time(now),
+			},
+			func(out *cloudwatch.GetMetricDataOutput, lastPage bool) bool {
+				results = append(results, out.MetricDataResults...)
+				return true
+			},
+		)
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get metric data", zap.Error(err))
+			continue
+		}
+		missing = missingMetrics(results, ts.cfg.ContainerInsightsMetrics)
+		if len(missing) == 0 {
+			ts.cfg.MetricsArrival = time.Since(start)
+			ts.cfg.MetricsArrivalString = ts.cfg.MetricsArrival.String()
+			fmt.Fprintf(ts.cfg.LogWriter, "\nContainer Insights metrics %q arrived for the cluster %q in %v\n\n", ts.cfg.ContainerInsightsMetrics, ts.cfg.ClusterName, ts.cfg.MetricsArrival)
+			return nil
+		}
+		ts.cfg.Logger.Info("waiting for Container Insights metrics", zap.Strings("missing", missing), zap.String("elapsed", time.Since(start).String()))
+	}
+	return fmt.Errorf("Container Insights metrics %q not found for the cluster %q within %v", missing, ts.cfg.ClusterName, ts.cfg.MetricsTimeout)
+}
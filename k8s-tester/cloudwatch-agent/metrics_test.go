@@ -0,0 +1,40 @@
+package cloudwatch_agent
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestMetricDataQueries(t *testing.T) {
+	names := []string{"node_cpu_utilization", "pod_memory_utilization"}
+	queries := metricDataQueries("test-cluster", names)
+	if len(queries) != 2 {
+		t.Fatalf("unexpected queries %v", queries)
+	}
+	for i, q := range queries {
+		if err := q.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if aws.StringValue(q.MetricStat.Metric.MetricName) != names[i] {
+			t.Fatalf("#%d: unexpected metric name %q", i, aws.StringValue(q.MetricStat.Metric.MetricName))
+		}
+		if aws.StringValue(q.MetricStat.Metric.Dimensions[0].Value) != "test-cluster" {
+			t.Fatalf("#%d: unexpected dimensions %v", i, q.MetricStat.Metric.Dimensions)
+		}
+	}
+
+	results := []*cloudwatch.MetricDataResult{
+		{Id: queries[0].Id, Values: aws.Float64Slice([]float64{1.5})},
+		{Id: queries[1].Id},
+	}
+	if missing := missingMetrics(results, names); !reflect.DeepEqual(missing, []string{"pod_memory_utilization"}) {
+		t.Fatalf("unexpected missing %v", missing)
+	}
+	results[1].Values = aws.Float64Slice([]float64{10})
+	if missing := missingMetrics(results, names); len(missing) != 0 {
+		t.Fatalf("unexpected missing %v", missing)
+	}
+}
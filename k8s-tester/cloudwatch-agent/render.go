@@ -0,0 +1,44 @@
+package cloudwatch_agent
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// NewRenderer returns the tester to render the manifests, without the AWS clients.
+func NewRenderer(cfg *Config) k8s_tester.Tester {
+	return &tester{cfg: cfg}
+}
+
+// Render writes the ServiceAccount, the RBAC, the ConfigMap and the DaemonSet, against a fake client.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createServiceAccount(); err != nil {
+		return err
+	}
+	if err := rt.createRBACClusterRole(); err != nil {
+		return err
+	}
+	if err := rt.createRBACClusterRoleBinding(); err != nil {
+		return err
+	}
+	if err := rt.createConfigMapConfig(); err != nil {
+		return err
+	}
+	if err := rt.createDaemonSet(); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -18,10 +19,15 @@ import (
 
 	"github.com/aws/aws-k8s-tester/client"
 	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
 	"github.com/aws/aws-k8s-tester/utils/rand"
 	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/manifoldco/promptui"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	apps_v1 "k8s.io/api/apps/v1"
 	core_v1 "k8s.io/api/core/v1"
 	rbac_v1 "k8s.io/api/rbac/v1"
@@ -40,6 +46,7 @@ type Config struct {
 	LogWriter io.Writer     `json:"-"`
 	Client    client.Client `json:"-"`
 
+	Partition   string `json:"partition"`
 	Region      string `json:"region"`
 	ClusterName string `json:"cluster_name" read-only:"true"`
 
@@ -47,6 +54,24 @@ type Config struct {
 	MinimumNodes int `json:"minimum_nodes"`
 	// Namespace to create test resources.
 	Namespace string `json:"namespace"`
+
+	CWAPI cloudwatchiface.CloudWatchAPI `json:"-"`
+
+	// CheckMetrics is true to query CloudWatch after the agent is running,
+	// and verify the Container Insights metrics arrive for the cluster.
+	// The tester principal requires "cloudwatch:GetMetricData".
+	CheckMetrics bool `json:"check_metrics"`
+	// ContainerInsightsMetrics is the list of Container Insights metric names
+	// that must have datapoints with the "ClusterName" dimension.
+	ContainerInsightsMetrics []string `json:"container_insights_metrics"`
+	// MetricsTimeout is the timeout for all metrics to arrive.
+	MetricsTimeout       time.Duration `json:"metrics_timeout"`
+	MetricsTimeoutString string        `json:"metrics_timeout_string" read-only:"true"`
+
+	// MetricsArrival is the duration from the agent running
+	// to all metrics found in CloudWatch.
+	MetricsArrival       time.Duration `json:"metrics_arrival" read-only:"true"`
+	MetricsArrivalString string        `json:"metrics_arrival_string" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults(clusterName string) error {
@@ -59,10 +84,38 @@ func (cfg *Config) ValidateAndSetDefaults(clusterName string) error {
 
 	cfg.ClusterName = clusterName
 
+	if !cfg.CheckMetrics {
+		return nil
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region with CheckMetrics")
+	}
+	if cfg.ClusterName == "" {
+		return errors.New("empty ClusterName with CheckMetrics")
+	}
+	if len(cfg.ContainerInsightsMetrics) == 0 {
+		cfg.ContainerInsightsMetrics = DefaultContainerInsightsMetrics()
+	}
+	if cfg.MetricsTimeout == time.Duration(0) {
+		cfg.MetricsTimeout = DefaultMetricsTimeout
+	}
+	cfg.MetricsTimeoutString = cfg.MetricsTimeout.String()
+
 	return nil
 }
 
-const DefaultMinimumNodes int = 1
+const (
+	DefaultMinimumNodes   int = 1
+	DefaultMetricsTimeout     = 10 * time.Minute
+)
+
+// DefaultContainerInsightsMetrics returns the default Container Insights metrics to check.
+func DefaultContainerInsightsMetrics() []string {
+	return []string{
+		"node_cpu_utilization",
+		"pod_memory_utilization",
+	}
+}
 
 func NewDefault() *Config {
 	return &Config{
@@ -70,13 +123,62 @@ func NewDefault() *Config {
 		Prompt:       false,
 		MinimumNodes: DefaultMinimumNodes,
 		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+
+		CheckMetrics:             false,
+		ContainerInsightsMetrics: DefaultContainerInsightsMetrics(),
+		MetricsTimeout:           DefaultMetricsTimeout,
 	}
 }
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	if cfg.CheckMetrics {
+		awsCfg := aws_v1.Config{
+			Logger:        cfg.Logger,
+			DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+			Partition:     cfg.Partition,
+			Region:        cfg.Region,
+		}
+		awsSession, _, _, err := aws_v1.New(&awsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create aws session (%v)", err)
+		}
+		cfg.CWAPI = cloudwatch.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	}
+
 	return &tester{
 		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config, clusterName string) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
 	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 type tester struct {
@@ -132,6 +234,12 @@ func (ts *tester) Apply() error {
 		return err
 	}
 
+	if ts.cfg.CheckMetrics {
+		if err := ts.checkMetrics(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -193,7 +301,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -0,0 +1,147 @@
+// Package cloudwatch_metrics publishes the k8s-tester run results to Amazon CloudWatch,
+// such as the duration and success of each add-on and the key tester metrics,
+// so that dashboards can track the long-term trends across runs.
+// ref. https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_PutMetricData.html
+package cloudwatch_metrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+
+	Logger *zap.Logger `json:"-"`
+
+	CWAPI cloudwatchiface.CloudWatchAPI `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// Namespace is the CloudWatch namespace to publish the metrics to.
+	Namespace string `json:"namespace"`
+	// Dimensions is the additional dimensions for all metrics
+	// (e.g., {"Environment":"nightly"}), in addition to "ClusterName" and "AddOn".
+	Dimensions map[string]string `json:"dimensions"`
+}
+
+const (
+	DefaultPartition = "aws"
+	DefaultNamespace = "k8s-tester"
+
+	// ref. https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/cloudwatch_limits.html
+	maxDimensions = 30
+	batchSize     = 20
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:    false,
+		Partition: DefaultPartition,
+		Namespace: DefaultNamespace,
+	}
+}
+
+func Env() string {
+	return "CLOUDWATCH_METRICS"
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultNamespace
+	}
+	if len(cfg.Dimensions)+2 > maxDimensions {
+		return fmt.Errorf("too many Dimensions %d (limit %d)", len(cfg.Dimensions), maxDimensions-2)
+	}
+	return nil
+}
+
+// Datum is a metric of an add-on to publish.
+type Datum struct {
+	// AddOn is the add-on name.
+	AddOn string
+	// Name is the metric name (e.g., "duration", "writes-qps").
+	Name string
+	// Unit is the CloudWatch standard unit (e.g., cloudwatch.StandardUnitSeconds).
+	Unit string
+	// Value is the metric value.
+	Value float64
+}
+
+// Publish sends the datums to CloudWatch, with the "ClusterName" and "AddOn" dimensions.
+func Publish(cfg *Config, clusterName string, datums []Datum) error {
+	if len(datums) == 0 {
+		cfg.Logger.Info("no metric to publish")
+		return nil
+	}
+	if cfg.CWAPI == nil {
+		awsCfg := aws_v1.Config{
+			Logger:        cfg.Logger,
+			DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+			Partition:     cfg.Partition,
+			Region:        cfg.Region,
+		}
+		awsSession, _, _, err := aws_v1.New(&awsCfg)
+		if err != nil {
+			return fmt.Errorf("failed to create aws session (%v)", err)
+		}
+		cfg.CWAPI = cloudwatch.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	}
+
+	extra := make([]string, 0, len(cfg.Dimensions))
+	for k := range cfg.Dimensions {
+		extra = append(extra, k)
+	}
+	sort.Strings(extra)
+
+	now := aws.Time(time.Now().UTC())
+	metrics := make([]*cloudwatch.MetricDatum, 0, len(datums))
+	for _, d := range datums {
+		dims := []*cloudwatch.Dimension{
+			{Name: aws.String("ClusterName"), Value: aws.String(clusterName)},
+			{Name: aws.String("AddOn"), Value: aws.String(d.AddOn)},
+		}
+		for _, k := range extra {
+			dims = append(dims, &cloudwatch.Dimension{Name: aws.String(k), Value: aws.String(cfg.Dimensions[k])})
+		}
+		metrics = append(metrics, &cloudwatch.MetricDatum{
+			Timestamp:  now,
+			MetricName: aws.String(d.Name),
+			Unit:       aws.String(d.Unit),
+			Value:      aws.Float64(d.Value),
+			Dimensions: dims,
+		})
+	}
+
+	for len(metrics) > 0 {
+		n := batchSize
+		if len(metrics) < n {
+			n = len(metrics)
+		}
+		if _, err := cfg.CWAPI.PutMetricData(&cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(cfg.Namespace),
+			MetricData: metrics[:n],
+		}); err != nil {
+			return fmt.Errorf("failed to put metric data to %q (%v)", cfg.Namespace, err)
+		}
+		metrics = metrics[n:]
+	}
+	cfg.Logger.Info("published metrics", zap.String("namespace", cfg.Namespace), zap.Int("datums", len(datums)))
+	return nil
+}
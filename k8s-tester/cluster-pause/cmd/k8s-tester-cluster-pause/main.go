@@ -0,0 +1,196 @@
+// k8s-tester-cluster-pause scales the node groups to zero and back, and validates the workload recovery.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	cluster_pause "github.com/aws/aws-k8s-tester/k8s-tester/cluster-pause"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-cluster-pause",
+	Short:      "Kubernetes cluster pause and resume tester",
+	SuggestFor: []string{"cluster-pause", "pause"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	partition          string
+	region             string
+	clusterName        string
+	statePath          string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", cluster_pause.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "namespace to create the workload")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", cluster_pause.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for EKS cluster")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster-name", "", "EKS cluster name")
+	rootCmd.PersistentFlags().StringVar(&statePath, "state-path", "", "file to persist the node group sizes before the pause (default to '[CLUSTER_NAME]-cluster-pause.json' in the temporary directory)")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-cluster-pause failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	nodeGroupNames   []string
+	asgNames         []string
+	image            string
+	storageClassName string
+	volumeSize       string
+	pauseTimeout     time.Duration
+	resumeTimeout    time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringSliceVar(&nodeGroupNames, "node-group-names", nil, "EKS managed node groups to pause (default to all managed node groups, if no '--asg-names')")
+	cmd.PersistentFlags().StringSliceVar(&asgNames, "asg-names", nil, "Auto Scaling groups of the self-managed node groups to pause")
+	cmd.PersistentFlags().StringVar(&image, "image", cluster_pause.DefaultImage, "workload image")
+	cmd.PersistentFlags().StringVar(&storageClassName, "storage-class-name", "", "StorageClass of the workload volume (default to the default StorageClass)")
+	cmd.PersistentFlags().StringVar(&volumeSize, "volume-size", cluster_pause.DefaultVolumeSize, "workload volume size")
+	cmd.PersistentFlags().DurationVar(&pauseTimeout, "pause-timeout", cluster_pause.DefaultPauseTimeout, "timeout for the node group instances to terminate")
+	cmd.PersistentFlags().DurationVar(&resumeTimeout, "resume-timeout", cluster_pause.DefaultResumeTimeout, "timeout for the nodes and the workload to recover")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &cluster_pause.Config{
+		Prompt:           prompt,
+		Logger:           lg,
+		LogWriter:        logWriter,
+		MinimumNodes:     minimumNodes,
+		Namespace:        namespace,
+		Client:           cli,
+		Partition:        partition,
+		Region:           region,
+		NodeGroupNames:   nodeGroupNames,
+		ASGNames:         asgNames,
+		StatePath:        statePath,
+		Image:            image,
+		StorageClassName: storageClassName,
+		VolumeSize:       volumeSize,
+		Marker:           rand.String(10),
+		PauseTimeout:     pauseTimeout,
+		ResumeTimeout:    resumeTimeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := cluster_pause.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-cluster-pause apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources, and resume the node groups paused by 'apply', if any",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &cluster_pause.Config{
+		Prompt:       prompt,
+		Logger:       lg,
+		LogWriter:    logWriter,
+		Namespace:    namespace,
+		Client:       cli,
+		Partition:    partition,
+		Region:       region,
+		ClusterName:  clusterName,
+		StatePath:    statePath,
+		PauseTimeout: cluster_pause.DefaultPauseTimeout,
+	}
+	if cfg.StatePath == "" {
+		cfg.StatePath = cluster_pause.DefaultStatePath(clusterName)
+	}
+
+	ts := cluster_pause.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-cluster-pause delete' success\n")
+}
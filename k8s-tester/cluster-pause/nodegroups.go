@@ -0,0 +1,283 @@
+package cluster_pause
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// NodeGroup is the node group and its size before the pause.
+type NodeGroup struct {
+	// Name is the EKS managed node group name, or the Auto Scaling group name.
+	Name string `json:"name"`
+	// Managed is true for the EKS managed node group.
+	Managed bool `json:"managed"`
+	// ASGNames is the Auto Scaling groups of the node group, to count the instances.
+	ASGNames []string `json:"asg_names"`
+
+	MinSize     int64 `json:"min_size"`
+	MaxSize     int64 `json:"max_size"`
+	DesiredSize int64 `json:"desired_size"`
+}
+
+// state is persisted to "StatePath" while the node groups are paused.
+type state struct {
+	ClusterName string      `json:"cluster_name"`
+	Paused      bool        `json:"paused"`
+	NodeGroups  []NodeGroup `json:"node_groups"`
+}
+
+func (ts *tester) saveState() error {
+	d, err := json.MarshalIndent(state{
+		ClusterName: ts.cfg.ClusterName,
+		Paused:      ts.cfg.Paused,
+		NodeGroups:  ts.cfg.NodeGroups,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(ts.cfg.StatePath, d, 0600); err != nil {
+		return fmt.Errorf("failed to write state %q (%v)", ts.cfg.StatePath, err)
+	}
+	ts.cfg.Logger.Info("saved node group sizes", zap.String("path", ts.cfg.StatePath), zap.Bool("paused", ts.cfg.Paused))
+	return nil
+}
+
+// loadState loads the node groups paused by the previous run, if any.
+func (ts *tester) loadState() error {
+	d, err := ioutil.ReadFile(ts.cfg.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state %q (%v)", ts.cfg.StatePath, err)
+	}
+	var st state
+	if err = json.Unmarshal(d, &st); err != nil {
+		return fmt.Errorf("failed to parse state %q (%v)", ts.cfg.StatePath, err)
+	}
+	if st.ClusterName != ts.cfg.ClusterName {
+		return fmt.Errorf("state %q is for cluster %q, not %q", ts.cfg.StatePath, st.ClusterName, ts.cfg.ClusterName)
+	}
+	ts.cfg.Paused, ts.cfg.NodeGroups = st.Paused, st.NodeGroups
+	return nil
+}
+
+// discoverNodeGroups returns the node groups to pause, with their current sizes.
+func (ts *tester) discoverNodeGroups() ([]NodeGroup, error) {
+	names := ts.cfg.NodeGroupNames
+	if len(names) == 0 && len(ts.cfg.ASGNames) == 0 {
+		err := ts.cfg.EKSAPI.ListNodegroupsPages(
+			&eks.ListNodegroupsInput{ClusterName: aws.String(ts.cfg.ClusterName)},
+			func(out *eks.ListNodegroupsOutput, lastPage bool) bool {
+				names = append(names, aws.StringValueSlice(out.Nodegroups)...)
+				return true
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list node groups (%v)", err)
+		}
+	}
+
+	ngs := make([]NodeGroup, 0, len(names)+len(ts.cfg.ASGNames))
+	for _, name := range names {
+		out, err := ts.cfg.EKSAPI.DescribeNodegroup(&eks.DescribeNodegroupInput{
+			ClusterName:   aws.String(ts.cfg.ClusterName),
+			NodegroupName: aws.String(name),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe node group %q (%v)", name, err)
+		}
+		ng := NodeGroup{Name: name, Managed: true}
+		if sc := out.Nodegroup.ScalingConfig; sc != nil {
+			ng.MinSize, ng.MaxSize, ng.DesiredSize = aws.Int64Value(sc.MinSize), aws.Int64Value(sc.MaxSize), aws.Int64Value(sc.DesiredSize)
+		}
+		if out.Nodegroup.Resources != nil {
+			for _, asg := range out.Nodegroup.Resources.AutoScalingGroups {
+				ng.ASGNames = append(ng.ASGNames, aws.StringValue(asg.Name))
+			}
+		}
+		ngs = append(ngs, ng)
+	}
+
+	if len(ts.cfg.ASGNames) > 0 {
+		out, err := ts.cfg.ASGAPI.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: aws.StringSlice(ts.cfg.ASGNames),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe Auto Scaling groups (%v)", err)
+		}
+		found := make(map[string]*autoscaling.Group, len(out.AutoScalingGroups))
+		for _, asg := range out.AutoScalingGroups {
+			found[aws.StringValue(asg.AutoScalingGroupName)] = asg
+		}
+		for _, name := range ts.cfg.ASGNames {
+			asg, ok := found[name]
+			if !ok {
+				return nil, fmt.Errorf("no Auto Scaling group %q", name)
+			}
+			ngs = append(ngs, NodeGroup{
+				Name:        name,
+				ASGNames:    []string{name},
+				MinSize:     aws.Int64Value(asg.MinSize),
+				MaxSize:     aws.Int64Value(asg.MaxSize),
+				DesiredSize: aws.Int64Value(asg.DesiredCapacity),
+			})
+		}
+	}
+
+	if len(ngs) == 0 {
+		return nil, fmt.Errorf("no node group found in cluster %q", ts.cfg.ClusterName)
+	}
+	ts.cfg.Logger.Info("discovered node groups", zap.Any("node-groups", ngs))
+	return ngs, nil
+}
+
+// pause scales all node groups to zero.
+func (ts *tester) pause() error {
+	for _, ng := range ts.cfg.NodeGroups {
+		if err := ts.scale(ng, 0, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resume scales all node groups back to their sizes before the pause,
+// and removes the persisted state.
+func (ts *tester) resume() error {
+	for _, ng := range ts.cfg.NodeGroups {
+		if err := ts.scale(ng, ng.MinSize, ng.DesiredSize); err != nil {
+			return err
+		}
+	}
+	ts.cfg.Paused = false
+	if err := os.Remove(ts.cfg.StatePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove state %q (%v)", ts.cfg.StatePath, err)
+	}
+	return nil
+}
+
+// scale updates the minimum and the desired size of the node group,
+// keeping its maximum size (must be greater than zero for the managed node groups).
+func (ts *tester) scale(ng NodeGroup, minSize int64, desiredSize int64) error {
+	ts.cfg.Logger.Info("scaling node group",
+		zap.String("name", ng.Name),
+		zap.Bool("managed", ng.Managed),
+		zap.Int64("min-size", minSize),
+		zap.Int64("desired-size", desiredSize),
+	)
+	if !ng.Managed {
+		_, err := ts.cfg.ASGAPI.UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(ng.Name),
+			MinSize:              aws.Int64(minSize),
+			DesiredCapacity:      aws.Int64(desiredSize),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scale Auto Scaling group %q (%v)", ng.Name, err)
+		}
+		return nil
+	}
+
+	// only one update at a time, so wait for the previous update (e.g., the pause)
+	if err := ts.waitForNodeGroupActive(ng.Name); err != nil {
+		return err
+	}
+	_, err := ts.cfg.EKSAPI.UpdateNodegroupConfig(&eks.UpdateNodegroupConfigInput{
+		ClusterName:   aws.String(ts.cfg.ClusterName),
+		NodegroupName: aws.String(ng.Name),
+		ScalingConfig: &eks.NodegroupScalingConfig{
+			MinSize:     aws.Int64(minSize),
+			MaxSize:     aws.Int64(ng.MaxSize),
+			DesiredSize: aws.Int64(desiredSize),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scale node group %q (%v)", ng.Name, err)
+	}
+	return nil
+}
+
+// waitForNodeGroupActive waits until the managed node group has no update in progress.
+func (ts *tester) waitForNodeGroupActive(name string) error {
+	status := ""
+	start := time.Now()
+	for time.Since(start) < ts.cfg.PauseTimeout {
+		out, err := ts.cfg.EKSAPI.DescribeNodegroup(&eks.DescribeNodegroupInput{
+			ClusterName:   aws.String(ts.cfg.ClusterName),
+			NodegroupName: aws.String(name),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe node group %q (%v)", name, err)
+		}
+		status = aws.StringValue(out.Nodegroup.Status)
+		switch status {
+		case eks.NodegroupStatusActive, eks.NodegroupStatusDegraded:
+			return nil
+		case eks.NodegroupStatusUpdating:
+		default:
+			return fmt.Errorf("unexpected node group %q status %q", name, status)
+		}
+		ts.cfg.Logger.Info("waiting for node group update", zap.String("name", name), zap.String("status", status))
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(15 * time.Second):
+		}
+	}
+	return fmt.Errorf("node group %q still %q after %v", name, status, ts.cfg.PauseTimeout)
+}
+
+// waitForInstances waits until the Auto Scaling groups of all node groups
+// have "n" instances in total.
+func (ts *tester) waitForInstances(n int, timeout time.Duration) error {
+	asgNames := make([]string, 0)
+	for _, ng := range ts.cfg.NodeGroups {
+		asgNames = append(asgNames, ng.ASGNames...)
+	}
+	ts.cfg.Logger.Info("waiting for node group instances", zap.Strings("asg-names", asgNames), zap.Int("instances", n))
+
+	instances := -1
+	start := time.Now()
+	for time.Since(start) < timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		out, err := ts.cfg.ASGAPI.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: aws.StringSlice(asgNames),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe Auto Scaling groups; retrying", zap.Error(err))
+			continue
+		}
+		instances = countInstances(out.AutoScalingGroups)
+		if instances == n {
+			ts.cfg.Logger.Info("node group instances scaled", zap.Int("instances", n), zap.String("took", time.Since(start).String()))
+			return nil
+		}
+		ts.cfg.Logger.Info("waiting for node group instances", zap.Int("instances", instances), zap.Int("expected", n), zap.String("elapsed", time.Since(start).String()))
+	}
+	return fmt.Errorf("node groups have %d instances, expected %d within %v", instances, n, timeout)
+}
+
+// countInstances returns the number of instances in the Auto Scaling groups,
+// including the instances still terminating.
+func countInstances(asgs []*autoscaling.Group) int {
+	n := 0
+	for _, asg := range asgs {
+		n += len(asg.Instances)
+	}
+	return n
+}
@@ -0,0 +1,553 @@
+// Package cluster_pause pauses the cluster by scaling all node groups to zero,
+// and resumes it by scaling them back to their prior sizes, to validate the
+// cost-saving workflow of the test clusters. It verifies that a workload with
+// a PersistentVolume recovers its data after the resume, and measures the recovery time.
+// The prior sizes are persisted to "StatePath" before the pause, so that "delete"
+// restores the node groups from a new process if the resume did not complete.
+package cluster_pause
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	EKSAPI eksiface.EKSAPI                 `json:"-"`
+	ASGAPI autoscalingiface.AutoScalingAPI `json:"-"`
+
+	Partition   string `json:"partition"`
+	Region      string `json:"region"`
+	ClusterName string `json:"cluster_name" read-only:"true"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// NodeGroupNames is the EKS managed node groups to pause.
+	// If empty, all managed node groups of the cluster are paused.
+	NodeGroupNames []string `json:"node_group_names"`
+	// ASGNames is the Auto Scaling groups of the self-managed node groups to pause.
+	ASGNames []string `json:"asg_names"`
+	// StatePath is the file to persist the node group sizes before the pause.
+	// Defaults to "[CLUSTER_NAME]-cluster-pause.json" in the temporary directory.
+	StatePath string `json:"state_path"`
+
+	// Image is the workload image with "/bin/sh".
+	Image string `json:"image"`
+	// StorageClassName is the StorageClass of the workload PersistentVolumeClaim.
+	// Empty to use the default StorageClass. The zonal volumes (e.g., EBS) require
+	// the resumed node groups to have nodes in the volume availability zone.
+	StorageClassName string `json:"storage_class_name"`
+	// VolumeSize is the requested PersistentVolumeClaim storage size.
+	VolumeSize string `json:"volume_size"`
+	// Marker is written to the workload volume before the pause,
+	// and expected in the volume after the resume.
+	Marker string `json:"marker"`
+
+	// PauseTimeout is the timeout for the node group instances to terminate.
+	PauseTimeout       time.Duration `json:"pause_timeout"`
+	PauseTimeoutString string        `json:"pause_timeout_string" read-only:"true"`
+	// ResumeTimeout is the timeout for the nodes and the workload to recover.
+	ResumeTimeout       time.Duration `json:"resume_timeout"`
+	ResumeTimeoutString string        `json:"resume_timeout_string" read-only:"true"`
+
+	// NodeGroups is the node groups and their sizes before the pause.
+	NodeGroups []NodeGroup `json:"node_groups" read-only:"true"`
+	// Paused is true if the node groups are scaled to zero, and not yet resumed.
+	Paused bool `json:"paused" read-only:"true"`
+	// NodesBefore is the number of ready nodes before the pause.
+	NodesBefore int `json:"nodes_before" read-only:"true"`
+	// PauseLatency is the time from the scale-in to all node group instances terminated.
+	PauseLatency       time.Duration `json:"pause_latency" read-only:"true"`
+	PauseLatencyString string        `json:"pause_latency_string" read-only:"true"`
+	// NodesRecoveryLatency is the time from the scale-out to "NodesBefore" nodes ready.
+	NodesRecoveryLatency       time.Duration `json:"nodes_recovery_latency" read-only:"true"`
+	NodesRecoveryLatencyString string        `json:"nodes_recovery_latency_string" read-only:"true"`
+	// RecoveryLatency is the time from the scale-out to the workload ready with its data.
+	RecoveryLatency       time.Duration `json:"recovery_latency" read-only:"true"`
+	RecoveryLatencyString string        `json:"recovery_latency_string" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults(clusterName string) error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if clusterName == "" {
+		return errors.New("empty ClusterName")
+	}
+	cfg.ClusterName = clusterName
+	if cfg.StatePath == "" {
+		cfg.StatePath = DefaultStatePath(clusterName)
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.VolumeSize == "" {
+		cfg.VolumeSize = DefaultVolumeSize
+	}
+	if _, err := resource.ParseQuantity(cfg.VolumeSize); err != nil {
+		return fmt.Errorf("invalid VolumeSize %q (%v)", cfg.VolumeSize, err)
+	}
+	if cfg.Marker == "" {
+		return errors.New("empty Marker")
+	}
+	if cfg.PauseTimeout == time.Duration(0) {
+		cfg.PauseTimeout = DefaultPauseTimeout
+	}
+	cfg.PauseTimeoutString = cfg.PauseTimeout.String()
+	if cfg.ResumeTimeout == time.Duration(0) {
+		cfg.ResumeTimeout = DefaultResumeTimeout
+	}
+	cfg.ResumeTimeoutString = cfg.ResumeTimeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes  int = 1
+	DefaultPartition         = "aws"
+	DefaultImage             = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultVolumeSize        = "1Gi"
+	DefaultPauseTimeout      = 20 * time.Minute
+	DefaultResumeTimeout     = 30 * time.Minute
+)
+
+// DefaultStatePath returns the default "StatePath" of the cluster.
+func DefaultStatePath(clusterName string) string {
+	return filepath.Join(os.TempDir(), clusterName+"-"+pkgName+".json")
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:        false,
+		Prompt:        false,
+		Partition:     DefaultPartition,
+		MinimumNodes:  DefaultMinimumNodes,
+		Namespace:     pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:         DefaultImage,
+		VolumeSize:    DefaultVolumeSize,
+		Marker:        rand.String(10),
+		PauseTimeout:  DefaultPauseTimeout,
+		ResumeTimeout: DefaultResumeTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.EKSAPI = eks.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	cfg.ASGAPI = autoscaling.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config, clusterName string) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	workloadName = "cluster-pause"
+	volumeMount  = "/data"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if len(nodes) < ts.cfg.MinimumNodes || err != nil {
+		return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+	if err := ts.createWorkload(); err != nil {
+		return err
+	}
+	podUID, err := ts.waitForWorkload(ts.cfg.ResumeTimeout)
+	if err != nil {
+		return err
+	}
+
+	ngs, err := ts.discoverNodeGroups()
+	if err != nil {
+		return err
+	}
+	ts.cfg.NodeGroups = ngs
+	if nodes, err = client.ListNodes(ts.cfg.Client.KubernetesClient()); err != nil {
+		return fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	ts.cfg.NodesBefore = countReadyNodes(nodes)
+
+	// persist the prior sizes before scaling in, to resume from a new process
+	ts.cfg.Paused = true
+	if err = ts.saveState(); err != nil {
+		return err
+	}
+	start := time.Now()
+	if err = ts.pause(); err != nil {
+		return err
+	}
+	if err = ts.waitForInstances(0, ts.cfg.PauseTimeout); err != nil {
+		return err
+	}
+	ts.cfg.PauseLatency = time.Since(start)
+	ts.cfg.PauseLatencyString = ts.cfg.PauseLatency.String()
+	ts.cfg.Logger.Info("paused node groups", zap.String("pause-latency", ts.cfg.PauseLatencyString))
+
+	start = time.Now()
+	if err = ts.resume(); err != nil {
+		return err
+	}
+	if err = ts.waitForNodesReady(ts.cfg.NodesBefore, ts.cfg.ResumeTimeout); err != nil {
+		return err
+	}
+	ts.cfg.NodesRecoveryLatency = time.Since(start)
+	ts.cfg.NodesRecoveryLatencyString = ts.cfg.NodesRecoveryLatency.String()
+
+	newPodUID, err := ts.waitForWorkload(ts.cfg.ResumeTimeout - time.Since(start))
+	if err != nil {
+		return err
+	}
+	if newPodUID == podUID {
+		return fmt.Errorf("workload Pod %q was not evicted by the pause; pause the node groups of all nodes", podUID)
+	}
+	ts.cfg.RecoveryLatency = time.Since(start)
+	ts.cfg.RecoveryLatencyString = ts.cfg.RecoveryLatency.String()
+
+	ts.cfg.Logger.Info("resumed node groups and recovered workload",
+		zap.Int("nodes", ts.cfg.NodesBefore),
+		zap.String("pause-latency", ts.cfg.PauseLatencyString),
+		zap.String("nodes-recovery-latency", ts.cfg.NodesRecoveryLatencyString),
+		zap.String("recovery-latency", ts.cfg.RecoveryLatencyString),
+	)
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	// e.g., deleting from a new process after a failed resume
+	if !ts.cfg.Paused {
+		if err := ts.loadState(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if ts.cfg.Paused {
+		if err := ts.resume(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, and to scale the node groups of %q to zero and back, should we continue?", action, ts.cfg.Namespace, ts.cfg.ClusterName)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// createWorkload creates the PersistentVolumeClaim, and the Deployment that writes
+// the marker to the volume only if the volume is new, and is ready only if the volume has the marker.
+func (ts *tester) createWorkload() error {
+	ts.cfg.Logger.Info("creating workload", zap.String("namespace", ts.cfg.Namespace))
+	pvc := &core_v1.PersistentVolumeClaim{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      workloadName,
+			Namespace: ts.cfg.Namespace,
+		},
+		Spec: core_v1.PersistentVolumeClaimSpec{
+			AccessModes: []core_v1.PersistentVolumeAccessMode{core_v1.ReadWriteOnce},
+			Resources: core_v1.VolumeResourceRequirements{
+				Requests: core_v1.ResourceList{
+					core_v1.ResourceStorage: resource.MustParse(ts.cfg.VolumeSize),
+				},
+			},
+		},
+	}
+	if ts.cfg.StorageClassName != "" {
+		pvc.Spec.StorageClassName = aws.String(ts.cfg.StorageClassName)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		PersistentVolumeClaims(ts.cfg.Namespace).
+		Create(ctx, pvc, meta_v1.CreateOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create PersistentVolumeClaim (%v)", err)
+	}
+
+	replicas := int32(1)
+	labels := map[string]string{"app.kubernetes.io/name": workloadName}
+	script := fmt.Sprintf(`[ -f %[1]s/marker ] || echo "$MARKER" > %[1]s/marker; cat %[1]s/marker; while true; do sleep 3600; done`, volumeMount)
+	dp := &apps_v1.Deployment{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      workloadName,
+			Namespace: ts.cfg.Namespace,
+			Labels:    labels,
+		},
+		Spec: apps_v1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &meta_v1.LabelSelector{MatchLabels: labels},
+			// the ReadWriteOnce volume cannot be attached to two Pods on different nodes
+			Strategy: apps_v1.DeploymentStrategy{Type: apps_v1.RecreateDeploymentStrategyType},
+			Template: core_v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{Labels: labels},
+				Spec: core_v1.PodSpec{
+					Containers: []core_v1.Container{
+						{
+							Name:            workloadName,
+							Image:           ts.cfg.Image,
+							ImagePullPolicy: core_v1.PullIfNotPresent,
+							Command:         []string{"/bin/sh", "-c", script},
+							Env:             []core_v1.EnvVar{{Name: "MARKER", Value: ts.cfg.Marker}},
+							ReadinessProbe: &core_v1.Probe{
+								ProbeHandler: core_v1.ProbeHandler{
+									Exec: &core_v1.ExecAction{
+										Command: []string{"/bin/sh", "-c", fmt.Sprintf(`grep -qx "$MARKER" %s/marker`, volumeMount)},
+									},
+								},
+								PeriodSeconds: 5,
+							},
+							VolumeMounts: []core_v1.VolumeMount{{Name: "data", MountPath: volumeMount}},
+						},
+					},
+					Volumes: []core_v1.Volume{
+						{
+							Name: "data",
+							VolumeSource: core_v1.VolumeSource{
+								PersistentVolumeClaim: &core_v1.PersistentVolumeClaimVolumeSource{ClaimName: workloadName},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(ctx, dp, meta_v1.CreateOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Deployment (%v)", err)
+	}
+	ts.cfg.Logger.Info("created workload")
+	return nil
+}
+
+// waitForWorkload waits until the workload Pod is ready with the marker in its volume,
+// and returns the Pod UID.
+func (ts *tester) waitForWorkload(timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	_, err := client.WaitForDeployment(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		workloadName,
+		client.WithStopc(ts.cfg.Stopc),
+	)
+	cancel()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + workloadName})
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to list workload Pods (%v)", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == core_v1.PodRunning {
+			return string(pod.UID), nil
+		}
+	}
+	return "", errors.New("no running workload Pod")
+}
+
+// countReadyNodes returns the number of ready nodes.
+func countReadyNodes(nodes []core_v1.Node) int {
+	n := 0
+	for i := range nodes {
+		if client.IsNodeReady(&nodes[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// waitForNodesReady waits until "n" nodes are ready.
+func (ts *tester) waitForNodesReady(n int, timeout time.Duration) error {
+	ts.cfg.Logger.Info("waiting for nodes ready", zap.Int("nodes", n))
+	ready := 0
+	start := time.Now()
+	for time.Since(start) < timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list nodes; retrying", zap.Error(err))
+			continue
+		}
+		ready = countReadyNodes(nodes)
+		if ready >= n {
+			ts.cfg.Logger.Info("nodes ready", zap.Int("ready", ready), zap.String("took", time.Since(start).String()))
+			return nil
+		}
+		ts.cfg.Logger.Info("waiting for nodes ready", zap.Int("ready", ready), zap.Int("expected", n), zap.String("elapsed", time.Since(start).String()))
+	}
+	return fmt.Errorf("%d of %d nodes ready within %v", ready, n, timeout)
+}
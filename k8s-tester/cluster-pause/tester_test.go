@@ -0,0 +1,155 @@
+package cluster_pause
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"go.uber.org/zap"
+)
+
+type fakeEKS struct {
+	eksiface.EKSAPI
+	scaling map[string]*eks.NodegroupScalingConfig
+}
+
+func (f *fakeEKS) ListNodegroupsPages(input *eks.ListNodegroupsInput, fn func(*eks.ListNodegroupsOutput, bool) bool) error {
+	fn(&eks.ListNodegroupsOutput{Nodegroups: aws.StringSlice([]string{"ng-1", "ng-2"})}, true)
+	return nil
+}
+
+func (f *fakeEKS) DescribeNodegroup(input *eks.DescribeNodegroupInput) (*eks.DescribeNodegroupOutput, error) {
+	name := aws.StringValue(input.NodegroupName)
+	return &eks.DescribeNodegroupOutput{
+		Nodegroup: &eks.Nodegroup{
+			NodegroupName: input.NodegroupName,
+			Status:        aws.String(eks.NodegroupStatusActive),
+			ScalingConfig: f.scaling[name],
+			Resources: &eks.NodegroupResources{
+				AutoScalingGroups: []*eks.AutoScalingGroup{{Name: aws.String("eks-" + name)}},
+			},
+		},
+	}, nil
+}
+
+func (f *fakeEKS) UpdateNodegroupConfig(input *eks.UpdateNodegroupConfigInput) (*eks.UpdateNodegroupConfigOutput, error) {
+	f.scaling[aws.StringValue(input.NodegroupName)] = input.ScalingConfig
+	return &eks.UpdateNodegroupConfigOutput{}, nil
+}
+
+type fakeASG struct {
+	autoscalingiface.AutoScalingAPI
+	groups map[string]*autoscaling.Group
+}
+
+func (f *fakeASG) DescribeAutoScalingGroups(input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	out := &autoscaling.DescribeAutoScalingGroupsOutput{}
+	for _, name := range aws.StringValueSlice(input.AutoScalingGroupNames) {
+		if asg, ok := f.groups[name]; ok {
+			out.AutoScalingGroups = append(out.AutoScalingGroups, asg)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeASG) UpdateAutoScalingGroup(input *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	asg := f.groups[aws.StringValue(input.AutoScalingGroupName)]
+	asg.MinSize, asg.DesiredCapacity = input.MinSize, input.DesiredCapacity
+	return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+}
+
+func TestPauseResume(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "cluster-pause")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fe := &fakeEKS{scaling: map[string]*eks.NodegroupScalingConfig{
+		"ng-1": {MinSize: aws.Int64(1), MaxSize: aws.Int64(5), DesiredSize: aws.Int64(3)},
+		"ng-2": {MinSize: aws.Int64(2), MaxSize: aws.Int64(2), DesiredSize: aws.Int64(2)},
+	}}
+	fa := &fakeASG{groups: map[string]*autoscaling.Group{
+		"self-managed": {AutoScalingGroupName: aws.String("self-managed"), MinSize: aws.Int64(1), MaxSize: aws.Int64(4), DesiredCapacity: aws.Int64(2)},
+	}}
+
+	cfg := NewDefault()
+	cfg.Region = "us-west-2"
+	cfg.StatePath = filepath.Join(dir, "state.json")
+	if err = cfg.ValidateAndSetDefaults("hello"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Logger, cfg.EKSAPI, cfg.ASGAPI = zap.NewExample(), fe, fa
+	ts := &tester{cfg: cfg}
+
+	// all managed node groups by default
+	ngs, err := ts.discoverNodeGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []NodeGroup{
+		{Name: "ng-1", Managed: true, ASGNames: []string{"eks-ng-1"}, MinSize: 1, MaxSize: 5, DesiredSize: 3},
+		{Name: "ng-2", Managed: true, ASGNames: []string{"eks-ng-2"}, MinSize: 2, MaxSize: 2, DesiredSize: 2},
+	}
+	if !reflect.DeepEqual(ngs, expected) {
+		t.Fatalf("expected node groups %+v, got %+v", expected, ngs)
+	}
+
+	cfg.NodeGroupNames, cfg.ASGNames = []string{"ng-1"}, []string{"self-managed"}
+	if cfg.NodeGroups, err = ts.discoverNodeGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.NodeGroups) != 2 || cfg.NodeGroups[1].Managed || cfg.NodeGroups[1].DesiredSize != 2 {
+		t.Fatalf("unexpected node groups %+v", cfg.NodeGroups)
+	}
+
+	cfg.Paused = true
+	if err = ts.saveState(); err != nil {
+		t.Fatal(err)
+	}
+	if err = ts.pause(); err != nil {
+		t.Fatal(err)
+	}
+	if sc := fe.scaling["ng-1"]; aws.Int64Value(sc.MinSize) != 0 || aws.Int64Value(sc.DesiredSize) != 0 || aws.Int64Value(sc.MaxSize) != 5 {
+		t.Fatalf("unexpected paused scaling config %+v", sc)
+	}
+	if asg := fa.groups["self-managed"]; aws.Int64Value(asg.MinSize) != 0 || aws.Int64Value(asg.DesiredCapacity) != 0 {
+		t.Fatalf("unexpected paused Auto Scaling group %+v", asg)
+	}
+
+	// resume from a new process with the persisted sizes
+	ts2 := &tester{cfg: &Config{Logger: cfg.Logger, EKSAPI: fe, ASGAPI: fa, ClusterName: "hello", StatePath: cfg.StatePath, PauseTimeout: cfg.PauseTimeout}}
+	if err = ts2.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	if !ts2.cfg.Paused || !reflect.DeepEqual(ts2.cfg.NodeGroups, cfg.NodeGroups) {
+		t.Fatalf("unexpected loaded state paused %v, node groups %+v", ts2.cfg.Paused, ts2.cfg.NodeGroups)
+	}
+	if err = ts2.resume(); err != nil {
+		t.Fatal(err)
+	}
+	if sc := fe.scaling["ng-1"]; aws.Int64Value(sc.MinSize) != 1 || aws.Int64Value(sc.DesiredSize) != 3 {
+		t.Fatalf("unexpected resumed scaling config %+v", sc)
+	}
+	if asg := fa.groups["self-managed"]; aws.Int64Value(asg.MinSize) != 1 || aws.Int64Value(asg.DesiredCapacity) != 2 {
+		t.Fatalf("unexpected resumed Auto Scaling group %+v", asg)
+	}
+	if _, err = os.Stat(cfg.StatePath); !os.IsNotExist(err) {
+		t.Fatalf("expected the state to be removed after resume, got %v", err)
+	}
+
+	ts2.cfg.ClusterName = "other"
+	if err = ts.saveState(); err != nil {
+		t.Fatal(err)
+	}
+	if err = ts2.loadState(); err == nil {
+		t.Fatal("expected error for the state of another cluster")
+	}
+}
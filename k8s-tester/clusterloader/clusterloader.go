@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -110,6 +111,13 @@ type TestOverride struct {
 	CL2SchedulerThroughputThreshold int  `json:"cl2_scheduler_throughput_threshold"`
 	PrometheusScrapeKubeProxy       bool `json:"prometheus_scrape_kube_proxy"`
 	EnableSystemPodMetrics          bool `json:"enable_system_pod_metrics"`
+
+	// Values is the free-form override key-value pairs (e.g., "CL2_SCHEDULER_THROUGHPUT_PODS": "5000"),
+	// rendered as-is into the override file. A key in the load test fields above is replaced.
+	Values map[string]string `json:"values"`
+	// OnlyValues is set to "true" to render only "Values", without the load test fields,
+	// for the test suites other than the load test (e.g., density, scheduler-throughput).
+	OnlyValues bool `json:"only_values"`
 }
 
 const (
@@ -159,10 +167,9 @@ func (to *TestOverride) Sync(lg *zap.Logger) error {
 		to.Path = DefaultTestOverridePath()
 	}
 
-	lg.Info("writing test override file", zap.String("path", to.Path))
-	buf := bytes.NewBuffer(nil)
-	tpl := template.Must(template.New("templateTestOverrides").Parse(templateTestOverrides))
-	if err := tpl.Execute(buf, to); err != nil {
+	lg.Info("writing test override file", zap.String("path", to.Path), zap.Int("values", len(to.Values)))
+	b, err := to.render()
+	if err != nil {
 		return err
 	}
 
@@ -171,7 +178,7 @@ func (to *TestOverride) Sync(lg *zap.Logger) error {
 	if err != nil {
 		return err
 	}
-	_, err = f.Write(buf.Bytes())
+	_, err = f.Write(b)
 	f.Close()
 	if err != nil {
 		return err
@@ -181,6 +188,52 @@ func (to *TestOverride) Sync(lg *zap.Logger) error {
 	return nil
 }
 
+// render renders the load test fields with the template, unless "OnlyValues" is "true",
+// and appends "Values" sorted by key, replacing the same keys from the template.
+func (to *TestOverride) render() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if !to.OnlyValues {
+		tb := bytes.NewBuffer(nil)
+		tpl := template.Must(template.New("templateTestOverrides").Parse(templateTestOverrides))
+		if err := tpl.Execute(tb, to); err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(strings.TrimSpace(tb.String()), "\n") {
+			key := strings.TrimSpace(strings.Split(line, ":")[0])
+			if _, ok := to.Values[key]; ok {
+				continue
+			}
+			buf.WriteString(line + "\n")
+		}
+	}
+
+	keys := make([]string, 0, len(to.Values))
+	for k := range to.Values {
+		if strings.TrimSpace(k) == "" || strings.Contains(k, ":") {
+			return nil, fmt.Errorf("invalid test override key %q", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "%s: %s\n", k, to.Values[k])
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseTestOverrideValues parses the "KEY=VALUE" pairs into the test override values.
+func ParseTestOverrideValues(kvs []string) (map[string]string, error) {
+	values := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		idx := strings.Index(kv, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid test override %q, expected 'KEY=VALUE'", kv)
+		}
+		values[strings.TrimSpace(kv[:idx])] = strings.TrimSpace(kv[idx+1:])
+	}
+	return values, nil
+}
+
 // ref. https://github.com/kubernetes/perf-tests/tree/master/clusterloader2/testing/load
 // ref. https://github.com/kubernetes/perf-tests/tree/master/clusterloader2/testing/overrides
 // ref. https://github.com/kubernetes/perf-tests/pull/1345
@@ -199,7 +252,8 @@ PROMETHEUS_SCRAPE_KUBE_PROXY: {{ .PrometheusScrapeKubeProxy }}
 ENABLE_SYSTEM_POD_METRICS: {{ .EnableSystemPodMetrics }}
 `
 
-func parsePodStartupLatency(fpath string) (perfData PerfData, err error) {
+// parsePerfData parses the clusterloader2 measurement JSON file.
+func parsePerfData(fpath string) (perfData PerfData, err error) {
 	rf, err := os.OpenFile(fpath, os.O_RDONLY, 0444)
 	if err != nil {
 		return PerfData{}, fmt.Errorf("failed to open %q (%v)", fpath, err)
@@ -19,7 +19,7 @@ func Test_installClusterloader(t *testing.T) {
 	}
 }
 
-func Test_parsePodStartupLatency(t *testing.T) {
+func Test_parsePerfData(t *testing.T) {
 	perfDatas := []PerfData{}
 	err := filepath.Walk("test-data", func(path string, info os.FileInfo, werr error) error {
 		if werr != nil {
@@ -31,7 +31,7 @@ func Test_parsePodStartupLatency(t *testing.T) {
 		if !strings.HasPrefix(filepath.Base(path), "PodStartupLatency-") {
 			return nil
 		}
-		p, perr := parsePodStartupLatency(path)
+		p, perr := parsePerfData(path)
 		if perr != nil {
 			return perr
 		}
@@ -50,3 +50,39 @@ func Test_parsePodStartupLatency(t *testing.T) {
 
 	fmt.Printf("%+v\n", mergePodStartupLatency(perfDatas...))
 }
+
+func Test_renderTestOverride(t *testing.T) {
+	values, err := ParseTestOverrideValues([]string{"PODS_PER_NODE=30", "CL2_SCHEDULER_THROUGHPUT_PODS = 5000", "CL2_ENABLE_DNSTESTS=true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := newDefaultTestOverride()
+	to.Values = values
+	b, err := to.render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	if strings.Count(out, "PODS_PER_NODE:") != 1 || !strings.Contains(out, "\nPODS_PER_NODE: 30\n") {
+		t.Fatalf("expected PODS_PER_NODE replaced, got\n%s", out)
+	}
+	if !strings.Contains(out, "NODES_PER_NAMESPACE: 10\n") || !strings.HasSuffix(out, "CL2_ENABLE_DNSTESTS: true\nCL2_SCHEDULER_THROUGHPUT_PODS: 5000\nPODS_PER_NODE: 30\n") {
+		t.Fatalf("unexpected override file\n%s", out)
+	}
+
+	to.OnlyValues = true
+	b, err = to.render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "CL2_ENABLE_DNSTESTS: true\nCL2_SCHEDULER_THROUGHPUT_PODS: 5000\nPODS_PER_NODE: 30\n"; string(b) != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, string(b))
+	}
+
+	if _, err = ParseTestOverrideValues([]string{"=1"}); err == nil {
+		t.Fatal("expected error for empty key")
+	}
+	if _, err = ParseTestOverrideValues([]string{"KEY"}); err == nil {
+		t.Fatal("expected error for missing value")
+	}
+}
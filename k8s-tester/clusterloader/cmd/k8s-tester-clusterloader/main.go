@@ -31,6 +31,8 @@ var (
 	kubectlDownloadURL string
 	kubectlPath        string
 	kubeconfigPath     string
+	runFromCluster     bool
+	namespace          string
 )
 
 func init() {
@@ -41,6 +43,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
 	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
 	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().BoolVar(&runFromCluster, "run-from-cluster", clusterloader.DefaultRunFromCluster, "to run clusterloader2 in cluster")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "namespace to run clusterloader2 pods in, with --run-from-cluster")
 
 	rootCmd.AddCommand(
 		newApply(),
@@ -65,11 +69,15 @@ var (
 	runs       int
 	runTimeout time.Duration
 
-	testConfigPath string
+	testConfigPaths    []string
+	cooldown           time.Duration
+	healthCheckTimeout time.Duration
 
-	runFromCluster    bool
-	nodes             int
-	enableExecService bool
+	runFromClusterImage        string
+	runFromClusterBinaryPath   string
+	runFromClusterBusyboxImage string
+	nodes                      int
+	enableExecService          bool
 
 	nodesPerNamespace int
 	podsPerNode       int
@@ -87,6 +95,15 @@ var (
 	cl2SchedulerThroughputThreshold int
 	prometheusScrapeKubeProxy       bool
 	enableSystemPodMetrics          bool
+
+	overrides    []string
+	overrideOnly bool
+
+	sloPodStartupLatency       time.Duration
+	sloAPICallLatency          time.Duration
+	sloAPINamespaceListLatency time.Duration
+	sloAPIClusterListLatency   time.Duration
+	sloFailOnViolation         bool
 )
 
 func newApply() *cobra.Command {
@@ -101,8 +118,14 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&provider, "provider", clusterloader.DefaultProvider, "clusterloader provider")
 	cmd.PersistentFlags().IntVar(&runs, "runs", clusterloader.DefaultRuns, "clusterloader runs")
 	cmd.PersistentFlags().DurationVar(&runTimeout, "run-timeout", clusterloader.DefaultRunTimeout, "clusterloader run timeout")
-	cmd.PersistentFlags().StringVar(&testConfigPath, "test-config-path", "", "clusterloader test config path")
-	cmd.PersistentFlags().BoolVar(&runFromCluster, "run-from-cluster", clusterloader.DefaultRunFromCluster, "to run clusterloader2 in cluster")
+	cmd.PersistentFlags().StringArrayVar(&testConfigPaths, "test-config-path", nil, "clusterloader test config path, repeat to run multiple test suites sequentially")
+	cmd.PersistentFlags().StringSliceVar(&testConfigPaths, "test-config-paths", nil, "clusterloader test config paths to run sequentially")
+	_ = cmd.PersistentFlags().MarkDeprecated("test-config-paths", "use --test-config-path multiple times")
+	cmd.PersistentFlags().DurationVar(&cooldown, "cooldown", clusterloader.DefaultCooldown, "clusterloader cooldown between test configs")
+	cmd.PersistentFlags().DurationVar(&healthCheckTimeout, "health-check-timeout", clusterloader.DefaultHealthCheckTimeout, "clusterloader cluster health check timeout between test configs")
+	cmd.PersistentFlags().StringVar(&runFromClusterImage, "run-from-cluster-image", "", "clusterloader2 container image to run in cluster")
+	cmd.PersistentFlags().StringVar(&runFromClusterBinaryPath, "run-from-cluster-binary-path", clusterloader.DefaultRunFromClusterBinaryPath, "clusterloader2 binary path in the container image")
+	cmd.PersistentFlags().StringVar(&runFromClusterBusyboxImage, "run-from-cluster-busybox-image", clusterloader.DefaultRunFromClusterBusyboxImage, "busybox image to copy the report out of the clusterloader2 pod")
 	cmd.PersistentFlags().IntVar(&nodes, "nodes", clusterloader.DefaultNodes, "clusterloader nodes")
 	cmd.PersistentFlags().BoolVar(&enableExecService, "enable-exec-service", clusterloader.DefaultEnableExecService, "clusterloader enable exec service")
 	cmd.PersistentFlags().IntVar(&nodesPerNamespace, "nodes-per-namespace", clusterloader.DefaultNodesPerNamespace, "clusterloader nodes per namespace")
@@ -118,6 +141,13 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().IntVar(&cl2SchedulerThroughputThreshold, "cl2-scheduler-throughput-threshold", clusterloader.DefaultCL2SchedulerThroughputThreshold, "clusterloader CL2 scheduler throughput threshold")
 	cmd.PersistentFlags().BoolVar(&prometheusScrapeKubeProxy, "prometheus-scrape-kube-proxy", clusterloader.DefaultPrometheusScrapeKubeProxy, "clusterloader prometheus scrape kube-proxy")
 	cmd.PersistentFlags().BoolVar(&enableSystemPodMetrics, "enable-system-pod-metrics", clusterloader.DefaultEnableSystemPodMetrics, "clusterloader enable system pod metrics")
+	cmd.PersistentFlags().StringArrayVar(&overrides, "override", nil, "clusterloader test override 'KEY=VALUE', repeat for multiple overrides")
+	cmd.PersistentFlags().BoolVar(&overrideOnly, "override-only", false, "'true' to render only --override values without the load test overrides")
+	cmd.PersistentFlags().DurationVar(&sloPodStartupLatency, "slo-pod-startup-latency", clusterloader.DefaultSLOPodStartupLatency, "99th percentile pod startup latency SLO")
+	cmd.PersistentFlags().DurationVar(&sloAPICallLatency, "slo-api-call-latency", clusterloader.DefaultSLOAPICallLatency, "99th percentile single object API call latency SLO")
+	cmd.PersistentFlags().DurationVar(&sloAPINamespaceListLatency, "slo-api-namespace-list-latency", clusterloader.DefaultSLOAPINamespaceListLatency, "99th percentile namespace-scoped LIST call latency SLO")
+	cmd.PersistentFlags().DurationVar(&sloAPIClusterListLatency, "slo-api-cluster-list-latency", clusterloader.DefaultSLOAPIClusterListLatency, "99th percentile cluster-scoped LIST call latency SLO")
+	cmd.PersistentFlags().BoolVar(&sloFailOnViolation, "slo-fail-on-violation", clusterloader.DefaultSLOFailOnViolation, "'true' to fail when any SLO is violated")
 
 	return cmd
 }
@@ -139,6 +169,12 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		lg.Panic("failed to create client", zap.Error(err))
 	}
 
+	overrideValues, err := clusterloader.ParseTestOverrideValues(overrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse --override (%v)\n", err)
+		os.Exit(1)
+	}
+
 	cfg := &clusterloader.Config{
 		Prompt:       prompt,
 		Logger:       lg,
@@ -154,11 +190,23 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		Runs:       runs,
 		RunTimeout: runTimeout,
 
-		TestConfigPath: testConfigPath,
+		TestConfigPaths:    testConfigPaths,
+		Cooldown:           cooldown,
+		HealthCheckTimeout: healthCheckTimeout,
+
+		RunFromCluster:             runFromCluster,
+		Namespace:                  namespace,
+		RunFromClusterImage:        runFromClusterImage,
+		RunFromClusterBinaryPath:   runFromClusterBinaryPath,
+		RunFromClusterBusyboxImage: runFromClusterBusyboxImage,
+		Nodes:                      nodes,
+		EnableExecService:          enableExecService,
 
-		RunFromCluster:    runFromCluster,
-		Nodes:             nodes,
-		EnableExecService: enableExecService,
+		SLOPodStartupLatency:       sloPodStartupLatency,
+		SLOAPICallLatency:          sloAPICallLatency,
+		SLOAPINamespaceListLatency: sloAPINamespaceListLatency,
+		SLOAPIClusterListLatency:   sloAPIClusterListLatency,
+		SLOFailOnViolation:         sloFailOnViolation,
 
 		TestOverride: &clusterloader.TestOverride{
 			Path: clusterloader.DefaultTestOverridePath(),
@@ -179,6 +227,9 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 			CL2SchedulerThroughputThreshold: cl2SchedulerThroughputThreshold,
 			PrometheusScrapeKubeProxy:       prometheusScrapeKubeProxy,
 			EnableSystemPodMetrics:          enableSystemPodMetrics,
+
+			Values:     overrideValues,
+			OnlyValues: overrideOnly,
 		},
 	}
 	if err := cfg.ValidateAndSetDefaults(); err != nil {
@@ -227,6 +278,9 @@ func createDeleteFunc(cmd *cobra.Command, args []string) {
 		Logger:    lg,
 		LogWriter: logWriter,
 		Client:    cli,
+
+		RunFromCluster: runFromCluster,
+		Namespace:      namespace,
 	}
 
 	ts := clusterloader.New(cfg)
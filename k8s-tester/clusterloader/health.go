@@ -0,0 +1,100 @@
+package clusterloader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// settle waits for "Cooldown" and for the cluster to be healthy,
+// before running the next test configuration.
+func (ts *tester) settle() error {
+	ts.cfg.Logger.Info("cooling down before next test config", zap.String("cooldown", ts.cfg.Cooldown.String()))
+	select {
+	case <-ts.cfg.Stopc:
+		return fmt.Errorf("stopped while cooling down")
+	case <-ts.rootCtx.Done():
+		return fmt.Errorf("timed out while cooling down")
+	case <-time.After(ts.cfg.Cooldown):
+	}
+	if ts.cfg.HealthCheckTimeout == 0 {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("waiting for cluster to be healthy", zap.String("timeout", ts.cfg.HealthCheckTimeout.String()))
+	waitDur := time.Now().Add(ts.cfg.HealthCheckTimeout)
+	var problems []string
+	for time.Now().Before(waitDur) {
+		problems = ts.checkClusterHealth()
+		if len(problems) == 0 {
+			ts.cfg.Logger.Info("cluster is healthy")
+			return nil
+		}
+		ts.cfg.Logger.Info("cluster is not healthy yet", zap.Strings("problems", problems))
+		select {
+		case <-ts.cfg.Stopc:
+			return fmt.Errorf("stopped while waiting for cluster to be healthy")
+		case <-ts.rootCtx.Done():
+			return fmt.Errorf("timed out while waiting for cluster to be healthy")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return fmt.Errorf("cluster not healthy after %v (%s)", ts.cfg.HealthCheckTimeout, strings.Join(problems, ", "))
+}
+
+// checkClusterHealth returns the problems that may skew the next measurements:
+// not-ready nodes, terminating namespaces left by the previous test, and "kube-system" Pods that are not running.
+func (ts *tester) checkClusterHealth() (problems []string) {
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return []string{fmt.Sprintf("failed to list nodes (%v)", err)}
+	}
+	notReady := 0
+	for _, node := range nodes {
+		ready := false
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == core_v1.NodeReady && cond.Status == core_v1.ConditionTrue {
+				ready = true
+			}
+		}
+		if !ready {
+			notReady++
+		}
+	}
+	if notReady > 0 {
+		problems = append(problems, fmt.Sprintf("%d node(s) not ready", notReady))
+	}
+
+	namespaces, err := client.ListNamespaces(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return append(problems, fmt.Sprintf("failed to list namespaces (%v)", err))
+	}
+	terminating := 0
+	for _, ns := range namespaces {
+		if ns.Status.Phase == core_v1.NamespaceTerminating {
+			terminating++
+		}
+	}
+	if terminating > 0 {
+		problems = append(problems, fmt.Sprintf("%d namespace(s) terminating", terminating))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.Client.KubernetesClient().CoreV1().Pods("kube-system").List(ctx, meta_v1.ListOptions{})
+	cancel()
+	if err != nil {
+		return append(problems, fmt.Sprintf("failed to list kube-system pods (%v)", err))
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != core_v1.PodRunning && pod.Status.Phase != core_v1.PodSucceeded {
+			problems = append(problems, fmt.Sprintf("pod kube-system/%s %s", pod.Name, pod.Status.Phase))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,534 @@
+package clusterloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	inClusterAppName                    = "clusterloader"
+	inClusterServiceAccountName         = "clusterloader-service-account"
+	inClusterRBACRoleName               = "clusterloader-rbac-role"
+	inClusterRBACClusterRoleBindingName = "clusterloader-rbac-role-binding"
+	inClusterConfigmapName              = "clusterloader-test-config"
+	inClusterConfigmapOverridesKey      = "testoverrides.yaml"
+
+	inClusterContainerName       = "clusterloader2"
+	inClusterReportContainerName = "report"
+	inClusterConfigDir           = "/clusterloader2/config"
+	inClusterOverridesDir        = "/clusterloader2/overrides"
+	inClusterReportDir           = "/clusterloader2/report"
+
+	// ref. https://kubernetes.io/docs/concepts/configuration/configmap/#motivation
+	maxConfigmapBytes = 1024 * 1024
+)
+
+// createInClusterResources creates the namespace, RBAC, and test configuration
+// to run "clusterloader2" Pods with the in-cluster config.
+func (ts *tester) createInClusterResources() error {
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+	if err := ts.createServiceAccount(); err != nil {
+		return err
+	}
+	if err := ts.createRBACClusterRole(); err != nil {
+		return err
+	}
+	if err := ts.createRBACClusterRoleBinding(); err != nil {
+		return err
+	}
+	overrides, err := ioutil.ReadFile(ts.cfg.TestOverride.Path)
+	if err != nil {
+		return err
+	}
+	ts.configItems = make([][]core_v1.KeyToPath, 0, len(ts.cfg.GetTestConfigPaths()))
+	for i, p := range ts.cfg.GetTestConfigPaths() {
+		items, err := ts.createConfigmap(i, p, overrides)
+		if err != nil {
+			return err
+		}
+		ts.configItems = append(ts.configItems, items)
+	}
+	return nil
+}
+
+func (ts *tester) deleteInClusterResources() (errs []string) {
+	if err := client.DeleteRBACClusterRoleBinding(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		inClusterRBACClusterRoleBindingName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete RBAC cluster role binding (%v)", err))
+	}
+	if err := client.DeleteRBACClusterRole(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		inClusterRBACRoleName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete RBAC cluster role (%v)", err))
+	}
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+	return errs
+}
+
+func (ts *tester) createServiceAccount() error {
+	ts.cfg.Logger.Info("creating clusterloader ServiceAccount")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ServiceAccounts(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ServiceAccount{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      inClusterServiceAccountName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": inClusterAppName,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("clusterloader ServiceAccount already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create clusterloader ServiceAccount (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created clusterloader ServiceAccount")
+	return nil
+}
+
+// "clusterloader2" creates and deletes test namespaces and arbitrary objects
+// defined in the test configuration, and scrapes the metrics endpoints,
+// thus requires cluster-wide access.
+// ref. https://github.com/kubernetes/perf-tests/pull/1295
+func (ts *tester) createRBACClusterRole() error {
+	ts.cfg.Logger.Info("creating clusterloader RBAC ClusterRole")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		ClusterRoles().
+		Create(
+			ctx,
+			&rbac_v1.ClusterRole{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRole",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: inClusterRBACRoleName,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": inClusterAppName,
+					},
+				},
+				Rules: []rbac_v1.PolicyRule{
+					{
+						APIGroups: []string{"*"},
+						Resources: []string{"*"},
+						Verbs:     []string{"*"},
+					},
+					{
+						NonResourceURLs: []string{"*"},
+						Verbs:           []string{"get"},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("clusterloader RBAC ClusterRole already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create clusterloader RBAC ClusterRole (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created clusterloader RBAC ClusterRole")
+	return nil
+}
+
+func (ts *tester) createRBACClusterRoleBinding() error {
+	ts.cfg.Logger.Info("creating clusterloader RBAC ClusterRoleBinding")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		ClusterRoleBindings().
+		Create(
+			ctx,
+			&rbac_v1.ClusterRoleBinding{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRoleBinding",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: inClusterRBACClusterRoleBindingName,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": inClusterAppName,
+					},
+				},
+				RoleRef: rbac_v1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "ClusterRole",
+					Name:     inClusterRBACRoleName,
+				},
+				Subjects: []rbac_v1.Subject{
+					{
+						APIGroup:  "",
+						Kind:      "ServiceAccount",
+						Name:      inClusterServiceAccountName,
+						Namespace: ts.cfg.Namespace,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("clusterloader RBAC ClusterRoleBinding already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create clusterloader RBAC ClusterRoleBinding (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created clusterloader RBAC ClusterRoleBinding")
+	return nil
+}
+
+// createConfigmap stores the test configuration directory (including the modules
+// referenced by the test configuration) and the test overrides in a ConfigMap,
+// keyed by index since ConfigMap keys cannot have path separators.
+// The relative paths are restored with the returned volume items.
+func (ts *tester) createConfigmap(configIdx int, testConfigPath string, overrides []byte) (items []core_v1.KeyToPath, err error) {
+	name := fmt.Sprintf("%s-%d", inClusterConfigmapName, configIdx)
+	ts.cfg.Logger.Info("creating clusterloader test config map", zap.String("name", name), zap.String("test-config-path", testConfigPath))
+
+	data := make(map[string]string)
+	items = make([]core_v1.KeyToPath, 0)
+	total := 0
+	rootDir := filepath.Dir(testConfigPath)
+	err = filepath.Walk(rootDir, func(p string, info os.FileInfo, ferr error) error {
+		if ferr != nil {
+			return ferr
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(rootDir, p)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("config-%03d", len(items))
+		data[key] = string(b)
+		total += len(b)
+		items = append(items, core_v1.KeyToPath{Key: key, Path: filepath.ToSlash(rel)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test config directory %q (%v)", rootDir, err)
+	}
+	data[inClusterConfigmapOverridesKey] = string(overrides)
+	total += len(overrides)
+	if total > maxConfigmapBytes {
+		return nil, fmt.Errorf("test config directory %q too large for config map (%d bytes, limit %d)", rootDir, total, maxConfigmapBytes)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ConfigMaps(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ConfigMap{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": inClusterAppName,
+					},
+				},
+				Data: data,
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("clusterloader test config map already exists", zap.String("name", name))
+			return items, nil
+		}
+		return nil, fmt.Errorf("failed to create clusterloader test config map (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created clusterloader test config map", zap.String("name", name), zap.Int("files", len(items)), zap.Int("bytes", total))
+	return items, nil
+}
+
+func (ts *tester) getInClusterCL2Args(testConfigPath string) (args []string) {
+	rel, _ := filepath.Rel(filepath.Dir(testConfigPath), testConfigPath)
+	return []string{
+		"--logtostderr",
+		"--alsologtostderr",
+		fmt.Sprintf("--enable-exec-service=%v", ts.cfg.EnableExecService),
+		"--testconfig=" + inClusterConfigDir + "/" + filepath.ToSlash(rel),
+		"--testoverrides=" + inClusterOverridesDir + "/" + inClusterConfigmapOverridesKey,
+		"--report-dir=" + inClusterReportDir,
+		"--nodes=" + fmt.Sprintf("%d", ts.cfg.Nodes),
+		"--provider=" + ts.cfg.Provider,
+		// ref. https://github.com/kubernetes/perf-tests/pull/1295
+		"--run-from-cluster=true",
+	}
+}
+
+// runCL2InCluster runs "clusterloader2" in a Pod, streams its logs to the test log file,
+// and copies the report directory out of the Pod, whether the run succeeds or fails.
+// The report volume is kept by a sidecar container, after "clusterloader2" exits.
+func (ts *tester) runCL2InCluster(idx int, configIdx int) (err error) {
+	podName := fmt.Sprintf("%s-%d", inClusterAppName, idx)
+	if err = ts.createPod(podName, configIdx); err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := ts.copyReport(podName, idx); cerr != nil {
+			ts.cfg.Logger.Warn("failed to copy report from pod", zap.String("pod", podName), zap.Error(cerr))
+			if err == nil {
+				err = cerr
+			}
+		}
+		if derr := client.DeletePod(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, podName); derr != nil {
+			ts.cfg.Logger.Warn("failed to delete pod", zap.String("pod", podName), zap.Error(derr))
+		}
+	}()
+
+	exitCode, err := ts.streamPodLogs(podName)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("command failed with exit code %d", exitCode)
+	}
+	return nil
+}
+
+func (ts *tester) createPod(podName string, configIdx int) error {
+	configmapName := fmt.Sprintf("%s-%d", inClusterConfigmapName, configIdx)
+	ts.cfg.Logger.Info("creating clusterloader pod", zap.String("pod", podName), zap.String("image", ts.cfg.RunFromClusterImage))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Pod{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      podName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": inClusterAppName,
+					},
+				},
+				Spec: core_v1.PodSpec{
+					ServiceAccountName: inClusterServiceAccountName,
+					RestartPolicy:      core_v1.RestartPolicyNever,
+					Containers: []core_v1.Container{
+						{
+							Name:            inClusterContainerName,
+							Image:           ts.cfg.RunFromClusterImage,
+							ImagePullPolicy: core_v1.PullIfNotPresent,
+							Command:         []string{ts.cfg.RunFromClusterBinaryPath},
+							Args:            ts.getInClusterCL2Args(ts.cfg.GetTestConfigPaths()[configIdx]),
+							WorkingDir:      inClusterConfigDir,
+							VolumeMounts: []core_v1.VolumeMount{
+								{Name: "config", MountPath: inClusterConfigDir, ReadOnly: true},
+								{Name: "overrides", MountPath: inClusterOverridesDir, ReadOnly: true},
+								{Name: "report", MountPath: inClusterReportDir},
+							},
+						},
+						{
+							Name:            inClusterReportContainerName,
+							Image:           ts.cfg.RunFromClusterBusyboxImage,
+							ImagePullPolicy: core_v1.PullIfNotPresent,
+							Command:         []string{"/bin/sh", "-c", "trap 'exit 0' TERM; while true; do sleep 5; done"},
+							VolumeMounts: []core_v1.VolumeMount{
+								{Name: "report", MountPath: inClusterReportDir},
+							},
+						},
+					},
+					Volumes: []core_v1.Volume{
+						{
+							Name: "config",
+							VolumeSource: core_v1.VolumeSource{
+								ConfigMap: &core_v1.ConfigMapVolumeSource{
+									LocalObjectReference: core_v1.LocalObjectReference{Name: configmapName},
+									Items:                ts.configItems[configIdx],
+								},
+							},
+						},
+						{
+							Name: "overrides",
+							VolumeSource: core_v1.VolumeSource{
+								ConfigMap: &core_v1.ConfigMapVolumeSource{
+									LocalObjectReference: core_v1.LocalObjectReference{Name: configmapName},
+									Items: []core_v1.KeyToPath{
+										{Key: inClusterConfigmapOverridesKey, Path: inClusterConfigmapOverridesKey},
+									},
+								},
+							},
+						},
+						{
+							Name: "report",
+							VolumeSource: core_v1.VolumeSource{
+								EmptyDir: &core_v1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create clusterloader pod (%v)", err)
+	}
+	ts.cfg.Logger.Info("created clusterloader pod", zap.String("pod", podName))
+	return nil
+}
+
+// streamPodLogs follows the "clusterloader2" container logs into the test log file,
+// reconnecting until the container terminates, and returns its exit code.
+func (ts *tester) streamPodLogs(podName string) (exitCode int32, err error) {
+	var since *meta_v1.Time
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return 0, fmt.Errorf("stopped while running pod %q", podName)
+		case <-ts.rootCtx.Done():
+			return 0, fmt.Errorf("timed out while running pod %q", podName)
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(ts.rootCtx, time.Minute)
+		pod, err := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Get(ctx, podName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get pod", zap.String("pod", podName), zap.Error(err))
+			continue
+		}
+		var state core_v1.ContainerState
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == inClusterContainerName {
+				state = cs.State
+			}
+		}
+		if state.Running == nil && state.Terminated == nil {
+			if pod.Status.Phase == core_v1.PodFailed {
+				return 0, fmt.Errorf("pod %q failed (%s)", podName, pod.Status.Message)
+			}
+			ts.cfg.Logger.Info("waiting for clusterloader container", zap.String("pod", podName), zap.String("phase", string(pod.Status.Phase)))
+			continue
+		}
+
+		now := meta_v1.Now()
+		stream, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Pods(ts.cfg.Namespace).
+			GetLogs(podName, &core_v1.PodLogOptions{
+				Container: inClusterContainerName,
+				Follow:    state.Terminated == nil,
+				SinceTime: since,
+			}).
+			Stream(ts.rootCtx)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to stream pod logs", zap.String("pod", podName), zap.Error(err))
+			continue
+		}
+		_, cerr := io.Copy(ts.testLogFile, stream)
+		stream.Close()
+		if cerr != nil {
+			ts.cfg.Logger.Warn("pod logs stream interrupted", zap.String("pod", podName), zap.Error(cerr))
+		}
+		since = &now
+
+		if state.Terminated != nil {
+			ts.cfg.Logger.Info("clusterloader container terminated",
+				zap.String("pod", podName),
+				zap.Int32("exit-code", state.Terminated.ExitCode),
+				zap.String("reason", state.Terminated.Reason),
+			)
+			return state.Terminated.ExitCode, nil
+		}
+	}
+}
+
+// copyReport copies the report directory out of the Pod to "TestReportDir",
+// with a sub-directory per run.
+func (ts *tester) copyReport(podName string, idx int) error {
+	dst := filepath.Join(ts.cfg.TestReportDir, fmt.Sprintf("run-%d", idx))
+	args := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"cp",
+		"--container=" + inClusterReportContainerName,
+		podName + ":" + inClusterReportDir,
+		dst,
+	}
+	cmd := strings.Join(args, " ")
+	ts.cfg.Logger.Info("copying report from pod", zap.String("command", cmd))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	cancel()
+	out := strings.TrimSpace(string(output))
+	if err != nil {
+		return fmt.Errorf("'%s' failed (output %q, error %v)", cmd, out, err)
+	}
+	ts.cfg.Logger.Info("copied report from pod", zap.String("pod", podName), zap.String("dir", dst))
+	return nil
+}
@@ -0,0 +1,55 @@
+package clusterloader
+
+import (
+	"fmt"
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the ServiceAccount, the RBAC, the test config maps and the Pod
+// of the first run with "RunFromCluster", against a fake client. Otherwise,
+// clusterloader runs locally and nothing is rendered.
+func (ts *tester) Render(w io.Writer) error {
+	if !ts.cfg.RunFromCluster {
+		return nil
+	}
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createServiceAccount(); err != nil {
+		return err
+	}
+	if err := rt.createRBACClusterRole(); err != nil {
+		return err
+	}
+	if err := rt.createRBACClusterRoleBinding(); err != nil {
+		return err
+	}
+	overrides, err := cfg.TestOverride.render()
+	if err != nil {
+		return err
+	}
+	rt.configItems = make([][]core_v1.KeyToPath, 0, len(cfg.GetTestConfigPaths()))
+	for i, p := range cfg.GetTestConfigPaths() {
+		items, err := rt.createConfigmap(i, p, overrides)
+		if err != nil {
+			return err
+		}
+		rt.configItems = append(rt.configItems, items)
+	}
+	if err := rt.createPod(fmt.Sprintf("%s-%d", inClusterAppName, 0), 0); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
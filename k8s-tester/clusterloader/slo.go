@@ -0,0 +1,222 @@
+package clusterloader
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SLIs is the key service level indicators of the clusterloader runs.
+// ref. https://github.com/kubernetes/community/blob/master/sig-scalability/slos/slos.md
+type SLIs struct {
+	// PodStartupLatencyP50 is the 50th percentile pod startup latency in milliseconds.
+	PodStartupLatencyP50 float64 `json:"pod_startup_latency_p50_ms"`
+	// PodStartupLatencyP90 is the 90th percentile pod startup latency in milliseconds.
+	PodStartupLatencyP90 float64 `json:"pod_startup_latency_p90_ms"`
+	// PodStartupLatencyP99 is the 99th percentile pod startup latency in milliseconds.
+	PodStartupLatencyP99 float64 `json:"pod_startup_latency_p99_ms"`
+
+	// APICallLatencyP99 is the highest 99th percentile latency
+	// of the single object (mutating or non-LIST) API calls, in milliseconds.
+	APICallLatencyP99 float64 `json:"api_call_latency_p99_ms"`
+	// APICallLatencyP99Call is the API call with the highest 99th percentile latency
+	// (e.g., "POST pods (namespace)").
+	APICallLatencyP99Call string `json:"api_call_latency_p99_call"`
+	// APIListLatencyP99 is the highest 99th percentile latency of the LIST API calls, in milliseconds.
+	APIListLatencyP99 float64 `json:"api_list_latency_p99_ms"`
+	// APIListLatencyP99Call is the LIST API call with the highest 99th percentile latency.
+	APIListLatencyP99Call string `json:"api_list_latency_p99_call"`
+
+	// TestCases is the number of test cases in the junit XML reports.
+	TestCases int `json:"test_cases"`
+	// TestFailures is the number of failed test cases in the junit XML reports.
+	TestFailures int `json:"test_failures"`
+
+	// Violations is the list of SLO violations and failed test cases.
+	Violations []string `json:"violations"`
+}
+
+// SLOThresholds is the SLO thresholds to mark the tester failed.
+type SLOThresholds struct {
+	PodStartupLatency       time.Duration
+	APICallLatency          time.Duration
+	APINamespaceListLatency time.Duration
+	APIClusterListLatency   time.Duration
+}
+
+type cl2JUnitTestSuite struct {
+	TestCases []cl2JUnitTestCase `xml:"testcase"`
+}
+
+type cl2JUnitTestCase struct {
+	Name    string `xml:"name,attr"`
+	Failure *struct {
+		Message  string `xml:"message,attr"`
+		Contents string `xml:",chardata"`
+	} `xml:"failure"`
+}
+
+// parseJUnit reads the clusterloader2 junit XML report,
+// and returns the number of test cases and the failed ones.
+// Failures only from the metrics that cannot be gathered on EKS are ignored.
+func parseJUnit(fpath string) (tests int, failed []string, err error) {
+	b, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return 0, nil, err
+	}
+	var suite cl2JUnitTestSuite
+	if err = xml.Unmarshal(b, &suite); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse junit XML %q (%v)", fpath, err)
+	}
+	for _, tc := range suite.TestCases {
+		if tc.Failure == nil {
+			continue
+		}
+		msg := strings.TrimSpace(tc.Failure.Message + " " + tc.Failure.Contents)
+		if strings.Contains(msg, skipErr) {
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("%s: %s", tc.Name, msg))
+	}
+	return len(suite.TestCases), failed, nil
+}
+
+// apiCallName returns the API call name from the "APIResponsiveness" data item labels.
+func apiCallName(labels map[string]string) string {
+	resource := labels["Resource"]
+	if sub := labels["Subresource"]; sub != "" {
+		resource += "/" + sub
+	}
+	name := labels["Verb"] + " " + resource
+	if scope := labels["Scope"]; scope != "" {
+		name += " (" + scope + ")"
+	}
+	return name
+}
+
+// evaluateSLOs computes the SLIs from the merged pod startup latency,
+// the API responsiveness measurements, and the junit failures,
+// and records the SLO violations against the thresholds (zero to skip).
+func evaluateSLOs(podStartup PerfData, apiCalls []PerfData, testCases int, testFailures []string, th SLOThresholds) (slis SLIs) {
+	slis.Violations = make([]string, 0)
+	for _, item := range podStartup.DataItems {
+		if item.Labels["Metric"] != "pod_startup" {
+			continue
+		}
+		slis.PodStartupLatencyP50 = item.Data["Perc50"]
+		slis.PodStartupLatencyP90 = item.Data["Perc90"]
+		slis.PodStartupLatencyP99 = item.Data["Perc99"]
+	}
+	if th.PodStartupLatency > 0 && slis.PodStartupLatencyP99 > toMs(th.PodStartupLatency) {
+		slis.Violations = append(slis.Violations, fmt.Sprintf("pod startup latency p99 %.0fms exceeds %v", slis.PodStartupLatencyP99, th.PodStartupLatency))
+	}
+
+	var callViolations []string
+	for _, d := range apiCalls {
+		for _, item := range d.DataItems {
+			verb := item.Labels["Verb"]
+			if verb == "" || verb == "WATCH" || verb == "CONNECT" {
+				continue
+			}
+			p99, name := item.Data["Perc99"], apiCallName(item.Labels)
+			threshold := th.APICallLatency
+			if verb == "LIST" {
+				switch item.Labels["Scope"] {
+				case "namespace":
+					threshold = th.APINamespaceListLatency
+				case "cluster":
+					threshold = th.APIClusterListLatency
+				}
+				if p99 > slis.APIListLatencyP99 {
+					slis.APIListLatencyP99, slis.APIListLatencyP99Call = p99, name
+				}
+			} else if p99 > slis.APICallLatencyP99 {
+				slis.APICallLatencyP99, slis.APICallLatencyP99Call = p99, name
+			}
+			if threshold > 0 && p99 > toMs(threshold) {
+				callViolations = append(callViolations, fmt.Sprintf("API call %q latency p99 %.0fms exceeds %v", name, p99, threshold))
+			}
+		}
+	}
+	sort.Strings(callViolations)
+	slis.Violations = append(slis.Violations, callViolations...)
+
+	slis.TestCases, slis.TestFailures = testCases, len(testFailures)
+	for _, f := range testFailures {
+		slis.Violations = append(slis.Violations, "test failed "+f)
+	}
+	return slis
+}
+
+func toMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// checkSLOs parses the junit XML reports and the API responsiveness measurements
+// in the report directory, writes the SLIs to "SLIsPath", and returns an error
+// if any SLO is violated and "SLOFailOnViolation" is "true".
+func (ts *tester) checkSLOs() error {
+	var (
+		apiCalls     []PerfData
+		testCases    int
+		testFailures []string
+	)
+	ts.cfg.MeasurementPaths = make([]string, 0)
+	err := filepath.Walk(ts.cfg.TestReportDir, func(path string, info os.FileInfo, ferr error) error {
+		if ferr != nil {
+			return ferr
+		}
+		if info.IsDir() {
+			return nil
+		}
+		base := filepath.Base(path)
+		switch {
+		case strings.HasPrefix(base, "junit") && strings.HasSuffix(base, ".xml"):
+			n, failed, perr := parseJUnit(path)
+			if perr != nil {
+				return perr
+			}
+			testCases += n
+			testFailures = append(testFailures, failed...)
+		case strings.HasPrefix(base, "APIResponsiveness") && strings.HasSuffix(base, ".json"):
+			d, perr := parsePerfData(path)
+			if perr != nil {
+				return fmt.Errorf("failed to parse %q (%v)", path, perr)
+			}
+			apiCalls = append(apiCalls, d)
+			ts.cfg.MeasurementPaths = append(ts.cfg.MeasurementPaths, path)
+		case strings.HasPrefix(base, "PodStartupLatency_") && strings.HasSuffix(base, ".json"):
+			ts.cfg.MeasurementPaths = append(ts.cfg.MeasurementPaths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.SLIs = evaluateSLOs(ts.cfg.PodStartupLatency, apiCalls, testCases, testFailures, SLOThresholds{
+		PodStartupLatency:       ts.cfg.SLOPodStartupLatency,
+		APICallLatency:          ts.cfg.SLOAPICallLatency,
+		APINamespaceListLatency: ts.cfg.SLOAPINamespaceListLatency,
+		APIClusterListLatency:   ts.cfg.SLOAPIClusterListLatency,
+	})
+	b, err := json.MarshalIndent(ts.cfg.SLIs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(ts.cfg.SLIsPath, b, 0600); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nclusterloader SLIs (%q):\n\n%s\n\n", ts.cfg.SLIsPath, string(b))
+	if len(ts.cfg.SLIs.Violations) > 0 && ts.cfg.SLOFailOnViolation {
+		return fmt.Errorf("%d SLO violation(s) (%s)", len(ts.cfg.SLIs.Violations), strings.Join(ts.cfg.SLIs.Violations, "; "))
+	}
+	return nil
+}
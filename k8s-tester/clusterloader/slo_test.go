@@ -0,0 +1,99 @@
+package clusterloader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_evaluateSLOs(t *testing.T) {
+	podStartup, err := parsePerfData("test-data/PodStartupLatency-1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiCalls := []PerfData{{
+		DataItems: []DataItem{
+			{
+				Data:   map[string]float64{"Perc50": 10, "Perc90": 50, "Perc99": 1500},
+				Unit:   "ms",
+				Labels: map[string]string{"Resource": "pods", "Verb": "POST", "Scope": "namespace"},
+			},
+			{
+				Data:   map[string]float64{"Perc50": 10, "Perc90": 50, "Perc99": 200},
+				Unit:   "ms",
+				Labels: map[string]string{"Resource": "pods", "Subresource": "status", "Verb": "PATCH", "Scope": "namespace"},
+			},
+			{
+				Data:   map[string]float64{"Perc50": 100, "Perc90": 500, "Perc99": 4000},
+				Unit:   "ms",
+				Labels: map[string]string{"Resource": "pods", "Verb": "LIST", "Scope": "namespace"},
+			},
+			{
+				Data:   map[string]float64{"Perc50": 100, "Perc90": 500, "Perc99": 90000},
+				Unit:   "ms",
+				Labels: map[string]string{"Resource": "pods", "Verb": "WATCH", "Scope": "cluster"},
+			},
+		},
+	}}
+	th := SLOThresholds{
+		PodStartupLatency:       time.Hour,
+		APICallLatency:          time.Second,
+		APINamespaceListLatency: 5 * time.Second,
+		APIClusterListLatency:   30 * time.Second,
+	}
+	slis := evaluateSLOs(podStartup, apiCalls, 3, nil, th)
+	if slis.PodStartupLatencyP99 == 0 {
+		t.Fatalf("expected pod startup latency, got %+v", slis)
+	}
+	if slis.APICallLatencyP99 != 1500 || slis.APICallLatencyP99Call != "POST pods (namespace)" {
+		t.Fatalf("unexpected API call latency %+v", slis)
+	}
+	if slis.APIListLatencyP99 != 4000 || slis.APIListLatencyP99Call != "LIST pods (namespace)" {
+		t.Fatalf("unexpected API list latency %+v", slis)
+	}
+	if len(slis.Violations) != 1 || !strings.Contains(slis.Violations[0], "POST pods (namespace)") {
+		t.Fatalf("unexpected violations %v", slis.Violations)
+	}
+
+	th.PodStartupLatency = time.Millisecond
+	slis = evaluateSLOs(podStartup, nil, 3, []string{"load: timed out"}, th)
+	if len(slis.Violations) != 2 || slis.TestCases != 3 || slis.TestFailures != 1 {
+		t.Fatalf("unexpected SLIs %+v", slis)
+	}
+}
+
+func Test_parseJUnit(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "clusterloader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "junit.xml")
+	if err = ioutil.WriteFile(p, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="ClusterLoaderV2" tests="3" failures="2" errors="0" time="600">
+  <testcase name="load overall (testing/load/config.yaml)" classname="ClusterLoaderV2" time="600"></testcase>
+  <testcase name="load: [step: 01] Collecting measurements [00] - TestMetrics" classname="ClusterLoaderV2" time="0">
+    <failure type="Failure">action gather failed for SchedulingMetrics measurement</failure>
+  </testcase>
+  <testcase name="load: [step: 02] Waiting for pods [00] - WaitForControlledPodsRunning" classname="ClusterLoaderV2" time="0">
+    <failure type="Failure">timed out</failure>
+  </testcase>
+</testsuite>
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests, failed, err := parseJUnit(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tests != 3 {
+		t.Fatalf("expected 3 tests, got %d", tests)
+	}
+	if len(failed) != 1 || !strings.Contains(failed[0], "WaitForControlledPodsRunning") {
+		t.Fatalf("unexpected failed %v", failed)
+	}
+}
@@ -21,14 +21,15 @@ import (
 	"github.com/aws/aws-k8s-tester/client"
 	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
 	"github.com/aws/aws-k8s-tester/utils/file"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
 	"github.com/dustin/go-humanize"
 	"github.com/manifoldco/promptui"
 	"github.com/mholt/archiver/v3"
 	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
 )
 
-// TODO: support s3 uploads
-
 // Config defines parameters for Kubernetes clusterloader tests.
 type Config struct {
 	Enable bool `json:"enable"`
@@ -64,12 +65,38 @@ type Config struct {
 	// ref. https://github.com/kubernetes/perf-tests/blob/master/clusterloader2/testing/load/config.yaml
 	// Set via "--testconfig" flag.
 	TestConfigPath string `json:"test_config_path"`
-
-	// RunFromCluster is set 'true' to override KUBECONFIG set in "Client" field.
-	// If "false", instead pass Client.Config().KubeconfigPath to "--kubeconfig" flag.
+	// TestConfigPaths is the list of clusterloader2 test configuration files
+	// to run sequentially, each for "Runs" times, instead of "TestConfigPath".
+	// Back-to-back suites interfere with each other's measurements,
+	// so the tester waits "Cooldown" and "HealthCheckTimeout" between them.
+	TestConfigPaths []string `json:"test_config_paths"`
+	// Cooldown is the period to let the cluster settle between test configurations
+	// (e.g., for the deleted test namespaces to be garbage collected).
+	Cooldown       time.Duration `json:"cooldown"`
+	CooldownString string        `json:"cooldown_string" read-only:"true"`
+	// HealthCheckTimeout is the timeout to wait for the cluster to be healthy
+	// between test configurations, after "Cooldown". The cluster is healthy
+	// when all nodes are ready, no namespace is terminating, and all "kube-system" Pods are running.
+	// Zero to skip the health checks.
+	HealthCheckTimeout       time.Duration `json:"health_check_timeout"`
+	HealthCheckTimeoutString string        `json:"health_check_timeout_string" read-only:"true"`
+
+	// RunFromCluster is set 'true' to run "clusterloader2" in a Pod with the in-cluster config,
+	// instead of running the local binary with Client.Config().KubeconfigPath.
+	// The test configuration directory is mounted via ConfigMap, and the report
+	// directory is copied out of the Pod to "TestReportDir" on completion or failure.
 	// Set via "--run-from-cluster" flag.
 	// ref. https://github.com/kubernetes/perf-tests/pull/1295
 	RunFromCluster bool `json:"run_from_cluster"`
+	// Namespace is the namespace to run "clusterloader2" Pods in, when "RunFromCluster" is "true".
+	Namespace string `json:"namespace"`
+	// RunFromClusterImage is the "clusterloader2" container image, required when "RunFromCluster" is "true".
+	RunFromClusterImage string `json:"run_from_cluster_image"`
+	// RunFromClusterBinaryPath is the "clusterloader2" binary path in the container image.
+	RunFromClusterBinaryPath string `json:"run_from_cluster_binary_path"`
+	// RunFromClusterBusyboxImage is the busybox image to keep the report volume
+	// after "clusterloader2" exits, in order to copy the report out of the Pod.
+	RunFromClusterBusyboxImage string `json:"run_from_cluster_busybox_image"`
 	// Nodes is the number of nodes.
 	// Set via "--nodes" flag.
 	Nodes int `json:"nodes"`
@@ -95,6 +122,31 @@ type Config struct {
 	PodStartupLatency PerfData `json:"pod_startup_latency" read-only:"true"`
 	// PodStartupLatencyPath is the JSON file path to store pod startup latency.
 	PodStartupLatencyPath string `json:"pod_startup_latency_path" read-only:"true"`
+
+	// SLOPodStartupLatency is the 99th percentile pod startup latency SLO.
+	// ref. https://github.com/kubernetes/community/blob/master/sig-scalability/slos/pod_startup_latency.md
+	SLOPodStartupLatency       time.Duration `json:"slo_pod_startup_latency"`
+	SLOPodStartupLatencyString string        `json:"slo_pod_startup_latency_string" read-only:"true"`
+	// SLOAPICallLatency is the 99th percentile latency SLO of the single object API calls.
+	// ref. https://github.com/kubernetes/community/blob/master/sig-scalability/slos/api_call_latency.md
+	SLOAPICallLatency       time.Duration `json:"slo_api_call_latency"`
+	SLOAPICallLatencyString string        `json:"slo_api_call_latency_string" read-only:"true"`
+	// SLOAPINamespaceListLatency is the 99th percentile latency SLO of the namespace-scoped LIST calls.
+	SLOAPINamespaceListLatency       time.Duration `json:"slo_api_namespace_list_latency"`
+	SLOAPINamespaceListLatencyString string        `json:"slo_api_namespace_list_latency_string" read-only:"true"`
+	// SLOAPIClusterListLatency is the 99th percentile latency SLO of the cluster-scoped LIST calls.
+	SLOAPIClusterListLatency       time.Duration `json:"slo_api_cluster_list_latency"`
+	SLOAPIClusterListLatencyString string        `json:"slo_api_cluster_list_latency_string" read-only:"true"`
+	// SLOFailOnViolation is true to fail the tester when any SLO is violated,
+	// or any test case fails in the junit XML reports.
+	SLOFailOnViolation bool `json:"slo_fail_on_violation"`
+
+	// SLIs is the key service level indicators parsed from the measurements and the junit XML reports.
+	SLIs SLIs `json:"slis" read-only:"true"`
+	// SLIsPath is the JSON file path to store the SLIs.
+	SLIsPath string `json:"slis_path" read-only:"true"`
+	// MeasurementPaths is the list of the measurement JSON files in the report directory.
+	MeasurementPaths []string `json:"measurement_paths" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
@@ -113,14 +165,42 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 	}
 	cfg.RunTimeoutString = cfg.RunTimeout.String()
 
-	if !file.Exist(cfg.TestConfigPath) {
+	if len(cfg.TestConfigPaths) == 0 && !file.Exist(cfg.TestConfigPath) {
 		return fmt.Errorf("TestConfigPath %q does not exist", cfg.TestConfigPath)
 	}
+	for _, p := range cfg.TestConfigPaths {
+		if !file.Exist(p) {
+			return fmt.Errorf("TestConfigPaths %q does not exist", p)
+		}
+	}
+	if cfg.Cooldown < 0 {
+		return fmt.Errorf("invalid Cooldown %v", cfg.Cooldown)
+	}
+	cfg.CooldownString = cfg.Cooldown.String()
+	if cfg.HealthCheckTimeout < 0 {
+		return fmt.Errorf("invalid HealthCheckTimeout %v", cfg.HealthCheckTimeout)
+	}
+	cfg.HealthCheckTimeoutString = cfg.HealthCheckTimeout.String()
 
 	if cfg.Nodes == 0 {
 		cfg.Nodes = cfg.MinimumNodes
 	}
 
+	if cfg.RunFromCluster {
+		if cfg.Namespace == "" {
+			return errors.New("empty Namespace")
+		}
+		if cfg.RunFromClusterImage == "" {
+			return errors.New("empty RunFromClusterImage")
+		}
+		if cfg.RunFromClusterBinaryPath == "" {
+			cfg.RunFromClusterBinaryPath = DefaultRunFromClusterBinaryPath
+		}
+		if cfg.RunFromClusterBusyboxImage == "" {
+			cfg.RunFromClusterBusyboxImage = DefaultRunFromClusterBusyboxImage
+		}
+	}
+
 	if cfg.TestReportDir == "" {
 		cfg.TestReportDir = DefaultTestReportDir()
 	}
@@ -136,10 +216,38 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 	if cfg.PodStartupLatencyPath == "" {
 		cfg.PodStartupLatencyPath = DefaultPodStartupLatencyPath()
 	}
+	if cfg.SLIsPath == "" {
+		cfg.SLIsPath = DefaultSLIsPath()
+	}
+
+	if cfg.SLOPodStartupLatency == time.Duration(0) {
+		cfg.SLOPodStartupLatency = DefaultSLOPodStartupLatency
+	}
+	cfg.SLOPodStartupLatencyString = cfg.SLOPodStartupLatency.String()
+	if cfg.SLOAPICallLatency == time.Duration(0) {
+		cfg.SLOAPICallLatency = DefaultSLOAPICallLatency
+	}
+	cfg.SLOAPICallLatencyString = cfg.SLOAPICallLatency.String()
+	if cfg.SLOAPINamespaceListLatency == time.Duration(0) {
+		cfg.SLOAPINamespaceListLatency = DefaultSLOAPINamespaceListLatency
+	}
+	cfg.SLOAPINamespaceListLatencyString = cfg.SLOAPINamespaceListLatency.String()
+	if cfg.SLOAPIClusterListLatency == time.Duration(0) {
+		cfg.SLOAPIClusterListLatency = DefaultSLOAPIClusterListLatency
+	}
+	cfg.SLOAPIClusterListLatencyString = cfg.SLOAPIClusterListLatency.String()
 
 	return nil
 }
 
+// GetTestConfigPaths returns the test configuration files to run sequentially.
+func (cfg *Config) GetTestConfigPaths() []string {
+	if len(cfg.TestConfigPaths) > 0 {
+		return cfg.TestConfigPaths
+	}
+	return []string{cfg.TestConfigPath}
+}
+
 var (
 	unixNano                      = time.Now().UnixNano()
 	defaultTestReportDir          = filepath.Join(os.TempDir(), fmt.Sprintf("clusterloader-test-report-dir-%x", unixNano))
@@ -147,6 +255,7 @@ var (
 	defaultTestOverridePath       = filepath.Join(defaultTestReportDir, fmt.Sprintf("clusterloader-test-overrides-%x.yaml", unixNano))
 	defaultTestLogPath            = filepath.Join(defaultTestReportDir, fmt.Sprintf("clusterloader-test-log-%x.log", unixNano))
 	defaultPodStartupLatencyPath  = filepath.Join(defaultTestReportDir, fmt.Sprintf("clusterloader-pod-startup-latency-%x.json", unixNano))
+	defaultSLIsPath               = filepath.Join(os.TempDir(), fmt.Sprintf("clusterloader-slis-%x.json", unixNano))
 )
 
 func DefaultTestOverridePath() string {
@@ -169,15 +278,32 @@ func DefaultPodStartupLatencyPath() string {
 	return defaultPodStartupLatencyPath
 }
 
+func DefaultSLIsPath() string {
+	return defaultSLIsPath
+}
+
 const (
 	DefaultMinimumNodes int = 1
 
 	DefaultRuns       = 2
 	DefaultRunTimeout = 30 * time.Minute
 
-	DefaultRunFromCluster    = false
-	DefaultNodes             = 10
-	DefaultEnableExecService = false
+	DefaultCooldown           = 2 * time.Minute
+	DefaultHealthCheckTimeout = 10 * time.Minute
+
+	DefaultRunFromCluster = false
+	// ref. https://github.com/kubernetes/perf-tests/blob/master/clusterloader2/Dockerfile
+	DefaultRunFromClusterBinaryPath   = "/clusterloader"
+	DefaultRunFromClusterBusyboxImage = "public.ecr.aws/hudsonbay/busybox:latest"
+	DefaultNodes                      = 10
+	DefaultEnableExecService          = false
+
+	// ref. https://github.com/kubernetes/community/blob/master/sig-scalability/slos/slos.md
+	DefaultSLOPodStartupLatency       = 5 * time.Second
+	DefaultSLOAPICallLatency          = time.Second
+	DefaultSLOAPINamespaceListLatency = 5 * time.Second
+	DefaultSLOAPIClusterListLatency   = 30 * time.Second
+	DefaultSLOFailOnViolation         = true
 )
 
 func NewDefault() *Config {
@@ -194,9 +320,15 @@ func NewDefault() *Config {
 		Runs:       DefaultRuns,
 		RunTimeout: DefaultRunTimeout,
 
-		RunFromCluster:    DefaultRunFromCluster,
-		Nodes:             DefaultNodes,
-		EnableExecService: DefaultEnableExecService,
+		Cooldown:           DefaultCooldown,
+		HealthCheckTimeout: DefaultHealthCheckTimeout,
+
+		RunFromCluster:             DefaultRunFromCluster,
+		Namespace:                  pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		RunFromClusterBinaryPath:   DefaultRunFromClusterBinaryPath,
+		RunFromClusterBusyboxImage: DefaultRunFromClusterBusyboxImage,
+		Nodes:                      DefaultNodes,
+		EnableExecService:          DefaultEnableExecService,
 
 		TestOverride: newDefaultTestOverride(),
 
@@ -204,6 +336,13 @@ func NewDefault() *Config {
 		TestReportDirTarGzPath: DefaultTestReportDirTarGzPath(),
 		TestLogPath:            DefaultTestLogPath(),
 		PodStartupLatencyPath:  DefaultPodStartupLatencyPath(),
+
+		SLOPodStartupLatency:       DefaultSLOPodStartupLatency,
+		SLOAPICallLatency:          DefaultSLOAPICallLatency,
+		SLOAPINamespaceListLatency: DefaultSLOAPINamespaceListLatency,
+		SLOAPIClusterListLatency:   DefaultSLOAPIClusterListLatency,
+		SLOFailOnViolation:         DefaultSLOFailOnViolation,
+		SLIsPath:                   DefaultSLIsPath(),
 	}
 }
 
@@ -216,6 +355,25 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 }
 
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
 type tester struct {
 	cfg         *Config
 	testLogFile *os.File
@@ -225,6 +383,10 @@ type tester struct {
 
 	rootCtx    context.Context
 	rootCancel context.CancelFunc
+
+	// configItems is the test configuration files in the ConfigMap of each test configuration,
+	// with the paths relative to the test configuration directory.
+	configItems [][]core_v1.KeyToPath
 }
 
 var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
@@ -252,8 +414,10 @@ func (ts *tester) Apply() (err error) {
 		}
 	}
 
-	if err = installClusterloader(ts.cfg.Logger, ts.cfg.ClusterloaderPath, ts.cfg.ClusterloaderDownloadURL); err != nil {
-		return err
+	if !ts.cfg.RunFromCluster {
+		if err = installClusterloader(ts.cfg.Logger, ts.cfg.ClusterloaderPath, ts.cfg.ClusterloaderDownloadURL); err != nil {
+			return err
+		}
 	}
 
 	if err = os.MkdirAll(ts.cfg.TestReportDir, 0700); err != nil {
@@ -268,6 +432,12 @@ func (ts *tester) Apply() (err error) {
 		return err
 	}
 
+	if ts.cfg.RunFromCluster {
+		if err = ts.createInClusterResources(); err != nil {
+			return err
+		}
+	}
+
 	ts.testLogFile, err = os.OpenFile(ts.cfg.TestLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
 	if err != nil {
 		return err
@@ -296,27 +466,37 @@ func (ts *tester) Apply() (err error) {
 	if err = ts.compressReports(); err != nil {
 		return err
 	}
+	sloErr := ts.checkSLOs()
 
-	if testFinishedCount == ts.cfg.Runs {
+	expectedRuns := ts.cfg.Runs * len(ts.cfg.GetTestConfigPaths())
+	if testFinishedCount == expectedRuns {
 		ts.cfg.Logger.Info("completed expected test runs; overriding error",
 			zap.Int("finished-count", testFinishedCount),
-			zap.Int("expected-runs", ts.cfg.Runs),
+			zap.Int("expected-runs", expectedRuns),
 			zap.Error(runErr),
 		)
 		runErr = nil
 	} else {
 		ts.cfg.Logger.Warn("failed to complete expected test runs",
 			zap.Int("finished-count", testFinishedCount),
-			zap.Int("expected-runs", ts.cfg.Runs),
+			zap.Int("expected-runs", expectedRuns),
 			zap.Error(runErr),
 		)
-		completeErr := fmt.Errorf("failed to complete expected test runs [expected %d, completed %d]", ts.cfg.Runs, testFinishedCount)
+		completeErr := fmt.Errorf("failed to complete expected test runs [expected %d, completed %d]", expectedRuns, testFinishedCount)
 		if runErr == nil {
 			runErr = completeErr
 		} else {
 			runErr = fmt.Errorf("%v (run error: %v)", completeErr, runErr)
 		}
 	}
+	if sloErr != nil {
+		ts.cfg.Logger.Warn("SLO check failed", zap.Error(sloErr))
+		if runErr == nil {
+			runErr = sloErr
+		} else {
+			runErr = fmt.Errorf("%v (SLO error: %v)", runErr, sloErr)
+		}
+	}
 	return runErr
 }
 
@@ -327,6 +507,10 @@ func (ts *tester) Delete() error {
 
 	var errs []string
 
+	if ts.cfg.RunFromCluster {
+		errs = append(errs, ts.deleteInClusterResources()...)
+	}
+
 	if len(errs) > 0 {
 		return errors.New(strings.Join(errs, ", "))
 	}
@@ -346,7 +530,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -392,23 +577,18 @@ func (ts *tester) streamTestLogs() (checkDonec chan struct{}) {
 	return checkDonec
 }
 
-func (ts *tester) getCL2Args() (args []string) {
+func (ts *tester) getCL2Args(testConfigPath string) (args []string) {
 	args = []string{
 		ts.cfg.ClusterloaderPath,
 		"--logtostderr",     // log to standard error instead of files (default true)
 		"--alsologtostderr", // log to standard error as well as files
 		fmt.Sprintf("--enable-exec-service=%v", ts.cfg.EnableExecService),
-		"--testconfig=" + ts.cfg.TestConfigPath,
+		"--testconfig=" + testConfigPath,
 		"--testoverrides=" + ts.cfg.TestOverride.Path,
 		"--report-dir=" + ts.cfg.TestReportDir,
 		"--nodes=" + fmt.Sprintf("%d", ts.cfg.Nodes),
 		"--provider=" + ts.cfg.Provider,
-	}
-	if ts.cfg.RunFromCluster {
-		// ref. https://github.com/kubernetes/perf-tests/pull/1295
-		args = append(args, "--run-from-cluster=true")
-	} else {
-		args = append(args, "--kubeconfig="+ts.cfg.Client.Config().KubeconfigPath)
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
 	}
 	return args
 }
@@ -465,50 +645,81 @@ func (ts *tester) runCL2(idx int, args []string) (err error) {
 	return err
 }
 
+// runCL2Once runs "clusterloader2" once, and returns no error
+// if the test log shows that the run succeeded regardless of the exit status.
+func (ts *tester) runCL2Once(idx int, configIdx int, args []string) error {
+	var rerr error
+	if ts.cfg.RunFromCluster {
+		rerr = ts.runCL2InCluster(idx, configIdx)
+	} else {
+		rerr = ts.runCL2(idx, args)
+	}
+	if rerr == nil {
+		return nil
+	}
+
+	ts.cfg.Logger.Warn("checking cluster loader error from log file", zap.Error(rerr))
+	b, lerr := ioutil.ReadFile(ts.cfg.TestLogPath)
+	if lerr != nil {
+		ts.cfg.Logger.Warn("failed to read cluster loader error from logs file", zap.Error(lerr))
+		return rerr
+	}
+	output := tailLogs(b)
+
+	if strings.Contains(output, `Status: Success`) {
+		// e.g., "Resource cleanup error: [timed out)"...
+		ts.cfg.Logger.Warn("cluster loader command exited but continue for its success status")
+		return nil
+	}
+	if strings.Contains(output, `PodStartupLatency: perc`) {
+		ts.cfg.Logger.Warn("cluster loader command exited but continue for its success report")
+		return nil
+	}
+	if strings.Contains(output, skipErr) {
+		ts.cfg.Logger.Warn("cluster loader failed but continue", zap.String("skip-error-message", skipErr))
+		return nil
+	}
+	return rerr
+}
+
 func (ts *tester) runCL2s(checkDonec chan struct{}) (runErr error) {
-	args := ts.getCL2Args()
+	testConfigPaths := ts.cfg.GetTestConfigPaths()
 	now := time.Now()
 	errc := make(chan error)
 	ts.rootCtx, ts.rootCancel = context.WithTimeout(context.Background(), ts.cfg.RunTimeout)
 	go func() {
-		for i := 0; i < ts.cfg.Runs; i++ {
-			select {
-			case <-ts.rootCtx.Done():
-				return
-			case <-time.After(5 * time.Second):
+		idx := 0
+		for ci, testConfigPath := range testConfigPaths {
+			if ci > 0 {
+				if err := ts.settle(); err != nil {
+					errc <- err
+					return
+				}
 			}
-
-			rerr := ts.runCL2(i, args)
-			if rerr == nil {
-				ts.cfg.Logger.Info("completed cluster loader", zap.Int("current-run", i), zap.Int("total-runs", ts.cfg.Runs))
-				continue
+			args := ts.getCL2Args(testConfigPath)
+			for i := 0; i < ts.cfg.Runs; i++ {
+				select {
+				case <-ts.rootCtx.Done():
+					return
+				case <-time.After(5 * time.Second):
+				}
+
+				ts.cfg.Logger.Info("running cluster loader",
+					zap.String("test-config-path", testConfigPath),
+					zap.Int("current-run", i),
+					zap.Int("total-runs", ts.cfg.Runs),
+				)
+				if rerr := ts.runCL2Once(idx, ci, args); rerr != nil {
+					errc <- rerr
+					return
+				}
+				ts.cfg.Logger.Info("completed cluster loader",
+					zap.String("test-config-path", testConfigPath),
+					zap.Int("current-run", i),
+					zap.Int("total-runs", ts.cfg.Runs),
+				)
+				idx++
 			}
-
-			ts.cfg.Logger.Warn("checking cluster loader error from log file", zap.Error(rerr))
-			b, lerr := ioutil.ReadFile(ts.cfg.TestLogPath)
-			if lerr != nil {
-				ts.cfg.Logger.Warn("failed to read cluster loader error from logs file", zap.Error(lerr))
-				errc <- rerr
-				return
-			}
-			output := tailLogs(b)
-
-			if strings.Contains(output, `Status: Success`) {
-				// e.g., "Resource cleanup error: [timed out)"...
-				ts.cfg.Logger.Warn("cluster loader command exited but continue for its success status")
-				continue
-			}
-			if strings.Contains(output, `PodStartupLatency: perc`) {
-				ts.cfg.Logger.Warn("cluster loader command exited but continue for its success report")
-				continue
-			}
-			if strings.Contains(output, skipErr) {
-				ts.cfg.Logger.Warn("cluster loader failed but continue", zap.String("skip-error-message", skipErr))
-				continue
-			}
-
-			errc <- rerr
-			return
 		}
 		errc <- nil
 	}()
@@ -580,7 +791,7 @@ func (ts *tester) appendResultsToTestLogPath() (podStartupLats []PerfData, err e
 
 		if strings.HasPrefix(filepath.Base(path), "PodStartupLatency_") {
 			ts.cfg.Logger.Info("parsing PodStartupLatency", zap.String("path", path))
-			p, perr := parsePodStartupLatency(path)
+			p, perr := parsePerfData(path)
 			if perr != nil {
 				ts.cfg.Logger.Warn("failed to parse PodStartupLatency", zap.String("path", path))
 				return perr
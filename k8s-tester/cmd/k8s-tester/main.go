@@ -2,14 +2,25 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester"
+	artifacts_s3 "github.com/aws/aws-k8s-tester/k8s-tester/artifacts-s3"
+	k8s_tester_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
 	"github.com/aws/aws-k8s-tester/k8s-tester/version"
+	"github.com/aws/aws-k8s-tester/utils/artifacts"
 	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 )
 
@@ -26,7 +37,13 @@ func init() {
 func init() {
 	rootCmd.AddCommand(
 		newApply(),
+		newRun(),
 		newDelete(),
+		newStatus(),
+		newDoctor(),
+		newRender(),
+		newReport(),
+		newArtifacts(),
 	)
 }
 
@@ -41,6 +58,11 @@ func main() {
 var (
 	path     string
 	autoPath bool
+	resume   bool
+
+	timeoutPerTester time.Duration
+	retries          int
+	assumeRoleARN    string
 )
 
 func newApply() *cobra.Command {
@@ -51,6 +73,10 @@ func newApply() *cobra.Command {
 	}
 	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "k8s-tester EKS configuration file path")
 	cmd.PersistentFlags().BoolVarP(&autoPath, "auto-path", "a", false, "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	cmd.PersistentFlags().BoolVar(&resume, "resume", false, "'true' to skip testers already applied in the previous run, and to keep applied testers on failure")
+	cmd.PersistentFlags().DurationVar(&timeoutPerTester, "timeout-per-tester", 0, "maximum duration of each tester apply attempt, overwrites the configuration if set (0 for no timeout)")
+	cmd.PersistentFlags().IntVar(&retries, "retries", 0, "number of retries for a failed tester apply, overwrites the configuration if set")
+	cmd.PersistentFlags().StringVar(&assumeRoleARN, "aws-assume-role-arn", "", "IAM role to assume for the EKS token and the artifacts upload, overwrites the configuration if set")
 	return cmd
 }
 
@@ -84,13 +110,26 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "failed to load configuration from environment variables %v\n", err)
 		os.Exit(1)
 	}
+	if resume {
+		cfg.Resume = true
+	}
+	if cmd.Flags().Changed("timeout-per-tester") {
+		cfg.TimeoutPerTester = timeoutPerTester
+	}
+	if cmd.Flags().Changed("retries") {
+		cfg.Retries = retries
+	}
+	if assumeRoleARN != "" {
+		cfg.AWSAssumeRoleARN = assumeRoleARN
+	}
 	err = cfg.ValidateAndSetDefaults()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to validate configuration %v\n", err)
 		os.Exit(1)
 	}
 
-	ts := k8s_tester.New(cfg)
+	ts, stop := newTester(cfg)
+	defer stop()
 
 	txt, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -108,6 +147,98 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 	fmt.Printf("'k8s-tester apply' success\n")
 }
 
+// newTester creates the tester, and stops its creation on SIGTERM or SIGINT.
+func newTester(cfg *k8s_tester.Config) (k8s_tester_tester.Tester, func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	ts, err := k8s_tester.NewTester(ctx, cfg)
+	if err != nil {
+		stop()
+		fmt.Fprintf(os.Stderr, "failed to create tester (%v)\n", err)
+		os.Exit(1)
+	}
+	return ts, stop
+}
+
+var (
+	suitePath string
+	dryRun    bool
+)
+
+func newRun() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Apply the testers of a suite manifest",
+		Long: `Apply the testers of a suite manifest, in the order of the suite and the dependencies.
+The suite lists the testers with their parameters, and extends the base suites (see "k8s_tester.Suite").
+The k8s-tester configuration generated from the suite is written to "--path", to delete the testers with "k8s-tester delete".`,
+		Run: createRunFunc,
+	}
+	cmd.PersistentFlags().StringVarP(&suitePath, "suite", "s", "", "suite manifest file path")
+	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "k8s-tester configuration file path to write (default to the suite path with '.k8s-tester.yaml' extension)")
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "'true' to print the tester order and the configuration, without applying")
+	cmd.PersistentFlags().StringVar(&assumeRoleARN, "aws-assume-role-arn", "", "IAM role to assume for the EKS token and the artifacts upload, overwrites the suite if set")
+	return cmd
+}
+
+func createRunFunc(cmd *cobra.Command, args []string) {
+	if suitePath == "" {
+		fmt.Fprintln(os.Stderr, "'--suite' flag is not specified")
+		os.Exit(1)
+	}
+	suite, err := k8s_tester.LoadSuite(suitePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load suite %q (%v)\n", suitePath, err)
+		os.Exit(1)
+	}
+	cfg, err := suite.BuildConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build configuration from suite %q (%v)\n", suitePath, err)
+		os.Exit(1)
+	}
+	if path == "" {
+		path = strings.TrimSuffix(suitePath, filepath.Ext(suitePath)) + ".k8s-tester.yaml"
+	}
+	cfg.ConfigPath = path
+
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration from environment variables %v\n", err)
+		os.Exit(1)
+	}
+	if assumeRoleARN != "" {
+		cfg.AWSAssumeRoleARN = assumeRoleARN
+	}
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to validate configuration %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("suite %q testers (%s):\n\n", suite.Name, suitePath)
+	for i, name := range cfg.TesterOrder {
+		fmt.Printf("%02d. %s\n", i+1, name)
+	}
+	if dryRun {
+		txt, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read configuration %q (%v)\n", path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n\n%q:\n\n%s\n\n", path, string(txt))
+		fmt.Printf("'k8s-tester run --dry-run' success\n")
+		return
+	}
+
+	ts, stop := newTester(cfg)
+	defer stop()
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester run' success\n")
+}
+
 func newDelete() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "delete",
@@ -125,7 +256,8 @@ func createDeleteFunc(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	ts := k8s_tester.New(cfg)
+	ts, stop := newTester(cfg)
+	defer stop()
 	if err := ts.Delete(); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
 		os.Exit(1)
@@ -134,3 +266,327 @@ func createDeleteFunc(cmd *cobra.Command, args []string) {
 	fmt.Printf("\n*********************************\n")
 	fmt.Printf("'k8s-tester delete' success\n")
 }
+
+var statusOutput string
+
+func newStatus() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Report the add-ons installed, healthy, or orphaned in the cluster (read-only)",
+		Run:   createStatusFunc,
+	}
+	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "k8s-tester EKS configuration file path")
+	cmd.PersistentFlags().StringVarP(&statusOutput, "output", "o", "table", "output format ('table' or 'json')")
+	return cmd
+}
+
+func createStatusFunc(cmd *cobra.Command, args []string) {
+	if statusOutput != "table" && statusOutput != "json" {
+		fmt.Fprintf(os.Stderr, "unknown '--output' %q\n", statusOutput)
+		os.Exit(1)
+	}
+
+	// do not call "ValidateAndSetDefaults" nor "New"
+	// to not set defaults nor create testers
+	cfg, err := k8s_tester.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	lg, _, _, err := log.NewWithStderrWriter(cfg.LogLevel, []string{"stderr"})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger (%v)\n", err)
+		os.Exit(1)
+	}
+
+	ss, err := cfg.Status(lg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get status (%v)\n", err)
+		os.Exit(1)
+	}
+
+	if statusOutput == "json" {
+		b, err := json.MarshalIndent(ss, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode status (%v)\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(b))
+		return
+	}
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester status' (%q)\n\n%s\n", path, k8s_tester.StatusTable(ss))
+}
+
+var doctorOutput string
+
+func newDoctor() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Validate the local environment for the configuration, and print the fixes (read-only)",
+		Long: `Validate the local environment for the configuration, and print the fixes (read-only).
+Checks the AWS credentials and the IAM permissions required by the enabled add-ons,
+the kubectl binary or its download mirror, the cluster connectivity,
+the free disk space for the artifacts, and the kubectl version skew against the cluster.
+Without "--path", checks the default configuration with the environment variables.
+Exits with 1 if any check failed.`,
+		Run: createDoctorFunc,
+	}
+	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "k8s-tester EKS configuration file path (optional)")
+	cmd.PersistentFlags().StringVarP(&doctorOutput, "output", "o", "table", "output format ('table' or 'json')")
+	return cmd
+}
+
+func createDoctorFunc(cmd *cobra.Command, args []string) {
+	if doctorOutput != "table" && doctorOutput != "json" {
+		fmt.Fprintf(os.Stderr, "unknown '--output' %q\n", doctorOutput)
+		os.Exit(1)
+	}
+
+	// do not call "ValidateAndSetDefaults" nor "New"
+	// to not write the configuration nor create testers
+	cfg := k8s_tester.NewDefault()
+	if path != "" {
+		var err error
+		cfg, err = k8s_tester.Load(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+			os.Exit(1)
+		}
+	}
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration from environment variables %v\n", err)
+		os.Exit(1)
+	}
+	lg, _, _, err := log.NewWithStderrWriter(cfg.LogLevel, []string{"stderr"})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger (%v)\n", err)
+		os.Exit(1)
+	}
+
+	cs := cfg.Doctor(lg)
+	if doctorOutput == "json" {
+		b, err := json.MarshalIndent(cs, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode checks (%v)\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(b))
+	} else {
+		fmt.Printf("\n*********************************\n")
+		fmt.Printf("'k8s-tester doctor' (%q)\n\n%s\n", path, k8s_tester.DoctorTable(cs))
+	}
+	if k8s_tester.DoctorFailed(cs) {
+		os.Exit(1)
+	}
+}
+
+var renderOutputDir string
+
+func newRender() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render the manifests and the Helm values of the enabled add-ons, without a cluster",
+		Long: `Render the manifests and the Helm values of the enabled add-ons, without a cluster.
+Use it to review the manifest changes. To regenerate the golden files of the add-on tests,
+run "UPDATE_GOLDEN=true go test ./k8s-tester/... -run TestRender".`,
+		Run: createRenderFunc,
+	}
+	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "k8s-tester EKS configuration file path")
+	cmd.PersistentFlags().StringVar(&renderOutputDir, "output-dir", "", "directory to write '<add-on>.yaml' files (empty to write to stdout)")
+	return cmd
+}
+
+func createRenderFunc(cmd *cobra.Command, args []string) {
+	cfg, err := k8s_tester.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration from environment variables %v\n", err)
+		os.Exit(1)
+	}
+	if assumeRoleARN != "" {
+		cfg.AWSAssumeRoleARN = assumeRoleARN
+	}
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to validate configuration %v\n", err)
+		os.Exit(1)
+	}
+
+	names, err := cfg.Render(os.Stdout, renderOutputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to render (%v)\n", err)
+		os.Exit(1)
+	}
+	if renderOutputDir != "" {
+		fmt.Printf("'k8s-tester render' wrote %q to %q\n", names, renderOutputDir)
+	}
+}
+
+var (
+	reportResultsPath string
+	reportOutputPath  string
+)
+
+func newReport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Render the HTML report from the results JSON of an 'apply'",
+		Long: `Render the HTML report from the results JSON of an 'apply'.
+'apply' already writes the HTML report to "report_html_path", use it to re-render the report
+(e.g., from the results JSON downloaded from S3).`,
+		Run: createReportFunc,
+	}
+	cmd.PersistentFlags().StringVar(&reportResultsPath, "results", "", "results JSON path (e.g., the 'report_results_path' of the configuration)")
+	cmd.PersistentFlags().StringVar(&reportOutputPath, "output", "", "HTML report path to write (empty to write to stdout)")
+	return cmd
+}
+
+func createReportFunc(cmd *cobra.Command, args []string) {
+	if reportResultsPath == "" {
+		fmt.Fprintln(os.Stderr, "'--results' flag is not specified")
+		os.Exit(1)
+	}
+	rs, err := k8s_tester.LoadResults(reportResultsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load results (%v)\n", err)
+		os.Exit(1)
+	}
+	if reportOutputPath == "" {
+		if err = rs.WriteHTML(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to render report (%v)\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	f, err := os.Create(reportOutputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create %q (%v)\n", reportOutputPath, err)
+		os.Exit(1)
+	}
+	err = rs.WriteHTML(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to render report (%v)\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("'k8s-tester report' wrote %q\n", reportOutputPath)
+}
+
+func newArtifacts() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "artifacts",
+		Short: "Manage the run artifacts",
+	}
+	cmd.AddCommand(newArtifactsPrune())
+	return cmd
+}
+
+var (
+	pruneDir       string
+	pruneS3Bucket  string
+	pruneS3Prefix  string
+	pruneS3Region  string
+	pruneMaxAge    time.Duration
+	pruneKeepCount int
+	pruneDryRun    bool
+)
+
+func newArtifactsPrune() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Prune the run artifacts in the local directory and the S3 prefix by age and count",
+		Long: `Prune the run artifacts in the local directory and the S3 prefix by age and count.
+A run is a top-level entry of "--dir", or a cluster run prefix of "--s3-prefix" (e.g., "k8s-tester/[CLUSTER_NAME]/[RUN_ID]/").
+A run is pruned if it is older than "--max-age", or if it is not one of the "--keep" most recent runs.
+With "--path", the S3 flags default to the "artifacts_s3" configuration.`,
+		Run: createArtifactsPruneFunc,
+	}
+	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "k8s-tester EKS configuration file path (optional)")
+	cmd.PersistentFlags().StringVar(&pruneDir, "dir", "", "local artifacts directory to prune (empty to skip)")
+	cmd.PersistentFlags().StringVar(&pruneS3Bucket, "s3-bucket", "", "S3 bucket to prune (empty to skip)")
+	cmd.PersistentFlags().StringVar(&pruneS3Prefix, "s3-prefix", artifacts_s3.DefaultPrefix, "S3 prefix of the runs")
+	cmd.PersistentFlags().StringVar(&pruneS3Region, "s3-region", "", "S3 bucket region")
+	cmd.PersistentFlags().DurationVar(&pruneMaxAge, "max-age", 0, "maximum age of the runs to keep (0 to not prune by age)")
+	cmd.PersistentFlags().IntVar(&pruneKeepCount, "keep", 0, "number of the most recent runs to keep (0 to not prune by count)")
+	cmd.PersistentFlags().BoolVar(&pruneDryRun, "dry-run", false, "'true' to only print the runs to prune")
+	return cmd
+}
+
+func createArtifactsPruneFunc(cmd *cobra.Command, args []string) {
+	policy := artifacts.Policy{MaxAge: pruneMaxAge, KeepCount: pruneKeepCount}
+	if err := policy.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid retention policy (%v)\n", err)
+		os.Exit(1)
+	}
+
+	s3Cfg := artifacts_s3.NewDefault()
+	if path != "" {
+		cfg, err := k8s_tester.Load(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+			os.Exit(1)
+		}
+		if cfg.ArtifactsS3 != nil && cfg.ArtifactsS3.Enable {
+			s3Cfg = cfg.ArtifactsS3
+		}
+	}
+	if pruneS3Bucket != "" {
+		s3Cfg.BucketName = pruneS3Bucket
+	}
+	if cmd.Flags().Changed("s3-prefix") || s3Cfg.Prefix == "" {
+		s3Cfg.Prefix = pruneS3Prefix
+	}
+	if pruneS3Region != "" {
+		s3Cfg.Region = pruneS3Region
+	}
+	if pruneDir == "" && s3Cfg.BucketName == "" {
+		fmt.Fprintln(os.Stderr, "'--dir' or '--s3-bucket' flag is not specified")
+		os.Exit(1)
+	}
+
+	lg, _, _, err := log.NewWithStderrWriter(log.DefaultLogLevel, []string{"stderr"})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger (%v)\n", err)
+		os.Exit(1)
+	}
+
+	var pruned []artifacts.Run
+	if pruneDir != "" {
+		runs, err := artifacts.PruneLocal(lg, pruneDir, policy, pruneDryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to prune %q (%v)\n", pruneDir, err)
+			os.Exit(1)
+		}
+		pruned = append(pruned, runs...)
+	}
+	if s3Cfg.BucketName != "" {
+		if err = s3Cfg.ValidateAndSetDefaults(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid S3 configuration (%v)\n", err)
+			os.Exit(1)
+		}
+		s3Cfg.Logger = lg
+		runs, err := artifacts_s3.Prune(s3Cfg, policy, pruneDryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to prune s3://%s/%s (%v)\n", s3Cfg.BucketName, s3Cfg.Prefix, err)
+			os.Exit(1)
+		}
+		pruned = append(pruned, runs...)
+	}
+
+	var size int64
+	for _, r := range pruned {
+		size += r.Size
+	}
+	fmt.Printf("\n*********************************\n")
+	if pruneDryRun {
+		fmt.Printf("'k8s-tester artifacts prune' would prune %d runs (%s)\n", len(pruned), humanize.Bytes(uint64(size)))
+	} else {
+		fmt.Printf("'k8s-tester artifacts prune' pruned %d runs (%s)\n", len(pruned), humanize.Bytes(uint64(size)))
+	}
+	for _, r := range pruned {
+		fmt.Printf("%s\t%s\t%d files\n", r.Path, r.Modified.Format(time.RFC3339), r.Files)
+	}
+}
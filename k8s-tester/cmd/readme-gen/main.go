@@ -12,34 +12,77 @@ import (
 	"strings"
 
 	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/adot"
 	"github.com/aws/aws-k8s-tester/k8s-tester/aqua"
+	argo_workflows "github.com/aws/aws-k8s-tester/k8s-tester/argo-workflows"
 	"github.com/aws/aws-k8s-tester/k8s-tester/armory"
+	artifacts_s3 "github.com/aws/aws-k8s-tester/k8s-tester/artifacts-s3"
+	auth_refresh "github.com/aws/aws-k8s-tester/k8s-tester/auth-refresh"
+	cert_rotation "github.com/aws/aws-k8s-tester/k8s-tester/cert-rotation"
 	cloudwatch_agent "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-agent"
+	cloudwatch_metrics "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-metrics"
+	cluster_pause "github.com/aws/aws-k8s-tester/k8s-tester/cluster-pause"
 	"github.com/aws/aws-k8s-tester/k8s-tester/clusterloader"
 	"github.com/aws/aws-k8s-tester/k8s-tester/cni"
 	"github.com/aws/aws-k8s-tester/k8s-tester/configmaps"
 	"github.com/aws/aws-k8s-tester/k8s-tester/conformance"
+	cross_cluster "github.com/aws/aws-k8s-tester/k8s-tester/cross-cluster"
 	csi_ebs "github.com/aws/aws-k8s-tester/k8s-tester/csi-ebs"
 	csi_efs "github.com/aws/aws-k8s-tester/k8s-tester/csi-efs"
+	csi_s3 "github.com/aws/aws-k8s-tester/k8s-tester/csi-s3"
 	"github.com/aws/aws-k8s-tester/k8s-tester/csrs"
+	daemonset_rollout "github.com/aws/aws-k8s-tester/k8s-tester/daemonset-rollout"
+	"github.com/aws/aws-k8s-tester/k8s-tester/dns"
+	emr_on_eks "github.com/aws/aws-k8s-tester/k8s-tester/emr-on-eks"
+	"github.com/aws/aws-k8s-tester/k8s-tester/endpointslices"
 	"github.com/aws/aws-k8s-tester/k8s-tester/epsagon"
+	event_flood "github.com/aws/aws-k8s-tester/k8s-tester/event-flood"
 	"github.com/aws/aws-k8s-tester/k8s-tester/falco"
 	"github.com/aws/aws-k8s-tester/k8s-tester/falcon"
+	"github.com/aws/aws-k8s-tester/k8s-tester/fargate"
 	fluent_bit "github.com/aws/aws-k8s-tester/k8s-tester/fluent-bit"
+	hollow_nodes "github.com/aws/aws-k8s-tester/k8s-tester/hollow-nodes"
+	hpa_cloudwatch "github.com/aws/aws-k8s-tester/k8s-tester/hpa-cloudwatch"
+	iam_preflight "github.com/aws/aws-k8s-tester/k8s-tester/iam-preflight"
+	image_prepull "github.com/aws/aws-k8s-tester/k8s-tester/image-prepull"
+	"github.com/aws/aws-k8s-tester/k8s-tester/irsa"
+	istio_ambient "github.com/aws/aws-k8s-tester/k8s-tester/istio-ambient"
 	jobs_echo "github.com/aws/aws-k8s-tester/k8s-tester/jobs-echo"
 	jobs_pi "github.com/aws/aws-k8s-tester/k8s-tester/jobs-pi"
+	"github.com/aws/aws-k8s-tester/k8s-tester/karpenter"
+	keda_sqs "github.com/aws/aws-k8s-tester/k8s-tester/keda-sqs"
+	kube_state_metrics "github.com/aws/aws-k8s-tester/k8s-tester/kube-state-metrics"
 	"github.com/aws/aws-k8s-tester/k8s-tester/kubecost"
 	kubernetes_dashboard "github.com/aws/aws-k8s-tester/k8s-tester/kubernetes-dashboard"
+	"github.com/aws/aws-k8s-tester/k8s-tester/kueue"
 	metrics_server "github.com/aws/aws-k8s-tester/k8s-tester/metrics-server"
+	multi_container "github.com/aws/aws-k8s-tester/k8s-tester/multi-container"
+	network_policy "github.com/aws/aws-k8s-tester/k8s-tester/network-policy"
+	"github.com/aws/aws-k8s-tester/k8s-tester/neuron"
 	nlb_guestbook "github.com/aws/aws-k8s-tester/k8s-tester/nlb-guestbook"
 	nlb_hello_world "github.com/aws/aws-k8s-tester/k8s-tester/nlb-hello-world"
+	node_problem_detector "github.com/aws/aws-k8s-tester/k8s-tester/node-problem-detector"
+	node_tag_labels "github.com/aws/aws-k8s-tester/k8s-tester/node-tag-labels"
+	nvidia_gpu "github.com/aws/aws-k8s-tester/k8s-tester/nvidia-gpu"
+	opensearch_results "github.com/aws/aws-k8s-tester/k8s-tester/opensearch-results"
+	"github.com/aws/aws-k8s-tester/k8s-tester/pdb"
 	php_apache "github.com/aws/aws-k8s-tester/k8s-tester/php-apache"
+	port_exhaustion "github.com/aws/aws-k8s-tester/k8s-tester/port-exhaustion"
+	probe_storm "github.com/aws/aws-k8s-tester/k8s-tester/probe-storm"
+	prometheus_grafana "github.com/aws/aws-k8s-tester/k8s-tester/prometheus-grafana"
+	pv_reclaim "github.com/aws/aws-k8s-tester/k8s-tester/pv-reclaim"
+	runtime_restart "github.com/aws/aws-k8s-tester/k8s-tester/runtime-restart"
 	"github.com/aws/aws-k8s-tester/k8s-tester/secrets"
 	"github.com/aws/aws-k8s-tester/k8s-tester/splunk"
+	static_pod "github.com/aws/aws-k8s-tester/k8s-tester/static-pod"
+	status_reporter "github.com/aws/aws-k8s-tester/k8s-tester/status-reporter"
+	steady_state "github.com/aws/aws-k8s-tester/k8s-tester/steady-state"
 	"github.com/aws/aws-k8s-tester/k8s-tester/stress"
 	stress_in_cluster "github.com/aws/aws-k8s-tester/k8s-tester/stress/in-cluster"
+	"github.com/aws/aws-k8s-tester/k8s-tester/sysctl"
 	"github.com/aws/aws-k8s-tester/k8s-tester/sysdig"
 	"github.com/aws/aws-k8s-tester/k8s-tester/vault"
+	"github.com/aws/aws-k8s-tester/k8s-tester/windows"
 	"github.com/aws/aws-k8s-tester/k8s-tester/wordpress"
 	aws_v1_ecr "github.com/aws/aws-k8s-tester/utils/aws/v1/ecr"
 	"github.com/olekukonko/tablewriter"
@@ -79,6 +122,16 @@ func createDoc() string {
 
 	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX, &k8s_tester.Config{}))
 
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+iam_preflight.Env()+"_", &iam_preflight.Config{}))
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+cloudwatch_metrics.Env()+"_", &cloudwatch_metrics.Config{}))
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+opensearch_results.Env()+"_", &opensearch_results.Config{}))
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+artifacts_s3.Env()+"_", &artifacts_s3.Config{}))
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+status_reporter.Env()+"_", &status_reporter.Config{}))
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+steady_state.Env()+"_", &steady_state.Config{}))
+
 	b.WriteByte('\n')
 	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+cloudwatch_agent.Env()+"_", &cloudwatch_agent.Config{}))
 	totalTestCases++
@@ -215,6 +268,154 @@ func createDoc() string {
 	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+splunk.Env()+"_", &splunk.Config{}))
 	totalTestCases++
 
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+hpa_cloudwatch.Env()+"_", &hpa_cloudwatch.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+keda_sqs.Env()+"_", &keda_sqs.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+csi_s3.Env()+"_", &csi_s3.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+emr_on_eks.Env()+"_", &emr_on_eks.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+karpenter.Env()+"_", &karpenter.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+istio_ambient.Env()+"_", &istio_ambient.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+argo_workflows.Env()+"_", &argo_workflows.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+image_prepull.Env()+"_", &image_prepull.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+pv_reclaim.Env()+"_", &pv_reclaim.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+sysctl.Env()+"_", &sysctl.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+network_policy.Env()+"_", &network_policy.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+hollow_nodes.Env()+"_", &hollow_nodes.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+dns.Env()+"_", &dns.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+irsa.Env()+"_", &irsa.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+fargate.Env()+"_", &fargate.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+endpointslices.Env()+"_", &endpointslices.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+prometheus_grafana.Env()+"_", &prometheus_grafana.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+port_exhaustion.Env()+"_", &port_exhaustion.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+runtime_restart.Env()+"_", &runtime_restart.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+cert_rotation.Env()+"_", &cert_rotation.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+kube_state_metrics.Env()+"_", &kube_state_metrics.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+adot.Env()+"_", &adot.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+node_problem_detector.Env()+"_", &node_problem_detector.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+pdb.Env()+"_", &pdb.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+daemonset_rollout.Env()+"_", &daemonset_rollout.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+windows.Env()+"_", &windows.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+nvidia_gpu.Env()+"_", &nvidia_gpu.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+kueue.Env()+"_", &kueue.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+neuron.Env()+"_", &neuron.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+multi_container.Env()+"_", &multi_container.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+node_tag_labels.Env()+"_", &node_tag_labels.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+cluster_pause.Env()+"_", &cluster_pause.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+cross_cluster.Env()+"_", &cross_cluster.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+auth_refresh.Env()+"_", &auth_refresh.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+probe_storm.Env()+"_", &probe_storm.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+event_flood.Env()+"_", &event_flood.Config{}))
+	totalTestCases++
+
+	b.WriteByte('\n')
+	b.WriteString(es.writeDoc(k8s_tester.ENV_PREFIX+static_pod.Env()+"_", &static_pod.Config{}))
+	totalTestCases++
+
 	return header + fmt.Sprintf("\n\nTotal %d test cases!\n\n", totalTestCases) + "```\n" + b.String() + "```\n"
 }
 
@@ -0,0 +1,30 @@
+package cni
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the server, the ping and the node Pods, against a fake client.
+// The IPs to ping are known only when the tester runs, so placeholders are rendered.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	for _, po := range []*core_v1.Pod{
+		client.NewBusyBoxPod(ServerPod, serverPodCommand),
+		client.NewBusyBoxPod(PingPod, client.PingCommand("SERVER_POD_IP")),
+		client.NewBusyBoxPod(NodePod, client.PingCommand("NODE_IP")),
+	} {
+		if _, err := cli.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Create(context.Background(), po, meta_v1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+	return fake.Dump(w, cli)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -58,6 +59,9 @@ const (
 	PodTimeout                 = 2 * time.Minute
 )
 
+// serverPodCommand keeps the server Pod running for the ping Pod
+const serverPodCommand = "sleep 120"
+
 func NewDefault() *Config {
 	return &Config{
 		Enable:       false,
@@ -73,8 +77,30 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 }
 
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
 type tester struct {
 	cfg *Config
+	// network is the cluster IP family, to ping the addresses
+	// of the primary family on IPv6 or dual-stack clusters.
+	network client.ClusterNetwork
 }
 
 var graceperiod = int64(0)
@@ -96,6 +122,11 @@ func (ts *tester) Apply() error {
 	if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
 		return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
 	}
+	network, err := client.DetectClusterNetwork(ts.cfg.Logger, ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	ts.network = network
 	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
 		return err
 	}
@@ -150,7 +181,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -187,7 +219,7 @@ func (ts *tester) checkCNI() error {
 func (ts *tester) testPodtoPod() error {
 	//Create Server Pod
 	ts.cfg.Logger.Info("Creating ServerPod:", zap.String("ServerPod", ServerPod))
-	serverPod := client.NewBusyBoxPod(ServerPod, "sleep 120")
+	serverPod := client.NewBusyBoxPod(ServerPod, serverPodCommand)
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	serverPod, err := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Create(ctx, serverPod, meta_v1.CreateOptions{})
 	if err != nil {
@@ -200,9 +232,13 @@ func (ts *tester) testPodtoPod() error {
 		return fmt.Errorf("failed to wait for CNI server pod to become healthy (%v)", err)
 	}
 	serverPod, err = ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Get(context.TODO(), ServerPod, meta_v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get CNI server pod (%v)", err)
+	}
+	serverIP := client.GetPodIP(serverPod, ts.network.PrimaryIPFamily())
 	//Create Ping Pod
-	ts.cfg.Logger.Info("Creating PingPod:", zap.String("PingPod", PingPod))
-	pingPod := client.NewBusyBoxPod(PingPod, "ping -c 3 -w 2 -w 30 "+serverPod.Status.PodIP)
+	ts.cfg.Logger.Info("Creating PingPod:", zap.String("PingPod", PingPod), zap.String("ServerPod IP", serverIP))
+	pingPod := client.NewBusyBoxPod(PingPod, client.PingCommand(serverIP))
 	ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
 	pingPod, err = ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Create(ctx, pingPod, meta_v1.CreateOptions{})
 	cancel()
@@ -242,14 +278,14 @@ func (ts *tester) testPodtoNode() error {
 	if err != nil {
 		return fmt.Errorf("failed getting random ready schedulable node (%v)", err)
 	}
-	internalIP, err := client.GetInternalIP(node)
+	internalIP, err := client.GetNodeInternalIP(node, ts.network.PrimaryIPFamily())
 	ts.cfg.Logger.Info("Found Random schedulabe Node:", zap.String("Node IP", internalIP))
 	if err != nil {
 		return fmt.Errorf("failed IP of schedulable node (%v)", err)
 	}
 	//Create Node Pod
 	ts.cfg.Logger.Info("Creating NodePod:", zap.String("NodePod", NodePod))
-	nodePod := client.NewBusyBoxPod(NodePod, "ping -c 3 -w 2 -w 30 "+internalIP)
+	nodePod := client.NewBusyBoxPod(NodePod, client.PingCommand(internalIP))
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	nodePod, err = ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Create(ctx, nodePod, meta_v1.CreateOptions{})
 	cancel()
@@ -16,34 +16,77 @@ import (
 	"time"
 
 	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/adot"
 	"github.com/aws/aws-k8s-tester/k8s-tester/aqua"
+	argo_workflows "github.com/aws/aws-k8s-tester/k8s-tester/argo-workflows"
 	"github.com/aws/aws-k8s-tester/k8s-tester/armory"
+	artifacts_s3 "github.com/aws/aws-k8s-tester/k8s-tester/artifacts-s3"
+	auth_refresh "github.com/aws/aws-k8s-tester/k8s-tester/auth-refresh"
+	cert_rotation "github.com/aws/aws-k8s-tester/k8s-tester/cert-rotation"
 	cloudwatch_agent "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-agent"
+	cloudwatch_metrics "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-metrics"
+	cluster_pause "github.com/aws/aws-k8s-tester/k8s-tester/cluster-pause"
 	"github.com/aws/aws-k8s-tester/k8s-tester/clusterloader"
 	cni "github.com/aws/aws-k8s-tester/k8s-tester/cni"
 	"github.com/aws/aws-k8s-tester/k8s-tester/configmaps"
 	"github.com/aws/aws-k8s-tester/k8s-tester/conformance"
+	cross_cluster "github.com/aws/aws-k8s-tester/k8s-tester/cross-cluster"
 	csi_ebs "github.com/aws/aws-k8s-tester/k8s-tester/csi-ebs"
 	csi_efs "github.com/aws/aws-k8s-tester/k8s-tester/csi-efs"
+	csi_s3 "github.com/aws/aws-k8s-tester/k8s-tester/csi-s3"
 	"github.com/aws/aws-k8s-tester/k8s-tester/csrs"
+	daemonset_rollout "github.com/aws/aws-k8s-tester/k8s-tester/daemonset-rollout"
+	"github.com/aws/aws-k8s-tester/k8s-tester/dns"
+	emr_on_eks "github.com/aws/aws-k8s-tester/k8s-tester/emr-on-eks"
+	"github.com/aws/aws-k8s-tester/k8s-tester/endpointslices"
 	"github.com/aws/aws-k8s-tester/k8s-tester/epsagon"
+	event_flood "github.com/aws/aws-k8s-tester/k8s-tester/event-flood"
 	falco "github.com/aws/aws-k8s-tester/k8s-tester/falco"
 	falcon "github.com/aws/aws-k8s-tester/k8s-tester/falcon"
+	"github.com/aws/aws-k8s-tester/k8s-tester/fargate"
 	fluent_bit "github.com/aws/aws-k8s-tester/k8s-tester/fluent-bit"
+	hollow_nodes "github.com/aws/aws-k8s-tester/k8s-tester/hollow-nodes"
+	hpa_cloudwatch "github.com/aws/aws-k8s-tester/k8s-tester/hpa-cloudwatch"
+	iam_preflight "github.com/aws/aws-k8s-tester/k8s-tester/iam-preflight"
+	image_prepull "github.com/aws/aws-k8s-tester/k8s-tester/image-prepull"
+	"github.com/aws/aws-k8s-tester/k8s-tester/irsa"
+	istio_ambient "github.com/aws/aws-k8s-tester/k8s-tester/istio-ambient"
 	jobs_echo "github.com/aws/aws-k8s-tester/k8s-tester/jobs-echo"
 	jobs_pi "github.com/aws/aws-k8s-tester/k8s-tester/jobs-pi"
+	"github.com/aws/aws-k8s-tester/k8s-tester/karpenter"
+	keda_sqs "github.com/aws/aws-k8s-tester/k8s-tester/keda-sqs"
+	kube_state_metrics "github.com/aws/aws-k8s-tester/k8s-tester/kube-state-metrics"
 	"github.com/aws/aws-k8s-tester/k8s-tester/kubecost"
 	kubernetes_dashboard "github.com/aws/aws-k8s-tester/k8s-tester/kubernetes-dashboard"
+	"github.com/aws/aws-k8s-tester/k8s-tester/kueue"
 	metrics_server "github.com/aws/aws-k8s-tester/k8s-tester/metrics-server"
+	multi_container "github.com/aws/aws-k8s-tester/k8s-tester/multi-container"
+	network_policy "github.com/aws/aws-k8s-tester/k8s-tester/network-policy"
+	"github.com/aws/aws-k8s-tester/k8s-tester/neuron"
 	nlb_guestbook "github.com/aws/aws-k8s-tester/k8s-tester/nlb-guestbook"
 	nlb_hello_world "github.com/aws/aws-k8s-tester/k8s-tester/nlb-hello-world"
+	node_problem_detector "github.com/aws/aws-k8s-tester/k8s-tester/node-problem-detector"
+	node_tag_labels "github.com/aws/aws-k8s-tester/k8s-tester/node-tag-labels"
+	nvidia_gpu "github.com/aws/aws-k8s-tester/k8s-tester/nvidia-gpu"
+	opensearch_results "github.com/aws/aws-k8s-tester/k8s-tester/opensearch-results"
+	"github.com/aws/aws-k8s-tester/k8s-tester/pdb"
 	php_apache "github.com/aws/aws-k8s-tester/k8s-tester/php-apache"
+	port_exhaustion "github.com/aws/aws-k8s-tester/k8s-tester/port-exhaustion"
+	probe_storm "github.com/aws/aws-k8s-tester/k8s-tester/probe-storm"
+	prometheus_grafana "github.com/aws/aws-k8s-tester/k8s-tester/prometheus-grafana"
+	pv_reclaim "github.com/aws/aws-k8s-tester/k8s-tester/pv-reclaim"
+	runtime_restart "github.com/aws/aws-k8s-tester/k8s-tester/runtime-restart"
 	"github.com/aws/aws-k8s-tester/k8s-tester/secrets"
 	"github.com/aws/aws-k8s-tester/k8s-tester/splunk"
+	static_pod "github.com/aws/aws-k8s-tester/k8s-tester/static-pod"
+	status_reporter "github.com/aws/aws-k8s-tester/k8s-tester/status-reporter"
+	steady_state "github.com/aws/aws-k8s-tester/k8s-tester/steady-state"
 	"github.com/aws/aws-k8s-tester/k8s-tester/stress"
 	stress_in_cluster "github.com/aws/aws-k8s-tester/k8s-tester/stress/in-cluster"
+	"github.com/aws/aws-k8s-tester/k8s-tester/sysctl"
 	"github.com/aws/aws-k8s-tester/k8s-tester/sysdig"
 	"github.com/aws/aws-k8s-tester/k8s-tester/vault"
+	"github.com/aws/aws-k8s-tester/k8s-tester/windows"
 	"github.com/aws/aws-k8s-tester/k8s-tester/wordpress"
 	aws_v1_ecr "github.com/aws/aws-k8s-tester/utils/aws/v1/ecr"
 	"github.com/aws/aws-k8s-tester/utils/file"
@@ -51,6 +94,8 @@ import (
 	"github.com/aws/aws-k8s-tester/utils/rand"
 	utils_time "github.com/aws/aws-k8s-tester/utils/time"
 	"github.com/mitchellh/colorstring"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/yaml"
 )
 
@@ -61,7 +106,8 @@ import (
 type Config struct {
 	mu *sync.RWMutex `json:"-"`
 
-	// Prompt is true to enable prompt mode.
+	// Prompt is true to enable prompt mode, that shows the plan of the testers
+	// (namespaces, AWS resources) to accept or skip each tester before "apply" or "delete".
 	Prompt bool `json:"prompt"`
 
 	// ClusterName is the Kubernetes cluster name.
@@ -90,6 +136,24 @@ type Config struct {
 	KubectlPath        string `json:"kubectl_path"`
 	KubeconfigPath     string `json:"kubeconfig_path"`
 	KubeconfigContext  string `json:"kubeconfig_context"`
+	// EKSClusterName is the EKS cluster name to create the client with the EKS token,
+	// and with the apiserver endpoint and the cluster CA from the EKS DescribeCluster API,
+	// without the kubeconfig file. Used only if "KubeconfigPath" is empty.
+	// The add-ons running kubectl still require "KubeconfigPath".
+	EKSClusterName string `json:"eks_cluster_name"`
+	// EKSRegion is the region of "EKSClusterName".
+	EKSRegion string `json:"eks_region"`
+	// AWSAssumeRoleARN is the IAM role to assume with the default credentials
+	// for the EKS token of "EKSClusterName", the doctor checks, and "ArtifactsS3".
+	// The add-ons calling the AWS APIs use the default credentials.
+	AWSAssumeRoleARN string `json:"aws_assume_role_arn"`
+	// AWSAssumeRoleDuration is the duration of the assumed role credentials (default 15 minutes).
+	AWSAssumeRoleDuration time.Duration `json:"aws_assume_role_duration"`
+	// ClientConfigMode is the source of the client configuration.
+	// "auto" (default) to load "KubeconfigPath" if set, and otherwise to fall back to
+	// "EKSClusterName" and the in-cluster configuration (e.g., running in a Pod).
+	// "kubeconfig" or "in-cluster" to force either mode.
+	ClientConfigMode string `json:"client_config_mode"`
 
 	// Clients is the number of kubernetes clients to create.
 	// Default is 1.
@@ -129,43 +193,195 @@ type Config struct {
 	// ClientTimeout is the client timeout.
 	ClientTimeout       time.Duration `json:"client_timeout"`
 	ClientTimeoutString string        `json:"client_timeout_string,omitempty" read-only:"true"`
+	// ClientRequestTimeout is the timeout of each Kubernetes API request,
+	// excluding the watch and streaming requests. Zero disables the timeout.
+	ClientRequestTimeout       time.Duration `json:"client_request_timeout"`
+	ClientRequestTimeoutString string        `json:"client_request_timeout_string,omitempty" read-only:"true"`
+	// ClientRetryInitialInterval is the initial interval of the exponential backoff
+	// for the retryable API errors (e.g., while deleting the namespaces).
+	// Zero uses the client default.
+	ClientRetryInitialInterval time.Duration `json:"client_retry_initial_interval"`
+	// ClientRetryFactor is the multiplier of the retry interval for each step.
+	// Zero uses the client default.
+	ClientRetryFactor float64 `json:"client_retry_factor"`
+	// ClientRetrySteps is the maximum number of the retries.
+	// Zero uses the client default.
+	ClientRetrySteps int `json:"client_retry_steps"`
+
+	// APIQPSBudget is the QPS budget of the Kubernetes API calls of the whole run,
+	// shared by all clients and testers (unlike "ClientQPS" that limits each client),
+	// for the shared clusters with strict API budgets. Zero means no budget.
+	APIQPSBudget float32 `json:"api_qps_budget"`
+	// APIBurstBudget is the burst of "APIQPSBudget".
+	// Defaults to "APIQPSBudget", rounded up.
+	APIBurstBudget int `json:"api_burst_budget"`
+	// APICalls is the number of the Kubernetes API calls made by each tester,
+	// keyed by tester name, and then by "[VERB] [RESOURCE]" (e.g., "list pods").
+	// The calls outside the testers (e.g., the node checks) are keyed by "k8s-tester".
+	APICalls map[string]map[string]int64 `json:"api_calls" read-only:"true"`
+	// APICallsTotal is the total number of the Kubernetes API calls of the run.
+	APICallsTotal int64 `json:"api_calls_total" read-only:"true"`
+	// APIThrottled is the total time the API calls waited for "APIQPSBudget".
+	APIThrottled       time.Duration `json:"api_throttled" read-only:"true"`
+	APIThrottledString string        `json:"api_throttled_string,omitempty" read-only:"true"`
 
 	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
 	MinimumNodes int `json:"minimum_nodes"`
 	// TotalNodes is the total number of nodes from all node groups.
 	TotalNodes int `json:"total_nodes" read-only:"true"`
+	// IPFamilies is the list of cluster IP families, with the primary family first
+	// (e.g., "IPv6" for EKS IPv6 clusters), detected before "apply".
+	IPFamilies []string `json:"ip_families" read-only:"true"`
+	// ServiceCIDR is the service IP range of the primary IP family, detected before "apply".
+	// Read from the kubeadm cluster configuration, or inferred from the "default/kubernetes" Service.
+	ServiceCIDR string `json:"service_cidr" read-only:"true"`
+
+	// Seed is the run-level random seed for the object name suffixes,
+	// the chaos schedules, and the generated payloads of all testers.
+	// Each tester is seeded with the value derived from this seed and its name,
+	// so a failed tester can be reproduced with the same seed from the results.
+	// Zero to generate one.
+	Seed int64 `json:"seed"`
+
+	// RunID is the unique ID of this run, appended to the namespaces of all testers
+	// (e.g., "test-namespace-[RUN_ID]"), so that multiple k8s-tester runs can
+	// target the same cluster concurrently. Leave empty to generate one.
+	RunID string `json:"run_id"`
+	// FixedNamespace is true to use the tester namespaces as configured,
+	// without the run ID suffix.
+	FixedNamespace bool `json:"fixed_namespace"`
+
+	// Resume is true to skip the testers that were already applied
+	// in the previous run (see "TesterStatus"), and to keep the applied
+	// testers on failure instead of reverting them, so that the next
+	// "k8s-tester apply --resume" can pick up where it failed.
+	// Only set by the "--resume" flag for the current invocation, and
+	// not persisted, so that the next plain "apply" reruns all testers.
+	Resume bool `json:"-"`
+	// ReportJUnitPath is the JUnit XML report path for the "apply" results of all testers.
+	// Defaults to the config path with ".junit.xml" extension.
+	ReportJUnitPath string `json:"report_junit_path"`
+	// ReportTAPPath is the TAP (Test Anything Protocol) report path for the "apply" results of all testers.
+	// Leave empty to skip the TAP report.
+	ReportTAPPath string `json:"report_tap_path"`
+	// ReportResultsPath is the JSON results path for the "apply" results of all testers,
+	// with the latency summaries and the artifact links (see "Results").
+	// Defaults to the config path with ".results.json" extension.
+	ReportResultsPath string `json:"report_results_path"`
+	// ReportHTMLPath is the self-contained HTML report path rendered from the results,
+	// for human review. Defaults to the config path with ".report.html" extension.
+	ReportHTMLPath string `json:"report_html_path"`
+
+	// TimeoutPerTester is the maximum duration of each tester "apply" attempt.
+	// A tester that does not return in time is recorded as failed, and its
+	// resources are deleted. Zero means no timeout.
+	TimeoutPerTester       time.Duration `json:"timeout_per_tester"`
+	TimeoutPerTesterString string        `json:"timeout_per_tester_string,omitempty" read-only:"true"`
+	// Retries is the number of times to retry a failed tester "apply",
+	// after deleting the resources of the failed attempt.
+	Retries int `json:"retries"`
+	// TesterPolicies overrides "TimeoutPerTester" and "Retries" per tester, keyed by the
+	// "TesterStatus" key, which is the tester name except "cron-jobs-echo" for the CronJob
+	// "jobs-echo" tester (e.g., {"nlb-guestbook":{"timeout":1800000000000,"retries":2}}).
+	TesterPolicies map[string]*TesterPolicy `json:"tester_policies"`
+	// TesterHooks is the commands to run before and after each tester "apply",
+	// keyed by tester name (e.g., {"nlb-guestbook":{"post_apply_hooks":[{"command":"kubectl get svc -A"}]}}).
+	TesterHooks map[string]*TesterHooks `json:"tester_hooks"`
+	// TesterOrder is the order to apply the enabled testers, by tester name
+	// (e.g., ["metrics-server","conformance"]), as generated by "k8s-tester run" from the suite.
+	// The testers not listed are applied after, in the default order.
+	TesterOrder []string `json:"tester_order"`
+
+	// TesterStatus is the status of each enabled tester, keyed by tester name.
+	TesterStatus map[string]*TesterStatus `json:"tester_status" read-only:"true"`
+
+	// IAMPreflight checks the AWS IAM permissions required by the enabled add-ons
+	// before "apply", and generates the minimal IAM policy for them.
+	IAMPreflight *iam_preflight.Config `json:"iam_preflight"`
+	// CloudWatchMetrics publishes the duration, success, and key metrics
+	// of the applied add-ons to CloudWatch after "apply".
+	CloudWatchMetrics *cloudwatch_metrics.Config `json:"cloudwatch_metrics"`
+	// ArtifactsS3 uploads the logs, the final configuration with the tester results,
+	// and the tester artifacts (e.g., sonobuoy results, clusterloader reports) to S3 after "apply".
+	ArtifactsS3 *artifacts_s3.Config `json:"artifacts_s3"`
+	// OpenSearchResults indexes the duration, success, and key metrics
+	// of the applied add-ons into OpenSearch after "apply".
+	OpenSearchResults *opensearch_results.Config `json:"opensearch_results"`
+	// StatusReporter reports the run status and the summary link as a GitHub
+	// commit status or check run, and copies the JUnit report for CodeBuild.
+	StatusReporter *status_reporter.Config `json:"status_reporter"`
+	// SteadyState waits between add-ons until the pod churn, the pending pods,
+	// and the API server error rate drop below the thresholds.
+	SteadyState *steady_state.Config `json:"steady_state"`
 
 	// tester order is defined as https://github.com/aws/aws-k8s-tester/blob/v1.5.9/eks/eks.go#L617
-	AddOnCloudwatchAgent     *cloudwatch_agent.Config     `json:"add_on_cloudwatch_agent"`
-	AddOnFluentBit           *fluent_bit.Config           `json:"add_on_fluent_bit"`
-	AddOnMetricsServer       *metrics_server.Config       `json:"add_on_metrics_server"`
-	AddOnKubecost            *kubecost.Config             `json:"add_on_kubecost"`
-	AddOnConformance         *conformance.Config          `json:"add_on_conformance"`
-	AddOnCNI                 *cni.Config                  `json:"add_on_cni"`
-	AddOnCSIEBS              *csi_ebs.Config              `json:"add_on_csi_ebs"`
-	AddOnCSIEFS              *csi_efs.Config              `json:"add_on_csi_efs"`
-	AddOnKubernetesDashboard *kubernetes_dashboard.Config `json:"add_on_kubernetes_dashboard"`
-	AddOnFalco               *falco.Config                `json:"add_on_falco"`
-	AddOnFalcon              *falcon.Config               `json:"add_on_falcon"`
-	AddOnPHPApache           *php_apache.Config           `json:"add_on_php_apache"`
-	AddOnNLBGuestbook        *nlb_guestbook.Config        `json:"add_on_nlb_guestbook"`
-	AddOnNLBHelloWorld       *nlb_hello_world.Config      `json:"add_on_nlb_hello_world"`
-	AddOnWordpress           *wordpress.Config            `json:"add_on_wordpress"`
-	AddOnVault               *vault.Config                `json:"add_on_vault"`
-	AddOnJobsPi              *jobs_pi.Config              `json:"add_on_jobs_pi"`
-	AddOnJobsEcho            *jobs_echo.Config            `json:"add_on_jobs_echo"`
-	AddOnCronJobsEcho        *jobs_echo.Config            `json:"add_on_cron_jobs_echo"`
-	AddOnCSRs                *csrs.Config                 `json:"add_on_csrs"`
-	AddOnConfigmaps          *configmaps.Config           `json:"add_on_configmaps"`
-	AddOnSecrets             *secrets.Config              `json:"add_on_secrets"`
-	AddOnClusterloader       *clusterloader.Config        `json:"add_on_clusterloader"`
-	AddOnStress              *stress.Config               `json:"add_on_stress"`
-	AddOnStressInCluster     *stress_in_cluster.Config    `json:"add_on_stress_in_cluster"`
-	AddOnAqua                *aqua.Config                 `json:"add_on_aqua"`
-	AddOnArmory              *armory.Config               `json:"add_on_armory"`
-	AddOnEpsagon             *epsagon.Config              `json:"add_on_epsagon"`
-	AddOnSysdig              *sysdig.Config               `json:"add_on_sysdig"`
-	AddOnSplunk              *splunk.Config               `json:"add_on_splunk"`
+	AddOnCloudwatchAgent     *cloudwatch_agent.Config      `json:"add_on_cloudwatch_agent"`
+	AddOnFluentBit           *fluent_bit.Config            `json:"add_on_fluent_bit"`
+	AddOnMetricsServer       *metrics_server.Config        `json:"add_on_metrics_server"`
+	AddOnKubecost            *kubecost.Config              `json:"add_on_kubecost"`
+	AddOnConformance         *conformance.Config           `json:"add_on_conformance"`
+	AddOnCNI                 *cni.Config                   `json:"add_on_cni"`
+	AddOnCSIEBS              *csi_ebs.Config               `json:"add_on_csi_ebs"`
+	AddOnCSIEFS              *csi_efs.Config               `json:"add_on_csi_efs"`
+	AddOnKubernetesDashboard *kubernetes_dashboard.Config  `json:"add_on_kubernetes_dashboard"`
+	AddOnFalco               *falco.Config                 `json:"add_on_falco"`
+	AddOnFalcon              *falcon.Config                `json:"add_on_falcon"`
+	AddOnPHPApache           *php_apache.Config            `json:"add_on_php_apache"`
+	AddOnNLBGuestbook        *nlb_guestbook.Config         `json:"add_on_nlb_guestbook"`
+	AddOnNLBHelloWorld       *nlb_hello_world.Config       `json:"add_on_nlb_hello_world"`
+	AddOnWordpress           *wordpress.Config             `json:"add_on_wordpress"`
+	AddOnVault               *vault.Config                 `json:"add_on_vault"`
+	AddOnJobsPi              *jobs_pi.Config               `json:"add_on_jobs_pi"`
+	AddOnJobsEcho            *jobs_echo.Config             `json:"add_on_jobs_echo"`
+	AddOnCronJobsEcho        *jobs_echo.Config             `json:"add_on_cron_jobs_echo"`
+	AddOnCSRs                *csrs.Config                  `json:"add_on_csrs"`
+	AddOnConfigmaps          *configmaps.Config            `json:"add_on_configmaps"`
+	AddOnSecrets             *secrets.Config               `json:"add_on_secrets"`
+	AddOnClusterloader       *clusterloader.Config         `json:"add_on_clusterloader"`
+	AddOnStress              *stress.Config                `json:"add_on_stress"`
+	AddOnStressInCluster     *stress_in_cluster.Config     `json:"add_on_stress_in_cluster"`
+	AddOnAqua                *aqua.Config                  `json:"add_on_aqua"`
+	AddOnArmory              *armory.Config                `json:"add_on_armory"`
+	AddOnEpsagon             *epsagon.Config               `json:"add_on_epsagon"`
+	AddOnSysdig              *sysdig.Config                `json:"add_on_sysdig"`
+	AddOnSplunk              *splunk.Config                `json:"add_on_splunk"`
+	AddOnHPACloudWatch       *hpa_cloudwatch.Config        `json:"add_on_hpa_cloudwatch"`
+	AddOnKEDASQS             *keda_sqs.Config              `json:"add_on_keda_sqs"`
+	AddOnCSIS3               *csi_s3.Config                `json:"add_on_csi_s3"`
+	AddOnEMROnEKS            *emr_on_eks.Config            `json:"add_on_emr_on_eks"`
+	AddOnKarpenter           *karpenter.Config             `json:"add_on_karpenter"`
+	AddOnIstioAmbient        *istio_ambient.Config         `json:"add_on_istio_ambient"`
+	AddOnArgoWorkflows       *argo_workflows.Config        `json:"add_on_argo_workflows"`
+	AddOnImagePrepull        *image_prepull.Config         `json:"add_on_image_prepull"`
+	AddOnPVReclaim           *pv_reclaim.Config            `json:"add_on_pv_reclaim"`
+	AddOnSysctl              *sysctl.Config                `json:"add_on_sysctl"`
+	AddOnNetworkPolicy       *network_policy.Config        `json:"add_on_network_policy"`
+	AddOnHollowNodes         *hollow_nodes.Config          `json:"add_on_hollow_nodes"`
+	AddOnDNS                 *dns.Config                   `json:"add_on_dns"`
+	AddOnIRSA                *irsa.Config                  `json:"add_on_irsa"`
+	AddOnFargate             *fargate.Config               `json:"add_on_fargate"`
+	AddOnEndpointSlices      *endpointslices.Config        `json:"add_on_endpoint_slices"`
+	AddOnPrometheusGrafana   *prometheus_grafana.Config    `json:"add_on_prometheus_grafana"`
+	AddOnPortExhaustion      *port_exhaustion.Config       `json:"add_on_port_exhaustion"`
+	AddOnRuntimeRestart      *runtime_restart.Config       `json:"add_on_runtime_restart"`
+	AddOnCertRotation        *cert_rotation.Config         `json:"add_on_cert_rotation"`
+	AddOnKubeStateMetrics    *kube_state_metrics.Config    `json:"add_on_kube_state_metrics"`
+	AddOnADOT                *adot.Config                  `json:"add_on_adot"`
+	AddOnNodeProblemDetector *node_problem_detector.Config `json:"add_on_node_problem_detector"`
+	AddOnPDB                 *pdb.Config                   `json:"add_on_pdb"`
+	AddOnDaemonSetRollout    *daemonset_rollout.Config     `json:"add_on_daemonset_rollout"`
+	AddOnWindows             *windows.Config               `json:"add_on_windows"`
+	AddOnNVIDIAGPU           *nvidia_gpu.Config            `json:"add_on_nvidia_gpu"`
+	AddOnKueue               *kueue.Config                 `json:"add_on_kueue"`
+	AddOnNeuron              *neuron.Config                `json:"add_on_neuron"`
+	AddOnMultiContainer      *multi_container.Config       `json:"add_on_multi_container"`
+	AddOnNodeTagLabels       *node_tag_labels.Config       `json:"add_on_node_tag_labels"`
+	AddOnClusterPause        *cluster_pause.Config         `json:"add_on_cluster_pause"`
+	AddOnCrossCluster        *cross_cluster.Config         `json:"add_on_cross_cluster"`
+	AddOnAuthRefresh         *auth_refresh.Config          `json:"add_on_auth_refresh"`
+	AddOnProbeStorm          *probe_storm.Config           `json:"add_on_probe_storm"`
+	AddOnEventFlood          *event_flood.Config           `json:"add_on_event_flood"`
+	AddOnStaticPod           *static_pod.Config            `json:"add_on_static_pod"`
 }
 
 const (
@@ -219,6 +435,13 @@ func NewDefault() *Config {
 
 		MinimumNodes: DefaultMinimumNodes,
 
+		IAMPreflight:      iam_preflight.NewDefault(),
+		CloudWatchMetrics: cloudwatch_metrics.NewDefault(),
+		OpenSearchResults: opensearch_results.NewDefault(),
+		ArtifactsS3:       artifacts_s3.NewDefault(),
+		StatusReporter:    status_reporter.NewDefault(),
+		SteadyState:       steady_state.NewDefault(),
+
 		// tester order is defined as https://github.com/aws/aws-k8s-tester/blob/v1.5.9/eks/eks.go#L617
 		AddOnCloudwatchAgent:     cloudwatch_agent.NewDefault(),
 		AddOnFluentBit:           fluent_bit.NewDefault(),
@@ -250,6 +473,43 @@ func NewDefault() *Config {
 		AddOnEpsagon:             epsagon.NewDefault(),
 		AddOnSysdig:              sysdig.NewDefault(),
 		AddOnSplunk:              splunk.NewDefault(),
+		AddOnHPACloudWatch:       hpa_cloudwatch.NewDefault(),
+		AddOnKEDASQS:             keda_sqs.NewDefault(),
+		AddOnCSIS3:               csi_s3.NewDefault(),
+		AddOnEMROnEKS:            emr_on_eks.NewDefault(),
+		AddOnKarpenter:           karpenter.NewDefault(),
+		AddOnIstioAmbient:        istio_ambient.NewDefault(),
+		AddOnArgoWorkflows:       argo_workflows.NewDefault(),
+		AddOnImagePrepull:        image_prepull.NewDefault(),
+		AddOnPVReclaim:           pv_reclaim.NewDefault(),
+		AddOnSysctl:              sysctl.NewDefault(),
+		AddOnNetworkPolicy:       network_policy.NewDefault(),
+		AddOnHollowNodes:         hollow_nodes.NewDefault(),
+		AddOnDNS:                 dns.NewDefault(),
+		AddOnIRSA:                irsa.NewDefault(),
+		AddOnFargate:             fargate.NewDefault(),
+		AddOnEndpointSlices:      endpointslices.NewDefault(),
+		AddOnPrometheusGrafana:   prometheus_grafana.NewDefault(),
+		AddOnPortExhaustion:      port_exhaustion.NewDefault(),
+		AddOnRuntimeRestart:      runtime_restart.NewDefault(),
+		AddOnCertRotation:        cert_rotation.NewDefault(),
+		AddOnKubeStateMetrics:    kube_state_metrics.NewDefault(),
+		AddOnADOT:                adot.NewDefault(),
+		AddOnNodeProblemDetector: node_problem_detector.NewDefault(),
+		AddOnPDB:                 pdb.NewDefault(),
+		AddOnDaemonSetRollout:    daemonset_rollout.NewDefault(),
+		AddOnWindows:             windows.NewDefault(),
+		AddOnNVIDIAGPU:           nvidia_gpu.NewDefault(),
+		AddOnKueue:               kueue.NewDefault(),
+		AddOnNeuron:              neuron.NewDefault(),
+		AddOnMultiContainer:      multi_container.NewDefault(),
+		AddOnNodeTagLabels:       node_tag_labels.NewDefault(),
+		AddOnClusterPause:        cluster_pause.NewDefault(),
+		AddOnCrossCluster:        cross_cluster.NewDefault(),
+		AddOnAuthRefresh:         auth_refresh.NewDefault(),
+		AddOnProbeStorm:          probe_storm.NewDefault(),
+		AddOnEventFlood:          event_flood.NewDefault(),
+		AddOnStaticPod:           static_pod.NewDefault(),
 	}
 }
 
@@ -272,6 +532,44 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 	if err := cfg.validateConfig(); err != nil {
 		return fmt.Errorf("validateConfig failed [%v]", err)
 	}
+	if !cfg.FixedNamespace {
+		cfg.setRunNamespaces()
+	}
+
+	if cfg.IAMPreflight != nil && cfg.IAMPreflight.Enable {
+		if err := cfg.IAMPreflight.ValidateAndSetDefaults(cfg.ConfigPath); err != nil {
+			return err
+		}
+	}
+	if cfg.CloudWatchMetrics != nil && cfg.CloudWatchMetrics.Enable {
+		if err := cfg.CloudWatchMetrics.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+	if cfg.OpenSearchResults != nil && cfg.OpenSearchResults.Enable {
+		if err := cfg.OpenSearchResults.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+	if cfg.ArtifactsS3 != nil && cfg.ArtifactsS3.Enable {
+		if cfg.ArtifactsS3.AssumeRoleARN == "" {
+			cfg.ArtifactsS3.AssumeRoleARN = cfg.AWSAssumeRoleARN
+			cfg.ArtifactsS3.AssumeRoleDuration = cfg.AWSAssumeRoleDuration
+		}
+		if err := cfg.ArtifactsS3.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+	if cfg.StatusReporter != nil && cfg.StatusReporter.Enable {
+		if err := cfg.StatusReporter.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+	if cfg.SteadyState != nil && cfg.SteadyState.Enable {
+		if err := cfg.SteadyState.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
 
 	if cfg.AddOnCloudwatchAgent != nil && cfg.AddOnCloudwatchAgent.Enable {
 		if err := cfg.AddOnCloudwatchAgent.ValidateAndSetDefaults(cfg.ClusterName); err != nil {
@@ -424,6 +722,231 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		}
 	}
 
+	if cfg.AddOnHPACloudWatch != nil && cfg.AddOnHPACloudWatch.Enable {
+		if err := cfg.AddOnHPACloudWatch.ValidateAndSetDefaults(cfg.ClusterName); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnKEDASQS != nil && cfg.AddOnKEDASQS.Enable {
+		if err := cfg.AddOnKEDASQS.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnCSIS3 != nil && cfg.AddOnCSIS3.Enable {
+		if err := cfg.AddOnCSIS3.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnEMROnEKS != nil && cfg.AddOnEMROnEKS.Enable {
+		if err := cfg.AddOnEMROnEKS.ValidateAndSetDefaults(cfg.ClusterName); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnKarpenter != nil && cfg.AddOnKarpenter.Enable {
+		if err := cfg.AddOnKarpenter.ValidateAndSetDefaults(cfg.ClusterName); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnIstioAmbient != nil && cfg.AddOnIstioAmbient.Enable {
+		if err := cfg.AddOnIstioAmbient.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnArgoWorkflows != nil && cfg.AddOnArgoWorkflows.Enable {
+		if err := cfg.AddOnArgoWorkflows.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnImagePrepull != nil && cfg.AddOnImagePrepull.Enable {
+		if err := cfg.AddOnImagePrepull.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnPVReclaim != nil && cfg.AddOnPVReclaim.Enable {
+		if err := cfg.AddOnPVReclaim.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnSysctl != nil && cfg.AddOnSysctl.Enable {
+		if err := cfg.AddOnSysctl.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnNetworkPolicy != nil && cfg.AddOnNetworkPolicy.Enable {
+		if err := cfg.AddOnNetworkPolicy.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnHollowNodes != nil && cfg.AddOnHollowNodes.Enable {
+		if err := cfg.AddOnHollowNodes.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnDNS != nil && cfg.AddOnDNS.Enable {
+		if err := cfg.AddOnDNS.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnIRSA != nil && cfg.AddOnIRSA.Enable {
+		if cfg.AddOnIRSA.ClusterName == "" {
+			cfg.AddOnIRSA.ClusterName = cfg.ClusterName
+		}
+		if err := cfg.AddOnIRSA.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnFargate != nil && cfg.AddOnFargate.Enable {
+		if err := cfg.AddOnFargate.ValidateAndSetDefaults(cfg.ClusterName); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnEndpointSlices != nil && cfg.AddOnEndpointSlices.Enable {
+		if err := cfg.AddOnEndpointSlices.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnPrometheusGrafana != nil && cfg.AddOnPrometheusGrafana.Enable {
+		if err := cfg.AddOnPrometheusGrafana.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnPortExhaustion != nil && cfg.AddOnPortExhaustion.Enable {
+		if err := cfg.AddOnPortExhaustion.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnRuntimeRestart != nil && cfg.AddOnRuntimeRestart.Enable {
+		if err := cfg.AddOnRuntimeRestart.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnCertRotation != nil && cfg.AddOnCertRotation.Enable {
+		if err := cfg.AddOnCertRotation.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnKubeStateMetrics != nil && cfg.AddOnKubeStateMetrics.Enable {
+		if err := cfg.AddOnKubeStateMetrics.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnADOT != nil && cfg.AddOnADOT.Enable {
+		if err := cfg.AddOnADOT.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnNodeProblemDetector != nil && cfg.AddOnNodeProblemDetector.Enable {
+		if err := cfg.AddOnNodeProblemDetector.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnPDB != nil && cfg.AddOnPDB.Enable {
+		if err := cfg.AddOnPDB.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnDaemonSetRollout != nil && cfg.AddOnDaemonSetRollout.Enable {
+		if err := cfg.AddOnDaemonSetRollout.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnWindows != nil && cfg.AddOnWindows.Enable {
+		if err := cfg.AddOnWindows.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnNVIDIAGPU != nil && cfg.AddOnNVIDIAGPU.Enable {
+		if err := cfg.AddOnNVIDIAGPU.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnKueue != nil && cfg.AddOnKueue.Enable {
+		if err := cfg.AddOnKueue.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnNeuron != nil && cfg.AddOnNeuron.Enable {
+		if err := cfg.AddOnNeuron.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnMultiContainer != nil && cfg.AddOnMultiContainer.Enable {
+		if err := cfg.AddOnMultiContainer.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnNodeTagLabels != nil && cfg.AddOnNodeTagLabels.Enable {
+		if err := cfg.AddOnNodeTagLabels.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnClusterPause != nil && cfg.AddOnClusterPause.Enable {
+		if err := cfg.AddOnClusterPause.ValidateAndSetDefaults(cfg.ClusterName); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnCrossCluster != nil && cfg.AddOnCrossCluster.Enable {
+		if err := cfg.AddOnCrossCluster.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnAuthRefresh != nil && cfg.AddOnAuthRefresh.Enable {
+		if err := cfg.AddOnAuthRefresh.ValidateAndSetDefaults(cfg.ClusterName); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnProbeStorm != nil && cfg.AddOnProbeStorm.Enable {
+		if err := cfg.AddOnProbeStorm.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnEventFlood != nil && cfg.AddOnEventFlood.Enable {
+		if err := cfg.AddOnEventFlood.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AddOnStaticPod != nil && cfg.AddOnStaticPod.Enable {
+		if err := cfg.AddOnStaticPod.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -448,6 +971,73 @@ func (cfg *Config) validateConfig() error {
 		cfg.ClientTimeout = DefaultClientTimeout
 	}
 	cfg.ClientTimeoutString = cfg.ClientTimeout.String()
+	if cfg.EKSClusterName != "" && cfg.EKSRegion == "" {
+		return errors.New("empty EKSRegion with EKSClusterName")
+	}
+	if cfg.AWSAssumeRoleDuration < 0 {
+		return fmt.Errorf("invalid AWSAssumeRoleDuration %v", cfg.AWSAssumeRoleDuration)
+	}
+	switch cfg.ClientConfigMode {
+	case "":
+		cfg.ClientConfigMode = client.ConfigModeAuto
+	case client.ConfigModeAuto, client.ConfigModeKubeconfig, client.ConfigModeInCluster:
+	default:
+		return fmt.Errorf("unknown ClientConfigMode %q", cfg.ClientConfigMode)
+	}
+	if cfg.ClientRequestTimeout < 0 {
+		return fmt.Errorf("invalid ClientRequestTimeout %v", cfg.ClientRequestTimeout)
+	}
+	cfg.ClientRequestTimeoutString = cfg.ClientRequestTimeout.String()
+	if cfg.ClientRetryInitialInterval < 0 {
+		return fmt.Errorf("invalid ClientRetryInitialInterval %v", cfg.ClientRetryInitialInterval)
+	}
+	if cfg.ClientRetryFactor < 0 {
+		return fmt.Errorf("invalid ClientRetryFactor %v", cfg.ClientRetryFactor)
+	}
+	if cfg.ClientRetrySteps < 0 {
+		return fmt.Errorf("invalid ClientRetrySteps %d", cfg.ClientRetrySteps)
+	}
+	if cfg.APIQPSBudget < 0 {
+		return fmt.Errorf("invalid APIQPSBudget %v", cfg.APIQPSBudget)
+	}
+	if cfg.APIBurstBudget < 0 {
+		return fmt.Errorf("invalid APIBurstBudget %d", cfg.APIBurstBudget)
+	}
+
+	if cfg.Seed == 0 {
+		cfg.Seed = rand.NewSeed()
+	}
+	if cfg.RunID == "" {
+		cfg.RunID = rand.String(8)
+	}
+	if errs := validation.IsDNS1123Label(cfg.RunID); len(errs) > 0 {
+		return fmt.Errorf("invalid RunID %q (%s)", cfg.RunID, strings.Join(errs, ", "))
+	}
+
+	if cfg.TimeoutPerTester < 0 {
+		return fmt.Errorf("invalid TimeoutPerTester %v", cfg.TimeoutPerTester)
+	}
+	cfg.TimeoutPerTesterString = cfg.TimeoutPerTester.String()
+	if cfg.Retries < 0 {
+		return fmt.Errorf("invalid Retries %d", cfg.Retries)
+	}
+	for name, p := range cfg.TesterPolicies {
+		if p == nil {
+			continue
+		}
+		if p.Timeout < 0 {
+			return fmt.Errorf("invalid TesterPolicies[%q].Timeout %v", name, p.Timeout)
+		}
+		if p.Retries != nil && *p.Retries < 0 {
+			return fmt.Errorf("invalid TesterPolicies[%q].Retries %d", name, *p.Retries)
+		}
+	}
+	if err := cfg.validateTesterHooks(); err != nil {
+		return err
+	}
+	if err := cfg.validateTesterOrder(); err != nil {
+		return err
+	}
 
 	if cfg.ConfigPath == "" {
 		rootDir, err := os.Getwd()
@@ -461,7 +1051,7 @@ func (cfg *Config) validateConfig() error {
 		var p string
 		p, err = filepath.Abs(cfg.ConfigPath)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		cfg.ConfigPath = p
 	}
@@ -472,6 +1062,16 @@ func (cfg *Config) validateConfig() error {
 		return err
 	}
 
+	if cfg.ReportJUnitPath == "" {
+		cfg.ReportJUnitPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".junit.xml"
+	}
+	if cfg.ReportResultsPath == "" {
+		cfg.ReportResultsPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".results.json"
+	}
+	if cfg.ReportHTMLPath == "" {
+		cfg.ReportHTMLPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".report.html"
+	}
+
 	if len(cfg.LogOutputs) == 1 && (cfg.LogOutputs[0] == "stderr" || cfg.LogOutputs[0] == "stdout") {
 		cfg.LogOutputs = append(cfg.LogOutputs, strings.ReplaceAll(cfg.ConfigPath, ".yaml", "")+".log")
 	}
@@ -489,6 +1089,79 @@ func (cfg *Config) validateConfig() error {
 	return nil
 }
 
+const (
+	// TesterStatusNotStarted is the status of the tester that has not been applied.
+	TesterStatusNotStarted = "not-started"
+	// TesterStatusApplied is the status of the tester that has been successfully applied.
+	TesterStatusApplied = "applied"
+	// TesterStatusFailed is the status of the tester that failed to apply or delete.
+	TesterStatusFailed = "failed"
+	// TesterStatusDeleted is the status of the tester that has been successfully deleted.
+	TesterStatusDeleted = "deleted"
+)
+
+// TesterStatus represents the current status of a tester.
+type TesterStatus struct {
+	// Status is either "not-started", "applied", "failed", or "deleted".
+	Status string `json:"status"`
+	// Error is the last error message, if the tester failed.
+	Error string `json:"error,omitempty"`
+	// UpdatedAt is the last time the status was updated.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TesterPolicy is the per-tester override of the timeout and retry policy.
+type TesterPolicy struct {
+	// Timeout overrides "TimeoutPerTester", if non-zero.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Retries overrides "Retries", if not nil.
+	Retries *int `json:"retries,omitempty"`
+}
+
+// GetTesterPolicy returns the timeout and the number of retries for the tester
+// with the "TesterStatus" key, with the per-tester overrides in "TesterPolicies".
+func (cfg *Config) GetTesterPolicy(name string) (timeout time.Duration, retries int) {
+	timeout, retries = cfg.TimeoutPerTester, cfg.Retries
+	if p, ok := cfg.TesterPolicies[name]; ok && p != nil {
+		if p.Timeout > 0 {
+			timeout = p.Timeout
+		}
+		if p.Retries != nil {
+			retries = *p.Retries
+		}
+	}
+	return timeout, retries
+}
+
+// GetTesterStatus returns the current status of the tester.
+// Returns "not-started" if the tester has not been recorded yet.
+func (cfg *Config) GetTesterStatus(name string) string {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	st, ok := cfg.TesterStatus[name]
+	if !ok || st == nil {
+		return TesterStatusNotStarted
+	}
+	return st.Status
+}
+
+// SetTesterStatus updates the status of the tester.
+func (cfg *Config) SetTesterStatus(name string, status string, err error) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if cfg.TesterStatus == nil {
+		cfg.TesterStatus = make(map[string]*TesterStatus)
+	}
+	st := &TesterStatus{
+		Status:    status,
+		UpdatedAt: time.Now(),
+	}
+	if err != nil {
+		st.Error = err.Error()
+	}
+	cfg.TesterStatus[name] = st
+}
+
 // ENV_PREFIX is the environment variable prefix.
 const ENV_PREFIX = "K8S_TESTER_"
 
@@ -577,73 +1250,133 @@ func (cfg *Config) UpdateFromEnvs() (err error) {
 		return fmt.Errorf("expected *Config, got %T", vv)
 	}
 
-	// tester order is defined as https://github.com/aws/aws-k8s-tester/blob/v1.5.9/eks/eks.go#L617
-	vv, err = parseEnvs(ENV_PREFIX+cloudwatch_agent.Env()+"_", cfg.AddOnCloudwatchAgent)
+	vv, err = parseEnvs(ENV_PREFIX+iam_preflight.Env()+"_", cfg.IAMPreflight)
 	if err != nil {
 		return err
 	}
-	if av, ok := vv.(*cloudwatch_agent.Config); ok {
-		cfg.AddOnCloudwatchAgent = av
+	if av, ok := vv.(*iam_preflight.Config); ok {
+		cfg.IAMPreflight = av
 	} else {
-		return fmt.Errorf("expected *cloudwatch_agent.Config, got %T", vv)
+		return fmt.Errorf("expected *iam_preflight.Config, got %T", vv)
 	}
 
-	vv, err = parseEnvs(ENV_PREFIX+fluent_bit.Env()+"_", cfg.AddOnFluentBit)
+	vv, err = parseEnvs(ENV_PREFIX+cloudwatch_metrics.Env()+"_", cfg.CloudWatchMetrics)
 	if err != nil {
 		return err
 	}
-	if av, ok := vv.(*fluent_bit.Config); ok {
-		cfg.AddOnFluentBit = av
+	if av, ok := vv.(*cloudwatch_metrics.Config); ok {
+		cfg.CloudWatchMetrics = av
 	} else {
-		return fmt.Errorf("expected *fluent_bit.Config, got %T", vv)
+		return fmt.Errorf("expected *cloudwatch_metrics.Config, got %T", vv)
 	}
 
-	vv, err = parseEnvs(ENV_PREFIX+metrics_server.Env()+"_", cfg.AddOnMetricsServer)
+	vv, err = parseEnvs(ENV_PREFIX+opensearch_results.Env()+"_", cfg.OpenSearchResults)
 	if err != nil {
 		return err
 	}
-	if av, ok := vv.(*metrics_server.Config); ok {
-		cfg.AddOnMetricsServer = av
+	if av, ok := vv.(*opensearch_results.Config); ok {
+		cfg.OpenSearchResults = av
 	} else {
-		return fmt.Errorf("expected *metrics_server.Config, got %T", vv)
+		return fmt.Errorf("expected *opensearch_results.Config, got %T", vv)
 	}
 
-	vv, err = parseEnvs(ENV_PREFIX+kubecost.Env()+"_", cfg.AddOnKubecost)
+	vv, err = parseEnvs(ENV_PREFIX+artifacts_s3.Env()+"_", cfg.ArtifactsS3)
 	if err != nil {
 		return err
 	}
-	if av, ok := vv.(*kubecost.Config); ok {
-		cfg.AddOnKubecost = av
+	if av, ok := vv.(*artifacts_s3.Config); ok {
+		cfg.ArtifactsS3 = av
 	} else {
-		return fmt.Errorf("expected *kubecost.Config, got %T", vv)
+		return fmt.Errorf("expected *artifacts_s3.Config, got %T", vv)
 	}
 
-	vv, err = parseEnvs(ENV_PREFIX+cni.Env()+"_", cfg.AddOnCNI)
+	vv, err = parseEnvs(ENV_PREFIX+status_reporter.Env()+"_", cfg.StatusReporter)
 	if err != nil {
 		return err
 	}
-	if av, ok := vv.(*cni.Config); ok {
-		cfg.AddOnCNI = av
+	if av, ok := vv.(*status_reporter.Config); ok {
+		cfg.StatusReporter = av
 	} else {
-		return fmt.Errorf("expected *cni.Config, got %T", vv)
+		return fmt.Errorf("expected *status_reporter.Config, got %T", vv)
 	}
 
-	vv, err = parseEnvs(ENV_PREFIX+conformance.Env()+"_", cfg.AddOnConformance)
+	vv, err = parseEnvs(ENV_PREFIX+steady_state.Env()+"_", cfg.SteadyState)
 	if err != nil {
 		return err
 	}
-	if av, ok := vv.(*conformance.Config); ok {
-		cfg.AddOnConformance = av
+	if av, ok := vv.(*steady_state.Config); ok {
+		cfg.SteadyState = av
 	} else {
-		return fmt.Errorf("expected *conformance.Config, got %T", vv)
+		return fmt.Errorf("expected *steady_state.Config, got %T", vv)
 	}
 
-	vv, err = parseEnvs(ENV_PREFIX+csi_ebs.Env()+"_", cfg.AddOnCSIEBS)
+	// tester order is defined as https://github.com/aws/aws-k8s-tester/blob/v1.5.9/eks/eks.go#L617
+	vv, err = parseEnvs(ENV_PREFIX+cloudwatch_agent.Env()+"_", cfg.AddOnCloudwatchAgent)
 	if err != nil {
 		return err
 	}
-	if av, ok := vv.(*csi_ebs.Config); ok {
-		cfg.AddOnCSIEBS = av
+	if av, ok := vv.(*cloudwatch_agent.Config); ok {
+		cfg.AddOnCloudwatchAgent = av
+	} else {
+		return fmt.Errorf("expected *cloudwatch_agent.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+fluent_bit.Env()+"_", cfg.AddOnFluentBit)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*fluent_bit.Config); ok {
+		cfg.AddOnFluentBit = av
+	} else {
+		return fmt.Errorf("expected *fluent_bit.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+metrics_server.Env()+"_", cfg.AddOnMetricsServer)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*metrics_server.Config); ok {
+		cfg.AddOnMetricsServer = av
+	} else {
+		return fmt.Errorf("expected *metrics_server.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+kubecost.Env()+"_", cfg.AddOnKubecost)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*kubecost.Config); ok {
+		cfg.AddOnKubecost = av
+	} else {
+		return fmt.Errorf("expected *kubecost.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+cni.Env()+"_", cfg.AddOnCNI)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*cni.Config); ok {
+		cfg.AddOnCNI = av
+	} else {
+		return fmt.Errorf("expected *cni.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+conformance.Env()+"_", cfg.AddOnConformance)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*conformance.Config); ok {
+		cfg.AddOnConformance = av
+	} else {
+		return fmt.Errorf("expected *conformance.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+csi_ebs.Env()+"_", cfg.AddOnCSIEBS)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*csi_ebs.Config); ok {
+		cfg.AddOnCSIEBS = av
 	} else {
 		return fmt.Errorf("expected *csi_ebs.Config, got %T", vv)
 	}
@@ -964,6 +1697,376 @@ func (cfg *Config) UpdateFromEnvs() (err error) {
 		return fmt.Errorf("expected *splunk.Config, got %T", vv)
 	}
 
+	vv, err = parseEnvs(ENV_PREFIX+hpa_cloudwatch.Env()+"_", cfg.AddOnHPACloudWatch)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*hpa_cloudwatch.Config); ok {
+		cfg.AddOnHPACloudWatch = av
+	} else {
+		return fmt.Errorf("expected *hpa_cloudwatch.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+keda_sqs.Env()+"_", cfg.AddOnKEDASQS)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*keda_sqs.Config); ok {
+		cfg.AddOnKEDASQS = av
+	} else {
+		return fmt.Errorf("expected *keda_sqs.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+csi_s3.Env()+"_", cfg.AddOnCSIS3)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*csi_s3.Config); ok {
+		cfg.AddOnCSIS3 = av
+	} else {
+		return fmt.Errorf("expected *csi_s3.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+emr_on_eks.Env()+"_", cfg.AddOnEMROnEKS)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*emr_on_eks.Config); ok {
+		cfg.AddOnEMROnEKS = av
+	} else {
+		return fmt.Errorf("expected *emr_on_eks.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+karpenter.Env()+"_", cfg.AddOnKarpenter)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*karpenter.Config); ok {
+		cfg.AddOnKarpenter = av
+	} else {
+		return fmt.Errorf("expected *karpenter.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+istio_ambient.Env()+"_", cfg.AddOnIstioAmbient)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*istio_ambient.Config); ok {
+		cfg.AddOnIstioAmbient = av
+	} else {
+		return fmt.Errorf("expected *istio_ambient.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+argo_workflows.Env()+"_", cfg.AddOnArgoWorkflows)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*argo_workflows.Config); ok {
+		cfg.AddOnArgoWorkflows = av
+	} else {
+		return fmt.Errorf("expected *argo_workflows.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+image_prepull.Env()+"_", cfg.AddOnImagePrepull)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*image_prepull.Config); ok {
+		cfg.AddOnImagePrepull = av
+	} else {
+		return fmt.Errorf("expected *image_prepull.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+pv_reclaim.Env()+"_", cfg.AddOnPVReclaim)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*pv_reclaim.Config); ok {
+		cfg.AddOnPVReclaim = av
+	} else {
+		return fmt.Errorf("expected *pv_reclaim.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+sysctl.Env()+"_", cfg.AddOnSysctl)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*sysctl.Config); ok {
+		cfg.AddOnSysctl = av
+	} else {
+		return fmt.Errorf("expected *sysctl.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+network_policy.Env()+"_", cfg.AddOnNetworkPolicy)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*network_policy.Config); ok {
+		cfg.AddOnNetworkPolicy = av
+	} else {
+		return fmt.Errorf("expected *network_policy.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+hollow_nodes.Env()+"_", cfg.AddOnHollowNodes)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*hollow_nodes.Config); ok {
+		cfg.AddOnHollowNodes = av
+	} else {
+		return fmt.Errorf("expected *hollow_nodes.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+dns.Env()+"_", cfg.AddOnDNS)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*dns.Config); ok {
+		cfg.AddOnDNS = av
+	} else {
+		return fmt.Errorf("expected *dns.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+irsa.Env()+"_", cfg.AddOnIRSA)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*irsa.Config); ok {
+		cfg.AddOnIRSA = av
+	} else {
+		return fmt.Errorf("expected *irsa.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+fargate.Env()+"_", cfg.AddOnFargate)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*fargate.Config); ok {
+		cfg.AddOnFargate = av
+	} else {
+		return fmt.Errorf("expected *fargate.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+endpointslices.Env()+"_", cfg.AddOnEndpointSlices)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*endpointslices.Config); ok {
+		cfg.AddOnEndpointSlices = av
+	} else {
+		return fmt.Errorf("expected *endpointslices.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+prometheus_grafana.Env()+"_", cfg.AddOnPrometheusGrafana)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*prometheus_grafana.Config); ok {
+		cfg.AddOnPrometheusGrafana = av
+	} else {
+		return fmt.Errorf("expected *prometheus_grafana.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+port_exhaustion.Env()+"_", cfg.AddOnPortExhaustion)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*port_exhaustion.Config); ok {
+		cfg.AddOnPortExhaustion = av
+	} else {
+		return fmt.Errorf("expected *port_exhaustion.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+runtime_restart.Env()+"_", cfg.AddOnRuntimeRestart)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*runtime_restart.Config); ok {
+		cfg.AddOnRuntimeRestart = av
+	} else {
+		return fmt.Errorf("expected *runtime_restart.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+cert_rotation.Env()+"_", cfg.AddOnCertRotation)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*cert_rotation.Config); ok {
+		cfg.AddOnCertRotation = av
+	} else {
+		return fmt.Errorf("expected *cert_rotation.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+kube_state_metrics.Env()+"_", cfg.AddOnKubeStateMetrics)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*kube_state_metrics.Config); ok {
+		cfg.AddOnKubeStateMetrics = av
+	} else {
+		return fmt.Errorf("expected *kube_state_metrics.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+adot.Env()+"_", cfg.AddOnADOT)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*adot.Config); ok {
+		cfg.AddOnADOT = av
+	} else {
+		return fmt.Errorf("expected *adot.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+node_problem_detector.Env()+"_", cfg.AddOnNodeProblemDetector)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*node_problem_detector.Config); ok {
+		cfg.AddOnNodeProblemDetector = av
+	} else {
+		return fmt.Errorf("expected *node_problem_detector.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+pdb.Env()+"_", cfg.AddOnPDB)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*pdb.Config); ok {
+		cfg.AddOnPDB = av
+	} else {
+		return fmt.Errorf("expected *pdb.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+daemonset_rollout.Env()+"_", cfg.AddOnDaemonSetRollout)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*daemonset_rollout.Config); ok {
+		cfg.AddOnDaemonSetRollout = av
+	} else {
+		return fmt.Errorf("expected *daemonset_rollout.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+windows.Env()+"_", cfg.AddOnWindows)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*windows.Config); ok {
+		cfg.AddOnWindows = av
+	} else {
+		return fmt.Errorf("expected *windows.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+nvidia_gpu.Env()+"_", cfg.AddOnNVIDIAGPU)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*nvidia_gpu.Config); ok {
+		cfg.AddOnNVIDIAGPU = av
+	} else {
+		return fmt.Errorf("expected *nvidia_gpu.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+kueue.Env()+"_", cfg.AddOnKueue)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*kueue.Config); ok {
+		cfg.AddOnKueue = av
+	} else {
+		return fmt.Errorf("expected *kueue.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+neuron.Env()+"_", cfg.AddOnNeuron)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*neuron.Config); ok {
+		cfg.AddOnNeuron = av
+	} else {
+		return fmt.Errorf("expected *neuron.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+multi_container.Env()+"_", cfg.AddOnMultiContainer)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*multi_container.Config); ok {
+		cfg.AddOnMultiContainer = av
+	} else {
+		return fmt.Errorf("expected *multi_container.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+node_tag_labels.Env()+"_", cfg.AddOnNodeTagLabels)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*node_tag_labels.Config); ok {
+		cfg.AddOnNodeTagLabels = av
+	} else {
+		return fmt.Errorf("expected *node_tag_labels.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+cluster_pause.Env()+"_", cfg.AddOnClusterPause)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*cluster_pause.Config); ok {
+		cfg.AddOnClusterPause = av
+	} else {
+		return fmt.Errorf("expected *cluster_pause.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+cross_cluster.Env()+"_", cfg.AddOnCrossCluster)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*cross_cluster.Config); ok {
+		cfg.AddOnCrossCluster = av
+	} else {
+		return fmt.Errorf("expected *cross_cluster.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+auth_refresh.Env()+"_", cfg.AddOnAuthRefresh)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*auth_refresh.Config); ok {
+		cfg.AddOnAuthRefresh = av
+	} else {
+		return fmt.Errorf("expected *auth_refresh.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+probe_storm.Env()+"_", cfg.AddOnProbeStorm)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*probe_storm.Config); ok {
+		cfg.AddOnProbeStorm = av
+	} else {
+		return fmt.Errorf("expected *probe_storm.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+event_flood.Env()+"_", cfg.AddOnEventFlood)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*event_flood.Config); ok {
+		cfg.AddOnEventFlood = av
+	} else {
+		return fmt.Errorf("expected *event_flood.Config, got %T", vv)
+	}
+
+	vv, err = parseEnvs(ENV_PREFIX+static_pod.Env()+"_", cfg.AddOnStaticPod)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*static_pod.Config); ok {
+		cfg.AddOnStaticPod = av
+	} else {
+		return fmt.Errorf("expected *static_pod.Config, got %T", vv)
+	}
+
 	return err
 }
 
@@ -1040,9 +2143,13 @@ func parseEnvs(pfx string, addOn interface{}) (interface{}, error) {
 		case reflect.Map:
 			switch fieldName {
 			case "Tags",
+				"Dimensions",
 				"NodeSelector",
 				"DeploymentNodeSelector",
-				"DeploymentNodeSelector2048":
+				"DeploymentNodeSelector2048",
+				"NodeLabels",
+				"ProfileSelectorLabels",
+				"Values":
 				vv.Field(i).Set(reflect.ValueOf(make(map[string]string)))
 				mm := make(map[string]string)
 				if err := json.Unmarshal([]byte(sv), &mm); err != nil {
@@ -1050,6 +2157,41 @@ func parseEnvs(pfx string, addOn interface{}) (interface{}, error) {
 				}
 				vv.Field(i).Set(reflect.ValueOf(mm))
 
+			case "Profiles":
+				mm := make(map[string]map[string]int64)
+				if err := json.Unmarshal([]byte(sv), &mm); err != nil {
+					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				}
+				vv.Field(i).Set(reflect.ValueOf(mm))
+
+			case "Shapes":
+				mm := make(map[string]*hollow_nodes.Shape)
+				if err := json.Unmarshal([]byte(sv), &mm); err != nil {
+					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				}
+				vv.Field(i).Set(reflect.ValueOf(mm))
+
+			case "Strategies":
+				mm := make(map[string]*daemonset_rollout.Strategy)
+				if err := json.Unmarshal([]byte(sv), &mm); err != nil {
+					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				}
+				vv.Field(i).Set(reflect.ValueOf(mm))
+
+			case "TesterPolicies":
+				mm := make(map[string]*TesterPolicy)
+				if err := json.Unmarshal([]byte(sv), &mm); err != nil {
+					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				}
+				vv.Field(i).Set(reflect.ValueOf(mm))
+
+			case "TesterHooks":
+				mm := make(map[string]*TesterHooks)
+				if err := json.Unmarshal([]byte(sv), &mm); err != nil {
+					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				}
+				vv.Field(i).Set(reflect.ValueOf(mm))
+
 			default:
 				return nil, fmt.Errorf("field %q not supported for reflect.Map", fieldName)
 			}
@@ -1109,3 +2251,36 @@ export KUBECTL="{{ .KubectlCommand }}"
 {{ .KubectlCommand }} get nodes -o=wide
 ###########################
 `
+
+// clientEKS returns the EKS client configuration,
+// or nil to create the client from the kubeconfig file.
+func (cfg *Config) clientEKS() *client.EKS {
+	if cfg.EKSClusterName == "" || cfg.KubeconfigPath != "" {
+		return nil
+	}
+	return &client.EKS{
+		Region:             cfg.EKSRegion,
+		ClusterName:        cfg.EKSClusterName,
+		AssumeRoleARN:      cfg.AWSAssumeRoleARN,
+		AssumeRoleDuration: cfg.AWSAssumeRoleDuration,
+	}
+}
+
+// clientRetryBackoff returns the backoff of the client retries,
+// or nil to use the client default.
+func (cfg *Config) clientRetryBackoff() *wait.Backoff {
+	if cfg.ClientRetryInitialInterval == 0 && cfg.ClientRetryFactor == 0 && cfg.ClientRetrySteps == 0 {
+		return nil
+	}
+	b := client.DefaultRetryBackoff()
+	if cfg.ClientRetryInitialInterval > 0 {
+		b.Duration = cfg.ClientRetryInitialInterval
+	}
+	if cfg.ClientRetryFactor > 0 {
+		b.Factor = cfg.ClientRetryFactor
+	}
+	if cfg.ClientRetrySteps > 0 {
+		b.Steps = cfg.ClientRetrySteps
+	}
+	return &b
+}
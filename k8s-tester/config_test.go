@@ -1,10 +1,18 @@
 package k8s_tester
 
 import (
+	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	daemonset_rollout "github.com/aws/aws-k8s-tester/k8s-tester/daemonset-rollout"
+	hollow_nodes "github.com/aws/aws-k8s-tester/k8s-tester/hollow-nodes"
+	prometheus_grafana "github.com/aws/aws-k8s-tester/k8s-tester/prometheus-grafana"
 )
 
 func TestEnv(t *testing.T) {
@@ -20,12 +28,48 @@ func TestEnv(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_CLIENTS")
 	os.Setenv("K8S_TESTER_CLIENT_TIMEOUT", "100m")
 	defer os.Unsetenv("K8S_TESTER_CLIENT_TIMEOUT")
+	os.Setenv("K8S_TESTER_EKS_CLUSTER_NAME", "hello-eks")
+	defer os.Unsetenv("K8S_TESTER_EKS_CLUSTER_NAME")
+	os.Setenv("K8S_TESTER_EKS_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_EKS_REGION")
+	os.Setenv("K8S_TESTER_AWS_ASSUME_ROLE_ARN", "arn:aws:iam::123:role/hello")
+	defer os.Unsetenv("K8S_TESTER_AWS_ASSUME_ROLE_ARN")
+	os.Setenv("K8S_TESTER_AWS_ASSUME_ROLE_DURATION", "1h")
+	defer os.Unsetenv("K8S_TESTER_AWS_ASSUME_ROLE_DURATION")
+	os.Setenv("K8S_TESTER_CLIENT_CONFIG_MODE", "in-cluster")
+	defer os.Unsetenv("K8S_TESTER_CLIENT_CONFIG_MODE")
+	os.Setenv("K8S_TESTER_CLIENT_REQUEST_TIMEOUT", "30s")
+	defer os.Unsetenv("K8S_TESTER_CLIENT_REQUEST_TIMEOUT")
+	os.Setenv("K8S_TESTER_CLIENT_RETRY_STEPS", "3")
+	defer os.Unsetenv("K8S_TESTER_CLIENT_RETRY_STEPS")
+	os.Setenv("K8S_TESTER_API_QPS_BUDGET", "50")
+	defer os.Unsetenv("K8S_TESTER_API_QPS_BUDGET")
+	os.Setenv("K8S_TESTER_API_BURST_BUDGET", "100")
+	defer os.Unsetenv("K8S_TESTER_API_BURST_BUDGET")
 	os.Setenv("K8S_TESTER_KUBECTL_DOWNLOAD_URL", "hello.url")
 	defer os.Unsetenv("K8S_TESTER_KUBECTL_DOWNLOAD_URL")
 	os.Setenv("K8S_TESTER_KUBECONFIG_PATH", "hello.config")
 	defer os.Unsetenv("K8S_TESTER_KUBECONFIG_PATH")
 	os.Setenv("K8S_TESTER_KUBECONFIG_CONTEXT", "hello.ctx")
 	defer os.Unsetenv("K8S_TESTER_KUBECONFIG_CONTEXT")
+	os.Setenv("K8S_TESTER_REPORT_TAP_PATH", "hello.tap")
+	defer os.Unsetenv("K8S_TESTER_REPORT_TAP_PATH")
+	os.Setenv("K8S_TESTER_REPORT_HTML_PATH", "hello.html")
+	defer os.Unsetenv("K8S_TESTER_REPORT_HTML_PATH")
+	os.Setenv("K8S_TESTER_TIMEOUT_PER_TESTER", "30m")
+	defer os.Unsetenv("K8S_TESTER_TIMEOUT_PER_TESTER")
+	os.Setenv("K8S_TESTER_SEED", "12345")
+	defer os.Unsetenv("K8S_TESTER_SEED")
+	os.Setenv("K8S_TESTER_RUN_ID", "run123")
+	defer os.Unsetenv("K8S_TESTER_RUN_ID")
+	os.Setenv("K8S_TESTER_FIXED_NAMESPACE", "true")
+	defer os.Unsetenv("K8S_TESTER_FIXED_NAMESPACE")
+	os.Setenv("K8S_TESTER_RETRIES", "2")
+	defer os.Unsetenv("K8S_TESTER_RETRIES")
+	os.Setenv("K8S_TESTER_TESTER_POLICIES", `{"nlb-guestbook":{"timeout":3600000000000,"retries":0},"cron-jobs-echo":{"retries":5}}`)
+	defer os.Unsetenv("K8S_TESTER_TESTER_POLICIES")
+	os.Setenv("K8S_TESTER_TESTER_HOOKS", `{"nlb-guestbook":{"pre_apply_hooks":[{"command":"echo pre"}],"post_apply_hooks":[{"command":"echo post","image":"busybox"}]}}`)
+	defer os.Unsetenv("K8S_TESTER_TESTER_HOOKS")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -46,6 +90,36 @@ func TestEnv(t *testing.T) {
 	if cfg.ClientTimeout != 100*time.Minute {
 		t.Fatalf("unexpected cfg.ClientTimeout %v", cfg.ClientTimeout)
 	}
+	if cfg.ClientConfigMode != "in-cluster" {
+		t.Fatalf("unexpected cfg.ClientConfigMode %q", cfg.ClientConfigMode)
+	}
+	if cfg.EKSClusterName != "hello-eks" || cfg.EKSRegion != "us-west-2" {
+		t.Fatalf("unexpected EKS cluster %q in %q", cfg.EKSClusterName, cfg.EKSRegion)
+	}
+	if eks := cfg.clientEKS(); (cfg.KubeconfigPath == "") != (eks != nil) {
+		t.Fatalf("unexpected client EKS %+v with kubeconfig %q", eks, cfg.KubeconfigPath)
+	}
+	if cfg.AWSAssumeRoleARN != "arn:aws:iam::123:role/hello" || cfg.AWSAssumeRoleDuration != time.Hour {
+		t.Fatalf("unexpected assume role %q for %v", cfg.AWSAssumeRoleARN, cfg.AWSAssumeRoleDuration)
+	}
+	if eks := cfg.clientEKS(); eks != nil && eks.AssumeRoleARN != cfg.AWSAssumeRoleARN {
+		t.Fatalf("unexpected client EKS assume role %q", eks.AssumeRoleARN)
+	}
+	if cfg.ClientRequestTimeout != 30*time.Second {
+		t.Fatalf("unexpected cfg.ClientRequestTimeout %v", cfg.ClientRequestTimeout)
+	}
+	if cfg.ClientRetrySteps != 3 {
+		t.Fatalf("unexpected cfg.ClientRetrySteps %d", cfg.ClientRetrySteps)
+	}
+	if b := cfg.clientRetryBackoff(); b == nil || b.Steps != 3 || b.Duration != client.DefaultRetryBackoff().Duration {
+		t.Fatalf("unexpected client retry backoff %+v", b)
+	}
+	if cfg.APIQPSBudget != 50 {
+		t.Fatalf("unexpected cfg.APIQPSBudget %v", cfg.APIQPSBudget)
+	}
+	if cfg.APIBurstBudget != 100 {
+		t.Fatalf("unexpected cfg.APIBurstBudget %v", cfg.APIBurstBudget)
+	}
 	if cfg.KubectlDownloadURL != "hello.url" {
 		t.Fatalf("unexpected cfg.KubectlDownloadURL %v", cfg.KubectlDownloadURL)
 	}
@@ -55,6 +129,98 @@ func TestEnv(t *testing.T) {
 	if cfg.KubeconfigContext != "hello.ctx" {
 		t.Fatalf("unexpected cfg.KubeconfigContext %v", cfg.KubeconfigContext)
 	}
+	if cfg.ReportTAPPath != "hello.tap" {
+		t.Fatalf("unexpected cfg.ReportTAPPath %v", cfg.ReportTAPPath)
+	}
+	if cfg.ReportHTMLPath != "hello.html" {
+		t.Fatalf("unexpected cfg.ReportHTMLPath %v", cfg.ReportHTMLPath)
+	}
+	if cfg.TimeoutPerTester != 30*time.Minute {
+		t.Fatalf("unexpected cfg.TimeoutPerTester %v", cfg.TimeoutPerTester)
+	}
+	if cfg.Seed != 12345 {
+		t.Fatalf("unexpected cfg.Seed %v", cfg.Seed)
+	}
+	if cfg.RunID != "run123" {
+		t.Fatalf("unexpected cfg.RunID %v", cfg.RunID)
+	}
+	if !cfg.FixedNamespace {
+		t.Fatalf("unexpected cfg.FixedNamespace %v", cfg.FixedNamespace)
+	}
+	if cfg.Retries != 2 {
+		t.Fatalf("unexpected cfg.Retries %v", cfg.Retries)
+	}
+	if timeout, retries := cfg.GetTesterPolicy("nlb-guestbook"); timeout != time.Hour || retries != 0 {
+		t.Fatalf("unexpected nlb-guestbook policy %v, %d", timeout, retries)
+	}
+	if timeout, retries := cfg.GetTesterPolicy("jobs-pi"); timeout != 30*time.Minute || retries != 2 {
+		t.Fatalf("unexpected jobs-pi policy %v, %d", timeout, retries)
+	}
+	// the CronJob "jobs-echo" tester has its own policy, keyed by its status key
+	if timeout, retries := cfg.GetTesterPolicy(cronJobsEchoStatusKey); timeout != 30*time.Minute || retries != 5 {
+		t.Fatalf("unexpected cron-jobs-echo policy %v, %d", timeout, retries)
+	}
+	if timeout, retries := cfg.GetTesterPolicy("jobs-echo"); timeout != 30*time.Minute || retries != 2 {
+		t.Fatalf("unexpected jobs-echo policy %v, %d", timeout, retries)
+	}
+	if hooks := cfg.getHooks("nlb-guestbook", HookPhasePreApply); len(hooks) != 1 || hooks[0].Command != "echo pre" {
+		t.Fatalf("unexpected nlb-guestbook pre-apply hooks %+v", hooks)
+	}
+	if hooks := cfg.getHooks("nlb-guestbook", HookPhasePostApply); len(hooks) != 1 || hooks[0].Image != "busybox" {
+		t.Fatalf("unexpected nlb-guestbook post-apply hooks %+v", hooks)
+	}
+}
+
+func TestTesterStatus(t *testing.T) {
+	cfg := NewDefault()
+
+	if st := cfg.GetTesterStatus("jobs-pi"); st != TesterStatusNotStarted {
+		t.Fatalf("unexpected tester status %q", st)
+	}
+	cfg.SetTesterStatus("jobs-pi", TesterStatusApplied, nil)
+	if st := cfg.GetTesterStatus("jobs-pi"); st != TesterStatusApplied {
+		t.Fatalf("unexpected tester status %q", st)
+	}
+	cfg.SetTesterStatus("jobs-pi", TesterStatusFailed, errors.New("hello"))
+	if st := cfg.GetTesterStatus("jobs-pi"); st != TesterStatusFailed {
+		t.Fatalf("unexpected tester status %q", st)
+	}
+	if cfg.TesterStatus["jobs-pi"].Error != "hello" {
+		t.Fatalf("unexpected tester error %q", cfg.TesterStatus["jobs-pi"].Error)
+	}
+
+	os.Setenv("K8S_TESTER_TESTER_STATUS", "hello")
+	defer os.Unsetenv("K8S_TESTER_TESTER_STATUS")
+	if err := cfg.UpdateFromEnvs(); err == nil {
+		t.Fatal("expected error for read-only field")
+	}
+}
+
+func TestResumeNotPersisted(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "k8s-tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := NewDefault()
+	cfg.ConfigPath = filepath.Join(dir, "k8s-tester.yaml")
+	cfg.Resume = true
+	cfg.SetTesterStatus("jobs-pi", TesterStatusApplied, nil)
+	if err = cfg.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(cfg.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Resume {
+		t.Fatalf("unexpected loaded.Resume %v", loaded.Resume)
+	}
+	if st := loaded.GetTesterStatus("jobs-pi"); st != TesterStatusApplied {
+		t.Fatalf("unexpected tester status %q", st)
+	}
 }
 
 func TestEnvAddOnCloudwatchAgent(t *testing.T) {
@@ -81,7 +247,8 @@ func TestEnvAddOnCloudwatchAgent(t *testing.T) {
 	if !cfg.AddOnCloudwatchAgent.Enable {
 		t.Fatalf("unexpected cfg.AddOnCloudwatchAgent.Enable %v", cfg.AddOnCloudwatchAgent.Enable)
 	}
-	if cfg.AddOnCloudwatchAgent.Namespace != "hello" {
+	// suffixed with the run ID for concurrent runs
+	if cfg.AddOnCloudwatchAgent.Namespace != "hello-"+cfg.RunID {
 		t.Fatalf("unexpected cfg.AddOnCloudwatchAgent.Namespace %v", cfg.AddOnCloudwatchAgent.Namespace)
 	}
 	// should be overwritten since it's a read-only field
@@ -90,6 +257,44 @@ func TestEnvAddOnCloudwatchAgent(t *testing.T) {
 	}
 }
 
+func TestEnvAddOnCloudwatchAgentCheckMetrics(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_CHECK_METRICS", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_CHECK_METRICS")
+	os.Setenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_CONTAINER_INSIGHTS_METRICS", "node_cpu_utilization,node_memory_utilization")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_CONTAINER_INSIGHTS_METRICS")
+	os.Setenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_METRICS_TIMEOUT", "15m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLOUDWATCH_AGENT_METRICS_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnCloudwatchAgent.CheckMetrics {
+		t.Fatalf("unexpected cfg.AddOnCloudwatchAgent.CheckMetrics %v", cfg.AddOnCloudwatchAgent.CheckMetrics)
+	}
+	if !reflect.DeepEqual(cfg.AddOnCloudwatchAgent.ContainerInsightsMetrics, []string{"node_cpu_utilization", "node_memory_utilization"}) {
+		t.Fatalf("unexpected cfg.AddOnCloudwatchAgent.ContainerInsightsMetrics %v", cfg.AddOnCloudwatchAgent.ContainerInsightsMetrics)
+	}
+	if cfg.AddOnCloudwatchAgent.MetricsTimeout != 15*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnCloudwatchAgent.MetricsTimeout %v", cfg.AddOnCloudwatchAgent.MetricsTimeout)
+	}
+	if err := cfg.AddOnCloudwatchAgent.ValidateAndSetDefaults(""); err == nil {
+		t.Fatal("expected error with empty ClusterName")
+	}
+	if err := cfg.AddOnCloudwatchAgent.ValidateAndSetDefaults("new-name"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnCloudwatchAgent.MetricsTimeoutString != "15m0s" {
+		t.Fatalf("unexpected cfg.AddOnCloudwatchAgent.MetricsTimeoutString %v", cfg.AddOnCloudwatchAgent.MetricsTimeoutString)
+	}
+}
+
 func TestEnvAddOnFluentBit(t *testing.T) {
 	cfg := NewDefault()
 
@@ -97,6 +302,14 @@ func TestEnvAddOnFluentBit(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_FLUENT_BIT_ENABLE")
 	os.Setenv("K8S_TESTER_ADD_ON_FLUENT_BIT_NAMESPACE", "hello")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_FLUENT_BIT_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_FLUENT_BIT_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FLUENT_BIT_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_FLUENT_BIT_CLOUDWATCH_LOGS", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FLUENT_BIT_CLOUDWATCH_LOGS")
+	os.Setenv("K8S_TESTER_ADD_ON_FLUENT_BIT_MARKER_LINES", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FLUENT_BIT_MARKER_LINES")
+	os.Setenv("K8S_TESTER_ADD_ON_FLUENT_BIT_DELIVERY_TIMEOUT", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FLUENT_BIT_DELIVERY_TIMEOUT")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -108,6 +321,24 @@ func TestEnvAddOnFluentBit(t *testing.T) {
 	if cfg.AddOnFluentBit.Namespace != "hello" {
 		t.Fatalf("unexpected cfg.AddOnFluentBit.Namespace %v", cfg.AddOnFluentBit.Namespace)
 	}
+	if cfg.AddOnFluentBit.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnFluentBit.Region %v", cfg.AddOnFluentBit.Region)
+	}
+	if !cfg.AddOnFluentBit.CloudWatchLogs {
+		t.Fatalf("unexpected cfg.AddOnFluentBit.CloudWatchLogs %v", cfg.AddOnFluentBit.CloudWatchLogs)
+	}
+	if cfg.AddOnFluentBit.MarkerLines != 10 {
+		t.Fatalf("unexpected cfg.AddOnFluentBit.MarkerLines %v", cfg.AddOnFluentBit.MarkerLines)
+	}
+	if cfg.AddOnFluentBit.DeliveryTimeout != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnFluentBit.DeliveryTimeout %v", cfg.AddOnFluentBit.DeliveryTimeout)
+	}
+	if err := cfg.AddOnFluentBit.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnFluentBit.CloudWatchLogGroupName != "/aws-k8s-tester/hello" {
+		t.Fatalf("unexpected cfg.AddOnFluentBit.CloudWatchLogGroupName %v", cfg.AddOnFluentBit.CloudWatchLogGroupName)
+	}
 }
 
 func TestEnvAddOnMetricsServer(t *testing.T) {
@@ -115,6 +346,12 @@ func TestEnvAddOnMetricsServer(t *testing.T) {
 
 	os.Setenv("K8S_TESTER_ADD_ON_METRICS_SERVER_ENABLE", "true")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_METRICS_SERVER_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_METRICS_SERVER_METRICS_MAX_AGE", "3m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_METRICS_SERVER_METRICS_MAX_AGE")
+	os.Setenv("K8S_TESTER_ADD_ON_METRICS_SERVER_API_QPS", "50.5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_METRICS_SERVER_API_QPS")
+	os.Setenv("K8S_TESTER_ADD_ON_METRICS_SERVER_API_LATENCY_P99_THRESHOLD", "0s")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_METRICS_SERVER_API_LATENCY_P99_THRESHOLD")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -123,6 +360,15 @@ func TestEnvAddOnMetricsServer(t *testing.T) {
 	if !cfg.AddOnMetricsServer.Enable {
 		t.Fatalf("unexpected cfg.AddOnMetricsServer.Enable %v", cfg.AddOnMetricsServer.Enable)
 	}
+	if cfg.AddOnMetricsServer.MetricsMaxAge != 3*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnMetricsServer.MetricsMaxAge %v", cfg.AddOnMetricsServer.MetricsMaxAge)
+	}
+	if cfg.AddOnMetricsServer.APIQPS != 50.5 {
+		t.Fatalf("unexpected cfg.AddOnMetricsServer.APIQPS %v", cfg.AddOnMetricsServer.APIQPS)
+	}
+	if cfg.AddOnMetricsServer.APILatencyP99Threshold != 0 {
+		t.Fatalf("unexpected cfg.AddOnMetricsServer.APILatencyP99Threshold %v", cfg.AddOnMetricsServer.APILatencyP99Threshold)
+	}
 }
 
 func TestEnvAddOnCNI(t *testing.T) {
@@ -169,6 +415,10 @@ func TestEnvAddOnConformance(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RUN_IMAGE")
 	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RUN_SYSTEMD_LOGS_IMAGE", "hello")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RUN_SYSTEMD_LOGS_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_PLUGINS", "e2e,hello.yaml")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_PLUGINS")
+	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_FLAKY_RETRIES", "2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_FLAKY_RETRIES")
 	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RESULTS_TAR_GZ_PATH", "hello")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RESULTS_TAR_GZ_PATH")
 	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RESULTS_E2E_LOG_PATH", "hello")
@@ -177,6 +427,12 @@ func TestEnvAddOnConformance(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RESULTS_JUNIT_XML_PATH")
 	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RESULTS_OUTPUT_DIR", "hello")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_SONOBUOY_RESULTS_OUTPUT_DIR")
+	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_BASELINE_JUNIT_XML_PATH", "s3://hello/junit_01.xml")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_BASELINE_JUNIT_XML_PATH")
+	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_BASELINE_S3_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_BASELINE_S3_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_CONFORMANCE_BASELINE_FAIL_ON_NEWLY_FAILING", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFORMANCE_BASELINE_FAIL_ON_NEWLY_FAILING")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -221,6 +477,12 @@ func TestEnvAddOnConformance(t *testing.T) {
 	if cfg.AddOnConformance.SonobuoyRunSystemdLogsImage != "hello" {
 		t.Fatalf("unexpected cfg.AddOnConformance.SonobuoyRunSystemdLogsImage %v", cfg.AddOnConformance.SonobuoyRunSystemdLogsImage)
 	}
+	if !reflect.DeepEqual(cfg.AddOnConformance.SonobuoyPlugins, []string{"e2e", "hello.yaml"}) {
+		t.Fatalf("unexpected cfg.AddOnConformance.SonobuoyPlugins %v", cfg.AddOnConformance.SonobuoyPlugins)
+	}
+	if cfg.AddOnConformance.FlakyRetries != 2 {
+		t.Fatalf("unexpected cfg.AddOnConformance.FlakyRetries %v", cfg.AddOnConformance.FlakyRetries)
+	}
 	if cfg.AddOnConformance.SonobuoyResultsTarGzPath != "hello" {
 		t.Fatalf("unexpected cfg.AddOnConformance.SonobuoyResultsTarGzPath %v", cfg.AddOnConformance.SonobuoyResultsTarGzPath)
 	}
@@ -233,6 +495,15 @@ func TestEnvAddOnConformance(t *testing.T) {
 	if cfg.AddOnConformance.SonobuoyResultsOutputDir != "hello" {
 		t.Fatalf("unexpected cfg.AddOnConformance.SonobuoyResultsOutputDir %v", cfg.AddOnConformance.SonobuoyResultsOutputDir)
 	}
+	if cfg.AddOnConformance.BaselineJUnitXMLPath != "s3://hello/junit_01.xml" {
+		t.Fatalf("unexpected cfg.AddOnConformance.BaselineJUnitXMLPath %v", cfg.AddOnConformance.BaselineJUnitXMLPath)
+	}
+	if cfg.AddOnConformance.BaselineS3Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnConformance.BaselineS3Region %v", cfg.AddOnConformance.BaselineS3Region)
+	}
+	if !cfg.AddOnConformance.BaselineFailOnNewlyFailing {
+		t.Fatalf("unexpected cfg.AddOnConformance.BaselineFailOnNewlyFailing %v", cfg.AddOnConformance.BaselineFailOnNewlyFailing)
+	}
 }
 
 func TestEnvAddOnCSIEFS(t *testing.T) {
@@ -326,6 +597,12 @@ func TestEnvAddOnPHPApache(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_PHP_APACHE_REPOSITORY_IMAGE_TAG")
 	os.Setenv("K8S_TESTER_ADD_ON_PHP_APACHE_DEPLOYMENT_NODE_SELECTOR", `{"a":"b","c":"d"}`)
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_PHP_APACHE_DEPLOYMENT_NODE_SELECTOR")
+	os.Setenv("K8S_TESTER_ADD_ON_PHP_APACHE_HPA_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PHP_APACHE_HPA_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_PHP_APACHE_HPA_TARGET_REPLICAS", "7")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PHP_APACHE_HPA_TARGET_REPLICAS")
+	os.Setenv("K8S_TESTER_ADD_ON_PHP_APACHE_HPA_SCALE_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PHP_APACHE_HPA_SCALE_TIMEOUT")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -358,6 +635,15 @@ func TestEnvAddOnPHPApache(t *testing.T) {
 	if !reflect.DeepEqual(cfg.AddOnPHPApache.DeploymentNodeSelector, map[string]string{"a": "b", "c": "d"}) {
 		t.Fatalf("unexpected cfg.AddOnPHPApache.DeploymentNodeSelector %v", cfg.AddOnPHPApache.DeploymentNodeSelector)
 	}
+	if !cfg.AddOnPHPApache.HPAEnable {
+		t.Fatalf("unexpected cfg.AddOnPHPApache.HPAEnable %v", cfg.AddOnPHPApache.HPAEnable)
+	}
+	if cfg.AddOnPHPApache.HPATargetReplicas != 7 {
+		t.Fatalf("unexpected cfg.AddOnPHPApache.HPATargetReplicas %v", cfg.AddOnPHPApache.HPATargetReplicas)
+	}
+	if cfg.AddOnPHPApache.HPAScaleTimeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnPHPApache.HPAScaleTimeout %v", cfg.AddOnPHPApache.HPAScaleTimeout)
+	}
 }
 
 func TestEnvAddOnNLBGuestbook(t *testing.T) {
@@ -373,6 +659,10 @@ func TestEnvAddOnNLBGuestbook(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_GUESTBOOK_NAMESPACE")
 	os.Setenv("K8S_TESTER_ADD_ON_NLB_GUESTBOOK_DEPLOYMENT_NODE_SELECTOR", `{"a":"b","c":"d"}`)
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_GUESTBOOK_DEPLOYMENT_NODE_SELECTOR")
+	os.Setenv("K8S_TESTER_ADD_ON_NLB_GUESTBOOK_PERSISTENCE_ENTRIES", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_GUESTBOOK_PERSISTENCE_ENTRIES")
+	os.Setenv("K8S_TESTER_ADD_ON_NLB_GUESTBOOK_REDIS_STORAGE_CLASS_NAME", "gp3")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_GUESTBOOK_REDIS_STORAGE_CLASS_NAME")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -393,6 +683,15 @@ func TestEnvAddOnNLBGuestbook(t *testing.T) {
 	if !reflect.DeepEqual(cfg.AddOnNLBGuestbook.DeploymentNodeSelector, map[string]string{"a": "b", "c": "d"}) {
 		t.Fatalf("unexpected cfg.AddOnNLBGuestbook.DeploymentNodeSelector %v", cfg.AddOnNLBGuestbook.DeploymentNodeSelector)
 	}
+	if !cfg.AddOnNLBGuestbook.PersistenceCheck {
+		t.Fatalf("unexpected cfg.AddOnNLBGuestbook.PersistenceCheck %v", cfg.AddOnNLBGuestbook.PersistenceCheck)
+	}
+	if cfg.AddOnNLBGuestbook.PersistenceEntries != 10 {
+		t.Fatalf("unexpected cfg.AddOnNLBGuestbook.PersistenceEntries %v", cfg.AddOnNLBGuestbook.PersistenceEntries)
+	}
+	if cfg.AddOnNLBGuestbook.RedisStorageClassName != "gp3" {
+		t.Fatalf("unexpected cfg.AddOnNLBGuestbook.RedisStorageClassName %v", cfg.AddOnNLBGuestbook.RedisStorageClassName)
+	}
 }
 
 func TestEnvAddOnNLBHelloWorld(t *testing.T) {
@@ -408,6 +707,12 @@ func TestEnvAddOnNLBHelloWorld(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_NAMESPACE")
 	os.Setenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_DEPLOYMENT_NODE_SELECTOR", `{"a":"b","c":"d"}`)
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_DEPLOYMENT_NODE_SELECTOR")
+	os.Setenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_TLS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_TLS_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_TLS_DOMAIN_NAME", "hello.example.com")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_TLS_DOMAIN_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_ROUTE53_HOSTED_ZONE_ID", "Z123")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NLB_HELLO_WORLD_ROUTE53_HOSTED_ZONE_ID")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -428,6 +733,15 @@ func TestEnvAddOnNLBHelloWorld(t *testing.T) {
 	if !reflect.DeepEqual(cfg.AddOnNLBHelloWorld.DeploymentNodeSelector, map[string]string{"a": "b", "c": "d"}) {
 		t.Fatalf("unexpected cfg.AddOnNLBHelloWorld.DeploymentNodeSelector %v", cfg.AddOnNLBHelloWorld.DeploymentNodeSelector)
 	}
+	if !cfg.AddOnNLBHelloWorld.TLSEnable {
+		t.Fatalf("unexpected cfg.AddOnNLBHelloWorld.TLSEnable %v", cfg.AddOnNLBHelloWorld.TLSEnable)
+	}
+	if cfg.AddOnNLBHelloWorld.TLSDomainName != "hello.example.com" {
+		t.Fatalf("unexpected cfg.AddOnNLBHelloWorld.TLSDomainName %v", cfg.AddOnNLBHelloWorld.TLSDomainName)
+	}
+	if cfg.AddOnNLBHelloWorld.Route53HostedZoneID != "Z123" {
+		t.Fatalf("unexpected cfg.AddOnNLBHelloWorld.Route53HostedZoneID %v", cfg.AddOnNLBHelloWorld.Route53HostedZoneID)
+	}
 }
 
 func TestEnvAddOnWordpress(t *testing.T) {
@@ -653,6 +967,14 @@ func TestEnvAddOnCSRs(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSRS_OBJECTS")
 	os.Setenv("K8S_TESTER_ADD_ON_CSRS_INITIAL_REQUEST_CONDITION_TYPE", "Approved")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSRS_INITIAL_REQUEST_CONDITION_TYPE")
+	os.Setenv("K8S_TESTER_ADD_ON_CSRS_SIGNER_NAME", "kubernetes.io/kubelet-serving")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSRS_SIGNER_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_CSRS_AUTO_APPROVE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSRS_AUTO_APPROVE")
+	os.Setenv("K8S_TESTER_ADD_ON_CSRS_VERIFY_ISSUED", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSRS_VERIFY_ISSUED")
+	os.Setenv("K8S_TESTER_ADD_ON_CSRS_ISSUE_TIMEOUT", "2m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSRS_ISSUE_TIMEOUT")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -673,6 +995,18 @@ func TestEnvAddOnCSRs(t *testing.T) {
 	if cfg.AddOnCSRs.InitialRequestConditionType != "Approved" {
 		t.Fatalf("unexpected cfg.AddOnCSRs.InitialRequestConditionType %v", cfg.AddOnCSRs.InitialRequestConditionType)
 	}
+	if cfg.AddOnCSRs.SignerName != "kubernetes.io/kubelet-serving" {
+		t.Fatalf("unexpected cfg.AddOnCSRs.SignerName %v", cfg.AddOnCSRs.SignerName)
+	}
+	if !cfg.AddOnCSRs.AutoApprove {
+		t.Fatalf("unexpected cfg.AddOnCSRs.AutoApprove %v", cfg.AddOnCSRs.AutoApprove)
+	}
+	if !cfg.AddOnCSRs.VerifyIssued {
+		t.Fatalf("unexpected cfg.AddOnCSRs.VerifyIssued %v", cfg.AddOnCSRs.VerifyIssued)
+	}
+	if cfg.AddOnCSRs.IssueTimeout != 2*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnCSRs.IssueTimeout %v", cfg.AddOnCSRs.IssueTimeout)
+	}
 }
 
 func TestEnvAddOnConfigmaps(t *testing.T) {
@@ -690,6 +1024,10 @@ func TestEnvAddOnConfigmaps(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFIGMAPS_OBJECTS")
 	os.Setenv("K8S_TESTER_ADD_ON_CONFIGMAPS_OBJECT_SIZE", `333`)
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFIGMAPS_OBJECT_SIZE")
+	os.Setenv("K8S_TESTER_ADD_ON_CONFIGMAPS_WATCHERS", `3`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFIGMAPS_WATCHERS")
+	os.Setenv("K8S_TESTER_ADD_ON_CONFIGMAPS_PAYLOAD_KIND", "mixed")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CONFIGMAPS_PAYLOAD_KIND")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -713,6 +1051,12 @@ func TestEnvAddOnConfigmaps(t *testing.T) {
 	if cfg.AddOnConfigmaps.ObjectSize != 333 {
 		t.Fatalf("unexpected cfg.AddOnConfigmaps.ObjectSize %v", cfg.AddOnConfigmaps.ObjectSize)
 	}
+	if cfg.AddOnConfigmaps.Watchers != 3 {
+		t.Fatalf("unexpected cfg.AddOnConfigmaps.Watchers %v", cfg.AddOnConfigmaps.Watchers)
+	}
+	if cfg.AddOnConfigmaps.PayloadKind != "mixed" {
+		t.Fatalf("unexpected cfg.AddOnConfigmaps.PayloadKind %v", cfg.AddOnConfigmaps.PayloadKind)
+	}
 }
 
 func TestEnvAddOnSecrets(t *testing.T) {
@@ -730,6 +1074,10 @@ func TestEnvAddOnSecrets(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_SECRETS_OBJECTS")
 	os.Setenv("K8S_TESTER_ADD_ON_SECRETS_OBJECT_SIZE", `333`)
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_SECRETS_OBJECT_SIZE")
+	os.Setenv("K8S_TESTER_ADD_ON_SECRETS_WATCHERS", `3`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_SECRETS_WATCHERS")
+	os.Setenv("K8S_TESTER_ADD_ON_SECRETS_PAYLOAD_KIND", "mixed")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_SECRETS_PAYLOAD_KIND")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -753,6 +1101,12 @@ func TestEnvAddOnSecrets(t *testing.T) {
 	if cfg.AddOnSecrets.ObjectSize != 333 {
 		t.Fatalf("unexpected cfg.AddOnSecrets.ObjectSize %v", cfg.AddOnSecrets.ObjectSize)
 	}
+	if cfg.AddOnSecrets.Watchers != 3 {
+		t.Fatalf("unexpected cfg.AddOnSecrets.Watchers %v", cfg.AddOnSecrets.Watchers)
+	}
+	if cfg.AddOnSecrets.PayloadKind != "mixed" {
+		t.Fatalf("unexpected cfg.AddOnSecrets.PayloadKind %v", cfg.AddOnSecrets.PayloadKind)
+	}
 }
 
 func TestEnvAddOnClusterloader(t *testing.T) {
@@ -770,12 +1124,24 @@ func TestEnvAddOnClusterloader(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_RUN_TIMEOUT")
 	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_CONFIG_PATH", "hello")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_CONFIG_PATH")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_CONFIG_PATHS", "a.yaml,b.yaml")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_CONFIG_PATHS")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_COOLDOWN", "5m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_COOLDOWN")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_HEALTH_CHECK_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_HEALTH_CHECK_TIMEOUT")
 	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_RUN_FROM_CLUSTER", "true")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_RUN_FROM_CLUSTER")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_RUN_FROM_CLUSTER_IMAGE", "hello-image")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_RUN_FROM_CLUSTER_IMAGE")
 	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_NODES", "100")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_NODES")
 	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_ENABLE_EXEC_SERVICE", "true")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_ENABLE_EXEC_SERVICE")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_SLO_POD_STARTUP_LATENCY", "10s")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_SLO_POD_STARTUP_LATENCY")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_SLO_FAIL_ON_VIOLATION", "false")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_SLO_FAIL_ON_VIOLATION")
 	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_NODES_PER_NAMESPACE", "100")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_NODES_PER_NAMESPACE")
 	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_PODS_PER_NODE", "100")
@@ -788,6 +1154,10 @@ func TestEnvAddOnClusterloader(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_CL2_SCHEDULER_THROUGHPUT_THRESHOLD")
 	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_ENABLE_SYSTEM_POD_METRICS", "true")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_ENABLE_SYSTEM_POD_METRICS")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_VALUES", `{"CL2_SCHEDULER_THROUGHPUT_PODS":"5000"}`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_VALUES")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_ONLY_VALUES", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTERLOADER_TEST_OVERRIDE_ONLY_VALUES")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -811,15 +1181,33 @@ func TestEnvAddOnClusterloader(t *testing.T) {
 	if cfg.AddOnClusterloader.TestConfigPath != "hello" {
 		t.Fatalf("unexpected cfg.AddOnClusterloader.TestConfigPath %v", cfg.AddOnClusterloader.TestConfigPath)
 	}
+	if !reflect.DeepEqual(cfg.AddOnClusterloader.TestConfigPaths, []string{"a.yaml", "b.yaml"}) {
+		t.Fatalf("unexpected cfg.AddOnClusterloader.TestConfigPaths %v", cfg.AddOnClusterloader.TestConfigPaths)
+	}
+	if cfg.AddOnClusterloader.Cooldown != 5*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnClusterloader.Cooldown %v", cfg.AddOnClusterloader.Cooldown)
+	}
+	if cfg.AddOnClusterloader.HealthCheckTimeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnClusterloader.HealthCheckTimeout %v", cfg.AddOnClusterloader.HealthCheckTimeout)
+	}
 	if !cfg.AddOnClusterloader.RunFromCluster {
 		t.Fatalf("unexpected cfg.AddOnClusterloader.RunFromCluster %v", cfg.AddOnClusterloader.RunFromCluster)
 	}
+	if cfg.AddOnClusterloader.RunFromClusterImage != "hello-image" {
+		t.Fatalf("unexpected cfg.AddOnClusterloader.RunFromClusterImage %v", cfg.AddOnClusterloader.RunFromClusterImage)
+	}
 	if cfg.AddOnClusterloader.Nodes != 100 {
 		t.Fatalf("unexpected cfg.AddOnClusterloader.Nodes %v", cfg.AddOnClusterloader.Nodes)
 	}
 	if !cfg.AddOnClusterloader.EnableExecService {
 		t.Fatalf("unexpected cfg.AddOnClusterloader.EnableExecService %v", cfg.AddOnClusterloader.EnableExecService)
 	}
+	if cfg.AddOnClusterloader.SLOPodStartupLatency != 10*time.Second {
+		t.Fatalf("unexpected cfg.AddOnClusterloader.SLOPodStartupLatency %v", cfg.AddOnClusterloader.SLOPodStartupLatency)
+	}
+	if cfg.AddOnClusterloader.SLOFailOnViolation {
+		t.Fatalf("unexpected cfg.AddOnClusterloader.SLOFailOnViolation %v", cfg.AddOnClusterloader.SLOFailOnViolation)
+	}
 	if cfg.AddOnClusterloader.TestOverride.NodesPerNamespace != 100 {
 		t.Fatalf("unexpected cfg.AddOnClusterloader.TestOverride.NodesPerNamespace %v", cfg.AddOnClusterloader.TestOverride.NodesPerNamespace)
 	}
@@ -838,6 +1226,12 @@ func TestEnvAddOnClusterloader(t *testing.T) {
 	if !cfg.AddOnClusterloader.TestOverride.EnableSystemPodMetrics {
 		t.Fatalf("unexpected cfg.AddOnClusterloader.TestOverride.EnableSystemPodMetrics %v", cfg.AddOnClusterloader.TestOverride.EnableSystemPodMetrics)
 	}
+	if !reflect.DeepEqual(cfg.AddOnClusterloader.TestOverride.Values, map[string]string{"CL2_SCHEDULER_THROUGHPUT_PODS": "5000"}) {
+		t.Fatalf("unexpected cfg.AddOnClusterloader.TestOverride.Values %v", cfg.AddOnClusterloader.TestOverride.Values)
+	}
+	if !cfg.AddOnClusterloader.TestOverride.OnlyValues {
+		t.Fatalf("unexpected cfg.AddOnClusterloader.TestOverride.OnlyValues %v", cfg.AddOnClusterloader.TestOverride.OnlyValues)
+	}
 }
 
 func TestEnvAddOnStress(t *testing.T) {
@@ -865,6 +1259,10 @@ func TestEnvAddOnStress(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_UPDATE_CONCURRENCY")
 	os.Setenv("K8S_TESTER_ADD_ON_STRESS_LIST_BATCH_LIMIT", "3000")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_LIST_BATCH_LIMIT")
+	os.Setenv("K8S_TESTER_ADD_ON_STRESS_OBJECT_KINDS", "configmaps,leases,crds")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_OBJECT_KINDS")
+	os.Setenv("K8S_TESTER_ADD_ON_STRESS_PROMETHEUS_PUSHGATEWAY_URL", "http://pushgateway:9091")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_PROMETHEUS_PUSHGATEWAY_URL")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -903,6 +1301,15 @@ func TestEnvAddOnStress(t *testing.T) {
 	if cfg.AddOnStress.ListBatchLimit != 3000 {
 		t.Fatalf("unexpected cfg.AddOnStress.ListBatchLimit %v", cfg.AddOnStress.ListBatchLimit)
 	}
+	if !reflect.DeepEqual(cfg.AddOnStress.ObjectKinds, []string{"configmaps", "leases", "crds"}) {
+		t.Fatalf("unexpected cfg.AddOnStress.ObjectKinds %v", cfg.AddOnStress.ObjectKinds)
+	}
+	if cfg.AddOnStress.PrometheusPushgatewayURL != "http://pushgateway:9091" {
+		t.Fatalf("unexpected cfg.AddOnStress.PrometheusPushgatewayURL %v", cfg.AddOnStress.PrometheusPushgatewayURL)
+	}
+	if cfg.AddOnStress.PrometheusPushJobName != "k8s-tester-stress" {
+		t.Fatalf("unexpected cfg.AddOnStress.PrometheusPushJobName %v", cfg.AddOnStress.PrometheusPushJobName)
+	}
 }
 
 func TestEnvAddOnStressInCluster(t *testing.T) {
@@ -917,6 +1324,8 @@ func TestEnvAddOnStressInCluster(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_COMPLETES")
 	os.Setenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_PARALLELS", `333`)
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_PARALLELS")
+	os.Setenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_IMAGE_VARIANT", "distroless")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_IMAGE_VARIANT")
 
 	os.Setenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_REPOSITORY_PARTITION", "aws")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_REPOSITORY_PARTITION")
@@ -947,6 +1356,10 @@ func TestEnvAddOnStressInCluster(t *testing.T) {
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_CLI_UPDATE_CONCURRENCY")
 	os.Setenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_CLI_LIST_BATCH_LIMIT", "3000")
 	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_CLI_LIST_BATCH_LIMIT")
+	os.Setenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_CLI_PROMETHEUS_PUSHGATEWAY_URL", "http://pushgateway:9091")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_CLI_PROMETHEUS_PUSHGATEWAY_URL")
+	os.Setenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_CLI_PROMETHEUS_PUSH_JOB_NAME", "hello-job")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STRESS_IN_CLUSTER_K8S_TESTER_STRESS_CLI_PROMETHEUS_PUSH_JOB_NAME")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -1008,4 +1421,2133 @@ func TestEnvAddOnStressInCluster(t *testing.T) {
 	if cfg.AddOnStressInCluster.K8sTesterStressCLI.ListBatchLimit != 3000 {
 		t.Fatalf("unexpected cfg.AddOnStressInCluster.ListBatchLimit %v", cfg.AddOnStressInCluster.K8sTesterStressCLI.ListBatchLimit)
 	}
+	if cfg.AddOnStressInCluster.K8sTesterStressCLI.PrometheusPushgatewayURL != "http://pushgateway:9091" {
+		t.Fatalf("unexpected cfg.AddOnStressInCluster.K8sTesterStressCLI.PrometheusPushgatewayURL %v", cfg.AddOnStressInCluster.K8sTesterStressCLI.PrometheusPushgatewayURL)
+	}
+	if cfg.AddOnStressInCluster.K8sTesterStressCLI.PrometheusPushJobName != "hello-job" {
+		t.Fatalf("unexpected cfg.AddOnStressInCluster.K8sTesterStressCLI.PrometheusPushJobName %v", cfg.AddOnStressInCluster.K8sTesterStressCLI.PrometheusPushJobName)
+	}
+	if cfg.AddOnStressInCluster.ImageVariant != "distroless" {
+		t.Fatalf("unexpected cfg.AddOnStressInCluster.ImageVariant %v", cfg.AddOnStressInCluster.ImageVariant)
+	}
+}
+
+func TestEnvAddOnHPACloudWatch(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_METRIC_NAME", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_METRIC_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_METRIC_VALUE", "200")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_METRIC_VALUE")
+	os.Setenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_MAX_REPLICAS", "20")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HPA_CLOUDWATCH_MAX_REPLICAS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnHPACloudWatch.Enable {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.Enable %v", cfg.AddOnHPACloudWatch.Enable)
+	}
+	if cfg.AddOnHPACloudWatch.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.Namespace %v", cfg.AddOnHPACloudWatch.Namespace)
+	}
+	if cfg.AddOnHPACloudWatch.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.Region %v", cfg.AddOnHPACloudWatch.Region)
+	}
+	if cfg.AddOnHPACloudWatch.MetricName != "hello" {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.MetricName %v", cfg.AddOnHPACloudWatch.MetricName)
+	}
+	if cfg.AddOnHPACloudWatch.MetricValue != 200 {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.MetricValue %v", cfg.AddOnHPACloudWatch.MetricValue)
+	}
+	if cfg.AddOnHPACloudWatch.MaxReplicas != 20 {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.MaxReplicas %v", cfg.AddOnHPACloudWatch.MaxReplicas)
+	}
+
+	if err := cfg.AddOnHPACloudWatch.ValidateAndSetDefaults("test-cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnHPACloudWatch.ClusterName != "test-cluster" {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.ClusterName %v", cfg.AddOnHPACloudWatch.ClusterName)
+	}
+	// 200 / 25 (default target metric value)
+	if cfg.AddOnHPACloudWatch.ExpectedReplicas != 8 {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.ExpectedReplicas %v", cfg.AddOnHPACloudWatch.ExpectedReplicas)
+	}
+	if cfg.AddOnHPACloudWatch.ScaleTimeoutString != "15m0s" {
+		t.Fatalf("unexpected cfg.AddOnHPACloudWatch.ScaleTimeoutString %v", cfg.AddOnHPACloudWatch.ScaleTimeoutString)
+	}
+	cfg.AddOnHPACloudWatch.MinReplicas = 30
+	if err := cfg.AddOnHPACloudWatch.ValidateAndSetDefaults("test-cluster"); err == nil {
+		t.Fatal("expected MinReplicas > MaxReplicas error")
+	}
+}
+
+func TestEnvAddOnKEDASQS(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_KEDA_SQS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KEDA_SQS_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_KEDA_SQS_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KEDA_SQS_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_KEDA_SQS_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KEDA_SQS_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_KEDA_SQS_QUEUE_NAME", "hello-queue")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KEDA_SQS_QUEUE_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_KEDA_SQS_MESSAGES", "100")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KEDA_SQS_MESSAGES")
+	os.Setenv("K8S_TESTER_ADD_ON_KEDA_SQS_QUEUE_LENGTH", "20")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KEDA_SQS_QUEUE_LENGTH")
+	os.Setenv("K8S_TESTER_ADD_ON_KEDA_SQS_SCALE_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KEDA_SQS_SCALE_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnKEDASQS.Enable {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.Enable %v", cfg.AddOnKEDASQS.Enable)
+	}
+	if cfg.AddOnKEDASQS.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.Namespace %v", cfg.AddOnKEDASQS.Namespace)
+	}
+	if cfg.AddOnKEDASQS.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.Region %v", cfg.AddOnKEDASQS.Region)
+	}
+	if cfg.AddOnKEDASQS.QueueName != "hello-queue" {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.QueueName %v", cfg.AddOnKEDASQS.QueueName)
+	}
+	if cfg.AddOnKEDASQS.Messages != 100 {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.Messages %v", cfg.AddOnKEDASQS.Messages)
+	}
+	if cfg.AddOnKEDASQS.QueueLength != 20 {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.QueueLength %v", cfg.AddOnKEDASQS.QueueLength)
+	}
+	if cfg.AddOnKEDASQS.ScaleTimeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.ScaleTimeout %v", cfg.AddOnKEDASQS.ScaleTimeout)
+	}
+
+	if err := cfg.AddOnKEDASQS.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	// 100 / 20
+	if cfg.AddOnKEDASQS.ExpectedReplicas != 5 {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.ExpectedReplicas %v", cfg.AddOnKEDASQS.ExpectedReplicas)
+	}
+	if cfg.AddOnKEDASQS.ScaleTimeoutString != "20m0s" {
+		t.Fatalf("unexpected cfg.AddOnKEDASQS.ScaleTimeoutString %v", cfg.AddOnKEDASQS.ScaleTimeoutString)
+	}
+	cfg.AddOnKEDASQS.Region = ""
+	if err := cfg.AddOnKEDASQS.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected empty Region error")
+	}
+}
+
+func TestEnvAddOnCSIS3(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_CSI_S3_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSI_S3_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_CSI_S3_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSI_S3_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_CSI_S3_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSI_S3_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_CSI_S3_ROLE_ARN", "arn:aws:iam::123:role/hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSI_S3_ROLE_ARN")
+	os.Setenv("K8S_TESTER_ADD_ON_CSI_S3_S3_BUCKET_CREATE", "false")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSI_S3_S3_BUCKET_CREATE")
+	os.Setenv("K8S_TESTER_ADD_ON_CSI_S3_S3_BUCKET_NAME", "hello-bucket")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSI_S3_S3_BUCKET_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_CSI_S3_FILE_SIZE_MIB", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSI_S3_FILE_SIZE_MIB")
+	os.Setenv("K8S_TESTER_ADD_ON_CSI_S3_MIN_READ_THROUGHPUT_MBPS", "50.5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CSI_S3_MIN_READ_THROUGHPUT_MBPS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnCSIS3.Enable {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.Enable %v", cfg.AddOnCSIS3.Enable)
+	}
+	if cfg.AddOnCSIS3.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.Namespace %v", cfg.AddOnCSIS3.Namespace)
+	}
+	if cfg.AddOnCSIS3.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.Region %v", cfg.AddOnCSIS3.Region)
+	}
+	if cfg.AddOnCSIS3.RoleARN != "arn:aws:iam::123:role/hello" {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.RoleARN %v", cfg.AddOnCSIS3.RoleARN)
+	}
+	if cfg.AddOnCSIS3.S3BucketCreate {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.S3BucketCreate %v", cfg.AddOnCSIS3.S3BucketCreate)
+	}
+	if cfg.AddOnCSIS3.S3BucketName != "hello-bucket" {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.S3BucketName %v", cfg.AddOnCSIS3.S3BucketName)
+	}
+	if cfg.AddOnCSIS3.FileSizeMiB != 10 {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.FileSizeMiB %v", cfg.AddOnCSIS3.FileSizeMiB)
+	}
+	if cfg.AddOnCSIS3.MinReadThroughputMBps != 50.5 {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.MinReadThroughputMBps %v", cfg.AddOnCSIS3.MinReadThroughputMBps)
+	}
+
+	if err := cfg.AddOnCSIS3.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnCSIS3.S3BucketName != "hello-bucket" {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.S3BucketName %v", cfg.AddOnCSIS3.S3BucketName)
+	}
+	if cfg.AddOnCSIS3.Files != 5 {
+		t.Fatalf("unexpected cfg.AddOnCSIS3.Files %v", cfg.AddOnCSIS3.Files)
+	}
+	cfg.AddOnCSIS3.S3BucketName = ""
+	if err := cfg.AddOnCSIS3.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected empty S3BucketName error")
+	}
+}
+
+func TestEnvAddOnEMROnEKS(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_EXECUTION_ROLE_ARN", "arn:aws:iam::123:role/hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_EXECUTION_ROLE_ARN")
+	os.Setenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_RELEASE_LABEL", "emr-7.0.0-latest")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_RELEASE_LABEL")
+	os.Setenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_EXECUTOR_INSTANCES", "5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_EXECUTOR_INSTANCES")
+	os.Setenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_JOB_RUN_TIMEOUT", "1h")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EMR_ON_EKS_JOB_RUN_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnEMROnEKS.Enable {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.Enable %v", cfg.AddOnEMROnEKS.Enable)
+	}
+	if cfg.AddOnEMROnEKS.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.Namespace %v", cfg.AddOnEMROnEKS.Namespace)
+	}
+	if cfg.AddOnEMROnEKS.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.Region %v", cfg.AddOnEMROnEKS.Region)
+	}
+	if cfg.AddOnEMROnEKS.ExecutionRoleARN != "arn:aws:iam::123:role/hello" {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.ExecutionRoleARN %v", cfg.AddOnEMROnEKS.ExecutionRoleARN)
+	}
+	if cfg.AddOnEMROnEKS.ReleaseLabel != "emr-7.0.0-latest" {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.ReleaseLabel %v", cfg.AddOnEMROnEKS.ReleaseLabel)
+	}
+	if cfg.AddOnEMROnEKS.ExecutorInstances != 5 {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.ExecutorInstances %v", cfg.AddOnEMROnEKS.ExecutorInstances)
+	}
+	if cfg.AddOnEMROnEKS.JobRunTimeout != time.Hour {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.JobRunTimeout %v", cfg.AddOnEMROnEKS.JobRunTimeout)
+	}
+
+	if err := cfg.AddOnEMROnEKS.ValidateAndSetDefaults("test-cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnEMROnEKS.ClusterName != "test-cluster" {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.ClusterName %v", cfg.AddOnEMROnEKS.ClusterName)
+	}
+	if cfg.AddOnEMROnEKS.VirtualClusterName != "hello" {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.VirtualClusterName %v", cfg.AddOnEMROnEKS.VirtualClusterName)
+	}
+	if cfg.AddOnEMROnEKS.JobRunTimeoutString != "1h0m0s" {
+		t.Fatalf("unexpected cfg.AddOnEMROnEKS.JobRunTimeoutString %v", cfg.AddOnEMROnEKS.JobRunTimeoutString)
+	}
+	cfg.AddOnEMROnEKS.ExecutionRoleARN = ""
+	if err := cfg.AddOnEMROnEKS.ValidateAndSetDefaults("test-cluster"); err == nil {
+		t.Fatal("expected empty ExecutionRoleARN error")
+	}
+}
+
+func TestEnvAddOnKarpenter(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_KARPENTER_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KARPENTER_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_KARPENTER_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KARPENTER_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_KARPENTER_NODE_ROLE_NAME", "hello-node-role")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KARPENTER_NODE_ROLE_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_KARPENTER_HELM_CHART_VERSION", "1.1.0")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KARPENTER_HELM_CHART_VERSION")
+	os.Setenv("K8S_TESTER_ADD_ON_KARPENTER_INSTANCE_TYPES", "m5.large,c5.large")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KARPENTER_INSTANCE_TYPES")
+	os.Setenv("K8S_TESTER_ADD_ON_KARPENTER_CAPACITY_TYPE", "spot")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KARPENTER_CAPACITY_TYPE")
+	os.Setenv("K8S_TESTER_ADD_ON_KARPENTER_REPLICAS", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KARPENTER_REPLICAS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnKarpenter.Enable {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.Enable %v", cfg.AddOnKarpenter.Enable)
+	}
+	if cfg.AddOnKarpenter.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.Namespace %v", cfg.AddOnKarpenter.Namespace)
+	}
+	if cfg.AddOnKarpenter.NodeRoleName != "hello-node-role" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.NodeRoleName %v", cfg.AddOnKarpenter.NodeRoleName)
+	}
+	if cfg.AddOnKarpenter.HelmChartVersion != "1.1.0" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.HelmChartVersion %v", cfg.AddOnKarpenter.HelmChartVersion)
+	}
+	if !reflect.DeepEqual(cfg.AddOnKarpenter.InstanceTypes, []string{"m5.large", "c5.large"}) {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.InstanceTypes %v", cfg.AddOnKarpenter.InstanceTypes)
+	}
+	if cfg.AddOnKarpenter.CapacityType != "spot" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.CapacityType %v", cfg.AddOnKarpenter.CapacityType)
+	}
+	if cfg.AddOnKarpenter.Replicas != 10 {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.Replicas %v", cfg.AddOnKarpenter.Replicas)
+	}
+
+	cfg.AddOnKarpenter.Region = "us-west-2"
+	if err := cfg.AddOnKarpenter.ValidateAndSetDefaults("test-cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnKarpenter.ClusterName != "test-cluster" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.ClusterName %v", cfg.AddOnKarpenter.ClusterName)
+	}
+	if cfg.AddOnKarpenter.DiscoveryTagValue != "test-cluster" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.DiscoveryTagValue %v", cfg.AddOnKarpenter.DiscoveryTagValue)
+	}
+	if cfg.AddOnKarpenter.NodePoolName != "hello" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.NodePoolName %v", cfg.AddOnKarpenter.NodePoolName)
+	}
+	if err := cfg.AddOnKarpenter.ValidateAndSetDefaults(""); err == nil {
+		t.Fatal("expected empty ClusterName error")
+	}
+	cfg.AddOnKarpenter.CapacityType = "hello"
+	if err := cfg.AddOnKarpenter.ValidateAndSetDefaults("test-cluster"); err == nil {
+		t.Fatal("expected unknown CapacityType error")
+	}
+}
+
+func TestEnvAddOnIstioAmbient(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_ISTIO_VERSION", "1.24.0")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_ISTIO_VERSION")
+	os.Setenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_REQUESTS", "500")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_REQUESTS")
+	os.Setenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_MAX_LATENCY_OVERHEAD_MS", "2.5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ISTIO_AMBIENT_MAX_LATENCY_OVERHEAD_MS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnIstioAmbient.Enable {
+		t.Fatalf("unexpected cfg.AddOnIstioAmbient.Enable %v", cfg.AddOnIstioAmbient.Enable)
+	}
+	if cfg.AddOnIstioAmbient.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnIstioAmbient.Namespace %v", cfg.AddOnIstioAmbient.Namespace)
+	}
+	if cfg.AddOnIstioAmbient.IstioVersion != "1.24.0" {
+		t.Fatalf("unexpected cfg.AddOnIstioAmbient.IstioVersion %v", cfg.AddOnIstioAmbient.IstioVersion)
+	}
+	if cfg.AddOnIstioAmbient.Requests != 500 {
+		t.Fatalf("unexpected cfg.AddOnIstioAmbient.Requests %v", cfg.AddOnIstioAmbient.Requests)
+	}
+	if cfg.AddOnIstioAmbient.MaxLatencyOverheadMs != 2.5 {
+		t.Fatalf("unexpected cfg.AddOnIstioAmbient.MaxLatencyOverheadMs %v", cfg.AddOnIstioAmbient.MaxLatencyOverheadMs)
+	}
+
+	if err := cfg.AddOnIstioAmbient.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.AddOnIstioAmbient.MaxLatencyOverheadMs = -1
+	if err := cfg.AddOnIstioAmbient.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected invalid MaxLatencyOverheadMs error")
+	}
+}
+
+func TestEnvAddOnArgoWorkflows(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_WORKFLOWS", "100")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_WORKFLOWS")
+	os.Setenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_DAG_WIDTH", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_DAG_WIDTH")
+	os.Setenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_STEP_DURATION", "5s")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ARGO_WORKFLOWS_STEP_DURATION")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnArgoWorkflows.Enable {
+		t.Fatalf("unexpected cfg.AddOnArgoWorkflows.Enable %v", cfg.AddOnArgoWorkflows.Enable)
+	}
+	if cfg.AddOnArgoWorkflows.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnArgoWorkflows.Namespace %v", cfg.AddOnArgoWorkflows.Namespace)
+	}
+	if cfg.AddOnArgoWorkflows.Workflows != 100 {
+		t.Fatalf("unexpected cfg.AddOnArgoWorkflows.Workflows %v", cfg.AddOnArgoWorkflows.Workflows)
+	}
+	if cfg.AddOnArgoWorkflows.DAGWidth != 10 {
+		t.Fatalf("unexpected cfg.AddOnArgoWorkflows.DAGWidth %v", cfg.AddOnArgoWorkflows.DAGWidth)
+	}
+	if cfg.AddOnArgoWorkflows.StepDuration != 5*time.Second {
+		t.Fatalf("unexpected cfg.AddOnArgoWorkflows.StepDuration %v", cfg.AddOnArgoWorkflows.StepDuration)
+	}
+
+	if err := cfg.AddOnArgoWorkflows.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnArgoWorkflows.StepDurationString != "5s" {
+		t.Fatalf("unexpected cfg.AddOnArgoWorkflows.StepDurationString %v", cfg.AddOnArgoWorkflows.StepDurationString)
+	}
+	if cfg.AddOnArgoWorkflows.DAGDepth != 3 {
+		t.Fatalf("unexpected cfg.AddOnArgoWorkflows.DAGDepth %v", cfg.AddOnArgoWorkflows.DAGDepth)
+	}
+	cfg.AddOnArgoWorkflows.Namespace = ""
+	if err := cfg.AddOnArgoWorkflows.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected empty Namespace error")
+	}
+}
+
+func TestEnvAddOnImagePrepull(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_IMAGE_PREPULL_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IMAGE_PREPULL_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_IMAGE_PREPULL_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IMAGE_PREPULL_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_IMAGE_PREPULL_IMAGES", "a,b")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IMAGE_PREPULL_IMAGES")
+	os.Setenv("K8S_TESTER_ADD_ON_IMAGE_PREPULL_PREPULL_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IMAGE_PREPULL_PREPULL_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnImagePrepull.Enable {
+		t.Fatalf("unexpected cfg.AddOnImagePrepull.Enable %v", cfg.AddOnImagePrepull.Enable)
+	}
+	if cfg.AddOnImagePrepull.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnImagePrepull.Namespace %v", cfg.AddOnImagePrepull.Namespace)
+	}
+	if !reflect.DeepEqual(cfg.AddOnImagePrepull.Images, []string{"a", "b"}) {
+		t.Fatalf("unexpected cfg.AddOnImagePrepull.Images %v", cfg.AddOnImagePrepull.Images)
+	}
+	if cfg.AddOnImagePrepull.PrepullTimeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnImagePrepull.PrepullTimeout %v", cfg.AddOnImagePrepull.PrepullTimeout)
+	}
+
+	if err := cfg.AddOnImagePrepull.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.AddOnImagePrepull.Images, []string{"a", "b"}) {
+		t.Fatalf("unexpected cfg.AddOnImagePrepull.Images %v", cfg.AddOnImagePrepull.Images)
+	}
+	if cfg.AddOnImagePrepull.PrepullTimeoutString != "20m0s" {
+		t.Fatalf("unexpected cfg.AddOnImagePrepull.PrepullTimeoutString %v", cfg.AddOnImagePrepull.PrepullTimeoutString)
+	}
+	cfg.AddOnImagePrepull.Namespace = ""
+	if err := cfg.AddOnImagePrepull.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected empty Namespace error")
+	}
+}
+
+func TestEnvAddOnPVReclaim(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_PV_RECLAIM_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PV_RECLAIM_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_PV_RECLAIM_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PV_RECLAIM_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_PV_RECLAIM_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PV_RECLAIM_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_PV_RECLAIM_CYCLES", "5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PV_RECLAIM_CYCLES")
+	os.Setenv("K8S_TESTER_ADD_ON_PV_RECLAIM_VOLUME_TYPE", "gp2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PV_RECLAIM_VOLUME_TYPE")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnPVReclaim.Enable {
+		t.Fatalf("unexpected cfg.AddOnPVReclaim.Enable %v", cfg.AddOnPVReclaim.Enable)
+	}
+	if cfg.AddOnPVReclaim.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnPVReclaim.Namespace %v", cfg.AddOnPVReclaim.Namespace)
+	}
+	if cfg.AddOnPVReclaim.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnPVReclaim.Region %v", cfg.AddOnPVReclaim.Region)
+	}
+	if cfg.AddOnPVReclaim.Cycles != 5 {
+		t.Fatalf("unexpected cfg.AddOnPVReclaim.Cycles %v", cfg.AddOnPVReclaim.Cycles)
+	}
+	if cfg.AddOnPVReclaim.VolumeType != "gp2" {
+		t.Fatalf("unexpected cfg.AddOnPVReclaim.VolumeType %v", cfg.AddOnPVReclaim.VolumeType)
+	}
+
+	if err := cfg.AddOnPVReclaim.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnPVReclaim.VolumeType != "gp2" {
+		t.Fatalf("unexpected cfg.AddOnPVReclaim.VolumeType %v", cfg.AddOnPVReclaim.VolumeType)
+	}
+	if cfg.AddOnPVReclaim.VolumeSize != "1Gi" {
+		t.Fatalf("unexpected cfg.AddOnPVReclaim.VolumeSize %v", cfg.AddOnPVReclaim.VolumeSize)
+	}
+	cfg.AddOnPVReclaim.VolumeSize = "hello"
+	if err := cfg.AddOnPVReclaim.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected invalid VolumeSize error")
+	}
+}
+
+func TestEnvAddOnSysctl(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_SYSCTL_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_SYSCTL_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_SYSCTL_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_SYSCTL_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_SYSCTL_PROFILES", `{"default":{"net.core.somaxconn":4096},"bottlerocket":{"fs.inotify.max_user_watches":65536}}`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_SYSCTL_PROFILES")
+	os.Setenv("K8S_TESTER_ADD_ON_SYSCTL_TIMEOUT", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_SYSCTL_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnSysctl.Enable {
+		t.Fatalf("unexpected cfg.AddOnSysctl.Enable %v", cfg.AddOnSysctl.Enable)
+	}
+	if cfg.AddOnSysctl.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnSysctl.Namespace %v", cfg.AddOnSysctl.Namespace)
+	}
+	expProfiles := map[string]map[string]int64{
+		"default":      {"net.core.somaxconn": 4096},
+		"bottlerocket": {"fs.inotify.max_user_watches": 65536},
+	}
+	if !reflect.DeepEqual(cfg.AddOnSysctl.Profiles, expProfiles) {
+		t.Fatalf("unexpected cfg.AddOnSysctl.Profiles %v", cfg.AddOnSysctl.Profiles)
+	}
+	if cfg.AddOnSysctl.Timeout != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnSysctl.Timeout %v", cfg.AddOnSysctl.Timeout)
+	}
+}
+
+func TestEnvAddOnNetworkPolicy(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_NETWORK_POLICY_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NETWORK_POLICY_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_NETWORK_POLICY_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NETWORK_POLICY_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_NETWORK_POLICY_IMAGE", "hello-agnhost")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NETWORK_POLICY_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_NETWORK_POLICY_PROPAGATION_TIMEOUT", "5m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NETWORK_POLICY_PROPAGATION_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnNetworkPolicy.Enable {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.Enable %v", cfg.AddOnNetworkPolicy.Enable)
+	}
+	if cfg.AddOnNetworkPolicy.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.Namespace %v", cfg.AddOnNetworkPolicy.Namespace)
+	}
+	if cfg.AddOnNetworkPolicy.Image != "hello-agnhost" {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.Image %v", cfg.AddOnNetworkPolicy.Image)
+	}
+	if cfg.AddOnNetworkPolicy.PropagationTimeout != 5*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.PropagationTimeout %v", cfg.AddOnNetworkPolicy.PropagationTimeout)
+	}
+}
+
+func TestEnvAddOnHollowNodes(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_KUBEMARK_IMAGE", "hello-kubemark")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_KUBEMARK_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_NODES", "500")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_NODES")
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_NODE_LABELS", `{"a":"b"}`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_NODE_LABELS")
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_MAX_PODS", "30")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_MAX_PODS")
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_DEPLOYMENT_NODE_SELECTOR", `{"c":"d"}`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_DEPLOYMENT_NODE_SELECTOR")
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_SHAPES", `{"gpu":{"nodes":3,"kubemark_image":"hello-kubemark-skew","taints":["gpu=true:NoSchedule"],"extended_resources":{"nvidia.com/gpu":"8"}}}`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_SHAPES")
+	os.Setenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_TIMEOUT", "1h")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_HOLLOW_NODES_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnHollowNodes.Enable {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.Enable %v", cfg.AddOnHollowNodes.Enable)
+	}
+	if cfg.AddOnHollowNodes.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.Namespace %v", cfg.AddOnHollowNodes.Namespace)
+	}
+	if cfg.AddOnHollowNodes.KubemarkImage != "hello-kubemark" {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.KubemarkImage %v", cfg.AddOnHollowNodes.KubemarkImage)
+	}
+	if cfg.AddOnHollowNodes.Nodes != 500 {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.Nodes %v", cfg.AddOnHollowNodes.Nodes)
+	}
+	if !reflect.DeepEqual(cfg.AddOnHollowNodes.NodeLabels, map[string]string{"a": "b"}) {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.NodeLabels %v", cfg.AddOnHollowNodes.NodeLabels)
+	}
+	if cfg.AddOnHollowNodes.MaxPods != 30 {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.MaxPods %v", cfg.AddOnHollowNodes.MaxPods)
+	}
+	if !reflect.DeepEqual(cfg.AddOnHollowNodes.DeploymentNodeSelector, map[string]string{"c": "d"}) {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.DeploymentNodeSelector %v", cfg.AddOnHollowNodes.DeploymentNodeSelector)
+	}
+	expShapes := map[string]*hollow_nodes.Shape{
+		"gpu": {
+			Nodes:             3,
+			KubemarkImage:     "hello-kubemark-skew",
+			Taints:            []string{"gpu=true:NoSchedule"},
+			ExtendedResources: map[string]string{"nvidia.com/gpu": "8"},
+		},
+	}
+	if !reflect.DeepEqual(cfg.AddOnHollowNodes.Shapes, expShapes) {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.Shapes %+v", cfg.AddOnHollowNodes.Shapes)
+	}
+	if cfg.AddOnHollowNodes.Timeout != time.Hour {
+		t.Fatalf("unexpected cfg.AddOnHollowNodes.Timeout %v", cfg.AddOnHollowNodes.Timeout)
+	}
+}
+
+func TestEnvAddOnDNS(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_IMAGE", "hello-dnsperf")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_QUERIES", "a.example.com A,b.example.com AAAA")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_QUERIES")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_CLIENTS", "5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_CLIENTS")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_QPS", "2000")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_QPS")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_DURATION", "5m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_DURATION")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_COREDNS_REPLICAS", "4")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_COREDNS_REPLICAS")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_LATENCY_P99_THRESHOLD", "20ms")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_LATENCY_P99_THRESHOLD")
+	os.Setenv("K8S_TESTER_ADD_ON_DNS_MAX_LOST_RATIO", "0.05")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DNS_MAX_LOST_RATIO")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnDNS.Enable {
+		t.Fatalf("unexpected cfg.AddOnDNS.Enable %v", cfg.AddOnDNS.Enable)
+	}
+	if cfg.AddOnDNS.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnDNS.Namespace %v", cfg.AddOnDNS.Namespace)
+	}
+	if cfg.AddOnDNS.Image != "hello-dnsperf" {
+		t.Fatalf("unexpected cfg.AddOnDNS.Image %v", cfg.AddOnDNS.Image)
+	}
+	if !reflect.DeepEqual(cfg.AddOnDNS.Queries, []string{"a.example.com A", "b.example.com AAAA"}) {
+		t.Fatalf("unexpected cfg.AddOnDNS.Queries %v", cfg.AddOnDNS.Queries)
+	}
+	if cfg.AddOnDNS.Clients != 5 {
+		t.Fatalf("unexpected cfg.AddOnDNS.Clients %v", cfg.AddOnDNS.Clients)
+	}
+	if cfg.AddOnDNS.QPS != 2000 {
+		t.Fatalf("unexpected cfg.AddOnDNS.QPS %v", cfg.AddOnDNS.QPS)
+	}
+	if cfg.AddOnDNS.Duration != 5*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnDNS.Duration %v", cfg.AddOnDNS.Duration)
+	}
+	if cfg.AddOnDNS.CoreDNSReplicas != 4 {
+		t.Fatalf("unexpected cfg.AddOnDNS.CoreDNSReplicas %v", cfg.AddOnDNS.CoreDNSReplicas)
+	}
+	if cfg.AddOnDNS.LatencyP99Threshold != 20*time.Millisecond {
+		t.Fatalf("unexpected cfg.AddOnDNS.LatencyP99Threshold %v", cfg.AddOnDNS.LatencyP99Threshold)
+	}
+	if cfg.AddOnDNS.MaxLostRatio != 0.05 {
+		t.Fatalf("unexpected cfg.AddOnDNS.MaxLostRatio %v", cfg.AddOnDNS.MaxLostRatio)
+	}
+}
+
+func TestEnvAddOnIRSA(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_IRSA_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IRSA_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_IRSA_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IRSA_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_IRSA_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IRSA_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_IRSA_CLUSTER_NAME", "hello-cluster")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IRSA_CLUSTER_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_IRSA_ROLE_NAME", "hello-role")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IRSA_ROLE_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_IRSA_S3_BUCKET_NAME", "hello-bucket")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IRSA_S3_BUCKET_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_IRSA_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_IRSA_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnIRSA.Enable {
+		t.Fatalf("unexpected cfg.AddOnIRSA.Enable %v", cfg.AddOnIRSA.Enable)
+	}
+	if cfg.AddOnIRSA.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnIRSA.Namespace %v", cfg.AddOnIRSA.Namespace)
+	}
+	if cfg.AddOnIRSA.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnIRSA.Region %v", cfg.AddOnIRSA.Region)
+	}
+	if cfg.AddOnIRSA.ClusterName != "hello-cluster" {
+		t.Fatalf("unexpected cfg.AddOnIRSA.ClusterName %v", cfg.AddOnIRSA.ClusterName)
+	}
+	if cfg.AddOnIRSA.RoleName != "hello-role" {
+		t.Fatalf("unexpected cfg.AddOnIRSA.RoleName %v", cfg.AddOnIRSA.RoleName)
+	}
+	if cfg.AddOnIRSA.S3BucketName != "hello-bucket" {
+		t.Fatalf("unexpected cfg.AddOnIRSA.S3BucketName %v", cfg.AddOnIRSA.S3BucketName)
+	}
+	if cfg.AddOnIRSA.Timeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnIRSA.Timeout %v", cfg.AddOnIRSA.Timeout)
+	}
+}
+
+func TestEnvAddOnFargate(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_FARGATE_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FARGATE_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_FARGATE_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FARGATE_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_FARGATE_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FARGATE_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_FARGATE_PROFILE_NAME", "hello-profile")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FARGATE_PROFILE_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_FARGATE_PROFILE_SELECTOR_LABELS", `{"compute":"fargate"}`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FARGATE_PROFILE_SELECTOR_LABELS")
+	os.Setenv("K8S_TESTER_ADD_ON_FARGATE_SUBNET_IDS", "subnet-a,subnet-b")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FARGATE_SUBNET_IDS")
+	os.Setenv("K8S_TESTER_ADD_ON_FARGATE_LOGGING_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FARGATE_LOGGING_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_FARGATE_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_FARGATE_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnFargate.Enable {
+		t.Fatalf("unexpected cfg.AddOnFargate.Enable %v", cfg.AddOnFargate.Enable)
+	}
+	if cfg.AddOnFargate.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnFargate.Namespace %v", cfg.AddOnFargate.Namespace)
+	}
+	if cfg.AddOnFargate.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnFargate.Region %v", cfg.AddOnFargate.Region)
+	}
+	if cfg.AddOnFargate.ProfileName != "hello-profile" {
+		t.Fatalf("unexpected cfg.AddOnFargate.ProfileName %v", cfg.AddOnFargate.ProfileName)
+	}
+	if !reflect.DeepEqual(cfg.AddOnFargate.ProfileSelectorLabels, map[string]string{"compute": "fargate"}) {
+		t.Fatalf("unexpected cfg.AddOnFargate.ProfileSelectorLabels %v", cfg.AddOnFargate.ProfileSelectorLabels)
+	}
+	if !reflect.DeepEqual(cfg.AddOnFargate.SubnetIDs, []string{"subnet-a", "subnet-b"}) {
+		t.Fatalf("unexpected cfg.AddOnFargate.SubnetIDs %v", cfg.AddOnFargate.SubnetIDs)
+	}
+	if !cfg.AddOnFargate.LoggingEnable {
+		t.Fatalf("unexpected cfg.AddOnFargate.LoggingEnable %v", cfg.AddOnFargate.LoggingEnable)
+	}
+	if cfg.AddOnFargate.Timeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnFargate.Timeout %v", cfg.AddOnFargate.Timeout)
+	}
+}
+
+func TestEnvAddOnEndpointSlices(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_SERVICES", "3")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_SERVICES")
+	os.Setenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_ENDPOINTS_PER_SERVICE", "5000")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_ENDPOINTS_PER_SERVICE")
+	os.Setenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_MIRRORED_ENDPOINTS", "500")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_MIRRORED_ENDPOINTS")
+	os.Setenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_CHURN_ROUNDS", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_CHURN_ROUNDS")
+	os.Setenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_PROPAGATION_TIMEOUT", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_PROPAGATION_TIMEOUT")
+	os.Setenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_NETWORK_PROGRAMMING_LATENCY_P99_THRESHOLD", "0s")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ENDPOINTSLICES_NETWORK_PROGRAMMING_LATENCY_P99_THRESHOLD")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnEndpointSlices.Enable {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.Enable %v", cfg.AddOnEndpointSlices.Enable)
+	}
+	if cfg.AddOnEndpointSlices.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.Namespace %v", cfg.AddOnEndpointSlices.Namespace)
+	}
+	if cfg.AddOnEndpointSlices.Services != 3 {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.Services %v", cfg.AddOnEndpointSlices.Services)
+	}
+	if cfg.AddOnEndpointSlices.EndpointsPerService != 5000 {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.EndpointsPerService %v", cfg.AddOnEndpointSlices.EndpointsPerService)
+	}
+	if cfg.AddOnEndpointSlices.MirroredEndpoints != 500 {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.MirroredEndpoints %v", cfg.AddOnEndpointSlices.MirroredEndpoints)
+	}
+	if cfg.AddOnEndpointSlices.ChurnRounds != 10 {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.ChurnRounds %v", cfg.AddOnEndpointSlices.ChurnRounds)
+	}
+	if cfg.AddOnEndpointSlices.PropagationTimeout != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.PropagationTimeout %v", cfg.AddOnEndpointSlices.PropagationTimeout)
+	}
+	if cfg.AddOnEndpointSlices.NetworkProgrammingLatencyP99Threshold != 0 {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.NetworkProgrammingLatencyP99Threshold %v", cfg.AddOnEndpointSlices.NetworkProgrammingLatencyP99Threshold)
+	}
+	if err := cfg.AddOnEndpointSlices.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnEndpointSlices.ChurnPods != 50 {
+		t.Fatalf("unexpected cfg.AddOnEndpointSlices.ChurnPods %v", cfg.AddOnEndpointSlices.ChurnPods)
+	}
+}
+
+func TestEnvAddOnPrometheusGrafana(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_PASSWORD", "secret")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_PASSWORD")
+	os.Setenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_NLB", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_NLB")
+	os.Setenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_TARGETS_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_TARGETS_TIMEOUT")
+	os.Setenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_REQUIRED_JOBS", "apiserver,kubelet")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_REQUIRED_JOBS")
+	os.Setenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_QUERIES", `up{job="apiserver"}`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROMETHEUS_GRAFANA_QUERIES")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnPrometheusGrafana.Enable {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.Enable %v", cfg.AddOnPrometheusGrafana.Enable)
+	}
+	if cfg.AddOnPrometheusGrafana.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.Namespace %v", cfg.AddOnPrometheusGrafana.Namespace)
+	}
+	if cfg.AddOnPrometheusGrafana.GrafanaAdminPassword != "secret" {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.GrafanaAdminPassword %v", cfg.AddOnPrometheusGrafana.GrafanaAdminPassword)
+	}
+	if !cfg.AddOnPrometheusGrafana.GrafanaNLB {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.GrafanaNLB %v", cfg.AddOnPrometheusGrafana.GrafanaNLB)
+	}
+	if cfg.AddOnPrometheusGrafana.TargetsTimeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.TargetsTimeout %v", cfg.AddOnPrometheusGrafana.TargetsTimeout)
+	}
+	if !reflect.DeepEqual(cfg.AddOnPrometheusGrafana.RequiredJobs, []string{"apiserver", "kubelet"}) {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.RequiredJobs %v", cfg.AddOnPrometheusGrafana.RequiredJobs)
+	}
+	if !reflect.DeepEqual(cfg.AddOnPrometheusGrafana.Queries, []string{`up{job="apiserver"}`}) {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.Queries %v", cfg.AddOnPrometheusGrafana.Queries)
+	}
+	if cfg.AddOnPrometheusGrafana.GrafanaAdminUserName != prometheus_grafana.DefaultGrafanaAdminUserName {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.GrafanaAdminUserName %v", cfg.AddOnPrometheusGrafana.GrafanaAdminUserName)
+	}
+}
+
+func TestEnvAddOnPortExhaustion(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_NODE_PORT_RANGE", "30000-30100")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_NODE_PORT_RANGE")
+	os.Setenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_HOST_PORT_EXHAUSTION", "false")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_HOST_PORT_EXHAUSTION")
+	os.Setenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_HOST_PORT", "18080")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PORT_EXHAUSTION_HOST_PORT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnPortExhaustion.Enable {
+		t.Fatalf("unexpected cfg.AddOnPortExhaustion.Enable %v", cfg.AddOnPortExhaustion.Enable)
+	}
+	if cfg.AddOnPortExhaustion.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnPortExhaustion.Namespace %v", cfg.AddOnPortExhaustion.Namespace)
+	}
+	if cfg.AddOnPortExhaustion.NodePortRange != "30000-30100" {
+		t.Fatalf("unexpected cfg.AddOnPortExhaustion.NodePortRange %v", cfg.AddOnPortExhaustion.NodePortRange)
+	}
+	if !cfg.AddOnPortExhaustion.NodePortExhaustion {
+		t.Fatalf("unexpected cfg.AddOnPortExhaustion.NodePortExhaustion %v", cfg.AddOnPortExhaustion.NodePortExhaustion)
+	}
+	if cfg.AddOnPortExhaustion.HostPortExhaustion {
+		t.Fatalf("unexpected cfg.AddOnPortExhaustion.HostPortExhaustion %v", cfg.AddOnPortExhaustion.HostPortExhaustion)
+	}
+	if cfg.AddOnPortExhaustion.HostPort != 18080 {
+		t.Fatalf("unexpected cfg.AddOnPortExhaustion.HostPort %v", cfg.AddOnPortExhaustion.HostPort)
+	}
+	if err := cfg.AddOnPortExhaustion.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvAddOnRuntimeRestart(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_COMPONENTS", "containerd,kubelet")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_COMPONENTS")
+	os.Setenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_NODES", "3")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_NODES")
+	os.Setenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_ROUNDS", "5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_ROUNDS")
+	os.Setenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_WORKLOAD_REPLICAS", "30")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_WORKLOAD_REPLICAS")
+	os.Setenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_RECOVERY_TIMEOUT", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_RECOVERY_TIMEOUT")
+	os.Setenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_MAX_SANDBOX_LEAKS", "2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_RUNTIME_RESTART_MAX_SANDBOX_LEAKS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnRuntimeRestart.Enable {
+		t.Fatalf("unexpected cfg.AddOnRuntimeRestart.Enable %v", cfg.AddOnRuntimeRestart.Enable)
+	}
+	if cfg.AddOnRuntimeRestart.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnRuntimeRestart.Namespace %v", cfg.AddOnRuntimeRestart.Namespace)
+	}
+	if !reflect.DeepEqual(cfg.AddOnRuntimeRestart.Components, []string{"containerd", "kubelet"}) {
+		t.Fatalf("unexpected cfg.AddOnRuntimeRestart.Components %v", cfg.AddOnRuntimeRestart.Components)
+	}
+	if cfg.AddOnRuntimeRestart.Nodes != 3 {
+		t.Fatalf("unexpected cfg.AddOnRuntimeRestart.Nodes %v", cfg.AddOnRuntimeRestart.Nodes)
+	}
+	if cfg.AddOnRuntimeRestart.Rounds != 5 {
+		t.Fatalf("unexpected cfg.AddOnRuntimeRestart.Rounds %v", cfg.AddOnRuntimeRestart.Rounds)
+	}
+	if cfg.AddOnRuntimeRestart.WorkloadReplicas != 30 {
+		t.Fatalf("unexpected cfg.AddOnRuntimeRestart.WorkloadReplicas %v", cfg.AddOnRuntimeRestart.WorkloadReplicas)
+	}
+	if cfg.AddOnRuntimeRestart.RecoveryTimeout != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnRuntimeRestart.RecoveryTimeout %v", cfg.AddOnRuntimeRestart.RecoveryTimeout)
+	}
+	if cfg.AddOnRuntimeRestart.MaxSandboxLeaks != 2 {
+		t.Fatalf("unexpected cfg.AddOnRuntimeRestart.MaxSandboxLeaks %v", cfg.AddOnRuntimeRestart.MaxSandboxLeaks)
+	}
+}
+
+func TestEnvAddOnCertRotation(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_CERT_ROTATION_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CERT_ROTATION_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_CERT_ROTATION_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CERT_ROTATION_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_CERT_ROTATION_CERTIFICATES", "serving")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CERT_ROTATION_CERTIFICATES")
+	os.Setenv("K8S_TESTER_ADD_ON_CERT_ROTATION_NODES", "2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CERT_ROTATION_NODES")
+	os.Setenv("K8S_TESTER_ADD_ON_CERT_ROTATION_APPROVE_CSRS", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CERT_ROTATION_APPROVE_CSRS")
+	os.Setenv("K8S_TESTER_ADD_ON_CERT_ROTATION_WORKLOAD_REPLICAS", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CERT_ROTATION_WORKLOAD_REPLICAS")
+	os.Setenv("K8S_TESTER_ADD_ON_CERT_ROTATION_ROTATION_TIMEOUT", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CERT_ROTATION_ROTATION_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnCertRotation.Enable {
+		t.Fatalf("unexpected cfg.AddOnCertRotation.Enable %v", cfg.AddOnCertRotation.Enable)
+	}
+	if cfg.AddOnCertRotation.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnCertRotation.Namespace %v", cfg.AddOnCertRotation.Namespace)
+	}
+	if !reflect.DeepEqual(cfg.AddOnCertRotation.Certificates, []string{"serving"}) {
+		t.Fatalf("unexpected cfg.AddOnCertRotation.Certificates %v", cfg.AddOnCertRotation.Certificates)
+	}
+	if cfg.AddOnCertRotation.Nodes != 2 {
+		t.Fatalf("unexpected cfg.AddOnCertRotation.Nodes %v", cfg.AddOnCertRotation.Nodes)
+	}
+	if !cfg.AddOnCertRotation.ApproveCSRs {
+		t.Fatalf("unexpected cfg.AddOnCertRotation.ApproveCSRs %v", cfg.AddOnCertRotation.ApproveCSRs)
+	}
+	if cfg.AddOnCertRotation.WorkloadReplicas != 10 {
+		t.Fatalf("unexpected cfg.AddOnCertRotation.WorkloadReplicas %v", cfg.AddOnCertRotation.WorkloadReplicas)
+	}
+	if cfg.AddOnCertRotation.RotationTimeout != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnCertRotation.RotationTimeout %v", cfg.AddOnCertRotation.RotationTimeout)
+	}
+}
+
+func TestEnvAddOnKubeStateMetrics(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_HELM_CHART_VERSION", "5.10.0")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_HELM_CHART_VERSION")
+	os.Setenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_SCRAPE_TIMEOUT", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_SCRAPE_TIMEOUT")
+	os.Setenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_REQUIRED_SERIES", "kube_node_info,kube_pod_info")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUBE_STATE_METRICS_REQUIRED_SERIES")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnKubeStateMetrics.Enable {
+		t.Fatalf("unexpected cfg.AddOnKubeStateMetrics.Enable %v", cfg.AddOnKubeStateMetrics.Enable)
+	}
+	if cfg.AddOnKubeStateMetrics.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnKubeStateMetrics.Namespace %v", cfg.AddOnKubeStateMetrics.Namespace)
+	}
+	if cfg.AddOnKubeStateMetrics.HelmChartVersion != "5.10.0" {
+		t.Fatalf("unexpected cfg.AddOnKubeStateMetrics.HelmChartVersion %v", cfg.AddOnKubeStateMetrics.HelmChartVersion)
+	}
+	if cfg.AddOnKubeStateMetrics.ScrapeTimeout != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnKubeStateMetrics.ScrapeTimeout %v", cfg.AddOnKubeStateMetrics.ScrapeTimeout)
+	}
+	if !reflect.DeepEqual(cfg.AddOnKubeStateMetrics.RequiredSeries, []string{"kube_node_info", "kube_pod_info"}) {
+		t.Fatalf("unexpected cfg.AddOnKubeStateMetrics.RequiredSeries %v", cfg.AddOnKubeStateMetrics.RequiredSeries)
+	}
+}
+
+func TestEnvAddOnADOT(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_ADOT_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ADOT_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_ADOT_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ADOT_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_ADOT_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ADOT_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_ADOT_TRACES", "20")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ADOT_TRACES")
+	os.Setenv("K8S_TESTER_ADD_ON_ADOT_DELIVERY_TIMEOUT", "15m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_ADOT_DELIVERY_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnADOT.Enable {
+		t.Fatalf("unexpected cfg.AddOnADOT.Enable %v", cfg.AddOnADOT.Enable)
+	}
+	if cfg.AddOnADOT.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnADOT.Namespace %v", cfg.AddOnADOT.Namespace)
+	}
+	if cfg.AddOnADOT.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnADOT.Region %v", cfg.AddOnADOT.Region)
+	}
+	if cfg.AddOnADOT.Traces != 20 {
+		t.Fatalf("unexpected cfg.AddOnADOT.Traces %v", cfg.AddOnADOT.Traces)
+	}
+	if cfg.AddOnADOT.DeliveryTimeout != 15*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnADOT.DeliveryTimeout %v", cfg.AddOnADOT.DeliveryTimeout)
+	}
+	if err := cfg.AddOnADOT.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnADOT.MetricNamespace != "aws-k8s-tester/hello" {
+		t.Fatalf("unexpected cfg.AddOnADOT.MetricNamespace %v", cfg.AddOnADOT.MetricNamespace)
+	}
+	if cfg.AddOnADOT.ServiceName != "hello" {
+		t.Fatalf("unexpected cfg.AddOnADOT.ServiceName %v", cfg.AddOnADOT.ServiceName)
+	}
+}
+
+func TestEnvAddOnNodeProblemDetector(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_PROBLEM_DETECTOR_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_PROBLEM_DETECTOR_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_PROBLEM_DETECTOR_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_PROBLEM_DETECTOR_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_PROBLEM_DETECTOR_IMAGE", "hello-npd")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_PROBLEM_DETECTOR_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_PROBLEM_DETECTOR_CONDITION_TIMEOUT", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_PROBLEM_DETECTOR_CONDITION_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnNodeProblemDetector.Enable {
+		t.Fatalf("unexpected cfg.AddOnNodeProblemDetector.Enable %v", cfg.AddOnNodeProblemDetector.Enable)
+	}
+	if cfg.AddOnNodeProblemDetector.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnNodeProblemDetector.Namespace %v", cfg.AddOnNodeProblemDetector.Namespace)
+	}
+	if cfg.AddOnNodeProblemDetector.Image != "hello-npd" {
+		t.Fatalf("unexpected cfg.AddOnNodeProblemDetector.Image %v", cfg.AddOnNodeProblemDetector.Image)
+	}
+	if cfg.AddOnNodeProblemDetector.ConditionTimeout != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnNodeProblemDetector.ConditionTimeout %v", cfg.AddOnNodeProblemDetector.ConditionTimeout)
+	}
+	if err := cfg.AddOnNodeProblemDetector.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnNodeProblemDetector.ConditionTimeoutString != "10m0s" {
+		t.Fatalf("unexpected cfg.AddOnNodeProblemDetector.ConditionTimeoutString %v", cfg.AddOnNodeProblemDetector.ConditionTimeoutString)
+	}
+
+	cfg.AddOnNodeProblemDetector.Namespace = ""
+	if err := cfg.AddOnNodeProblemDetector.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for empty Namespace")
+	}
+}
+
+func TestEnvAddOnPDB(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_PDB_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PDB_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_PDB_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PDB_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_PDB_REPLICAS", "6")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PDB_REPLICAS")
+	os.Setenv("K8S_TESTER_ADD_ON_PDB_MAX_UNAVAILABLE", "2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PDB_MAX_UNAVAILABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_PDB_DRAIN_NODE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PDB_DRAIN_NODE")
+	os.Setenv("K8S_TESTER_ADD_ON_PDB_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PDB_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnPDB.Enable {
+		t.Fatalf("unexpected cfg.AddOnPDB.Enable %v", cfg.AddOnPDB.Enable)
+	}
+	if cfg.AddOnPDB.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnPDB.Namespace %v", cfg.AddOnPDB.Namespace)
+	}
+	if cfg.AddOnPDB.Replicas != 6 {
+		t.Fatalf("unexpected cfg.AddOnPDB.Replicas %v", cfg.AddOnPDB.Replicas)
+	}
+	if cfg.AddOnPDB.MaxUnavailable != 2 {
+		t.Fatalf("unexpected cfg.AddOnPDB.MaxUnavailable %v", cfg.AddOnPDB.MaxUnavailable)
+	}
+	if !cfg.AddOnPDB.DrainNode {
+		t.Fatalf("unexpected cfg.AddOnPDB.DrainNode %v", cfg.AddOnPDB.DrainNode)
+	}
+	if cfg.AddOnPDB.Timeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnPDB.Timeout %v", cfg.AddOnPDB.Timeout)
+	}
+	if err := cfg.AddOnPDB.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnPDB.TimeoutString != "20m0s" {
+		t.Fatalf("unexpected cfg.AddOnPDB.TimeoutString %v", cfg.AddOnPDB.TimeoutString)
+	}
+
+	cfg.AddOnPDB.MaxUnavailable = 6
+	if err := cfg.AddOnPDB.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for MaxUnavailable not less than Replicas")
+	}
+	cfg.AddOnPDB.MaxUnavailable = 2
+	cfg.AddOnPDB.Namespace = ""
+	if err := cfg.AddOnPDB.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for empty Namespace")
+	}
+}
+
+func TestEnvAddOnDaemonSetRollout(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_DAEMONSET_ROLLOUT_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DAEMONSET_ROLLOUT_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_DAEMONSET_ROLLOUT_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DAEMONSET_ROLLOUT_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_DAEMONSET_ROLLOUT_STRATEGIES", `{"one":{"max_unavailable":"1"},"surge":{"max_unavailable":"0","max_surge":"25%"}}`)
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DAEMONSET_ROLLOUT_STRATEGIES")
+	os.Setenv("K8S_TESTER_ADD_ON_DAEMONSET_ROLLOUT_ROLLOUT_TIMEOUT", "1h")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_DAEMONSET_ROLLOUT_ROLLOUT_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnDaemonSetRollout.Enable {
+		t.Fatalf("unexpected cfg.AddOnDaemonSetRollout.Enable %v", cfg.AddOnDaemonSetRollout.Enable)
+	}
+	if cfg.AddOnDaemonSetRollout.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnDaemonSetRollout.Namespace %v", cfg.AddOnDaemonSetRollout.Namespace)
+	}
+	expectedStrategies := map[string]*daemonset_rollout.Strategy{
+		"one":   {MaxUnavailable: "1"},
+		"surge": {MaxUnavailable: "0", MaxSurge: "25%"},
+	}
+	if !reflect.DeepEqual(cfg.AddOnDaemonSetRollout.Strategies, expectedStrategies) {
+		t.Fatalf("unexpected cfg.AddOnDaemonSetRollout.Strategies %+v", cfg.AddOnDaemonSetRollout.Strategies)
+	}
+	if cfg.AddOnDaemonSetRollout.RolloutTimeout != time.Hour {
+		t.Fatalf("unexpected cfg.AddOnDaemonSetRollout.RolloutTimeout %v", cfg.AddOnDaemonSetRollout.RolloutTimeout)
+	}
+	if err := cfg.AddOnDaemonSetRollout.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnDaemonSetRollout.RolloutTimeoutString != "1h0m0s" {
+		t.Fatalf("unexpected cfg.AddOnDaemonSetRollout.RolloutTimeoutString %v", cfg.AddOnDaemonSetRollout.RolloutTimeoutString)
+	}
+
+	cfg.AddOnDaemonSetRollout.Strategies["bad"] = &daemonset_rollout.Strategy{MaxUnavailable: "1", MaxSurge: "1"}
+	if err := cfg.AddOnDaemonSetRollout.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for MaxUnavailable with MaxSurge")
+	}
+}
+
+func TestEnvAddOnWindows(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_WINDOWS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_WINDOWS_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_WINDOWS_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_WINDOWS_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_WINDOWS_MINIMUM_WINDOWS_NODES", "3")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_WINDOWS_MINIMUM_WINDOWS_NODES")
+	os.Setenv("K8S_TESTER_ADD_ON_WINDOWS_IMAGE", "mcr.microsoft.com/windows/servercore/iis:windowsservercore-ltsc2022")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_WINDOWS_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_WINDOWS_REPLICAS", "5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_WINDOWS_REPLICAS")
+	os.Setenv("K8S_TESTER_ADD_ON_WINDOWS_TIMEOUT", "30m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_WINDOWS_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnWindows.Enable {
+		t.Fatalf("unexpected cfg.AddOnWindows.Enable %v", cfg.AddOnWindows.Enable)
+	}
+	if cfg.AddOnWindows.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnWindows.Namespace %v", cfg.AddOnWindows.Namespace)
+	}
+	if cfg.AddOnWindows.MinimumWindowsNodes != 3 {
+		t.Fatalf("unexpected cfg.AddOnWindows.MinimumWindowsNodes %v", cfg.AddOnWindows.MinimumWindowsNodes)
+	}
+	if cfg.AddOnWindows.Image != "mcr.microsoft.com/windows/servercore/iis:windowsservercore-ltsc2022" {
+		t.Fatalf("unexpected cfg.AddOnWindows.Image %v", cfg.AddOnWindows.Image)
+	}
+	if cfg.AddOnWindows.Replicas != 5 {
+		t.Fatalf("unexpected cfg.AddOnWindows.Replicas %v", cfg.AddOnWindows.Replicas)
+	}
+	if cfg.AddOnWindows.Timeout != 30*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnWindows.Timeout %v", cfg.AddOnWindows.Timeout)
+	}
+	if err := cfg.AddOnWindows.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnWindows.TimeoutString != "30m0s" {
+		t.Fatalf("unexpected cfg.AddOnWindows.TimeoutString %v", cfg.AddOnWindows.TimeoutString)
+	}
+
+	os.Setenv("K8S_TESTER_ADD_ON_WINDOWS_WINDOWS_NODES", "a,b")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_WINDOWS_WINDOWS_NODES")
+	if err := cfg.UpdateFromEnvs(); err == nil {
+		t.Fatal("expected error for read-only WindowsNodes")
+	}
+}
+
+func TestEnvAddOnNVIDIAGPU(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_MINIMUM_GPU_NODES", "2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_MINIMUM_GPU_NODES")
+	os.Setenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_INSTALL_DEVICE_PLUGIN", "false")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_INSTALL_DEVICE_PLUGIN")
+	os.Setenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_IMAGE", "nvcr.io/nvidia/k8s/cuda-sample:vectoradd-cuda11.7.1")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_GPUS", "4")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_GPUS")
+	os.Setenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_TIMEOUT", "30m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NVIDIA_GPU_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnNVIDIAGPU.Enable {
+		t.Fatalf("unexpected cfg.AddOnNVIDIAGPU.Enable %v", cfg.AddOnNVIDIAGPU.Enable)
+	}
+	if cfg.AddOnNVIDIAGPU.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnNVIDIAGPU.Namespace %v", cfg.AddOnNVIDIAGPU.Namespace)
+	}
+	if cfg.AddOnNVIDIAGPU.MinimumGPUNodes != 2 {
+		t.Fatalf("unexpected cfg.AddOnNVIDIAGPU.MinimumGPUNodes %v", cfg.AddOnNVIDIAGPU.MinimumGPUNodes)
+	}
+	if cfg.AddOnNVIDIAGPU.InstallDevicePlugin {
+		t.Fatalf("unexpected cfg.AddOnNVIDIAGPU.InstallDevicePlugin %v", cfg.AddOnNVIDIAGPU.InstallDevicePlugin)
+	}
+	if cfg.AddOnNVIDIAGPU.Image != "nvcr.io/nvidia/k8s/cuda-sample:vectoradd-cuda11.7.1" {
+		t.Fatalf("unexpected cfg.AddOnNVIDIAGPU.Image %v", cfg.AddOnNVIDIAGPU.Image)
+	}
+	if cfg.AddOnNVIDIAGPU.GPUs != 4 {
+		t.Fatalf("unexpected cfg.AddOnNVIDIAGPU.GPUs %v", cfg.AddOnNVIDIAGPU.GPUs)
+	}
+	if cfg.AddOnNVIDIAGPU.Timeout != 30*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnNVIDIAGPU.Timeout %v", cfg.AddOnNVIDIAGPU.Timeout)
+	}
+	if err := cfg.AddOnNVIDIAGPU.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnNVIDIAGPU.DevicePluginImage != "nvcr.io/nvidia/k8s-device-plugin:v0.14.1" {
+		t.Fatalf("unexpected cfg.AddOnNVIDIAGPU.DevicePluginImage %v", cfg.AddOnNVIDIAGPU.DevicePluginImage)
+	}
+
+	cfg.AddOnNVIDIAGPU.GPUs = -1
+	if err := cfg.AddOnNVIDIAGPU.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for negative GPUs")
+	}
+}
+
+func TestEnvAddOnKueue(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_KUEUE_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUEUE_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_KUEUE_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUEUE_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_KUEUE_HELM_CHART_VERSION", "0.12.0")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUEUE_HELM_CHART_VERSION")
+	os.Setenv("K8S_TESTER_ADD_ON_KUEUE_QUOTA_CPU", "3")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUEUE_QUOTA_CPU")
+	os.Setenv("K8S_TESTER_ADD_ON_KUEUE_JOB_CPU", "1")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUEUE_JOB_CPU")
+	os.Setenv("K8S_TESTER_ADD_ON_KUEUE_BURST_JOBS", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUEUE_BURST_JOBS")
+	os.Setenv("K8S_TESTER_ADD_ON_KUEUE_JOB_DURATION", "2m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUEUE_JOB_DURATION")
+	os.Setenv("K8S_TESTER_ADD_ON_KUEUE_TIMEOUT", "30m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_KUEUE_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnKueue.Enable {
+		t.Fatalf("unexpected cfg.AddOnKueue.Enable %v", cfg.AddOnKueue.Enable)
+	}
+	if cfg.AddOnKueue.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnKueue.Namespace %v", cfg.AddOnKueue.Namespace)
+	}
+	if cfg.AddOnKueue.HelmChartVersion != "0.12.0" {
+		t.Fatalf("unexpected cfg.AddOnKueue.HelmChartVersion %v", cfg.AddOnKueue.HelmChartVersion)
+	}
+	if cfg.AddOnKueue.QuotaCPU != "3" {
+		t.Fatalf("unexpected cfg.AddOnKueue.QuotaCPU %v", cfg.AddOnKueue.QuotaCPU)
+	}
+	if cfg.AddOnKueue.JobCPU != "1" {
+		t.Fatalf("unexpected cfg.AddOnKueue.JobCPU %v", cfg.AddOnKueue.JobCPU)
+	}
+	if cfg.AddOnKueue.BurstJobs != 10 {
+		t.Fatalf("unexpected cfg.AddOnKueue.BurstJobs %v", cfg.AddOnKueue.BurstJobs)
+	}
+	if cfg.AddOnKueue.JobDuration != 2*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnKueue.JobDuration %v", cfg.AddOnKueue.JobDuration)
+	}
+	if cfg.AddOnKueue.Timeout != 30*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnKueue.Timeout %v", cfg.AddOnKueue.Timeout)
+	}
+	if err := cfg.AddOnKueue.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnKueue.JobDurationString != "2m0s" {
+		t.Fatalf("unexpected cfg.AddOnKueue.JobDurationString %v", cfg.AddOnKueue.JobDurationString)
+	}
+
+	cfg.AddOnKueue.BurstJobs = 3
+	if err := cfg.AddOnKueue.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for BurstJobs within the quota")
+	}
+	cfg.AddOnKueue.BurstJobs = 10
+	cfg.AddOnKueue.JobDuration = 10 * time.Second
+	if err := cfg.AddOnKueue.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for short JobDuration")
+	}
+}
+
+func TestEnvAddOnNeuron(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_NEURON_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NEURON_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_NEURON_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NEURON_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_NEURON_MINIMUM_NEURON_NODES", "2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NEURON_MINIMUM_NEURON_NODES")
+	os.Setenv("K8S_TESTER_ADD_ON_NEURON_INSTALL_DEVICE_PLUGIN", "false")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NEURON_INSTALL_DEVICE_PLUGIN")
+	os.Setenv("K8S_TESTER_ADD_ON_NEURON_IMAGE", "public.ecr.aws/neuron/pytorch-inference-neuronx:2.1.2-neuronx-py310-sdk2.19.1-ubuntu20.04")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NEURON_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_NEURON_NEURON_DEVICES", "4")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NEURON_NEURON_DEVICES")
+	os.Setenv("K8S_TESTER_ADD_ON_NEURON_TIMEOUT", "30m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NEURON_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnNeuron.Enable {
+		t.Fatalf("unexpected cfg.AddOnNeuron.Enable %v", cfg.AddOnNeuron.Enable)
+	}
+	if cfg.AddOnNeuron.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnNeuron.Namespace %v", cfg.AddOnNeuron.Namespace)
+	}
+	if cfg.AddOnNeuron.MinimumNeuronNodes != 2 {
+		t.Fatalf("unexpected cfg.AddOnNeuron.MinimumNeuronNodes %v", cfg.AddOnNeuron.MinimumNeuronNodes)
+	}
+	if cfg.AddOnNeuron.InstallDevicePlugin {
+		t.Fatalf("unexpected cfg.AddOnNeuron.InstallDevicePlugin %v", cfg.AddOnNeuron.InstallDevicePlugin)
+	}
+	if cfg.AddOnNeuron.Image != "public.ecr.aws/neuron/pytorch-inference-neuronx:2.1.2-neuronx-py310-sdk2.19.1-ubuntu20.04" {
+		t.Fatalf("unexpected cfg.AddOnNeuron.Image %v", cfg.AddOnNeuron.Image)
+	}
+	if cfg.AddOnNeuron.NeuronDevices != 4 {
+		t.Fatalf("unexpected cfg.AddOnNeuron.NeuronDevices %v", cfg.AddOnNeuron.NeuronDevices)
+	}
+	if cfg.AddOnNeuron.Timeout != 30*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnNeuron.Timeout %v", cfg.AddOnNeuron.Timeout)
+	}
+	if err := cfg.AddOnNeuron.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnNeuron.DevicePluginImage != "public.ecr.aws/neuron/neuron-device-plugin:2.22.4.0" {
+		t.Fatalf("unexpected cfg.AddOnNeuron.DevicePluginImage %v", cfg.AddOnNeuron.DevicePluginImage)
+	}
+
+	cfg.AddOnNeuron.NeuronDevices = -1
+	if err := cfg.AddOnNeuron.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for negative NeuronDevices")
+	}
+}
+
+func TestEnvAddOnMultiContainer(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_IMAGE", "public.ecr.aws/docker/library/busybox:1.35")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_IMAGE")
+	os.Setenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_SIDECAR_TERMINATION_DELAY", "10s")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_SIDECAR_TERMINATION_DELAY")
+	os.Setenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_TIMEOUT", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_MULTI_CONTAINER_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnMultiContainer.Enable {
+		t.Fatalf("unexpected cfg.AddOnMultiContainer.Enable %v", cfg.AddOnMultiContainer.Enable)
+	}
+	if cfg.AddOnMultiContainer.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnMultiContainer.Namespace %v", cfg.AddOnMultiContainer.Namespace)
+	}
+	if cfg.AddOnMultiContainer.Image != "public.ecr.aws/docker/library/busybox:1.35" {
+		t.Fatalf("unexpected cfg.AddOnMultiContainer.Image %v", cfg.AddOnMultiContainer.Image)
+	}
+	if cfg.AddOnMultiContainer.SidecarTerminationDelay != 10*time.Second {
+		t.Fatalf("unexpected cfg.AddOnMultiContainer.SidecarTerminationDelay %v", cfg.AddOnMultiContainer.SidecarTerminationDelay)
+	}
+	if cfg.AddOnMultiContainer.Timeout != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnMultiContainer.Timeout %v", cfg.AddOnMultiContainer.Timeout)
+	}
+	if err := cfg.AddOnMultiContainer.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.AddOnMultiContainer.SidecarTerminationDelay = time.Minute
+	if err := cfg.AddOnMultiContainer.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for SidecarTerminationDelay exceeding the grace period")
+	}
+}
+
+func TestEnvAddOnNodeTagLabels(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_NODES", "3")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_NODES")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_LABEL_TAG_PREFIX", "node.k8s.aws/label/")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_LABEL_TAG_PREFIX")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_LABEL_VALUE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_LABEL_VALUE")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_TAINT_ENABLE", "false")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_TAINT_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_TIMEOUT", "20m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_NODE_TAG_LABELS_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnNodeTagLabels.Enable {
+		t.Fatalf("unexpected cfg.AddOnNodeTagLabels.Enable %v", cfg.AddOnNodeTagLabels.Enable)
+	}
+	if cfg.AddOnNodeTagLabels.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnNodeTagLabels.Region %v", cfg.AddOnNodeTagLabels.Region)
+	}
+	if cfg.AddOnNodeTagLabels.Nodes != 3 {
+		t.Fatalf("unexpected cfg.AddOnNodeTagLabels.Nodes %v", cfg.AddOnNodeTagLabels.Nodes)
+	}
+	if cfg.AddOnNodeTagLabels.LabelTagPrefix != "node.k8s.aws/label/" {
+		t.Fatalf("unexpected cfg.AddOnNodeTagLabels.LabelTagPrefix %v", cfg.AddOnNodeTagLabels.LabelTagPrefix)
+	}
+	if cfg.AddOnNodeTagLabels.LabelValue != "hello" {
+		t.Fatalf("unexpected cfg.AddOnNodeTagLabels.LabelValue %v", cfg.AddOnNodeTagLabels.LabelValue)
+	}
+	if cfg.AddOnNodeTagLabels.TaintEnable {
+		t.Fatalf("unexpected cfg.AddOnNodeTagLabels.TaintEnable %v", cfg.AddOnNodeTagLabels.TaintEnable)
+	}
+	if cfg.AddOnNodeTagLabels.Timeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnNodeTagLabels.Timeout %v", cfg.AddOnNodeTagLabels.Timeout)
+	}
+	if err := cfg.AddOnNodeTagLabels.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AddOnNodeTagLabels.MinimumNodes != 3 {
+		t.Fatalf("unexpected cfg.AddOnNodeTagLabels.MinimumNodes %v", cfg.AddOnNodeTagLabels.MinimumNodes)
+	}
+
+	cfg.AddOnNodeTagLabels.TaintEffect = "Evict"
+	if err := cfg.AddOnNodeTagLabels.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for invalid TaintEffect")
+	}
+	cfg.AddOnNodeTagLabels.TaintEffect = "NoSchedule"
+	cfg.AddOnNodeTagLabels.LabelValue = "not a label value"
+	if err := cfg.AddOnNodeTagLabels.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for invalid LabelValue")
+	}
+}
+
+func TestEnvAddOnClusterPause(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_NODE_GROUP_NAMES", "ng-1,ng-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_NODE_GROUP_NAMES")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_ASG_NAMES", "asg-1")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_ASG_NAMES")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_STORAGE_CLASS_NAME", "gp3")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_STORAGE_CLASS_NAME")
+	os.Setenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_RESUME_TIMEOUT", "1h")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CLUSTER_PAUSE_RESUME_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnClusterPause.Enable {
+		t.Fatalf("unexpected cfg.AddOnClusterPause.Enable %v", cfg.AddOnClusterPause.Enable)
+	}
+	if cfg.AddOnClusterPause.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnClusterPause.Region %v", cfg.AddOnClusterPause.Region)
+	}
+	if !reflect.DeepEqual(cfg.AddOnClusterPause.NodeGroupNames, []string{"ng-1", "ng-2"}) {
+		t.Fatalf("unexpected cfg.AddOnClusterPause.NodeGroupNames %v", cfg.AddOnClusterPause.NodeGroupNames)
+	}
+	if !reflect.DeepEqual(cfg.AddOnClusterPause.ASGNames, []string{"asg-1"}) {
+		t.Fatalf("unexpected cfg.AddOnClusterPause.ASGNames %v", cfg.AddOnClusterPause.ASGNames)
+	}
+	if cfg.AddOnClusterPause.StorageClassName != "gp3" {
+		t.Fatalf("unexpected cfg.AddOnClusterPause.StorageClassName %v", cfg.AddOnClusterPause.StorageClassName)
+	}
+	if cfg.AddOnClusterPause.ResumeTimeout != time.Hour {
+		t.Fatalf("unexpected cfg.AddOnClusterPause.ResumeTimeout %v", cfg.AddOnClusterPause.ResumeTimeout)
+	}
+	if err := cfg.AddOnClusterPause.ValidateAndSetDefaults(""); err == nil {
+		t.Fatal("expected error for empty ClusterName")
+	}
+	if err := cfg.AddOnClusterPause.ValidateAndSetDefaults("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(cfg.AddOnClusterPause.StatePath) != "hello-cluster-pause.json" {
+		t.Fatalf("unexpected cfg.AddOnClusterPause.StatePath %v", cfg.AddOnClusterPause.StatePath)
+	}
+}
+
+func TestEnvAddOnCrossCluster(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_REMOTE_KUBECONFIG_PATH", "/tmp/remote.yaml")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_REMOTE_KUBECONFIG_PATH")
+	os.Setenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_MECHANISM", "cloud-map")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_MECHANISM")
+	os.Setenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_CLOUD_MAP_NAMESPACE_ID", "ns-hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_CLOUD_MAP_NAMESPACE_ID")
+	os.Setenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_REQUESTS", "1000")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_REQUESTS")
+	os.Setenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_REQUEST_TIMEOUT", "10s")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_CROSS_CLUSTER_REQUEST_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnCrossCluster.Enable {
+		t.Fatalf("unexpected cfg.AddOnCrossCluster.Enable %v", cfg.AddOnCrossCluster.Enable)
+	}
+	if cfg.AddOnCrossCluster.RemoteKubeconfigPath != "/tmp/remote.yaml" {
+		t.Fatalf("unexpected cfg.AddOnCrossCluster.RemoteKubeconfigPath %v", cfg.AddOnCrossCluster.RemoteKubeconfigPath)
+	}
+	if cfg.AddOnCrossCluster.Mechanism != "cloud-map" {
+		t.Fatalf("unexpected cfg.AddOnCrossCluster.Mechanism %v", cfg.AddOnCrossCluster.Mechanism)
+	}
+	if cfg.AddOnCrossCluster.CloudMapNamespaceID != "ns-hello" {
+		t.Fatalf("unexpected cfg.AddOnCrossCluster.CloudMapNamespaceID %v", cfg.AddOnCrossCluster.CloudMapNamespaceID)
+	}
+	if cfg.AddOnCrossCluster.Requests != 1000 {
+		t.Fatalf("unexpected cfg.AddOnCrossCluster.Requests %v", cfg.AddOnCrossCluster.Requests)
+	}
+	if cfg.AddOnCrossCluster.RequestTimeout != 10*time.Second {
+		t.Fatalf("unexpected cfg.AddOnCrossCluster.RequestTimeout %v", cfg.AddOnCrossCluster.RequestTimeout)
+	}
+	if err := cfg.AddOnCrossCluster.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for empty Region")
+	}
+	cfg.AddOnCrossCluster.Region = "us-west-2"
+	if err := cfg.AddOnCrossCluster.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.AddOnCrossCluster.Mechanism = "hello"
+	if err := cfg.AddOnCrossCluster.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown Mechanism")
+	}
+}
+
+func TestEnvAddOnAuthRefresh(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_MODES", "exec,eks")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_MODES")
+	os.Setenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_TOKEN_DURATION", "15m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_TOKEN_DURATION")
+	os.Setenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_DURATION", "16m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_DURATION")
+	os.Setenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_MAX_FAILURE_RATIO", "0.05")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_AUTH_REFRESH_MAX_FAILURE_RATIO")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnAuthRefresh.Enable {
+		t.Fatalf("unexpected cfg.AddOnAuthRefresh.Enable %v", cfg.AddOnAuthRefresh.Enable)
+	}
+	if !reflect.DeepEqual(cfg.AddOnAuthRefresh.Modes, []string{"exec", "eks"}) {
+		t.Fatalf("unexpected cfg.AddOnAuthRefresh.Modes %v", cfg.AddOnAuthRefresh.Modes)
+	}
+	if cfg.AddOnAuthRefresh.TokenDuration != 15*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnAuthRefresh.TokenDuration %v", cfg.AddOnAuthRefresh.TokenDuration)
+	}
+	if cfg.AddOnAuthRefresh.Duration != 16*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnAuthRefresh.Duration %v", cfg.AddOnAuthRefresh.Duration)
+	}
+	if cfg.AddOnAuthRefresh.MaxFailureRatio != 0.05 {
+		t.Fatalf("unexpected cfg.AddOnAuthRefresh.MaxFailureRatio %v", cfg.AddOnAuthRefresh.MaxFailureRatio)
+	}
+	cfg.AddOnAuthRefresh.Region = "us-west-2"
+	if err := cfg.AddOnAuthRefresh.ValidateAndSetDefaults("hello"); err == nil {
+		t.Fatal("expected error for short Duration")
+	}
+	cfg.AddOnAuthRefresh.Duration = 30 * time.Minute
+	if err := cfg.AddOnAuthRefresh.ValidateAndSetDefaults("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if actions := cfg.RequiredIAMActions()["auth-refresh"]; len(actions) == 0 {
+		t.Fatal("expected IAM actions for the 'eks' mode")
+	}
+}
+
+func TestEnvAddOnProbeStorm(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_PROBE_STORM_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROBE_STORM_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_PROBE_STORM_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROBE_STORM_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_PROBE_STORM_PROBE_TYPES", "http")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROBE_STORM_PROBE_TYPES")
+	os.Setenv("K8S_TESTER_ADD_ON_PROBE_STORM_REPLICAS", "200")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROBE_STORM_REPLICAS")
+	os.Setenv("K8S_TESTER_ADD_ON_PROBE_STORM_PROBE_PERIOD_SECONDS", "2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROBE_STORM_PROBE_PERIOD_SECONDS")
+	os.Setenv("K8S_TESTER_ADD_ON_PROBE_STORM_DURATION", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROBE_STORM_DURATION")
+	os.Setenv("K8S_TESTER_ADD_ON_PROBE_STORM_MAX_RESTARTS", "5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_PROBE_STORM_MAX_RESTARTS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnProbeStorm.Enable {
+		t.Fatalf("unexpected cfg.AddOnProbeStorm.Enable %v", cfg.AddOnProbeStorm.Enable)
+	}
+	if cfg.AddOnProbeStorm.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnProbeStorm.Namespace %v", cfg.AddOnProbeStorm.Namespace)
+	}
+	if !reflect.DeepEqual(cfg.AddOnProbeStorm.ProbeTypes, []string{"http"}) {
+		t.Fatalf("unexpected cfg.AddOnProbeStorm.ProbeTypes %v", cfg.AddOnProbeStorm.ProbeTypes)
+	}
+	if cfg.AddOnProbeStorm.Replicas != 200 {
+		t.Fatalf("unexpected cfg.AddOnProbeStorm.Replicas %v", cfg.AddOnProbeStorm.Replicas)
+	}
+	if cfg.AddOnProbeStorm.ProbePeriodSeconds != 2 {
+		t.Fatalf("unexpected cfg.AddOnProbeStorm.ProbePeriodSeconds %v", cfg.AddOnProbeStorm.ProbePeriodSeconds)
+	}
+	if cfg.AddOnProbeStorm.Duration != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnProbeStorm.Duration %v", cfg.AddOnProbeStorm.Duration)
+	}
+	if cfg.AddOnProbeStorm.MaxRestarts != 5 {
+		t.Fatalf("unexpected cfg.AddOnProbeStorm.MaxRestarts %v", cfg.AddOnProbeStorm.MaxRestarts)
+	}
+}
+
+func TestEnvAddOnEventFlood(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_GENERATORS", "5")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_GENERATORS")
+	os.Setenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_CONCURRENCY", "8")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_CONCURRENCY")
+	os.Setenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_FLOOD_DURATION", "10m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_FLOOD_DURATION")
+	os.Setenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_DEDUP_EVENTS", "10")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_DEDUP_EVENTS")
+	os.Setenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_TTL_WAIT", "65m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_EVENT_FLOOD_TTL_WAIT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnEventFlood.Enable {
+		t.Fatalf("unexpected cfg.AddOnEventFlood.Enable %v", cfg.AddOnEventFlood.Enable)
+	}
+	if cfg.AddOnEventFlood.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnEventFlood.Namespace %v", cfg.AddOnEventFlood.Namespace)
+	}
+	if cfg.AddOnEventFlood.Generators != 5 {
+		t.Fatalf("unexpected cfg.AddOnEventFlood.Generators %v", cfg.AddOnEventFlood.Generators)
+	}
+	if cfg.AddOnEventFlood.Concurrency != 8 {
+		t.Fatalf("unexpected cfg.AddOnEventFlood.Concurrency %v", cfg.AddOnEventFlood.Concurrency)
+	}
+	if cfg.AddOnEventFlood.FloodDuration != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnEventFlood.FloodDuration %v", cfg.AddOnEventFlood.FloodDuration)
+	}
+	if cfg.AddOnEventFlood.DedupEvents != 10 {
+		t.Fatalf("unexpected cfg.AddOnEventFlood.DedupEvents %v", cfg.AddOnEventFlood.DedupEvents)
+	}
+	if cfg.AddOnEventFlood.TTLWait != 65*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnEventFlood.TTLWait %v", cfg.AddOnEventFlood.TTLWait)
+	}
+}
+
+func TestEnvAddOnStaticPod(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ADD_ON_STATIC_POD_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STATIC_POD_ENABLE")
+	os.Setenv("K8S_TESTER_ADD_ON_STATIC_POD_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STATIC_POD_REGION")
+	os.Setenv("K8S_TESTER_ADD_ON_STATIC_POD_NODES", "2")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STATIC_POD_NODES")
+	os.Setenv("K8S_TESTER_ADD_ON_STATIC_POD_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STATIC_POD_NAMESPACE")
+	os.Setenv("K8S_TESTER_ADD_ON_STATIC_POD_STATIC_POD_PATH", "/etc/kubernetes/manifests")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STATIC_POD_STATIC_POD_PATH")
+	os.Setenv("K8S_TESTER_ADD_ON_STATIC_POD_TIMEOUT", "3m")
+	defer os.Unsetenv("K8S_TESTER_ADD_ON_STATIC_POD_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AddOnStaticPod.Enable {
+		t.Fatalf("unexpected cfg.AddOnStaticPod.Enable %v", cfg.AddOnStaticPod.Enable)
+	}
+	if cfg.AddOnStaticPod.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.AddOnStaticPod.Region %v", cfg.AddOnStaticPod.Region)
+	}
+	if cfg.AddOnStaticPod.Nodes != 2 {
+		t.Fatalf("unexpected cfg.AddOnStaticPod.Nodes %v", cfg.AddOnStaticPod.Nodes)
+	}
+	if cfg.AddOnStaticPod.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.AddOnStaticPod.Namespace %v", cfg.AddOnStaticPod.Namespace)
+	}
+	if cfg.AddOnStaticPod.StaticPodPath != "/etc/kubernetes/manifests" {
+		t.Fatalf("unexpected cfg.AddOnStaticPod.StaticPodPath %v", cfg.AddOnStaticPod.StaticPodPath)
+	}
+	if cfg.AddOnStaticPod.Timeout != 3*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnStaticPod.Timeout %v", cfg.AddOnStaticPod.Timeout)
+	}
+}
+
+func TestEnvIAMPreflight(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_IAM_PREFLIGHT_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_IAM_PREFLIGHT_ENABLE")
+	os.Setenv("K8S_TESTER_IAM_PREFLIGHT_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_IAM_PREFLIGHT_REGION")
+	os.Setenv("K8S_TESTER_IAM_PREFLIGHT_POLICY_PATH", "/tmp/policy.json")
+	defer os.Unsetenv("K8S_TESTER_IAM_PREFLIGHT_POLICY_PATH")
+	os.Setenv("K8S_TESTER_IAM_PREFLIGHT_FAIL_ON_MISSING", "false")
+	defer os.Unsetenv("K8S_TESTER_IAM_PREFLIGHT_FAIL_ON_MISSING")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.IAMPreflight.Enable {
+		t.Fatalf("unexpected cfg.IAMPreflight.Enable %v", cfg.IAMPreflight.Enable)
+	}
+	if cfg.IAMPreflight.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.IAMPreflight.Region %v", cfg.IAMPreflight.Region)
+	}
+	if cfg.IAMPreflight.PolicyPath != "/tmp/policy.json" {
+		t.Fatalf("unexpected cfg.IAMPreflight.PolicyPath %v", cfg.IAMPreflight.PolicyPath)
+	}
+	if cfg.IAMPreflight.FailOnMissing {
+		t.Fatalf("unexpected cfg.IAMPreflight.FailOnMissing %v", cfg.IAMPreflight.FailOnMissing)
+	}
+	if err := cfg.IAMPreflight.ValidateAndSetDefaults("/tmp/config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.IAMPreflight.PolicyPath != "/tmp/policy.json" {
+		t.Fatalf("unexpected cfg.IAMPreflight.PolicyPath %v", cfg.IAMPreflight.PolicyPath)
+	}
+	cfg.IAMPreflight.PolicyPath = ""
+	if err := cfg.IAMPreflight.ValidateAndSetDefaults("/tmp/config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.IAMPreflight.PolicyPath != "/tmp/config.iam-policy.json" {
+		t.Fatalf("unexpected cfg.IAMPreflight.PolicyPath %v", cfg.IAMPreflight.PolicyPath)
+	}
+	cfg.IAMPreflight.Region = ""
+	if err := cfg.IAMPreflight.ValidateAndSetDefaults("/tmp/config.yaml"); err == nil {
+		t.Fatal("expected empty Region error")
+	}
+
+	os.Setenv("K8S_TESTER_IAM_PREFLIGHT_MISSING_ACTIONS", "a,b")
+	defer os.Unsetenv("K8S_TESTER_IAM_PREFLIGHT_MISSING_ACTIONS")
+	if err := cfg.UpdateFromEnvs(); err == nil {
+		t.Fatal("expected read-only error")
+	}
+}
+
+func TestEnvCloudWatchMetrics(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_CLOUDWATCH_METRICS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_CLOUDWATCH_METRICS_ENABLE")
+	os.Setenv("K8S_TESTER_CLOUDWATCH_METRICS_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_CLOUDWATCH_METRICS_REGION")
+	os.Setenv("K8S_TESTER_CLOUDWATCH_METRICS_NAMESPACE", "hello")
+	defer os.Unsetenv("K8S_TESTER_CLOUDWATCH_METRICS_NAMESPACE")
+	os.Setenv("K8S_TESTER_CLOUDWATCH_METRICS_DIMENSIONS", `{"Environment":"nightly"}`)
+	defer os.Unsetenv("K8S_TESTER_CLOUDWATCH_METRICS_DIMENSIONS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.CloudWatchMetrics.Enable {
+		t.Fatalf("unexpected cfg.CloudWatchMetrics.Enable %v", cfg.CloudWatchMetrics.Enable)
+	}
+	if cfg.CloudWatchMetrics.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.CloudWatchMetrics.Region %v", cfg.CloudWatchMetrics.Region)
+	}
+	if cfg.CloudWatchMetrics.Namespace != "hello" {
+		t.Fatalf("unexpected cfg.CloudWatchMetrics.Namespace %v", cfg.CloudWatchMetrics.Namespace)
+	}
+	if cfg.CloudWatchMetrics.Dimensions["Environment"] != "nightly" {
+		t.Fatalf("unexpected cfg.CloudWatchMetrics.Dimensions %v", cfg.CloudWatchMetrics.Dimensions)
+	}
+
+	if err := cfg.CloudWatchMetrics.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CloudWatchMetrics.Partition != "aws" {
+		t.Fatalf("unexpected cfg.CloudWatchMetrics.Partition %v", cfg.CloudWatchMetrics.Partition)
+	}
+	cfg.CloudWatchMetrics.Region = ""
+	if err := cfg.CloudWatchMetrics.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected empty Region error")
+	}
+}
+
+func TestEnvOpenSearchResults(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_OPENSEARCH_RESULTS_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_OPENSEARCH_RESULTS_ENABLE")
+	os.Setenv("K8S_TESTER_OPENSEARCH_RESULTS_ENDPOINT", "https://search.example.com/")
+	defer os.Unsetenv("K8S_TESTER_OPENSEARCH_RESULTS_ENDPOINT")
+	os.Setenv("K8S_TESTER_OPENSEARCH_RESULTS_INDEX_PREFIX", "nightly")
+	defer os.Unsetenv("K8S_TESTER_OPENSEARCH_RESULTS_INDEX_PREFIX")
+	os.Setenv("K8S_TESTER_OPENSEARCH_RESULTS_SIGV4", "true")
+	defer os.Unsetenv("K8S_TESTER_OPENSEARCH_RESULTS_SIGV4")
+	os.Setenv("K8S_TESTER_OPENSEARCH_RESULTS_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_OPENSEARCH_RESULTS_REGION")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.OpenSearchResults.Enable {
+		t.Fatalf("unexpected cfg.OpenSearchResults.Enable %v", cfg.OpenSearchResults.Enable)
+	}
+	if cfg.OpenSearchResults.IndexPrefix != "nightly" {
+		t.Fatalf("unexpected cfg.OpenSearchResults.IndexPrefix %v", cfg.OpenSearchResults.IndexPrefix)
+	}
+	if !cfg.OpenSearchResults.SigV4 {
+		t.Fatalf("unexpected cfg.OpenSearchResults.SigV4 %v", cfg.OpenSearchResults.SigV4)
+	}
+	if err := cfg.OpenSearchResults.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.OpenSearchResults.Endpoint != "https://search.example.com" {
+		t.Fatalf("unexpected cfg.OpenSearchResults.Endpoint %v", cfg.OpenSearchResults.Endpoint)
+	}
+}
+
+func TestEnvStatusReporter(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_STATUS_REPORTER_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_STATUS_REPORTER_ENABLE")
+	os.Setenv("K8S_TESTER_STATUS_REPORTER_GITHUB_REPOSITORY", "aws/aws-k8s-tester")
+	defer os.Unsetenv("K8S_TESTER_STATUS_REPORTER_GITHUB_REPOSITORY")
+	os.Setenv("K8S_TESTER_STATUS_REPORTER_GITHUB_COMMIT_SHA", "abc123")
+	defer os.Unsetenv("K8S_TESTER_STATUS_REPORTER_GITHUB_COMMIT_SHA")
+	os.Setenv("K8S_TESTER_STATUS_REPORTER_GITHUB_CHECK_RUN", "true")
+	defer os.Unsetenv("K8S_TESTER_STATUS_REPORTER_GITHUB_CHECK_RUN")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.StatusReporter.Enable {
+		t.Fatalf("unexpected cfg.StatusReporter.Enable %v", cfg.StatusReporter.Enable)
+	}
+	if cfg.StatusReporter.GitHubRepository != "aws/aws-k8s-tester" {
+		t.Fatalf("unexpected cfg.StatusReporter.GitHubRepository %v", cfg.StatusReporter.GitHubRepository)
+	}
+	if cfg.StatusReporter.GitHubCommitSHA != "abc123" {
+		t.Fatalf("unexpected cfg.StatusReporter.GitHubCommitSHA %v", cfg.StatusReporter.GitHubCommitSHA)
+	}
+	if !cfg.StatusReporter.GitHubCheckRun {
+		t.Fatalf("unexpected cfg.StatusReporter.GitHubCheckRun %v", cfg.StatusReporter.GitHubCheckRun)
+	}
+	if err := cfg.StatusReporter.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvArtifactsS3(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_ENABLE")
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_REGION", "us-west-2")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_REGION")
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_BUCKET_NAME", "hello-bucket")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_BUCKET_NAME")
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_PREFIX", "/nightly/")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_PREFIX")
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_KMS_KEY_ID", "hello-key")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_KMS_KEY_ID")
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_BUCKET_CREATE", "true")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_BUCKET_CREATE")
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_BUCKET_LIFECYCLE_EXPIRATION_DAYS", "1")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_BUCKET_LIFECYCLE_EXPIRATION_DAYS")
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_COMPONENT_LAYOUT", "true")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_COMPONENT_LAYOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.ArtifactsS3.Enable {
+		t.Fatalf("unexpected cfg.ArtifactsS3.Enable %v", cfg.ArtifactsS3.Enable)
+	}
+	if cfg.ArtifactsS3.Region != "us-west-2" {
+		t.Fatalf("unexpected cfg.ArtifactsS3.Region %v", cfg.ArtifactsS3.Region)
+	}
+	if cfg.ArtifactsS3.BucketName != "hello-bucket" {
+		t.Fatalf("unexpected cfg.ArtifactsS3.BucketName %v", cfg.ArtifactsS3.BucketName)
+	}
+	if cfg.ArtifactsS3.KMSKeyID != "hello-key" {
+		t.Fatalf("unexpected cfg.ArtifactsS3.KMSKeyID %v", cfg.ArtifactsS3.KMSKeyID)
+	}
+	if err := cfg.ArtifactsS3.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ArtifactsS3.Prefix != "nightly" {
+		t.Fatalf("unexpected cfg.ArtifactsS3.Prefix %v", cfg.ArtifactsS3.Prefix)
+	}
+	if !cfg.ArtifactsS3.BucketCreate {
+		t.Fatalf("unexpected cfg.ArtifactsS3.BucketCreate %v", cfg.ArtifactsS3.BucketCreate)
+	}
+	if cfg.ArtifactsS3.BucketLifecycleExpirationDays != 3 {
+		t.Fatalf("unexpected cfg.ArtifactsS3.BucketLifecycleExpirationDays %v", cfg.ArtifactsS3.BucketLifecycleExpirationDays)
+	}
+	if !cfg.ArtifactsS3.ComponentLayout {
+		t.Fatalf("unexpected cfg.ArtifactsS3.ComponentLayout %v", cfg.ArtifactsS3.ComponentLayout)
+	}
+
+	os.Setenv("K8S_TESTER_ARTIFACTS_S3_UPLOADED_KEYS", "a,b")
+	defer os.Unsetenv("K8S_TESTER_ARTIFACTS_S3_UPLOADED_KEYS")
+	if err := cfg.UpdateFromEnvs(); err == nil {
+		t.Fatal("expected read-only error")
+	}
+}
+
+func TestEnvSteadyState(t *testing.T) {
+	cfg := NewDefault()
+
+	os.Setenv("K8S_TESTER_STEADY_STATE_ENABLE", "true")
+	defer os.Unsetenv("K8S_TESTER_STEADY_STATE_ENABLE")
+	os.Setenv("K8S_TESTER_STEADY_STATE_INTERVAL", "30s")
+	defer os.Unsetenv("K8S_TESTER_STEADY_STATE_INTERVAL")
+	os.Setenv("K8S_TESTER_STEADY_STATE_TIMEOUT", "5m")
+	defer os.Unsetenv("K8S_TESTER_STEADY_STATE_TIMEOUT")
+	os.Setenv("K8S_TESTER_STEADY_STATE_MAX_POD_CHURN", "10")
+	defer os.Unsetenv("K8S_TESTER_STEADY_STATE_MAX_POD_CHURN")
+	os.Setenv("K8S_TESTER_STEADY_STATE_MAX_PENDING_PODS", "3")
+	defer os.Unsetenv("K8S_TESTER_STEADY_STATE_MAX_PENDING_PODS")
+	os.Setenv("K8S_TESTER_STEADY_STATE_MAX_API_ERROR_RATE", "0.05")
+	defer os.Unsetenv("K8S_TESTER_STEADY_STATE_MAX_API_ERROR_RATE")
+	os.Setenv("K8S_TESTER_STEADY_STATE_FAIL_ON_TIMEOUT", "true")
+	defer os.Unsetenv("K8S_TESTER_STEADY_STATE_FAIL_ON_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.SteadyState.Enable {
+		t.Fatalf("unexpected cfg.SteadyState.Enable %v", cfg.SteadyState.Enable)
+	}
+	if cfg.SteadyState.Interval != 30*time.Second {
+		t.Fatalf("unexpected cfg.SteadyState.Interval %v", cfg.SteadyState.Interval)
+	}
+	if cfg.SteadyState.Timeout != 5*time.Minute {
+		t.Fatalf("unexpected cfg.SteadyState.Timeout %v", cfg.SteadyState.Timeout)
+	}
+	if cfg.SteadyState.MaxPodChurn != 10 {
+		t.Fatalf("unexpected cfg.SteadyState.MaxPodChurn %v", cfg.SteadyState.MaxPodChurn)
+	}
+	if cfg.SteadyState.MaxPendingPods != 3 {
+		t.Fatalf("unexpected cfg.SteadyState.MaxPendingPods %v", cfg.SteadyState.MaxPendingPods)
+	}
+	if cfg.SteadyState.MaxAPIErrorRate != 0.05 {
+		t.Fatalf("unexpected cfg.SteadyState.MaxAPIErrorRate %v", cfg.SteadyState.MaxAPIErrorRate)
+	}
+	if !cfg.SteadyState.FailOnTimeout {
+		t.Fatalf("unexpected cfg.SteadyState.FailOnTimeout %v", cfg.SteadyState.FailOnTimeout)
+	}
+	if err := cfg.SteadyState.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SteadyState.StableSamples != 2 {
+		t.Fatalf("unexpected cfg.SteadyState.StableSamples %v", cfg.SteadyState.StableSamples)
+	}
 }
@@ -0,0 +1,216 @@
+package conformance
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	specPassed  = "passed"
+	specFailed  = "failed"
+	specSkipped = "skipped"
+)
+
+// BaselineDiff is the conformance results relative to a baseline run.
+type BaselineDiff struct {
+	// Baseline is the baseline junit XML path or S3 URL.
+	Baseline string `json:"baseline"`
+	// NewlyFailing is the list of specs that fail in this run,
+	// but passed, were skipped, or did not run in the baseline.
+	NewlyFailing []string `json:"newly_failing"`
+	// NewlyPassing is the list of specs that pass in this run,
+	// but failed in the baseline.
+	NewlyPassing []string `json:"newly_passing"`
+	// Missing is the list of specs that ran in the baseline, but not in this run.
+	Missing []string `json:"missing"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Skipped   *struct{}     `xml:"skipped"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+}
+
+// readJUnitResults returns the spec status keyed by the spec name,
+// from the junit XML file with either "testsuites" or "testsuite" root element.
+func readJUnitResults(p string) (map[string]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rs := make(map[string]string)
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse junit XML %q (%v)", p, err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "testcase" {
+			continue
+		}
+		var tc junitTestCase
+		if err = dec.DecodeElement(&tc, &se); err != nil {
+			return nil, fmt.Errorf("failed to parse junit XML %q (%v)", p, err)
+		}
+		name := strings.TrimSpace(tc.Name)
+		if name == "" {
+			continue
+		}
+		switch {
+		case tc.Failure != nil, tc.Error != nil:
+			rs[name] = specFailed
+		case tc.Skipped != nil:
+			// do not overwrite, the same spec may be skipped in one suite but run in another
+			if _, ok := rs[name]; !ok {
+				rs[name] = specSkipped
+			}
+		default:
+			if rs[name] != specFailed {
+				rs[name] = specPassed
+			}
+		}
+	}
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("no testcase found in junit XML %q", p)
+	}
+	return rs, nil
+}
+
+// diffResults compares the current spec results against the baseline.
+func diffResults(baseline map[string]string, current map[string]string) (diff BaselineDiff) {
+	diff.NewlyFailing, diff.NewlyPassing, diff.Missing = make([]string, 0), make([]string, 0), make([]string, 0)
+	for name, cur := range current {
+		prev := baseline[name]
+		switch {
+		case cur == specFailed && prev != specFailed:
+			diff.NewlyFailing = append(diff.NewlyFailing, name)
+		case cur == specPassed && prev == specFailed:
+			diff.NewlyPassing = append(diff.NewlyPassing, name)
+		}
+	}
+	for name, prev := range baseline {
+		if _, ok := current[name]; !ok && prev != specSkipped {
+			diff.Missing = append(diff.Missing, name)
+		}
+	}
+	sort.Strings(diff.NewlyFailing)
+	sort.Strings(diff.NewlyPassing)
+	sort.Strings(diff.Missing)
+	return diff
+}
+
+// fetchBaseline returns the local path of the baseline junit XML,
+// downloading it first if the baseline is an S3 URL.
+func (ts *tester) fetchBaseline() (localPath string, cleanup func(), err error) {
+	cleanup = func() {}
+	if !strings.HasPrefix(ts.cfg.BaselineJUnitXMLPath, "s3://") {
+		return ts.cfg.BaselineJUnitXMLPath, cleanup, nil
+	}
+
+	ss := strings.SplitN(strings.TrimPrefix(ts.cfg.BaselineJUnitXMLPath, "s3://"), "/", 2)
+	if len(ss) != 2 || ss[0] == "" || ss[1] == "" {
+		return "", cleanup, fmt.Errorf("invalid baseline S3 URL %q (expected 's3://bucket/key')", ts.cfg.BaselineJUnitXMLPath)
+	}
+	if ts.cfg.S3API == nil {
+		awsCfg := aws_v1.Config{
+			Logger:        ts.cfg.Logger,
+			DebugAPICalls: ts.cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+			Partition:     ts.cfg.Partition,
+			Region:        ts.cfg.BaselineS3Region,
+		}
+		awsSession, _, _, err := aws_v1.New(&awsCfg)
+		if err != nil {
+			return "", cleanup, fmt.Errorf("failed to create aws session (%v)", err)
+		}
+		ts.cfg.S3API = s3.New(awsSession, aws.NewConfig().WithRegion(ts.cfg.BaselineS3Region))
+	}
+	localPath, err = aws_s3.DownloadToTempFile(ts.cfg.Logger, ts.cfg.S3API, ss[0], ss[1], aws_s3.WithTimeout(3*time.Minute))
+	if err != nil {
+		return "", cleanup, fmt.Errorf("failed to download baseline %q (%v)", ts.cfg.BaselineJUnitXMLPath, err)
+	}
+	return localPath, func() { os.RemoveAll(localPath) }, nil
+}
+
+// compareBaseline diffs the junit XML results of this run against the baseline,
+// and writes the diff to "BaselineDiffPath".
+func (ts *tester) compareBaseline(xmlPath string) error {
+	baselinePath, cleanup, err := ts.fetchBaseline()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	baseline, err := readJUnitResults(baselinePath)
+	if err != nil {
+		return err
+	}
+	current, err := readJUnitResults(xmlPath)
+	if err != nil {
+		return err
+	}
+	diff := diffResults(baseline, current)
+	diff.Baseline = ts.cfg.BaselineJUnitXMLPath
+	ts.cfg.BaselineNewlyFailing = diff.NewlyFailing
+	ts.cfg.BaselineNewlyPassing = diff.NewlyPassing
+	if ts.cfg.BaselineDiffPath == "" {
+		ts.cfg.BaselineDiffPath = strings.TrimSuffix(xmlPath, ".xml") + ".baseline-diff.json"
+	}
+
+	b, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(ts.cfg.BaselineDiffPath, b, 0600); err != nil {
+		return fmt.Errorf("failed to write baseline diff %q (%v)", ts.cfg.BaselineDiffPath, err)
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nconformance results against baseline %q (%d newly failing, %d newly passing, %d missing):\n\n", diff.Baseline, len(diff.NewlyFailing), len(diff.NewlyPassing), len(diff.Missing))
+	for _, name := range diff.NewlyFailing {
+		fmt.Fprintf(ts.cfg.LogWriter, "  NEWLY FAILING  %s\n", name)
+	}
+	for _, name := range diff.NewlyPassing {
+		fmt.Fprintf(ts.cfg.LogWriter, "  NEWLY PASSING  %s\n", name)
+	}
+	for _, name := range diff.Missing {
+		fmt.Fprintf(ts.cfg.LogWriter, "  MISSING        %s\n", name)
+	}
+	fmt.Fprintln(ts.cfg.LogWriter)
+
+	ts.cfg.Logger.Info("compared conformance results against baseline",
+		zap.String("baseline", diff.Baseline),
+		zap.Int("newly-failing", len(diff.NewlyFailing)),
+		zap.Int("newly-passing", len(diff.NewlyPassing)),
+		zap.Int("missing", len(diff.Missing)),
+		zap.String("diff-path", ts.cfg.BaselineDiffPath),
+	)
+	if ts.cfg.BaselineFailOnNewlyFailing && len(diff.NewlyFailing) > 0 {
+		return fmt.Errorf("%d spec(s) newly failing against baseline %q (see %q)", len(diff.NewlyFailing), diff.Baseline, ts.cfg.BaselineDiffPath)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package conformance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	baselinePath := filepath.Join(dir, "baseline.xml")
+	if err = ioutil.WriteFile(baselinePath, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="Kubernetes e2e suite" tests="5" failures="1">
+    <testcase name="[sig-network] DNS should provide DNS for services" classname="Kubernetes e2e suite" time="10"></testcase>
+    <testcase name="[sig-storage] Projected secret should be consumable" classname="Kubernetes e2e suite" time="10">
+      <failure type="Failure">timed out</failure>
+    </testcase>
+    <testcase name="[sig-apps] Deployment should run the lifecycle" classname="Kubernetes e2e suite" time="10"></testcase>
+    <testcase name="[sig-node] Pods should be removed" classname="Kubernetes e2e suite" time="10"></testcase>
+    <testcase name="[sig-cli] Kubectl logs" classname="Kubernetes e2e suite" time="0"><skipped></skipped></testcase>
+  </testsuite>
+</testsuites>
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	currentPath := filepath.Join(dir, "current.xml")
+	if err = ioutil.WriteFile(currentPath, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="Kubernetes e2e suite" tests="4" failures="1">
+  <testcase name="[sig-network] DNS should provide DNS for services" classname="Kubernetes e2e suite" time="10">
+    <failure type="Failure">lookup failed</failure>
+  </testcase>
+  <testcase name="[sig-storage] Projected secret should be consumable" classname="Kubernetes e2e suite" time="10"></testcase>
+  <testcase name="[sig-apps] Deployment should run the lifecycle" classname="Kubernetes e2e suite" time="10"></testcase>
+  <testcase name="[sig-cli] Kubectl logs" classname="Kubernetes e2e suite" time="0"><skipped></skipped></testcase>
+</testsuite>
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	baseline, err := readJUnitResults(baselinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := readJUnitResults(currentPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := diffResults(baseline, current)
+	if !reflect.DeepEqual(diff.NewlyFailing, []string{"[sig-network] DNS should provide DNS for services"}) {
+		t.Fatalf("unexpected newly failing %v", diff.NewlyFailing)
+	}
+	if !reflect.DeepEqual(diff.NewlyPassing, []string{"[sig-storage] Projected secret should be consumable"}) {
+		t.Fatalf("unexpected newly passing %v", diff.NewlyPassing)
+	}
+	if !reflect.DeepEqual(diff.Missing, []string{"[sig-node] Pods should be removed"}) {
+		t.Fatalf("unexpected missing %v", diff.Missing)
+	}
+}
@@ -70,10 +70,17 @@ var (
 	sonobuoyRunE2ERepoConfig        string
 	sonobuoyRunImage                string
 	sonobuoyRunSystemdLogsImage     string
+	sonobuoyPlugins                 []string
+	flakyRetries                    int
+	flakyResultsPath                string
 	sonobuoyResultsTarGzPath        string
 	sonobuoyResultsE2ELogPath       string
 	sonobuoyResultsJunitXMLPath     string
 	sonobuoyResultsOutputDir        string
+	baselineJUnitXMLPath            string
+	baselineS3Region                string
+	baselineDiffPath                string
+	baselineFailOnNewlyFailing      bool
 )
 
 func newApply() *cobra.Command {
@@ -94,10 +101,17 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&sonobuoyRunE2ERepoConfig, "sonobuoy-run-e2e-repo-config", "", "sonobuoy run e2e repo config")
 	cmd.PersistentFlags().StringVar(&sonobuoyRunImage, "sonobuoy-run-image", "", "sonobuoy run image")
 	cmd.PersistentFlags().StringVar(&sonobuoyRunSystemdLogsImage, "sonobuoy-run-systemd-logs-image", "", "sonobuoy run systemd logs image")
+	cmd.PersistentFlags().StringSliceVar(&sonobuoyPlugins, "sonobuoy-plugins", conformance.DefaultSonobuoyPlugins, "sonobuoy plugins to run, built-in plugin names ('e2e', 'systemd-logs') or custom plugin YAML file paths")
+	cmd.PersistentFlags().IntVar(&flakyRetries, "flaky-retries", 0, "number of times to rerun only the failed e2e specs, 0 to disable")
+	cmd.PersistentFlags().StringVar(&flakyResultsPath, "flaky-results-path", "", "file path to write the flaky retry results")
 	cmd.PersistentFlags().StringVar(&sonobuoyResultsTarGzPath, "sonobuoy-results-tar-gz-path", "", "sonobuoy results tar.gz path")
 	cmd.PersistentFlags().StringVar(&sonobuoyResultsE2ELogPath, "sonobuoy-results-e2e-log-path", "", "sonobuoy e2e log path")
 	cmd.PersistentFlags().StringVar(&sonobuoyResultsJunitXMLPath, "sonobuoy-results-junit-xml-path", "", "sonobuoy results Junit XML path")
 	cmd.PersistentFlags().StringVar(&sonobuoyResultsOutputDir, "sonobuoy-results-output-dir", "", "sonobuoy results output dir")
+	cmd.PersistentFlags().StringVar(&baselineJUnitXMLPath, "baseline-junit-xml-path", "", "baseline junit XML path or S3 URL (s3://bucket/key) to compare the results against")
+	cmd.PersistentFlags().StringVar(&baselineS3Region, "baseline-s3-region", "", "S3 bucket region for the baseline S3 URL")
+	cmd.PersistentFlags().StringVar(&baselineDiffPath, "baseline-diff-path", "", "file path to write the baseline comparison")
+	cmd.PersistentFlags().BoolVar(&baselineFailOnNewlyFailing, "baseline-fail-on-newly-failing", false, "'true' to fail when any spec newly fails against the baseline")
 
 	return cmd
 }
@@ -138,10 +152,19 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		SonobuoyRunE2ERepoConfig:        sonobuoyRunE2ERepoConfig,
 		SonobuoyRunImage:                sonobuoyRunImage,
 		SonobuoyRunSystemdLogsImage:     sonobuoyRunSystemdLogsImage,
+		SonobuoyPlugins:                 sonobuoyPlugins,
+		FlakyRetries:                    flakyRetries,
+		FlakyResultsPath:                flakyResultsPath,
 		SonobuoyResultsTarGzPath:        sonobuoyResultsTarGzPath,
 		SonobuoyResultsE2ELogPath:       sonobuoyResultsE2ELogPath,
 		SonobuoyResultsJunitXMLPath:     sonobuoyResultsJunitXMLPath,
 		SonobuoyResultsOutputDir:        sonobuoyResultsOutputDir,
+
+		Partition:                  conformance.DefaultPartition,
+		BaselineJUnitXMLPath:       baselineJUnitXMLPath,
+		BaselineS3Region:           baselineS3Region,
+		BaselineDiffPath:           baselineDiffPath,
+		BaselineFailOnNewlyFailing: baselineFailOnNewlyFailing,
 	}
 
 	ts := conformance.New(cfg)
@@ -0,0 +1,157 @@
+package conformance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// FlakyResults is the results of the reruns of the failed specs.
+type FlakyResults struct {
+	// Retries is the number of reruns.
+	Retries int `json:"retries"`
+	// Flaky is the list of specs that failed, but passed in a rerun.
+	Flaky []string `json:"flaky"`
+	// Failed is the list of specs that failed in every rerun.
+	Failed []string `json:"failed"`
+}
+
+// failedSpecs returns the sorted list of the failed specs.
+func failedSpecs(results map[string]string) (failed []string) {
+	for name, status := range results {
+		if status == specFailed {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// focusRegex returns the "--e2e-focus" regex that matches only the specs.
+func focusRegex(specs []string) string {
+	ss := make([]string, 0, len(specs))
+	for _, name := range specs {
+		ss = append(ss, regexp.QuoteMeta(name))
+	}
+	return strings.Join(ss, "|")
+}
+
+// mergeRetryResults returns the failed specs that passed in the rerun,
+// and the ones that still failed or did not run.
+func mergeRetryResults(failed []string, rerun map[string]string) (passed []string, stillFailed []string) {
+	for _, name := range failed {
+		if rerun[name] == specPassed {
+			passed = append(passed, name)
+		} else {
+			stillFailed = append(stillFailed, name)
+		}
+	}
+	return passed, stillFailed
+}
+
+// onlyE2EFailed returns true if the e2e plugin failed while the other plugins passed.
+func (ts *tester) onlyE2EFailed() bool {
+	e2eFailed := false
+	for _, rs := range ts.cfg.SonobuoyPluginResults {
+		if rs.Passed() {
+			continue
+		}
+		if rs.Name != PluginE2E {
+			return false
+		}
+		e2eFailed = true
+	}
+	return e2eFailed
+}
+
+// retryFailedSpecs reruns only the failed specs from the junit XML results,
+// up to "FlakyRetries" times, and returns an error if any spec fails in every rerun.
+func (ts *tester) retryFailedSpecs(xmlPath string) error {
+	results, err := readJUnitResults(xmlPath)
+	if err != nil {
+		return err
+	}
+	failed := failedSpecs(results)
+	if len(failed) == 0 {
+		return errors.New("e2e plugin failed without any failed spec in the results")
+	}
+
+	rs := FlakyResults{Flaky: make([]string, 0)}
+	for rs.Retries < ts.cfg.FlakyRetries && len(failed) > 0 {
+		rs.Retries++
+		ts.cfg.Logger.Info("rerunning failed specs",
+			zap.Int("retry", rs.Retries),
+			zap.Int("retries", ts.cfg.FlakyRetries),
+			zap.Strings("specs", failed),
+		)
+		if err = ts.deleteSonobuoy(); err != nil {
+			return err
+		}
+		if err = ts.runSonobuoy([]string{PluginE2E}, focusRegex(failed)); err != nil {
+			return err
+		}
+		if err = ts.checkSonobuoy(); err != nil {
+			return err
+		}
+
+		tarGzPath := strings.TrimSuffix(ts.cfg.SonobuoyResultsTarGzPath, ".tar.gz") + fmt.Sprintf(".retry-%d.tar.gz", rs.Retries)
+		stopped, err := ts.retrieveResults(tarGzPath)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			return errors.New("flaky retry stopped")
+		}
+		_, retryXMLPath, err := untarResults(ts.cfg.Logger, tarGzPath, fmt.Sprintf("%s-retry-%d", ts.cfg.SonobuoyResultsOutputDir, rs.Retries), true)
+		if err != nil {
+			return err
+		}
+		rerun, err := readJUnitResults(retryXMLPath)
+		if err != nil {
+			return err
+		}
+
+		var passed []string
+		passed, failed = mergeRetryResults(failed, rerun)
+		rs.Flaky = append(rs.Flaky, passed...)
+		ts.cfg.Logger.Info("reran failed specs",
+			zap.Int("retry", rs.Retries),
+			zap.Strings("passed", passed),
+			zap.Strings("failed", failed),
+		)
+	}
+	sort.Strings(rs.Flaky)
+	rs.Failed = failed
+	if rs.Failed == nil {
+		rs.Failed = make([]string, 0)
+	}
+	ts.cfg.FlakySpecs, ts.cfg.FlakyFailedSpecs = rs.Flaky, rs.Failed
+
+	b, err := json.MarshalIndent(rs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(ts.cfg.FlakyResultsPath, b, 0600); err != nil {
+		return fmt.Errorf("failed to write flaky results %q (%v)", ts.cfg.FlakyResultsPath, err)
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nconformance flaky retry results (%d retries, %d flaky, %d failed):\n\n", rs.Retries, len(rs.Flaky), len(rs.Failed))
+	for _, name := range rs.Flaky {
+		fmt.Fprintf(ts.cfg.LogWriter, "  FLAKY   %s\n", name)
+	}
+	for _, name := range rs.Failed {
+		fmt.Fprintf(ts.cfg.LogWriter, "  FAILED  %s\n", name)
+	}
+	fmt.Fprintln(ts.cfg.LogWriter)
+
+	if len(rs.Failed) > 0 {
+		return fmt.Errorf("%d spec(s) failed after %d retries (see %q)", len(rs.Failed), rs.Retries, ts.cfg.FlakyResultsPath)
+	}
+	return nil
+}
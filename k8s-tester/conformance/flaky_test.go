@@ -0,0 +1,64 @@
+package conformance
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestFlakyRetry(t *testing.T) {
+	results := map[string]string{
+		"[sig-network] DNS should provide DNS for services": specFailed,
+		"[sig-storage] Projected secret (1.2) should work":  specFailed,
+		"[sig-apps] Deployment should run the lifecycle":    specPassed,
+		"[sig-cli] Kubectl logs":                            specSkipped,
+	}
+	failed := failedSpecs(results)
+	expected := []string{
+		"[sig-network] DNS should provide DNS for services",
+		"[sig-storage] Projected secret (1.2) should work",
+	}
+	if !reflect.DeepEqual(failed, expected) {
+		t.Fatalf("expected %v, got %v", expected, failed)
+	}
+
+	re := regexp.MustCompile(focusRegex(failed))
+	for _, name := range failed {
+		if !re.MatchString(name) {
+			t.Fatalf("expected focus to match %q", name)
+		}
+	}
+	for _, name := range []string{"[sig-apps] Deployment should run the lifecycle", "[sig-storage] Projected secret (112) should work"} {
+		if re.MatchString(name) {
+			t.Fatalf("unexpected focus match %q", name)
+		}
+	}
+
+	passed, stillFailed := mergeRetryResults(failed, map[string]string{
+		"[sig-network] DNS should provide DNS for services": specPassed,
+	})
+	if !reflect.DeepEqual(passed, []string{"[sig-network] DNS should provide DNS for services"}) {
+		t.Fatalf("unexpected passed %v", passed)
+	}
+	if !reflect.DeepEqual(stillFailed, []string{"[sig-storage] Projected secret (1.2) should work"}) {
+		t.Fatalf("unexpected still failed %v", stillFailed)
+	}
+}
+
+func TestOnlyE2EFailed(t *testing.T) {
+	tests := []struct {
+		results  []PluginResult
+		expected bool
+	}{
+		{[]PluginResult{{Name: PluginE2E, Status: "failed"}, {Name: PluginSystemdLogs, Status: "passed"}}, true},
+		{[]PluginResult{{Name: PluginE2E, Status: "failed"}, {Name: PluginSystemdLogs, Status: "failed"}}, false},
+		{[]PluginResult{{Name: PluginE2E, Status: "passed"}}, false},
+		{nil, false},
+	}
+	for i, tt := range tests {
+		ts := &tester{cfg: &Config{SonobuoyPluginResults: tt.results}}
+		if v := ts.onlyE2EFailed(); v != tt.expected {
+			t.Fatalf("#%d: expected %v, got %v", i, tt.expected, v)
+		}
+	}
+}
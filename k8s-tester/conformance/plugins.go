@@ -0,0 +1,83 @@
+package conformance
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Built-in sonobuoy plugins.
+// ref. https://sonobuoy.io/docs/main/plugins/
+const (
+	PluginE2E         = "e2e"
+	PluginSystemdLogs = "systemd-logs"
+)
+
+// DefaultSonobuoyPlugins is the list of plugins that "sonobuoy run" runs by default.
+var DefaultSonobuoyPlugins = []string{PluginE2E, PluginSystemdLogs}
+
+// PluginResult is the result of a sonobuoy plugin.
+type PluginResult struct {
+	// Name is the plugin name.
+	Name string `json:"name"`
+	// Status is the aggregated plugin status (e.g., "passed", "failed").
+	Status string `json:"status"`
+	// ResultsDir is the plugin results directory after untar.
+	ResultsDir string `json:"results_dir"`
+}
+
+// Passed returns true if the plugin has passed.
+// Plugins with the "raw" result format report "complete" instead of "passed".
+func (r PluginResult) Passed() bool {
+	return r.Status == "passed" || r.Status == "complete"
+}
+
+// isBuiltinPlugin returns true if the plugin is run by its name, not by a definition file.
+func isBuiltinPlugin(plugin string) bool {
+	return plugin == PluginE2E || plugin == PluginSystemdLogs
+}
+
+type pluginDefinition struct {
+	SonobuoyConfig struct {
+		PluginName string `json:"plugin-name"`
+	} `json:"sonobuoy-config"`
+}
+
+// pluginName returns the name of the built-in plugin, or reads the
+// "sonobuoy-config.plugin-name" from the custom plugin definition YAML file.
+func pluginName(plugin string) (string, error) {
+	if isBuiltinPlugin(plugin) {
+		return plugin, nil
+	}
+	d, err := ioutil.ReadFile(plugin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read plugin %q (%v)", plugin, err)
+	}
+	var def pluginDefinition
+	if err = yaml.Unmarshal(d, &def); err != nil {
+		return "", fmt.Errorf("failed to parse plugin %q (%v)", plugin, err)
+	}
+	if def.SonobuoyConfig.PluginName == "" {
+		return "", fmt.Errorf("plugin %q missing 'sonobuoy-config.plugin-name'", plugin)
+	}
+	return def.SonobuoyConfig.PluginName, nil
+}
+
+// parsePluginStatus parses the plugin status from the "sonobuoy results --plugin" output.
+//
+//	Plugin: e2e
+//	Status: passed
+//	Total: 5771
+func parsePluginStatus(out string) string {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Status:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Status:"))
+		}
+	}
+	return ""
+}
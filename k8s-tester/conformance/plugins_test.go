@@ -0,0 +1,68 @@
+package conformance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPluginName(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "custom.yaml")
+	if err = ioutil.WriteFile(p, []byte(`sonobuoy-config:
+  driver: Job
+  plugin-name: custom-check
+  result-format: raw
+spec:
+  name: plugin
+  image: busybox
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for plugin, expected := range map[string]string{
+		PluginE2E:         PluginE2E,
+		PluginSystemdLogs: PluginSystemdLogs,
+		p:                 "custom-check",
+	} {
+		name, err := pluginName(plugin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != expected {
+			t.Fatalf("expected %q, got %q", expected, name)
+		}
+	}
+
+	if _, err = pluginName(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("expected error for missing plugin file")
+	}
+}
+
+func TestParsePluginStatus(t *testing.T) {
+	tests := []struct {
+		out    string
+		status string
+		passed bool
+	}{
+		{"Plugin: e2e\nStatus: passed\nTotal: 5771\nPassed: 311\n", "passed", true},
+		{"Plugin: e2e\nStatus: failed\nTotal: 5771\nFailed: 1\n", "failed", false},
+		{"Plugin: custom-check\nStatus: complete\n", "complete", true},
+		{"error: plugin not found", "", false},
+	}
+	for i, tt := range tests {
+		status := parsePluginStatus(tt.out)
+		if status != tt.status {
+			t.Fatalf("#%d: expected %q, got %q", i, tt.status, status)
+		}
+		if passed := (PluginResult{Status: status}).Passed(); passed != tt.passed {
+			t.Fatalf("#%d: expected passed %v, got %v", i, tt.passed, passed)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package conformance
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the "sonobuoy run" command, since sonobuoy
+// generates the manifests of the plugins when it runs.
+func (ts *tester) Render(w io.Writer) error {
+	cfg := *ts.cfg
+	cfg.Client = fake.NewClient()
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.resolvePlugins(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "# %s\n", strings.Join(rt.sonobuoyRunArgs(cfg.SonobuoyPlugins, cfg.SonobuoyRunE2EFocus), " "))
+	return err
+}
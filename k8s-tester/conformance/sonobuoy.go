@@ -2,7 +2,6 @@ package conformance
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -118,33 +117,45 @@ func installSonobuoy(lg *zap.Logger, sonobuoyPath string, sonobuoyDownloadURL st
 	return nil
 }
 
-func readResults(lg *zap.Logger, logWriter io.Writer, sonobuoyPath string, tarGzPath string) error {
+// readResults reads the status of each plugin from the sonobuoy results,
+// and returns an error if any plugin has not passed.
+func readResults(lg *zap.Logger, logWriter io.Writer, sonobuoyPath string, tarGzPath string, plugins []string) (results []PluginResult, err error) {
 	if !file.Exist(tarGzPath) {
-		return fmt.Errorf("AddOnConformance.SonobuoyResultTarGzPath does not exist [%q]", tarGzPath)
+		return nil, fmt.Errorf("AddOnConformance.SonobuoyResultTarGzPath does not exist [%q]", tarGzPath)
 	}
 
-	args := []string{sonobuoyPath, "results", tarGzPath}
-	cmd := strings.Join(args, " ")
+	var failed []string
+	for _, plugin := range plugins {
+		args := []string{sonobuoyPath, "results", tarGzPath, "--plugin=" + plugin}
+		cmd := strings.Join(args, " ")
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	output, err := exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
-	cancel()
-	out := strings.TrimSpace(string(output))
-	if err != nil {
-		lg.Warn("failed to run sonobuoy results", zap.String("command", cmd), zap.Error(err))
-		return err
-	}
-	fmt.Fprintf(logWriter, "\n'%s' output:\n\n%s\n\n", cmd, out)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		output, err := exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		cancel()
+		out := strings.TrimSpace(string(output))
+		if err != nil {
+			lg.Warn("failed to run sonobuoy results", zap.String("command", cmd), zap.Error(err))
+			return nil, err
+		}
+		fmt.Fprintf(logWriter, "\n'%s' output:\n\n%s\n\n", cmd, out)
 
-	if !strings.Contains(out, "Plugin: e2e\nStatus: passed") {
-		return errors.New("sonobuoy tests failed (expected 'Status: passed')")
+		rs := PluginResult{Name: plugin, Status: parsePluginStatus(out)}
+		results = append(results, rs)
+		if !rs.Passed() {
+			failed = append(failed, fmt.Sprintf("%s (status %q)", plugin, rs.Status))
+		}
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("sonobuoy plugins failed: %s", strings.Join(failed, ", "))
 	}
 
-	lg.Info("sonobuoy results passed", zap.String("path", tarGzPath))
-	return nil
+	lg.Info("sonobuoy results passed", zap.String("path", tarGzPath), zap.Strings("plugins", plugins))
+	return results, nil
 }
 
-func untarResults(lg *zap.Logger, tarGzPath string, outputDir string) (logPath string, xmlPath string, err error) {
+// untarResults untars the sonobuoy results, and returns the e2e log and junit XML paths
+// if the e2e plugin has run.
+func untarResults(lg *zap.Logger, tarGzPath string, outputDir string, e2e bool) (logPath string, xmlPath string, err error) {
 	if !file.Exist(tarGzPath) {
 		return "", "", fmt.Errorf("sonobuoy result tar.gz file does not exist [%q]", tarGzPath)
 	}
@@ -154,12 +165,15 @@ func untarResults(lg *zap.Logger, tarGzPath string, outputDir string) (logPath s
 		return "", "", fmt.Errorf("failed to decompress sonobuoy results tar file %v", err)
 	}
 	lg.Info("untar success", zap.String("tar-gz-path", tarGzPath), zap.String("output-directory", outputDir))
+	if !e2e {
+		return "", "", nil
+	}
 
-	logPath = filepath.Join(outputDir, "plugins", "e2e", "results", "global", "e2e.log")
+	logPath = filepath.Join(outputDir, "plugins", PluginE2E, "results", "global", "e2e.log")
 	if !file.Exist(logPath) {
 		return "", "", fmt.Errorf("result dir %q does not have e2e.log %q", outputDir, logPath)
 	}
-	xmlPath = filepath.Join(outputDir, "plugins", "e2e", "results", "global", "junit_01.xml")
+	xmlPath = filepath.Join(outputDir, "plugins", PluginE2E, "results", "global", "junit_01.xml")
 	if !file.Exist(xmlPath) {
 		return "", "", fmt.Errorf("result dir %q does not have junit_01.xml %q", outputDir, xmlPath)
 	}
@@ -16,13 +16,17 @@ func TestSonobuoy(t *testing.T) {
 		t.Skip(err)
 	}
 
-	if err := readResults(zap.NewExample(), os.Stderr, DefaultSonobuoyPath(), "test-data/test.tar.gz"); err != nil {
+	results, err := readResults(zap.NewExample(), os.Stderr, DefaultSonobuoyPath(), "test-data/test.tar.gz", []string{PluginE2E})
+	if err != nil {
 		t.Fatal(err)
 	}
+	if len(results) != 1 || results[0].Name != PluginE2E || !results[0].Passed() {
+		t.Fatalf("unexpected results %+v", results)
+	}
 
 	os.RemoveAll("test-data/output")
 	defer os.RemoveAll("test-data/output")
-	logPath, xmlPath, err := untarResults(zap.NewExample(), "test-data/test.tar.gz", "test-data/output")
+	logPath, xmlPath, err := untarResults(zap.NewExample(), "test-data/test.tar.gz", "test-data/output", true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
@@ -18,9 +19,9 @@ import (
 	"github.com/aws/aws-k8s-tester/utils/file"
 	"github.com/aws/aws-k8s-tester/utils/rand"
 	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/manifoldco/promptui"
 	"go.uber.org/zap"
-	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/exec"
 )
@@ -71,6 +72,29 @@ type Config struct {
 	// SonobuoyRunSystemdLogsImage is the image for systemd-logs plugin image.
 	SonobuoyRunSystemdLogsImage string `json:"sonobuoy_run_systemd_logs_image"`
 
+	// SonobuoyPlugins is the list of plugins to run, either the built-in plugin names
+	// ("e2e", "systemd-logs") or the custom plugin definition YAML file paths.
+	// The tester waits on and aggregates the results from each plugin.
+	// Leave empty to run the sonobuoy default plugins ("e2e", "systemd-logs").
+	// ref. https://sonobuoy.io/docs/main/plugins/
+	SonobuoyPlugins []string `json:"sonobuoy_plugins"`
+	// SonobuoyPluginNames is the list of plugin names, resolved from "SonobuoyPlugins".
+	SonobuoyPluginNames []string `json:"sonobuoy_plugin_names" read-only:"true"`
+	// SonobuoyPluginResults is the list of results from each plugin.
+	SonobuoyPluginResults []PluginResult `json:"sonobuoy_plugin_results" read-only:"true"`
+
+	// FlakyRetries is the number of times to rerun only the failed e2e specs,
+	// with the "--e2e-focus" generated from the results, after a run completes with failures.
+	// The run passes if every failed spec passes in a rerun, and such specs are reported as flaky.
+	// Zero to disable.
+	FlakyRetries int `json:"flaky_retries"`
+	// FlakyResultsPath is the file path to write the flaky retry results in JSON.
+	FlakyResultsPath string `json:"flaky_results_path"`
+	// FlakySpecs is the list of specs that failed, but passed in a rerun.
+	FlakySpecs []string `json:"flaky_specs" read-only:"true"`
+	// FlakyFailedSpecs is the list of specs that failed in every rerun.
+	FlakyFailedSpecs []string `json:"flaky_failed_specs" read-only:"true"`
+
 	// SonobuoyResultsTarGzPath is the sonobuoy results tar.gz file path after downloaded from the sonobuoy Pod.
 	SonobuoyResultsTarGzPath string `json:"sonobuoy_results_tar_gz_path"`
 	// SonobuoyResultsE2ELogPath is the sonobuoy results log file path after downloaded from the sonobuoy Pod.
@@ -79,6 +103,26 @@ type Config struct {
 	SonobuoyResultsJunitXMLPath string `json:"sonobuoy_results_junit_xml_path"`
 	// SonobuoyResultsOutputDir is the sonobuoy results output path after untar.
 	SonobuoyResultsOutputDir string `json:"sonobuoy_results_output_dir"`
+
+	S3API s3iface.S3API `json:"-"`
+	// Partition is the AWS partition for the S3 baseline.
+	Partition string `json:"partition"`
+
+	// BaselineJUnitXMLPath is the junit XML results of a baseline run (e.g., previous release)
+	// to compare against, either a local file path or an S3 URL ("s3://bucket/key").
+	// Leave empty to skip the comparison.
+	BaselineJUnitXMLPath string `json:"baseline_junit_xml_path"`
+	// BaselineS3Region is the region of the S3 bucket, if the baseline is an S3 URL.
+	BaselineS3Region string `json:"baseline_s3_region"`
+	// BaselineDiffPath is the file path to write the baseline comparison in JSON.
+	BaselineDiffPath string `json:"baseline_diff_path"`
+	// BaselineFailOnNewlyFailing is true to fail the tester when any spec
+	// newly fails relative to the baseline, even if "sonobuoy results" passed.
+	BaselineFailOnNewlyFailing bool `json:"baseline_fail_on_newly_failing"`
+	// BaselineNewlyFailing is the list of specs that fail in this run, but not in the baseline.
+	BaselineNewlyFailing []string `json:"baseline_newly_failing" read-only:"true"`
+	// BaselineNewlyPassing is the list of specs that pass in this run, but failed in the baseline.
+	BaselineNewlyPassing []string `json:"baseline_newly_passing" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
@@ -113,6 +157,15 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		cfg.SonobuoyRunKubeConformanceImage = DefaultSonobuoyRunKubeConformanceImage
 	}
 
+	if len(cfg.SonobuoyPlugins) == 0 {
+		cfg.SonobuoyPlugins = append([]string(nil), DefaultSonobuoyPlugins...)
+	}
+	for _, plugin := range cfg.SonobuoyPlugins {
+		if !isBuiltinPlugin(plugin) && !file.Exist(plugin) {
+			return fmt.Errorf("SonobuoyPlugins %q is neither a built-in plugin nor a plugin file", plugin)
+		}
+	}
+
 	if !strings.HasSuffix(cfg.SonobuoyResultsTarGzPath, ".tar.gz") {
 		return fmt.Errorf("SonobuoyResultsTarGzPath %q missing .tar.gz", cfg.SonobuoyResultsTarGzPath)
 	}
@@ -123,6 +176,29 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		return fmt.Errorf("SonobuoyResultsJunitXMLPath %q missing .xml", cfg.SonobuoyResultsJunitXMLPath)
 	}
 
+	if cfg.FlakyRetries < 0 {
+		return fmt.Errorf("invalid FlakyRetries %d", cfg.FlakyRetries)
+	}
+	if cfg.FlakyRetries > 0 && cfg.FlakyResultsPath == "" {
+		cfg.FlakyResultsPath = strings.TrimSuffix(cfg.SonobuoyResultsJunitXMLPath, ".xml") + ".flaky.json"
+	}
+
+	if cfg.BaselineJUnitXMLPath != "" {
+		if strings.HasPrefix(cfg.BaselineJUnitXMLPath, "s3://") {
+			if cfg.Partition == "" {
+				cfg.Partition = DefaultPartition
+			}
+			if cfg.BaselineS3Region == "" {
+				return fmt.Errorf("empty BaselineS3Region for baseline %q", cfg.BaselineJUnitXMLPath)
+			}
+		} else if !file.Exist(cfg.BaselineJUnitXMLPath) {
+			return fmt.Errorf("BaselineJUnitXMLPath %q does not exist", cfg.BaselineJUnitXMLPath)
+		}
+		if cfg.BaselineDiffPath == "" {
+			cfg.BaselineDiffPath = strings.TrimSuffix(cfg.SonobuoyResultsJunitXMLPath, ".xml") + ".baseline-diff.json"
+		}
+	}
+
 	return nil
 }
 
@@ -132,6 +208,7 @@ const (
 	DefaultSonobuoyDeleteTimeout               = 5 * time.Minute
 	DefaultSonobuoyRunMode                     = "certified-conformance"
 	DefaultSonobuoyRunKubeConformanceImage     = "k8s.gcr.io/conformance:v1.21.0"
+	DefaultPartition                           = "aws"
 )
 
 func NewDefault() *Config {
@@ -156,11 +233,14 @@ func NewDefault() *Config {
 		SonobuoyRunE2ERepoConfig:        "",
 		SonobuoyRunImage:                "public.ecr.aws/v3f2w6a4/sonobuoy:v0.52",
 		SonobuoyRunSystemdLogsImage:     "",
+		SonobuoyPlugins:                 append([]string(nil), DefaultSonobuoyPlugins...),
 
 		SonobuoyResultsTarGzPath:    file.GetTempFilePath("sonobuoy_results") + ".tar.gz",
 		SonobuoyResultsE2ELogPath:   file.GetTempFilePath("sonobuoy_results") + ".e2e.log",
 		SonobuoyResultsJunitXMLPath: file.GetTempFilePath("sonobuoy_results") + ".xml",
 		SonobuoyResultsOutputDir:    file.MkDir("", "sonobuoy-output"),
+
+		Partition: DefaultPartition,
 	}
 }
 
@@ -170,6 +250,25 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 }
 
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
 type tester struct {
 	cfg *Config
 }
@@ -199,13 +298,16 @@ func (ts *tester) Apply() error {
 		return err
 	}
 
+	if err := ts.resolvePlugins(); err != nil {
+		return err
+	}
 	if err := installSonobuoy(ts.cfg.Logger, ts.cfg.SonobuoyPath, ts.cfg.SonobuoyDownloadURL); err != nil {
 		return err
 	}
 	if err := ts.deleteSonobuoy(); err != nil {
 		return err
 	}
-	if err := ts.runSonobuoy(); err != nil {
+	if err := ts.runSonobuoy(ts.cfg.SonobuoyPlugins, ts.cfg.SonobuoyRunE2EFocus); err != nil {
 		return err
 	}
 	if err := ts.checkSonobuoy(); err != nil {
@@ -262,7 +364,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -272,6 +375,32 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 	return true
 }
 
+// resolvePlugins resolves the plugin names to wait on and to aggregate the results from.
+func (ts *tester) resolvePlugins() error {
+	if len(ts.cfg.SonobuoyPlugins) == 0 {
+		ts.cfg.SonobuoyPlugins = append([]string(nil), DefaultSonobuoyPlugins...)
+	}
+	ts.cfg.SonobuoyPluginNames = make([]string, 0, len(ts.cfg.SonobuoyPlugins))
+	for _, plugin := range ts.cfg.SonobuoyPlugins {
+		name, err := pluginName(plugin)
+		if err != nil {
+			return err
+		}
+		ts.cfg.SonobuoyPluginNames = append(ts.cfg.SonobuoyPluginNames, name)
+	}
+	ts.cfg.Logger.Info("resolved sonobuoy plugins", zap.Strings("plugins", ts.cfg.SonobuoyPlugins), zap.Strings("names", ts.cfg.SonobuoyPluginNames))
+	return nil
+}
+
+func (ts *tester) hasPlugin(name string) bool {
+	for _, v := range ts.cfg.SonobuoyPluginNames {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (ts *tester) deleteSonobuoy() (err error) {
 	args := []string{
 		ts.cfg.SonobuoyPath,
@@ -305,9 +434,10 @@ func (ts *tester) deleteSonobuoy() (err error) {
 	return nil
 }
 
-func (ts *tester) runSonobuoy() (err error) {
+// sonobuoyRunArgs returns the "sonobuoy run" command with the plugins, and the e2e focus if not empty.
+func (ts *tester) sonobuoyRunArgs(plugins []string, e2eFocus string) (args []string) {
 	timeoutSeconds := int64(ts.cfg.SonobuoyRunTimeout.Seconds())
-	args := []string{
+	args = []string{
 		ts.cfg.SonobuoyPath,
 		"--logtostderr",
 		"--alsologtostderr",
@@ -320,6 +450,9 @@ func (ts *tester) runSonobuoy() (err error) {
 		"--show-default-podspec=true",
 		fmt.Sprintf("--timeout=%d", timeoutSeconds), // default "10800", 3-hour
 	}
+	for _, plugin := range plugins {
+		args = append(args, "--plugin="+plugin)
+	}
 	if ts.cfg.SonobuoyRunE2ERepoConfig != "" {
 		args = append(args, "--e2e-repo-config="+ts.cfg.SonobuoyRunE2ERepoConfig)
 	}
@@ -329,18 +462,26 @@ func (ts *tester) runSonobuoy() (err error) {
 	if ts.cfg.SonobuoyRunSystemdLogsImage != "" {
 		args = append(args, "--systemd-logs-image="+ts.cfg.SonobuoyRunSystemdLogsImage)
 	}
-	if ts.cfg.SonobuoyRunE2EFocus != "" {
-		args = append(args, "--e2e-focus="+ts.cfg.SonobuoyRunE2EFocus)
+	if e2eFocus != "" {
+		args = append(args, "--e2e-focus="+e2eFocus)
 	}
 	if ts.cfg.SonobuoyRunE2ESkip != "" {
 		args = append(args, "--e2e-skip="+ts.cfg.SonobuoyRunE2ESkip)
 	}
+	return args
+}
+
+// runSonobuoy launches the sonobuoy run with the plugins, and overrides the e2e focus if not empty.
+func (ts *tester) runSonobuoy(plugins []string, e2eFocus string) (err error) {
+	timeoutSeconds := int64(ts.cfg.SonobuoyRunTimeout.Seconds())
+	args := ts.sonobuoyRunArgs(plugins, e2eFocus)
 	cmd := strings.Join(args, " ")
 
 	ts.cfg.Logger.Info("running sonobuoy",
 		zap.Duration("timeout", ts.cfg.SonobuoyRunTimeout),
 		zap.Int64("timeout-seconds", timeoutSeconds),
 		zap.String("mode", ts.cfg.SonobuoyRunMode),
+		zap.Strings("plugins", ts.cfg.SonobuoyPluginNames),
 		zap.String("command", cmd),
 	)
 
@@ -359,24 +500,20 @@ func (ts *tester) runSonobuoy() (err error) {
 	return nil
 }
 
-func (ts *tester) checkSonobuoy() (err error) {
+// findE2EJobPod finds the e2e plugin Pod, or returns empty if stopped.
+func (ts *tester) findE2EJobPod() (pod string, err error) {
 	ts.cfg.Logger.Info("checking pod/sonobuoy-e2e-job")
-	sonobuoyE2EJobPod := ""
 	retryStart := time.Now()
 	for time.Since(retryStart) < 10*time.Minute {
 		select {
 		case <-ts.cfg.Stopc:
 			ts.cfg.Logger.Warn("sonobuoy check stopped")
-			return nil
-		case <-ts.cfg.Stopc:
-			ts.cfg.Logger.Warn("sonobuoy check timeout")
-			return fmt.Errorf("sonobuoy run took too long (exceeded %v)", ts.cfg.SonobuoyRunTimeout)
+			return "", nil
 		case <-time.After(10 * time.Second):
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		var pods *v1.PodList
-		pods, err = ts.cfg.Client.KubernetesClient().
+		pods, err := ts.cfg.Client.KubernetesClient().
 			CoreV1().
 			Pods(ts.cfg.Namespace).
 			List(ctx, meta_v1.ListOptions{})
@@ -389,18 +526,29 @@ func (ts *tester) checkSonobuoy() (err error) {
 		for _, pv := range pods.Items {
 			ts.cfg.Logger.Info("found pod", zap.String("name", pv.GetName()))
 			if strings.HasPrefix(pv.GetName(), "sonobuoy-e2e-job-") {
-				sonobuoyE2EJobPod = pv.GetName()
+				pod = pv.GetName()
 				break
 			}
 		}
-		if sonobuoyE2EJobPod != "" {
+		if pod != "" {
 			break
 		}
 	}
-	if sonobuoyE2EJobPod == "" {
-		return fmt.Errorf("failed to find pod/sonobuoy-e2e-job in %q", ts.cfg.Namespace)
+	if pod == "" {
+		return "", fmt.Errorf("failed to find pod/sonobuoy-e2e-job in %q", ts.cfg.Namespace)
+	}
+	ts.cfg.Logger.Info("found pod/sonobuoy-e2e-job", zap.String("name", pod))
+	return pod, nil
+}
+
+func (ts *tester) checkSonobuoy() (err error) {
+	// only the e2e plugin Pod logs are tailed, other plugins are tracked by "sonobuoy status"
+	sonobuoyE2EJobPod := ""
+	if ts.hasPlugin(PluginE2E) {
+		if sonobuoyE2EJobPod, err = ts.findE2EJobPod(); err != nil {
+			return err
+		}
 	}
-	ts.cfg.Logger.Info("found pod/sonobuoy-e2e-job", zap.String("name", sonobuoyE2EJobPod))
 
 	argsLogsSonobuoy := []string{
 		ts.cfg.SonobuoyPath,
@@ -467,7 +615,7 @@ func (ts *tester) checkSonobuoy() (err error) {
 		}
 
 		argsLogs, cmdLogs := argsLogsSonobuoy, cmdLogsSonobuoy
-		if cnt%2 == 0 {
+		if cnt%2 == 0 && sonobuoyE2EJobPod != "" {
 			argsLogs, cmdLogs = argsLogsPod, cmdLogsPod
 		}
 		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
@@ -512,7 +660,8 @@ func (ts *tester) checkSonobuoy() (err error) {
 	return nil
 }
 
-func (ts *tester) checkResults() (err error) {
+// retrieveResults retrieves the sonobuoy results to the tar.gz path.
+func (ts *tester) retrieveResults(tarGzPath string) (stopped bool, err error) {
 	argsRetrieve := []string{
 		ts.cfg.SonobuoyPath,
 		"retrieve",
@@ -524,13 +673,13 @@ func (ts *tester) checkResults() (err error) {
 
 	ts.cfg.Logger.Info("running sonobuoy", zap.String("retrieve-command", cmdRetrieve))
 
-	os.RemoveAll(ts.cfg.SonobuoyResultsTarGzPath)
+	os.RemoveAll(tarGzPath)
 	start, waitDur := time.Now(), 3*time.Minute
 	for time.Since(start) < waitDur {
 		select {
 		case <-ts.cfg.Stopc:
 			ts.cfg.Logger.Warn("sonobuoy retrieve stopped")
-			return nil
+			return true, nil
 		default:
 		}
 		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
@@ -544,30 +693,46 @@ func (ts *tester) checkResults() (err error) {
 		}
 		fmt.Fprintf(ts.cfg.LogWriter, "\n'%s' output:\n\n%s\n\n", cmdRetrieve, out)
 
-		if err = file.Copy(out, ts.cfg.SonobuoyResultsTarGzPath); err != nil {
+		if err = file.Copy(out, tarGzPath); err != nil {
 			ts.cfg.Logger.Warn("failed to copy sonobuoy retrieve results", zap.Error(err))
-			return err
+			return false, err
 		}
 
-		ts.cfg.Logger.Info("retrieved sonobuoy results", zap.String("path", ts.cfg.SonobuoyResultsTarGzPath))
+		ts.cfg.Logger.Info("retrieved sonobuoy results", zap.String("path", tarGzPath))
 		break
 	}
+	return false, nil
+}
 
-	err = readResults(
+func (ts *tester) checkResults() (err error) {
+	stopped, err := ts.retrieveResults(ts.cfg.SonobuoyResultsTarGzPath)
+	if stopped || err != nil {
+		return err
+	}
+
+	ts.cfg.SonobuoyPluginResults, err = readResults(
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
 		ts.cfg.SonobuoyPath,
 		ts.cfg.SonobuoyResultsTarGzPath,
+		ts.cfg.SonobuoyPluginNames,
 	)
 	if err != nil {
 		ts.cfg.Logger.Warn("read results failed", zap.Error(err))
 	}
 
+	e2e := ts.hasPlugin(PluginE2E)
 	logPath, xmlPath, terr := untarResults(
 		ts.cfg.Logger,
 		ts.cfg.SonobuoyResultsTarGzPath,
 		ts.cfg.SonobuoyResultsOutputDir,
+		e2e,
 	)
+	if terr == nil {
+		for i := range ts.cfg.SonobuoyPluginResults {
+			ts.cfg.SonobuoyPluginResults[i].ResultsDir = filepath.Join(ts.cfg.SonobuoyResultsOutputDir, "plugins", ts.cfg.SonobuoyPluginResults[i].Name, "results")
+		}
+	}
 	if terr != nil {
 		ts.cfg.Logger.Warn("failed to untar results", zap.Error(terr))
 		if err == nil {
@@ -575,10 +740,33 @@ func (ts *tester) checkResults() (err error) {
 		} else {
 			err = fmt.Errorf("read results error [%v], untar error [%v]", err, terr)
 		}
+	} else if e2e {
+		if err != nil && ts.cfg.FlakyRetries > 0 && ts.onlyE2EFailed() {
+			// the other plugins passed, so the run passes if the failed specs pass in a rerun
+			if ferr := ts.retryFailedSpecs(xmlPath); ferr != nil {
+				err = fmt.Errorf("read results error [%v], flaky retry error [%v]", err, ferr)
+			} else {
+				err = nil
+			}
+		}
+		if ts.cfg.BaselineJUnitXMLPath != "" {
+			// compare even if the tests failed, to tell the regressions from the known failures
+			if berr := ts.compareBaseline(xmlPath); berr != nil {
+				ts.cfg.Logger.Warn("baseline comparison failed", zap.Error(berr))
+				if err == nil {
+					err = berr
+				} else {
+					err = fmt.Errorf("read results error [%v], baseline error [%v]", err, berr)
+				}
+			}
+		}
 	}
 	if err != nil {
 		return err
 	}
+	if !e2e {
+		return nil
+	}
 	if err = file.Copy(logPath, ts.cfg.SonobuoyResultsE2ELogPath); err != nil {
 		return err
 	}
@@ -0,0 +1,193 @@
+package cross_cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// registerCloudMap creates the Cloud Map service in the private DNS namespace,
+// registers the remote server Pod IPs as its instances, and sets the service
+// DNS name as the target.
+func (ts *tester) registerCloudMap() error {
+	nout, err := ts.cfg.SDAPI.GetNamespace(&servicediscovery.GetNamespaceInput{
+		Id: aws.String(ts.cfg.CloudMapNamespaceID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get Cloud Map namespace %q (%v)", ts.cfg.CloudMapNamespaceID, err)
+	}
+	if tp := aws.StringValue(nout.Namespace.Type); tp != servicediscovery.NamespaceTypeDnsPrivate {
+		return fmt.Errorf("Cloud Map namespace %q type %q, expected %q", ts.cfg.CloudMapNamespaceID, tp, servicediscovery.NamespaceTypeDnsPrivate)
+	}
+	ts.cfg.CloudMapNamespaceName = aws.StringValue(nout.Namespace.Name)
+
+	ts.cfg.Logger.Info("creating Cloud Map service",
+		zap.String("namespace-id", ts.cfg.CloudMapNamespaceID),
+		zap.String("namespace-name", ts.cfg.CloudMapNamespaceName),
+		zap.String("service-name", ts.cfg.CloudMapServiceName),
+	)
+	sout, err := ts.cfg.SDAPI.CreateService(&servicediscovery.CreateServiceInput{
+		Name:        aws.String(ts.cfg.CloudMapServiceName),
+		NamespaceId: aws.String(ts.cfg.CloudMapNamespaceID),
+		DnsConfig: &servicediscovery.DnsConfig{
+			RoutingPolicy: aws.String(servicediscovery.RoutingPolicyMultivalue),
+			DnsRecords: []*servicediscovery.DnsRecord{
+				{Type: aws.String(servicediscovery.RecordTypeA), TTL: aws.Int64(10)},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud Map service %q (%v)", ts.cfg.CloudMapServiceName, err)
+	}
+	ts.cfg.CloudMapServiceID = aws.StringValue(sout.Service.Id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.RemoteClient.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{LabelSelector: serverLabel + "=" + serverName})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list remote server Pods (%v)", err)
+	}
+	registered := 0
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != core_v1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		ts.cfg.Logger.Info("registering server Pod", zap.String("pod", pod.Name), zap.String("ip", pod.Status.PodIP))
+		rout, err := ts.cfg.SDAPI.RegisterInstance(&servicediscovery.RegisterInstanceInput{
+			ServiceId:  aws.String(ts.cfg.CloudMapServiceID),
+			InstanceId: aws.String(pod.Name),
+			Attributes: map[string]*string{
+				"AWS_INSTANCE_IPV4": aws.String(pod.Status.PodIP),
+				"AWS_INSTANCE_PORT": aws.String(fmt.Sprint(serverPort)),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register server Pod %q (%v)", pod.Name, err)
+		}
+		if err = ts.waitForOperation(aws.StringValue(rout.OperationId)); err != nil {
+			return err
+		}
+		registered++
+	}
+	if registered == 0 {
+		return errors.New("no running remote server Pod to register")
+	}
+
+	ts.cfg.Target = fmt.Sprintf("%s.%s:%d", ts.cfg.CloudMapServiceName, ts.cfg.CloudMapNamespaceName, serverPort)
+	return nil
+}
+
+// waitForOperation waits for the Cloud Map operation to succeed.
+func (ts *tester) waitForOperation(id string) error {
+	status := ""
+	start := time.Now()
+	for time.Since(start) < ts.cfg.DiscoveryTimeout {
+		out, err := ts.cfg.SDAPI.GetOperation(&servicediscovery.GetOperationInput{OperationId: aws.String(id)})
+		if err != nil {
+			return fmt.Errorf("failed to get Cloud Map operation %q (%v)", id, err)
+		}
+		status = aws.StringValue(out.Operation.Status)
+		switch status {
+		case servicediscovery.OperationStatusSuccess:
+			return nil
+		case servicediscovery.OperationStatusFail:
+			return fmt.Errorf("Cloud Map operation %q failed (%s)", id, aws.StringValue(out.Operation.ErrorMessage))
+		}
+		ts.cfg.Logger.Info("waiting for Cloud Map operation", zap.String("id", id), zap.String("status", status))
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return fmt.Errorf("Cloud Map operation %q still %q after %v", id, status, ts.cfg.DiscoveryTimeout)
+}
+
+// deleteCloudMap deregisters all instances of the Cloud Map service, and deletes the service.
+// The service is found by its name, to delete from a new process.
+func (ts *tester) deleteCloudMap() error {
+	if ts.cfg.CloudMapServiceID == "" {
+		err := ts.cfg.SDAPI.ListServicesPages(
+			&servicediscovery.ListServicesInput{
+				Filters: []*servicediscovery.ServiceFilter{
+					{
+						Name:      aws.String(servicediscovery.ServiceFilterNameNamespaceId),
+						Values:    aws.StringSlice([]string{ts.cfg.CloudMapNamespaceID}),
+						Condition: aws.String(servicediscovery.FilterConditionEq),
+					},
+				},
+			},
+			func(out *servicediscovery.ListServicesOutput, lastPage bool) bool {
+				for _, svc := range out.Services {
+					if aws.StringValue(svc.Name) == ts.cfg.CloudMapServiceName {
+						ts.cfg.CloudMapServiceID = aws.StringValue(svc.Id)
+						return false
+					}
+				}
+				return true
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to list Cloud Map services (%v)", err)
+		}
+		if ts.cfg.CloudMapServiceID == "" {
+			ts.cfg.Logger.Info("Cloud Map service not found; skipping", zap.String("service-name", ts.cfg.CloudMapServiceName))
+			return nil
+		}
+	}
+
+	var ids []string
+	err := ts.cfg.SDAPI.ListInstancesPages(
+		&servicediscovery.ListInstancesInput{ServiceId: aws.String(ts.cfg.CloudMapServiceID)},
+		func(out *servicediscovery.ListInstancesOutput, lastPage bool) bool {
+			for _, inst := range out.Instances {
+				ids = append(ids, aws.StringValue(inst.Id))
+			}
+			return true
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to list Cloud Map instances (%v)", err)
+	}
+	for _, id := range ids {
+		ts.cfg.Logger.Info("deregistering Cloud Map instance", zap.String("id", id))
+		out, err := ts.cfg.SDAPI.DeregisterInstance(&servicediscovery.DeregisterInstanceInput{
+			ServiceId:  aws.String(ts.cfg.CloudMapServiceID),
+			InstanceId: aws.String(id),
+		})
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to deregister Cloud Map instance %q (%v)", id, err)
+		}
+		if err = ts.waitForOperation(aws.StringValue(out.OperationId)); err != nil {
+			return err
+		}
+	}
+
+	ts.cfg.Logger.Info("deleting Cloud Map service", zap.String("id", ts.cfg.CloudMapServiceID))
+	_, err = ts.cfg.SDAPI.DeleteService(&servicediscovery.DeleteServiceInput{Id: aws.String(ts.cfg.CloudMapServiceID)})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete Cloud Map service %q (%v)", ts.cfg.CloudMapServiceID, err)
+	}
+	ts.cfg.CloudMapServiceID = ""
+	return nil
+}
+
+func isNotFound(err error) bool {
+	return strings.Contains(err.Error(), servicediscovery.ErrCodeServiceNotFound) ||
+		strings.Contains(err.Error(), servicediscovery.ErrCodeInstanceNotFound)
+}
@@ -0,0 +1,190 @@
+// k8s-tester-cross-cluster validates the service discovery and the connectivity between two clusters.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	cross_cluster "github.com/aws/aws-k8s-tester/k8s-tester/cross-cluster"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-cross-cluster",
+	Short:      "Kubernetes cross-cluster service connectivity tester",
+	SuggestFor: []string{"cross-cluster"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt                  bool
+	logLevel                string
+	logOutputs              []string
+	minimumNodes            int
+	namespace               string
+	kubectlDownloadURL      string
+	kubectlPath             string
+	kubeconfigPath          string
+	remoteKubeconfigPath    string
+	remoteKubeconfigContext string
+	mechanism               string
+	partition               string
+	region                  string
+	cloudMapNamespaceID     string
+	cloudMapServiceName     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", cross_cluster.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "namespace to create resources in both clusters")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path of the local cluster to run the clients")
+	rootCmd.PersistentFlags().StringVar(&remoteKubeconfigPath, "remote-kubeconfig-path", "", "KUBECONFIG path of the remote cluster to run the servers")
+	rootCmd.PersistentFlags().StringVar(&remoteKubeconfigContext, "remote-kubeconfig-context", "", "KUBECONFIG context of the remote cluster (default to the current context)")
+	rootCmd.PersistentFlags().StringVar(&mechanism, "mechanism", cross_cluster.DefaultMechanism, "cross-cluster service discovery mechanism, 'nlb' or 'cloud-map'")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", cross_cluster.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for AWS Cloud Map")
+	rootCmd.PersistentFlags().StringVar(&cloudMapNamespaceID, "cloud-map-namespace-id", "", "AWS Cloud Map private DNS namespace ID for the 'cloud-map' mechanism")
+	rootCmd.PersistentFlags().StringVar(&cloudMapServiceName, "cloud-map-service-name", "k8s-tester-cross-cluster", "AWS Cloud Map service name for the 'cloud-map' mechanism")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-cross-cluster failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	nlbScheme           string
+	serverImage         string
+	serverReplicas      int32
+	clientImage         string
+	clients             int32
+	requests            int
+	requestInterval     time.Duration
+	requestTimeout      time.Duration
+	latencyP99Threshold time.Duration
+	maxFailureRatio     float64
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&nlbScheme, "nlb-scheme", cross_cluster.DefaultNLBScheme, "NLB scheme for the 'nlb' mechanism, 'internal' or 'internet-facing'")
+	cmd.PersistentFlags().StringVar(&serverImage, "server-image", cross_cluster.DefaultServerImage, "server image serving HTTP on port 80")
+	cmd.PersistentFlags().Int32Var(&serverReplicas, "server-replicas", cross_cluster.DefaultServerReplicas, "number of server Pods in the remote cluster")
+	cmd.PersistentFlags().StringVar(&clientImage, "client-image", cross_cluster.DefaultClientImage, "client image with 'sh' and 'curl'")
+	cmd.PersistentFlags().Int32Var(&clients, "clients", cross_cluster.DefaultClients, "number of client Pods in the local cluster")
+	cmd.PersistentFlags().IntVar(&requests, "requests", cross_cluster.DefaultRequests, "number of requests per client Pod")
+	cmd.PersistentFlags().DurationVar(&requestInterval, "request-interval", cross_cluster.DefaultRequestInterval, "interval between the requests")
+	cmd.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", cross_cluster.DefaultRequestTimeout, "timeout of each request")
+	cmd.PersistentFlags().DurationVar(&latencyP99Threshold, "latency-p99-threshold", cross_cluster.DefaultLatencyP99Threshold, "maximum p99 latency of the successful requests")
+	cmd.PersistentFlags().Float64Var(&maxFailureRatio, "max-failure-ratio", cross_cluster.DefaultMaxFailureRatio, "maximum ratio of the failed requests")
+	return cmd
+}
+
+func newConfig() *cross_cluster.Config {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	return &cross_cluster.Config{
+		Prompt:                  prompt,
+		Logger:                  lg,
+		LogWriter:               logWriter,
+		MinimumNodes:            minimumNodes,
+		Namespace:               namespace,
+		Client:                  cli,
+		RemoteKubeconfigPath:    remoteKubeconfigPath,
+		RemoteKubeconfigContext: remoteKubeconfigContext,
+		Mechanism:               mechanism,
+		NLBScheme:               nlbScheme,
+		Partition:               partition,
+		Region:                  region,
+		CloudMapNamespaceID:     cloudMapNamespaceID,
+		CloudMapServiceName:     cloudMapServiceName,
+		ServerImage:             serverImage,
+		ServerReplicas:          serverReplicas,
+		ClientImage:             clientImage,
+		Clients:                 clients,
+		Requests:                requests,
+		RequestInterval:         requestInterval,
+		RequestTimeout:          requestTimeout,
+		LatencyP99Threshold:     latencyP99Threshold,
+		MaxFailureRatio:         maxFailureRatio,
+	}
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	cfg := newConfig()
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		cfg.Logger.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := cross_cluster.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-cross-cluster apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources in both clusters, and the AWS Cloud Map service, if any",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	cfg := newConfig()
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		cfg.Logger.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := cross_cluster.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-cross-cluster delete' success\n")
+}
@@ -0,0 +1,164 @@
+package cross_cluster
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	requestLinePrefix = "cross-cluster-request"
+	doneLine          = "cross-cluster-done"
+)
+
+// Result is the cross-cluster request result.
+type Result struct {
+	Requests  int64 `json:"requests"`
+	Succeeded int64 `json:"succeeded"`
+	// Failures is the number of failed requests, keyed by the failure mode
+	// ("dns", "connect", "timeout", "reset", "http-[STATUS CODE]", "curl-[EXIT CODE]").
+	Failures map[string]int64 `json:"failures"`
+
+	// LatencyP50, LatencyP99, and LatencyMax are the latencies of the successful requests.
+	LatencyP50       time.Duration `json:"latency_p50"`
+	LatencyP50String string        `json:"latency_p50_string"`
+	LatencyP99       time.Duration `json:"latency_p99"`
+	LatencyP99String string        `json:"latency_p99_string"`
+	LatencyMax       time.Duration `json:"latency_max"`
+	LatencyMaxString string        `json:"latency_max_string"`
+	// NameLookupP99 is the p99 name resolution latency of the successful requests.
+	NameLookupP99       time.Duration `json:"name_lookup_p99"`
+	NameLookupP99String string        `json:"name_lookup_p99_string"`
+}
+
+// sample is the result of one request.
+type sample struct {
+	// failure is the failure mode, empty if succeeded.
+	failure    string
+	nameLookup time.Duration
+	total      time.Duration
+}
+
+// parseSamples parses the request results of the client Pod logs.
+//
+//	cross-cluster-request 0 200 0.004512 0.012345
+//	cross-cluster-request 6 000 0.000000 0.000000
+//	cross-cluster-request 28 000 0.001000 5.001234
+//	cross-cluster-done
+func parseSamples(logs string) ([]sample, error) {
+	done := false
+	var ss []sample
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if line == doneLine {
+			done = true
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != requestLinePrefix {
+			continue
+		}
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("invalid request line %q", line)
+		}
+		exit, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q (%v)", line, err)
+		}
+		status, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q (%v)", line, err)
+		}
+		s := sample{failure: failureMode(exit, status)}
+		if s.nameLookup, err = parseSeconds(fields[3]); err != nil {
+			return nil, fmt.Errorf("failed to parse %q (%v)", line, err)
+		}
+		if s.total, err = parseSeconds(fields[4]); err != nil {
+			return nil, fmt.Errorf("failed to parse %q (%v)", line, err)
+		}
+		ss = append(ss, s)
+	}
+	if !done {
+		return nil, fmt.Errorf("%q not found", doneLine)
+	}
+	if len(ss) == 0 {
+		return nil, errors.New("no request result found")
+	}
+	return ss, nil
+}
+
+// failureMode returns the failure mode of the curl exit code and the HTTP status code,
+// or empty if the request succeeded.
+// ref. https://curl.se/docs/manpage.html#EXIT
+func failureMode(exit int, status int) string {
+	switch exit {
+	case 0:
+		if status >= 200 && status < 400 {
+			return ""
+		}
+		return fmt.Sprintf("http-%d", status)
+	case 6:
+		return "dns"
+	case 7:
+		return "connect"
+	case 28:
+		return "timeout"
+	case 52, 56:
+		return "reset"
+	default:
+		return fmt.Sprintf("curl-%d", exit)
+	}
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(math.Round(f * float64(time.Second))), nil
+}
+
+// summarize counts the requests by the failure mode, and computes the latency
+// percentiles of the successful requests of all client Pods.
+func summarize(ss []sample) (r Result) {
+	r.Failures = make(map[string]int64)
+	var totals, lookups []time.Duration
+	for _, s := range ss {
+		r.Requests++
+		if s.failure != "" {
+			r.Failures[s.failure]++
+			continue
+		}
+		r.Succeeded++
+		totals = append(totals, s.total)
+		lookups = append(lookups, s.nameLookup)
+	}
+	r.LatencyP50 = percentile(totals, 50)
+	r.LatencyP99 = percentile(totals, 99)
+	r.LatencyMax = percentile(totals, 100)
+	r.NameLookupP99 = percentile(lookups, 99)
+	r.LatencyP50String = r.LatencyP50.String()
+	r.LatencyP99String = r.LatencyP99.String()
+	r.LatencyMaxString = r.LatencyMax.String()
+	r.NameLookupP99String = r.NameLookupP99.String()
+	return r
+}
+
+// percentile returns the nearest-rank percentile, or zero if empty.
+func percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(ds))
+	copy(sorted, ds)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := (len(sorted)*p+99)/100 - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,74 @@
+package cross_cluster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSamples(t *testing.T) {
+	logs := `cross-cluster-request 0 200 0.001000 0.010000
+cross-cluster-request 0 200 0.002000 0.020000
+cross-cluster-request 0 503 0.001000 0.005000
+cross-cluster-request 6 000 0.000000 0.000000
+cross-cluster-request 28 000 0.001000 5.001000
+cross-cluster-request 0 200 0.003000 0.030000
+cross-cluster-done
+`
+	ss, err := parseSamples(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 6 {
+		t.Fatalf("expected 6 samples, got %d", len(ss))
+	}
+
+	r := summarize(ss)
+	if r.Requests != 6 || r.Succeeded != 3 {
+		t.Fatalf("unexpected requests %d, succeeded %d", r.Requests, r.Succeeded)
+	}
+	if expected := map[string]int64{"http-503": 1, "dns": 1, "timeout": 1}; !reflect.DeepEqual(r.Failures, expected) {
+		t.Fatalf("expected failures %v, got %v", expected, r.Failures)
+	}
+	if r.LatencyP50 != 20*time.Millisecond {
+		t.Fatalf("unexpected p50 latency %v", r.LatencyP50)
+	}
+	if r.LatencyP99 != 30*time.Millisecond || r.LatencyMax != 30*time.Millisecond {
+		t.Fatalf("unexpected p99 latency %v, max %v", r.LatencyP99, r.LatencyMax)
+	}
+	if r.NameLookupP99 != 3*time.Millisecond {
+		t.Fatalf("unexpected name lookup p99 %v", r.NameLookupP99)
+	}
+
+	if _, err = parseSamples("cross-cluster-request 0 200 0.001000 0.010000\n"); err == nil {
+		t.Fatal("expected error without 'cross-cluster-done'")
+	}
+	if _, err = parseSamples("cross-cluster-done\n"); err == nil {
+		t.Fatal("expected error without requests")
+	}
+	if _, err = parseSamples("cross-cluster-request 0 200\ncross-cluster-done\n"); err == nil {
+		t.Fatal("expected error for invalid request line")
+	}
+}
+
+func TestFailureMode(t *testing.T) {
+	tests := []struct {
+		exit     int
+		status   int
+		expected string
+	}{
+		{0, 200, ""},
+		{0, 301, ""},
+		{0, 404, "http-404"},
+		{6, 0, "dns"},
+		{7, 0, "connect"},
+		{28, 0, "timeout"},
+		{56, 0, "reset"},
+		{35, 0, "curl-35"},
+	}
+	for _, tt := range tests {
+		if mode := failureMode(tt.exit, tt.status); mode != tt.expected {
+			t.Errorf("failureMode(%d, %d) expected %q, got %q", tt.exit, tt.status, tt.expected, mode)
+		}
+	}
+}
@@ -0,0 +1,746 @@
+// Package cross_cluster validates the service discovery and the connectivity
+// between two clusters. It runs the server Pods in the remote cluster, and the client
+// Pods in the local cluster that send requests to the server via the chosen mechanism:
+//
+//	"nlb": the server is exposed by the NLB Service, and the clients request the NLB host name
+//	       (e.g., the internal NLB reachable over the VPC peering).
+//	"cloud-map": the server Pod IPs are registered in the AWS Cloud Map private DNS namespace,
+//	             and the clients resolve the Cloud Map service name (requires the VPC peering
+//	             between the cluster VPCs, and the namespace associated with the local VPC).
+//
+// It reports the request latency percentiles and the failure modes (e.g., DNS resolution,
+// connection, timeout, HTTP status).
+package cross_cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/aws/aws-sdk-go/service/servicediscovery/servicediscoveryiface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	// Client is the client of the local cluster, to run the client Pods.
+	Client client.Client `json:"-"`
+	// RemoteClient is the client of the remote cluster, to run the server Pods.
+	// If nil, created from "RemoteKubeconfigPath".
+	RemoteClient client.Client `json:"-"`
+
+	SDAPI servicediscoveryiface.ServiceDiscoveryAPI `json:"-"`
+
+	// RemoteKubeconfigPath is the KUBECONFIG path of the remote cluster.
+	RemoteKubeconfigPath string `json:"remote_kubeconfig_path"`
+	// RemoteKubeconfigContext is the KUBECONFIG context of the remote cluster.
+	// Empty to use the current context.
+	RemoteKubeconfigContext string `json:"remote_kubeconfig_context"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources, in both clusters.
+	Namespace string `json:"namespace"`
+
+	// Mechanism is the cross-cluster service discovery mechanism, "nlb" or "cloud-map".
+	Mechanism string `json:"mechanism"`
+	// NLBScheme is the NLB scheme for the "nlb" mechanism, "internal" or "internet-facing".
+	// The internal NLB is reachable from the local cluster only over the VPC peering.
+	NLBScheme string `json:"nlb_scheme"`
+
+	// Partition and Region are the AWS Cloud Map partition and region for the "cloud-map" mechanism.
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+	// CloudMapNamespaceID is the existing AWS Cloud Map private DNS namespace ID
+	// for the "cloud-map" mechanism, associated with the local cluster VPC.
+	CloudMapNamespaceID string `json:"cloud_map_namespace_id"`
+	// CloudMapNamespaceName is the DNS name of the Cloud Map namespace.
+	CloudMapNamespaceName string `json:"cloud_map_namespace_name" read-only:"true"`
+	// CloudMapServiceName is the Cloud Map service to register the server Pod IPs.
+	CloudMapServiceName string `json:"cloud_map_service_name"`
+	// CloudMapServiceID is the ID of the created Cloud Map service.
+	CloudMapServiceID string `json:"cloud_map_service_id" read-only:"true"`
+
+	// ServerImage is the server image that serves HTTP on port 80.
+	ServerImage string `json:"server_image"`
+	// ServerReplicas is the number of server Pods in the remote cluster.
+	ServerReplicas int32 `json:"server_replicas"`
+	// ClientImage is the client image with "sh" and "curl".
+	ClientImage string `json:"client_image"`
+	// Clients is the number of client Pods in the local cluster.
+	Clients int32 `json:"clients"`
+	// Requests is the number of requests per client Pod.
+	Requests int `json:"requests"`
+	// RequestInterval is the interval between the requests of each client Pod.
+	RequestInterval       time.Duration `json:"request_interval"`
+	RequestIntervalString string        `json:"request_interval_string" read-only:"true"`
+	// RequestTimeout is the timeout of each request, including the name resolution.
+	RequestTimeout       time.Duration `json:"request_timeout"`
+	RequestTimeoutString string        `json:"request_timeout_string" read-only:"true"`
+	// DiscoveryTimeout is the timeout for the server to be discoverable
+	// (e.g., the NLB host name, the Cloud Map registration).
+	DiscoveryTimeout       time.Duration `json:"discovery_timeout"`
+	DiscoveryTimeoutString string        `json:"discovery_timeout_string" read-only:"true"`
+
+	// LatencyP99Threshold is the maximum p99 latency of the successful requests.
+	LatencyP99Threshold       time.Duration `json:"latency_p99_threshold"`
+	LatencyP99ThresholdString string        `json:"latency_p99_threshold_string" read-only:"true"`
+	// MaxFailureRatio is the maximum ratio of the failed requests.
+	MaxFailureRatio float64 `json:"max_failure_ratio"`
+
+	// Target is the "host:port" requested by the client Pods.
+	Target string `json:"target" read-only:"true"`
+	// Result is the aggregated result of all client Pods.
+	Result Result `json:"result" read-only:"true"`
+}
+
+const (
+	MechanismNLB      = "nlb"
+	MechanismCloudMap = "cloud-map"
+
+	NLBSchemeInternal       = "internal"
+	NLBSchemeInternetFacing = "internet-facing"
+)
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.RemoteKubeconfigPath == "" && cfg.RemoteClient == nil {
+		return errors.New("empty RemoteKubeconfigPath")
+	}
+	switch cfg.Mechanism {
+	case MechanismNLB:
+		if cfg.NLBScheme == "" {
+			cfg.NLBScheme = DefaultNLBScheme
+		}
+		if cfg.NLBScheme != NLBSchemeInternal && cfg.NLBScheme != NLBSchemeInternetFacing {
+			return fmt.Errorf("invalid NLBScheme %q", cfg.NLBScheme)
+		}
+	case MechanismCloudMap:
+		if cfg.Partition == "" {
+			cfg.Partition = DefaultPartition
+		}
+		if cfg.Region == "" {
+			return errors.New("empty Region")
+		}
+		if cfg.CloudMapNamespaceID == "" {
+			return errors.New("empty CloudMapNamespaceID")
+		}
+		if cfg.CloudMapServiceName == "" {
+			return errors.New("empty CloudMapServiceName")
+		}
+	default:
+		return fmt.Errorf("unknown Mechanism %q (expected %q or %q)", cfg.Mechanism, MechanismNLB, MechanismCloudMap)
+	}
+	if cfg.ServerImage == "" {
+		cfg.ServerImage = DefaultServerImage
+	}
+	if cfg.ServerReplicas <= 0 {
+		cfg.ServerReplicas = DefaultServerReplicas
+	}
+	if cfg.ClientImage == "" {
+		cfg.ClientImage = DefaultClientImage
+	}
+	if cfg.Clients <= 0 {
+		cfg.Clients = DefaultClients
+	}
+	if cfg.Requests <= 0 {
+		cfg.Requests = DefaultRequests
+	}
+	if cfg.RequestInterval < 0 {
+		return fmt.Errorf("invalid RequestInterval %v", cfg.RequestInterval)
+	}
+	cfg.RequestIntervalString = cfg.RequestInterval.String()
+	if cfg.RequestTimeout == time.Duration(0) {
+		cfg.RequestTimeout = DefaultRequestTimeout
+	}
+	if cfg.RequestTimeout < time.Second {
+		return fmt.Errorf("RequestTimeout %v too short (< 1s)", cfg.RequestTimeout)
+	}
+	cfg.RequestTimeoutString = cfg.RequestTimeout.String()
+	if cfg.DiscoveryTimeout == time.Duration(0) {
+		cfg.DiscoveryTimeout = DefaultDiscoveryTimeout
+	}
+	cfg.DiscoveryTimeoutString = cfg.DiscoveryTimeout.String()
+	if cfg.LatencyP99Threshold == time.Duration(0) {
+		cfg.LatencyP99Threshold = DefaultLatencyP99Threshold
+	}
+	cfg.LatencyP99ThresholdString = cfg.LatencyP99Threshold.String()
+	if cfg.MaxFailureRatio < 0 || cfg.MaxFailureRatio > 1 {
+		return fmt.Errorf("invalid MaxFailureRatio %v", cfg.MaxFailureRatio)
+	}
+	return nil
+}
+
+const (
+	DefaultMinimumNodes        int   = 1
+	DefaultMechanism                 = MechanismNLB
+	DefaultNLBScheme                 = NLBSchemeInternal
+	DefaultPartition                 = "aws"
+	DefaultServerImage               = "public.ecr.aws/docker/library/nginx:stable-alpine"
+	DefaultServerReplicas      int32 = 2
+	DefaultClientImage               = "curlimages/curl:8.4.0"
+	DefaultClients             int32 = 1
+	DefaultRequests            int   = 300
+	DefaultRequestInterval           = 200 * time.Millisecond
+	DefaultRequestTimeout            = 5 * time.Second
+	DefaultDiscoveryTimeout          = 10 * time.Minute
+	DefaultLatencyP99Threshold       = time.Second
+	DefaultMaxFailureRatio           = 0.01
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:              false,
+		Prompt:              false,
+		MinimumNodes:        DefaultMinimumNodes,
+		Namespace:           pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Mechanism:           DefaultMechanism,
+		NLBScheme:           DefaultNLBScheme,
+		Partition:           DefaultPartition,
+		CloudMapServiceName: pkgName + "-" + rand.String(10),
+		ServerImage:         DefaultServerImage,
+		ServerReplicas:      DefaultServerReplicas,
+		ClientImage:         DefaultClientImage,
+		Clients:             DefaultClients,
+		Requests:            DefaultRequests,
+		RequestInterval:     DefaultRequestInterval,
+		RequestTimeout:      DefaultRequestTimeout,
+		DiscoveryTimeout:    DefaultDiscoveryTimeout,
+		LatencyP99Threshold: DefaultLatencyP99Threshold,
+		MaxFailureRatio:     DefaultMaxFailureRatio,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	if cfg.RemoteClient == nil {
+		ccfg := cfg.Client.Config()
+		cli, err := client.New(&client.Config{
+			Logger:             cfg.Logger,
+			KubectlDownloadURL: ccfg.KubectlDownloadURL,
+			KubectlPath:        ccfg.KubectlPath,
+			KubeconfigPath:     cfg.RemoteKubeconfigPath,
+			KubeconfigContext:  cfg.RemoteKubeconfigContext,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remote cluster client (%v)", err)
+		}
+		cfg.RemoteClient = cli
+	}
+
+	if cfg.Mechanism == MechanismCloudMap && cfg.SDAPI == nil {
+		awsCfg := aws_v1.Config{
+			Logger:        cfg.Logger,
+			DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+			Partition:     cfg.Partition,
+			Region:        cfg.Region,
+		}
+		awsSession, _, _, err := aws_v1.New(&awsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create aws session (%v)", err)
+		}
+		cfg.SDAPI = servicediscovery.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	}
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	serverName  = "cross-cluster-server"
+	jobName     = "cross-cluster-client"
+	serverPort  = 80
+	serverLabel = "app.kubernetes.io/name"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+		if nodes, err := client.ListNodes(ts.cfg.RemoteClient.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate remote cluster minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.RemoteClient.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return fmt.Errorf("failed to create remote cluster namespace (%v)", err)
+	}
+	if err := ts.createServer(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.DiscoveryTimeout)
+	_, err := client.WaitForDeployment(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.RemoteClient.KubernetesClient(),
+		ts.cfg.Namespace,
+		serverName,
+		client.WithStopc(ts.cfg.Stopc),
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("server Deployment not ready in remote cluster (%v)", err)
+	}
+
+	switch ts.cfg.Mechanism {
+	case MechanismNLB:
+		err = ts.exposeNLB()
+	case MechanismCloudMap:
+		err = ts.registerCloudMap()
+	}
+	if err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("exposed server to local cluster", zap.String("mechanism", ts.cfg.Mechanism), zap.String("target", ts.cfg.Target))
+
+	if err = client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+	if err = ts.createJob(); err != nil {
+		return err
+	}
+	pods, err := ts.waitForJob()
+	if err != nil {
+		return err
+	}
+	return ts.checkResults(pods)
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if ts.cfg.Mechanism == MechanismCloudMap {
+		if err := ts.deleteCloudMap(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// deletes the NLB Service first, for the controller to delete the NLB
+	if ts.cfg.Mechanism == MechanismNLB {
+		if err := client.DeleteService(
+			ts.cfg.Logger,
+			ts.cfg.RemoteClient.KubernetesClient(),
+			ts.cfg.Namespace,
+			serverName,
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete remote cluster NLB Service (%v)", err))
+		}
+	}
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.RemoteClient.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete remote cluster namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q in both clusters (mechanism %q), should we continue?", action, ts.cfg.Namespace, ts.cfg.Mechanism)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// createServer creates the server Deployment in the remote cluster.
+func (ts *tester) createServer() error {
+	ts.cfg.Logger.Info("creating server Deployment in remote cluster", zap.Int32("replicas", ts.cfg.ServerReplicas))
+	labels := map[string]string{serverLabel: serverName}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.RemoteClient.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serverName,
+					Namespace: ts.cfg.Namespace,
+					Labels:    labels,
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &ts.cfg.ServerReplicas,
+					Selector: &meta_v1.LabelSelector{MatchLabels: labels},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{Labels: labels},
+						Spec: core_v1.PodSpec{
+							Containers: []core_v1.Container{
+								{
+									Name:            serverName,
+									Image:           ts.cfg.ServerImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Ports: []core_v1.ContainerPort{
+										{Protocol: core_v1.ProtocolTCP, ContainerPort: serverPort},
+									},
+									ReadinessProbe: &core_v1.Probe{
+										ProbeHandler: core_v1.ProbeHandler{
+											HTTPGet: &core_v1.HTTPGetAction{Path: "/", Port: intstr.FromInt(serverPort)},
+										},
+										PeriodSeconds: 5,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("server Deployment already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create server Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created server Deployment")
+	return nil
+}
+
+// exposeNLB creates the NLB Service for the server in the remote cluster,
+// and sets the NLB host name as the target.
+func (ts *tester) exposeNLB() error {
+	ts.cfg.Logger.Info("creating server NLB Service in remote cluster", zap.String("scheme", ts.cfg.NLBScheme))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.RemoteClient.KubernetesClient().
+		CoreV1().
+		Services(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Service{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serverName,
+					Namespace: ts.cfg.Namespace,
+					Annotations: map[string]string{
+						"service.beta.kubernetes.io/aws-load-balancer-type":   "nlb",
+						"service.beta.kubernetes.io/aws-load-balancer-scheme": ts.cfg.NLBScheme,
+					},
+				},
+				Spec: core_v1.ServiceSpec{
+					Selector: map[string]string{serverLabel: serverName},
+					Type:     core_v1.ServiceTypeLoadBalancer,
+					Ports: []core_v1.ServicePort{
+						{
+							Name:       "http",
+							Protocol:   core_v1.ProtocolTCP,
+							Port:       serverPort,
+							TargetPort: intstr.FromInt(serverPort),
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create server NLB Service (%v)", err)
+	}
+
+	hostName, _, _, err := client.WaitForServiceIngressHostname(
+		ts.cfg.Logger,
+		ts.cfg.RemoteClient.KubernetesClient(),
+		ts.cfg.Namespace,
+		serverName,
+		ts.cfg.Stopc,
+		ts.cfg.DiscoveryTimeout,
+		"",
+		"",
+	)
+	if err != nil {
+		return fmt.Errorf("server NLB not provisioned (%v)", err)
+	}
+	ts.cfg.Target = fmt.Sprintf("%s:%d", hostName, serverPort)
+	return nil
+}
+
+// requestScript sends the requests with curl, and prints the curl exit code,
+// the HTTP status code, the name lookup time, and the total time of each request.
+// curl exits with 6 on the name resolution failure, 7 on the connection failure,
+// and 28 on the timeout.
+// ref. https://curl.se/docs/manpage.html#EXIT
+func requestScript(requests int, interval time.Duration, timeout time.Duration) string {
+	return fmt.Sprintf(`i=0
+while [ "$i" -lt %d ]; do
+  i=$((i + 1))
+  out=$(curl -s -o /dev/null -w '%%{http_code} %%{time_namelookup} %%{time_total}' --max-time %d "http://$TARGET/")
+  echo "%s $? $out"
+  sleep %.3f
+done
+echo %s
+`, requests, int(timeout.Seconds()), requestLinePrefix, interval.Seconds(), doneLine)
+}
+
+func (ts *tester) createJob() error {
+	ts.cfg.Logger.Info("creating client Job",
+		zap.String("target", ts.cfg.Target),
+		zap.Int32("clients", ts.cfg.Clients),
+		zap.Int("requests", ts.cfg.Requests),
+	)
+	backoffLimit := int32(0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					Completions:  &ts.cfg.Clients,
+					Parallelism:  &ts.cfg.Clients,
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           ts.cfg.ClientImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c"},
+									Args:            []string{requestScript(ts.cfg.Requests, ts.cfg.RequestInterval, ts.cfg.RequestTimeout)},
+									Env: []core_v1.EnvVar{
+										{Name: "TARGET", Value: ts.cfg.Target},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("client Job already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create client Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created client Job")
+	return nil
+}
+
+func (ts *tester) waitForJob() ([]core_v1.Pod, error) {
+	// each request takes at most the timeout and the interval
+	dur := time.Duration(ts.cfg.Requests) * (ts.cfg.RequestTimeout + ts.cfg.RequestInterval)
+	ctx, cancel := context.WithTimeout(context.Background(), dur+10*time.Minute)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		time.Duration(ts.cfg.Requests)*ts.cfg.RequestInterval,
+		10*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		int(ts.cfg.Clients),
+	)
+	cancel()
+	return pods, err
+}
+
+func (ts *tester) checkResults(pods []core_v1.Pod) error {
+	var samples []sample
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		logs, err := ts.readPodLogs(pod.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read client Pod %q logs (%v)", pod.Name, err)
+		}
+		ss, err := parseSamples(logs)
+		if err != nil {
+			return fmt.Errorf("failed to parse client Pod %q logs (%v)", pod.Name, err)
+		}
+		samples = append(samples, ss...)
+	}
+	if len(samples) == 0 {
+		return errors.New("no cross-cluster request result")
+	}
+	ts.cfg.Result = summarize(samples)
+
+	r := ts.cfg.Result
+	fmt.Fprintf(ts.cfg.LogWriter, "\ncross-cluster %q requests to %q: %d, succeeded: %d\n", ts.cfg.Mechanism, ts.cfg.Target, r.Requests, r.Succeeded)
+	fmt.Fprintf(ts.cfg.LogWriter, "cross-cluster latency p50: %v, p99: %v, max: %v (threshold %v), name lookup p99: %v\n", r.LatencyP50, r.LatencyP99, r.LatencyMax, ts.cfg.LatencyP99Threshold, r.NameLookupP99)
+	modes := make([]string, 0, len(r.Failures))
+	for mode := range r.Failures {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	for _, mode := range modes {
+		fmt.Fprintf(ts.cfg.LogWriter, "cross-cluster failure %q: %d\n", mode, r.Failures[mode])
+	}
+	fmt.Fprintln(ts.cfg.LogWriter)
+
+	if ratio := float64(r.Requests-r.Succeeded) / float64(r.Requests); ratio > ts.cfg.MaxFailureRatio {
+		return fmt.Errorf("cross-cluster failure ratio %.4f > %.4f (failures %v)", ratio, ts.cfg.MaxFailureRatio, r.Failures)
+	}
+	if r.LatencyP99 > ts.cfg.LatencyP99Threshold {
+		return fmt.Errorf("cross-cluster p99 latency %v > %v", r.LatencyP99, ts.cfg.LatencyP99Threshold)
+	}
+	return nil
+}
+
+func (ts *tester) readPodLogs(podName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rc, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		GetLogs(podName, &core_v1.PodLogOptions{Container: jobName}).
+		Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
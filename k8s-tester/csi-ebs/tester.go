@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -86,6 +87,25 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 }
 
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
 type tester struct {
 	cfg *Config
 }
@@ -183,7 +203,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -416,8 +437,8 @@ func (ts *tester) deletePersistentVolumeClaim() error {
 	return nil
 }
 
-// dynamically provision a volume from the PVC without pod mount/startup failure
-func (ts *tester) provisionPVC() error {
+// createProvisionPod creates the Pod that mounts the PVC to dynamically provision the volume
+func (ts *tester) createProvisionPod() error {
 	var gracePeriod int64 = 1
 	ts.cfg.Logger.Info("creating Pod to test volume provisioning", zap.String("Pod", provisionPodName))
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
@@ -471,12 +492,20 @@ func (ts *tester) provisionPVC() error {
 	if err != nil {
 		return fmt.Errorf("failed to create VolumeProvision Pod for provisionPVC test: (%v)", err)
 	}
+	return nil
+}
+
+// dynamically provision a volume from the PVC without pod mount/startup failure
+func (ts *tester) provisionPVC() error {
+	if err := ts.createProvisionPod(); err != nil {
+		return err
+	}
 
 	// wait for Pod to spawn
 	time.Sleep(20 * time.Second)
 
 	ts.cfg.Logger.Info("retrieving Dynamic Provisioed Claim on Pod", zap.String("claim", pvcProvisionName))
-	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	claim, err := ts.cfg.Client.KubernetesClient().
 		CoreV1().
 		PersistentVolumeClaims(ts.cfg.Namespace).
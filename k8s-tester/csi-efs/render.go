@@ -0,0 +1,34 @@
+package csi_efs
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the Helm values, the StorageClass and the PersistentVolumeClaim,
+// against a fake client.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := fake.WriteValues(w, chartName, values); err != nil {
+		return err
+	}
+	if err := rt.createSC(); err != nil {
+		return err
+	}
+	if err := rt.createPVC(); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -0,0 +1,190 @@
+// k8s-tester-csi-s3 installs the Mountpoint for Amazon S3 CSI driver and tests S3 workloads.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-k8s-tester/client"
+	csi_s3 "github.com/aws/aws-k8s-tester/k8s-tester/csi-s3"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-csi-s3",
+	Short:      "Kubernetes Mountpoint for Amazon S3 CSI driver tester",
+	SuggestFor: []string{"csi-s3"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	partition          string
+	region             string
+	s3BucketCreate     bool
+	s3BucketName       string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", csi_s3.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", csi_s3.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for S3 bucket")
+	rootCmd.PersistentFlags().BoolVar(&s3BucketCreate, "s3-bucket-create", csi_s3.DefaultS3BucketCreate, "'true' to create the S3 bucket and delete it on delete")
+	rootCmd.PersistentFlags().StringVar(&s3BucketName, "s3-bucket-name", "", "S3 bucket name to mount (default to namespace, if created)")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-csi-s3 failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	helmChartRepoURL       string
+	roleARN                string
+	files                  int
+	fileSizeMiB            int
+	minWriteThroughputMBps float64
+	minReadThroughputMBps  float64
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&helmChartRepoURL, "helm-chart-repo-url", csi_s3.DefaultHelmChartRepoURL, "helm chart repo URL")
+	cmd.PersistentFlags().StringVar(&roleARN, "role-arn", "", "IAM role ARN for the driver node service account (IRSA)")
+	cmd.PersistentFlags().IntVar(&files, "files", csi_s3.DefaultFiles, "number of files to write and read")
+	cmd.PersistentFlags().IntVar(&fileSizeMiB, "file-size-mib", csi_s3.DefaultFileSizeMiB, "size of each file in MiB")
+	cmd.PersistentFlags().Float64Var(&minWriteThroughputMBps, "min-write-throughput-mbps", csi_s3.DefaultMinWriteThroughputMBps, "minimum write throughput in MB/s (0 to skip)")
+	cmd.PersistentFlags().Float64Var(&minReadThroughputMBps, "min-read-throughput-mbps", csi_s3.DefaultMinReadThroughputMBps, "minimum read throughput in MB/s (0 to skip)")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &csi_s3.Config{
+		Prompt:                 prompt,
+		Logger:                 lg,
+		LogWriter:              logWriter,
+		MinimumNodes:           minimumNodes,
+		Namespace:              namespace,
+		Client:                 cli,
+		Partition:              partition,
+		Region:                 region,
+		HelmChartRepoURL:       helmChartRepoURL,
+		RoleARN:                roleARN,
+		S3BucketCreate:         s3BucketCreate,
+		S3BucketName:           s3BucketName,
+		Files:                  files,
+		FileSizeMiB:            fileSizeMiB,
+		MinWriteThroughputMBps: minWriteThroughputMBps,
+		MinReadThroughputMBps:  minReadThroughputMBps,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := csi_s3.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-csi-s3 apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	if s3BucketName == "" {
+		s3BucketName = namespace
+	}
+	cfg := &csi_s3.Config{
+		Prompt:         prompt,
+		Logger:         lg,
+		LogWriter:      logWriter,
+		Namespace:      namespace,
+		Client:         cli,
+		Partition:      partition,
+		Region:         region,
+		S3BucketCreate: s3BucketCreate,
+		S3BucketName:   s3BucketName,
+	}
+
+	ts := csi_s3.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-csi-s3 delete' success\n")
+}
@@ -0,0 +1,780 @@
+// Package csi_s3 installs the Mountpoint for Amazon S3 CSI driver,
+// mounts an S3 bucket in a Pod, and validates read/write/list workloads
+// and throughput.
+// ref. https://github.com/awslabs/mountpoint-s3-csi-driver
+//
+// The driver only supports static provisioning, thus the tester creates
+// a PersistentVolume that references the bucket. Set "RoleARN" to use IRSA
+// for the driver's node service account; otherwise, the node instance role
+// must allow access to the bucket.
+package csi_s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	api_resource "k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	S3API s3iface.S3API `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// HelmChartRepoURL is the Mountpoint for Amazon S3 CSI driver helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+	// RoleARN is the IAM role ARN for the driver's node service account (IRSA).
+	// Leave empty to use the node instance role.
+	RoleARN string `json:"role_arn"`
+
+	// S3BucketCreate is true to create the S3 bucket and delete it on "Delete".
+	S3BucketCreate bool `json:"s3_bucket_create"`
+	// S3BucketName is the S3 bucket name to mount.
+	S3BucketName string `json:"s3_bucket_name"`
+
+	// Files is the number of files to write and read.
+	Files int `json:"files"`
+	// FileSizeMiB is the size of each file in MiB.
+	FileSizeMiB int `json:"file_size_mib"`
+	// MinWriteThroughputMBps is the minimum write throughput in MB/s.
+	// Zero to skip the check.
+	MinWriteThroughputMBps float64 `json:"min_write_throughput_mbps"`
+	// MinReadThroughputMBps is the minimum read throughput in MB/s.
+	// Zero to skip the check.
+	MinReadThroughputMBps float64 `json:"min_read_throughput_mbps"`
+
+	// WriteThroughputMBps is the write throughput in MB/s measured in the workload Pod.
+	WriteThroughputMBps float64 `json:"write_throughput_mbps" read-only:"true"`
+	// ReadThroughputMBps is the read throughput in MB/s measured in the workload Pod.
+	ReadThroughputMBps float64 `json:"read_throughput_mbps" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.S3BucketName == "" {
+		if !cfg.S3BucketCreate {
+			return errors.New("empty S3BucketName with S3BucketCreate false")
+		}
+		cfg.S3BucketName = cfg.Namespace
+	}
+	// ref. https://docs.aws.amazon.com/AmazonS3/latest/userguide/bucketnamingrules.html
+	if len(cfg.S3BucketName) > 63 {
+		cfg.S3BucketName = cfg.S3BucketName[:63]
+	}
+	if cfg.Files == 0 {
+		cfg.Files = DefaultFiles
+	}
+	if cfg.FileSizeMiB == 0 {
+		cfg.FileSizeMiB = DefaultFileSizeMiB
+	}
+	if cfg.MinWriteThroughputMBps < 0 {
+		return fmt.Errorf("invalid MinWriteThroughputMBps %v", cfg.MinWriteThroughputMBps)
+	}
+	if cfg.MinReadThroughputMBps < 0 {
+		return fmt.Errorf("invalid MinReadThroughputMBps %v", cfg.MinReadThroughputMBps)
+	}
+	return nil
+}
+
+const (
+	DefaultMinimumNodes           int     = 1
+	DefaultPartition                      = "aws"
+	DefaultHelmChartRepoURL               = "https://github.com/awslabs/mountpoint-s3-csi-driver/releases/download/helm-chart-aws-mountpoint-s3-csi-driver-1.4.0/aws-mountpoint-s3-csi-driver-1.4.0.tgz"
+	DefaultS3BucketCreate                 = true
+	DefaultFiles                  int     = 5
+	DefaultFileSizeMiB            int     = 100
+	DefaultMinWriteThroughputMBps float64 = 5
+	DefaultMinReadThroughputMBps  float64 = 5
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:                 false,
+		Prompt:                 false,
+		Partition:              DefaultPartition,
+		MinimumNodes:           DefaultMinimumNodes,
+		Namespace:              pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL:       DefaultHelmChartRepoURL,
+		S3BucketCreate:         DefaultS3BucketCreate,
+		Files:                  DefaultFiles,
+		FileSizeMiB:            DefaultFileSizeMiB,
+		MinWriteThroughputMBps: DefaultMinWriteThroughputMBps,
+		MinReadThroughputMBps:  DefaultMinReadThroughputMBps,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.S3API = s3.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	chartName          = "aws-mountpoint-s3-csi-driver"
+	chartNamespace     = "kube-system"
+	driverName         = "s3.csi.aws.com"
+	nodeServiceAccount = "s3-csi-driver-sa"
+	pvcName            = "s3-claim"
+	jobName            = "s3-workload"
+	jobImageName       = "public.ecr.aws/amazonlinux/amazonlinux:2023"
+	mountPath          = "/data"
+)
+
+// PersistentVolume is cluster-scoped, thus named after the namespace.
+func (ts *tester) pvName() string {
+	return ts.cfg.Namespace + "-pv"
+}
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if ts.cfg.S3BucketCreate {
+		if err := aws_s3.CreateBucket(ts.cfg.Logger, ts.cfg.S3API, ts.cfg.S3BucketName, ts.cfg.Region, "", 0); err != nil {
+			return err
+		}
+	}
+
+	if err := ts.installChart(); err != nil {
+		return err
+	}
+
+	if err := ts.createPV(); err != nil {
+		return err
+	}
+
+	if err := ts.createPVC(); err != nil {
+		return err
+	}
+
+	if err := ts.createJob(); err != nil {
+		return err
+	}
+
+	if err := ts.checkJob(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	ts.cfg.Logger.Info("deleting Job", zap.String("name", jobName))
+	if err := client.DeleteJob(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, jobName); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+	}
+
+	if err := ts.deletePVC(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := ts.deletePV(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := ts.deleteChart(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete helm chart (%v)", err))
+	}
+
+	if ts.cfg.S3BucketCreate {
+		if err := aws_s3.EmptyBucket(ts.cfg.Logger, ts.cfg.S3API, ts.cfg.S3BucketName); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to empty S3 bucket (%v)", err))
+		} else if err = aws_s3.DeleteBucket(ts.cfg.Logger, ts.cfg.S3API, ts.cfg.S3BucketName); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete S3 bucket (%v)", err))
+		}
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// https://github.com/awslabs/mountpoint-s3-csi-driver/blob/main/charts/aws-mountpoint-s3-csi-driver/values.yaml
+func (ts *tester) installChart() error {
+	values := map[string]interface{}{}
+	if ts.cfg.RoleARN != "" {
+		values["node"] = map[string]interface{}{
+			"serviceAccount": map[string]interface{}{
+				"create": true,
+				"name":   nodeServiceAccount,
+				"annotations": map[string]interface{}{
+					"eks.amazonaws.com/role-arn": ts.cfg.RoleARN,
+				},
+			},
+		}
+	}
+
+	getAllArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + chartNamespace,
+		"get",
+		"all",
+		"--selector=app.kubernetes.io/name=" + chartName,
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        10 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      chartNamespace,
+		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+		Values:         values,
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteChart() error {
+	ts.cfg.Logger.Info("deleting helm chart", zap.String("helm-chart-name", chartName))
+	err := helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        3 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      chartNamespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+	})
+	if err == nil {
+		ts.cfg.Logger.Info("deleted helm chart", zap.String("namespace", chartNamespace), zap.String("name", chartName))
+		return nil
+	}
+	if k8s_errors.IsNotFound(err) || k8s_errors.IsGone(err) {
+		ts.cfg.Logger.Info("helm chart already deleted", zap.String("namespace", chartNamespace), zap.String("name", chartName), zap.Error(err))
+		return nil
+	}
+	ts.cfg.Logger.Warn("failed to delete helm chart", zap.String("namespace", chartNamespace), zap.String("name", chartName), zap.Error(err))
+	return err
+}
+
+func (ts *tester) createPV() error {
+	ts.cfg.Logger.Info("creating PersistentVolume for S3", zap.String("name", ts.pvName()), zap.String("bucket", ts.cfg.S3BucketName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().CoreV1().PersistentVolumes().Create(
+		ctx,
+		&core_v1.PersistentVolume{
+			TypeMeta: meta_v1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PersistentVolume",
+			},
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name: ts.pvName(),
+			},
+			Spec: core_v1.PersistentVolumeSpec{
+				// ignored, required
+				Capacity: core_v1.ResourceList{
+					core_v1.ResourceStorage: api_resource.MustParse("1200Gi"),
+				},
+				AccessModes:                   []core_v1.PersistentVolumeAccessMode{core_v1.ReadWriteMany},
+				PersistentVolumeReclaimPolicy: core_v1.PersistentVolumeReclaimRetain,
+				// ref. https://github.com/awslabs/mountpoint-s3/blob/main/doc/CONFIGURATION.md
+				MountOptions: []string{
+					"allow-delete",
+					"region " + ts.cfg.Region,
+				},
+				ClaimRef: &core_v1.ObjectReference{
+					Namespace: ts.cfg.Namespace,
+					Name:      pvcName,
+				},
+				PersistentVolumeSource: core_v1.PersistentVolumeSource{
+					CSI: &core_v1.CSIPersistentVolumeSource{
+						Driver:       driverName,
+						VolumeHandle: ts.cfg.S3BucketName,
+						VolumeAttributes: map[string]string{
+							"bucketName": ts.cfg.S3BucketName,
+						},
+					},
+				},
+			},
+		},
+		meta_v1.CreateOptions{},
+	)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("PersistentVolume already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create PersistentVolume (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created PersistentVolume for S3")
+	return nil
+}
+
+func (ts *tester) deletePV() error {
+	ts.cfg.Logger.Info("deleting PersistentVolume for S3", zap.String("name", ts.pvName()))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	err := ts.cfg.Client.KubernetesClient().CoreV1().PersistentVolumes().Delete(
+		ctx,
+		ts.pvName(),
+		meta_v1.DeleteOptions{
+			PropagationPolicy: &foreground,
+		},
+	)
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PersistentVolume (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("deleted PersistentVolume for S3")
+	return nil
+}
+
+func (ts *tester) createPVC() error {
+	scName := ""
+	ts.cfg.Logger.Info("creating PersistentVolumeClaim for S3", zap.String("name", pvcName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().CoreV1().PersistentVolumeClaims(ts.cfg.Namespace).Create(
+		ctx,
+		&core_v1.PersistentVolumeClaim{
+			TypeMeta: meta_v1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+			},
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      pvcName,
+				Namespace: ts.cfg.Namespace,
+			},
+			Spec: core_v1.PersistentVolumeClaimSpec{
+				AccessModes: []core_v1.PersistentVolumeAccessMode{core_v1.ReadWriteMany},
+				// empty for static provisioning
+				StorageClassName: &scName,
+				VolumeName:       ts.pvName(),
+				Resources: core_v1.VolumeResourceRequirements{
+					Requests: core_v1.ResourceList{
+						core_v1.ResourceStorage: api_resource.MustParse("1200Gi"),
+					},
+				},
+			},
+		},
+		meta_v1.CreateOptions{},
+	)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("PersistentVolumeClaim already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create PersistentVolumeClaim (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created PersistentVolumeClaim for S3")
+	return nil
+}
+
+var foreground = meta_v1.DeletePropagationForeground
+
+func (ts *tester) deletePVC() error {
+	ts.cfg.Logger.Info("deleting PersistentVolumeClaim for S3", zap.String("name", pvcName))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	err := ts.cfg.Client.KubernetesClient().CoreV1().PersistentVolumeClaims(ts.cfg.Namespace).Delete(
+		ctx,
+		pvcName,
+		meta_v1.DeleteOptions{
+			PropagationPolicy: &foreground,
+		},
+	)
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PersistentVolumeClaim (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("deleted PersistentVolumeClaim for S3")
+	return nil
+}
+
+// workloadTmpl writes, lists, reads, and removes files under a per-namespace
+// prefix in the mounted bucket. Mountpoint only supports sequential writes
+// to new files, thus each file is written once with "dd".
+const workloadTmpl = `set -euo pipefail
+dir={{ .MountPath }}/{{ .Prefix }}
+mkdir -p ${dir}
+
+start=$(date +%s%N)
+for i in $(seq 1 {{ .Files }}); do
+  dd if=/dev/zero of=${dir}/file-${i} bs=1M count={{ .FileSizeMiB }} status=none
+done
+end=$(date +%s%N)
+echo "WRITE_BYTES=$(({{ .Files }}*{{ .FileSizeMiB }}*1024*1024)) WRITE_NANOSECONDS=$((end-start))"
+
+listed=$(ls ${dir} | wc -l)
+echo "LISTED_FILES=${listed}"
+
+start=$(date +%s%N)
+for i in $(seq 1 {{ .Files }}); do
+  dd if=${dir}/file-${i} of=/dev/null bs=1M status=none
+done
+end=$(date +%s%N)
+echo "READ_BYTES=$(({{ .Files }}*{{ .FileSizeMiB }}*1024*1024)) READ_NANOSECONDS=$((end-start))"
+
+rm -f ${dir}/file-*
+`
+
+func (ts *tester) createJob() error {
+	tpl := template.Must(template.New("workloadTmpl").Parse(workloadTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, struct {
+		MountPath   string
+		Prefix      string
+		Files       int
+		FileSizeMiB int
+	}{
+		MountPath:   mountPath,
+		Prefix:      ts.cfg.Namespace,
+		Files:       ts.cfg.Files,
+		FileSizeMiB: ts.cfg.FileSizeMiB,
+	}); err != nil {
+		return err
+	}
+
+	var backoffLimit int32
+	ts.cfg.Logger.Info("creating S3 workload Job", zap.String("name", jobName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           jobImageName,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/bash", "-c", buf.String()},
+									VolumeMounts: []core_v1.VolumeMount{
+										{
+											Name:      "persistent-storage",
+											MountPath: mountPath,
+										},
+									},
+								},
+							},
+							Volumes: []core_v1.Volume{
+								{
+									Name: "persistent-storage",
+									VolumeSource: core_v1.VolumeSource{
+										PersistentVolumeClaim: &core_v1.PersistentVolumeClaimVolumeSource{
+											ClaimName: pvcName,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("S3 workload Job already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create S3 workload Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created S3 workload Job")
+	return nil
+}
+
+func (ts *tester) checkJob() error {
+	timeout := 10*time.Minute + time.Duration(ts.cfg.Files*ts.cfg.FileSizeMiB)*time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		time.Minute,
+		5*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	var logs string
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		logs, err = client.CheckPodLogs(
+			ts.cfg.Logger,
+			ts.cfg.LogWriter,
+			ts.cfg.Stopc,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			pod.Name,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to get S3 workload Pod logs (%v)", err)
+		}
+		break
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\nS3 workload Job output:\n\n%s\n\n", logs)
+
+	rs, err := parseWorkloadOutput(logs)
+	if err != nil {
+		return err
+	}
+	if rs["LISTED_FILES"] != int64(ts.cfg.Files) {
+		return fmt.Errorf("expected %d files listed, got %d", ts.cfg.Files, rs["LISTED_FILES"])
+	}
+	ts.cfg.WriteThroughputMBps = throughputMBps(rs["WRITE_BYTES"], rs["WRITE_NANOSECONDS"])
+	ts.cfg.ReadThroughputMBps = throughputMBps(rs["READ_BYTES"], rs["READ_NANOSECONDS"])
+	ts.cfg.Logger.Info("measured S3 throughput",
+		zap.Float64("write-mbps", ts.cfg.WriteThroughputMBps),
+		zap.Float64("read-mbps", ts.cfg.ReadThroughputMBps),
+	)
+
+	if ts.cfg.MinWriteThroughputMBps > 0 && ts.cfg.WriteThroughputMBps < ts.cfg.MinWriteThroughputMBps {
+		return fmt.Errorf("write throughput %.2f MB/s is below threshold %.2f MB/s", ts.cfg.WriteThroughputMBps, ts.cfg.MinWriteThroughputMBps)
+	}
+	if ts.cfg.MinReadThroughputMBps > 0 && ts.cfg.ReadThroughputMBps < ts.cfg.MinReadThroughputMBps {
+		return fmt.Errorf("read throughput %.2f MB/s is below threshold %.2f MB/s", ts.cfg.ReadThroughputMBps, ts.cfg.MinReadThroughputMBps)
+	}
+	return nil
+}
+
+var workloadKeys = []string{
+	"WRITE_BYTES",
+	"WRITE_NANOSECONDS",
+	"LISTED_FILES",
+	"READ_BYTES",
+	"READ_NANOSECONDS",
+}
+
+// parseWorkloadOutput parses "KEY=VALUE" pairs from the workload Pod logs.
+func parseWorkloadOutput(logs string) (map[string]int64, error) {
+	rs := make(map[string]int64)
+	for _, line := range strings.Split(logs, "\n") {
+		for _, field := range strings.Fields(line) {
+			ss := strings.SplitN(field, "=", 2)
+			if len(ss) != 2 {
+				continue
+			}
+			v, err := strconv.ParseInt(ss[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			rs[ss[0]] = v
+		}
+	}
+	for _, k := range workloadKeys {
+		if _, ok := rs[k]; !ok {
+			return nil, fmt.Errorf("%q not found in S3 workload output", k)
+		}
+	}
+	return rs, nil
+}
+
+func throughputMBps(n int64, nanoseconds int64) float64 {
+	if nanoseconds <= 0 {
+		return 0
+	}
+	return float64(n) / 1000 / 1000 / (float64(nanoseconds) / float64(time.Second))
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/client"
 	"github.com/aws/aws-k8s-tester/k8s-tester/csrs"
@@ -59,6 +60,10 @@ var (
 	clients                     int
 	objects                     int
 	initialRequestConditionType string
+	signerName                  string
+	autoApprove                 bool
+	verifyIssued                bool
+	issueTimeout                time.Duration
 )
 
 func newApply() *cobra.Command {
@@ -70,6 +75,10 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().IntVar(&clients, "clients", 5, "number of clients")
 	cmd.PersistentFlags().IntVar(&objects, "objects", csrs.DefaultObjects, "number of objects")
 	cmd.PersistentFlags().StringVar(&initialRequestConditionType, "initial-condition-type", csrs.DefaultInitialRequestConditionType, "initial CSR condition type")
+	cmd.PersistentFlags().StringVar(&signerName, "signer-name", csrs.DefaultSignerName, "CSR signer name (e.g., kubernetes.io/kubelet-serving, kubernetes.io/kube-apiserver-client, or custom)")
+	cmd.PersistentFlags().BoolVar(&autoApprove, "auto-approve", false, "'true' to approve the pending CSRs")
+	cmd.PersistentFlags().BoolVar(&verifyIssued, "verify-issued", false, "'true' to verify that the certificates are issued for the approved CSRs")
+	cmd.PersistentFlags().DurationVar(&issueTimeout, "issue-timeout", csrs.DefaultIssueTimeout, "timeout to wait for the certificates to be issued")
 	return cmd
 }
 
@@ -99,6 +108,13 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		Client:                      cli,
 		Objects:                     objects,
 		InitialRequestConditionType: initialRequestConditionType,
+		SignerName:                  signerName,
+		AutoApprove:                 autoApprove,
+		VerifyIssued:                verifyIssued,
+		IssueTimeout:                issueTimeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
 	}
 
 	ts := csrs.New(cfg)
@@ -108,7 +124,7 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 	}
 
 	fmt.Printf("\n*********************************\n")
-	fmt.Printf("'k8s-tester-csrs apply' success\n")
+	fmt.Printf("'k8s-tester-csrs apply' success (approved %d, denied %d, issued %d)\n", cfg.ApprovedTotal, cfg.DeniedTotal, cfg.IssuedTotal)
 }
 
 func newDelete() *cobra.Command {
@@ -0,0 +1,36 @@
+package csrs
+
+import (
+	"context"
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the first CertificateSigningRequest of the writes, against a fake client.
+// The private key is generated for each request, so a placeholder request is rendered.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	key := "csr0"
+	_, usages, err := createRequest(cfg.SignerName, key)
+	if err != nil {
+		return err
+	}
+	_, err = cli.KubernetesClient().CertificatesV1().CertificateSigningRequests().Create(context.Background(), rt.csrObject(key, []byte(fake.Redacted), usages), meta_v1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -1,13 +1,21 @@
-// Package csrs a simple pi Pod with Job.
+// Package csrs creates CertificateSigningRequests, optionally approves them,
+// and verifies that the signer issues the certificates.
 // Replace https://github.com/aws/aws-k8s-tester/tree/v1.5.9/eks/csrs.
 package csrs
 
 import (
 	"context"
-	"encoding/base64"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"os"
 	"path"
 	"reflect"
 	"sort"
@@ -22,7 +30,9 @@ import (
 	"github.com/manifoldco/promptui"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
-	certificates_v1beta1 "k8s.io/api/certificates/v1beta1"
+	certificates_v1 "k8s.io/api/certificates/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -78,15 +88,46 @@ type Config struct {
 	// to simulate CSR condition.
 	//
 	// Valid values are:
-	//   "k8s.io/api/certificates/v1beta1.CertificateApproved" == "Approved"
-	//   "k8s.io/api/certificates/v1beta1.CertificateDenied" == "Denied"
+	//   "k8s.io/api/certificates/v1.CertificateApproved" == "Approved"
+	//   "k8s.io/api/certificates/v1.CertificateDenied" == "Denied"
 	//   "Random"
 	//   "Pending"
 	//   ""
 	//
+	// The condition is set via the "approval" subresource after creation,
+	// since the status on create is ignored by the API server.
 	InitialRequestConditionType string `json:"initial_request_condition_type"`
 
+	// SignerName is the CSR signer name.
+	//
+	// Valid values are:
+	//   "kubernetes.io/kube-apiserver-client"
+	//   "kubernetes.io/kube-apiserver-client-kubelet"
+	//   "kubernetes.io/kubelet-serving"
+	//   or any custom signer name in the form of "<domain>/<path>"
+	//
+	// The CSR subject and key usages are generated to satisfy the signer.
+	// ref. https://kubernetes.io/docs/reference/access-authn-authz/certificate-signing-requests/#kubernetes-signers
+	SignerName string `json:"signer_name"`
+	// AutoApprove is true to approve the CSRs that are still pending
+	// after the initial condition, as an external approver would.
+	AutoApprove bool `json:"auto_approve"`
+	// VerifyIssued is true to wait for the signer to issue the certificates
+	// for all approved CSRs, and to fail if any certificate is not issued.
+	// Custom signers require a signer controller running in the cluster.
+	VerifyIssued bool `json:"verify_issued"`
+	// IssueTimeout is the timeout to wait for the certificates to be issued.
+	IssueTimeout       time.Duration `json:"issue_timeout"`
+	IssueTimeoutString string        `json:"issue_timeout_string" read-only:"true"`
+
 	LatencySummary latency.Summary `json:"latency_summary" read-only:"true"`
+
+	// ApprovedTotal is the number of approved CSRs.
+	ApprovedTotal int `json:"approved_total" read-only:"true"`
+	// DeniedTotal is the number of denied CSRs.
+	DeniedTotal int `json:"denied_total" read-only:"true"`
+	// IssuedTotal is the number of approved CSRs with the issued certificates.
+	IssuedTotal int `json:"issued_total" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
@@ -103,6 +144,20 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		return fmt.Errorf("unknown InitialRequestConditionType %q", cfg.InitialRequestConditionType)
 	}
 
+	if cfg.SignerName == "" {
+		cfg.SignerName = DefaultSignerName
+	}
+	if !strings.Contains(cfg.SignerName, "/") {
+		return fmt.Errorf("invalid SignerName %q (expected '<domain>/<path>')", cfg.SignerName)
+	}
+	if cfg.VerifyIssued && !cfg.AutoApprove && cfg.InitialRequestConditionType != "Approved" && cfg.InitialRequestConditionType != "Random" {
+		return errors.New("VerifyIssued requires AutoApprove or approved InitialRequestConditionType")
+	}
+	if cfg.IssueTimeout == time.Duration(0) {
+		cfg.IssueTimeout = DefaultIssueTimeout
+	}
+	cfg.IssueTimeoutString = cfg.IssueTimeout.String()
+
 	return nil
 }
 
@@ -110,6 +165,8 @@ const (
 	DefaultMinimumNodes                int    = 1
 	DefaultObjects                     int    = 10 // 1000 objects generates 5 MB data to etcd
 	DefaultInitialRequestConditionType string = "Pending"
+	DefaultSignerName                  string = certificates_v1.KubeAPIServerClientSignerName
+	DefaultIssueTimeout                       = 5 * time.Minute
 )
 
 func NewDefault() *Config {
@@ -119,6 +176,8 @@ func NewDefault() *Config {
 		MinimumNodes:                DefaultMinimumNodes,
 		Objects:                     DefaultObjects,
 		InitialRequestConditionType: DefaultInitialRequestConditionType,
+		SignerName:                  DefaultSignerName,
+		IssueTimeout:                DefaultIssueTimeout,
 	}
 }
 
@@ -130,6 +189,25 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 }
 
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
 type tester struct {
 	cfg            *Config
 	donec          chan struct{}
@@ -157,7 +235,7 @@ func (ts *tester) Apply() error {
 		}
 	}
 
-	latencies := ts.startWrites()
+	names, latencies := ts.startWrites()
 	if len(latencies) == 0 {
 		ts.cfg.Logger.Warn("no latency collected")
 		return nil
@@ -200,6 +278,16 @@ func (ts *tester) Apply() error {
 	}
 	fmt.Fprintf(ts.cfg.LogWriter, "\n\nLatencySummary:\n%s\n", ts.cfg.LatencySummary.Table())
 
+	approved, err := ts.approve(names)
+	if err != nil {
+		return err
+	}
+	if ts.cfg.VerifyIssued {
+		if err = ts.verifyIssued(approved); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -214,6 +302,17 @@ func (ts *tester) Delete() error {
 
 	var errs []string
 
+	ts.cfg.Logger.Info("deleting csrs", zap.String("label", csrLabelKey))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		CertificatesV1().
+		CertificateSigningRequests().
+		DeleteCollection(ctx, meta_v1.DeleteOptions{}, meta_v1.ListOptions{LabelSelector: csrLabelKey + "=true"})
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		errs = append(errs, fmt.Sprintf("failed to delete csrs (%v)", err))
+	}
+
 	if len(errs) > 0 {
 		return errors.New(strings.Join(errs, ", "))
 	}
@@ -233,7 +332,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -243,8 +343,16 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 	return true
 }
 
-func (ts *tester) startWrites() (latencies latency.Durations) {
-	ts.cfg.Logger.Info("writing", zap.Int("objects", ts.cfg.Objects), zap.Int("object-size", ts.cfg.Objects))
+const (
+	// csrLabelKey labels the CSRs created by this tester for clean up.
+	csrLabelKey = "k8s-tester-csrs"
+	// approverReason is the reason for the conditions set by this tester.
+	approverReason = "K8sTesterCSRs"
+)
+
+func (ts *tester) startWrites() (names []string, latencies latency.Durations) {
+	ts.cfg.Logger.Info("writing", zap.Int("objects", ts.cfg.Objects), zap.String("signer-name", ts.cfg.SignerName))
+	names = make([]string, 0, ts.cfg.Objects)
 	latencies = make(latency.Durations, 0, 20000)
 
 	for i := 0; i < ts.cfg.Objects; i++ {
@@ -259,39 +367,18 @@ func (ts *tester) startWrites() (latencies latency.Durations) {
 		}
 
 		key := fmt.Sprintf("csr%d%s", i, rand.String(7))
-		cd := createCond(i, "test via "+key, ts.cfg.InitialRequestConditionType)
+		req, usages, err := createRequest(ts.cfg.SignerName, key)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to create certificate request", zap.Error(err))
+			continue
+		}
 
 		start := time.Now()
 		ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
-		_, err := ts.cfg.Client.KubernetesClient().
-			CertificatesV1beta1().
+		_, err = ts.cfg.Client.KubernetesClient().
+			CertificatesV1().
 			CertificateSigningRequests().
-			Create(ctx, &certificates_v1beta1.CertificateSigningRequest{
-				TypeMeta: meta_v1.TypeMeta{
-					APIVersion: "certificates.k8s.io/v1beta1",
-					Kind:       "CertificateSigningRequest",
-				},
-				ObjectMeta: meta_v1.ObjectMeta{
-					Name:              key,
-					GenerateName:      key,
-					CreationTimestamp: meta_v1.Time{Time: time.Now().Add(-20 * time.Minute)},
-				},
-				Spec: certificates_v1beta1.CertificateSigningRequestSpec{
-					Groups:  []string{"system:bootstrappers", "system:nodes", "system:authenticated"},
-					Request: reqData,
-					UID:     "heptio-authenticator-aws:280347406217:AROAUCRQB56EUYTYXXJKV",
-					Usages: []certificates_v1beta1.KeyUsage{
-						certificates_v1beta1.UsageDigitalSignature,
-						certificates_v1beta1.UsageKeyEncipherment,
-						certificates_v1beta1.UsageServerAuth,
-					},
-					Username: "system:node:ip-172-20-32-89.us-west-2.compute.internal",
-				},
-				Status: certificates_v1beta1.CertificateSigningRequestStatus{
-					Certificate: nil,
-					Conditions:  cd,
-				},
-			}, meta_v1.CreateOptions{})
+			Create(ctx, ts.csrObject(key, req, usages), meta_v1.CreateOptions{})
 		cancel()
 		took := time.Since(start)
 		tookMS := float64(took / time.Millisecond)
@@ -302,97 +389,205 @@ func (ts *tester) startWrites() (latencies latency.Durations) {
 			ts.cfg.Logger.Warn("write csr failed", zap.Error(err))
 		} else {
 			writeRequestsSuccessTotal.Inc()
+			names = append(names, key)
 			if i%20 == 0 {
 				ts.cfg.Logger.Info("wrote csr", zap.Int("iteration", i))
 			}
 		}
 	}
-	return latencies
-}
-
-var conds = []certificates_v1beta1.RequestConditionType{
-	certificates_v1beta1.CertificateApproved,
-	certificates_v1beta1.CertificateDenied,
-	certificates_v1beta1.RequestConditionType(""),
+	return names, latencies
 }
 
-func createCond(idx int, msg string, tp string) (cs []certificates_v1beta1.CertificateSigningRequestCondition) {
-	cs = []certificates_v1beta1.CertificateSigningRequestCondition{
-		{
-			Reason:         "Test",
-			Message:        msg,
-			LastUpdateTime: meta_v1.NewTime(time.Now().Add(-time.Hour)),
+// csrObject returns the CertificateSigningRequest to write with the certificate request.
+func (ts *tester) csrObject(key string, req []byte, usages []certificates_v1.KeyUsage) *certificates_v1.CertificateSigningRequest {
+	return &certificates_v1.CertificateSigningRequest{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "certificates.k8s.io/v1",
+			Kind:       "CertificateSigningRequest",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: key,
+			Labels: map[string]string{
+				csrLabelKey: "true",
+			},
+		},
+		Spec: certificates_v1.CertificateSigningRequestSpec{
+			Request:    req,
+			SignerName: ts.cfg.SignerName,
+			Usages:     usages,
 		},
 	}
+}
+
+var conds = []certificates_v1.RequestConditionType{
+	certificates_v1.CertificateApproved,
+	certificates_v1.CertificateDenied,
+	certificates_v1.RequestConditionType(""),
+}
+
+// pickCond returns the initial condition type for the CSR,
+// or an empty string to leave the CSR pending.
+func pickCond(idx int, tp string) certificates_v1.RequestConditionType {
 	switch tp {
-	case string(certificates_v1beta1.CertificateApproved):
-		cs[0].Type = certificates_v1beta1.CertificateApproved
-	case string(certificates_v1beta1.CertificateDenied):
-		cs[0].Type = certificates_v1beta1.CertificateDenied
-	case "Pending", "":
-		cs = make([]certificates_v1beta1.CertificateSigningRequestCondition, 0)
+	case string(certificates_v1.CertificateApproved):
+		return certificates_v1.CertificateApproved
+	case string(certificates_v1.CertificateDenied):
+		return certificates_v1.CertificateDenied
 	case "Random":
-		cs[0].Type = conds[idx%3]
+		return conds[idx%3]
 	}
-	return cs
+	return ""
 }
 
-var reqData, _ = base64.StdEncoding.DecodeString("LS0tLS1CRUdJTiBDRVJUSUZJQ0FURSBSRVFVRVNULS0tLS0KTUlJQnJEQ0NBVk1DQVFBd1dERVZNQk1HQTFVRUNoTU1jM2x6ZEdWdE9tNXZaR1Z6TVQ4d1BRWURWUVFERXpaegplWE4wWlcwNmJtOWtaVHBwY0MweE56SXRNakF0TXpJdE9Ea3VkWE10ZDJWemRDMHlMbU52YlhCMWRHVXVhVzUwClpYSnVZV3d3V1RBVEJnY3Foa2pPUFFJQkJnZ3Foa2pPUFFNQkJ3TkNBQVJGSzI3L2w4U2NtMXF1K2xXbEs5WFoKUUtVM0grSnFENTZuSEFYOXBUQ25YVWRQaUppemRzc01QaSs2emtCU1I2MXVJcVRsdnNIcjkwbFNyU2tQeDd1aQpvSUdZTUlHVkJna3Foa2lHOXcwQkNRNHhnWWN3Z1lRd2dZRUdBMVVkRVFSNk1IaUNNbVZqTWkwMU5DMHhPRFV0Ck1qUTJMVEV5T0M1MWN5MTNaWE4wTFRJdVkyOXRjSFYwWlM1aGJXRjZiMjVoZDNNdVkyOXRod1NzRkNCWmh3UTIKdWZhQWhqWnplWE4wWlcwNmJtOWtaVHBwY0MweE56SXRNakF0TXpJdE9Ea3VkWE10ZDJWemRDMHlMbU52YlhCMQpkR1V1YVc1MFpYSnVZV3d3Q2dZSUtvWkl6ajBFQXdJRFJ3QXdSQUlnVTUrNEFkWVcvRm9kdDExMmgvRjV4RHFQClFJS1BJemk4TUJMSTBBaVE2cGtDSUdqOHZPNDlTQldJVlo2SnhJL1lENldrRVhXdlZEbFp4cjFlZmVMM0NIeEgKLS0tLS1FTkQgQ0VSVElGSUNBVEUgUkVRVUVTVC0tLS0tCg==")
-
-/*
-https://kubernetes.io/docs/tasks/tls/managing-tls-in-a-cluster/
-
-$ cat <<EOF | cfssl genkey - | cfssljson -bare server
-{
-  "hosts": [
-    "ec2-54-185-246-128.us-west-2.compute.amazonaws.com",
-    "system:node:ip-172-20-32-89.us-west-2.compute.internal",
-    "172.20.32.89",
-    "54.185.246.128"
-  ],
-  "names": [
-    {
-      "O": "system:nodes"
-    }
-  ],
-  "CN": "system:node:ip-172-20-32-89.us-west-2.compute.internal",
-  "key": {
-    "algo": "ecdsa",
-    "size": 256
-  }
+// approve sets the initial conditions on the created CSRs,
+// and approves the pending ones if "AutoApprove" is true.
+// It returns the names of the approved CSRs.
+func (ts *tester) approve(names []string) (approved []string, err error) {
+	ts.cfg.ApprovedTotal, ts.cfg.DeniedTotal = 0, 0
+	for idx, name := range names {
+		tp := pickCond(idx, ts.cfg.InitialRequestConditionType)
+		if tp == "" && ts.cfg.AutoApprove {
+			tp = certificates_v1.CertificateApproved
+		}
+		if tp == "" {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
+		csr, err := ts.cfg.Client.KubernetesClient().CertificatesV1().CertificateSigningRequests().Get(ctx, name, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			return approved, fmt.Errorf("failed to get csr %q (%v)", name, err)
+		}
+		csr.Status.Conditions = append(csr.Status.Conditions, certificates_v1.CertificateSigningRequestCondition{
+			Type:           tp,
+			Status:         core_v1.ConditionTrue,
+			Reason:         approverReason,
+			Message:        fmt.Sprintf("%s by k8s-tester", strings.ToLower(string(tp))),
+			LastUpdateTime: meta_v1.Now(),
+		})
+		ctx, cancel = context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
+		_, err = ts.cfg.Client.KubernetesClient().CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, name, csr, meta_v1.UpdateOptions{})
+		cancel()
+		if err != nil {
+			return approved, fmt.Errorf("failed to update csr %q approval to %q (%v)", name, tp, err)
+		}
+
+		switch tp {
+		case certificates_v1.CertificateApproved:
+			ts.cfg.ApprovedTotal++
+			approved = append(approved, name)
+		case certificates_v1.CertificateDenied:
+			ts.cfg.DeniedTotal++
+		}
+	}
+	ts.cfg.Logger.Info("updated csr approvals",
+		zap.Int("approved", ts.cfg.ApprovedTotal),
+		zap.Int("denied", ts.cfg.DeniedTotal),
+		zap.Int("pending", len(names)-ts.cfg.ApprovedTotal-ts.cfg.DeniedTotal),
+	)
+	return approved, nil
+}
+
+// verifyIssued waits until the signer issues the certificates for all approved CSRs,
+// and checks that each issued certificate is valid.
+func (ts *tester) verifyIssued(names []string) error {
+	ts.cfg.Logger.Info("waiting for certificates to be issued", zap.Int("approved", len(names)), zap.String("timeout", ts.cfg.IssueTimeoutString))
+	ts.cfg.IssuedTotal = 0
+	pending := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		pending[name] = struct{}{}
+	}
+
+	deadline := time.Now().Add(ts.cfg.IssueTimeout)
+	for len(pending) > 0 && time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("certificate issue check aborted")
+		case <-ts.donec:
+			return errors.New("certificate issue check aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		for name := range pending {
+			ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
+			csr, err := ts.cfg.Client.KubernetesClient().CertificatesV1().CertificateSigningRequests().Get(ctx, name, meta_v1.GetOptions{})
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to get csr", zap.String("name", name), zap.Error(err))
+				continue
+			}
+			for _, cond := range csr.Status.Conditions {
+				if cond.Type == certificates_v1.CertificateFailed {
+					return fmt.Errorf("csr %q failed to be signed by %q (%s, %s)", name, ts.cfg.SignerName, cond.Reason, cond.Message)
+				}
+			}
+			if len(csr.Status.Certificate) == 0 {
+				continue
+			}
+			if err = checkCertificate(csr.Status.Certificate); err != nil {
+				return fmt.Errorf("csr %q issued invalid certificate (%v)", name, err)
+			}
+			delete(pending, name)
+			ts.cfg.IssuedTotal++
+		}
+		ts.cfg.Logger.Info("checked issued certificates", zap.Int("issued", ts.cfg.IssuedTotal), zap.Int("pending", len(pending)))
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%d certificate(s) not issued by %q within %v (issued %d)", len(pending), ts.cfg.SignerName, ts.cfg.IssueTimeout, ts.cfg.IssuedTotal)
+	}
+	ts.cfg.Logger.Info("all certificates issued", zap.Int("issued", ts.cfg.IssuedTotal))
+	return nil
+}
+
+// nodeName is the fake node name for the node client and serving certificates.
+const nodeName = "ip-172-20-32-89.us-west-2.compute.internal"
+
+// createRequest generates a PEM-encoded PKCS#10 certificate request
+// with the subject and key usages that the signer requires.
+// ref. https://kubernetes.io/docs/reference/access-authn-authz/certificate-signing-requests/#kubernetes-signers
+func createRequest(signerName string, key string) (req []byte, usages []certificates_v1.KeyUsage, err error) {
+	tmpl := &x509.CertificateRequest{}
+	switch signerName {
+	case certificates_v1.KubeletServingSignerName:
+		tmpl.Subject = pkix.Name{CommonName: "system:node:" + nodeName, Organization: []string{"system:nodes"}}
+		tmpl.DNSNames = []string{nodeName}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("172.20.32.89")}
+		usages = []certificates_v1.KeyUsage{certificates_v1.UsageDigitalSignature, certificates_v1.UsageServerAuth}
+	case certificates_v1.KubeAPIServerClientKubeletSignerName:
+		tmpl.Subject = pkix.Name{CommonName: "system:node:" + nodeName, Organization: []string{"system:nodes"}}
+		usages = []certificates_v1.KeyUsage{certificates_v1.UsageDigitalSignature, certificates_v1.UsageClientAuth}
+	default:
+		tmpl.Subject = pkix.Name{CommonName: key, Organization: []string{csrLabelKey}}
+		usages = []certificates_v1.KeyUsage{certificates_v1.UsageDigitalSignature, certificates_v1.UsageClientAuth}
+	}
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificateRequest(crand.Reader, tmpl, pk)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), usages, nil
+}
+
+// checkCertificate parses the issued PEM certificate,
+// and checks that it is valid now.
+func checkCertificate(data []byte) error {
+	blk, _ := pem.Decode(data)
+	if blk == nil || blk.Type != "CERTIFICATE" {
+		return errors.New("no PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(blk.Bytes)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if now.Before(cert.NotBefore.Add(-time.Minute)) || now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate %q not valid now (not before %v, not after %v)", cert.Subject.CommonName, cert.NotBefore, cert.NotAfter)
+	}
+	return nil
 }
-EOF
-
-
-$ openssl req -text -noout -in server.csr
-
-Certificate Request:
-    Data:
-        Version: 1 (0x0)
-        Subject: O = system:nodes, CN = system:node:ip-172-20-32-89.us-west-2.compute.internal
-        Subject Public Key Info:
-            Public Key Algorithm: id-ecPublicKey
-                Public-Key: (256 bit)
-                pub:
-                    04:45:2b:6e:ff:97:c4:9c:9b:5a:ae:fa:55:a5:2b:
-                    d5:d9:40:a5:37:1f:e2:6a:0f:9e:a7:1c:05:fd:a5:
-                    30:a7:5d:47:4f:88:98:b3:76:cb:0c:3e:2f:ba:ce:
-                    40:52:47:ad:6e:22:a4:e5:be:c1:eb:f7:49:52:ad:
-                    29:0f:c7:bb:a2
-                ASN1 OID: prime256v1
-                NIST CURVE: P-256
-        Attributes:
-        Requested Extensions:
-            X509v3 Subject Alternative Name:
-                DNS:ec2-54-185-246-128.us-west-2.compute.amazonaws.com, IP Address:172.20.32.89, IP Address:54.185.246.128, URI:system:node:ip-172-20-32-89.us-west-2.compute.internal
-    Signature Algorithm: ecdsa-with-SHA256
-         30:44:02:20:53:9f:b8:01:d6:16:fc:5a:1d:b7:5d:76:87:f1:
-         79:c4:3a:8f:40:82:8f:23:38:bc:30:12:c8:d0:08:90:ea:99:
-         02:20:68:fc:bc:ee:3d:48:15:88:55:9e:89:c4:8f:d8:0f:a5:
-         a4:11:75:af:54:39:59:c6:bd:5e:7d:e2:f7:08:7c:47
-
-$ cat server.csr | base64 --wrap=0
-
-$ echo "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURSBSRVFVRVNULS0tLS0KTUlJQnJEQ0NBVk1DQVFBd1dERVZNQk1HQTFVRUNoTU1jM2x6ZEdWdE9tNXZaR1Z6TVQ4d1BRWURWUVFERXpaegplWE4wWlcwNmJtOWtaVHBwY0MweE56SXRNakF0TXpJdE9Ea3VkWE10ZDJWemRDMHlMbU52YlhCMWRHVXVhVzUwClpYSnVZV3d3V1RBVEJnY3Foa2pPUFFJQkJnZ3Foa2pPUFFNQkJ3TkNBQVJGSzI3L2w4U2NtMXF1K2xXbEs5WFoKUUtVM0grSnFENTZuSEFYOXBUQ25YVWRQaUppemRzc01QaSs2emtCU1I2MXVJcVRsdnNIcjkwbFNyU2tQeDd1aQpvSUdZTUlHVkJna3Foa2lHOXcwQkNRNHhnWWN3Z1lRd2dZRUdBMVVkRVFSNk1IaUNNbVZqTWkwMU5DMHhPRFV0Ck1qUTJMVEV5T0M1MWN5MTNaWE4wTFRJdVkyOXRjSFYwWlM1aGJXRjZiMjVoZDNNdVkyOXRod1NzRkNCWmh3UTIKdWZhQWhqWnplWE4wWlcwNmJtOWtaVHBwY0MweE56SXRNakF0TXpJdE9Ea3VkWE10ZDJWemRDMHlMbU52YlhCMQpkR1V1YVc1MFpYSnVZV3d3Q2dZSUtvWkl6ajBFQXdJRFJ3QXdSQUlnVTUrNEFkWVcvRm9kdDExMmgvRjV4RHFQClFJS1BJemk4TUJMSTBBaVE2cGtDSUdqOHZPNDlTQldJVlo2SnhJL1lENldrRVhXdlZEbFp4cjFlZmVMM0NIeEgKLS0tLS1FTkQgQ0VSVElGSUNBVEUgUkVRVUVTVC0tLS0tCg==" | base64 --decode > /tmp/csr.out && openssl req -text -noout -in /tmp/csr.out
-
-*/
@@ -0,0 +1,32 @@
+package daemonset_rollout
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the DaemonSet as updated by the last strategy against a fake client.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createDaemonSet(); err != nil {
+		return err
+	}
+	for _, name := range cfg.strategyNames() {
+		if err := rt.updateDaemonSet(name, cfg.Strategies[name]); err != nil {
+			return err
+		}
+	}
+	return fake.Dump(w, cli)
+}
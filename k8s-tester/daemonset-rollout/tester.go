@@ -0,0 +1,554 @@
+// Package daemonset_rollout deploys a DaemonSet across all nodes, and updates it
+// with each rolling update strategy (e.g., "maxUnavailable" and "maxSurge" variations),
+// measuring the rollout completion time versus the node count, and detecting
+// the nodes where the updated Pod never becomes ready.
+// ref. https://kubernetes.io/docs/tasks/manage-daemon/update-daemon-set/
+package daemonset_rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Strategy is the rolling update strategy of a DaemonSet update.
+// ref. https://kubernetes.io/docs/reference/kubernetes-api/workload-resources/daemon-set-v1/#DaemonSetSpec
+type Strategy struct {
+	// MaxUnavailable is the number (e.g., "1") or the percentage (e.g., "10%")
+	// of the nodes whose Pod can be unavailable during the update.
+	// Must be "0" if "MaxSurge" is set.
+	MaxUnavailable string `json:"max_unavailable"`
+	// MaxSurge is the number or the percentage of the nodes that can run
+	// the updated Pod along with the old Pod during the update.
+	MaxSurge string `json:"max_surge,omitempty"`
+}
+
+// RolloutResult is the result of a DaemonSet update.
+type RolloutResult struct {
+	// Nodes is the number of the nodes scheduled to run the DaemonSet Pod.
+	Nodes int32 `json:"nodes"`
+	// Took is the time from the update to all Pods updated and available.
+	Took       time.Duration `json:"took"`
+	TookString string        `json:"took_string"`
+	// TookPerNode is "Took" divided by "Nodes".
+	TookPerNode       time.Duration `json:"took_per_node"`
+	TookPerNodeString string        `json:"took_per_node_string"`
+	// StuckNodes is the nodes where the updated Pod never became ready.
+	StuckNodes []string `json:"stuck_nodes,omitempty"`
+}
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the image of the DaemonSet Pods.
+	Image string `json:"image"`
+	// Strategies is the rolling update strategies keyed by the strategy name
+	// (e.g., {"surge":{"max_unavailable":"0","max_surge":"10%"}}).
+	// The DaemonSet is updated once per strategy, in the order of the names.
+	Strategies map[string]*Strategy `json:"strategies"`
+	// RolloutTimeout is the timeout of each rollout.
+	// The nodes where the updated Pod is not ready by then are reported as stuck.
+	RolloutTimeout       time.Duration `json:"rollout_timeout"`
+	RolloutTimeoutString string        `json:"rollout_timeout_string" read-only:"true"`
+
+	// Results is the rollout results keyed by the strategy name,
+	// with the initial rollout as "create".
+	Results map[string]*RolloutResult `json:"results" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if len(cfg.Strategies) == 0 {
+		cfg.Strategies = DefaultStrategies()
+	}
+	for name, st := range cfg.Strategies {
+		if name == createRolloutName {
+			return fmt.Errorf("strategy name %q is reserved for the initial rollout", name)
+		}
+		if st == nil {
+			return fmt.Errorf("empty strategy %q", name)
+		}
+		if err := st.validate(); err != nil {
+			return fmt.Errorf("invalid strategy %q (%v)", name, err)
+		}
+	}
+	if cfg.RolloutTimeout == time.Duration(0) {
+		cfg.RolloutTimeout = DefaultRolloutTimeout
+	}
+	cfg.RolloutTimeoutString = cfg.RolloutTimeout.String()
+	return nil
+}
+
+// strategyNames returns the strategy names in the rollout order.
+func (cfg *Config) strategyNames() []string {
+	names := make([]string, 0, len(cfg.Strategies))
+	for name := range cfg.Strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (st *Strategy) validate() error {
+	unavailable := intstr.Parse(st.MaxUnavailable)
+	surge := intstr.Parse(st.MaxSurge)
+	if st.MaxUnavailable == "" {
+		unavailable = intstr.FromInt(0)
+	}
+	if st.MaxSurge == "" {
+		surge = intstr.FromInt(0)
+	}
+	// same as the API server validation
+	// ref. https://github.com/kubernetes/kubernetes/blob/v1.29.0/pkg/apis/apps/validation/validation.go
+	isZero := func(v intstr.IntOrString) bool {
+		if v.Type == intstr.Int {
+			return v.IntVal == 0
+		}
+		return strings.TrimSuffix(v.StrVal, "%") == "0"
+	}
+	if isZero(unavailable) && isZero(surge) {
+		return errors.New("MaxUnavailable and MaxSurge cannot be both zero")
+	}
+	if !isZero(unavailable) && !isZero(surge) {
+		return errors.New("MaxUnavailable must be zero when MaxSurge is set")
+	}
+	for _, v := range []intstr.IntOrString{unavailable, surge} {
+		if v.Type == intstr.String && !strings.HasSuffix(v.StrVal, "%") {
+			return fmt.Errorf("%q is neither a number nor a percentage", v.StrVal)
+		}
+	}
+	return nil
+}
+
+// rollingUpdate returns the DaemonSet rolling update of the strategy.
+func (st *Strategy) rollingUpdate() *apps_v1.RollingUpdateDaemonSet {
+	// an empty "maxUnavailable" defaults to 1, which is invalid with "maxSurge"
+	unavailable := intstr.FromInt(0)
+	if st.MaxUnavailable != "" {
+		unavailable = intstr.Parse(st.MaxUnavailable)
+	}
+	ru := &apps_v1.RollingUpdateDaemonSet{MaxUnavailable: &unavailable}
+	if st.MaxSurge != "" {
+		v := intstr.Parse(st.MaxSurge)
+		ru.MaxSurge = &v
+	}
+	return ru
+}
+
+const (
+	DefaultMinimumNodes   int = 1
+	DefaultImage              = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	DefaultRolloutTimeout     = 30 * time.Minute
+)
+
+// DefaultStrategies returns the default rolling update strategies,
+// one node at a time, 10% of the nodes at a time, and surging 10% of the nodes.
+func DefaultStrategies() map[string]*Strategy {
+	return map[string]*Strategy{
+		"max-unavailable-1":      {MaxUnavailable: "1"},
+		"max-unavailable-10-pct": {MaxUnavailable: "10%"},
+		"max-surge-10-pct":       {MaxUnavailable: "0", MaxSurge: "10%"},
+	}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:         false,
+		Prompt:         false,
+		MinimumNodes:   DefaultMinimumNodes,
+		Namespace:      pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:          DefaultImage,
+		Strategies:     DefaultStrategies(),
+		RolloutTimeout: DefaultRolloutTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	daemonSetName = "rollout-app"
+	appLabel      = "app.kubernetes.io/name"
+
+	// rolloutAnnotation is the Pod template annotation changed by each update
+	// to trigger the rollout.
+	rolloutAnnotation = "k8s-tester.aws/rollout"
+	// createRolloutName is the result name of the initial rollout.
+	createRolloutName = "create"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	ts.cfg.Results = make(map[string]*RolloutResult)
+	start := time.Now()
+	if err := ts.createDaemonSet(); err != nil {
+		return err
+	}
+	if err := ts.waitForRollout(createRolloutName, start); err != nil {
+		return err
+	}
+
+	names := ts.cfg.strategyNames()
+	for _, name := range names {
+		start = time.Now()
+		if err := ts.updateDaemonSet(name, ts.cfg.Strategies[name]); err != nil {
+			return err
+		}
+		if err := ts.waitForRollout(name, start); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\n")
+	for _, name := range append([]string{createRolloutName}, names...) {
+		rs := ts.cfg.Results[name]
+		fmt.Fprintf(ts.cfg.LogWriter, "rollout %q: %d nodes, took %v (%v per node)\n", name, rs.Nodes, rs.TookString, rs.TookPerNodeString)
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\n")
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteDaemonSet(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		daemonSetName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete DaemonSet (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// tolerateAll lets the DaemonSet run on every node, including tainted ones.
+var tolerateAll = []core_v1.Toleration{
+	{Operator: core_v1.TolerationOpExists},
+}
+
+func (ts *tester) createDaemonSet() error {
+	ts.cfg.Logger.Info("creating DaemonSet", zap.String("image", ts.cfg.Image))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      daemonSetName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							appLabel: daemonSetName,
+						},
+					},
+					UpdateStrategy: apps_v1.DaemonSetUpdateStrategy{
+						Type: apps_v1.RollingUpdateDaemonSetStrategyType,
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: daemonSetName,
+							},
+							Annotations: map[string]string{
+								rolloutAnnotation: createRolloutName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Tolerations:   tolerateAll,
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            daemonSetName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created DaemonSet")
+	return nil
+}
+
+// updateDaemonSet sets the rolling update strategy, and changes the Pod template
+// annotation to the strategy name to roll out the Pods.
+func (ts *tester) updateDaemonSet(name string, st *Strategy) error {
+	ts.cfg.Logger.Info("updating DaemonSet",
+		zap.String("strategy", name),
+		zap.String("max-unavailable", st.MaxUnavailable),
+		zap.String("max-surge", st.MaxSurge),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ds, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Get(ctx, daemonSetName, meta_v1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get DaemonSet (%v)", err)
+	}
+
+	ds.Spec.UpdateStrategy = apps_v1.DaemonSetUpdateStrategy{
+		Type:          apps_v1.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: st.rollingUpdate(),
+	}
+	if ds.Spec.Template.Annotations == nil {
+		ds.Spec.Template.Annotations = make(map[string]string)
+	}
+	ds.Spec.Template.Annotations[rolloutAnnotation] = name
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Update(ctx, ds, meta_v1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("updated DaemonSet", zap.String("strategy", name))
+	return nil
+}
+
+// waitForRollout waits until the DaemonSet Pods on all scheduled nodes are updated
+// and available, and records the result. On timeout, it records the nodes
+// where the updated Pod is not ready, and returns an error.
+func (ts *tester) waitForRollout(name string, start time.Time) error {
+	rs := &RolloutResult{}
+	ts.cfg.Results[name] = rs
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.RolloutTimeout-time.Since(start))
+	ds, err := client.WaitForDaemonSet(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		daemonSetName,
+		client.WithPollInterval(5*time.Second),
+		client.WithStopc(ts.cfg.Stopc),
+	)
+	cancel()
+	if ds != nil {
+		rs.Nodes = ds.Status.DesiredNumberScheduled
+	}
+	if err == nil && rs.Nodes == 0 {
+		err = errors.New("no node scheduled")
+	}
+	if err != nil {
+		stuck, serr := ts.stuckNodes(name)
+		if serr != nil {
+			return fmt.Errorf("DaemonSet rollout %q not complete within %v (%v, %v)", name, ts.cfg.RolloutTimeout, err, serr)
+		}
+		rs.StuckNodes = stuck
+		return fmt.Errorf("DaemonSet rollout %q not complete within %v (%v, stuck nodes %q)", name, ts.cfg.RolloutTimeout, err, stuck)
+	}
+
+	rs.Took = time.Since(start)
+	rs.TookString = rs.Took.String()
+	rs.TookPerNode = rs.Took / time.Duration(rs.Nodes)
+	rs.TookPerNodeString = rs.TookPerNode.String()
+	ts.cfg.Logger.Info("DaemonSet rolled out",
+		zap.String("rollout", name),
+		zap.Int32("nodes", rs.Nodes),
+		zap.String("took", rs.TookString),
+	)
+	return nil
+}
+
+// stuckNodes returns the nodes where the Pod of the rollout is not ready,
+// including the eligible nodes without the Pod, sorted by name.
+func (ts *tester) stuckNodes(name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{LabelSelector: appLabel + "=" + daemonSetName})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Pods (%v)", err)
+	}
+	nodes, err := client.ListNodesWithOptions(
+		ts.cfg.Client.KubernetesClient(),
+		meta_v1.ListOptions{LabelSelector: "kubernetes.io/os=linux"},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return findStuckNodes(name, nodes, pods.Items), nil
+}
+
+// findStuckNodes returns the nodes where the Pod with the rollout annotation
+// is missing or not ready, sorted by name. The cordoned and the not ready nodes
+// are included, since the DaemonSet Pods tolerate them.
+func findStuckNodes(name string, nodes []core_v1.Node, pods []core_v1.Pod) []string {
+	ready := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Annotations[rolloutAnnotation] != name {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == core_v1.PodReady && cond.Status == core_v1.ConditionTrue {
+				ready[pod.Spec.NodeName] = true
+			}
+		}
+	}
+	var stuck []string
+	for _, node := range nodes {
+		if ready[node.Name] {
+			continue
+		}
+		stuck = append(stuck, node.Name)
+	}
+	sort.Strings(stuck)
+	return stuck
+}
@@ -0,0 +1,78 @@
+package daemonset_rollout
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateStrategies(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategies  map[string]*Strategy
+		expectedErr string
+	}{
+		{name: "default"},
+		{name: "surge", strategies: map[string]*Strategy{"surge": {MaxSurge: "1"}}},
+		{name: "reserved", strategies: map[string]*Strategy{"create": {MaxUnavailable: "1"}}, expectedErr: "reserved"},
+		{name: "both-zero", strategies: map[string]*Strategy{"zero": {MaxUnavailable: "0%"}}, expectedErr: "both zero"},
+		{name: "both-set", strategies: map[string]*Strategy{"both": {MaxUnavailable: "1", MaxSurge: "1"}}, expectedErr: "must be zero"},
+		{name: "not-percentage", strategies: map[string]*Strategy{"bad": {MaxUnavailable: "ten"}}, expectedErr: "neither a number nor a percentage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefault()
+			if tt.strategies != nil {
+				cfg.Strategies = tt.strategies
+			}
+			err := cfg.ValidateAndSetDefaults()
+			if tt.expectedErr == "" && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedErr)) {
+				t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRollingUpdate(t *testing.T) {
+	ru := (&Strategy{MaxSurge: "10%"}).rollingUpdate()
+	if ru.MaxUnavailable == nil || ru.MaxUnavailable.IntValue() != 0 {
+		t.Fatalf("expected zero MaxUnavailable, got %v", ru.MaxUnavailable)
+	}
+	if ru.MaxSurge == nil || ru.MaxSurge.String() != "10%" {
+		t.Fatalf("expected 10%% MaxSurge, got %v", ru.MaxSurge)
+	}
+}
+
+func TestFindStuckNodes(t *testing.T) {
+	pod := func(node string, rollout string, ready core_v1.ConditionStatus) core_v1.Pod {
+		return core_v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Annotations: map[string]string{rolloutAnnotation: rollout}},
+			Spec:       core_v1.PodSpec{NodeName: node},
+			Status:     core_v1.PodStatus{Conditions: []core_v1.PodCondition{{Type: core_v1.PodReady, Status: ready}}},
+		}
+	}
+	nodes := []core_v1.Node{
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "d"}},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "a"}},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "b"}},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "c"}},
+	}
+	pods := []core_v1.Pod{
+		pod("a", "surge", core_v1.ConditionTrue),
+		// old Pod ready, but not updated
+		pod("b", "create", core_v1.ConditionTrue),
+		// updated Pod not ready
+		pod("c", "surge", core_v1.ConditionFalse),
+		// no Pod on "d"
+	}
+	stuck := findStuckNodes("surge", nodes, pods)
+	if !reflect.DeepEqual(stuck, []string{"b", "c", "d"}) {
+		t.Fatalf("unexpected stuck nodes %q", stuck)
+	}
+}
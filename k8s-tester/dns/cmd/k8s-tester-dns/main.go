@@ -0,0 +1,188 @@
+// k8s-tester-dns measures the CoreDNS query throughput and latency.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/dns"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-dns",
+	Short:      "Kubernetes CoreDNS scale and latency tester",
+	SuggestFor: []string{"dns"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", dns.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-dns failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	image               string
+	server              string
+	queries             []string
+	clients             int32
+	concurrentQueries   int
+	qps                 int
+	duration            time.Duration
+	coreDNSReplicas     int32
+	latencyP99Threshold time.Duration
+	maxLostRatio        float64
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&image, "image", "", "query Pod image with dnsperf")
+	cmd.PersistentFlags().StringVar(&server, "server", "", "DNS server address, empty for the kube-dns Service cluster IP")
+	cmd.PersistentFlags().StringSliceVar(&queries, "queries", dns.DefaultQueries(), "'<name> <type>' queries")
+	cmd.PersistentFlags().Int32Var(&clients, "clients", dns.DefaultClients, "number of query Pods to run in parallel")
+	cmd.PersistentFlags().IntVar(&concurrentQueries, "concurrent-queries", dns.DefaultConcurrentQueries, "number of outstanding queries per query Pod")
+	cmd.PersistentFlags().IntVar(&qps, "qps", dns.DefaultQPS, "maximum queries per second per query Pod, 0 for unlimited")
+	cmd.PersistentFlags().DurationVar(&duration, "duration", dns.DefaultDuration, "duration of the queries")
+	cmd.PersistentFlags().Int32Var(&coreDNSReplicas, "coredns-replicas", 0, "number of CoreDNS replicas to scale to, 0 to keep the current replicas")
+	cmd.PersistentFlags().DurationVar(&latencyP99Threshold, "latency-p99-threshold", dns.DefaultLatencyP99Threshold, "maximum p99 lookup latency")
+	cmd.PersistentFlags().Float64Var(&maxLostRatio, "max-lost-ratio", dns.DefaultMaxLostRatio, "maximum ratio of the lost queries")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &dns.Config{
+		Prompt:              prompt,
+		Logger:              lg,
+		LogWriter:           logWriter,
+		MinimumNodes:        minimumNodes,
+		Namespace:           namespace,
+		Client:              cli,
+		Image:               image,
+		Server:              server,
+		Queries:             queries,
+		Clients:             clients,
+		ConcurrentQueries:   concurrentQueries,
+		QPS:                 qps,
+		Duration:            duration,
+		CoreDNSReplicas:     coreDNSReplicas,
+		LatencyP99Threshold: latencyP99Threshold,
+		MaxLostRatio:        maxLostRatio,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := dns.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-dns apply' success\n")
+}
+
+var coreDNSOriginalReplicas int32
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	cmd.PersistentFlags().Int32Var(&coreDNSOriginalReplicas, "coredns-original-replicas", 0, "number of CoreDNS replicas to restore, 0 to keep the current replicas")
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &dns.Config{
+		Prompt:                  prompt,
+		Logger:                  lg,
+		LogWriter:               logWriter,
+		Namespace:               namespace,
+		Client:                  cli,
+		CoreDNSOriginalReplicas: coreDNSOriginalReplicas,
+	}
+
+	ts := dns.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-dns delete' success\n")
+}
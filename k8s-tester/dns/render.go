@@ -0,0 +1,37 @@
+package dns
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// renderServer is the DNS server rendered in place of the kube-dns ClusterIP.
+const renderServer = "kube-dns.kube-system.svc.cluster.local"
+
+// Render writes the queries ConfigMap and the dnsperf Job, against a fake client.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createConfigMap(); err != nil {
+		return err
+	}
+	server := cfg.Server
+	if server == "" {
+		server = renderServer
+	}
+	if err := rt.createJob(server); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
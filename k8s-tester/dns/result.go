@@ -0,0 +1,104 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseResult parses the dnsperf summary and the p99 latency of the query Pod logs.
+//
+//	Statistics:
+//
+//	  Queries sent:         30000
+//	  Queries completed:    29990 (99.97%)
+//	  Queries lost:         10 (0.03%)
+//	  ...
+//	  Queries per second:   499.833
+//	  ...
+//	  Average Latency (s):  0.000500 (min 0.000100, max 0.010000)
+//	dns-p99-latency-seconds 0.001200
+//	dns-done
+func parseResult(logs string) (r Result, err error) {
+	done := false
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "dns-done" {
+			done = true
+			continue
+		}
+		if strings.HasPrefix(line, "dns-p99-latency-seconds ") {
+			r.LatencyP99, err = parseSeconds(strings.TrimPrefix(line, "dns-p99-latency-seconds "))
+			if err != nil {
+				return Result{}, err
+			}
+			continue
+		}
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			continue
+		}
+		fields := strings.Fields(line[idx+1:])
+		if len(fields) == 0 {
+			continue
+		}
+		switch line[:idx] {
+		case "Queries sent":
+			r.QueriesSent, err = strconv.ParseInt(fields[0], 10, 64)
+		case "Queries completed":
+			r.QueriesCompleted, err = strconv.ParseInt(fields[0], 10, 64)
+		case "Queries lost":
+			r.QueriesLost, err = strconv.ParseInt(fields[0], 10, 64)
+		case "Queries per second":
+			r.QPS, err = strconv.ParseFloat(fields[0], 64)
+		case "Average Latency (s)":
+			r.LatencyAvg, err = parseSeconds(fields[0])
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to parse %q (%v)", line, err)
+		}
+	}
+	if !done {
+		return Result{}, errors.New("'dns-done' not found")
+	}
+	if r.QueriesSent == 0 {
+		return Result{}, errors.New("dnsperf statistics not found")
+	}
+	r.LatencyAvgString = r.LatencyAvg.String()
+	r.LatencyP99String = r.LatencyP99.String()
+	return r, nil
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(math.Round(f * float64(time.Second))), nil
+}
+
+// aggregate sums up the query Pod results. The average latency is weighted
+// by the completed queries, and the p99 latency is the largest of all Pods,
+// since the percentiles of the Pods cannot be merged.
+func aggregate(rs []Result) (agg Result) {
+	var weighted float64
+	for _, r := range rs {
+		agg.QueriesSent += r.QueriesSent
+		agg.QueriesCompleted += r.QueriesCompleted
+		agg.QueriesLost += r.QueriesLost
+		agg.QPS += r.QPS
+		weighted += float64(r.LatencyAvg) * float64(r.QueriesCompleted)
+		if r.LatencyP99 > agg.LatencyP99 {
+			agg.LatencyP99 = r.LatencyP99
+		}
+	}
+	if agg.QueriesCompleted > 0 {
+		agg.LatencyAvg = time.Duration(weighted / float64(agg.QueriesCompleted))
+	}
+	agg.LatencyAvgString = agg.LatencyAvg.String()
+	agg.LatencyP99String = agg.LatencyP99.String()
+	return agg
+}
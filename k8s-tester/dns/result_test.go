@@ -0,0 +1,72 @@
+package dns
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseResult(t *testing.T) {
+	logs := `DNS Performance Testing Tool
+Version 2.14.0
+
+[Status] Command line: dnsperf -s 10.100.0.10 -d /queries/queries.txt -l 60 -c 10 -Q 500 -v
+[Status] Sending queries (to 10.100.0.10:53)
+[Status] Testing complete (time limit)
+
+Statistics:
+
+  Queries sent:         30000
+  Queries completed:    29990 (99.97%)
+  Queries lost:         10 (0.03%)
+
+  Response codes:       NOERROR 29990 (100.00%)
+  Average packet size:  request 45, response 120
+  Run time (s):         60.001234
+  Queries per second:   499.823
+
+  Average Latency (s):  0.000500 (min 0.000100, max 0.010000)
+  Latency StdDev (s):   0.000200
+
+dns-p99-latency-seconds 0.001200
+dns-done
+`
+	r, err := parseResult(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.QueriesSent != 30000 || r.QueriesCompleted != 29990 || r.QueriesLost != 10 {
+		t.Fatalf("unexpected queries %+v", r)
+	}
+	if r.QPS != 499.823 {
+		t.Fatalf("unexpected QPS %v", r.QPS)
+	}
+	if r.LatencyAvg != 500*time.Microsecond {
+		t.Fatalf("unexpected average latency %v", r.LatencyAvg)
+	}
+	if r.LatencyP99 != 1200*time.Microsecond {
+		t.Fatalf("unexpected p99 latency %v", r.LatencyP99)
+	}
+
+	if _, err = parseResult("Statistics:\n"); err == nil {
+		t.Fatal("expected error without 'dns-done'")
+	}
+	if _, err = parseResult("dns-done\n"); err == nil {
+		t.Fatal("expected error without statistics")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	agg := aggregate([]Result{
+		{QueriesSent: 100, QueriesCompleted: 100, QPS: 10, LatencyAvg: time.Millisecond, LatencyP99: 5 * time.Millisecond},
+		{QueriesSent: 300, QueriesCompleted: 300, QueriesLost: 1, QPS: 30, LatencyAvg: 3 * time.Millisecond, LatencyP99: 2 * time.Millisecond},
+	})
+	if agg.QueriesSent != 400 || agg.QueriesCompleted != 400 || agg.QueriesLost != 1 || agg.QPS != 40 {
+		t.Fatalf("unexpected aggregate %+v", agg)
+	}
+	if agg.LatencyAvg != 2500*time.Microsecond {
+		t.Fatalf("unexpected average latency %v", agg.LatencyAvg)
+	}
+	if agg.LatencyP99 != 5*time.Millisecond {
+		t.Fatalf("unexpected p99 latency %v", agg.LatencyP99)
+	}
+}
@@ -0,0 +1,591 @@
+// Package dns measures the DNS query throughput and latency against CoreDNS
+// with dnsperf, optionally scaling the CoreDNS replicas for the test.
+// ref. https://github.com/DNS-OARC/dnsperf
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the query Pod image with "dnsperf", "sh", "awk", and "sort"
+	// (e.g., built from https://github.com/DNS-OARC/dnsperf on alpine).
+	Image string `json:"image"`
+	// Server is the DNS server address to query.
+	// If empty, the "kube-dns" Service cluster IP is used.
+	Server string `json:"server"`
+	// Queries is the list of "<name> <type>" queries, sent in a loop.
+	Queries []string `json:"queries"`
+	// Clients is the number of query Pods to run in parallel.
+	Clients int32 `json:"clients"`
+	// ConcurrentQueries is the number of outstanding queries per query Pod ("dnsperf -c").
+	ConcurrentQueries int `json:"concurrent_queries"`
+	// QPS is the maximum queries per second per query Pod ("dnsperf -Q").
+	// Zero to send as fast as possible.
+	QPS int `json:"qps"`
+	// Duration is the duration of the queries.
+	Duration       time.Duration `json:"duration"`
+	DurationString string        `json:"duration_string" read-only:"true"`
+
+	// CoreDNSReplicas is the number of CoreDNS replicas to scale to before the queries.
+	// Zero to keep the current replicas. The original replicas are restored on "delete".
+	CoreDNSReplicas int32 `json:"coredns_replicas"`
+	// CoreDNSOriginalReplicas is the CoreDNS replicas before scaling.
+	CoreDNSOriginalReplicas int32 `json:"coredns_original_replicas" read-only:"true"`
+
+	// LatencyP99Threshold is the maximum p99 lookup latency.
+	LatencyP99Threshold       time.Duration `json:"latency_p99_threshold"`
+	LatencyP99ThresholdString string        `json:"latency_p99_threshold_string" read-only:"true"`
+	// MaxLostRatio is the maximum ratio of the lost queries.
+	MaxLostRatio float64 `json:"max_lost_ratio"`
+
+	// Result is the aggregated result of all query Pods.
+	Result Result `json:"result" read-only:"true"`
+}
+
+// Result is the dnsperf result.
+type Result struct {
+	QueriesSent      int64   `json:"queries_sent"`
+	QueriesCompleted int64   `json:"queries_completed"`
+	QueriesLost      int64   `json:"queries_lost"`
+	QPS              float64 `json:"qps"`
+
+	LatencyAvg       time.Duration `json:"latency_avg"`
+	LatencyAvgString string        `json:"latency_avg_string"`
+	// LatencyP99 is the largest p99 latency of all query Pods.
+	LatencyP99       time.Duration `json:"latency_p99"`
+	LatencyP99String string        `json:"latency_p99_string"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		return errors.New("empty Image")
+	}
+	if len(cfg.Queries) == 0 {
+		cfg.Queries = DefaultQueries()
+	}
+	for _, q := range cfg.Queries {
+		if len(strings.Fields(q)) != 2 {
+			return fmt.Errorf("invalid query %q, expected '<name> <type>'", q)
+		}
+	}
+	if cfg.Clients <= 0 {
+		cfg.Clients = DefaultClients
+	}
+	if cfg.ConcurrentQueries <= 0 {
+		cfg.ConcurrentQueries = DefaultConcurrentQueries
+	}
+	if cfg.QPS < 0 {
+		return fmt.Errorf("invalid QPS %d", cfg.QPS)
+	}
+	if cfg.Duration == time.Duration(0) {
+		cfg.Duration = DefaultDuration
+	}
+	cfg.DurationString = cfg.Duration.String()
+	if cfg.CoreDNSReplicas < 0 {
+		return fmt.Errorf("invalid CoreDNSReplicas %d", cfg.CoreDNSReplicas)
+	}
+	if cfg.LatencyP99Threshold == time.Duration(0) {
+		cfg.LatencyP99Threshold = DefaultLatencyP99Threshold
+	}
+	cfg.LatencyP99ThresholdString = cfg.LatencyP99Threshold.String()
+	if cfg.MaxLostRatio < 0 || cfg.MaxLostRatio > 1 {
+		return fmt.Errorf("invalid MaxLostRatio %v", cfg.MaxLostRatio)
+	}
+	return nil
+}
+
+const (
+	DefaultMinimumNodes        int   = 1
+	DefaultClients             int32 = 1
+	DefaultConcurrentQueries   int   = 10
+	DefaultQPS                 int   = 500
+	DefaultDuration                  = time.Minute
+	DefaultLatencyP99Threshold       = 100 * time.Millisecond
+	DefaultMaxLostRatio              = 0.01
+)
+
+// DefaultQueries returns the default queries, the cluster-internal names
+// and an external name to exercise the CoreDNS forwarding and cache.
+func DefaultQueries() []string {
+	return []string{
+		"kubernetes.default.svc.cluster.local A",
+		"kube-dns.kube-system.svc.cluster.local A",
+		"amazon.com A",
+	}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:              false,
+		Prompt:              false,
+		MinimumNodes:        DefaultMinimumNodes,
+		Namespace:           pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Queries:             DefaultQueries(),
+		Clients:             DefaultClients,
+		ConcurrentQueries:   DefaultConcurrentQueries,
+		QPS:                 DefaultQPS,
+		Duration:            DefaultDuration,
+		LatencyP99Threshold: DefaultLatencyP99Threshold,
+		MaxLostRatio:        DefaultMaxLostRatio,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	jobName             = "dnsperf"
+	queriesConfigMapKey = "queries.txt"
+	queriesMountPath    = "/queries"
+
+	coreDNSNamespace      = "kube-system"
+	coreDNSDeploymentName = "coredns"
+	kubeDNSServiceName    = "kube-dns"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if ts.cfg.CoreDNSReplicas > 0 {
+		if err := ts.scaleCoreDNS(ts.cfg.CoreDNSReplicas); err != nil {
+			return err
+		}
+	}
+
+	server := ts.cfg.Server
+	if server == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		svc, err := ts.cfg.Client.KubernetesClient().CoreV1().Services(coreDNSNamespace).Get(ctx, kubeDNSServiceName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get %q Service (%v)", kubeDNSServiceName, err)
+		}
+		server = svc.Spec.ClusterIP
+	}
+
+	if err := ts.createConfigMap(); err != nil {
+		return err
+	}
+	if err := ts.createJob(server); err != nil {
+		return err
+	}
+	pods, err := ts.waitForJob()
+	if err != nil {
+		return err
+	}
+	return ts.checkResults(pods)
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteJob(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		jobName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+	}
+
+	if ts.cfg.CoreDNSOriginalReplicas > 0 {
+		if err := ts.scaleCoreDNS(ts.cfg.CoreDNSOriginalReplicas); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			ts.cfg.CoreDNSOriginalReplicas = 0
+		}
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// scaleCoreDNS scales the CoreDNS Deployment, recording the original replicas
+// on the first scale, and waits for the replicas to be available.
+func (ts *tester) scaleCoreDNS(replicas int32) error {
+	ts.cfg.Logger.Info("scaling CoreDNS", zap.Int32("replicas", replicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	scale, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(coreDNSNamespace).
+		GetScale(ctx, coreDNSDeploymentName, meta_v1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get CoreDNS scale (%v)", err)
+	}
+	if ts.cfg.CoreDNSOriginalReplicas == 0 {
+		ts.cfg.CoreDNSOriginalReplicas = scale.Spec.Replicas
+	}
+	if scale.Spec.Replicas != replicas {
+		scale.Spec.Replicas = replicas
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		_, err = ts.cfg.Client.KubernetesClient().
+			AppsV1().
+			Deployments(coreDNSNamespace).
+			UpdateScale(ctx, coreDNSDeploymentName, scale, meta_v1.UpdateOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to scale CoreDNS to %d (%v)", replicas, err)
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
+	_, err = client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		10*time.Second,
+		coreDNSNamespace,
+		coreDNSDeploymentName,
+		replicas,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("CoreDNS %d replicas not available (%v)", replicas, err)
+	}
+	ts.cfg.Logger.Info("scaled CoreDNS", zap.Int32("replicas", replicas))
+	return nil
+}
+
+func (ts *tester) createConfigMap() error {
+	ts.cfg.Logger.Info("creating dnsperf queries ConfigMap", zap.Strings("queries", ts.cfg.Queries))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ConfigMaps(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ConfigMap{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Data: map[string]string{
+					queriesConfigMapKey: strings.Join(ts.cfg.Queries, "\n") + "\n",
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("dnsperf queries ConfigMap already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create dnsperf queries ConfigMap (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created dnsperf queries ConfigMap")
+	return nil
+}
+
+// queryScript runs dnsperf in verbose mode, prints the dnsperf summary,
+// and computes the p99 latency from the per-query latencies in the Pod,
+// so that only the summary is read from the Pod logs.
+func queryScript(durationSeconds int, concurrentQueries int, qps int) string {
+	qpsFlag := ""
+	if qps > 0 {
+		qpsFlag = fmt.Sprintf(" -Q %d", qps)
+	}
+	return fmt.Sprintf(`dnsperf -s "$DNS_SERVER" -d %s/%s -l %d -c %d%s -v > /tmp/dnsperf.txt 2>&1 || true
+grep -v '^> ' /tmp/dnsperf.txt || true
+grep '^> ' /tmp/dnsperf.txt | awk '$NF ~ /^[0-9.]+$/ {print $NF}' | sort -n > /tmp/latencies.txt
+n=$(wc -l < /tmp/latencies.txt)
+if [ "$n" -gt 0 ]; then
+  echo "dns-p99-latency-seconds $(sed -n "$(( (n * 99 + 99) / 100 ))p" /tmp/latencies.txt)"
+fi
+echo dns-done
+`, queriesMountPath, queriesConfigMapKey, durationSeconds, concurrentQueries, qpsFlag)
+}
+
+func (ts *tester) createJob(server string) error {
+	ts.cfg.Logger.Info("creating dnsperf Job",
+		zap.String("server", server),
+		zap.Int32("clients", ts.cfg.Clients),
+		zap.Int("qps", ts.cfg.QPS),
+		zap.String("duration", ts.cfg.Duration.String()),
+	)
+	backoffLimit := int32(0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					Completions:  &ts.cfg.Clients,
+					Parallelism:  &ts.cfg.Clients,
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c"},
+									Args:            []string{queryScript(int(ts.cfg.Duration.Seconds()), ts.cfg.ConcurrentQueries, ts.cfg.QPS)},
+									Env: []core_v1.EnvVar{
+										{Name: "DNS_SERVER", Value: server},
+									},
+									VolumeMounts: []core_v1.VolumeMount{
+										{Name: "queries", MountPath: queriesMountPath, ReadOnly: true},
+									},
+								},
+							},
+							Volumes: []core_v1.Volume{
+								{
+									Name: "queries",
+									VolumeSource: core_v1.VolumeSource{
+										ConfigMap: &core_v1.ConfigMapVolumeSource{
+											LocalObjectReference: core_v1.LocalObjectReference{Name: jobName},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("dnsperf Job already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create dnsperf Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created dnsperf Job")
+	return nil
+}
+
+func (ts *tester) waitForJob() ([]core_v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Duration+10*time.Minute)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Duration,
+		10*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		int(ts.cfg.Clients),
+	)
+	cancel()
+	return pods, err
+}
+
+func (ts *tester) checkResults(pods []core_v1.Pod) error {
+	var rs []Result
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		logs, err := ts.readPodLogs(pod.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read dnsperf Pod %q logs (%v)", pod.Name, err)
+		}
+		r, err := parseResult(logs)
+		if err != nil {
+			return fmt.Errorf("failed to parse dnsperf Pod %q logs (%v)", pod.Name, err)
+		}
+		ts.cfg.Logger.Info("dnsperf Pod result",
+			zap.String("pod", pod.Name),
+			zap.Int64("sent", r.QueriesSent),
+			zap.Int64("lost", r.QueriesLost),
+			zap.Float64("qps", r.QPS),
+			zap.String("latency-avg", r.LatencyAvgString),
+			zap.String("latency-p99", r.LatencyP99String),
+		)
+		rs = append(rs, r)
+	}
+	if len(rs) == 0 {
+		return errors.New("no dnsperf result")
+	}
+	ts.cfg.Result = aggregate(rs)
+
+	r := ts.cfg.Result
+	fmt.Fprintf(ts.cfg.LogWriter, "\nDNS queries sent: %d, completed: %d, lost: %d\n", r.QueriesSent, r.QueriesCompleted, r.QueriesLost)
+	fmt.Fprintf(ts.cfg.LogWriter, "DNS QPS: %.1f, latency avg: %v, p99: %v (threshold %v)\n\n", r.QPS, r.LatencyAvg, r.LatencyP99, ts.cfg.LatencyP99Threshold)
+
+	if r.QueriesSent == 0 {
+		return errors.New("no DNS query sent")
+	}
+	if ratio := float64(r.QueriesLost) / float64(r.QueriesSent); ratio > ts.cfg.MaxLostRatio {
+		return fmt.Errorf("DNS lost query ratio %.4f > %.4f", ratio, ts.cfg.MaxLostRatio)
+	}
+	if r.LatencyP99 > ts.cfg.LatencyP99Threshold {
+		return fmt.Errorf("DNS p99 latency %v > %v", r.LatencyP99, ts.cfg.LatencyP99Threshold)
+	}
+	return nil
+}
+
+func (ts *tester) readPodLogs(podName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rc, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		GetLogs(podName, &core_v1.PodLogOptions{Container: jobName}).
+		Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,358 @@
+package k8s_tester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	iam_preflight "github.com/aws/aws-k8s-tester/k8s-tester/iam-preflight"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/dustin/go-humanize"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+	k8s_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/exec"
+)
+
+const (
+	// DoctorResultOK is the check that passed.
+	DoctorResultOK = "ok"
+	// DoctorResultWarn is the check that may fail the run.
+	DoctorResultWarn = "warn"
+	// DoctorResultFail is the check that will fail the run.
+	DoctorResultFail = "fail"
+	// DoctorResultSkipped is the check that is not required by the configuration,
+	// or that depends on a failed check.
+	DoctorResultSkipped = "skipped"
+)
+
+// DefaultDoctorMinimumFreeDisk is the minimum free disk space of the artifacts directory
+// (the directory of the configuration file, where the logs and the reports are written).
+const DefaultDoctorMinimumFreeDisk = 1 << 30
+
+// DoctorCheck is the result of an environment check.
+type DoctorCheck struct {
+	// Name is the check name.
+	Name string `json:"name"`
+	// Result is the check result.
+	Result string `json:"result"`
+	// Message is the details of the result.
+	Message string `json:"message"`
+	// Fix is the suggested fix, if the check did not pass.
+	Fix string `json:"fix,omitempty"`
+}
+
+// Doctor validates the local environment for the configuration:
+// the AWS credentials and a sample of the required IAM permissions,
+// the kubectl binary or its download mirror, the cluster connectivity,
+// the free disk space for the artifacts, and the kubectl version skew.
+// It does not create or delete any resource, and does not download kubectl.
+func (cfg *Config) Doctor(lg *zap.Logger) []DoctorCheck {
+	cs := make([]DoctorCheck, 0, 6)
+
+	sess, callerARN, chk := cfg.doctorAWSCredentials(lg)
+	cs = append(cs, chk)
+	cs = append(cs, cfg.doctorIAMPermissions(sess, callerARN))
+
+	kubectlVer, chk := cfg.doctorKubectl()
+	cs = append(cs, chk)
+	serverVer, chk := cfg.doctorCluster(lg, file.Exist(cfg.KubectlPath))
+	cs = append(cs, chk)
+
+	cs = append(cs, doctorDiskSpace(filepath.Dir(cfg.ConfigPath), DefaultDoctorMinimumFreeDisk))
+	cs = append(cs, doctorVersionSkew(kubectlVer, serverVer))
+	return cs
+}
+
+// DoctorFailed returns true if any check failed.
+func DoctorFailed(cs []DoctorCheck) bool {
+	for _, c := range cs {
+		if c.Result == DoctorResultFail {
+			return true
+		}
+	}
+	return false
+}
+
+// DoctorTable returns the table of the doctor checks.
+func DoctorTable(cs []DoctorCheck) string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"Check", "Result", "Message", "Fix"})
+	for _, c := range cs {
+		tb.Append([]string{c.Name, c.Result, c.Message, c.Fix})
+	}
+	tb.Render()
+	return buf.String()
+}
+
+// doctorRegion returns the AWS region of the configuration.
+func (cfg *Config) doctorRegion() string {
+	switch {
+	case cfg.EKSRegion != "":
+		return cfg.EKSRegion
+	case cfg.IAMPreflight != nil && cfg.IAMPreflight.Region != "":
+		return cfg.IAMPreflight.Region
+	}
+	return os.Getenv("AWS_REGION")
+}
+
+func (cfg *Config) doctorAWSCredentials(lg *zap.Logger) (*session.Session, string, DoctorCheck) {
+	chk := DoctorCheck{Name: "aws-credentials"}
+	if len(cfg.RequiredIAMActions()) == 0 && cfg.EKSClusterName == "" {
+		chk.Result, chk.Message = DoctorResultSkipped, "no enabled add-on calls the AWS APIs"
+		return nil, "", chk
+	}
+	region := cfg.doctorRegion()
+	if region == "" {
+		chk.Result, chk.Message = DoctorResultFail, "no AWS region configured"
+		chk.Fix = "set " + ENV_PREFIX + "EKS_REGION, " + ENV_PREFIX + iam_preflight.Env() + "_REGION, or AWS_REGION"
+		return nil, "", chk
+	}
+	sess, stsOutput, _, err := aws_v1.New(&aws_v1.Config{
+		Logger:             lg,
+		Partition:          iam_preflight.DefaultPartition,
+		Region:             region,
+		AssumeRoleARN:      cfg.AWSAssumeRoleARN,
+		AssumeRoleDuration: cfg.AWSAssumeRoleDuration,
+	})
+	if err != nil {
+		chk.Result, chk.Message = DoctorResultFail, fmt.Sprintf("failed to get the caller identity (%v)", err)
+		chk.Fix = "configure the AWS credentials (e.g., AWS_PROFILE, or the instance profile), and check 'aws sts get-caller-identity'"
+		return nil, "", chk
+	}
+	callerARN := aws.StringValue(stsOutput.Arn)
+	chk.Result, chk.Message = DoctorResultOK, fmt.Sprintf("caller %q in %q", callerARN, region)
+	return sess, callerARN, chk
+}
+
+func (cfg *Config) doctorIAMPermissions(sess *session.Session, callerARN string) DoctorCheck {
+	chk := DoctorCheck{Name: "aws-permissions"}
+	required := cfg.RequiredIAMActions()
+	if len(required) == 0 {
+		chk.Result, chk.Message = DoctorResultSkipped, "no IAM permission required for the enabled add-ons"
+		return chk
+	}
+	if sess == nil {
+		chk.Result, chk.Message = DoctorResultSkipped, "no AWS credentials"
+		return chk
+	}
+	var actions []string
+	for _, acts := range required {
+		actions = append(actions, acts...)
+	}
+	denied, err := iam_preflight.Simulate(iam.New(sess), callerARN, actions)
+	if err != nil {
+		chk.Result, chk.Message = DoctorResultWarn, fmt.Sprintf("failed to simulate the IAM policy (%v)", err)
+		chk.Fix = "allow 'iam:SimulatePrincipalPolicy' to check the permissions, or review the required policy with " + ENV_PREFIX + iam_preflight.Env() + "_SKIP_SIMULATION=true"
+		return chk
+	}
+	if len(denied) > 0 {
+		chk.Result, chk.Message = DoctorResultFail, fmt.Sprintf("missing %d IAM permission(s) %q", len(denied), denied)
+		chk.Fix = "attach the policy generated by " + ENV_PREFIX + iam_preflight.Env() + "_ENABLE=true to the caller"
+		return chk
+	}
+	chk.Result, chk.Message = DoctorResultOK, fmt.Sprintf("%d required actions allowed", len(actions))
+	return chk
+}
+
+// doctorKubectl checks the kubectl binary, or its download URL if not installed,
+// and returns the kubectl client version, if known.
+func (cfg *Config) doctorKubectl() (string, DoctorCheck) {
+	chk := DoctorCheck{Name: "kubectl"}
+	if file.Exist(cfg.KubectlPath) {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		output, err := exec.New().CommandContext(ctx, cfg.KubectlPath, "version", "--client", "-o", "json").Output()
+		cancel()
+		var v struct {
+			ClientVersion struct {
+				GitVersion string `json:"gitVersion"`
+			} `json:"clientVersion"`
+		}
+		if err == nil {
+			err = json.Unmarshal(output, &v)
+		}
+		if err != nil {
+			chk.Result, chk.Message = DoctorResultFail, fmt.Sprintf("'%s version' failed (%v)", cfg.KubectlPath, err)
+			chk.Fix = "remove " + cfg.KubectlPath + " to download again, or install kubectl for " + runtime.GOOS + "/" + runtime.GOARCH
+			return "", chk
+		}
+		chk.Result, chk.Message = DoctorResultOK, fmt.Sprintf("%q %s", cfg.KubectlPath, v.ClientVersion.GitVersion)
+		return v.ClientVersion.GitVersion, chk
+	}
+
+	fix := "install kubectl at " + cfg.KubectlPath + ", or set " + ENV_PREFIX + "KUBECTL_DOWNLOAD_URL to a reachable mirror"
+	if cfg.KubectlDownloadURL == "" {
+		chk.Result, chk.Message, chk.Fix = DoctorResultFail, fmt.Sprintf("%q not found, and empty download URL", cfg.KubectlPath), fix
+		return "", chk
+	}
+	cli := &http.Client{Timeout: 15 * time.Second}
+	resp, err := cli.Head(cfg.KubectlDownloadURL)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("status %q", resp.Status)
+		}
+	}
+	if err != nil {
+		chk.Result, chk.Message, chk.Fix = DoctorResultFail, fmt.Sprintf("%q not found, and %q not reachable (%v)", cfg.KubectlPath, cfg.KubectlDownloadURL, err), fix
+		return "", chk
+	}
+	chk.Result, chk.Message = DoctorResultOK, fmt.Sprintf("%q not found; to download from %q", cfg.KubectlPath, cfg.KubectlDownloadURL)
+	return kubectlDownloadVersion(cfg.KubectlDownloadURL), chk
+}
+
+// doctorCluster checks the cluster connectivity, and returns the server version.
+func (cfg *Config) doctorCluster(lg *zap.Logger, kubectlInstalled bool) (string, DoctorCheck) {
+	chk := DoctorCheck{Name: "cluster"}
+	var c k8s_client.Interface
+	switch {
+	case cfg.KubeconfigPath != "":
+		if !file.Exist(cfg.KubeconfigPath) {
+			chk.Result, chk.Message = DoctorResultFail, fmt.Sprintf("kubeconfig %q not found", cfg.KubeconfigPath)
+			chk.Fix = "write the kubeconfig (e.g., 'aws eks update-kubeconfig --kubeconfig " + cfg.KubeconfigPath + "'), or set " + ENV_PREFIX + "EKS_CLUSTER_NAME"
+			return "", chk
+		}
+		kcfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: cfg.KubeconfigPath},
+			&clientcmd.ConfigOverrides{CurrentContext: cfg.KubeconfigContext},
+		).ClientConfig()
+		if err == nil {
+			kcfg.Timeout = 30 * time.Second
+			c, err = k8s_client.NewForConfig(kcfg)
+		}
+		if err != nil {
+			chk.Result, chk.Message = DoctorResultFail, fmt.Sprintf("invalid kubeconfig %q (%v)", cfg.KubeconfigPath, err)
+			chk.Fix = "check the kubeconfig context " + strconv.Quote(cfg.KubeconfigContext)
+			return "", chk
+		}
+	case cfg.EKSClusterName != "":
+		// the client installs kubectl, thus checked only with kubectl installed
+		if !kubectlInstalled {
+			chk.Result, chk.Message = DoctorResultSkipped, "kubectl not installed"
+			return "", chk
+		}
+		cli, err := client.New(&client.Config{
+			Logger:         lg,
+			KubectlPath:    cfg.KubectlPath,
+			EKS:            cfg.clientEKS(),
+			Clients:        1,
+			RequestTimeout: 30 * time.Second,
+		})
+		if err != nil {
+			chk.Result, chk.Message = DoctorResultFail, fmt.Sprintf("failed to create client for EKS cluster %q (%v)", cfg.EKSClusterName, err)
+			chk.Fix = "check 'aws eks describe-cluster --name " + cfg.EKSClusterName + " --region " + cfg.EKSRegion + "'"
+			return "", chk
+		}
+		c = cli.KubernetesClient()
+	default:
+		chk.Result, chk.Message = DoctorResultFail, "no cluster configured"
+		chk.Fix = "set " + ENV_PREFIX + "KUBECONFIG_PATH, or " + ENV_PREFIX + "EKS_CLUSTER_NAME and " + ENV_PREFIX + "EKS_REGION"
+		return "", chk
+	}
+
+	info, err := c.Discovery().ServerVersion()
+	if err != nil {
+		chk.Result, chk.Message = DoctorResultFail, fmt.Sprintf("failed to connect to the cluster (%v)", err)
+		chk.Fix = "check the network path to the apiserver endpoint, and refresh the cluster credentials"
+		return "", chk
+	}
+	chk.Result, chk.Message = DoctorResultOK, "server version "+info.GitVersion
+	return info.GitVersion, chk
+}
+
+// doctorDiskSpace checks the free disk space of the artifacts directory.
+func doctorDiskSpace(dir string, minFree uint64) DoctorCheck {
+	chk := DoctorCheck{Name: "disk-space"}
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		chk.Result, chk.Message = DoctorResultWarn, fmt.Sprintf("failed to get the free disk space of %q (%v)", dir, err)
+		return chk
+	}
+	if free < minFree {
+		chk.Result, chk.Message = DoctorResultFail, fmt.Sprintf("%s free in %q, less than %s", humanize.Bytes(free), dir, humanize.Bytes(minFree))
+		chk.Fix = "free up the disk space (e.g., 'k8s-tester artifacts prune'), or move the configuration file to a larger disk"
+		return chk
+	}
+	chk.Result, chk.Message = DoctorResultOK, fmt.Sprintf("%s free in %q", humanize.Bytes(free), dir)
+	return chk
+}
+
+// freeDiskSpace returns the disk space in bytes available to the user.
+func freeDiskSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// doctorVersionSkew checks the kubectl version skew against the server,
+// which supports kubectl within one minor version.
+// ref. https://kubernetes.io/releases/version-skew-policy/#kubectl
+func doctorVersionSkew(kubectlVer string, serverVer string) DoctorCheck {
+	chk := DoctorCheck{Name: "version-skew"}
+	kmajor, kminor, kok := parseMinorVersion(kubectlVer)
+	smajor, sminor, sok := parseMinorVersion(serverVer)
+	if !kok || !sok {
+		chk.Result, chk.Message = DoctorResultSkipped, fmt.Sprintf("unknown version (kubectl %q, server %q)", kubectlVer, serverVer)
+		return chk
+	}
+	skew := kminor - sminor
+	if skew < 0 {
+		skew = -skew
+	}
+	if kmajor != smajor || skew > 1 {
+		chk.Result, chk.Message = DoctorResultWarn, fmt.Sprintf("kubectl %s is not supported by server %s", kubectlVer, serverVer)
+		chk.Fix = fmt.Sprintf("set %sKUBECTL_DOWNLOAD_URL to kubectl v%d.%d", ENV_PREFIX, smajor, sminor)
+		return chk
+	}
+	chk.Result, chk.Message = DoctorResultOK, fmt.Sprintf("kubectl %s, server %s", kubectlVer, serverVer)
+	return chk
+}
+
+var (
+	minorVersionRegex    = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+	kubectlURLVersionRex = regexp.MustCompile(`/(v?\d+\.\d+\.\d+[^/]*)/`)
+)
+
+// parseMinorVersion parses the major and the minor versions
+// (e.g., "v1.21.1" and "v1.21.2-eks-0389ca3").
+func parseMinorVersion(ver string) (major int, minor int, ok bool) {
+	ms := minorVersionRegex.FindStringSubmatch(ver)
+	if len(ms) != 3 {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(ms[1])
+	minor, _ = strconv.Atoi(ms[2])
+	return major, minor, true
+}
+
+// kubectlDownloadVersion returns the kubectl version in the download URL
+// (e.g., "https://dl.k8s.io/release/v1.21.0/bin/linux/amd64/kubectl"),
+// or an empty string if unknown.
+func kubectlDownloadVersion(u string) string {
+	ms := kubectlURLVersionRex.FindStringSubmatch(u)
+	if len(ms) != 2 {
+		return ""
+	}
+	return ms[1]
+}
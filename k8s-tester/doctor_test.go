@@ -0,0 +1,62 @@
+package k8s_tester
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDoctorVersionSkew(t *testing.T) {
+	tests := []struct {
+		kubectl  string
+		server   string
+		expected string
+	}{
+		{"v1.21.1", "v1.21.2-eks-0389ca3", DoctorResultOK},
+		{"v1.22.0", "v1.21.2-eks-0389ca3", DoctorResultOK},
+		{"v1.20.0", "v1.21.2-eks-0389ca3", DoctorResultOK},
+		{"v1.23.0", "v1.21.2-eks-0389ca3", DoctorResultWarn},
+		{"v1.19.0", "v1.21.2", DoctorResultWarn},
+		{"", "v1.21.2", DoctorResultSkipped},
+		{"v1.21.1", "", DoctorResultSkipped},
+	}
+	for _, tt := range tests {
+		if chk := doctorVersionSkew(tt.kubectl, tt.server); chk.Result != tt.expected {
+			t.Fatalf("kubectl %q, server %q: expected %q, got %+v", tt.kubectl, tt.server, tt.expected, chk)
+		}
+	}
+}
+
+func TestKubectlDownloadVersion(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://dl.k8s.io/release/v1.21.0/bin/linux/amd64/kubectl", "v1.21.0"},
+		{"https://amazon-eks.s3.us-west-2.amazonaws.com/1.21.2/2021-07-05/bin/linux/amd64/kubectl", "1.21.2"},
+		{"https://mirror.example.com/kubernetes/v1.22.1-rc.0/kubectl", "v1.22.1-rc.0"},
+	}
+	for _, tt := range tests {
+		if v := kubectlDownloadVersion(tt.url); v != tt.expected {
+			t.Fatalf("%q: expected %q, got %q", tt.url, tt.expected, v)
+		}
+	}
+}
+
+func TestDoctorDiskSpace(t *testing.T) {
+	if chk := doctorDiskSpace(os.TempDir(), 1); chk.Result != DoctorResultOK {
+		t.Fatalf("unexpected check %+v", chk)
+	}
+	if chk := doctorDiskSpace(os.TempDir(), 1<<62); chk.Result != DoctorResultFail || chk.Fix == "" {
+		t.Fatalf("unexpected check %+v", chk)
+	}
+}
+
+func TestDoctorFailed(t *testing.T) {
+	cs := []DoctorCheck{{Name: "a", Result: DoctorResultOK}, {Name: "b", Result: DoctorResultWarn}}
+	if DoctorFailed(cs) {
+		t.Fatal("unexpected failure")
+	}
+	if !DoctorFailed(append(cs, DoctorCheck{Name: "c", Result: DoctorResultFail})) {
+		t.Fatal("expected failure")
+	}
+}
@@ -0,0 +1,188 @@
+// k8s-tester-emr-on-eks registers an EMR on EKS virtual cluster and tests Spark job runs.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	emr_on_eks "github.com/aws/aws-k8s-tester/k8s-tester/emr-on-eks"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-emr-on-eks",
+	Short:      "Kubernetes EMR on EKS Spark job tester",
+	SuggestFor: []string{"emr-on-eks"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	partition          string
+	region             string
+	clusterName        string
+	virtualClusterID   string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", emr_on_eks.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", emr_on_eks.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for EMR on EKS")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster-name", "", "EKS cluster name")
+	rootCmd.PersistentFlags().StringVar(&virtualClusterID, "virtual-cluster-id", "", "EMR virtual cluster ID to delete")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-emr-on-eks failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	executionRoleARN  string
+	releaseLabel      string
+	entryPoint        string
+	executorInstances int
+	jobRunTimeout     string
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&executionRoleARN, "execution-role-arn", "", "IAM role ARN for the job run")
+	cmd.PersistentFlags().StringVar(&releaseLabel, "release-label", emr_on_eks.DefaultReleaseLabel, "Amazon EMR release version")
+	cmd.PersistentFlags().StringVar(&entryPoint, "entry-point", emr_on_eks.DefaultEntryPoint, "Spark job entry point")
+	cmd.PersistentFlags().IntVar(&executorInstances, "executor-instances", emr_on_eks.DefaultExecutorInstances, "number of Spark executors")
+	cmd.PersistentFlags().StringVar(&jobRunTimeout, "job-run-timeout", emr_on_eks.DefaultJobRunTimeout.String(), "timeout to wait for the job run completion")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	timeout, err := time.ParseDuration(jobRunTimeout)
+	if err != nil {
+		lg.Panic("failed to parse job run timeout", zap.String("job-run-timeout", jobRunTimeout), zap.Error(err))
+	}
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &emr_on_eks.Config{
+		Prompt:            prompt,
+		Logger:            lg,
+		LogWriter:         logWriter,
+		MinimumNodes:      minimumNodes,
+		Namespace:         namespace,
+		Client:            cli,
+		Partition:         partition,
+		Region:            region,
+		ExecutionRoleARN:  executionRoleARN,
+		ReleaseLabel:      releaseLabel,
+		EntryPoint:        entryPoint,
+		ExecutorInstances: executorInstances,
+		JobRunTimeout:     timeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := emr_on_eks.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-emr-on-eks apply' success (virtual cluster ID %q)\n", cfg.VirtualClusterID)
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &emr_on_eks.Config{
+		Prompt:           prompt,
+		Logger:           lg,
+		LogWriter:        logWriter,
+		Namespace:        namespace,
+		Client:           cli,
+		Partition:        partition,
+		Region:           region,
+		ClusterName:      clusterName,
+		VirtualClusterID: virtualClusterID,
+	}
+
+	ts := emr_on_eks.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-emr-on-eks delete' success\n")
+}
@@ -0,0 +1,597 @@
+// Package emr_on_eks registers the cluster as an EMR on EKS virtual cluster,
+// submits a sample Spark job, and validates its completion along with
+// the driver and executor Pod scheduling.
+// ref. https://docs.aws.amazon.com/emr/latest/EMR-on-EKS-DevelopmentGuide/getting-started.html
+//
+// The EMR on EKS service-linked role "AWSServiceRoleForAmazonEMRContainers"
+// must be mapped to the Kubernetes user "emr-containers" (e.g.,
+// "eksctl create iamidentitymapping --service-name emr-containers"),
+// which is bound to the Role created in the test namespace.
+package emr_on_eks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/emrcontainers"
+	"github.com/aws/aws-sdk-go/service/emrcontainers/emrcontainersiface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	EMRContainersAPI emrcontainersiface.EMRContainersAPI `json:"-"`
+
+	Partition   string `json:"partition"`
+	Region      string `json:"region"`
+	ClusterName string `json:"cluster_name" read-only:"true"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// VirtualClusterName is the EMR on EKS virtual cluster name.
+	// Defaults to the namespace.
+	VirtualClusterName string `json:"virtual_cluster_name"`
+	// VirtualClusterID is the EMR on EKS virtual cluster ID created.
+	VirtualClusterID string `json:"virtual_cluster_id" read-only:"true"`
+
+	// ExecutionRoleARN is the IAM role ARN for the job run.
+	// ref. https://docs.aws.amazon.com/emr/latest/EMR-on-EKS-DevelopmentGuide/creating-job-execution-role.html
+	ExecutionRoleARN string `json:"execution_role_arn"`
+	// ReleaseLabel is the Amazon EMR release version.
+	ReleaseLabel string `json:"release_label"`
+	// EntryPoint is the Spark job entry point.
+	EntryPoint string `json:"entry_point"`
+	// ExecutorInstances is the number of Spark executors.
+	ExecutorInstances int `json:"executor_instances"`
+	// JobRunTimeout is the timeout to wait for the job run completion.
+	JobRunTimeout       time.Duration `json:"job_run_timeout"`
+	JobRunTimeoutString string        `json:"job_run_timeout_string" read-only:"true"`
+
+	// JobRunID is the ID of the job run submitted.
+	JobRunID string `json:"job_run_id" read-only:"true"`
+	// JobRunState is the last observed state of the job run.
+	JobRunState string `json:"job_run_state" read-only:"true"`
+	// ObservedDriverPods is the number of Spark driver Pods observed to be scheduled.
+	ObservedDriverPods int `json:"observed_driver_pods" read-only:"true"`
+	// ObservedExecutorPods is the number of Spark executor Pods observed to be scheduled.
+	ObservedExecutorPods int `json:"observed_executor_pods" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults(clusterName string) error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.ExecutionRoleARN == "" {
+		return errors.New("empty ExecutionRoleARN")
+	}
+	if cfg.VirtualClusterName == "" {
+		cfg.VirtualClusterName = cfg.Namespace
+	}
+	if cfg.ReleaseLabel == "" {
+		cfg.ReleaseLabel = DefaultReleaseLabel
+	}
+	if cfg.EntryPoint == "" {
+		cfg.EntryPoint = DefaultEntryPoint
+	}
+	if cfg.ExecutorInstances == 0 {
+		cfg.ExecutorInstances = DefaultExecutorInstances
+	}
+	if cfg.JobRunTimeout == time.Duration(0) {
+		cfg.JobRunTimeout = DefaultJobRunTimeout
+	}
+	cfg.JobRunTimeoutString = cfg.JobRunTimeout.String()
+
+	cfg.ClusterName = clusterName
+	return nil
+}
+
+const (
+	DefaultMinimumNodes      int = 1
+	DefaultPartition             = "aws"
+	DefaultReleaseLabel          = "emr-6.15.0-latest"
+	DefaultEntryPoint            = "local:///usr/lib/spark/examples/src/main/python/pi.py"
+	DefaultExecutorInstances int = 2
+	DefaultJobRunTimeout         = 30 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:            false,
+		Prompt:            false,
+		Partition:         DefaultPartition,
+		MinimumNodes:      DefaultMinimumNodes,
+		Namespace:         pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		ReleaseLabel:      DefaultReleaseLabel,
+		EntryPoint:        DefaultEntryPoint,
+		ExecutorInstances: DefaultExecutorInstances,
+		JobRunTimeout:     DefaultJobRunTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.EMRContainersAPI = emrcontainers.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config, clusterName string) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	// emrUserName is the Kubernetes user mapped from the EMR on EKS service-linked role.
+	emrUserName            = "emr-containers"
+	appRBACRoleName        = "emr-containers"
+	appRBACRoleBindingName = "emr-containers"
+	jobRunName             = "k8s-tester-spark-pi"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createRBACRole(); err != nil {
+		return err
+	}
+
+	if err := ts.createRBACRoleBinding(); err != nil {
+		return err
+	}
+
+	if err := ts.createVirtualCluster(); err != nil {
+		return err
+	}
+
+	if err := ts.startJobRun(); err != nil {
+		return err
+	}
+
+	if err := ts.checkJobRun(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := ts.deleteVirtualCluster(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteRBACRoleBinding(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, appRBACRoleBindingName); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete RoleBinding (%v)", err))
+	}
+
+	if err := client.DeleteRBACRole(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, appRBACRoleName); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Role (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// ref. https://docs.aws.amazon.com/emr/latest/EMR-on-EKS-DevelopmentGuide/setting-up-cluster-access.html
+func (ts *tester) createRBACRole() error {
+	ts.cfg.Logger.Info("creating Role", zap.String("name", appRBACRoleName))
+	verbs := []string{"get", "list", "watch", "describe", "create", "edit", "delete", "deletecollection", "annotate", "patch", "label"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		Roles(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&rbac_v1.Role{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "Role",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      appRBACRoleName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Rules: []rbac_v1.PolicyRule{
+					{
+						APIGroups: []string{""},
+						Resources: []string{"namespaces"},
+						Verbs:     []string{"get"},
+					},
+					{
+						APIGroups: []string{""},
+						Resources: []string{"serviceaccounts", "services", "configmaps", "events", "pods", "pods/log", "persistentvolumeclaims"},
+						Verbs:     verbs,
+					},
+					{
+						APIGroups: []string{""},
+						Resources: []string{"secrets"},
+						Verbs:     []string{"create", "patch", "delete", "watch"},
+					},
+					{
+						APIGroups: []string{"apps"},
+						Resources: []string{"statefulsets", "deployments"},
+						Verbs:     verbs,
+					},
+					{
+						APIGroups: []string{"batch"},
+						Resources: []string{"jobs"},
+						Verbs:     verbs,
+					},
+					{
+						APIGroups: []string{"extensions", "networking.k8s.io"},
+						Resources: []string{"ingresses"},
+						Verbs:     verbs,
+					},
+					{
+						APIGroups: []string{"rbac.authorization.k8s.io"},
+						Resources: []string{"roles", "rolebindings"},
+						Verbs:     verbs,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("Role already exists", zap.String("name", appRBACRoleName))
+			return nil
+		}
+		return fmt.Errorf("failed to create Role (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Role", zap.String("name", appRBACRoleName))
+	return nil
+}
+
+func (ts *tester) createRBACRoleBinding() error {
+	ts.cfg.Logger.Info("creating RoleBinding", zap.String("name", appRBACRoleBindingName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		RoleBindings(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&rbac_v1.RoleBinding{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "RoleBinding",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      appRBACRoleBindingName,
+					Namespace: ts.cfg.Namespace,
+				},
+				RoleRef: rbac_v1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "Role",
+					Name:     appRBACRoleName,
+				},
+				Subjects: []rbac_v1.Subject{
+					{
+						APIGroup: "rbac.authorization.k8s.io",
+						Kind:     "User",
+						Name:     emrUserName,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("RoleBinding already exists", zap.String("name", appRBACRoleBindingName))
+			return nil
+		}
+		return fmt.Errorf("failed to create RoleBinding (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created RoleBinding", zap.String("name", appRBACRoleBindingName))
+	return nil
+}
+
+func (ts *tester) createVirtualCluster() error {
+	ts.cfg.Logger.Info("creating EMR virtual cluster",
+		zap.String("name", ts.cfg.VirtualClusterName),
+		zap.String("cluster-name", ts.cfg.ClusterName),
+		zap.String("namespace", ts.cfg.Namespace),
+	)
+	out, err := ts.cfg.EMRContainersAPI.CreateVirtualCluster(&emrcontainers.CreateVirtualClusterInput{
+		Name: aws.String(ts.cfg.VirtualClusterName),
+		ContainerProvider: &emrcontainers.ContainerProvider{
+			Id:   aws.String(ts.cfg.ClusterName),
+			Type: aws.String(emrcontainers.ContainerProviderTypeEks),
+			Info: &emrcontainers.ContainerInfo{
+				EksInfo: &emrcontainers.EksInfo{
+					Namespace: aws.String(ts.cfg.Namespace),
+				},
+			},
+		},
+		ClientToken: aws.String(ts.cfg.Namespace),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create EMR virtual cluster (%v)", err)
+	}
+	ts.cfg.VirtualClusterID = aws.StringValue(out.Id)
+
+	ts.cfg.Logger.Info("created EMR virtual cluster", zap.String("id", ts.cfg.VirtualClusterID))
+	return nil
+}
+
+func (ts *tester) deleteVirtualCluster() error {
+	if ts.cfg.VirtualClusterID == "" {
+		ts.cfg.Logger.Info("empty EMR virtual cluster ID; skipping deletion")
+		return nil
+	}
+
+	if ts.cfg.JobRunID != "" {
+		switch ts.cfg.JobRunState {
+		case emrcontainers.JobRunStateCompleted,
+			emrcontainers.JobRunStateFailed,
+			emrcontainers.JobRunStateCancelled:
+		default:
+			ts.cfg.Logger.Info("cancelling EMR job run", zap.String("id", ts.cfg.JobRunID))
+			_, err := ts.cfg.EMRContainersAPI.CancelJobRun(&emrcontainers.CancelJobRunInput{
+				VirtualClusterId: aws.String(ts.cfg.VirtualClusterID),
+				Id:               aws.String(ts.cfg.JobRunID),
+			})
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to cancel EMR job run", zap.Error(err))
+			}
+		}
+	}
+
+	ts.cfg.Logger.Info("deleting EMR virtual cluster", zap.String("id", ts.cfg.VirtualClusterID))
+	_, err := ts.cfg.EMRContainersAPI.DeleteVirtualCluster(&emrcontainers.DeleteVirtualClusterInput{
+		Id: aws.String(ts.cfg.VirtualClusterID),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), emrcontainers.ErrCodeResourceNotFoundException) {
+			ts.cfg.Logger.Info("EMR virtual cluster already deleted", zap.Error(err))
+			return nil
+		}
+		return fmt.Errorf("failed to delete EMR virtual cluster (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("deleted EMR virtual cluster", zap.String("id", ts.cfg.VirtualClusterID))
+	return nil
+}
+
+func (ts *tester) startJobRun() error {
+	params := fmt.Sprintf(
+		"--conf spark.executor.instances=%d --conf spark.executor.memory=2G --conf spark.executor.cores=1 --conf spark.driver.cores=1",
+		ts.cfg.ExecutorInstances,
+	)
+	ts.cfg.Logger.Info("starting EMR job run",
+		zap.String("release-label", ts.cfg.ReleaseLabel),
+		zap.String("entry-point", ts.cfg.EntryPoint),
+		zap.String("spark-submit-parameters", params),
+	)
+	out, err := ts.cfg.EMRContainersAPI.StartJobRun(&emrcontainers.StartJobRunInput{
+		VirtualClusterId: aws.String(ts.cfg.VirtualClusterID),
+		Name:             aws.String(jobRunName),
+		ExecutionRoleArn: aws.String(ts.cfg.ExecutionRoleARN),
+		ReleaseLabel:     aws.String(ts.cfg.ReleaseLabel),
+		JobDriver: &emrcontainers.JobDriver{
+			SparkSubmitJobDriver: &emrcontainers.SparkSubmitJobDriver{
+				EntryPoint:            aws.String(ts.cfg.EntryPoint),
+				SparkSubmitParameters: aws.String(params),
+			},
+		},
+		ClientToken: aws.String(ts.cfg.Namespace + "-" + jobRunName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start EMR job run (%v)", err)
+	}
+	ts.cfg.JobRunID = aws.StringValue(out.Id)
+
+	ts.cfg.Logger.Info("started EMR job run", zap.String("id", ts.cfg.JobRunID))
+	return nil
+}
+
+// checkJobRun polls the job run state until it completes,
+// while counting the Spark driver and executor Pods scheduled in the namespace.
+// Executor Pods are removed when the job completes, thus counted while running.
+func (ts *tester) checkJobRun() error {
+	ts.cfg.Logger.Info("waiting for EMR job run", zap.String("id", ts.cfg.JobRunID), zap.Duration("timeout", ts.cfg.JobRunTimeout))
+	drivers, executors := make(map[string]struct{}), make(map[string]struct{})
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.JobRunTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check EMR job run aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		pods, err := client.ListPods(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, 1000, 2*time.Second)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Pods; retrying", zap.Error(err))
+		}
+		for _, pod := range pods {
+			if pod.Spec.NodeName == "" || pod.Status.Phase == core_v1.PodPending {
+				continue
+			}
+			switch pod.Labels["spark-role"] {
+			case "driver":
+				drivers[pod.Name] = struct{}{}
+			case "executor":
+				executors[pod.Name] = struct{}{}
+			}
+		}
+		ts.cfg.ObservedDriverPods, ts.cfg.ObservedExecutorPods = len(drivers), len(executors)
+
+		out, err := ts.cfg.EMRContainersAPI.DescribeJobRun(&emrcontainers.DescribeJobRunInput{
+			VirtualClusterId: aws.String(ts.cfg.VirtualClusterID),
+			Id:               aws.String(ts.cfg.JobRunID),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe EMR job run; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.JobRunState = aws.StringValue(out.JobRun.State)
+		ts.cfg.Logger.Info("checked EMR job run",
+			zap.String("state", ts.cfg.JobRunState),
+			zap.Int("driver-pods", ts.cfg.ObservedDriverPods),
+			zap.Int("executor-pods", ts.cfg.ObservedExecutorPods),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+
+		switch ts.cfg.JobRunState {
+		case emrcontainers.JobRunStateCompleted:
+			if ts.cfg.ObservedDriverPods < 1 {
+				return errors.New("EMR job run completed but no Spark driver Pod was observed")
+			}
+			if ts.cfg.ObservedExecutorPods < ts.cfg.ExecutorInstances {
+				return fmt.Errorf("EMR job run completed but observed %d Spark executor Pods (expected %d)", ts.cfg.ObservedExecutorPods, ts.cfg.ExecutorInstances)
+			}
+			ts.cfg.Logger.Info("EMR job run completed")
+			return nil
+		case emrcontainers.JobRunStateFailed, emrcontainers.JobRunStateCancelled:
+			return fmt.Errorf("EMR job run %q %s (%s)", ts.cfg.JobRunID, ts.cfg.JobRunState, aws.StringValue(out.JobRun.StateDetails))
+		}
+	}
+
+	return fmt.Errorf("EMR job run %q did not complete within %v (last state %q)", ts.cfg.JobRunID, ts.cfg.JobRunTimeout, ts.cfg.JobRunState)
+}
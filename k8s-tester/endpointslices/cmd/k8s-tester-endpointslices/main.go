@@ -0,0 +1,184 @@
+// k8s-tester-endpointslices installs Kubernetes EndpointSlice scale and consistency tester.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/endpointslices"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-endpointslices",
+	Short:      "Kubernetes EndpointSlice scale and consistency tester",
+	SuggestFor: []string{"endpointslices"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", endpointslices.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-endpointslices failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	image                                 string
+	services                              int
+	endpointsPerService                   int32
+	maxEndpointsPerSlice                  int
+	mirroredEndpoints                     int
+	churnRounds                           int
+	churnPods                             int
+	propagationTimeout                    time.Duration
+	propagationLatencyP99Threshold        time.Duration
+	networkProgrammingLatencyP99Threshold time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&image, "image", endpointslices.DefaultImage, "backend Pod image")
+	cmd.PersistentFlags().IntVar(&services, "services", endpointslices.DefaultServices, "number of Services")
+	cmd.PersistentFlags().Int32Var(&endpointsPerService, "endpoints-per-service", endpointslices.DefaultEndpointsPerService, "number of backend Pods per Service")
+	cmd.PersistentFlags().IntVar(&maxEndpointsPerSlice, "max-endpoints-per-slice", endpointslices.DefaultMaxEndpointsPerSlice, "kube-controller-manager --max-endpoints-per-slice")
+	cmd.PersistentFlags().IntVar(&mirroredEndpoints, "mirrored-endpoints", endpointslices.DefaultMirroredEndpoints, "number of custom Endpoints addresses to validate the mirroring, 0 to skip")
+	cmd.PersistentFlags().IntVar(&churnRounds, "churn-rounds", endpointslices.DefaultChurnRounds, "number of rounds to delete the backend Pods")
+	cmd.PersistentFlags().IntVar(&churnPods, "churn-pods", endpointslices.DefaultChurnPods, "number of backend Pods to delete per Service in each round")
+	cmd.PersistentFlags().DurationVar(&propagationTimeout, "propagation-timeout", endpointslices.DefaultPropagationTimeout, "timeout for the EndpointSlices to reflect the changes")
+	cmd.PersistentFlags().DurationVar(&propagationLatencyP99Threshold, "propagation-latency-p99-threshold", endpointslices.DefaultPropagationLatencyP99Threshold, "maximum p99 latency from the backend Pod deletion to the EndpointSlice update")
+	cmd.PersistentFlags().DurationVar(&networkProgrammingLatencyP99Threshold, "network-programming-latency-p99-threshold", endpointslices.DefaultNetworkProgrammingLatencyP99Threshold, "maximum p99 kube-proxy network programming latency, 0 to skip")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &endpointslices.Config{
+		Prompt:                                prompt,
+		Logger:                                lg,
+		LogWriter:                             logWriter,
+		MinimumNodes:                          minimumNodes,
+		Namespace:                             namespace,
+		Client:                                cli,
+		Image:                                 image,
+		Services:                              services,
+		EndpointsPerService:                   endpointsPerService,
+		MaxEndpointsPerSlice:                  maxEndpointsPerSlice,
+		MirroredEndpoints:                     mirroredEndpoints,
+		ChurnRounds:                           churnRounds,
+		ChurnPods:                             churnPods,
+		PropagationTimeout:                    propagationTimeout,
+		PropagationLatencyP99Threshold:        propagationLatencyP99Threshold,
+		NetworkProgrammingLatencyP99Threshold: networkProgrammingLatencyP99Threshold,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := endpointslices.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-endpointslices apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &endpointslices.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := endpointslices.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-endpointslices delete' success\n")
+}
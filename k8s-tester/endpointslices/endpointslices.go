@@ -0,0 +1,227 @@
+package endpointslices
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/utils/latency"
+	"github.com/prometheus/common/expfmt"
+	discovery_v1 "k8s.io/api/discovery/v1"
+)
+
+// endpointReady returns true if the endpoint is ready.
+// A nil ready condition is interpreted as ready.
+// ref. https://kubernetes.io/docs/concepts/services-networking/endpoint-slices/#conditions
+func endpointReady(ep discovery_v1.Endpoint) bool {
+	return ep.Conditions.Ready == nil || *ep.Conditions.Ready
+}
+
+// validateSlices validates the EndpointSlices of a Service against the expected addresses.
+// Each slice must be within the maximum endpoints, and each expected address
+// must be in exactly one slice. Returns the number of addresses in the slices.
+func validateSlices(slices []discovery_v1.EndpointSlice, expected map[string]struct{}, maxPerSlice int) (total int, err error) {
+	seen := make(map[string]string)
+	for _, s := range slices {
+		if maxPerSlice > 0 && len(s.Endpoints) > maxPerSlice {
+			return 0, fmt.Errorf("EndpointSlice %q has %d endpoints > max %d", s.Name, len(s.Endpoints), maxPerSlice)
+		}
+		for _, ep := range s.Endpoints {
+			for _, addr := range ep.Addresses {
+				if prev, ok := seen[addr]; ok {
+					return 0, fmt.Errorf("address %q duplicated in EndpointSlices %q and %q", addr, prev, s.Name)
+				}
+				seen[addr] = s.Name
+			}
+		}
+	}
+	var missing, unexpected []string
+	for addr := range expected {
+		if _, ok := seen[addr]; !ok {
+			missing = append(missing, addr)
+		}
+	}
+	for addr := range seen {
+		if _, ok := expected[addr]; !ok {
+			unexpected = append(unexpected, addr)
+		}
+	}
+	if len(missing) > 0 || len(unexpected) > 0 {
+		sort.Strings(missing)
+		sort.Strings(unexpected)
+		return 0, fmt.Errorf("EndpointSlices inconsistent (%d missing %s, %d unexpected %s)", len(missing), truncate(missing), len(unexpected), truncate(unexpected))
+	}
+	return len(seen), nil
+}
+
+func truncate(ss []string) string {
+	if len(ss) > 5 {
+		return fmt.Sprintf("%q...", ss[:5])
+	}
+	return fmt.Sprintf("%q", ss)
+}
+
+// minSlices returns the minimum number of EndpointSlices for the endpoints.
+func minSlices(endpoints int, maxPerSlice int) int {
+	if maxPerSlice <= 0 {
+		return 1
+	}
+	return int(math.Ceil(float64(endpoints) / float64(maxPerSlice)))
+}
+
+// sliceTracker tracks the ready addresses of the EndpointSlices from the watch events,
+// and records the latency from each backend deletion to its removal from all slices.
+type sliceTracker struct {
+	mu        sync.Mutex
+	ready     map[string]map[string]struct{}
+	pending   map[string]time.Time
+	latencies latency.Durations
+}
+
+func newSliceTracker() *sliceTracker {
+	return &sliceTracker{
+		ready:   make(map[string]map[string]struct{}),
+		pending: make(map[string]time.Time),
+	}
+}
+
+// update updates the ready addresses of the slice, and resolves the pending addresses.
+func (t *sliceTracker) update(s *discovery_v1.EndpointSlice, deleted bool, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if deleted {
+		delete(t.ready, s.Name)
+	} else {
+		addrs := make(map[string]struct{})
+		for _, ep := range s.Endpoints {
+			if !endpointReady(ep) {
+				continue
+			}
+			for _, addr := range ep.Addresses {
+				addrs[addr] = struct{}{}
+			}
+		}
+		t.ready[s.Name] = addrs
+	}
+	t.resolve(now)
+}
+
+// expect marks the addresses to be removed from the slices, since the deletion time.
+func (t *sliceTracker) expect(addrs []string, deleted time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, addr := range addrs {
+		t.pending[addr] = deleted
+	}
+}
+
+func (t *sliceTracker) resolve(now time.Time) {
+	for addr, deleted := range t.pending {
+		found := false
+		for _, addrs := range t.ready {
+			if _, ok := addrs[addr]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.latencies = append(t.latencies, now.Sub(deleted))
+			delete(t.pending, addr)
+		}
+	}
+}
+
+// drain returns the number of pending addresses and clears them.
+func (t *sliceTracker) drain() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := len(t.pending)
+	t.pending = make(map[string]time.Time)
+	return n
+}
+
+func (t *sliceTracker) pendings() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.pending)
+}
+
+// networkProgrammingMetric is the kube-proxy histogram of the latency
+// from the Service or Pod change to the proxy rules being programmed.
+// ref. https://github.com/kubernetes/community/blob/master/sig-scalability/slos/network_programming_latency.md
+const networkProgrammingMetric = "kubeproxy_network_programming_duration_seconds"
+
+// parseHistogramBuckets returns the cumulative counts by the upper bound
+// of the histogram, summed across all label sets.
+func parseHistogramBuckets(b []byte, name string) (map[float64]float64, error) {
+	var parser expfmt.TextParser
+	mfs, err := parser.TextToMetricFamilies(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	mf, ok := mfs[name]
+	if !ok {
+		return nil, fmt.Errorf("%q not found", name)
+	}
+	buckets := make(map[float64]float64)
+	for _, m := range mf.GetMetric() {
+		h := m.GetHistogram()
+		for _, bk := range h.GetBucket() {
+			if math.IsInf(bk.GetUpperBound(), 1) {
+				continue
+			}
+			buckets[bk.GetUpperBound()] += float64(bk.GetCumulativeCount())
+		}
+		buckets[math.Inf(1)] += float64(h.GetSampleCount())
+	}
+	return buckets, nil
+}
+
+// addBuckets adds the cumulative counts of "b" to "a".
+func addBuckets(a, b map[float64]float64) {
+	for k, v := range b {
+		a[k] += v
+	}
+}
+
+// histogramQuantile returns the quantile of the observations between
+// the two cumulative histograms, with the linear interpolation in the bucket,
+// in the same way as the Prometheus "histogram_quantile".
+func histogramQuantile(q float64, before, after map[float64]float64) (time.Duration, float64) {
+	bounds := make([]float64, 0, len(after))
+	for k := range after {
+		bounds = append(bounds, k)
+	}
+	sort.Float64s(bounds)
+	if len(bounds) == 0 {
+		return 0, 0
+	}
+	total := after[math.Inf(1)] - before[math.Inf(1)]
+	if total <= 0 {
+		return 0, 0
+	}
+	rank := q * total
+	prevBound, prevCount := 0.0, 0.0
+	for _, bound := range bounds {
+		count := after[bound] - before[bound]
+		if count >= rank {
+			if math.IsInf(bound, 1) {
+				return secondsToDuration(prevBound), total
+			}
+			v := prevBound
+			if count > prevCount {
+				v += (bound - prevBound) * (rank - prevCount) / (count - prevCount)
+			}
+			return secondsToDuration(v), total
+		}
+		prevBound, prevCount = bound, count
+	}
+	return secondsToDuration(prevBound), total
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
@@ -0,0 +1,144 @@
+package endpointslices
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	discovery_v1 "k8s.io/api/discovery/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func slice(name string, ready bool, addrs ...string) discovery_v1.EndpointSlice {
+	s := discovery_v1.EndpointSlice{ObjectMeta: meta_v1.ObjectMeta{Name: name}}
+	for _, addr := range addrs {
+		r := ready
+		s.Endpoints = append(s.Endpoints, discovery_v1.Endpoint{
+			Addresses:  []string{addr},
+			Conditions: discovery_v1.EndpointConditions{Ready: &r},
+		})
+	}
+	return s
+}
+
+func TestValidateSlices(t *testing.T) {
+	expected := map[string]struct{}{"10.0.0.1": {}, "10.0.0.2": {}, "10.0.0.3": {}}
+
+	total, err := validateSlices([]discovery_v1.EndpointSlice{
+		slice("a", true, "10.0.0.1", "10.0.0.2"),
+		slice("b", true, "10.0.0.3"),
+	}, expected, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Fatalf("expected 3, got %d", total)
+	}
+
+	tests := []struct {
+		slices []discovery_v1.EndpointSlice
+		errMsg string
+	}{
+		{[]discovery_v1.EndpointSlice{slice("a", true, "10.0.0.1", "10.0.0.2", "10.0.0.3")}, "> max"},
+		{[]discovery_v1.EndpointSlice{slice("a", true, "10.0.0.1", "10.0.0.2"), slice("b", true, "10.0.0.2", "10.0.0.3")}, "duplicated"},
+		{[]discovery_v1.EndpointSlice{slice("a", true, "10.0.0.1", "10.0.0.2")}, "1 missing"},
+		{[]discovery_v1.EndpointSlice{slice("a", true, "10.0.0.1", "10.0.0.2"), slice("b", true, "10.0.0.3", "10.0.0.4")}, "1 unexpected"},
+	}
+	for i, tt := range tests {
+		_, err := validateSlices(tt.slices, expected, 2)
+		if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Fatalf("#%d: expected error %q, got %v", i, tt.errMsg, err)
+		}
+	}
+
+	if n := minSlices(1000, 100); n != 10 {
+		t.Fatalf("expected 10, got %d", n)
+	}
+	if n := minSlices(1001, 100); n != 11 {
+		t.Fatalf("expected 11, got %d", n)
+	}
+}
+
+func TestSliceTracker(t *testing.T) {
+	tr := newSliceTracker()
+	now := time.Now()
+	a := slice("a", true, "10.0.0.1", "10.0.0.2")
+	tr.update(&a, false, now)
+
+	tr.expect([]string{"10.0.0.1", "10.0.0.2"}, now)
+	if n := tr.pendings(); n != 2 {
+		t.Fatalf("expected 2 pending, got %d", n)
+	}
+
+	// terminating endpoint is not ready
+	a = slice("a", true, "10.0.0.2")
+	a.Endpoints = append(a.Endpoints, slice("", false, "10.0.0.1").Endpoints...)
+	tr.update(&a, false, now.Add(time.Second))
+	if n := tr.pendings(); n != 1 {
+		t.Fatalf("expected 1 pending, got %d", n)
+	}
+	if len(tr.latencies) != 1 || tr.latencies[0] != time.Second {
+		t.Fatalf("unexpected latencies %v", tr.latencies)
+	}
+
+	tr.update(&a, true, now.Add(3*time.Second))
+	if n := tr.pendings(); n != 0 {
+		t.Fatalf("expected 0 pending, got %d", n)
+	}
+	if len(tr.latencies) != 2 || tr.latencies[1] != 3*time.Second {
+		t.Fatalf("unexpected latencies %v", tr.latencies)
+	}
+
+	tr.expect([]string{"10.0.0.9"}, now)
+	if n := tr.drain(); n != 1 {
+		t.Fatalf("expected 1 drained, got %d", n)
+	}
+	if n := tr.pendings(); n != 0 {
+		t.Fatalf("expected 0 pending, got %d", n)
+	}
+}
+
+const kubeProxyMetrics = `# HELP kubeproxy_network_programming_duration_seconds [ALPHA] In Cluster Network Programming Latency in seconds
+# TYPE kubeproxy_network_programming_duration_seconds histogram
+kubeproxy_network_programming_duration_seconds_bucket{le="0.5"} %d
+kubeproxy_network_programming_duration_seconds_bucket{le="1"} %d
+kubeproxy_network_programming_duration_seconds_bucket{le="2"} %d
+kubeproxy_network_programming_duration_seconds_bucket{le="+Inf"} %d
+kubeproxy_network_programming_duration_seconds_sum 100
+kubeproxy_network_programming_duration_seconds_count %d
+`
+
+func TestHistogramQuantile(t *testing.T) {
+	before, err := parseHistogramBuckets([]byte(strings.NewReplacer("%d", "10").Replace(kubeProxyMetrics)), networkProgrammingMetric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := parseHistogramBuckets([]byte(`# TYPE kubeproxy_network_programming_duration_seconds histogram
+kubeproxy_network_programming_duration_seconds_bucket{le="0.5"} 60
+kubeproxy_network_programming_duration_seconds_bucket{le="1"} 90
+kubeproxy_network_programming_duration_seconds_bucket{le="2"} 110
+kubeproxy_network_programming_duration_seconds_bucket{le="+Inf"} 110
+kubeproxy_network_programming_duration_seconds_sum 100
+kubeproxy_network_programming_duration_seconds_count 110
+`), networkProgrammingMetric)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 100 observations between the two: 50 <= 0.5s, 30 <= 1s, 20 <= 2s
+	p50, total := histogramQuantile(0.5, before, after)
+	if total != 100 {
+		t.Fatalf("expected 100 samples, got %v", total)
+	}
+	if p50 != 500*time.Millisecond {
+		t.Fatalf("expected p50 500ms, got %v", p50)
+	}
+	p99, _ := histogramQuantile(0.99, before, after)
+	if p99 != 1950*time.Millisecond {
+		t.Fatalf("expected p99 1.95s, got %v", p99)
+	}
+
+	if _, err = parseHistogramBuckets([]byte("up 1\n"), networkProgrammingMetric); err == nil {
+		t.Fatal("expected error for missing metric")
+	}
+}
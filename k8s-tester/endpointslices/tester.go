@@ -0,0 +1,820 @@
+// Package endpointslices creates Services with thousands of endpoints, validates
+// the EndpointSlice distribution and the mirroring of the custom Endpoints,
+// and measures the endpoint propagation latency as the backends churn.
+// ref. https://kubernetes.io/docs/concepts/services-networking/endpoint-slices/
+package endpointslices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/latency"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	discovery_v1 "k8s.io/api/discovery/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the backend Pod image.
+	Image string `json:"image"`
+	// Services is the number of Services, each with its own backend Deployment.
+	Services int `json:"services"`
+	// EndpointsPerService is the number of backend Pods per Service.
+	EndpointsPerService int32 `json:"endpoints_per_service"`
+	// MaxEndpointsPerSlice is the kube-controller-manager "--max-endpoints-per-slice".
+	MaxEndpointsPerSlice int `json:"max_endpoints_per_slice"`
+	// MirroredEndpoints is the number of addresses in the custom Endpoints
+	// of the selector-less Service, to validate the EndpointSlice mirroring.
+	// The mirroring controller mirrors at most 1,000 addresses. Zero to skip.
+	MirroredEndpoints int `json:"mirrored_endpoints"`
+
+	// ChurnRounds is the number of rounds to delete the backend Pods.
+	ChurnRounds int `json:"churn_rounds"`
+	// ChurnPods is the number of backend Pods to delete per Service in each round.
+	ChurnPods int `json:"churn_pods"`
+
+	// PropagationTimeout is the timeout for the EndpointSlices to reflect the changes.
+	PropagationTimeout       time.Duration `json:"propagation_timeout"`
+	PropagationTimeoutString string        `json:"propagation_timeout_string" read-only:"true"`
+	// PropagationLatencyP99Threshold is the maximum p99 latency
+	// from the backend Pod deletion to its removal from the EndpointSlices.
+	PropagationLatencyP99Threshold       time.Duration `json:"propagation_latency_p99_threshold"`
+	PropagationLatencyP99ThresholdString string        `json:"propagation_latency_p99_threshold_string" read-only:"true"`
+	// NetworkProgrammingLatencyP99Threshold is the maximum p99 kube-proxy
+	// network programming latency during the churn. Zero to skip.
+	NetworkProgrammingLatencyP99Threshold       time.Duration `json:"network_programming_latency_p99_threshold"`
+	NetworkProgrammingLatencyP99ThresholdString string        `json:"network_programming_latency_p99_threshold_string" read-only:"true"`
+
+	// Result is the EndpointSlice test result.
+	Result Result `json:"result" read-only:"true"`
+}
+
+// Result is the EndpointSlice test result.
+type Result struct {
+	// Endpoints is the total number of backend endpoints in the EndpointSlices.
+	Endpoints int `json:"endpoints"`
+	// Slices is the total number of backend EndpointSlices.
+	Slices int `json:"slices"`
+	// MinSlices is the minimum number of EndpointSlices for the backend endpoints.
+	MinSlices int `json:"min_slices"`
+	// MirroredEndpoints is the number of addresses in the mirrored EndpointSlices.
+	MirroredEndpoints int `json:"mirrored_endpoints"`
+	// MirroredSlices is the number of mirrored EndpointSlices.
+	MirroredSlices int `json:"mirrored_slices"`
+
+	// PropagationLatency is the latency from the backend Pod deletion
+	// to its removal from the EndpointSlices, observed by the watch.
+	// "FailureTotal" is the number of the deletions not observed within the timeout.
+	PropagationLatency latency.Summary `json:"propagation_latency"`
+
+	// NetworkProgrammingNodes is the number of nodes whose kube-proxy metrics are scraped.
+	NetworkProgrammingNodes int `json:"network_programming_nodes"`
+	// NetworkProgrammingSamples is the number of kube-proxy network programming observations during the churn.
+	NetworkProgrammingSamples float64 `json:"network_programming_samples"`
+	// NetworkProgrammingLatencyP50 is the 50-percentile kube-proxy network programming latency during the churn.
+	NetworkProgrammingLatencyP50       time.Duration `json:"network_programming_latency_p50"`
+	NetworkProgrammingLatencyP50String string        `json:"network_programming_latency_p50_string"`
+	// NetworkProgrammingLatencyP99 is the 99-percentile kube-proxy network programming latency during the churn.
+	NetworkProgrammingLatencyP99       time.Duration `json:"network_programming_latency_p99"`
+	NetworkProgrammingLatencyP99String string        `json:"network_programming_latency_p99_string"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.Services <= 0 {
+		cfg.Services = DefaultServices
+	}
+	if cfg.EndpointsPerService <= 0 {
+		cfg.EndpointsPerService = DefaultEndpointsPerService
+	}
+	if cfg.MaxEndpointsPerSlice <= 0 {
+		cfg.MaxEndpointsPerSlice = DefaultMaxEndpointsPerSlice
+	}
+	if cfg.MirroredEndpoints < 0 || cfg.MirroredEndpoints > maxMirroredEndpoints {
+		return fmt.Errorf("invalid MirroredEndpoints %d (must be 0 to %d)", cfg.MirroredEndpoints, maxMirroredEndpoints)
+	}
+	if cfg.ChurnRounds < 0 {
+		return fmt.Errorf("invalid ChurnRounds %d", cfg.ChurnRounds)
+	}
+	if cfg.ChurnPods < 0 || cfg.ChurnPods > int(cfg.EndpointsPerService) {
+		return fmt.Errorf("invalid ChurnPods %d (EndpointsPerService %d)", cfg.ChurnPods, cfg.EndpointsPerService)
+	}
+	if cfg.PropagationTimeout == time.Duration(0) {
+		cfg.PropagationTimeout = DefaultPropagationTimeout
+	}
+	cfg.PropagationTimeoutString = cfg.PropagationTimeout.String()
+	if cfg.PropagationLatencyP99Threshold == time.Duration(0) {
+		cfg.PropagationLatencyP99Threshold = DefaultPropagationLatencyP99Threshold
+	}
+	cfg.PropagationLatencyP99ThresholdString = cfg.PropagationLatencyP99Threshold.String()
+	cfg.NetworkProgrammingLatencyP99ThresholdString = cfg.NetworkProgrammingLatencyP99Threshold.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes                   int   = 10
+	DefaultImage                                = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	DefaultServices                       int   = 1
+	DefaultEndpointsPerService            int32 = 1000
+	DefaultMaxEndpointsPerSlice           int   = 100
+	DefaultMirroredEndpoints              int   = 1000
+	DefaultChurnRounds                    int   = 5
+	DefaultChurnPods                      int   = 50
+	DefaultPropagationTimeout                   = 5 * time.Minute
+	DefaultPropagationLatencyP99Threshold       = 10 * time.Second
+	// DefaultNetworkProgrammingLatencyP99Threshold is the SIG scalability SLO.
+	// ref. https://github.com/kubernetes/community/blob/master/sig-scalability/slos/network_programming_latency.md
+	DefaultNetworkProgrammingLatencyP99Threshold = 30 * time.Second
+
+	// maxMirroredEndpoints is the maximum number of addresses
+	// mirrored from the custom Endpoints to the EndpointSlices.
+	maxMirroredEndpoints = 1000
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:                                false,
+		Prompt:                                false,
+		MinimumNodes:                          DefaultMinimumNodes,
+		Namespace:                             pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:                                 DefaultImage,
+		Services:                              DefaultServices,
+		EndpointsPerService:                   DefaultEndpointsPerService,
+		MaxEndpointsPerSlice:                  DefaultMaxEndpointsPerSlice,
+		MirroredEndpoints:                     DefaultMirroredEndpoints,
+		ChurnRounds:                           DefaultChurnRounds,
+		ChurnPods:                             DefaultChurnPods,
+		PropagationTimeout:                    DefaultPropagationTimeout,
+		PropagationLatencyP99Threshold:        DefaultPropagationLatencyP99Threshold,
+		NetworkProgrammingLatencyP99Threshold: DefaultNetworkProgrammingLatencyP99Threshold,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	backendPrefix = "backend"
+	mirroredName  = "mirrored"
+	appLabel      = "app.kubernetes.io/name"
+
+	managedByController = "endpointslice-controller.k8s.io"
+	managedByMirroring  = "endpointslicemirroring-controller.k8s.io"
+
+	kubeProxyMetricsPort = 10249
+)
+
+func backendName(i int) string { return fmt.Sprintf("%s-%d", backendPrefix, i) }
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	for i := 0; i < ts.cfg.Services; i++ {
+		if err := ts.createService(backendName(i), map[string]string{appLabel: backendName(i)}); err != nil {
+			return err
+		}
+		if err := ts.createDeployment(backendName(i)); err != nil {
+			return err
+		}
+	}
+	if err := ts.waitForDeployments(); err != nil {
+		return err
+	}
+	if err := ts.checkBackendSlices(); err != nil {
+		return err
+	}
+
+	if ts.cfg.MirroredEndpoints > 0 {
+		if err := ts.createService(mirroredName, nil); err != nil {
+			return err
+		}
+		if err := ts.createEndpoints(); err != nil {
+			return err
+		}
+		if err := ts.checkMirroredSlices(); err != nil {
+			return err
+		}
+	}
+
+	if ts.cfg.ChurnRounds == 0 || ts.cfg.ChurnPods == 0 {
+		return nil
+	}
+	return ts.churn()
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// createService creates the Service, selector-less if the selector is nil.
+func (ts *tester) createService(name string, selector map[string]string) error {
+	ts.cfg.Logger.Info("creating Service", zap.String("name", name))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Services(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Service{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: core_v1.ServiceSpec{
+					Type:     core_v1.ServiceTypeClusterIP,
+					Selector: selector,
+					Ports: []core_v1.ServicePort{
+						{
+							Name:       "http",
+							Protocol:   core_v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("Service already exists", zap.String("name", name))
+			return nil
+		}
+		return fmt.Errorf("failed to create Service %q (%v)", name, err)
+	}
+	return nil
+}
+
+func (ts *tester) createDeployment(name string) error {
+	ts.cfg.Logger.Info("creating Deployment", zap.String("name", name), zap.Int32("replicas", ts.cfg.EndpointsPerService))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &ts.cfg.EndpointsPerService,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{appLabel: name},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{appLabel: name},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            backendPrefix,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("Deployment already exists", zap.String("name", name))
+			return nil
+		}
+		return fmt.Errorf("failed to create Deployment %q (%v)", name, err)
+	}
+	return nil
+}
+
+func (ts *tester) waitForDeployments() error {
+	for i := 0; i < ts.cfg.Services; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+		_, err := client.WaitForDeploymentAvailables(
+			ctx,
+			ts.cfg.Logger,
+			ts.cfg.LogWriter,
+			ts.cfg.Stopc,
+			ts.cfg.Client.KubernetesClient(),
+			10*time.Second,
+			10*time.Second,
+			ts.cfg.Namespace,
+			backendName(i),
+			ts.cfg.EndpointsPerService,
+		)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("Deployment %q not available (%v)", backendName(i), err)
+		}
+	}
+	return nil
+}
+
+func (ts *tester) listSlices(serviceName string, managedBy string) ([]discovery_v1.EndpointSlice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ls, err := ts.cfg.Client.KubernetesClient().
+		DiscoveryV1().
+		EndpointSlices(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s,%s=%s", discovery_v1.LabelServiceName, serviceName, discovery_v1.LabelManagedBy, managedBy),
+		})
+	if err != nil {
+		return nil, err
+	}
+	return ls.Items, nil
+}
+
+// readyPodIPs returns the IPs of the running and not terminating backend Pods.
+func (ts *tester) readyPodIPs(name string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	pods, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{LabelSelector: appLabel + "=" + name})
+	if err != nil {
+		return nil, err
+	}
+	ips := make(map[string]string)
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != core_v1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		ips[pod.Status.PodIP] = pod.Name
+	}
+	return ips, nil
+}
+
+// waitForSlices polls until the EndpointSlices of the Service are consistent
+// with the expected addresses, and returns the slices.
+func (ts *tester) waitForSlices(serviceName string, managedBy string, expected func() (map[string]struct{}, error)) ([]discovery_v1.EndpointSlice, int, error) {
+	deadline := time.Now().Add(ts.cfg.PropagationTimeout)
+	var lastErr error
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, 0, errors.New("stopped")
+		case <-time.After(5 * time.Second):
+		}
+
+		addrs, err := expected()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		slices, err := ts.listSlices(serviceName, managedBy)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		total, err := validateSlices(slices, addrs, ts.cfg.MaxEndpointsPerSlice)
+		if err == nil {
+			return slices, total, nil
+		}
+		lastErr = err
+		ts.cfg.Logger.Info("waiting for EndpointSlices", zap.String("service", serviceName), zap.Error(err))
+	}
+	return nil, 0, fmt.Errorf("EndpointSlices of Service %q not consistent within %v (%v)", serviceName, ts.cfg.PropagationTimeout, lastErr)
+}
+
+func (ts *tester) checkBackendSlices() error {
+	ts.cfg.Result.Endpoints, ts.cfg.Result.Slices, ts.cfg.Result.MinSlices = 0, 0, 0
+	for i := 0; i < ts.cfg.Services; i++ {
+		name := backendName(i)
+		slices, total, err := ts.waitForSlices(name, managedByController, func() (map[string]struct{}, error) {
+			ips, err := ts.readyPodIPs(name)
+			if err != nil {
+				return nil, err
+			}
+			addrs := make(map[string]struct{}, len(ips))
+			for ip := range ips {
+				addrs[ip] = struct{}{}
+			}
+			return addrs, nil
+		})
+		if err != nil {
+			return err
+		}
+		ts.cfg.Logger.Info("validated EndpointSlices",
+			zap.String("service", name),
+			zap.Int("endpoints", total),
+			zap.Int("slices", len(slices)),
+			zap.Int("min-slices", minSlices(total, ts.cfg.MaxEndpointsPerSlice)),
+		)
+		ts.cfg.Result.Endpoints += total
+		ts.cfg.Result.Slices += len(slices)
+		ts.cfg.Result.MinSlices += minSlices(total, ts.cfg.MaxEndpointsPerSlice)
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\nEndpointSlices: %d endpoints in %d slices (minimum %d slices)\n\n", ts.cfg.Result.Endpoints, ts.cfg.Result.Slices, ts.cfg.Result.MinSlices)
+	return nil
+}
+
+// mirroredAddresses returns the addresses for the custom Endpoints
+// in the benchmarking range "198.18.0.0/15".
+func mirroredAddresses(n int) []string {
+	addrs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		addrs = append(addrs, fmt.Sprintf("198.18.%d.%d", i/250, i%250+1))
+	}
+	return addrs
+}
+
+func (ts *tester) createEndpoints() error {
+	addrs := mirroredAddresses(ts.cfg.MirroredEndpoints)
+	ts.cfg.Logger.Info("creating custom Endpoints", zap.String("name", mirroredName), zap.Int("addresses", len(addrs)))
+	eas := make([]core_v1.EndpointAddress, 0, len(addrs))
+	for _, addr := range addrs {
+		eas = append(eas, core_v1.EndpointAddress{IP: addr})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Endpoints(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Endpoints{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Endpoints",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      mirroredName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Subsets: []core_v1.EndpointSubset{
+					{
+						Addresses: eas,
+						Ports: []core_v1.EndpointPort{
+							{Name: "http", Port: 8080, Protocol: core_v1.ProtocolTCP},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("custom Endpoints already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create custom Endpoints (%v)", err)
+	}
+	return nil
+}
+
+func (ts *tester) checkMirroredSlices() error {
+	slices, total, err := ts.waitForSlices(mirroredName, managedByMirroring, func() (map[string]struct{}, error) {
+		addrs := make(map[string]struct{}, ts.cfg.MirroredEndpoints)
+		for _, addr := range mirroredAddresses(ts.cfg.MirroredEndpoints) {
+			addrs[addr] = struct{}{}
+		}
+		return addrs, nil
+	})
+	if err != nil {
+		return err
+	}
+	ts.cfg.Result.MirroredEndpoints, ts.cfg.Result.MirroredSlices = total, len(slices)
+	fmt.Fprintf(ts.cfg.LogWriter, "\nMirrored EndpointSlices: %d endpoints in %d slices\n\n", total, len(slices))
+	return nil
+}
+
+// churn deletes the backend Pods in rounds, and measures the latency from
+// each deletion to the removal from the EndpointSlices, and the kube-proxy
+// network programming latency during the churn.
+func (ts *tester) churn() error {
+	tracker := newSliceTracker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchDonec := make(chan struct{})
+	go func() {
+		defer close(watchDonec)
+		ts.watchSlices(ctx, tracker)
+	}()
+
+	before, _ := ts.scrapeNetworkProgramming()
+
+	timedOut := 0
+	for round := 1; round <= ts.cfg.ChurnRounds; round++ {
+		ts.cfg.Logger.Info("churning backends", zap.Int("round", round), zap.Int("rounds", ts.cfg.ChurnRounds), zap.Int("pods", ts.cfg.ChurnPods))
+		for i := 0; i < ts.cfg.Services; i++ {
+			if err := ts.deleteBackends(backendName(i), tracker); err != nil {
+				return err
+			}
+		}
+
+		deadline := time.Now().Add(ts.cfg.PropagationTimeout)
+		for tracker.pendings() > 0 && time.Now().Before(deadline) {
+			select {
+			case <-ts.cfg.Stopc:
+				return errors.New("stopped")
+			case <-time.After(time.Second):
+			}
+		}
+		if n := tracker.drain(); n > 0 {
+			ts.cfg.Logger.Warn("backend deletions not propagated", zap.Int("round", round), zap.Int("pending", n))
+			timedOut += n
+		}
+		if err := ts.waitForDeployments(); err != nil {
+			return err
+		}
+	}
+
+	after, nodes := ts.scrapeNetworkProgramming()
+	cancel()
+	<-watchDonec
+
+	ts.summarizePropagation(tracker.latencies, timedOut)
+	if nodes > 0 {
+		ts.cfg.Result.NetworkProgrammingNodes = nodes
+		ts.cfg.Result.NetworkProgrammingLatencyP50, _ = histogramQuantile(0.5, before, after)
+		ts.cfg.Result.NetworkProgrammingLatencyP99, ts.cfg.Result.NetworkProgrammingSamples = histogramQuantile(0.99, before, after)
+		ts.cfg.Result.NetworkProgrammingLatencyP50String = ts.cfg.Result.NetworkProgrammingLatencyP50.String()
+		ts.cfg.Result.NetworkProgrammingLatencyP99String = ts.cfg.Result.NetworkProgrammingLatencyP99.String()
+		fmt.Fprintf(ts.cfg.LogWriter, "\nkube-proxy network programming latency (%d nodes, %.0f samples): p50 %v, p99 %v (threshold %v)\n\n",
+			nodes,
+			ts.cfg.Result.NetworkProgrammingSamples,
+			ts.cfg.Result.NetworkProgrammingLatencyP50,
+			ts.cfg.Result.NetworkProgrammingLatencyP99,
+			ts.cfg.NetworkProgrammingLatencyP99Threshold,
+		)
+	} else {
+		ts.cfg.Logger.Warn("no kube-proxy metrics scraped; skipping network programming latency")
+	}
+
+	if timedOut > 0 {
+		return fmt.Errorf("%d backend deletions not propagated to EndpointSlices within %v", timedOut, ts.cfg.PropagationTimeout)
+	}
+	if p99 := ts.cfg.Result.PropagationLatency.P99; p99 > ts.cfg.PropagationLatencyP99Threshold {
+		return fmt.Errorf("EndpointSlice propagation p99 latency %v > %v", p99, ts.cfg.PropagationLatencyP99Threshold)
+	}
+	if th := ts.cfg.NetworkProgrammingLatencyP99Threshold; th > 0 && ts.cfg.Result.NetworkProgrammingLatencyP99 > th {
+		return fmt.Errorf("kube-proxy network programming p99 latency %v > %v", ts.cfg.Result.NetworkProgrammingLatencyP99, th)
+	}
+	return nil
+}
+
+// watchSlices lists and watches the backend EndpointSlices until the context is done,
+// re-listing when the watch is closed by the server.
+func (ts *tester) watchSlices(ctx context.Context, tracker *sliceTracker) {
+	opts := meta_v1.ListOptions{LabelSelector: discovery_v1.LabelManagedBy + "=" + managedByController}
+	for ctx.Err() == nil {
+		ls, err := ts.cfg.Client.KubernetesClient().DiscoveryV1().EndpointSlices(ts.cfg.Namespace).List(ctx, opts)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list EndpointSlices", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		now := time.Now()
+		for i := range ls.Items {
+			tracker.update(&ls.Items[i], false, now)
+		}
+
+		wopts := opts
+		wopts.ResourceVersion = ls.ResourceVersion
+		w, err := ts.cfg.Client.KubernetesClient().DiscoveryV1().EndpointSlices(ts.cfg.Namespace).Watch(ctx, wopts)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to watch EndpointSlices", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		for ev := range w.ResultChan() {
+			s, ok := ev.Object.(*discovery_v1.EndpointSlice)
+			if !ok {
+				continue
+			}
+			tracker.update(s, ev.Type == watch.Deleted, time.Now())
+		}
+		w.Stop()
+	}
+}
+
+func (ts *tester) deleteBackends(name string, tracker *sliceTracker) error {
+	ips, err := ts.readyPodIPs(name)
+	if err != nil {
+		return err
+	}
+	sorted := make([]string, 0, len(ips))
+	for ip := range ips {
+		sorted = append(sorted, ip)
+	}
+	sort.Strings(sorted)
+	if len(sorted) > ts.cfg.ChurnPods {
+		sorted = sorted[:ts.cfg.ChurnPods]
+	}
+
+	for _, ip := range sorted {
+		tracker.expect([]string{ip}, time.Now())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err = ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Delete(ctx, ips[ip], meta_v1.DeleteOptions{})
+		cancel()
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Pod %q (%v)", ips[ip], err)
+		}
+	}
+	ts.cfg.Logger.Info("deleted backend Pods", zap.String("deployment", name), zap.Int("pods", len(sorted)))
+	return nil
+}
+
+func (ts *tester) summarizePropagation(latencies latency.Durations, timedOut int) {
+	ts.cfg.Result.PropagationLatency = latency.Summary{}
+	ts.cfg.Result.PropagationLatency.TestID = time.Now().UTC().Format(time.RFC3339Nano)
+	ts.cfg.Result.PropagationLatency.SuccessTotal = float64(len(latencies))
+	ts.cfg.Result.PropagationLatency.FailureTotal = float64(timedOut)
+	if len(latencies) == 0 {
+		ts.cfg.Logger.Warn("no EndpointSlice propagation latency collected")
+		return
+	}
+	sort.Sort(latencies)
+	ts.cfg.Result.PropagationLatency.P50 = latencies.PickP50()
+	ts.cfg.Result.PropagationLatency.P90 = latencies.PickP90()
+	ts.cfg.Result.PropagationLatency.P99 = latencies.PickP99()
+	ts.cfg.Result.PropagationLatency.P999 = latencies.PickP999()
+	ts.cfg.Result.PropagationLatency.P9999 = latencies.PickP9999()
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\nEndpointSlice propagation latency:\n%s\n", ts.cfg.Result.PropagationLatency.Table())
+}
+
+// scrapeNetworkProgramming scrapes the kube-proxy metrics of all nodes
+// via the API server node proxy, and returns the network programming
+// latency histogram summed across the nodes. Nodes without the kube-proxy
+// metrics (e.g., Fargate) are skipped.
+func (ts *tester) scrapeNetworkProgramming() (buckets map[float64]float64, nodes int) {
+	buckets = make(map[float64]float64)
+	ns, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to list nodes", zap.Error(err))
+		return buckets, 0
+	}
+	for _, node := range ns {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		b, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			RESTClient().
+			Get().
+			AbsPath(fmt.Sprintf("/api/v1/nodes/%s:%d/proxy/metrics", node.Name, kubeProxyMetricsPort)).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Debug("failed to scrape kube-proxy metrics", zap.String("node", node.Name), zap.Error(err))
+			continue
+		}
+		bs, err := parseHistogramBuckets(b, networkProgrammingMetric)
+		if err != nil {
+			ts.cfg.Logger.Debug("failed to parse kube-proxy metrics", zap.String("node", node.Name), zap.Error(err))
+			continue
+		}
+		addBuckets(buckets, bs)
+		nodes++
+	}
+	return buckets, nodes
+}
@@ -0,0 +1,181 @@
+// k8s-tester-event-flood installs Kubernetes event flood tester.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	event_flood "github.com/aws/aws-k8s-tester/k8s-tester/event-flood"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-event-flood",
+	Short:      "Kubernetes event flood tester",
+	SuggestFor: []string{"event-flood"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", event_flood.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-event-flood failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	generatorImage      string
+	generators          int32
+	concurrency         int
+	floodDuration       time.Duration
+	probeInterval       time.Duration
+	latencyP99Threshold time.Duration
+	dedupEvents         int
+	aggregateEvents     int
+	ttlWait             time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&generatorImage, "generator-image", event_flood.DefaultGeneratorImage, "generator Pod image with 'curl'")
+	cmd.PersistentFlags().Int32Var(&generators, "generators", event_flood.DefaultGenerators, "number of generator Pods")
+	cmd.PersistentFlags().IntVar(&concurrency, "concurrency", event_flood.DefaultConcurrency, "number of concurrent event creators per generator Pod")
+	cmd.PersistentFlags().DurationVar(&floodDuration, "flood-duration", event_flood.DefaultFloodDuration, "duration of the flood")
+	cmd.PersistentFlags().DurationVar(&probeInterval, "probe-interval", event_flood.DefaultProbeInterval, "interval of the event API latency probes during the flood")
+	cmd.PersistentFlags().DurationVar(&latencyP99Threshold, "latency-p99-threshold", event_flood.DefaultLatencyP99Threshold, "maximum p99 latency of the event API requests during the flood")
+	cmd.PersistentFlags().IntVar(&dedupEvents, "dedup-events", event_flood.DefaultDedupEvents, "number of identical events expected to be deduplicated (at most 25)")
+	cmd.PersistentFlags().IntVar(&aggregateEvents, "aggregate-events", event_flood.DefaultAggregateEvents, "number of similar events expected to be aggregated (at most 25)")
+	cmd.PersistentFlags().DurationVar(&ttlWait, "ttl-wait", event_flood.DefaultTTLWait, "wait after the flood for the flood events to expire, longer than the API server event TTL (0 to skip)")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &event_flood.Config{
+		Prompt:              prompt,
+		Logger:              lg,
+		LogWriter:           logWriter,
+		MinimumNodes:        minimumNodes,
+		Namespace:           namespace,
+		Client:              cli,
+		GeneratorImage:      generatorImage,
+		Generators:          generators,
+		Concurrency:         concurrency,
+		FloodDuration:       floodDuration,
+		ProbeInterval:       probeInterval,
+		LatencyP99Threshold: latencyP99Threshold,
+		DedupEvents:         dedupEvents,
+		AggregateEvents:     aggregateEvents,
+		TTLWait:             ttlWait,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := event_flood.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-event-flood apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &event_flood.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := event_flood.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-event-flood delete' success\n")
+}
@@ -0,0 +1,132 @@
+package event_flood
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	typed_core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	correlateComponent = "event-flood-correlator"
+	dedupReason        = "Dedup"
+	aggregateReason    = "Aggregate"
+
+	// maxSimilarEvents is the number of the unique similar events (same source, object,
+	// type, and reason, different messages) at which the client-go event aggregator
+	// starts combining the messages into one event, so at most "maxSimilarEvents"
+	// events are stored for the similar events.
+	// ref. https://github.com/kubernetes/client-go/blob/master/tools/record/events_cache.go
+	maxSimilarEvents = 10
+	// combinedMessagePrefix is the message prefix of the aggregated events.
+	combinedMessagePrefix = "(combined from similar events): "
+	// maxSpamBurst is the burst of the client-go event spam filter per object,
+	// the events beyond are dropped.
+	maxSpamBurst = 25
+)
+
+// CorrelationResult is the result of the client-go event correlation
+// (deduplication and aggregation) against the API server.
+type CorrelationResult struct {
+	// DedupObjects is the number of the stored events of the identical events,
+	// expected 1.
+	DedupObjects int `json:"dedup_objects"`
+	// DedupCount is the count of the deduplicated event, expected "DedupEvents".
+	DedupCount int32 `json:"dedup_count"`
+	// AggregateObjects is the number of the stored events of the similar events,
+	// expected at most "maxSimilarEvents", including the combined event.
+	AggregateObjects int `json:"aggregate_objects"`
+	// AggregateCombinedCount is the count of the combined event.
+	AggregateCombinedCount int32 `json:"aggregate_combined_count"`
+}
+
+// check returns an error if the events were not correlated as expected.
+func (r CorrelationResult) check(dedupEvents int, aggregateEvents int) error {
+	if r.DedupObjects != 1 || r.DedupCount != int32(dedupEvents) {
+		return fmt.Errorf("%d identical events not deduplicated (%d objects, count %d)", dedupEvents, r.DedupObjects, r.DedupCount)
+	}
+	if aggregateEvents < maxSimilarEvents {
+		if r.AggregateObjects != aggregateEvents || r.AggregateCombinedCount != 0 {
+			return fmt.Errorf("%d similar events unexpectedly aggregated (%d objects, combined count %d)", aggregateEvents, r.AggregateObjects, r.AggregateCombinedCount)
+		}
+		return nil
+	}
+	if r.AggregateObjects != maxSimilarEvents || r.AggregateCombinedCount != int32(aggregateEvents-maxSimilarEvents+1) {
+		return fmt.Errorf("%d similar events not aggregated (%d objects, combined count %d)", aggregateEvents, r.AggregateObjects, r.AggregateCombinedCount)
+	}
+	return nil
+}
+
+// correlationResult summarizes the stored events of the correlated objects.
+func correlationResult(events []core_v1.Event) (r CorrelationResult) {
+	for _, ev := range events {
+		switch ev.Reason {
+		case dedupReason:
+			r.DedupObjects++
+			r.DedupCount += ev.Count
+		case aggregateReason:
+			r.AggregateObjects++
+			if strings.HasPrefix(ev.Message, combinedMessagePrefix) {
+				r.AggregateCombinedCount += ev.Count
+			}
+		}
+	}
+	return r
+}
+
+// correlate records the identical events and the similar events with the client-go
+// event recorder, and waits until the API server stores the correlated events.
+func (ts *tester) correlate() (CorrelationResult, error) {
+	ts.cfg.Logger.Info("recording events to correlate",
+		zap.Int("dedup-events", ts.cfg.DedupEvents),
+		zap.Int("aggregate-events", ts.cfg.AggregateEvents),
+	)
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typed_core_v1.EventSinkImpl{
+		Interface: ts.cfg.Client.KubernetesClient().CoreV1().Events(ts.cfg.Namespace),
+	})
+	defer broadcaster.Shutdown()
+	recorder := broadcaster.NewRecorder(scheme.Scheme, core_v1.EventSource{Component: correlateComponent})
+
+	// the spam filter and the aggregator are keyed by the object, so use one object each
+	dedupObj := &core_v1.ObjectReference{Kind: "ConfigMap", Namespace: ts.cfg.Namespace, Name: "dedup-target", APIVersion: "v1"}
+	aggregateObj := &core_v1.ObjectReference{Kind: "ConfigMap", Namespace: ts.cfg.Namespace, Name: "aggregate-target", APIVersion: "v1"}
+	for i := 0; i < ts.cfg.DedupEvents; i++ {
+		recorder.Event(dedupObj, core_v1.EventTypeNormal, dedupReason, "identical event")
+	}
+	for i := 0; i < ts.cfg.AggregateEvents; i++ {
+		recorder.Eventf(aggregateObj, core_v1.EventTypeNormal, aggregateReason, "similar event %d", i)
+	}
+
+	var r CorrelationResult
+	err := wait.PollImmediate(2*time.Second, 2*time.Minute, func() (bool, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		events, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Events(ts.cfg.Namespace).
+			List(ctx, meta_v1.ListOptions{FieldSelector: "source=" + correlateComponent})
+		cancel()
+		if err != nil {
+			if k8s_errors.IsTooManyRequests(err) || k8s_errors.IsServerTimeout(err) || k8s_errors.IsTimeout(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		r = correlationResult(events.Items)
+		return r.check(ts.cfg.DedupEvents, ts.cfg.AggregateEvents) == nil, nil
+	})
+	if err != nil {
+		return r, fmt.Errorf("events not correlated (%v, %+v)", err, r)
+	}
+	ts.cfg.Logger.Info("events correlated", zap.String("result", fmt.Sprintf("%+v", r)))
+	return r, nil
+}
@@ -0,0 +1,41 @@
+package event_flood
+
+import (
+	"fmt"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+)
+
+func TestCorrelationResult(t *testing.T) {
+	events := []core_v1.Event{
+		{Reason: dedupReason, Message: "identical event", Count: 20},
+		{Reason: "Other", Message: "other", Count: 3},
+	}
+	for i := 0; i < maxSimilarEvents-1; i++ {
+		events = append(events, core_v1.Event{Reason: aggregateReason, Message: fmt.Sprintf("similar event %d", i), Count: 1})
+	}
+	events = append(events, core_v1.Event{Reason: aggregateReason, Message: combinedMessagePrefix + "similar event 14", Count: 6})
+
+	r := correlationResult(events)
+	if r != (CorrelationResult{DedupObjects: 1, DedupCount: 20, AggregateObjects: 10, AggregateCombinedCount: 6}) {
+		t.Fatalf("unexpected result %+v", r)
+	}
+	if err := r.check(20, 15); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range [][2]int{{21, 15}, {20, 16}, {20, 5}} {
+		if err := r.check(c[0], c[1]); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+
+	r = CorrelationResult{DedupObjects: 1, DedupCount: 5, AggregateObjects: 5}
+	if err := r.check(5, 5); err != nil {
+		t.Fatal(err)
+	}
+	r.DedupObjects = 5
+	if err := r.check(5, 5); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,148 @@
+package event_flood
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	generatorLinePrefix = "event-flood-result"
+	doneLine            = "event-flood-done"
+
+	// floodReason is the reason of the flood events.
+	floodReason = "Flood"
+)
+
+// floodScript runs "concurrency" workers that create the events for the duration
+// with curl, using the generator Pod's ServiceAccount token. Each worker prints
+// the number of the created, the throttled (HTTP 429), and the failed requests.
+// The events are created for the generator Pod, to be cleaned up with the namespace.
+func floodScript(concurrency int, duration time.Duration) string {
+	return fmt.Sprintf(`SA=/var/run/secrets/kubernetes.io/serviceaccount
+TOKEN=$(cat $SA/token)
+API="https://kubernetes.default.svc/api/v1/namespaces/$NAMESPACE/events"
+end=$(( $(date +%%s) + %d ))
+flood() {
+  created=0; throttled=0; failed=0; n=0
+  while [ "$(date +%%s)" -lt "$end" ]; do
+    n=$((n + 1))
+    ts=$(date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ)
+    code=$(curl -s -o /dev/null -w '%%{http_code}' --max-time 10 --cacert $SA/ca.crt \
+      -H "Authorization: Bearer $TOKEN" -H 'Content-Type: application/json' -X POST "$API" \
+      -d "{\"metadata\":{\"generateName\":\"$POD_NAME-\"},\"involvedObject\":{\"kind\":\"Pod\",\"namespace\":\"$NAMESPACE\",\"name\":\"$POD_NAME\",\"uid\":\"$POD_UID\"},\"reason\":\"%s\",\"message\":\"worker $1 event $n\",\"type\":\"Normal\",\"source\":{\"component\":\"%s\"},\"firstTimestamp\":\"$ts\",\"lastTimestamp\":\"$ts\",\"count\":1}")
+    case "$code" in
+      201) created=$((created + 1)) ;;
+      429) throttled=$((throttled + 1)) ;;
+      *) failed=$((failed + 1)) ;;
+    esac
+  done
+  echo "%s $created $throttled $failed"
+}
+i=0
+while [ "$i" -lt %d ]; do
+  i=$((i + 1))
+  flood "$i" &
+done
+wait
+echo %s
+`, int(duration.Seconds()), floodReason, generatorName, generatorLinePrefix, concurrency, doneLine)
+}
+
+// GeneratorResult is the sum of the generator worker results.
+type GeneratorResult struct {
+	Created   int64 `json:"created"`
+	Throttled int64 `json:"throttled"`
+	Failed    int64 `json:"failed"`
+}
+
+func (r *GeneratorResult) add(o GeneratorResult) {
+	r.Created += o.Created
+	r.Throttled += o.Throttled
+	r.Failed += o.Failed
+}
+
+// parseGeneratorLogs sums the worker results of the generator Pod logs.
+func parseGeneratorLogs(logs string) (r GeneratorResult, err error) {
+	done := false
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if line == doneLine {
+			done = true
+			continue
+		}
+		if !strings.HasPrefix(line, generatorLinePrefix+" ") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, generatorLinePrefix+" "))
+		if len(fields) != 3 {
+			return GeneratorResult{}, fmt.Errorf("unexpected generator result %q", line)
+		}
+		var vs [3]int64
+		for i, f := range fields {
+			vs[i], err = strconv.ParseInt(f, 10, 64)
+			if err != nil {
+				return GeneratorResult{}, fmt.Errorf("failed to parse %q (%v)", line, err)
+			}
+		}
+		r.add(GeneratorResult{Created: vs[0], Throttled: vs[1], Failed: vs[2]})
+	}
+	if !done {
+		return GeneratorResult{}, fmt.Errorf("%q not found", doneLine)
+	}
+	return r, nil
+}
+
+// StorageStats is the API server storage stats of the events.
+type StorageStats struct {
+	// EventObjects is the number of the stored events in the cluster
+	// ("apiserver_storage_objects" or "etcd_object_counts"), -1 if not reported.
+	EventObjects int64 `json:"event_objects"`
+	// SizeBytes is the etcd database size ("apiserver_storage_size_bytes"
+	// or "etcd_db_total_size_in_bytes"), -1 if not reported.
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// parseStorageStats parses the API server "/metrics" for the storage stats.
+// The newer metrics are preferred over the deprecated ones.
+// The database size is summed over the storage clusters (e.g., the events etcd).
+func parseStorageStats(b []byte) (StorageStats, error) {
+	var parser expfmt.TextParser
+	mfs, err := parser.TextToMetricFamilies(bytes.NewReader(b))
+	if err != nil {
+		return StorageStats{}, err
+	}
+	st := StorageStats{EventObjects: -1, SizeBytes: -1}
+	for _, name := range []string{"apiserver_storage_objects", "etcd_object_counts"} {
+		mf, ok := mfs[name]
+		if !ok {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "resource" && l.GetValue() == "events" {
+					st.EventObjects = int64(m.GetGauge().GetValue())
+				}
+			}
+		}
+		if st.EventObjects >= 0 {
+			break
+		}
+	}
+	for _, name := range []string{"apiserver_storage_size_bytes", "etcd_db_total_size_in_bytes"} {
+		mf, ok := mfs[name]
+		if !ok || len(mf.GetMetric()) == 0 {
+			continue
+		}
+		st.SizeBytes = 0
+		for _, m := range mf.GetMetric() {
+			st.SizeBytes += int64(m.GetGauge().GetValue())
+		}
+		break
+	}
+	return st, nil
+}
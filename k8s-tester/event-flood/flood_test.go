@@ -0,0 +1,88 @@
+package event_flood
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFloodScript(t *testing.T) {
+	s := floodScript(3, 2*time.Minute)
+	for _, want := range []string{
+		"end=$(( $(date +%s) + 120 ))",
+		`\"reason\":\"Flood\"`,
+		`\"component\":\"event-flood\"`,
+		`echo "event-flood-result $created $throttled $failed"`,
+		`while [ "$i" -lt 3 ]; do`,
+		"echo event-flood-done",
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("%q not found in\n%s", want, s)
+		}
+	}
+}
+
+func TestParseGeneratorLogs(t *testing.T) {
+	logs := `
+event-flood-result 100 2 0
+event-flood-result 80 10 1
+event-flood-done
+`
+	r, err := parseGeneratorLogs(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != (GeneratorResult{Created: 180, Throttled: 12, Failed: 1}) {
+		t.Fatalf("unexpected result %+v", r)
+	}
+
+	for i, logs := range []string{
+		"event-flood-result 1 2 3\n",
+		"event-flood-result 1 2\nevent-flood-done\n",
+		"event-flood-result 1 x 3\nevent-flood-done\n",
+	} {
+		if _, err := parseGeneratorLogs(logs); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestParseStorageStats(t *testing.T) {
+	b := []byte(`# HELP apiserver_storage_objects [STABLE] Number of stored objects at the time of last check split by kind.
+# TYPE apiserver_storage_objects gauge
+apiserver_storage_objects{resource="configmaps"} 50
+apiserver_storage_objects{resource="events"} 1234
+# HELP apiserver_storage_size_bytes [ALPHA] Size of the storage database file physically allocated in bytes.
+# TYPE apiserver_storage_size_bytes gauge
+apiserver_storage_size_bytes{storage_cluster_id="etcd-0"} 1e+06
+apiserver_storage_size_bytes{storage_cluster_id="etcd-1"} 500000
+`)
+	st, err := parseStorageStats(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != (StorageStats{EventObjects: 1234, SizeBytes: 1500000}) {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	b = []byte(`# TYPE etcd_object_counts gauge
+etcd_object_counts{resource="events"} 77
+# TYPE etcd_db_total_size_in_bytes gauge
+etcd_db_total_size_in_bytes{endpoint="http://127.0.0.1:2379"} 4096
+`)
+	st, err = parseStorageStats(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != (StorageStats{EventObjects: 77, SizeBytes: 4096}) {
+		t.Fatalf("unexpected deprecated stats %+v", st)
+	}
+
+	st, err = parseStorageStats([]byte("# TYPE apiserver_request_total counter\napiserver_request_total{code=\"200\"} 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != (StorageStats{EventObjects: -1, SizeBytes: -1}) {
+		t.Fatalf("unexpected unreported stats %+v", st)
+	}
+}
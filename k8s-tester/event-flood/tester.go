@@ -0,0 +1,815 @@
+// Package event_flood generates a high rate of Events from the generator Pods,
+// and validates the API server event handling under the flood: the event API
+// latency during the flood, the client-go event deduplication and aggregation,
+// the event TTL expiry, and the impact on the etcd object count and size.
+// The etcd stats are read from the API server "/metrics", and are skipped if not reported.
+// ref. https://kubernetes.io/docs/reference/kubernetes-api/cluster-resources/event-v1/
+package event_flood
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/latency"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// GeneratorImage is the image of the generator Pods, with "curl".
+	GeneratorImage string `json:"generator_image"`
+	// Generators is the number of the generator Pods.
+	Generators int32 `json:"generators"`
+	// Concurrency is the number of the concurrent event creators per generator Pod.
+	Concurrency int `json:"concurrency"`
+	// FloodDuration is the duration of the flood.
+	FloodDuration       time.Duration `json:"flood_duration"`
+	FloodDurationString string        `json:"flood_duration_string" read-only:"true"`
+
+	// ProbeInterval is the interval of the event API latency probes during the flood.
+	// Each probe creates an event and lists the events.
+	ProbeInterval       time.Duration `json:"probe_interval"`
+	ProbeIntervalString string        `json:"probe_interval_string" read-only:"true"`
+	// LatencyP99Threshold is the maximum p99 latency of the event API requests during the flood.
+	LatencyP99Threshold       time.Duration `json:"latency_p99_threshold"`
+	LatencyP99ThresholdString string        `json:"latency_p99_threshold_string" read-only:"true"`
+
+	// DedupEvents is the number of the identical events recorded after the flood,
+	// expected to be deduplicated by the client-go event recorder into one event.
+	// At most 25, the burst of the client-go event spam filter.
+	DedupEvents int `json:"dedup_events"`
+	// AggregateEvents is the number of the similar events (different messages) recorded
+	// after the flood, expected to be aggregated by the client-go event recorder.
+	// At most 25, the burst of the client-go event spam filter.
+	AggregateEvents int `json:"aggregate_events"`
+
+	// TTLWait is the wait after the flood for the flood events to expire,
+	// longer than the API server "--event-ttl" (default 1 hour).
+	// Zero to skip the TTL check.
+	TTLWait       time.Duration `json:"ttl_wait"`
+	TTLWaitString string        `json:"ttl_wait_string" read-only:"true"`
+
+	// GeneratorResult is the sum of the generator results.
+	GeneratorResult GeneratorResult `json:"generator_result" read-only:"true"`
+	// EventsPerSecond is the created flood events per second.
+	EventsPerSecond float64 `json:"events_per_second" read-only:"true"`
+	// StoredEvents is the number of the flood events stored after the flood.
+	StoredEvents int `json:"stored_events" read-only:"true"`
+	// StorageStatsBefore is the storage stats before the flood.
+	StorageStatsBefore StorageStats `json:"storage_stats_before" read-only:"true"`
+	// StorageStatsAfter is the storage stats after the flood.
+	StorageStatsAfter StorageStats `json:"storage_stats_after" read-only:"true"`
+
+	// CreateLatencyP50 is the p50 latency of the event creates during the flood.
+	CreateLatencyP50       time.Duration `json:"create_latency_p50" read-only:"true"`
+	CreateLatencyP50String string        `json:"create_latency_p50_string" read-only:"true"`
+	// CreateLatencyP99 is the p99 latency of the event creates during the flood.
+	CreateLatencyP99       time.Duration `json:"create_latency_p99" read-only:"true"`
+	CreateLatencyP99String string        `json:"create_latency_p99_string" read-only:"true"`
+	// ListLatencyP50 is the p50 latency of the event lists during the flood.
+	ListLatencyP50       time.Duration `json:"list_latency_p50" read-only:"true"`
+	ListLatencyP50String string        `json:"list_latency_p50_string" read-only:"true"`
+	// ListLatencyP99 is the p99 latency of the event lists during the flood.
+	ListLatencyP99       time.Duration `json:"list_latency_p99" read-only:"true"`
+	ListLatencyP99String string        `json:"list_latency_p99_string" read-only:"true"`
+	// ProbeErrors is the number of the failed latency probe requests.
+	ProbeErrors int `json:"probe_errors" read-only:"true"`
+
+	// CorrelationResult is the result of the event deduplication and aggregation.
+	CorrelationResult CorrelationResult `json:"correlation_result" read-only:"true"`
+	// TTLRemainingEvents is the number of the flood events remaining after "TTLWait",
+	// -1 if the TTL check is skipped.
+	TTLRemainingEvents int `json:"ttl_remaining_events" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.GeneratorImage == "" {
+		cfg.GeneratorImage = DefaultGeneratorImage
+	}
+	if cfg.Generators == 0 {
+		cfg.Generators = DefaultGenerators
+	}
+	if cfg.Generators < 0 {
+		return fmt.Errorf("invalid Generators %d", cfg.Generators)
+	}
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+	if cfg.Concurrency < 0 {
+		return fmt.Errorf("invalid Concurrency %d", cfg.Concurrency)
+	}
+	if cfg.FloodDuration == time.Duration(0) {
+		cfg.FloodDuration = DefaultFloodDuration
+	}
+	if cfg.FloodDuration < time.Second {
+		return fmt.Errorf("FloodDuration %v too short", cfg.FloodDuration)
+	}
+	cfg.FloodDurationString = cfg.FloodDuration.String()
+	if cfg.ProbeInterval == time.Duration(0) {
+		cfg.ProbeInterval = DefaultProbeInterval
+	}
+	if cfg.ProbeInterval > cfg.FloodDuration {
+		return fmt.Errorf("ProbeInterval %v > FloodDuration %v", cfg.ProbeInterval, cfg.FloodDuration)
+	}
+	cfg.ProbeIntervalString = cfg.ProbeInterval.String()
+	if cfg.LatencyP99Threshold == time.Duration(0) {
+		cfg.LatencyP99Threshold = DefaultLatencyP99Threshold
+	}
+	cfg.LatencyP99ThresholdString = cfg.LatencyP99Threshold.String()
+	if cfg.DedupEvents == 0 {
+		cfg.DedupEvents = DefaultDedupEvents
+	}
+	if cfg.DedupEvents < 2 || cfg.DedupEvents > maxSpamBurst {
+		return fmt.Errorf("invalid DedupEvents %d (expected between 2 and %d)", cfg.DedupEvents, maxSpamBurst)
+	}
+	if cfg.AggregateEvents == 0 {
+		cfg.AggregateEvents = DefaultAggregateEvents
+	}
+	if cfg.AggregateEvents < 2 || cfg.AggregateEvents > maxSpamBurst {
+		return fmt.Errorf("invalid AggregateEvents %d (expected between 2 and %d)", cfg.AggregateEvents, maxSpamBurst)
+	}
+	if cfg.TTLWait < 0 {
+		return fmt.Errorf("invalid TTLWait %v", cfg.TTLWait)
+	}
+	cfg.TTLWaitString = cfg.TTLWait.String()
+	cfg.TTLRemainingEvents = -1
+	return nil
+}
+
+const (
+	DefaultMinimumNodes          int   = 1
+	DefaultGeneratorImage              = "curlimages/curl:8.4.0"
+	DefaultGenerators            int32 = 2
+	DefaultConcurrency           int   = 4
+	DefaultFloodDuration               = 3 * time.Minute
+	DefaultProbeInterval               = time.Second
+	DefaultLatencyP99Threshold         = 3 * time.Second
+	DefaultDedupEvents           int   = 20
+	DefaultAggregateEvents       int   = 15
+	DefaultTTLWait                     = time.Duration(0)
+	defaultProbeListLimit        int64 = 500
+	defaultStoredEventsListLimit int64 = 500
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:              false,
+		Prompt:              false,
+		MinimumNodes:        DefaultMinimumNodes,
+		Namespace:           pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		GeneratorImage:      DefaultGeneratorImage,
+		Generators:          DefaultGenerators,
+		Concurrency:         DefaultConcurrency,
+		FloodDuration:       DefaultFloodDuration,
+		ProbeInterval:       DefaultProbeInterval,
+		LatencyP99Threshold: DefaultLatencyP99Threshold,
+		DedupEvents:         DefaultDedupEvents,
+		AggregateEvents:     DefaultAggregateEvents,
+		TTLWait:             DefaultTTLWait,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	generatorName      = "event-flood"
+	serviceAccountName = "event-flood"
+	roleName           = "event-flood"
+	roleBindingName    = "event-flood"
+	probeReason        = "LatencyProbe"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+	if err := ts.createRBAC(); err != nil {
+		return err
+	}
+
+	ts.cfg.StorageStatsBefore = ts.getStorageStats()
+	if err := ts.createJob(); err != nil {
+		return err
+	}
+
+	donec := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ts.probeLatency(donec)
+	}()
+	floodStart := time.Now()
+	pods, err := ts.waitForJob()
+	floodTook := time.Since(floodStart)
+	close(donec)
+	wg.Wait()
+	if err != nil {
+		return fmt.Errorf("generator Job failed (%v)", err)
+	}
+	if err := ts.checkGenerators(pods, floodTook); err != nil {
+		return err
+	}
+
+	ts.cfg.StorageStatsAfter = ts.getStorageStats()
+	stored, err := ts.countFloodEvents()
+	if err != nil {
+		return err
+	}
+	ts.cfg.StoredEvents = stored
+
+	var errs []string
+	corr, err := ts.correlate()
+	ts.cfg.CorrelationResult = corr
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if ts.cfg.TTLWait > 0 {
+		if err := ts.waitForTTL(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\n%s\n", ts.cfg.report())
+
+	if ts.cfg.GeneratorResult.Created == 0 {
+		errs = append(errs, "no flood event created")
+	}
+	if ts.cfg.CreateLatencyP99 > ts.cfg.LatencyP99Threshold {
+		errs = append(errs, fmt.Sprintf("event create p99 latency %v > threshold %v", ts.cfg.CreateLatencyP99, ts.cfg.LatencyP99Threshold))
+	}
+	if ts.cfg.ListLatencyP99 > ts.cfg.LatencyP99Threshold {
+		errs = append(errs, fmt.Sprintf("event list p99 latency %v > threshold %v", ts.cfg.ListLatencyP99, ts.cfg.LatencyP99Threshold))
+	}
+	if ts.cfg.TTLRemainingEvents > 0 {
+		errs = append(errs, fmt.Sprintf("%d flood events not expired after %v", ts.cfg.TTLRemainingEvents, ts.cfg.TTLWait))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteJob(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		generatorName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete generator Job (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// createRBAC creates the ServiceAccount of the generator Pods, allowed to create the events.
+func (ts *tester) createRBAC() error {
+	ts.cfg.Logger.Info("creating ServiceAccount and RBAC Role")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ServiceAccounts(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ServiceAccount{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serviceAccountName,
+					Namespace: ts.cfg.Namespace,
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create ServiceAccount (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		Roles(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&rbac_v1.Role{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "Role",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      roleName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Rules: []rbac_v1.PolicyRule{
+					{
+						APIGroups: []string{""},
+						Resources: []string{"events"},
+						Verbs:     []string{"create"},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create RBAC Role (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		RoleBindings(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&rbac_v1.RoleBinding{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "RoleBinding",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      roleBindingName,
+					Namespace: ts.cfg.Namespace,
+				},
+				RoleRef: rbac_v1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "Role",
+					Name:     roleName,
+				},
+				Subjects: []rbac_v1.Subject{
+					{
+						Kind:      "ServiceAccount",
+						Name:      serviceAccountName,
+						Namespace: ts.cfg.Namespace,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create RBAC RoleBinding (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created ServiceAccount and RBAC Role")
+	return nil
+}
+
+// getStorageStats returns the current storage stats,
+// or the unreported stats if the API server "/metrics" is not available.
+func (ts *tester) getStorageStats() StorageStats {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	b, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		RESTClient().
+		Get().
+		AbsPath("/metrics").
+		DoRaw(ctx)
+	cancel()
+	if err == nil {
+		var st StorageStats
+		st, err = parseStorageStats(b)
+		if err == nil {
+			ts.cfg.Logger.Info("fetched storage stats",
+				zap.Int64("event-objects", st.EventObjects),
+				zap.Int64("size-bytes", st.SizeBytes),
+			)
+			return st
+		}
+	}
+	ts.cfg.Logger.Warn("failed to fetch storage stats; skipping", zap.Error(err))
+	return StorageStats{EventObjects: -1, SizeBytes: -1}
+}
+
+func (ts *tester) createJob() error {
+	ts.cfg.Logger.Info("creating generator Job",
+		zap.Int32("generators", ts.cfg.Generators),
+		zap.Int("concurrency", ts.cfg.Concurrency),
+		zap.String("flood-duration", ts.cfg.FloodDurationString),
+	)
+	generators := ts.cfg.Generators
+	backoffLimit := int32(0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      generatorName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					Completions:  &generators,
+					Parallelism:  &generators,
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						Spec: core_v1.PodSpec{
+							RestartPolicy:      core_v1.RestartPolicyNever,
+							ServiceAccountName: serviceAccountName,
+							Containers: []core_v1.Container{
+								{
+									Name:            generatorName,
+									Image:           ts.cfg.GeneratorImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c"},
+									Args:            []string{floodScript(ts.cfg.Concurrency, ts.cfg.FloodDuration)},
+									Env: []core_v1.EnvVar{
+										{Name: "NAMESPACE", Value: ts.cfg.Namespace},
+										{
+											Name: "POD_NAME",
+											ValueFrom: &core_v1.EnvVarSource{
+												FieldRef: &core_v1.ObjectFieldSelector{FieldPath: "metadata.name"},
+											},
+										},
+										{
+											Name: "POD_UID",
+											ValueFrom: &core_v1.EnvVarSource{
+												FieldRef: &core_v1.ObjectFieldSelector{FieldPath: "metadata.uid"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("generator Job already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create generator Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created generator Job")
+	return nil
+}
+
+func (ts *tester) waitForJob() ([]core_v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.FloodDuration+10*time.Minute)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.FloodDuration,
+		10*time.Second,
+		ts.cfg.Namespace,
+		generatorName,
+		int(ts.cfg.Generators),
+	)
+	cancel()
+	return pods, err
+}
+
+// probeLatency creates an event and lists the events every "ProbeInterval"
+// until "donec" is closed, and summarizes the request latencies.
+func (ts *tester) probeLatency(donec chan struct{}) {
+	ts.cfg.Logger.Info("probing event API latency", zap.String("interval", ts.cfg.ProbeIntervalString))
+	ref := core_v1.ObjectReference{Kind: "ConfigMap", Namespace: ts.cfg.Namespace, Name: "latency-probe", APIVersion: "v1"}
+	var creates, lists latency.Durations
+	errs := 0
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			ts.cfg.Logger.Warn("event API latency probe aborted")
+			return
+		case <-donec:
+			ts.cfg.CreateLatencyP50, ts.cfg.CreateLatencyP99 = creates.PickP50(), creates.PickP99()
+			ts.cfg.ListLatencyP50, ts.cfg.ListLatencyP99 = lists.PickP50(), lists.PickP99()
+			ts.cfg.CreateLatencyP50String, ts.cfg.CreateLatencyP99String = ts.cfg.CreateLatencyP50.String(), ts.cfg.CreateLatencyP99.String()
+			ts.cfg.ListLatencyP50String, ts.cfg.ListLatencyP99String = ts.cfg.ListLatencyP50.String(), ts.cfg.ListLatencyP99.String()
+			ts.cfg.ProbeErrors = errs
+			ts.cfg.Logger.Info("probed event API latency",
+				zap.Int("creates", len(creates)),
+				zap.Int("lists", len(lists)),
+				zap.Int("errors", errs),
+			)
+			return
+		case <-time.After(ts.cfg.ProbeInterval):
+		}
+
+		now := meta_v1.Now()
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		_, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Events(ts.cfg.Namespace).
+			Create(
+				ctx,
+				&core_v1.Event{
+					ObjectMeta: meta_v1.ObjectMeta{
+						GenerateName: ref.Name + "-",
+						Namespace:    ts.cfg.Namespace,
+					},
+					InvolvedObject: ref,
+					Reason:         probeReason,
+					Message:        "event API latency probe",
+					Type:           core_v1.EventTypeNormal,
+					Source:         core_v1.EventSource{Component: pkgName},
+					FirstTimestamp: now,
+					LastTimestamp:  now,
+					Count:          1,
+				},
+				meta_v1.CreateOptions{},
+			)
+		cancel()
+		if err != nil {
+			errs++
+			ts.cfg.Logger.Warn("failed to create probe event", zap.Error(err))
+		} else {
+			creates = append(creates, time.Since(start))
+		}
+
+		start = time.Now()
+		ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+		_, err = ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Events(ts.cfg.Namespace).
+			List(ctx, meta_v1.ListOptions{Limit: defaultProbeListLimit})
+		cancel()
+		if err != nil {
+			errs++
+			ts.cfg.Logger.Warn("failed to list events", zap.Error(err))
+		} else {
+			lists = append(lists, time.Since(start))
+		}
+	}
+}
+
+// checkGenerators sums the generator results from the generator Pod logs.
+func (ts *tester) checkGenerators(pods []core_v1.Pod, took time.Duration) error {
+	var r GeneratorResult
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		logs, err := ts.readPodLogs(pod.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read generator Pod %q logs (%v)", pod.Name, err)
+		}
+		pr, err := parseGeneratorLogs(logs)
+		if err != nil {
+			return fmt.Errorf("generator Pod %q (%v)", pod.Name, err)
+		}
+		r.add(pr)
+	}
+	ts.cfg.GeneratorResult = r
+	// the generators run for "FloodDuration" once started, the Job may take longer
+	dur := ts.cfg.FloodDuration
+	if took < dur {
+		dur = took
+	}
+	ts.cfg.EventsPerSecond = float64(r.Created) / dur.Seconds()
+	ts.cfg.Logger.Info("flood completed",
+		zap.Int64("created", r.Created),
+		zap.Int64("throttled", r.Throttled),
+		zap.Int64("failed", r.Failed),
+		zap.Float64("events-per-second", ts.cfg.EventsPerSecond),
+	)
+	return nil
+}
+
+func (ts *tester) readPodLogs(podName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rc, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		GetLogs(podName, &core_v1.PodLogOptions{Container: generatorName}).
+		Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// countFloodEvents returns the number of the stored flood events, listed in pages.
+func (ts *tester) countFloodEvents() (int, error) {
+	n, cont := 0, ""
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		events, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Events(ts.cfg.Namespace).
+			List(ctx, meta_v1.ListOptions{
+				FieldSelector: "reason=" + floodReason,
+				Limit:         defaultStoredEventsListLimit,
+				Continue:      cont,
+			})
+		cancel()
+		if err != nil {
+			return 0, fmt.Errorf("failed to list flood events (%v)", err)
+		}
+		n += len(events.Items)
+		cont = events.Continue
+		if cont == "" {
+			break
+		}
+	}
+	ts.cfg.Logger.Info("counted flood events", zap.Int("stored", n))
+	return n, nil
+}
+
+// waitForTTL waits "TTLWait" after the flood, and counts the flood events not expired.
+func (ts *tester) waitForTTL() error {
+	ts.cfg.Logger.Info("waiting for flood events to expire", zap.String("ttl-wait", ts.cfg.TTLWaitString))
+	select {
+	case <-ts.cfg.Stopc:
+		return errors.New("event TTL wait aborted")
+	case <-time.After(ts.cfg.TTLWait):
+	}
+	n, err := ts.countFloodEvents()
+	if err != nil {
+		return err
+	}
+	ts.cfg.TTLRemainingEvents = n
+	return nil
+}
+
+// report returns the flood summary table.
+func (cfg *Config) report() string {
+	storage := func(v int64) string {
+		if v < 0 {
+			return "n/a"
+		}
+		return fmt.Sprintf("%d", v)
+	}
+	ttl := "skipped"
+	if cfg.TTLRemainingEvents >= 0 {
+		ttl = fmt.Sprintf("%d remaining after %v", cfg.TTLRemainingEvents, cfg.TTLWait)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"Metric", "Value"})
+	tb.AppendBulk([][]string{
+		{"Created events", fmt.Sprintf("%d (%.1f/s)", cfg.GeneratorResult.Created, cfg.EventsPerSecond)},
+		{"Throttled requests", fmt.Sprintf("%d", cfg.GeneratorResult.Throttled)},
+		{"Failed requests", fmt.Sprintf("%d", cfg.GeneratorResult.Failed)},
+		{"Stored flood events", fmt.Sprintf("%d", cfg.StoredEvents)},
+		{"Create latency p50/p99", fmt.Sprintf("%v / %v", cfg.CreateLatencyP50, cfg.CreateLatencyP99)},
+		{"List latency p50/p99", fmt.Sprintf("%v / %v", cfg.ListLatencyP50, cfg.ListLatencyP99)},
+		{"Probe errors", fmt.Sprintf("%d", cfg.ProbeErrors)},
+		{"Stored events (etcd) before/after", storage(cfg.StorageStatsBefore.EventObjects) + " / " + storage(cfg.StorageStatsAfter.EventObjects)},
+		{"Storage size bytes before/after", storage(cfg.StorageStatsBefore.SizeBytes) + " / " + storage(cfg.StorageStatsAfter.SizeBytes)},
+		{"Deduplicated events", fmt.Sprintf("%d into %d object(s), count %d", cfg.DedupEvents, cfg.CorrelationResult.DedupObjects, cfg.CorrelationResult.DedupCount)},
+		{"Aggregated events", fmt.Sprintf("%d into %d object(s), combined count %d", cfg.AggregateEvents, cfg.CorrelationResult.AggregateObjects, cfg.CorrelationResult.AggregateCombinedCount)},
+		{"TTL", ttl},
+	})
+	tb.Render()
+	return buf.String()
+}
@@ -0,0 +1,49 @@
+package event_flood
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateAndSetDefaults(t *testing.T) {
+	cfg := &Config{Namespace: "test-namespace"}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Generators != DefaultGenerators || cfg.DedupEvents != DefaultDedupEvents || cfg.FloodDurationString != DefaultFloodDuration.String() || cfg.TTLRemainingEvents != -1 {
+		t.Fatalf("unexpected defaults %+v", cfg)
+	}
+
+	for i, cfg := range []*Config{
+		{},
+		{Namespace: "n", Generators: -1},
+		{Namespace: "n", FloodDuration: time.Millisecond},
+		{Namespace: "n", FloodDuration: time.Minute, ProbeInterval: 2 * time.Minute},
+		{Namespace: "n", DedupEvents: 26},
+		{Namespace: "n", AggregateEvents: 1},
+		{Namespace: "n", TTLWait: -time.Second},
+	} {
+		if err := cfg.ValidateAndSetDefaults(); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	cfg := &Config{Namespace: "n"}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.GeneratorResult = GeneratorResult{Created: 900, Throttled: 3}
+	cfg.EventsPerSecond = 5
+	cfg.StorageStatsBefore = StorageStats{EventObjects: -1, SizeBytes: -1}
+	cfg.StorageStatsAfter = StorageStats{EventObjects: -1, SizeBytes: -1}
+	if rp := cfg.report(); !strings.Contains(rp, "900 (5.0/s)") || !strings.Contains(rp, "n/a / n/a") || !strings.Contains(rp, "skipped") {
+		t.Fatalf("unexpected report\n%s", rp)
+	}
+	cfg.TTLWait, cfg.TTLRemainingEvents = time.Hour, 0
+	if rp := cfg.report(); !strings.Contains(rp, "0 remaining after 1h0m0s") {
+		t.Fatalf("unexpected report\n%s", rp)
+	}
+}
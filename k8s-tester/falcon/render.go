@@ -0,0 +1,29 @@
+package falcon
+
+import (
+	"fmt"
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"sigs.k8s.io/yaml"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the FalconContainer, after the URL of the operator manifests
+// applied with kubectl, since they are not vendored.
+func (ts *tester) Render(w io.Writer) error {
+	cfg := *ts.cfg
+	// the credentials are not rendered
+	cfg.FalconClientId = fake.Redacted
+	cfg.FalconClientSecret = fake.Redacted
+	rt := &tester{cfg: &cfg}
+
+	b, err := yaml.Marshal(rt.falconContainer())
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "# kubectl apply --filename=%s\n---\n%s", operatorSpecUri, b)
+	return err
+}
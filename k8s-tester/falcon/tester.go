@@ -144,8 +144,9 @@ func (ts *tester) waitForInjectorRunning(ctx context.Context) error {
 	}
 }
 
-func (ts *tester) deployFalconContainer(ctx context.Context) error {
-	container := falconv1alpha1.FalconContainer{
+// falconContainer returns the FalconContainer that the operator deploys the injector with.
+func (ts *tester) falconContainer() falconv1alpha1.FalconContainer {
+	return falconv1alpha1.FalconContainer{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "FalconContainer",
 			APIVersion: falconv1alpha1.GroupVersion.String(),
@@ -167,6 +168,10 @@ func (ts *tester) deployFalconContainer(ctx context.Context) error {
 			},
 		},
 	}
+}
+
+func (ts *tester) deployFalconContainer(ctx context.Context) error {
+	container := ts.falconContainer()
 
 	var out bytes.Buffer
 	enc := json.NewEncoder(&out)
@@ -0,0 +1,207 @@
+// k8s-tester-fargate validates the Fargate profile scheduling.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/fargate"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-fargate",
+	Short:      "Kubernetes Fargate profile tester",
+	SuggestFor: []string{"fargate"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	partition          string
+	region             string
+	clusterName        string
+	profileName        string
+	roleName           string
+	loggingEnable      bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", fargate.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", fargate.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for EKS cluster")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster-name", "", "EKS cluster name")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile-name", "", "Fargate profile name (default to namespace)")
+	rootCmd.PersistentFlags().StringVar(&roleName, "role-name", "", "Fargate Pod execution role name to create (default to namespace)")
+	rootCmd.PersistentFlags().BoolVar(&loggingEnable, "logging-enable", false, "'true' to configure the Fargate log router to CloudWatch Logs")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-fargate failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	profileSelectorLabels map[string]string
+	subnetIDs             []string
+	roleARN               string
+	image                 string
+	timeout               time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringToStringVar(&profileSelectorLabels, "profile-selector-labels", map[string]string{fargate.DefaultProfileSelectorLabelKey: fargate.DefaultProfileSelectorLabelValue}, "Pod labels of the Fargate profile selector")
+	cmd.PersistentFlags().StringSliceVar(&subnetIDs, "subnet-ids", nil, "private subnets of the Fargate profile (default to the cluster subnets)")
+	cmd.PersistentFlags().StringVar(&roleARN, "role-arn", "", "existing Fargate Pod execution role ARN, empty to create one")
+	cmd.PersistentFlags().StringVar(&image, "image", fargate.DefaultImage, "test Pod image")
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", fargate.DefaultTimeout, "timeout to wait for the test Pod to complete")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &fargate.Config{
+		Prompt:                prompt,
+		Logger:                lg,
+		LogWriter:             logWriter,
+		MinimumNodes:          minimumNodes,
+		Namespace:             namespace,
+		Client:                cli,
+		Partition:             partition,
+		Region:                region,
+		ProfileName:           profileName,
+		ProfileSelectorLabels: profileSelectorLabels,
+		SubnetIDs:             subnetIDs,
+		RoleARN:               roleARN,
+		RoleName:              roleName,
+		LoggingEnable:         loggingEnable,
+		Image:                 image,
+		Timeout:               timeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := fargate.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-fargate apply' success\n")
+}
+
+var (
+	deleteRole          bool
+	deleteLoggingConfig bool
+)
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&deleteRole, "delete-role", true, "'true' to delete the Pod execution role, only if created by 'apply'")
+	cmd.PersistentFlags().BoolVar(&deleteLoggingConfig, "delete-logging-config", false, "'true' to delete the 'aws-logging' ConfigMap, only if created by 'apply'")
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &fargate.Config{
+		Prompt:               prompt,
+		Logger:               lg,
+		LogWriter:            logWriter,
+		Namespace:            namespace,
+		Client:               cli,
+		Partition:            partition,
+		Region:               region,
+		ClusterName:          clusterName,
+		ProfileName:          profileName,
+		RoleName:             roleName,
+		RoleCreated:          deleteRole,
+		LoggingEnable:        loggingEnable,
+		LoggingConfigCreated: deleteLoggingConfig,
+	}
+	if cfg.ProfileName == "" {
+		cfg.ProfileName = namespace
+	}
+	if cfg.RoleName == "" {
+		cfg.RoleName = namespace + "-role"
+	}
+
+	ts := fargate.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-fargate delete' success\n")
+}
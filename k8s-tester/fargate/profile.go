@@ -0,0 +1,299 @@
+package fargate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"go.uber.org/zap"
+)
+
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+type policyStatement struct {
+	Effect    string            `json:"Effect"`
+	Principal map[string]string `json:"Principal,omitempty"`
+	Action    []string          `json:"Action"`
+	Resource  []string          `json:"Resource,omitempty"`
+}
+
+// trustPolicy returns the Pod execution role trust policy.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/pod-execution-role.html
+func trustPolicy() (string, error) {
+	doc := policyDocument{
+		Version: "2012-10-17",
+		Statement: []policyStatement{
+			{
+				Effect:    "Allow",
+				Principal: map[string]string{"Service": "eks-fargate-pods.amazonaws.com"},
+				Action:    []string{"sts:AssumeRole"},
+			},
+		},
+	}
+	b, err := json.Marshal(doc)
+	return string(b), err
+}
+
+// loggingPolicy returns the role policy for the Fargate log router.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/fargate-logging.html
+func loggingPolicy() (string, error) {
+	doc := policyDocument{
+		Version: "2012-10-17",
+		Statement: []policyStatement{
+			{
+				Effect: "Allow",
+				Action: []string{
+					"logs:CreateLogStream",
+					"logs:CreateLogGroup",
+					"logs:DescribeLogStreams",
+					"logs:PutLogEvents",
+				},
+				Resource: []string{"*"},
+			},
+		},
+	}
+	b, err := json.Marshal(doc)
+	return string(b), err
+}
+
+const rolePolicyName = "fargate-logging"
+
+func (ts *tester) podExecutionPolicyARN() string {
+	return fmt.Sprintf("arn:%s:iam::aws:policy/AmazonEKSFargatePodExecutionRolePolicy", ts.cfg.Partition)
+}
+
+func (ts *tester) createRole() error {
+	if ts.cfg.RoleARN != "" && !ts.cfg.RoleCreated {
+		ts.cfg.Logger.Info("using existing Fargate Pod execution role", zap.String("role-arn", ts.cfg.RoleARN))
+		return nil
+	}
+	trust, err := trustPolicy()
+	if err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("creating Fargate Pod execution role", zap.String("role-name", ts.cfg.RoleName))
+	out, err := ts.cfg.IAMAPI.CreateRole(&iam.CreateRoleInput{
+		RoleName:                 aws.String(ts.cfg.RoleName),
+		AssumeRolePolicyDocument: aws.String(trust),
+		Description:              aws.String("aws-k8s-tester " + pkgName + " " + ts.cfg.Namespace),
+		Tags: []*iam.Tag{
+			{Key: aws.String("Kind"), Value: aws.String("aws-k8s-tester")},
+			{Key: aws.String("Namespace"), Value: aws.String(ts.cfg.Namespace)},
+		},
+	})
+	if err != nil {
+		aerr, ok := err.(awserr.Error)
+		if !ok || aerr.Code() != iam.ErrCodeEntityAlreadyExistsException {
+			return fmt.Errorf("failed to create Fargate Pod execution role (%v)", err)
+		}
+		gout, gerr := ts.cfg.IAMAPI.GetRole(&iam.GetRoleInput{RoleName: aws.String(ts.cfg.RoleName)})
+		if gerr != nil {
+			return fmt.Errorf("failed to get Fargate Pod execution role (%v)", gerr)
+		}
+		ts.cfg.RoleARN = aws.StringValue(gout.Role.Arn)
+		ts.cfg.Logger.Info("Fargate Pod execution role already exists", zap.String("role-arn", ts.cfg.RoleARN))
+	} else {
+		ts.cfg.RoleARN = aws.StringValue(out.Role.Arn)
+		ts.cfg.Logger.Info("created Fargate Pod execution role", zap.String("role-arn", ts.cfg.RoleARN))
+	}
+	ts.cfg.RoleCreated = true
+
+	_, err = ts.cfg.IAMAPI.AttachRolePolicy(&iam.AttachRolePolicyInput{
+		RoleName:  aws.String(ts.cfg.RoleName),
+		PolicyArn: aws.String(ts.podExecutionPolicyARN()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach Fargate Pod execution role policy (%v)", err)
+	}
+
+	if !ts.cfg.LoggingEnable {
+		return nil
+	}
+	policy, err := loggingPolicy()
+	if err != nil {
+		return err
+	}
+	_, err = ts.cfg.IAMAPI.PutRolePolicy(&iam.PutRolePolicyInput{
+		RoleName:       aws.String(ts.cfg.RoleName),
+		PolicyName:     aws.String(rolePolicyName),
+		PolicyDocument: aws.String(policy),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put Fargate logging role policy (%v)", err)
+	}
+	ts.cfg.Logger.Info("put Fargate logging role policy")
+	return nil
+}
+
+func (ts *tester) deleteRole() error {
+	if !ts.cfg.RoleCreated || ts.cfg.RoleName == "" {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting Fargate Pod execution role", zap.String("role-name", ts.cfg.RoleName))
+	_, err := ts.cfg.IAMAPI.DetachRolePolicy(&iam.DetachRolePolicyInput{
+		RoleName:  aws.String(ts.cfg.RoleName),
+		PolicyArn: aws.String(ts.podExecutionPolicyARN()),
+	})
+	if err != nil && !isNoSuchEntity(err) {
+		return fmt.Errorf("failed to detach Fargate Pod execution role policy (%v)", err)
+	}
+	if ts.cfg.LoggingEnable {
+		_, err = ts.cfg.IAMAPI.DeleteRolePolicy(&iam.DeleteRolePolicyInput{
+			RoleName:   aws.String(ts.cfg.RoleName),
+			PolicyName: aws.String(rolePolicyName),
+		})
+		if err != nil && !isNoSuchEntity(err) {
+			return fmt.Errorf("failed to delete Fargate logging role policy (%v)", err)
+		}
+	}
+	_, err = ts.cfg.IAMAPI.DeleteRole(&iam.DeleteRoleInput{
+		RoleName: aws.String(ts.cfg.RoleName),
+	})
+	if err != nil && !isNoSuchEntity(err) {
+		return fmt.Errorf("failed to delete Fargate Pod execution role (%v)", err)
+	}
+	ts.cfg.RoleARN, ts.cfg.RoleCreated = "", false
+	ts.cfg.Logger.Info("deleted Fargate Pod execution role")
+	return nil
+}
+
+func isNoSuchEntity(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == iam.ErrCodeNoSuchEntityException
+}
+
+func (ts *tester) createProfile() error {
+	if len(ts.cfg.SubnetIDs) == 0 {
+		out, err := ts.cfg.EKSAPI.DescribeCluster(&eks.DescribeClusterInput{
+			Name: aws.String(ts.cfg.ClusterName),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe cluster %q (%v)", ts.cfg.ClusterName, err)
+		}
+		if out.Cluster.ResourcesVpcConfig == nil || len(out.Cluster.ResourcesVpcConfig.SubnetIds) == 0 {
+			return fmt.Errorf("cluster %q has no subnet", ts.cfg.ClusterName)
+		}
+		ts.cfg.SubnetIDs = aws.StringValueSlice(out.Cluster.ResourcesVpcConfig.SubnetIds)
+	}
+
+	ts.cfg.Logger.Info("creating Fargate profile",
+		zap.String("profile-name", ts.cfg.ProfileName),
+		zap.Strings("subnet-ids", ts.cfg.SubnetIDs),
+		zap.Any("selector-labels", ts.cfg.ProfileSelectorLabels),
+	)
+	// retry on the IAM role propagation delay
+	var err error
+	for i := 0; i < 10; i++ {
+		_, err = ts.cfg.EKSAPI.CreateFargateProfile(&eks.CreateFargateProfileInput{
+			ClusterName:         aws.String(ts.cfg.ClusterName),
+			FargateProfileName:  aws.String(ts.cfg.ProfileName),
+			PodExecutionRoleArn: aws.String(ts.cfg.RoleARN),
+			Subnets:             aws.StringSlice(ts.cfg.SubnetIDs),
+			Selectors: []*eks.FargateProfileSelector{
+				{
+					Namespace: aws.String(ts.cfg.Namespace),
+					Labels:    aws.StringMap(ts.cfg.ProfileSelectorLabels),
+				},
+			},
+			Tags: aws.StringMap(map[string]string{"Kind": "aws-k8s-tester"}),
+		})
+		if err == nil {
+			break
+		}
+		aerr, ok := err.(awserr.Error)
+		if ok && aerr.Code() == eks.ErrCodeResourceInUseException {
+			ts.cfg.Logger.Info("Fargate profile already exists")
+			err = nil
+			break
+		}
+		if !ok || aerr.Code() != eks.ErrCodeInvalidParameterException {
+			break
+		}
+		ts.cfg.Logger.Warn("failed to create Fargate profile; retrying", zap.Error(err))
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Fargate profile creation aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create Fargate profile (%v)", err)
+	}
+
+	if err = ts.waitProfile(eks.FargateProfileStatusActive); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("created Fargate profile", zap.String("profile-name", ts.cfg.ProfileName))
+	return nil
+}
+
+func (ts *tester) deleteProfile() error {
+	ts.cfg.Logger.Info("deleting Fargate profile", zap.String("profile-name", ts.cfg.ProfileName))
+	_, err := ts.cfg.EKSAPI.DeleteFargateProfile(&eks.DeleteFargateProfileInput{
+		ClusterName:        aws.String(ts.cfg.ClusterName),
+		FargateProfileName: aws.String(ts.cfg.ProfileName),
+	})
+	if err != nil {
+		if isProfileNotFound(err) {
+			ts.cfg.Logger.Info("Fargate profile already deleted")
+			return nil
+		}
+		return fmt.Errorf("failed to delete Fargate profile (%v)", err)
+	}
+	if err = ts.waitProfile(""); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("deleted Fargate profile")
+	return nil
+}
+
+func isProfileNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == eks.ErrCodeResourceNotFoundException
+}
+
+// waitProfile waits until the Fargate profile reaches the status,
+// or is deleted if the status is empty.
+func (ts *tester) waitProfile(status string) error {
+	// the profile deletion may take longer, after evicting the Fargate Pods
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+	defer cancel()
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Fargate profile wait aborted")
+		case <-ctx.Done():
+			return fmt.Errorf("Fargate profile %q wait timed out (%v)", ts.cfg.ProfileName, ctx.Err())
+		case <-time.After(10 * time.Second):
+		}
+
+		out, err := ts.cfg.EKSAPI.DescribeFargateProfile(&eks.DescribeFargateProfileInput{
+			ClusterName:        aws.String(ts.cfg.ClusterName),
+			FargateProfileName: aws.String(ts.cfg.ProfileName),
+		})
+		if err != nil {
+			if status == "" && isProfileNotFound(err) {
+				return nil
+			}
+			ts.cfg.Logger.Warn("failed to describe Fargate profile", zap.Error(err))
+			continue
+		}
+		current := aws.StringValue(out.FargateProfile.Status)
+		ts.cfg.Logger.Info("polled Fargate profile", zap.String("status", current), zap.String("desired", status))
+		switch current {
+		case status:
+			return nil
+		case eks.FargateProfileStatusCreateFailed, eks.FargateProfileStatusDeleteFailed:
+			return fmt.Errorf("Fargate profile %q status %q", ts.cfg.ProfileName, current)
+		}
+	}
+}
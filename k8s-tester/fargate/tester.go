@@ -0,0 +1,571 @@
+// Package fargate validates the Fargate profile scheduling, by creating a Fargate profile,
+// running a Pod that matches the profile selector, and verifying the Pod lands on a
+// Fargate node and its logs are routed by the Fargate log router.
+// Replace https://github.com/aws/aws-k8s-tester/tree/v1.5.9/eks/fargate.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/fargate.html
+package fargate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	EKSAPI eksiface.EKSAPI `json:"-"`
+	IAMAPI iamiface.IAMAPI `json:"-"`
+
+	Partition   string `json:"partition"`
+	Region      string `json:"region"`
+	ClusterName string `json:"cluster_name" read-only:"true"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	// Zero by default, since the Fargate Pods do not run on the existing nodes.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources, also used for the Fargate profile selector.
+	Namespace string `json:"namespace"`
+
+	// ProfileName is the name of the Fargate profile to create.
+	ProfileName string `json:"profile_name"`
+	// ProfileSelectorLabels is the Pod labels of the Fargate profile selector.
+	ProfileSelectorLabels map[string]string `json:"profile_selector_labels"`
+	// SubnetIDs is the private subnets of the Fargate profile.
+	// If empty, defaults to the cluster subnets which must all be private.
+	SubnetIDs []string `json:"subnet_ids"`
+	// RoleARN is the existing Pod execution role for the Fargate profile.
+	// If empty, a new role is created with "AmazonEKSFargatePodExecutionRolePolicy".
+	RoleARN string `json:"role_arn"`
+	// RoleName is the name of the Pod execution role to create, if "RoleARN" is empty.
+	RoleName string `json:"role_name"`
+
+	// LoggingEnable is true to configure the Fargate log router
+	// to send the container logs to CloudWatch Logs.
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/fargate-logging.html
+	LoggingEnable bool `json:"logging_enable"`
+	// LoggingLogGroupName is the CloudWatch Logs group of the Fargate log router.
+	LoggingLogGroupName string `json:"logging_log_group_name"`
+
+	// Image is the test Pod image with "/bin/sh".
+	Image string `json:"image"`
+	// Timeout is the timeout to wait for the test Pod to complete on Fargate.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// RoleCreated is true if the Pod execution role was created by this tester,
+	// thus to be deleted on "delete".
+	RoleCreated bool `json:"role_created" read-only:"true"`
+	// LoggingConfigCreated is true if the "aws-logging" ConfigMap was created by this tester,
+	// thus to be deleted on "delete".
+	LoggingConfigCreated bool `json:"logging_config_created" read-only:"true"`
+	// NodeName is the Fargate node of the test Pod.
+	NodeName string `json:"node_name" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults(clusterName string) error {
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if clusterName == "" {
+		return errors.New("empty ClusterName")
+	}
+	cfg.ClusterName = clusterName
+	if cfg.ProfileName == "" {
+		cfg.ProfileName = cfg.Namespace
+	}
+	// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_CreateFargateProfile.html
+	if len(cfg.ProfileName) > 100 {
+		cfg.ProfileName = cfg.ProfileName[len(cfg.ProfileName)-100:]
+	}
+	if len(cfg.ProfileSelectorLabels) == 0 {
+		cfg.ProfileSelectorLabels = map[string]string{DefaultProfileSelectorLabelKey: DefaultProfileSelectorLabelValue}
+	}
+	if cfg.RoleARN == "" {
+		if cfg.RoleName == "" {
+			cfg.RoleName = cfg.Namespace + "-role"
+		}
+		// ref. https://docs.aws.amazon.com/IAM/latest/APIReference/API_CreateRole.html
+		if len(cfg.RoleName) > 64 {
+			cfg.RoleName = cfg.RoleName[len(cfg.RoleName)-64:]
+		}
+	}
+	if cfg.LoggingEnable && cfg.LoggingLogGroupName == "" {
+		cfg.LoggingLogGroupName = "/aws/eks/" + clusterName + "/fargate"
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes              int = 0
+	DefaultPartition                     = "aws"
+	DefaultProfileSelectorLabelKey       = "k8s-tester-fargate"
+	DefaultProfileSelectorLabelValue     = "true"
+	DefaultImage                         = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultTimeout                       = 15 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:       false,
+		Prompt:       false,
+		Partition:    DefaultPartition,
+		MinimumNodes: DefaultMinimumNodes,
+		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		ProfileSelectorLabels: map[string]string{
+			DefaultProfileSelectorLabelKey: DefaultProfileSelectorLabelValue,
+		},
+		Image:   DefaultImage,
+		Timeout: DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.EKSAPI = eks.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	cfg.IAMAPI = iam.New(awsSession)
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config, clusterName string) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	jobName = "fargate"
+
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/fargate-logging.html
+	loggingNamespace     = "aws-observability"
+	loggingConfigMapName = "aws-logging"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createRole(); err != nil {
+		return err
+	}
+	if ts.cfg.LoggingEnable {
+		if err := ts.createLoggingConfig(); err != nil {
+			return err
+		}
+	}
+	if err := ts.createProfile(); err != nil {
+		return err
+	}
+	if err := ts.createJob(); err != nil {
+		return err
+	}
+
+	return ts.checkJob()
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteJob(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		jobName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	// the profile must be deleted before its Pod execution role
+	if err := ts.deleteProfile(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteLoggingConfig(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteRole(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// loggingOutputConf returns the Fluent Bit output of the Fargate log router.
+func loggingOutputConf(region string, logGroupName string) string {
+	return fmt.Sprintf(`[OUTPUT]
+    Name cloudwatch_logs
+    Match *
+    region %s
+    log_group_name %s
+    log_stream_prefix fargate-
+    auto_create_group true
+`, region, logGroupName)
+}
+
+func (ts *tester) createLoggingConfig() error {
+	ts.cfg.Logger.Info("creating Fargate logging ConfigMap", zap.String("log-group-name", ts.cfg.LoggingLogGroupName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Namespaces().
+		Create(
+			ctx,
+			&core_v1.Namespace{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:   loggingNamespace,
+					Labels: map[string]string{loggingNamespace: "enabled"},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %q (%v)", loggingNamespace, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ConfigMaps(loggingNamespace).
+		Create(
+			ctx,
+			&core_v1.ConfigMap{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      loggingConfigMapName,
+					Namespace: loggingNamespace,
+				},
+				Data: map[string]string{
+					"output.conf": loggingOutputConf(ts.cfg.Region, ts.cfg.LoggingLogGroupName),
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			// do not overwrite the existing cluster logging configuration
+			ts.cfg.Logger.Info("Fargate logging ConfigMap already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create Fargate logging ConfigMap (%v)", err)
+	}
+	ts.cfg.LoggingConfigCreated = true
+
+	ts.cfg.Logger.Info("created Fargate logging ConfigMap")
+	return nil
+}
+
+func (ts *tester) deleteLoggingConfig() error {
+	if !ts.cfg.LoggingConfigCreated {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting Fargate logging ConfigMap")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ConfigMaps(loggingNamespace).
+		Delete(ctx, loggingConfigMapName, meta_v1.DeleteOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Fargate logging ConfigMap (%v)", err)
+	}
+	ts.cfg.LoggingConfigCreated = false
+	ts.cfg.Logger.Info("deleted Fargate logging ConfigMap")
+	return nil
+}
+
+func (ts *tester) createJob() error {
+	ts.cfg.Logger.Info("creating Fargate Job", zap.String("image", ts.cfg.Image))
+	backoffLimit := int32(0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: ts.cfg.ProfileSelectorLabels,
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c"},
+									Args:            []string{"echo fargate-hello " + ts.cfg.Namespace},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("Fargate Job already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create Fargate Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Fargate Job")
+	return nil
+}
+
+// fargateNodePrefix is the node name prefix of the Fargate Pods,
+// e.g., "fargate-ip-192-168-1-1.us-west-2.compute.internal".
+const fargateNodePrefix = "fargate-"
+
+func (ts *tester) checkJob() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Timeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		time.Minute,
+		10*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		ts.cfg.NodeName = pod.Spec.NodeName
+		fmt.Fprintf(ts.cfg.LogWriter, "\nFargate Pod %q node: %s\n\n", pod.Name, pod.Spec.NodeName)
+		if !strings.HasPrefix(pod.Spec.NodeName, fargateNodePrefix) {
+			return fmt.Errorf("Fargate Pod %q scheduled to non-Fargate node %q", pod.Name, pod.Spec.NodeName)
+		}
+		if v := pod.Spec.SchedulerName; v != "fargate-scheduler" {
+			return fmt.Errorf("Fargate Pod %q scheduled by %q, expected \"fargate-scheduler\"", pod.Name, v)
+		}
+
+		logs, err := ts.readPodLogs(pod.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read Fargate Pod %q logs (%v)", pod.Name, err)
+		}
+		if !strings.Contains(logs, "fargate-hello "+ts.cfg.Namespace) {
+			return fmt.Errorf("unexpected Fargate Pod %q logs %q", pod.Name, logs)
+		}
+		if ts.cfg.LoggingEnable {
+			return ts.checkLoggingEvent(pod.Name)
+		}
+		return nil
+	}
+	return errors.New("no succeeded Fargate Pod")
+}
+
+// checkLoggingEvent checks the "LoggingEnabled" event, that the Fargate
+// log router picked up the "aws-logging" ConfigMap for the Pod.
+func (ts *tester) checkLoggingEvent(podName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	events, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Events(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{FieldSelector: "involvedObject.name=" + podName})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list Fargate Pod %q events (%v)", podName, err)
+	}
+	for _, ev := range events.Items {
+		if ev.Reason == "LoggingEnabled" {
+			ts.cfg.Logger.Info("Fargate logging enabled", zap.String("pod", podName), zap.String("message", ev.Message))
+			return nil
+		}
+	}
+	return fmt.Errorf("Fargate Pod %q has no 'LoggingEnabled' event", podName)
+}
+
+func (ts *tester) readPodLogs(podName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rc, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		GetLogs(podName, &core_v1.PodLogOptions{Container: jobName}).
+		Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
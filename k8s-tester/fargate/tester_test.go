@@ -0,0 +1,58 @@
+package fargate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateAndSetDefaults(t *testing.T) {
+	cfg := &Config{Namespace: strings.Repeat("a", 70), Region: "us-west-2"}
+	if err := cfg.ValidateAndSetDefaults(""); err == nil {
+		t.Fatal("expected error for empty cluster name")
+	}
+	cfg.LoggingEnable = true
+	if err := cfg.ValidateAndSetDefaults("my-cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.RoleName) != 64 {
+		t.Fatalf("unexpected role name length %d", len(cfg.RoleName))
+	}
+	if cfg.ProfileSelectorLabels[DefaultProfileSelectorLabelKey] != DefaultProfileSelectorLabelValue {
+		t.Fatalf("unexpected selector labels %v", cfg.ProfileSelectorLabels)
+	}
+	if cfg.LoggingLogGroupName != "/aws/eks/my-cluster/fargate" {
+		t.Fatalf("unexpected log group name %q", cfg.LoggingLogGroupName)
+	}
+
+	cfg = &Config{Namespace: "a", Region: "us-west-2", RoleARN: "arn:aws:iam::123456789012:role/a"}
+	if err := cfg.ValidateAndSetDefaults("my-cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RoleName != "" {
+		t.Fatalf("unexpected role name %q with existing role ARN", cfg.RoleName)
+	}
+}
+
+func TestTrustPolicy(t *testing.T) {
+	s, err := trustPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc policyDocument
+	if err = json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Statement) != 1 || doc.Statement[0].Principal["Service"] != "eks-fargate-pods.amazonaws.com" {
+		t.Fatalf("unexpected trust policy %s", s)
+	}
+}
+
+func TestLoggingOutputConf(t *testing.T) {
+	conf := loggingOutputConf("us-west-2", "/aws/eks/my-cluster/fargate")
+	for _, s := range []string{"Name cloudwatch_logs", "region us-west-2", "log_group_name /aws/eks/my-cluster/fargate"} {
+		if !strings.Contains(conf, s) {
+			t.Fatalf("%q not found in %q", s, conf)
+		}
+	}
+}
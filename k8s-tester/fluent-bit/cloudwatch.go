@@ -0,0 +1,170 @@
+package fluent_bit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	markerPod         = "cloudwatch-marker-pod"
+	markerLogFileName = "cloudwatch-marker.log"
+	logStreamPrefix   = "fluent-bit-"
+)
+
+// cloudWatchOutputConf returns the CloudWatch Logs output plugin configuration,
+// in addition to the stdout output for the in-cluster tests.
+// ref. https://docs.fluentbit.io/manual/pipeline/outputs/cloudwatch
+func cloudWatchOutputConf(region string, logGroupName string) string {
+	return OutputConf + fmt.Sprintf(`
+[OUTPUT]
+    Name cloudwatch_logs
+    Match *
+    region %s
+    log_group_name %s
+    log_stream_prefix %s
+    auto_create_group On
+`, region, logGroupName, logStreamPrefix)
+}
+
+// newMarker returns a unique marker to find the log lines in CloudWatch Logs.
+func newMarker() string {
+	return "k8s-tester-fluent-bit-" + strings.ToLower(rand.String(12))
+}
+
+// markerScript writes the marker log lines to the file tailed by fluent-bit.
+func markerScript(marker string, lines int) string {
+	return fmt.Sprintf(`for i in $(seq 1 %d); do echo "%s $i" >> /var/log/suite/%s; done`, lines, marker, markerLogFileName)
+}
+
+// countMarkerLines returns the number of distinct marker log lines in the messages.
+// The messages may be delivered more than once on the retries,
+// or wrapped in a JSON record with the "log" key.
+func countMarkerLines(messages []string, marker string, lines int) int {
+	found := make(map[int]struct{})
+	for _, msg := range messages {
+		idx := strings.Index(msg, marker+" ")
+		if idx < 0 {
+			continue
+		}
+		var n int
+		if _, err := fmt.Sscanf(msg[idx+len(marker)+1:], "%d", &n); err != nil {
+			continue
+		}
+		if n >= 1 && n <= lines {
+			found[n] = struct{}{}
+		}
+	}
+	return len(found)
+}
+
+func newMarkerPod(name string, marker string, lines int) *v1.Pod {
+	pod := newAlpineLoggingPod(name)
+	pod.Spec.Containers[0].Args = []string{"-c", markerScript(marker, lines)}
+	return pod
+}
+
+// testCloudWatchLogs writes the marker log lines from a test Pod,
+// and waits for all of them to be delivered to CloudWatch Logs.
+func (ts *tester) testCloudWatchLogs() error {
+	ts.cfg.Marker = newMarker()
+	ts.cfg.Logger.Info("writing marker log lines",
+		zap.String("marker", ts.cfg.Marker),
+		zap.Int("lines", ts.cfg.MarkerLines),
+		zap.String("log-group-name", ts.cfg.CloudWatchLogGroupName),
+	)
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		Create(ctx, newMarkerPod(markerPod, ts.cfg.Marker, ts.cfg.MarkerLines), meta_v1.CreateOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Pod %q (%v)", markerPod, err)
+	}
+
+	deadline := start.Add(ts.cfg.DeliveryTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("CloudWatch Logs delivery check aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		messages, err := ts.filterMarkerLogEvents(start.Add(-time.Minute))
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to filter log events", zap.Error(err))
+			continue
+		}
+		ts.cfg.DeliveredLines = countMarkerLines(messages, ts.cfg.Marker, ts.cfg.MarkerLines)
+		ts.cfg.Logger.Info("checked marker log lines in CloudWatch Logs",
+			zap.Int("delivered", ts.cfg.DeliveredLines),
+			zap.Int("expected", ts.cfg.MarkerLines),
+		)
+		if ts.cfg.DeliveredLines >= ts.cfg.MarkerLines {
+			ts.cfg.DeliveryLatency = time.Since(start)
+			ts.cfg.DeliveryLatencyString = ts.cfg.DeliveryLatency.String()
+			fmt.Fprintf(ts.cfg.LogWriter, "\nCloudWatch Logs delivered %d marker lines to %q in %v\n\n", ts.cfg.DeliveredLines, ts.cfg.CloudWatchLogGroupName, ts.cfg.DeliveryLatency)
+			return nil
+		}
+	}
+	return fmt.Errorf("only %d of %d marker log lines delivered to CloudWatch Logs %q within %v", ts.cfg.DeliveredLines, ts.cfg.MarkerLines, ts.cfg.CloudWatchLogGroupName, ts.cfg.DeliveryTimeout)
+}
+
+func (ts *tester) filterMarkerLogEvents(since time.Time) (messages []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err = ts.cfg.CWLogsAPI.FilterLogEventsPagesWithContext(
+		ctx,
+		&cloudwatchlogs.FilterLogEventsInput{
+			LogGroupName:        aws.String(ts.cfg.CloudWatchLogGroupName),
+			LogStreamNamePrefix: aws.String(logStreamPrefix),
+			StartTime:           aws.Int64(since.UnixNano() / int64(time.Millisecond)),
+			FilterPattern:       aws.String(fmt.Sprintf("%q", ts.cfg.Marker)),
+		},
+		func(out *cloudwatchlogs.FilterLogEventsOutput, lastPage bool) bool {
+			for _, ev := range out.Events {
+				messages = append(messages, aws.StringValue(ev.Message))
+			}
+			return true
+		},
+	)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException {
+			// log group is not created until the first delivery
+			return nil, nil
+		}
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (ts *tester) deleteLogGroup() error {
+	ts.cfg.Logger.Info("deleting log group", zap.String("log-group-name", ts.cfg.CloudWatchLogGroupName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.CWLogsAPI.DeleteLogGroupWithContext(ctx, &cloudwatchlogs.DeleteLogGroupInput{
+		LogGroupName: aws.String(ts.cfg.CloudWatchLogGroupName),
+	})
+	cancel()
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException {
+			ts.cfg.Logger.Info("log group already deleted", zap.String("log-group-name", ts.cfg.CloudWatchLogGroupName))
+			return nil
+		}
+		return err
+	}
+	ts.cfg.Logger.Info("deleted log group", zap.String("log-group-name", ts.cfg.CloudWatchLogGroupName))
+	return nil
+}
@@ -0,0 +1,34 @@
+package fluent_bit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCloudWatchOutputConf(t *testing.T) {
+	conf := cloudWatchOutputConf("us-west-2", "/aws-k8s-tester/test")
+	for _, s := range []string{"Name stdout", "Name cloudwatch_logs", "region us-west-2", "log_group_name /aws-k8s-tester/test", "auto_create_group On"} {
+		if !strings.Contains(conf, s) {
+			t.Fatalf("%q not found in %q", s, conf)
+		}
+	}
+}
+
+func TestCountMarkerLines(t *testing.T) {
+	marker := "k8s-tester-fluent-bit-abc"
+	messages := []string{
+		`{"log":"k8s-tester-fluent-bit-abc 1"}`,
+		"k8s-tester-fluent-bit-abc 2",
+		"k8s-tester-fluent-bit-abc 2",
+		"k8s-tester-fluent-bit-abc 11",
+		"k8s-tester-fluent-bit-xyz 3",
+		"k8s-tester-fluent-bit-abc foo",
+		"unrelated",
+	}
+	if n := countMarkerLines(messages, marker, 10); n != 2 {
+		t.Fatalf("expected 2, got %d", n)
+	}
+	if s := markerScript(marker, 3); !strings.Contains(s, "seq 1 3") || !strings.Contains(s, marker) {
+		t.Fatalf("unexpected script %q", s)
+	}
+}
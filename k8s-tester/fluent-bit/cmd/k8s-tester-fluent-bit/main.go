@@ -4,6 +4,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/client"
 	fluent_bit "github.com/aws/aws-k8s-tester/k8s-tester/fluent-bit"
@@ -31,6 +32,11 @@ var (
 	kubectlDownloadURL string
 	kubectlPath        string
 	kubeconfigPath     string
+
+	partition              string
+	region                 string
+	cloudWatchLogs         bool
+	cloudWatchLogGroupName string
 )
 
 func init() {
@@ -42,6 +48,10 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
 	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
 	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", "aws", "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for CloudWatch Logs")
+	rootCmd.PersistentFlags().BoolVar(&cloudWatchLogs, "cloudwatch-logs", false, "'true' to verify the log delivery to CloudWatch Logs")
+	rootCmd.PersistentFlags().StringVar(&cloudWatchLogGroupName, "cloudwatch-log-group-name", "", "CloudWatch Logs log group name (defaults to '/aws-k8s-tester/[namespace]')")
 
 	rootCmd.AddCommand(
 		newApply(),
@@ -57,12 +67,19 @@ func main() {
 	os.Exit(0)
 }
 
+var (
+	markerLines     int
+	deliveryTimeout time.Duration
+)
+
 func newApply() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "apply",
 		Short: "Apply tests",
 		Run:   createApplyFunc,
 	}
+	cmd.PersistentFlags().IntVar(&markerLines, "marker-lines", fluent_bit.DefaultMarkerLines, "number of marker log lines to write")
+	cmd.PersistentFlags().DurationVar(&deliveryTimeout, "delivery-timeout", fluent_bit.DefaultDeliveryTimeout, "timeout for all marker log lines to be delivered to CloudWatch Logs")
 	return cmd
 }
 
@@ -91,6 +108,16 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		MinimumNodes: minimumNodes,
 		Namespace:    namespace,
 		Client:       cli,
+
+		Partition:              partition,
+		Region:                 region,
+		CloudWatchLogs:         cloudWatchLogs,
+		CloudWatchLogGroupName: cloudWatchLogGroupName,
+		MarkerLines:            markerLines,
+		DeliveryTimeout:        deliveryTimeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
 	}
 
 	ts := fluent_bit.New(cfg)
@@ -135,6 +162,14 @@ func createDeleteFunc(cmd *cobra.Command, args []string) {
 		LogWriter: logWriter,
 		Namespace: namespace,
 		Client:    cli,
+
+		Partition:              partition,
+		Region:                 region,
+		CloudWatchLogs:         cloudWatchLogs,
+		CloudWatchLogGroupName: cloudWatchLogGroupName,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
 	}
 
 	ts := fluent_bit.New(cfg)
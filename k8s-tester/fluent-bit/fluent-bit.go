@@ -326,6 +326,13 @@ const ParsersConf = `
 	Time_Keep   On
 `
 
+func (ts *tester) outputConf() string {
+	if ts.cfg.CloudWatchLogs {
+		return cloudWatchOutputConf(ts.cfg.Region, ts.cfg.CloudWatchLogGroupName)
+	}
+	return OutputConf
+}
+
 func (ts *tester) createAppConfigMap() error {
 	ts.cfg.Logger.Info("creating: ", zap.String("Configmap", appConfigMapNameConfig))
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
@@ -350,7 +357,7 @@ func (ts *tester) createAppConfigMap() error {
 					"fluent-bit.conf":       FluentBitConf,
 					"input-kubernetes.conf": InputConf,
 					"parsers.conf":          ParsersConf,
-					"output.conf":           OutputConf,
+					"output.conf":           ts.outputConf(),
 				},
 			},
 			meta_v1.CreateOptions{},
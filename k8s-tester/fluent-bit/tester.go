@@ -2,9 +2,11 @@
 package fluent_bit
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -12,10 +14,14 @@ import (
 
 	"github.com/aws/aws-k8s-tester/client"
 	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
 	"github.com/aws/aws-k8s-tester/utils/rand"
 	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
 	"github.com/manifoldco/promptui"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type Config struct {
@@ -31,28 +37,124 @@ type Config struct {
 	MinimumNodes int `json:"minimum_nodes"`
 	// Namespace to create test resources.
 	Namespace string `json:"namespace"`
+
+	CWLogsAPI cloudwatchlogsiface.CloudWatchLogsAPI `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// CloudWatchLogs is true to configure the CloudWatch Logs output plugin,
+	// and verify the marker log lines from a test Pod are delivered to CloudWatch Logs.
+	// The node instance role requires "logs:CreateLogGroup", "logs:CreateLogStream",
+	// "logs:DescribeLogStreams", and "logs:PutLogEvents".
+	CloudWatchLogs bool `json:"cloudwatch_logs"`
+	// CloudWatchLogGroupName is the log group name to deliver the logs.
+	// If empty, "/aws-k8s-tester/[Namespace]" is used.
+	// The log group is deleted on "delete".
+	CloudWatchLogGroupName string `json:"cloudwatch_log_group_name"`
+	// MarkerLines is the number of marker log lines to write.
+	MarkerLines int `json:"marker_lines"`
+	// DeliveryTimeout is the timeout for all marker log lines to be delivered.
+	DeliveryTimeout       time.Duration `json:"delivery_timeout"`
+	DeliveryTimeoutString string        `json:"delivery_timeout_string" read-only:"true"`
+
+	// Marker is the unique marker of the log lines.
+	Marker string `json:"marker" read-only:"true"`
+	// DeliveredLines is the number of marker log lines found in CloudWatch Logs.
+	DeliveredLines int `json:"delivered_lines" read-only:"true"`
+	// DeliveryLatency is the duration from the marker Pod creation
+	// to all marker log lines found in CloudWatch Logs.
+	DeliveryLatency       time.Duration `json:"delivery_latency" read-only:"true"`
+	DeliveryLatencyString string        `json:"delivery_latency_string" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
-
+	if !cfg.CloudWatchLogs {
+		return nil
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region with CloudWatchLogs")
+	}
+	if cfg.CloudWatchLogGroupName == "" {
+		cfg.CloudWatchLogGroupName = "/aws-k8s-tester/" + cfg.Namespace
+	}
+	if cfg.MarkerLines <= 0 {
+		cfg.MarkerLines = DefaultMarkerLines
+	}
+	if cfg.DeliveryTimeout == time.Duration(0) {
+		cfg.DeliveryTimeout = DefaultDeliveryTimeout
+	}
+	cfg.DeliveryTimeoutString = cfg.DeliveryTimeout.String()
 	return nil
 }
 
-const DefaultMinimumNodes int = 1
+const (
+	DefaultMinimumNodes    int = 1
+	DefaultMarkerLines     int = 100
+	DefaultDeliveryTimeout     = 5 * time.Minute
+)
 
 func NewDefault() *Config {
 	return &Config{
-		Enable:       false,
-		Prompt:       false,
-		MinimumNodes: DefaultMinimumNodes,
-		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Enable:          false,
+		Prompt:          false,
+		MinimumNodes:    DefaultMinimumNodes,
+		Namespace:       pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		CloudWatchLogs:  false,
+		MarkerLines:     DefaultMarkerLines,
+		DeliveryTimeout: DefaultDeliveryTimeout,
 	}
 }
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	if cfg.CloudWatchLogs {
+		awsCfg := aws_v1.Config{
+			Logger:        cfg.Logger,
+			DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+			Partition:     cfg.Partition,
+			Region:        cfg.Region,
+		}
+		awsSession, _, _, err := aws_v1.New(&awsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create aws session (%v)", err)
+		}
+		cfg.CWLogsAPI = cloudwatchlogs.New(awsSession)
+	}
+
 	return &tester{
 		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
 	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 type tester struct {
@@ -120,6 +222,12 @@ func (ts *tester) Apply() error {
 		return err
 	}
 
+	if ts.cfg.CloudWatchLogs {
+		if err := ts.testCloudWatchLogs(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -212,6 +320,21 @@ func (ts *tester) Delete() error {
 	}
 	ts.cfg.Logger.Info("Deleting %s: %s", zap.String("Pod", loggingPod))
 
+	if ts.cfg.CloudWatchLogs {
+		if err := client.DeletePod(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			markerPod,
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete Pod (%v)", err))
+		}
+
+		if err := ts.deleteLogGroup(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete log group (%v)", err))
+		}
+	}
+
 	if err := client.DeleteNamespaceAndWait(
 		ts.cfg.Logger,
 		ts.cfg.Client.KubernetesClient(),
@@ -241,7 +364,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -98,3 +98,128 @@ gofmt -s -w ./tester
 
 goimports -w ./wordpress
 gofmt -s -w ./wordpress
+
+goimports -w ./hpa-cloudwatch
+gofmt -s -w ./hpa-cloudwatch
+
+goimports -w ./keda-sqs
+gofmt -s -w ./keda-sqs
+
+goimports -w ./csi-s3
+gofmt -s -w ./csi-s3
+
+goimports -w ./emr-on-eks
+gofmt -s -w ./emr-on-eks
+
+goimports -w ./karpenter
+gofmt -s -w ./karpenter
+
+goimports -w ./istio-ambient
+gofmt -s -w ./istio-ambient
+
+goimports -w ./argo-workflows
+gofmt -s -w ./argo-workflows
+
+goimports -w ./image-prepull
+gofmt -s -w ./image-prepull
+
+goimports -w ./pv-reclaim
+gofmt -s -w ./pv-reclaim
+
+goimports -w ./iam-preflight
+gofmt -s -w ./iam-preflight
+
+goimports -w ./cloudwatch-metrics
+gofmt -s -w ./cloudwatch-metrics
+goimports -w ./opensearch-results
+gofmt -s -w ./opensearch-results
+goimports -w ./artifacts-s3
+gofmt -s -w ./artifacts-s3
+goimports -w ./status-reporter
+gofmt -s -w ./status-reporter
+goimports -w ./steady-state
+gofmt -s -w ./steady-state
+
+goimports -w ./sysctl
+gofmt -s -w ./sysctl
+
+goimports -w ./network-policy
+gofmt -s -w ./network-policy
+
+goimports -w ./hollow-nodes
+gofmt -s -w ./hollow-nodes
+
+goimports -w ./dns
+gofmt -s -w ./dns
+
+goimports -w ./irsa
+gofmt -s -w ./irsa
+
+goimports -w ./fargate
+gofmt -s -w ./fargate
+
+goimports -w ./endpointslices
+gofmt -s -w ./endpointslices
+
+goimports -w ./prometheus-grafana
+gofmt -s -w ./prometheus-grafana
+
+goimports -w ./port-exhaustion
+gofmt -s -w ./port-exhaustion
+
+goimports -w ./runtime-restart
+gofmt -s -w ./runtime-restart
+
+goimports -w ./cert-rotation
+gofmt -s -w ./cert-rotation
+
+goimports -w ./kube-state-metrics
+gofmt -s -w ./kube-state-metrics
+
+goimports -w ./adot
+gofmt -s -w ./adot
+
+goimports -w ./node-problem-detector
+gofmt -s -w ./node-problem-detector
+
+goimports -w ./pdb
+gofmt -s -w ./pdb
+
+goimports -w ./daemonset-rollout
+gofmt -s -w ./daemonset-rollout
+
+goimports -w ./windows
+gofmt -s -w ./windows
+
+goimports -w ./nvidia-gpu
+gofmt -s -w ./nvidia-gpu
+
+goimports -w ./kueue
+gofmt -s -w ./kueue
+
+goimports -w ./neuron
+gofmt -s -w ./neuron
+
+goimports -w ./multi-container
+gofmt -s -w ./multi-container
+
+goimports -w ./node-tag-labels
+gofmt -s -w ./node-tag-labels
+
+goimports -w ./cluster-pause
+gofmt -s -w ./cluster-pause
+
+goimports -w ./cross-cluster
+gofmt -s -w ./cross-cluster
+
+goimports -w ./auth-refresh
+gofmt -s -w ./auth-refresh
+
+goimports -w ./probe-storm
+gofmt -s -w ./probe-storm
+
+goimports -w ./event-flood
+gofmt -s -w ./event-flood
+
+goimports -w ./static-pod
+gofmt -s -w ./static-pod
@@ -21,6 +21,7 @@ import (
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/repo"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
@@ -121,10 +122,15 @@ type InstallConfig struct {
 
 	KubeconfigPath string
 	Namespace      string
-	ChartRepoURL   string
-	ChartName      string
-	ReleaseName    string
-	Values         map[string]interface{}
+	// ChartRepoURL is the chart repository URL, the chart .tgz URL,
+	// or the OCI registry URL (e.g., "oci://public.ecr.aws/karpenter").
+	ChartRepoURL string
+	ChartName    string
+	// ChartVersion is the chart version to install.
+	// Leave empty to install the latest.
+	ChartVersion string
+	ReleaseName  string
+	Values       map[string]interface{}
 
 	LogFunc       action.DebugLog
 	QueryFunc     func()
@@ -221,8 +227,20 @@ func Install(cfg InstallConfig) (err error) {
 			zap.String("chart-name", cfg.ChartName),
 			zap.String("release-name", cfg.ReleaseName),
 		)
-		install.ChartPathOptions.RepoURL = cfg.ChartRepoURL
-		chartPath, err := install.ChartPathOptions.LocateChart(cfg.ChartName, cli.New())
+		install.ChartPathOptions.Version = cfg.ChartVersion
+		chartRef := cfg.ChartName
+		if registry.IsOCI(cfg.ChartRepoURL) {
+			// e.g., "oci://public.ecr.aws/karpenter/karpenter"
+			rc, err := registry.NewClient()
+			if err != nil {
+				return err
+			}
+			install.SetRegistryClient(rc)
+			chartRef = strings.TrimSuffix(cfg.ChartRepoURL, "/") + "/" + cfg.ChartName
+		} else {
+			install.ChartPathOptions.RepoURL = cfg.ChartRepoURL
+		}
+		chartPath, err := install.ChartPathOptions.LocateChart(chartRef, cli.New())
 		if err != nil {
 			cfg.Logger.Warn("failed to locate chart",
 				zap.String("chart-repo", cfg.ChartRepoURL),
@@ -0,0 +1,181 @@
+// k8s-tester-hollow-nodes registers kubemark hollow nodes at scale.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	hollow_nodes "github.com/aws/aws-k8s-tester/k8s-tester/hollow-nodes"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-hollow-nodes",
+	Short:      "Kubernetes hollow nodes tester",
+	SuggestFor: []string{"hollow-nodes"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	nodeLabels         map[string]string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", hollow_nodes.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringToStringVar(&nodeLabels, "node-labels", map[string]string{hollow_nodes.DefaultNodeLabelKey: hollow_nodes.DefaultNodeLabelValue}, "hollow node labels, also used to select the hollow nodes to delete")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-hollow-nodes failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	kubemarkImage          string
+	nodes                  int32
+	maxPods                int
+	shapes                 string
+	deploymentNodeSelector map[string]string
+	timeout                time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&kubemarkImage, "kubemark-image", "", "kubemark image matching the control plane version")
+	cmd.PersistentFlags().Int32Var(&nodes, "nodes", hollow_nodes.DefaultNodes, "number of hollow nodes to register")
+	cmd.PersistentFlags().IntVar(&maxPods, "max-pods", hollow_nodes.DefaultMaxPods, "maximum number of Pods on each hollow node")
+	cmd.PersistentFlags().StringVar(&shapes, "shapes", "", `hollow node shapes in JSON keyed by the shape name (e.g., '{"gpu":{"nodes":10,"taints":["gpu=true:NoSchedule"],"extended_resources":{"nvidia.com/gpu":"8"}}}'), empty for the default shape`)
+	cmd.PersistentFlags().StringToStringVar(&deploymentNodeSelector, "deployment-node-selector", nil, "node selector for the hollow node Pods")
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", hollow_nodes.DefaultTimeout, "timeout to wait for all hollow nodes to be ready")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &hollow_nodes.Config{
+		Prompt:                 prompt,
+		Logger:                 lg,
+		LogWriter:              logWriter,
+		MinimumNodes:           minimumNodes,
+		Namespace:              namespace,
+		Client:                 cli,
+		KubemarkImage:          kubemarkImage,
+		Nodes:                  nodes,
+		NodeLabels:             nodeLabels,
+		MaxPods:                maxPods,
+		DeploymentNodeSelector: deploymentNodeSelector,
+		Timeout:                timeout,
+	}
+	if shapes != "" {
+		if err := json.Unmarshal([]byte(shapes), &cfg.Shapes); err != nil {
+			lg.Panic("failed to parse --shapes", zap.Error(err))
+		}
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := hollow_nodes.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-hollow-nodes apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &hollow_nodes.Config{
+		Prompt:     prompt,
+		Logger:     lg,
+		LogWriter:  logWriter,
+		Namespace:  namespace,
+		Client:     cli,
+		NodeLabels: nodeLabels,
+	}
+
+	ts := hollow_nodes.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-hollow-nodes delete' success\n")
+}
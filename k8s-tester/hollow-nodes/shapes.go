@@ -0,0 +1,138 @@
+package hollow_nodes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Shape is a group of hollow nodes with the same capacity, labels, taints,
+// and kubelet version, to simulate heterogeneous fleets.
+// The CPU and memory capacity are fixed by the kubemark fake cAdvisor,
+// so the capacity is shaped with "MaxPods" and "ExtendedResources".
+type Shape struct {
+	// Nodes is the number of hollow nodes of this shape.
+	Nodes int32 `json:"nodes"`
+	// KubemarkImage overrides the kubemark image for this shape,
+	// to simulate the kubelet version skew (e.g., one minor version behind the control plane).
+	KubemarkImage string `json:"kubemark_image"`
+	// Labels is the additional labels for this shape,
+	// merged with the common "NodeLabels".
+	Labels map[string]string `json:"labels"`
+	// Taints is the list of taints to register with,
+	// in the "key[=value]:Effect" format of kubelet "--register-with-taints".
+	Taints []string `json:"taints"`
+	// MaxPods overrides the maximum number of Pods on each hollow node of this shape.
+	MaxPods int `json:"max_pods"`
+	// ExtendedResources is the extended resource capacity of each hollow node
+	// (e.g., {"nvidia.com/gpu": "8"}).
+	ExtendedResources map[string]string `json:"extended_resources"`
+}
+
+// ShapeResult is the registration result of a shape.
+type ShapeResult struct {
+	// ReadyNodes is the number of ready hollow nodes of this shape.
+	ReadyNodes int `json:"ready_nodes"`
+	// KubeletVersions is the list of kubelet versions reported by the hollow nodes.
+	KubeletVersions []string `json:"kubelet_versions"`
+}
+
+const (
+	// DefaultShapeName is the shape name when "Shapes" is empty.
+	DefaultShapeName = "default"
+	// ShapeLabelKey is the node label key for the shape name.
+	ShapeLabelKey = "hollow-nodes.k8s-tester/shape"
+)
+
+func (sh *Shape) validateAndSetDefaults(name string, maxPods int) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid shape name %q (%s)", name, strings.Join(errs, ", "))
+	}
+	if sh.Nodes <= 0 {
+		return fmt.Errorf("shape %q has invalid Nodes %d", name, sh.Nodes)
+	}
+	if sh.MaxPods <= 0 {
+		sh.MaxPods = maxPods
+	}
+	if _, ok := sh.Labels[ShapeLabelKey]; ok {
+		return fmt.Errorf("shape %q overrides reserved label %q", name, ShapeLabelKey)
+	}
+	for _, taint := range sh.Taints {
+		if err := validateTaint(taint); err != nil {
+			return fmt.Errorf("shape %q has invalid taint %q (%v)", name, taint, err)
+		}
+	}
+	for k, v := range sh.ExtendedResources {
+		// native resources cannot be faked through the extended resources
+		if !strings.Contains(k, "/") || strings.HasPrefix(k, "kubernetes.io/") {
+			return fmt.Errorf("shape %q has invalid extended resource name %q", name, k)
+		}
+		if _, err := resource.ParseQuantity(v); err != nil {
+			return fmt.Errorf("shape %q has invalid extended resource quantity %q (%v)", name, v, err)
+		}
+	}
+	return nil
+}
+
+// validateTaint validates the "key[=value]:Effect" taint.
+func validateTaint(taint string) error {
+	idx := strings.LastIndex(taint, ":")
+	if idx < 0 {
+		return errors.New("missing effect")
+	}
+	switch core_v1.TaintEffect(taint[idx+1:]) {
+	case core_v1.TaintEffectNoSchedule,
+		core_v1.TaintEffectPreferNoSchedule,
+		core_v1.TaintEffectNoExecute:
+	default:
+		return fmt.Errorf("unknown effect %q", taint[idx+1:])
+	}
+	key := strings.SplitN(taint[:idx], "=", 2)[0]
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// shapes returns the configured shapes, or the default shape with "Nodes" hollow nodes.
+func (cfg *Config) shapes() map[string]*Shape {
+	if len(cfg.Shapes) > 0 {
+		return cfg.Shapes
+	}
+	return map[string]*Shape{
+		DefaultShapeName: {Nodes: cfg.Nodes, MaxPods: cfg.MaxPods},
+	}
+}
+
+// shapeNames returns the sorted shape names.
+func (cfg *Config) shapeNames() []string {
+	shapes := cfg.shapes()
+	names := make([]string, 0, len(shapes))
+	for name := range shapes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shapeLabels returns the node labels of the shape.
+func (cfg *Config) shapeLabels(name string, sh *Shape) map[string]string {
+	m := make(map[string]string, len(cfg.NodeLabels)+len(sh.Labels)+1)
+	for k, v := range cfg.NodeLabels {
+		m[k] = v
+	}
+	for k, v := range sh.Labels {
+		m[k] = v
+	}
+	m[ShapeLabelKey] = name
+	return m
+}
+
+func shapeDeploymentName(name string) string {
+	return deploymentName + "-" + name
+}
@@ -0,0 +1,717 @@
+// Package hollow_nodes registers kubemark-style hollow nodes at scale,
+// a fake kubelet and kube-proxy per Pod without a container runtime,
+// so that the control-plane scale tests can run without thousands of real nodes.
+// Replace https://github.com/aws/aws-k8s-tester/tree/v1.5.9/eks/hollow-nodes.
+// ref. https://github.com/kubernetes/community/blob/master/contributors/devel/sig-scalability/kubemark-guide.md
+package hollow_nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// KubemarkImage is the image with the "/kubemark" binary,
+	// built from "k8s.io/kubernetes/cluster/images/kubemark".
+	// The kubemark version should match the control plane version.
+	KubemarkImage string `json:"kubemark_image"`
+	// Nodes is the number of hollow nodes to register with the default shape.
+	// If "Shapes" is set, it is the total number of hollow nodes of all shapes.
+	Nodes int32 `json:"nodes"`
+	// NodeLabels is the labels of the hollow nodes,
+	// also used to select the hollow nodes to delete.
+	NodeLabels map[string]string `json:"node_labels"`
+	// MaxPods is the maximum number of Pods on each hollow node.
+	MaxPods int `json:"max_pods"`
+	// Shapes is the hollow node shapes keyed by the shape name,
+	// to simulate heterogeneous fleets. If empty, all hollow nodes
+	// are registered with the "default" shape.
+	Shapes map[string]*Shape `json:"shapes"`
+	// DeploymentNodeSelector is the node selector for the hollow node Pods,
+	// to run them on the real nodes.
+	DeploymentNodeSelector map[string]string `json:"deployment_node_selector"`
+	// Timeout is the timeout to wait for all hollow nodes to be ready.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// ReadyNodes is the number of hollow nodes that became ready.
+	ReadyNodes int `json:"ready_nodes" read-only:"true"`
+	// ShapeResults is the registration results keyed by the shape name.
+	ShapeResults map[string]*ShapeResult `json:"shape_results" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.KubemarkImage == "" {
+		return errors.New("empty KubemarkImage")
+	}
+	if cfg.Nodes <= 0 {
+		cfg.Nodes = DefaultNodes
+	}
+	// the labels select the hollow nodes to delete
+	// (hollow kubelets do not remove their node objects)
+	if len(cfg.NodeLabels) == 0 {
+		cfg.NodeLabels = map[string]string{DefaultNodeLabelKey: DefaultNodeLabelValue}
+	}
+	if cfg.MaxPods <= 0 {
+		cfg.MaxPods = DefaultMaxPods
+	}
+	if len(cfg.Shapes) > 0 {
+		var total int32
+		for name, sh := range cfg.Shapes {
+			if err := sh.validateAndSetDefaults(name, cfg.MaxPods); err != nil {
+				return err
+			}
+			total += sh.Nodes
+		}
+		cfg.Nodes = total
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes int   = 1
+	DefaultNodes        int32 = 10
+	DefaultMaxPods      int   = 110
+	DefaultTimeout            = 30 * time.Minute
+
+	DefaultNodeLabelKey   = "NodeType"
+	DefaultNodeLabelValue = "hollow-nodes"
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:       false,
+		Prompt:       false,
+		MinimumNodes: DefaultMinimumNodes,
+		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Nodes:        DefaultNodes,
+		NodeLabels:   map[string]string{DefaultNodeLabelKey: DefaultNodeLabelValue},
+		MaxPods:      DefaultMaxPods,
+		Timeout:      DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	deploymentName = "hollow-node"
+	appName        = "hollow-node"
+
+	serviceAccountName = "hollow-node"
+	// hollow kubelets need the same permissions as real kubelets
+	// ref. https://kubernetes.io/docs/reference/access-authn-authz/rbac/#core-component-roles
+	nodeClusterRoleName        = "system:node"
+	nodeProxierClusterRoleName = "system:node-proxier"
+
+	kubeconfigConfigMapName = "hollow-node-kubeconfig"
+	kubeconfigMountPath     = "/kubeconfig"
+	kubeconfigFileName      = "kubeconfig"
+)
+
+func (ts *tester) clusterRoleBindingName(role string) string {
+	return ts.cfg.Namespace + "-" + strings.Replace(role, ":", "-", -1)
+}
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+	if err := ts.createServiceAccount(); err != nil {
+		return err
+	}
+	for _, role := range []string{nodeClusterRoleName, nodeProxierClusterRoleName} {
+		if err := ts.createRBACClusterRoleBinding(role); err != nil {
+			return err
+		}
+	}
+	if err := ts.createKubeconfigConfigMap(); err != nil {
+		return err
+	}
+	shapes := ts.cfg.shapes()
+	for _, name := range ts.cfg.shapeNames() {
+		if err := ts.createDeployment(name, shapes[name]); err != nil {
+			return err
+		}
+	}
+
+	return ts.waitForNodes()
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	for _, name := range ts.cfg.shapeNames() {
+		if err := client.DeleteDeployment(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			shapeDeploymentName(name),
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete Deployment (%v)", err))
+		}
+	}
+
+	if err := ts.deleteNodes(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	for _, role := range []string{nodeClusterRoleName, nodeProxierClusterRoleName} {
+		if err := client.DeleteRBACClusterRoleBinding(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ts.clusterRoleBindingName(role),
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete RBAC cluster role binding (%v)", err))
+		}
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createServiceAccount() error {
+	ts.cfg.Logger.Info("creating hollow node ServiceAccount")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ServiceAccounts(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ServiceAccount{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serviceAccountName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("hollow node ServiceAccount already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create hollow node ServiceAccount (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created hollow node ServiceAccount")
+	return nil
+}
+
+func (ts *tester) createRBACClusterRoleBinding(role string) error {
+	name := ts.clusterRoleBindingName(role)
+	ts.cfg.Logger.Info("creating hollow node RBAC ClusterRoleBinding", zap.String("name", name), zap.String("role", role))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		ClusterRoleBindings().
+		Create(
+			ctx,
+			&rbac_v1.ClusterRoleBinding{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRoleBinding",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: name,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+				RoleRef: rbac_v1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "ClusterRole",
+					Name:     role,
+				},
+				Subjects: []rbac_v1.Subject{
+					{
+						APIGroup:  "",
+						Kind:      "ServiceAccount",
+						Name:      serviceAccountName,
+						Namespace: ts.cfg.Namespace,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("hollow node RBAC ClusterRoleBinding already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create hollow node RBAC ClusterRoleBinding (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created hollow node RBAC ClusterRoleBinding")
+	return nil
+}
+
+// kubemark requires a kubeconfig file, so point it to the in-cluster
+// API server with the projected ServiceAccount token.
+const kubeconfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- name: in-cluster
+  cluster:
+    certificate-authority: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+    server: https://kubernetes.default.svc
+users:
+- name: hollow-node
+  user:
+    tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+contexts:
+- name: hollow-node
+  context:
+    cluster: in-cluster
+    user: hollow-node
+current-context: hollow-node
+`
+
+func (ts *tester) createKubeconfigConfigMap() error {
+	ts.cfg.Logger.Info("creating hollow node kubeconfig ConfigMap")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ConfigMaps(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ConfigMap{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      kubeconfigConfigMapName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+				Data: map[string]string{
+					kubeconfigFileName: kubeconfigTemplate,
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("hollow node kubeconfig ConfigMap already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create hollow node kubeconfig ConfigMap (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created hollow node kubeconfig ConfigMap")
+	return nil
+}
+
+// nodeLabelsFlag returns the sorted "--node-labels" flag value.
+func nodeLabelsFlag(m map[string]string) string {
+	ss := make([]string, 0, len(m))
+	for k, v := range m {
+		ss = append(ss, k+"="+v)
+	}
+	sort.Strings(ss)
+	return strings.Join(ss, ",")
+}
+
+// ref. https://github.com/kubernetes/kubernetes/blob/master/test/kubemark/resources/hollow-node_template.yaml
+func (ts *tester) hollowContainers(name string, sh *Shape) []core_v1.Container {
+	image := ts.cfg.KubemarkImage
+	if sh.KubemarkImage != "" {
+		image = sh.KubemarkImage
+	}
+	kubeletArgs := []string{
+		"--morph=kubelet",
+		"--name=$(NODE_NAME)",
+		"--kubeconfig=" + kubeconfigMountPath + "/" + kubeconfigFileName,
+		"--node-labels=" + nodeLabelsFlag(ts.cfg.shapeLabels(name, sh)),
+		fmt.Sprintf("--max-pods=%d", sh.MaxPods),
+	}
+	if len(sh.Taints) > 0 {
+		kubeletArgs = append(kubeletArgs, "--register-with-taints="+strings.Join(sh.Taints, ","))
+	}
+	if len(sh.ExtendedResources) > 0 {
+		kubeletArgs = append(kubeletArgs, "--extended-resources="+nodeLabelsFlag(sh.ExtendedResources))
+	}
+	kubeletArgs = append(kubeletArgs, "--v=2")
+
+	env := []core_v1.EnvVar{
+		{
+			Name: "NODE_NAME",
+			ValueFrom: &core_v1.EnvVarSource{
+				FieldRef: &core_v1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+	}
+	mounts := []core_v1.VolumeMount{
+		{Name: "kubeconfig", MountPath: kubeconfigMountPath, ReadOnly: true},
+	}
+	privileged := true
+	return []core_v1.Container{
+		{
+			Name:            "hollow-kubelet",
+			Image:           image,
+			ImagePullPolicy: core_v1.PullIfNotPresent,
+			Command:         []string{"/kubemark"},
+			Args:            kubeletArgs,
+			Env:             env,
+			VolumeMounts:    mounts,
+			Resources: core_v1.ResourceRequirements{
+				Requests: core_v1.ResourceList{
+					core_v1.ResourceCPU:    resource.MustParse("40m"),
+					core_v1.ResourceMemory: resource.MustParse("100Mi"),
+				},
+			},
+			SecurityContext: &core_v1.SecurityContext{Privileged: &privileged},
+		},
+		{
+			Name:            "hollow-proxy",
+			Image:           image,
+			ImagePullPolicy: core_v1.PullIfNotPresent,
+			Command:         []string{"/kubemark"},
+			Args: []string{
+				"--morph=proxy",
+				"--name=$(NODE_NAME)",
+				"--kubeconfig=" + kubeconfigMountPath + "/" + kubeconfigFileName,
+				"--use-real-proxier=false",
+				"--v=2",
+			},
+			Env:          env,
+			VolumeMounts: mounts,
+			Resources: core_v1.ResourceRequirements{
+				Requests: core_v1.ResourceList{
+					core_v1.ResourceCPU:    resource.MustParse("20m"),
+					core_v1.ResourceMemory: resource.MustParse("50Mi"),
+				},
+			},
+		},
+	}
+}
+
+func (ts *tester) createDeployment(name string, sh *Shape) error {
+	var nodeSelector map[string]string
+	if len(ts.cfg.DeploymentNodeSelector) > 0 {
+		nodeSelector = ts.cfg.DeploymentNodeSelector
+	} else {
+		nodeSelector = nil
+	}
+	ts.cfg.Logger.Info("creating hollow node Deployment", zap.String("shape", name), zap.Int32("nodes", sh.Nodes), zap.Any("node-selector", nodeSelector))
+	replicas := sh.Nodes
+	podLabels := map[string]string{
+		"app.kubernetes.io/name": appName,
+		ShapeLabelKey:            name,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      shapeDeploymentName(name),
+					Namespace: ts.cfg.Namespace,
+					Labels:    podLabels,
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: podLabels,
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: podLabels,
+						},
+						Spec: core_v1.PodSpec{
+							ServiceAccountName: serviceAccountName,
+							RestartPolicy:      core_v1.RestartPolicyAlways,
+							Containers:         ts.hollowContainers(name, sh),
+							Volumes: []core_v1.Volume{
+								{
+									Name: "kubeconfig",
+									VolumeSource: core_v1.VolumeSource{
+										ConfigMap: &core_v1.ConfigMapVolumeSource{
+											LocalObjectReference: core_v1.LocalObjectReference{Name: kubeconfigConfigMapName},
+										},
+									},
+								},
+							},
+							NodeSelector: nodeSelector,
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("hollow node Deployment already exists", zap.String("shape", name))
+			return nil
+		}
+		return fmt.Errorf("failed to create hollow node Deployment for shape %q (%v)", name, err)
+	}
+
+	ts.cfg.Logger.Info("created hollow node Deployment", zap.String("shape", name))
+	return nil
+}
+
+func (ts *tester) listNodes() ([]core_v1.Node, error) {
+	return client.ListNodesWithOptions(
+		ts.cfg.Client.KubernetesClient(),
+		meta_v1.ListOptions{LabelSelector: labels.SelectorFromSet(ts.cfg.NodeLabels).String()},
+	)
+}
+
+// waitForNodes waits until the hollow nodes of every shape are registered and ready,
+// by watching the nodes instead of listing them every poll interval.
+func (ts *tester) waitForNodes() error {
+	ts.cfg.Logger.Info("waiting for hollow nodes", zap.Int32("nodes", ts.cfg.Nodes), zap.String("timeout", ts.cfg.Timeout.String()))
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Timeout)
+	nodes, err := client.WaitForNodes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		func(nodes []core_v1.Node) (bool, error) {
+			ts.cfg.ShapeResults = summarizeNodes(nodes)
+			ready, done := 0, true
+			for name, sh := range ts.cfg.shapes() {
+				rs, ok := ts.cfg.ShapeResults[name]
+				if !ok {
+					done = false
+					continue
+				}
+				ready += rs.ReadyNodes
+				if rs.ReadyNodes < int(sh.Nodes) {
+					done = false
+				}
+			}
+			if ready != ts.cfg.ReadyNodes {
+				ts.cfg.Logger.Info("hollow nodes ready",
+					zap.Int("registered", len(nodes)),
+					zap.Int("ready", ready),
+					zap.Int32("expected", ts.cfg.Nodes),
+					zap.String("took", time.Since(start).String()),
+				)
+			}
+			ts.cfg.ReadyNodes = ready
+			return done, nil
+		},
+		client.WithLabelSelector(labels.SelectorFromSet(ts.cfg.NodeLabels).String()),
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("%d of %d hollow nodes ready after %v (%v)", ts.cfg.ReadyNodes, ts.cfg.Nodes, time.Since(start), err)
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\n%d hollow nodes ready (took %v)\n", ts.cfg.ReadyNodes, time.Since(start))
+	for _, name := range ts.cfg.shapeNames() {
+		rs := ts.cfg.ShapeResults[name]
+		fmt.Fprintf(ts.cfg.LogWriter, "shape %q: %d ready, kubelet versions %q\n", name, rs.ReadyNodes, rs.KubeletVersions)
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\n")
+	ts.cfg.Logger.Info("hollow nodes ready", zap.Int("nodes", len(nodes)))
+	return nil
+}
+
+// summarizeNodes returns the ready node counts and the kubelet versions
+// keyed by the shape name.
+func summarizeNodes(nodes []core_v1.Node) map[string]*ShapeResult {
+	rs := make(map[string]*ShapeResult)
+	versions := make(map[string]map[string]struct{})
+	for _, node := range nodes {
+		name := node.Labels[ShapeLabelKey]
+		if _, ok := rs[name]; !ok {
+			rs[name] = &ShapeResult{}
+			versions[name] = make(map[string]struct{})
+		}
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == core_v1.NodeReady && cond.Status == core_v1.ConditionTrue {
+				rs[name].ReadyNodes++
+				break
+			}
+		}
+		if v := node.Status.NodeInfo.KubeletVersion; v != "" {
+			if _, ok := versions[name][v]; !ok {
+				versions[name][v] = struct{}{}
+				rs[name].KubeletVersions = append(rs[name].KubeletVersions, v)
+			}
+		}
+	}
+	for _, r := range rs {
+		sort.Strings(r.KubeletVersions)
+	}
+	return rs
+}
+
+// deleteNodes deletes the hollow node objects left after the hollow kubelets are gone.
+func (ts *tester) deleteNodes() error {
+	nodes, err := ts.listNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list hollow nodes (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleting hollow nodes", zap.Int("nodes", len(nodes)))
+	var errs []string
+	for _, node := range nodes {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err = ts.cfg.Client.KubernetesClient().CoreV1().Nodes().Delete(ctx, node.Name, meta_v1.DeleteOptions{})
+		cancel()
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("failed to delete hollow node %q (%v)", node.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	ts.cfg.Logger.Info("deleted hollow nodes", zap.Int("nodes", len(nodes)))
+	return nil
+}
@@ -0,0 +1,115 @@
+package hollow_nodes
+
+import (
+	"reflect"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeLabelsFlag(t *testing.T) {
+	tt := []struct {
+		labels map[string]string
+		exp    string
+	}{
+		{map[string]string{"NodeType": "hollow-nodes"}, "NodeType=hollow-nodes"},
+		{map[string]string{"b": "2", "a": "1", "c": "3"}, "a=1,b=2,c=3"},
+		{nil, ""},
+	}
+	for i, tv := range tt {
+		if v := nodeLabelsFlag(tv.labels); v != tv.exp {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.exp, v)
+		}
+	}
+}
+
+func TestValidateTaint(t *testing.T) {
+	tt := []struct {
+		taint string
+		ok    bool
+	}{
+		{"gpu=true:NoSchedule", true},
+		{"dedicated:NoExecute", true},
+		{"example.com/spot=yes:PreferNoSchedule", true},
+		{"gpu=true", false},
+		{"gpu=true:Never", false},
+		{"-bad=true:NoSchedule", false},
+	}
+	for i, tv := range tt {
+		err := validateTaint(tv.taint)
+		if tv.ok && err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if !tv.ok && err == nil {
+			t.Fatalf("#%d: expected error for %q", i, tv.taint)
+		}
+	}
+}
+
+func TestValidateShapes(t *testing.T) {
+	cfg := NewDefault()
+	cfg.KubemarkImage = "kubemark"
+	cfg.Shapes = map[string]*Shape{
+		"small": {Nodes: 3},
+		"gpu":   {Nodes: 2, MaxPods: 30, ExtendedResources: map[string]string{"nvidia.com/gpu": "8"}},
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Nodes != 5 {
+		t.Fatalf("expected total 5 nodes, got %d", cfg.Nodes)
+	}
+	if cfg.Shapes["small"].MaxPods != DefaultMaxPods {
+		t.Fatalf("expected default max pods, got %d", cfg.Shapes["small"].MaxPods)
+	}
+	if !reflect.DeepEqual(cfg.shapeNames(), []string{"gpu", "small"}) {
+		t.Fatalf("unexpected shape names %v", cfg.shapeNames())
+	}
+	exp := map[string]string{DefaultNodeLabelKey: DefaultNodeLabelValue, ShapeLabelKey: "gpu", "a": "b"}
+	if labels := cfg.shapeLabels("gpu", &Shape{Labels: map[string]string{"a": "b"}}); !reflect.DeepEqual(labels, exp) {
+		t.Fatalf("expected %v, got %v", exp, labels)
+	}
+
+	for i, sh := range []map[string]*Shape{
+		{"Bad_Name": {Nodes: 1}},
+		{"empty": {Nodes: 0}},
+		{"gpu": {Nodes: 1, ExtendedResources: map[string]string{"cpu": "8"}}},
+		{"gpu": {Nodes: 1, ExtendedResources: map[string]string{"nvidia.com/gpu": "many"}}},
+		{"gpu": {Nodes: 1, Labels: map[string]string{ShapeLabelKey: "other"}}},
+	} {
+		cfg.Shapes = sh
+		if err := cfg.ValidateAndSetDefaults(); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestSummarizeNodes(t *testing.T) {
+	node := func(shape string, version string, ready bool) core_v1.Node {
+		status := core_v1.ConditionFalse
+		if ready {
+			status = core_v1.ConditionTrue
+		}
+		return core_v1.Node{
+			ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{ShapeLabelKey: shape}},
+			Status: core_v1.NodeStatus{
+				Conditions: []core_v1.NodeCondition{{Type: core_v1.NodeReady, Status: status}},
+				NodeInfo:   core_v1.NodeSystemInfo{KubeletVersion: version},
+			},
+		}
+	}
+	rs := summarizeNodes([]core_v1.Node{
+		node("default", "v1.29.3", true),
+		node("default", "v1.29.3", false),
+		node("skew", "v1.28.8", true),
+		node("skew", "v1.27.12", true),
+	})
+	exp := map[string]*ShapeResult{
+		"default": {ReadyNodes: 1, KubeletVersions: []string{"v1.29.3"}},
+		"skew":    {ReadyNodes: 2, KubeletVersions: []string{"v1.27.12", "v1.28.8"}},
+	}
+	if !reflect.DeepEqual(rs, exp) {
+		t.Fatalf("expected %+v, got %+v", exp, rs)
+	}
+}
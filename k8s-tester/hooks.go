@@ -0,0 +1,284 @@
+package k8s_tester
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"go.uber.org/zap"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+// TesterHooks is the per-tester commands to run before and after "apply",
+// e.g., to seed data, to capture state, or to run custom validations between add-ons.
+type TesterHooks struct {
+	// PreApplyHooks run in order before the tester "apply".
+	// A failed hook fails the tester without applying it.
+	PreApplyHooks []*Hook `json:"pre_apply_hooks,omitempty"`
+	// PostApplyHooks run in order after the tester "apply" succeeds.
+	// A failed hook fails the tester.
+	PostApplyHooks []*Hook `json:"post_apply_hooks,omitempty"`
+}
+
+// Hook is a shell command run locally, or in an in-cluster Job if "Image" is set.
+// The command has the environmental variables "K8S_TESTER_HOOK_TESTER" and
+// "K8S_TESTER_HOOK_PHASE" of the tester name and the hook phase.
+// The local command also has "K8S_TESTER_CONFIG_PATH", and "KUBECONFIG" if configured.
+type Hook struct {
+	// Name is the hook name in the logs. Defaults to the phase with the index.
+	Name string `json:"name,omitempty"`
+	// Command is the shell command run with "sh -c".
+	Command string `json:"command"`
+	// Image is the container image of the in-cluster Job, with "sh".
+	// Leave empty to run the command locally.
+	Image string `json:"image,omitempty"`
+	// Namespace is the namespace of the in-cluster Job. Defaults to "default".
+	Namespace string `json:"namespace,omitempty"`
+	// Timeout is the timeout of the hook. Defaults to 10 minutes.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// IgnoreFailure is true to only log the hook failure, without failing the tester.
+	IgnoreFailure bool `json:"ignore_failure,omitempty"`
+}
+
+const (
+	// HookPhasePreApply is the phase of the hooks run before the tester "apply".
+	HookPhasePreApply = "pre-apply"
+	// HookPhasePostApply is the phase of the hooks run after the tester "apply".
+	HookPhasePostApply = "post-apply"
+
+	// DefaultHookTimeout is the default timeout of each hook.
+	DefaultHookTimeout = 10 * time.Minute
+	// DefaultHookNamespace is the default namespace of the in-cluster hook Jobs.
+	DefaultHookNamespace = "default"
+)
+
+// validateTesterHooks validates the hooks, and sets the defaults.
+func (cfg *Config) validateTesterHooks() error {
+	for name, hooks := range cfg.TesterHooks {
+		if hooks == nil {
+			continue
+		}
+		for phase, hs := range map[string][]*Hook{
+			HookPhasePreApply:  hooks.PreApplyHooks,
+			HookPhasePostApply: hooks.PostApplyHooks,
+		} {
+			for i, h := range hs {
+				if h == nil {
+					return fmt.Errorf("empty TesterHooks[%q] %s hook #%d", name, phase, i)
+				}
+				if h.Name == "" {
+					h.Name = fmt.Sprintf("%s-%d", phase, i)
+				}
+				if strings.TrimSpace(h.Command) == "" {
+					return fmt.Errorf("empty TesterHooks[%q] %s hook %q Command", name, phase, h.Name)
+				}
+				if h.Timeout < 0 {
+					return fmt.Errorf("invalid TesterHooks[%q] %s hook %q Timeout %v", name, phase, h.Name, h.Timeout)
+				}
+				if h.Timeout == 0 {
+					h.Timeout = DefaultHookTimeout
+				}
+				if h.Image != "" && h.Namespace == "" {
+					h.Namespace = DefaultHookNamespace
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getHooks returns the hooks of the tester for the phase.
+func (cfg *Config) getHooks(name string, phase string) []*Hook {
+	hooks, ok := cfg.TesterHooks[name]
+	if !ok || hooks == nil {
+		return nil
+	}
+	switch phase {
+	case HookPhasePreApply:
+		return hooks.PreApplyHooks
+	case HookPhasePostApply:
+		return hooks.PostApplyHooks
+	}
+	return nil
+}
+
+// runHooks runs the hooks of the tester for the phase in order,
+// and returns the first error of the hooks not ignoring failures.
+func (ts *tester) runHooks(name string, phase string) error {
+	for _, h := range ts.cfg.getHooks(name, phase) {
+		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+		fmt.Fprintf(ts.logWriter, ts.color("[light_green]Hook [cyan]%q [default]%s [cyan]%q [default](%q)\n"), name, phase, h.Name, ts.cfg.ConfigPath)
+		start := time.Now()
+		var err error
+		if h.Image == "" {
+			err = ts.runLocalHook(name, phase, h)
+		} else {
+			err = ts.runJobHook(name, phase, h)
+		}
+		if err == nil {
+			ts.logger.Info("hook succeeded", zap.String("tester", name), zap.String("phase", phase), zap.String("hook", h.Name), zap.Duration("took", time.Since(start)))
+			continue
+		}
+		if h.IgnoreFailure {
+			ts.logger.Warn("hook failed; ignoring", zap.String("tester", name), zap.String("phase", phase), zap.String("hook", h.Name), zap.Error(err))
+			continue
+		}
+		return fmt.Errorf("%s hook %q failed (%v)", phase, h.Name, err)
+	}
+	return nil
+}
+
+// hookEnvs returns the environmental variables of the hook.
+func hookEnvs(name string, phase string) map[string]string {
+	return map[string]string{
+		"K8S_TESTER_HOOK_TESTER": name,
+		"K8S_TESTER_HOOK_PHASE":  phase,
+	}
+}
+
+func (ts *tester) runLocalHook(name string, phase string, h *Hook) error {
+	env := os.Environ()
+	for k, v := range hookEnvs(name, phase) {
+		env = append(env, k+"="+v)
+	}
+	env = append(env, "K8S_TESTER_CONFIG_PATH="+ts.cfg.ConfigPath)
+	if ts.cfg.KubeconfigPath != "" {
+		env = append(env, "KUBECONFIG="+ts.cfg.KubeconfigPath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	cmd := exec.New().CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.SetEnv(env)
+	out, err := cmd.CombinedOutput()
+	cancel()
+	fmt.Fprintf(ts.logWriter, "\n'%s' output:\n\n%s\n\n", h.Command, string(out))
+	return err
+}
+
+// hookJobName returns the name of the in-cluster hook Job,
+// truncated to fit in the maximum length of a DNS-1123 label.
+func hookJobName(name string, phase string, hookName string) string {
+	s := strings.ToLower(strings.Join([]string{"hook", name, phase, hookName}, "-"))
+	s = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, s)
+	if len(s) > maxNamespaceLen {
+		s = s[:maxNamespaceLen]
+	}
+	return strings.TrimRight(s, "-")
+}
+
+// hookJob returns the in-cluster Job of the hook.
+func (ts *tester) hookJob(name string, phase string, h *Hook) *batch_v1.Job {
+	jobName := hookJobName(name, phase, h.Name)
+	backoffLimit := int32(0)
+	activeDeadlineSeconds := int64(h.Timeout.Seconds())
+	envs := hookEnvs(name, phase)
+	env := make([]core_v1.EnvVar, 0, len(envs))
+	for _, k := range sortedEnvKeys(envs) {
+		env = append(env, core_v1.EnvVar{Name: k, Value: envs[k]})
+	}
+	return &batch_v1.Job{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      jobName,
+			Namespace: h.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       jobName,
+				"app.kubernetes.io/managed-by": pkgName,
+			},
+		},
+		Spec: batch_v1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &activeDeadlineSeconds,
+			Template: core_v1.PodTemplateSpec{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Labels: map[string]string{
+						"app.kubernetes.io/name": jobName,
+					},
+				},
+				Spec: core_v1.PodSpec{
+					RestartPolicy: core_v1.RestartPolicyNever,
+					Containers: []core_v1.Container{
+						{
+							Name:            "hook",
+							Image:           h.Image,
+							ImagePullPolicy: core_v1.PullIfNotPresent,
+							Command:         []string{"sh", "-c", h.Command},
+							Env:             env,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func sortedEnvKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (ts *tester) runJobHook(name string, phase string, h *Hook) error {
+	job := ts.hookJob(name, phase, h)
+	cli := ts.cli.KubernetesClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := cli.BatchV1().Jobs(job.Namespace).Create(ctx, job, meta_v1.CreateOptions{})
+	cancel()
+	if k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("hook Job %q already exists; delete the Job left by the previous run", job.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create hook Job %q (%v)", job.Name, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), h.Timeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.logger,
+		ts.logWriter,
+		ts.stopCreationCh,
+		cli,
+		5*time.Second,
+		5*time.Second,
+		job.Namespace,
+		job.Name,
+		1,
+	)
+	cancel()
+	for _, pod := range pods {
+		if pod.Labels["job-name"] != job.Name {
+			continue
+		}
+		logs, lerr := client.CheckPodLogs(ts.logger, ts.logWriter, ts.stopCreationCh, cli, job.Namespace, pod.Name)
+		if lerr != nil {
+			ts.logger.Warn("failed to get hook Pod logs", zap.String("pod", pod.Name), zap.Error(lerr))
+			continue
+		}
+		fmt.Fprintf(ts.logWriter, "\nhook Pod %q logs:\n\n%s\n\n", pod.Name, logs)
+	}
+
+	if derr := client.DeleteJob(ts.logger, cli, job.Namespace, job.Name); derr != nil {
+		ts.logger.Warn("failed to delete hook Job", zap.String("job", job.Name), zap.Error(derr))
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package k8s_tester
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTesterHooks(t *testing.T) {
+	cfg := NewDefault()
+	cfg.TesterHooks = map[string]*TesterHooks{
+		"nlb-guestbook": {
+			PreApplyHooks:  []*Hook{{Command: "echo pre"}},
+			PostApplyHooks: []*Hook{{Name: "check", Command: "echo post", Image: "busybox", Timeout: time.Minute}},
+		},
+	}
+	if err := cfg.validateTesterHooks(); err != nil {
+		t.Fatal(err)
+	}
+	pre := cfg.TesterHooks["nlb-guestbook"].PreApplyHooks[0]
+	if pre.Name != "pre-apply-0" || pre.Timeout != DefaultHookTimeout || pre.Namespace != "" {
+		t.Fatalf("unexpected pre-apply hook defaults %+v", pre)
+	}
+	post := cfg.TesterHooks["nlb-guestbook"].PostApplyHooks[0]
+	if post.Name != "check" || post.Timeout != time.Minute || post.Namespace != DefaultHookNamespace {
+		t.Fatalf("unexpected post-apply hook defaults %+v", post)
+	}
+
+	cfg.TesterHooks["nlb-guestbook"].PreApplyHooks[0].Command = " "
+	if err := cfg.validateTesterHooks(); err == nil {
+		t.Fatal("expected error for empty Command")
+	}
+	cfg.TesterHooks["nlb-guestbook"].PreApplyHooks[0] = &Hook{Command: "echo", Timeout: -time.Second}
+	if err := cfg.validateTesterHooks(); err == nil {
+		t.Fatal("expected error for negative Timeout")
+	}
+}
+
+func TestRunLocalHooks(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	ft := &fakeTester{}
+	ts := newFakeTesterRunner(ft, 0, 0)
+	ts.color = func(s string) string { return s }
+	ts.logWriter = new(bytes.Buffer)
+	ts.cfg.ConfigPath = "test.yaml"
+	ts.cfg.TesterHooks = map[string]*TesterHooks{
+		"fake": {
+			PreApplyHooks: []*Hook{
+				{Command: `echo "$K8S_TESTER_HOOK_TESTER $K8S_TESTER_HOOK_PHASE $K8S_TESTER_CONFIG_PATH" >> ` + out},
+				{Command: "exit 1", IgnoreFailure: true},
+			},
+			PostApplyHooks: []*Hook{
+				{Command: `echo "$K8S_TESTER_HOOK_PHASE" >> ` + out},
+				{Name: "fail", Command: "exit 2"},
+				{Command: "echo unreachable >> " + out},
+			},
+		},
+	}
+	if err = ts.cfg.validateTesterHooks(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ts.runHooks("fake", HookPhasePreApply); err != nil {
+		t.Fatal(err)
+	}
+	err = ts.runHooks("fake", HookPhasePostApply)
+	if err == nil || !strings.Contains(err.Error(), `post-apply hook "fail" failed`) {
+		t.Fatalf("expected post-apply hook failure, got %v", err)
+	}
+	if err = ts.runHooks("other", HookPhasePreApply); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "fake pre-apply test.yaml\npost-apply\n"; string(b) != expected {
+		t.Fatalf("expected %q, got %q", expected, string(b))
+	}
+}
+
+func TestHookJob(t *testing.T) {
+	ts := newFakeTesterRunner(&fakeTester{}, 0, 0)
+	h := &Hook{Name: "Seed_Data", Command: "echo seed", Image: "busybox", Namespace: "default", Timeout: time.Minute}
+	job := ts.hookJob("nlb-guestbook", HookPhasePreApply, h)
+	if job.Name != "hook-nlb-guestbook-pre-apply-seed-data" {
+		t.Fatalf("unexpected Job name %q", job.Name)
+	}
+	if job.Namespace != "default" {
+		t.Fatalf("unexpected Job namespace %q", job.Namespace)
+	}
+	if *job.Spec.ActiveDeadlineSeconds != 60 {
+		t.Fatalf("unexpected Job deadline %d", *job.Spec.ActiveDeadlineSeconds)
+	}
+	c := job.Spec.Template.Spec.Containers[0]
+	if c.Image != "busybox" || strings.Join(c.Command, " ") != "sh -c echo seed" {
+		t.Fatalf("unexpected container %+v", c)
+	}
+	if len(c.Env) != 2 || c.Env[0].Name != "K8S_TESTER_HOOK_PHASE" || c.Env[1].Value != "nlb-guestbook" {
+		t.Fatalf("unexpected env %+v", c.Env)
+	}
+
+	long := hookJobName(strings.Repeat("a", 60), HookPhasePostApply, "x")
+	if len(long) > maxNamespaceLen || strings.HasSuffix(long, "-") {
+		t.Fatalf("unexpected long Job name %q", long)
+	}
+}
@@ -0,0 +1,193 @@
+// k8s-tester-hpa-cloudwatch installs KEDA and tests HPA scaling on a CloudWatch external metric.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	hpa_cloudwatch "github.com/aws/aws-k8s-tester/k8s-tester/hpa-cloudwatch"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-hpa-cloudwatch",
+	Short:      "Kubernetes HPA on CloudWatch external metrics tester",
+	SuggestFor: []string{"hpa-cloudwatch"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	partition          string
+	region             string
+	clusterName        string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", hpa_cloudwatch.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", hpa_cloudwatch.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for CloudWatch metrics")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster-name", "", "cluster name used for the CloudWatch metric dimension")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-hpa-cloudwatch failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	helmChartRepoURL  string
+	metricNamespace   string
+	metricName        string
+	metricValue       float64
+	targetMetricValue float64
+	minReplicas       int32
+	maxReplicas       int32
+	scaleTimeout      string
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&helmChartRepoURL, "helm-chart-repo-url", hpa_cloudwatch.DefaultHelmChartRepoURL, "helm chart repo URL")
+	cmd.PersistentFlags().StringVar(&metricNamespace, "metric-namespace", hpa_cloudwatch.DefaultMetricNamespace, "CloudWatch namespace for the synthetic metric")
+	cmd.PersistentFlags().StringVar(&metricName, "metric-name", hpa_cloudwatch.DefaultMetricName, "CloudWatch metric name for the synthetic metric")
+	cmd.PersistentFlags().Float64Var(&metricValue, "metric-value", hpa_cloudwatch.DefaultMetricValue, "synthetic metric value to publish")
+	cmd.PersistentFlags().Float64Var(&targetMetricValue, "target-metric-value", hpa_cloudwatch.DefaultTargetMetricValue, "per-replica target value for the external metric")
+	cmd.PersistentFlags().Int32Var(&minReplicas, "min-replicas", hpa_cloudwatch.DefaultMinReplicas, "minimum number of replicas")
+	cmd.PersistentFlags().Int32Var(&maxReplicas, "max-replicas", hpa_cloudwatch.DefaultMaxReplicas, "maximum number of replicas")
+	cmd.PersistentFlags().StringVar(&scaleTimeout, "scale-timeout", hpa_cloudwatch.DefaultScaleTimeout.String(), "timeout to wait for scale-out")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	timeout, err := time.ParseDuration(scaleTimeout)
+	if err != nil {
+		lg.Panic("failed to parse scale timeout", zap.String("scale-timeout", scaleTimeout), zap.Error(err))
+	}
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &hpa_cloudwatch.Config{
+		Prompt:            prompt,
+		Logger:            lg,
+		LogWriter:         logWriter,
+		MinimumNodes:      minimumNodes,
+		Namespace:         namespace,
+		Client:            cli,
+		Partition:         partition,
+		Region:            region,
+		HelmChartRepoURL:  helmChartRepoURL,
+		MetricNamespace:   metricNamespace,
+		MetricName:        metricName,
+		MetricValue:       metricValue,
+		TargetMetricValue: targetMetricValue,
+		MinReplicas:       minReplicas,
+		MaxReplicas:       maxReplicas,
+		ScaleTimeout:      timeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := hpa_cloudwatch.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-hpa-cloudwatch apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &hpa_cloudwatch.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+		Partition: partition,
+		Region:    region,
+	}
+
+	ts := hpa_cloudwatch.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-hpa-cloudwatch delete' success\n")
+}
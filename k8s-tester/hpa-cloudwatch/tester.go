@@ -0,0 +1,760 @@
+// Package hpa_cloudwatch installs KEDA with the AWS CloudWatch scaler,
+// publishes a synthetic CloudWatch metric, and validates that the
+// HorizontalPodAutoscaler scales the target Deployment via "external.metrics.k8s.io".
+// ref. https://keda.sh/docs/latest/scalers/aws-cloudwatch
+//
+// The KEDA operator uses its own identity ("identityOwner: operator"),
+// thus the node instance role (or the IRSA role of the operator) must
+// allow "cloudwatch:GetMetricData".
+package hpa_cloudwatch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	apps_v1 "k8s.io/api/apps/v1"
+	autoscaling_v2 "k8s.io/api/autoscaling/v2"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	CWAPI cloudwatchiface.CloudWatchAPI `json:"-"`
+
+	Partition   string `json:"partition"`
+	Region      string `json:"region"`
+	ClusterName string `json:"cluster_name" read-only:"true"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// HelmChartRepoURL is the KEDA helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+
+	// MetricNamespace is the CloudWatch namespace for the synthetic metric.
+	MetricNamespace string `json:"metric_namespace"`
+	// MetricName is the CloudWatch metric name for the synthetic metric.
+	// The metric is published with the "ClusterName" dimension.
+	MetricName string `json:"metric_name"`
+	// MetricValue is the synthetic metric value to publish.
+	MetricValue float64 `json:"metric_value"`
+	// TargetMetricValue is the per-replica target value for the external metric.
+	// The expected number of replicas is "ceil(MetricValue / TargetMetricValue)",
+	// bounded by "MaxReplicas".
+	TargetMetricValue float64 `json:"target_metric_value"`
+
+	// MinReplicas is the minimum number of replicas for the target Deployment.
+	MinReplicas int32 `json:"min_replicas"`
+	// MaxReplicas is the maximum number of replicas for the target Deployment.
+	MaxReplicas int32 `json:"max_replicas"`
+	// ScaleTimeout is the timeout to wait for the target Deployment to scale out.
+	ScaleTimeout       time.Duration `json:"scale_timeout"`
+	ScaleTimeoutString string        `json:"scale_timeout_string" read-only:"true"`
+
+	// ExpectedReplicas is the number of replicas expected from the external metric.
+	ExpectedReplicas int32 `json:"expected_replicas" read-only:"true"`
+	// ObservedReplicas is the number of replicas observed after scaling.
+	ObservedReplicas int32 `json:"observed_replicas" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults(clusterName string) error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.MetricNamespace == "" {
+		cfg.MetricNamespace = DefaultMetricNamespace
+	}
+	if cfg.MetricName == "" {
+		cfg.MetricName = DefaultMetricName
+	}
+	if cfg.MetricValue <= 0 {
+		cfg.MetricValue = DefaultMetricValue
+	}
+	if cfg.TargetMetricValue <= 0 {
+		cfg.TargetMetricValue = DefaultTargetMetricValue
+	}
+	if cfg.MinReplicas == 0 {
+		cfg.MinReplicas = DefaultMinReplicas
+	}
+	if cfg.MaxReplicas == 0 {
+		cfg.MaxReplicas = DefaultMaxReplicas
+	}
+	if cfg.MinReplicas > cfg.MaxReplicas {
+		return fmt.Errorf("MinReplicas %d > MaxReplicas %d", cfg.MinReplicas, cfg.MaxReplicas)
+	}
+	if cfg.ScaleTimeout == time.Duration(0) {
+		cfg.ScaleTimeout = DefaultScaleTimeout
+	}
+	cfg.ScaleTimeoutString = cfg.ScaleTimeout.String()
+
+	cfg.ClusterName = clusterName
+
+	cfg.ExpectedReplicas = int32(math.Ceil(cfg.MetricValue / cfg.TargetMetricValue))
+	if cfg.ExpectedReplicas > cfg.MaxReplicas {
+		cfg.ExpectedReplicas = cfg.MaxReplicas
+	}
+	if cfg.ExpectedReplicas <= cfg.MinReplicas {
+		return fmt.Errorf("expected replicas %d must be greater than MinReplicas %d to observe scale-out (MetricValue %v, TargetMetricValue %v)",
+			cfg.ExpectedReplicas, cfg.MinReplicas, cfg.MetricValue, cfg.TargetMetricValue)
+	}
+
+	return nil
+}
+
+const chartName = "keda"
+
+const (
+	DefaultMinimumNodes      int   = 1
+	DefaultPartition               = "aws"
+	DefaultHelmChartRepoURL        = "https://github.com/kedacore/charts/releases/download/v2.12.1/keda-2.12.1.tgz"
+	DefaultMetricNamespace         = "K8sTester/HPA"
+	DefaultMetricName              = "QueueDepth"
+	DefaultMetricValue             = 100.0
+	DefaultTargetMetricValue       = 25.0
+	DefaultMinReplicas       int32 = 1
+	DefaultMaxReplicas       int32 = 10
+	DefaultScaleTimeout            = 15 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:            false,
+		Prompt:            false,
+		Partition:         DefaultPartition,
+		MinimumNodes:      DefaultMinimumNodes,
+		Namespace:         pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL:  DefaultHelmChartRepoURL,
+		MetricNamespace:   DefaultMetricNamespace,
+		MetricName:        DefaultMetricName,
+		MetricValue:       DefaultMetricValue,
+		TargetMetricValue: DefaultTargetMetricValue,
+		MinReplicas:       DefaultMinReplicas,
+		MaxReplicas:       DefaultMaxReplicas,
+		ScaleTimeout:      DefaultScaleTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.CWAPI = cloudwatch.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config, clusterName string) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	deploymentName   = "hpa-cloudwatch-target"
+	appName          = "hpa-cloudwatch-target"
+	appImageName     = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	scaledObjectName = "hpa-cloudwatch"
+	// KEDA names the HPA after the ScaledObject.
+	hpaName = "keda-hpa-" + scaledObjectName
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createHelmKEDA(); err != nil {
+		return err
+	}
+
+	if err := ts.createDeployment(); err != nil {
+		return err
+	}
+
+	if err := ts.checkDeployment(ts.cfg.MinReplicas); err != nil {
+		return err
+	}
+
+	if err := ts.putMetric(); err != nil {
+		return err
+	}
+
+	if err := ts.applyScaledObject(); err != nil {
+		return err
+	}
+
+	if err := ts.checkHPA(); err != nil {
+		return err
+	}
+
+	if err := ts.checkScaleOut(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := ts.deleteScaledObject(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	ts.cfg.Logger.Info("deleting deployment", zap.String("deployment-name", deploymentName))
+	if err := client.DeleteDeployment(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		deploymentName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Deployment (%v)", err))
+	}
+
+	if err := ts.deleteHelmKEDA(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// https://github.com/kedacore/charts/blob/main/keda/values.yaml
+func (ts *tester) createHelmKEDA() error {
+	values := map[string]interface{}{}
+
+	getAllArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"get",
+		"all",
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        10 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+		Values:         values,
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmKEDA() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+	})
+}
+
+func (ts *tester) createDeployment() error {
+	ts.cfg.Logger.Info("creating HPA target Deployment", zap.Int32("replicas", ts.cfg.MinReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &ts.cfg.MinReplicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": appName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": appName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            appName,
+									Image:           appImageName,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("HPA target Deployment already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create HPA target Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created HPA target Deployment")
+	return nil
+}
+
+func (ts *tester) checkDeployment(replicas int32) error {
+	timeout := 7*time.Minute + time.Duration(replicas)*time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	_, err := client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		time.Minute,
+		20*time.Second,
+		ts.cfg.Namespace,
+		deploymentName,
+		replicas,
+	)
+	cancel()
+	return err
+}
+
+func (ts *tester) putMetric() error {
+	ts.cfg.Logger.Info("publishing synthetic metric",
+		zap.String("metric-namespace", ts.cfg.MetricNamespace),
+		zap.String("metric-name", ts.cfg.MetricName),
+		zap.Float64("metric-value", ts.cfg.MetricValue),
+	)
+	_, err := ts.cfg.CWAPI.PutMetricData(&cloudwatch.PutMetricDataInput{
+		Namespace: aws.String(ts.cfg.MetricNamespace),
+		MetricData: []*cloudwatch.MetricDatum{
+			{
+				MetricName: aws.String(ts.cfg.MetricName),
+				Dimensions: []*cloudwatch.Dimension{
+					{
+						Name:  aws.String("ClusterName"),
+						Value: aws.String(ts.cfg.ClusterName),
+					},
+				},
+				Timestamp: aws.Time(time.Now()),
+				Unit:      aws.String(cloudwatch.StandardUnitCount),
+				Value:     aws.Float64(ts.cfg.MetricValue),
+			},
+		},
+	})
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to publish synthetic metric", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ref. https://keda.sh/docs/latest/scalers/aws-cloudwatch
+const scaledObjectTmpl = `
+apiVersion: keda.sh/v1alpha1
+kind: ScaledObject
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+spec:
+  scaleTargetRef:
+    name: {{ .DeploymentName }}
+  minReplicaCount: {{ .MinReplicas }}
+  maxReplicaCount: {{ .MaxReplicas }}
+  pollingInterval: 30
+  cooldownPeriod: 60
+  triggers:
+  - type: aws-cloudwatch
+    metadata:
+      namespace: {{ .MetricNamespace }}
+      metricName: {{ .MetricName }}
+      dimensionName: ClusterName
+      dimensionValue: {{ .ClusterName }}
+      targetMetricValue: "{{ .TargetMetricValue }}"
+      minMetricValue: "0"
+      metricStat: Maximum
+      metricStatPeriod: "60"
+      metricCollectionTime: "300"
+      awsRegion: {{ .Region }}
+      identityOwner: operator
+`
+
+func (ts *tester) scaledObjectPath() (string, error) {
+	tpl := template.Must(template.New("scaledObjectTmpl").Parse(scaledObjectTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, struct {
+		Name              string
+		Namespace         string
+		DeploymentName    string
+		MinReplicas       int32
+		MaxReplicas       int32
+		MetricNamespace   string
+		MetricName        string
+		ClusterName       string
+		TargetMetricValue float64
+		Region            string
+	}{
+		Name:              scaledObjectName,
+		Namespace:         ts.cfg.Namespace,
+		DeploymentName:    deploymentName,
+		MinReplicas:       ts.cfg.MinReplicas,
+		MaxReplicas:       ts.cfg.MaxReplicas,
+		MetricNamespace:   ts.cfg.MetricNamespace,
+		MetricName:        ts.cfg.MetricName,
+		ClusterName:       ts.cfg.ClusterName,
+		TargetMetricValue: ts.cfg.TargetMetricValue,
+		Region:            ts.cfg.Region,
+	}); err != nil {
+		return "", err
+	}
+	return file.WriteTempFile(buf.Bytes())
+}
+
+func (ts *tester) applyScaledObject() (err error) {
+	fpath, err := ts.scaledObjectPath()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to write ScaledObject YAML", zap.Error(err))
+		return err
+	}
+	ts.cfg.Logger.Info("applying ScaledObject YAML", zap.String("path", fpath))
+
+	applyArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"apply",
+		"--filename=" + fpath,
+	}
+	applyCmd := strings.Join(applyArgs, " ")
+
+	var output []byte
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create ScaledObject aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		output, err = exec.New().CommandContext(ctx, applyArgs[0], applyArgs[1:]...).CombinedOutput()
+		cancel()
+		out := string(output)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", applyCmd, out)
+		if err == nil {
+			break
+		}
+		if strings.Contains(out, " created") || strings.Contains(out, " unchanged") {
+			err = nil
+			break
+		}
+
+		ts.cfg.Logger.Warn("create ScaledObject failed", zap.Error(err))
+	}
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+	}
+
+	ts.cfg.Logger.Info("created ScaledObject")
+	return nil
+}
+
+func (ts *tester) deleteScaledObject() error {
+	ts.cfg.Logger.Info("deleting ScaledObject", zap.String("name", scaledObjectName))
+	deleteArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"delete",
+		"scaledobject.keda.sh",
+		scaledObjectName,
+		"--ignore-not-found=true",
+	}
+	deleteCmd := strings.Join(deleteArgs, " ")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(ctx, deleteArgs[0], deleteArgs[1:]...).CombinedOutput()
+	cancel()
+	out := string(output)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", deleteCmd, out)
+	if err != nil {
+		// CRD may have been removed already
+		if strings.Contains(out, "the server doesn't have a resource type") {
+			return nil
+		}
+		return fmt.Errorf("'kubectl delete' failed %v (output %q)", err, out)
+	}
+
+	ts.cfg.Logger.Info("deleted ScaledObject")
+	return nil
+}
+
+// checkHPA ensures KEDA created a HorizontalPodAutoscaler backed by an external metric.
+func (ts *tester) checkHPA() error {
+	ts.cfg.Logger.Info("checking HPA", zap.String("name", hpaName))
+	var hpa *autoscaling_v2.HorizontalPodAutoscaler
+	var err error
+	retryStart, waitDur := time.Now(), 5*time.Minute
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check HPA aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		hpa, err = ts.cfg.Client.KubernetesClient().
+			AutoscalingV2().
+			HorizontalPodAutoscalers(ts.cfg.Namespace).
+			Get(ctx, hpaName, meta_v1.GetOptions{})
+		cancel()
+		if err == nil {
+			break
+		}
+		ts.cfg.Logger.Warn("failed to get HPA; retrying", zap.Error(err))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get HPA %q (%v)", hpaName, err)
+	}
+
+	external := false
+	for _, m := range hpa.Spec.Metrics {
+		if m.Type == autoscaling_v2.ExternalMetricSourceType {
+			external = true
+			break
+		}
+	}
+	if !external {
+		return fmt.Errorf("HPA %q has no external metric source (%+v)", hpaName, hpa.Spec.Metrics)
+	}
+
+	ts.cfg.Logger.Info("checked HPA with external metric", zap.String("name", hpaName))
+	return nil
+}
+
+// checkScaleOut keeps publishing the synthetic metric until
+// the target Deployment scales to the expected number of replicas.
+func (ts *tester) checkScaleOut() error {
+	ts.cfg.Logger.Info("waiting for scale-out",
+		zap.Int32("expected-replicas", ts.cfg.ExpectedReplicas),
+		zap.Duration("timeout", ts.cfg.ScaleTimeout),
+	)
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.ScaleTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check scale-out aborted")
+		case <-time.After(30 * time.Second):
+		}
+
+		if err := ts.putMetric(); err != nil {
+			ts.cfg.Logger.Warn("failed to publish synthetic metric; retrying", zap.Error(err))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		dp, err := ts.cfg.Client.KubernetesClient().
+			AppsV1().
+			Deployments(ts.cfg.Namespace).
+			Get(ctx, deploymentName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Deployment; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.ObservedReplicas = dp.Status.Replicas
+
+		ts.cfg.Logger.Info("checked Deployment replicas",
+			zap.Int32("desired", *dp.Spec.Replicas),
+			zap.Int32("current", dp.Status.Replicas),
+			zap.Int32("expected", ts.cfg.ExpectedReplicas),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+		if *dp.Spec.Replicas >= ts.cfg.ExpectedReplicas {
+			ts.cfg.Logger.Info("scaled out by external metric")
+			return ts.checkDeployment(*dp.Spec.Replicas)
+		}
+	}
+
+	return fmt.Errorf("Deployment %q did not scale to %d replicas (observed %d) within %v",
+		deploymentName, ts.cfg.ExpectedReplicas, ts.cfg.ObservedReplicas, ts.cfg.ScaleTimeout)
+}
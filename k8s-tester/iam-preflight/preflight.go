@@ -0,0 +1,263 @@
+// Package iam_preflight checks the AWS IAM permissions that the enabled
+// add-ons require, before creating any resources, using the IAM policy
+// simulator against the caller's principal. It also generates a minimal
+// IAM policy document for the selected add-ons, so that users can
+// provision scoped roles to run k8s-tester.
+// ref. https://docs.aws.amazon.com/IAM/latest/APIReference/API_SimulatePrincipalPolicy.html
+package iam_preflight
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+
+	Logger    *zap.Logger `json:"-"`
+	LogWriter io.Writer   `json:"-"`
+
+	IAMAPI iamiface.IAMAPI `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// PolicyPath is the file path to write the generated minimal IAM policy
+	// for the enabled add-ons. Defaults to the config path with ".iam-policy.json" extension.
+	PolicyPath string `json:"policy_path"`
+	// SkipSimulation is true to only generate the IAM policy,
+	// without simulating the permissions of the caller.
+	SkipSimulation bool `json:"skip_simulation"`
+	// FailOnMissing is true to fail "apply" when the caller
+	// is missing any of the required permissions.
+	FailOnMissing bool `json:"fail_on_missing"`
+
+	// PrincipalARN is the IAM principal used for the simulation,
+	// resolved from the caller identity.
+	PrincipalARN string `json:"principal_arn" read-only:"true"`
+	// MissingActions is the list of required actions that were not allowed
+	// for the principal, in the format of "<statement ID>:<action>".
+	MissingActions []string `json:"missing_actions" read-only:"true"`
+}
+
+const DefaultPartition = "aws"
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:        false,
+		Partition:     DefaultPartition,
+		FailOnMissing: true,
+	}
+}
+
+func Env() string {
+	return "IAM_PREFLIGHT"
+}
+
+func (cfg *Config) ValidateAndSetDefaults(configPath string) error {
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.PolicyPath == "" {
+		cfg.PolicyPath = strings.ReplaceAll(configPath, ".yaml", "") + ".iam-policy.json"
+	}
+	return nil
+}
+
+// Statement is an IAM policy statement.
+type Statement struct {
+	Sid      string   `json:"Sid"`
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource string   `json:"Resource"`
+}
+
+// Policy is an IAM policy document.
+type Policy struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+// NewPolicy returns a minimal IAM policy with one statement per add-on,
+// given the required actions keyed by the add-on name.
+func NewPolicy(required map[string][]string) *Policy {
+	names := make([]string, 0, len(required))
+	for name := range required {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	p := &Policy{Version: "2012-10-17", Statement: make([]Statement, 0, len(names))}
+	for _, name := range names {
+		if len(required[name]) == 0 {
+			continue
+		}
+		p.Statement = append(p.Statement, Statement{
+			Sid:      toSid(name),
+			Effect:   "Allow",
+			Action:   dedupe(required[name]),
+			Resource: "*",
+		})
+	}
+	return p
+}
+
+// Run writes the minimal IAM policy for the required actions and,
+// unless skipped, simulates the actions against the caller's principal.
+// It returns an error for missing permissions only if "FailOnMissing" is true.
+func Run(cfg *Config, required map[string][]string) error {
+	p := NewPolicy(required)
+	if len(p.Statement) == 0 {
+		cfg.Logger.Info("no IAM permission required for the enabled add-ons; skipping preflight")
+		return nil
+	}
+
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(cfg.PolicyPath, b, 0600); err != nil {
+		return fmt.Errorf("failed to write IAM policy %q (%v)", cfg.PolicyPath, err)
+	}
+	cfg.Logger.Info("wrote minimal IAM policy", zap.String("path", cfg.PolicyPath), zap.Int("statements", len(p.Statement)))
+	if cfg.LogWriter != nil {
+		fmt.Fprintf(cfg.LogWriter, "\n\n# minimal IAM policy for the enabled add-ons (%q)\n%s\n\n", cfg.PolicyPath, string(b))
+	}
+
+	if cfg.SkipSimulation {
+		cfg.Logger.Info("skipping IAM policy simulation")
+		return nil
+	}
+
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, stsOutput, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	if cfg.IAMAPI == nil {
+		cfg.IAMAPI = iam.New(awsSession)
+	}
+	cfg.PrincipalARN = toPrincipalARN(aws.StringValue(stsOutput.Arn))
+
+	cfg.MissingActions = nil
+	for _, st := range p.Statement {
+		denied, err := simulate(cfg.IAMAPI, cfg.PrincipalARN, st.Action)
+		if err != nil {
+			return fmt.Errorf("failed to simulate IAM policy for %q (%v)", st.Sid, err)
+		}
+		for _, act := range denied {
+			cfg.MissingActions = append(cfg.MissingActions, st.Sid+":"+act)
+		}
+	}
+	if len(cfg.MissingActions) == 0 {
+		cfg.Logger.Info("IAM preflight passed", zap.String("principal", cfg.PrincipalARN))
+		return nil
+	}
+
+	cfg.Logger.Warn("IAM preflight found missing permissions",
+		zap.String("principal", cfg.PrincipalARN),
+		zap.Strings("missing", cfg.MissingActions),
+	)
+	if cfg.FailOnMissing {
+		return fmt.Errorf("principal %q is missing %d IAM permission(s) %v (see %q for the required policy)",
+			cfg.PrincipalARN, len(cfg.MissingActions), cfg.MissingActions, cfg.PolicyPath)
+	}
+	return nil
+}
+
+// Simulate simulates the actions against the IAM principal of the caller ARN
+// (e.g., from "sts:GetCallerIdentity"), and returns the actions not allowed.
+func Simulate(iamAPI iamiface.IAMAPI, callerARN string, actions []string) (denied []string, err error) {
+	return simulate(iamAPI, toPrincipalARN(callerARN), dedupe(actions))
+}
+
+func simulate(iamAPI iamiface.IAMAPI, principal string, actions []string) (denied []string, err error) {
+	err = iamAPI.SimulatePrincipalPolicyPages(
+		&iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(principal),
+			ActionNames:     aws.StringSlice(actions),
+		},
+		func(output *iam.SimulatePolicyResponse, lastPage bool) bool {
+			for _, res := range output.EvaluationResults {
+				if aws.StringValue(res.EvalDecision) != iam.PolicyEvaluationDecisionTypeAllowed {
+					denied = append(denied, aws.StringValue(res.EvalActionName))
+				}
+			}
+			return true
+		},
+	)
+	return denied, err
+}
+
+// toPrincipalARN converts an assumed role session ARN to its IAM role ARN,
+// since the policy simulator does not accept STS ARNs.
+// e.g., "arn:aws:sts::123:assumed-role/my-role/session" to "arn:aws:iam::123:role/my-role".
+// Role paths are not preserved in the session ARN, thus roles with
+// a non-default path are not resolved.
+func toPrincipalARN(arn string) string {
+	ss := strings.Split(arn, ":")
+	if len(ss) != 6 || ss[2] != "sts" {
+		return arn
+	}
+	res := strings.Split(ss[5], "/")
+	if len(res) < 2 || res[0] != "assumed-role" {
+		return arn
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", ss[1], ss[4], res[1])
+}
+
+// toSid converts an add-on name to an alphanumeric statement ID.
+func toSid(name string) string {
+	var sb strings.Builder
+	up := true
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z':
+			if up {
+				r = r - 'a' + 'A'
+			}
+			sb.WriteRune(r)
+			up = false
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+			up = false
+		default:
+			up = true
+		}
+	}
+	return sb.String()
+}
+
+func dedupe(ss []string) []string {
+	seen := make(map[string]struct{}, len(ss))
+	rs := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		rs = append(rs, s)
+	}
+	sort.Strings(rs)
+	return rs
+}
@@ -0,0 +1,247 @@
+package k8s_tester
+
+import (
+	"strings"
+
+	auth_refresh "github.com/aws/aws-k8s-tester/k8s-tester/auth-refresh"
+	cross_cluster "github.com/aws/aws-k8s-tester/k8s-tester/cross-cluster"
+	aws_v1_ecr "github.com/aws/aws-k8s-tester/utils/aws/v1/ecr"
+)
+
+var (
+	ecrActions = []string{
+		"ecr:DescribeRepositories",
+		"ecr:DescribeImages",
+	}
+	elbv2DeleteActions = []string{
+		"elasticloadbalancing:DescribeListeners",
+		"elasticloadbalancing:DescribeRules",
+		"elasticloadbalancing:DescribeTargetGroups",
+		"elasticloadbalancing:DeleteListener",
+		"elasticloadbalancing:DeleteRule",
+		"elasticloadbalancing:DeleteTargetGroup",
+		"elasticloadbalancing:DeleteLoadBalancer",
+	}
+)
+
+// RequiredIAMActions returns the AWS IAM actions that the enabled add-ons
+// call with the tester credentials, keyed by the add-on name.
+// Permissions for the in-cluster components (e.g., IRSA or node instance roles)
+// are not included, since they are not exercised by the tester principal.
+// Update this when an add-on adds a new AWS API call.
+func (cfg *Config) RequiredIAMActions() map[string][]string {
+	required := make(map[string][]string)
+	addECR := func(name string, repos ...*aws_v1_ecr.Repository) {
+		for _, repo := range repos {
+			if repo != nil && !repo.IsEmpty() {
+				required[name] = append(required[name], ecrActions...)
+				return
+			}
+		}
+	}
+
+	if cfg.AddOnPHPApache != nil && cfg.AddOnPHPApache.Enable {
+		addECR("php-apache", cfg.AddOnPHPApache.Repository)
+	}
+	if cfg.AddOnNLBGuestbook != nil && cfg.AddOnNLBGuestbook.Enable {
+		required["nlb-guestbook"] = append(required["nlb-guestbook"], elbv2DeleteActions...)
+	}
+	if cfg.AddOnNLBHelloWorld != nil && cfg.AddOnNLBHelloWorld.Enable {
+		required["nlb-hello-world"] = append(required["nlb-hello-world"], elbv2DeleteActions...)
+		if cfg.AddOnNLBHelloWorld.TLSEnable {
+			required["nlb-hello-world"] = append(required["nlb-hello-world"], "acm:DescribeCertificate")
+			if cfg.AddOnNLBHelloWorld.ACMCertificateARN == "" {
+				required["nlb-hello-world"] = append(required["nlb-hello-world"], "acm:RequestCertificate", "acm:AddTagsToCertificate", "acm:DeleteCertificate")
+			}
+			if cfg.AddOnNLBHelloWorld.TLSDomainName != "" {
+				required["nlb-hello-world"] = append(required["nlb-hello-world"], "route53:ChangeResourceRecordSets")
+			}
+		}
+	}
+	if cfg.AddOnWordpress != nil && cfg.AddOnWordpress.Enable {
+		required["wordpress"] = append(required["wordpress"], elbv2DeleteActions...)
+	}
+	if cfg.AddOnCloudwatchAgent != nil && cfg.AddOnCloudwatchAgent.Enable && cfg.AddOnCloudwatchAgent.CheckMetrics {
+		required["cloudwatch-agent"] = append(required["cloudwatch-agent"], "cloudwatch:GetMetricData")
+	}
+	if cfg.AddOnADOT != nil && cfg.AddOnADOT.Enable {
+		required["adot"] = append(required["adot"], "xray:GetTraceSummaries", "cloudwatch:ListMetrics", "logs:DeleteLogGroup")
+	}
+	if cfg.AddOnFluentBit != nil && cfg.AddOnFluentBit.Enable && cfg.AddOnFluentBit.CloudWatchLogs {
+		required["fluent-bit"] = append(required["fluent-bit"], "logs:FilterLogEvents", "logs:DeleteLogGroup")
+	}
+	if cfg.AddOnPrometheusGrafana != nil && cfg.AddOnPrometheusGrafana.Enable && cfg.AddOnPrometheusGrafana.GrafanaNLB {
+		required["prometheus-grafana"] = append(required["prometheus-grafana"], elbv2DeleteActions...)
+	}
+	if cfg.AddOnJobsEcho != nil && cfg.AddOnJobsEcho.Enable {
+		addECR("jobs-echo", cfg.AddOnJobsEcho.Repository)
+	}
+	if cfg.AddOnCronJobsEcho != nil && cfg.AddOnCronJobsEcho.Enable {
+		addECR("cron-jobs-echo", cfg.AddOnCronJobsEcho.Repository)
+	}
+	if cfg.AddOnStress != nil && cfg.AddOnStress.Enable && cfg.AddOnStress.ECRBusyboxImage == "" {
+		addECR("stress", cfg.AddOnStress.Repository)
+	}
+	if cfg.AddOnStressInCluster != nil && cfg.AddOnStressInCluster.Enable {
+		var busybox *aws_v1_ecr.Repository
+		if cfg.AddOnStressInCluster.K8sTesterStressCLI != nil {
+			busybox = cfg.AddOnStressInCluster.K8sTesterStressCLI.BusyboxRepository
+		}
+		addECR("stress-in-cluster", cfg.AddOnStressInCluster.K8sTesterStressRepository, busybox)
+	}
+	if cfg.AddOnHPACloudWatch != nil && cfg.AddOnHPACloudWatch.Enable {
+		required["hpa-cloudwatch"] = []string{
+			"cloudwatch:PutMetricData",
+		}
+	}
+	if cfg.AddOnKEDASQS != nil && cfg.AddOnKEDASQS.Enable {
+		required["keda-sqs"] = []string{
+			"sqs:CreateQueue",
+			"sqs:GetQueueUrl",
+			"sqs:SendMessage",
+			"sqs:ReceiveMessage",
+			"sqs:DeleteMessage",
+			"sqs:DeleteQueue",
+		}
+	}
+	if cfg.AddOnCSIS3 != nil && cfg.AddOnCSIS3.Enable {
+		required["csi-s3"] = []string{
+			"s3:CreateBucket",
+			"s3:PutBucketTagging",
+			"s3:PutLifecycleConfiguration",
+			"s3:ListBucket",
+			"s3:GetObject",
+			"s3:PutObject",
+			"s3:DeleteObject",
+			"s3:DeleteBucket",
+		}
+	}
+	if cfg.AddOnEMROnEKS != nil && cfg.AddOnEMROnEKS.Enable {
+		required["emr-on-eks"] = []string{
+			"emr-containers:CreateVirtualCluster",
+			"emr-containers:StartJobRun",
+			"emr-containers:DescribeJobRun",
+			"emr-containers:CancelJobRun",
+			"emr-containers:DeleteVirtualCluster",
+			"iam:PassRole",
+		}
+	}
+	if cfg.AddOnKarpenter != nil && cfg.AddOnKarpenter.Enable {
+		required["karpenter"] = []string{
+			"ec2:DescribeInstances",
+			"ec2:TerminateInstances",
+		}
+	}
+	if cfg.AddOnNodeTagLabels != nil && cfg.AddOnNodeTagLabels.Enable {
+		required["node-tag-labels"] = []string{
+			"ec2:CreateTags",
+			"ec2:DeleteTags",
+		}
+	}
+	if cfg.AddOnStaticPod != nil && cfg.AddOnStaticPod.Enable {
+		required["static-pod"] = []string{
+			"ssm:SendCommand",
+			"ssm:GetCommandInvocation",
+		}
+	}
+	if cfg.AddOnClusterPause != nil && cfg.AddOnClusterPause.Enable {
+		required["cluster-pause"] = []string{
+			"eks:ListNodegroups",
+			"eks:DescribeNodegroup",
+			"eks:UpdateNodegroupConfig",
+			"autoscaling:DescribeAutoScalingGroups",
+			"autoscaling:UpdateAutoScalingGroup",
+		}
+	}
+	if cfg.AddOnCrossCluster != nil && cfg.AddOnCrossCluster.Enable && cfg.AddOnCrossCluster.Mechanism == cross_cluster.MechanismCloudMap {
+		required["cross-cluster"] = []string{
+			"servicediscovery:GetNamespace",
+			"servicediscovery:CreateService",
+			"servicediscovery:ListServices",
+			"servicediscovery:DeleteService",
+			"servicediscovery:RegisterInstance",
+			"servicediscovery:DeregisterInstance",
+			"servicediscovery:ListInstances",
+			"servicediscovery:GetOperation",
+			"route53:ChangeResourceRecordSets",
+			"route53:GetHostedZone",
+		}
+	}
+	if cfg.AddOnAuthRefresh != nil && cfg.AddOnAuthRefresh.Enable {
+		for _, mode := range cfg.AddOnAuthRefresh.Modes {
+			if mode == auth_refresh.ModeEKS {
+				required["auth-refresh"] = []string{
+					"eks:DescribeCluster",
+				}
+			}
+		}
+	}
+	if cfg.AddOnConformance != nil && cfg.AddOnConformance.Enable && strings.HasPrefix(cfg.AddOnConformance.BaselineJUnitXMLPath, "s3://") {
+		required["conformance"] = []string{
+			"s3:GetObject",
+		}
+	}
+	if cfg.AddOnPVReclaim != nil && cfg.AddOnPVReclaim.Enable {
+		required["pv-reclaim"] = []string{
+			"ec2:DescribeVolumes",
+			"ec2:DeleteVolume",
+		}
+	}
+	if cfg.AddOnIRSA != nil && cfg.AddOnIRSA.Enable {
+		required["irsa"] = []string{
+			"eks:DescribeCluster",
+			"iam:GetOpenIDConnectProvider",
+			"iam:CreateOpenIDConnectProvider",
+			"iam:DeleteOpenIDConnectProvider",
+			"iam:CreateRole",
+			"iam:GetRole",
+			"iam:TagRole",
+			"iam:DeleteRole",
+		}
+		if cfg.AddOnIRSA.S3BucketName != "" {
+			required["irsa"] = append(required["irsa"], "iam:PutRolePolicy", "iam:DeleteRolePolicy")
+		}
+	}
+	if cfg.AddOnFargate != nil && cfg.AddOnFargate.Enable {
+		required["fargate"] = []string{
+			"eks:DescribeCluster",
+			"eks:CreateFargateProfile",
+			"eks:DescribeFargateProfile",
+			"eks:DeleteFargateProfile",
+			"eks:TagResource",
+		}
+		if cfg.AddOnFargate.RoleARN == "" {
+			required["fargate"] = append(required["fargate"],
+				"iam:CreateRole",
+				"iam:GetRole",
+				"iam:TagRole",
+				"iam:AttachRolePolicy",
+				"iam:DetachRolePolicy",
+				"iam:DeleteRole",
+			)
+			if cfg.AddOnFargate.LoggingEnable {
+				required["fargate"] = append(required["fargate"], "iam:PutRolePolicy", "iam:DeleteRolePolicy")
+			}
+		}
+		// to create the Fargate profile with the Pod execution role
+		required["fargate"] = append(required["fargate"], "iam:PassRole")
+	}
+	if cfg.CloudWatchMetrics != nil && cfg.CloudWatchMetrics.Enable {
+		required["cloudwatch-metrics"] = []string{
+			"cloudwatch:PutMetricData",
+		}
+	}
+	if cfg.ArtifactsS3 != nil && cfg.ArtifactsS3.Enable {
+		required["artifacts-s3"] = []string{
+			"s3:PutObject",
+		}
+		if cfg.ArtifactsS3.BucketCreate {
+			required["artifacts-s3"] = append(required["artifacts-s3"], "s3:CreateBucket", "s3:PutBucketTagging", "s3:PutLifecycleConfiguration")
+		}
+		if cfg.ArtifactsS3.KMSKeyID != "" {
+			required["artifacts-s3"] = append(required["artifacts-s3"], "kms:GenerateDataKey")
+		}
+	}
+
+	return required
+}
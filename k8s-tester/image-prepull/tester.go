@@ -0,0 +1,598 @@
+// Package image_prepull deploys a DaemonSet that prepulls a list of images on every node,
+// measures the per-node warm-up time, and verifies the Pod start latency improvement
+// over a cold start where the image has to be pulled.
+package image_prepull
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/latency"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Images is the list of images to prepull on every node.
+	// Each image must ship a "sh" shell.
+	// The first image is used to measure the Pod start latency.
+	Images []string `json:"images"`
+	// PrepullTimeout is the timeout to wait for all nodes to prepull the images.
+	PrepullTimeout       time.Duration `json:"prepull_timeout"`
+	PrepullTimeoutString string        `json:"prepull_timeout_string" read-only:"true"`
+
+	// ColdStartNodeName is the node where the cold start Pod ran before prepulling.
+	ColdStartNodeName string `json:"cold_start_node_name" read-only:"true"`
+	// ColdStartImageCached is true if the image was already present on the cold start node,
+	// in which case the cold start latency is not compared.
+	ColdStartImageCached bool `json:"cold_start_image_cached" read-only:"true"`
+	// ColdStartLatency is the start latency of the Pod before prepulling.
+	ColdStartLatency       time.Duration `json:"cold_start_latency" read-only:"true"`
+	ColdStartLatencyString string        `json:"cold_start_latency_string" read-only:"true"`
+
+	// NodeWarmUpLatencies is the time each node took to prepull all images, keyed by node name.
+	NodeWarmUpLatencies map[string]time.Duration `json:"node_warm_up_latencies" read-only:"true"`
+	// WarmUpLatencySummary is the latency summary of the node warm-ups.
+	WarmUpLatencySummary latency.Summary `json:"warm_up_latency_summary" read-only:"true"`
+	// WarmStartLatencySummary is the latency summary of the Pod starts on every node after prepulling.
+	WarmStartLatencySummary latency.Summary `json:"warm_start_latency_summary" read-only:"true"`
+
+	// StartLatencyImprovement is the cold start latency minus the p50 warm start latency.
+	StartLatencyImprovement       time.Duration `json:"start_latency_improvement" read-only:"true"`
+	StartLatencyImprovementString string        `json:"start_latency_improvement_string" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if len(cfg.Images) == 0 {
+		cfg.Images = []string{DefaultImage}
+	}
+	if cfg.PrepullTimeout == time.Duration(0) {
+		cfg.PrepullTimeout = DefaultPrepullTimeout
+	}
+	cfg.PrepullTimeoutString = cfg.PrepullTimeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes int = 1
+	// DefaultImage is large enough for the image pull to dominate the Pod start latency.
+	DefaultImage          = "public.ecr.aws/docker/library/python:3.12"
+	DefaultPrepullTimeout = 15 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:         false,
+		Prompt:         false,
+		MinimumNodes:   DefaultMinimumNodes,
+		Namespace:      pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Images:         []string{DefaultImage},
+		PrepullTimeout: DefaultPrepullTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	daemonSetName  = "image-prepull"
+	daemonSetLabel = "image-prepull"
+	startPodLabel  = "image-prepull-start"
+	pauseImageName = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if ts.cfg.MinimumNodes > 0 {
+		if len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.checkColdStart(nodes[0].Name); err != nil {
+		return err
+	}
+
+	if err := ts.createDaemonSet(); err != nil {
+		return err
+	}
+
+	if err := ts.checkWarmUp(); err != nil {
+		return err
+	}
+
+	if err := ts.checkWarmStart(); err != nil {
+		return err
+	}
+
+	if ts.cfg.ColdStartImageCached {
+		ts.cfg.Logger.Warn("image was already present on the cold start node; skipping start latency comparison",
+			zap.String("node-name", ts.cfg.ColdStartNodeName),
+		)
+		return nil
+	}
+	ts.cfg.StartLatencyImprovement = ts.cfg.ColdStartLatency - ts.cfg.WarmStartLatencySummary.P50
+	ts.cfg.StartLatencyImprovementString = ts.cfg.StartLatencyImprovement.String()
+	ts.cfg.Logger.Info("compared Pod start latency",
+		zap.String("cold-start", ts.cfg.ColdStartLatencyString),
+		zap.String("warm-start-p50", ts.cfg.WarmStartLatencySummary.P50.String()),
+		zap.String("improvement", ts.cfg.StartLatencyImprovementString),
+	)
+	if ts.cfg.StartLatencyImprovement <= 0 {
+		return fmt.Errorf("prepulling did not improve Pod start latency (cold %v, warm p50 %v)", ts.cfg.ColdStartLatency, ts.cfg.WarmStartLatencySummary.P50)
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteDaemonSet(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		daemonSetName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete DaemonSet (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// tolerateAll lets the test Pods run on every node, including tainted ones.
+var tolerateAll = []core_v1.Toleration{
+	{Operator: core_v1.TolerationOpExists},
+}
+
+func (ts *tester) createDaemonSet() error {
+	initContainers := make([]core_v1.Container, 0, len(ts.cfg.Images))
+	for i, img := range ts.cfg.Images {
+		initContainers = append(initContainers, core_v1.Container{
+			Name:            fmt.Sprintf("prepull-%d", i),
+			Image:           img,
+			ImagePullPolicy: core_v1.PullIfNotPresent,
+			Command:         []string{"sh", "-c", "exit 0"},
+		})
+	}
+
+	ts.cfg.Logger.Info("creating prepull DaemonSet", zap.Strings("images", ts.cfg.Images))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      daemonSetName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": daemonSetLabel,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": daemonSetLabel,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy:  core_v1.RestartPolicyAlways,
+							Tolerations:    tolerateAll,
+							InitContainers: initContainers,
+							// keeps the Pod around once all images are pulled,
+							// so the images are not garbage collected
+							Containers: []core_v1.Container{
+								{
+									Name:            "pause",
+									Image:           pauseImageName,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create prepull DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created prepull DaemonSet")
+	return nil
+}
+
+// checkWarmUp waits until the prepull Pods are ready on all scheduled nodes,
+// and records the time each node took to pull all images.
+func (ts *tester) checkWarmUp() error {
+	ts.cfg.Logger.Info("waiting for nodes to prepull images", zap.String("timeout", ts.cfg.PrepullTimeoutString))
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.PrepullTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check warm-up aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		ds, err := ts.cfg.Client.KubernetesClient().
+			AppsV1().
+			DaemonSets(ts.cfg.Namespace).
+			Get(ctx, daemonSetName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get prepull DaemonSet; retrying", zap.Error(err))
+			continue
+		}
+		pods, err := ts.listPods(daemonSetLabel)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list prepull Pods; retrying", zap.Error(err))
+			continue
+		}
+
+		warmUps := make(map[string]time.Duration)
+		for _, pod := range pods {
+			for _, cond := range pod.Status.Conditions {
+				if cond.Type == core_v1.PodReady && cond.Status == core_v1.ConditionTrue {
+					warmUps[pod.Spec.NodeName] = cond.LastTransitionTime.Sub(pod.CreationTimestamp.Time)
+				}
+			}
+		}
+		ts.cfg.Logger.Info("checked prepull Pods",
+			zap.Int32("desired", ds.Status.DesiredNumberScheduled),
+			zap.Int("warmed-up", len(warmUps)),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+		if ds.Status.DesiredNumberScheduled == 0 || len(warmUps) < int(ds.Status.DesiredNumberScheduled) {
+			continue
+		}
+
+		ts.cfg.NodeWarmUpLatencies = warmUps
+		durs := make(latency.Durations, 0, len(warmUps))
+		for _, d := range warmUps {
+			durs = append(durs, d)
+		}
+		ts.cfg.WarmUpLatencySummary = summarize(durs)
+		ts.cfg.Logger.Info("nodes warmed up",
+			zap.Int("nodes", len(warmUps)),
+			zap.String("p50", ts.cfg.WarmUpLatencySummary.P50.String()),
+			zap.String("p99", ts.cfg.WarmUpLatencySummary.P99.String()),
+		)
+		return nil
+	}
+	return fmt.Errorf("nodes did not prepull images within %v", ts.cfg.PrepullTimeout)
+}
+
+// checkColdStart runs a Pod with the first image on the node before prepulling,
+// and records its start latency.
+func (ts *tester) checkColdStart(nodeName string) error {
+	ts.cfg.ColdStartNodeName = nodeName
+	if err := ts.createStartPod("cold-start", nodeName); err != nil {
+		return err
+	}
+	durs, err := ts.waitForStartPods(1)
+	if err != nil {
+		return fmt.Errorf("cold start Pod did not start (%v)", err)
+	}
+	ts.cfg.ColdStartLatency = durs[0]
+	ts.cfg.ColdStartLatencyString = ts.cfg.ColdStartLatency.String()
+
+	ts.cfg.ColdStartImageCached, err = ts.imageAlreadyPresent("cold-start")
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to check cold start Pod events", zap.Error(err))
+	}
+	ts.cfg.Logger.Info("cold start Pod started",
+		zap.String("node-name", nodeName),
+		zap.String("latency", ts.cfg.ColdStartLatencyString),
+		zap.Bool("image-cached", ts.cfg.ColdStartImageCached),
+	)
+	return ts.deleteStartPods()
+}
+
+// checkWarmStart runs a Pod with the first image on every node after prepulling,
+// and records the start latency summary.
+func (ts *tester) checkWarmStart() error {
+	nodeNames := make([]string, 0, len(ts.cfg.NodeWarmUpLatencies))
+	for name := range ts.cfg.NodeWarmUpLatencies {
+		nodeNames = append(nodeNames, name)
+	}
+	sort.Strings(nodeNames)
+	for i, name := range nodeNames {
+		if err := ts.createStartPod(fmt.Sprintf("warm-start-%d", i), name); err != nil {
+			return err
+		}
+	}
+	durs, err := ts.waitForStartPods(len(nodeNames))
+	if err != nil {
+		return fmt.Errorf("warm start Pods did not start (%v)", err)
+	}
+	ts.cfg.WarmStartLatencySummary = summarize(durs)
+	ts.cfg.Logger.Info("warm start Pods started",
+		zap.Int("pods", len(durs)),
+		zap.String("p50", ts.cfg.WarmStartLatencySummary.P50.String()),
+		zap.String("p99", ts.cfg.WarmStartLatencySummary.P99.String()),
+	)
+	return ts.deleteStartPods()
+}
+
+func (ts *tester) createStartPod(name string, nodeName string) error {
+	ts.cfg.Logger.Info("creating start Pod", zap.String("name", name), zap.String("node-name", nodeName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Pod{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": startPodLabel,
+					},
+				},
+				Spec: core_v1.PodSpec{
+					RestartPolicy: core_v1.RestartPolicyNever,
+					// bypasses the scheduler to pin the Pod to the node
+					NodeName:    nodeName,
+					Tolerations: tolerateAll,
+					Containers: []core_v1.Container{
+						{
+							Name:            "start",
+							Image:           ts.cfg.Images[0],
+							ImagePullPolicy: core_v1.PullIfNotPresent,
+							Command:         []string{"sh", "-c", "sleep 3600"},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create start Pod %q (%v)", name, err)
+	}
+	return nil
+}
+
+// waitForStartPods waits until "n" start Pods are running,
+// and returns the latency from each Pod creation to its container start.
+func (ts *tester) waitForStartPods(n int) (latency.Durations, error) {
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.PrepullTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, errors.New("wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		pods, err := ts.listPods(startPodLabel)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list start Pods; retrying", zap.Error(err))
+			continue
+		}
+		durs := make(latency.Durations, 0, n)
+		for _, pod := range pods {
+			if pod.Status.Phase == core_v1.PodFailed {
+				return nil, fmt.Errorf("start Pod %q failed (%s)", pod.Name, pod.Status.Message)
+			}
+			for _, st := range pod.Status.ContainerStatuses {
+				if st.State.Running != nil {
+					durs = append(durs, st.State.Running.StartedAt.Sub(pod.CreationTimestamp.Time))
+				}
+			}
+		}
+		ts.cfg.Logger.Info("checked start Pods",
+			zap.Int("running", len(durs)),
+			zap.Int("target", n),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+		if len(durs) >= n {
+			return durs, nil
+		}
+	}
+	return nil, errors.New("timed out")
+}
+
+func (ts *tester) deleteStartPods() error {
+	ts.cfg.Logger.Info("deleting start Pods")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		DeleteCollection(
+			ctx,
+			meta_v1.DeleteOptions{GracePeriodSeconds: int64Ref(0)},
+			meta_v1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + startPodLabel},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to delete start Pods (%v)", err)
+	}
+	return nil
+}
+
+// imageAlreadyPresent returns true if the kubelet reported the Pod image
+// was already present on the node, instead of pulling it.
+func (ts *tester) imageAlreadyPresent(podName string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	evs, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Events(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{FieldSelector: "involvedObject.name=" + podName})
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	for _, ev := range evs.Items {
+		if ev.Reason == "Pulled" && strings.Contains(ev.Message, "already present on machine") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (ts *tester) listPods(label string) ([]core_v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + label})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func summarize(durs latency.Durations) latency.Summary {
+	sort.Sort(durs)
+	return latency.Summary{
+		TestID: pkgName,
+		P50:    durs.PickP50(),
+		P90:    durs.PickP90(),
+		P99:    durs.PickP99(),
+	}
+}
+
+func int64Ref(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,187 @@
+// k8s-tester-irsa validates IAM Roles for Service Accounts (IRSA).
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/irsa"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-irsa",
+	Short:      "Kubernetes IAM Roles for Service Accounts (IRSA) tester",
+	SuggestFor: []string{"irsa"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	partition          string
+	region             string
+	clusterName        string
+	roleName           string
+	s3BucketName       string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", irsa.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", irsa.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for EKS cluster")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster-name", "", "EKS cluster name")
+	rootCmd.PersistentFlags().StringVar(&roleName, "role-name", "", "IAM role name for the ServiceAccount (default to namespace)")
+	rootCmd.PersistentFlags().StringVar(&s3BucketName, "s3-bucket-name", "", "existing S3 bucket to write and read a test object, empty to only call STS")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-irsa failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	image   string
+	timeout time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&image, "image", irsa.DefaultImage, "test Pod image with the AWS CLI")
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", irsa.DefaultTimeout, "timeout to wait for the test Pod to complete")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &irsa.Config{
+		Prompt:       prompt,
+		Logger:       lg,
+		LogWriter:    logWriter,
+		MinimumNodes: minimumNodes,
+		Namespace:    namespace,
+		Client:       cli,
+		Partition:    partition,
+		Region:       region,
+		ClusterName:  clusterName,
+		Image:        image,
+		RoleName:     roleName,
+		S3BucketName: s3BucketName,
+		Timeout:      timeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := irsa.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-irsa apply' success\n")
+}
+
+var oidcProviderARN string
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	cmd.PersistentFlags().StringVar(&oidcProviderARN, "oidc-provider-arn", "", "IAM OIDC provider ARN to delete, only if created by 'apply'")
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &irsa.Config{
+		Prompt:              prompt,
+		Logger:              lg,
+		LogWriter:           logWriter,
+		Namespace:           namespace,
+		Client:              cli,
+		Partition:           partition,
+		Region:              region,
+		RoleName:            roleName,
+		S3BucketName:        s3BucketName,
+		OIDCProviderARN:     oidcProviderARN,
+		OIDCProviderCreated: oidcProviderARN != "",
+	}
+	if cfg.RoleName == "" {
+		cfg.RoleName = namespace + "-role"
+	}
+
+	ts := irsa.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-irsa delete' success\n")
+}
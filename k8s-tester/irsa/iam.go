@@ -0,0 +1,267 @@
+package irsa
+
+import (
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"go.uber.org/zap"
+)
+
+// stsAudience is the audience of the projected ServiceAccount token
+// injected by the pod identity webhook.
+const stsAudience = "sts.amazonaws.com"
+
+// createOIDCProvider creates the IAM OIDC provider for the cluster issuer,
+// unless the provider already exists (e.g., created by "eksctl utils associate-iam-oidc-provider").
+func (ts *tester) createOIDCProvider() error {
+	out, err := ts.cfg.EKSAPI.DescribeCluster(&eks.DescribeClusterInput{
+		Name: aws.String(ts.cfg.ClusterName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe cluster %q (%v)", ts.cfg.ClusterName, err)
+	}
+	if out.Cluster.Identity == nil || out.Cluster.Identity.Oidc == nil || aws.StringValue(out.Cluster.Identity.Oidc.Issuer) == "" {
+		return fmt.Errorf("cluster %q has no OIDC issuer", ts.cfg.ClusterName)
+	}
+	ts.cfg.OIDCIssuerURL = aws.StringValue(out.Cluster.Identity.Oidc.Issuer)
+	hostPath, err := issuerHostPath(ts.cfg.OIDCIssuerURL)
+	if err != nil {
+		return err
+	}
+	ts.cfg.OIDCProviderARN = fmt.Sprintf("arn:%s:iam::%s:oidc-provider/%s", ts.cfg.Partition, ts.cfg.AccountID, hostPath)
+
+	_, err = ts.cfg.IAMAPI.GetOpenIDConnectProvider(&iam.GetOpenIDConnectProviderInput{
+		OpenIDConnectProviderArn: aws.String(ts.cfg.OIDCProviderARN),
+	})
+	if err == nil {
+		ts.cfg.Logger.Info("IAM OIDC provider already exists", zap.String("provider-arn", ts.cfg.OIDCProviderARN))
+		return nil
+	}
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != iam.ErrCodeNoSuchEntityException {
+		return fmt.Errorf("failed to get IAM OIDC provider %q (%v)", ts.cfg.OIDCProviderARN, err)
+	}
+
+	thumbprint, err := fetchThumbprint(ts.cfg.OIDCIssuerURL)
+	if err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("creating IAM OIDC provider",
+		zap.String("issuer-url", ts.cfg.OIDCIssuerURL),
+		zap.String("thumbprint", thumbprint),
+	)
+	cout, err := ts.cfg.IAMAPI.CreateOpenIDConnectProvider(&iam.CreateOpenIDConnectProviderInput{
+		Url:            aws.String(ts.cfg.OIDCIssuerURL),
+		ClientIDList:   aws.StringSlice([]string{stsAudience}),
+		ThumbprintList: aws.StringSlice([]string{thumbprint}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create IAM OIDC provider (%v)", err)
+	}
+	ts.cfg.OIDCProviderARN = aws.StringValue(cout.OpenIDConnectProviderArn)
+	ts.cfg.OIDCProviderCreated = true
+	ts.cfg.Logger.Info("created IAM OIDC provider", zap.String("provider-arn", ts.cfg.OIDCProviderARN))
+	return nil
+}
+
+func (ts *tester) deleteOIDCProvider() error {
+	if !ts.cfg.OIDCProviderCreated || ts.cfg.OIDCProviderARN == "" {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting IAM OIDC provider", zap.String("provider-arn", ts.cfg.OIDCProviderARN))
+	_, err := ts.cfg.IAMAPI.DeleteOpenIDConnectProvider(&iam.DeleteOpenIDConnectProviderInput{
+		OpenIDConnectProviderArn: aws.String(ts.cfg.OIDCProviderARN),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != iam.ErrCodeNoSuchEntityException {
+			return fmt.Errorf("failed to delete IAM OIDC provider %q (%v)", ts.cfg.OIDCProviderARN, err)
+		}
+	}
+	ts.cfg.OIDCProviderCreated = false
+	ts.cfg.Logger.Info("deleted IAM OIDC provider")
+	return nil
+}
+
+// issuerHostPath returns the issuer URL without the scheme,
+// e.g., "oidc.eks.us-west-2.amazonaws.com/id/EXAMPLED539D4633E53DE1B716D3041E".
+func issuerHostPath(issuerURL string) (string, error) {
+	u, err := url.Parse(issuerURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse OIDC issuer URL %q (%v)", issuerURL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("invalid OIDC issuer URL %q", issuerURL)
+	}
+	return u.Host + strings.TrimSuffix(u.Path, "/"), nil
+}
+
+// fetchThumbprint returns the SHA-1 thumbprint of the top intermediate
+// or root CA certificate of the issuer.
+// ref. https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_providers_create_oidc_verify-thumbprint.html
+func fetchThumbprint(issuerURL string) (string, error) {
+	u, err := url.Parse(issuerURL)
+	if err != nil {
+		return "", err
+	}
+	var conn *tls.Conn
+	for i := 0; i < 5; i++ {
+		conn, err = tls.Dial("tcp", u.Host+":443", &tls.Config{ServerName: u.Hostname()})
+		if err == nil {
+			break
+		}
+		time.Sleep(5 * time.Second)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to OIDC issuer %q (%v)", issuerURL, err)
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", fmt.Errorf("OIDC issuer %q returned no certificate", issuerURL)
+	}
+	return fmt.Sprintf("%x", sha1.Sum(certs[len(certs)-1].Raw)), nil
+}
+
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+type policyStatement struct {
+	Effect    string                       `json:"Effect"`
+	Principal map[string]string            `json:"Principal,omitempty"`
+	Action    []string                     `json:"Action"`
+	Resource  []string                     `json:"Resource,omitempty"`
+	Condition map[string]map[string]string `json:"Condition,omitempty"`
+}
+
+// trustPolicy returns the role trust policy that only allows the test ServiceAccount.
+func trustPolicy(providerARN string, hostPath string, namespace string) (string, error) {
+	doc := policyDocument{
+		Version: "2012-10-17",
+		Statement: []policyStatement{
+			{
+				Effect:    "Allow",
+				Principal: map[string]string{"Federated": providerARN},
+				Action:    []string{"sts:AssumeRoleWithWebIdentity"},
+				Condition: map[string]map[string]string{
+					"StringEquals": {
+						hostPath + ":sub": "system:serviceaccount:" + namespace + ":" + serviceAccountName,
+						hostPath + ":aud": stsAudience,
+					},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(doc)
+	return string(b), err
+}
+
+// s3Policy returns the role policy for the S3 test object.
+func s3Policy(partition string, bucket string, key string) (string, error) {
+	doc := policyDocument{
+		Version: "2012-10-17",
+		Statement: []policyStatement{
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:PutObject", "s3:GetObject", "s3:DeleteObject"},
+				Resource: []string{fmt.Sprintf("arn:%s:s3:::%s/%s", partition, bucket, key)},
+			},
+		},
+	}
+	b, err := json.Marshal(doc)
+	return string(b), err
+}
+
+const rolePolicyName = "irsa-s3"
+
+func (ts *tester) createRole() error {
+	hostPath, err := issuerHostPath(ts.cfg.OIDCIssuerURL)
+	if err != nil {
+		return err
+	}
+	trust, err := trustPolicy(ts.cfg.OIDCProviderARN, hostPath, ts.cfg.Namespace)
+	if err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("creating IRSA role", zap.String("role-name", ts.cfg.RoleName))
+	out, err := ts.cfg.IAMAPI.CreateRole(&iam.CreateRoleInput{
+		RoleName:                 aws.String(ts.cfg.RoleName),
+		AssumeRolePolicyDocument: aws.String(trust),
+		Description:              aws.String("aws-k8s-tester " + pkgName + " " + ts.cfg.Namespace),
+		Tags: []*iam.Tag{
+			{Key: aws.String("Kind"), Value: aws.String("aws-k8s-tester")},
+			{Key: aws.String("Namespace"), Value: aws.String(ts.cfg.Namespace)},
+		},
+	})
+	if err != nil {
+		aerr, ok := err.(awserr.Error)
+		if !ok || aerr.Code() != iam.ErrCodeEntityAlreadyExistsException {
+			return fmt.Errorf("failed to create IRSA role (%v)", err)
+		}
+		gout, gerr := ts.cfg.IAMAPI.GetRole(&iam.GetRoleInput{RoleName: aws.String(ts.cfg.RoleName)})
+		if gerr != nil {
+			return fmt.Errorf("failed to get IRSA role (%v)", gerr)
+		}
+		ts.cfg.RoleARN = aws.StringValue(gout.Role.Arn)
+		ts.cfg.Logger.Info("IRSA role already exists", zap.String("role-arn", ts.cfg.RoleARN))
+	} else {
+		ts.cfg.RoleARN = aws.StringValue(out.Role.Arn)
+		ts.cfg.Logger.Info("created IRSA role", zap.String("role-arn", ts.cfg.RoleARN))
+	}
+
+	if ts.cfg.S3BucketName == "" {
+		return nil
+	}
+	policy, err := s3Policy(ts.cfg.Partition, ts.cfg.S3BucketName, ts.cfg.S3Key)
+	if err != nil {
+		return err
+	}
+	_, err = ts.cfg.IAMAPI.PutRolePolicy(&iam.PutRolePolicyInput{
+		RoleName:       aws.String(ts.cfg.RoleName),
+		PolicyName:     aws.String(rolePolicyName),
+		PolicyDocument: aws.String(policy),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put IRSA role policy (%v)", err)
+	}
+	ts.cfg.Logger.Info("put IRSA role policy", zap.String("bucket", ts.cfg.S3BucketName), zap.String("key", ts.cfg.S3Key))
+	return nil
+}
+
+func (ts *tester) deleteRole() error {
+	if ts.cfg.RoleName == "" {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting IRSA role", zap.String("role-name", ts.cfg.RoleName))
+	if ts.cfg.S3BucketName != "" {
+		_, err := ts.cfg.IAMAPI.DeleteRolePolicy(&iam.DeleteRolePolicyInput{
+			RoleName:   aws.String(ts.cfg.RoleName),
+			PolicyName: aws.String(rolePolicyName),
+		})
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != iam.ErrCodeNoSuchEntityException {
+				return fmt.Errorf("failed to delete IRSA role policy (%v)", err)
+			}
+		}
+	}
+	_, err := ts.cfg.IAMAPI.DeleteRole(&iam.DeleteRoleInput{
+		RoleName: aws.String(ts.cfg.RoleName),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != iam.ErrCodeNoSuchEntityException {
+			return fmt.Errorf("failed to delete IRSA role (%v)", err)
+		}
+	}
+	ts.cfg.RoleARN = ""
+	ts.cfg.Logger.Info("deleted IRSA role")
+	return nil
+}
@@ -0,0 +1,529 @@
+// Package irsa validates IAM Roles for Service Accounts (IRSA), by creating
+// an IAM role trusted by the cluster OIDC provider, annotating a ServiceAccount,
+// and running a Pod that calls STS and S3 with the web identity credentials.
+// Replace https://github.com/aws/aws-k8s-tester/tree/v1.5.9/eks/irsa.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/iam-roles-for-service-accounts.html
+package irsa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	EKSAPI eksiface.EKSAPI `json:"-"`
+	IAMAPI iamiface.IAMAPI `json:"-"`
+
+	Partition   string `json:"partition"`
+	Region      string `json:"region"`
+	ClusterName string `json:"cluster_name"`
+	AccountID   string `json:"account_id" read-only:"true"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the test Pod image with the AWS CLI.
+	Image string `json:"image"`
+	// RoleName is the name of the IAM role to create for the ServiceAccount.
+	RoleName string `json:"role_name"`
+	// S3BucketName is the existing S3 bucket to write, read, and delete a test object
+	// with the web identity credentials. If empty, only STS is called.
+	S3BucketName string `json:"s3_bucket_name"`
+	// S3Key is the S3 key of the test object.
+	S3Key string `json:"s3_key"`
+	// Timeout is the timeout to wait for the test Pod to complete.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// OIDCIssuerURL is the cluster OIDC issuer URL.
+	OIDCIssuerURL string `json:"oidc_issuer_url" read-only:"true"`
+	// OIDCProviderARN is the IAM OIDC provider ARN for the cluster.
+	OIDCProviderARN string `json:"oidc_provider_arn" read-only:"true"`
+	// OIDCProviderCreated is true if the IAM OIDC provider was created by this tester,
+	// thus to be deleted on "delete".
+	OIDCProviderCreated bool `json:"oidc_provider_created" read-only:"true"`
+	// RoleARN is the created IAM role ARN.
+	RoleARN string `json:"role_arn" read-only:"true"`
+	// CallerARN is the caller identity ARN returned to the test Pod,
+	// expected to be the assumed role of "RoleName".
+	CallerARN string `json:"caller_arn" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.ClusterName == "" {
+		return errors.New("empty ClusterName")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.RoleName == "" {
+		cfg.RoleName = cfg.Namespace + "-role"
+	}
+	// ref. https://docs.aws.amazon.com/IAM/latest/APIReference/API_CreateRole.html
+	if len(cfg.RoleName) > 64 {
+		cfg.RoleName = cfg.RoleName[len(cfg.RoleName)-64:]
+	}
+	if cfg.S3Key == "" {
+		cfg.S3Key = path.Join(cfg.Namespace, "irsa-test")
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes int = 1
+	DefaultPartition        = "aws"
+	DefaultImage            = "public.ecr.aws/aws-cli/aws-cli:2.15.30"
+	DefaultTimeout          = 10 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:       false,
+		Prompt:       false,
+		Partition:    DefaultPartition,
+		MinimumNodes: DefaultMinimumNodes,
+		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:        DefaultImage,
+		Timeout:      DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, stsOutput, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.AccountID = aws.StringValue(stsOutput.Account)
+	cfg.EKSAPI = eks.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+	cfg.IAMAPI = iam.New(awsSession)
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	serviceAccountName = "irsa"
+	jobName            = "irsa"
+
+	// ref. https://github.com/aws/amazon-eks-pod-identity-webhook
+	roleARNAnnotation = "eks.amazonaws.com/role-arn"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createOIDCProvider(); err != nil {
+		return err
+	}
+	if err := ts.createRole(); err != nil {
+		return err
+	}
+	if err := ts.createServiceAccount(); err != nil {
+		return err
+	}
+	if err := ts.createJob(); err != nil {
+		return err
+	}
+
+	return ts.checkJob()
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteJob(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		jobName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if err := ts.deleteRole(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteOIDCProvider(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createServiceAccount() error {
+	ts.cfg.Logger.Info("creating IRSA ServiceAccount", zap.String("role-arn", ts.cfg.RoleARN))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ServiceAccounts(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ServiceAccount{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serviceAccountName,
+					Namespace: ts.cfg.Namespace,
+					Annotations: map[string]string{
+						roleARNAnnotation: ts.cfg.RoleARN,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("IRSA ServiceAccount already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create IRSA ServiceAccount (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created IRSA ServiceAccount")
+	return nil
+}
+
+// testScript prints the caller identity, and writes, reads, and deletes
+// the S3 test object if the bucket is given.
+const testScript = `set -e
+echo "irsa-caller-arn $(aws sts get-caller-identity --query Arn --output text)"
+if [ -n "$S3_BUCKET_NAME" ]; then
+  echo "$S3_BODY" > /tmp/irsa-test
+  aws s3 cp /tmp/irsa-test "s3://$S3_BUCKET_NAME/$S3_KEY"
+  echo "irsa-s3-body $(aws s3 cp "s3://$S3_BUCKET_NAME/$S3_KEY" -)"
+  aws s3 rm "s3://$S3_BUCKET_NAME/$S3_KEY"
+fi
+echo irsa-done
+`
+
+func (ts *tester) createJob() error {
+	ts.cfg.Logger.Info("creating IRSA Job", zap.String("image", ts.cfg.Image))
+	// retry on the IAM role propagation delay
+	backoffLimit := int32(6)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						Spec: core_v1.PodSpec{
+							ServiceAccountName: serviceAccountName,
+							RestartPolicy:      core_v1.RestartPolicyNever,
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c"},
+									Args:            []string{testScript},
+									Env: []core_v1.EnvVar{
+										{Name: "AWS_REGION", Value: ts.cfg.Region},
+										{Name: "S3_BUCKET_NAME", Value: ts.cfg.S3BucketName},
+										{Name: "S3_KEY", Value: ts.cfg.S3Key},
+										{Name: "S3_BODY", Value: ts.cfg.Namespace},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("IRSA Job already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create IRSA Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created IRSA Job")
+	return nil
+}
+
+func (ts *tester) checkJob() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Timeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		5*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		// the pod identity webhook injects the web identity credentials
+		if err := checkWebhookEnvs(pod, ts.cfg.RoleARN); err != nil {
+			return fmt.Errorf("IRSA Pod %q not mutated by pod identity webhook (%v)", pod.Name, err)
+		}
+		logs, err := ts.readPodLogs(pod.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read IRSA Pod %q logs (%v)", pod.Name, err)
+		}
+		out, err := parseOutput(logs)
+		if err != nil {
+			return fmt.Errorf("failed to parse IRSA Pod %q logs (%v)", pod.Name, err)
+		}
+		ts.cfg.CallerARN = out.callerARN
+		fmt.Fprintf(ts.cfg.LogWriter, "\nIRSA Pod %q caller ARN: %s\n\n", pod.Name, out.callerARN)
+
+		// e.g., "arn:aws:sts::123456789012:assumed-role/[ROLE NAME]/botocore-session-1234567890"
+		if !strings.Contains(out.callerARN, ":assumed-role/"+ts.cfg.RoleName+"/") {
+			return fmt.Errorf("unexpected IRSA caller ARN %q, expected assumed role %q", out.callerARN, ts.cfg.RoleName)
+		}
+		if ts.cfg.S3BucketName != "" && out.s3Body != ts.cfg.Namespace {
+			return fmt.Errorf("unexpected IRSA S3 object body %q, expected %q", out.s3Body, ts.cfg.Namespace)
+		}
+		return nil
+	}
+	return errors.New("no succeeded IRSA Pod")
+}
+
+func checkWebhookEnvs(pod core_v1.Pod, roleARN string) error {
+	envs := make(map[string]string)
+	for _, c := range pod.Spec.Containers {
+		for _, env := range c.Env {
+			envs[env.Name] = env.Value
+		}
+	}
+	if v := envs["AWS_ROLE_ARN"]; v != roleARN {
+		return fmt.Errorf("unexpected AWS_ROLE_ARN %q, expected %q", v, roleARN)
+	}
+	if envs["AWS_WEB_IDENTITY_TOKEN_FILE"] == "" {
+		return errors.New("empty AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	return nil
+}
+
+type output struct {
+	callerARN string
+	s3Body    string
+}
+
+func parseOutput(logs string) (out output, err error) {
+	done := false
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "irsa-caller-arn "):
+			out.callerARN = strings.TrimPrefix(line, "irsa-caller-arn ")
+		case strings.HasPrefix(line, "irsa-s3-body "):
+			out.s3Body = strings.TrimPrefix(line, "irsa-s3-body ")
+		case line == "irsa-done":
+			done = true
+		}
+	}
+	if !done {
+		return output{}, errors.New("'irsa-done' not found")
+	}
+	if out.callerARN == "" {
+		return output{}, errors.New("caller ARN not found")
+	}
+	return out, nil
+}
+
+func (ts *tester) readPodLogs(podName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rc, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		GetLogs(podName, &core_v1.PodLogOptions{Container: jobName}).
+		Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
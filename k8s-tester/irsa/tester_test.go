@@ -0,0 +1,88 @@
+package irsa
+
+import (
+	"encoding/json"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+)
+
+func TestIssuerHostPath(t *testing.T) {
+	hostPath, err := issuerHostPath("https://oidc.eks.us-west-2.amazonaws.com/id/EXAMPLED539D4633E53DE1B716D3041E")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostPath != "oidc.eks.us-west-2.amazonaws.com/id/EXAMPLED539D4633E53DE1B716D3041E" {
+		t.Fatalf("unexpected host path %q", hostPath)
+	}
+	if _, err = issuerHostPath("http://oidc.eks.us-west-2.amazonaws.com/id/A"); err == nil {
+		t.Fatal("expected error for non-https issuer")
+	}
+}
+
+func TestTrustPolicy(t *testing.T) {
+	hostPath := "oidc.eks.us-west-2.amazonaws.com/id/A"
+	s, err := trustPolicy("arn:aws:iam::123456789012:oidc-provider/"+hostPath, hostPath, "irsa-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc policyDocument
+	if err = json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Statement) != 1 {
+		t.Fatalf("unexpected statements %+v", doc.Statement)
+	}
+	cond := doc.Statement[0].Condition["StringEquals"]
+	if v := cond[hostPath+":sub"]; v != "system:serviceaccount:irsa-test:"+serviceAccountName {
+		t.Fatalf("unexpected sub condition %q", v)
+	}
+	if v := cond[hostPath+":aud"]; v != stsAudience {
+		t.Fatalf("unexpected aud condition %q", v)
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	out, err := parseOutput(`irsa-caller-arn arn:aws:sts::123456789012:assumed-role/irsa-role/botocore-session-1
+upload: ../tmp/irsa-test to s3://bucket/key
+irsa-s3-body irsa-test
+delete: s3://bucket/key
+irsa-done
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.callerARN != "arn:aws:sts::123456789012:assumed-role/irsa-role/botocore-session-1" {
+		t.Fatalf("unexpected caller ARN %q", out.callerARN)
+	}
+	if out.s3Body != "irsa-test" {
+		t.Fatalf("unexpected S3 body %q", out.s3Body)
+	}
+	if _, err = parseOutput("irsa-caller-arn a\n"); err == nil {
+		t.Fatal("expected error without 'irsa-done'")
+	}
+}
+
+func TestCheckWebhookEnvs(t *testing.T) {
+	pod := core_v1.Pod{
+		Spec: core_v1.PodSpec{
+			Containers: []core_v1.Container{
+				{
+					Env: []core_v1.EnvVar{
+						{Name: "AWS_ROLE_ARN", Value: "arn:aws:iam::123456789012:role/irsa-role"},
+						{Name: "AWS_WEB_IDENTITY_TOKEN_FILE", Value: "/var/run/secrets/eks.amazonaws.com/serviceaccount/token"},
+					},
+				},
+			},
+		},
+	}
+	if err := checkWebhookEnvs(pod, "arn:aws:iam::123456789012:role/irsa-role"); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWebhookEnvs(pod, "arn:aws:iam::123456789012:role/other"); err == nil {
+		t.Fatal("expected role ARN mismatch")
+	}
+	if err := checkWebhookEnvs(core_v1.Pod{}, "arn:aws:iam::123456789012:role/irsa-role"); err == nil {
+		t.Fatal("expected error without webhook envs")
+	}
+}
@@ -0,0 +1,798 @@
+// Package istio_ambient installs Istio in ambient mode, enforces strict mTLS
+// in a meshed namespace, and validates that plaintext Pod-to-Pod traffic
+// into the mesh is rejected while meshed traffic succeeds.
+// It also measures the latency added by the mesh, relative to a
+// non-mesh baseline collected in the same run.
+// ref. https://istio.io/latest/docs/ambient/install/helm
+package istio_ambient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	// The namespace is enrolled in the ambient mesh with strict mTLS,
+	// while the non-mesh baseline runs in the namespace with "-baseline" suffix.
+	Namespace string `json:"namespace"`
+
+	// HelmChartRepoURL is the Istio helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+	// IstioVersion is the Istio helm chart version.
+	IstioVersion string `json:"istio_version"`
+
+	// Requests is the number of HTTP requests each client sends.
+	Requests int `json:"requests"`
+	// MaxLatencyOverheadMs is the maximum p50 latency in milliseconds
+	// the mesh may add over the baseline (0 to skip).
+	MaxLatencyOverheadMs float64 `json:"max_latency_overhead_ms"`
+
+	// PlaintextRejected is true if the plaintext requests into the mesh were rejected.
+	PlaintextRejected bool `json:"plaintext_rejected" read-only:"true"`
+	// BaselineP50Ms is the p50 latency in milliseconds without the mesh.
+	BaselineP50Ms float64 `json:"baseline_p50_ms" read-only:"true"`
+	// BaselineP99Ms is the p99 latency in milliseconds without the mesh.
+	BaselineP99Ms float64 `json:"baseline_p99_ms" read-only:"true"`
+	// MeshedP50Ms is the p50 latency in milliseconds with the mesh.
+	MeshedP50Ms float64 `json:"meshed_p50_ms" read-only:"true"`
+	// MeshedP99Ms is the p99 latency in milliseconds with the mesh.
+	MeshedP99Ms float64 `json:"meshed_p99_ms" read-only:"true"`
+	// LatencyOverheadMs is the p50 latency in milliseconds added by the mesh.
+	LatencyOverheadMs float64 `json:"latency_overhead_ms" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.IstioVersion == "" {
+		cfg.IstioVersion = DefaultIstioVersion
+	}
+	if cfg.Requests == 0 {
+		cfg.Requests = DefaultRequests
+	}
+	if cfg.MaxLatencyOverheadMs < 0 {
+		return fmt.Errorf("invalid MaxLatencyOverheadMs %f", cfg.MaxLatencyOverheadMs)
+	}
+	return nil
+}
+
+const (
+	DefaultMinimumNodes     int = 1
+	DefaultHelmChartRepoURL     = "https://istio-release.storage.googleapis.com/charts"
+	DefaultIstioVersion         = "1.23.2"
+	DefaultRequests         int = 200
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL: DefaultHelmChartRepoURL,
+		IstioVersion:     DefaultIstioVersion,
+		Requests:         DefaultRequests,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	istioNamespace = "istio-system"
+
+	serverName      = "mesh-server"
+	serverImageName = "public.ecr.aws/nginx/nginx:1.27"
+	clientImageName = "public.ecr.aws/docker/library/alpine:3.20"
+
+	meshedJobName    = "mesh-client-meshed"
+	baselineJobName  = "mesh-client-baseline"
+	plaintextJobName = "mesh-client-plaintext"
+
+	// plaintextRequests is the number of plaintext requests sent into the mesh,
+	// all of which are expected to be rejected.
+	plaintextRequests = 10
+)
+
+// charts to install in order, and to uninstall in reverse order.
+// ref. https://istio.io/latest/docs/ambient/install/helm
+var charts = []struct {
+	name   string
+	values map[string]interface{}
+}{
+	{name: "base"},
+	{name: "istiod", values: map[string]interface{}{"profile": "ambient"}},
+	{name: "cni", values: map[string]interface{}{"profile": "ambient"}},
+	{name: "ztunnel"},
+}
+
+func (ts *tester) baselineNamespace() string {
+	return ts.cfg.Namespace + "-baseline"
+}
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	for _, ns := range []string{istioNamespace, ts.cfg.Namespace, ts.baselineNamespace()} {
+		if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ns); err != nil {
+			return err
+		}
+	}
+
+	if err := ts.installCharts(); err != nil {
+		return err
+	}
+
+	if err := ts.enrollNamespace(); err != nil {
+		return err
+	}
+
+	if err := ts.applyPeerAuthentication(); err != nil {
+		return err
+	}
+
+	for _, ns := range []string{ts.cfg.Namespace, ts.baselineNamespace()} {
+		if err := ts.createServer(ns); err != nil {
+			return err
+		}
+	}
+
+	if err := ts.checkBaseline(); err != nil {
+		return err
+	}
+
+	if err := ts.checkMeshed(); err != nil {
+		return err
+	}
+
+	if err := ts.checkPlaintext(); err != nil {
+		return err
+	}
+
+	ts.cfg.LatencyOverheadMs = ts.cfg.MeshedP50Ms - ts.cfg.BaselineP50Ms
+	ts.cfg.Logger.Info("measured mesh latency overhead",
+		zap.Float64("baseline-p50-ms", ts.cfg.BaselineP50Ms),
+		zap.Float64("baseline-p99-ms", ts.cfg.BaselineP99Ms),
+		zap.Float64("meshed-p50-ms", ts.cfg.MeshedP50Ms),
+		zap.Float64("meshed-p99-ms", ts.cfg.MeshedP99Ms),
+		zap.Float64("overhead-p50-ms", ts.cfg.LatencyOverheadMs),
+	)
+	if ts.cfg.MaxLatencyOverheadMs > 0 && ts.cfg.LatencyOverheadMs > ts.cfg.MaxLatencyOverheadMs {
+		return fmt.Errorf("mesh p50 latency overhead %.3f ms exceeds threshold %.3f ms", ts.cfg.LatencyOverheadMs, ts.cfg.MaxLatencyOverheadMs)
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	for _, ns := range []string{ts.cfg.Namespace, ts.baselineNamespace()} {
+		if err := client.DeleteNamespaceAndWait(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ns,
+			client.DefaultNamespaceDeletionInterval,
+			client.DefaultNamespaceDeletionTimeout,
+			client.WithForceDelete(true),
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete namespace %q (%v)", ns, err))
+		}
+	}
+
+	if err := ts.uninstallCharts(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		istioNamespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace %q (%v)", istioNamespace, err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) installCharts() error {
+	getAllArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + istioNamespace,
+		"get",
+		"all",
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	for _, chart := range charts {
+		values := chart.values
+		if values == nil {
+			values = map[string]interface{}{}
+		}
+		if err := helm.Install(helm.InstallConfig{
+			Logger:         ts.cfg.Logger,
+			LogWriter:      ts.cfg.LogWriter,
+			Stopc:          ts.cfg.Stopc,
+			Timeout:        10 * time.Minute,
+			KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+			Namespace:      istioNamespace,
+			ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+			ChartName:      chart.name,
+			ChartVersion:   ts.cfg.IstioVersion,
+			ReleaseName:    "istio-" + chart.name,
+			Values:         values,
+			LogFunc: func(format string, v ...interface{}) {
+				ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+			},
+			QueryFunc: func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+				output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+				cancel()
+				out := strings.TrimSpace(string(output))
+				if err != nil {
+					ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+				}
+				fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+			},
+			QueryInterval: 30 * time.Second,
+		}); err != nil {
+			return fmt.Errorf("failed to install Istio chart %q (%v)", chart.name, err)
+		}
+	}
+	return nil
+}
+
+func (ts *tester) uninstallCharts() error {
+	var errs []string
+	for i := len(charts) - 1; i >= 0; i-- {
+		if err := helm.Uninstall(helm.InstallConfig{
+			Logger:         ts.cfg.Logger,
+			LogWriter:      ts.cfg.LogWriter,
+			Timeout:        15 * time.Minute,
+			KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+			Namespace:      istioNamespace,
+			ChartName:      charts[i].name,
+			ReleaseName:    "istio-" + charts[i].name,
+		}); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to uninstall Istio chart %q (%v)", charts[i].name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// enrollNamespace adds the test namespace to the ambient mesh.
+func (ts *tester) enrollNamespace() error {
+	ts.cfg.Logger.Info("enrolling namespace in ambient mesh", zap.String("namespace", ts.cfg.Namespace))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Namespaces().
+		Patch(
+			ctx,
+			ts.cfg.Namespace,
+			types.MergePatchType,
+			[]byte(`{"metadata":{"labels":{"istio.io/dataplane-mode":"ambient"}}}`),
+			meta_v1.PatchOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to label namespace %q (%v)", ts.cfg.Namespace, err)
+	}
+
+	ts.cfg.Logger.Info("enrolled namespace in ambient mesh", zap.String("namespace", ts.cfg.Namespace))
+	return nil
+}
+
+// ref. https://istio.io/latest/docs/reference/config/security/peer_authentication
+const peerAuthenticationTmpl = `
+apiVersion: security.istio.io/v1
+kind: PeerAuthentication
+metadata:
+  name: default
+  namespace: {{ .Namespace }}
+spec:
+  mtls:
+    mode: STRICT
+`
+
+func (ts *tester) applyPeerAuthentication() (err error) {
+	tpl := template.Must(template.New("peerAuthenticationTmpl").Parse(peerAuthenticationTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err = tpl.Execute(buf, struct {
+		Namespace string
+	}{
+		Namespace: ts.cfg.Namespace,
+	}); err != nil {
+		return err
+	}
+	fpath, err := file.WriteTempFile(buf.Bytes())
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to write PeerAuthentication YAML", zap.Error(err))
+		return err
+	}
+	ts.cfg.Logger.Info("applying PeerAuthentication YAML", zap.String("path", fpath))
+
+	applyArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"apply",
+		"--filename=" + fpath,
+	}
+	applyCmd := strings.Join(applyArgs, " ")
+
+	var output []byte
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create PeerAuthentication aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		output, err = exec.New().CommandContext(ctx, applyArgs[0], applyArgs[1:]...).CombinedOutput()
+		cancel()
+		out := string(output)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", applyCmd, out)
+		if err == nil {
+			break
+		}
+
+		ts.cfg.Logger.Warn("create PeerAuthentication failed", zap.Error(err))
+	}
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+	}
+
+	ts.cfg.Logger.Info("created PeerAuthentication")
+	return nil
+}
+
+func (ts *tester) createServer(ns string) error {
+	ts.cfg.Logger.Info("creating server Deployment", zap.String("namespace", ns))
+	var replicas int32 = 1
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ns).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serverName,
+					Namespace: ns,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": serverName,
+					},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": serverName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": serverName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            serverName,
+									Image:           serverImageName,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Ports: []core_v1.ContainerPort{
+										{
+											Protocol:      core_v1.ProtocolTCP,
+											ContainerPort: 80,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create server Deployment (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Services(ns).
+		Create(
+			ctx,
+			&core_v1.Service{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serverName,
+					Namespace: ns,
+				},
+				Spec: core_v1.ServiceSpec{
+					Type: core_v1.ServiceTypeClusterIP,
+					Selector: map[string]string{
+						"app.kubernetes.io/name": serverName,
+					},
+					Ports: []core_v1.ServicePort{
+						{
+							Protocol:   core_v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create server Service (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Minute)
+	_, err = client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		10*time.Second,
+		ns,
+		serverName,
+		replicas,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created server Deployment", zap.String("namespace", ns))
+	return nil
+}
+
+// clientTmpl sends the requests and prints the number of successes and failures,
+// and the latency percentiles in microseconds, as "KEY=VALUE" pairs.
+// When "Warmup" is set, it first waits for the server to become reachable,
+// since the mesh may take a moment to enroll a new Pod.
+const clientTmpl = `
+apk add --no-cache curl >/dev/null
+URL="{{ .URL }}"
+{{- if .Warmup }}
+for i in $(seq 1 60); do
+  curl -sf -o /dev/null --max-time 2 "${URL}" && break
+  sleep 1
+done
+{{- end }}
+: > /tmp/latency
+FAILURES=0
+for i in $(seq 1 {{ .Requests }}); do
+  if T=$(curl -sf -o /dev/null --max-time 5 -w '%{time_total}' "${URL}"); then
+    echo "${T}" >> /tmp/latency
+  else
+    FAILURES=$((FAILURES+1))
+  fi
+done
+SUCCESSES=$(wc -l < /tmp/latency)
+echo "SUCCESSES=${SUCCESSES} FAILURES=${FAILURES}"
+if [ "${SUCCESSES}" -gt 0 ]; then
+  sort -n /tmp/latency | awk '{ a[NR] = $1 } END { printf "P50_MICROSECONDS=%d P99_MICROSECONDS=%d\n", a[int((NR-1)*0.50)+1]*1000000, a[int((NR-1)*0.99)+1]*1000000 }'
+else
+  echo "P50_MICROSECONDS=0 P99_MICROSECONDS=0"
+fi
+`
+
+var clientKeys = []string{
+	"SUCCESSES",
+	"FAILURES",
+	"P50_MICROSECONDS",
+	"P99_MICROSECONDS",
+}
+
+// runClient runs a client Job in the namespace "ns" against the server
+// in the namespace "serverNS", and returns the parsed output.
+func (ts *tester) runClient(ns string, jobName string, serverNS string, requests int, warmup bool) (map[string]int64, error) {
+	tpl := template.Must(template.New("clientTmpl").Parse(clientTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, struct {
+		URL      string
+		Requests int
+		Warmup   bool
+	}{
+		URL:      fmt.Sprintf("http://%s.%s.svc.cluster.local", serverName, serverNS),
+		Requests: requests,
+		Warmup:   warmup,
+	}); err != nil {
+		return nil, err
+	}
+
+	var backoffLimit int32
+	ts.cfg.Logger.Info("creating client Job", zap.String("namespace", ns), zap.String("name", jobName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ns).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ns,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           clientImageName,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c", buf.String()},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create client Job (%v)", err)
+	}
+
+	timeout := 5*time.Minute + time.Duration(requests)*time.Second
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		30*time.Second,
+		5*time.Second,
+		ns,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	var logs string
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		logs, err = client.CheckPodLogs(
+			ts.cfg.Logger,
+			ts.cfg.LogWriter,
+			ts.cfg.Stopc,
+			ts.cfg.Client.KubernetesClient(),
+			ns,
+			pod.Name,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get client Pod logs (%v)", err)
+		}
+		break
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\nclient Job %q output:\n\n%s\n\n", jobName, logs)
+
+	return parseClientOutput(logs)
+}
+
+func (ts *tester) checkBaseline() error {
+	rs, err := ts.runClient(ts.baselineNamespace(), baselineJobName, ts.baselineNamespace(), ts.cfg.Requests, false)
+	if err != nil {
+		return err
+	}
+	if rs["SUCCESSES"] != int64(ts.cfg.Requests) {
+		return fmt.Errorf("baseline client expected %d successful requests, got %d (failures %d)", ts.cfg.Requests, rs["SUCCESSES"], rs["FAILURES"])
+	}
+	ts.cfg.BaselineP50Ms = float64(rs["P50_MICROSECONDS"]) / 1000
+	ts.cfg.BaselineP99Ms = float64(rs["P99_MICROSECONDS"]) / 1000
+	return nil
+}
+
+func (ts *tester) checkMeshed() error {
+	rs, err := ts.runClient(ts.cfg.Namespace, meshedJobName, ts.cfg.Namespace, ts.cfg.Requests, true)
+	if err != nil {
+		return err
+	}
+	if rs["SUCCESSES"] != int64(ts.cfg.Requests) {
+		return fmt.Errorf("meshed client expected %d successful requests, got %d (failures %d)", ts.cfg.Requests, rs["SUCCESSES"], rs["FAILURES"])
+	}
+	ts.cfg.MeshedP50Ms = float64(rs["P50_MICROSECONDS"]) / 1000
+	ts.cfg.MeshedP99Ms = float64(rs["P99_MICROSECONDS"]) / 1000
+	return nil
+}
+
+// checkPlaintext sends requests from the non-mesh namespace into the mesh,
+// which must be rejected under strict mTLS.
+func (ts *tester) checkPlaintext() error {
+	rs, err := ts.runClient(ts.baselineNamespace(), plaintextJobName, ts.cfg.Namespace, plaintextRequests, false)
+	if err != nil {
+		return err
+	}
+	ts.cfg.PlaintextRejected = rs["SUCCESSES"] == 0
+	if !ts.cfg.PlaintextRejected {
+		return fmt.Errorf("%d of %d plaintext requests into the mesh succeeded, expected all rejected", rs["SUCCESSES"], plaintextRequests)
+	}
+	ts.cfg.Logger.Info("plaintext requests into the mesh rejected")
+	return nil
+}
+
+func parseClientOutput(logs string) (map[string]int64, error) {
+	rs := make(map[string]int64)
+	for _, line := range strings.Split(logs, "\n") {
+		for _, field := range strings.Fields(line) {
+			ss := strings.SplitN(field, "=", 2)
+			if len(ss) != 2 {
+				continue
+			}
+			v, err := strconv.ParseInt(ss[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			rs[ss[0]] = v
+		}
+	}
+	for _, k := range clientKeys {
+		if _, ok := rs[k]; !ok {
+			return nil, fmt.Errorf("%q not found in client output", k)
+		}
+	}
+	return rs, nil
+}
@@ -0,0 +1,37 @@
+package jobs_echo
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// NewRenderer returns the tester to render the manifests, without the AWS clients.
+func NewRenderer(cfg *Config) k8s_tester.Tester {
+	return &tester{cfg: cfg}
+}
+
+// Render writes the Job or the CronJob, against a fake client.
+// The image is not described via ECR.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	img := jobBusyboxImageName
+	if !cfg.Repository.IsEmpty() {
+		img = cfg.Repository.ImageURI()
+	}
+	if err := rt.createJob(img); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
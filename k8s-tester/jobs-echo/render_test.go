@@ -0,0 +1,34 @@
+package jobs_echo
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+)
+
+func TestRender(t *testing.T) {
+	tt := []struct {
+		jobType string
+		golden  string
+	}{
+		{jobType: "Job", golden: "render.golden.yaml"},
+		{jobType: "CronJob", golden: "render-cronjob.golden.yaml"},
+	}
+	for _, tv := range tt {
+		t.Run(tv.jobType, func(t *testing.T) {
+			cfg := NewDefault(tv.jobType)
+			cfg.Namespace = "test-namespace"
+			if err := cfg.ValidateAndSetDefaults(); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := NewRenderer(cfg).(*tester).Render(&buf); err != nil {
+				t.Fatal(err)
+			}
+			fake.AssertGolden(t, filepath.Join("testdata", tv.golden), buf.Bytes())
+		})
+	}
+}
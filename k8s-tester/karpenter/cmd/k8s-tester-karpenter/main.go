@@ -0,0 +1,204 @@
+// k8s-tester-karpenter installs Karpenter and tests node provisioning.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/karpenter"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-karpenter",
+	Short:      "Kubernetes Karpenter provisioning tester",
+	SuggestFor: []string{"karpenter"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	partition          string
+	region             string
+	clusterName        string
+	nodePoolName       string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", karpenter.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", karpenter.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for EC2 instances")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster-name", "", "EKS cluster name")
+	rootCmd.PersistentFlags().StringVar(&nodePoolName, "node-pool-name", "", "NodePool name (default to namespace)")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-karpenter failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	helmChartRepoURL  string
+	helmChartVersion  string
+	controllerRoleARN string
+	nodeRoleName      string
+	discoveryTagValue string
+	instanceTypes     []string
+	capacityType      string
+	replicas          int32
+	provisionTimeout  string
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&helmChartRepoURL, "helm-chart-repo-url", karpenter.DefaultHelmChartRepoURL, "helm chart repo URL")
+	cmd.PersistentFlags().StringVar(&helmChartVersion, "helm-chart-version", karpenter.DefaultHelmChartVersion, "helm chart version")
+	cmd.PersistentFlags().StringVar(&controllerRoleARN, "controller-role-arn", "", "IAM role ARN for the Karpenter controller service account (IRSA)")
+	cmd.PersistentFlags().StringVar(&nodeRoleName, "node-role-name", "", "IAM role name for the nodes launched by Karpenter")
+	cmd.PersistentFlags().StringVar(&discoveryTagValue, "discovery-tag-value", "", "'karpenter.sh/discovery' tag value for subnets and security groups (default to cluster name)")
+	cmd.PersistentFlags().StringSliceVar(&instanceTypes, "instance-types", nil, "instance types to allow in the NodePool")
+	cmd.PersistentFlags().StringVar(&capacityType, "capacity-type", karpenter.DefaultCapacityType, "NodePool capacity type ('on-demand' or 'spot')")
+	cmd.PersistentFlags().Int32Var(&replicas, "replicas", karpenter.DefaultReplicas, "number of workload replicas")
+	cmd.PersistentFlags().StringVar(&provisionTimeout, "provision-timeout", karpenter.DefaultProvisionTimeout.String(), "timeout to wait for Karpenter to launch and register capacity")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	timeout, err := time.ParseDuration(provisionTimeout)
+	if err != nil {
+		lg.Panic("failed to parse provision timeout", zap.String("provision-timeout", provisionTimeout), zap.Error(err))
+	}
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &karpenter.Config{
+		Prompt:            prompt,
+		Logger:            lg,
+		LogWriter:         logWriter,
+		MinimumNodes:      minimumNodes,
+		Namespace:         namespace,
+		Client:            cli,
+		Partition:         partition,
+		Region:            region,
+		HelmChartRepoURL:  helmChartRepoURL,
+		HelmChartVersion:  helmChartVersion,
+		ControllerRoleARN: controllerRoleARN,
+		NodeRoleName:      nodeRoleName,
+		DiscoveryTagValue: discoveryTagValue,
+		NodePoolName:      nodePoolName,
+		InstanceTypes:     instanceTypes,
+		CapacityType:      capacityType,
+		Replicas:          replicas,
+		ProvisionTimeout:  timeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := karpenter.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-karpenter apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	if nodePoolName == "" {
+		nodePoolName = namespace
+	}
+	cfg := &karpenter.Config{
+		Prompt:       prompt,
+		Logger:       lg,
+		LogWriter:    logWriter,
+		Namespace:    namespace,
+		Client:       cli,
+		Partition:    partition,
+		Region:       region,
+		ClusterName:  clusterName,
+		NodePoolName: nodePoolName,
+	}
+
+	ts := karpenter.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-karpenter delete' success\n")
+}
@@ -0,0 +1,787 @@
+// Package karpenter installs Karpenter, creates a NodePool and EC2NodeClass,
+// deploys a workload that is only schedulable on the NodePool nodes,
+// and validates that Karpenter launches and registers the capacity.
+// ref. https://karpenter.sh/docs/getting-started/getting-started-with-karpenter
+//
+// The Karpenter controller IAM role (e.g., via "ControllerRoleARN" for IRSA)
+// and the node IAM role (with its aws-auth mapping or access entry) must exist,
+// and the subnets and security groups must be tagged with the discovery tag
+// "karpenter.sh/discovery".
+package karpenter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	EC2API ec2iface.EC2API `json:"-"`
+
+	Partition   string `json:"partition"`
+	Region      string `json:"region"`
+	ClusterName string `json:"cluster_name" read-only:"true"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// HelmChartRepoURL is the Karpenter helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+	// HelmChartVersion is the Karpenter helm chart version.
+	HelmChartVersion string `json:"helm_chart_version"`
+
+	// ControllerRoleARN is the IAM role ARN for the Karpenter controller service account (IRSA).
+	// Leave empty if the controller gets its credentials elsewhere (e.g., EKS Pod Identity).
+	ControllerRoleARN string `json:"controller_role_arn"`
+	// NodeRoleName is the IAM role name for the nodes launched by Karpenter.
+	NodeRoleName string `json:"node_role_name"`
+	// DiscoveryTagValue is the value of the "karpenter.sh/discovery" tag
+	// to select subnets and security groups.
+	// Defaults to the cluster name.
+	DiscoveryTagValue string `json:"discovery_tag_value"`
+
+	// NodePoolName is the name of the NodePool and EC2NodeClass to create.
+	// Defaults to the namespace.
+	NodePoolName string `json:"node_pool_name"`
+	// InstanceTypes is the list of instance types to allow in the NodePool.
+	// Leave empty to let Karpenter choose.
+	InstanceTypes []string `json:"instance_types"`
+	// CapacityType is the capacity type of the NodePool ("on-demand" or "spot").
+	CapacityType string `json:"capacity_type"`
+
+	// Replicas is the number of workload replicas.
+	Replicas int32 `json:"replicas"`
+	// ProvisionTimeout is the timeout to wait for Karpenter to launch
+	// and register capacity.
+	ProvisionTimeout       time.Duration `json:"provision_timeout"`
+	ProvisionTimeoutString string        `json:"provision_timeout_string" read-only:"true"`
+
+	// LaunchedNodeNames is the list of nodes launched by Karpenter.
+	LaunchedNodeNames []string `json:"launched_node_names" read-only:"true"`
+	// ProvisionLatency is the time taken for the workload to become available.
+	ProvisionLatency       time.Duration `json:"provision_latency" read-only:"true"`
+	ProvisionLatencyString string        `json:"provision_latency_string" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults(clusterName string) error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if clusterName == "" {
+		return errors.New("empty ClusterName")
+	}
+	if cfg.NodeRoleName == "" {
+		return errors.New("empty NodeRoleName")
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.HelmChartVersion == "" {
+		cfg.HelmChartVersion = DefaultHelmChartVersion
+	}
+	if cfg.DiscoveryTagValue == "" {
+		cfg.DiscoveryTagValue = clusterName
+	}
+	if cfg.NodePoolName == "" {
+		cfg.NodePoolName = cfg.Namespace
+	}
+	switch cfg.CapacityType {
+	case "":
+		cfg.CapacityType = DefaultCapacityType
+	case "on-demand", "spot":
+	default:
+		return fmt.Errorf("unknown CapacityType %q", cfg.CapacityType)
+	}
+	if cfg.Replicas == 0 {
+		cfg.Replicas = DefaultReplicas
+	}
+	if cfg.ProvisionTimeout == time.Duration(0) {
+		cfg.ProvisionTimeout = DefaultProvisionTimeout
+	}
+	cfg.ProvisionTimeoutString = cfg.ProvisionTimeout.String()
+
+	cfg.ClusterName = clusterName
+	return nil
+}
+
+const (
+	DefaultMinimumNodes     int   = 1
+	DefaultPartition              = "aws"
+	DefaultHelmChartRepoURL       = "oci://public.ecr.aws/karpenter"
+	DefaultHelmChartVersion       = "1.0.6"
+	DefaultCapacityType           = "on-demand"
+	DefaultReplicas         int32 = 3
+	DefaultProvisionTimeout       = 15 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		Partition:        DefaultPartition,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL: DefaultHelmChartRepoURL,
+		HelmChartVersion: DefaultHelmChartVersion,
+		CapacityType:     DefaultCapacityType,
+		Replicas:         DefaultReplicas,
+		ProvisionTimeout: DefaultProvisionTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.EC2API = ec2.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config, clusterName string) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(clusterName); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	chartName      = "karpenter"
+	deploymentName = "karpenter-inflate"
+	appName        = "karpenter-inflate"
+	appImageName   = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+
+	// nodePoolLabelKey is the well-known label Karpenter sets on the nodes it launches.
+	// The same key is used as the EC2 instance tag.
+	nodePoolLabelKey = "karpenter.sh/nodepool"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createHelmKarpenter(); err != nil {
+		return err
+	}
+
+	if err := ts.applyNodePool(); err != nil {
+		return err
+	}
+
+	if err := ts.createDeployment(); err != nil {
+		return err
+	}
+
+	if err := ts.checkProvisioning(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	ts.cfg.Logger.Info("deleting deployment", zap.String("deployment-name", deploymentName))
+	if err := client.DeleteDeployment(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		deploymentName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Deployment (%v)", err))
+	}
+
+	// deleting the NodePool while the controller is still running
+	// lets Karpenter drain and terminate its nodes
+	if err := ts.deleteNodePool(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.waitForNodesDeleted(); err != nil {
+		ts.cfg.Logger.Warn("Karpenter nodes not deleted; terminating instances", zap.Error(err))
+	}
+	if err := ts.terminateInstances(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := ts.deleteHelmKarpenter(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// https://github.com/aws/karpenter-provider-aws/blob/main/charts/karpenter/values.yaml
+func (ts *tester) createHelmKarpenter() error {
+	values := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"clusterName": ts.cfg.ClusterName,
+		},
+	}
+	if ts.cfg.ControllerRoleARN != "" {
+		values["serviceAccount"] = map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"eks.amazonaws.com/role-arn": ts.cfg.ControllerRoleARN,
+			},
+		}
+	}
+
+	getAllArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"get",
+		"all",
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        10 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.HelmChartVersion,
+		ReleaseName:    chartName,
+		Values:         values,
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmKarpenter() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+	})
+}
+
+// ref. https://karpenter.sh/docs/concepts/nodepools
+// ref. https://karpenter.sh/docs/concepts/nodeclasses
+const nodePoolTmpl = `
+apiVersion: karpenter.k8s.aws/v1
+kind: EC2NodeClass
+metadata:
+  name: {{ .Name }}
+spec:
+  role: {{ .NodeRoleName }}
+  amiSelectorTerms:
+  - alias: al2023@latest
+  subnetSelectorTerms:
+  - tags:
+      karpenter.sh/discovery: {{ .DiscoveryTagValue }}
+  securityGroupSelectorTerms:
+  - tags:
+      karpenter.sh/discovery: {{ .DiscoveryTagValue }}
+---
+apiVersion: karpenter.sh/v1
+kind: NodePool
+metadata:
+  name: {{ .Name }}
+spec:
+  template:
+    spec:
+      nodeClassRef:
+        group: karpenter.k8s.aws
+        kind: EC2NodeClass
+        name: {{ .Name }}
+      requirements:
+      - key: kubernetes.io/arch
+        operator: In
+        values: ["amd64"]
+      - key: karpenter.sh/capacity-type
+        operator: In
+        values: ["{{ .CapacityType }}"]
+{{- if .InstanceTypes }}
+      - key: node.kubernetes.io/instance-type
+        operator: In
+        values: [{{ .InstanceTypes }}]
+{{- end }}
+  limits:
+    cpu: 1000
+  disruption:
+    consolidationPolicy: WhenEmptyOrUnderutilized
+    consolidateAfter: 1m
+`
+
+func (ts *tester) nodePoolPath() (string, error) {
+	instanceTypes := make([]string, 0, len(ts.cfg.InstanceTypes))
+	for _, v := range ts.cfg.InstanceTypes {
+		instanceTypes = append(instanceTypes, fmt.Sprintf("%q", v))
+	}
+
+	tpl := template.Must(template.New("nodePoolTmpl").Parse(nodePoolTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, struct {
+		Name              string
+		NodeRoleName      string
+		DiscoveryTagValue string
+		CapacityType      string
+		InstanceTypes     string
+	}{
+		Name:              ts.cfg.NodePoolName,
+		NodeRoleName:      ts.cfg.NodeRoleName,
+		DiscoveryTagValue: ts.cfg.DiscoveryTagValue,
+		CapacityType:      ts.cfg.CapacityType,
+		InstanceTypes:     strings.Join(instanceTypes, ", "),
+	}); err != nil {
+		return "", err
+	}
+	return file.WriteTempFile(buf.Bytes())
+}
+
+func (ts *tester) applyNodePool() (err error) {
+	fpath, err := ts.nodePoolPath()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to write NodePool YAML", zap.Error(err))
+		return err
+	}
+	ts.cfg.Logger.Info("applying NodePool YAML", zap.String("path", fpath))
+
+	applyArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"apply",
+		"--filename=" + fpath,
+	}
+	applyCmd := strings.Join(applyArgs, " ")
+
+	var output []byte
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create NodePool aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		output, err = exec.New().CommandContext(ctx, applyArgs[0], applyArgs[1:]...).CombinedOutput()
+		cancel()
+		out := string(output)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", applyCmd, out)
+		if err == nil {
+			break
+		}
+
+		ts.cfg.Logger.Warn("create NodePool failed", zap.Error(err))
+	}
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+	}
+
+	ts.cfg.Logger.Info("created NodePool", zap.String("name", ts.cfg.NodePoolName))
+	return nil
+}
+
+func (ts *tester) deleteNodePool() error {
+	var errs []string
+	for _, kind := range []string{"nodepools.karpenter.sh", "ec2nodeclasses.karpenter.k8s.aws"} {
+		ts.cfg.Logger.Info("deleting Karpenter resource", zap.String("kind", kind), zap.String("name", ts.cfg.NodePoolName))
+		deleteArgs := []string{
+			ts.cfg.Client.Config().KubectlPath,
+			"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+			"delete",
+			kind,
+			ts.cfg.NodePoolName,
+			"--ignore-not-found=true",
+			"--wait=false",
+		}
+		deleteCmd := strings.Join(deleteArgs, " ")
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		output, err := exec.New().CommandContext(ctx, deleteArgs[0], deleteArgs[1:]...).CombinedOutput()
+		cancel()
+		out := string(output)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", deleteCmd, out)
+		if err != nil {
+			// CRD may have been removed already
+			if strings.Contains(out, "the server doesn't have a resource type") {
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("'kubectl delete' failed %v (output %q)", err, out))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.Logger.Info("deleted NodePool", zap.String("name", ts.cfg.NodePoolName))
+	return nil
+}
+
+func (ts *tester) createDeployment() error {
+	ts.cfg.Logger.Info("creating Deployment", zap.String("name", deploymentName), zap.Int32("replicas", ts.cfg.Replicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: aws.Int32(ts.cfg.Replicas),
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": appName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": appName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy:                 core_v1.RestartPolicyAlways,
+							TerminationGracePeriodSeconds: aws.Int64(0),
+							Containers: []core_v1.Container{
+								{
+									Name:            appName,
+									Image:           appImageName,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Resources: core_v1.ResourceRequirements{
+										Requests: core_v1.ResourceList{
+											core_v1.ResourceCPU: resource.MustParse("1"),
+										},
+									},
+								},
+							},
+							// only the nodes launched for the NodePool satisfy the selector,
+							// thus the Pods stay unschedulable until Karpenter adds capacity
+							NodeSelector: map[string]string{
+								nodePoolLabelKey: ts.cfg.NodePoolName,
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("Deployment already exists", zap.String("name", deploymentName))
+			return nil
+		}
+		return fmt.Errorf("failed to create Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Deployment", zap.String("name", deploymentName))
+	return nil
+}
+
+func (ts *tester) listNodePoolNodes() ([]core_v1.Node, error) {
+	return client.ListNodesWithOptions(
+		ts.cfg.Client.KubernetesClient(),
+		meta_v1.ListOptions{
+			LabelSelector: nodePoolLabelKey + "=" + ts.cfg.NodePoolName,
+		},
+	)
+}
+
+// checkProvisioning waits until the workload becomes available
+// on the nodes launched by Karpenter.
+func (ts *tester) checkProvisioning() error {
+	ts.cfg.Logger.Info("waiting for Karpenter provisioning", zap.Duration("timeout", ts.cfg.ProvisionTimeout))
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.ProvisionTimeout)
+	_, err := client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		time.Minute,
+		20*time.Second,
+		ts.cfg.Namespace,
+		deploymentName,
+		ts.cfg.Replicas,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("Deployment %q did not become available within %v (%v)", deploymentName, ts.cfg.ProvisionTimeout, err)
+	}
+	ts.cfg.ProvisionLatency = time.Since(start)
+	ts.cfg.ProvisionLatencyString = ts.cfg.ProvisionLatency.String()
+
+	nodes, err := ts.listNodePoolNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list Karpenter nodes (%v)", err)
+	}
+	ts.cfg.LaunchedNodeNames = make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ready := false
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == core_v1.NodeReady && cond.Status == core_v1.ConditionTrue {
+				ready = true
+				break
+			}
+		}
+		if !ready {
+			continue
+		}
+		ts.cfg.LaunchedNodeNames = append(ts.cfg.LaunchedNodeNames, node.Name)
+	}
+	sort.Strings(ts.cfg.LaunchedNodeNames)
+	if len(ts.cfg.LaunchedNodeNames) == 0 {
+		return fmt.Errorf("no ready node registered for NodePool %q", ts.cfg.NodePoolName)
+	}
+
+	ts.cfg.Logger.Info("Karpenter launched and registered capacity",
+		zap.Strings("nodes", ts.cfg.LaunchedNodeNames),
+		zap.String("latency", ts.cfg.ProvisionLatencyString),
+	)
+	return nil
+}
+
+func (ts *tester) waitForNodesDeleted() error {
+	ts.cfg.Logger.Info("waiting for Karpenter nodes deletion")
+	waitDur := 10 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for Karpenter nodes deletion aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		nodes, err := ts.listNodePoolNodes()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Karpenter nodes; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("listed Karpenter nodes", zap.Int("nodes", len(nodes)))
+		if len(nodes) == 0 {
+			ts.cfg.Logger.Info("deleted Karpenter nodes")
+			return nil
+		}
+	}
+	return fmt.Errorf("Karpenter nodes for NodePool %q not deleted within %v", ts.cfg.NodePoolName, waitDur)
+}
+
+// terminateInstances terminates the remaining EC2 instances
+// launched for the NodePool, in case the controller failed to clean them up.
+func (ts *tester) terminateInstances() error {
+	ts.cfg.Logger.Info("describing Karpenter instances", zap.String("node-pool", ts.cfg.NodePoolName))
+	var ids []*string
+	err := ts.cfg.EC2API.DescribeInstancesPages(
+		&ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("tag:" + nodePoolLabelKey),
+					Values: aws.StringSlice([]string{ts.cfg.NodePoolName}),
+				},
+				{
+					Name:   aws.String("instance-state-name"),
+					Values: aws.StringSlice([]string{"pending", "running", "stopping", "stopped"}),
+				},
+			},
+		},
+		func(output *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, rv := range output.Reservations {
+				for _, inst := range rv.Instances {
+					ids = append(ids, inst.InstanceId)
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to describe Karpenter instances (%v)", err)
+	}
+	if len(ids) == 0 {
+		ts.cfg.Logger.Info("no Karpenter instance to terminate")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("terminating Karpenter instances", zap.Strings("instance-ids", aws.StringValueSlice(ids)))
+	if _, err = ts.cfg.EC2API.TerminateInstances(&ec2.TerminateInstancesInput{
+		InstanceIds: ids,
+	}); err != nil {
+		return fmt.Errorf("failed to terminate Karpenter instances (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("terminated Karpenter instances")
+	return nil
+}
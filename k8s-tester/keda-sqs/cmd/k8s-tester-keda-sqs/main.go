@@ -0,0 +1,189 @@
+// k8s-tester-keda-sqs installs KEDA and tests event-driven scaling with SQS.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	keda_sqs "github.com/aws/aws-k8s-tester/k8s-tester/keda-sqs"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-keda-sqs",
+	Short:      "Kubernetes KEDA event-driven scaling with SQS tester",
+	SuggestFor: []string{"keda-sqs"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+	partition          string
+	region             string
+	queueName          string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", keda_sqs.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", keda_sqs.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for SQS queue")
+	rootCmd.PersistentFlags().StringVar(&queueName, "queue-name", "", "SQS queue name (default to namespace)")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-keda-sqs failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	helmChartRepoURL string
+	messages         int
+	queueLength      int
+	maxReplicas      int32
+	scaleTimeout     string
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&helmChartRepoURL, "helm-chart-repo-url", keda_sqs.DefaultHelmChartRepoURL, "helm chart repo URL")
+	cmd.PersistentFlags().IntVar(&messages, "messages", keda_sqs.DefaultMessages, "number of messages to send to the queue")
+	cmd.PersistentFlags().IntVar(&queueLength, "queue-length", keda_sqs.DefaultQueueLength, "target number of messages per replica")
+	cmd.PersistentFlags().Int32Var(&maxReplicas, "max-replicas", keda_sqs.DefaultMaxReplicas, "maximum number of replicas")
+	cmd.PersistentFlags().StringVar(&scaleTimeout, "scale-timeout", keda_sqs.DefaultScaleTimeout.String(), "timeout to wait for scale-out and scale-to-zero")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	timeout, err := time.ParseDuration(scaleTimeout)
+	if err != nil {
+		lg.Panic("failed to parse scale timeout", zap.String("scale-timeout", scaleTimeout), zap.Error(err))
+	}
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &keda_sqs.Config{
+		Prompt:           prompt,
+		Logger:           lg,
+		LogWriter:        logWriter,
+		MinimumNodes:     minimumNodes,
+		Namespace:        namespace,
+		Client:           cli,
+		Partition:        partition,
+		Region:           region,
+		HelmChartRepoURL: helmChartRepoURL,
+		QueueName:        queueName,
+		Messages:         messages,
+		QueueLength:      queueLength,
+		MaxReplicas:      maxReplicas,
+		ScaleTimeout:     timeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := keda_sqs.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-keda-sqs apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	if queueName == "" {
+		queueName = namespace
+	}
+	cfg := &keda_sqs.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+		Partition: partition,
+		Region:    region,
+		QueueName: queueName,
+	}
+
+	ts := keda_sqs.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-keda-sqs delete' success\n")
+}
@@ -0,0 +1,838 @@
+// Package keda_sqs installs KEDA, creates an SQS queue, and validates
+// event-driven scale-out and scale-to-zero of a consumer Deployment
+// via the KEDA "aws-sqs-queue" scaler.
+// ref. https://keda.sh/docs/latest/scalers/aws-sqs
+//
+// The KEDA operator uses its own identity ("identityOwner: operator"),
+// thus the node instance role (or the IRSA role of the operator) must
+// allow "sqs:GetQueueAttributes".
+package keda_sqs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	SQSAPI sqsiface.SQSAPI `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// HelmChartRepoURL is the KEDA helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+
+	// QueueName is the name of the SQS queue to create.
+	QueueName string `json:"queue_name"`
+	// QueueURL is the URL of the SQS queue created.
+	QueueURL string `json:"queue_url" read-only:"true"`
+
+	// Messages is the number of messages to send to the queue.
+	Messages int `json:"messages"`
+	// QueueLength is the target number of messages per replica.
+	// The expected number of replicas is "ceil(Messages / QueueLength)",
+	// bounded by "MaxReplicas".
+	QueueLength int `json:"queue_length"`
+	// MaxReplicas is the maximum number of replicas for the consumer Deployment.
+	// The minimum number of replicas is always zero, to validate scale-to-zero.
+	MaxReplicas int32 `json:"max_replicas"`
+	// ScaleTimeout is the timeout to wait for the consumer Deployment to scale out or in.
+	ScaleTimeout       time.Duration `json:"scale_timeout"`
+	ScaleTimeoutString string        `json:"scale_timeout_string" read-only:"true"`
+
+	// ExpectedReplicas is the number of replicas expected from the queue length.
+	ExpectedReplicas int32 `json:"expected_replicas" read-only:"true"`
+	// ObservedReplicas is the number of replicas observed after scale-out.
+	ObservedReplicas int32 `json:"observed_replicas" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.QueueName == "" {
+		cfg.QueueName = cfg.Namespace
+	}
+	// ref. https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_CreateQueue.html
+	if len(cfg.QueueName) > 80 {
+		cfg.QueueName = cfg.QueueName[:80]
+	}
+	if cfg.Messages <= 0 {
+		cfg.Messages = DefaultMessages
+	}
+	if cfg.QueueLength <= 0 {
+		cfg.QueueLength = DefaultQueueLength
+	}
+	if cfg.MaxReplicas == 0 {
+		cfg.MaxReplicas = DefaultMaxReplicas
+	}
+	if cfg.ScaleTimeout == time.Duration(0) {
+		cfg.ScaleTimeout = DefaultScaleTimeout
+	}
+	cfg.ScaleTimeoutString = cfg.ScaleTimeout.String()
+
+	cfg.ExpectedReplicas = int32(math.Ceil(float64(cfg.Messages) / float64(cfg.QueueLength)))
+	if cfg.ExpectedReplicas > cfg.MaxReplicas {
+		cfg.ExpectedReplicas = cfg.MaxReplicas
+	}
+
+	return nil
+}
+
+const chartName = "keda"
+
+const (
+	DefaultMinimumNodes     int   = 1
+	DefaultPartition              = "aws"
+	DefaultHelmChartRepoURL       = "https://github.com/kedacore/charts/releases/download/v2.12.1/keda-2.12.1.tgz"
+	DefaultMessages         int   = 50
+	DefaultQueueLength      int   = 10
+	DefaultMaxReplicas      int32 = 10
+	DefaultScaleTimeout           = 15 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		Partition:        DefaultPartition,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL: DefaultHelmChartRepoURL,
+		Messages:         DefaultMessages,
+		QueueLength:      DefaultQueueLength,
+		MaxReplicas:      DefaultMaxReplicas,
+		ScaleTimeout:     DefaultScaleTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.SQSAPI = sqs.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	deploymentName   = "keda-sqs-consumer"
+	appName          = "keda-sqs-consumer"
+	appImageName     = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	scaledObjectName = "keda-sqs"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createHelmKEDA(); err != nil {
+		return err
+	}
+
+	if err := ts.createQueue(); err != nil {
+		return err
+	}
+
+	if err := ts.createDeployment(); err != nil {
+		return err
+	}
+
+	if err := ts.applyScaledObject(); err != nil {
+		return err
+	}
+
+	if err := ts.sendMessages(); err != nil {
+		return err
+	}
+
+	if err := ts.checkScaleOut(); err != nil {
+		return err
+	}
+
+	if err := ts.drainQueue(); err != nil {
+		return err
+	}
+
+	if err := ts.checkScaleToZero(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := ts.deleteScaledObject(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	ts.cfg.Logger.Info("deleting deployment", zap.String("deployment-name", deploymentName))
+	if err := client.DeleteDeployment(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		deploymentName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Deployment (%v)", err))
+	}
+
+	if err := ts.deleteHelmKEDA(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := ts.deleteQueue(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// https://github.com/kedacore/charts/blob/main/keda/values.yaml
+func (ts *tester) createHelmKEDA() error {
+	values := map[string]interface{}{}
+
+	getAllArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"get",
+		"all",
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        10 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+		Values:         values,
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmKEDA() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+	})
+}
+
+func (ts *tester) createQueue() error {
+	ts.cfg.Logger.Info("creating SQS queue", zap.String("queue-name", ts.cfg.QueueName))
+	out, err := ts.cfg.SQSAPI.CreateQueue(&sqs.CreateQueueInput{
+		QueueName: aws.String(ts.cfg.QueueName),
+		Attributes: map[string]*string{
+			sqs.QueueAttributeNameMessageRetentionPeriod: aws.String("3600"),
+			sqs.QueueAttributeNameVisibilityTimeout:      aws.String("30"),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create SQS queue %q (%v)", ts.cfg.QueueName, err)
+	}
+	ts.cfg.QueueURL = aws.StringValue(out.QueueUrl)
+
+	ts.cfg.Logger.Info("created SQS queue", zap.String("queue-url", ts.cfg.QueueURL))
+	return nil
+}
+
+func (ts *tester) deleteQueue() error {
+	if ts.cfg.QueueURL == "" && ts.cfg.QueueName != "" {
+		out, err := ts.cfg.SQSAPI.GetQueueUrl(&sqs.GetQueueUrlInput{
+			QueueName: aws.String(ts.cfg.QueueName),
+		})
+		if err != nil {
+			ev, ok := err.(awserr.Error)
+			if ok && ev.Code() == sqs.ErrCodeQueueDoesNotExist {
+				ts.cfg.Logger.Info("SQS queue not found; skipping queue deletion", zap.String("queue-name", ts.cfg.QueueName))
+				return nil
+			}
+			return fmt.Errorf("failed to get SQS queue URL %q (%v)", ts.cfg.QueueName, err)
+		}
+		ts.cfg.QueueURL = aws.StringValue(out.QueueUrl)
+	}
+	if ts.cfg.QueueURL == "" {
+		ts.cfg.Logger.Info("empty SQS queue URL; skipping queue deletion")
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting SQS queue", zap.String("queue-url", ts.cfg.QueueURL))
+	_, err := ts.cfg.SQSAPI.DeleteQueue(&sqs.DeleteQueueInput{
+		QueueUrl: aws.String(ts.cfg.QueueURL),
+	})
+	if err != nil {
+		ev, ok := err.(awserr.Error)
+		if ok && ev.Code() == sqs.ErrCodeQueueDoesNotExist {
+			ts.cfg.Logger.Info("SQS queue already deleted", zap.String("error-code", ev.Code()))
+			return nil
+		}
+		return fmt.Errorf("failed to delete SQS queue %q (%v)", ts.cfg.QueueURL, err)
+	}
+
+	ts.cfg.Logger.Info("deleted SQS queue", zap.String("queue-url", ts.cfg.QueueURL))
+	return nil
+}
+
+// sendMessages sends the messages in batches of 10, the maximum allowed by SQS.
+func (ts *tester) sendMessages() error {
+	ts.cfg.Logger.Info("sending messages", zap.Int("messages", ts.cfg.Messages))
+	sent := 0
+	for sent < ts.cfg.Messages {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("send messages aborted")
+		default:
+		}
+
+		entries := make([]*sqs.SendMessageBatchRequestEntry, 0, 10)
+		for i := sent; i < ts.cfg.Messages && len(entries) < 10; i++ {
+			entries = append(entries, &sqs.SendMessageBatchRequestEntry{
+				Id:          aws.String(fmt.Sprintf("msg-%d", i)),
+				MessageBody: aws.String(fmt.Sprintf("k8s-tester message %d", i)),
+			})
+		}
+		out, err := ts.cfg.SQSAPI.SendMessageBatch(&sqs.SendMessageBatchInput{
+			QueueUrl: aws.String(ts.cfg.QueueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send messages (%v)", err)
+		}
+		if len(out.Failed) > 0 {
+			return fmt.Errorf("failed to send %d messages (first error %q)", len(out.Failed), aws.StringValue(out.Failed[0].Message))
+		}
+		sent += len(entries)
+	}
+
+	ts.cfg.Logger.Info("sent messages", zap.Int("messages", sent))
+	return nil
+}
+
+// drainQueue receives and deletes all messages, so that KEDA scales in the consumer.
+// The consumer Deployment does not process messages, which keeps the queue length
+// deterministic while checking scale-out.
+func (ts *tester) drainQueue() error {
+	ts.cfg.Logger.Info("draining SQS queue", zap.String("queue-url", ts.cfg.QueueURL))
+	deleted := 0
+	retryStart, waitDur := time.Now(), 5*time.Minute
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("drain queue aborted")
+		default:
+		}
+
+		out, err := ts.cfg.SQSAPI.ReceiveMessage(&sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(ts.cfg.QueueURL),
+			MaxNumberOfMessages: aws.Int64(10),
+			WaitTimeSeconds:     aws.Int64(5),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to receive messages; retrying", zap.Error(err))
+			continue
+		}
+		if len(out.Messages) == 0 {
+			ts.cfg.Logger.Info("drained SQS queue", zap.Int("deleted", deleted))
+			return nil
+		}
+
+		entries := make([]*sqs.DeleteMessageBatchRequestEntry, 0, len(out.Messages))
+		for _, msg := range out.Messages {
+			entries = append(entries, &sqs.DeleteMessageBatchRequestEntry{
+				Id:            msg.MessageId,
+				ReceiptHandle: msg.ReceiptHandle,
+			})
+		}
+		dout, err := ts.cfg.SQSAPI.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(ts.cfg.QueueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to delete messages; retrying", zap.Error(err))
+			continue
+		}
+		deleted += len(dout.Successful)
+	}
+
+	return fmt.Errorf("failed to drain SQS queue %q within %v (deleted %d)", ts.cfg.QueueURL, waitDur, deleted)
+}
+
+func (ts *tester) createDeployment() error {
+	var replicas int32
+	ts.cfg.Logger.Info("creating consumer Deployment", zap.Int32("replicas", replicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": appName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": appName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            appName,
+									Image:           appImageName,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("consumer Deployment already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create consumer Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created consumer Deployment")
+	return nil
+}
+
+// ref. https://keda.sh/docs/latest/scalers/aws-sqs
+const scaledObjectTmpl = `
+apiVersion: keda.sh/v1alpha1
+kind: ScaledObject
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+spec:
+  scaleTargetRef:
+    name: {{ .DeploymentName }}
+  minReplicaCount: 0
+  maxReplicaCount: {{ .MaxReplicas }}
+  pollingInterval: 15
+  cooldownPeriod: 60
+  triggers:
+  - type: aws-sqs-queue
+    metadata:
+      queueURL: {{ .QueueURL }}
+      queueLength: "{{ .QueueLength }}"
+      awsRegion: {{ .Region }}
+      identityOwner: operator
+`
+
+func (ts *tester) scaledObjectPath() (string, error) {
+	tpl := template.Must(template.New("scaledObjectTmpl").Parse(scaledObjectTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, struct {
+		Name           string
+		Namespace      string
+		DeploymentName string
+		MaxReplicas    int32
+		QueueURL       string
+		QueueLength    int
+		Region         string
+	}{
+		Name:           scaledObjectName,
+		Namespace:      ts.cfg.Namespace,
+		DeploymentName: deploymentName,
+		MaxReplicas:    ts.cfg.MaxReplicas,
+		QueueURL:       ts.cfg.QueueURL,
+		QueueLength:    ts.cfg.QueueLength,
+		Region:         ts.cfg.Region,
+	}); err != nil {
+		return "", err
+	}
+	return file.WriteTempFile(buf.Bytes())
+}
+
+func (ts *tester) applyScaledObject() (err error) {
+	fpath, err := ts.scaledObjectPath()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to write ScaledObject YAML", zap.Error(err))
+		return err
+	}
+	ts.cfg.Logger.Info("applying ScaledObject YAML", zap.String("path", fpath))
+
+	applyArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"apply",
+		"--filename=" + fpath,
+	}
+	applyCmd := strings.Join(applyArgs, " ")
+
+	var output []byte
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create ScaledObject aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		output, err = exec.New().CommandContext(ctx, applyArgs[0], applyArgs[1:]...).CombinedOutput()
+		cancel()
+		out := string(output)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", applyCmd, out)
+		if err == nil {
+			break
+		}
+		if strings.Contains(out, " created") || strings.Contains(out, " unchanged") {
+			err = nil
+			break
+		}
+
+		ts.cfg.Logger.Warn("create ScaledObject failed", zap.Error(err))
+	}
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+	}
+
+	ts.cfg.Logger.Info("created ScaledObject")
+	return nil
+}
+
+func (ts *tester) deleteScaledObject() error {
+	ts.cfg.Logger.Info("deleting ScaledObject", zap.String("name", scaledObjectName))
+	deleteArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"delete",
+		"scaledobject.keda.sh",
+		scaledObjectName,
+		"--ignore-not-found=true",
+	}
+	deleteCmd := strings.Join(deleteArgs, " ")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := exec.New().CommandContext(ctx, deleteArgs[0], deleteArgs[1:]...).CombinedOutput()
+	cancel()
+	out := string(output)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", deleteCmd, out)
+	if err != nil {
+		// CRD may have been removed already
+		if strings.Contains(out, "the server doesn't have a resource type") {
+			return nil
+		}
+		return fmt.Errorf("'kubectl delete' failed %v (output %q)", err, out)
+	}
+
+	ts.cfg.Logger.Info("deleted ScaledObject")
+	return nil
+}
+
+func (ts *tester) getDeploymentReplicas() (desired int32, current int32, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	dp, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Get(ctx, deploymentName, meta_v1.GetOptions{})
+	cancel()
+	if err != nil {
+		return 0, 0, err
+	}
+	if dp.Spec.Replicas != nil {
+		desired = *dp.Spec.Replicas
+	}
+	return desired, dp.Status.Replicas, nil
+}
+
+// checkScaleOut waits until the consumer Deployment scales out
+// to the expected number of replicas.
+func (ts *tester) checkScaleOut() error {
+	ts.cfg.Logger.Info("waiting for scale-out",
+		zap.Int32("expected-replicas", ts.cfg.ExpectedReplicas),
+		zap.Duration("timeout", ts.cfg.ScaleTimeout),
+	)
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.ScaleTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check scale-out aborted")
+		case <-time.After(20 * time.Second):
+		}
+
+		desired, current, err := ts.getDeploymentReplicas()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Deployment; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.ObservedReplicas = current
+
+		ts.cfg.Logger.Info("checked Deployment replicas",
+			zap.Int32("desired", desired),
+			zap.Int32("current", current),
+			zap.Int32("expected", ts.cfg.ExpectedReplicas),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+		if desired >= ts.cfg.ExpectedReplicas {
+			ts.cfg.Logger.Info("scaled out by SQS queue length")
+			return ts.checkDeployment(desired)
+		}
+	}
+
+	return fmt.Errorf("Deployment %q did not scale to %d replicas (observed %d) within %v",
+		deploymentName, ts.cfg.ExpectedReplicas, ts.cfg.ObservedReplicas, ts.cfg.ScaleTimeout)
+}
+
+func (ts *tester) checkDeployment(replicas int32) error {
+	timeout := 7*time.Minute + time.Duration(replicas)*time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	_, err := client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		time.Minute,
+		20*time.Second,
+		ts.cfg.Namespace,
+		deploymentName,
+		replicas,
+	)
+	cancel()
+	return err
+}
+
+// checkScaleToZero waits until the consumer Deployment scales in to zero
+// after the queue has been drained.
+func (ts *tester) checkScaleToZero() error {
+	ts.cfg.Logger.Info("waiting for scale-to-zero", zap.Duration("timeout", ts.cfg.ScaleTimeout))
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.ScaleTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check scale-to-zero aborted")
+		case <-time.After(20 * time.Second):
+		}
+
+		desired, current, err := ts.getDeploymentReplicas()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Deployment; retrying", zap.Error(err))
+			continue
+		}
+
+		ts.cfg.Logger.Info("checked Deployment replicas",
+			zap.Int32("desired", desired),
+			zap.Int32("current", current),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+		if desired == 0 && current == 0 {
+			ts.cfg.Logger.Info("scaled to zero")
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Deployment %q did not scale to zero within %v", deploymentName, ts.cfg.ScaleTimeout)
+}
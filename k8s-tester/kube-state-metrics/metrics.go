@@ -0,0 +1,49 @@
+package kube_state_metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+// SeriesResult is the number of samples of a required metric family.
+type SeriesResult struct {
+	// Name is the metric family name (e.g., "kube_pod_status_phase").
+	Name string `json:"name"`
+	// Samples is the number of samples of the metric family.
+	Samples int `json:"samples"`
+}
+
+// parseSamples parses the Prometheus text exposition format,
+// and returns the number of samples by metric family name.
+// kube-state-metrics exposes the HELP and TYPE of the families
+// even when the resources cannot be listed (e.g., missing RBAC),
+// so the families without samples are counted as zero.
+func parseSamples(b string) (map[string]int, error) {
+	var parser expfmt.TextParser
+	mfs, err := parser.TextToMetricFamilies(strings.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics (%v)", err)
+	}
+	samples := make(map[string]int, len(mfs))
+	for name, mf := range mfs {
+		samples[name] = len(mf.GetMetric())
+	}
+	return samples, nil
+}
+
+// checkSeries returns the samples of the required metric families,
+// and the ones without any sample.
+func checkSeries(samples map[string]int, required []string) (rs []SeriesResult, missing []string) {
+	for _, name := range required {
+		n := samples[name]
+		rs = append(rs, SeriesResult{Name: name, Samples: n})
+		if n == 0 {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return rs, missing
+}
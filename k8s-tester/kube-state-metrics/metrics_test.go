@@ -0,0 +1,46 @@
+package kube_state_metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSamples(t *testing.T) {
+	b := `# HELP kube_node_info Information about a cluster node.
+# TYPE kube_node_info gauge
+kube_node_info{node="a",kernel_version="5.10"} 1
+kube_node_info{node="b",kernel_version="5.10"} 1
+# HELP kube_pod_status_phase The pods current phase.
+# TYPE kube_pod_status_phase gauge
+kube_pod_status_phase{namespace="default",pod="a",phase="Running"} 1
+kube_pod_status_phase{namespace="default",pod="a",phase="Pending"} 0
+kube_pod_status_phase{namespace="default",pod="a",phase="Failed"} 0
+# HELP kube_deployment_created Unix creation timestamp
+# TYPE kube_deployment_created gauge
+`
+	samples, err := parseSamples(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples["kube_node_info"] != 2 || samples["kube_pod_status_phase"] != 3 || samples["kube_deployment_created"] != 0 {
+		t.Fatalf("unexpected samples %v", samples)
+	}
+
+	rs, missing := checkSeries(samples, []string{"kube_pod_status_phase", "kube_node_info", "kube_deployment_created", "kube_unknown"})
+	expected := []SeriesResult{
+		{Name: "kube_pod_status_phase", Samples: 3},
+		{Name: "kube_node_info", Samples: 2},
+		{Name: "kube_deployment_created", Samples: 0},
+		{Name: "kube_unknown", Samples: 0},
+	}
+	if !reflect.DeepEqual(rs, expected) {
+		t.Fatalf("expected %v, got %v", expected, rs)
+	}
+	if !reflect.DeepEqual(missing, []string{"kube_deployment_created", "kube_unknown"}) {
+		t.Fatalf("unexpected missing %v", missing)
+	}
+
+	if _, err = parseSamples("kube_node_info{node=\"a\" 1\n"); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
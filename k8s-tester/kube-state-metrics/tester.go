@@ -0,0 +1,455 @@
+// Package kube_state_metrics installs kube-state-metrics, scrapes its "/metrics" endpoint
+// from a test Pod, and validates the required series exist, to catch the RBAC or
+// the version skew issues on new clusters.
+// ref. https://github.com/kubernetes/kube-state-metrics
+// ref. https://github.com/prometheus-community/helm-charts/tree/main/charts/kube-state-metrics
+package kube_state_metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+	// HelmChartRepoURL is the helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+	// HelmChartVersion is the kube-state-metrics helm chart version.
+	// If empty, the latest chart is installed.
+	HelmChartVersion string `json:"helm_chart_version"`
+
+	// ScraperImage is the scraper Pod image with "wget".
+	ScraperImage string `json:"scraper_image"`
+	// ScrapeTimeout is the timeout for the scraper Pod to complete.
+	ScrapeTimeout       time.Duration `json:"scrape_timeout"`
+	ScrapeTimeoutString string        `json:"scrape_timeout_string" read-only:"true"`
+	// RequiredSeries is the list of metric families that must have at least one sample.
+	RequiredSeries []string `json:"required_series"`
+
+	// Series is the number of samples of the required metric families.
+	Series []SeriesResult `json:"series" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.ScraperImage == "" {
+		cfg.ScraperImage = DefaultScraperImage
+	}
+	if cfg.ScrapeTimeout == time.Duration(0) {
+		cfg.ScrapeTimeout = DefaultScrapeTimeout
+	}
+	cfg.ScrapeTimeoutString = cfg.ScrapeTimeout.String()
+	if len(cfg.RequiredSeries) == 0 {
+		cfg.RequiredSeries = DefaultRequiredSeries()
+	}
+	return nil
+}
+
+const (
+	chartRepoName = "prometheus-community"
+	chartName     = "kube-state-metrics"
+
+	// service name from the release name "kube-state-metrics"
+	serviceName = chartName
+	servicePort = 8080
+
+	jobName = "kube-state-metrics-scraper"
+)
+
+const (
+	DefaultMinimumNodes     int = 1
+	DefaultHelmChartRepoURL     = "https://prometheus-community.github.io/helm-charts"
+	DefaultScraperImage         = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultScrapeTimeout        = 5 * time.Minute
+)
+
+// DefaultRequiredSeries returns the metric families that kube-state-metrics
+// exposes with samples on any running cluster.
+func DefaultRequiredSeries() []string {
+	return []string{
+		"kube_node_info",
+		"kube_pod_status_phase",
+		"kube_namespace_status_phase",
+		"kube_deployment_status_replicas",
+	}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL: DefaultHelmChartRepoURL,
+		ScraperImage:     DefaultScraperImage,
+		ScrapeTimeout:    DefaultScrapeTimeout,
+		RequiredSeries:   DefaultRequiredSeries(),
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := helm.AddUpdate(ts.cfg.Logger, chartRepoName, ts.cfg.HelmChartRepoURL); err != nil {
+		return err
+	}
+	if err := ts.installChart(); err != nil {
+		return err
+	}
+	if err := ts.createJob(); err != nil {
+		return err
+	}
+	return ts.checkSeries()
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteJob(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		jobName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+	}
+
+	if err := ts.deleteHelm(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// https://github.com/prometheus-community/helm-charts/blob/main/charts/kube-state-metrics/values.yaml
+func (ts *tester) helmValues() map[string]interface{} {
+	return map[string]interface{}{
+		"service": map[string]interface{}{
+			"port": servicePort,
+		},
+	}
+}
+
+func (ts *tester) installChart() error {
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        10 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.HelmChartVersion,
+		ReleaseName:    chartName,
+		Values:         ts.helmValues(),
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			getAllArgs := []string{
+				ts.cfg.Client.Config().KubectlPath,
+				"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+				"--namespace=" + ts.cfg.Namespace,
+				"get",
+				"all",
+			}
+			getAllCmd := strings.Join(getAllArgs, " ")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelm() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        10 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+	})
+}
+
+// scrapeScript fetches the metrics, retrying while the Service endpoints are not ready,
+// and prints only the metrics so that the Pod logs can be parsed as is.
+func scrapeScript(url string) string {
+	return fmt.Sprintf(`for i in $(seq 1 30); do
+  if wget -q -T 30 -O /tmp/metrics %s; then
+    cat /tmp/metrics
+    exit 0
+  fi
+  sleep 5
+done
+exit 1
+`, url)
+}
+
+func (ts *tester) createJob() error {
+	url := fmt.Sprintf("http://%s.%s.svc.cluster.local:%d/metrics", serviceName, ts.cfg.Namespace, servicePort)
+	ts.cfg.Logger.Info("creating scraper Job", zap.String("url", url))
+	backoffLimit := int32(2)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           ts.cfg.ScraperImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c"},
+									Args:            []string{scrapeScript(url)},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("scraper Job already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create scraper Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created scraper Job")
+	return nil
+}
+
+func (ts *tester) checkSeries() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.ScrapeTimeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		10*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("scraper Job not completed (%v)", err)
+	}
+
+	var podName string
+	for _, pod := range pods {
+		if pod.Status.Phase == core_v1.PodSucceeded {
+			podName = pod.Name
+			break
+		}
+	}
+	if podName == "" {
+		return errors.New("no succeeded scraper Pod")
+	}
+	logs, err := ts.readPodLogs(podName)
+	if err != nil {
+		return fmt.Errorf("failed to read scraper Pod %q logs (%v)", podName, err)
+	}
+	samples, err := parseSamples(logs)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	ts.cfg.Series, missing = checkSeries(samples, ts.cfg.RequiredSeries)
+	fmt.Fprintf(ts.cfg.LogWriter, "\nkube-state-metrics series (%d metric families):\n\n", len(samples))
+	for _, s := range ts.cfg.Series {
+		fmt.Fprintf(ts.cfg.LogWriter, "  %-40s %d samples\n", s.Name, s.Samples)
+	}
+	fmt.Fprintln(ts.cfg.LogWriter)
+
+	if len(missing) > 0 {
+		return fmt.Errorf("kube-state-metrics series %q have no sample (missing RBAC or unsupported version?)", missing)
+	}
+	return nil
+}
+
+func (ts *tester) readPodLogs(podName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rc, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		GetLogs(podName, &core_v1.PodLogOptions{Container: jobName}).
+		Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
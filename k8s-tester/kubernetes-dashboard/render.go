@@ -0,0 +1,22 @@
+package kubernetes_dashboard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the Kubernetes Dashboard and the eks-admin ServiceAccount manifests, as applied with kubectl.
+func (ts *tester) Render(w io.Writer) error {
+	for _, s := range []string{dashboardYAML, eksAdminYAML} {
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "\n"), "---\n")
+		if _, err := fmt.Fprintf(w, "---\n%s", s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package kueue
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the Kueue queues and the Jobs, against a fake client.
+// The Kueue helm chart is installed with its default values.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	b, err := rt.queuesYAML()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(b); err != nil {
+		return err
+	}
+	for i := 0; i < cfg.BurstJobs; i++ {
+		if err = rt.createJob(lowPriorityJobName(i), cfg.Namespace+"-low"); err != nil {
+			return err
+		}
+	}
+	if err = rt.createJob(highPriorityJobName, cfg.Namespace+"-high"); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -0,0 +1,785 @@
+// Package kueue installs Kueue, configures a ClusterQueue and a LocalQueue with
+// a CPU quota, submits a burst of Jobs exceeding the quota, and validates the
+// quota enforcement, the FIFO admission order, and the preemption of the
+// lower priority Jobs by a higher priority Job.
+// ref. https://kueue.sigs.k8s.io/docs/overview/
+package kueue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	// Also names the cluster-scoped Kueue resources.
+	Namespace string `json:"namespace"`
+
+	// HelmChartRepoURL is the Kueue helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+	// HelmChartVersion is the Kueue helm chart version.
+	HelmChartVersion string `json:"helm_chart_version"`
+
+	// QuotaCPU is the CPU nominal quota of the ClusterQueue (e.g., "2").
+	QuotaCPU string `json:"quota_cpu"`
+	// JobCPU is the CPU request of each Job (e.g., "500m").
+	// The quota admits "QuotaCPU / JobCPU" Jobs at a time.
+	JobCPU string `json:"job_cpu"`
+	// BurstJobs is the number of the low priority Jobs submitted at once.
+	// Must exceed the number of the Jobs the quota admits at a time.
+	BurstJobs int `json:"burst_jobs"`
+	// JobImage is the image of the Job Pods, which must have "sleep".
+	JobImage string `json:"job_image"`
+	// JobDuration is how long each Job runs.
+	// Must be long enough for the high priority Job to find the quota in use.
+	JobDuration       time.Duration `json:"job_duration"`
+	JobDurationString string        `json:"job_duration_string" read-only:"true"`
+	// Timeout is the timeout for all Jobs to complete.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// MaxAdmittedJobs is the maximum number of the Jobs observed admitted at a time.
+	MaxAdmittedJobs int `json:"max_admitted_jobs" read-only:"true"`
+	// Preemptions is the number of the admitted Jobs observed suspended again.
+	Preemptions int `json:"preemptions" read-only:"true"`
+	// AdmissionOrder is the names of the Jobs in the order of their first admission.
+	AdmissionOrder []string `json:"admission_order" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.HelmChartVersion == "" {
+		cfg.HelmChartVersion = DefaultHelmChartVersion
+	}
+	if cfg.QuotaCPU == "" {
+		cfg.QuotaCPU = DefaultQuotaCPU
+	}
+	if cfg.JobCPU == "" {
+		cfg.JobCPU = DefaultJobCPU
+	}
+	if cfg.BurstJobs == 0 {
+		cfg.BurstJobs = DefaultBurstJobs
+	}
+	capacity, err := cfg.capacity()
+	if err != nil {
+		return err
+	}
+	if cfg.BurstJobs <= capacity {
+		return fmt.Errorf("BurstJobs %d must exceed the %d Jobs the quota admits at a time", cfg.BurstJobs, capacity)
+	}
+	if cfg.JobImage == "" {
+		cfg.JobImage = DefaultJobImage
+	}
+	if cfg.JobDuration == time.Duration(0) {
+		cfg.JobDuration = DefaultJobDuration
+	}
+	if cfg.JobDuration < MinJobDuration {
+		return fmt.Errorf("JobDuration %v must be at least %v", cfg.JobDuration, MinJobDuration)
+	}
+	cfg.JobDurationString = cfg.JobDuration.String()
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+// capacity returns the number of the Jobs the quota admits at a time.
+func (cfg *Config) capacity() (int, error) {
+	quota, err := resource.ParseQuantity(cfg.QuotaCPU)
+	if err != nil {
+		return 0, fmt.Errorf("invalid QuotaCPU %q (%v)", cfg.QuotaCPU, err)
+	}
+	job, err := resource.ParseQuantity(cfg.JobCPU)
+	if err != nil {
+		return 0, fmt.Errorf("invalid JobCPU %q (%v)", cfg.JobCPU, err)
+	}
+	if job.MilliValue() <= 0 {
+		return 0, fmt.Errorf("invalid JobCPU %q", cfg.JobCPU)
+	}
+	capacity := int(quota.MilliValue() / job.MilliValue())
+	if capacity < 1 {
+		return 0, fmt.Errorf("QuotaCPU %q admits no Job of JobCPU %q", cfg.QuotaCPU, cfg.JobCPU)
+	}
+	return capacity, nil
+}
+
+const (
+	DefaultMinimumNodes     int = 1
+	DefaultHelmChartRepoURL     = "oci://registry.k8s.io/kueue/charts"
+	DefaultHelmChartVersion     = "0.13.4"
+	DefaultQuotaCPU             = "2"
+	DefaultJobCPU               = "500m"
+	DefaultBurstJobs        int = 8
+	DefaultJobImage             = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultJobDuration          = time.Minute
+	DefaultTimeout              = 15 * time.Minute
+
+	MinJobDuration = 30 * time.Second
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL: DefaultHelmChartRepoURL,
+		HelmChartVersion: DefaultHelmChartVersion,
+		QuotaCPU:         DefaultQuotaCPU,
+		JobCPU:           DefaultJobCPU,
+		BurstJobs:        DefaultBurstJobs,
+		JobImage:         DefaultJobImage,
+		JobDuration:      DefaultJobDuration,
+		Timeout:          DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	chartName      = "kueue"
+	localQueueName = "kueue-tester"
+	appLabel       = "app.kubernetes.io/name"
+	appName        = "kueue-tester"
+
+	queueNameLabel     = "kueue.x-k8s.io/queue-name"
+	priorityClassLabel = "kueue.x-k8s.io/priority-class"
+
+	highPriorityJobName = "high-0"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createHelmKueue(); err != nil {
+		return err
+	}
+
+	if err := ts.applyQueues(); err != nil {
+		return err
+	}
+
+	if err := ts.createBurstJobs(); err != nil {
+		return err
+	}
+
+	if err := ts.checkJobs(); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("Kueue Jobs completed",
+		zap.Int("max-admitted-jobs", ts.cfg.MaxAdmittedJobs),
+		zap.Int("preemptions", ts.cfg.Preemptions),
+		zap.Strings("admission-order", ts.cfg.AdmissionOrder),
+	)
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	// delete the Jobs and queues while the controller is still running,
+	// to remove the finalizers of the Kueue resources
+	if err := ts.deleteQueues(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := ts.deleteHelmKueue(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// https://github.com/kubernetes-sigs/kueue/blob/main/charts/kueue/values.yaml
+func (ts *tester) createHelmKueue() error {
+	getAllArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"get",
+		"all",
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        10 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.HelmChartVersion,
+		ReleaseName:    chartName,
+		Values:         map[string]interface{}{},
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmKueue() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+	})
+}
+
+// The cluster-scoped resources are named after the namespace,
+// and the ClusterQueue only serves the namespace.
+// "StrictFIFO" admits the Jobs of the same priority in the creation order,
+// and "LowerPriority" lets the high priority Job preempt the admitted ones.
+// ref. https://kueue.sigs.k8s.io/docs/concepts/cluster_queue/
+// ref. https://kueue.sigs.k8s.io/docs/concepts/preemption/
+const queuesTmpl = `---
+apiVersion: kueue.x-k8s.io/v1beta1
+kind: ResourceFlavor
+metadata:
+  name: {{ .Name }}
+---
+apiVersion: kueue.x-k8s.io/v1beta1
+kind: WorkloadPriorityClass
+metadata:
+  name: {{ .Name }}-low
+value: 100
+---
+apiVersion: kueue.x-k8s.io/v1beta1
+kind: WorkloadPriorityClass
+metadata:
+  name: {{ .Name }}-high
+value: 1000
+---
+apiVersion: kueue.x-k8s.io/v1beta1
+kind: ClusterQueue
+metadata:
+  name: {{ .Name }}
+spec:
+  namespaceSelector:
+    matchLabels:
+      kubernetes.io/metadata.name: {{ .Namespace }}
+  queueingStrategy: StrictFIFO
+  preemption:
+    withinClusterQueue: LowerPriority
+  resourceGroups:
+  - coveredResources: ["cpu"]
+    flavors:
+    - name: {{ .Name }}
+      resources:
+      - name: cpu
+        nominalQuota: {{ .QuotaCPU }}
+---
+apiVersion: kueue.x-k8s.io/v1beta1
+kind: LocalQueue
+metadata:
+  name: {{ .LocalQueueName }}
+  namespace: {{ .Namespace }}
+spec:
+  clusterQueue: {{ .Name }}
+`
+
+func (ts *tester) queuesYAML() ([]byte, error) {
+	tpl := template.Must(template.New("queuesTmpl").Parse(queuesTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, struct {
+		Name           string
+		Namespace      string
+		LocalQueueName string
+		QuotaCPU       string
+	}{
+		Name:           ts.cfg.Namespace,
+		Namespace:      ts.cfg.Namespace,
+		LocalQueueName: localQueueName,
+		QuotaCPU:       fmt.Sprintf("%q", ts.cfg.QuotaCPU),
+	}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (ts *tester) applyQueues() (err error) {
+	b, err := ts.queuesYAML()
+	if err != nil {
+		return err
+	}
+	fpath, err := file.WriteTempFile(b)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to write queues YAML", zap.Error(err))
+		return err
+	}
+	ts.cfg.Logger.Info("applying queues YAML", zap.String("path", fpath))
+
+	applyArgs := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"apply",
+		"--filename=" + fpath,
+	}
+	applyCmd := strings.Join(applyArgs, " ")
+
+	// the Kueue webhook may not serve right after the install
+	var output []byte
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create queues aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		output, err = exec.New().CommandContext(ctx, applyArgs[0], applyArgs[1:]...).CombinedOutput()
+		cancel()
+		out := string(output)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", applyCmd, out)
+		if err == nil {
+			break
+		}
+
+		ts.cfg.Logger.Warn("create queues failed", zap.Error(err))
+	}
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+	}
+
+	ts.cfg.Logger.Info("created queues", zap.String("cluster-queue", ts.cfg.Namespace), zap.String("local-queue", localQueueName))
+	return nil
+}
+
+func (ts *tester) deleteQueues() error {
+	var errs []string
+	for _, args := range [][]string{
+		{"--namespace=" + ts.cfg.Namespace, "delete", "jobs", "--selector=" + appLabel + "=" + appName},
+		{"--namespace=" + ts.cfg.Namespace, "delete", "localqueues.kueue.x-k8s.io", localQueueName},
+		{"delete", "clusterqueues.kueue.x-k8s.io", ts.cfg.Namespace},
+		{"delete", "resourceflavors.kueue.x-k8s.io", ts.cfg.Namespace},
+		{"delete", "workloadpriorityclasses.kueue.x-k8s.io", ts.cfg.Namespace + "-low", ts.cfg.Namespace + "-high"},
+	} {
+		deleteArgs := append([]string{
+			ts.cfg.Client.Config().KubectlPath,
+			"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		}, args...)
+		deleteArgs = append(deleteArgs, "--ignore-not-found=true", "--cascade=foreground", "--timeout=5m")
+		deleteCmd := strings.Join(deleteArgs, " ")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 6*time.Minute)
+		output, err := exec.New().CommandContext(ctx, deleteArgs[0], deleteArgs[1:]...).CombinedOutput()
+		cancel()
+		out := string(output)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"%s\" output:\n%s\n", deleteCmd, out)
+		if err != nil {
+			// CRD may have been removed already
+			if strings.Contains(out, "the server doesn't have a resource type") {
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("'kubectl delete' failed %v (output %q)", err, out))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.Logger.Info("deleted queues", zap.String("cluster-queue", ts.cfg.Namespace))
+	return nil
+}
+
+func lowPriorityJobName(idx int) string { return fmt.Sprintf("low-%d", idx) }
+
+// createBurstJobs creates the low priority Jobs, a second apart,
+// so that their creation timestamps define the FIFO order.
+func (ts *tester) createBurstJobs() error {
+	for i := 0; i < ts.cfg.BurstJobs; i++ {
+		if i > 0 {
+			select {
+			case <-ts.cfg.Stopc:
+				return errors.New("create Jobs aborted")
+			case <-time.After(time.Second):
+			}
+		}
+		if err := ts.createJob(lowPriorityJobName(i), ts.cfg.Namespace+"-low"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *tester) createJob(name string, priorityClass string) error {
+	ts.cfg.Logger.Info("creating Job", zap.String("name", name), zap.String("priority-class", priorityClass))
+	backoffLimit := int32(2)
+	suspend := true
+	gracePeriod := int64(0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						appLabel:           appName,
+						queueNameLabel:     localQueueName,
+						priorityClassLabel: priorityClass,
+					},
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					// Kueue unsuspends the Job when admitted
+					Suspend: &suspend,
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: appName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy:                 core_v1.RestartPolicyNever,
+							TerminationGracePeriodSeconds: &gracePeriod,
+							Containers: []core_v1.Container{
+								{
+									Name:            appName,
+									Image:           ts.cfg.JobImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"sleep", fmt.Sprintf("%d", int64(ts.cfg.JobDuration.Seconds()))},
+									Resources: core_v1.ResourceRequirements{
+										Requests: core_v1.ResourceList{
+											core_v1.ResourceCPU: resource.MustParse(ts.cfg.JobCPU),
+										},
+									},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Job %q (%v)", name, err)
+	}
+	return nil
+}
+
+// checkJobs polls the Jobs until all complete. It fails if the admitted Jobs
+// ever exceed the quota. Once the quota is full with Jobs pending, it submits
+// the high priority Job, which must be admitted by preempting a low priority Job
+// ahead of the pending ones. The low priority Jobs must be first admitted in
+// the creation order.
+func (ts *tester) checkJobs() error {
+	capacity, err := ts.cfg.capacity()
+	if err != nil {
+		return err
+	}
+	total := ts.cfg.BurstJobs + 1
+
+	firstAdmitted := make(map[string]int)
+	prevAdmitted := make(map[string]bool)
+	pendingAtSubmit := []string(nil)
+	highSubmitted, completed := false, false
+
+	retryStart := time.Now()
+	for round := 0; time.Since(retryStart) < ts.cfg.Timeout; round++ {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check Jobs aborted")
+		case <-time.After(2 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		jobs, err := ts.cfg.Client.KubernetesClient().
+			BatchV1().
+			Jobs(ts.cfg.Namespace).
+			List(ctx, meta_v1.ListOptions{LabelSelector: appLabel + "=" + appName})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Jobs; retrying", zap.Error(err))
+			continue
+		}
+
+		st := summarizeJobs(jobs.Items)
+		if len(st.failed) > 0 {
+			return fmt.Errorf("Jobs failed %v", st.failed)
+		}
+		if len(st.admitted) > capacity {
+			return fmt.Errorf("%d Jobs admitted %v, exceeding the quota of %d Jobs", len(st.admitted), st.admitted, capacity)
+		}
+		if len(st.admitted) > ts.cfg.MaxAdmittedJobs {
+			ts.cfg.MaxAdmittedJobs = len(st.admitted)
+		}
+
+		admitted := make(map[string]bool, len(st.admitted))
+		for _, name := range st.admitted {
+			admitted[name] = true
+			if _, ok := firstAdmitted[name]; !ok {
+				firstAdmitted[name] = round
+				ts.cfg.AdmissionOrder = append(ts.cfg.AdmissionOrder, name)
+			}
+		}
+		for _, name := range st.pending {
+			if prevAdmitted[name] {
+				ts.cfg.Preemptions++
+				ts.cfg.Logger.Info("Job preempted", zap.String("name", name))
+			}
+		}
+		prevAdmitted = admitted
+
+		ts.cfg.Logger.Info("checked Jobs",
+			zap.Int("admitted", len(st.admitted)),
+			zap.Int("pending", len(st.pending)),
+			zap.Int("finished", st.finished),
+			zap.Int("preemptions", ts.cfg.Preemptions),
+		)
+
+		if !highSubmitted && len(st.admitted) == capacity && len(st.pending) > 0 {
+			if err = ts.createJob(highPriorityJobName, ts.cfg.Namespace+"-high"); err != nil {
+				return err
+			}
+			highSubmitted = true
+			pendingAtSubmit = st.pending
+		}
+		if st.finished == total {
+			completed = true
+			break
+		}
+	}
+	if !highSubmitted {
+		return errors.New("quota never filled up to submit the high priority Job")
+	}
+	if !completed {
+		return fmt.Errorf("Jobs not completed within %v", ts.cfg.Timeout)
+	}
+
+	if ts.cfg.Preemptions == 0 {
+		return errors.New("high priority Job admitted without preempting any low priority Job")
+	}
+	for _, name := range pendingAtSubmit {
+		if firstAdmitted[name] < firstAdmitted[highPriorityJobName] {
+			return fmt.Errorf("pending low priority Job %q admitted before the high priority Job", name)
+		}
+	}
+
+	lows := make([]string, 0, ts.cfg.BurstJobs)
+	for i := 0; i < ts.cfg.BurstJobs; i++ {
+		lows = append(lows, lowPriorityJobName(i))
+	}
+	return checkAdmissionOrder(lows, firstAdmitted)
+}
+
+type jobsSummary struct {
+	// admitted is the names of the unsuspended and unfinished Jobs.
+	admitted []string
+	// pending is the names of the suspended Jobs.
+	pending  []string
+	failed   []string
+	finished int
+}
+
+func summarizeJobs(jobs []batch_v1.Job) (st jobsSummary) {
+	for _, job := range jobs {
+		switch {
+		case jobCondition(job, batch_v1.JobFailed):
+			st.failed = append(st.failed, job.Name)
+			st.finished++
+		case jobCondition(job, batch_v1.JobComplete):
+			st.finished++
+		case job.Spec.Suspend != nil && *job.Spec.Suspend:
+			st.pending = append(st.pending, job.Name)
+		default:
+			st.admitted = append(st.admitted, job.Name)
+		}
+	}
+	sort.Strings(st.admitted)
+	sort.Strings(st.pending)
+	sort.Strings(st.failed)
+	return st
+}
+
+func jobCondition(job batch_v1.Job, tp batch_v1.JobConditionType) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == tp && cond.Status == core_v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAdmissionOrder returns an error if a Job is never observed admitted, or
+// is first admitted in an earlier poll round than a Job created before it.
+// Jobs admitted in the same round are in order.
+func checkAdmissionOrder(created []string, firstAdmitted map[string]int) error {
+	for _, name := range created {
+		if _, ok := firstAdmitted[name]; !ok {
+			return fmt.Errorf("Job %q never observed admitted", name)
+		}
+	}
+	for i := 1; i < len(created); i++ {
+		prev, cur := created[i-1], created[i]
+		if firstAdmitted[cur] < firstAdmitted[prev] {
+			return fmt.Errorf("Job %q admitted before %q created earlier (rounds %d < %d)", cur, prev, firstAdmitted[cur], firstAdmitted[prev])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package kueue
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCapacity(t *testing.T) {
+	tests := []struct {
+		quota       string
+		job         string
+		capacity    int
+		expectedErr string
+	}{
+		{quota: "2", job: "500m", capacity: 4},
+		{quota: "2", job: "600m", capacity: 3},
+		{quota: "1500m", job: "1", capacity: 1},
+		{quota: "500m", job: "1", expectedErr: "admits no Job"},
+		{quota: "x", job: "1", expectedErr: "invalid QuotaCPU"},
+		{quota: "1", job: "0", expectedErr: "invalid JobCPU"},
+	}
+	for i, tt := range tests {
+		cfg := &Config{QuotaCPU: tt.quota, JobCPU: tt.job}
+		capacity, err := cfg.capacity()
+		if tt.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("#%d: expected error %q, got %v", i, tt.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if capacity != tt.capacity {
+			t.Fatalf("#%d: expected capacity %d, got %d", i, tt.capacity, capacity)
+		}
+	}
+}
+
+func TestValidateBurstJobs(t *testing.T) {
+	cfg := NewDefault()
+	cfg.BurstJobs = 4
+	if err := cfg.ValidateAndSetDefaults(); err == nil || !strings.Contains(err.Error(), "must exceed") {
+		t.Fatalf("expected BurstJobs error, got %v", err)
+	}
+	cfg.BurstJobs = 5
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSummarizeJobs(t *testing.T) {
+	suspended, unsuspended := true, false
+	job := func(name string, suspend *bool, cond batch_v1.JobConditionType) batch_v1.Job {
+		j := batch_v1.Job{ObjectMeta: meta_v1.ObjectMeta{Name: name}}
+		j.Spec.Suspend = suspend
+		if cond != "" {
+			j.Status.Conditions = []batch_v1.JobCondition{{Type: cond, Status: core_v1.ConditionTrue}}
+		}
+		return j
+	}
+	st := summarizeJobs([]batch_v1.Job{
+		job("low-3", &suspended, ""),
+		job("low-1", &unsuspended, ""),
+		job("low-0", &unsuspended, batch_v1.JobComplete),
+		job("low-2", nil, ""),
+		job("high-0", &unsuspended, batch_v1.JobFailed),
+	})
+	if !reflect.DeepEqual(st.admitted, []string{"low-1", "low-2"}) {
+		t.Fatalf("unexpected admitted %v", st.admitted)
+	}
+	if !reflect.DeepEqual(st.pending, []string{"low-3"}) {
+		t.Fatalf("unexpected pending %v", st.pending)
+	}
+	if !reflect.DeepEqual(st.failed, []string{"high-0"}) {
+		t.Fatalf("unexpected failed %v", st.failed)
+	}
+	if st.finished != 2 {
+		t.Fatalf("unexpected finished %d", st.finished)
+	}
+}
+
+func TestCheckAdmissionOrder(t *testing.T) {
+	created := []string{"low-0", "low-1", "low-2"}
+	tests := []struct {
+		firstAdmitted map[string]int
+		expectedErr   string
+	}{
+		{firstAdmitted: map[string]int{"low-0": 0, "low-1": 0, "low-2": 5}},
+		{firstAdmitted: map[string]int{"low-0": 0, "low-1": 7, "low-2": 5}, expectedErr: `"low-2" admitted before "low-1"`},
+		{firstAdmitted: map[string]int{"low-0": 0, "low-1": 1}, expectedErr: `"low-2" never observed admitted`},
+	}
+	for i, tt := range tests {
+		err := checkAdmissionOrder(created, tt.firstAdmitted)
+		if tt.expectedErr == "" && err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if tt.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedErr)) {
+			t.Fatalf("#%d: expected error %q, got %v", i, tt.expectedErr, err)
+		}
+	}
+}
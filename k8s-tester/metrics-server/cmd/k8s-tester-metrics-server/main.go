@@ -4,6 +4,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/client"
 	metrics_server "github.com/aws/aws-k8s-tester/k8s-tester/metrics-server"
@@ -57,12 +58,25 @@ func main() {
 	os.Exit(0)
 }
 
+var (
+	metricsMaxAge          time.Duration
+	metricsTimeout         time.Duration
+	apiQPS                 float64
+	apiDuration            time.Duration
+	apiLatencyP99Threshold time.Duration
+)
+
 func newApply() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "apply",
 		Short: "Apply tests",
 		Run:   createApplyFunc,
 	}
+	cmd.PersistentFlags().DurationVar(&metricsMaxAge, "metrics-max-age", metrics_server.DefaultMetricsMaxAge, "maximum age of the node and pod metrics")
+	cmd.PersistentFlags().DurationVar(&metricsTimeout, "metrics-timeout", metrics_server.DefaultMetricsTimeout, "timeout for the metrics of all nodes and pods to be complete and fresh")
+	cmd.PersistentFlags().Float64Var(&apiQPS, "api-qps", metrics_server.DefaultAPIQPS, "metrics.k8s.io list requests per second to measure the API latency, 0 to skip")
+	cmd.PersistentFlags().DurationVar(&apiDuration, "api-duration", metrics_server.DefaultAPIDuration, "duration to send the metrics.k8s.io list requests")
+	cmd.PersistentFlags().DurationVar(&apiLatencyP99Threshold, "api-latency-p99-threshold", metrics_server.DefaultAPILatencyP99Threshold, "maximum p99 metrics.k8s.io list latency, 0 to skip")
 	return cmd
 }
 
@@ -90,6 +104,15 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		MinimumNodes: minimumNodes,
 		Namespace:    namespace,
 		Client:       cli,
+
+		MetricsMaxAge:          metricsMaxAge,
+		MetricsTimeout:         metricsTimeout,
+		APIQPS:                 apiQPS,
+		APIDuration:            apiDuration,
+		APILatencyP99Threshold: apiLatencyP99Threshold,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
 	}
 
 	ts := metrics_server.New(cfg)
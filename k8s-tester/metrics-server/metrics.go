@@ -0,0 +1,106 @@
+package metrics_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+)
+
+const (
+	nodeMetricsPath = "/apis/metrics.k8s.io/v1beta1/nodes"
+	podMetricsPath  = "/apis/metrics.k8s.io/v1beta1/pods"
+)
+
+// metricsList is the subset of "NodeMetricsList" and "PodMetricsList".
+// ref. https://github.com/kubernetes/metrics/blob/master/pkg/apis/metrics/v1beta1/types.go
+type metricsList struct {
+	Items []struct {
+		Metadata struct {
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"metadata"`
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"items"`
+}
+
+// parseMetricsTimestamps parses the "metrics.k8s.io" list response,
+// and returns the metrics timestamp by object key ("name" or "namespace/name").
+func parseMetricsTimestamps(b []byte) (map[string]time.Time, error) {
+	var ls metricsList
+	if err := json.Unmarshal(b, &ls); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics list (%v)", err)
+	}
+	timestamps := make(map[string]time.Time, len(ls.Items))
+	for _, item := range ls.Items {
+		timestamps[objectKey(item.Metadata.Namespace, item.Metadata.Name)] = item.Timestamp
+	}
+	return timestamps, nil
+}
+
+func objectKey(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+const fargateComputeTypeLabel = "eks.amazonaws.com/compute-type"
+
+// expectedNodes returns the ready nodes that metrics-server is expected to scrape.
+func expectedNodes(nodes []core_v1.Node) (names []string) {
+	for _, node := range nodes {
+		if node.Labels[fargateComputeTypeLabel] == "fargate" {
+			continue
+		}
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == core_v1.NodeReady && cond.Status == core_v1.ConditionTrue {
+				names = append(names, node.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expectedPods returns the keys of the running Pods on the expected nodes
+// that have been running long enough for metrics-server to scrape at least once.
+func expectedPods(pods []core_v1.Pod, nodes []string, now time.Time, minAge time.Duration) (keys []string) {
+	scraped := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		scraped[n] = struct{}{}
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodRunning || pod.DeletionTimestamp != nil || pod.Status.StartTime == nil {
+			continue
+		}
+		if _, ok := scraped[pod.Spec.NodeName]; !ok {
+			continue
+		}
+		if now.Sub(pod.Status.StartTime.Time) < minAge {
+			continue
+		}
+		keys = append(keys, objectKey(pod.Namespace, pod.Name))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkMetrics returns the expected keys missing from the metrics,
+// and the ones whose metrics are older than "maxAge".
+func checkMetrics(expected []string, timestamps map[string]time.Time, now time.Time, maxAge time.Duration) (missing []string, stale []string) {
+	for _, k := range expected {
+		ts, ok := timestamps[k]
+		if !ok {
+			missing = append(missing, k)
+			continue
+		}
+		if now.Sub(ts) > maxAge {
+			stale = append(stale, k)
+		}
+	}
+	return missing, stale
+}
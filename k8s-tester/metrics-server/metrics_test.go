@@ -0,0 +1,77 @@
+package metrics_server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseMetricsTimestamps(t *testing.T) {
+	b := []byte(`{
+  "kind": "PodMetricsList",
+  "apiVersion": "metrics.k8s.io/v1beta1",
+  "items": [
+    {"metadata": {"name": "coredns-1", "namespace": "kube-system"}, "timestamp": "2021-09-01T00:00:00Z", "window": "10s", "containers": []},
+    {"metadata": {"name": "app", "namespace": "default"}, "timestamp": "2021-09-01T00:00:30Z", "window": "10s", "containers": []}
+  ]
+}`)
+	timestamps, err := parseMetricsTimestamps(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]time.Time{
+		"kube-system/coredns-1": time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC),
+		"default/app":           time.Date(2021, 9, 1, 0, 0, 30, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(timestamps, expected) {
+		t.Fatalf("expected %v, got %v", expected, timestamps)
+	}
+
+	now := time.Date(2021, 9, 1, 0, 1, 0, 0, time.UTC)
+	missing, stale := checkMetrics([]string{"default/app", "default/missing", "kube-system/coredns-1"}, timestamps, now, 45*time.Second)
+	if !reflect.DeepEqual(missing, []string{"default/missing"}) {
+		t.Fatalf("unexpected missing %v", missing)
+	}
+	if !reflect.DeepEqual(stale, []string{"kube-system/coredns-1"}) {
+		t.Fatalf("unexpected stale %v", stale)
+	}
+}
+
+func TestExpected(t *testing.T) {
+	ready := core_v1.NodeStatus{Conditions: []core_v1.NodeCondition{{Type: core_v1.NodeReady, Status: core_v1.ConditionTrue}}}
+	nodes := expectedNodes([]core_v1.Node{
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "b"}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "a"}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "fargate", Labels: map[string]string{fargateComputeTypeLabel: "fargate"}}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "not-ready"}},
+	})
+	if !reflect.DeepEqual(nodes, []string{"a", "b"}) {
+		t.Fatalf("unexpected nodes %v", nodes)
+	}
+
+	now := time.Date(2021, 9, 1, 0, 10, 0, 0, time.UTC)
+	started := func(d time.Duration) *meta_v1.Time {
+		st := meta_v1.NewTime(now.Add(-d))
+		return &st
+	}
+	pod := func(name, node string, phase core_v1.PodPhase, age time.Duration) core_v1.Pod {
+		return core_v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       core_v1.PodSpec{NodeName: node},
+			Status:     core_v1.PodStatus{Phase: phase, StartTime: started(age)},
+		}
+	}
+	pods := expectedPods([]core_v1.Pod{
+		pod("old", "a", core_v1.PodRunning, 5*time.Minute),
+		pod("new", "a", core_v1.PodRunning, 10*time.Second),
+		pod("pending", "b", core_v1.PodPending, 5*time.Minute),
+		pod("on-fargate", "fargate", core_v1.PodRunning, 5*time.Minute),
+		pod("another", "b", core_v1.PodRunning, 5*time.Minute),
+	}, nodes, now, 2*time.Minute)
+	if !reflect.DeepEqual(pods, []string{"default/another", "default/old"}) {
+		t.Fatalf("unexpected pods %v", pods)
+	}
+}
@@ -7,14 +7,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/client"
 	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
 	"github.com/aws/aws-k8s-tester/utils/file"
+	"github.com/aws/aws-k8s-tester/utils/latency"
 	"github.com/manifoldco/promptui"
 	"go.uber.org/zap"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
@@ -33,20 +37,74 @@ type Config struct {
 
 	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
 	MinimumNodes int `json:"minimum_nodes"`
+
+	// MetricsMaxAge is the maximum age of the node and pod metrics.
+	MetricsMaxAge       time.Duration `json:"metrics_max_age"`
+	MetricsMaxAgeString string        `json:"metrics_max_age_string" read-only:"true"`
+	// MetricsTimeout is the timeout for the metrics of all nodes and pods to be complete and fresh.
+	MetricsTimeout       time.Duration `json:"metrics_timeout"`
+	MetricsTimeoutString string        `json:"metrics_timeout_string" read-only:"true"`
+
+	// APIQPS is the number of "metrics.k8s.io" list requests per second
+	// to measure the API latency. Zero to skip.
+	APIQPS float64 `json:"api_qps"`
+	// APIDuration is the duration to send the "metrics.k8s.io" list requests.
+	APIDuration       time.Duration `json:"api_duration"`
+	APIDurationString string        `json:"api_duration_string" read-only:"true"`
+	// APILatencyP99Threshold is the maximum p99 "metrics.k8s.io" list latency. Zero to skip.
+	APILatencyP99Threshold       time.Duration `json:"api_latency_p99_threshold"`
+	APILatencyP99ThresholdString string        `json:"api_latency_p99_threshold_string" read-only:"true"`
+
+	// NodeMetrics is the number of nodes with metrics.
+	NodeMetrics int `json:"node_metrics" read-only:"true"`
+	// PodMetrics is the number of pods with metrics.
+	PodMetrics int `json:"pod_metrics" read-only:"true"`
+	// APILatency is the "metrics.k8s.io" list latency summary.
+	APILatency latency.Summary `json:"api_latency" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
-
+	if cfg.MetricsMaxAge == time.Duration(0) {
+		cfg.MetricsMaxAge = DefaultMetricsMaxAge
+	}
+	cfg.MetricsMaxAgeString = cfg.MetricsMaxAge.String()
+	if cfg.MetricsTimeout == time.Duration(0) {
+		cfg.MetricsTimeout = DefaultMetricsTimeout
+	}
+	cfg.MetricsTimeoutString = cfg.MetricsTimeout.String()
+	if cfg.APIQPS < 0 {
+		return fmt.Errorf("invalid APIQPS %v", cfg.APIQPS)
+	}
+	if cfg.APIDuration == time.Duration(0) {
+		cfg.APIDuration = DefaultAPIDuration
+	}
+	cfg.APIDurationString = cfg.APIDuration.String()
+	cfg.APILatencyP99ThresholdString = cfg.APILatencyP99Threshold.String()
 	return nil
 }
 
-const DefaultMinimumNodes int = 1
+const (
+	DefaultMinimumNodes int = 1
+	// DefaultMetricsMaxAge is twice the metrics-server default "--metric-resolution" plus the scrape window.
+	DefaultMetricsMaxAge          = 2 * time.Minute
+	DefaultMetricsTimeout         = 10 * time.Minute
+	DefaultAPIQPS         float64 = 10
+	DefaultAPIDuration            = time.Minute
+	// DefaultAPILatencyP99Threshold is the SIG scalability SLO for the cluster-scoped LIST calls.
+	// ref. https://github.com/kubernetes/community/blob/master/sig-scalability/slos/api_call_latency.md
+	DefaultAPILatencyP99Threshold = 30 * time.Second
+)
 
 func NewDefault() *Config {
 	return &Config{
-		Enable:       false,
-		Prompt:       false,
-		MinimumNodes: DefaultMinimumNodes,
+		Enable:                 false,
+		Prompt:                 false,
+		MinimumNodes:           DefaultMinimumNodes,
+		MetricsMaxAge:          DefaultMetricsMaxAge,
+		MetricsTimeout:         DefaultMetricsTimeout,
+		APIQPS:                 DefaultAPIQPS,
+		APIDuration:            DefaultAPIDuration,
+		APILatencyP99Threshold: DefaultAPILatencyP99Threshold,
 	}
 }
 
@@ -56,6 +114,25 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 }
 
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
 type tester struct {
 	cfg *Config
 }
@@ -93,6 +170,16 @@ func (ts *tester) Apply() error {
 		return err
 	}
 
+	if err := ts.checkMetricsAPI(); err != nil {
+		return err
+	}
+
+	if ts.cfg.APIQPS > 0 {
+		if err := ts.checkAPILatency(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -126,7 +213,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -455,6 +543,153 @@ func (ts *tester) checkMetricsServer() error {
 	return nil
 }
 
+func (ts *tester) listMetrics(apiPath string) (map[string]time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	b, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		RESTClient().
+		Get().
+		AbsPath(apiPath).
+		DoRaw(ctx)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %q (%v)", apiPath, err)
+	}
+	return parseMetricsTimestamps(b)
+}
+
+// checkMetricsAPI polls the "metrics.k8s.io" API until the node and pod metrics
+// are available for all ready nodes and running pods, and are fresh.
+func (ts *tester) checkMetricsAPI() error {
+	ts.cfg.Logger.Info("checking metrics API completeness and freshness", zap.Duration("max-age", ts.cfg.MetricsMaxAge))
+	var lastErr error
+	deadline := time.Now().Add(ts.cfg.MetricsTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("metrics API check aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		if lastErr = ts.checkMetricsOnce(); lastErr == nil {
+			ts.cfg.Logger.Info("metrics API complete and fresh", zap.Int("nodes", ts.cfg.NodeMetrics), zap.Int("pods", ts.cfg.PodMetrics))
+			return nil
+		}
+		ts.cfg.Logger.Info("metrics API not ready; retrying", zap.Error(lastErr))
+	}
+	return fmt.Errorf("metrics API not complete and fresh within %v (%v)", ts.cfg.MetricsTimeout, lastErr)
+}
+
+func (ts *tester) checkMetricsOnce() error {
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	pods, err := client.ListPods(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), meta_v1.NamespaceAll, 1000, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	nodeMetrics, err := ts.listMetrics(nodeMetricsPath)
+	if err != nil {
+		return err
+	}
+	podMetrics, err := ts.listMetrics(podMetricsPath)
+	if err != nil {
+		return err
+	}
+	ts.cfg.NodeMetrics, ts.cfg.PodMetrics = len(nodeMetrics), len(podMetrics)
+
+	now := time.Now()
+	expected := expectedNodes(nodes)
+	var errs []string
+	missing, stale := checkMetrics(expected, nodeMetrics, now, ts.cfg.MetricsMaxAge)
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Sprintf("%d node(s) missing metrics %v", len(missing), missing))
+	}
+	if len(stale) > 0 {
+		errs = append(errs, fmt.Sprintf("%d node(s) with stale metrics %v", len(stale), stale))
+	}
+	// metrics-server scrapes every "--metric-resolution",
+	// so the new pods may not have the metrics yet
+	missing, stale = checkMetrics(expectedPods(pods, expected, now, ts.cfg.MetricsMaxAge), podMetrics, now, ts.cfg.MetricsMaxAge)
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Sprintf("%d pod(s) missing metrics %v", len(missing), missing))
+	}
+	if len(stale) > 0 {
+		errs = append(errs, fmt.Sprintf("%d pod(s) with stale metrics %v", len(stale), stale))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// checkAPILatency sends the node and pod metrics list requests at "APIQPS"
+// for "APIDuration", and checks the p99 latency against the threshold.
+func (ts *tester) checkAPILatency() error {
+	ts.cfg.Logger.Info("measuring metrics API latency", zap.Float64("qps", ts.cfg.APIQPS), zap.Duration("duration", ts.cfg.APIDuration))
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies = make(latency.Durations, 0, int(ts.cfg.APIQPS*ts.cfg.APIDuration.Seconds()))
+		failures  int
+	)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / ts.cfg.APIQPS))
+	defer ticker.Stop()
+	deadline := time.Now().Add(ts.cfg.APIDuration)
+	for i := 0; time.Now().Before(deadline); i++ {
+		select {
+		case <-ts.cfg.Stopc:
+			wg.Wait()
+			return errors.New("metrics API latency check aborted")
+		case <-ticker.C:
+		}
+		apiPath := nodeMetricsPath
+		if i%2 == 1 {
+			apiPath = podMetricsPath
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			_, err := ts.listMetrics(apiPath)
+			took := time.Since(start)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				ts.cfg.Logger.Warn("metrics API request failed", zap.String("path", apiPath), zap.Error(err))
+				failures++
+				return
+			}
+			latencies = append(latencies, took)
+		}()
+	}
+	wg.Wait()
+
+	ts.cfg.APILatency = latency.Summary{}
+	ts.cfg.APILatency.TestID = time.Now().UTC().Format(time.RFC3339Nano)
+	ts.cfg.APILatency.SuccessTotal = float64(len(latencies))
+	ts.cfg.APILatency.FailureTotal = float64(failures)
+	if len(latencies) == 0 {
+		return fmt.Errorf("all %d metrics API requests failed", failures)
+	}
+	sort.Sort(latencies)
+	ts.cfg.APILatency.P50 = latencies.PickP50()
+	ts.cfg.APILatency.P90 = latencies.PickP90()
+	ts.cfg.APILatency.P99 = latencies.PickP99()
+	ts.cfg.APILatency.P999 = latencies.PickP999()
+	ts.cfg.APILatency.P9999 = latencies.PickP9999()
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\nmetrics API latency:\n%s\n", ts.cfg.APILatency.Table())
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d metrics API requests failed", failures, failures+len(latencies))
+	}
+	if ts.cfg.APILatencyP99Threshold > 0 && ts.cfg.APILatency.P99 > ts.cfg.APILatencyP99Threshold {
+		return fmt.Errorf("metrics API latency p99 %v exceeds %v", ts.cfg.APILatency.P99, ts.cfg.APILatencyP99Threshold)
+	}
+	return nil
+}
+
 func (ts *tester) deleteDeployment() error {
 	ts.cfg.Logger.Info("deleting deployment")
 	foreground := meta_v1.DeletePropagationForeground
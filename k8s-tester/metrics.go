@@ -0,0 +1,128 @@
+package k8s_tester
+
+import (
+	"time"
+
+	cloudwatch_metrics "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-metrics"
+	opensearch_results "github.com/aws/aws-k8s-tester/k8s-tester/opensearch-results"
+	"github.com/aws/aws-k8s-tester/utils/latency"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// metricDatums returns the CloudWatch datums for the "apply" results,
+// with the duration, success, and API calls of each tester that ran, and the key
+// metrics of the testers that succeeded.
+func (ts *tester) metricDatums() []cloudwatch_metrics.Datum {
+	datums := make([]cloudwatch_metrics.Datum, 0)
+	succeeded := make(map[string]bool)
+	for _, res := range ts.results {
+		if res.skipped {
+			continue
+		}
+		success, failure := 1.0, 0.0
+		if res.err != nil {
+			success, failure = 0.0, 1.0
+		}
+		succeeded[res.name] = res.err == nil
+		datums = append(datums,
+			cloudwatch_metrics.Datum{AddOn: res.name, Name: "duration", Unit: cloudwatch.StandardUnitSeconds, Value: res.took.Seconds()},
+			cloudwatch_metrics.Datum{AddOn: res.name, Name: "success", Unit: cloudwatch.StandardUnitCount, Value: success},
+			cloudwatch_metrics.Datum{AddOn: res.name, Name: "failure", Unit: cloudwatch.StandardUnitCount, Value: failure},
+		)
+		if calls, ok := ts.cfg.APICalls[res.name]; ok {
+			var total int64
+			for _, v := range calls {
+				total += v
+			}
+			datums = append(datums, cloudwatch_metrics.Datum{AddOn: res.name, Name: "api-calls", Unit: cloudwatch.StandardUnitCount, Value: float64(total)})
+		}
+	}
+
+	add := func(name string, metric string, unit string, v float64) {
+		if succeeded[name] {
+			datums = append(datums, cloudwatch_metrics.Datum{AddOn: name, Name: metric, Unit: unit, Value: v})
+		}
+	}
+	addDuration := func(name string, metric string, d time.Duration) {
+		add(name, metric, cloudwatch.StandardUnitMilliseconds, float64(d.Milliseconds()))
+	}
+	addSummary := func(name string, metric string, s latency.Summary) {
+		addDuration(name, metric+"-latency-p50", s.P50)
+		addDuration(name, metric+"-latency-p99", s.P99)
+		add(name, metric+"-failures", cloudwatch.StandardUnitCount, s.FailureTotal)
+	}
+
+	if ts.cfg.AddOnStress != nil && ts.cfg.AddOnStress.Enable {
+		add("stress", "writes-qps", cloudwatch.StandardUnitCountSecond, ts.cfg.AddOnStress.QPSWrites)
+		add("stress", "gets-qps", cloudwatch.StandardUnitCountSecond, ts.cfg.AddOnStress.QPSGets)
+		add("stress", "range-gets-qps", cloudwatch.StandardUnitCountSecond, ts.cfg.AddOnStress.QPSRangeGets)
+		addSummary("stress", "writes", ts.cfg.AddOnStress.LatencySummaryWrites)
+		addSummary("stress", "gets", ts.cfg.AddOnStress.LatencySummaryGets)
+	}
+	if ts.cfg.AddOnPHPApache != nil && ts.cfg.AddOnPHPApache.Enable {
+		addDuration("php-apache", "hpa-scale-up-latency", ts.cfg.AddOnPHPApache.HPAScaleUpLatency)
+		addDuration("php-apache", "hpa-scale-down-latency", ts.cfg.AddOnPHPApache.HPAScaleDownLatency)
+	}
+	if ts.cfg.AddOnConfigmaps != nil && ts.cfg.AddOnConfigmaps.Enable {
+		addSummary("configmaps", "writes", ts.cfg.AddOnConfigmaps.LatencySummary)
+	}
+	if ts.cfg.AddOnSecrets != nil && ts.cfg.AddOnSecrets.Enable {
+		addSummary("secrets", "writes", ts.cfg.AddOnSecrets.LatencySummary)
+	}
+	if ts.cfg.AddOnCSRs != nil && ts.cfg.AddOnCSRs.Enable {
+		addSummary("csrs", "writes", ts.cfg.AddOnCSRs.LatencySummary)
+	}
+	if ts.cfg.AddOnCSIS3 != nil && ts.cfg.AddOnCSIS3.Enable {
+		add("csi-s3", "write-throughput", cloudwatch.StandardUnitMegabytesSecond, ts.cfg.AddOnCSIS3.WriteThroughputMBps)
+		add("csi-s3", "read-throughput", cloudwatch.StandardUnitMegabytesSecond, ts.cfg.AddOnCSIS3.ReadThroughputMBps)
+	}
+	if ts.cfg.AddOnKarpenter != nil && ts.cfg.AddOnKarpenter.Enable {
+		addDuration("karpenter", "provision-latency", ts.cfg.AddOnKarpenter.ProvisionLatency)
+	}
+	if ts.cfg.AddOnImagePrepull != nil && ts.cfg.AddOnImagePrepull.Enable {
+		addDuration("image-prepull", "cold-start-latency", ts.cfg.AddOnImagePrepull.ColdStartLatency)
+		addDuration("image-prepull", "start-latency-improvement", ts.cfg.AddOnImagePrepull.StartLatencyImprovement)
+	}
+
+	return datums
+}
+
+// resultDocuments returns the OpenSearch documents for the "apply" results,
+// with an "add-on" document per tester, and a "metric" document per
+// CloudWatch datum, so that both can be aggregated in the same index.
+func (ts *tester) resultDocuments() []opensearch_results.Document {
+	timestamp := ts.started.UTC().Format(time.RFC3339)
+	docs := make([]opensearch_results.Document, 0)
+	for _, res := range ts.results {
+		doc := opensearch_results.Document{
+			"@timestamp":       timestamp,
+			"type":             "add-on",
+			"cluster_name":     ts.cfg.ClusterName,
+			"run_id":           ts.cfg.RunID,
+			"add_on":           res.name,
+			"duration_seconds": res.took.Seconds(),
+			"success":          !res.skipped && res.err == nil,
+			"skipped":          res.skipped,
+		}
+		if res.err != nil {
+			doc["error"] = res.err.Error()
+		}
+		if res.skipped {
+			doc["skip_note"] = res.skipNote
+		}
+		docs = append(docs, doc)
+	}
+	for _, d := range ts.metricDatums() {
+		docs = append(docs, opensearch_results.Document{
+			"@timestamp":   timestamp,
+			"type":         "metric",
+			"cluster_name": ts.cfg.ClusterName,
+			"run_id":       ts.cfg.RunID,
+			"add_on":       d.AddOn,
+			"metric":       d.Name,
+			"unit":         d.Unit,
+			"value":        d.Value,
+		})
+	}
+	return docs
+}
@@ -0,0 +1,34 @@
+package multi_container
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the init containers Pod, and the native sidecar Job and Pod
+// against a fake client, regardless of the server version.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createInitPod(); err != nil {
+		return err
+	}
+	if err := rt.createSidecarJob(); err != nil {
+		return err
+	}
+	if err := rt.createTerminationPod(); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -0,0 +1,701 @@
+// Package multi_container tests the multi-container Pod patterns:
+// the init containers run to completion in order, the native sidecar containers
+// (init containers with "restartPolicy: Always") start before and do not block
+// the completion of the main containers, and the sidecars are terminated after
+// the main containers on Pod deletion.
+// The native sidecar cases are skipped on the clusters older than 1.29,
+// where the "SidecarContainers" feature is not enabled by default.
+// ref. https://kubernetes.io/docs/concepts/workloads/pods/init-containers/
+// ref. https://kubernetes.io/docs/concepts/workloads/pods/sidecar-containers/
+package multi_container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the image of all containers, with a POSIX shell.
+	Image string `json:"image"`
+	// SidecarTerminationDelay is the delay of the sidecar container exit
+	// after it receives SIGTERM, to observe the main container terminated first.
+	// Must be less than the Pod termination grace period of 30 seconds.
+	SidecarTerminationDelay       time.Duration `json:"sidecar_termination_delay"`
+	SidecarTerminationDelayString string        `json:"sidecar_termination_delay_string" read-only:"true"`
+	// Timeout is the timeout for each Pod and Job to complete or to be deleted.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// ServerVersion is the Kubernetes API server version.
+	ServerVersion string `json:"server_version" read-only:"true"`
+	// NativeSidecarSupported is true if the native sidecar cases ran.
+	NativeSidecarSupported bool `json:"native_sidecar_supported" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.SidecarTerminationDelay == time.Duration(0) {
+		cfg.SidecarTerminationDelay = DefaultSidecarTerminationDelay
+	}
+	if cfg.SidecarTerminationDelay < time.Second || cfg.SidecarTerminationDelay >= terminationGracePeriod {
+		return fmt.Errorf("SidecarTerminationDelay %v must be between 1s and %v", cfg.SidecarTerminationDelay, terminationGracePeriod)
+	}
+	cfg.SidecarTerminationDelayString = cfg.SidecarTerminationDelay.String()
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes            int = 1
+	DefaultImage                       = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultSidecarTerminationDelay     = 5 * time.Second
+	DefaultTimeout                     = 5 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:                  false,
+		Prompt:                  false,
+		MinimumNodes:            DefaultMinimumNodes,
+		Namespace:               pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:                   DefaultImage,
+		SidecarTerminationDelay: DefaultSidecarTerminationDelay,
+		Timeout:                 DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	initPodName         = "init-containers"
+	sidecarJobName      = "sidecar-job"
+	terminationPodName  = "sidecar-termination"
+	mainContainerName   = "main"
+	sidecarName         = "sidecar"
+	sharedVolumeName    = "shared"
+	sharedMountPath     = "/shared"
+	sidecarStartedFile  = sharedMountPath + "/sidecar-started"
+	appLabel            = "app.kubernetes.io/name"
+	sidecarSeenByMain   = "MAIN SAW SIDECAR"
+	initContainerPrefix = "init-"
+	initContainers      = 3
+
+	terminationGracePeriod = 30 * time.Second
+)
+
+// minimumNativeSidecarVersion is the first version with the "SidecarContainers"
+// feature enabled by default (beta).
+var minimumNativeSidecarVersion = version.MustParseGeneric("1.29")
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	info, err := ts.cfg.Client.KubernetesClient().Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("failed to get server version (%v)", err)
+	}
+	ts.cfg.ServerVersion = info.GitVersion
+	ts.cfg.NativeSidecarSupported, err = nativeSidecarSupported(info.GitVersion)
+	if err != nil {
+		return err
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createInitPod(); err != nil {
+		return err
+	}
+	if err := ts.checkInitPod(); err != nil {
+		return err
+	}
+
+	if !ts.cfg.NativeSidecarSupported {
+		ts.cfg.Logger.Warn("native sidecar containers not supported; skipping sidecar cases",
+			zap.String("server-version", ts.cfg.ServerVersion),
+			zap.String("minimum-version", minimumNativeSidecarVersion.String()),
+		)
+		return nil
+	}
+
+	if err := ts.createSidecarJob(); err != nil {
+		return err
+	}
+	if err := ts.checkSidecarJob(); err != nil {
+		return err
+	}
+
+	if err := ts.createTerminationPod(); err != nil {
+		return err
+	}
+	if err := ts.checkTerminationOrder(); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("multi-container Pod patterns validated", zap.String("server-version", ts.cfg.ServerVersion))
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteJob(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		sidecarJobName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// nativeSidecarSupported returns true if the server version enables
+// the native sidecar containers by default.
+func nativeSidecarSupported(gitVersion string) (bool, error) {
+	v, err := version.ParseGeneric(gitVersion)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse server version %q (%v)", gitVersion, err)
+	}
+	return v.AtLeast(minimumNativeSidecarVersion), nil
+}
+
+var sharedVolume = core_v1.Volume{
+	Name: sharedVolumeName,
+	VolumeSource: core_v1.VolumeSource{
+		EmptyDir: &core_v1.EmptyDirVolumeSource{},
+	},
+}
+
+var sharedVolumeMount = core_v1.VolumeMount{
+	Name:      sharedVolumeName,
+	MountPath: sharedMountPath,
+}
+
+// sidecarContainer returns the native sidecar container that marks its start
+// in the shared volume, and delays its exit by "delay" on SIGTERM.
+func (ts *tester) sidecarContainer(delay time.Duration) core_v1.Container {
+	restartPolicy := core_v1.ContainerRestartPolicyAlways
+	return core_v1.Container{
+		Name:            sidecarName,
+		Image:           ts.cfg.Image,
+		ImagePullPolicy: core_v1.PullIfNotPresent,
+		RestartPolicy:   &restartPolicy,
+		Command: []string{
+			"/bin/sh",
+			"-c",
+			fmt.Sprintf(`trap 'echo sidecar received SIGTERM; sleep %d; exit 0' TERM; touch %s; while true; do sleep 1; done`, int(delay.Seconds()), sidecarStartedFile),
+		},
+		StartupProbe: &core_v1.Probe{
+			ProbeHandler: core_v1.ProbeHandler{
+				Exec: &core_v1.ExecAction{
+					Command: []string{"test", "-f", sidecarStartedFile},
+				},
+			},
+			PeriodSeconds: 1,
+		},
+		VolumeMounts: []core_v1.VolumeMount{sharedVolumeMount},
+	}
+}
+
+// createInitPod creates a Pod whose init containers append their names
+// to a shared file, and whose main container prints the file.
+func (ts *tester) createInitPod() error {
+	ts.cfg.Logger.Info("creating init containers Pod", zap.Int("init-containers", initContainers))
+	inits := make([]core_v1.Container, 0, initContainers)
+	for i := 0; i < initContainers; i++ {
+		inits = append(inits, core_v1.Container{
+			Name:            fmt.Sprintf("%s%d", initContainerPrefix, i),
+			Image:           ts.cfg.Image,
+			ImagePullPolicy: core_v1.PullIfNotPresent,
+			Command: []string{
+				"/bin/sh",
+				"-c",
+				fmt.Sprintf("echo %s%d >> %s/order", initContainerPrefix, i, sharedMountPath),
+			},
+			VolumeMounts: []core_v1.VolumeMount{sharedVolumeMount},
+		})
+	}
+	return ts.createPod(&core_v1.Pod{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      initPodName,
+			Namespace: ts.cfg.Namespace,
+			Labels: map[string]string{
+				appLabel: initPodName,
+			},
+		},
+		Spec: core_v1.PodSpec{
+			RestartPolicy:  core_v1.RestartPolicyNever,
+			InitContainers: inits,
+			Containers: []core_v1.Container{
+				{
+					Name:            mainContainerName,
+					Image:           ts.cfg.Image,
+					ImagePullPolicy: core_v1.PullIfNotPresent,
+					Command:         []string{"cat", sharedMountPath + "/order"},
+					VolumeMounts:    []core_v1.VolumeMount{sharedVolumeMount},
+				},
+			},
+			Volumes: []core_v1.Volume{sharedVolume},
+		},
+	})
+}
+
+// createSidecarJob creates a Job with a native sidecar container that never exits.
+// The Job completes only if the sidecar does not block the Pod completion.
+func (ts *tester) createSidecarJob() error {
+	ts.cfg.Logger.Info("creating sidecar Job")
+	backoffLimit := int32(0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      sidecarJobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: sidecarJobName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy:  core_v1.RestartPolicyNever,
+							InitContainers: []core_v1.Container{ts.sidecarContainer(0)},
+							Containers: []core_v1.Container{
+								{
+									Name:            mainContainerName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command: []string{
+										"/bin/sh",
+										"-c",
+										fmt.Sprintf("test -f %s && echo %s", sidecarStartedFile, sidecarSeenByMain),
+									},
+									VolumeMounts: []core_v1.VolumeMount{sharedVolumeMount},
+								},
+							},
+							Volumes: []core_v1.Volume{sharedVolume},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created sidecar Job")
+	return nil
+}
+
+// createTerminationPod creates a long-running Pod with a native sidecar
+// that delays its exit on SIGTERM, and a main container that exits immediately.
+func (ts *tester) createTerminationPod() error {
+	ts.cfg.Logger.Info("creating sidecar termination Pod", zap.Duration("sidecar-termination-delay", ts.cfg.SidecarTerminationDelay))
+	gracePeriod := int64(terminationGracePeriod.Seconds())
+	return ts.createPod(&core_v1.Pod{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      terminationPodName,
+			Namespace: ts.cfg.Namespace,
+			Labels: map[string]string{
+				appLabel: terminationPodName,
+			},
+		},
+		Spec: core_v1.PodSpec{
+			RestartPolicy:                 core_v1.RestartPolicyAlways,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			InitContainers:                []core_v1.Container{ts.sidecarContainer(ts.cfg.SidecarTerminationDelay)},
+			Containers: []core_v1.Container{
+				{
+					Name:            mainContainerName,
+					Image:           ts.cfg.Image,
+					ImagePullPolicy: core_v1.PullIfNotPresent,
+					Command: []string{
+						"/bin/sh",
+						"-c",
+						`trap 'echo main received SIGTERM; exit 0' TERM; while true; do sleep 1; done`,
+					},
+					VolumeMounts: []core_v1.VolumeMount{sharedVolumeMount},
+				},
+			},
+			Volumes: []core_v1.Volume{sharedVolume},
+		},
+	})
+}
+
+func (ts *tester) createPod(pod *core_v1.Pod) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		Create(ctx, pod, meta_v1.CreateOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Pod %q (%v)", pod.Name, err)
+	}
+
+	ts.cfg.Logger.Info("created Pod", zap.String("pod", pod.Name))
+	return nil
+}
+
+// waitForPod polls the Pod until "done" returns true, or returns an error.
+func (ts *tester) waitForPod(podName string, done func(pod *core_v1.Pod) (bool, error)) (*core_v1.Pod, error) {
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, errors.New("wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		pod, err := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Get(ctx, podName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Pod; retrying", zap.String("pod", podName), zap.Error(err))
+			continue
+		}
+		ok, err := done(pod)
+		if err != nil {
+			return pod, err
+		}
+		if ok {
+			return pod, nil
+		}
+		ts.cfg.Logger.Info("waiting for Pod", zap.String("pod", podName), zap.String("phase", string(pod.Status.Phase)))
+	}
+	return nil, fmt.Errorf("Pod %q not done within %v", podName, ts.cfg.Timeout)
+}
+
+// checkInitPod waits for the init containers Pod to succeed, and checks
+// that the init containers ran in order before the main container.
+func (ts *tester) checkInitPod() error {
+	if _, err := ts.waitForPod(initPodName, func(pod *core_v1.Pod) (bool, error) {
+		switch pod.Status.Phase {
+		case core_v1.PodSucceeded:
+			return true, nil
+		case core_v1.PodFailed:
+			return false, fmt.Errorf("Pod %q failed (%s)", pod.Name, pod.Status.Message)
+		}
+		return false, nil
+	}); err != nil {
+		return err
+	}
+
+	logs, err := client.CheckPodLogs(
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		initPodName,
+	)
+	if err != nil {
+		return err
+	}
+	if err = checkInitOrder(logs, initContainers); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("init containers ran in order", zap.Int("init-containers", initContainers))
+	return nil
+}
+
+// checkInitOrder returns an error if the output of the main container
+// does not list the init containers in order.
+func checkInitOrder(logs string, n int) error {
+	got := strings.Fields(logs)
+	if len(got) != n {
+		return fmt.Errorf("expected %d init containers ran, got %q", n, got)
+	}
+	for i, name := range got {
+		if expected := fmt.Sprintf("%s%d", initContainerPrefix, i); name != expected {
+			return fmt.Errorf("expected %q at #%d, got %q", expected, i, got)
+		}
+	}
+	return nil
+}
+
+// checkSidecarJob waits for the sidecar Job to complete, and checks that
+// the main container saw the sidecar started.
+func (ts *tester) checkSidecarJob() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Timeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		5*time.Second,
+		ts.cfg.Namespace,
+		sidecarJobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("sidecar Job did not complete (%v)", err)
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		b, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Pods(ts.cfg.Namespace).
+			GetLogs(pod.Name, &core_v1.PodLogOptions{Container: mainContainerName}).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get Pod %q logs (%v)", pod.Name, err)
+		}
+		if !strings.Contains(string(b), sidecarSeenByMain) {
+			return fmt.Errorf("main container of Pod %q did not see the sidecar started (%q)", pod.Name, string(b))
+		}
+		ts.cfg.Logger.Info("sidecar Job completed with the sidecar running", zap.String("pod", pod.Name))
+		return nil
+	}
+	return errors.New("no succeeded sidecar Job Pod")
+}
+
+// checkTerminationOrder waits for the termination Pod to be ready, deletes it,
+// and checks that the sidecar outlives the main container.
+func (ts *tester) checkTerminationOrder() error {
+	if _, err := ts.waitForPod(terminationPodName, func(pod *core_v1.Pod) (bool, error) {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == core_v1.PodReady && cond.Status == core_v1.ConditionTrue {
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleting sidecar termination Pod")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Delete(ctx, terminationPodName, meta_v1.DeleteOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to delete Pod %q (%v)", terminationPodName, err)
+	}
+
+	// poll faster than the sidecar termination delay, to observe
+	// the main container terminated while the sidecar is still running
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(500 * time.Millisecond):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		pod, err := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Get(ctx, terminationPodName, meta_v1.GetOptions{})
+		cancel()
+		if k8s_errors.IsNotFound(err) {
+			return fmt.Errorf("Pod %q deleted before its termination order was observed", terminationPodName)
+		}
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Pod; retrying", zap.Error(err))
+			continue
+		}
+		decided, err := sidecarOutlivedMain(pod)
+		if err != nil {
+			return err
+		}
+		if decided {
+			ts.cfg.Logger.Info("sidecar terminated after the main container")
+			return nil
+		}
+	}
+	return fmt.Errorf("Pod %q termination not observed within %v", terminationPodName, ts.cfg.Timeout)
+}
+
+// sidecarOutlivedMain returns true if the Pod status shows the main container
+// terminated no later than the sidecar, or an error if the sidecar terminated first.
+// It returns false if the main container is still running.
+func sidecarOutlivedMain(pod *core_v1.Pod) (bool, error) {
+	var main, sidecar *core_v1.ContainerStatus
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == mainContainerName {
+			main = &pod.Status.ContainerStatuses[i]
+		}
+	}
+	for i := range pod.Status.InitContainerStatuses {
+		if pod.Status.InitContainerStatuses[i].Name == sidecarName {
+			sidecar = &pod.Status.InitContainerStatuses[i]
+		}
+	}
+	if main == nil || sidecar == nil {
+		return false, nil
+	}
+
+	mainTerminated, sidecarTerminated := main.State.Terminated, sidecar.State.Terminated
+	switch {
+	case mainTerminated == nil && sidecarTerminated != nil:
+		return false, fmt.Errorf("sidecar terminated at %v before the main container", sidecarTerminated.FinishedAt.Time)
+	case mainTerminated == nil:
+		return false, nil
+	case sidecarTerminated == nil:
+		return true, nil
+	case sidecarTerminated.FinishedAt.Before(&mainTerminated.FinishedAt):
+		return false, fmt.Errorf("sidecar terminated at %v before the main container at %v", sidecarTerminated.FinishedAt.Time, mainTerminated.FinishedAt.Time)
+	}
+	return true, nil
+}
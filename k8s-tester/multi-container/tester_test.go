@@ -0,0 +1,83 @@
+package multi_container
+
+import (
+	"testing"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNativeSidecarSupported(t *testing.T) {
+	tests := []struct {
+		gitVersion string
+		supported  bool
+		err        bool
+	}{
+		{gitVersion: "v1.27.16-eks-2f46c53", supported: false},
+		{gitVersion: "v1.28.15-eks-7f9249a", supported: false},
+		{gitVersion: "v1.29.10-eks-7f9249a", supported: true},
+		{gitVersion: "v1.31.2", supported: true},
+		{gitVersion: "unknown", err: true},
+	}
+	for i, tt := range tests {
+		supported, err := nativeSidecarSupported(tt.gitVersion)
+		if (err != nil) != tt.err {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if supported != tt.supported {
+			t.Fatalf("#%d: expected supported %v, got %v", i, tt.supported, supported)
+		}
+	}
+}
+
+func TestCheckInitOrder(t *testing.T) {
+	if err := checkInitOrder("init-0\ninit-1\ninit-2\n", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkInitOrder("init-1\ninit-0\ninit-2\n", 3); err == nil {
+		t.Fatal("expected error for out of order init containers")
+	}
+	if err := checkInitOrder("init-0\ninit-1\n", 3); err == nil {
+		t.Fatal("expected error for missing init container")
+	}
+}
+
+func TestSidecarOutlivedMain(t *testing.T) {
+	now := time.Now()
+	running := core_v1.ContainerState{Running: &core_v1.ContainerStateRunning{}}
+	terminated := func(at time.Time) core_v1.ContainerState {
+		return core_v1.ContainerState{Terminated: &core_v1.ContainerStateTerminated{FinishedAt: meta_v1.NewTime(at)}}
+	}
+	pod := func(main, sidecar core_v1.ContainerState) *core_v1.Pod {
+		return &core_v1.Pod{
+			Status: core_v1.PodStatus{
+				InitContainerStatuses: []core_v1.ContainerStatus{{Name: sidecarName, State: sidecar}},
+				ContainerStatuses:     []core_v1.ContainerStatus{{Name: mainContainerName, State: main}},
+			},
+		}
+	}
+
+	tests := []struct {
+		pod     *core_v1.Pod
+		decided bool
+		err     bool
+	}{
+		{pod: &core_v1.Pod{}, decided: false},
+		{pod: pod(running, running), decided: false},
+		{pod: pod(terminated(now), running), decided: true},
+		{pod: pod(terminated(now), terminated(now.Add(5*time.Second))), decided: true},
+		{pod: pod(terminated(now), terminated(now)), decided: true},
+		{pod: pod(running, terminated(now)), err: true},
+		{pod: pod(terminated(now.Add(5*time.Second)), terminated(now)), err: true},
+	}
+	for i, tt := range tests {
+		decided, err := sidecarOutlivedMain(tt.pod)
+		if (err != nil) != tt.err {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if decided != tt.decided {
+			t.Fatalf("#%d: expected decided %v, got %v", i, tt.decided, decided)
+		}
+	}
+}
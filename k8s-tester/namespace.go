@@ -0,0 +1,60 @@
+package k8s_tester
+
+import (
+	"reflect"
+	"strings"
+)
+
+// setRunNamespaces appends the run ID to the namespaces of the enabled testers,
+// except the shared system namespaces (e.g., "kube-system").
+// The namespaces that already have the run ID suffix are kept as is,
+// so that the same config can be re-applied (e.g., "--resume").
+func (cfg *Config) setRunNamespaces() {
+	vv := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < vv.NumField(); i++ {
+		fv := vv.Field(i)
+		if fv.Kind() != reflect.Ptr || fv.IsNil() || fv.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		enable, ns := fv.Elem().FieldByName("Enable"), fv.Elem().FieldByName("Namespace")
+		if !enable.IsValid() || enable.Kind() != reflect.Bool || !enable.Bool() {
+			continue
+		}
+		if !ns.IsValid() || ns.Kind() != reflect.String || !ns.CanSet() {
+			continue
+		}
+		ns.SetString(runNamespace(ns.String(), cfg.RunID))
+	}
+}
+
+// maxNamespaceLen is the maximum length of a namespace (DNS-1123 label).
+const maxNamespaceLen = 63
+
+// runNamespace returns the namespace with the run ID suffix,
+// truncating the namespace to fit in the maximum length.
+func runNamespace(ns string, runID string) string {
+	if ns == "" || runID == "" || isSystemNamespace(ns) {
+		return ns
+	}
+	suffix := "-" + runID
+	if strings.HasSuffix(ns, suffix) {
+		return ns
+	}
+	if len(ns)+len(suffix) > maxNamespaceLen {
+		ns = strings.TrimRight(ns[:maxNamespaceLen-len(suffix)], "-")
+	}
+	return ns + suffix
+}
+
+// systemNamespaces are the namespaces shared by all runs.
+var systemNamespaces = map[string]struct{}{
+	"default":         {},
+	"kube-system":     {},
+	"kube-public":     {},
+	"kube-node-lease": {},
+}
+
+func isSystemNamespace(ns string) bool {
+	_, ok := systemNamespaces[ns]
+	return ok
+}
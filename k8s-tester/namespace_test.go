@@ -0,0 +1,50 @@
+package k8s_tester
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunNamespace(t *testing.T) {
+	long := strings.Repeat("a", 60)
+	tests := []struct {
+		ns       string
+		expected string
+	}{
+		{"test-namespace", "test-namespace-abc123"},
+		{"test-namespace-abc123", "test-namespace-abc123"},
+		{"kube-system", "kube-system"},
+		{"kube-public", "kube-public"},
+		{"kube-node-lease", "kube-node-lease"},
+		{"kube-state-metrics", "kube-state-metrics-abc123"},
+		{"default", "default"},
+		{"", ""},
+		{long, strings.Repeat("a", 63-len("-abc123")) + "-abc123"},
+	}
+	for i, tt := range tests {
+		ns := runNamespace(tt.ns, "abc123")
+		if ns != tt.expected {
+			t.Fatalf("#%d: expected %q, got %q", i, tt.expected, ns)
+		}
+		if len(ns) > maxNamespaceLen {
+			t.Fatalf("#%d: namespace %q too long", i, ns)
+		}
+	}
+}
+
+func TestSetRunNamespaces(t *testing.T) {
+	cfg := NewDefault()
+	cfg.RunID = "abc123"
+	cfg.AddOnConformance.Enable = true
+	cfg.AddOnConformance.Namespace = "test-namespace"
+	cfg.AddOnJobsPi.Enable = false
+	cfg.AddOnJobsPi.Namespace = "test-namespace"
+
+	cfg.setRunNamespaces()
+	if cfg.AddOnConformance.Namespace != "test-namespace-abc123" {
+		t.Fatalf("unexpected cfg.AddOnConformance.Namespace %q", cfg.AddOnConformance.Namespace)
+	}
+	if cfg.AddOnJobsPi.Namespace != "test-namespace" {
+		t.Fatalf("unexpected cfg.AddOnJobsPi.Namespace %q", cfg.AddOnJobsPi.Namespace)
+	}
+}
@@ -0,0 +1,57 @@
+package network_policy
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+	networking_v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8s_testing "k8s.io/client-go/testing"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the server and client Pods, and the NetworkPolicies of each case, against a fake client.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	// the fake clientset does not implement "DeleteCollection",
+	// so remove the previous case policies from the tracker
+	cli.Clientset.PrependReactor("delete-collection", "networkpolicies", func(action k8s_testing.Action) (bool, runtime.Object, error) {
+		gvr := networking_v1.SchemeGroupVersion.WithResource("networkpolicies")
+		gvk := networking_v1.SchemeGroupVersion.WithKind("NetworkPolicy")
+		obj, err := cli.Clientset.Tracker().List(gvr, gvk, action.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+		for _, np := range obj.(*networking_v1.NetworkPolicyList).Items {
+			if err = cli.Clientset.Tracker().Delete(gvr, np.Namespace, np.Name); err != nil {
+				return true, nil, err
+			}
+		}
+		return true, nil, nil
+	})
+
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createPod(cfg.Namespace, serverPodName, rt.serverContainer()); err != nil {
+		return err
+	}
+	for _, ns := range []string{rt.allowedNamespace(), rt.deniedNamespace()} {
+		if err := rt.createPod(ns, clientPodName, rt.clientContainer()); err != nil {
+			return err
+		}
+	}
+	for _, c := range rt.cases() {
+		if err := rt.replacePolicies(c.policies); err != nil {
+			return err
+		}
+	}
+	return fake.Dump(w, cli)
+}
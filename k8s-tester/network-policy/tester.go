@@ -0,0 +1,551 @@
+// Package network_policy deploys client and server Pods across namespaces,
+// applies a set of NetworkPolicy objects (deny-all, namespace selectors, port rules),
+// and verifies the connectivity matches the expectations, in order to validate
+// the VPC CNI network policy support on EKS.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-network-policy.html
+package network_policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create the server Pod.
+	// The client Pods are created in "Namespace-allowed" and "Namespace-denied".
+	Namespace string `json:"namespace"`
+
+	// Image is the agnhost image to run the server ("porter") and the probes ("connect").
+	Image string `json:"image"`
+	// PropagationTimeout is the timeout for each case to match the expected connectivity,
+	// since the policies are enforced asynchronously by the network policy agent.
+	PropagationTimeout       time.Duration `json:"propagation_timeout"`
+	PropagationTimeoutString string        `json:"propagation_timeout_string" read-only:"true"`
+
+	// Results is the connectivity result of each case.
+	Results []CaseResult `json:"results" read-only:"true"`
+}
+
+// CaseResult is the connectivity result of a policy case.
+type CaseResult struct {
+	// Name is the case name (e.g., "deny-all").
+	Name string `json:"name"`
+	// Passed is true if the connectivity matched the expectations.
+	Passed bool `json:"passed"`
+	// Mismatches is the list of probes that did not match, in the last attempt.
+	Mismatches []string `json:"mismatches,omitempty"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	// leave room for the "-allowed" suffix
+	if len(cfg.Namespace) > 63-len(allowedSuffix) {
+		return fmt.Errorf("Namespace %q too long", cfg.Namespace)
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.PropagationTimeout == time.Duration(0) {
+		cfg.PropagationTimeout = DefaultPropagationTimeout
+	}
+	cfg.PropagationTimeoutString = cfg.PropagationTimeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes int = 1
+	// ref. https://github.com/kubernetes/kubernetes/tree/master/test/images/agnhost
+	DefaultImage              = "registry.k8s.io/e2e-test-images/agnhost:2.47"
+	DefaultPropagationTimeout = 2 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:             false,
+		Prompt:             false,
+		MinimumNodes:       DefaultMinimumNodes,
+		Namespace:          pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:              DefaultImage,
+		PropagationTimeout: DefaultPropagationTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	allowedSuffix = "-allowed"
+	deniedSuffix  = "-denied"
+
+	serverPodName = "server"
+	partOfLabel   = "network-policy"
+	clientPodName = "client"
+
+	portA int32 = 8080
+	portB int32 = 9090
+)
+
+func (ts *tester) allowedNamespace() string { return ts.cfg.Namespace + allowedSuffix }
+func (ts *tester) deniedNamespace() string  { return ts.cfg.Namespace + deniedSuffix }
+
+func (ts *tester) namespaces() []string {
+	return []string{ts.cfg.Namespace, ts.allowedNamespace(), ts.deniedNamespace()}
+}
+
+func (ts *tester) Apply() (err error) {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	for _, ns := range ts.namespaces() {
+		if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ns); err != nil {
+			return err
+		}
+	}
+
+	if err := ts.createPod(ts.cfg.Namespace, serverPodName, ts.serverContainer()); err != nil {
+		return err
+	}
+	for _, ns := range []string{ts.allowedNamespace(), ts.deniedNamespace()} {
+		if err := ts.createPod(ns, clientPodName, ts.clientContainer()); err != nil {
+			return err
+		}
+	}
+
+	serverIP, err := ts.waitForPods()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Results = nil
+	var failed []string
+	for _, c := range ts.cases() {
+		res := ts.runCase(c, serverIP)
+		ts.cfg.Results = append(ts.cfg.Results, res)
+		if !res.Passed {
+			failed = append(failed, c.name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("network policy cases failed %q", failed)
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	for _, ns := range ts.namespaces() {
+		if err := client.DeleteNamespaceAndWait(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ns,
+			client.DefaultNamespaceDeletionInterval,
+			client.DefaultNamespaceDeletionTimeout,
+			client.WithForceDelete(true),
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete namespace %q (%v)", ns, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespaces %q, should we continue?", action, ts.namespaces())
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// serverContainer serves the port number on both ports.
+// ref. https://github.com/kubernetes/kubernetes/tree/master/test/images/agnhost#porter
+func (ts *tester) serverContainer() core_v1.Container {
+	return core_v1.Container{
+		Name:            serverPodName,
+		Image:           ts.cfg.Image,
+		ImagePullPolicy: core_v1.PullIfNotPresent,
+		Args:            []string{"porter"},
+		Env: []core_v1.EnvVar{
+			{Name: fmt.Sprintf("SERVE_PORT_%d", portA), Value: fmt.Sprintf("%d", portA)},
+			{Name: fmt.Sprintf("SERVE_PORT_%d", portB), Value: fmt.Sprintf("%d", portB)},
+		},
+		Ports: []core_v1.ContainerPort{
+			{Name: "port-a", Protocol: core_v1.ProtocolTCP, ContainerPort: portA},
+			{Name: "port-b", Protocol: core_v1.ProtocolTCP, ContainerPort: portB},
+		},
+	}
+}
+
+func (ts *tester) clientContainer() core_v1.Container {
+	return core_v1.Container{
+		Name:            clientPodName,
+		Image:           ts.cfg.Image,
+		ImagePullPolicy: core_v1.PullIfNotPresent,
+		Args:            []string{"pause"},
+	}
+}
+
+func (ts *tester) createPod(namespace string, name string, container core_v1.Container) error {
+	ts.cfg.Logger.Info("creating Pod", zap.String("namespace", namespace), zap.String("name", name))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(namespace).
+		Create(
+			ctx,
+			&core_v1.Pod{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name":    name,
+						"app.kubernetes.io/part-of": partOfLabel,
+					},
+				},
+				Spec: core_v1.PodSpec{
+					RestartPolicy: core_v1.RestartPolicyAlways,
+					Containers:    []core_v1.Container{container},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("Pod already exists", zap.String("namespace", namespace), zap.String("name", name))
+			return nil
+		}
+		return fmt.Errorf("failed to create Pod %s/%s (%v)", namespace, name, err)
+	}
+	return nil
+}
+
+// waitForPods waits for the server and client Pods to be running,
+// and returns the server Pod IP.
+func (ts *tester) waitForPods() (serverIP string, err error) {
+	pods := map[string]string{
+		ts.cfg.Namespace:      serverPodName,
+		ts.allowedNamespace(): clientPodName,
+		ts.deniedNamespace():  clientPodName,
+	}
+	for ns, name := range pods {
+		if err := client.WaitTimeoutForPodRunningInNamespace(ts.cfg.Client.KubernetesClient(), name, ns, 5*time.Minute); err != nil {
+			return "", fmt.Errorf("Pod %s/%s not running (%v)", ns, name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pod, err := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Get(ctx, serverPodName, meta_v1.GetOptions{})
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to get server Pod (%v)", err)
+	}
+	if pod.Status.PodIP == "" {
+		return "", errors.New("empty server Pod IP")
+	}
+	ts.cfg.Logger.Info("server Pod is running", zap.String("pod-ip", pod.Status.PodIP), zap.String("node", pod.Spec.NodeName))
+	return pod.Status.PodIP, nil
+}
+
+// probe is a connection from a client namespace to a server port.
+type probe struct {
+	from string // "allowed" or "denied"
+	port int32
+}
+
+func (p probe) String() string { return fmt.Sprintf("%s->%d", p.from, p.port) }
+
+var probes = []probe{
+	{from: "allowed", port: portA},
+	{from: "allowed", port: portB},
+	{from: "denied", port: portA},
+	{from: "denied", port: portB},
+}
+
+// policyCase is a set of policies in the server namespace,
+// and the expected connectivity of each probe.
+type policyCase struct {
+	name     string
+	policies []*networking_v1.NetworkPolicy
+	expected map[probe]bool
+}
+
+func expectAll(v bool) map[probe]bool {
+	m := make(map[probe]bool, len(probes))
+	for _, p := range probes {
+		m[p] = v
+	}
+	return m
+}
+
+// cases returns the policy cases in order.
+// Each case replaces the policies of the previous case.
+func (ts *tester) cases() []policyCase {
+	serverSelector := meta_v1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": serverPodName}}
+	denyAll := &networking_v1.NetworkPolicy{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "deny-all"},
+		Spec: networking_v1.NetworkPolicySpec{
+			PodSelector: meta_v1.LabelSelector{},
+			PolicyTypes: []networking_v1.PolicyType{networking_v1.PolicyTypeIngress},
+		},
+	}
+	// "kubernetes.io/metadata.name" is set on all namespaces since v1.22
+	allowedPeer := networking_v1.NetworkPolicyPeer{
+		NamespaceSelector: &meta_v1.LabelSelector{
+			MatchLabels: map[string]string{"kubernetes.io/metadata.name": ts.allowedNamespace()},
+		},
+	}
+	allowNamespace := &networking_v1.NetworkPolicy{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "allow-namespace"},
+		Spec: networking_v1.NetworkPolicySpec{
+			PodSelector: serverSelector,
+			PolicyTypes: []networking_v1.PolicyType{networking_v1.PolicyTypeIngress},
+			Ingress: []networking_v1.NetworkPolicyIngressRule{
+				{From: []networking_v1.NetworkPolicyPeer{allowedPeer}},
+			},
+		},
+	}
+	tcp := core_v1.ProtocolTCP
+	portAStr := intstr.FromInt(int(portA))
+	allowPort := &networking_v1.NetworkPolicy{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "allow-namespace-port"},
+		Spec: networking_v1.NetworkPolicySpec{
+			PodSelector: serverSelector,
+			PolicyTypes: []networking_v1.PolicyType{networking_v1.PolicyTypeIngress},
+			Ingress: []networking_v1.NetworkPolicyIngressRule{
+				{
+					From:  []networking_v1.NetworkPolicyPeer{allowedPeer},
+					Ports: []networking_v1.NetworkPolicyPort{{Protocol: &tcp, Port: &portAStr}},
+				},
+			},
+		},
+	}
+
+	return []policyCase{
+		{name: "no-policy", expected: expectAll(true)},
+		{name: "deny-all", policies: []*networking_v1.NetworkPolicy{denyAll}, expected: expectAll(false)},
+		{
+			name:     "allow-namespace",
+			policies: []*networking_v1.NetworkPolicy{denyAll, allowNamespace},
+			expected: map[probe]bool{
+				{from: "allowed", port: portA}: true,
+				{from: "allowed", port: portB}: true,
+				{from: "denied", port: portA}:  false,
+				{from: "denied", port: portB}:  false,
+			},
+		},
+		{
+			name:     "allow-namespace-port",
+			policies: []*networking_v1.NetworkPolicy{denyAll, allowPort},
+			expected: map[probe]bool{
+				{from: "allowed", port: portA}: true,
+				{from: "allowed", port: portB}: false,
+				{from: "denied", port: portA}:  false,
+				{from: "denied", port: portB}:  false,
+			},
+		},
+		// connectivity must be restored once the policies are removed
+		{name: "policies-removed", expected: expectAll(true)},
+	}
+}
+
+func (ts *tester) runCase(c policyCase, serverIP string) CaseResult {
+	fmt.Fprintf(ts.cfg.LogWriter, "\nrunning network policy case %q\n", c.name)
+	res := CaseResult{Name: c.name}
+	if err := ts.replacePolicies(c.policies); err != nil {
+		res.Mismatches = []string{err.Error()}
+		return res
+	}
+
+	retryStart := time.Now()
+	for {
+		res.Mismatches = nil
+		for _, p := range probes {
+			ok := ts.connect(p, serverIP)
+			if ok != c.expected[p] {
+				res.Mismatches = append(res.Mismatches, fmt.Sprintf("%s expected connected %v, got %v", p, c.expected[p], ok))
+			}
+		}
+		sort.Strings(res.Mismatches)
+		if len(res.Mismatches) == 0 {
+			res.Passed = true
+			ts.cfg.Logger.Info("network policy case passed", zap.String("case", c.name), zap.String("took", time.Since(retryStart).String()))
+			return res
+		}
+		if time.Since(retryStart) > ts.cfg.PropagationTimeout {
+			ts.cfg.Logger.Warn("network policy case failed", zap.String("case", c.name), zap.Strings("mismatches", res.Mismatches))
+			return res
+		}
+		ts.cfg.Logger.Info("network policy case not matched yet; retrying", zap.String("case", c.name), zap.Strings("mismatches", res.Mismatches))
+		select {
+		case <-ts.cfg.Stopc:
+			res.Mismatches = append(res.Mismatches, "aborted")
+			return res
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// replacePolicies deletes all policies in the server namespace, and creates the new ones.
+func (ts *tester) replacePolicies(policies []*networking_v1.NetworkPolicy) error {
+	cli := ts.cfg.Client.KubernetesClient().NetworkingV1().NetworkPolicies(ts.cfg.Namespace)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := cli.DeleteCollection(ctx, meta_v1.DeleteOptions{}, meta_v1.ListOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to delete NetworkPolicies (%v)", err)
+	}
+	for _, p := range policies {
+		np := p.DeepCopy()
+		np.Namespace = ts.cfg.Namespace
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		_, err = cli.Create(ctx, np, meta_v1.CreateOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to create NetworkPolicy %q (%v)", np.Name, err)
+		}
+		ts.cfg.Logger.Info("created NetworkPolicy", zap.String("name", np.Name))
+	}
+	return nil
+}
+
+// connect returns true if the client Pod connects to the server port.
+// ref. https://github.com/kubernetes/kubernetes/tree/master/test/images/agnhost#connect
+func (ts *tester) connect(p probe, serverIP string) bool {
+	ns := ts.allowedNamespace()
+	if p.from == "denied" {
+		ns = ts.deniedNamespace()
+	}
+	args := []string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ns,
+		"exec",
+		clientPodName,
+		"--",
+		"/agnhost",
+		"connect",
+		net.JoinHostPort(serverIP, fmt.Sprintf("%d", p.port)),
+		"--timeout=3s",
+		"--protocol=tcp",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	out, err := exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	cancel()
+	if err != nil {
+		ts.cfg.Logger.Debug("probe not connected", zap.String("probe", p.String()), zap.String("output", string(out)), zap.Error(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package network_policy
+
+import (
+	"testing"
+)
+
+func TestCases(t *testing.T) {
+	ts := &tester{cfg: NewDefault()}
+	names := make(map[string]struct{})
+	for _, c := range ts.cases() {
+		if _, ok := names[c.name]; ok {
+			t.Fatalf("duplicate case %q", c.name)
+		}
+		names[c.name] = struct{}{}
+		if len(c.expected) != len(probes) {
+			t.Fatalf("case %q expected %d probes, got %d", c.name, len(probes), len(c.expected))
+		}
+		for _, p := range probes {
+			if _, ok := c.expected[p]; !ok {
+				t.Fatalf("case %q missing expectation for %s", c.name, p)
+			}
+		}
+		for _, np := range c.policies {
+			if np.Name == "" {
+				t.Fatalf("case %q has unnamed policy", c.name)
+			}
+		}
+	}
+}
+
+func TestValidateAndSetDefaults(t *testing.T) {
+	cfg := NewDefault()
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Namespace = "network-policy-0123456789012345678901234567890123456789012345678"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected too long Namespace error")
+	}
+}
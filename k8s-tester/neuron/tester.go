@@ -0,0 +1,752 @@
+// Package neuron installs the Neuron device plugin, verifies that the Inferentia
+// and Trainium nodes advertise "aws.amazon.com/neuron" allocatable, and runs a
+// minimal inference Job to confirm that the Neuron runtime works.
+// ref. https://awsdocs-neuron.readthedocs-hosted.com/en/latest/containers/kubernetes-getting-started.html
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/inferentia-support.html
+package neuron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNeuronNodes is the minimum number of the nodes with "NeuronDevices"
+	// allocatable required for installing this addon.
+	MinimumNeuronNodes int `json:"minimum_neuron_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// InstallDevicePlugin is true to install the Neuron device plugin DaemonSet.
+	// Set to false when the cluster already runs the device plugin,
+	// since two plugins conflict on the kubelet socket.
+	InstallDevicePlugin bool `json:"install_device_plugin"`
+	// DevicePluginImage is the image of the Neuron device plugin.
+	DevicePluginImage string `json:"device_plugin_image"`
+	// Image is the Neuron deep learning container image of the inference Job,
+	// with "neuron-ls" and "torch_neuronx" (i.e., for Inferentia2 and Trainium).
+	// ref. https://github.com/aws-neuron/deep-learning-containers
+	Image string `json:"image"`
+	// NeuronDevices is the number of Neuron devices requested by the Job Pod.
+	NeuronDevices int64 `json:"neuron_devices"`
+	// Timeout is the timeout for the Neuron devices to be allocatable, and for the Job to complete.
+	// The inference Job pulls a large image and compiles the model.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// NeuronNodes is the names of the nodes with "NeuronDevices" allocatable.
+	NeuronNodes []string `json:"neuron_nodes" read-only:"true"`
+	// AllocatableNeuronDevices is the total number of "aws.amazon.com/neuron" allocatable of the nodes.
+	AllocatableNeuronDevices int64 `json:"allocatable_neuron_devices" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNeuronNodes == 0 {
+		cfg.MinimumNeuronNodes = DefaultMinimumNeuronNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.DevicePluginImage == "" {
+		cfg.DevicePluginImage = DefaultDevicePluginImage
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.NeuronDevices == 0 {
+		cfg.NeuronDevices = DefaultNeuronDevices
+	}
+	if cfg.NeuronDevices < 0 {
+		return fmt.Errorf("invalid NeuronDevices %d", cfg.NeuronDevices)
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNeuronNodes int   = 1
+	DefaultDevicePluginImage        = "public.ecr.aws/neuron/neuron-device-plugin:2.22.4.0"
+	DefaultImage                    = "public.ecr.aws/neuron/pytorch-inference-neuronx:2.1.2-neuronx-py310-sdk2.20.0-ubuntu20.04"
+	DefaultNeuronDevices      int64 = 1
+	DefaultTimeout                  = 30 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:              false,
+		Prompt:              false,
+		MinimumNeuronNodes:  DefaultMinimumNeuronNodes,
+		Namespace:           pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		InstallDevicePlugin: true,
+		DevicePluginImage:   DefaultDevicePluginImage,
+		Image:               DefaultImage,
+		NeuronDevices:       DefaultNeuronDevices,
+		Timeout:             DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	devicePluginName = "neuron-device-plugin"
+	jobName          = "neuron-inference"
+	appLabel         = "app.kubernetes.io/name"
+
+	neuronResource core_v1.ResourceName = "aws.amazon.com/neuron"
+
+	// inferencePassed is printed by the inference Job on success.
+	inferencePassed = "NEURON INFERENCE PASSED"
+)
+
+// devicePluginClusterRoleName returns the name of the cluster-scoped RBAC resources,
+// unique per namespace.
+func (ts *tester) devicePluginClusterRoleName() string {
+	return ts.cfg.Namespace + "-" + devicePluginName
+}
+
+// neuronInstanceTypes are the instance types the device plugin runs on.
+// ref. https://github.com/aws-neuron/aws-neuron-sdk/blob/master/src/k8/k8s-neuron-device-plugin.yml
+var neuronInstanceTypes = []string{
+	"inf1.xlarge",
+	"inf1.2xlarge",
+	"inf1.6xlarge",
+	"inf1.24xlarge",
+	"inf2.xlarge",
+	"inf2.8xlarge",
+	"inf2.24xlarge",
+	"inf2.48xlarge",
+	"trn1.2xlarge",
+	"trn1.32xlarge",
+	"trn1n.32xlarge",
+	"trn2.48xlarge",
+}
+
+// inferenceScript lists the Neuron devices, and compiles and runs
+// a small model on a NeuronCore.
+const inferenceScript = `set -e
+neuron-ls
+python3 -c '
+import torch
+import torch_neuronx
+model = torch.nn.Linear(4, 4).eval()
+example = torch.rand(1, 4)
+traced = torch_neuronx.trace(model, example)
+print(traced(example))
+print("` + inferencePassed + `")
+'
+`
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if ts.cfg.InstallDevicePlugin {
+		if err := ts.createServiceAccount(); err != nil {
+			return err
+		}
+		if err := ts.createRBACClusterRole(); err != nil {
+			return err
+		}
+		if err := ts.createRBACClusterRoleBinding(); err != nil {
+			return err
+		}
+		if err := ts.createDevicePlugin(); err != nil {
+			return err
+		}
+	}
+	if err := ts.waitForAllocatable(); err != nil {
+		return err
+	}
+
+	if err := ts.createJob(); err != nil {
+		return err
+	}
+	if err := ts.checkJob(); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("Neuron inference Job completed",
+		zap.Strings("neuron-nodes", ts.cfg.NeuronNodes),
+		zap.Int64("allocatable-neuron-devices", ts.cfg.AllocatableNeuronDevices),
+	)
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteJob(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		jobName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+	}
+
+	if err := client.DeleteDaemonSet(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		devicePluginName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete DaemonSet (%v)", err))
+	}
+
+	if err := client.DeleteRBACClusterRoleBinding(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.devicePluginClusterRoleName(),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ClusterRoleBinding (%v)", err))
+	}
+
+	if err := client.DeleteRBACClusterRole(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.devicePluginClusterRoleName(),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ClusterRole (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createServiceAccount() error {
+	ts.cfg.Logger.Info("creating device plugin ServiceAccount")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ServiceAccounts(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ServiceAccount{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      devicePluginName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						appLabel: devicePluginName,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create device plugin ServiceAccount (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created device plugin ServiceAccount")
+	return nil
+}
+
+// ref. https://github.com/aws-neuron/aws-neuron-sdk/blob/master/src/k8/k8s-neuron-device-plugin-rbac.yml
+func (ts *tester) createRBACClusterRole() error {
+	ts.cfg.Logger.Info("creating device plugin RBAC ClusterRole")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		ClusterRoles().
+		Create(
+			ctx,
+			&rbac_v1.ClusterRole{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRole",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: ts.devicePluginClusterRoleName(),
+					Labels: map[string]string{
+						appLabel: devicePluginName,
+					},
+				},
+				Rules: []rbac_v1.PolicyRule{
+					{
+						APIGroups: []string{""},
+						Resources: []string{"nodes"},
+						Verbs:     []string{"get", "list", "watch"},
+					},
+					{
+						APIGroups: []string{""},
+						Resources: []string{"events"},
+						Verbs:     []string{"create", "patch"},
+					},
+					{
+						APIGroups: []string{""},
+						Resources: []string{"pods"},
+						Verbs:     []string{"update", "patch", "get", "list", "watch"},
+					},
+					{
+						APIGroups: []string{""},
+						Resources: []string{"nodes/status"},
+						Verbs:     []string{"patch", "update"},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create device plugin RBAC ClusterRole (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created device plugin RBAC ClusterRole")
+	return nil
+}
+
+func (ts *tester) createRBACClusterRoleBinding() error {
+	ts.cfg.Logger.Info("creating device plugin RBAC ClusterRoleBinding")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		ClusterRoleBindings().
+		Create(
+			ctx,
+			&rbac_v1.ClusterRoleBinding{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRoleBinding",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: ts.devicePluginClusterRoleName(),
+					Labels: map[string]string{
+						appLabel: devicePluginName,
+					},
+				},
+				RoleRef: rbac_v1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "ClusterRole",
+					Name:     ts.devicePluginClusterRoleName(),
+				},
+				Subjects: []rbac_v1.Subject{
+					{
+						Kind:      "ServiceAccount",
+						Name:      devicePluginName,
+						Namespace: ts.cfg.Namespace,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create device plugin RBAC ClusterRoleBinding (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created device plugin RBAC ClusterRoleBinding")
+	return nil
+}
+
+// ref. https://github.com/aws-neuron/aws-neuron-sdk/blob/master/src/k8/k8s-neuron-device-plugin.yml
+func (ts *tester) createDevicePlugin() error {
+	ts.cfg.Logger.Info("creating device plugin DaemonSet", zap.String("image", ts.cfg.DevicePluginImage))
+	dirType := core_v1.HostPathDirectory
+	allowPrivilegeEscalation := false
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      devicePluginName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							appLabel: devicePluginName,
+						},
+					},
+					UpdateStrategy: apps_v1.DaemonSetUpdateStrategy{
+						Type: apps_v1.RollingUpdateDaemonSetStrategyType,
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: devicePluginName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							ServiceAccountName: devicePluginName,
+							PriorityClassName:  "system-node-critical",
+							Tolerations: []core_v1.Toleration{
+								{
+									Key:      "CriticalAddonsOnly",
+									Operator: core_v1.TolerationOpExists,
+								},
+								{
+									Key:      string(neuronResource),
+									Operator: core_v1.TolerationOpExists,
+									Effect:   core_v1.TaintEffectNoSchedule,
+								},
+							},
+							Affinity: &core_v1.Affinity{
+								NodeAffinity: &core_v1.NodeAffinity{
+									RequiredDuringSchedulingIgnoredDuringExecution: &core_v1.NodeSelector{
+										NodeSelectorTerms: []core_v1.NodeSelectorTerm{
+											{
+												MatchExpressions: []core_v1.NodeSelectorRequirement{
+													{
+														Key:      "node.kubernetes.io/instance-type",
+														Operator: core_v1.NodeSelectorOpIn,
+														Values:   neuronInstanceTypes,
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            devicePluginName,
+									Image:           ts.cfg.DevicePluginImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Env: []core_v1.EnvVar{
+										{
+											Name:  "KUBECONFIG",
+											Value: "/etc/kubernetes/kubelet.conf",
+										},
+										{
+											Name: "NODE_NAME",
+											ValueFrom: &core_v1.EnvVarSource{
+												FieldRef: &core_v1.ObjectFieldSelector{
+													FieldPath: "spec.nodeName",
+												},
+											},
+										},
+									},
+									SecurityContext: &core_v1.SecurityContext{
+										AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+										Capabilities: &core_v1.Capabilities{
+											Drop: []core_v1.Capability{"ALL"},
+										},
+									},
+									VolumeMounts: []core_v1.VolumeMount{
+										{
+											Name:      "device-plugin",
+											MountPath: "/var/lib/kubelet/device-plugins",
+										},
+										{
+											Name:      "infa-map",
+											MountPath: "/run",
+										},
+									},
+								},
+							},
+							Volumes: []core_v1.Volume{
+								{
+									Name: "device-plugin",
+									VolumeSource: core_v1.VolumeSource{
+										HostPath: &core_v1.HostPathVolumeSource{
+											Path: "/var/lib/kubelet/device-plugins",
+											Type: &dirType,
+										},
+									},
+								},
+								{
+									Name: "infa-map",
+									VolumeSource: core_v1.VolumeSource{
+										HostPath: &core_v1.HostPathVolumeSource{
+											Path: "/run",
+											Type: &dirType,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created device plugin DaemonSet")
+	return nil
+}
+
+// waitForAllocatable waits until "MinimumNeuronNodes" nodes advertise
+// at least "NeuronDevices" of "aws.amazon.com/neuron" allocatable.
+func (ts *tester) waitForAllocatable() error {
+	ts.cfg.Logger.Info("waiting for Neuron allocatable", zap.Int("minimum-neuron-nodes", ts.cfg.MinimumNeuronNodes), zap.Int64("neuron-devices", ts.cfg.NeuronDevices))
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list nodes; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.NeuronNodes, ts.cfg.AllocatableNeuronDevices = findNeuronNodes(nodes, ts.cfg.NeuronDevices)
+		ts.cfg.Logger.Info("checked Neuron allocatable",
+			zap.Int("nodes", len(nodes)),
+			zap.Int("neuron-nodes", len(ts.cfg.NeuronNodes)),
+			zap.Int64("allocatable-neuron-devices", ts.cfg.AllocatableNeuronDevices),
+		)
+		if len(ts.cfg.NeuronNodes) >= ts.cfg.MinimumNeuronNodes {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to validate minimum Neuron nodes requirement %d (Neuron nodes %d) within %v", ts.cfg.MinimumNeuronNodes, len(ts.cfg.NeuronNodes), ts.cfg.Timeout)
+}
+
+// findNeuronNodes returns the sorted names of the nodes with at least "devices" of
+// "aws.amazon.com/neuron" allocatable, and the total allocatable of all nodes.
+func findNeuronNodes(nodes []core_v1.Node, devices int64) (names []string, allocatable int64) {
+	for _, node := range nodes {
+		q, ok := node.Status.Allocatable[neuronResource]
+		if !ok {
+			continue
+		}
+		allocatable += q.Value()
+		if q.Value() >= devices {
+			names = append(names, node.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, allocatable
+}
+
+func (ts *tester) createJob() error {
+	ts.cfg.Logger.Info("creating Job", zap.String("image", ts.cfg.Image), zap.Int64("neuron-devices", ts.cfg.NeuronDevices))
+	backoffLimit := int32(2)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: jobName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Tolerations: []core_v1.Toleration{
+								{
+									Key:      string(neuronResource),
+									Operator: core_v1.TolerationOpExists,
+									Effect:   core_v1.TaintEffectNoSchedule,
+								},
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c", inferenceScript},
+									Resources: core_v1.ResourceRequirements{
+										Limits: core_v1.ResourceList{
+											neuronResource: *resource.NewQuantity(ts.cfg.NeuronDevices, resource.DecimalSI),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Job")
+	return nil
+}
+
+// checkJob waits for the Job to complete, and checks the inference output
+// of the succeeded Pod.
+func (ts *tester) checkJob() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Timeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		time.Minute,
+		10*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		logs, err := client.CheckPodLogs(
+			ts.cfg.Logger,
+			ts.cfg.LogWriter,
+			ts.cfg.Stopc,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			pod.Name,
+		)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\nJob Pod %q logs:\n\n%s\n\n", pod.Name, logs)
+		if !strings.Contains(logs, inferencePassed) {
+			return fmt.Errorf("unexpected Neuron inference output from Pod %q", pod.Name)
+		}
+		return nil
+	}
+	return errors.New("no succeeded Job Pod")
+}
@@ -0,0 +1,45 @@
+package neuron
+
+import (
+	"reflect"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFindNeuronNodes(t *testing.T) {
+	node := func(name string, devices string) core_v1.Node {
+		n := core_v1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: name}}
+		if devices != "" {
+			n.Status.Allocatable = core_v1.ResourceList{neuronResource: resource.MustParse(devices)}
+		}
+		return n
+	}
+	nodes := []core_v1.Node{
+		node("inf2-24xlarge", "6"),
+		node("m5-large", ""),
+		node("inf2-xlarge", "1"),
+		node("trn1-unready", "0"),
+	}
+
+	tests := []struct {
+		devices     int64
+		names       []string
+		allocatable int64
+	}{
+		{devices: 1, names: []string{"inf2-24xlarge", "inf2-xlarge"}, allocatable: 7},
+		{devices: 2, names: []string{"inf2-24xlarge"}, allocatable: 7},
+		{devices: 16, names: nil, allocatable: 7},
+	}
+	for i, tt := range tests {
+		names, allocatable := findNeuronNodes(nodes, tt.devices)
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Fatalf("#%d: expected names %v, got %v", i, tt.names, names)
+		}
+		if allocatable != tt.allocatable {
+			t.Fatalf("#%d: expected allocatable %d, got %d", i, tt.allocatable, allocatable)
+		}
+	}
+}
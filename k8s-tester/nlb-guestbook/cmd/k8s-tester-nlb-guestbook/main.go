@@ -63,6 +63,8 @@ var (
 	region                 string
 	deploymentNodeSelector string
 	deploymentReplicas     int32
+	persistenceCheck       bool
+	redisStorageClassName  string
 )
 
 func newApply() *cobra.Command {
@@ -76,6 +78,8 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&region, "region", "", "region for ELB resource")
 	cmd.PersistentFlags().StringVar(&deploymentNodeSelector, "deployment-node-selector", "", "map of deployment node selector, must be valid JSON format")
 	cmd.PersistentFlags().Int32Var(&deploymentReplicas, "deployment-replicas", nlb_guestbook.DefaultDeploymentReplicas, "number of deployment replicas")
+	cmd.PersistentFlags().BoolVar(&persistenceCheck, "persistence-check", true, "'true' to verify guestbook entries survive a redis leader restart")
+	cmd.PersistentFlags().StringVar(&redisStorageClassName, "redis-storage-class-name", "", "storage class for the redis leader data, leave empty for the default storage class")
 
 	return cmd
 }
@@ -115,6 +119,10 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 
 		DeploymentNodeSelector: nodeSelector,
 		DeploymentReplicas:     deploymentReplicas,
+
+		PersistenceCheck:      persistenceCheck,
+		PersistenceEntries:    nlb_guestbook.DefaultPersistenceEntries,
+		RedisStorageClassName: redisStorageClassName,
 	}
 
 	ts := nlb_guestbook.New(cfg)
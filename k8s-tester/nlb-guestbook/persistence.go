@@ -0,0 +1,281 @@
+package nlb_guestbook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/utils/http"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	api_resource "k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	redisLeaderPVCName    = "redis-leader-data"
+	redisLeaderVolumeName = "redis-data"
+	// ref. https://github.com/bitnami/containers/tree/main/bitnami/redis#persisting-your-database
+	redisLeaderDataDir = "/bitnami/redis/data"
+	// bitnami images run as non-root "1001"
+	redisLeaderFSGroup int64 = 1001
+
+	// ref. https://github.com/kubernetes/examples/blob/master/guestbook-go/main.go
+	guestbookKey = "guestbook"
+)
+
+func (ts *tester) createPVCRedisLeader() error {
+	ts.cfg.Logger.Info("creating redis leader PersistentVolumeClaim", zap.String("storage-class", ts.cfg.RedisStorageClassName))
+	var scName *string
+	if ts.cfg.RedisStorageClassName != "" {
+		scName = &ts.cfg.RedisStorageClassName
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		PersistentVolumeClaims(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.PersistentVolumeClaim{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "PersistentVolumeClaim",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      redisLeaderPVCName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: core_v1.PersistentVolumeClaimSpec{
+					AccessModes:      []core_v1.PersistentVolumeAccessMode{core_v1.ReadWriteOnce},
+					StorageClassName: scName,
+					Resources: core_v1.VolumeResourceRequirements{
+						Requests: core_v1.ResourceList{
+							core_v1.ResourceStorage: api_resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("redis leader PersistentVolumeClaim already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create redis leader PersistentVolumeClaim (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created redis leader PersistentVolumeClaim")
+	return nil
+}
+
+// redisLeaderStrategy recreates the redis leader Pod, since the
+// "ReadWriteOnce" volume cannot be attached to two Pods on different nodes.
+func (ts *tester) redisLeaderStrategy() apps_v1.DeploymentStrategy {
+	if !ts.cfg.PersistenceCheck {
+		return apps_v1.DeploymentStrategy{}
+	}
+	return apps_v1.DeploymentStrategy{Type: apps_v1.RecreateDeploymentStrategyType}
+}
+
+func (ts *tester) redisLeaderVolumes() []core_v1.Volume {
+	if !ts.cfg.PersistenceCheck {
+		return nil
+	}
+	return []core_v1.Volume{
+		{
+			Name: redisLeaderVolumeName,
+			VolumeSource: core_v1.VolumeSource{
+				PersistentVolumeClaim: &core_v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: redisLeaderPVCName,
+				},
+			},
+		},
+	}
+}
+
+func (ts *tester) redisLeaderVolumeMounts() []core_v1.VolumeMount {
+	if !ts.cfg.PersistenceCheck {
+		return nil
+	}
+	return []core_v1.VolumeMount{
+		{
+			Name:      redisLeaderVolumeName,
+			MountPath: redisLeaderDataDir,
+		},
+	}
+}
+
+func (ts *tester) redisLeaderSecurityContext() *core_v1.PodSecurityContext {
+	if !ts.cfg.PersistenceCheck {
+		return nil
+	}
+	fsGroup := redisLeaderFSGroup
+	return &core_v1.PodSecurityContext{FSGroup: &fsGroup}
+}
+
+// checkPersistence posts guestbook entries through the frontend,
+// restarts the redis leader Pod, and verifies the entries survive.
+// The frontend writes to the redis leader and reads from the redis follower,
+// which resyncs from the restarted leader, so the entries survive
+// only if the leader persisted them.
+func (ts *tester) checkPersistence() error {
+	prefix := "k8s-tester-" + rand.String(7)
+	entries := make([]string, 0, ts.cfg.PersistenceEntries)
+	for i := 0; i < ts.cfg.PersistenceEntries; i++ {
+		entries = append(entries, fmt.Sprintf("%s-%d", prefix, i))
+	}
+
+	ts.cfg.Logger.Info("posting guestbook entries", zap.Strings("entries", entries))
+	for _, entry := range entries {
+		if err := ts.pushEntry(entry); err != nil {
+			return err
+		}
+	}
+	if err := ts.waitForEntries(entries, 3*time.Minute); err != nil {
+		return fmt.Errorf("guestbook entries not found before redis leader restart (%v)", err)
+	}
+
+	if err := ts.restartRedisLeader(); err != nil {
+		return err
+	}
+
+	if err := ts.waitForEntries(entries, 5*time.Minute); err != nil {
+		return fmt.Errorf("guestbook entries lost after redis leader restart (%v)", err)
+	}
+	ts.cfg.PersistedEntries = entries
+	fmt.Fprintf(ts.cfg.LogWriter, "\nNLB guestbook entries persisted through redis leader restart: %q\n\n", entries)
+	return nil
+}
+
+func (ts *tester) pushEntry(entry string) (err error) {
+	u := ts.cfg.ELBURL + "/rpush/" + guestbookKey + "/" + entry
+	for i := 0; i < 10; i++ {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("guestbook entry post aborted")
+		default:
+		}
+		if _, err = http.ReadInsecure(ts.cfg.Logger, ioutil.Discard, u); err == nil {
+			return nil
+		}
+		ts.cfg.Logger.Warn("failed to post guestbook entry; retrying", zap.String("entry", entry), zap.Error(err))
+		time.Sleep(5 * time.Second)
+	}
+	return fmt.Errorf("failed to post guestbook entry %q (%v)", entry, err)
+}
+
+// waitForEntries polls the guestbook entries until all expected entries are found.
+func (ts *tester) waitForEntries(entries []string, timeout time.Duration) error {
+	u := ts.cfg.ELBURL + "/lrange/" + guestbookKey
+	var missing []string
+	retryStart := time.Now()
+	for time.Since(retryStart) < timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("guestbook entry check aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		out, err := http.ReadInsecure(ts.cfg.Logger, ioutil.Discard, u)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to read guestbook entries; retrying", zap.Error(err))
+			continue
+		}
+		var found []string
+		if err = json.Unmarshal(out, &found); err != nil {
+			ts.cfg.Logger.Warn("failed to parse guestbook entries; retrying", zap.String("output", string(out)), zap.Error(err))
+			continue
+		}
+		missing = diffEntries(entries, found)
+		if len(missing) == 0 {
+			ts.cfg.Logger.Info("found all guestbook entries", zap.Int("entries", len(entries)))
+			return nil
+		}
+		ts.cfg.Logger.Info("guestbook entries missing; retrying", zap.Strings("missing", missing))
+	}
+	return fmt.Errorf("missing entries %q after %v", missing, timeout)
+}
+
+func diffEntries(expected []string, found []string) (missing []string) {
+	m := make(map[string]struct{}, len(found))
+	for _, v := range found {
+		m[v] = struct{}{}
+	}
+	for _, v := range expected {
+		if _, ok := m[v]; !ok {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// restartRedisLeader deletes the redis leader Pod, and waits for
+// its replacement to be available.
+func (ts *tester) restartRedisLeader() error {
+	selector := "app.kubernetes.io/name=" + redisLabelName + ",role=" + redisLeaderRoleName
+	pods, err := client.ListPods(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		10,
+		0,
+		client.WithLabelSelector(selector),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list redis leader Pods (%v)", err)
+	}
+	if len(pods) == 0 {
+		return errors.New("redis leader Pod not found")
+	}
+	deleted := make(map[types.UID]struct{}, len(pods))
+	for _, pod := range pods {
+		ts.cfg.Logger.Info("restarting redis leader Pod", zap.String("pod", pod.Name))
+		if err := client.DeletePod(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, pod.Name); err != nil {
+			return fmt.Errorf("failed to delete redis leader Pod (%v)", err)
+		}
+		deleted[pod.UID] = struct{}{}
+	}
+
+	// the volume is detached from the old node before the new Pod starts
+	retryStart := time.Now()
+	for time.Since(retryStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("redis leader restart aborted")
+		case <-time.After(10 * time.Second):
+		}
+		pods, err = client.ListPods(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			10,
+			0,
+			client.WithLabelSelector(selector),
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list redis leader Pods; retrying", zap.Error(err))
+			continue
+		}
+		for _, pod := range pods {
+			if _, ok := deleted[pod.UID]; ok {
+				continue
+			}
+			if pod.Status.Phase == core_v1.PodRunning {
+				ts.cfg.Logger.Info("redis leader Pod restarted", zap.String("pod", pod.Name), zap.String("node", pod.Spec.NodeName))
+				return ts.checkDeploymentRedisLeader()
+			}
+			ts.cfg.Logger.Info("waiting for new redis leader Pod", zap.String("pod", pod.Name), zap.String("phase", string(pod.Status.Phase)))
+		}
+	}
+	return errors.New("redis leader Pod not restarted in time")
+}
@@ -0,0 +1,54 @@
+package nlb_guestbook
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// NewRenderer returns the tester to render the manifests, without the AWS clients.
+func NewRenderer(cfg *Config) k8s_tester.Tester {
+	return &tester{cfg: cfg}
+}
+
+// Render writes the Redis leader PersistentVolumeClaim with "PersistenceCheck",
+// and the Deployments and the Services of Redis and the guestbook, against a fake client.
+// The Services are rendered for an IPv4 cluster.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if cfg.PersistenceCheck {
+		if err := rt.createPVCRedisLeader(); err != nil {
+			return err
+		}
+	}
+	if err := rt.createDeploymentRedisLeader(); err != nil {
+		return err
+	}
+	if err := rt.createServiceRedisLeader(); err != nil {
+		return err
+	}
+	if err := rt.createDeploymentRedisFollower(); err != nil {
+		return err
+	}
+	if err := rt.createServiceRedisFollower(); err != nil {
+		return err
+	}
+	if err := rt.createDeployment(); err != nil {
+		return err
+	}
+	if err := rt.createService(); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
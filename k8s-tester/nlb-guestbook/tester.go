@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -65,12 +66,28 @@ type Config struct {
 	ELBName string `json:"elb_name" read-only:"true"`
 	// ELBURL is the host name for guestbook service.
 	ELBURL string `json:"elb_url" read-only:"true"`
+
+	// PersistenceCheck is true to validate the data persistence through redis:
+	// post guestbook entries through the frontend, restart the redis leader Pod,
+	// and verify the entries survive. The redis leader stores its data
+	// in a PersistentVolumeClaim, which requires a storage class on the cluster.
+	PersistenceCheck bool `json:"persistence_check"`
+	// PersistenceEntries is the number of guestbook entries to post.
+	PersistenceEntries int `json:"persistence_entries"`
+	// RedisStorageClassName is the storage class for the redis leader data.
+	// Leave empty to use the cluster default storage class.
+	RedisStorageClassName string `json:"redis_storage_class_name"`
+	// PersistedEntries is the list of guestbook entries verified after the redis leader restart.
+	PersistedEntries []string `json:"persisted_entries" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
 	if cfg.Namespace == "" {
 		return errors.New("empty Namespace")
 	}
+	if cfg.PersistenceCheck && cfg.PersistenceEntries <= 0 {
+		cfg.PersistenceEntries = DefaultPersistenceEntries
+	}
 
 	return nil
 }
@@ -78,6 +95,7 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 const (
 	DefaultMinimumNodes       int   = 1
 	DefaultDeploymentReplicas int32 = 2
+	DefaultPersistenceEntries int   = 5
 )
 
 func NewDefault() *Config {
@@ -87,10 +105,20 @@ func NewDefault() *Config {
 		MinimumNodes:       DefaultMinimumNodes,
 		Namespace:          pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
 		DeploymentReplicas: DefaultDeploymentReplicas,
+		PersistenceCheck:   true,
+		PersistenceEntries: DefaultPersistenceEntries,
 	}
 }
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
 	awsCfg := aws_v1.Config{
 		Logger:        cfg.Logger,
 		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
@@ -99,7 +127,7 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 	awsSession, stsOutput, _, err := aws_v1.New(&awsCfg)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	cfg.ELB2API = elbv2.New(awsSession)
 	if cfg.AccountID == "" && stsOutput.Account != nil {
@@ -108,11 +136,36 @@ func New(cfg *Config) k8s_tester.Tester {
 
 	return &tester{
 		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return ts, nil
 }
 
 type tester struct {
 	cfg *Config
+	// network is the cluster IP family, to provision a dual-stack NLB on IPv6 clusters.
+	network client.ClusterNetwork
 }
 
 var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
@@ -162,10 +215,21 @@ func (ts *tester) Apply() error {
 		}
 	}
 
+	network, err := client.DetectClusterNetwork(ts.cfg.Logger, ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	ts.network = network
+
 	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
 		return err
 	}
 
+	if ts.cfg.PersistenceCheck {
+		if err := ts.createPVCRedisLeader(); err != nil {
+			return err
+		}
+	}
 	if err := ts.createDeploymentRedisLeader(); err != nil {
 		return err
 	}
@@ -214,6 +278,12 @@ func (ts *tester) Apply() error {
 		return err
 	}
 
+	if ts.cfg.PersistenceCheck {
+		if err := ts.checkPersistence(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -367,7 +437,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -406,6 +477,7 @@ func (ts *tester) createDeploymentRedisLeader() error {
 				},
 				Spec: apps_v1.DeploymentSpec{
 					Replicas: int32Ref(redisLeaderTargetReplicas),
+					Strategy: ts.redisLeaderStrategy(),
 					Selector: &meta_v1.LabelSelector{
 						MatchLabels: map[string]string{
 							"app.kubernetes.io/name": redisLabelName,
@@ -439,9 +511,12 @@ func (ts *tester) createDeploymentRedisLeader() error {
 											Value: "yes",
 										},
 									},
+									VolumeMounts: ts.redisLeaderVolumeMounts(),
 								},
 							},
-							NodeSelector: nodeSelector,
+							Volumes:         ts.redisLeaderVolumes(),
+							SecurityContext: ts.redisLeaderSecurityContext(),
+							NodeSelector:    nodeSelector,
 						},
 					},
 				},
@@ -958,6 +1033,7 @@ func (ts *tester) createService() error {
 					Labels: map[string]string{
 						"app.kubernetes.io/name": appName,
 					},
+					Annotations: client.WithLoadBalancerIPFamily(ts.network, nil),
 				},
 				Spec: core_v1.ServiceSpec{
 					Selector: map[string]string{
@@ -63,6 +63,10 @@ var (
 	region                 string
 	deploymentNodeSelector string
 	deploymentReplicas     int32
+	tlsEnable              bool
+	acmCertificateARN      string
+	tlsDomainName          string
+	route53HostedZoneID    string
 )
 
 func newApply() *cobra.Command {
@@ -76,6 +80,10 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&region, "region", "", "region for ELB resource")
 	cmd.PersistentFlags().StringVar(&deploymentNodeSelector, "deployment-node-selector", "", "map of deployment node selector, must be valid JSON format")
 	cmd.PersistentFlags().Int32Var(&deploymentReplicas, "deployment-replicas", nlb_hello_world.DefaultDeploymentReplicas, "number of deployment replicas")
+	cmd.PersistentFlags().BoolVar(&tlsEnable, "tls-enable", false, "'true' to add a TLS listener with the ACM certificate and verify HTTPS")
+	cmd.PersistentFlags().StringVar(&acmCertificateARN, "acm-certificate-arn", "", "existing ACM certificate ARN, leave empty to request one for --tls-domain-name")
+	cmd.PersistentFlags().StringVar(&tlsDomainName, "tls-domain-name", "", "domain name to point at the NLB and to request the ACM certificate for")
+	cmd.PersistentFlags().StringVar(&route53HostedZoneID, "route53-hosted-zone-id", "", "Route53 hosted zone ID of --tls-domain-name")
 
 	return cmd
 }
@@ -115,6 +123,11 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 
 		DeploymentNodeSelector: nodeSelector,
 		DeploymentReplicas:     deploymentReplicas,
+
+		TLSEnable:           tlsEnable,
+		ACMCertificateARN:   acmCertificateARN,
+		TLSDomainName:       tlsDomainName,
+		Route53HostedZoneID: route53HostedZoneID,
 	}
 
 	ts := nlb_hello_world.New(cfg)
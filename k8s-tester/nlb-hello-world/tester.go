@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -20,8 +21,12 @@ import (
 	"github.com/aws/aws-k8s-tester/utils/http"
 	"github.com/aws/aws-k8s-tester/utils/rand"
 	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 	"github.com/manifoldco/promptui"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -42,7 +47,9 @@ type Config struct {
 	LogWriter io.Writer     `json:"-"`
 	Client    client.Client `json:"-"`
 
-	ELB2API elbv2iface.ELBV2API `json:"-"`
+	ELB2API    elbv2iface.ELBV2API     `json:"-"`
+	ACMAPI     acmiface.ACMAPI         `json:"-"`
+	Route53API route53iface.Route53API `json:"-"`
 
 	AccountID string `json:"account_id" read-only:"true"`
 	Partition string `json:"partition"`
@@ -65,6 +72,32 @@ type Config struct {
 	ELBName string `json:"elb_name" read-only:"true"`
 	// ELBURL is the host name for hello-world service.
 	ELBURL string `json:"elb_url" read-only:"true"`
+
+	// TLSEnable is true to add a TLS listener on port 443 to the NLB,
+	// terminating TLS with the ACM certificate, and verify HTTPS end to end.
+	TLSEnable bool `json:"tls_enable"`
+	// ACMCertificateARN is the existing ACM certificate for the TLS listener.
+	// If empty, a new certificate is requested for "TLSDomainName"
+	// and validated with DNS records in "Route53HostedZoneID".
+	ACMCertificateARN string `json:"acm_certificate_arn"`
+	// TLSDomainName is the domain name to point at the NLB,
+	// to verify the certificate over HTTPS. If empty, HTTPS is checked
+	// against the NLB host name without verifying the certificate.
+	TLSDomainName string `json:"tls_domain_name"`
+	// Route53HostedZoneID is the public hosted zone of "TLSDomainName".
+	Route53HostedZoneID string `json:"route53_hosted_zone_id"`
+
+	// ACMCertificateRequested is true if the tester requested "ACMCertificateARN",
+	// so that the certificate is deleted with the NLB.
+	ACMCertificateRequested bool `json:"acm_certificate_requested" read-only:"true"`
+	// ACMValidationRecordName is the CNAME record name created for ACM DNS validation.
+	ACMValidationRecordName string `json:"acm_validation_record_name" read-only:"true"`
+	// ACMValidationRecordValue is the CNAME record value created for ACM DNS validation.
+	ACMValidationRecordValue string `json:"acm_validation_record_value" read-only:"true"`
+	// TLSDomainRecordValue is the NLB host name that "TLSDomainName" points to.
+	TLSDomainRecordValue string `json:"tls_domain_record_value" read-only:"true"`
+	// ELBHTTPSURL is the HTTPS URL for hello-world service.
+	ELBHTTPSURL string `json:"elb_https_url" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
@@ -72,6 +105,15 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		return errors.New("empty Namespace")
 	}
 
+	if cfg.TLSEnable {
+		if cfg.TLSDomainName != "" && cfg.Route53HostedZoneID == "" {
+			return errors.New("non-empty TLSDomainName but empty Route53HostedZoneID")
+		}
+		if cfg.ACMCertificateARN == "" && cfg.TLSDomainName == "" {
+			return errors.New("TLSEnable but empty ACMCertificateARN and TLSDomainName")
+		}
+	}
+
 	return nil
 }
 
@@ -91,6 +133,14 @@ func NewDefault() *Config {
 }
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
 	awsCfg := aws_v1.Config{
 		Logger:        cfg.Logger,
 		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
@@ -99,20 +149,47 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 	awsSession, stsOutput, _, err := aws_v1.New(&awsCfg)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	cfg.ELB2API = elbv2.New(awsSession)
+	cfg.ACMAPI = acm.New(awsSession)
+	cfg.Route53API = route53.New(awsSession)
 	if cfg.AccountID == "" && stsOutput.Account != nil {
 		cfg.AccountID = *stsOutput.Account
 	}
 
 	return &tester{
 		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
 	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 type tester struct {
 	cfg *Config
+	// network is the cluster IP family, to provision a dual-stack NLB on IPv6 clusters.
+	network client.ClusterNetwork
 }
 
 var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
@@ -143,10 +220,22 @@ func (ts *tester) Apply() error {
 		}
 	}
 
+	network, err := client.DetectClusterNetwork(ts.cfg.Logger, ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	ts.network = network
+
 	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
 		return err
 	}
 
+	if ts.cfg.TLSEnable {
+		if err := ts.requestCertificate(); err != nil {
+			return err
+		}
+	}
+
 	if err := ts.createDeployment(); err != nil {
 		return err
 	}
@@ -171,6 +260,12 @@ func (ts *tester) Apply() error {
 		return err
 	}
 
+	if ts.cfg.TLSEnable {
+		if err := ts.checkHTTPS(strings.TrimPrefix(ts.cfg.ELBURL, "http://")); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -244,6 +339,13 @@ func (ts *tester) Delete() error {
 		errs = append(errs, fmt.Sprintf("failed to delete ELB (%v)", err))
 	}
 
+	if ts.cfg.TLSEnable {
+		errs = append(errs, ts.deleteRecords()...)
+		if err := ts.deleteCertificate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
 	if err := client.DeleteNamespaceAndWait(
 		ts.cfg.Logger,
 		ts.cfg.Client.KubernetesClient(),
@@ -274,7 +376,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -410,24 +513,16 @@ func (ts *tester) createService() error {
 					Kind:       "Service",
 				},
 				ObjectMeta: meta_v1.ObjectMeta{
-					Name:      serviceName,
-					Namespace: ts.cfg.Namespace,
-					Annotations: map[string]string{
-						"service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
-					},
+					Name:        serviceName,
+					Namespace:   ts.cfg.Namespace,
+					Annotations: client.WithLoadBalancerIPFamily(ts.network, ts.serviceAnnotations()),
 				},
 				Spec: core_v1.ServiceSpec{
 					Selector: map[string]string{
 						"app.kubernetes.io/name": appName,
 					},
-					Type: core_v1.ServiceTypeLoadBalancer,
-					Ports: []core_v1.ServicePort{
-						{
-							Protocol:   core_v1.ProtocolTCP,
-							Port:       80,
-							TargetPort: intstr.FromInt(80),
-						},
-					},
+					Type:  core_v1.ServiceTypeLoadBalancer,
+					Ports: ts.servicePorts(),
 				},
 			},
 			meta_v1.CreateOptions{},
@@ -445,6 +540,41 @@ func (ts *tester) createService() error {
 	return nil
 }
 
+// serviceAnnotations returns the NLB annotations, terminating TLS
+// on port 443 with the ACM certificate if enabled.
+// ref. https://kubernetes-sigs.github.io/aws-load-balancer-controller/latest/guide/service/annotations/#tls
+func (ts *tester) serviceAnnotations() map[string]string {
+	annotations := map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+	}
+	if ts.cfg.TLSEnable {
+		annotations["service.beta.kubernetes.io/aws-load-balancer-ssl-cert"] = ts.cfg.ACMCertificateARN
+		annotations["service.beta.kubernetes.io/aws-load-balancer-ssl-ports"] = "443"
+		annotations["service.beta.kubernetes.io/aws-load-balancer-backend-protocol"] = "tcp"
+	}
+	return annotations
+}
+
+func (ts *tester) servicePorts() []core_v1.ServicePort {
+	ports := []core_v1.ServicePort{
+		{
+			Name:       "http",
+			Protocol:   core_v1.ProtocolTCP,
+			Port:       80,
+			TargetPort: intstr.FromInt(80),
+		},
+	}
+	if ts.cfg.TLSEnable {
+		ports = append(ports, core_v1.ServicePort{
+			Name:       "https",
+			Protocol:   core_v1.ProtocolTCP,
+			Port:       443,
+			TargetPort: intstr.FromInt(80),
+		})
+	}
+	return ports
+}
+
 func (ts *tester) checkService() (err error) {
 	queryFunc := func() {
 		args := []string{
@@ -0,0 +1,250 @@
+package nlb_hello_world
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/utils/http"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"go.uber.org/zap"
+)
+
+// requestCertificate requests an ACM certificate for "TLSDomainName" with DNS validation,
+// creates the validation record in "Route53HostedZoneID", and waits until the certificate is issued.
+// ref. https://docs.aws.amazon.com/acm/latest/userguide/dns-validation.html
+// If "ACMCertificateARN" is given, it only checks that the certificate is issued.
+func (ts *tester) requestCertificate() error {
+	if ts.cfg.ACMCertificateARN != "" && !ts.cfg.ACMCertificateRequested {
+		out, err := ts.cfg.ACMAPI.DescribeCertificate(&acm.DescribeCertificateInput{
+			CertificateArn: aws.String(ts.cfg.ACMCertificateARN),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe ACM certificate %q (%v)", ts.cfg.ACMCertificateARN, err)
+		}
+		if status := aws.StringValue(out.Certificate.Status); status != acm.CertificateStatusIssued {
+			return fmt.Errorf("ACM certificate %q is not issued (status %q)", ts.cfg.ACMCertificateARN, status)
+		}
+		ts.cfg.Logger.Info("using ACM certificate", zap.String("certificate-arn", ts.cfg.ACMCertificateARN))
+		return nil
+	}
+
+	if ts.cfg.ACMCertificateARN == "" {
+		ts.cfg.Logger.Info("requesting ACM certificate", zap.String("domain-name", ts.cfg.TLSDomainName))
+		out, err := ts.cfg.ACMAPI.RequestCertificate(&acm.RequestCertificateInput{
+			DomainName:       aws.String(ts.cfg.TLSDomainName),
+			ValidationMethod: aws.String(acm.ValidationMethodDns),
+			// same token for retries within an hour returns the same certificate
+			IdempotencyToken: aws.String(idempotencyToken(ts.cfg.Namespace)),
+			Tags: []*acm.Tag{
+				{Key: aws.String("Kind"), Value: aws.String("aws-k8s-tester")},
+				{Key: aws.String("Namespace"), Value: aws.String(ts.cfg.Namespace)},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to request ACM certificate (%v)", err)
+		}
+		ts.cfg.ACMCertificateARN = aws.StringValue(out.CertificateArn)
+		ts.cfg.ACMCertificateRequested = true
+		ts.cfg.Logger.Info("requested ACM certificate", zap.String("certificate-arn", ts.cfg.ACMCertificateARN))
+	}
+
+	// the validation record is populated asynchronously after the request
+	var record *acm.ResourceRecord
+	retryStart := time.Now()
+	for time.Since(retryStart) < 3*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("ACM certificate request aborted")
+		case <-time.After(5 * time.Second):
+		}
+		out, err := ts.cfg.ACMAPI.DescribeCertificate(&acm.DescribeCertificateInput{
+			CertificateArn: aws.String(ts.cfg.ACMCertificateARN),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe ACM certificate; retrying", zap.Error(err))
+			continue
+		}
+		if aws.StringValue(out.Certificate.Status) == acm.CertificateStatusIssued {
+			ts.cfg.Logger.Info("ACM certificate already issued")
+			return nil
+		}
+		for _, opt := range out.Certificate.DomainValidationOptions {
+			if opt.ResourceRecord != nil {
+				record = opt.ResourceRecord
+			}
+		}
+		if record != nil {
+			break
+		}
+	}
+	if record == nil {
+		return fmt.Errorf("ACM certificate %q has no DNS validation record", ts.cfg.ACMCertificateARN)
+	}
+	ts.cfg.ACMValidationRecordName = aws.StringValue(record.Name)
+	ts.cfg.ACMValidationRecordValue = aws.StringValue(record.Value)
+	if err := ts.changeRecord(route53.ChangeActionUpsert, ts.cfg.ACMValidationRecordName, ts.cfg.ACMValidationRecordValue); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("waiting for ACM certificate validation", zap.String("certificate-arn", ts.cfg.ACMCertificateARN))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	err := ts.cfg.ACMAPI.WaitUntilCertificateValidatedWithContext(ctx, &acm.DescribeCertificateInput{
+		CertificateArn: aws.String(ts.cfg.ACMCertificateARN),
+	})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("ACM certificate %q not validated (%v)", ts.cfg.ACMCertificateARN, err)
+	}
+	ts.cfg.Logger.Info("ACM certificate issued", zap.String("certificate-arn", ts.cfg.ACMCertificateARN))
+	return nil
+}
+
+// deleteCertificate deletes the requested ACM certificate, retrying while
+// the certificate is still associated with the NLB being deleted.
+func (ts *tester) deleteCertificate() error {
+	if !ts.cfg.ACMCertificateRequested || ts.cfg.ACMCertificateARN == "" {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting ACM certificate", zap.String("certificate-arn", ts.cfg.ACMCertificateARN))
+	var err error
+	for i := 0; i < 10; i++ {
+		_, err = ts.cfg.ACMAPI.DeleteCertificate(&acm.DeleteCertificateInput{
+			CertificateArn: aws.String(ts.cfg.ACMCertificateARN),
+		})
+		if err == nil {
+			ts.cfg.Logger.Info("deleted ACM certificate")
+			ts.cfg.ACMCertificateRequested = false
+			return nil
+		}
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case acm.ErrCodeResourceNotFoundException:
+				ts.cfg.Logger.Info("ACM certificate already deleted")
+				ts.cfg.ACMCertificateRequested = false
+				return nil
+			case acm.ErrCodeResourceInUseException:
+				ts.cfg.Logger.Info("ACM certificate still in use; retrying", zap.Error(err))
+				time.Sleep(30 * time.Second)
+				continue
+			}
+		}
+		break
+	}
+	return fmt.Errorf("failed to delete ACM certificate %q (%v)", ts.cfg.ACMCertificateARN, err)
+}
+
+// changeRecord creates, updates, or deletes the CNAME record in "Route53HostedZoneID".
+func (ts *tester) changeRecord(action string, name string, value string) error {
+	ts.cfg.Logger.Info("changing Route53 record",
+		zap.String("action", action),
+		zap.String("hosted-zone-id", ts.cfg.Route53HostedZoneID),
+		zap.String("name", name),
+		zap.String("value", value),
+	)
+	_, err := ts.cfg.Route53API.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(ts.cfg.Route53HostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: aws.String("aws-k8s-tester " + pkgName + " " + ts.cfg.Namespace),
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(action),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name: aws.String(name),
+						Type: aws.String(route53.RRTypeCname),
+						TTL:  aws.Int64(60),
+						ResourceRecords: []*route53.ResourceRecord{
+							{Value: aws.String(value)},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if action == route53.ChangeActionDelete {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == route53.ErrCodeInvalidChangeBatch {
+				ts.cfg.Logger.Info("Route53 record already deleted", zap.String("name", name))
+				return nil
+			}
+		}
+		return fmt.Errorf("failed to %s Route53 record %q (%v)", strings.ToLower(action), name, err)
+	}
+	return nil
+}
+
+// deleteRecords deletes the Route53 records created for the TLS listener.
+func (ts *tester) deleteRecords() (errs []string) {
+	if ts.cfg.TLSDomainRecordValue != "" {
+		if err := ts.changeRecord(route53.ChangeActionDelete, ts.cfg.TLSDomainName, ts.cfg.TLSDomainRecordValue); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			ts.cfg.TLSDomainRecordValue = ""
+		}
+	}
+	if ts.cfg.ACMCertificateRequested && ts.cfg.ACMValidationRecordName != "" {
+		if err := ts.changeRecord(route53.ChangeActionDelete, ts.cfg.ACMValidationRecordName, ts.cfg.ACMValidationRecordValue); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			ts.cfg.ACMValidationRecordName, ts.cfg.ACMValidationRecordValue = "", ""
+		}
+	}
+	return errs
+}
+
+// checkHTTPS verifies the TLS listener end to end. If "TLSDomainName" is set,
+// the domain is pointed at the NLB and the certificate is verified against it.
+// Otherwise, the NLB host name is read without verifying the certificate,
+// since the certificate does not cover the NLB host name.
+func (ts *tester) checkHTTPS(hostName string) error {
+	httpsURL := "https://" + hostName
+	read := http.ReadInsecure
+	if ts.cfg.TLSDomainName != "" {
+		if err := ts.changeRecord(route53.ChangeActionUpsert, ts.cfg.TLSDomainName, hostName); err != nil {
+			return err
+		}
+		ts.cfg.TLSDomainRecordValue = hostName
+		httpsURL = "https://" + ts.cfg.TLSDomainName
+		read = http.Read
+	}
+	ts.cfg.ELBHTTPSURL = httpsURL
+	fmt.Fprintf(ts.cfg.LogWriter, "NLB hello-world HTTPS URL: %s\n\n", httpsURL)
+
+	// DNS propagation for the new record may take a few minutes
+	retryStart := time.Now()
+	for time.Since(retryStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("hello-world Service HTTPS check aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		out, err := read(ts.cfg.Logger, ioutil.Discard, httpsURL)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to read NLB hello-world Service over HTTPS; retrying", zap.Error(err))
+			continue
+		}
+		if strings.Contains(string(out), `<h1>Hello world!</h1>`) {
+			ts.cfg.Logger.Info("read hello-world Service over HTTPS", zap.String("url", httpsURL))
+			return nil
+		}
+		ts.cfg.Logger.Warn("unexpected hello-world Service HTTPS output; retrying")
+	}
+	return fmt.Errorf("NLB hello-world %q did not return expected HTML output over HTTPS", httpsURL)
+}
+
+// idempotencyToken returns the ACM request token from the namespace,
+// which must be alphanumeric and up to 32 characters.
+func idempotencyToken(namespace string) string {
+	token := strings.Replace(namespace, "-", "", -1)
+	if len(token) > 32 {
+		token = token[len(token)-32:]
+	}
+	return token
+}
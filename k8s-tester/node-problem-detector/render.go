@@ -0,0 +1,43 @@
+package node_problem_detector
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// renderNodeName is the node rendered in place of the node of a ready node-problem-detector Pod.
+const renderNodeName = "ip-192-168-0-1.us-west-2.compute.internal"
+
+// Render writes the node-problem-detector DaemonSet, its RBAC and monitor config,
+// and the injector Pod, against a fake client.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createServiceAccount(); err != nil {
+		return err
+	}
+	if err := rt.createRBACClusterRoleBinding(); err != nil {
+		return err
+	}
+	if err := rt.createConfigMap(); err != nil {
+		return err
+	}
+	if err := rt.createDaemonSet(); err != nil {
+		return err
+	}
+	if err := rt.createInjectorPod(renderNodeName); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -0,0 +1,751 @@
+// Package node_problem_detector installs node-problem-detector as a DaemonSet,
+// injects a synthetic kernel problem on a node by writing to "/dev/kmsg" from a privileged Pod,
+// and verifies the matching condition surfaces on the Node object,
+// in order to validate the node health plumbing end to end.
+// ref. https://github.com/kubernetes/node-problem-detector
+package node_problem_detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the node-problem-detector image.
+	Image string `json:"image"`
+	// InjectorImage is the image of the privileged Pod that writes to "/dev/kmsg".
+	// It must ship a "sh" shell.
+	InjectorImage string `json:"injector_image"`
+	// ConditionTimeout is the timeout to wait for the injected condition on the Node.
+	ConditionTimeout       time.Duration `json:"condition_timeout"`
+	ConditionTimeoutString string        `json:"condition_timeout_string" read-only:"true"`
+
+	// InjectedNodeName is the node where the problem was injected.
+	InjectedNodeName string `json:"injected_node_name" read-only:"true"`
+	// ConditionLatency is the time from the injection to the condition on the Node.
+	ConditionLatency       time.Duration `json:"condition_latency" read-only:"true"`
+	ConditionLatencyString string        `json:"condition_latency_string" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.InjectorImage == "" {
+		cfg.InjectorImage = DefaultInjectorImage
+	}
+	if cfg.ConditionTimeout == time.Duration(0) {
+		cfg.ConditionTimeout = DefaultConditionTimeout
+	}
+	cfg.ConditionTimeoutString = cfg.ConditionTimeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes     int = 1
+	DefaultImage                = "registry.k8s.io/node-problem-detector/node-problem-detector:v0.8.19"
+	DefaultInjectorImage        = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultConditionTimeout     = 5 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:            DefaultImage,
+		InjectorImage:    DefaultInjectorImage,
+		ConditionTimeout: DefaultConditionTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	npdName                  = "node-problem-detector"
+	npdServiceAccountName    = "node-problem-detector"
+	npdClusterRoleBinding    = "k8s-tester-node-problem-detector"
+	npdConfigMapName         = "node-problem-detector-config"
+	npdConfigMapFileName     = "k8s-tester-monitor.json"
+	injectorPodName          = "node-problem-injector"
+	appLabel                 = "app.kubernetes.io/name"
+	injectedConditionType    = "K8sTesterProblem"
+	injectedConditionReason  = "K8sTesterInjectedProblem"
+	injectedConditionMessage = "k8s-tester injected problem"
+)
+
+// npdClusterRoleName is the bootstrap ClusterRole of node-problem-detector,
+// which allows updating the Node status and creating the Node events.
+// ref. https://kubernetes.io/docs/reference/access-authn-authz/rbac/#other-component-roles
+const npdClusterRoleName = "system:node-problem-detector"
+
+// monitorConfig is the kmsg system log monitor with a single permanent rule,
+// which sets "K8sTesterProblem" on the Node for the injected kernel messages.
+// The default monitors are not loaded, so the tester does not change
+// the conditions of the other node-problem-detector installations.
+// ref. https://github.com/kubernetes/node-problem-detector/blob/master/config/kernel-monitor.json
+const monitorConfig = `{
+  "plugin": "kmsg",
+  "logPath": "/dev/kmsg",
+  "lookback": "5m",
+  "bufferSize": 10,
+  "source": "k8s-tester-monitor",
+  "conditions": [
+    {
+      "type": "` + injectedConditionType + `",
+      "reason": "NoK8sTesterProblem",
+      "message": "k8s-tester has not injected a problem"
+    }
+  ],
+  "rules": [
+    {
+      "type": "permanent",
+      "condition": "` + injectedConditionType + `",
+      "reason": "` + injectedConditionReason + `",
+      "pattern": "` + injectedConditionMessage + `: .*"
+    }
+  ]
+}
+`
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createServiceAccount(); err != nil {
+		return err
+	}
+
+	if err := ts.createRBACClusterRoleBinding(); err != nil {
+		return err
+	}
+
+	if err := ts.createConfigMap(); err != nil {
+		return err
+	}
+
+	if err := ts.createDaemonSet(); err != nil {
+		return err
+	}
+
+	nodeName, err := ts.waitForDaemonSet()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.InjectedNodeName = nodeName
+	injectStart := time.Now()
+	if err := ts.createInjectorPod(nodeName); err != nil {
+		return err
+	}
+	if err := client.WaitForPodSuccessInNamespaceTimeout(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		injectorPodName,
+		ts.cfg.Namespace,
+		ts.cfg.ConditionTimeout,
+	); err != nil {
+		return fmt.Errorf("injector Pod did not succeed (%v)", err)
+	}
+
+	if err := ts.waitForCondition(nodeName); err != nil {
+		return err
+	}
+	ts.cfg.ConditionLatency = time.Since(injectStart)
+	ts.cfg.ConditionLatencyString = ts.cfg.ConditionLatency.String()
+	ts.cfg.Logger.Info("injected condition surfaced on Node",
+		zap.String("node-name", nodeName),
+		zap.String("condition-type", injectedConditionType),
+		zap.String("latency", ts.cfg.ConditionLatencyString),
+	)
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeletePod(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		injectorPodName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete injector Pod (%v)", err))
+	}
+
+	if err := client.DeleteDaemonSet(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		npdName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete DaemonSet (%v)", err))
+	}
+
+	if err := client.DeleteConfigmap(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		npdConfigMapName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ConfigMap (%v)", err))
+	}
+
+	if err := client.DeleteRBACClusterRoleBinding(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		npdClusterRoleBinding,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ClusterRoleBinding (%v)", err))
+	}
+
+	if err := client.DeleteServiceAccount(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		npdServiceAccountName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ServiceAccount (%v)", err))
+	}
+
+	// wait for the node-problem-detector Pods to be gone before clearing the conditions,
+	// otherwise they set the conditions again on the next status sync
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if err := ts.clearConditions(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to clear Node conditions (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createServiceAccount() error {
+	ts.cfg.Logger.Info("creating node-problem-detector ServiceAccount")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ServiceAccounts(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ServiceAccount{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      npdServiceAccountName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						appLabel: npdName,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create node-problem-detector ServiceAccount (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created node-problem-detector ServiceAccount")
+	return nil
+}
+
+func (ts *tester) createRBACClusterRoleBinding() error {
+	ts.cfg.Logger.Info("creating node-problem-detector RBAC ClusterRoleBinding")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		RbacV1().
+		ClusterRoleBindings().
+		Create(
+			ctx,
+			&rbac_v1.ClusterRoleBinding{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRoleBinding",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: npdClusterRoleBinding,
+					Labels: map[string]string{
+						appLabel: npdName,
+					},
+				},
+				RoleRef: rbac_v1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "ClusterRole",
+					Name:     npdClusterRoleName,
+				},
+				Subjects: []rbac_v1.Subject{
+					{
+						Kind:      "ServiceAccount",
+						Name:      npdServiceAccountName,
+						Namespace: ts.cfg.Namespace,
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create node-problem-detector RBAC ClusterRoleBinding (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created node-problem-detector RBAC ClusterRoleBinding")
+	return nil
+}
+
+func (ts *tester) createConfigMap() error {
+	ts.cfg.Logger.Info("creating node-problem-detector ConfigMap")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		ConfigMaps(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.ConfigMap{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      npdConfigMapName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						appLabel: npdName,
+					},
+				},
+				Data: map[string]string{
+					npdConfigMapFileName: monitorConfig,
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create node-problem-detector ConfigMap (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created node-problem-detector ConfigMap")
+	return nil
+}
+
+// tolerateAll lets the node-problem-detector run on every node, including tainted ones.
+var tolerateAll = []core_v1.Toleration{
+	{Operator: core_v1.TolerationOpExists},
+}
+
+func (ts *tester) createDaemonSet() error {
+	ts.cfg.Logger.Info("creating node-problem-detector DaemonSet", zap.String("image", ts.cfg.Image))
+	privileged := true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      npdName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							appLabel: npdName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: npdName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							ServiceAccountName: npdServiceAccountName,
+							RestartPolicy:      core_v1.RestartPolicyAlways,
+							Tolerations:        tolerateAll,
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            npdName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command: []string{
+										"/node-problem-detector",
+										"--logtostderr",
+										"--config.system-log-monitor=/config/" + npdConfigMapFileName,
+									},
+									Env: []core_v1.EnvVar{
+										{
+											Name: "NODE_NAME",
+											ValueFrom: &core_v1.EnvVarSource{
+												FieldRef: &core_v1.ObjectFieldSelector{
+													FieldPath: "spec.nodeName",
+												},
+											},
+										},
+									},
+									// reading "/dev/kmsg" requires privileged
+									SecurityContext: &core_v1.SecurityContext{
+										Privileged: &privileged,
+									},
+									VolumeMounts: []core_v1.VolumeMount{
+										{
+											Name:      "kmsg",
+											MountPath: "/dev/kmsg",
+											ReadOnly:  true,
+										},
+										{
+											Name:      "config",
+											MountPath: "/config",
+											ReadOnly:  true,
+										},
+									},
+								},
+							},
+							Volumes: []core_v1.Volume{
+								{
+									Name: "kmsg",
+									VolumeSource: core_v1.VolumeSource{
+										HostPath: &core_v1.HostPathVolumeSource{
+											Path: "/dev/kmsg",
+										},
+									},
+								},
+								{
+									Name: "config",
+									VolumeSource: core_v1.VolumeSource{
+										ConfigMap: &core_v1.ConfigMapVolumeSource{
+											LocalObjectReference: core_v1.LocalObjectReference{
+												Name: npdConfigMapName,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create node-problem-detector DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created node-problem-detector DaemonSet")
+	return nil
+}
+
+// waitForDaemonSet waits until the node-problem-detector Pods are ready on all scheduled nodes,
+// and returns the node name of a ready Pod to inject the problem.
+func (ts *tester) waitForDaemonSet() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.ConditionTimeout)
+	_, err := client.WaitForDaemonSet(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		npdName,
+		client.WithPollInterval(10*time.Second),
+		client.WithStopc(ts.cfg.Stopc),
+	)
+	cancel()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{LabelSelector: appLabel + "=" + npdName})
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to list node-problem-detector Pods (%v)", err)
+	}
+	for _, pod := range pods.Items {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == core_v1.PodReady && cond.Status == core_v1.ConditionTrue {
+				return pod.Spec.NodeName, nil
+			}
+		}
+	}
+	return "", errors.New("no ready node-problem-detector Pod")
+}
+
+// createInjectorPod creates the privileged Pod on the node,
+// which writes the problem message to the kernel ring buffer and exits.
+func (ts *tester) createInjectorPod(nodeName string) error {
+	ts.cfg.Logger.Info("creating injector Pod", zap.String("node-name", nodeName))
+	privileged := true
+	msg := fmt.Sprintf("%s: %s", injectedConditionMessage, ts.cfg.Namespace)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Pod{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      injectorPodName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						appLabel: injectorPodName,
+					},
+				},
+				Spec: core_v1.PodSpec{
+					RestartPolicy: core_v1.RestartPolicyNever,
+					// bypasses the scheduler to pin the Pod to the node
+					NodeName:    nodeName,
+					Tolerations: tolerateAll,
+					Containers: []core_v1.Container{
+						{
+							Name:            injectorPodName,
+							Image:           ts.cfg.InjectorImage,
+							ImagePullPolicy: core_v1.PullIfNotPresent,
+							Command:         []string{"sh", "-c", fmt.Sprintf("echo %q > /dev/kmsg", msg)},
+							SecurityContext: &core_v1.SecurityContext{
+								Privileged: &privileged,
+							},
+							VolumeMounts: []core_v1.VolumeMount{
+								{
+									Name:      "kmsg",
+									MountPath: "/dev/kmsg",
+								},
+							},
+						},
+					},
+					Volumes: []core_v1.Volume{
+						{
+							Name: "kmsg",
+							VolumeSource: core_v1.VolumeSource{
+								HostPath: &core_v1.HostPathVolumeSource{
+									Path: "/dev/kmsg",
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create injector Pod (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created injector Pod")
+	return nil
+}
+
+// waitForCondition waits until the injected condition is "True" on the Node.
+func (ts *tester) waitForCondition(nodeName string) error {
+	ts.cfg.Logger.Info("waiting for injected condition", zap.String("node-name", nodeName))
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.ConditionTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		node, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Nodes().
+			Get(ctx, nodeName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Node; retrying", zap.Error(err))
+			continue
+		}
+		for _, cond := range node.Status.Conditions {
+			if string(cond.Type) != injectedConditionType {
+				continue
+			}
+			ts.cfg.Logger.Info("found condition",
+				zap.String("status", string(cond.Status)),
+				zap.String("reason", cond.Reason),
+				zap.String("message", cond.Message),
+			)
+			if cond.Status == core_v1.ConditionTrue && cond.Reason == injectedConditionReason {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("condition %q not set on Node %q within %v", injectedConditionType, nodeName, ts.cfg.ConditionTimeout)
+}
+
+// clearConditions removes the injected condition from all Nodes,
+// since the Node conditions outlive node-problem-detector.
+func (ts *tester) clearConditions() error {
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		conds := make([]core_v1.NodeCondition, 0, len(node.Status.Conditions))
+		for _, cond := range node.Status.Conditions {
+			if string(cond.Type) != injectedConditionType {
+				conds = append(conds, cond)
+			}
+		}
+		if len(conds) == len(node.Status.Conditions) {
+			continue
+		}
+
+		node := node
+		node.Status.Conditions = conds
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err = ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Nodes().
+			UpdateStatus(ctx, &node, meta_v1.UpdateOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to update Node %q status (%v)", node.Name, err)
+		}
+		ts.cfg.Logger.Info("cleared injected condition", zap.String("node-name", node.Name))
+	}
+	return nil
+}
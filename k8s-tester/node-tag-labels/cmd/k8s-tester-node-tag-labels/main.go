@@ -0,0 +1,196 @@
+// k8s-tester-node-tag-labels tests the node label and taint propagation from EC2 instance tags.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	node_tag_labels "github.com/aws/aws-k8s-tester/k8s-tester/node-tag-labels"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-node-tag-labels",
+	Short:      "Kubernetes node EC2 tag propagation tester",
+	SuggestFor: []string{"node-tag-labels", "tags"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	partition          string
+	region             string
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	nodes              int
+	labelTagPrefix     string
+	labelKey           string
+	labelValue         string
+	taintEnable        bool
+	taintTagPrefix     string
+	taintKey           string
+	taintEffect        string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", node_tag_labels.DefaultPartition, "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for EC2 instances")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", node_tag_labels.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().IntVar(&nodes, "nodes", node_tag_labels.DefaultNodes, "number of EC2 worker nodes to tag")
+	rootCmd.PersistentFlags().StringVar(&labelTagPrefix, "label-tag-prefix", node_tag_labels.DefaultLabelTagPrefix, "tag key prefix of the EC2 tags propagated to the node labels")
+	rootCmd.PersistentFlags().StringVar(&labelKey, "label-key", node_tag_labels.DefaultLabelKey, "node label key expected from the EC2 tag")
+	rootCmd.PersistentFlags().StringVar(&labelValue, "label-value", "k8s-tester", "node label and taint value expected from the EC2 tags")
+	rootCmd.PersistentFlags().BoolVar(&taintEnable, "taint-enable", true, "'true' to also tag and verify a node taint")
+	rootCmd.PersistentFlags().StringVar(&taintTagPrefix, "taint-tag-prefix", node_tag_labels.DefaultTaintTagPrefix, "tag key prefix of the EC2 tags propagated to the node taints")
+	rootCmd.PersistentFlags().StringVar(&taintKey, "taint-key", node_tag_labels.DefaultTaintKey, "node taint key expected from the EC2 tag")
+	rootCmd.PersistentFlags().StringVar(&taintEffect, "taint-effect", node_tag_labels.DefaultTaintEffect, "node taint effect expected from the EC2 tag")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-node-tag-labels failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	timeout time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", node_tag_labels.DefaultTimeout, "timeout for the labels and taints to appear on the nodes")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &node_tag_labels.Config{
+		Prompt:         prompt,
+		Logger:         lg,
+		LogWriter:      logWriter,
+		Partition:      partition,
+		Region:         region,
+		MinimumNodes:   minimumNodes,
+		Client:         cli,
+		Nodes:          nodes,
+		LabelTagPrefix: labelTagPrefix,
+		LabelKey:       labelKey,
+		LabelValue:     labelValue,
+		TaintEnable:    taintEnable,
+		TaintTagPrefix: taintTagPrefix,
+		TaintKey:       taintKey,
+		TaintEffect:    taintEffect,
+		Timeout:        timeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := node_tag_labels.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-node-tag-labels apply' success (%d nodes, propagation latency %v)\n", len(cfg.TaggedNodes), cfg.PropagationLatency)
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &node_tag_labels.Config{
+		Prompt:         prompt,
+		Logger:         lg,
+		LogWriter:      logWriter,
+		Partition:      partition,
+		Region:         region,
+		Client:         cli,
+		Nodes:          nodes,
+		LabelTagPrefix: labelTagPrefix,
+		LabelKey:       labelKey,
+		LabelValue:     labelValue,
+		TaintEnable:    taintEnable,
+		TaintTagPrefix: taintTagPrefix,
+		TaintKey:       taintKey,
+		TaintEffect:    taintEffect,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := node_tag_labels.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-node-tag-labels delete' success\n")
+}
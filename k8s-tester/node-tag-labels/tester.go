@@ -0,0 +1,519 @@
+// Package node_tag_labels sets EC2 instance tags on the worker nodes, and verifies
+// that the expected node labels and taints appear, as propagated by the cloud
+// provider or the custom controller under test.
+// The tag keys follow the cluster autoscaler node template convention by default,
+// "<LabelTagPrefix><label key>=<label value>" and
+// "<TaintTagPrefix><taint key>=<taint value>:<taint effect>".
+// ref. https://github.com/kubernetes/autoscaler/blob/master/cluster-autoscaler/cloudprovider/aws/README.md#auto-discovery-setup
+package node_tag_labels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	EC2API ec2iface.EC2API `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Nodes is the number of the EC2 worker nodes to tag.
+	// The nodes are chosen in the order of their names.
+	Nodes int `json:"nodes"`
+
+	// LabelTagPrefix is the tag key prefix of the EC2 tags propagated to the node labels.
+	LabelTagPrefix string `json:"label_tag_prefix"`
+	// LabelKey is the node label key expected from the EC2 tag.
+	LabelKey string `json:"label_key"`
+	// LabelValue is the node label value expected from the EC2 tag,
+	// unique per run so that the stale labels are not mistaken for the propagation.
+	LabelValue string `json:"label_value"`
+
+	// TaintEnable is true to also tag and to verify a node taint.
+	TaintEnable bool `json:"taint_enable"`
+	// TaintTagPrefix is the tag key prefix of the EC2 tags propagated to the node taints.
+	TaintTagPrefix string `json:"taint_tag_prefix"`
+	// TaintKey is the node taint key expected from the EC2 tag.
+	// The taint value is "LabelValue".
+	TaintKey string `json:"taint_key"`
+	// TaintEffect is the node taint effect expected from the EC2 tag.
+	// Defaults to "PreferNoSchedule" so as not to disrupt the other add-ons.
+	TaintEffect string `json:"taint_effect"`
+
+	// Timeout is the timeout for the labels and taints to appear on the nodes.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// TaggedInstanceIDs is the EC2 instances tagged by this tester.
+	TaggedInstanceIDs []string `json:"tagged_instance_ids" read-only:"true"`
+	// TaggedNodes is the nodes of the tagged EC2 instances.
+	TaggedNodes []string `json:"tagged_nodes" read-only:"true"`
+	// PropagationLatency is the time from tagging to all labels and taints observed.
+	PropagationLatency       time.Duration `json:"propagation_latency" read-only:"true"`
+	PropagationLatencyString string        `json:"propagation_latency_string" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.Nodes == 0 {
+		cfg.Nodes = DefaultNodes
+	}
+	if cfg.Nodes < 0 {
+		return fmt.Errorf("invalid Nodes %d", cfg.Nodes)
+	}
+	if cfg.MinimumNodes < cfg.Nodes {
+		cfg.MinimumNodes = cfg.Nodes
+	}
+	if cfg.LabelTagPrefix == "" {
+		cfg.LabelTagPrefix = DefaultLabelTagPrefix
+	}
+	if cfg.LabelKey == "" {
+		cfg.LabelKey = DefaultLabelKey
+	}
+	if errs := validation.IsQualifiedName(cfg.LabelKey); len(errs) > 0 {
+		return fmt.Errorf("invalid LabelKey %q (%s)", cfg.LabelKey, strings.Join(errs, ", "))
+	}
+	if cfg.LabelValue == "" {
+		return errors.New("empty LabelValue")
+	}
+	if errs := validation.IsValidLabelValue(cfg.LabelValue); len(errs) > 0 {
+		return fmt.Errorf("invalid LabelValue %q (%s)", cfg.LabelValue, strings.Join(errs, ", "))
+	}
+	if cfg.TaintTagPrefix == "" {
+		cfg.TaintTagPrefix = DefaultTaintTagPrefix
+	}
+	if cfg.TaintKey == "" {
+		cfg.TaintKey = DefaultTaintKey
+	}
+	if errs := validation.IsQualifiedName(cfg.TaintKey); len(errs) > 0 {
+		return fmt.Errorf("invalid TaintKey %q (%s)", cfg.TaintKey, strings.Join(errs, ", "))
+	}
+	if cfg.TaintEffect == "" {
+		cfg.TaintEffect = DefaultTaintEffect
+	}
+	switch core_v1.TaintEffect(cfg.TaintEffect) {
+	case core_v1.TaintEffectNoSchedule, core_v1.TaintEffectPreferNoSchedule, core_v1.TaintEffectNoExecute:
+	default:
+		return fmt.Errorf("invalid TaintEffect %q", cfg.TaintEffect)
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes   int = 1
+	DefaultPartition          = "aws"
+	DefaultNodes          int = 1
+	DefaultLabelTagPrefix     = "k8s.io/cluster-autoscaler/node-template/label/"
+	DefaultLabelKey           = "k8s-tester.aws/tag-label"
+	DefaultTaintTagPrefix     = "k8s.io/cluster-autoscaler/node-template/taint/"
+	DefaultTaintKey           = "k8s-tester.aws/tag-taint"
+	DefaultTaintEffect        = string(core_v1.TaintEffectPreferNoSchedule)
+	DefaultTimeout            = 10 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:         false,
+		Prompt:         false,
+		Partition:      DefaultPartition,
+		MinimumNodes:   DefaultMinimumNodes,
+		Nodes:          DefaultNodes,
+		LabelTagPrefix: DefaultLabelTagPrefix,
+		LabelKey:       DefaultLabelKey,
+		LabelValue:     rand.String(10),
+		TaintEnable:    true,
+		TaintTagPrefix: DefaultTaintTagPrefix,
+		TaintKey:       DefaultTaintKey,
+		TaintEffect:    DefaultTaintEffect,
+		Timeout:        DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.EC2API = ec2.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if len(nodes) < ts.cfg.MinimumNodes || err != nil {
+		return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+	}
+
+	targets := pickNodes(nodes, ts.cfg.Nodes)
+	if len(targets) < ts.cfg.Nodes {
+		return fmt.Errorf("expected %d EC2 nodes to tag, found %d", ts.cfg.Nodes, len(targets))
+	}
+	ts.setTargets(targets)
+
+	start := time.Now()
+	if err := ts.createTags(); err != nil {
+		return err
+	}
+	if err := ts.waitForNodes(); err != nil {
+		return err
+	}
+	ts.cfg.PropagationLatency = time.Since(start)
+	ts.cfg.PropagationLatencyString = ts.cfg.PropagationLatency.String()
+
+	ts.cfg.Logger.Info("EC2 tags propagated to nodes",
+		zap.Strings("nodes", ts.cfg.TaggedNodes),
+		zap.String("propagation-latency", ts.cfg.PropagationLatencyString),
+	)
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if len(ts.cfg.TaggedNodes) == 0 {
+		// e.g., deleting from a new process, the nodes are chosen in the same order
+		nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+		if err != nil {
+			return fmt.Errorf("failed to list nodes (%v)", err)
+		}
+		ts.setTargets(pickNodes(nodes, ts.cfg.Nodes))
+	}
+
+	if len(ts.cfg.TaggedInstanceIDs) > 0 {
+		ts.cfg.Logger.Info("deleting EC2 tags", zap.Strings("instance-ids", ts.cfg.TaggedInstanceIDs))
+		_, err := ts.cfg.EC2API.DeleteTags(&ec2.DeleteTagsInput{
+			Resources: aws.StringSlice(ts.cfg.TaggedInstanceIDs),
+			Tags:      toEC2Tags(ts.tags()),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete EC2 tags (%v)", err))
+		}
+	}
+
+	// the labels and taints may not be removed with the tags,
+	// depending on the controller under test
+	for _, nodeName := range ts.cfg.TaggedNodes {
+		if err := ts.removeLabelAndTaint(nodeName); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q EC2 tags %v on the nodes, should we continue?", action, ts.tags())
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// tags returns the EC2 tags expected to be propagated to the node labels and taints.
+func (ts *tester) tags() map[string]string {
+	tags := map[string]string{
+		ts.cfg.LabelTagPrefix + ts.cfg.LabelKey: ts.cfg.LabelValue,
+	}
+	if ts.cfg.TaintEnable {
+		tags[ts.cfg.TaintTagPrefix+ts.cfg.TaintKey] = ts.cfg.LabelValue + ":" + ts.cfg.TaintEffect
+	}
+	return tags
+}
+
+func toEC2Tags(tags map[string]string) []*ec2.Tag {
+	rs := make([]*ec2.Tag, 0, len(tags))
+	for _, k := range sortedKeys(tags) {
+		rs = append(rs, &ec2.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return rs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// instanceID returns the EC2 instance ID from the node provider ID
+// (e.g., "aws:///us-west-2a/i-0123456789abcdef0"), or an empty string
+// for the non-EC2 nodes (e.g., Fargate).
+func instanceID(providerID string) string {
+	if !strings.HasPrefix(providerID, "aws://") {
+		return ""
+	}
+	id := providerID[strings.LastIndex(providerID, "/")+1:]
+	if !strings.HasPrefix(id, "i-") {
+		return ""
+	}
+	return id
+}
+
+// pickNodes returns up to "n" EC2 nodes in the order of their names,
+// mapped to their instance IDs.
+func pickNodes(nodes []core_v1.Node, n int) map[string]string {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	targets := make(map[string]string)
+	for _, node := range nodes {
+		if len(targets) == n {
+			break
+		}
+		if id := instanceID(node.Spec.ProviderID); id != "" {
+			targets[node.Name] = id
+		}
+	}
+	return targets
+}
+
+// setTargets sets the tagged nodes and their instance IDs in the order of the node names.
+func (ts *tester) setTargets(targets map[string]string) {
+	ts.cfg.TaggedNodes, ts.cfg.TaggedInstanceIDs = nil, nil
+	for _, nodeName := range sortedKeys(targets) {
+		ts.cfg.TaggedNodes = append(ts.cfg.TaggedNodes, nodeName)
+		ts.cfg.TaggedInstanceIDs = append(ts.cfg.TaggedInstanceIDs, targets[nodeName])
+	}
+}
+
+func (ts *tester) createTags() error {
+	ts.cfg.Logger.Info("creating EC2 tags",
+		zap.Strings("instance-ids", ts.cfg.TaggedInstanceIDs),
+		zap.Any("tags", ts.tags()),
+	)
+	_, err := ts.cfg.EC2API.CreateTags(&ec2.CreateTagsInput{
+		Resources: aws.StringSlice(ts.cfg.TaggedInstanceIDs),
+		Tags:      toEC2Tags(ts.tags()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create EC2 tags (%v)", err)
+	}
+	ts.cfg.Logger.Info("created EC2 tags")
+	return nil
+}
+
+// waitForNodes waits until all tagged nodes have the expected label and taint.
+func (ts *tester) waitForNodes() error {
+	var pending []string
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		pending = nil
+		for _, nodeName := range ts.cfg.TaggedNodes {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			node, err := ts.cfg.Client.KubernetesClient().CoreV1().Nodes().Get(ctx, nodeName, meta_v1.GetOptions{})
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to get node; retrying", zap.String("node-name", nodeName), zap.Error(err))
+				pending = append(pending, nodeName)
+				continue
+			}
+			if !ts.propagated(node) {
+				pending = append(pending, nodeName)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		ts.cfg.Logger.Info("waiting for EC2 tags to propagate",
+			zap.Strings("pending-nodes", pending),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+	}
+	return fmt.Errorf("EC2 tags not propagated to nodes %v within %v", pending, ts.cfg.Timeout)
+}
+
+// propagated returns true if the node has the expected label, and the expected taint if enabled.
+func (ts *tester) propagated(node *core_v1.Node) bool {
+	if node.Labels[ts.cfg.LabelKey] != ts.cfg.LabelValue {
+		return false
+	}
+	if !ts.cfg.TaintEnable {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == ts.cfg.TaintKey && taint.Value == ts.cfg.LabelValue && string(taint.Effect) == ts.cfg.TaintEffect {
+			return true
+		}
+	}
+	return false
+}
+
+// removeLabelAndTaint removes the label and taint of this tester from the node, if any.
+func (ts *tester) removeLabelAndTaint(nodeName string) error {
+	ts.cfg.Logger.Info("removing node label and taint", zap.String("node-name", nodeName))
+	patch := fmt.Sprintf(`{"metadata":{"labels":{%q:null}}}`, ts.cfg.LabelKey)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Nodes().
+		Patch(ctx, nodeName, types.StrategicMergePatchType, []byte(patch), meta_v1.PatchOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to remove label from node %q (%v)", nodeName, err)
+	}
+
+	if !ts.cfg.TaintEnable {
+		return nil
+	}
+	// "spec.taints" has no patch merge key, so update the whole list
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		node, err := ts.cfg.Client.KubernetesClient().CoreV1().Nodes().Get(ctx, nodeName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			return err
+		}
+		taints := make([]core_v1.Taint, 0, len(node.Spec.Taints))
+		for _, taint := range node.Spec.Taints {
+			if taint.Key != ts.cfg.TaintKey {
+				taints = append(taints, taint)
+			}
+		}
+		if len(taints) == len(node.Spec.Taints) {
+			return nil
+		}
+		node.Spec.Taints = taints
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		_, err = ts.cfg.Client.KubernetesClient().CoreV1().Nodes().Update(ctx, node, meta_v1.UpdateOptions{})
+		cancel()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove taint from node %q (%v)", nodeName, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package node_tag_labels
+
+import (
+	"reflect"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInstanceID(t *testing.T) {
+	tests := []struct {
+		providerID string
+		id         string
+	}{
+		{providerID: "aws:///us-west-2a/i-0123456789abcdef0", id: "i-0123456789abcdef0"},
+		{providerID: "aws:///us-west-2a/fargate-ip-192-168-1-1.us-west-2.compute.internal", id: ""},
+		{providerID: "kind://docker/kind/kind-control-plane", id: ""},
+		{providerID: "", id: ""},
+	}
+	for i, tt := range tests {
+		if id := instanceID(tt.providerID); id != tt.id {
+			t.Fatalf("#%d: expected %q, got %q", i, tt.id, id)
+		}
+	}
+}
+
+func TestPickNodes(t *testing.T) {
+	node := func(name, providerID string) core_v1.Node {
+		return core_v1.Node{
+			ObjectMeta: meta_v1.ObjectMeta{Name: name},
+			Spec:       core_v1.NodeSpec{ProviderID: providerID},
+		}
+	}
+	nodes := []core_v1.Node{
+		node("ip-192-168-3-3", "aws:///us-west-2c/i-3"),
+		node("fargate-ip-192-168-1-1", "aws:///us-west-2a/fargate-ip-192-168-1-1"),
+		node("ip-192-168-2-2", "aws:///us-west-2b/i-2"),
+		node("ip-192-168-1-1", "aws:///us-west-2a/i-1"),
+	}
+
+	targets := pickNodes(nodes, 2)
+	expected := map[string]string{"ip-192-168-1-1": "i-1", "ip-192-168-2-2": "i-2"}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Fatalf("expected %v, got %v", expected, targets)
+	}
+	if targets = pickNodes(nodes, 5); len(targets) != 3 {
+		t.Fatalf("expected 3 EC2 nodes, got %v", targets)
+	}
+}
+
+func TestPropagated(t *testing.T) {
+	cfg := NewDefault()
+	cfg.Region = "us-west-2"
+	cfg.LabelValue = "abc"
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	ts := &tester{cfg: cfg}
+
+	expectedTags := map[string]string{
+		"k8s.io/cluster-autoscaler/node-template/label/k8s-tester.aws/tag-label": "abc",
+		"k8s.io/cluster-autoscaler/node-template/taint/k8s-tester.aws/tag-taint": "abc:PreferNoSchedule",
+	}
+	if tags := ts.tags(); !reflect.DeepEqual(tags, expectedTags) {
+		t.Fatalf("expected tags %v, got %v", expectedTags, tags)
+	}
+
+	node := &core_v1.Node{ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{cfg.LabelKey: "abc"}}}
+	if ts.propagated(node) {
+		t.Fatal("unexpected propagated without taint")
+	}
+	node.Spec.Taints = []core_v1.Taint{{Key: cfg.TaintKey, Value: "abc", Effect: core_v1.TaintEffectNoSchedule}}
+	if ts.propagated(node) {
+		t.Fatal("unexpected propagated with wrong taint effect")
+	}
+	node.Spec.Taints = append(node.Spec.Taints, core_v1.Taint{Key: cfg.TaintKey, Value: "abc", Effect: core_v1.TaintEffectPreferNoSchedule})
+	if !ts.propagated(node) {
+		t.Fatal("expected propagated")
+	}
+	node.Labels[cfg.LabelKey] = "stale"
+	if ts.propagated(node) {
+		t.Fatal("unexpected propagated with stale label")
+	}
+
+	cfg.TaintEnable = false
+	if tags := ts.tags(); len(tags) != 1 {
+		t.Fatalf("expected label tag only, got %v", tags)
+	}
+}
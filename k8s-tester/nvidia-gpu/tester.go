@@ -0,0 +1,524 @@
+// Package nvidia_gpu installs the NVIDIA device plugin, verifies that the GPU nodes
+// advertise "nvidia.com/gpu" allocatable, and runs a CUDA vector-add Job on them.
+// Replaces "eks/cuda-vector-add" and the device plugin install of "eks/gpu".
+// ref. https://github.com/NVIDIA/k8s-device-plugin
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/gpu-ami.html
+package nvidia_gpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumGPUNodes is the minimum number of the nodes with "GPUs" allocatable
+	// required for installing this addon.
+	MinimumGPUNodes int `json:"minimum_gpu_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// InstallDevicePlugin is true to install the NVIDIA device plugin DaemonSet.
+	// Set to false when the cluster already runs the device plugin
+	// (e.g., NVIDIA GPU operator), since two plugins conflict on the kubelet socket.
+	InstallDevicePlugin bool `json:"install_device_plugin"`
+	// DevicePluginImage is the image of the NVIDIA device plugin.
+	DevicePluginImage string `json:"device_plugin_image"`
+	// Image is the CUDA vector-add image of the Job.
+	// ref. https://github.com/kubernetes/kubernetes/tree/master/test/images/cuda-vector-add-2
+	Image string `json:"image"`
+	// GPUs is the number of GPUs requested by the Job Pod.
+	GPUs int64 `json:"gpus"`
+	// Timeout is the timeout for the GPUs to be allocatable, and for the Job to complete.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// GPUNodes is the names of the nodes with "GPUs" allocatable.
+	GPUNodes []string `json:"gpu_nodes" read-only:"true"`
+	// AllocatableGPUs is the total number of "nvidia.com/gpu" allocatable of the nodes.
+	AllocatableGPUs int64 `json:"allocatable_gpus" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumGPUNodes == 0 {
+		cfg.MinimumGPUNodes = DefaultMinimumGPUNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.DevicePluginImage == "" {
+		cfg.DevicePluginImage = DefaultDevicePluginImage
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.GPUs == 0 {
+		cfg.GPUs = DefaultGPUs
+	}
+	if cfg.GPUs < 0 {
+		return fmt.Errorf("invalid GPUs %d", cfg.GPUs)
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumGPUNodes   int   = 1
+	DefaultDevicePluginImage       = "nvcr.io/nvidia/k8s-device-plugin:v0.14.1"
+	DefaultImage                   = "registry.k8s.io/e2e-test-images/cuda-vector-add:2.3"
+	DefaultGPUs              int64 = 1
+	DefaultTimeout                 = 15 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:              false,
+		Prompt:              false,
+		MinimumGPUNodes:     DefaultMinimumGPUNodes,
+		Namespace:           pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		InstallDevicePlugin: true,
+		DevicePluginImage:   DefaultDevicePluginImage,
+		Image:               DefaultImage,
+		GPUs:                DefaultGPUs,
+		Timeout:             DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	devicePluginName = "nvidia-device-plugin"
+	jobName          = "cuda-vector-add"
+	appLabel         = "app.kubernetes.io/name"
+
+	gpuResource core_v1.ResourceName = "nvidia.com/gpu"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if ts.cfg.InstallDevicePlugin {
+		if err := ts.createDevicePlugin(); err != nil {
+			return err
+		}
+	}
+	if err := ts.waitForAllocatable(); err != nil {
+		return err
+	}
+
+	if err := ts.createJob(); err != nil {
+		return err
+	}
+	if err := ts.checkJob(); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("CUDA vector-add Job completed",
+		zap.Strings("gpu-nodes", ts.cfg.GPUNodes),
+		zap.Int64("allocatable-gpus", ts.cfg.AllocatableGPUs),
+	)
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteJob(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		jobName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Job (%v)", err))
+	}
+
+	if err := client.DeleteDaemonSet(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		devicePluginName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete DaemonSet (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// ref. https://github.com/NVIDIA/k8s-device-plugin/blob/main/deployments/static/nvidia-device-plugin.yml
+func (ts *tester) createDevicePlugin() error {
+	ts.cfg.Logger.Info("creating device plugin DaemonSet", zap.String("image", ts.cfg.DevicePluginImage))
+	dirType := core_v1.HostPathDirectory
+	allowPrivilegeEscalation := false
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      devicePluginName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							appLabel: devicePluginName,
+						},
+					},
+					UpdateStrategy: apps_v1.DaemonSetUpdateStrategy{
+						Type: apps_v1.RollingUpdateDaemonSetStrategyType,
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: devicePluginName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							PriorityClassName: "system-node-critical",
+							Tolerations: []core_v1.Toleration{
+								{
+									Key:      "CriticalAddonsOnly",
+									Operator: core_v1.TolerationOpExists,
+								},
+								{
+									Key:      string(gpuResource),
+									Operator: core_v1.TolerationOpExists,
+									Effect:   core_v1.TaintEffectNoSchedule,
+								},
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            devicePluginName,
+									Image:           ts.cfg.DevicePluginImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									// keep running on the non-GPU nodes
+									Args: []string{"--fail-on-init-error=false"},
+									SecurityContext: &core_v1.SecurityContext{
+										AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+										Capabilities: &core_v1.Capabilities{
+											Drop: []core_v1.Capability{"ALL"},
+										},
+									},
+									VolumeMounts: []core_v1.VolumeMount{
+										{
+											Name:      "device-plugin",
+											MountPath: "/var/lib/kubelet/device-plugins",
+										},
+									},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+							Volumes: []core_v1.Volume{
+								{
+									Name: "device-plugin",
+									VolumeSource: core_v1.VolumeSource{
+										HostPath: &core_v1.HostPathVolumeSource{
+											Path: "/var/lib/kubelet/device-plugins",
+											Type: &dirType,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created device plugin DaemonSet")
+	return nil
+}
+
+// waitForAllocatable waits until "MinimumGPUNodes" nodes advertise
+// at least "GPUs" of "nvidia.com/gpu" allocatable.
+func (ts *tester) waitForAllocatable() error {
+	ts.cfg.Logger.Info("waiting for GPU allocatable", zap.Int("minimum-gpu-nodes", ts.cfg.MinimumGPUNodes), zap.Int64("gpus", ts.cfg.GPUs))
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list nodes; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.GPUNodes, ts.cfg.AllocatableGPUs = findGPUNodes(nodes, ts.cfg.GPUs)
+		ts.cfg.Logger.Info("checked GPU allocatable",
+			zap.Int("nodes", len(nodes)),
+			zap.Int("gpu-nodes", len(ts.cfg.GPUNodes)),
+			zap.Int64("allocatable-gpus", ts.cfg.AllocatableGPUs),
+		)
+		if len(ts.cfg.GPUNodes) >= ts.cfg.MinimumGPUNodes {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to validate minimum GPU nodes requirement %d (GPU nodes %d) within %v", ts.cfg.MinimumGPUNodes, len(ts.cfg.GPUNodes), ts.cfg.Timeout)
+}
+
+// findGPUNodes returns the sorted names of the nodes with at least "gpus" of
+// "nvidia.com/gpu" allocatable, and the total allocatable of all nodes.
+func findGPUNodes(nodes []core_v1.Node, gpus int64) (names []string, allocatable int64) {
+	for _, node := range nodes {
+		q, ok := node.Status.Allocatable[gpuResource]
+		if !ok {
+			continue
+		}
+		allocatable += q.Value()
+		if q.Value() >= gpus {
+			names = append(names, node.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, allocatable
+}
+
+func (ts *tester) createJob() error {
+	ts.cfg.Logger.Info("creating Job", zap.String("image", ts.cfg.Image), zap.Int64("gpus", ts.cfg.GPUs))
+	backoffLimit := int32(2)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		BatchV1().
+		Jobs(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&batch_v1.Job{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      jobName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: batch_v1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: jobName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyNever,
+							Tolerations: []core_v1.Toleration{
+								{
+									Key:      string(gpuResource),
+									Operator: core_v1.TolerationOpExists,
+									Effect:   core_v1.TaintEffectNoSchedule,
+								},
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            jobName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Resources: core_v1.ResourceRequirements{
+										Limits: core_v1.ResourceList{
+											gpuResource: *resource.NewQuantity(ts.cfg.GPUs, resource.DecimalSI),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Job")
+	return nil
+}
+
+// checkJob waits for the Job to complete, and checks the vector-add output
+// of the succeeded Pod.
+//
+//	[Vector addition of 50000 elements]
+//	Copy input data from the host memory to the CUDA device
+//	CUDA kernel launch with 196 blocks of 256 threads
+//	Copy output data from the CUDA device to the host memory
+//	Test PASSED
+//	Done
+func (ts *tester) checkJob() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Timeout)
+	_, pods, err := client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		30*time.Second,
+		5*time.Second,
+		ts.cfg.Namespace,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		logs, err := client.CheckPodLogs(
+			ts.cfg.Logger,
+			ts.cfg.LogWriter,
+			ts.cfg.Stopc,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			pod.Name,
+		)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\nJob Pod %q logs:\n\n%s\n\n", pod.Name, logs)
+		if !vectorAddPassed(logs) {
+			return fmt.Errorf("unexpected CUDA vector-add output from Pod %q", pod.Name)
+		}
+		return nil
+	}
+	return errors.New("no succeeded Job Pod")
+}
+
+func vectorAddPassed(logs string) bool {
+	return strings.Contains(logs, "[Vector addition") && strings.Contains(logs, "Test PASSED")
+}
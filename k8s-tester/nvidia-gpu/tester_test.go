@@ -0,0 +1,61 @@
+package nvidia_gpu
+
+import (
+	"reflect"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFindGPUNodes(t *testing.T) {
+	node := func(name string, gpus string) core_v1.Node {
+		n := core_v1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: name}}
+		if gpus != "" {
+			n.Status.Allocatable = core_v1.ResourceList{gpuResource: resource.MustParse(gpus)}
+		}
+		return n
+	}
+	nodes := []core_v1.Node{
+		node("p3-8xlarge", "4"),
+		node("m5-large", ""),
+		node("g4dn-xlarge", "1"),
+		node("g4dn-unready", "0"),
+	}
+
+	tests := []struct {
+		gpus        int64
+		names       []string
+		allocatable int64
+	}{
+		{gpus: 1, names: []string{"g4dn-xlarge", "p3-8xlarge"}, allocatable: 5},
+		{gpus: 2, names: []string{"p3-8xlarge"}, allocatable: 5},
+		{gpus: 8, names: nil, allocatable: 5},
+	}
+	for i, tt := range tests {
+		names, allocatable := findGPUNodes(nodes, tt.gpus)
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Fatalf("#%d: expected names %v, got %v", i, tt.names, names)
+		}
+		if allocatable != tt.allocatable {
+			t.Fatalf("#%d: expected allocatable %d, got %d", i, tt.allocatable, allocatable)
+		}
+	}
+}
+
+func TestVectorAddPassed(t *testing.T) {
+	passed := `[Vector addition of 50000 elements]
+Copy input data from the host memory to the CUDA device
+CUDA kernel launch with 196 blocks of 256 threads
+Copy output data from the CUDA device to the host memory
+Test PASSED
+Done
+`
+	if !vectorAddPassed(passed) {
+		t.Fatal("expected vector-add passed")
+	}
+	if vectorAddPassed("[Vector addition of 50000 elements]\nFailed to allocate device vector A (error code no CUDA-capable device is detected)!\n") {
+		t.Fatal("expected vector-add failed")
+	}
+}
@@ -0,0 +1,240 @@
+// Package opensearch_results indexes the k8s-tester run results into OpenSearch
+// (or Elasticsearch), such as the duration and success of each add-on and the key
+// tester metrics, so that the existing OpenSearch dashboards can track the trends
+// across runs.
+// ref. https://opensearch.org/docs/latest/api-reference/document-apis/bulk/
+package opensearch_results
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+
+	Logger *zap.Logger `json:"-"`
+
+	// Endpoint is the OpenSearch endpoint URL
+	// (e.g., "https://search-my-domain.us-west-2.es.amazonaws.com").
+	Endpoint string `json:"endpoint"`
+	// IndexPrefix is the prefix of the daily indices, named "[IndexPrefix]-YYYY.MM.DD",
+	// so that an ISM policy with the index pattern "[IndexPrefix]-*" can manage
+	// the retention by the index age.
+	// ref. https://opensearch.org/docs/latest/im-plugin/ism/index/
+	IndexPrefix string `json:"index_prefix"`
+
+	// SigV4 is true to sign the requests for Amazon OpenSearch Service
+	// with the AWS credentials, instead of the basic auth.
+	SigV4     bool   `json:"sigv4"`
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+	// Username is the basic auth user name.
+	// The password is read from the "OPENSEARCH_PASSWORD" environmental variable,
+	// to not persist it in the configuration file.
+	Username string `json:"username"`
+
+	// Timeout is the timeout for the bulk request.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// Index is the index of the last run.
+	Index string `json:"index" read-only:"true"`
+	// IndexedDocuments is the number of documents indexed in the last run.
+	IndexedDocuments int `json:"indexed_documents" read-only:"true"`
+}
+
+const (
+	DefaultPartition   = "aws"
+	DefaultIndexPrefix = "k8s-tester-results"
+	DefaultTimeout     = time.Minute
+
+	// PasswordEnv is the environmental variable of the basic auth password.
+	PasswordEnv = "OPENSEARCH_PASSWORD"
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:      false,
+		IndexPrefix: DefaultIndexPrefix,
+		Partition:   DefaultPartition,
+		Timeout:     DefaultTimeout,
+	}
+}
+
+func Env() string {
+	return "OPENSEARCH_RESULTS"
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.Endpoint == "" {
+		return errors.New("empty Endpoint")
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if cfg.IndexPrefix == "" {
+		cfg.IndexPrefix = DefaultIndexPrefix
+	}
+	if cfg.IndexPrefix != strings.ToLower(cfg.IndexPrefix) || strings.ContainsAny(cfg.IndexPrefix, ` "*\<|,>/?#:`) {
+		return fmt.Errorf("invalid IndexPrefix %q (must be lowercase without special characters)", cfg.IndexPrefix)
+	}
+	if cfg.SigV4 {
+		if cfg.Partition == "" {
+			cfg.Partition = DefaultPartition
+		}
+		if cfg.Region == "" {
+			return errors.New("empty Region with SigV4")
+		}
+		if cfg.Username != "" {
+			return errors.New("Username and SigV4 are mutually exclusive")
+		}
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+// Document is a results document to index.
+type Document map[string]interface{}
+
+// IndexName returns the daily index name of the time.
+func IndexName(prefix string, t time.Time) string {
+	return prefix + "-" + t.UTC().Format("2006.01.02")
+}
+
+// bulkBody returns the newline-delimited JSON body of the bulk request.
+func bulkBody(index string, docs []Document) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	action, err := json.Marshal(map[string]interface{}{"index": map[string]string{"_index": index}})
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulkErrors returns the number of failed items and the first error reason.
+func bulkErrors(b []byte) (failed int, reason string, err error) {
+	var rs bulkResponse
+	if err = json.Unmarshal(b, &rs); err != nil {
+		return 0, "", err
+	}
+	if !rs.Errors {
+		return 0, "", nil
+	}
+	for _, item := range rs.Items {
+		for _, v := range item {
+			if v.Error == nil {
+				continue
+			}
+			failed++
+			if reason == "" {
+				reason = v.Error.Type + ": " + v.Error.Reason
+			}
+		}
+	}
+	return failed, reason, nil
+}
+
+// Index indexes the documents into the daily index with the bulk API.
+func Index(cfg *Config, docs []Document) error {
+	if len(docs) == 0 {
+		cfg.Logger.Info("no document to index")
+		return nil
+	}
+
+	now := time.Now().UTC()
+	cfg.Index = IndexName(cfg.IndexPrefix, now)
+	for _, doc := range docs {
+		if _, ok := doc["@timestamp"]; !ok {
+			doc["@timestamp"] = now.Format(time.RFC3339)
+		}
+	}
+	body, err := bulkBody(cfg.Index, docs)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Endpoint+"/_bulk", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	switch {
+	case cfg.SigV4:
+		awsCfg := aws_v1.Config{
+			Logger:        cfg.Logger,
+			DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+			Partition:     cfg.Partition,
+			Region:        cfg.Region,
+		}
+		awsSession, _, _, err := aws_v1.New(&awsCfg)
+		if err != nil {
+			return fmt.Errorf("failed to create aws session (%v)", err)
+		}
+		if _, err = v4.NewSigner(awsSession.Config.Credentials).Sign(req, bytes.NewReader(body), "es", cfg.Region, time.Now()); err != nil {
+			return fmt.Errorf("failed to sign request (%v)", err)
+		}
+	case cfg.Username != "":
+		req.SetBasicAuth(cfg.Username, os.Getenv(PasswordEnv))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to index documents to %q (%v)", cfg.Endpoint, err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to index documents to %q (status %q, %s)", cfg.Endpoint, resp.Status, strings.TrimSpace(string(b)))
+	}
+	failed, reason, err := bulkErrors(b)
+	if err != nil {
+		return fmt.Errorf("failed to parse bulk response (%v)", err)
+	}
+	cfg.IndexedDocuments = len(docs) - failed
+	if failed > 0 {
+		return fmt.Errorf("failed to index %d of %d documents to %q (%s)", failed, len(docs), cfg.Index, reason)
+	}
+	cfg.Logger.Info("indexed documents", zap.String("index", cfg.Index), zap.Int("documents", len(docs)))
+	return nil
+}
@@ -0,0 +1,67 @@
+package opensearch_results
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIndexName(t *testing.T) {
+	ts := time.Date(2021, time.March, 4, 23, 0, 0, 0, time.FixedZone("PST", -8*3600))
+	if name := IndexName("k8s-tester-results", ts); name != "k8s-tester-results-2021.03.05" {
+		t.Fatalf("unexpected index name %q", name)
+	}
+}
+
+func TestBulkBody(t *testing.T) {
+	b, err := bulkBody("idx", []Document{
+		{"add_on": "stress", "value": 1.5},
+		{"add_on": "csrs"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"index":{"_index":"idx"}}
+{"add_on":"stress","value":1.5}
+{"index":{"_index":"idx"}}
+{"add_on":"csrs"}
+`
+	if string(b) != expected {
+		t.Fatalf("expected %q, got %q", expected, string(b))
+	}
+}
+
+func TestBulkErrors(t *testing.T) {
+	failed, reason, err := bulkErrors([]byte(`{"took":3,"errors":false,"items":[{"index":{"status":201}}]}`))
+	if err != nil || failed != 0 || reason != "" {
+		t.Fatalf("unexpected failed %d, reason %q, error %v", failed, reason, err)
+	}
+	failed, reason, err = bulkErrors([]byte(`{"took":3,"errors":true,"items":[
+{"index":{"status":201}},
+{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 1 || reason != "mapper_parsing_exception: failed to parse" {
+		t.Fatalf("unexpected failed %d, reason %q", failed, reason)
+	}
+}
+
+func TestValidateAndSetDefaults(t *testing.T) {
+	cfg := NewDefault()
+	cfg.Endpoint = "https://search.example.com/"
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Endpoint != "https://search.example.com" {
+		t.Fatalf("unexpected Endpoint %q", cfg.Endpoint)
+	}
+	cfg.IndexPrefix = "K8s"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error with uppercase IndexPrefix")
+	}
+	cfg.IndexPrefix = DefaultIndexPrefix
+	cfg.SigV4 = true
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error with empty Region")
+	}
+}
@@ -0,0 +1,657 @@
+// Package pdb creates a Deployment with a PodDisruptionBudget, evicts its Pods
+// with the eviction API (or drains a node), and verifies that the evictions are
+// throttled per the budget and that the evicted Pods are rescheduled,
+// in order to validate the managed node group upgrade behavior.
+// ref. https://kubernetes.io/docs/tasks/run-application/configure-pdb/
+// ref. https://kubernetes.io/docs/concepts/scheduling-eviction/api-eviction/
+package pdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	policy_v1 "k8s.io/api/policy/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	// At least two nodes are required to reschedule the Pods of a drained node.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the image of the Deployment Pods.
+	Image string `json:"image"`
+	// Replicas is the number of the Deployment replicas.
+	Replicas int32 `json:"replicas"`
+	// MaxUnavailable is the "maxUnavailable" of the PodDisruptionBudget,
+	// the number of Pods that can be evicted at a time.
+	// Must be less than "Replicas".
+	MaxUnavailable int32 `json:"max_unavailable"`
+	// DrainNode is true to cordon a node and to evict the Deployment Pods on the node,
+	// as "kubectl drain" and the managed node group upgrades do.
+	// Otherwise, all Deployment Pods are evicted.
+	DrainNode bool `json:"drain_node"`
+	// Timeout is the timeout to evict the Pods, and to reschedule the evicted Pods.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// DrainedNodeName is the node cordoned and drained, if "DrainNode" is true.
+	DrainedNodeName string `json:"drained_node_name" read-only:"true"`
+	// Evictions is the number of the evictions requested, including the throttled ones.
+	Evictions int `json:"evictions" read-only:"true"`
+	// EvictionsThrottled is the number of the evictions rejected by the PodDisruptionBudget
+	// with "429 Too Many Requests".
+	EvictionsThrottled int `json:"evictions_throttled" read-only:"true"`
+	// EvictedPods is the number of the Pods evicted.
+	EvictedPods int `json:"evicted_pods" read-only:"true"`
+	// RescheduleLatency is the time from the first eviction to all replicas available again.
+	RescheduleLatency       time.Duration `json:"reschedule_latency" read-only:"true"`
+	RescheduleLatencyString string        `json:"reschedule_latency_string" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.Replicas == 0 {
+		cfg.Replicas = DefaultReplicas
+	}
+	if cfg.MaxUnavailable == 0 {
+		cfg.MaxUnavailable = DefaultMaxUnavailable
+	}
+	if cfg.MaxUnavailable >= cfg.Replicas {
+		return fmt.Errorf("MaxUnavailable %d must be less than Replicas %d", cfg.MaxUnavailable, cfg.Replicas)
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes   int   = 2
+	DefaultImage                = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	DefaultReplicas       int32 = 4
+	DefaultMaxUnavailable int32 = 1
+	DefaultTimeout              = 10 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:         false,
+		Prompt:         false,
+		MinimumNodes:   DefaultMinimumNodes,
+		Namespace:      pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:          DefaultImage,
+		Replicas:       DefaultReplicas,
+		MaxUnavailable: DefaultMaxUnavailable,
+		Timeout:        DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	deploymentName = "pdb-app"
+	pdbName        = "pdb-app"
+	appLabel       = "app.kubernetes.io/name"
+
+	// cordonedByAnnotation marks the node cordoned by the tester with its namespace,
+	// so that "Delete" uncordons only that node, even from another process.
+	cordonedByAnnotation = "k8s-tester.aws/pdb-cordoned-by"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createDeployment(); err != nil {
+		return err
+	}
+	if err := ts.waitForDeployment(); err != nil {
+		return err
+	}
+
+	if err := ts.createPDB(); err != nil {
+		return err
+	}
+	allowed, err := ts.waitForPDB()
+	if err != nil {
+		return err
+	}
+
+	pods, err := ts.listPods()
+	if err != nil {
+		return err
+	}
+	if ts.cfg.DrainNode {
+		nodeName := pods[0].Spec.NodeName
+		if err = ts.cordon(nodeName); err != nil {
+			return err
+		}
+		ts.cfg.DrainedNodeName = nodeName
+		pods = podsOnNode(pods, nodeName)
+	}
+
+	evictStart := time.Now()
+	if err = ts.evictAll(pods, allowed); err != nil {
+		return err
+	}
+
+	if err = ts.waitForDeployment(); err != nil {
+		return err
+	}
+	ts.cfg.RescheduleLatency = time.Since(evictStart)
+	ts.cfg.RescheduleLatencyString = ts.cfg.RescheduleLatency.String()
+
+	if ts.cfg.DrainNode {
+		pods, err = ts.listPods()
+		if err != nil {
+			return err
+		}
+		if n := len(podsOnNode(pods, ts.cfg.DrainedNodeName)); n > 0 {
+			return fmt.Errorf("%d Pods rescheduled on the cordoned node %q", n, ts.cfg.DrainedNodeName)
+		}
+	}
+
+	ts.cfg.Logger.Info("evicted Pods rescheduled",
+		zap.Int("evictions", ts.cfg.Evictions),
+		zap.Int("evictions-throttled", ts.cfg.EvictionsThrottled),
+		zap.Int("evicted-pods", ts.cfg.EvictedPods),
+		zap.String("reschedule-latency", ts.cfg.RescheduleLatencyString),
+	)
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := ts.uncordon(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to uncordon node (%v)", err))
+	}
+
+	if err := ts.deletePDB(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete PodDisruptionBudget (%v)", err))
+	}
+
+	if err := client.DeleteDeployment(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		deploymentName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Deployment (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createDeployment() error {
+	ts.cfg.Logger.Info("creating Deployment", zap.Int32("replicas", ts.cfg.Replicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						appLabel: deploymentName,
+					},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &ts.cfg.Replicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							appLabel: deploymentName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: deploymentName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							// spread the replicas, so that a drained node has
+							// a part of the replicas and the rest can take them
+							Affinity: &core_v1.Affinity{
+								PodAntiAffinity: &core_v1.PodAntiAffinity{
+									PreferredDuringSchedulingIgnoredDuringExecution: []core_v1.WeightedPodAffinityTerm{
+										{
+											Weight: 100,
+											PodAffinityTerm: core_v1.PodAffinityTerm{
+												LabelSelector: &meta_v1.LabelSelector{
+													MatchLabels: map[string]string{
+														appLabel: deploymentName,
+													},
+												},
+												TopologyKey: "kubernetes.io/hostname",
+											},
+										},
+									},
+								},
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            deploymentName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Deployment")
+	return nil
+}
+
+func (ts *tester) waitForDeployment() error {
+	timeout := ts.cfg.Timeout + time.Duration(ts.cfg.Replicas)*5*time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	_, err := client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		10*time.Second,
+		ts.cfg.Namespace,
+		deploymentName,
+		ts.cfg.Replicas,
+	)
+	cancel()
+	return err
+}
+
+func (ts *tester) createPDB() error {
+	ts.cfg.Logger.Info("creating PodDisruptionBudget", zap.Int32("max-unavailable", ts.cfg.MaxUnavailable))
+	maxUnavailable := intstr.FromInt(int(ts.cfg.MaxUnavailable))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		PolicyV1().
+		PodDisruptionBudgets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&policy_v1.PodDisruptionBudget{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "policy/v1",
+					Kind:       "PodDisruptionBudget",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      pdbName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: policy_v1.PodDisruptionBudgetSpec{
+					MaxUnavailable: &maxUnavailable,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							appLabel: deploymentName,
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create PodDisruptionBudget (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created PodDisruptionBudget")
+	return nil
+}
+
+// waitForPDB waits until the disruption controller observes all replicas healthy,
+// and returns the number of the disruptions allowed.
+func (ts *tester) waitForPDB() (int32, error) {
+	ts.cfg.Logger.Info("waiting for PodDisruptionBudget")
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return 0, errors.New("wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		pdb, err := ts.cfg.Client.KubernetesClient().
+			PolicyV1().
+			PodDisruptionBudgets(ts.cfg.Namespace).
+			Get(ctx, pdbName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get PodDisruptionBudget; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("checked PodDisruptionBudget",
+			zap.Int32("current-healthy", pdb.Status.CurrentHealthy),
+			zap.Int32("desired-healthy", pdb.Status.DesiredHealthy),
+			zap.Int32("disruptions-allowed", pdb.Status.DisruptionsAllowed),
+		)
+		if pdb.Status.ObservedGeneration >= pdb.Generation &&
+			pdb.Status.CurrentHealthy == ts.cfg.Replicas &&
+			pdb.Status.DisruptionsAllowed > 0 {
+			if pdb.Status.DisruptionsAllowed > ts.cfg.MaxUnavailable {
+				return 0, fmt.Errorf("PodDisruptionBudget allows %d disruptions, expected at most %d", pdb.Status.DisruptionsAllowed, ts.cfg.MaxUnavailable)
+			}
+			return pdb.Status.DisruptionsAllowed, nil
+		}
+	}
+	return 0, fmt.Errorf("PodDisruptionBudget not ready within %v", ts.cfg.Timeout)
+}
+
+func (ts *tester) deletePDB() error {
+	ts.cfg.Logger.Info("deleting PodDisruptionBudget")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		PolicyV1().
+		PodDisruptionBudgets(ts.cfg.Namespace).
+		Delete(ctx, pdbName, meta_v1.DeleteOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+	ts.cfg.Logger.Info("deleted PodDisruptionBudget")
+	return nil
+}
+
+// listPods returns the running Deployment Pods.
+func (ts *tester) listPods() ([]core_v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{LabelSelector: appLabel + "=" + deploymentName})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Pods (%v)", err)
+	}
+	running := make([]core_v1.Pod, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == core_v1.PodRunning {
+			running = append(running, pod)
+		}
+	}
+	if len(running) == 0 {
+		return nil, errors.New("no running Pod")
+	}
+	return running, nil
+}
+
+func podsOnNode(pods []core_v1.Pod, nodeName string) []core_v1.Pod {
+	var rs []core_v1.Pod
+	for _, pod := range pods {
+		if pod.Spec.NodeName == nodeName {
+			rs = append(rs, pod)
+		}
+	}
+	return rs
+}
+
+func (ts *tester) cordon(nodeName string) error {
+	ts.cfg.Logger.Info("cordoning node", zap.String("node-name", nodeName))
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}},"spec":{"unschedulable":true}}`, cordonedByAnnotation, ts.cfg.Namespace)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Nodes().
+		Patch(ctx, nodeName, types.StrategicMergePatchType, []byte(patch), meta_v1.PatchOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to cordon node %q (%v)", nodeName, err)
+	}
+	ts.cfg.Logger.Info("cordoned node", zap.String("node-name", nodeName))
+	return nil
+}
+
+// uncordon uncordons the node cordoned by this tester, if any.
+func (ts *tester) uncordon() error {
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if node.Annotations[cordonedByAnnotation] != ts.cfg.Namespace {
+			continue
+		}
+		ts.cfg.Logger.Info("uncordoning node", zap.String("node-name", node.Name))
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:null}},"spec":{"unschedulable":null}}`, cordonedByAnnotation)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err = ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Nodes().
+			Patch(ctx, node.Name, types.StrategicMergePatchType, []byte(patch), meta_v1.PatchOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to uncordon node %q (%v)", node.Name, err)
+		}
+		ts.cfg.Logger.Info("uncordoned node", zap.String("node-name", node.Name))
+	}
+	return nil
+}
+
+// evict requests the eviction of the Pod, and returns true if the
+// PodDisruptionBudget rejected it with "429 Too Many Requests".
+func (ts *tester) evict(pod core_v1.Pod) (throttled bool, err error) {
+	ts.cfg.Evictions++
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err = ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(pod.Namespace).
+		EvictV1(ctx, &policy_v1.Eviction{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+		})
+	cancel()
+	switch {
+	case err == nil:
+		ts.cfg.EvictedPods++
+		ts.cfg.Logger.Info("evicted Pod", zap.String("pod-name", pod.Name), zap.String("node-name", pod.Spec.NodeName))
+		return false, nil
+	case k8s_errors.IsTooManyRequests(err):
+		ts.cfg.EvictionsThrottled++
+		return true, nil
+	case k8s_errors.IsNotFound(err):
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to evict Pod %q (%v)", pod.Name, err)
+}
+
+// evictAll evicts the Pods until all are evicted, as "kubectl drain" does.
+// The first round requests all evictions at once, and fails if the
+// PodDisruptionBudget lets more than "allowed" Pods go at a time.
+func (ts *tester) evictAll(pods []core_v1.Pod, allowed int32) error {
+	ts.cfg.Logger.Info("evicting Pods", zap.Int("pods", len(pods)), zap.Int32("disruptions-allowed", allowed))
+
+	remaining := make([]core_v1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		throttled, err := ts.evict(pod)
+		if err != nil {
+			return err
+		}
+		if throttled {
+			remaining = append(remaining, pod)
+		}
+	}
+	if evicted := int32(len(pods) - len(remaining)); evicted > allowed {
+		return fmt.Errorf("PodDisruptionBudget let %d Pods evicted at once, expected at most %d", evicted, allowed)
+	}
+	if int32(len(pods)) > allowed && len(remaining) == 0 {
+		return fmt.Errorf("PodDisruptionBudget did not throttle %d evictions, expected at most %d at once", len(pods), allowed)
+	}
+	ts.cfg.Logger.Info("evictions throttled", zap.Int("evicted", len(pods)-len(remaining)), zap.Int("throttled", len(remaining)))
+
+	retryStart := time.Now()
+	for len(remaining) > 0 {
+		if time.Since(retryStart) > ts.cfg.Timeout {
+			return fmt.Errorf("%d Pods not evicted within %v", len(remaining), ts.cfg.Timeout)
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("eviction aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		next := remaining[:0]
+		for _, pod := range remaining {
+			throttled, err := ts.evict(pod)
+			if err != nil {
+				return err
+			}
+			if throttled {
+				next = append(next, pod)
+			}
+		}
+		remaining = next
+		ts.cfg.Logger.Info("evicting Pods", zap.Int("remaining", len(remaining)), zap.Int("throttled", ts.cfg.EvictionsThrottled))
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/client"
 	php_apache "github.com/aws/aws-k8s-tester/k8s-tester/php-apache"
@@ -68,6 +69,13 @@ var (
 
 	deploymentNodeSelector string
 	deploymentReplicas     int32
+
+	hpaEnable                      bool
+	hpaTargetCPUUtilizationPercent int32
+	hpaMaxReplicas                 int32
+	hpaTargetReplicas              int32
+	hpaLoadGeneratorReplicas       int32
+	hpaScaleTimeout                string
 )
 
 func newApply() *cobra.Command {
@@ -84,6 +92,12 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&repositoryImageTag, "repository-image-tag", "", "image tag for tester ECR image")
 	cmd.PersistentFlags().StringVar(&deploymentNodeSelector, "deployment-node-selector", "", "map of deployment node selector, must be valid JSON format")
 	cmd.PersistentFlags().Int32Var(&deploymentReplicas, "deployment-replicas", php_apache.DefaultDeploymentReplicas, "number of deployment replicas")
+	cmd.PersistentFlags().BoolVar(&hpaEnable, "hpa-enable", false, "'true' to validate scale-up and scale-down with HorizontalPodAutoscaler and load generator")
+	cmd.PersistentFlags().Int32Var(&hpaTargetCPUUtilizationPercent, "hpa-target-cpu-utilization-percent", php_apache.DefaultHPATargetCPUUtilizationPercent, "HPA target average CPU utilization")
+	cmd.PersistentFlags().Int32Var(&hpaMaxReplicas, "hpa-max-replicas", php_apache.DefaultHPAMaxReplicas, "HPA maximum number of replicas")
+	cmd.PersistentFlags().Int32Var(&hpaTargetReplicas, "hpa-target-replicas", php_apache.DefaultHPATargetReplicas, "number of replicas to scale up to under load")
+	cmd.PersistentFlags().Int32Var(&hpaLoadGeneratorReplicas, "hpa-load-generator-replicas", php_apache.DefaultHPALoadGeneratorReplicas, "number of load generator replicas")
+	cmd.PersistentFlags().StringVar(&hpaScaleTimeout, "hpa-scale-timeout", php_apache.DefaultHPAScaleTimeout.String(), "timeout to wait for scale-up and scale-down")
 
 	return cmd
 }
@@ -100,6 +114,11 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		lg.Panic("failed to parse", zap.String("deloyment-node-selector", deploymentNodeSelector), zap.Error(err))
 	}
 
+	scaleTimeout, err := time.ParseDuration(hpaScaleTimeout)
+	if err != nil {
+		lg.Panic("failed to parse", zap.String("hpa-scale-timeout", hpaScaleTimeout), zap.Error(err))
+	}
+
 	cli, err := client.New(&client.Config{
 		Logger:             lg,
 		KubectlDownloadURL: kubectlDownloadURL,
@@ -126,6 +145,16 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		},
 		DeploymentNodeSelector: nodeSelector,
 		DeploymentReplicas:     deploymentReplicas,
+
+		HPAEnable:                      hpaEnable,
+		HPATargetCPUUtilizationPercent: hpaTargetCPUUtilizationPercent,
+		HPAMaxReplicas:                 hpaMaxReplicas,
+		HPATargetReplicas:              hpaTargetReplicas,
+		HPALoadGeneratorReplicas:       hpaLoadGeneratorReplicas,
+		HPAScaleTimeout:                scaleTimeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
 	}
 
 	ts := php_apache.New(cfg)
@@ -0,0 +1,309 @@
+package php_apache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	autoscaling_v2 "k8s.io/api/autoscaling/v2"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	serviceName = "php-apache-service"
+	hpaName     = "php-apache-hpa"
+
+	loadGeneratorName      = "php-apache-load-generator"
+	loadGeneratorImageName = "public.ecr.aws/docker/library/busybox:1.36"
+
+	// hpaScaleDownStabilizationSeconds overrides the default 5-minute
+	// scale-down stabilization window, to shorten the test.
+	hpaScaleDownStabilizationSeconds int32 = 60
+)
+
+// resources returns the container resources of the PHP Apache Deployment.
+// CPU requests are required for the HorizontalPodAutoscaler to compute utilization.
+func (ts *tester) resources() core_v1.ResourceRequirements {
+	if !ts.cfg.HPAEnable {
+		return core_v1.ResourceRequirements{}
+	}
+	return core_v1.ResourceRequirements{
+		Requests: core_v1.ResourceList{
+			core_v1.ResourceCPU: resource.MustParse("200m"),
+		},
+		Limits: core_v1.ResourceList{
+			core_v1.ResourceCPU: resource.MustParse("500m"),
+		},
+	}
+}
+
+func (ts *tester) createService() error {
+	ts.cfg.Logger.Info("creating PHP Apache Service")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Services(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Service{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serviceName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: core_v1.ServiceSpec{
+					Type: core_v1.ServiceTypeClusterIP,
+					Selector: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+					Ports: []core_v1.ServicePort{
+						{
+							Protocol:   core_v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("PHP Apache Service already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create PHP Apache Service (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created PHP Apache Service")
+	return nil
+}
+
+func (ts *tester) createHPA() error {
+	ts.cfg.Logger.Info("creating HorizontalPodAutoscaler",
+		zap.Int32("min-replicas", ts.cfg.DeploymentReplicas),
+		zap.Int32("max-replicas", ts.cfg.HPAMaxReplicas),
+		zap.Int32("target-cpu-utilization-percent", ts.cfg.HPATargetCPUUtilizationPercent),
+	)
+	stabilization := hpaScaleDownStabilizationSeconds
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AutoscalingV2().
+		HorizontalPodAutoscalers(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&autoscaling_v2.HorizontalPodAutoscaler{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "autoscaling/v2",
+					Kind:       "HorizontalPodAutoscaler",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      hpaName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: autoscaling_v2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscaling_v2.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deploymentName,
+					},
+					MinReplicas: &ts.cfg.DeploymentReplicas,
+					MaxReplicas: ts.cfg.HPAMaxReplicas,
+					Metrics: []autoscaling_v2.MetricSpec{
+						{
+							Type: autoscaling_v2.ResourceMetricSourceType,
+							Resource: &autoscaling_v2.ResourceMetricSource{
+								Name: core_v1.ResourceCPU,
+								Target: autoscaling_v2.MetricTarget{
+									Type:               autoscaling_v2.UtilizationMetricType,
+									AverageUtilization: &ts.cfg.HPATargetCPUUtilizationPercent,
+								},
+							},
+						},
+					},
+					Behavior: &autoscaling_v2.HorizontalPodAutoscalerBehavior{
+						ScaleDown: &autoscaling_v2.HPAScalingRules{
+							StabilizationWindowSeconds: &stabilization,
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("HorizontalPodAutoscaler already exists")
+			return nil
+		}
+		return fmt.Errorf("failed to create HorizontalPodAutoscaler (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created HorizontalPodAutoscaler")
+	return nil
+}
+
+func (ts *tester) deleteHPA() error {
+	ts.cfg.Logger.Info("deleting HorizontalPodAutoscaler")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		AutoscalingV2().
+		HorizontalPodAutoscalers(ts.cfg.Namespace).
+		Delete(ctx, hpaName, meta_v1.DeleteOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete HorizontalPodAutoscaler (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("deleted HorizontalPodAutoscaler")
+	return nil
+}
+
+func (ts *tester) createLoadGenerator() error {
+	ts.cfg.Logger.Info("creating load generator Deployment", zap.Int32("replicas", ts.cfg.HPALoadGeneratorReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      loadGeneratorName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": loadGeneratorName,
+					},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &ts.cfg.HPALoadGeneratorReplicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": loadGeneratorName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": loadGeneratorName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            loadGeneratorName,
+									Image:           loadGeneratorImageName,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command: []string{
+										"/bin/sh",
+										"-c",
+										fmt.Sprintf("while sleep 0.01; do wget -q -O- http://%s > /dev/null; done", serviceName),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create load generator Deployment (%v)", err)
+	}
+	ts.loadStarted = time.Now()
+
+	ts.cfg.Logger.Info("created load generator Deployment")
+	return nil
+}
+
+func (ts *tester) deleteLoadGenerator() error {
+	if err := client.DeleteDeployment(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		loadGeneratorName,
+	); err != nil {
+		return fmt.Errorf("failed to delete load generator Deployment (%v)", err)
+	}
+	ts.loadStopped = time.Now()
+	return nil
+}
+
+// waitForReplicas waits until the desired and ready replicas of the PHP Apache Deployment
+// satisfy "cond", and returns the elapsed time since "start".
+func (ts *tester) waitForReplicas(action string, start time.Time, cond func(desired int32, ready int32) bool) (time.Duration, error) {
+	ts.cfg.Logger.Info("waiting for HPA "+action, zap.Duration("timeout", ts.cfg.HPAScaleTimeout))
+	for time.Since(start) < ts.cfg.HPAScaleTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return 0, fmt.Errorf("check HPA %s aborted", action)
+		case <-time.After(15 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		dp, err := ts.cfg.Client.KubernetesClient().
+			AppsV1().
+			Deployments(ts.cfg.Namespace).
+			Get(ctx, deploymentName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Deployment; retrying", zap.Error(err))
+			continue
+		}
+
+		ts.cfg.Logger.Info("checked Deployment replicas",
+			zap.String("action", action),
+			zap.Int32("desired", *dp.Spec.Replicas),
+			zap.Int32("ready", dp.Status.ReadyReplicas),
+			zap.String("elapsed", time.Since(start).String()),
+		)
+		if cond(*dp.Spec.Replicas, dp.Status.ReadyReplicas) {
+			return time.Since(start), nil
+		}
+	}
+	return 0, errors.New("timed out")
+}
+
+func (ts *tester) checkScaleUp() (err error) {
+	ts.cfg.HPAScaleUpLatency, err = ts.waitForReplicas("scale-up", ts.loadStarted, func(_ int32, ready int32) bool {
+		return ready >= ts.cfg.HPATargetReplicas
+	})
+	if err != nil {
+		return fmt.Errorf("Deployment %q did not scale up to %d replicas within %v (%v)", deploymentName, ts.cfg.HPATargetReplicas, ts.cfg.HPAScaleTimeout, err)
+	}
+	ts.cfg.HPAScaleUpLatencyString = ts.cfg.HPAScaleUpLatency.String()
+
+	ts.cfg.Logger.Info("HPA scaled up", zap.String("latency", ts.cfg.HPAScaleUpLatencyString))
+	return nil
+}
+
+func (ts *tester) checkScaleDown() (err error) {
+	ts.cfg.HPAScaleDownLatency, err = ts.waitForReplicas("scale-down", ts.loadStopped, func(desired int32, _ int32) bool {
+		return desired <= ts.cfg.DeploymentReplicas
+	})
+	if err != nil {
+		return fmt.Errorf("Deployment %q did not scale down to %d replicas within %v (%v)", deploymentName, ts.cfg.DeploymentReplicas, ts.cfg.HPAScaleTimeout, err)
+	}
+	ts.cfg.HPAScaleDownLatencyString = ts.cfg.HPAScaleDownLatency.String()
+
+	ts.cfg.Logger.Info("HPA scaled down", zap.String("latency", ts.cfg.HPAScaleDownLatencyString))
+	return nil
+}
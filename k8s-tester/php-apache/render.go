@@ -0,0 +1,49 @@
+package php_apache
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// NewRenderer returns the tester to render the manifests, without the AWS clients.
+func NewRenderer(cfg *Config) k8s_tester.Tester {
+	return &tester{cfg: cfg}
+}
+
+// Render writes the Deployment, and the Service, the HorizontalPodAutoscaler
+// and the load generator with "HPAEnable", against a fake client.
+// The image is not described via ECR.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	img := appImageName
+	if !cfg.Repository.IsEmpty() {
+		img = cfg.Repository.ImageURI()
+	}
+	if err := rt.createDeployment(img); err != nil {
+		return err
+	}
+	if cfg.HPAEnable {
+		if err := rt.createService(); err != nil {
+			return err
+		}
+		if err := rt.createHPA(); err != nil {
+			return err
+		}
+		if err := rt.createLoadGenerator(); err != nil {
+			return err
+		}
+	}
+	return fake.Dump(w, cli)
+}
@@ -1,5 +1,9 @@
 // Package php_apache installs a simple PHP Apache application.
 // Replace https://github.com/aws/aws-k8s-tester/tree/v1.5.9/eks/php-apache.
+//
+// Optionally, it creates a HorizontalPodAutoscaler and a load generator
+// to validate CPU-based scale-up and scale-down, which requires metrics-server.
+// ref. https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale-walkthrough
 package php_apache
 
 import (
@@ -7,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -54,6 +59,29 @@ type Config struct {
 	DeploymentNodeSelector map[string]string `json:"deployment_node_selector"`
 	// DeploymentReplicas is the number of replicas to deploy using "Deployment" object.
 	DeploymentReplicas int32 `json:"deployment_replicas"`
+
+	// HPAEnable is true to create a HorizontalPodAutoscaler and a load generator,
+	// and validate the scale-up and scale-down of the Deployment.
+	HPAEnable bool `json:"hpa_enable"`
+	// HPATargetCPUUtilizationPercent is the target average CPU utilization of the HorizontalPodAutoscaler.
+	HPATargetCPUUtilizationPercent int32 `json:"hpa_target_cpu_utilization_percent"`
+	// HPAMaxReplicas is the maximum number of replicas of the HorizontalPodAutoscaler.
+	// The minimum number of replicas is "DeploymentReplicas".
+	HPAMaxReplicas int32 `json:"hpa_max_replicas"`
+	// HPATargetReplicas is the number of replicas the Deployment must scale up to under load.
+	HPATargetReplicas int32 `json:"hpa_target_replicas"`
+	// HPALoadGeneratorReplicas is the number of load generator replicas.
+	HPALoadGeneratorReplicas int32 `json:"hpa_load_generator_replicas"`
+	// HPAScaleTimeout is the timeout to wait for the scale-up and the scale-down, respectively.
+	HPAScaleTimeout       time.Duration `json:"hpa_scale_timeout"`
+	HPAScaleTimeoutString string        `json:"hpa_scale_timeout_string" read-only:"true"`
+
+	// HPAScaleUpLatency is the time taken to scale up to "HPATargetReplicas" after the load started.
+	HPAScaleUpLatency       time.Duration `json:"hpa_scale_up_latency" read-only:"true"`
+	HPAScaleUpLatencyString string        `json:"hpa_scale_up_latency_string" read-only:"true"`
+	// HPAScaleDownLatency is the time taken to scale back down to "DeploymentReplicas" after the load stopped.
+	HPAScaleDownLatency       time.Duration `json:"hpa_scale_down_latency" read-only:"true"`
+	HPAScaleDownLatencyString string        `json:"hpa_scale_down_latency_string" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
@@ -61,12 +89,47 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		return errors.New("empty Namespace")
 	}
 
+	if cfg.HPAEnable {
+		if cfg.DeploymentReplicas == 0 {
+			cfg.DeploymentReplicas = DefaultDeploymentReplicas
+		}
+		if cfg.HPATargetCPUUtilizationPercent == 0 {
+			cfg.HPATargetCPUUtilizationPercent = DefaultHPATargetCPUUtilizationPercent
+		}
+		if cfg.HPAMaxReplicas == 0 {
+			cfg.HPAMaxReplicas = DefaultHPAMaxReplicas
+		}
+		if cfg.HPATargetReplicas == 0 {
+			cfg.HPATargetReplicas = DefaultHPATargetReplicas
+		}
+		if cfg.HPALoadGeneratorReplicas == 0 {
+			cfg.HPALoadGeneratorReplicas = DefaultHPALoadGeneratorReplicas
+		}
+		if cfg.HPAScaleTimeout == time.Duration(0) {
+			cfg.HPAScaleTimeout = DefaultHPAScaleTimeout
+		}
+		cfg.HPAScaleTimeoutString = cfg.HPAScaleTimeout.String()
+
+		if cfg.HPAMaxReplicas < cfg.DeploymentReplicas {
+			return fmt.Errorf("HPAMaxReplicas %d < DeploymentReplicas %d", cfg.HPAMaxReplicas, cfg.DeploymentReplicas)
+		}
+		if cfg.HPATargetReplicas <= cfg.DeploymentReplicas || cfg.HPATargetReplicas > cfg.HPAMaxReplicas {
+			return fmt.Errorf("HPATargetReplicas %d must be > DeploymentReplicas %d and <= HPAMaxReplicas %d", cfg.HPATargetReplicas, cfg.DeploymentReplicas, cfg.HPAMaxReplicas)
+		}
+	}
+
 	return nil
 }
 
 const (
 	DefaultMinimumNodes       int   = 1
 	DefaultDeploymentReplicas int32 = 3
+
+	DefaultHPATargetCPUUtilizationPercent int32 = 50
+	DefaultHPAMaxReplicas                 int32 = 10
+	DefaultHPATargetReplicas              int32 = 5
+	DefaultHPALoadGeneratorReplicas       int32 = 2
+	DefaultHPAScaleTimeout                      = 15 * time.Minute
 )
 
 func NewDefault() *Config {
@@ -77,10 +140,25 @@ func NewDefault() *Config {
 		Namespace:          pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
 		Repository:         &aws_v1_ecr.Repository{},
 		DeploymentReplicas: DefaultDeploymentReplicas,
+
+		HPAEnable:                      false,
+		HPATargetCPUUtilizationPercent: DefaultHPATargetCPUUtilizationPercent,
+		HPAMaxReplicas:                 DefaultHPAMaxReplicas,
+		HPATargetReplicas:              DefaultHPATargetReplicas,
+		HPALoadGeneratorReplicas:       DefaultHPALoadGeneratorReplicas,
+		HPAScaleTimeout:                DefaultHPAScaleTimeout,
 	}
 }
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
 	ts := &tester{
 		cfg: cfg,
 	}
@@ -93,16 +171,44 @@ func New(cfg *Config) k8s_tester.Tester {
 		}
 		awsSession, _, _, err := aws_v1.New(&awsCfg)
 		if err != nil {
-			cfg.Logger.Panic("failed to create aws session", zap.Error(err))
+			return nil, fmt.Errorf("failed to create aws session (%v)", err)
 		}
 		ts.ecrAPI = ecr.New(awsSession, aws.NewConfig().WithRegion(cfg.Repository.Region))
 	}
-	return ts
+	return ts, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 type tester struct {
 	cfg    *Config
 	ecrAPI ecriface.ECRAPI
+
+	// loadStarted and loadStopped are the times when the load generator
+	// was created and deleted, to measure the HPA scaling latency.
+	loadStarted time.Time
+	loadStopped time.Time
 }
 
 var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
@@ -147,6 +253,27 @@ func (ts *tester) Apply() (err error) {
 		return err
 	}
 
+	if ts.cfg.HPAEnable {
+		if err := ts.createService(); err != nil {
+			return err
+		}
+		if err := ts.createHPA(); err != nil {
+			return err
+		}
+		if err := ts.createLoadGenerator(); err != nil {
+			return err
+		}
+		if err := ts.checkScaleUp(); err != nil {
+			return err
+		}
+		if err := ts.deleteLoadGenerator(); err != nil {
+			return err
+		}
+		if err := ts.checkScaleDown(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -157,6 +284,14 @@ func (ts *tester) Delete() (err error) {
 
 	var errs []string
 
+	// no-op if "HPAEnable" was not set
+	if err := ts.deleteLoadGenerator(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteHPA(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
 	ts.cfg.Logger.Info("deleting deployment", zap.String("deployment-name", deploymentName))
 	if err := client.DeleteDeployment(
 		ts.cfg.Logger,
@@ -199,7 +334,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -272,6 +408,13 @@ func (ts *tester) createDeployment(containerImg string) error {
 									Name:            appName,
 									Image:           containerImg,
 									ImagePullPolicy: core_v1.PullAlways,
+									Ports: []core_v1.ContainerPort{
+										{
+											Protocol:      core_v1.ProtocolTCP,
+											ContainerPort: 80,
+										},
+									},
+									Resources: ts.resources(),
 								},
 							},
 							NodeSelector: nodeSelector,
@@ -0,0 +1,182 @@
+package k8s_tester
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/manifoldco/promptui"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+)
+
+// planItem is the tester in the prompt plan.
+type planItem struct {
+	tester k8s_tester.Tester
+	// key is the "TesterStatus" key of the tester.
+	key string
+	// namespace is the namespace the tester creates, if any.
+	namespace string
+	// awsWrites is the AWS IAM actions of the tester that create,
+	// update, or delete the AWS resources.
+	awsWrites []string
+	// awsReads is the number of the read-only AWS IAM actions of the tester.
+	awsReads int
+	skip     bool
+}
+
+// buildPlan returns the plan of the enabled testers, in the order to run.
+func (ts *tester) buildPlan() []*planItem {
+	namespaces := make(map[string]string)
+	fields := addOnFields()
+	vv := reflect.ValueOf(ts.cfg).Elem()
+	for key, idx := range fields {
+		fv := vv.Field(idx)
+		if fv.IsNil() {
+			continue
+		}
+		if nv := fv.Elem().FieldByName("Namespace"); nv.IsValid() && nv.Kind() == reflect.String {
+			namespaces[key] = nv.String()
+		}
+	}
+	actions := ts.cfg.RequiredIAMActions()
+
+	items := make([]*planItem, 0, len(ts.testers))
+	for _, cur := range ts.testers {
+		if !cur.Enabled() {
+			continue
+		}
+		it := &planItem{tester: cur, key: ts.statusKey(cur), namespace: namespaces[ts.statusKey(cur)]}
+		for _, action := range actions[cur.Name()] {
+			if isReadOnlyAction(action) {
+				it.awsReads++
+			} else {
+				it.awsWrites = append(it.awsWrites, action)
+			}
+		}
+		sort.Strings(it.awsWrites)
+		items = append(items, it)
+	}
+	return items
+}
+
+// isReadOnlyAction returns true if the AWS IAM action (e.g., "ec2:DescribeInstances") does not modify resources.
+func isReadOnlyAction(action string) bool {
+	verb := action[strings.Index(action, ":")+1:]
+	for _, pfx := range []string{"Describe", "Get", "List"} {
+		if strings.HasPrefix(verb, pfx) {
+			return true
+		}
+	}
+	return false
+}
+
+// planTable returns the table of the plan.
+func planTable(items []*planItem) string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"#", "Tester", "Run", "Namespace", "AWS Writes", "AWS Reads"})
+	for i, it := range items {
+		run := "yes"
+		if it.skip {
+			run = "skip"
+		}
+		tb.Append([]string{fmt.Sprint(i + 1), it.key, run, it.namespace, strings.Join(it.awsWrites, ", "), fmt.Sprint(it.awsReads)})
+	}
+	tb.Render()
+	return buf.String()
+}
+
+// label returns the prompt label of the plan item.
+func (it *planItem) label() string {
+	mark := "[x]"
+	if it.skip {
+		mark = "[ ]"
+	}
+	s := fmt.Sprintf("%s %s", mark, it.key)
+	if it.namespace != "" {
+		s += fmt.Sprintf(" (namespace %q)", it.namespace)
+	}
+	if len(it.awsWrites) > 0 {
+		s += fmt.Sprintf(" ⚠ AWS %s", strings.Join(it.awsWrites, ", "))
+	}
+	return s
+}
+
+// runPrompt shows the plan of the enabled testers, and lets the operator
+// accept or skip each tester before the "action". The skipped testers are
+// recorded in "skipped". Returns false if cancelled.
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if !ts.cfg.Prompt {
+		return true
+	}
+	items := ts.buildPlan()
+	if len(items) == 0 {
+		return true
+	}
+
+	cursor := 0
+	for {
+		fmt.Fprintf(ts.logWriter, "\nPlan to %q (%q):\n%s\n", action, ts.cfg.ConfigPath, planTable(items))
+
+		accepted := 0
+		for _, it := range items {
+			if !it.skip {
+				accepted++
+			}
+		}
+		labels := []string{
+			fmt.Sprintf("Yes, let's %q %d of %d tester(s)!", action, accepted, len(items)),
+			"No, cancel it!",
+		}
+		for _, it := range items {
+			labels = append(labels, it.label())
+		}
+		prompt := promptui.Select{
+			Label:     fmt.Sprintf("Ready to %q resources, select a tester to accept or skip it, should we continue?", action),
+			Items:     labels,
+			Size:      len(labels),
+			CursorPos: cursor,
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		switch idx {
+		case 0:
+			if accepted == 0 {
+				fmt.Fprintf(ts.logWriter, "all testers skipped; accept a tester or cancel %q\n", action)
+				cursor = 0
+				continue
+			}
+			ts.skipped = make(map[k8s_tester.Tester]struct{})
+			for _, it := range items {
+				if it.skip {
+					ts.skipped[it.tester] = struct{}{}
+					ts.logger.Info("skipping tester by prompt", zap.String("tester", it.key), zap.String("action", action))
+				}
+			}
+			return true
+		case 1:
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		default:
+			it := items[idx-2]
+			it.skip = !it.skip
+			cursor = idx
+		}
+	}
+}
+
+// isSkipped returns true if the tester is skipped by the prompt.
+func (ts *tester) isSkipped(cur k8s_tester.Tester) bool {
+	_, ok := ts.skipped[cur]
+	return ok
+}
@@ -0,0 +1,61 @@
+package k8s_tester
+
+import (
+	"strings"
+	"testing"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+)
+
+func TestBuildPlan(t *testing.T) {
+	cfg := NewDefault()
+	cfg.AddOnConfigmaps.Namespace = "configmaps-ns"
+	ts := &tester{cfg: cfg, statusKeys: make(map[k8s_tester.Tester]string)}
+	for _, name := range []string{"configmaps", "csrs"} {
+		cur := &fakeTester{}
+		ts.testers = append(ts.testers, cur)
+		ts.statusKeys[cur] = name
+	}
+
+	items := ts.buildPlan()
+	if len(items) != 2 {
+		t.Fatalf("expected 2 plan items, got %d", len(items))
+	}
+	if items[0].key != "configmaps" || items[0].namespace != "configmaps-ns" {
+		t.Fatalf("unexpected plan item %+v", items[0])
+	}
+	if items[1].key != "csrs" || items[1].namespace != "" {
+		t.Fatalf("unexpected plan item %+v", items[1])
+	}
+
+	items[1].skip = true
+	items[1].awsWrites = []string{"ec2:CreateTags"}
+	if label := items[1].label(); !strings.HasPrefix(label, "[ ] csrs") || !strings.Contains(label, "ec2:CreateTags") {
+		t.Fatalf("unexpected label %q", label)
+	}
+	if label := items[0].label(); label != `[x] configmaps (namespace "configmaps-ns")` {
+		t.Fatalf("unexpected label %q", label)
+	}
+	if tb := planTable(items); !strings.Contains(tb, "skip") || !strings.Contains(tb, "configmaps-ns") {
+		t.Fatalf("unexpected plan table\n%s", tb)
+	}
+
+	ts.skipped = map[k8s_tester.Tester]struct{}{items[1].tester: {}}
+	if ts.isSkipped(items[0].tester) || !ts.isSkipped(items[1].tester) {
+		t.Fatal("unexpected skipped testers")
+	}
+}
+
+func TestIsReadOnlyAction(t *testing.T) {
+	for action, expected := range map[string]bool{
+		"ec2:DescribeInstances":     true,
+		"s3:GetObject":              true,
+		"eks:ListNodegroups":        true,
+		"ec2:TerminateInstances":    false,
+		"eks:UpdateNodegroupConfig": false,
+	} {
+		if ro := isReadOnlyAction(action); ro != expected {
+			t.Errorf("isReadOnlyAction(%q) expected %v, got %v", action, expected, ro)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// k8s-tester-port-exhaustion installs Kubernetes HostPort and NodePort exhaustion tester.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	port_exhaustion "github.com/aws/aws-k8s-tester/k8s-tester/port-exhaustion"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-port-exhaustion",
+	Short:      "Kubernetes HostPort and NodePort exhaustion tester",
+	SuggestFor: []string{"port-exhaustion"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", port_exhaustion.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-port-exhaustion failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	nodePortExhaustion bool
+	nodePortRange      string
+	hostPortExhaustion bool
+	hostPort           int32
+	image              string
+	podTimeout         time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&nodePortExhaustion, "node-port-exhaustion", port_exhaustion.DefaultNodePortExhaustion, "'true' to allocate the NodePorts up to the range limit")
+	cmd.PersistentFlags().StringVar(&nodePortRange, "node-port-range", port_exhaustion.DefaultNodePortRange, "kube-apiserver --service-node-port-range")
+	cmd.PersistentFlags().BoolVar(&hostPortExhaustion, "host-port-exhaustion", port_exhaustion.DefaultHostPortExhaustion, "'true' to allocate the host port on every schedulable node")
+	cmd.PersistentFlags().Int32Var(&hostPort, "host-port", port_exhaustion.DefaultHostPort, "host port to allocate across the nodes")
+	cmd.PersistentFlags().StringVar(&image, "image", port_exhaustion.DefaultImage, "host port Pod image")
+	cmd.PersistentFlags().DurationVar(&podTimeout, "pod-timeout", port_exhaustion.DefaultPodTimeout, "timeout for the host port Pods to be running or unschedulable")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &port_exhaustion.Config{
+		Prompt:             prompt,
+		Logger:             lg,
+		LogWriter:          logWriter,
+		MinimumNodes:       minimumNodes,
+		Namespace:          namespace,
+		Client:             cli,
+		NodePortExhaustion: nodePortExhaustion,
+		NodePortRange:      nodePortRange,
+		HostPortExhaustion: hostPortExhaustion,
+		HostPort:           hostPort,
+		Image:              image,
+		PodTimeout:         podTimeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := port_exhaustion.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-port-exhaustion apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &port_exhaustion.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := port_exhaustion.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-port-exhaustion delete' success\n")
+}
@@ -0,0 +1,129 @@
+package port_exhaustion
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	core_v1 "k8s.io/api/core/v1"
+)
+
+// parseNodePortRange parses the kube-apiserver "--service-node-port-range" (e.g., "30000-32767").
+func parseNodePortRange(s string) (min int32, max int32, err error) {
+	ss := strings.Split(strings.TrimSpace(s), "-")
+	if len(ss) != 2 {
+		return 0, 0, fmt.Errorf("invalid node port range %q (expected 'min-max')", s)
+	}
+	lo, err := strconv.ParseInt(strings.TrimSpace(ss[0]), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid node port range %q (%v)", s, err)
+	}
+	hi, err := strconv.ParseInt(strings.TrimSpace(ss[1]), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid node port range %q (%v)", s, err)
+	}
+	if lo <= 0 || hi > 65535 || lo > hi {
+		return 0, 0, fmt.Errorf("invalid node port range %q", s)
+	}
+	return int32(lo), int32(hi), nil
+}
+
+// nodePortsInUse returns the sorted node ports within the range allocated by the Services.
+func nodePortsInUse(svcs []core_v1.Service, min int32, max int32) (ports []int32) {
+	seen := make(map[int32]struct{})
+	for _, svc := range svcs {
+		for _, p := range svc.Spec.Ports {
+			if p.NodePort < min || p.NodePort > max {
+				continue
+			}
+			if _, ok := seen[p.NodePort]; ok {
+				continue
+			}
+			seen[p.NodePort] = struct{}{}
+			ports = append(ports, p.NodePort)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports
+}
+
+// leakedNodePorts returns the ports in use after the cleanup that were not in use before.
+func leakedNodePorts(before []int32, after []int32) (leaked []int32) {
+	prev := make(map[int32]struct{}, len(before))
+	for _, p := range before {
+		prev[p] = struct{}{}
+	}
+	for _, p := range after {
+		if _, ok := prev[p]; !ok {
+			leaked = append(leaked, p)
+		}
+	}
+	return leaked
+}
+
+// isRangeFull returns true if the Service creation failed
+// because no node port is left in the range.
+// e.g., "Internal error occurred: failed to allocate a nodePort: range is full"
+func isRangeFull(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "range is full")
+}
+
+// isPortAllocated returns true if the Service creation failed
+// because the requested node port is already allocated.
+// e.g., "spec.ports[0].nodePort: Invalid value: 30000: provided port is already allocated"
+func isPortAllocated(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "provided port is already allocated")
+}
+
+const fargateComputeTypeLabel = "eks.amazonaws.com/compute-type"
+
+// schedulableNodes returns the names of the ready nodes that accept
+// the Pods without tolerations, excluding Fargate nodes that do not support "hostPort".
+func schedulableNodes(nodes []core_v1.Node) (names []string) {
+	for _, node := range nodes {
+		if node.Spec.Unschedulable || node.Labels[fargateComputeTypeLabel] == "fargate" {
+			continue
+		}
+		tainted := false
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect == core_v1.TaintEffectNoSchedule || taint.Effect == core_v1.TaintEffectNoExecute {
+				tainted = true
+				break
+			}
+		}
+		if tainted {
+			continue
+		}
+		ready := false
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == core_v1.NodeReady && cond.Status == core_v1.ConditionTrue {
+				ready = true
+				break
+			}
+		}
+		if ready {
+			names = append(names, node.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hostPortUnschedulable returns true if the Pod is pending
+// because no node has the requested host port free.
+// e.g., "0/3 nodes are available: 3 node(s) didn't have free ports for the requested pod ports."
+func hostPortUnschedulable(pod core_v1.Pod) bool {
+	if pod.Status.Phase != core_v1.PodPending || pod.Spec.NodeName != "" {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == core_v1.PodScheduled &&
+			cond.Status == core_v1.ConditionFalse &&
+			cond.Reason == core_v1.PodReasonUnschedulable &&
+			strings.Contains(cond.Message, "free ports") {
+			return true
+		}
+	}
+	return false
+}
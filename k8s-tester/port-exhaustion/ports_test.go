@@ -0,0 +1,109 @@
+package port_exhaustion
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseNodePortRange(t *testing.T) {
+	tests := []struct {
+		s        string
+		min, max int32
+		err      bool
+	}{
+		{"30000-32767", 30000, 32767, false},
+		{" 30000 - 30010 ", 30000, 30010, false},
+		{"30000", 0, 0, true},
+		{"32767-30000", 0, 0, true},
+		{"30000-70000", 0, 0, true},
+		{"a-b", 0, 0, true},
+	}
+	for i, tt := range tests {
+		min, max, err := parseNodePortRange(tt.s)
+		if (err != nil) != tt.err {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if min != tt.min || max != tt.max {
+			t.Fatalf("#%d: expected %d-%d, got %d-%d", i, tt.min, tt.max, min, max)
+		}
+	}
+}
+
+func TestNodePortsInUse(t *testing.T) {
+	svcs := []core_v1.Service{
+		{Spec: core_v1.ServiceSpec{Ports: []core_v1.ServicePort{{NodePort: 30005}, {NodePort: 30001}}}},
+		{Spec: core_v1.ServiceSpec{Ports: []core_v1.ServicePort{{Port: 80}}}},
+		{Spec: core_v1.ServiceSpec{Ports: []core_v1.ServicePort{{NodePort: 30001}, {NodePort: 40000}}}},
+	}
+	ports := nodePortsInUse(svcs, 30000, 32767)
+	if !reflect.DeepEqual(ports, []int32{30001, 30005}) {
+		t.Fatalf("unexpected ports %v", ports)
+	}
+
+	leaked := leakedNodePorts(ports, []int32{30001, 30002, 30005, 30100})
+	if !reflect.DeepEqual(leaked, []int32{30002, 30100}) {
+		t.Fatalf("unexpected leaked ports %v", leaked)
+	}
+	if leaked = leakedNodePorts(ports, ports); len(leaked) != 0 {
+		t.Fatalf("unexpected leaked ports %v", leaked)
+	}
+}
+
+func TestAllocationErrors(t *testing.T) {
+	full := errors.New("Internal error occurred: failed to allocate a nodePort: range is full")
+	allocated := errors.New(`Service "a" is invalid: spec.ports[0].nodePort: Invalid value: 30000: provided port is already allocated`)
+	if !isRangeFull(full) || isRangeFull(allocated) || isRangeFull(nil) {
+		t.Fatal("unexpected isRangeFull")
+	}
+	if !isPortAllocated(allocated) || isPortAllocated(full) || isPortAllocated(nil) {
+		t.Fatal("unexpected isPortAllocated")
+	}
+}
+
+func TestSchedulableNodes(t *testing.T) {
+	ready := core_v1.NodeStatus{Conditions: []core_v1.NodeCondition{{Type: core_v1.NodeReady, Status: core_v1.ConditionTrue}}}
+	nodes := []core_v1.Node{
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "b"}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "a"}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "cordoned"}, Spec: core_v1.NodeSpec{Unschedulable: true}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "tainted"}, Spec: core_v1.NodeSpec{Taints: []core_v1.Taint{{Key: "gpu", Effect: core_v1.TaintEffectNoSchedule}}}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "prefer"}, Spec: core_v1.NodeSpec{Taints: []core_v1.Taint{{Key: "x", Effect: core_v1.TaintEffectPreferNoSchedule}}}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "fargate", Labels: map[string]string{fargateComputeTypeLabel: "fargate"}}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "not-ready"}},
+	}
+	names := schedulableNodes(nodes)
+	if !reflect.DeepEqual(names, []string{"a", "b", "prefer"}) {
+		t.Fatalf("unexpected nodes %v", names)
+	}
+}
+
+func TestHostPortUnschedulable(t *testing.T) {
+	pending := func(reason, msg string) core_v1.Pod {
+		return core_v1.Pod{Status: core_v1.PodStatus{
+			Phase: core_v1.PodPending,
+			Conditions: []core_v1.PodCondition{{
+				Type:    core_v1.PodScheduled,
+				Status:  core_v1.ConditionFalse,
+				Reason:  reason,
+				Message: msg,
+			}},
+		}}
+	}
+	tests := []struct {
+		pod      core_v1.Pod
+		expected bool
+	}{
+		{pending(core_v1.PodReasonUnschedulable, "0/3 nodes are available: 3 node(s) didn't have free ports for the requested pod ports."), true},
+		{pending(core_v1.PodReasonUnschedulable, "0/3 nodes are available: 3 Insufficient cpu."), false},
+		{core_v1.Pod{Status: core_v1.PodStatus{Phase: core_v1.PodRunning}}, false},
+	}
+	for i, tt := range tests {
+		if v := hostPortUnschedulable(tt.pod); v != tt.expected {
+			t.Fatalf("#%d: expected %v, got %v", i, tt.expected, v)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+// Package port_exhaustion allocates NodePorts up to the range limit and hostPorts
+// across all schedulable nodes, validates the failure behavior at exhaustion,
+// and validates that the ports are released after the deletion.
+// ref. https://kubernetes.io/docs/concepts/services-networking/service/#type-nodeport
+package port_exhaustion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// NodePortExhaustion is true to allocate the NodePorts up to the range limit.
+	// Other Services cannot allocate a NodePort while the range is full.
+	NodePortExhaustion bool `json:"node_port_exhaustion"`
+	// NodePortRange is the kube-apiserver "--service-node-port-range".
+	NodePortRange string `json:"node_port_range"`
+
+	// HostPortExhaustion is true to allocate the host port on every schedulable node.
+	HostPortExhaustion bool `json:"host_port_exhaustion"`
+	// HostPort is the host port to allocate across the nodes.
+	HostPort int32 `json:"host_port"`
+	// Image is the host port Pod image.
+	Image string `json:"image"`
+	// PodTimeout is the timeout for the host port Pods to be running or unschedulable.
+	PodTimeout       time.Duration `json:"pod_timeout"`
+	PodTimeoutString string        `json:"pod_timeout_string" read-only:"true"`
+
+	// Result is the port exhaustion test result.
+	Result Result `json:"result" read-only:"true"`
+}
+
+// Result is the port exhaustion test result.
+type Result struct {
+	// NodePortRangeSize is the number of ports in the node port range.
+	NodePortRangeSize int `json:"node_port_range_size"`
+	// NodePortsInUse is the number of node ports allocated before the test.
+	NodePortsInUse int `json:"node_ports_in_use"`
+	// NodePortsAllocated is the number of node ports allocated by the test until the range is full.
+	NodePortsAllocated int `json:"node_ports_allocated"`
+	// NodePortsLeaked is the node ports still allocated after deleting the test Services.
+	NodePortsLeaked []int32 `json:"node_ports_leaked"`
+
+	// HostPortNodes is the number of schedulable nodes that allocated the host port.
+	HostPortNodes int `json:"host_port_nodes"`
+	// HostPortRescheduled is true if the unschedulable Pod is scheduled
+	// after the host port is released on a node.
+	HostPortRescheduled bool `json:"host_port_rescheduled"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if !cfg.NodePortExhaustion && !cfg.HostPortExhaustion {
+		return errors.New("both NodePortExhaustion and HostPortExhaustion are disabled")
+	}
+	if cfg.NodePortRange == "" {
+		cfg.NodePortRange = DefaultNodePortRange
+	}
+	if _, _, err := parseNodePortRange(cfg.NodePortRange); err != nil {
+		return err
+	}
+	if cfg.HostPort == 0 {
+		cfg.HostPort = DefaultHostPort
+	}
+	if cfg.HostPort < 0 || cfg.HostPort > 65535 {
+		return fmt.Errorf("invalid HostPort %d", cfg.HostPort)
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.PodTimeout == time.Duration(0) {
+		cfg.PodTimeout = DefaultPodTimeout
+	}
+	cfg.PodTimeoutString = cfg.PodTimeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes       int = 1
+	DefaultNodePortExhaustion     = true
+	// DefaultNodePortRange is the kube-apiserver default "--service-node-port-range".
+	DefaultNodePortRange            = "30000-32767"
+	DefaultHostPortExhaustion       = true
+	DefaultHostPort           int32 = 19999
+	DefaultImage                    = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	DefaultPodTimeout               = 5 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:             false,
+		Prompt:             false,
+		MinimumNodes:       DefaultMinimumNodes,
+		Namespace:          pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		NodePortExhaustion: DefaultNodePortExhaustion,
+		NodePortRange:      DefaultNodePortRange,
+		HostPortExhaustion: DefaultHostPortExhaustion,
+		HostPort:           DefaultHostPort,
+		Image:              DefaultImage,
+		PodTimeout:         DefaultPodTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	nodePortPrefix = "node-port"
+	hostPortPrefix = "host-port"
+	appLabel       = "app.kubernetes.io/name"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if ts.cfg.NodePortExhaustion {
+		if err := ts.checkNodePorts(); err != nil {
+			return err
+		}
+	}
+	if ts.cfg.HostPortExhaustion {
+		if err := ts.checkHostPorts(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// listNodePortsInUse returns the node ports within the range allocated by all Services in the cluster.
+func (ts *tester) listNodePortsInUse(min int32, max int32) ([]int32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	svcs, err := ts.cfg.Client.KubernetesClient().CoreV1().Services(meta_v1.NamespaceAll).List(ctx, meta_v1.ListOptions{})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Services (%v)", err)
+	}
+	return nodePortsInUse(svcs.Items, min, max), nil
+}
+
+// createNodePortService creates the selector-less NodePort Service,
+// with the node port allocated by the API server if zero.
+func (ts *tester) createNodePortService(name string, nodePort int32) (*core_v1.Service, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	svc, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Services(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Service{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+					Labels:    map[string]string{appLabel: nodePortPrefix},
+				},
+				Spec: core_v1.ServiceSpec{
+					Type: core_v1.ServiceTypeNodePort,
+					Ports: []core_v1.ServicePort{
+						{
+							Protocol:   core_v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+							NodePort:   nodePort,
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	return svc, err
+}
+
+func (ts *tester) deleteNodePortServices(names []string) error {
+	foreground := meta_v1.DeletePropagationForeground
+	for _, name := range names {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Services(ts.cfg.Namespace).
+			Delete(
+				ctx,
+				name,
+				meta_v1.DeleteOptions{
+					GracePeriodSeconds: int64Ref(0),
+					PropagationPolicy:  &foreground,
+				},
+			)
+		cancel()
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Service %q (%v)", name, err)
+		}
+	}
+	return nil
+}
+
+func (ts *tester) checkNodePorts() error {
+	min, max, err := parseNodePortRange(ts.cfg.NodePortRange)
+	if err != nil {
+		return err
+	}
+	size := int(max-min) + 1
+	inUse, err := ts.listNodePortsInUse(min, max)
+	if err != nil {
+		return err
+	}
+	free := size - len(inUse)
+	ts.cfg.Result.NodePortRangeSize, ts.cfg.Result.NodePortsInUse = size, len(inUse)
+	ts.cfg.Logger.Info("allocating node ports until the range is full",
+		zap.String("range", ts.cfg.NodePortRange),
+		zap.Int("in-use", len(inUse)),
+		zap.Int("free", free),
+	)
+
+	var (
+		names     []string
+		allocated []int32
+		full      bool
+	)
+	// allocate one more than the free ports, to hit the exhaustion
+	for i := 0; i <= free; i++ {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("node port allocation aborted")
+		default:
+		}
+		name := fmt.Sprintf("%s-%d", nodePortPrefix, i)
+		svc, err := ts.createNodePortService(name, 0)
+		if isRangeFull(err) {
+			ts.cfg.Logger.Info("node port range is full", zap.Int("allocated", len(allocated)), zap.Error(err))
+			full = true
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create NodePort Service %q (%v)", name, err)
+		}
+		names = append(names, name)
+		allocated = append(allocated, svc.Spec.Ports[0].NodePort)
+		if len(allocated)%500 == 0 {
+			ts.cfg.Logger.Info("allocated node ports", zap.Int("allocated", len(allocated)), zap.Int("free", free))
+		}
+	}
+	ts.cfg.Result.NodePortsAllocated = len(allocated)
+	if !full {
+		return fmt.Errorf("node port range %q not exhausted after allocating %d ports (%d in use before)", ts.cfg.NodePortRange, len(allocated), len(inUse))
+	}
+	if len(allocated) != free {
+		// other Services may allocate or release the ports during the test
+		ts.cfg.Logger.Warn("unexpected number of allocated node ports", zap.Int("allocated", len(allocated)), zap.Int("expected", free))
+	}
+
+	var errs []string
+	if len(allocated) > 0 {
+		_, err = ts.createNodePortService(nodePortPrefix+"-duplicate", allocated[0])
+		if !isPortAllocated(err) {
+			errs = append(errs, fmt.Sprintf("expected 'already allocated' error for node port %d, got %v", allocated[0], err))
+		}
+	}
+
+	ts.cfg.Logger.Info("deleting NodePort Services", zap.Int("services", len(names)))
+	if err = ts.deleteNodePortServices(names); err != nil {
+		return err
+	}
+	after, err := ts.listNodePortsInUse(min, max)
+	if err != nil {
+		return err
+	}
+	ts.cfg.Result.NodePortsLeaked = leakedNodePorts(inUse, after)
+	if n := len(ts.cfg.Result.NodePortsLeaked); n > 0 {
+		errs = append(errs, fmt.Sprintf("%d node port(s) still in use after deleting the Services %v", n, ts.cfg.Result.NodePortsLeaked))
+	}
+
+	// the ports released from the deleted Services must be allocatable again
+	if len(allocated) > 0 {
+		reuse := []int32{allocated[0], allocated[len(allocated)-1]}
+		var reused []string
+		for i, port := range reuse {
+			name := fmt.Sprintf("%s-reuse-%d", nodePortPrefix, i)
+			if _, err = ts.createNodePortService(name, port); err != nil {
+				if k8s_errors.IsAlreadyExists(err) {
+					continue
+				}
+				errs = append(errs, fmt.Sprintf("failed to reuse released node port %d (%v)", port, err))
+				continue
+			}
+			reused = append(reused, name)
+		}
+		if err = ts.deleteNodePortServices(reused); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nNodePort range %q: %d in use, %d allocated until full, %d leaked\n\n",
+		ts.cfg.NodePortRange,
+		ts.cfg.Result.NodePortsInUse,
+		ts.cfg.Result.NodePortsAllocated,
+		len(ts.cfg.Result.NodePortsLeaked),
+	)
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) createHostPortPod(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Pod{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+					Labels:    map[string]string{appLabel: hostPortPrefix},
+				},
+				Spec: core_v1.PodSpec{
+					RestartPolicy: core_v1.RestartPolicyAlways,
+					NodeSelector: map[string]string{
+						// do not schedule to windows
+						"kubernetes.io/os": "linux",
+					},
+					Containers: []core_v1.Container{
+						{
+							Name:            hostPortPrefix,
+							Image:           ts.cfg.Image,
+							ImagePullPolicy: core_v1.PullIfNotPresent,
+							Ports: []core_v1.ContainerPort{
+								{
+									Protocol:      core_v1.ProtocolTCP,
+									ContainerPort: 80,
+									HostPort:      ts.cfg.HostPort,
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create Pod %q (%v)", name, err)
+	}
+	return nil
+}
+
+// waitForPod polls the Pod until the condition is met.
+func (ts *tester) waitForPod(name string, desc string, cond func(core_v1.Pod) bool) (pod core_v1.Pod, err error) {
+	deadline := time.Now().Add(ts.cfg.PodTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return pod, fmt.Errorf("waiting for Pod %q %s aborted", name, desc)
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		p, err := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Get(ctx, name, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Pod", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		pod = *p
+		if cond(pod) {
+			return pod, nil
+		}
+	}
+	return pod, fmt.Errorf("Pod %q not %s within %v (phase %q)", name, desc, ts.cfg.PodTimeout, pod.Status.Phase)
+}
+
+func isRunning(pod core_v1.Pod) bool { return pod.Status.Phase == core_v1.PodRunning }
+
+func (ts *tester) checkHostPorts() error {
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	names := schedulableNodes(nodes)
+	if len(names) == 0 {
+		return errors.New("no schedulable node for host port Pods")
+	}
+	ts.cfg.Logger.Info("allocating host port on every schedulable node", zap.Int32("host-port", ts.cfg.HostPort), zap.Int("nodes", len(names)))
+
+	// the scheduler spreads the Pods, since a node can allocate the host port only once
+	podNodes := make(map[string]string)
+	for i := range names {
+		if err = ts.createHostPortPod(fmt.Sprintf("%s-%d", hostPortPrefix, i)); err != nil {
+			return err
+		}
+	}
+	for i := range names {
+		name := fmt.Sprintf("%s-%d", hostPortPrefix, i)
+		pod, err := ts.waitForPod(name, "running", isRunning)
+		if err != nil {
+			return err
+		}
+		if prev, ok := podNodes[pod.Spec.NodeName]; ok {
+			return fmt.Errorf("Pods %q and %q allocated the same host port %d on the node %q", prev, name, ts.cfg.HostPort, pod.Spec.NodeName)
+		}
+		podNodes[pod.Spec.NodeName] = name
+	}
+	ts.cfg.Result.HostPortNodes = len(podNodes)
+
+	extra := hostPortPrefix + "-extra"
+	if err = ts.createHostPortPod(extra); err != nil {
+		return err
+	}
+	if _, err = ts.waitForPod(extra, "unschedulable for the host port", hostPortUnschedulable); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("host port exhausted; Pod is unschedulable", zap.String("name", extra))
+
+	released := hostPortPrefix + "-0"
+	ts.cfg.Logger.Info("deleting Pod to release host port", zap.String("name", released))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err = ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Delete(ctx, released, meta_v1.DeleteOptions{GracePeriodSeconds: int64Ref(0)})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to delete Pod %q (%v)", released, err)
+	}
+	pod, err := ts.waitForPod(extra, "running after the host port release", isRunning)
+	if err != nil {
+		return err
+	}
+	ts.cfg.Result.HostPortRescheduled = true
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nhost port %d: allocated on %d node(s), Pod %q rescheduled to %q after release\n\n",
+		ts.cfg.HostPort,
+		ts.cfg.Result.HostPortNodes,
+		extra,
+		pod.Spec.NodeName,
+	)
+	return nil
+}
+
+func int64Ref(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,184 @@
+// k8s-tester-probe-storm installs Kubernetes readiness/liveness probe storm tester.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	probe_storm "github.com/aws/aws-k8s-tester/k8s-tester/probe-storm"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-probe-storm",
+	Short:      "Kubernetes readiness/liveness probe storm tester",
+	SuggestFor: []string{"probe-storm"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", probe_storm.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-probe-storm failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	image                 string
+	probeTypes            []string
+	replicas              int32
+	probePeriodSeconds    int32
+	probeTimeoutSeconds   int32
+	probeFailureThreshold int32
+	duration              time.Duration
+	metricsInterval       time.Duration
+	rolloutTimeout        time.Duration
+	maxRestarts           int32
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&image, "image", probe_storm.DefaultImage, "storm Pod image with 'httpd'")
+	cmd.PersistentFlags().StringSliceVar(&probeTypes, "probe-types", probe_storm.DefaultProbeTypes(), "probe types (exec, http), one Deployment per type")
+	cmd.PersistentFlags().Int32Var(&replicas, "replicas", probe_storm.DefaultReplicas, "number of storm Pods per probe type")
+	cmd.PersistentFlags().Int32Var(&probePeriodSeconds, "probe-period-seconds", probe_storm.DefaultProbePeriodSeconds, "readiness and liveness probe period")
+	cmd.PersistentFlags().Int32Var(&probeTimeoutSeconds, "probe-timeout-seconds", probe_storm.DefaultProbeTimeoutSeconds, "readiness and liveness probe timeout")
+	cmd.PersistentFlags().Int32Var(&probeFailureThreshold, "probe-failure-threshold", probe_storm.DefaultProbeFailureThreshold, "consecutive liveness probe failures to restart the container")
+	cmd.PersistentFlags().DurationVar(&duration, "duration", probe_storm.DefaultDuration, "duration of the storm after all Pods are ready")
+	cmd.PersistentFlags().DurationVar(&metricsInterval, "metrics-interval", probe_storm.DefaultMetricsInterval, "interval of the node CPU usage samples")
+	cmd.PersistentFlags().DurationVar(&rolloutTimeout, "rollout-timeout", probe_storm.DefaultRolloutTimeout, "timeout for all storm Pods to become ready")
+	cmd.PersistentFlags().Int32Var(&maxRestarts, "max-restarts", probe_storm.DefaultMaxRestarts, "maximum number of probe-induced container restarts")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &probe_storm.Config{
+		Prompt:                prompt,
+		Logger:                lg,
+		LogWriter:             logWriter,
+		MinimumNodes:          minimumNodes,
+		Namespace:             namespace,
+		Client:                cli,
+		Image:                 image,
+		ProbeTypes:            probeTypes,
+		Replicas:              replicas,
+		ProbePeriodSeconds:    probePeriodSeconds,
+		ProbeTimeoutSeconds:   probeTimeoutSeconds,
+		ProbeFailureThreshold: probeFailureThreshold,
+		Duration:              duration,
+		MetricsInterval:       metricsInterval,
+		RolloutTimeout:        rolloutTimeout,
+		MaxRestarts:           maxRestarts,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := probe_storm.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-probe-storm apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &probe_storm.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := probe_storm.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-probe-storm delete' success\n")
+}
@@ -0,0 +1,177 @@
+package probe_storm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Probe types of the storm Pods.
+const (
+	ProbeTypeExec = "exec"
+	ProbeTypeHTTP = "http"
+)
+
+// validProbeType returns true if the probe type is supported.
+func validProbeType(p string) bool {
+	return p == ProbeTypeExec || p == ProbeTypeHTTP
+}
+
+// NodeImpact is the probe storm impact on a node.
+type NodeImpact struct {
+	// Node is the node name.
+	Node string `json:"node"`
+	// Pods is the number of the storm Pods scheduled on the node.
+	Pods int `json:"pods"`
+	// Restarts is the number of the storm container restarts on the node,
+	// that are killed by the failed liveness probes.
+	Restarts int32 `json:"restarts"`
+	// UnhealthyEvents is the number of the "Unhealthy" (probe failure) events
+	// reported by the kubelet of the node.
+	UnhealthyEvents int32 `json:"unhealthy_events"`
+	// BaselineCPUMillicores is the node CPU usage before the storm.
+	// Zero if the "metrics.k8s.io" API is not available.
+	BaselineCPUMillicores int64 `json:"baseline_cpu_millicores"`
+	// StormCPUMillicores is the average node CPU usage during the storm.
+	// Zero if the "metrics.k8s.io" API is not available.
+	StormCPUMillicores int64 `json:"storm_cpu_millicores"`
+}
+
+// CPUDeltaMillicores returns the node CPU usage increase during the storm.
+func (ni NodeImpact) CPUDeltaMillicores() int64 {
+	return ni.StormCPUMillicores - ni.BaselineCPUMillicores
+}
+
+const nodeMetricsPath = "/apis/metrics.k8s.io/v1beta1/nodes"
+
+// nodeMetricsList is the subset of "NodeMetricsList".
+// ref. https://github.com/kubernetes/metrics/blob/master/pkg/apis/metrics/v1beta1/types.go
+type nodeMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Usage map[string]string `json:"usage"`
+	} `json:"items"`
+}
+
+// parseNodeCPU parses the "metrics.k8s.io" node list response,
+// and returns the CPU usage millicores by node name.
+func parseNodeCPU(b []byte) (map[string]int64, error) {
+	var ls nodeMetricsList
+	if err := json.Unmarshal(b, &ls); err != nil {
+		return nil, fmt.Errorf("failed to parse node metrics list (%v)", err)
+	}
+	cpu := make(map[string]int64, len(ls.Items))
+	for _, item := range ls.Items {
+		q, err := resource.ParseQuantity(item.Usage["cpu"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node %q CPU usage %q (%v)", item.Metadata.Name, item.Usage["cpu"], err)
+		}
+		cpu[item.Metadata.Name] = q.MilliValue()
+	}
+	return cpu, nil
+}
+
+// averageCPU returns the average CPU usage of each node over the samples,
+// counting only the samples where the node is present.
+func averageCPU(samples []map[string]int64) map[string]int64 {
+	sums, counts := make(map[string]int64), make(map[string]int64)
+	for _, sample := range samples {
+		for node, v := range sample {
+			sums[node] += v
+			counts[node]++
+		}
+	}
+	avg := make(map[string]int64, len(sums))
+	for node, sum := range sums {
+		avg[node] = sum / counts[node]
+	}
+	return avg
+}
+
+// unhealthyReason is the kubelet event reason of the failed probes.
+const unhealthyReason = "Unhealthy"
+
+// nodeImpacts aggregates the storm Pods, their "Unhealthy" events,
+// and the CPU usages by node, sorted by node name.
+// The events of the deleted Pods are attributed by the event source host.
+func nodeImpacts(pods []core_v1.Pod, events []core_v1.Event, baseline map[string]int64, storm map[string]int64) []NodeImpact {
+	impacts := make(map[string]*NodeImpact)
+	get := func(node string) *NodeImpact {
+		ni, ok := impacts[node]
+		if !ok {
+			ni = &NodeImpact{Node: node, BaselineCPUMillicores: baseline[node], StormCPUMillicores: storm[node]}
+			impacts[node] = ni
+		}
+		return ni
+	}
+
+	podNodes := make(map[string]string, len(pods))
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		podNodes[pod.Name] = pod.Spec.NodeName
+		ni := get(pod.Spec.NodeName)
+		ni.Pods++
+		for _, cs := range pod.Status.ContainerStatuses {
+			ni.Restarts += cs.RestartCount
+		}
+	}
+	for _, ev := range events {
+		if ev.Reason != unhealthyReason {
+			continue
+		}
+		node, ok := podNodes[ev.InvolvedObject.Name]
+		if !ok {
+			node = ev.Source.Host
+		}
+		if node == "" {
+			continue
+		}
+		count := ev.Count
+		if count == 0 {
+			count = 1
+		}
+		get(node).UnhealthyEvents += count
+	}
+
+	rs := make([]NodeImpact, 0, len(impacts))
+	for _, ni := range impacts {
+		rs = append(rs, *ni)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Node < rs[j].Node })
+	return rs
+}
+
+// eventsSince returns the events last seen at or after "since".
+func eventsSince(events []core_v1.Event, since time.Time) (evs []core_v1.Event) {
+	for _, ev := range events {
+		last := ev.LastTimestamp.Time
+		if last.IsZero() {
+			last = ev.EventTime.Time
+		}
+		if last.Before(since) {
+			continue
+		}
+		evs = append(evs, ev)
+	}
+	return evs
+}
+
+// countEvents returns the total number of the events, including the repeats.
+func countEvents(events []core_v1.Event) (total int32) {
+	for _, ev := range events {
+		if ev.Count > 0 {
+			total += ev.Count
+		} else {
+			total++
+		}
+	}
+	return total
+}
@@ -0,0 +1,106 @@
+package probe_storm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseNodeCPU(t *testing.T) {
+	b := []byte(`{"kind":"NodeMetricsList","items":[
+{"metadata":{"name":"a"},"usage":{"cpu":"250m","memory":"1Gi"}},
+{"metadata":{"name":"b"},"usage":{"cpu":"123456789n","memory":"2Gi"}},
+{"metadata":{"name":"c"},"usage":{"cpu":"2","memory":"2Gi"}}
+]}`)
+	cpu, err := parseNodeCPU(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cpu, map[string]int64{"a": 250, "b": 124, "c": 2000}) {
+		t.Fatalf("unexpected CPU %v", cpu)
+	}
+	if _, err := parseNodeCPU([]byte(`{"items":[{"metadata":{"name":"a"},"usage":{"cpu":"x"}}]}`)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestAverageCPU(t *testing.T) {
+	avg := averageCPU([]map[string]int64{
+		{"a": 100, "b": 300},
+		{"a": 200},
+		{"a": 300, "b": 500},
+	})
+	if !reflect.DeepEqual(avg, map[string]int64{"a": 200, "b": 400}) {
+		t.Fatalf("unexpected average %v", avg)
+	}
+	if avg := averageCPU(nil); len(avg) != 0 {
+		t.Fatalf("unexpected average %v", avg)
+	}
+}
+
+func TestNodeImpacts(t *testing.T) {
+	pod := func(name string, node string, restarts int32) core_v1.Pod {
+		return core_v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Name: name},
+			Spec:       core_v1.PodSpec{NodeName: node},
+			Status: core_v1.PodStatus{
+				ContainerStatuses: []core_v1.ContainerStatus{{Name: containerName, RestartCount: restarts}},
+			},
+		}
+	}
+	event := func(reason string, pod string, host string, count int32) core_v1.Event {
+		return core_v1.Event{
+			Reason:         reason,
+			InvolvedObject: core_v1.ObjectReference{Kind: "Pod", Name: pod},
+			Source:         core_v1.EventSource{Host: host},
+			Count:          count,
+		}
+	}
+	pods := []core_v1.Pod{
+		pod("p1", "b", 2),
+		pod("p2", "a", 0),
+		pod("p3", "b", 1),
+		pod("pending", "", 0),
+	}
+	events := []core_v1.Event{
+		event(unhealthyReason, "p1", "b", 5),
+		event(unhealthyReason, "p2", "", 0),
+		event(unhealthyReason, "deleted", "c", 3),
+		event("Killing", "p1", "b", 2),
+	}
+	impacts := nodeImpacts(pods, events, map[string]int64{"a": 100, "b": 200}, map[string]int64{"a": 150, "b": 400})
+	expected := []NodeImpact{
+		{Node: "a", Pods: 1, UnhealthyEvents: 1, BaselineCPUMillicores: 100, StormCPUMillicores: 150},
+		{Node: "b", Pods: 2, Restarts: 3, UnhealthyEvents: 5, BaselineCPUMillicores: 200, StormCPUMillicores: 400},
+		{Node: "c", UnhealthyEvents: 3},
+	}
+	if !reflect.DeepEqual(impacts, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, impacts)
+	}
+	if d := impacts[1].CPUDeltaMillicores(); d != 200 {
+		t.Fatalf("unexpected CPU delta %d", d)
+	}
+	if total := countEvents(events); total != 11 {
+		t.Fatalf("unexpected total events %d", total)
+	}
+}
+
+func TestEventsSince(t *testing.T) {
+	now := time.Now()
+	events := []core_v1.Event{
+		{Reason: "before", LastTimestamp: meta_v1.NewTime(now.Add(-time.Minute))},
+		{Reason: "after", LastTimestamp: meta_v1.NewTime(now.Add(time.Minute))},
+		{Reason: "event-time", EventTime: meta_v1.NewMicroTime(now.Add(time.Second))},
+		{Reason: "no-time"},
+	}
+	var reasons []string
+	for _, ev := range eventsSince(events, now) {
+		reasons = append(reasons, ev.Reason)
+	}
+	if !reflect.DeepEqual(reasons, []string{"after", "event-time"}) {
+		t.Fatalf("unexpected events %v", reasons)
+	}
+}
@@ -0,0 +1,599 @@
+// Package probe_storm deploys Pods with aggressive exec and HTTP readiness/liveness probes
+// at scale, to quantify the kubelet probe overhead (the node CPU usage and the probe
+// failure events volume), and to detect the probe-induced restarts under load,
+// with a per-node impact report.
+// The node CPU usage requires the "metrics.k8s.io" API (e.g., metrics-server),
+// and is skipped if not available.
+// ref. https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/
+package probe_storm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the image of the storm Pods, that serves HTTP with "httpd" (e.g., busybox).
+	Image string `json:"image"`
+	// ProbeTypes is the list of the probe types, "exec" or "http".
+	// One Deployment of "Replicas" Pods is created per probe type,
+	// with the readiness and the liveness probes of the type.
+	ProbeTypes []string `json:"probe_types"`
+	// Replicas is the number of the storm Pods per probe type.
+	Replicas int32 `json:"replicas"`
+	// ProbePeriodSeconds is the period of the readiness and the liveness probes.
+	ProbePeriodSeconds int32 `json:"probe_period_seconds"`
+	// ProbeTimeoutSeconds is the timeout of the readiness and the liveness probes.
+	ProbeTimeoutSeconds int32 `json:"probe_timeout_seconds"`
+	// ProbeFailureThreshold is the number of the consecutive liveness probe failures
+	// to restart the container.
+	ProbeFailureThreshold int32 `json:"probe_failure_threshold"`
+
+	// Duration is the duration of the storm after all Pods are ready.
+	Duration       time.Duration `json:"duration"`
+	DurationString string        `json:"duration_string" read-only:"true"`
+	// MetricsInterval is the interval of the node CPU usage samples during the storm.
+	MetricsInterval       time.Duration `json:"metrics_interval"`
+	MetricsIntervalString string        `json:"metrics_interval_string" read-only:"true"`
+	// RolloutTimeout is the timeout for all storm Pods to become ready.
+	RolloutTimeout       time.Duration `json:"rollout_timeout"`
+	RolloutTimeoutString string        `json:"rollout_timeout_string" read-only:"true"`
+	// MaxRestarts is the maximum number of the probe-induced container restarts.
+	// The probes always succeed on a healthy node, so any restart is a probe
+	// that timed out under load.
+	MaxRestarts int32 `json:"max_restarts"`
+
+	// MetricsAvailable is true if the node CPU usages were collected.
+	MetricsAvailable bool `json:"metrics_available" read-only:"true"`
+	// TotalRestarts is the number of the storm container restarts.
+	TotalRestarts int32 `json:"total_restarts" read-only:"true"`
+	// TotalUnhealthyEvents is the number of the probe failure events.
+	TotalUnhealthyEvents int32 `json:"total_unhealthy_events" read-only:"true"`
+	// TotalEvents is the number of the events in the namespace during the storm,
+	// including the repeats.
+	TotalEvents int32 `json:"total_events" read-only:"true"`
+	// EventsPerMinute is "TotalEvents" per minute of the storm.
+	EventsPerMinute float64 `json:"events_per_minute" read-only:"true"`
+	// NodeImpacts is the per-node impact report, sorted by node name.
+	NodeImpacts []NodeImpact `json:"node_impacts" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if len(cfg.ProbeTypes) == 0 {
+		cfg.ProbeTypes = DefaultProbeTypes()
+	}
+	for _, p := range cfg.ProbeTypes {
+		if !validProbeType(p) {
+			return fmt.Errorf("unknown probe type %q (expected %q or %q)", p, ProbeTypeExec, ProbeTypeHTTP)
+		}
+	}
+	if cfg.Replicas == 0 {
+		cfg.Replicas = DefaultReplicas
+	}
+	if cfg.Replicas < 0 {
+		return fmt.Errorf("invalid Replicas %d", cfg.Replicas)
+	}
+	if cfg.ProbePeriodSeconds == 0 {
+		cfg.ProbePeriodSeconds = DefaultProbePeriodSeconds
+	}
+	if cfg.ProbeTimeoutSeconds == 0 {
+		cfg.ProbeTimeoutSeconds = DefaultProbeTimeoutSeconds
+	}
+	if cfg.ProbeTimeoutSeconds > cfg.ProbePeriodSeconds {
+		return fmt.Errorf("ProbeTimeoutSeconds %d > ProbePeriodSeconds %d", cfg.ProbeTimeoutSeconds, cfg.ProbePeriodSeconds)
+	}
+	if cfg.ProbeFailureThreshold == 0 {
+		cfg.ProbeFailureThreshold = DefaultProbeFailureThreshold
+	}
+	if cfg.Duration == time.Duration(0) {
+		cfg.Duration = DefaultDuration
+	}
+	cfg.DurationString = cfg.Duration.String()
+	if cfg.MetricsInterval == time.Duration(0) {
+		cfg.MetricsInterval = DefaultMetricsInterval
+	}
+	if cfg.MetricsInterval > cfg.Duration {
+		return fmt.Errorf("MetricsInterval %v > Duration %v", cfg.MetricsInterval, cfg.Duration)
+	}
+	cfg.MetricsIntervalString = cfg.MetricsInterval.String()
+	if cfg.RolloutTimeout == time.Duration(0) {
+		cfg.RolloutTimeout = DefaultRolloutTimeout
+	}
+	cfg.RolloutTimeoutString = cfg.RolloutTimeout.String()
+	if cfg.MaxRestarts < 0 {
+		return fmt.Errorf("invalid MaxRestarts %d", cfg.MaxRestarts)
+	}
+	return nil
+}
+
+const (
+	DefaultMinimumNodes          int   = 1
+	DefaultImage                       = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultReplicas              int32 = 50
+	DefaultProbePeriodSeconds    int32 = 1
+	DefaultProbeTimeoutSeconds   int32 = 1
+	DefaultProbeFailureThreshold int32 = 3
+	DefaultDuration                    = 5 * time.Minute
+	DefaultMetricsInterval             = 30 * time.Second
+	DefaultRolloutTimeout              = 15 * time.Minute
+	DefaultMaxRestarts           int32 = 0
+)
+
+// DefaultProbeTypes returns the default probe types.
+func DefaultProbeTypes() []string {
+	return []string{ProbeTypeExec, ProbeTypeHTTP}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:                false,
+		Prompt:                false,
+		MinimumNodes:          DefaultMinimumNodes,
+		Namespace:             pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:                 DefaultImage,
+		ProbeTypes:            DefaultProbeTypes(),
+		Replicas:              DefaultReplicas,
+		ProbePeriodSeconds:    DefaultProbePeriodSeconds,
+		ProbeTimeoutSeconds:   DefaultProbeTimeoutSeconds,
+		ProbeFailureThreshold: DefaultProbeFailureThreshold,
+		Duration:              DefaultDuration,
+		MetricsInterval:       DefaultMetricsInterval,
+		RolloutTimeout:        DefaultRolloutTimeout,
+		MaxRestarts:           DefaultMaxRestarts,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	appLabel      = "app.kubernetes.io/name"
+	probeLabel    = "k8s-tester.aws/probe-type"
+	containerName = "storm"
+	httpPort      = 8080
+
+	// stormCommand serves the probe target file over HTTP.
+	stormCommand = "mkdir -p /www && echo ok > /www/index.html && exec httpd -f -p 8080 -h /www"
+)
+
+// deploymentName returns the storm Deployment name of the probe type.
+func deploymentName(probeType string) string {
+	return "probe-storm-" + probeType
+}
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	baseline, err := ts.getNodeCPU()
+	if err != nil {
+		ts.cfg.Logger.Warn("node metrics not available; skipping node CPU usage", zap.Error(err))
+	}
+	ts.cfg.MetricsAvailable = err == nil
+
+	for _, p := range ts.cfg.ProbeTypes {
+		if err := ts.createDeployment(p); err != nil {
+			return err
+		}
+	}
+	for _, p := range ts.cfg.ProbeTypes {
+		if err := ts.waitForDeployment(p); err != nil {
+			return err
+		}
+	}
+
+	stormStart := time.Now()
+	storm, err := ts.runStorm()
+	if err != nil {
+		return err
+	}
+	if err := ts.collectImpacts(stormStart, baseline, storm); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\n%s\n", ts.cfg.report())
+	if ts.cfg.TotalRestarts > ts.cfg.MaxRestarts {
+		return fmt.Errorf("%d probe-induced restarts > MaxRestarts %d", ts.cfg.TotalRestarts, ts.cfg.MaxRestarts)
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	for _, p := range ts.cfg.ProbeTypes {
+		if err := client.DeleteDeployment(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			deploymentName(p),
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete Deployment %q (%v)", deploymentName(p), err))
+		}
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// probe returns the readiness or the liveness probe of the probe type.
+func (cfg *Config) probe(probeType string) *core_v1.Probe {
+	pb := &core_v1.Probe{
+		PeriodSeconds:    cfg.ProbePeriodSeconds,
+		TimeoutSeconds:   cfg.ProbeTimeoutSeconds,
+		FailureThreshold: cfg.ProbeFailureThreshold,
+	}
+	switch probeType {
+	case ProbeTypeExec:
+		pb.Exec = &core_v1.ExecAction{
+			Command: []string{"cat", "/www/index.html"},
+		}
+	case ProbeTypeHTTP:
+		pb.HTTPGet = &core_v1.HTTPGetAction{
+			Path: "/",
+			Port: intstr.FromInt(httpPort),
+		}
+	}
+	return pb
+}
+
+func (ts *tester) createDeployment(probeType string) error {
+	name := deploymentName(probeType)
+	ts.cfg.Logger.Info("creating Deployment",
+		zap.String("name", name),
+		zap.Int32("replicas", ts.cfg.Replicas),
+		zap.Int32("probe-period-seconds", ts.cfg.ProbePeriodSeconds),
+	)
+	replicas := ts.cfg.Replicas
+	labels := map[string]string{
+		appLabel:   name,
+		probeLabel: probeType,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+					Labels:    labels,
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: labels,
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+							// spread the storm evenly, to compare the nodes
+							TopologySpreadConstraints: []core_v1.TopologySpreadConstraint{
+								{
+									MaxSkew:           1,
+									TopologyKey:       "kubernetes.io/hostname",
+									WhenUnsatisfiable: core_v1.ScheduleAnyway,
+									LabelSelector: &meta_v1.LabelSelector{
+										MatchLabels: labels,
+									},
+								},
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            containerName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"sh", "-c", stormCommand},
+									Ports: []core_v1.ContainerPort{
+										{
+											Protocol:      core_v1.ProtocolTCP,
+											ContainerPort: httpPort,
+										},
+									},
+									ReadinessProbe: ts.cfg.probe(probeType),
+									LivenessProbe:  ts.cfg.probe(probeType),
+									Resources: core_v1.ResourceRequirements{
+										Requests: core_v1.ResourceList{
+											core_v1.ResourceCPU:    resource.MustParse("10m"),
+											core_v1.ResourceMemory: resource.MustParse("16Mi"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Deployment %q (%v)", name, err)
+	}
+
+	ts.cfg.Logger.Info("created Deployment", zap.String("name", name))
+	return nil
+}
+
+func (ts *tester) waitForDeployment(probeType string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.RolloutTimeout)
+	_, err := client.WaitForDeployment(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		deploymentName(probeType),
+		client.WithPollInterval(10*time.Second),
+		client.WithStopc(ts.cfg.Stopc),
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("Deployment %q not ready within %v (%v)", deploymentName(probeType), ts.cfg.RolloutTimeout, err)
+	}
+	return nil
+}
+
+// getNodeCPU returns the current CPU usage millicores by node name.
+func (ts *tester) getNodeCPU() (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	b, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		RESTClient().
+		Get().
+		AbsPath(nodeMetricsPath).
+		DoRaw(ctx)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %q (%v)", nodeMetricsPath, err)
+	}
+	return parseNodeCPU(b)
+}
+
+// runStorm lets the probes run for "Duration", and returns the average
+// node CPU usages sampled every "MetricsInterval".
+func (ts *tester) runStorm() (map[string]int64, error) {
+	ts.cfg.Logger.Info("running probe storm", zap.String("duration", ts.cfg.DurationString))
+	var samples []map[string]int64
+	start := time.Now()
+	for time.Since(start) < ts.cfg.Duration {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, errors.New("probe storm aborted")
+		case <-time.After(ts.cfg.MetricsInterval):
+		}
+		if !ts.cfg.MetricsAvailable {
+			continue
+		}
+		sample, err := ts.getNodeCPU()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to sample node CPU usage", zap.Error(err))
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	ts.cfg.Logger.Info("finished probe storm", zap.Int("cpu-samples", len(samples)))
+	return averageCPU(samples), nil
+}
+
+// collectImpacts lists the storm Pods and the namespace events since the storm start,
+// and aggregates them by node. The events of the rollout (e.g., the readiness probe
+// failures before the HTTP server starts) are excluded.
+func (ts *tester) collectImpacts(since time.Time, baseline map[string]int64, storm map[string]int64) error {
+	var pods []core_v1.Pod
+	for _, p := range ts.cfg.ProbeTypes {
+		ps, err := client.ListPods(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			1000,
+			2*time.Second,
+			client.WithLabelSelector(appLabel+"="+deploymentName(p)),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to list Pods (%v)", err)
+		}
+		pods = append(pods, ps...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	events, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Events(ts.cfg.Namespace).
+		List(ctx, meta_v1.ListOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list events (%v)", err)
+	}
+
+	evs := eventsSince(events.Items, since)
+	ts.cfg.NodeImpacts = nodeImpacts(pods, evs, baseline, storm)
+	ts.cfg.TotalRestarts, ts.cfg.TotalUnhealthyEvents = 0, 0
+	for _, ni := range ts.cfg.NodeImpacts {
+		ts.cfg.TotalRestarts += ni.Restarts
+		ts.cfg.TotalUnhealthyEvents += ni.UnhealthyEvents
+	}
+	ts.cfg.TotalEvents = countEvents(evs)
+	ts.cfg.EventsPerMinute = float64(ts.cfg.TotalEvents) / ts.cfg.Duration.Minutes()
+	ts.cfg.Logger.Info("collected probe storm impacts",
+		zap.Int("nodes", len(ts.cfg.NodeImpacts)),
+		zap.Int32("restarts", ts.cfg.TotalRestarts),
+		zap.Int32("unhealthy-events", ts.cfg.TotalUnhealthyEvents),
+		zap.Int32("events", ts.cfg.TotalEvents),
+	)
+	return nil
+}
+
+// report returns the per-node impact table with the totals.
+func (cfg *Config) report() string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"Node", "Pods", "Restarts", "Unhealthy Events", "Baseline CPU", "Storm CPU", "CPU Delta"})
+	for _, ni := range cfg.NodeImpacts {
+		baseline, storm, delta := "n/a", "n/a", "n/a"
+		if cfg.MetricsAvailable {
+			baseline = fmt.Sprintf("%dm", ni.BaselineCPUMillicores)
+			storm = fmt.Sprintf("%dm", ni.StormCPUMillicores)
+			delta = fmt.Sprintf("%+dm", ni.CPUDeltaMillicores())
+		}
+		tb.Append([]string{
+			ni.Node,
+			fmt.Sprintf("%d", ni.Pods),
+			fmt.Sprintf("%d", ni.Restarts),
+			fmt.Sprintf("%d", ni.UnhealthyEvents),
+			baseline,
+			storm,
+			delta,
+		})
+	}
+	tb.Render()
+	fmt.Fprintf(buf, "probe storm %v: restarts %d, unhealthy events %d, events %d (%.1f/min)\n",
+		cfg.Duration, cfg.TotalRestarts, cfg.TotalUnhealthyEvents, cfg.TotalEvents, cfg.EventsPerMinute)
+	return buf.String()
+}
@@ -0,0 +1,45 @@
+package probe_storm
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateAndSetDefaults(t *testing.T) {
+	cfg := &Config{Namespace: "test-namespace"}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Replicas != DefaultReplicas || cfg.ProbePeriodSeconds != DefaultProbePeriodSeconds || cfg.DurationString != DefaultDuration.String() {
+		t.Fatalf("unexpected defaults %+v", cfg)
+	}
+
+	for i, cfg := range []*Config{
+		{},
+		{Namespace: "n", ProbeTypes: []string{"tcp"}},
+		{Namespace: "n", ProbePeriodSeconds: 1, ProbeTimeoutSeconds: 2},
+		{Namespace: "n", Duration: time.Minute, MetricsInterval: 2 * time.Minute},
+		{Namespace: "n", MaxRestarts: -1},
+	} {
+		if err := cfg.ValidateAndSetDefaults(); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	cfg := &Config{
+		Duration:    5 * time.Minute,
+		NodeImpacts: []NodeImpact{{Node: "a", Pods: 2, Restarts: 1, UnhealthyEvents: 4, BaselineCPUMillicores: 100, StormCPUMillicores: 350}},
+		TotalEvents: 30,
+	}
+	cfg.EventsPerMinute = float64(cfg.TotalEvents) / cfg.Duration.Minutes()
+	if rp := cfg.report(); !strings.Contains(rp, "n/a") || !strings.Contains(rp, "(6.0/min)") {
+		t.Fatalf("unexpected report\n%s", rp)
+	}
+	cfg.MetricsAvailable = true
+	if rp := cfg.report(); !strings.Contains(rp, "+250m") || strings.Contains(rp, "n/a") {
+		t.Fatalf("unexpected report\n%s", rp)
+	}
+}
@@ -0,0 +1,185 @@
+// k8s-tester-prometheus-grafana installs Kubernetes prometheus-grafana tester.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	prometheus_grafana "github.com/aws/aws-k8s-tester/k8s-tester/prometheus-grafana"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-prometheus-grafana",
+	Short:      "Kubernetes prometheus-grafana tester",
+	SuggestFor: []string{"prometheus-grafana"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+
+	partition  string
+	region     string
+	grafanaNLB bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", prometheus_grafana.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&partition, "partition", "aws", "partition for AWS region")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "region for ELB resource")
+	rootCmd.PersistentFlags().BoolVar(&grafanaNLB, "grafana-nlb", false, "'true' to expose Grafana via NLB")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-prometheus-grafana failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	helmChartRepoURL     string
+	grafanaAdminUserName string
+	grafanaAdminPassword string
+	targetsTimeout       time.Duration
+	requiredJobs         []string
+	queries              []string
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&helmChartRepoURL, "helm-chart-repo-url", prometheus_grafana.DefaultHelmChartRepoURL, "helm chart repo URL")
+	cmd.PersistentFlags().StringVar(&grafanaAdminUserName, "grafana-admin-user-name", prometheus_grafana.DefaultGrafanaAdminUserName, "Grafana admin user name")
+	cmd.PersistentFlags().StringVar(&grafanaAdminPassword, "grafana-admin-password", "", "Grafana admin password (random if empty)")
+	cmd.PersistentFlags().DurationVar(&targetsTimeout, "targets-timeout", prometheus_grafana.DefaultTargetsTimeout, "timeout to wait for the required scrape targets to be healthy")
+	cmd.PersistentFlags().StringSliceVar(&requiredJobs, "required-jobs", prometheus_grafana.DefaultRequiredJobs(), "scrape jobs that must have at least one healthy target")
+	cmd.PersistentFlags().StringArrayVar(&queries, "query", prometheus_grafana.DefaultQueries(), "PromQL expression that must return at least one series (repeatable)")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &prometheus_grafana.Config{
+		Prompt:               prompt,
+		Logger:               lg,
+		LogWriter:            logWriter,
+		MinimumNodes:         minimumNodes,
+		Namespace:            namespace,
+		Client:               cli,
+		Partition:            partition,
+		Region:               region,
+		HelmChartRepoURL:     helmChartRepoURL,
+		GrafanaAdminUserName: grafanaAdminUserName,
+		GrafanaAdminPassword: grafanaAdminPassword,
+		GrafanaNLB:           grafanaNLB,
+		TargetsTimeout:       targetsTimeout,
+		RequiredJobs:         requiredJobs,
+		Queries:              queries,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := prometheus_grafana.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-prometheus-grafana apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &prometheus_grafana.Config{
+		Prompt:     prompt,
+		Logger:     lg,
+		LogWriter:  logWriter,
+		Namespace:  namespace,
+		Client:     cli,
+		Partition:  partition,
+		Region:     region,
+		GrafanaNLB: grafanaNLB,
+	}
+
+	ts := prometheus_grafana.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-prometheus-grafana delete' success\n")
+}
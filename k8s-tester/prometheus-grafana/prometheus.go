@@ -0,0 +1,110 @@
+package prometheus_grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// JobTargets is the health of the Prometheus scrape targets of a job.
+type JobTargets struct {
+	// Job is the scrape job name (e.g., "apiserver", "kubelet").
+	Job string `json:"job"`
+	// Up is the number of healthy targets.
+	Up int `json:"up"`
+	// Down is the number of unhealthy or unknown targets.
+	Down int `json:"down"`
+	// LastErrors is the list of last scrape errors of the unhealthy targets.
+	LastErrors []string `json:"last_errors,omitempty"`
+}
+
+// QueryResult is the result of a PromQL query.
+type QueryResult struct {
+	// Query is the PromQL expression.
+	Query string `json:"query"`
+	// Series is the number of series returned from the query.
+	Series int `json:"series"`
+}
+
+// ref. https://prometheus.io/docs/prometheus/latest/querying/api/#targets
+type targetsResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ActiveTargets []struct {
+			Labels    map[string]string `json:"labels"`
+			Health    string            `json:"health"`
+			LastError string            `json:"lastError"`
+		} `json:"activeTargets"`
+	} `json:"data"`
+}
+
+// parseTargets parses the "/api/v1/targets" response,
+// and returns the target health aggregated by job, sorted by job name.
+func parseTargets(b []byte) ([]JobTargets, error) {
+	var resp targetsResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse targets response (%v)", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("unexpected targets response status %q", resp.Status)
+	}
+	jobs := make(map[string]*JobTargets)
+	for _, t := range resp.Data.ActiveTargets {
+		job := t.Labels["job"]
+		jt, ok := jobs[job]
+		if !ok {
+			jt = &JobTargets{Job: job}
+			jobs[job] = jt
+		}
+		if t.Health == "up" {
+			jt.Up++
+			continue
+		}
+		jt.Down++
+		if t.LastError != "" {
+			jt.LastErrors = append(jt.LastErrors, t.LastError)
+		}
+	}
+	targets := make([]JobTargets, 0, len(jobs))
+	for _, jt := range jobs {
+		targets = append(targets, *jt)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Job < targets[j].Job })
+	return targets, nil
+}
+
+// unhealthyJobs returns the required jobs without any healthy target.
+func unhealthyJobs(targets []JobTargets, required []string) (unhealthy []string) {
+	up := make(map[string]bool)
+	for _, jt := range targets {
+		up[jt.Job] = jt.Up > 0
+	}
+	for _, job := range required {
+		if !up[job] {
+			unhealthy = append(unhealthy, job)
+		}
+	}
+	return unhealthy
+}
+
+// ref. https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string            `json:"resultType"`
+		Result     []json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// parseQuery parses the "/api/v1/query" response, and returns the number of series.
+func parseQuery(b []byte) (series int, err error) {
+	var resp queryResponse
+	if err = json.Unmarshal(b, &resp); err != nil {
+		return 0, fmt.Errorf("failed to parse query response (%v)", err)
+	}
+	if resp.Status != "success" {
+		return 0, fmt.Errorf("query failed with status %q (%s)", resp.Status, resp.Error)
+	}
+	return len(resp.Data.Result), nil
+}
@@ -0,0 +1,65 @@
+package prometheus_grafana
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTargets(t *testing.T) {
+	b := []byte(`{
+  "status": "success",
+  "data": {
+    "activeTargets": [
+      {"labels": {"job": "kubelet", "instance": "10.0.0.1:10250"}, "health": "up", "lastError": ""},
+      {"labels": {"job": "kubelet", "instance": "10.0.0.2:10250"}, "health": "up", "lastError": ""},
+      {"labels": {"job": "apiserver", "instance": "10.0.0.10:443"}, "health": "up", "lastError": ""},
+      {"labels": {"job": "kube-etcd", "instance": "10.0.0.11:2379"}, "health": "down", "lastError": "connection refused"},
+      {"labels": {"job": "node-exporter", "instance": "10.0.0.1:9100"}, "health": "unknown", "lastError": ""}
+    ]
+  }
+}`)
+	targets, err := parseTargets(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []JobTargets{
+		{Job: "apiserver", Up: 1},
+		{Job: "kube-etcd", Down: 1, LastErrors: []string{"connection refused"}},
+		{Job: "kubelet", Up: 2},
+		{Job: "node-exporter", Down: 1},
+	}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, targets)
+	}
+
+	unhealthy := unhealthyJobs(targets, []string{"apiserver", "kubelet", "node-exporter", "kube-state-metrics"})
+	if !reflect.DeepEqual(unhealthy, []string{"node-exporter", "kube-state-metrics"}) {
+		t.Fatalf("unexpected unhealthy jobs %v", unhealthy)
+	}
+
+	if _, err = parseTargets([]byte(`{"status":"error"}`)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		body   string
+		series int
+		err    bool
+	}{
+		{`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"kubelet"},"value":[1,"1"]},{"metric":{"job":"kubelet"},"value":[1,"1"]}]}}`, 2, false},
+		{`{"status":"success","data":{"resultType":"vector","result":[]}}`, 0, false},
+		{`{"status":"error","errorType":"bad_data","error":"parse error"}`, 0, true},
+		{`not json`, 0, true},
+	}
+	for i, tt := range tests {
+		series, err := parseQuery([]byte(tt.body))
+		if (err != nil) != tt.err {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if series != tt.series {
+			t.Fatalf("#%d: expected %d series, got %d", i, tt.series, series)
+		}
+	}
+}
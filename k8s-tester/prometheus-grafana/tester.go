@@ -0,0 +1,613 @@
+// Package prometheus_grafana installs kube-prometheus-stack (Prometheus, Grafana, and exporters),
+// waits for the scrape targets to be healthy, and validates the scraping with PromQL queries.
+// Replace https://github.com/aws/aws-k8s-tester/blob/v1.5.9/eks/prometheus-grafana
+// ref. https://github.com/prometheus-community/helm-charts/tree/main/charts/kube-prometheus-stack
+package prometheus_grafana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/helm"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	aws_v1_elb "github.com/aws/aws-k8s-tester/utils/aws/v1/elb"
+	"github.com/aws/aws-k8s-tester/utils/http"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	ELB2API elbv2iface.ELBV2API `json:"-"`
+
+	AccountID string `json:"account_id" read-only:"true"`
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+	// HelmChartRepoURL is the helm chart repo URL.
+	HelmChartRepoURL string `json:"helm_chart_repo_url"`
+
+	// GrafanaAdminUserName is the admin user name for Grafana.
+	GrafanaAdminUserName string `json:"grafana_admin_user_name"`
+	// GrafanaAdminPassword is the admin password for Grafana.
+	// If empty, a random password is generated.
+	GrafanaAdminPassword string `json:"grafana_admin_password"`
+	// GrafanaNLB is true to expose Grafana via NLB.
+	GrafanaNLB bool `json:"grafana_nlb"`
+
+	// TargetsTimeout is the timeout to wait for the required scrape targets to be healthy.
+	TargetsTimeout       time.Duration `json:"targets_timeout"`
+	TargetsTimeoutString string        `json:"targets_timeout_string" read-only:"true"`
+	// RequiredJobs is the list of scrape jobs that must have at least one healthy target.
+	RequiredJobs []string `json:"required_jobs"`
+	// Queries is the list of PromQL expressions that must return at least one series.
+	// When set via environment variable, the expressions are comma-separated.
+	Queries []string `json:"queries"`
+
+	// Targets is the health of the scrape targets by job.
+	Targets []JobTargets `json:"targets" read-only:"true"`
+	// QueryResults is the results of the PromQL queries.
+	QueryResults []QueryResult `json:"query_results" read-only:"true"`
+
+	// ELBARN is the ARN of the ELB created from the Grafana service.
+	ELBARN string `json:"elb_arn" read-only:"true"`
+	// ELBName is the name of the ELB created from the Grafana service.
+	ELBName string `json:"elb_name" read-only:"true"`
+	// ELBURL is the host name for Grafana service.
+	ELBURL string `json:"elb_url" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.HelmChartRepoURL == "" {
+		cfg.HelmChartRepoURL = DefaultHelmChartRepoURL
+	}
+	if cfg.GrafanaAdminUserName == "" {
+		cfg.GrafanaAdminUserName = DefaultGrafanaAdminUserName
+	}
+	if cfg.GrafanaAdminPassword == "" {
+		cfg.GrafanaAdminPassword = rand.String(15)
+	}
+	if cfg.GrafanaNLB && cfg.Region == "" {
+		return errors.New("empty Region with GrafanaNLB")
+	}
+	if cfg.TargetsTimeout == time.Duration(0) {
+		cfg.TargetsTimeout = DefaultTargetsTimeout
+	}
+	cfg.TargetsTimeoutString = cfg.TargetsTimeout.String()
+	if len(cfg.RequiredJobs) == 0 {
+		cfg.RequiredJobs = DefaultRequiredJobs()
+	}
+	if len(cfg.Queries) == 0 {
+		cfg.Queries = DefaultQueries()
+	}
+	return nil
+}
+
+const (
+	chartRepoName = "prometheus-community"
+	chartName     = "kube-prometheus-stack"
+
+	// service names from the release name "kube-prometheus-stack"
+	prometheusServiceName = chartName + "-prometheus"
+	prometheusServicePort = 9090
+	grafanaServiceName    = chartName + "-grafana"
+)
+
+const (
+	DefaultMinimumNodes         int = 1
+	DefaultHelmChartRepoURL         = "https://prometheus-community.github.io/helm-charts"
+	DefaultGrafanaAdminUserName     = "admin"
+	DefaultTargetsTimeout           = 10 * time.Minute
+)
+
+// DefaultRequiredJobs returns the scrape jobs that kube-prometheus-stack
+// configures by default and are expected to be healthy on EKS.
+// The control plane components other than the API server are not reachable on EKS.
+func DefaultRequiredJobs() []string {
+	return []string{
+		"apiserver",
+		"kubelet",
+		"node-exporter",
+		"kube-state-metrics",
+	}
+}
+
+// DefaultQueries returns the PromQL expressions to validate the scraping.
+func DefaultQueries() []string {
+	return []string{
+		`up{job="apiserver"} == 1`,
+		`up{job="kubelet"} == 1`,
+		`node_cpu_seconds_total`,
+		`kube_node_info`,
+	}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:               false,
+		Prompt:               false,
+		MinimumNodes:         DefaultMinimumNodes,
+		Namespace:            pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		HelmChartRepoURL:     DefaultHelmChartRepoURL,
+		GrafanaAdminUserName: DefaultGrafanaAdminUserName,
+		GrafanaNLB:           false,
+		TargetsTimeout:       DefaultTargetsTimeout,
+		RequiredJobs:         DefaultRequiredJobs(),
+		Queries:              DefaultQueries(),
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	if cfg.GrafanaNLB {
+		awsCfg := aws_v1.Config{
+			Logger:        cfg.Logger,
+			DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+			Partition:     cfg.Partition,
+			Region:        cfg.Region,
+		}
+		awsSession, stsOutput, _, err := aws_v1.New(&awsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create aws session (%v)", err)
+		}
+		cfg.ELB2API = elbv2.New(awsSession)
+		if cfg.AccountID == "" && stsOutput.Account != nil {
+			cfg.AccountID = *stsOutput.Account
+		}
+	}
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := helm.AddUpdate(ts.cfg.Logger, chartRepoName, ts.cfg.HelmChartRepoURL); err != nil {
+		return err
+	}
+	if err := ts.installChart(); err != nil {
+		return err
+	}
+	if err := ts.waitForTargets(); err != nil {
+		return err
+	}
+	if err := ts.checkQueries(); err != nil {
+		return err
+	}
+	if ts.cfg.GrafanaNLB {
+		if err := ts.checkGrafana(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	// get ELB ARN before deleting the service
+	if ts.cfg.GrafanaNLB && ts.cfg.ELBARN == "" {
+		_, elbARN, elbName, exists, err := client.FindServiceIngressHostname(
+			ts.cfg.Logger,
+			ts.cfg.Client.KubernetesClient(),
+			ts.cfg.Namespace,
+			grafanaServiceName,
+			ts.cfg.Stopc,
+			3*time.Minute,
+			ts.cfg.AccountID,
+			ts.cfg.Region,
+		)
+		if err != nil {
+			if exists { // maybe already deleted from previous run
+				errs = append(errs, fmt.Sprintf("ELB exists but failed to find ingress ELB ARN (%v)", err))
+			}
+		}
+		ts.cfg.ELBARN = elbARN
+		ts.cfg.ELBName = elbName
+	}
+
+	if ts.cfg.ELBARN != "" {
+		if err := aws_v1_elb.DeleteELBv2(
+			ts.cfg.Logger,
+			ts.cfg.ELB2API,
+			ts.cfg.ELBARN,
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete ELB (%v)", err))
+		}
+	}
+
+	if err := ts.deleteHelm(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources, should we continue?", action)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// https://github.com/prometheus-community/helm-charts/blob/main/charts/kube-prometheus-stack/values.yaml
+func (ts *tester) helmValues() map[string]interface{} {
+	grafanaService := map[string]interface{}{
+		"type": "ClusterIP",
+	}
+	if ts.cfg.GrafanaNLB {
+		grafanaService = map[string]interface{}{
+			"type": "LoadBalancer",
+			"annotations": map[string]interface{}{
+				"service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+			},
+		}
+	}
+	return map[string]interface{}{
+		// control plane components other than the API server are not reachable on EKS
+		"kubeControllerManager": map[string]interface{}{"enabled": false},
+		"kubeScheduler":         map[string]interface{}{"enabled": false},
+		"kubeEtcd":              map[string]interface{}{"enabled": false},
+		"kubeProxy":             map[string]interface{}{"enabled": false},
+		"grafana": map[string]interface{}{
+			"adminUser":     ts.cfg.GrafanaAdminUserName,
+			"adminPassword": ts.cfg.GrafanaAdminPassword,
+			"service":       grafanaService,
+		},
+	}
+}
+
+func (ts *tester) installChart() error {
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        15 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+		Values:         ts.helmValues(),
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			getAllArgs := []string{
+				ts.cfg.Client.Config().KubectlPath,
+				"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+				"--namespace=" + ts.cfg.Namespace,
+				"get",
+				"all",
+			}
+			getAllCmd := strings.Join(getAllArgs, " ")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelm() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeconfigPath: ts.cfg.Client.Config().KubeconfigPath,
+		Namespace:      ts.cfg.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+	})
+}
+
+// queryPrometheus sends a GET request to the Prometheus HTTP API via the API server service proxy.
+func (ts *tester) queryPrometheus(apiPath string, params map[string]string) ([]byte, error) {
+	req := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		RESTClient().
+		Get().
+		AbsPath(fmt.Sprintf("/api/v1/namespaces/%s/services/%s:%d/proxy%s", ts.cfg.Namespace, prometheusServiceName, prometheusServicePort, apiPath))
+	for k, v := range params {
+		req = req.Param(k, v)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	b, err := req.DoRaw(ctx)
+	cancel()
+	return b, err
+}
+
+func (ts *tester) waitForTargets() error {
+	ts.cfg.Logger.Info("waiting for Prometheus scrape targets", zap.Strings("required-jobs", ts.cfg.RequiredJobs), zap.Duration("timeout", ts.cfg.TargetsTimeout))
+	var unhealthy []string
+	deadline := time.Now().Add(ts.cfg.TargetsTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Prometheus targets check aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		b, err := ts.queryPrometheus("/api/v1/targets", map[string]string{"state": "active"})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Prometheus targets; retrying", zap.Error(err))
+			continue
+		}
+		targets, err := parseTargets(b)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to parse Prometheus targets; retrying", zap.Error(err))
+			continue
+		}
+		ts.cfg.Targets = targets
+
+		unhealthy = unhealthyJobs(targets, ts.cfg.RequiredJobs)
+		if len(unhealthy) == 0 {
+			break
+		}
+		ts.cfg.Logger.Info("waiting for healthy Prometheus targets", zap.Strings("unhealthy-jobs", unhealthy))
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nPrometheus scrape targets:\n\n")
+	for _, jt := range ts.cfg.Targets {
+		fmt.Fprintf(ts.cfg.LogWriter, "  %-40s up %d, down %d\n", jt.Job, jt.Up, jt.Down)
+		for _, e := range jt.LastErrors {
+			fmt.Fprintf(ts.cfg.LogWriter, "    %s\n", e)
+		}
+	}
+	fmt.Fprintln(ts.cfg.LogWriter)
+
+	if len(ts.cfg.Targets) == 0 {
+		return fmt.Errorf("no Prometheus target found within %v", ts.cfg.TargetsTimeout)
+	}
+	if len(unhealthy) > 0 {
+		return fmt.Errorf("Prometheus jobs %q without healthy target within %v", unhealthy, ts.cfg.TargetsTimeout)
+	}
+	ts.cfg.Logger.Info("Prometheus targets are healthy", zap.Int("jobs", len(ts.cfg.Targets)))
+	return nil
+}
+
+func (ts *tester) checkQueries() error {
+	ts.cfg.QueryResults = make([]QueryResult, 0, len(ts.cfg.Queries))
+	var errs []string
+	for _, q := range ts.cfg.Queries {
+		b, err := ts.queryPrometheus("/api/v1/query", map[string]string{"query": q})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to query %q (%v)", q, err))
+			continue
+		}
+		series, err := parseQuery(b)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to query %q (%v)", q, err))
+			continue
+		}
+		ts.cfg.QueryResults = append(ts.cfg.QueryResults, QueryResult{Query: q, Series: series})
+		ts.cfg.Logger.Info("queried Prometheus", zap.String("query", q), zap.Int("series", series))
+		if series == 0 {
+			errs = append(errs, fmt.Sprintf("query %q returned no series", q))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) checkGrafana() (err error) {
+	queryFunc := func() {
+		args := []string{
+			ts.cfg.Client.Config().KubectlPath,
+			"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+			"--namespace=" + ts.cfg.Namespace,
+			"describe",
+			"svc",
+			grafanaServiceName,
+		}
+		argsCmd := strings.Join(args, " ")
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		cmdOut, err := exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("'kubectl describe svc' failed", zap.String("command", argsCmd), zap.Error(err))
+		} else {
+			out := string(cmdOut)
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n\"%s\" output:\n%s\n\n", argsCmd, out)
+		}
+	}
+
+	hostName, elbARN, elbName, err := client.WaitForServiceIngressHostname(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		grafanaServiceName,
+		ts.cfg.Stopc,
+		3*time.Minute,
+		ts.cfg.AccountID,
+		ts.cfg.Region,
+		client.WithQueryFunc(queryFunc),
+	)
+	if err != nil {
+		return err
+	}
+	elbURL := "http://" + hostName
+
+	ts.cfg.ELBARN = elbARN
+	ts.cfg.ELBName = elbName
+	ts.cfg.ELBURL = elbURL
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nNLB grafana ARN: %s\n", elbARN)
+	fmt.Fprintf(ts.cfg.LogWriter, "NLB grafana name: %s\n", elbName)
+	fmt.Fprintf(ts.cfg.LogWriter, "NLB grafana URL: %s\n\n", elbURL)
+
+	ts.cfg.Logger.Info("waiting before testing grafana Service")
+	time.Sleep(20 * time.Second)
+
+	healthChecked := false
+	retryStart := time.Now()
+	for time.Since(retryStart) < 5*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("grafana Service check aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		out, err := http.ReadInsecure(ts.cfg.Logger, ioutil.Discard, elbURL+"/api/health")
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to read NLB grafana Service; retrying", zap.Error(err))
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		httpOutput := string(out)
+		fmt.Fprintf(ts.cfg.LogWriter, "\nNLB grafana Service output:\n%s\n", httpOutput)
+
+		if strings.Contains(httpOutput, `"database"`) && strings.Contains(httpOutput, `"ok"`) {
+			ts.cfg.Logger.Info("read grafana Service; exiting", zap.String("host-name", hostName))
+			healthChecked = true
+			break
+		}
+
+		ts.cfg.Logger.Warn("unexpected grafana Service output; retrying")
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nNLB grafana URL: %s\n", elbURL)
+	fmt.Fprintf(ts.cfg.LogWriter, "Grafana UserName: %s\n", ts.cfg.GrafanaAdminUserName)
+	fmt.Fprintf(ts.cfg.LogWriter, "Grafana Password: %d characters\n\n", len(ts.cfg.GrafanaAdminPassword))
+
+	if !healthChecked {
+		return fmt.Errorf("NLB grafana %q did not return expected health output", elbURL)
+	}
+
+	return nil
+}
@@ -0,0 +1,710 @@
+// Package pv_reclaim cycles PersistentVolumeClaim creates and deletes
+// with both "Delete" and "Retain" reclaim policies, and checks with EC2
+// that the backing EBS volumes are actually deleted or retained,
+// to catch orphaned volumes leaked at the AWS level.
+// The EBS CSI driver must be installed (e.g., see "k8s-tester/csi-ebs").
+// ref. https://kubernetes.io/docs/concepts/storage/persistent-volumes/#reclaiming
+package pv_reclaim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	core_v1 "k8s.io/api/core/v1"
+	storage_v1 "k8s.io/api/storage/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	EC2API ec2iface.EC2API `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Cycles is the number of PersistentVolumeClaim create and delete cycles
+	// for each reclaim policy.
+	Cycles int `json:"cycles"`
+	// VolumeSize is the requested PersistentVolumeClaim storage size.
+	VolumeSize string `json:"volume_size"`
+	// VolumeType is the EBS volume type of the StorageClasses.
+	VolumeType string `json:"volume_type"`
+	// CycleTimeout is the timeout for each PersistentVolumeClaim to bind,
+	// and for its EBS volume to be deleted.
+	CycleTimeout       time.Duration `json:"cycle_timeout"`
+	CycleTimeoutString string        `json:"cycle_timeout_string" read-only:"true"`
+
+	// DeletedVolumeIDs is the list of EBS volumes deleted with the "Delete" reclaim policy.
+	DeletedVolumeIDs []string `json:"deleted_volume_ids" read-only:"true"`
+	// RetainedVolumeIDs is the list of EBS volumes retained with the "Retain" reclaim policy,
+	// and then deleted by the tester.
+	RetainedVolumeIDs []string `json:"retained_volume_ids" read-only:"true"`
+	// LeakedVolumeIDs is the list of EBS volumes not deleted as expected.
+	LeakedVolumeIDs []string `json:"leaked_volume_ids" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.Cycles == 0 {
+		cfg.Cycles = DefaultCycles
+	}
+	if cfg.VolumeSize == "" {
+		cfg.VolumeSize = DefaultVolumeSize
+	}
+	if _, err := resource.ParseQuantity(cfg.VolumeSize); err != nil {
+		return fmt.Errorf("invalid VolumeSize %q (%v)", cfg.VolumeSize, err)
+	}
+	if cfg.VolumeType == "" {
+		cfg.VolumeType = DefaultVolumeType
+	}
+	if cfg.CycleTimeout == time.Duration(0) {
+		cfg.CycleTimeout = DefaultCycleTimeout
+	}
+	cfg.CycleTimeoutString = cfg.CycleTimeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes int = 1
+	DefaultPartition        = "aws"
+	DefaultCycles       int = 3
+	DefaultVolumeSize       = "1Gi"
+	DefaultVolumeType       = "gp3"
+	DefaultCycleTimeout     = 10 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:       false,
+		Prompt:       false,
+		Partition:    DefaultPartition,
+		MinimumNodes: DefaultMinimumNodes,
+		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Cycles:       DefaultCycles,
+		VolumeSize:   DefaultVolumeSize,
+		VolumeType:   DefaultVolumeType,
+		CycleTimeout: DefaultCycleTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.EC2API = ec2.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	provisioner = "ebs.csi.aws.com"
+	// volumeTagKey tags the EBS volumes provisioned by this tester,
+	// to find leaked volumes regardless of the Kubernetes objects.
+	volumeTagKey = "k8s-tester-pv-reclaim"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	for _, policy := range []core_v1.PersistentVolumeReclaimPolicy{core_v1.PersistentVolumeReclaimDelete, core_v1.PersistentVolumeReclaimRetain} {
+		if err := ts.createStorageClass(policy); err != nil {
+			return err
+		}
+	}
+
+	ts.cfg.DeletedVolumeIDs, ts.cfg.RetainedVolumeIDs, ts.cfg.LeakedVolumeIDs = nil, nil, nil
+	for i := 0; i < ts.cfg.Cycles; i++ {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("cycles aborted")
+		default:
+		}
+		ts.cfg.Logger.Info("starting PersistentVolumeClaim cycle", zap.Int("cycle", i+1), zap.Int("cycles", ts.cfg.Cycles))
+		if err := ts.cycleDelete(i); err != nil {
+			return err
+		}
+		if err := ts.cycleRetain(i); err != nil {
+			return err
+		}
+	}
+
+	if err := ts.checkLeaks(); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("checked EBS volume reclaims",
+		zap.Strings("deleted", ts.cfg.DeletedVolumeIDs),
+		zap.Strings("retained", ts.cfg.RetainedVolumeIDs),
+	)
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	// PersistentVolumes are cluster-scoped, and retained ones outlive the namespace
+	if err := ts.deleteReleasedPVs(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	for _, policy := range []core_v1.PersistentVolumeReclaimPolicy{core_v1.PersistentVolumeReclaimDelete, core_v1.PersistentVolumeReclaimRetain} {
+		if err := ts.deleteStorageClass(policy); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// clean up any volume left behind by a failed run
+	volumeIDs, err := ts.listTaggedVolumes()
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, id := range volumeIDs {
+		if err := ts.deleteVolume(id); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) storageClassName(policy core_v1.PersistentVolumeReclaimPolicy) string {
+	return ts.cfg.Namespace + "-" + strings.ToLower(string(policy))
+}
+
+func (ts *tester) createStorageClass(policy core_v1.PersistentVolumeReclaimPolicy) error {
+	name := ts.storageClassName(policy)
+	ts.cfg.Logger.Info("creating StorageClass", zap.String("name", name), zap.String("reclaim-policy", string(policy)))
+	// binds without a consumer Pod, to provision on PVC creation
+	immediate := storage_v1.VolumeBindingImmediate
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		StorageV1().
+		StorageClasses().
+		Create(
+			ctx,
+			&storage_v1.StorageClass{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "storage.k8s.io/v1",
+					Kind:       "StorageClass",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: name,
+				},
+				Provisioner:       provisioner,
+				ReclaimPolicy:     &policy,
+				VolumeBindingMode: &immediate,
+				Parameters: map[string]string{
+					"type": ts.cfg.VolumeType,
+					// ref. https://github.com/kubernetes-sigs/aws-ebs-csi-driver/blob/master/docs/tagging.md
+					"tagSpecification_1": volumeTagKey + "=" + ts.cfg.Namespace,
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create StorageClass %q (%v)", name, err)
+	}
+
+	ts.cfg.Logger.Info("created StorageClass", zap.String("name", name))
+	return nil
+}
+
+func (ts *tester) deleteStorageClass(policy core_v1.PersistentVolumeReclaimPolicy) error {
+	name := ts.storageClassName(policy)
+	ts.cfg.Logger.Info("deleting StorageClass", zap.String("name", name))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		StorageV1().
+		StorageClasses().
+		Delete(ctx, name, meta_v1.DeleteOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete StorageClass %q (%v)", name, err)
+	}
+
+	ts.cfg.Logger.Info("deleted StorageClass", zap.String("name", name))
+	return nil
+}
+
+// cycleDelete creates and deletes a PVC with the "Delete" reclaim policy,
+// and expects both the PV and the EBS volume to be deleted.
+func (ts *tester) cycleDelete(idx int) error {
+	pvcName := fmt.Sprintf("delete-%d", idx)
+	pv, err := ts.createBoundPVC(pvcName, core_v1.PersistentVolumeReclaimDelete)
+	if err != nil {
+		return err
+	}
+	volumeID := pv.Spec.CSI.VolumeHandle
+
+	if err = ts.deletePVC(pvcName); err != nil {
+		return err
+	}
+	if err = ts.waitForPVDeleted(pv.Name); err != nil {
+		ts.cfg.LeakedVolumeIDs = append(ts.cfg.LeakedVolumeIDs, volumeID)
+		return err
+	}
+	if err = ts.waitForVolumeDeleted(volumeID); err != nil {
+		ts.cfg.LeakedVolumeIDs = append(ts.cfg.LeakedVolumeIDs, volumeID)
+		return fmt.Errorf("EBS volume %q leaked after its PersistentVolume %q was deleted (%v)", volumeID, pv.Name, err)
+	}
+	ts.cfg.DeletedVolumeIDs = append(ts.cfg.DeletedVolumeIDs, volumeID)
+	return nil
+}
+
+// cycleRetain creates and deletes a PVC with the "Retain" reclaim policy,
+// expects the PV to be released and the EBS volume to be kept,
+// and then deletes both.
+func (ts *tester) cycleRetain(idx int) error {
+	pvcName := fmt.Sprintf("retain-%d", idx)
+	pv, err := ts.createBoundPVC(pvcName, core_v1.PersistentVolumeReclaimRetain)
+	if err != nil {
+		return err
+	}
+	volumeID := pv.Spec.CSI.VolumeHandle
+
+	if err = ts.deletePVC(pvcName); err != nil {
+		return err
+	}
+	if err = ts.waitForPVReleased(pv.Name); err != nil {
+		return err
+	}
+	state, err := ts.volumeState(volumeID)
+	if err != nil {
+		return err
+	}
+	if state != ec2.VolumeStateAvailable {
+		return fmt.Errorf("EBS volume %q of retained PersistentVolume %q is %q, expected %q", volumeID, pv.Name, state, ec2.VolumeStateAvailable)
+	}
+	ts.cfg.Logger.Info("EBS volume retained", zap.String("pv-name", pv.Name), zap.String("volume-id", volumeID))
+
+	if err = ts.deletePV(pv.Name); err != nil {
+		return err
+	}
+	if err = ts.deleteVolume(volumeID); err != nil {
+		return err
+	}
+	ts.cfg.RetainedVolumeIDs = append(ts.cfg.RetainedVolumeIDs, volumeID)
+	return nil
+}
+
+// createBoundPVC creates a PVC and returns its PV once bound.
+func (ts *tester) createBoundPVC(name string, policy core_v1.PersistentVolumeReclaimPolicy) (*core_v1.PersistentVolume, error) {
+	storageClassName := ts.storageClassName(policy)
+	ts.cfg.Logger.Info("creating PersistentVolumeClaim", zap.String("name", name), zap.String("storage-class", storageClassName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		PersistentVolumeClaims(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.PersistentVolumeClaim{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "PersistentVolumeClaim",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: core_v1.PersistentVolumeClaimSpec{
+					AccessModes:      []core_v1.PersistentVolumeAccessMode{core_v1.ReadWriteOnce},
+					StorageClassName: &storageClassName,
+					Resources: core_v1.VolumeResourceRequirements{
+						Requests: core_v1.ResourceList{
+							core_v1.ResourceStorage: resource.MustParse(ts.cfg.VolumeSize),
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PersistentVolumeClaim %q (%v)", name, err)
+	}
+
+	start := time.Now()
+	for time.Since(start) < ts.cfg.CycleTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, errors.New("wait for PersistentVolumeClaim aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pvc, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			PersistentVolumeClaims(ts.cfg.Namespace).
+			Get(ctx, name, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get PersistentVolumeClaim; retrying", zap.Error(err))
+			continue
+		}
+		if pvc.Status.Phase != core_v1.ClaimBound {
+			ts.cfg.Logger.Info("PersistentVolumeClaim not bound yet", zap.String("name", name), zap.String("phase", string(pvc.Status.Phase)))
+			continue
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pv, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			PersistentVolumes().
+			Get(ctx, pvc.Spec.VolumeName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get PersistentVolume; retrying", zap.Error(err))
+			continue
+		}
+		if pv.Spec.CSI == nil {
+			return nil, fmt.Errorf("PersistentVolume %q is not provisioned by %q", pv.Name, provisioner)
+		}
+		ts.cfg.Logger.Info("PersistentVolumeClaim bound",
+			zap.String("name", name),
+			zap.String("pv-name", pv.Name),
+			zap.String("volume-id", pv.Spec.CSI.VolumeHandle),
+			zap.String("took", time.Since(start).String()),
+		)
+		return pv, nil
+	}
+	return nil, fmt.Errorf("PersistentVolumeClaim %q not bound within %v", name, ts.cfg.CycleTimeout)
+}
+
+func (ts *tester) deletePVC(name string) error {
+	ts.cfg.Logger.Info("deleting PersistentVolumeClaim", zap.String("name", name))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		PersistentVolumeClaims(ts.cfg.Namespace).
+		Delete(ctx, name, meta_v1.DeleteOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PersistentVolumeClaim %q (%v)", name, err)
+	}
+	return nil
+}
+
+func (ts *tester) deletePV(name string) error {
+	ts.cfg.Logger.Info("deleting PersistentVolume", zap.String("name", name))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		PersistentVolumes().
+		Delete(ctx, name, meta_v1.DeleteOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PersistentVolume %q (%v)", name, err)
+	}
+	return nil
+}
+
+// deleteReleasedPVs deletes the PersistentVolumes that were bound to this namespace.
+func (ts *tester) deleteReleasedPVs() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pvs, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		PersistentVolumes().
+		List(ctx, meta_v1.ListOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list PersistentVolumes (%v)", err)
+	}
+	for _, pv := range pvs.Items {
+		if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Namespace != ts.cfg.Namespace {
+			continue
+		}
+		if err = ts.deletePV(pv.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *tester) waitForPV(name string, desc string, cond func(pv *core_v1.PersistentVolume, notFound bool) bool) error {
+	start := time.Now()
+	for time.Since(start) < ts.cfg.CycleTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return fmt.Errorf("wait for PersistentVolume %s aborted", desc)
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		pv, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			PersistentVolumes().
+			Get(ctx, name, meta_v1.GetOptions{})
+		cancel()
+		notFound := k8s_errors.IsNotFound(err)
+		if err != nil && !notFound {
+			ts.cfg.Logger.Warn("failed to get PersistentVolume; retrying", zap.Error(err))
+			continue
+		}
+		if cond(pv, notFound) {
+			ts.cfg.Logger.Info("PersistentVolume "+desc, zap.String("name", name), zap.String("took", time.Since(start).String()))
+			return nil
+		}
+	}
+	return fmt.Errorf("PersistentVolume %q not %s within %v", name, desc, ts.cfg.CycleTimeout)
+}
+
+func (ts *tester) waitForPVDeleted(name string) error {
+	return ts.waitForPV(name, "deleted", func(_ *core_v1.PersistentVolume, notFound bool) bool {
+		return notFound
+	})
+}
+
+func (ts *tester) waitForPVReleased(name string) error {
+	return ts.waitForPV(name, "released", func(pv *core_v1.PersistentVolume, notFound bool) bool {
+		return !notFound && pv.Status.Phase == core_v1.VolumeReleased
+	})
+}
+
+// volumeState returns the EBS volume state,
+// or "deleted" if the volume is not found.
+func (ts *tester) volumeState(volumeID string) (string, error) {
+	out, err := ts.cfg.EC2API.DescribeVolumes(&ec2.DescribeVolumesInput{
+		VolumeIds: aws.StringSlice([]string{volumeID}),
+	})
+	if err != nil {
+		if ev, ok := err.(awserr.Error); ok && ev.Code() == "InvalidVolume.NotFound" {
+			return ec2.VolumeStateDeleted, nil
+		}
+		return "", fmt.Errorf("failed to describe EBS volume %q (%v)", volumeID, err)
+	}
+	if len(out.Volumes) == 0 {
+		return ec2.VolumeStateDeleted, nil
+	}
+	return aws.StringValue(out.Volumes[0].State), nil
+}
+
+func (ts *tester) waitForVolumeDeleted(volumeID string) error {
+	start := time.Now()
+	for time.Since(start) < ts.cfg.CycleTimeout {
+		state, err := ts.volumeState(volumeID)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe EBS volume; retrying", zap.Error(err))
+		} else {
+			ts.cfg.Logger.Info("described EBS volume", zap.String("volume-id", volumeID), zap.String("state", state))
+			if state == ec2.VolumeStateDeleted {
+				return nil
+			}
+		}
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for EBS volume deletion aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return fmt.Errorf("not deleted within %v", ts.cfg.CycleTimeout)
+}
+
+func (ts *tester) deleteVolume(volumeID string) error {
+	ts.cfg.Logger.Info("deleting EBS volume", zap.String("volume-id", volumeID))
+	_, err := ts.cfg.EC2API.DeleteVolume(&ec2.DeleteVolumeInput{
+		VolumeId: aws.String(volumeID),
+	})
+	if err != nil {
+		if ev, ok := err.(awserr.Error); ok && ev.Code() == "InvalidVolume.NotFound" {
+			return nil
+		}
+		return fmt.Errorf("failed to delete EBS volume %q (%v)", volumeID, err)
+	}
+	return ts.waitForVolumeDeleted(volumeID)
+}
+
+// listTaggedVolumes returns the EBS volumes provisioned by this tester
+// that are not deleted yet.
+func (ts *tester) listTaggedVolumes() (volumeIDs []string, err error) {
+	err = ts.cfg.EC2API.DescribeVolumesPages(
+		&ec2.DescribeVolumesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("tag:" + volumeTagKey),
+					Values: aws.StringSlice([]string{ts.cfg.Namespace}),
+				},
+			},
+		},
+		func(output *ec2.DescribeVolumesOutput, lastPage bool) bool {
+			for _, v := range output.Volumes {
+				if aws.StringValue(v.State) == ec2.VolumeStateDeleted {
+					continue
+				}
+				volumeIDs = append(volumeIDs, aws.StringValue(v.VolumeId))
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EBS volumes (%v)", err)
+	}
+	return volumeIDs, nil
+}
+
+// checkLeaks fails if any EBS volume provisioned by this tester still exists,
+// after all cycles cleaned up their volumes.
+func (ts *tester) checkLeaks() error {
+	volumeIDs, err := ts.listTaggedVolumes()
+	if err != nil {
+		return err
+	}
+	if len(volumeIDs) > 0 {
+		ts.cfg.LeakedVolumeIDs = append(ts.cfg.LeakedVolumeIDs, volumeIDs...)
+		return fmt.Errorf("found %d leaked EBS volumes %v", len(volumeIDs), volumeIDs)
+	}
+	return nil
+}
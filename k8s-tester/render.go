@@ -0,0 +1,213 @@
+package k8s_tester
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	cloudwatch_agent "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-agent"
+	"github.com/aws/aws-k8s-tester/k8s-tester/clusterloader"
+	"github.com/aws/aws-k8s-tester/k8s-tester/cni"
+	"github.com/aws/aws-k8s-tester/k8s-tester/configmaps"
+	"github.com/aws/aws-k8s-tester/k8s-tester/conformance"
+	csi_ebs "github.com/aws/aws-k8s-tester/k8s-tester/csi-ebs"
+	"github.com/aws/aws-k8s-tester/k8s-tester/csrs"
+	daemonset_rollout "github.com/aws/aws-k8s-tester/k8s-tester/daemonset-rollout"
+	"github.com/aws/aws-k8s-tester/k8s-tester/dns"
+	"github.com/aws/aws-k8s-tester/k8s-tester/falco"
+	"github.com/aws/aws-k8s-tester/k8s-tester/falcon"
+	fluent_bit "github.com/aws/aws-k8s-tester/k8s-tester/fluent-bit"
+	image_prepull "github.com/aws/aws-k8s-tester/k8s-tester/image-prepull"
+	jobs_echo "github.com/aws/aws-k8s-tester/k8s-tester/jobs-echo"
+	jobs_pi "github.com/aws/aws-k8s-tester/k8s-tester/jobs-pi"
+	kube_state_metrics "github.com/aws/aws-k8s-tester/k8s-tester/kube-state-metrics"
+	kubernetes_dashboard "github.com/aws/aws-k8s-tester/k8s-tester/kubernetes-dashboard"
+	"github.com/aws/aws-k8s-tester/k8s-tester/kueue"
+	metrics_server "github.com/aws/aws-k8s-tester/k8s-tester/metrics-server"
+	multi_container "github.com/aws/aws-k8s-tester/k8s-tester/multi-container"
+	network_policy "github.com/aws/aws-k8s-tester/k8s-tester/network-policy"
+	"github.com/aws/aws-k8s-tester/k8s-tester/neuron"
+	nlb_guestbook "github.com/aws/aws-k8s-tester/k8s-tester/nlb-guestbook"
+	nlb_hello_world "github.com/aws/aws-k8s-tester/k8s-tester/nlb-hello-world"
+	node_problem_detector "github.com/aws/aws-k8s-tester/k8s-tester/node-problem-detector"
+	nvidia_gpu "github.com/aws/aws-k8s-tester/k8s-tester/nvidia-gpu"
+	"github.com/aws/aws-k8s-tester/k8s-tester/pdb"
+	php_apache "github.com/aws/aws-k8s-tester/k8s-tester/php-apache"
+	probe_storm "github.com/aws/aws-k8s-tester/k8s-tester/probe-storm"
+	prometheus_grafana "github.com/aws/aws-k8s-tester/k8s-tester/prometheus-grafana"
+	"github.com/aws/aws-k8s-tester/k8s-tester/secrets"
+	"github.com/aws/aws-k8s-tester/k8s-tester/stress"
+	stress_in_cluster "github.com/aws/aws-k8s-tester/k8s-tester/stress/in-cluster"
+	"github.com/aws/aws-k8s-tester/k8s-tester/sysctl"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/windows"
+	"github.com/aws/aws-k8s-tester/k8s-tester/wordpress"
+)
+
+// renderers returns the enabled add-ons that implement "tester.Renderer",
+// in the same order as "createTesters", and the status keys of the add-ons
+// that share a name. The add-ons whose "New" calls AWS are created with
+// "NewRenderer", without the AWS clients.
+func (cfg *Config) renderers() (rs []k8s_tester.Tester, keys map[k8s_tester.Tester]string) {
+	keys = make(map[k8s_tester.Tester]string)
+	if cfg.AddOnCloudwatchAgent != nil && cfg.AddOnCloudwatchAgent.Enable {
+		rs = append(rs, cloudwatch_agent.NewRenderer(cfg.AddOnCloudwatchAgent))
+	}
+	if cfg.AddOnFluentBit != nil && cfg.AddOnFluentBit.Enable {
+		rs = append(rs, fluent_bit.NewRenderer(cfg.AddOnFluentBit))
+	}
+	if cfg.AddOnMetricsServer != nil && cfg.AddOnMetricsServer.Enable {
+		rs = append(rs, metrics_server.New(cfg.AddOnMetricsServer))
+	}
+	if cfg.AddOnCNI != nil && cfg.AddOnCNI.Enable {
+		rs = append(rs, cni.New(cfg.AddOnCNI))
+	}
+	if cfg.AddOnConformance != nil && cfg.AddOnConformance.Enable {
+		rs = append(rs, conformance.New(cfg.AddOnConformance))
+	}
+	if cfg.AddOnCSIEBS != nil && cfg.AddOnCSIEBS.Enable {
+		rs = append(rs, csi_ebs.New(cfg.AddOnCSIEBS))
+	}
+	if cfg.AddOnKubernetesDashboard != nil && cfg.AddOnKubernetesDashboard.Enable {
+		rs = append(rs, kubernetes_dashboard.New(cfg.AddOnKubernetesDashboard))
+	}
+	if cfg.AddOnPHPApache != nil && cfg.AddOnPHPApache.Enable {
+		rs = append(rs, php_apache.NewRenderer(cfg.AddOnPHPApache))
+	}
+	if cfg.AddOnNLBGuestbook != nil && cfg.AddOnNLBGuestbook.Enable {
+		rs = append(rs, nlb_guestbook.NewRenderer(cfg.AddOnNLBGuestbook))
+	}
+	if cfg.AddOnNLBHelloWorld != nil && cfg.AddOnNLBHelloWorld.Enable {
+		rs = append(rs, nlb_hello_world.NewRenderer(cfg.AddOnNLBHelloWorld))
+	}
+	if cfg.AddOnWordpress != nil && cfg.AddOnWordpress.Enable {
+		rs = append(rs, wordpress.NewRenderer(cfg.AddOnWordpress))
+	}
+	if cfg.AddOnJobsPi != nil && cfg.AddOnJobsPi.Enable {
+		rs = append(rs, jobs_pi.New(cfg.AddOnJobsPi))
+	}
+	if cfg.AddOnJobsEcho != nil && cfg.AddOnJobsEcho.Enable {
+		rs = append(rs, jobs_echo.NewRenderer(cfg.AddOnJobsEcho))
+	}
+	if cfg.AddOnCronJobsEcho != nil && cfg.AddOnCronJobsEcho.Enable {
+		cur := jobs_echo.NewRenderer(cfg.AddOnCronJobsEcho)
+		rs = append(rs, cur)
+		keys[cur] = cronJobsEchoStatusKey
+	}
+	if cfg.AddOnCSRs != nil && cfg.AddOnCSRs.Enable {
+		rs = append(rs, csrs.New(cfg.AddOnCSRs))
+	}
+	if cfg.AddOnConfigmaps != nil && cfg.AddOnConfigmaps.Enable {
+		rs = append(rs, configmaps.New(cfg.AddOnConfigmaps))
+	}
+	if cfg.AddOnSecrets != nil && cfg.AddOnSecrets.Enable {
+		rs = append(rs, secrets.New(cfg.AddOnSecrets))
+	}
+	if cfg.AddOnClusterloader != nil && cfg.AddOnClusterloader.Enable {
+		rs = append(rs, clusterloader.New(cfg.AddOnClusterloader))
+	}
+	if cfg.AddOnStress != nil && cfg.AddOnStress.Enable {
+		rs = append(rs, stress.NewRenderer(cfg.AddOnStress))
+	}
+	if cfg.AddOnStressInCluster != nil && cfg.AddOnStressInCluster.Enable {
+		rs = append(rs, stress_in_cluster.NewRenderer(cfg.AddOnStressInCluster))
+	}
+	if cfg.AddOnFalco != nil && cfg.AddOnFalco.Enable {
+		rs = append(rs, falco.New(cfg.AddOnFalco))
+	}
+	if cfg.AddOnFalcon != nil && cfg.AddOnFalcon.Enable {
+		rs = append(rs, falcon.New(cfg.AddOnFalcon))
+	}
+	if cfg.AddOnImagePrepull != nil && cfg.AddOnImagePrepull.Enable {
+		rs = append(rs, image_prepull.New(cfg.AddOnImagePrepull))
+	}
+	if cfg.AddOnSysctl != nil && cfg.AddOnSysctl.Enable {
+		rs = append(rs, sysctl.New(cfg.AddOnSysctl))
+	}
+	if cfg.AddOnNetworkPolicy != nil && cfg.AddOnNetworkPolicy.Enable {
+		rs = append(rs, network_policy.New(cfg.AddOnNetworkPolicy))
+	}
+	if cfg.AddOnDNS != nil && cfg.AddOnDNS.Enable {
+		rs = append(rs, dns.New(cfg.AddOnDNS))
+	}
+	if cfg.AddOnPrometheusGrafana != nil && cfg.AddOnPrometheusGrafana.Enable {
+		rs = append(rs, prometheus_grafana.New(cfg.AddOnPrometheusGrafana))
+	}
+	if cfg.AddOnKubeStateMetrics != nil && cfg.AddOnKubeStateMetrics.Enable {
+		rs = append(rs, kube_state_metrics.New(cfg.AddOnKubeStateMetrics))
+	}
+	if cfg.AddOnNodeProblemDetector != nil && cfg.AddOnNodeProblemDetector.Enable {
+		rs = append(rs, node_problem_detector.New(cfg.AddOnNodeProblemDetector))
+	}
+	if cfg.AddOnPDB != nil && cfg.AddOnPDB.Enable {
+		rs = append(rs, pdb.New(cfg.AddOnPDB))
+	}
+	if cfg.AddOnDaemonSetRollout != nil && cfg.AddOnDaemonSetRollout.Enable {
+		rs = append(rs, daemonset_rollout.New(cfg.AddOnDaemonSetRollout))
+	}
+	if cfg.AddOnWindows != nil && cfg.AddOnWindows.Enable {
+		rs = append(rs, windows.New(cfg.AddOnWindows))
+	}
+	if cfg.AddOnNVIDIAGPU != nil && cfg.AddOnNVIDIAGPU.Enable {
+		rs = append(rs, nvidia_gpu.New(cfg.AddOnNVIDIAGPU))
+	}
+	if cfg.AddOnKueue != nil && cfg.AddOnKueue.Enable {
+		rs = append(rs, kueue.New(cfg.AddOnKueue))
+	}
+	if cfg.AddOnNeuron != nil && cfg.AddOnNeuron.Enable {
+		rs = append(rs, neuron.New(cfg.AddOnNeuron))
+	}
+	if cfg.AddOnMultiContainer != nil && cfg.AddOnMultiContainer.Enable {
+		rs = append(rs, multi_container.New(cfg.AddOnMultiContainer))
+	}
+	if cfg.AddOnProbeStorm != nil && cfg.AddOnProbeStorm.Enable {
+		rs = append(rs, probe_storm.New(cfg.AddOnProbeStorm))
+	}
+	return rs, keys
+}
+
+// Render writes the manifests and the Helm values of the enabled add-ons,
+// without a cluster. If "outputDir" is empty, it writes all add-ons to "w".
+// Otherwise, it writes each add-on to "<outputDir>/<name>.yaml".
+// It returns the names of the rendered add-ons.
+func (cfg *Config) Render(w io.Writer, outputDir string) (names []string, err error) {
+	if outputDir != "" {
+		if err = os.MkdirAll(outputDir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	rs, keys := cfg.renderers()
+	for _, cur := range rs {
+		r, ok := cur.(k8s_tester.Renderer)
+		if !ok {
+			continue
+		}
+		name := cur.Name()
+		if key, ok := keys[cur]; ok {
+			name = key
+		}
+		if outputDir == "" {
+			if _, err = fmt.Fprintf(w, "# %s\n", name); err != nil {
+				return nil, err
+			}
+			if err = r.Render(w); err != nil {
+				return nil, fmt.Errorf("failed to render %q (%v)", name, err)
+			}
+			names = append(names, name)
+			continue
+		}
+
+		f, err := os.Create(filepath.Join(outputDir, name+".yaml"))
+		if err != nil {
+			return nil, err
+		}
+		err = r.Render(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %q (%v)", name, err)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
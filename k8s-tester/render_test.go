@@ -0,0 +1,58 @@
+package k8s_tester
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	cfg := NewDefault()
+	cfg.AddOnSysctl.Enable = true
+	cfg.AddOnSysctl.Namespace = "test-sysctl"
+	cfg.AddOnKubeStateMetrics.Enable = true
+	cfg.AddOnKubeStateMetrics.Namespace = "test-kube-state-metrics"
+	if err := cfg.AddOnSysctl.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.AddOnKubeStateMetrics.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	names, err := cfg.Render(&buf, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"sysctl", "kube-state-metrics"}) {
+		t.Fatalf("unexpected rendered add-ons %q", names)
+	}
+	out := buf.String()
+	for _, s := range []string{"# sysctl\n", "kind: DaemonSet", "namespace: test-sysctl", "# kube-state-metrics\n", "kind: Job", "namespace: test-kube-state-metrics"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("rendered output missing %q\n%s", s, out)
+		}
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "k8s-tester-render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err = cfg.Render(nil, dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name+".yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, string(b)) {
+			t.Fatalf("%q output differs from the stdout output", name)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package k8s_tester
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// testResult is the result of a single tester "Apply".
+type testResult struct {
+	name     string
+	took     time.Duration
+	err      error
+	skipped  bool
+	skipNote string
+}
+
+// ref. https://github.com/windyroad/JUnit-Schema/blob/master/JUnit.xsd
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// createJUnitReport returns the JUnit XML report for the test results,
+// with the run seed as a suite property.
+func createJUnitReport(suiteName string, started time.Time, seed int64, results []testResult) ([]byte, error) {
+	suite := junitTestSuite{
+		Name:       suiteName,
+		Tests:      len(results),
+		Timestamp:  started.UTC().Format(time.RFC3339),
+		Properties: []junitProperty{{Name: "seed", Value: fmt.Sprintf("%d", seed)}},
+		TestCases:  make([]junitTestCase, 0, len(results)),
+	}
+	var total time.Duration
+	for _, rs := range results {
+		total += rs.took
+		tc := junitTestCase{
+			Classname: suiteName,
+			Name:      rs.name,
+			Time:      seconds(rs.took),
+		}
+		switch {
+		case rs.skipped:
+			suite.Skipped++
+			tc.Skipped = &junitSkipped{Message: rs.skipNote}
+		case rs.err != nil:
+			suite.Failures++
+			tc.Failure = &junitFailure{Message: "Apply failed", Contents: rs.err.Error()}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Time = seconds(total)
+
+	d, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(d, '\n')...), nil
+}
+
+// createTAPReport returns the TAP (Test Anything Protocol) report for the test results.
+// ref. https://testanything.org/tap-version-13-specification.html
+func createTAPReport(seed int64, results []testResult) []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("TAP version 13\n")
+	buf.WriteString(fmt.Sprintf("1..%d\n", len(results)))
+	buf.WriteString(fmt.Sprintf("# seed %d\n", seed))
+	for idx, rs := range results {
+		switch {
+		case rs.skipped:
+			buf.WriteString(fmt.Sprintf("ok %d - %s # SKIP %s\n", idx+1, rs.name, rs.skipNote))
+		case rs.err != nil:
+			buf.WriteString(fmt.Sprintf("not ok %d - %s\n", idx+1, rs.name))
+			buf.WriteString("  ---\n")
+			buf.WriteString(fmt.Sprintf("  message: %q\n", rs.err.Error()))
+			buf.WriteString(fmt.Sprintf("  duration_ms: %d\n", rs.took.Milliseconds()))
+			buf.WriteString("  ...\n")
+		default:
+			buf.WriteString(fmt.Sprintf("ok %d - %s # time=%dms\n", idx+1, rs.name, rs.took.Milliseconds()))
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeReport(p string, d []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, d, 0600)
+}
+
+// writeReports writes the JUnit XML, TAP, results JSON, and HTML reports
+// of the test results to the configured paths.
+func (ts *tester) writeReports() (err error) {
+	var errs []string
+	if ts.cfg.ReportJUnitPath != "" {
+		var d []byte
+		d, err = createJUnitReport(ts.cfg.ClusterName, ts.started, ts.cfg.Seed, ts.results)
+		if err == nil {
+			err = writeReport(ts.cfg.ReportJUnitPath, d)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to write JUnit report %q (%v)", ts.cfg.ReportJUnitPath, err))
+		}
+	}
+	if ts.cfg.ReportTAPPath != "" {
+		if err = writeReport(ts.cfg.ReportTAPPath, createTAPReport(ts.cfg.Seed, ts.results)); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to write TAP report %q (%v)", ts.cfg.ReportTAPPath, err))
+		}
+	}
+	if ts.cfg.ReportResultsPath != "" || ts.cfg.ReportHTMLPath != "" {
+		rs := ts.createResults()
+		if ts.cfg.ReportResultsPath != "" {
+			var d []byte
+			d, err = json.MarshalIndent(rs, "", "  ")
+			if err == nil {
+				err = writeReport(ts.cfg.ReportResultsPath, d)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to write results %q (%v)", ts.cfg.ReportResultsPath, err))
+			}
+		}
+		if ts.cfg.ReportHTMLPath != "" {
+			var d []byte
+			d, err = createHTMLReport(rs)
+			if err == nil {
+				err = writeReport(ts.cfg.ReportHTMLPath, d)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to write HTML report %q (%v)", ts.cfg.ReportHTMLPath, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
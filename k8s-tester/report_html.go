@@ -0,0 +1,324 @@
+package k8s_tester
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	utils_s3 "github.com/aws/aws-k8s-tester/utils/aws/s3"
+	"github.com/aws/aws-k8s-tester/utils/latency"
+)
+
+const (
+	// ResultPassed is the add-on result that applied successfully.
+	ResultPassed = "passed"
+	// ResultFailed is the add-on result that failed to apply.
+	ResultFailed = "failed"
+	// ResultSkipped is the add-on result that was not applied.
+	ResultSkipped = "skipped"
+)
+
+// Results is the "apply" results of all testers, written to "ReportResultsPath"
+// in JSON and rendered to the HTML report.
+type Results struct {
+	ClusterName string    `json:"cluster_name"`
+	RunID       string    `json:"run_id"`
+	Seed        int64     `json:"seed"`
+	Started     time.Time `json:"started"`
+	Finished    time.Time `json:"finished"`
+
+	AddOns    []AddOnResult   `json:"add_ons"`
+	Latencies []LatencyResult `json:"latencies"`
+	Artifacts []ArtifactLink  `json:"artifacts"`
+}
+
+// AddOnResult is the "apply" result of a tester.
+type AddOnResult struct {
+	Name string `json:"name"`
+	// Result is either "passed", "failed", or "skipped".
+	Result   string        `json:"result"`
+	Took     time.Duration `json:"took"`
+	Error    string        `json:"error,omitempty"`
+	SkipNote string        `json:"skip_note,omitempty"`
+	// APICalls is the number of the Kubernetes API calls made by the tester.
+	APICalls int64 `json:"api_calls"`
+}
+
+// LatencyResult is a latency summary of a tester, with the histogram for the chart.
+type LatencyResult struct {
+	AddOn   string          `json:"add_on"`
+	Name    string          `json:"name"`
+	Summary latency.Summary `json:"summary"`
+}
+
+// ArtifactLink is a run artifact, linked relative to the HTML report.
+type ArtifactLink struct {
+	Component string `json:"component"`
+	Name      string `json:"name"`
+	Href      string `json:"href"`
+}
+
+// LoadResults loads the results JSON.
+func LoadResults(p string) (*Results, error) {
+	d, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	rs := new(Results)
+	if err = json.Unmarshal(d, rs); err != nil {
+		return nil, fmt.Errorf("failed to parse results %q (%v)", p, err)
+	}
+	return rs, nil
+}
+
+// createResults returns the results of the "apply" so far.
+func (ts *tester) createResults() *Results {
+	rs := &Results{
+		ClusterName: ts.cfg.ClusterName,
+		RunID:       ts.cfg.RunID,
+		Seed:        ts.cfg.Seed,
+		Started:     ts.started,
+		Finished:    time.Now(),
+		AddOns:      make([]AddOnResult, 0, len(ts.results)),
+		Latencies:   ts.cfg.latencyResults(),
+	}
+	for _, res := range ts.results {
+		ar := AddOnResult{Name: res.name, Result: ResultPassed, Took: res.took}
+		switch {
+		case res.skipped:
+			ar.Result, ar.SkipNote = ResultSkipped, res.skipNote
+		case res.err != nil:
+			ar.Result, ar.Error = ResultFailed, res.err.Error()
+		}
+		for _, v := range ts.cfg.APICalls[res.name] {
+			ar.APICalls += v
+		}
+		rs.AddOns = append(rs.AddOns, ar)
+	}
+
+	s3Upload := ts.cfg.ArtifactsS3 != nil && ts.cfg.ArtifactsS3.Enable
+	componentLayout := s3Upload && ts.cfg.ArtifactsS3.ComponentLayout
+	paths := ts.artifactPaths()
+	components := make([]string, 0, len(paths))
+	for c := range paths {
+		components = append(components, c)
+	}
+	sort.Strings(components)
+	for _, c := range components {
+		for _, p := range paths[c] {
+			if p == "" || p == ts.cfg.ReportHTMLPath {
+				continue
+			}
+			rs.Artifacts = append(rs.Artifacts, ArtifactLink{
+				Component: c,
+				Name:      filepath.Base(p),
+				Href:      artifactHref(s3Upload, componentLayout, c, p, ts.cfg.ReportHTMLPath),
+			})
+		}
+	}
+	return rs
+}
+
+// artifactHref returns the link to the artifact relative to the HTML report.
+// If the artifacts are uploaded to S3, the link follows the S3 layout
+// ("[RUN_PREFIX]/[NAME]", or "[RUN_PREFIX]/[COMPONENT]/[NAME]" with the
+// component layout), so that the report works when browsed from the bucket.
+// Otherwise, the link is relative to the local report directory.
+func artifactHref(s3Upload bool, componentLayout bool, component string, p string, reportPath string) string {
+	name := filepath.Base(p)
+	if s3Upload {
+		if !componentLayout || component == utils_s3.ComponentReports {
+			return name
+		}
+		return path.Join("..", component, name)
+	}
+	rel, err := filepath.Rel(filepath.Dir(reportPath), p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// latencyResults returns the latency summaries of the enabled testers.
+func (cfg *Config) latencyResults() (rs []LatencyResult) {
+	add := func(addOn string, name string, s latency.Summary) {
+		if len(s.Histogram) == 0 && s.P99 == 0 {
+			return
+		}
+		rs = append(rs, LatencyResult{AddOn: addOn, Name: name, Summary: s})
+	}
+	if cfg.AddOnStress != nil && cfg.AddOnStress.Enable {
+		add("stress", "writes", cfg.AddOnStress.LatencySummaryWrites)
+		add("stress", "gets", cfg.AddOnStress.LatencySummaryGets)
+		add("stress", "range-gets", cfg.AddOnStress.LatencySummaryRangeGets)
+	}
+	if cfg.AddOnConfigmaps != nil && cfg.AddOnConfigmaps.Enable {
+		add("configmaps", "writes", cfg.AddOnConfigmaps.LatencySummary)
+		add("configmaps", "watch", cfg.AddOnConfigmaps.WatchLatencySummary)
+	}
+	if cfg.AddOnSecrets != nil && cfg.AddOnSecrets.Enable {
+		add("secrets", "writes", cfg.AddOnSecrets.LatencySummary)
+		add("secrets", "watch", cfg.AddOnSecrets.WatchLatencySummary)
+	}
+	if cfg.AddOnCSRs != nil && cfg.AddOnCSRs.Enable {
+		add("csrs", "writes", cfg.AddOnCSRs.LatencySummary)
+	}
+	if cfg.AddOnMetricsServer != nil && cfg.AddOnMetricsServer.Enable {
+		add("metrics-server", "api", cfg.AddOnMetricsServer.APILatency)
+	}
+	if cfg.AddOnImagePrepull != nil && cfg.AddOnImagePrepull.Enable {
+		add("image-prepull", "warm-up", cfg.AddOnImagePrepull.WarmUpLatencySummary)
+		add("image-prepull", "warm-start", cfg.AddOnImagePrepull.WarmStartLatencySummary)
+	}
+	if cfg.AddOnEndpointSlices != nil && cfg.AddOnEndpointSlices.Enable {
+		add("endpointslices", "propagation", cfg.AddOnEndpointSlices.Result.PropagationLatency)
+	}
+	return rs
+}
+
+const (
+	chartWidth  = 640
+	chartHeight = 160
+)
+
+// chartBar is a histogram bucket bar of the latency chart.
+type chartBar struct {
+	X, Y, Width, Height float64
+	Title               string
+}
+
+// histogramBars returns the bars of the histogram buckets, scaled to the chart.
+func histogramBars(buckets latency.HistogramBuckets) []chartBar {
+	if len(buckets) == 0 {
+		return nil
+	}
+	var max uint64
+	for _, b := range buckets {
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+	width := float64(chartWidth) / float64(len(buckets))
+	bars := make([]chartBar, 0, len(buckets))
+	for i, b := range buckets {
+		h := 0.0
+		if max > 0 {
+			h = float64(b.Count) / float64(max) * chartHeight
+		}
+		bars = append(bars, chartBar{
+			X:      float64(i) * width,
+			Y:      chartHeight - h,
+			Width:  width * 0.9,
+			Height: h,
+			Title:  fmt.Sprintf("[%g, %g) %s: %d", b.LowerBound, b.UpperBound, b.Scale, b.Count),
+		})
+	}
+	return bars
+}
+
+// WriteHTML renders the results to a self-contained HTML report, with the
+// styles and the latency charts (SVG) inlined, so that it can be viewed
+// without the network access.
+func (rs *Results) WriteHTML(w io.Writer) error {
+	counts := map[string]int{}
+	var took time.Duration
+	for _, ar := range rs.AddOns {
+		counts[ar.Result]++
+		took += ar.Took
+	}
+	return htmlReportTemplate.Execute(w, struct {
+		*Results
+		Counts map[string]int
+		Took   time.Duration
+	}{rs, counts, took})
+}
+
+// createHTMLReport returns the HTML report of the results.
+func createHTMLReport(rs *Results) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := rs.WriteHTML(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bars":        histogramBars,
+	"seconds":     seconds,
+	"timestamp":   func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"chartWidth":  func() int { return chartWidth },
+	"chartHeight": func() int { return chartHeight },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>k8s-tester {{.ClusterName}} {{.RunID}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+td.num { text-align: right; }
+.passed { color: #1a7f37; font-weight: bold; }
+.failed { color: #cf222e; font-weight: bold; }
+.skipped { color: #9a6700; font-weight: bold; }
+pre { margin: 0; white-space: pre-wrap; max-width: 60em; }
+svg rect { fill: #4a7bd0; }
+</style>
+</head>
+<body>
+<h1>k8s-tester report</h1>
+<table>
+<tr><th>Cluster</th><td>{{.ClusterName}}</td></tr>
+<tr><th>Run ID</th><td>{{.RunID}}</td></tr>
+<tr><th>Seed</th><td>{{.Seed}}</td></tr>
+<tr><th>Started</th><td>{{timestamp .Started}}</td></tr>
+<tr><th>Finished</th><td>{{timestamp .Finished}}</td></tr>
+<tr><th>Add-ons</th><td><span class="passed">{{index .Counts "passed"}} passed</span>, <span class="failed">{{index .Counts "failed"}} failed</span>, <span class="skipped">{{index .Counts "skipped"}} skipped</span> ({{seconds .Took}}s)</td></tr>
+</table>
+
+<h2>Add-ons</h2>
+<table>
+<tr><th>Add-on</th><th>Result</th><th>Duration (s)</th><th>API calls</th><th>Details</th></tr>
+{{- range .AddOns}}
+<tr><td>{{.Name}}</td><td class="{{.Result}}">{{.Result}}</td><td class="num">{{seconds .Took}}</td><td class="num">{{.APICalls}}</td><td>{{if .Error}}<pre>{{.Error}}</pre>{{else if .SkipNote}}{{.SkipNote}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- if .Latencies}}
+
+<h2>Latencies</h2>
+{{- range .Latencies}}
+<h3>{{.AddOn}} {{.Name}}</h3>
+<table>
+<tr><th>Success</th><th>Failure</th><th>p50</th><th>p90</th><th>p99</th><th>p99.9</th><th>p99.99</th></tr>
+<tr><td class="num">{{.Summary.SuccessTotal}}</td><td class="num">{{.Summary.FailureTotal}}</td><td>{{.Summary.P50}}</td><td>{{.Summary.P90}}</td><td>{{.Summary.P99}}</td><td>{{.Summary.P999}}</td><td>{{.Summary.P9999}}</td></tr>
+</table>
+{{- with bars .Summary.Histogram}}
+<svg width="{{chartWidth}}" height="{{chartHeight}}" viewBox="0 0 {{chartWidth}} {{chartHeight}}" xmlns="http://www.w3.org/2000/svg">
+{{- range .}}
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}"><title>{{.Title}}</title></rect>
+{{- end}}
+</svg>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Artifacts}}
+
+<h2>Artifacts</h2>
+<table>
+<tr><th>Component</th><th>Artifact</th></tr>
+{{- range .Artifacts}}
+<tr><td>{{.Component}}</td><td><a href="{{.Href}}">{{.Name}}</a></td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,175 @@
+package k8s_tester
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	status_reporter "github.com/aws/aws-k8s-tester/k8s-tester/status-reporter"
+	"github.com/aws/aws-k8s-tester/utils/latency"
+)
+
+func TestReport(t *testing.T) {
+	results := []testResult{
+		{name: "jobs-pi", took: 3 * time.Second},
+		{name: "csrs", took: time.Second, err: errors.New("hello")},
+		{name: "secrets", skipped: true, skipNote: "not run due to \"csrs\" failure"},
+	}
+
+	d, err := createJUnitReport("test-cluster", time.Now(), 42, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err = xml.Unmarshal(d, &suites); err != nil {
+		t.Fatal(err)
+	}
+	if len(suites.Suites) != 1 {
+		t.Fatalf("unexpected suites %d", len(suites.Suites))
+	}
+	suite := suites.Suites[0]
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 {
+		t.Fatalf("unexpected suite %+v", suite)
+	}
+	if suite.Time != "4.000" {
+		t.Fatalf("unexpected suite time %q", suite.Time)
+	}
+	if len(suite.Properties) != 1 || suite.Properties[0].Name != "seed" || suite.Properties[0].Value != "42" {
+		t.Fatalf("unexpected suite properties %+v", suite.Properties)
+	}
+	if suite.TestCases[1].Failure == nil || suite.TestCases[1].Failure.Contents != "hello" {
+		t.Fatalf("unexpected test case %+v", suite.TestCases[1])
+	}
+	if suite.TestCases[2].Skipped == nil {
+		t.Fatalf("unexpected test case %+v", suite.TestCases[2])
+	}
+
+	tap := string(createTAPReport(42, results))
+	for _, line := range []string{
+		"TAP version 13\n",
+		"1..3\n",
+		"# seed 42\n",
+		"ok 1 - jobs-pi # time=3000ms\n",
+		"not ok 2 - csrs\n",
+		"ok 3 - secrets # SKIP not run due to \"csrs\" failure\n",
+	} {
+		if !strings.Contains(tap, line) {
+			t.Fatalf("expected %q in TAP report:\n%s", line, tap)
+		}
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	rs := &Results{
+		ClusterName: "test-cluster",
+		RunID:       "run-1",
+		Seed:        42,
+		AddOns: []AddOnResult{
+			{Name: "jobs-pi", Result: ResultPassed, Took: 3 * time.Second, APICalls: 10},
+			{Name: "csrs", Result: ResultFailed, Took: time.Second, Error: "<hello>"},
+			{Name: "secrets", Result: ResultSkipped, SkipNote: "not run due to \"csrs\" failure"},
+		},
+		Latencies: []LatencyResult{
+			{
+				AddOn: "configmaps",
+				Name:  "writes",
+				Summary: latency.Summary{
+					SuccessTotal: 3,
+					Histogram: latency.HistogramBuckets{
+						{Scale: "milliseconds", LowerBound: 0, UpperBound: 10, Count: 1},
+						{Scale: "milliseconds", LowerBound: 10, UpperBound: 20, Count: 2},
+					},
+					P99: 15 * time.Millisecond,
+				},
+			},
+		},
+		Artifacts: []ArtifactLink{
+			{Component: "logs", Name: "k8s-tester.log", Href: "../logs/k8s-tester.log"},
+		},
+	}
+	d, err := createHTMLReport(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(d)
+	for _, s := range []string{
+		"test-cluster",
+		"1 passed",
+		"1 failed",
+		"1 skipped",
+		`<td class="failed">failed</td>`,
+		"&lt;hello&gt;",
+		"configmaps",
+		"<svg",
+		"<rect",
+		`href="../logs/k8s-tester.log"`,
+	} {
+		if !strings.Contains(html, s) {
+			t.Fatalf("expected %q in HTML report:\n%s", s, html)
+		}
+	}
+
+	bars := histogramBars(rs.Latencies[0].Summary.Histogram)
+	if len(bars) != 2 {
+		t.Fatalf("unexpected bars %+v", bars)
+	}
+	if bars[1].Height != chartHeight || bars[1].Y != 0 || bars[0].Height != chartHeight/2 {
+		t.Fatalf("unexpected bars %+v", bars)
+	}
+	if bars[1].X != chartWidth/2 {
+		t.Fatalf("unexpected bar x %v", bars[1].X)
+	}
+}
+
+func TestArtifactHref(t *testing.T) {
+	tests := []struct {
+		s3Upload        bool
+		componentLayout bool
+		component       string
+		p               string
+		href            string
+	}{
+		{false, false, "logs", "/tmp/run/k8s-tester.log", "k8s-tester.log"},
+		{false, false, "results", "/tmp/run/results/stress.json", "results/stress.json"},
+		{false, false, "config", "/tmp/other/config.yaml", "../other/config.yaml"},
+		{true, false, "logs", "/tmp/run/k8s-tester.log", "k8s-tester.log"},
+		{true, true, "logs", "/tmp/run/k8s-tester.log", "../logs/k8s-tester.log"},
+		{true, true, "reports", "/tmp/run/junit.xml", "junit.xml"},
+	}
+	for i, tt := range tests {
+		href := artifactHref(tt.s3Upload, tt.componentLayout, tt.component, tt.p, "/tmp/run/report.html")
+		if href != tt.href {
+			t.Fatalf("#%d: expected %q, got %q", i, tt.href, href)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	st := createStatus("test-cluster", nil, 3, false, nil)
+	if st.State != status_reporter.StatePending || st.Description != `applying 3 add-ons on "test-cluster"` {
+		t.Fatalf("unexpected status %+v", st)
+	}
+
+	results := []testResult{
+		{name: "jobs-pi", took: 3 * time.Second},
+		{name: "csrs", took: time.Second, err: errors.New("hello | world")},
+		{name: "secrets", skipped: true, skipNote: "not run due to \"csrs\" failure"},
+	}
+	st = createStatus("test-cluster", results, 3, true, errors.New("hello | world"))
+	if st.State != status_reporter.StateFailure {
+		t.Fatalf("unexpected state %q", st.State)
+	}
+	if st.Description != "1 passed, 1 failed, 1 skipped (4s)" {
+		t.Fatalf("unexpected description %q", st.Description)
+	}
+	if !strings.Contains(st.Summary, `| csrs | failed: hello \| world | 1s |`) {
+		t.Fatalf("unexpected summary:\n%s", st.Summary)
+	}
+
+	st = createStatus("test-cluster", results[:1], 1, true, errors.New("timed out"))
+	if st.State != status_reporter.StateFailure || st.Description != "1 passed, 0 failed, 0 skipped (3s), timed out" {
+		t.Fatalf("unexpected status %+v", st)
+	}
+}
@@ -0,0 +1,178 @@
+// k8s-tester-runtime-restart installs Kubernetes container runtime restart resilience tester.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	runtime_restart "github.com/aws/aws-k8s-tester/k8s-tester/runtime-restart"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-runtime-restart",
+	Short:      "Kubernetes container runtime restart resilience tester",
+	SuggestFor: []string{"runtime-restart"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", runtime_restart.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-runtime-restart failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	components       []string
+	nodes            int
+	rounds           int
+	restarterImage   string
+	workloadImage    string
+	workloadReplicas int32
+	recoveryTimeout  time.Duration
+	maxSandboxLeaks  int
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringSliceVar(&components, "components", runtime_restart.DefaultComponents(), "systemd units to restart (containerd, kubelet)")
+	cmd.PersistentFlags().IntVar(&nodes, "nodes", runtime_restart.DefaultNodes, "number of nodes to restart the components")
+	cmd.PersistentFlags().IntVar(&rounds, "rounds", runtime_restart.DefaultRounds, "number of restarts on each node")
+	cmd.PersistentFlags().StringVar(&restarterImage, "restarter-image", runtime_restart.DefaultRestarterImage, "privileged DaemonSet image with nsenter")
+	cmd.PersistentFlags().StringVar(&workloadImage, "workload-image", runtime_restart.DefaultWorkloadImage, "workload Pod image")
+	cmd.PersistentFlags().Int32Var(&workloadReplicas, "workload-replicas", runtime_restart.DefaultWorkloadReplicas, "number of workload Pods running during the restarts")
+	cmd.PersistentFlags().DurationVar(&recoveryTimeout, "recovery-timeout", runtime_restart.DefaultRecoveryTimeout, "timeout for the nodes and the workloads to recover after each restart")
+	cmd.PersistentFlags().IntVar(&maxSandboxLeaks, "max-sandbox-leaks", runtime_restart.DefaultMaxSandboxLeaks, "maximum number of pod sandboxes accumulated on a node over the restarts")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &runtime_restart.Config{
+		Prompt:           prompt,
+		Logger:           lg,
+		LogWriter:        logWriter,
+		MinimumNodes:     minimumNodes,
+		Namespace:        namespace,
+		Client:           cli,
+		Components:       components,
+		Nodes:            nodes,
+		Rounds:           rounds,
+		RestarterImage:   restarterImage,
+		WorkloadImage:    workloadImage,
+		WorkloadReplicas: workloadReplicas,
+		RecoveryTimeout:  recoveryTimeout,
+		MaxSandboxLeaks:  maxSandboxLeaks,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := runtime_restart.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-runtime-restart apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &runtime_restart.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := runtime_restart.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-runtime-restart delete' success\n")
+}
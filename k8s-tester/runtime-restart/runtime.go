@@ -0,0 +1,105 @@
+package runtime_restart
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	core_v1 "k8s.io/api/core/v1"
+)
+
+// Systemd units that can be restarted.
+const (
+	ComponentContainerd = "containerd"
+	ComponentKubelet    = "kubelet"
+)
+
+// validComponent returns true if the systemd unit is supported.
+func validComponent(c string) bool {
+	return c == ComponentContainerd || c == ComponentKubelet
+}
+
+// NodeResult is the runtime restart result of a node.
+type NodeResult struct {
+	// Node is the node name.
+	Node string `json:"node"`
+	// SandboxesBefore is the number of pod sandboxes before the restarts.
+	SandboxesBefore int `json:"sandboxes_before"`
+	// SandboxesAfter is the number of pod sandboxes after the restarts.
+	SandboxesAfter int `json:"sandboxes_after"`
+}
+
+// Leaked returns the number of pod sandboxes accumulated over the restarts.
+func (r NodeResult) Leaked() int {
+	if r.SandboxesAfter <= r.SandboxesBefore {
+		return 0
+	}
+	return r.SandboxesAfter - r.SandboxesBefore
+}
+
+// selectNodes returns up to "n" nodes from the sorted node names.
+func selectNodes(names []string, n int) []string {
+	ss := make([]string, len(names))
+	copy(ss, names)
+	sort.Strings(ss)
+	if n < len(ss) {
+		ss = ss[:n]
+	}
+	return ss
+}
+
+const fargateComputeTypeLabel = "eks.amazonaws.com/compute-type"
+
+// restartableNodes returns the names of the ready Linux nodes
+// whose systemd units can be restarted, excluding Fargate nodes.
+func restartableNodes(nodes []core_v1.Node) (names []string) {
+	for _, node := range nodes {
+		if node.Spec.Unschedulable ||
+			node.Labels[fargateComputeTypeLabel] == "fargate" ||
+			node.Labels["kubernetes.io/os"] == "windows" {
+			continue
+		}
+		if nodeReady(node) {
+			names = append(names, node.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func nodeReady(node core_v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == core_v1.NodeReady {
+			return cond.Status == core_v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// hostCommand returns the command that runs the script in the host namespaces
+// from the privileged Pod with "hostPID".
+func hostCommand(script string) []string {
+	return []string{"nsenter", "--target", "1", "--mount", "--uts", "--ipc", "--net", "--pid", "--", "sh", "-c", script}
+}
+
+// countSandboxesScript counts the pod sandboxes in all states,
+// with "ctr" if "crictl" is not installed on the host.
+const countSandboxesScript = `if command -v crictl >/dev/null 2>&1; then crictl pods -q | wc -l; else ctr --namespace k8s.io containers ls -q 'labels."io.cri-containerd.kind"==sandbox' | wc -l; fi`
+
+// restartScript returns the script that restarts the systemd units.
+// "--no-block" returns before the kubelet restart breaks the exec session.
+func restartScript(components []string) string {
+	return "systemctl --no-block restart " + strings.Join(components, " ")
+}
+
+// parseCount parses the last line of the command output as a count.
+func parseCount(out string) (int, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	n, err := strconv.Atoi(last)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse count from %q (%v)", out, err)
+	}
+	return n, nil
+}
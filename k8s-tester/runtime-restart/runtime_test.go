@@ -0,0 +1,78 @@
+package runtime_restart
+
+import (
+	"reflect"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRestartableNodes(t *testing.T) {
+	ready := core_v1.NodeStatus{Conditions: []core_v1.NodeCondition{{Type: core_v1.NodeReady, Status: core_v1.ConditionTrue}}}
+	nodes := []core_v1.Node{
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "c"}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "a"}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "b"}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "cordoned"}, Spec: core_v1.NodeSpec{Unschedulable: true}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "fargate", Labels: map[string]string{fargateComputeTypeLabel: "fargate"}}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "windows", Labels: map[string]string{"kubernetes.io/os": "windows"}}, Status: ready},
+		{ObjectMeta: meta_v1.ObjectMeta{Name: "not-ready"}},
+	}
+	names := restartableNodes(nodes)
+	if !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected nodes %v", names)
+	}
+	if selected := selectNodes([]string{"c", "a", "b"}, 2); !reflect.DeepEqual(selected, []string{"a", "b"}) {
+		t.Fatalf("unexpected selected nodes %v", selected)
+	}
+	if selected := selectNodes(names, 5); !reflect.DeepEqual(selected, names) {
+		t.Fatalf("unexpected selected nodes %v", selected)
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		out      string
+		expected int
+		err      bool
+	}{
+		{"12\n", 12, false},
+		{"/usr/bin/crictl\n  7 \n", 7, false},
+		{"", 0, true},
+		{"sh: crictl: not found", 0, true},
+	}
+	for i, tt := range tests {
+		n, err := parseCount(tt.out)
+		if (err != nil) != tt.err {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if n != tt.expected {
+			t.Fatalf("#%d: expected %d, got %d", i, tt.expected, n)
+		}
+	}
+}
+
+func TestNodeResultLeaked(t *testing.T) {
+	for i, tt := range []struct {
+		rs       NodeResult
+		expected int
+	}{
+		{NodeResult{SandboxesBefore: 10, SandboxesAfter: 10}, 0},
+		{NodeResult{SandboxesBefore: 10, SandboxesAfter: 13}, 3},
+		{NodeResult{SandboxesBefore: 10, SandboxesAfter: 8}, 0},
+	} {
+		if v := tt.rs.Leaked(); v != tt.expected {
+			t.Fatalf("#%d: expected %d, got %d", i, tt.expected, v)
+		}
+	}
+}
+
+func TestRestartScript(t *testing.T) {
+	if s := restartScript([]string{ComponentContainerd, ComponentKubelet}); s != "systemctl --no-block restart containerd kubelet" {
+		t.Fatalf("unexpected script %q", s)
+	}
+	if !validComponent(ComponentKubelet) || validComponent("docker") {
+		t.Fatal("unexpected validComponent")
+	}
+}
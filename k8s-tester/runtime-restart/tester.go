@@ -0,0 +1,619 @@
+// Package runtime_restart restarts the container runtime (containerd) and kubelet
+// on a subset of nodes via a privileged DaemonSet while workloads run, and validates
+// the workloads recover without leaking pod sandboxes.
+package runtime_restart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Components is the list of systemd units to restart ("containerd", "kubelet").
+	Components []string `json:"components"`
+	// Nodes is the number of nodes to restart the components.
+	Nodes int `json:"nodes"`
+	// Rounds is the number of restarts on each node.
+	Rounds int `json:"rounds"`
+
+	// RestarterImage is the privileged DaemonSet image with "nsenter".
+	RestarterImage string `json:"restarter_image"`
+	// WorkloadImage is the workload Pod image.
+	WorkloadImage string `json:"workload_image"`
+	// WorkloadReplicas is the number of workload Pods running during the restarts.
+	WorkloadReplicas int32 `json:"workload_replicas"`
+
+	// RecoveryTimeout is the timeout for the nodes and the workloads to recover after each restart.
+	RecoveryTimeout       time.Duration `json:"recovery_timeout"`
+	RecoveryTimeoutString string        `json:"recovery_timeout_string" read-only:"true"`
+	// MaxSandboxLeaks is the maximum number of pod sandboxes
+	// accumulated on a node over the restarts.
+	MaxSandboxLeaks int `json:"max_sandbox_leaks"`
+
+	// NodeResults is the list of the results per restarted node.
+	NodeResults []NodeResult `json:"node_results" read-only:"true"`
+	// WorkloadRestarts is the total number of workload container restarts.
+	WorkloadRestarts int32 `json:"workload_restarts" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if len(cfg.Components) == 0 {
+		cfg.Components = DefaultComponents()
+	}
+	for _, c := range cfg.Components {
+		if !validComponent(c) {
+			return fmt.Errorf("invalid component %q", c)
+		}
+	}
+	if cfg.Nodes <= 0 {
+		cfg.Nodes = DefaultNodes
+	}
+	if cfg.Rounds <= 0 {
+		cfg.Rounds = DefaultRounds
+	}
+	if cfg.RestarterImage == "" {
+		cfg.RestarterImage = DefaultRestarterImage
+	}
+	if cfg.WorkloadImage == "" {
+		cfg.WorkloadImage = DefaultWorkloadImage
+	}
+	if cfg.WorkloadReplicas <= 0 {
+		cfg.WorkloadReplicas = DefaultWorkloadReplicas
+	}
+	if cfg.RecoveryTimeout == time.Duration(0) {
+		cfg.RecoveryTimeout = DefaultRecoveryTimeout
+	}
+	cfg.RecoveryTimeoutString = cfg.RecoveryTimeout.String()
+	if cfg.MaxSandboxLeaks < 0 {
+		return fmt.Errorf("invalid MaxSandboxLeaks %d", cfg.MaxSandboxLeaks)
+	}
+	return nil
+}
+
+const (
+	DefaultMinimumNodes     int   = 1
+	DefaultNodes            int   = 1
+	DefaultRounds           int   = 3
+	DefaultRestarterImage         = "public.ecr.aws/docker/library/busybox:1.36"
+	DefaultWorkloadImage          = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	DefaultWorkloadReplicas int32 = 10
+	DefaultRecoveryTimeout        = 5 * time.Minute
+	DefaultMaxSandboxLeaks  int   = 0
+)
+
+// DefaultComponents returns the systemd units restarted by default.
+func DefaultComponents() []string {
+	return []string{ComponentContainerd}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:           false,
+		Prompt:           false,
+		MinimumNodes:     DefaultMinimumNodes,
+		Namespace:        pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Components:       DefaultComponents(),
+		Nodes:            DefaultNodes,
+		Rounds:           DefaultRounds,
+		RestarterImage:   DefaultRestarterImage,
+		WorkloadImage:    DefaultWorkloadImage,
+		WorkloadReplicas: DefaultWorkloadReplicas,
+		RecoveryTimeout:  DefaultRecoveryTimeout,
+		MaxSandboxLeaks:  DefaultMaxSandboxLeaks,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	restarterName = "restarter"
+	workloadName  = "workload"
+	appLabel      = "app.kubernetes.io/name"
+)
+
+func (ts *tester) Apply() (err error) {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return err
+	}
+	selected := selectNodes(restartableNodes(nodes), ts.cfg.Nodes)
+	if len(selected) == 0 {
+		return errors.New("no restartable node found")
+	}
+	ts.cfg.Logger.Info("selected nodes to restart", zap.Strings("nodes", selected), zap.Strings("components", ts.cfg.Components))
+
+	if err = client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+	if err = ts.createWorkload(); err != nil {
+		return err
+	}
+	if err = ts.waitForWorkload(); err != nil {
+		return err
+	}
+	if err = ts.createRestarter(selected); err != nil {
+		return err
+	}
+	restarters, err := ts.waitForRestarters(selected)
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.NodeResults = make([]NodeResult, 0, len(selected))
+	for _, node := range selected {
+		n, err := ts.countSandboxes(restarters[node])
+		if err != nil {
+			return err
+		}
+		ts.cfg.NodeResults = append(ts.cfg.NodeResults, NodeResult{Node: node, SandboxesBefore: n})
+	}
+
+	for round := 1; round <= ts.cfg.Rounds; round++ {
+		ts.cfg.Logger.Info("restarting components", zap.Int("round", round), zap.Int("rounds", ts.cfg.Rounds))
+		for _, node := range selected {
+			if err = ts.restart(node, restarters[node]); err != nil {
+				return err
+			}
+		}
+		// wait for the restarts to take effect, before checking the recovery
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("runtime restart aborted")
+		case <-time.After(15 * time.Second):
+		}
+		if err = ts.waitForNodes(selected); err != nil {
+			return err
+		}
+		if restarters, err = ts.waitForRestarters(selected); err != nil {
+			return err
+		}
+		if err = ts.waitForWorkload(); err != nil {
+			return err
+		}
+	}
+
+	var leaks []string
+	for i, node := range selected {
+		n, err := ts.countSandboxes(restarters[node])
+		if err != nil {
+			return err
+		}
+		ts.cfg.NodeResults[i].SandboxesAfter = n
+		if leaked := ts.cfg.NodeResults[i].Leaked(); leaked > ts.cfg.MaxSandboxLeaks {
+			leaks = append(leaks, fmt.Sprintf("%s (%d sandboxes leaked)", node, leaked))
+		}
+	}
+	if err = ts.countWorkloadRestarts(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nruntime restart results (%d rounds of %q, %d workload container restarts):\n\n", ts.cfg.Rounds, ts.cfg.Components, ts.cfg.WorkloadRestarts)
+	for _, rs := range ts.cfg.NodeResults {
+		fmt.Fprintf(ts.cfg.LogWriter, "  %-50s sandboxes before %d, after %d\n", rs.Node, rs.SandboxesBefore, rs.SandboxesAfter)
+	}
+	fmt.Fprintln(ts.cfg.LogWriter)
+
+	if len(leaks) > 0 {
+		return fmt.Errorf("pod sandboxes leaked over %d restarts on %s (max %d)", ts.cfg.Rounds, strings.Join(leaks, ", "), ts.cfg.MaxSandboxLeaks)
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createWorkload() error {
+	ts.cfg.Logger.Info("creating workload Deployment", zap.Int32("replicas", ts.cfg.WorkloadReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      workloadName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &ts.cfg.WorkloadReplicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{appLabel: workloadName},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{appLabel: workloadName},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            workloadName,
+									Image:           ts.cfg.WorkloadImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+							// spread the workloads, so that the restarted nodes run some of them
+							TopologySpreadConstraints: []core_v1.TopologySpreadConstraint{
+								{
+									MaxSkew:           1,
+									TopologyKey:       "kubernetes.io/hostname",
+									WhenUnsatisfiable: core_v1.ScheduleAnyway,
+									LabelSelector: &meta_v1.LabelSelector{
+										MatchLabels: map[string]string{appLabel: workloadName},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("Deployment already exists", zap.String("name", workloadName))
+			return nil
+		}
+		return fmt.Errorf("failed to create Deployment %q (%v)", workloadName, err)
+	}
+	return nil
+}
+
+func (ts *tester) waitForWorkload() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.RecoveryTimeout)
+	_, err := client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		10*time.Second,
+		10*time.Second,
+		ts.cfg.Namespace,
+		workloadName,
+		ts.cfg.WorkloadReplicas,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("Deployment %q not available (%v)", workloadName, err)
+	}
+	return nil
+}
+
+func (ts *tester) countWorkloadRestarts() error {
+	pods, err := client.ListPods(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, 1000, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to list Pods (%v)", err)
+	}
+	ts.cfg.WorkloadRestarts = 0
+	for _, pod := range pods {
+		if pod.Labels[appLabel] != workloadName {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			ts.cfg.WorkloadRestarts += cs.RestartCount
+		}
+	}
+	return nil
+}
+
+// createRestarter creates the privileged DaemonSet with "hostPID"
+// on the selected nodes, to run the commands in the host namespaces.
+func (ts *tester) createRestarter(nodes []string) error {
+	ts.cfg.Logger.Info("creating restarter DaemonSet", zap.Strings("nodes", nodes))
+	privileged := true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      restarterName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{appLabel: restarterName},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{appLabel: restarterName},
+						},
+						Spec: core_v1.PodSpec{
+							HostPID:       true,
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            restarterName,
+									Image:           ts.cfg.RestarterImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"sleep", "2147483647"},
+									SecurityContext: &core_v1.SecurityContext{
+										Privileged: &privileged,
+									},
+								},
+							},
+							Tolerations: []core_v1.Toleration{
+								{Operator: core_v1.TolerationOpExists},
+							},
+							Affinity: &core_v1.Affinity{
+								NodeAffinity: &core_v1.NodeAffinity{
+									RequiredDuringSchedulingIgnoredDuringExecution: &core_v1.NodeSelector{
+										NodeSelectorTerms: []core_v1.NodeSelectorTerm{
+											{
+												MatchFields: []core_v1.NodeSelectorRequirement{
+													{
+														Key:      "metadata.name",
+														Operator: core_v1.NodeSelectorOpIn,
+														Values:   nodes,
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		if k8s_errors.IsAlreadyExists(err) {
+			ts.cfg.Logger.Info("DaemonSet already exists", zap.String("name", restarterName))
+			return nil
+		}
+		return fmt.Errorf("failed to create DaemonSet %q (%v)", restarterName, err)
+	}
+	return nil
+}
+
+// waitForRestarters returns the ready restarter Pod names by node name.
+func (ts *tester) waitForRestarters(nodes []string) (map[string]string, error) {
+	deadline := time.Now().Add(ts.cfg.RecoveryTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, errors.New("waiting for restarter Pods aborted")
+		case <-time.After(5 * time.Second):
+		}
+		pods, err := client.ListPods(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace, 1000, 5*time.Second)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Pods", zap.Error(err))
+			continue
+		}
+		restarters := make(map[string]string)
+		for _, pod := range pods {
+			if pod.Labels[appLabel] != restarterName || pod.DeletionTimestamp != nil || !podReady(pod) {
+				continue
+			}
+			restarters[pod.Spec.NodeName] = pod.Name
+		}
+		ready := 0
+		for _, node := range nodes {
+			if _, ok := restarters[node]; ok {
+				ready++
+			}
+		}
+		if ready == len(nodes) {
+			return restarters, nil
+		}
+		ts.cfg.Logger.Info("waiting for restarter Pods", zap.Int("ready", ready), zap.Int("nodes", len(nodes)))
+	}
+	return nil, fmt.Errorf("restarter Pods not ready within %v", ts.cfg.RecoveryTimeout)
+}
+
+func podReady(pod core_v1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == core_v1.PodReady {
+			return cond.Status == core_v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// waitForNodes waits for the restarted nodes to be ready.
+func (ts *tester) waitForNodes(nodes []string) error {
+	deadline := time.Now().Add(ts.cfg.RecoveryTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("waiting for nodes aborted")
+		case <-time.After(5 * time.Second):
+		}
+		var notReady []string
+		for _, name := range nodes {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			node, err := ts.cfg.Client.KubernetesClient().CoreV1().Nodes().Get(ctx, name, meta_v1.GetOptions{})
+			cancel()
+			if err != nil || !nodeReady(*node) {
+				notReady = append(notReady, name)
+			}
+		}
+		if len(notReady) == 0 {
+			return nil
+		}
+		ts.cfg.Logger.Info("waiting for nodes to be ready", zap.Strings("not-ready", notReady))
+	}
+	return fmt.Errorf("nodes %q not ready within %v", nodes, ts.cfg.RecoveryTimeout)
+}
+
+// hostExec runs the script in the host namespaces via the restarter Pod.
+func (ts *tester) hostExec(podName string, script string) (string, error) {
+	args := append([]string{
+		ts.cfg.Client.Config().KubectlPath,
+		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
+		"--namespace=" + ts.cfg.Namespace,
+		"exec",
+		podName,
+		"--",
+	}, hostCommand(script)...)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	out, err := exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	cancel()
+	if err != nil {
+		return string(out), fmt.Errorf("failed to exec %q on Pod %q (%v, output %q)", script, podName, err, string(out))
+	}
+	return string(out), nil
+}
+
+func (ts *tester) countSandboxes(podName string) (int, error) {
+	out, err := ts.hostExec(podName, countSandboxesScript)
+	if err != nil {
+		return 0, err
+	}
+	return parseCount(out)
+}
+
+func (ts *tester) restart(node string, podName string) error {
+	ts.cfg.Logger.Info("restarting components", zap.String("node", node), zap.Strings("components", ts.cfg.Components))
+	_, err := ts.hostExec(podName, restartScript(ts.cfg.Components))
+	return err
+}
@@ -58,9 +58,11 @@ func main() {
 }
 
 var (
-	clients    int
-	objects    int
-	objectSize int
+	clients     int
+	objects     int
+	objectSize  int
+	payloadKind string
+	watchers    int
 )
 
 func newApply() *cobra.Command {
@@ -72,6 +74,8 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().IntVar(&clients, "clients", 5, "number of clients")
 	cmd.PersistentFlags().IntVar(&objects, "objects", secrets.DefaultObjects, "number of objects")
 	cmd.PersistentFlags().IntVar(&objectSize, "object-size", secrets.DefaultObjectSize, "object size")
+	cmd.PersistentFlags().StringVar(&payloadKind, "payload-kind", secrets.DefaultPayloadKind, "payload kind (ascii, binary, unicode, max-key, max-value, invalid-key, oversize, mixed)")
+	cmd.PersistentFlags().IntVar(&watchers, "watchers", 0, "number of watchers to measure the latency from write to watch event delivery (0 to disable)")
 	return cmd
 }
 
@@ -102,6 +106,8 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		Client:       cli,
 		Objects:      objects,
 		ObjectSize:   objectSize,
+		PayloadKind:  payloadKind,
+		Watchers:     watchers,
 	}
 
 	ts := secrets.New(cfg)
@@ -0,0 +1,39 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"github.com/aws/aws-k8s-tester/utils/payload"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the first Secret of the writes, against a fake client.
+// The payload is random, so a placeholder value is rendered.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	p := payload.Generate(cfg.PayloadKind, "data", 0, cfg.ObjectSize)
+	val := fmt.Sprintf("<%d bytes of %s payload>", len(p.Value), p.Kind)
+	sec := rt.secretObject("secret0", p)
+	for k := range sec.Data {
+		sec.Data[k] = []byte(val)
+	}
+	_, err := cli.KubernetesClient().CoreV1().Secrets(cfg.Namespace).Create(context.Background(), sec, meta_v1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
 	"sort"
@@ -17,6 +18,7 @@ import (
 	"github.com/aws/aws-k8s-tester/client"
 	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
 	"github.com/aws/aws-k8s-tester/utils/latency"
+	"github.com/aws/aws-k8s-tester/utils/payload"
 	"github.com/aws/aws-k8s-tester/utils/rand"
 	utils_time "github.com/aws/aws-k8s-tester/utils/time"
 	"github.com/manifoldco/promptui"
@@ -25,6 +27,7 @@ import (
 	core_v1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 var (
@@ -49,6 +52,17 @@ var (
 			Name:      "write_request_latency_milliseconds",
 			Help:      "Bucketed histogram of client-side write request and response latency.",
 
+			// lowest bucket start of upper bound 0.5 ms with factor 2
+			// highest bucket start of 0.5 ms * 2^13 == 4.096 sec
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 14),
+		})
+	watchEventLatencyMs = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "secrets",
+			Subsystem: "client",
+			Name:      "watch_event_latency_milliseconds",
+			Help:      "Bucketed histogram of latency from write request to watch event delivery.",
+
 			// lowest bucket start of upper bound 0.5 ms with factor 2
 			// highest bucket start of 0.5 ms * 2^13 == 4.096 sec
 			Buckets: prometheus.ExponentialBuckets(0.5, 2, 14),
@@ -59,6 +73,7 @@ func init() {
 	prometheus.MustRegister(writeRequestsSuccessTotal)
 	prometheus.MustRegister(writeRequestsFailureTotal)
 	prometheus.MustRegister(writeRequestLatencyMs)
+	prometheus.MustRegister(watchEventLatencyMs)
 }
 
 type Config struct {
@@ -79,14 +94,36 @@ type Config struct {
 	Objects int `json:"objects"`
 	// ObjectSize is the size in bytes per object.
 	ObjectSize int `json:"object_size"`
+	// PayloadKind is the kind of the object data to write
+	// (e.g., "ascii", "binary", "unicode", "max-key", "max-value", "invalid-key", "oversize", "mixed").
+	// "invalid-key" and "oversize" payloads are expected to be rejected by the server-side validation.
+	PayloadKind string `json:"payload_kind"`
+	// Watchers is the number of watchers to start on the namespace before writes,
+	// to measure the propagation latency from each write request to its watch event delivery.
+	// Zero to disable watchers.
+	Watchers int `json:"watchers"`
 
 	LatencySummary latency.Summary `json:"latency_summary" read-only:"true"`
+	// WatchLatencySummary is the latency from write request to watch event delivery for all watchers.
+	// "FailureTotal" is the number of watch events that were not delivered.
+	WatchLatencySummary latency.Summary `json:"watch_latency_summary" read-only:"true"`
+	// ValidationSummary is the server-side validation results of the written payloads.
+	ValidationSummary payload.ValidationSummary `json:"validation_summary" read-only:"true"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
 	if cfg.Namespace == "" {
 		return errors.New("empty Namespace")
 	}
+	if cfg.Watchers < 0 {
+		return fmt.Errorf("invalid Watchers %d", cfg.Watchers)
+	}
+	if cfg.PayloadKind == "" {
+		cfg.PayloadKind = DefaultPayloadKind
+	}
+	if err := payload.Valid(cfg.PayloadKind); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -95,6 +132,7 @@ const (
 	DefaultMinimumNodes int = 1
 	DefaultObjects      int = 10
 	DefaultObjectSize   int = 10 * 1024 // 10 KB
+	DefaultPayloadKind      = payload.KindASCII
 
 	// writes total 300 MB data to etcd
 	// Objects: 1000,
@@ -109,6 +147,7 @@ func NewDefault() *Config {
 		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
 		Objects:      DefaultObjects,
 		ObjectSize:   DefaultObjectSize,
+		PayloadKind:  DefaultPayloadKind,
 	}
 }
 
@@ -120,6 +159,25 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 }
 
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
 type tester struct {
 	cfg            *Config
 	donec          chan struct{}
@@ -151,7 +209,27 @@ func (ts *tester) Apply() error {
 		return err
 	}
 
-	latencies := ts.startWrites()
+	var writeStarts sync.Map
+	var stopWatchers func(expected int) latency.Durations
+	if ts.cfg.Watchers > 0 {
+		var err error
+		stopWatchers, err = ts.startWatchers(&writeStarts)
+		if err != nil {
+			return err
+		}
+	}
+
+	latencies := ts.startWrites(&writeStarts)
+	if stopWatchers != nil {
+		expected := 0
+		writeStarts.Range(func(_, _ interface{}) bool {
+			expected++
+			return true
+		})
+		if err := ts.summarizeWatchLatencies(stopWatchers(expected), expected); err != nil {
+			return err
+		}
+	}
 	if len(latencies) == 0 {
 		ts.cfg.Logger.Warn("no latency collected")
 		return nil
@@ -194,7 +272,10 @@ func (ts *tester) Apply() error {
 	}
 
 	fmt.Fprintf(ts.cfg.LogWriter, "\n\nLatencySummary:\n%s\n", ts.cfg.LatencySummary.Table())
-	return nil
+	if ts.cfg.PayloadKind != payload.KindASCII {
+		fmt.Fprintf(ts.cfg.LogWriter, "\nValidationSummary (payload kind %q):\n%+v\n", ts.cfg.PayloadKind, ts.cfg.ValidationSummary)
+	}
+	return ts.cfg.ValidationSummary.Err()
 }
 
 func (ts *tester) Delete() error {
@@ -238,7 +319,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -248,11 +330,11 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 	return true
 }
 
-func (ts *tester) startWrites() (latencies latency.Durations) {
-	ts.cfg.Logger.Info("writing", zap.Int("objects", ts.cfg.Objects), zap.Int("object-size", ts.cfg.Objects))
+func (ts *tester) startWrites(writeStarts *sync.Map) (latencies latency.Durations) {
+	ts.cfg.Logger.Info("writing", zap.Int("objects", ts.cfg.Objects), zap.Int("object-size", ts.cfg.ObjectSize), zap.String("payload-kind", ts.cfg.PayloadKind))
 	latencies = make(latency.Durations, 0, 20000)
+	ts.cfg.ValidationSummary = payload.ValidationSummary{}
 
-	val := rand.String(ts.cfg.ObjectSize)
 	for i := 0; i < ts.cfg.Objects; i++ {
 		select {
 		case <-ts.cfg.Stopc:
@@ -265,35 +347,28 @@ func (ts *tester) startWrites() (latencies latency.Durations) {
 		}
 
 		key := fmt.Sprintf("secret%d%s", i, rand.String(7))
+		p := payload.Generate(ts.cfg.PayloadKind, "data", i, ts.cfg.ObjectSize)
 
 		start := time.Now()
+		// store before the write, since the watch event may be delivered before the response
+		writeStarts.Store(key, start)
 		ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
 		_, err := ts.cfg.Client.KubernetesClient().
 			CoreV1().
 			Secrets(ts.cfg.Namespace).
-			Create(ctx, &core_v1.Secret{
-				TypeMeta: meta_v1.TypeMeta{
-					APIVersion: "v1",
-					Kind:       "Secret",
-				},
-				ObjectMeta: meta_v1.ObjectMeta{
-					Name:      key,
-					Namespace: ts.cfg.Namespace,
-					Labels: map[string]string{
-						"name": key,
-					},
-				},
-				Data: map[string][]byte{key: []byte(val)},
-			}, meta_v1.CreateOptions{})
+			Create(ctx, ts.secretObject(key, p), meta_v1.CreateOptions{})
 		cancel()
 		took := time.Since(start)
 		tookMS := float64(took / time.Millisecond)
 		writeRequestLatencyMs.Observe(tookMS)
 		latencies = append(latencies, took)
+		invalid := err != nil && (k8s_errors.IsInvalid(err) || k8s_errors.IsRequestEntityTooLargeError(err))
+		ts.cfg.ValidationSummary.Observe(p, invalid, err == nil)
 		if err != nil {
-			if !k8s_errors.IsAlreadyExists(err) {
+			writeStarts.Delete(key)
+			if !k8s_errors.IsAlreadyExists(err) && (!invalid || !p.ExpectInvalid) {
 				writeRequestsFailureTotal.Inc()
-				ts.cfg.Logger.Warn("write secret failed", zap.String("namespace", ts.cfg.Namespace), zap.Error(err))
+				ts.cfg.Logger.Warn("write secret failed", zap.String("namespace", ts.cfg.Namespace), zap.String("payload-kind", p.Kind), zap.Error(err))
 			}
 		} else {
 			writeRequestsSuccessTotal.Inc()
@@ -304,3 +379,144 @@ func (ts *tester) startWrites() (latencies latency.Durations) {
 	}
 	return latencies
 }
+
+// secretObject returns the Secret to write with the payload.
+func (ts *tester) secretObject(key string, p payload.Payload) *core_v1.Secret {
+	return &core_v1.Secret{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      key,
+			Namespace: ts.cfg.Namespace,
+			Labels: map[string]string{
+				"name": key,
+			},
+		},
+		Data: map[string][]byte{p.Key: p.Value},
+	}
+}
+
+// watchDrainTimeout is the timeout to wait for the watchers
+// to receive all events after the writes are done.
+const watchDrainTimeout = time.Minute
+
+// startWatchers starts the watchers on the namespace, and returns a function that
+// waits until each watcher receives the expected number of events (or times out),
+// stops the watchers, and returns the latencies from the write request to the
+// watch event delivery for all watchers.
+func (ts *tester) startWatchers(writeStarts *sync.Map) (stop func(expected int) latency.Durations, err error) {
+	ts.cfg.Logger.Info("starting watchers", zap.Int("watchers", ts.cfg.Watchers), zap.String("namespace", ts.cfg.Namespace))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var mu sync.Mutex
+	latencies := make(latency.Durations, 0, 20000)
+	received := make([]int, ts.cfg.Watchers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < ts.cfg.Watchers; i++ {
+		// start watch before writes, to not miss any event
+		w, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Secrets(ts.cfg.Namespace).
+			Watch(ctx, meta_v1.ListOptions{})
+		if err != nil {
+			cancel()
+			wg.Wait()
+			return nil, fmt.Errorf("failed to start watcher %d (%v)", i, err)
+		}
+
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			defer w.Stop()
+			for ev := range w.ResultChan() {
+				if ev.Type != watch.Added {
+					continue
+				}
+				obj, ok := ev.Object.(*core_v1.Secret)
+				if !ok {
+					continue
+				}
+				v, ok := writeStarts.Load(obj.Name)
+				if !ok {
+					continue
+				}
+				took := time.Since(v.(time.Time))
+				watchEventLatencyMs.Observe(float64(took / time.Millisecond))
+				mu.Lock()
+				latencies = append(latencies, took)
+				received[idx]++
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	stop = func(expected int) latency.Durations {
+		deadline := time.Now().Add(watchDrainTimeout)
+		for time.Now().Before(deadline) {
+			done := true
+			mu.Lock()
+			for _, n := range received {
+				if n < expected {
+					done = false
+					break
+				}
+			}
+			mu.Unlock()
+			if done {
+				break
+			}
+			select {
+			case <-ts.cfg.Stopc:
+				deadline = time.Now()
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		cancel()
+		wg.Wait()
+
+		ts.cfg.Logger.Info("stopped watchers", zap.Int("watchers", ts.cfg.Watchers), zap.Int("expected-events-per-watcher", expected), zap.Ints("received", received))
+		return latencies
+	}
+	return stop, nil
+}
+
+func (ts *tester) summarizeWatchLatencies(latencies latency.Durations, expected int) (err error) {
+	ts.cfg.WatchLatencySummary = latency.Summary{}
+	ts.cfg.WatchLatencySummary.TestID = time.Now().UTC().Format(time.RFC3339Nano)
+	ts.cfg.WatchLatencySummary.SuccessTotal = float64(len(latencies))
+	ts.cfg.WatchLatencySummary.FailureTotal = float64(expected*ts.cfg.Watchers - len(latencies))
+	if len(latencies) == 0 {
+		ts.cfg.Logger.Warn("no watch latency collected")
+		return nil
+	}
+
+	sort.Sort(latencies)
+	ts.cfg.WatchLatencySummary.P50 = latencies.PickP50()
+	ts.cfg.WatchLatencySummary.P90 = latencies.PickP90()
+	ts.cfg.WatchLatencySummary.P99 = latencies.PickP99()
+	ts.cfg.WatchLatencySummary.P999 = latencies.PickP999()
+	ts.cfg.WatchLatencySummary.P9999 = latencies.PickP9999()
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to gather prometheus metrics", zap.Error(err))
+		return err
+	}
+	for _, mf := range mfs {
+		if mf == nil {
+			continue
+		}
+		if *mf.Name == "secrets_client_watch_event_latency_milliseconds" {
+			ts.cfg.WatchLatencySummary.Histogram, err = latency.ParseHistogram("milliseconds", mf.Metric[0].GetHistogram())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\nWatchLatencySummary:\n%s\n", ts.cfg.WatchLatencySummary.Table())
+	return nil
+}
@@ -0,0 +1,69 @@
+package static_pod
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"go.uber.org/zap"
+)
+
+// runShellScriptDocument runs the shell commands on the Linux instances.
+// ref. https://docs.aws.amazon.com/systems-manager/latest/userguide/documents-command-ssm-plugin-reference.html#aws-runShellScript
+const runShellScriptDocument = "AWS-RunShellScript"
+
+// runCommand runs the shell commands on the instance with SSM Run Command,
+// and waits for the command to complete.
+func (ts *tester) runCommand(instanceID string, commands []string) (string, error) {
+	out, err := ts.cfg.SSMAPI.SendCommand(&ssm.SendCommandInput{
+		DocumentName: aws.String(runShellScriptDocument),
+		Comment:      aws.String(pkgName),
+		InstanceIds:  aws.StringSlice([]string{instanceID}),
+		Parameters: map[string][]*string{
+			"commands": aws.StringSlice(commands),
+		},
+		TimeoutSeconds: aws.Int64(int64(ts.cfg.Timeout.Seconds())),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to send SSM command to %q (%v)", instanceID, err)
+	}
+	cmdID := aws.StringValue(out.Command.CommandId)
+	ts.cfg.Logger.Info("sent SSM command", zap.String("instance-id", instanceID), zap.String("command-id", cmdID))
+
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return "", errors.New("wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		inv, err := ts.cfg.SSMAPI.GetCommandInvocation(&ssm.GetCommandInvocationInput{
+			CommandId:  aws.String(cmdID),
+			InstanceId: aws.String(instanceID),
+		})
+		if err != nil {
+			// the invocation may not exist right after the command is sent
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeInvocationDoesNotExist {
+				continue
+			}
+			ts.cfg.Logger.Warn("failed to get SSM command invocation; retrying", zap.String("command-id", cmdID), zap.Error(err))
+			continue
+		}
+		status := aws.StringValue(inv.Status)
+		switch status {
+		case ssm.CommandInvocationStatusSuccess:
+			return aws.StringValue(inv.StandardOutputContent), nil
+		case ssm.CommandInvocationStatusPending,
+			ssm.CommandInvocationStatusInProgress,
+			ssm.CommandInvocationStatusDelayed:
+			continue
+		default:
+			return "", fmt.Errorf("SSM command %q on %q %s (%s)", cmdID, instanceID, status, aws.StringValue(inv.StandardErrorContent))
+		}
+	}
+	return "", fmt.Errorf("SSM command %q on %q not completed within %v", cmdID, instanceID, ts.cfg.Timeout)
+}
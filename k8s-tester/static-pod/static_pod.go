@@ -0,0 +1,190 @@
+package static_pod
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	staticPodName = "static-pod"
+	appLabel      = "app.kubernetes.io/name"
+
+	// mirrorAnnotation is set by the kubelet on the mirror pods.
+	// ref. https://github.com/kubernetes/kubernetes/blob/master/pkg/kubelet/types/pod_update.go
+	mirrorAnnotation = "kubernetes.io/config.mirror"
+	// sourceAnnotation is the config source of the pod, "file" for the manifests
+	// in the kubelet "staticPodPath".
+	sourceAnnotation = "kubernetes.io/config.source"
+	sourceFile       = "file"
+)
+
+// manifestFileName is the file name of the static pod manifest in the kubelet "staticPodPath".
+func manifestFileName() string {
+	return "k8s-tester-" + staticPodName + ".yaml"
+}
+
+// mirrorPodName returns the name of the mirror pod, the static pod name
+// suffixed with the node name by the kubelet.
+func mirrorPodName(nodeName string) string {
+	return staticPodName + "-" + strings.ToLower(nodeName)
+}
+
+// staticPodManifest returns the static pod manifest in YAML.
+// The static pods can't refer to the API objects (e.g., ServiceAccount, ConfigMap),
+// so the pod only runs the image.
+func staticPodManifest(namespace string, image string) ([]byte, error) {
+	pod := &core_v1.Pod{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      staticPodName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				appLabel: staticPodName,
+			},
+		},
+		Spec: core_v1.PodSpec{
+			Containers: []core_v1.Container{
+				{
+					Name:            staticPodName,
+					Image:           image,
+					ImagePullPolicy: core_v1.PullIfNotPresent,
+				},
+			},
+			RestartPolicy: core_v1.RestartPolicyAlways,
+		},
+	}
+	return yaml.Marshal(pod)
+}
+
+// writeManifestCommands returns the shell commands to write the manifest to the
+// static pod path. The manifest is written to a dot file first, which the kubelet
+// ignores, and renamed so that the kubelet never reads a partial manifest.
+func writeManifestCommands(staticPodPath string, manifest []byte) []string {
+	dst := path.Join(staticPodPath, manifestFileName())
+	tmp := path.Join(staticPodPath, "."+manifestFileName())
+	return []string{
+		"set -e",
+		fmt.Sprintf("mkdir -p %s", staticPodPath),
+		fmt.Sprintf("echo %s | base64 -d > %s", base64.StdEncoding.EncodeToString(manifest), tmp),
+		fmt.Sprintf("mv -f %s %s", tmp, dst),
+	}
+}
+
+// removeManifestCommands returns the shell commands to remove the manifest from the static pod path.
+func removeManifestCommands(staticPodPath string) []string {
+	return []string{
+		fmt.Sprintf("rm -f %s", path.Join(staticPodPath, manifestFileName())),
+	}
+}
+
+// parseStaticPodPath returns the "staticPodPath" of the kubelet "/configz" response.
+func parseStaticPodPath(b []byte) (string, error) {
+	var configz struct {
+		KubeletConfig struct {
+			StaticPodPath string `json:"staticPodPath"`
+		} `json:"kubeletconfig"`
+	}
+	if err := json.Unmarshal(b, &configz); err != nil {
+		return "", fmt.Errorf("failed to parse kubelet configz (%v)", err)
+	}
+	if configz.KubeletConfig.StaticPodPath == "" {
+		return "", errors.New("empty staticPodPath, static pods are disabled on the kubelet")
+	}
+	return configz.KubeletConfig.StaticPodPath, nil
+}
+
+// checkMirrorPod returns an error if the pod is not the mirror pod of the static pod on the node.
+func checkMirrorPod(pod *core_v1.Pod, nodeName string) error {
+	if _, ok := pod.Annotations[mirrorAnnotation]; !ok {
+		return fmt.Errorf("pod %q has no %q annotation", pod.Name, mirrorAnnotation)
+	}
+	if src := pod.Annotations[sourceAnnotation]; src != sourceFile {
+		return fmt.Errorf("pod %q has %q annotation %q, expected %q", pod.Name, sourceAnnotation, src, sourceFile)
+	}
+	if pod.Spec.NodeName != nodeName {
+		return fmt.Errorf("pod %q is on node %q, expected %q", pod.Name, pod.Spec.NodeName, nodeName)
+	}
+	// the kubelet sets the node as the owner of the mirror pods since v1.18
+	owned := false
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "Node" && ref.Name == nodeName {
+			owned = true
+			break
+		}
+	}
+	if !owned {
+		return fmt.Errorf("pod %q is not owned by node %q", pod.Name, nodeName)
+	}
+	return nil
+}
+
+// containerIDs returns the sorted container IDs of the running containers,
+// which stay the same as long as the static pod is not restarted.
+func containerIDs(pod *core_v1.Pod) []string {
+	ids := make([]string, 0, len(pod.Status.ContainerStatuses))
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Running != nil && cs.ContainerID != "" {
+			ids = append(ids, cs.ContainerID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// mirrorPodRunning returns true if all containers of the mirror pod are running.
+func mirrorPodRunning(pod *core_v1.Pod) bool {
+	return pod.Status.Phase == core_v1.PodRunning &&
+		len(pod.Spec.Containers) > 0 &&
+		len(containerIDs(pod)) == len(pod.Spec.Containers)
+}
+
+// instanceID returns the EC2 instance ID from the node provider ID
+// (e.g., "aws:///us-west-2a/i-0123456789abcdef0"), or an empty string
+// for the non-EC2 nodes (e.g., Fargate).
+func instanceID(providerID string) string {
+	if !strings.HasPrefix(providerID, "aws://") {
+		return ""
+	}
+	id := providerID[strings.LastIndex(providerID, "/")+1:]
+	if !strings.HasPrefix(id, "i-") {
+		return ""
+	}
+	return id
+}
+
+// pickNodes returns up to "n" EC2 nodes in the order of their names,
+// mapped to their instance IDs.
+func pickNodes(nodes []core_v1.Node, n int) map[string]string {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	targets := make(map[string]string)
+	for _, node := range nodes {
+		if len(targets) == n {
+			break
+		}
+		if id := instanceID(node.Spec.ProviderID); id != "" {
+			targets[node.Name] = id
+		}
+	}
+	return targets
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,174 @@
+package static_pod
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestStaticPodManifest(t *testing.T) {
+	b, err := staticPodManifest("test-ns", DefaultImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pod core_v1.Pod
+	if err := yaml.Unmarshal(b, &pod); err != nil {
+		t.Fatal(err)
+	}
+	if pod.Kind != "Pod" || pod.Name != staticPodName || pod.Namespace != "test-ns" {
+		t.Fatalf("unexpected pod %s %s/%s", pod.Kind, pod.Namespace, pod.Name)
+	}
+	if len(pod.Spec.Containers) != 1 || pod.Spec.Containers[0].Image != DefaultImage {
+		t.Fatalf("unexpected containers %+v", pod.Spec.Containers)
+	}
+
+	cmds := writeManifestCommands("/etc/kubernetes/manifests", b)
+	expected := []string{
+		"set -e",
+		"mkdir -p /etc/kubernetes/manifests",
+		"echo " + base64.StdEncoding.EncodeToString(b) + " | base64 -d > /etc/kubernetes/manifests/.k8s-tester-static-pod.yaml",
+		"mv -f /etc/kubernetes/manifests/.k8s-tester-static-pod.yaml /etc/kubernetes/manifests/k8s-tester-static-pod.yaml",
+	}
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Fatalf("expected %q, got %q", expected, cmds)
+	}
+	cmds = removeManifestCommands("/etc/kubernetes/manifests")
+	expected = []string{"rm -f /etc/kubernetes/manifests/k8s-tester-static-pod.yaml"}
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Fatalf("expected %q, got %q", expected, cmds)
+	}
+}
+
+func TestParseStaticPodPath(t *testing.T) {
+	tests := []struct {
+		configz string
+		path    string
+		err     string
+	}{
+		{
+			configz: `{"kubeletconfig":{"staticPodPath":"/etc/kubernetes/manifests","syncFrequency":"1m0s"}}`,
+			path:    "/etc/kubernetes/manifests",
+		},
+		{
+			configz: `{"kubeletconfig":{"syncFrequency":"1m0s"}}`,
+			err:     "static pods are disabled",
+		},
+		{
+			configz: `not json`,
+			err:     "failed to parse",
+		},
+	}
+	for i, tt := range tests {
+		p, err := parseStaticPodPath([]byte(tt.configz))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("#%d: expected error %q, got %v", i, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if p != tt.path {
+			t.Fatalf("#%d: expected %q, got %q", i, tt.path, p)
+		}
+	}
+}
+
+func TestCheckMirrorPod(t *testing.T) {
+	nodeName := "ip-192-168-1-1.us-west-2.compute.internal"
+	pod := &core_v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: mirrorPodName(nodeName),
+			Annotations: map[string]string{
+				mirrorAnnotation: "abc",
+				sourceAnnotation: sourceFile,
+			},
+			OwnerReferences: []meta_v1.OwnerReference{
+				{APIVersion: "v1", Kind: "Node", Name: nodeName},
+			},
+		},
+		Spec: core_v1.PodSpec{
+			NodeName:   nodeName,
+			Containers: []core_v1.Container{{Name: staticPodName}},
+		},
+	}
+	if err := checkMirrorPod(pod, nodeName); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMirrorPod(pod, "other"); err == nil {
+		t.Fatal("expected error for other node")
+	}
+	pod.OwnerReferences = nil
+	if err := checkMirrorPod(pod, nodeName); err == nil {
+		t.Fatal("expected error without node owner")
+	}
+	delete(pod.Annotations, mirrorAnnotation)
+	if err := checkMirrorPod(pod, nodeName); err == nil {
+		t.Fatal("expected error without mirror annotation")
+	}
+
+	if mirrorPodRunning(pod) {
+		t.Fatal("unexpected running without container status")
+	}
+	pod.Status = core_v1.PodStatus{
+		Phase: core_v1.PodRunning,
+		ContainerStatuses: []core_v1.ContainerStatus{
+			{
+				Name:        staticPodName,
+				ContainerID: "containerd://abc",
+				State:       core_v1.ContainerState{Running: &core_v1.ContainerStateRunning{}},
+			},
+		},
+	}
+	if !mirrorPodRunning(pod) {
+		t.Fatal("expected running")
+	}
+	if ids := containerIDs(pod); !reflect.DeepEqual(ids, []string{"containerd://abc"}) {
+		t.Fatalf("unexpected container IDs %v", ids)
+	}
+}
+
+func TestPickNodes(t *testing.T) {
+	node := func(name, providerID string) core_v1.Node {
+		return core_v1.Node{
+			ObjectMeta: meta_v1.ObjectMeta{Name: name},
+			Spec:       core_v1.NodeSpec{ProviderID: providerID},
+		}
+	}
+	nodes := []core_v1.Node{
+		node("ip-192-168-2-2", "aws:///us-west-2b/i-2"),
+		node("fargate-ip-192-168-1-1", "aws:///us-west-2a/fargate-ip-192-168-1-1"),
+		node("ip-192-168-1-1", "aws:///us-west-2a/i-1"),
+	}
+	targets := pickNodes(nodes, 1)
+	expected := map[string]string{"ip-192-168-1-1": "i-1"}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Fatalf("expected %v, got %v", expected, targets)
+	}
+
+	cfg := NewDefault()
+	cfg.Region = "us-west-2"
+	cfg.StaticPodPath = "/etc/kubernetes/manifests"
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	ts := &tester{cfg: cfg}
+	ts.setTargets(pickNodes(nodes, 5))
+	if len(cfg.Results) != 2 || cfg.Results[0].Node != "ip-192-168-1-1" || cfg.Results[1].InstanceID != "i-2" {
+		t.Fatalf("unexpected results %+v", cfg.Results)
+	}
+	if cfg.Results[0].StaticPodPath != cfg.StaticPodPath {
+		t.Fatalf("expected static pod path %q, got %q", cfg.StaticPodPath, cfg.Results[0].StaticPodPath)
+	}
+
+	cfg.StaticPodPath = "etc/kubernetes/manifests"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for relative static pod path")
+	}
+}
@@ -0,0 +1,489 @@
+// Package static_pod writes the static pod manifests to the EC2 worker nodes via SSM,
+// and verifies the kubelet mirror pod semantics: the mirror pod is created for the
+// static pod, is recreated without restarting the static pod when deleted through
+// the API server, and is deleted when the manifest is removed.
+// The worker nodes must run the SSM agent with the instance role allowing SSM
+// (e.g., "AmazonSSMManagedInstanceCore"), as the self-managed nodes of this repo.
+// ref. https://kubernetes.io/docs/tasks/configure-pod-container/static-pod/
+package static_pod
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	aws_v1 "github.com/aws/aws-k8s-tester/utils/aws/v1"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/manifoldco/promptui"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	SSMAPI ssmiface.SSMAPI `json:"-"`
+
+	Partition string `json:"partition"`
+	Region    string `json:"region"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Nodes is the number of the EC2 worker nodes to write the static pod manifest to.
+	// The nodes are chosen in the order of their names.
+	Nodes int `json:"nodes"`
+	// Namespace to create the static pods in.
+	Namespace string `json:"namespace"`
+
+	// Image is the image of the static pods.
+	// The image must be pullable by the kubelet without the image pull secrets.
+	Image string `json:"image"`
+	// StaticPodPath is the kubelet static pod path on the nodes.
+	// Empty to read "staticPodPath" from the kubelet "/configz" of each node.
+	StaticPodPath string `json:"static_pod_path"`
+
+	// Timeout is the timeout for each SSM command and each mirror pod transition.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// Results is the mirror pod results of the nodes.
+	Results []NodeResult `json:"results" read-only:"true"`
+}
+
+// NodeResult is the mirror pod result of a node.
+type NodeResult struct {
+	Node          string `json:"node"`
+	InstanceID    string `json:"instance_id"`
+	StaticPodPath string `json:"static_pod_path"`
+
+	// CreateLatency is the time from writing the manifest to the mirror pod running.
+	CreateLatency       time.Duration `json:"create_latency"`
+	CreateLatencyString string        `json:"create_latency_string"`
+	// RecreateLatency is the time from deleting the mirror pod to the new mirror pod running.
+	RecreateLatency       time.Duration `json:"recreate_latency"`
+	RecreateLatencyString string        `json:"recreate_latency_string"`
+	// RemoveLatency is the time from removing the manifest to the mirror pod deleted.
+	RemoveLatency       time.Duration `json:"remove_latency"`
+	RemoveLatencyString string        `json:"remove_latency_string"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Region == "" {
+		return errors.New("empty Region")
+	}
+	if cfg.Partition == "" {
+		cfg.Partition = DefaultPartition
+	}
+	if cfg.Nodes == 0 {
+		cfg.Nodes = DefaultNodes
+	}
+	if cfg.Nodes < 0 {
+		return fmt.Errorf("invalid Nodes %d", cfg.Nodes)
+	}
+	if cfg.MinimumNodes < cfg.Nodes {
+		cfg.MinimumNodes = cfg.Nodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.StaticPodPath != "" && !path.IsAbs(cfg.StaticPodPath) {
+		return fmt.Errorf("StaticPodPath %q is not absolute", cfg.StaticPodPath)
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes int = 1
+	DefaultPartition        = "aws"
+	DefaultNodes        int = 1
+	DefaultImage            = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	DefaultTimeout          = 5 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:       false,
+		Prompt:       false,
+		Partition:    DefaultPartition,
+		MinimumNodes: DefaultMinimumNodes,
+		Nodes:        DefaultNodes,
+		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:        DefaultImage,
+		Timeout:      DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
+	awsCfg := aws_v1.Config{
+		Logger:        cfg.Logger,
+		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	}
+	awsSession, _, _, err := aws_v1.New(&awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session (%v)", err)
+	}
+	cfg.SSMAPI = ssm.New(awsSession, aws.NewConfig().WithRegion(cfg.Region))
+
+	return &tester{
+		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if len(nodes) < ts.cfg.MinimumNodes || err != nil {
+		return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+	}
+
+	targets := pickNodes(nodes, ts.cfg.Nodes)
+	if len(targets) < ts.cfg.Nodes {
+		return fmt.Errorf("expected %d EC2 nodes for the static pods, found %d", ts.cfg.Nodes, len(targets))
+	}
+	ts.setTargets(targets)
+
+	// the kubelet can't create the mirror pods in a missing namespace
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	manifest, err := staticPodManifest(ts.cfg.Namespace, ts.cfg.Image)
+	if err != nil {
+		return fmt.Errorf("failed to create static pod manifest (%v)", err)
+	}
+	for i := range ts.cfg.Results {
+		if err := ts.testNode(&ts.cfg.Results[i], manifest); err != nil {
+			return fmt.Errorf("node %q (%v)", ts.cfg.Results[i].Node, err)
+		}
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\n%s\n", ts.cfg.report())
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if len(ts.cfg.Results) == 0 {
+		// e.g., deleting from a new process, the nodes are chosen in the same order
+		nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+		if err != nil {
+			return fmt.Errorf("failed to list nodes (%v)", err)
+		}
+		ts.setTargets(pickNodes(nodes, ts.cfg.Nodes))
+	}
+
+	// the manifests may be left on the nodes if "Apply" failed
+	for i := range ts.cfg.Results {
+		res := &ts.cfg.Results[i]
+		if res.StaticPodPath == "" {
+			p, err := ts.staticPodPath(res.Node)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to get static pod path of node %q (%v)", res.Node, err))
+				continue
+			}
+			res.StaticPodPath = p
+		}
+		if _, err := ts.runCommand(res.InstanceID, removeManifestCommands(res.StaticPodPath)); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to remove static pod manifest from node %q (%v)", res.Node, err))
+		}
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q static pod manifests on the nodes for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// setTargets sets the results of the nodes in the order of the node names.
+func (ts *tester) setTargets(targets map[string]string) {
+	ts.cfg.Results = nil
+	for _, nodeName := range sortedKeys(targets) {
+		ts.cfg.Results = append(ts.cfg.Results, NodeResult{
+			Node:          nodeName,
+			InstanceID:    targets[nodeName],
+			StaticPodPath: ts.cfg.StaticPodPath,
+		})
+	}
+}
+
+// staticPodPath returns the static pod path of the node from the kubelet "/configz".
+func (ts *tester) staticPodPath(nodeName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	b, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		RESTClient().
+		Get().
+		AbsPath("/api/v1/nodes", nodeName, "proxy", "configz").
+		DoRaw(ctx)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to get kubelet configz (%v)", err)
+	}
+	return parseStaticPodPath(b)
+}
+
+// testNode writes the static pod manifest to the node, deletes the mirror pod,
+// and removes the manifest, verifying the mirror pod at each step.
+func (ts *tester) testNode(res *NodeResult, manifest []byte) error {
+	if res.StaticPodPath == "" {
+		p, err := ts.staticPodPath(res.Node)
+		if err != nil {
+			return err
+		}
+		res.StaticPodPath = p
+	}
+	podName := mirrorPodName(res.Node)
+	ts.cfg.Logger.Info("writing static pod manifest",
+		zap.String("node-name", res.Node),
+		zap.String("instance-id", res.InstanceID),
+		zap.String("static-pod-path", res.StaticPodPath),
+	)
+
+	start := time.Now()
+	if _, err := ts.runCommand(res.InstanceID, writeManifestCommands(res.StaticPodPath, manifest)); err != nil {
+		return err
+	}
+	pod, err := ts.waitForMirrorPod(podName, "running", func(pod *core_v1.Pod) bool {
+		return pod != nil && mirrorPodRunning(pod)
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkMirrorPod(pod, res.Node); err != nil {
+		return err
+	}
+	res.CreateLatency = time.Since(start)
+	res.CreateLatencyString = res.CreateLatency.String()
+	ts.cfg.Logger.Info("mirror pod created", zap.String("pod-name", podName), zap.String("latency", res.CreateLatencyString))
+
+	// deleting the mirror pod must not stop the static pod,
+	// the kubelet recreates the mirror pod for the running containers
+	uid, ids := pod.UID, containerIDs(pod)
+	start = time.Now()
+	if err := ts.deleteMirrorPod(podName, uid); err != nil {
+		return err
+	}
+	pod, err = ts.waitForMirrorPod(podName, "recreated", func(pod *core_v1.Pod) bool {
+		return pod != nil && pod.UID != uid && mirrorPodRunning(pod)
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkMirrorPod(pod, res.Node); err != nil {
+		return err
+	}
+	if recreated := containerIDs(pod); !reflect.DeepEqual(recreated, ids) {
+		return fmt.Errorf("static pod restarted on mirror pod deletion (containers %v, expected %v)", recreated, ids)
+	}
+	res.RecreateLatency = time.Since(start)
+	res.RecreateLatencyString = res.RecreateLatency.String()
+	ts.cfg.Logger.Info("mirror pod recreated", zap.String("pod-name", podName), zap.String("latency", res.RecreateLatencyString))
+
+	start = time.Now()
+	if _, err := ts.runCommand(res.InstanceID, removeManifestCommands(res.StaticPodPath)); err != nil {
+		return err
+	}
+	if _, err = ts.waitForMirrorPod(podName, "deleted", func(pod *core_v1.Pod) bool {
+		return pod == nil
+	}); err != nil {
+		return err
+	}
+	res.RemoveLatency = time.Since(start)
+	res.RemoveLatencyString = res.RemoveLatency.String()
+	ts.cfg.Logger.Info("mirror pod deleted", zap.String("pod-name", podName), zap.String("latency", res.RemoveLatencyString))
+	return nil
+}
+
+// deleteMirrorPod deletes the mirror pod of the UID through the API server.
+func (ts *tester) deleteMirrorPod(podName string, uid types.UID) error {
+	ts.cfg.Logger.Info("deleting mirror pod", zap.String("pod-name", podName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		Delete(ctx, podName, meta_v1.DeleteOptions{Preconditions: &meta_v1.Preconditions{UID: &uid}})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to delete mirror pod %q (%v)", podName, err)
+	}
+	return nil
+}
+
+// waitForMirrorPod waits until "done" returns true for the mirror pod,
+// or for nil if the mirror pod is not found.
+func (ts *tester) waitForMirrorPod(podName string, desc string, done func(pod *core_v1.Pod) bool) (*core_v1.Pod, error) {
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, errors.New("wait aborted")
+		case <-time.After(2 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		pod, err := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace).Get(ctx, podName, meta_v1.GetOptions{})
+		cancel()
+		switch {
+		case k8s_errors.IsNotFound(err):
+			pod = nil
+		case err != nil:
+			ts.cfg.Logger.Warn("failed to get mirror pod; retrying", zap.String("pod-name", podName), zap.Error(err))
+			continue
+		}
+		if done(pod) {
+			return pod, nil
+		}
+		ts.cfg.Logger.Info("waiting for mirror pod",
+			zap.String("pod-name", podName),
+			zap.String("expected", desc),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+	}
+	return nil, fmt.Errorf("mirror pod %q not %s within %v", podName, desc, ts.cfg.Timeout)
+}
+
+// report returns the mirror pod latencies of the nodes.
+func (cfg *Config) report() string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"Node", "Instance ID", "Static Pod Path", "Create", "Recreate", "Remove"})
+	for _, res := range cfg.Results {
+		tb.Append([]string{
+			res.Node,
+			res.InstanceID,
+			res.StaticPodPath,
+			res.CreateLatencyString,
+			res.RecreateLatencyString,
+			res.RemoveLatencyString,
+		})
+	}
+	tb.Render()
+	return buf.String()
+}
@@ -0,0 +1,320 @@
+// Package status_reporter reports the k8s-tester run status to the CI systems,
+// as a GitHub commit status or check run with the link to the run summary,
+// and as an AWS CodeBuild test report, so that the cluster qualification runs
+// can gate the merges without custom scripts.
+// ref. https://docs.github.com/en/rest/commits/statuses
+// ref. https://docs.github.com/en/rest/checks/runs
+// ref. https://docs.aws.amazon.com/codebuild/latest/userguide/test-reporting.html
+package status_reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+
+	Logger *zap.Logger `json:"-"`
+
+	// GitHubRepository is the GitHub repository to report the status to, in the form "owner/repo".
+	// Defaults to "GITHUB_REPOSITORY" in GitHub Actions, or the source repository in CodeBuild.
+	// Leave empty to not report to GitHub.
+	// The token is read from the "GITHUB_TOKEN" environmental variable,
+	// to not persist it in the configuration file.
+	GitHubRepository string `json:"github_repository"`
+	// GitHubCommitSHA is the commit to report the status of.
+	// Defaults to "GITHUB_SHA" in GitHub Actions, or "CODEBUILD_RESOLVED_SOURCE_VERSION" in CodeBuild.
+	GitHubCommitSHA string `json:"github_commit_sha"`
+	// GitHubAPIURL is the GitHub API URL, to use with GitHub Enterprise Server.
+	// Defaults to "GITHUB_API_URL" in GitHub Actions, or "https://api.github.com".
+	GitHubAPIURL string `json:"github_api_url"`
+	// GitHubCheckRun is true to create a check run instead of a commit status,
+	// which shows the per-add-on summary in the pull request "Checks" tab.
+	// Check runs require a GitHub App installation token.
+	GitHubCheckRun bool `json:"github_check_run"`
+	// GitHubContext is the commit status context, or the check run name,
+	// that the branch protection rules require.
+	GitHubContext string `json:"github_context"`
+
+	// CodeBuildReportDir is the "base-directory" of a "JUNITXML" report group
+	// in the CodeBuild buildspec, to copy the JUnit report to.
+	// ref. https://docs.aws.amazon.com/codebuild/latest/userguide/report-group-test-cases.html
+	CodeBuildReportDir string `json:"codebuild_report_dir"`
+
+	// TargetURL is the link of the status, to the run summary.
+	// Defaults to the S3 HTML report if uploaded, or the CI build URL.
+	TargetURL string `json:"target_url"`
+
+	// Timeout is the timeout for each GitHub API request.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// GitHubCheckRunID is the ID of the check run created for the run.
+	GitHubCheckRunID int64 `json:"github_check_run_id" read-only:"true"`
+	// ReportedState is the last state reported.
+	ReportedState string `json:"reported_state" read-only:"true"`
+}
+
+const (
+	DefaultGitHubAPIURL  = "https://api.github.com"
+	DefaultGitHubContext = "k8s-tester"
+	DefaultTimeout       = 30 * time.Second
+
+	// TokenEnv is the environmental variable of the GitHub token.
+	TokenEnv = "GITHUB_TOKEN"
+
+	// maxDescription is the maximum length of the commit status description.
+	maxDescription = 140
+)
+
+const (
+	// StatePending is the state of a run in progress.
+	StatePending = "pending"
+	// StateSuccess is the state of a run with all add-ons applied.
+	StateSuccess = "success"
+	// StateFailure is the state of a failed run.
+	StateFailure = "failure"
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:        false,
+		GitHubContext: DefaultGitHubContext,
+		Timeout:       DefaultTimeout,
+	}
+}
+
+func Env() string {
+	return "STATUS_REPORTER"
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.GitHubRepository == "" {
+		cfg.GitHubRepository = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if cfg.GitHubRepository == "" {
+		cfg.GitHubRepository = codeBuildRepository(os.Getenv("CODEBUILD_SOURCE_REPO_URL"))
+	}
+	if cfg.GitHubCommitSHA == "" {
+		cfg.GitHubCommitSHA = os.Getenv("GITHUB_SHA")
+	}
+	if cfg.GitHubCommitSHA == "" {
+		cfg.GitHubCommitSHA = os.Getenv("CODEBUILD_RESOLVED_SOURCE_VERSION")
+	}
+	if cfg.GitHubAPIURL == "" {
+		cfg.GitHubAPIURL = os.Getenv("GITHUB_API_URL")
+	}
+	if cfg.GitHubAPIURL == "" {
+		cfg.GitHubAPIURL = DefaultGitHubAPIURL
+	}
+	cfg.GitHubAPIURL = strings.TrimSuffix(cfg.GitHubAPIURL, "/")
+	if cfg.GitHubContext == "" {
+		cfg.GitHubContext = DefaultGitHubContext
+	}
+	if cfg.GitHubRepository == "" && cfg.CodeBuildReportDir == "" {
+		return errors.New("empty GitHubRepository and CodeBuildReportDir, nothing to report to")
+	}
+	if cfg.GitHubRepository != "" {
+		if ss := strings.Split(cfg.GitHubRepository, "/"); len(ss) != 2 || ss[0] == "" || ss[1] == "" {
+			return fmt.Errorf("invalid GitHubRepository %q (expected 'owner/repo')", cfg.GitHubRepository)
+		}
+		if cfg.GitHubCommitSHA == "" {
+			return errors.New("empty GitHubCommitSHA")
+		}
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+// codeBuildRepository returns the "owner/repo" of a GitHub source repository URL of CodeBuild
+// (e.g., "https://github.com/aws/aws-k8s-tester.git"), or an empty string for the other sources.
+func codeBuildRepository(u string) string {
+	const prefix = "https://github.com/"
+	if !strings.HasPrefix(u, prefix) {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(u, prefix), ".git")
+}
+
+// BuildURL returns the URL of the GitHub Actions run or the CodeBuild build, if running in one.
+func BuildURL() string {
+	if u := os.Getenv("CODEBUILD_BUILD_URL"); u != "" {
+		return u
+	}
+	if os.Getenv("GITHUB_RUN_ID") != "" {
+		server := os.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		return fmt.Sprintf("%s/%s/actions/runs/%s", server, os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"))
+	}
+	return ""
+}
+
+// Status is the run status to report.
+type Status struct {
+	// State is either "pending", "success", or "failure".
+	State string
+	// Description is the one-line summary (e.g., "12 passed, 1 failed, 2 skipped").
+	Description string
+	// Summary is the Markdown summary of the check run (e.g., the per-add-on results table).
+	Summary string
+	// TargetURL is the link to the run summary, overridden by "Config.TargetURL".
+	TargetURL string
+	// JUnitPath is the JUnit report to copy to "CodeBuildReportDir".
+	JUnitPath string
+}
+
+// Report reports the status to GitHub and CodeBuild.
+func Report(cfg *Config, st Status) error {
+	if cfg.TargetURL != "" {
+		st.TargetURL = cfg.TargetURL
+	}
+	var errs []string
+	if cfg.GitHubRepository != "" {
+		var err error
+		if cfg.GitHubCheckRun {
+			err = cfg.reportCheckRun(st)
+		} else {
+			err = cfg.reportCommitStatus(st)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			cfg.Logger.Info("reported status to GitHub",
+				zap.String("repository", cfg.GitHubRepository),
+				zap.String("commit", cfg.GitHubCommitSHA),
+				zap.String("state", st.State),
+			)
+		}
+	}
+	if cfg.CodeBuildReportDir != "" && st.State != StatePending && st.JUnitPath != "" {
+		if err := copyFile(st.JUnitPath, filepath.Join(cfg.CodeBuildReportDir, filepath.Base(st.JUnitPath))); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to copy JUnit report to %q (%v)", cfg.CodeBuildReportDir, err))
+		} else {
+			cfg.Logger.Info("copied JUnit report for CodeBuild", zap.String("dir", cfg.CodeBuildReportDir))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	cfg.ReportedState = st.State
+	return nil
+}
+
+func (cfg *Config) reportCommitStatus(st Status) error {
+	body := map[string]string{
+		"state":       st.State,
+		"description": truncate(st.Description, maxDescription),
+		"context":     cfg.GitHubContext,
+	}
+	if st.TargetURL != "" {
+		body["target_url"] = st.TargetURL
+	}
+	_, err := cfg.do(http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", cfg.GitHubRepository, cfg.GitHubCommitSHA), body)
+	return err
+}
+
+// reportCheckRun creates the check run on the first report, and updates it after.
+func (cfg *Config) reportCheckRun(st Status) error {
+	body := map[string]interface{}{
+		"name":     cfg.GitHubContext,
+		"head_sha": cfg.GitHubCommitSHA,
+		"output": map[string]string{
+			"title":   truncate(st.Description, maxDescription),
+			"summary": st.Summary,
+		},
+	}
+	if st.TargetURL != "" {
+		body["details_url"] = st.TargetURL
+	}
+	if st.State == StatePending {
+		body["status"] = "in_progress"
+	} else {
+		body["status"] = "completed"
+		body["conclusion"] = st.State
+		body["completed_at"] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	if cfg.GitHubCheckRunID == 0 {
+		b, err := cfg.do(http.MethodPost, fmt.Sprintf("/repos/%s/check-runs", cfg.GitHubRepository), body)
+		if err != nil {
+			return err
+		}
+		var run struct {
+			ID int64 `json:"id"`
+		}
+		if err = json.Unmarshal(b, &run); err != nil {
+			return fmt.Errorf("failed to parse check run (%v)", err)
+		}
+		cfg.GitHubCheckRunID = run.ID
+		return nil
+	}
+	_, err := cfg.do(http.MethodPatch, fmt.Sprintf("/repos/%s/check-runs/%d", cfg.GitHubRepository, cfg.GitHubCheckRunID), body)
+	return err
+}
+
+func (cfg *Config) do(method string, path string, body interface{}) ([]byte, error) {
+	d, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, cfg.GitHubAPIURL+path, bytes.NewReader(d))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv(TokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s %q (%v)", method, path, err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to %s %q (status %q, %s)", method, path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+func truncate(s string, n int) string {
+	rs := []rune(s)
+	if len(rs) <= n {
+		return s
+	}
+	return string(rs[:n-3]) + "..."
+}
+
+func copyFile(src string, dst string) error {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, b, 0600)
+}
@@ -0,0 +1,179 @@
+package status_reporter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type request struct {
+	method string
+	path   string
+	auth   string
+	body   map[string]interface{}
+}
+
+func newServer(t *testing.T) (*httptest.Server, *[]request) {
+	reqs := make([]request, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{method: r.Method, path: r.URL.Path, auth: r.Header.Get("Authorization")}
+		if err := json.NewDecoder(r.Body).Decode(&req.body); err != nil {
+			t.Errorf("failed to decode body (%v)", err)
+		}
+		reqs = append(reqs, req)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":42}`))
+	}))
+	return ts, &reqs
+}
+
+func TestReportCommitStatus(t *testing.T) {
+	srv, reqs := newServer(t)
+	defer srv.Close()
+	os.Setenv(TokenEnv, "hello-token")
+	defer os.Unsetenv(TokenEnv)
+
+	cfg := NewDefault()
+	cfg.Enable = true
+	cfg.Logger = zap.NewExample()
+	cfg.GitHubRepository = "aws/aws-k8s-tester"
+	cfg.GitHubCommitSHA = "abc123"
+	cfg.GitHubAPIURL = srv.URL + "/"
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	err := Report(cfg, Status{
+		State:       StateFailure,
+		Description: strings.Repeat("x", 200),
+		TargetURL:   "https://example.com/report.html",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*reqs) != 1 {
+		t.Fatalf("unexpected requests %+v", *reqs)
+	}
+	req := (*reqs)[0]
+	if req.method != http.MethodPost || req.path != "/repos/aws/aws-k8s-tester/statuses/abc123" || req.auth != "Bearer hello-token" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if req.body["state"] != StateFailure || req.body["context"] != DefaultGitHubContext || req.body["target_url"] != "https://example.com/report.html" {
+		t.Fatalf("unexpected body %+v", req.body)
+	}
+	if d := req.body["description"].(string); len(d) != maxDescription || !strings.HasSuffix(d, "...") {
+		t.Fatalf("unexpected description %q", d)
+	}
+	if cfg.ReportedState != StateFailure {
+		t.Fatalf("unexpected ReportedState %q", cfg.ReportedState)
+	}
+}
+
+func TestReportCheckRun(t *testing.T) {
+	srv, reqs := newServer(t)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "status-reporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	junitPath := filepath.Join(dir, "k8s-tester.junit.xml")
+	if err = ioutil.WriteFile(junitPath, []byte("<testsuites/>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewDefault()
+	cfg.Enable = true
+	cfg.Logger = zap.NewExample()
+	cfg.GitHubRepository = "aws/aws-k8s-tester"
+	cfg.GitHubCommitSHA = "abc123"
+	cfg.GitHubAPIURL = srv.URL
+	cfg.GitHubCheckRun = true
+	cfg.CodeBuildReportDir = filepath.Join(dir, "codebuild")
+	cfg.TargetURL = "https://example.com/override"
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Report(cfg, Status{State: StatePending, Description: "applying", JUnitPath: junitPath}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GitHubCheckRunID != 42 {
+		t.Fatalf("unexpected GitHubCheckRunID %d", cfg.GitHubCheckRunID)
+	}
+	if _, err = os.Stat(filepath.Join(cfg.CodeBuildReportDir, "k8s-tester.junit.xml")); !os.IsNotExist(err) {
+		t.Fatalf("unexpected JUnit report copied while pending (%v)", err)
+	}
+	if err = Report(cfg, Status{State: StateSuccess, Description: "1 passed", Summary: "| a | b |", JUnitPath: junitPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*reqs) != 2 {
+		t.Fatalf("unexpected requests %+v", *reqs)
+	}
+	created, updated := (*reqs)[0], (*reqs)[1]
+	if created.method != http.MethodPost || created.path != "/repos/aws/aws-k8s-tester/check-runs" {
+		t.Fatalf("unexpected request %+v", created)
+	}
+	if created.body["status"] != "in_progress" || created.body["head_sha"] != "abc123" || created.body["details_url"] != "https://example.com/override" {
+		t.Fatalf("unexpected body %+v", created.body)
+	}
+	if updated.method != http.MethodPatch || updated.path != "/repos/aws/aws-k8s-tester/check-runs/42" {
+		t.Fatalf("unexpected request %+v", updated)
+	}
+	if updated.body["status"] != "completed" || updated.body["conclusion"] != StateSuccess {
+		t.Fatalf("unexpected body %+v", updated.body)
+	}
+	if output := updated.body["output"].(map[string]interface{}); output["summary"] != "| a | b |" {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(cfg.CodeBuildReportDir, "k8s-tester.junit.xml"))
+	if err != nil || string(b) != "<testsuites/>" {
+		t.Fatalf("unexpected JUnit report copy %q (%v)", string(b), err)
+	}
+}
+
+func TestValidateAndSetDefaults(t *testing.T) {
+	if repo := codeBuildRepository("https://github.com/aws/aws-k8s-tester.git"); repo != "aws/aws-k8s-tester" {
+		t.Fatalf("unexpected repository %q", repo)
+	}
+	if repo := codeBuildRepository("https://git-codecommit.us-west-2.amazonaws.com/v1/repos/hello"); repo != "" {
+		t.Fatalf("unexpected repository %q", repo)
+	}
+
+	os.Setenv("GITHUB_REPOSITORY", "")
+	defer os.Unsetenv("GITHUB_REPOSITORY")
+	os.Setenv("CODEBUILD_SOURCE_REPO_URL", "")
+	defer os.Unsetenv("CODEBUILD_SOURCE_REPO_URL")
+	os.Setenv("GITHUB_SHA", "")
+	defer os.Unsetenv("GITHUB_SHA")
+	os.Setenv("CODEBUILD_RESOLVED_SOURCE_VERSION", "")
+	defer os.Unsetenv("CODEBUILD_RESOLVED_SOURCE_VERSION")
+
+	cfg := NewDefault()
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error without GitHubRepository and CodeBuildReportDir")
+	}
+	cfg.GitHubRepository = "aws"
+	cfg.GitHubCommitSHA = "abc123"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected invalid GitHubRepository error")
+	}
+
+	os.Setenv("CODEBUILD_SOURCE_REPO_URL", "https://github.com/aws/aws-k8s-tester.git")
+	os.Setenv("CODEBUILD_RESOLVED_SOURCE_VERSION", "def456")
+	cfg = NewDefault()
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GitHubRepository != "aws/aws-k8s-tester" || cfg.GitHubCommitSHA != "def456" || cfg.GitHubAPIURL != DefaultGitHubAPIURL {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
@@ -0,0 +1,253 @@
+package k8s_tester
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_client "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// AddOnStateHealthy is the add-on that is applied, with all Pods in its namespace running.
+	AddOnStateHealthy = "healthy"
+	// AddOnStateUnhealthy is the add-on that is applied, with some Pods in its namespace not running.
+	AddOnStateUnhealthy = "unhealthy"
+	// AddOnStateInstalled is the add-on that is applied, without its own namespace to check.
+	AddOnStateInstalled = "installed"
+	// AddOnStateMissing is the add-on that is applied, but its namespace is not found.
+	AddOnStateMissing = "missing"
+	// AddOnStateOrphaned is the namespace left behind by an add-on that is not applied,
+	// or by a previous run with a different configuration.
+	AddOnStateOrphaned = "orphaned"
+	// AddOnStateNotInstalled is the add-on that is not applied.
+	AddOnStateNotInstalled = "not-installed"
+)
+
+// AddOnStatus is the status of an add-on in the cluster.
+type AddOnStatus struct {
+	// Name is the add-on name.
+	Name string `json:"name"`
+	// Enabled is true if the add-on is enabled in the configuration.
+	Enabled bool `json:"enabled"`
+	// TesterStatus is the tester status recorded in the configuration.
+	TesterStatus string `json:"tester_status"`
+	// Namespace is the add-on namespace, if any.
+	Namespace string `json:"namespace,omitempty"`
+	// State is the add-on state in the cluster.
+	State string `json:"state"`
+	// Message is the details of the state.
+	Message string `json:"message,omitempty"`
+}
+
+// Status queries the cluster for the add-ons in the configuration,
+// and returns which add-ons are installed, healthy, or orphaned.
+// It does not create or delete any resource.
+func (cfg *Config) Status(lg *zap.Logger) ([]AddOnStatus, error) {
+	// read-only, thus no kubectl to install
+	c, err := client.NewKubernetesClient(&client.Config{
+		Logger:            lg,
+		KubeconfigPath:    cfg.KubeconfigPath,
+		KubeconfigContext: cfg.KubeconfigContext,
+		ConfigMode:        cfg.ClientConfigMode,
+		EKS:               cfg.clientEKS(),
+		ClientQPS:         cfg.ClientQPS,
+		ClientBurst:       cfg.ClientBurst,
+		ClientTimeout:     cfg.ClientTimeout,
+		RequestTimeout:    cfg.ClientRequestTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nss, err := client.ListNamespaces(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces (%v)", err)
+	}
+	existing := make(map[string]core_v1.Namespace, len(nss))
+	for _, ns := range nss {
+		existing[ns.Name] = ns
+	}
+
+	type addOn struct {
+		name      string
+		statusKey string
+		enabled   bool
+		namespace string
+	}
+	addOns := make([]addOn, 0)
+	configured := make(map[string]struct{})
+	tp, vv := reflect.TypeOf(cfg).Elem(), reflect.ValueOf(cfg).Elem()
+	for i := 0; i < tp.NumField(); i++ {
+		if !strings.HasPrefix(tp.Field(i).Name, "AddOn") || vv.Field(i).Kind() != reflect.Ptr || vv.Field(i).IsNil() {
+			continue
+		}
+		name, key := addOnNames(tp.Field(i))
+		ao := addOn{name: name, statusKey: key}
+		fv := vv.Field(i).Elem()
+		if ev := fv.FieldByName("Enable"); ev.IsValid() && ev.Kind() == reflect.Bool {
+			ao.enabled = ev.Bool()
+		}
+		if nv := fv.FieldByName("Namespace"); nv.IsValid() && nv.Kind() == reflect.String {
+			ao.namespace = nv.String()
+			configured[ao.namespace] = struct{}{}
+		}
+		addOns = append(addOns, ao)
+	}
+
+	ss := make([]AddOnStatus, 0, len(addOns))
+	orphaned := make(map[string]struct{})
+	for _, ao := range addOns {
+		st := AddOnStatus{
+			Name:         ao.statusKey,
+			Enabled:      ao.enabled,
+			TesterStatus: cfg.GetTesterStatus(ao.statusKey),
+			Namespace:    ao.namespace,
+		}
+		applied := ao.enabled && st.TesterStatus == TesterStatusApplied
+		ns, found := existing[ao.namespace]
+
+		switch {
+		case ao.namespace == "" && applied:
+			st.State = AddOnStateInstalled
+		case ao.namespace == "":
+			st.State = AddOnStateNotInstalled
+		case applied && !found:
+			st.State, st.Message = AddOnStateMissing, "namespace not found"
+		case applied:
+			st.State, st.Message, err = checkNamespacePods(c, ns)
+			if err != nil {
+				return nil, err
+			}
+		case found:
+			st.State = AddOnStateOrphaned
+			st.Message = fmt.Sprintf("namespace %s, created %s", ns.Status.Phase, ns.CreationTimestamp.Format(time.RFC3339))
+		default:
+			st.State = AddOnStateNotInstalled
+		}
+		ss = append(ss, st)
+
+		// namespaces left behind by previous runs with the default namespace names
+		pattern := orphanNamespacePattern(ao.name)
+		for _, ns := range nss {
+			if _, ok := configured[ns.Name]; ok || !pattern.MatchString(ns.Name) {
+				continue
+			}
+			if _, ok := orphaned[ns.Name]; ok {
+				continue
+			}
+			orphaned[ns.Name] = struct{}{}
+			ss = append(ss, AddOnStatus{
+				Name:         ao.statusKey,
+				Enabled:      ao.enabled,
+				TesterStatus: st.TesterStatus,
+				Namespace:    ns.Name,
+				State:        AddOnStateOrphaned,
+				Message:      fmt.Sprintf("namespace not in config, %s, created %s", ns.Status.Phase, ns.CreationTimestamp.Format(time.RFC3339)),
+			})
+		}
+	}
+	return ss, nil
+}
+
+// addOnTesterNames is the tester "Name" of the config fields
+// whose JSON names do not match the tester "Name".
+var addOnTesterNames = map[string]string{
+	"AddOnEndpointSlices": "endpointslices",
+	"AddOnCronJobsEcho":   "jobs-echo",
+}
+
+// addOnStatusKeys is the "TesterStatus" key of the config fields
+// whose testers are not keyed by the tester "Name".
+var addOnStatusKeys = map[string]string{
+	"AddOnCronJobsEcho": cronJobsEchoStatusKey,
+}
+
+// addOnNames returns the tester "Name" of the add-on config field,
+// and its key in "TesterStatus" (see "tester.statusKey").
+func addOnNames(field reflect.StructField) (name string, statusKey string) {
+	name, ok := addOnTesterNames[field.Name]
+	if !ok {
+		jv := strings.Replace(field.Tag.Get("json"), ",omitempty", "", -1)
+		name = strings.Replace(strings.TrimPrefix(jv, "add_on_"), "_", "-", -1)
+	}
+	statusKey, ok = addOnStatusKeys[field.Name]
+	if !ok {
+		statusKey = name
+	}
+	return name, statusKey
+}
+
+// orphanNamespacePattern matches the default namespace names of the tester,
+// e.g., "configmaps-" + rand.String(10) + "-" + utils_time.GetTS(10),
+// with or without the run ID suffix (see "runNamespace").
+func orphanNamespacePattern(name string) *regexp.Regexp {
+	return regexp.MustCompile("^" + regexp.QuoteMeta(name) + "-[a-z0-9]{10}-[0-9]{10}(-[a-z0-9]([-a-z0-9]*[a-z0-9])?)?$")
+}
+
+// checkNamespacePods returns "unhealthy" if the namespace is terminating,
+// or if any Pod in the namespace is neither running with all containers ready nor succeeded.
+func checkNamespacePods(c k8s_client.Interface, ns core_v1.Namespace) (state string, msg string, err error) {
+	if ns.Status.Phase == core_v1.NamespaceTerminating {
+		return AddOnStateUnhealthy, "namespace terminating", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := c.CoreV1().Pods(ns.Name).List(ctx, meta_v1.ListOptions{})
+	cancel()
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return AddOnStateMissing, "namespace not found", nil
+		}
+		return "", "", fmt.Errorf("failed to list pods in %q (%v)", ns.Name, err)
+	}
+
+	notReady := make([]string, 0)
+	for _, pod := range pods.Items {
+		switch pod.Status.Phase {
+		case core_v1.PodSucceeded:
+			continue
+		case core_v1.PodRunning:
+			ready := true
+			for _, cs := range pod.Status.ContainerStatuses {
+				if !cs.Ready {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				continue
+			}
+		}
+		notReady = append(notReady, fmt.Sprintf("%s (%s)", pod.Name, pod.Status.Phase))
+	}
+	if len(notReady) > 0 {
+		return AddOnStateUnhealthy, fmt.Sprintf("%d of %d pod(s) not ready %v", len(notReady), len(pods.Items), notReady), nil
+	}
+	return AddOnStateHealthy, fmt.Sprintf("%d pod(s)", len(pods.Items)), nil
+}
+
+// StatusTable returns the table of the add-on statuses.
+func StatusTable(ss []AddOnStatus) string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"Add-on", "Enabled", "Tester Status", "Namespace", "State", "Message"})
+	for _, st := range ss {
+		tb.Append([]string{st.Name, fmt.Sprintf("%v", st.Enabled), st.TesterStatus, st.Namespace, st.State, st.Message})
+	}
+	tb.Render()
+	return buf.String()
+}
@@ -0,0 +1,91 @@
+package k8s_tester
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	status_reporter "github.com/aws/aws-k8s-tester/k8s-tester/status-reporter"
+	"go.uber.org/zap"
+)
+
+// createStatus returns the CI status of the "apply" results, "pending" until
+// the "apply" is done, with the per-add-on results table as the summary.
+func createStatus(clusterName string, results []testResult, testers int, done bool, applyErr error) status_reporter.Status {
+	st := status_reporter.Status{State: status_reporter.StatePending}
+	if !done {
+		st.Description = fmt.Sprintf("applying %d add-ons on %q", testers, clusterName)
+		st.Summary = st.Description
+		return st
+	}
+
+	st.State = status_reporter.StateSuccess
+	if applyErr != nil {
+		st.State = status_reporter.StateFailure
+	}
+	var passed, failed, skipped int
+	var took time.Duration
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(fmt.Sprintf("Cluster `%s`\n\n", clusterName))
+	buf.WriteString("| Add-on | Result | Took |\n|---|---|---|\n")
+	for _, rs := range results {
+		result := ResultPassed
+		switch {
+		case rs.skipped:
+			skipped++
+			result = ResultSkipped + ": " + rs.skipNote
+		case rs.err != nil:
+			failed++
+			result = ResultFailed + ": " + rs.err.Error()
+		default:
+			passed++
+		}
+		took += rs.took
+		buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", rs.name, markdownCell(result), rs.took.Round(time.Second)))
+	}
+	st.Description = fmt.Sprintf("%d passed, %d failed, %d skipped (%s)", passed, failed, skipped, took.Round(time.Second))
+	if applyErr != nil && failed == 0 {
+		// e.g., failed to delete the add-ons, or timed out
+		st.Description = fmt.Sprintf("%s, %v", st.Description, applyErr)
+		buf.WriteString(fmt.Sprintf("\n%s\n", markdownCell(applyErr.Error())))
+	}
+	st.Summary = buf.String()
+	return st
+}
+
+// markdownCell escapes the text for a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r", " ", "\n", " ").Replace(s)
+}
+
+// statusTargetURL returns the link of the CI status, to the HTML report
+// uploaded to S3, or to the CI build.
+func (ts *tester) statusTargetURL() string {
+	if ts.cfg.ArtifactsS3 != nil && ts.cfg.ArtifactsS3.Enable && ts.cfg.ReportHTMLPath != "" {
+		name := filepath.Base(ts.cfg.ReportHTMLPath)
+		for _, key := range ts.cfg.ArtifactsS3.UploadedKeys {
+			if key == name || strings.HasSuffix(key, "/"+name) {
+				return fmt.Sprintf("https://s3.console.aws.amazon.com/s3/object/%s?region=%s&prefix=%s",
+					ts.cfg.ArtifactsS3.BucketName, ts.cfg.ArtifactsS3.Region, url.QueryEscape(key))
+			}
+		}
+	}
+	return status_reporter.BuildURL()
+}
+
+// reportStatus reports the CI status of the "apply", if enabled.
+func (ts *tester) reportStatus(done bool, applyErr error) {
+	if ts.cfg.StatusReporter == nil || !ts.cfg.StatusReporter.Enable {
+		return
+	}
+	ts.cfg.StatusReporter.Logger = ts.logger
+	st := createStatus(ts.cfg.ClusterName, ts.results, len(ts.testers), done, applyErr)
+	st.TargetURL = ts.statusTargetURL()
+	st.JUnitPath = ts.cfg.ReportJUnitPath
+	if err := status_reporter.Report(ts.cfg.StatusReporter, st); err != nil {
+		ts.logger.Warn("failed to report status", zap.String("state", st.State), zap.Error(err))
+	}
+}
@@ -0,0 +1,58 @@
+package k8s_tester
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddOnNames(t *testing.T) {
+	tp := reflect.TypeOf(Config{})
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		if !strings.HasPrefix(field.Name, "AddOn") || field.Type.Kind() != reflect.Ptr {
+			continue
+		}
+		// each tester is named after its package (see "pkgName")
+		pkgPath := field.Type.Elem().PkgPath()
+		expected := path.Base(pkgPath)
+		if strings.HasSuffix(pkgPath, "/stress/in-cluster") {
+			expected = "stress-" + expected
+		}
+		expectedKey := expected
+		if field.Name == "AddOnCronJobsEcho" {
+			expectedKey = cronJobsEchoStatusKey
+		}
+
+		name, key := addOnNames(field)
+		if name != expected {
+			t.Fatalf("%s: expected name %q, got %q", field.Name, expected, name)
+		}
+		if key != expectedKey {
+			t.Fatalf("%s: expected status key %q, got %q", field.Name, expectedKey, key)
+		}
+	}
+}
+
+func TestOrphanNamespacePattern(t *testing.T) {
+	tests := []struct {
+		ns      string
+		matched bool
+	}{
+		{"endpointslices-abcdefghij-1234567890", true},
+		{"endpointslices-abcdefghij-1234567890-abc123", true},
+		{"endpointslices-abcdefghij-1234567890-abc-123", true},
+		{"endpointslices-abcdefghij-1234567890-", false},
+		{"endpointslices-abcdefghij-1234567890-abc-", false},
+		{"endpoint-slices-abcdefghij-1234567890", false},
+		{"endpointslices", false},
+		{"endpointslices-abcdefghi-1234567890", false},
+	}
+	pattern := orphanNamespacePattern("endpointslices")
+	for i, tt := range tests {
+		if matched := pattern.MatchString(tt.ns); matched != tt.matched {
+			t.Fatalf("#%d: %q expected matched %v, got %v", i, tt.ns, tt.matched, matched)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Package steady_state waits for the cluster to settle between add-ons,
+// until the pod churn, the pending pods, and the API server error rate
+// drop below the thresholds, so that the cleanup of the previous add-on
+// does not skew the measurements of the next one.
+package steady_state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// Interval is the duration between each sample.
+	// The pod churn and the API error rate are computed over this interval.
+	Interval       time.Duration `json:"interval"`
+	IntervalString string        `json:"interval_string" read-only:"true"`
+	// Timeout is the maximum duration to wait for the steady state after each add-on.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+	// StableSamples is the number of consecutive samples below the thresholds
+	// to consider the cluster settled.
+	StableSamples int `json:"stable_samples"`
+	// FailOnTimeout is true to fail "apply" if the cluster does not settle in time.
+	// Otherwise, the next add-on starts with a warning.
+	FailOnTimeout bool `json:"fail_on_timeout"`
+
+	// MaxPodChurn is the maximum number of pods created or deleted within an interval.
+	MaxPodChurn int `json:"max_pod_churn"`
+	// MaxPendingPods is the maximum number of pods in "Pending" phase.
+	MaxPendingPods int `json:"max_pending_pods"`
+	// MaxAPIErrorRate is the maximum ratio of API server requests within an interval
+	// that returned 5xx or 429, from the "apiserver_request_total" metrics.
+	MaxAPIErrorRate float64 `json:"max_api_error_rate"`
+
+	// Waits is the list of steady state waits in the last "apply".
+	Waits []Wait `json:"waits" read-only:"true"`
+}
+
+// Wait is the result of a steady state wait after an add-on.
+type Wait struct {
+	// After is the add-on name that ran before the wait.
+	After string `json:"after"`
+	// Settled is true if the cluster settled before the timeout.
+	Settled bool `json:"settled"`
+	// Took is the duration of the wait.
+	Took       time.Duration `json:"took"`
+	TookString string        `json:"took_string"`
+	// Last is the last sample.
+	Last Sample `json:"last"`
+}
+
+// Sample is a cluster sample within an interval.
+type Sample struct {
+	PodChurn     int     `json:"pod_churn"`
+	PendingPods  int     `json:"pending_pods"`
+	APIErrorRate float64 `json:"api_error_rate"`
+}
+
+const (
+	DefaultInterval        = 15 * time.Second
+	DefaultTimeout         = 10 * time.Minute
+	DefaultStableSamples   = 2
+	DefaultMaxPodChurn     = 5
+	DefaultMaxPendingPods  = 0
+	DefaultMaxAPIErrorRate = 0.01
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:          false,
+		Interval:        DefaultInterval,
+		Timeout:         DefaultTimeout,
+		StableSamples:   DefaultStableSamples,
+		MaxPodChurn:     DefaultMaxPodChurn,
+		MaxPendingPods:  DefaultMaxPendingPods,
+		MaxAPIErrorRate: DefaultMaxAPIErrorRate,
+	}
+}
+
+func Env() string {
+	return "STEADY_STATE"
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultInterval
+	}
+	cfg.IntervalString = cfg.Interval.String()
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	if cfg.Timeout < cfg.Interval {
+		return fmt.Errorf("Timeout %v < Interval %v", cfg.Timeout, cfg.Interval)
+	}
+	if cfg.StableSamples <= 0 {
+		cfg.StableSamples = DefaultStableSamples
+	}
+	if cfg.MaxPodChurn < 0 {
+		return fmt.Errorf("invalid MaxPodChurn %d", cfg.MaxPodChurn)
+	}
+	if cfg.MaxPendingPods < 0 {
+		return fmt.Errorf("invalid MaxPendingPods %d", cfg.MaxPendingPods)
+	}
+	if cfg.MaxAPIErrorRate < 0 || cfg.MaxAPIErrorRate > 1 {
+		return fmt.Errorf("invalid MaxAPIErrorRate %v", cfg.MaxAPIErrorRate)
+	}
+	return nil
+}
+
+// Wait blocks until the cluster settles after the add-on "after",
+// and appends the result to "Waits". It returns an error on timeout
+// only if "FailOnTimeout" is true.
+func (cfg *Config) Wait(after string) error {
+	cfg.Logger.Info("waiting for steady state",
+		zap.String("after", after),
+		zap.String("interval", cfg.Interval.String()),
+		zap.String("timeout", cfg.Timeout.String()),
+	)
+	start := time.Now()
+	wait := Wait{After: after}
+	defer func() {
+		wait.Took = time.Since(start)
+		wait.TookString = wait.Took.String()
+		cfg.Waits = append(cfg.Waits, wait)
+	}()
+
+	prevPods, _, err := cfg.listPods()
+	if err != nil {
+		cfg.Logger.Warn("failed to list pods", zap.Error(err))
+	}
+	prevTotal, prevErrors, err := cfg.apiRequestCounts()
+	if err != nil {
+		cfg.Logger.Warn("failed to read API server metrics", zap.Error(err))
+	}
+
+	stable := 0
+	var problems []string
+	for time.Since(start) < cfg.Timeout {
+		select {
+		case <-cfg.Stopc:
+			return errors.New("steady state wait aborted")
+		case <-time.After(cfg.Interval):
+		}
+
+		var sample Sample
+		problems = nil
+
+		pods, pending, err := cfg.listPods()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("failed to list pods (%v)", err))
+		} else {
+			sample.PendingPods = pending
+			if prevPods != nil {
+				sample.PodChurn = churn(prevPods, pods)
+			}
+			prevPods = pods
+		}
+
+		total, errs, err := cfg.apiRequestCounts()
+		if err != nil {
+			// some managed control planes do not expose the API server metrics
+			cfg.Logger.Warn("failed to read API server metrics; skipping error rate", zap.Error(err))
+		} else {
+			if prevTotal > 0 && total > prevTotal {
+				sample.APIErrorRate = (errs - prevErrors) / (total - prevTotal)
+			}
+			prevTotal, prevErrors = total, errs
+		}
+		wait.Last = sample
+
+		if sample.PodChurn > cfg.MaxPodChurn {
+			problems = append(problems, fmt.Sprintf("pod churn %d > %d", sample.PodChurn, cfg.MaxPodChurn))
+		}
+		if sample.PendingPods > cfg.MaxPendingPods {
+			problems = append(problems, fmt.Sprintf("pending pods %d > %d", sample.PendingPods, cfg.MaxPendingPods))
+		}
+		if sample.APIErrorRate > cfg.MaxAPIErrorRate {
+			problems = append(problems, fmt.Sprintf("API error rate %.4f > %.4f", sample.APIErrorRate, cfg.MaxAPIErrorRate))
+		}
+		if len(problems) > 0 {
+			stable = 0
+			cfg.Logger.Info("cluster not settled yet", zap.String("after", after), zap.Strings("problems", problems))
+			continue
+		}
+
+		stable++
+		cfg.Logger.Info("cluster sample below thresholds",
+			zap.String("after", after),
+			zap.Int("stable-samples", stable),
+			zap.Int("pod-churn", sample.PodChurn),
+			zap.Int("pending-pods", sample.PendingPods),
+			zap.Float64("api-error-rate", sample.APIErrorRate),
+		)
+		if stable >= cfg.StableSamples {
+			wait.Settled = true
+			fmt.Fprintf(cfg.LogWriter, "\ncluster settled after %q (took %v)\n\n", after, time.Since(start))
+			return nil
+		}
+	}
+
+	err = fmt.Errorf("cluster did not settle after %q in %v (%s)", after, cfg.Timeout, strings.Join(problems, ", "))
+	if cfg.FailOnTimeout {
+		return err
+	}
+	cfg.Logger.Warn("steady state wait timed out; continuing", zap.Error(err))
+	return nil
+}
+
+func (cfg *Config) listPods() (uids map[types.UID]struct{}, pending int, err error) {
+	pods, err := client.ListPods(cfg.Logger, cfg.Client.KubernetesClient(), "", 3000, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	uids = make(map[types.UID]struct{}, len(pods))
+	for _, pod := range pods {
+		uids[pod.UID] = struct{}{}
+		if pod.Status.Phase == core_v1.PodPending {
+			pending++
+		}
+	}
+	return uids, pending, nil
+}
+
+// churn returns the number of pods created or deleted between two samples.
+func churn(prev map[types.UID]struct{}, cur map[types.UID]struct{}) (n int) {
+	for uid := range cur {
+		if _, ok := prev[uid]; !ok {
+			n++
+		}
+	}
+	for uid := range prev {
+		if _, ok := cur[uid]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// apiRequestCounts returns the cumulative API server request count,
+// and the count of the requests that returned 5xx or 429.
+func (cfg *Config) apiRequestCounts() (total float64, errs float64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	b, err := cfg.Client.KubernetesClient().
+		CoreV1().
+		RESTClient().
+		Get().
+		AbsPath("/metrics").
+		DoRaw(ctx)
+	cancel()
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseAPIRequestCounts(b)
+}
+
+func parseAPIRequestCounts(b []byte) (total float64, errs float64, err error) {
+	var parser expfmt.TextParser
+	mfs, err := parser.TextToMetricFamilies(bytes.NewReader(b))
+	if err != nil {
+		return 0, 0, err
+	}
+	mf, ok := mfs["apiserver_request_total"]
+	if !ok {
+		return 0, 0, errors.New("'apiserver_request_total' not found")
+	}
+	for _, m := range mf.GetMetric() {
+		v := m.GetCounter().GetValue()
+		total += v
+		for _, l := range m.GetLabel() {
+			if l.GetName() != "code" {
+				continue
+			}
+			if code := l.GetValue(); strings.HasPrefix(code, "5") || code == "429" {
+				errs += v
+			}
+		}
+	}
+	return total, errs, nil
+}
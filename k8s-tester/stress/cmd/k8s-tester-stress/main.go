@@ -34,6 +34,7 @@ var (
 	kubectlDownloadURL    string
 	kubectlPath           string
 	kubeconfigPath        string
+	clientConfigMode      string
 )
 
 func init() {
@@ -46,6 +47,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
 	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
 	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+	rootCmd.PersistentFlags().StringVar(&clientConfigMode, "client-config-mode", client.ConfigModeAuto, "client configuration source ('auto' to fall back to the in-cluster config without '--kubeconfig-path', 'kubeconfig', or 'in-cluster')")
 
 	rootCmd.AddCommand(
 		newApply(),
@@ -69,13 +71,19 @@ var (
 	repositoryName      string
 	repositoryImageTag  string
 
+	restrictedPodSecurity bool
+
 	runTimeout        time.Duration
 	clients           int
+	objectKinds       []string
 	objectKeyPrefix   string
 	objects           int
 	objectSize        int
 	updateConcurrency int
 	listBatchLimit    int64
+
+	prometheusPushgatewayURL string
+	prometheusPushJobName    string
 )
 
 func newApply() *cobra.Command {
@@ -92,14 +100,20 @@ func newApply() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&repositoryName, "repository-name", "", "repository name for tester ECR image")
 	cmd.PersistentFlags().StringVar(&repositoryImageTag, "repository-image-tag", "", "image tag for tester ECR image")
 
+	cmd.PersistentFlags().BoolVar(&restrictedPodSecurity, "restricted-pod-security", false, "'true' to create stress Pods with the restricted Pod Security Standard security context")
+
 	cmd.PersistentFlags().DurationVar(&runTimeout, "run-timeout", stress.DefaultRunTimeout, "run timeout")
 	cmd.PersistentFlags().IntVar(&clients, "clients", 5, "number of clients")
+	cmd.PersistentFlags().StringSliceVar(&objectKinds, "object-kinds", stress.DefaultObjectKinds(), "object kinds to stress (pods, configmaps, secrets, endpoints, leases, events, crds)")
 	cmd.PersistentFlags().StringVar(&objectKeyPrefix, "object-key-prefix", stress.DefaultObjectKeyPrefix(), "object key prefix")
 	cmd.PersistentFlags().IntVar(&objects, "objects", stress.DefaultObjects, "number of objects")
 	cmd.PersistentFlags().IntVar(&objectSize, "object-size", stress.DefaultObjectSize, "object size")
 	cmd.PersistentFlags().IntVar(&updateConcurrency, "update-concurrency", stress.DefaultUpdateConcurrency, "update concurrency")
 	cmd.PersistentFlags().Int64Var(&listBatchLimit, "list-batch-limit", stress.DefaultListBatchLimit, "list limit")
 
+	cmd.PersistentFlags().StringVar(&prometheusPushgatewayURL, "prometheus-pushgateway-url", "", "Prometheus Pushgateway URL to push client metrics to (empty to skip)")
+	cmd.PersistentFlags().StringVar(&prometheusPushJobName, "prometheus-push-job-name", stress.DefaultPrometheusPushJobName, "Prometheus Pushgateway job name")
+
 	return cmd
 }
 
@@ -115,6 +129,7 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 		KubectlDownloadURL: kubectlDownloadURL,
 		KubectlPath:        kubectlPath,
 		KubeconfigPath:     kubeconfigPath,
+		ConfigMode:         clientConfigMode,
 		Clients:            clients,
 	})
 	if err != nil {
@@ -137,14 +152,23 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 			Name:      repositoryName,
 			ImageTag:  repositoryImageTag,
 		},
+		RestrictedPodSecurity: restrictedPodSecurity,
 
 		Client:            cli,
 		RunTimeout:        runTimeout,
+		ObjectKinds:       objectKinds,
 		ObjectKeyPrefix:   objectKeyPrefix,
 		Objects:           objects,
 		ObjectSize:        objectSize,
 		UpdateConcurrency: updateConcurrency,
 		ListBatchLimit:    listBatchLimit,
+
+		PrometheusPushgatewayURL: prometheusPushgatewayURL,
+		PrometheusPushJobName:    prometheusPushJobName,
+	}
+
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
 	}
 
 	ts := stress.New(cfg)
@@ -154,7 +178,7 @@ func createApplyFunc(cmd *cobra.Command, args []string) {
 	}
 
 	fmt.Printf("\n*********************************\n")
-	fmt.Printf("'k8s-tester-stress apply' success\n")
+	fmt.Printf("'k8s-tester-stress apply' success (QPS writes %.2f, gets %.2f, range gets %.2f)\n", cfg.QPSWrites, cfg.QPSGets, cfg.QPSRangeGets)
 }
 
 func newDelete() *cobra.Command {
@@ -163,6 +187,7 @@ func newDelete() *cobra.Command {
 		Short: "Delete resources",
 		Run:   createDeleteFunc,
 	}
+	cmd.PersistentFlags().StringSliceVar(&objectKinds, "object-kinds", stress.DefaultObjectKinds(), "object kinds to clean up (\"crds\" to delete the CustomResourceDefinition)")
 	return cmd
 }
 
@@ -178,17 +203,19 @@ func createDeleteFunc(cmd *cobra.Command, args []string) {
 		KubectlDownloadURL: kubectlDownloadURL,
 		KubectlPath:        kubectlPath,
 		KubeconfigPath:     kubeconfigPath,
+		ConfigMode:         clientConfigMode,
 	})
 	if err != nil {
 		lg.Panic("failed to create client", zap.Error(err))
 	}
 
 	cfg := &stress.Config{
-		Prompt:    prompt,
-		Logger:    lg,
-		LogWriter: logWriter,
-		Namespace: namespace,
-		Client:    cli,
+		Prompt:      prompt,
+		Logger:      lg,
+		LogWriter:   logWriter,
+		Namespace:   namespace,
+		Client:      cli,
+		ObjectKinds: objectKinds,
 	}
 
 	ts := stress.New(cfg)
@@ -0,0 +1,50 @@
+package in_cluster
+
+import (
+	"io"
+	"io/ioutil"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// NewRenderer returns the tester to render the manifests, without the AWS clients.
+func NewRenderer(cfg *Config) k8s_tester.Tester {
+	return &tester{cfg: cfg}
+}
+
+// Render writes the ServiceAccount, the RBAC, the ConfigMap and the CronJob,
+// against a fake client. The images are not described via ECR.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = ioutil.Discard
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createServiceAccount(); err != nil {
+		return err
+	}
+	if err := rt.createRBACClusterRole(); err != nil {
+		return err
+	}
+	if err := rt.createRBACClusterRoleBinding(); err != nil {
+		return err
+	}
+	// the credentials are not rendered
+	if err := rt.createConfigmapWithKubeconfig(fake.Redacted); err != nil {
+		return err
+	}
+	if err := rt.createCronJob(cfg.K8sTesterStressRepository.ImageURI(), cfg.K8sTesterStressCLI.BusyboxRepository.ImageURI()); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
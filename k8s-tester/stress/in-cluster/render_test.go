@@ -0,0 +1,26 @@
+package in_cluster
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	aws_v1_ecr "github.com/aws/aws-k8s-tester/utils/aws/v1/ecr"
+)
+
+func TestRender(t *testing.T) {
+	cfg := NewDefault()
+	cfg.Namespace = "test-namespace"
+	cfg.K8sTesterStressRepository = &aws_v1_ecr.Repository{Partition: "aws", AccountID: "123", Region: "us-west-2", Name: "k8s-tester-stress", ImageTag: "latest"}
+	cfg.K8sTesterStressCLI.BusyboxRepository = &aws_v1_ecr.Repository{Partition: "aws", AccountID: "123", Region: "us-west-2", Name: "busybox", ImageTag: "latest"}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewRenderer(cfg).(*tester).Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	fake.AssertGolden(t, filepath.Join("testdata", "render.golden.yaml"), buf.Bytes())
+}
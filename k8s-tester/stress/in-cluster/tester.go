@@ -1,6 +1,7 @@
 // Package in_cluster implements stress tester in remote worker nodes
 // which runs workloads against its Kubernetes control plane, thus "in cluster".
-// See "Dockerfile.k8s-tester-stress" for an example docker image.
+// See "Dockerfile.k8s-tester-stress" for an example docker image,
+// and "Dockerfile.k8s-tester-stress-distroless" for the multi-arch distroless variant.
 // Replace https://github.com/aws/aws-k8s-tester/tree/v1.5.9/eks/stresser/remote.
 package in_cluster
 
@@ -10,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -55,6 +57,15 @@ type Config struct {
 	// K8sTesterStressRepository defines a custom ECR image repository.
 	// For "k8s-tester-stress".
 	K8sTesterStressRepository *aws_v1_ecr.Repository `json:"k8s_tester_stress_repository,omitempty"`
+	// ImageVariant is the "k8s-tester-stress" image variant of the repository image.
+	// "default" runs the image built with "Dockerfile.k8s-tester-stress" via shell,
+	// as a privileged container with the host "/var/log" mounted.
+	// "distroless" runs the image built with "Dockerfile.k8s-tester-stress-distroless"
+	// without shell, as a non-root user with read-only root filesystem,
+	// and creates the stress Pods with the same restrictions,
+	// to run on clusters enforcing the "restricted" Pod Security Standard.
+	// ref. https://kubernetes.io/docs/concepts/security/pod-security-standards/#restricted
+	ImageVariant string `json:"image_variant"`
 
 	// Completes is the desired number of successfully finished pods.
 	Completes int32 `json:"completes"`
@@ -82,7 +93,9 @@ type K8sTesterStressCLI struct {
 	// After timeout, it stops all stress requests.
 	RunTimeout       time.Duration `json:"run_timeout"`
 	RunTimeoutString string        `json:"run_timeout_string" read-only:"true"`
-	// ObjectKeyPrefix is the key prefix for "Pod" objects.
+	// ObjectKinds is the list of object kinds to stress.
+	ObjectKinds []string `json:"object_kinds"`
+	// ObjectKeyPrefix is the key prefix for objects.
 	ObjectKeyPrefix string `json:"object_key_prefix"`
 	// Objects is the desired number of objects to create and update.
 	// This doesn't apply to reads.
@@ -96,6 +109,16 @@ type K8sTesterStressCLI struct {
 	// ListBatchLimit is the number of objects to return for each list response.
 	// If negative, the tester disables list calls (only runs mutable requests).
 	ListBatchLimit int64 `json:"list_batch_limit"`
+	// PrometheusPushgatewayURL is the Prometheus Pushgateway URL each worker pushes its client metrics to.
+	// If empty, metrics are not pushed.
+	PrometheusPushgatewayURL string `json:"prometheus_pushgateway_url"`
+	// PrometheusPushJobName is the job name to group the pushed metrics.
+	// Leave empty to use the "k8s-tester-stress" CLI default.
+	PrometheusPushJobName string `json:"prometheus_push_job_name"`
+	// RestrictedPodSecurity is true to create the stress Pods with the
+	// "restricted" Pod Security Standard security context.
+	// Always true for the "distroless" image variant.
+	RestrictedPodSecurity bool `json:"restricted_pod_security"`
 }
 
 func (cfg *Config) ValidateAndSetDefaults() error {
@@ -103,6 +126,16 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		return errors.New("empty Namespace")
 	}
 
+	switch cfg.ImageVariant {
+	case "":
+		cfg.ImageVariant = ImageVariantDefault
+	case ImageVariantDefault:
+	case ImageVariantDistroless:
+		cfg.K8sTesterStressCLI.RestrictedPodSecurity = true
+	default:
+		return fmt.Errorf("unknown ImageVariant %q", cfg.ImageVariant)
+	}
+
 	if cfg.Completes == 0 {
 		cfg.Completes = DefaultCompletes
 	}
@@ -138,6 +171,13 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 	return nil
 }
 
+const (
+	// ImageVariantDefault is the "k8s-tester-stress" image built with "Dockerfile.k8s-tester-stress".
+	ImageVariantDefault = "default"
+	// ImageVariantDistroless is the "k8s-tester-stress" image built with "Dockerfile.k8s-tester-stress-distroless".
+	ImageVariantDistroless = "distroless"
+)
+
 const (
 	DefaultMinimumNodes int = 1
 
@@ -173,6 +213,7 @@ func NewDefault() *Config {
 		MinimumNodes:               DefaultMinimumNodes,
 		Namespace:                  pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
 		K8sTesterStressRepository:  &aws_v1_ecr.Repository{},
+		ImageVariant:               ImageVariantDefault,
 		Completes:                  DefaultCompletes,
 		Parallels:                  DefaultParallels,
 		Schedule:                   DefaultSchedule,
@@ -187,6 +228,7 @@ func NewDefaultK8sTesterStressCLI() *K8sTesterStressCLI {
 		BusyboxRepository: &aws_v1_ecr.Repository{},
 		RunTimeout:        DefaultRunTimeout,
 		RunTimeoutString:  DefaultRunTimeout.String(),
+		ObjectKinds:       []string{"pods"},
 		ObjectKeyPrefix:   DefaultObjectKeyPrefix(),
 		Objects:           DefaultObjects,
 		ObjectSize:        DefaultObjectSize,
@@ -196,6 +238,14 @@ func NewDefaultK8sTesterStressCLI() *K8sTesterStressCLI {
 }
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
 	ts := &tester{
 		cfg: cfg,
 	}
@@ -208,7 +258,7 @@ func New(cfg *Config) k8s_tester.Tester {
 		}
 		awsSession, _, _, err := aws_v1.New(&awsCfg)
 		if err != nil {
-			cfg.Logger.Panic("failed to create aws session", zap.Error(err))
+			return nil, fmt.Errorf("failed to create aws session (%v)", err)
 		}
 		ts.ecrAPI = ecr.New(awsSession, aws.NewConfig().WithRegion(cfg.K8sTesterStressRepository.Region))
 	}
@@ -221,11 +271,34 @@ func New(cfg *Config) k8s_tester.Tester {
 		}
 		awsSession, _, _, err := aws_v1.New(&awsCfg)
 		if err != nil {
-			cfg.Logger.Panic("failed to create aws session", zap.Error(err))
+			return nil, fmt.Errorf("failed to create aws session (%v)", err)
 		}
 		ts.ecrAPI = ecr.New(awsSession, aws.NewConfig().WithRegion(cfg.K8sTesterStressCLI.BusyboxRepository.Region))
 	}
-	return ts
+	return ts, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 type tester struct {
@@ -383,7 +456,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -495,6 +569,8 @@ func (ts *tester) createRBACClusterRole() error {
 							"services",
 							"jobs",
 							"cronjobs",
+							"customresourcedefinitions",
+							"stressobjects",
 						},
 						Verbs: []string{
 							"create",
@@ -579,14 +655,19 @@ func (ts *tester) createRBACClusterRoleBinding() error {
 }
 
 func (ts *tester) createConfigmap() error {
-	ts.cfg.Logger.Info("creating config map")
-
 	b, err := ioutil.ReadFile(ts.cfg.Client.Config().KubeconfigPath)
 	if err != nil {
 		return err
 	}
+	return ts.createConfigmapWithKubeconfig(string(b))
+}
+
+// createConfigmapWithKubeconfig creates the ConfigMap that mounts the kubeconfig into the CronJob Pods
+func (ts *tester) createConfigmapWithKubeconfig(kubeconfig string) error {
+	ts.cfg.Logger.Info("creating config map")
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	_, err = ts.cfg.Client.KubernetesClient().
+	_, err := ts.cfg.Client.KubernetesClient().
 		CoreV1().
 		ConfigMaps(ts.cfg.Namespace).
 		Create(
@@ -604,7 +685,7 @@ func (ts *tester) createConfigmap() error {
 					},
 				},
 				Data: map[string]string{
-					kubeconfigConfigmapFileName: string(b),
+					kubeconfigConfigmapFileName: kubeconfig,
 				},
 			},
 			meta_v1.CreateOptions{},
@@ -624,16 +705,36 @@ func (ts *tester) createConfigmap() error {
 
 func (ts *tester) createCronJobObject(k8sTesterStressImg string, busyboxImg string) (batch_v1beta1.CronJob, string, error) {
 	// do not pass kubeconfig to use in-cluster client
-	cmd := "/k8s-tester-stress --prompt=false --minimum-nodes=0"
-	cmd += fmt.Sprintf(" --namespace %s --skip-namespace-creation=true", ts.cfg.Namespace)
-	cmd += " --kubectl-path /kubectl"
-	cmd += fmt.Sprintf(" apply --ecr-busybox-image %s", busyboxImg)
-	cmd += fmt.Sprintf(" --run-timeout %s", ts.cfg.K8sTesterStressCLI.RunTimeout)
-	cmd += fmt.Sprintf(" --object-key-prefix %s", ts.cfg.K8sTesterStressCLI.ObjectKeyPrefix)
-	cmd += fmt.Sprintf(" --objects %d", ts.cfg.K8sTesterStressCLI.Objects)
-	cmd += fmt.Sprintf(" --object-size %d", ts.cfg.K8sTesterStressCLI.ObjectSize)
-	cmd += fmt.Sprintf(" --update-concurrency %d", ts.cfg.K8sTesterStressCLI.UpdateConcurrency)
-	cmd += fmt.Sprintf(" --list-batch-limit %d", ts.cfg.K8sTesterStressCLI.ListBatchLimit)
+	args := []string{
+		"--prompt=false",
+		"--minimum-nodes=0",
+		"--namespace", ts.cfg.Namespace,
+		"--skip-namespace-creation=true",
+		"--kubectl-path", "/kubectl",
+		"--client-config-mode", client.ConfigModeInCluster,
+		"apply",
+		"--ecr-busybox-image", busyboxImg,
+		"--run-timeout", ts.cfg.K8sTesterStressCLI.RunTimeout.String(),
+	}
+	if len(ts.cfg.K8sTesterStressCLI.ObjectKinds) > 0 {
+		args = append(args, "--object-kinds", strings.Join(ts.cfg.K8sTesterStressCLI.ObjectKinds, ","))
+	}
+	args = append(args,
+		"--object-key-prefix", ts.cfg.K8sTesterStressCLI.ObjectKeyPrefix,
+		"--objects", fmt.Sprintf("%d", ts.cfg.K8sTesterStressCLI.Objects),
+		"--object-size", fmt.Sprintf("%d", ts.cfg.K8sTesterStressCLI.ObjectSize),
+		"--update-concurrency", fmt.Sprintf("%d", ts.cfg.K8sTesterStressCLI.UpdateConcurrency),
+		"--list-batch-limit", fmt.Sprintf("%d", ts.cfg.K8sTesterStressCLI.ListBatchLimit),
+	)
+	if ts.cfg.K8sTesterStressCLI.PrometheusPushgatewayURL != "" {
+		args = append(args, "--prometheus-pushgateway-url", ts.cfg.K8sTesterStressCLI.PrometheusPushgatewayURL)
+		if ts.cfg.K8sTesterStressCLI.PrometheusPushJobName != "" {
+			args = append(args, "--prometheus-push-job-name", ts.cfg.K8sTesterStressCLI.PrometheusPushJobName)
+		}
+	}
+	if ts.cfg.K8sTesterStressCLI.RestrictedPodSecurity {
+		args = append(args, "--restricted-pod-security=true")
+	}
 
 	dirOrCreate := core_v1.HostPathDirectoryOrCreate
 	podSpec := core_v1.PodTemplateSpec{
@@ -653,7 +754,7 @@ func (ts *tester) createCronJobObject(k8sTesterStressImg string, busyboxImg stri
 					Command: []string{
 						"/bin/sh",
 						"-ec",
-						cmd,
+						"/k8s-tester-stress " + strings.Join(args, " "),
 					},
 
 					// grant access "/dev/kmsg"
@@ -701,6 +802,44 @@ func (ts *tester) createCronJobObject(k8sTesterStressImg string, busyboxImg stri
 			},
 		},
 	}
+	if ts.cfg.ImageVariant == ImageVariantDistroless {
+		// no shell, no host path, no privilege
+		// to satisfy the "restricted" Pod Security Standard
+		// ref. https://github.com/GoogleContainerTools/distroless
+		container := &podSpec.Spec.Containers[0]
+		container.Command = []string{"/k8s-tester-stress"}
+		container.Args = args
+		container.SecurityContext = &v1.SecurityContext{
+			AllowPrivilegeEscalation: boolRef(false),
+			ReadOnlyRootFilesystem:   boolRef(true),
+			Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+		}
+		container.VolumeMounts = []core_v1.VolumeMount{
+			{ // to execute
+				Name:      kubeconfigConfigmapName,
+				MountPath: "/opt",
+			},
+			{ // to write temporary files with read-only root filesystem
+				Name:      "tmp",
+				MountPath: "/tmp",
+			},
+		}
+		podSpec.Spec.SecurityContext = &v1.PodSecurityContext{
+			RunAsNonRoot:   boolRef(true),
+			RunAsUser:      int64Ref(distrolessNonRootUID),
+			RunAsGroup:     int64Ref(distrolessNonRootUID),
+			SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+		}
+		podSpec.Spec.Volumes = []core_v1.Volume{
+			podSpec.Spec.Volumes[0],
+			{ // to write temporary files with read-only root filesystem
+				Name: "tmp",
+				VolumeSource: v1.VolumeSource{
+					EmptyDir: &v1.EmptyDirVolumeSource{},
+				},
+			},
+		}
+	}
 
 	jobSpec := batch_v1beta1.JobTemplateSpec{
 		ObjectMeta: meta_v1.ObjectMeta{
@@ -917,6 +1056,13 @@ func int32Ref(v int32) *int32 {
 	return &v
 }
 
+// distrolessNonRootUID is the "nonroot" user ID of the distroless base image.
+const distrolessNonRootUID int64 = 65532
+
+func int64Ref(v int64) *int64 {
+	return &v
+}
+
 func boolRef(v bool) *bool {
 	return &v
 }
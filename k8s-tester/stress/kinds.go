@@ -0,0 +1,376 @@
+package stress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"go.uber.org/zap"
+	coordination_v1 "k8s.io/api/coordination/v1"
+	core_v1 "k8s.io/api/core/v1"
+	apiextensions_v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Supported object kinds, each stressing a different apiserver storage path.
+const (
+	ObjectKindPods            = "pods"
+	ObjectKindConfigMaps      = "configmaps"
+	ObjectKindSecrets         = "secrets"
+	ObjectKindEndpoints       = "endpoints"
+	ObjectKindLeases          = "leases"
+	ObjectKindEvents          = "events"
+	ObjectKindCustomResources = "crds"
+)
+
+var supportedObjectKinds = map[string]struct{}{
+	ObjectKindPods:            {},
+	ObjectKindConfigMaps:      {},
+	ObjectKindSecrets:         {},
+	ObjectKindEndpoints:       {},
+	ObjectKindLeases:          {},
+	ObjectKindEvents:          {},
+	ObjectKindCustomResources: {},
+}
+
+// payloadAnnotationKey holds the object payload for kinds without a data field.
+const payloadAnnotationKey = "k8s-tester-stress/payload"
+
+// objectKind issues requests against one object kind.
+type objectKind struct {
+	name string
+	// get returns the object, or a "NotFound" error.
+	get func(ctx context.Context, name string) (meta_v1.Object, error)
+	// create creates the object with the payload.
+	create func(ctx context.Context, name string) error
+	// update writes back the object returned by "get".
+	update func(ctx context.Context, obj meta_v1.Object) error
+	// list lists the objects with the limit.
+	list func(ctx context.Context, limit int64) error
+}
+
+func (ts *tester) newObjectKinds(podImg string, val string) []objectKind {
+	kinds := make([]objectKind, 0, len(ts.cfg.ObjectKinds))
+	for _, k := range ts.cfg.ObjectKinds {
+		kinds = append(kinds, ts.newObjectKind(k, podImg, val))
+	}
+	return kinds
+}
+
+func (ts *tester) newObjectKind(kind string, podImg string, val string) objectKind {
+	cli := ts.cfg.Client.KubernetesClient()
+	ns := ts.cfg.Namespace
+	payload := map[string]string{payloadAnnotationKey: val}
+
+	switch kind {
+	case ObjectKindConfigMaps:
+		c := cli.CoreV1().ConfigMaps(ns)
+		return objectKind{
+			name: kind,
+			get: func(ctx context.Context, name string) (meta_v1.Object, error) {
+				return c.Get(ctx, name, meta_v1.GetOptions{})
+			},
+			create: func(ctx context.Context, name string) error {
+				_, err := c.Create(ctx, &core_v1.ConfigMap{
+					ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: ns},
+					Data:       map[string]string{"data": val},
+				}, meta_v1.CreateOptions{})
+				return err
+			},
+			update: func(ctx context.Context, obj meta_v1.Object) error {
+				_, err := c.Update(ctx, obj.(*core_v1.ConfigMap), meta_v1.UpdateOptions{})
+				return err
+			},
+			list: func(ctx context.Context, limit int64) error {
+				_, err := c.List(ctx, meta_v1.ListOptions{Limit: limit})
+				return err
+			},
+		}
+
+	case ObjectKindSecrets:
+		c := cli.CoreV1().Secrets(ns)
+		return objectKind{
+			name: kind,
+			get: func(ctx context.Context, name string) (meta_v1.Object, error) {
+				return c.Get(ctx, name, meta_v1.GetOptions{})
+			},
+			create: func(ctx context.Context, name string) error {
+				_, err := c.Create(ctx, &core_v1.Secret{
+					ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: ns},
+					Type:       core_v1.SecretTypeOpaque,
+					Data:       map[string][]byte{"data": []byte(val)},
+				}, meta_v1.CreateOptions{})
+				return err
+			},
+			update: func(ctx context.Context, obj meta_v1.Object) error {
+				_, err := c.Update(ctx, obj.(*core_v1.Secret), meta_v1.UpdateOptions{})
+				return err
+			},
+			list: func(ctx context.Context, limit int64) error {
+				_, err := c.List(ctx, meta_v1.ListOptions{Limit: limit})
+				return err
+			},
+		}
+
+	case ObjectKindEndpoints:
+		c := cli.CoreV1().Endpoints(ns)
+		return objectKind{
+			name: kind,
+			get: func(ctx context.Context, name string) (meta_v1.Object, error) {
+				return c.Get(ctx, name, meta_v1.GetOptions{})
+			},
+			create: func(ctx context.Context, name string) error {
+				_, err := c.Create(ctx, &core_v1.Endpoints{
+					ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: ns, Annotations: payload},
+					Subsets: []core_v1.EndpointSubset{
+						{
+							Addresses: []core_v1.EndpointAddress{{IP: "10.0.0.1"}},
+							Ports:     []core_v1.EndpointPort{{Name: "http", Port: 80, Protocol: core_v1.ProtocolTCP}},
+						},
+					},
+				}, meta_v1.CreateOptions{})
+				return err
+			},
+			update: func(ctx context.Context, obj meta_v1.Object) error {
+				_, err := c.Update(ctx, obj.(*core_v1.Endpoints), meta_v1.UpdateOptions{})
+				return err
+			},
+			list: func(ctx context.Context, limit int64) error {
+				_, err := c.List(ctx, meta_v1.ListOptions{Limit: limit})
+				return err
+			},
+		}
+
+	case ObjectKindLeases:
+		c := cli.CoordinationV1().Leases(ns)
+		return objectKind{
+			name: kind,
+			get: func(ctx context.Context, name string) (meta_v1.Object, error) {
+				return c.Get(ctx, name, meta_v1.GetOptions{})
+			},
+			create: func(ctx context.Context, name string) error {
+				holder := name
+				_, err := c.Create(ctx, &coordination_v1.Lease{
+					ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: ns, Annotations: payload},
+					Spec:       coordination_v1.LeaseSpec{HolderIdentity: &holder},
+				}, meta_v1.CreateOptions{})
+				return err
+			},
+			update: func(ctx context.Context, obj meta_v1.Object) error {
+				// renews the lease as leader election does
+				lease := obj.(*coordination_v1.Lease)
+				now := meta_v1.NewMicroTime(time.Now())
+				lease.Spec.RenewTime = &now
+				_, err := c.Update(ctx, lease, meta_v1.UpdateOptions{})
+				return err
+			},
+			list: func(ctx context.Context, limit int64) error {
+				_, err := c.List(ctx, meta_v1.ListOptions{Limit: limit})
+				return err
+			},
+		}
+
+	case ObjectKindEvents:
+		// events are stored with an etcd lease (TTL)
+		c := cli.CoreV1().Events(ns)
+		return objectKind{
+			name: kind,
+			get: func(ctx context.Context, name string) (meta_v1.Object, error) {
+				return c.Get(ctx, name, meta_v1.GetOptions{})
+			},
+			create: func(ctx context.Context, name string) error {
+				now := meta_v1.Now()
+				_, err := c.Create(ctx, &core_v1.Event{
+					ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: ns, Annotations: payload},
+					InvolvedObject: core_v1.ObjectReference{
+						APIVersion: "v1",
+						Kind:       "Namespace",
+						Name:       ns,
+						Namespace:  ns,
+					},
+					Reason:         "Stress",
+					Message:        "stress test event",
+					Type:           core_v1.EventTypeNormal,
+					Source:         core_v1.EventSource{Component: pkgName},
+					FirstTimestamp: now,
+					LastTimestamp:  now,
+					Count:          1,
+				}, meta_v1.CreateOptions{})
+				return err
+			},
+			update: func(ctx context.Context, obj meta_v1.Object) error {
+				// aggregates the event as the event recorder does
+				ev := obj.(*core_v1.Event)
+				ev.Count++
+				ev.LastTimestamp = meta_v1.Now()
+				_, err := c.Update(ctx, ev, meta_v1.UpdateOptions{})
+				return err
+			},
+			list: func(ctx context.Context, limit int64) error {
+				_, err := c.List(ctx, meta_v1.ListOptions{Limit: limit})
+				return err
+			},
+		}
+
+	case ObjectKindCustomResources:
+		// no dynamic client, so send the JSON requests with the REST client
+		rc := cli.Discovery().RESTClient()
+		resourcePath := fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", crdGroup, crdVersion, ns, crdPlural)
+		return objectKind{
+			name: kind,
+			get: func(ctx context.Context, name string) (meta_v1.Object, error) {
+				b, err := rc.Get().AbsPath(resourcePath, name).DoRaw(ctx)
+				if err != nil {
+					return nil, err
+				}
+				obj := &unstructured.Unstructured{}
+				if err = obj.UnmarshalJSON(b); err != nil {
+					return nil, err
+				}
+				return obj, nil
+			},
+			create: func(ctx context.Context, name string) error {
+				obj := &unstructured.Unstructured{}
+				obj.SetAPIVersion(crdGroup + "/" + crdVersion)
+				obj.SetKind(crdKind)
+				obj.SetName(name)
+				obj.SetNamespace(ns)
+				if err := unstructured.SetNestedField(obj.Object, val, "spec", "data"); err != nil {
+					return err
+				}
+				b, err := json.Marshal(obj.Object)
+				if err != nil {
+					return err
+				}
+				return rc.Post().AbsPath(resourcePath).SetHeader("Content-Type", "application/json").Body(b).Do(ctx).Error()
+			},
+			update: func(ctx context.Context, obj meta_v1.Object) error {
+				b, err := json.Marshal(obj.(*unstructured.Unstructured).Object)
+				if err != nil {
+					return err
+				}
+				return rc.Put().AbsPath(resourcePath, obj.GetName()).SetHeader("Content-Type", "application/json").Body(b).Do(ctx).Error()
+			},
+			list: func(ctx context.Context, limit int64) error {
+				return rc.Get().AbsPath(resourcePath).Param("limit", fmt.Sprint(limit)).Do(ctx).Error()
+			},
+		}
+	}
+
+	c := cli.CoreV1().Pods(ns)
+	return objectKind{
+		name: ObjectKindPods,
+		get: func(ctx context.Context, name string) (meta_v1.Object, error) {
+			return c.Get(ctx, name, meta_v1.GetOptions{})
+		},
+		create: func(ctx context.Context, name string) error {
+			_, err := c.Create(ctx, ts.createPodObject(name, podImg, val), meta_v1.CreateOptions{})
+			return err
+		},
+		update: func(ctx context.Context, obj meta_v1.Object) error {
+			_, err := c.Update(ctx, obj.(*core_v1.Pod), meta_v1.UpdateOptions{})
+			return err
+		},
+		list: func(ctx context.Context, limit int64) error {
+			_, err := c.List(ctx, meta_v1.ListOptions{Limit: limit})
+			return err
+		},
+	}
+}
+
+const (
+	crdGroup   = "stress.k8s-tester.aws"
+	crdVersion = "v1"
+	crdKind    = "StressObject"
+	crdPlural  = "stressobjects"
+	crdName    = crdPlural + "." + crdGroup
+)
+
+func (ts *tester) hasObjectKind(kind string) bool {
+	for _, k := range ts.cfg.ObjectKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// createCRD creates the CustomResourceDefinition to stress custom resource writes,
+// and waits until it is established.
+// crdObject returns the CustomResourceDefinition of the custom resources to stress.
+func crdObject() *apiextensions_v1.CustomResourceDefinition {
+	preserveUnknownFields := true
+	return &apiextensions_v1.CustomResourceDefinition{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: crdName,
+		},
+		Spec: apiextensions_v1.CustomResourceDefinitionSpec{
+			Group: crdGroup,
+			Names: apiextensions_v1.CustomResourceDefinitionNames{
+				Plural:   crdPlural,
+				Singular: "stressobject",
+				Kind:     crdKind,
+				ListKind: crdKind + "List",
+			},
+			Scope: apiextensions_v1.NamespaceScoped,
+			Versions: []apiextensions_v1.CustomResourceDefinitionVersion{
+				{
+					Name:    crdVersion,
+					Served:  true,
+					Storage: true,
+					Schema: &apiextensions_v1.CustomResourceValidation{
+						OpenAPIV3Schema: &apiextensions_v1.JSONSchemaProps{
+							Type:                   "object",
+							XPreserveUnknownFields: &preserveUnknownFields,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (ts *tester) createCRD() error {
+	ts.cfg.Logger.Info("creating CustomResourceDefinition", zap.String("name", crdName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.APIExtensionsClient().
+		ApiextensionsV1().
+		CustomResourceDefinitions().
+		Create(
+			ctx,
+			crdObject(),
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create CustomResourceDefinition (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Minute)
+	_, err = client.WaitForCRDEstablished(ctx, ts.cfg.Logger, ts.cfg.Client.APIExtensionsClient(), crdName, client.WithStopc(ts.cfg.Stopc))
+	cancel()
+	if err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("created CustomResourceDefinition", zap.String("name", crdName))
+	return nil
+}
+
+func (ts *tester) deleteCRD() error {
+	ts.cfg.Logger.Info("deleting CustomResourceDefinition", zap.String("name", crdName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.Client.APIExtensionsClient().
+		ApiextensionsV1().
+		CustomResourceDefinitions().
+		Delete(ctx, crdName, meta_v1.DeleteOptions{})
+	cancel()
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete CustomResourceDefinition (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted CustomResourceDefinition", zap.String("name", crdName))
+	return nil
+}
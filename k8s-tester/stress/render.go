@@ -0,0 +1,62 @@
+package stress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// NewRenderer returns the tester to render the manifests, without the AWS clients.
+func NewRenderer(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg:            cfg,
+		donec:          make(chan struct{}),
+		donecCloseOnce: new(sync.Once),
+	}
+}
+
+// Render writes the CustomResourceDefinition, and the first object of each kind
+// with a placeholder payload, against a fake client. The custom resources are
+// sent with the REST client, so they are not rendered. The image is not described via ECR.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	podImg := cfg.ECRBusyboxImage
+	if podImg == "" {
+		podImg = busyboxImageName
+		if !cfg.Repository.IsEmpty() {
+			podImg = cfg.Repository.ImageURI()
+		}
+	}
+	if rt.hasObjectKind(ObjectKindCustomResources) {
+		// not waiting for the CustomResourceDefinition to be established
+		_, err := cli.APIExtensionsClient().ApiextensionsV1().CustomResourceDefinitions().Create(context.Background(), crdObject(), meta_v1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	val := fmt.Sprintf("<%d random characters>", cfg.ObjectSize)
+	for _, kind := range rt.newObjectKinds(podImg, val) {
+		if kind.name == ObjectKindCustomResources {
+			continue
+		}
+		if err := kind.create(context.Background(), cfg.ObjectKeyPrefix+"0"); err != nil {
+			return fmt.Errorf("failed to create %q (%v)", kind.name, err)
+		}
+	}
+	return fake.Dump(w, cli)
+}
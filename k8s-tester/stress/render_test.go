@@ -0,0 +1,25 @@
+package stress
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+)
+
+func TestRender(t *testing.T) {
+	cfg := NewDefault()
+	cfg.Namespace = "test-namespace"
+	cfg.ObjectKinds = []string{ObjectKindPods, ObjectKindConfigMaps, ObjectKindSecrets, ObjectKindEndpoints, ObjectKindLeases, ObjectKindEvents, ObjectKindCustomResources}
+	cfg.ObjectKeyPrefix = "test-object"
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewRenderer(cfg).(*tester).Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	fake.AssertGolden(t, filepath.Join("testdata", "render.golden.yaml"), buf.Bytes())
+}
@@ -1,4 +1,5 @@
-// Package stress implements stress tester using "Pod" objects.
+// Package stress implements stress tester using "Pod" objects by default,
+// or any of the other object kinds in "ObjectKinds" (see "kinds.go").
 // Do not parallelize locally, instead parallelize by distributing workers across nodes.
 // It uses "Update" for stressing writes, and "List" for stressing reads.
 // Both Kubernetes "Create" and "Update" are same for etcd, as they are etcd mutable transactions.
@@ -14,6 +15,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
 	"sort"
@@ -34,6 +36,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/manifoldco/promptui"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	core_v1 "k8s.io/api/core/v1"
@@ -120,8 +123,32 @@ var (
 			// highest bucket start of 0.5 ms * 2^13 == 4.096 sec
 			Buckets: prometheus.ExponentialBuckets(0.5, 2, 14),
 		})
+
+	requestsPerSecond = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "stress",
+			Subsystem: "client",
+			Name:      "requests_per_second",
+			Help:      "Average number of requests per second over the run, by verb.",
+		},
+		[]string{"verb"},
+	)
 )
 
+// pushCollectors is the list of metrics to push to the Prometheus Pushgateway.
+var pushCollectors = []prometheus.Collector{
+	writeRequestsSuccessTotal,
+	writeRequestsFailureTotal,
+	writeRequestLatencyMs,
+	getRequestsSuccessTotal,
+	getRequestsFailureTotal,
+	getRequestLatencyMs,
+	rangeGetRequestsSuccessTotal,
+	rangeGetRequestsFailureTotal,
+	rangeGetRequestLatencyMs,
+	requestsPerSecond,
+}
+
 func init() {
 	prometheus.MustRegister(writeRequestsSuccessTotal)
 	prometheus.MustRegister(writeRequestsFailureTotal)
@@ -134,6 +161,8 @@ func init() {
 	prometheus.MustRegister(rangeGetRequestsSuccessTotal)
 	prometheus.MustRegister(rangeGetRequestsFailureTotal)
 	prometheus.MustRegister(rangeGetRequestLatencyMs)
+
+	prometheus.MustRegister(requestsPerSecond)
 }
 
 type Config struct {
@@ -157,12 +186,21 @@ type Config struct {
 	// Repository defines a custom ECR image repository.
 	// For "busybox".
 	Repository *aws_v1_ecr.Repository `json:"busybox_repository,omitempty"`
+	// RestrictedPodSecurity is true to create the stress Pods with the security context
+	// that satisfies the "restricted" Pod Security Standard (non-root, read-only root filesystem,
+	// no privilege escalation, all capabilities dropped), for namespaces that enforce it.
+	// ref. https://kubernetes.io/docs/concepts/security/pod-security-standards/#restricted
+	RestrictedPodSecurity bool `json:"restricted_pod_security"`
 
 	// RunTimeout is the duration of stress runs.
 	// After timeout, it stops all stress requests.
 	RunTimeout       time.Duration `json:"run_timeout"`
 	RunTimeoutString string        `json:"run_timeout_string" read-only:"true"`
-	// ObjectKeyPrefix is the key prefix for "Pod" objects.
+	// ObjectKinds is the list of object kinds to stress, in round-robin
+	// ("pods", "configmaps", "secrets", "endpoints", "leases", "events", "crds").
+	// Each kind exercises a different apiserver storage path and admission chain.
+	ObjectKinds []string `json:"object_kinds"`
+	// ObjectKeyPrefix is the key prefix for objects.
 	ObjectKeyPrefix string `json:"object_key_prefix"`
 	// Objects is the desired number of objects to create and update.
 	// This doesn't apply to reads.
@@ -177,6 +215,21 @@ type Config struct {
 	// If negative, the tester disables list calls (only runs mutable requests).
 	ListBatchLimit int64 `json:"list_batch_limit"`
 
+	// PrometheusPushgatewayURL is the Prometheus Pushgateway URL to push the client metrics to
+	// after the run (e.g., "http://pushgateway.monitoring:9091").
+	// If empty, metrics are only written to the result file.
+	PrometheusPushgatewayURL string `json:"prometheus_pushgateway_url"`
+	// PrometheusPushJobName is the job name to group the pushed metrics,
+	// with the "namespace" and "instance" grouping labels set to the test namespace and the host name.
+	PrometheusPushJobName string `json:"prometheus_push_job_name"`
+
+	// QPSWrites is the average number of "Create" and "Update" requests per second.
+	QPSWrites float64 `json:"qps_writes" read-only:"true"`
+	// QPSGets is the average number of "Get" requests per second.
+	QPSGets float64 `json:"qps_gets" read-only:"true"`
+	// QPSRangeGets is the average number of "List" requests per second.
+	QPSRangeGets float64 `json:"qps_range_gets" read-only:"true"`
+
 	// LatencySummaryWrites represents latencies for "Create" and "Update" requests.
 	LatencySummaryWrites latency.Summary `json:"latency_summary_writes" read-only:"true"`
 	// LatencySummaryGets represents latencies for "Get" requests.
@@ -195,6 +248,15 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 	}
 	cfg.RunTimeoutString = cfg.RunTimeout.String()
 
+	if len(cfg.ObjectKinds) == 0 {
+		cfg.ObjectKinds = DefaultObjectKinds()
+	}
+	for _, k := range cfg.ObjectKinds {
+		if _, ok := supportedObjectKinds[k]; !ok {
+			return fmt.Errorf("unknown ObjectKinds %q", k)
+		}
+	}
+
 	if cfg.ObjectKeyPrefix == "" {
 		cfg.ObjectKeyPrefix = DefaultObjectKeyPrefix()
 	}
@@ -206,6 +268,10 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		cfg.UpdateConcurrency = DefaultUpdateConcurrency
 	}
 
+	if cfg.PrometheusPushJobName == "" {
+		cfg.PrometheusPushJobName = DefaultPrometheusPushJobName
+	}
+
 	return nil
 }
 
@@ -224,6 +290,8 @@ const (
 
 	DefaultUpdateConcurrency int   = 10
 	DefaultListBatchLimit    int64 = 1000
+
+	DefaultPrometheusPushJobName = "k8s-tester-stress"
 )
 
 var defaultObjectKeyPrefix string = fmt.Sprintf("pod%s", rand.String(7))
@@ -232,6 +300,10 @@ func DefaultObjectKeyPrefix() string {
 	return defaultObjectKeyPrefix
 }
 
+func DefaultObjectKinds() []string {
+	return []string{ObjectKindPods}
+}
+
 func NewDefault() *Config {
 	return &Config{
 		Enable:                false,
@@ -243,15 +315,25 @@ func NewDefault() *Config {
 		Repository:            &aws_v1_ecr.Repository{},
 		RunTimeout:            DefaultRunTimeout,
 		RunTimeoutString:      DefaultRunTimeout.String(),
+		ObjectKinds:           DefaultObjectKinds(),
 		ObjectKeyPrefix:       DefaultObjectKeyPrefix(),
 		Objects:               DefaultObjects,
 		ObjectSize:            DefaultObjectSize,
 		UpdateConcurrency:     DefaultUpdateConcurrency,
 		ListBatchLimit:        DefaultListBatchLimit,
+		PrometheusPushJobName: DefaultPrometheusPushJobName,
 	}
 }
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
 	ts := &tester{
 		cfg:            cfg,
 		donec:          make(chan struct{}),
@@ -266,11 +348,34 @@ func New(cfg *Config) k8s_tester.Tester {
 		}
 		awsSession, _, _, err := aws_v1.New(&awsCfg)
 		if err != nil {
-			cfg.Logger.Panic("failed to create aws session", zap.Error(err))
+			return nil, fmt.Errorf("failed to create aws session (%v)", err)
 		}
 		ts.ecrAPI = ecr.New(awsSession, aws.NewConfig().WithRegion(cfg.Repository.Region))
 	}
-	return ts
+	return ts, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 type tester struct {
@@ -319,15 +424,23 @@ func (ts *tester) Apply() (err error) {
 		}
 	}
 
+	if ts.hasObjectKind(ObjectKindCustomResources) {
+		if err := ts.createCRD(); err != nil {
+			return err
+		}
+	}
+
+	kinds := ts.newObjectKinds(podImg, rand.String(ts.cfg.ObjectSize))
+	runStart := time.Now()
 	latenciesWritesCh, latenciesGetsCh := make(chan latency.Durations), make(chan latency.Durations)
 	go func() {
-		latenciesWrites, latenciesGets := ts.startUpdates(podImg)
+		latenciesWrites, latenciesGets := ts.startUpdates(kinds)
 		latenciesWritesCh <- latenciesWrites
 		latenciesGetsCh <- latenciesGets
 	}()
 	latenciesRangeGetsCh := make(chan latency.Durations)
 	go func() {
-		latenciesRangeGetsCh <- ts.startRangeGets()
+		latenciesRangeGetsCh <- ts.startRangeGets(kinds)
 	}()
 
 	ts.cfg.Logger.Info("waiting for test run timeout", zap.String("timeout", ts.cfg.RunTimeoutString))
@@ -344,6 +457,7 @@ func (ts *tester) Apply() (err error) {
 		})
 		ts.cfg.Logger.Info("run timeout, signaled done channel")
 	}
+	runElapsed := time.Since(runStart)
 
 	latenciesWrites := make(latency.Durations, 0)
 	select {
@@ -461,12 +575,65 @@ func (ts *tester) Apply() (err error) {
 		}
 	}
 
+	ts.cfg.QPSWrites = qps(ts.cfg.LatencySummaryWrites, runElapsed)
+	ts.cfg.QPSGets = qps(ts.cfg.LatencySummaryGets, runElapsed)
+	ts.cfg.QPSRangeGets = qps(ts.cfg.LatencySummaryRangeGets, runElapsed)
+	requestsPerSecond.WithLabelValues("write").Set(ts.cfg.QPSWrites)
+	requestsPerSecond.WithLabelValues("get").Set(ts.cfg.QPSGets)
+	requestsPerSecond.WithLabelValues("range_get").Set(ts.cfg.QPSRangeGets)
+	ts.cfg.Logger.Info("computed QPS",
+		zap.String("run-elapsed", runElapsed.String()),
+		zap.Float64("writes", ts.cfg.QPSWrites),
+		zap.Float64("gets", ts.cfg.QPSGets),
+		zap.Float64("range-gets", ts.cfg.QPSRangeGets),
+	)
+
+	if ts.cfg.PrometheusPushgatewayURL != "" {
+		// the metrics are in the result file as well,
+		// so a Pushgateway outage does not fail the run
+		if perr := ts.pushMetrics(); perr != nil {
+			ts.cfg.Logger.Warn("failed to push metrics to Prometheus Pushgateway; continuing", zap.Error(perr))
+		}
+	}
+
 	fmt.Fprintf(ts.cfg.LogWriter, "\n\nLatencySummaryWrites:\n%s\n", ts.cfg.LatencySummaryWrites.Table())
 	fmt.Fprintf(ts.cfg.LogWriter, "\n\nLatencySummaryGets:\n%s\n", ts.cfg.LatencySummaryGets.Table())
 	fmt.Fprintf(ts.cfg.LogWriter, "\n\nLatencySummaryRangeGets:\n%s\n", ts.cfg.LatencySummaryRangeGets.Table())
 	return nil
 }
 
+// qps returns the average number of requests per second over the run.
+func qps(s latency.Summary, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return (s.SuccessTotal + s.FailureTotal) / elapsed.Seconds()
+}
+
+// pushMetrics pushes the client metrics to the Prometheus Pushgateway,
+// so that runs can be compared over time.
+// ref. https://github.com/prometheus/pushgateway
+func (ts *tester) pushMetrics() error {
+	ts.cfg.Logger.Info("pushing metrics to Prometheus Pushgateway",
+		zap.String("url", ts.cfg.PrometheusPushgatewayURL),
+		zap.String("job", ts.cfg.PrometheusPushJobName),
+	)
+	// in-cluster workers push to the same job, so group by host
+	// to not overwrite each other's metrics
+	host, _ := os.Hostname()
+	pusher := push.New(ts.cfg.PrometheusPushgatewayURL, ts.cfg.PrometheusPushJobName).
+		Grouping("namespace", ts.cfg.Namespace).
+		Grouping("instance", host)
+	for _, c := range pushCollectors {
+		pusher = pusher.Collector(c)
+	}
+	if err := pusher.Push(); err != nil {
+		return fmt.Errorf("failed to push metrics to %q (%v)", ts.cfg.PrometheusPushgatewayURL, err)
+	}
+	ts.cfg.Logger.Info("pushed metrics to Prometheus Pushgateway")
+	return nil
+}
+
 func (ts *tester) Delete() error {
 	if ok := ts.runPrompt("delete"); !ok {
 		return errors.New("cancelled")
@@ -489,6 +656,12 @@ func (ts *tester) Delete() error {
 		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
 	}
 
+	if ts.hasObjectKind(ObjectKindCustomResources) {
+		if err := ts.deleteCRD(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
 	if len(errs) > 0 {
 		return errors.New(strings.Join(errs, ", "))
 	}
@@ -508,7 +681,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -518,14 +692,21 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 	return true
 }
 
-func (ts *tester) startUpdates(podImg string) (latenciesWrites latency.Durations, latenciesGets latency.Durations) {
+func (ts *tester) startUpdates(kinds []objectKind) (latenciesWrites latency.Durations, latenciesGets latency.Durations) {
 	ts.cfg.Logger.Info("updating",
+		zap.Strings("object-kinds", ts.cfg.ObjectKinds),
 		zap.Int("objects", ts.cfg.Objects),
 		zap.String("object-size", humanize.Bytes(uint64(ts.cfg.ObjectSize))),
 		zap.Int("concurrency", ts.cfg.UpdateConcurrency),
 	)
 	latenciesWrites, latenciesGets = make(latency.Durations, 0, 20000), make(latency.Durations, 0, 20000)
-	val := rand.String(ts.cfg.ObjectSize)
+	// update routines run concurrently
+	var latenciesMu sync.Mutex
+	observe := func(ls *latency.Durations, took time.Duration) {
+		latenciesMu.Lock()
+		*ls = append(*ls, took)
+		latenciesMu.Unlock()
+	}
 
 	shouldContinue := func(idx int) bool { return idx < ts.cfg.Objects }
 	if ts.cfg.Objects < 0 {
@@ -542,63 +723,67 @@ func (ts *tester) startUpdates(podImg string) (latenciesWrites latency.Durations
 		default:
 		}
 
-		podName := fmt.Sprintf("%s%d", ts.cfg.ObjectKeyPrefix, i%10)
+		kind := kinds[i%len(kinds)]
+		objName := fmt.Sprintf("%s%d", ts.cfg.ObjectKeyPrefix, i%10)
 
 		updateFunc := func() error {
-			podClient := ts.cfg.Client.KubernetesClient().CoreV1().Pods(ts.cfg.Namespace)
-
 			start := time.Now()
 			ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
-			pod, err := podClient.Get(ctx, podName, meta_v1.GetOptions{})
+			obj, err := kind.get(ctx, objName)
 			cancel()
 			took := time.Since(start)
 			tookMS := float64(took / time.Millisecond)
 			getRequestLatencyMs.Observe(tookMS)
-			latenciesGets = append(latenciesGets, took)
+			observe(&latenciesGets, took)
 			if err == nil {
 				getRequestsSuccessTotal.Inc()
 			} else {
 				if k8s_errors.IsNotFound(err) {
 					start = time.Now()
 					ctx, cancel = context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
-					_, err := podClient.Create(ctx, ts.createPodObject(podName, podImg, val), meta_v1.CreateOptions{})
+					err := kind.create(ctx, objName)
 					cancel()
 					took = time.Since(start)
 					tookMS = float64(took / time.Millisecond)
 					writeRequestLatencyMs.Observe(tookMS)
-					latenciesWrites = append(latenciesWrites, took)
+					observe(&latenciesWrites, took)
 					if err != nil {
 						if !k8s_errors.IsAlreadyExists(err) {
 							writeRequestsFailureTotal.Inc()
-							ts.cfg.Logger.Warn("create pod failed", zap.String("namespace", ts.cfg.Namespace), zap.Error(err))
+							ts.cfg.Logger.Warn("create failed", zap.String("kind", kind.name), zap.String("namespace", ts.cfg.Namespace), zap.Error(err))
 						}
 					} else {
 						writeRequestsSuccessTotal.Inc()
 						if i%20 == 0 {
-							ts.cfg.Logger.Info("created pod", zap.Int("iteration", i), zap.String("namespace", ts.cfg.Namespace))
+							ts.cfg.Logger.Info("created", zap.String("kind", kind.name), zap.Int("iteration", i), zap.String("namespace", ts.cfg.Namespace))
 						}
 					}
 					return nil
 				}
 				getRequestsFailureTotal.Inc()
-				ts.cfg.Logger.Warn("get pod failed", zap.String("namespace", ts.cfg.Namespace), zap.Error(err))
+				ts.cfg.Logger.Warn("get failed", zap.String("kind", kind.name), zap.String("namespace", ts.cfg.Namespace), zap.Error(err))
 				return err
 			}
 
 			// only update on "Get" success
-			if pod.Annotations == nil {
-				pod.Annotations = map[string]string{"key": "value"}
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			if _, ok := annotations["key"]; ok {
+				delete(annotations, "key")
 			} else {
-				pod.Annotations = nil
+				annotations["key"] = "value"
 			}
+			obj.SetAnnotations(annotations)
 			start = time.Now()
 			ctx, cancel = context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
-			_, updateErr := podClient.Update(ctx, pod, meta_v1.UpdateOptions{})
+			updateErr := kind.update(ctx, obj)
 			cancel()
 			took = time.Since(start)
 			tookMS = float64(took / time.Millisecond)
 			writeRequestLatencyMs.Observe(tookMS)
-			latenciesWrites = append(latenciesWrites, took)
+			observe(&latenciesWrites, took)
 			return updateErr
 		}
 
@@ -618,7 +803,7 @@ func (ts *tester) startUpdates(podImg string) (latenciesWrites latency.Durations
 	return latenciesWrites, latenciesGets
 }
 
-func (ts *tester) startRangeGets() (latenciesRangeGets latency.Durations) {
+func (ts *tester) startRangeGets(kinds []objectKind) (latenciesRangeGets latency.Durations) {
 	if ts.cfg.ListBatchLimit < 0 {
 		ts.cfg.Logger.Info("skipping range gets", zap.Int64("list-limit", ts.cfg.ListBatchLimit))
 		return latenciesRangeGets
@@ -638,12 +823,10 @@ func (ts *tester) startRangeGets() (latenciesRangeGets latency.Durations) {
 		default:
 		}
 
+		kind := kinds[i%len(kinds)]
 		start := time.Now()
 		ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Client.Config().ClientTimeout)
-		_, err := ts.cfg.Client.KubernetesClient().
-			CoreV1().
-			Pods(ts.cfg.Namespace).
-			List(ctx, meta_v1.ListOptions{Limit: ts.cfg.ListBatchLimit})
+		err := kind.list(ctx, ts.cfg.ListBatchLimit)
 		cancel()
 		took := time.Since(start)
 		tookMS := float64(took / time.Millisecond)
@@ -652,12 +835,12 @@ func (ts *tester) startRangeGets() (latenciesRangeGets latency.Durations) {
 		if err != nil {
 			rangeGetRequestsFailureTotal.Inc()
 			if i%10 == 0 {
-				ts.cfg.Logger.Warn("list pod failed", zap.String("namespace", ts.cfg.Namespace), zap.Error(err))
+				ts.cfg.Logger.Warn("list failed", zap.String("kind", kind.name), zap.String("namespace", ts.cfg.Namespace), zap.Error(err))
 			}
 		} else {
 			rangeGetRequestsSuccessTotal.Inc()
 			if i%200 == 0 {
-				ts.cfg.Logger.Info("listed pod", zap.Int("iteration", i), zap.String("namespace", ts.cfg.Namespace))
+				ts.cfg.Logger.Info("listed", zap.String("kind", kind.name), zap.Int("iteration", i), zap.String("namespace", ts.cfg.Namespace))
 			}
 		}
 	}
@@ -679,7 +862,7 @@ func (ts *tester) checkECRImage() (img string, err error) {
 
 // "string" in Go is just a pointer, so it's not being copied here
 func (ts *tester) createPodObject(podName string, busyboxImg string, val string) (po *core_v1.Pod) {
-	return &core_v1.Pod{
+	po = &core_v1.Pod{
 		TypeMeta: meta_v1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "Pod",
@@ -723,4 +906,29 @@ func (ts *tester) createPodObject(podName string, busyboxImg string, val string)
 			},
 		},
 	}
+	if ts.cfg.RestrictedPodSecurity {
+		po.Spec.SecurityContext = &core_v1.PodSecurityContext{
+			RunAsNonRoot:   boolRef(true),
+			RunAsUser:      int64Ref(nobodyUID),
+			SeccompProfile: &core_v1.SeccompProfile{Type: core_v1.SeccompProfileTypeRuntimeDefault},
+		}
+		po.Spec.Containers[0].SecurityContext = &core_v1.SecurityContext{
+			AllowPrivilegeEscalation: boolRef(false),
+			ReadOnlyRootFilesystem:   boolRef(true),
+			Capabilities:             &core_v1.Capabilities{Drop: []core_v1.Capability{"ALL"}},
+		}
+	}
+	return po
+}
+
+// nobodyUID is the non-root user ID to run the stress Pods
+// with the "restricted" Pod Security Standard.
+const nobodyUID int64 = 65534
+
+func boolRef(v bool) *bool {
+	return &v
+}
+
+func int64Ref(v int64) *int64 {
+	return &v
 }
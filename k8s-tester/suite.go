@@ -0,0 +1,323 @@
+package k8s_tester
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Suite is the test suite manifest for "k8s-tester run", that lists the testers
+// to run with their parameters, instead of one configuration with every add-on.
+// For example:
+//
+//	name: nightly
+//	extends:
+//	- base.yaml
+//	config:
+//	  minimum_nodes: 3
+//	testers:
+//	- name: metrics-server
+//	- name: conformance
+//	  depends_on:
+//	  - metrics-server
+//	  params:
+//	    sonobuoy_run_mode: quick
+type Suite struct {
+	// Name is the name of the suite.
+	Name string `json:"name"`
+	// Extends is the list of base suite files, relative to this suite file.
+	// The testers of the base suites run first, in the order of "Extends".
+	// A tester listed again overwrites the parameters of the base suite,
+	// and "Config" overwrites the configuration of the base suites.
+	Extends []string `json:"extends,omitempty"`
+	// Config is the k8s-tester configuration other than the add-ons
+	// (e.g., "minimum_nodes"), in the same format as the configuration file.
+	Config map[string]interface{} `json:"config,omitempty"`
+	// Testers is the list of testers to run, in order.
+	Testers []*SuiteTester `json:"testers"`
+}
+
+// SuiteTester is the tester in the suite.
+type SuiteTester struct {
+	// Name is the tester name, as in "TesterStatus" (e.g., "metrics-server", "cron-jobs-echo").
+	Name string `json:"name"`
+	// DependsOn is the list of testers in the suite that must run before this tester.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Params is the add-on configuration of the tester
+	// (e.g., "add_on_conformance" for "conformance"), merged into its defaults.
+	Params map[string]interface{} `json:"params,omitempty"`
+	// Disable is true to remove the tester of the base suites.
+	Disable bool `json:"disable,omitempty"`
+}
+
+// LoadSuite loads the suite manifest, with its base suites,
+// and validates the testers and their parameters.
+func LoadSuite(p string) (*Suite, error) {
+	s, err := loadSuite(p, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.validate(); err != nil {
+		return nil, fmt.Errorf("invalid suite %q (%v)", p, err)
+	}
+	return s, nil
+}
+
+// loadSuite loads the suite and merges its base suites,
+// where "loading" is the list of the suite files being loaded, to detect cycles.
+func loadSuite(p string, loading []string) (*Suite, error) {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range loading {
+		if l == p {
+			return nil, fmt.Errorf("suite %q extends itself (%s)", p, strings.Join(append(loading, p), " -> "))
+		}
+	}
+	d, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	s := new(Suite)
+	if err = yaml.Unmarshal(d, s, yaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf("failed to parse suite %q (%v)", p, err)
+	}
+
+	merged := &Suite{Name: s.Name, Config: make(map[string]interface{})}
+	for _, base := range s.Extends {
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(p), base)
+		}
+		bs, err := loadSuite(base, append(loading, p))
+		if err != nil {
+			return nil, err
+		}
+		merged.merge(bs)
+	}
+	merged.merge(s)
+	return merged, nil
+}
+
+// merge merges the configuration and the testers of "s" into "merged".
+func (merged *Suite) merge(s *Suite) {
+	mergeParams(merged.Config, s.Config)
+	for _, st := range s.Testers {
+		idx := -1
+		for i, cur := range merged.Testers {
+			if cur.Name == st.Name {
+				idx = i
+				break
+			}
+		}
+		switch {
+		case st.Disable && idx >= 0:
+			merged.Testers = append(merged.Testers[:idx], merged.Testers[idx+1:]...)
+		case st.Disable:
+		case idx >= 0:
+			cur := merged.Testers[idx]
+			if st.DependsOn != nil {
+				cur.DependsOn = st.DependsOn
+			}
+			if cur.Params == nil {
+				cur.Params = make(map[string]interface{})
+			}
+			mergeParams(cur.Params, st.Params)
+		default:
+			cur := &SuiteTester{Name: st.Name, DependsOn: st.DependsOn, Params: make(map[string]interface{})}
+			mergeParams(cur.Params, st.Params)
+			merged.Testers = append(merged.Testers, cur)
+		}
+	}
+}
+
+// mergeParams merges "src" into "dst", where the nested objects are merged
+// and the other values of "src" overwrite the ones in "dst".
+func mergeParams(dst map[string]interface{}, src map[string]interface{}) {
+	for k, v := range src {
+		sv, ok1 := v.(map[string]interface{})
+		dv, ok2 := dst[k].(map[string]interface{})
+		if ok1 && ok2 {
+			mergeParams(dv, sv)
+			continue
+		}
+		if ok1 {
+			cp := make(map[string]interface{}, len(sv))
+			mergeParams(cp, sv)
+			v = cp
+		}
+		dst[k] = v
+	}
+}
+
+// validate returns an error if the tester names, the dependencies,
+// or the parameters of the suite are invalid.
+func (s *Suite) validate() error {
+	if len(s.Testers) == 0 {
+		return errors.New("no tester")
+	}
+	for k := range s.Config {
+		if strings.HasPrefix(k, "add_on_") || k == "tester_order" {
+			return fmt.Errorf("config %q not allowed; configure the tester with \"testers\"", k)
+		}
+	}
+	// validates the suite configuration with the defaults
+	if _, err := s.BuildConfig(); err != nil {
+		return err
+	}
+	_, err := s.Order()
+	return err
+}
+
+// Order returns the tester names in the order to run, where each tester runs
+// after its dependencies, and otherwise in the order of the suite.
+func (s *Suite) Order() ([]string, error) {
+	listed := make(map[string]struct{}, len(s.Testers))
+	for _, st := range s.Testers {
+		listed[st.Name] = struct{}{}
+	}
+	for _, st := range s.Testers {
+		for _, dep := range st.DependsOn {
+			if _, ok := listed[dep]; !ok {
+				return nil, fmt.Errorf("tester %q depends on %q not in the suite", st.Name, dep)
+			}
+		}
+	}
+
+	order := make([]string, 0, len(s.Testers))
+	placed := make(map[string]struct{}, len(s.Testers))
+	for len(order) < len(s.Testers) {
+		progress := false
+		for _, st := range s.Testers {
+			if _, ok := placed[st.Name]; ok {
+				continue
+			}
+			ready := true
+			for _, dep := range st.DependsOn {
+				if _, ok := placed[dep]; !ok {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				order = append(order, st.Name)
+				placed[st.Name] = struct{}{}
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			cycle := make([]string, 0)
+			for _, st := range s.Testers {
+				if _, ok := placed[st.Name]; !ok {
+					cycle = append(cycle, st.Name)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle among testers %q", cycle)
+		}
+	}
+	return order, nil
+}
+
+// BuildConfig returns the k8s-tester configuration of the suite,
+// where only the testers of the suite are enabled, in the order of "Order".
+// Returns an error if a tester or a parameter is unknown, or has a wrong type.
+func (s *Suite) BuildConfig() (*Config, error) {
+	cfg := NewDefault()
+	if len(s.Config) > 0 {
+		d, err := yaml.Marshal(s.Config)
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.Unmarshal(d, cfg, yaml.DisallowUnknownFields); err != nil {
+			return nil, fmt.Errorf("invalid config (%v)", err)
+		}
+	}
+
+	fields := addOnFields()
+	vv := reflect.ValueOf(cfg).Elem()
+	for _, idx := range fields {
+		if ev := vv.Field(idx).Elem().FieldByName("Enable"); ev.IsValid() && ev.Kind() == reflect.Bool {
+			ev.SetBool(false)
+		}
+	}
+	seen := make(map[string]struct{}, len(s.Testers))
+	for _, st := range s.Testers {
+		if _, ok := seen[st.Name]; ok {
+			return nil, fmt.Errorf("duplicate tester %q", st.Name)
+		}
+		seen[st.Name] = struct{}{}
+		idx, ok := fields[st.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown tester %q (expected one of %q)", st.Name, addOnFieldNames(fields))
+		}
+		if _, ok = st.Params["enable"]; ok {
+			return nil, fmt.Errorf("tester %q param \"enable\" not allowed; the testers in the suite are enabled", st.Name)
+		}
+		fv := vv.Field(idx).Elem()
+		if len(st.Params) > 0 {
+			d, err := yaml.Marshal(st.Params)
+			if err != nil {
+				return nil, err
+			}
+			if err = yaml.Unmarshal(d, fv.Addr().Interface(), yaml.DisallowUnknownFields); err != nil {
+				return nil, fmt.Errorf("invalid tester %q params (%v)", st.Name, err)
+			}
+		}
+		if ev := fv.FieldByName("Enable"); ev.IsValid() && ev.Kind() == reflect.Bool {
+			ev.SetBool(true)
+		}
+	}
+
+	order, err := s.Order()
+	if err != nil {
+		return nil, err
+	}
+	cfg.TesterOrder = order
+	return cfg, nil
+}
+
+// addOnFields returns the index of the add-on config fields, keyed by "TesterStatus" key.
+func addOnFields() map[string]int {
+	fields := make(map[string]int)
+	tp := reflect.TypeOf(Config{})
+	for i := 0; i < tp.NumField(); i++ {
+		if !strings.HasPrefix(tp.Field(i).Name, "AddOn") || tp.Field(i).Type.Kind() != reflect.Ptr {
+			continue
+		}
+		_, key := addOnNames(tp.Field(i))
+		fields[key] = i
+	}
+	return fields
+}
+
+func addOnFieldNames(fields map[string]int) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateTesterOrder returns an error if "TesterOrder" lists an unknown tester, or a tester twice.
+func (cfg *Config) validateTesterOrder() error {
+	fields := addOnFields()
+	seen := make(map[string]struct{}, len(cfg.TesterOrder))
+	for _, name := range cfg.TesterOrder {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("unknown TesterOrder tester %q", name)
+		}
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("duplicate TesterOrder tester %q", name)
+		}
+		seen[name] = struct{}{}
+	}
+	return nil
+}
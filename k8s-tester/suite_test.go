@@ -0,0 +1,175 @@
+package k8s_tester
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+)
+
+func writeSuites(t *testing.T, suites map[string]string) string {
+	dir, err := ioutil.TempDir(os.TempDir(), "suite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, txt := range suites {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(txt), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadSuite(t *testing.T) {
+	dir := writeSuites(t, map[string]string{
+		"base.yaml": `
+name: base
+config:
+  minimum_nodes: 3
+  cluster_name: base
+testers:
+- name: configmaps
+  params:
+    objects: 10
+    object_size: 100
+- name: secrets
+- name: metrics-server
+`,
+		"nightly.yaml": `
+name: nightly
+extends:
+- base.yaml
+config:
+  cluster_name: nightly
+testers:
+- name: conformance
+  params:
+    sonobuoy_run_mode: quick
+- name: configmaps
+  depends_on:
+  - conformance
+  params:
+    objects: 20
+- name: secrets
+  disable: true
+`,
+	})
+
+	s, err := LoadSuite(filepath.Join(dir, "nightly.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := s.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"metrics-server", "conformance", "configmaps"}; !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected order %q, got %q", expected, order)
+	}
+
+	cfg, err := s.BuildConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ClusterName != "nightly" || cfg.MinimumNodes != 3 {
+		t.Fatalf("unexpected config cluster name %q, minimum nodes %d", cfg.ClusterName, cfg.MinimumNodes)
+	}
+	if !cfg.AddOnConfigmaps.Enable || cfg.AddOnConfigmaps.Objects != 20 || cfg.AddOnConfigmaps.ObjectSize != 100 {
+		t.Fatalf("unexpected configmaps config %+v", cfg.AddOnConfigmaps)
+	}
+	if !cfg.AddOnConformance.Enable || cfg.AddOnConformance.SonobuoyRunMode != "quick" {
+		t.Fatalf("unexpected conformance config %+v", cfg.AddOnConformance)
+	}
+	if cfg.AddOnSecrets.Enable || cfg.AddOnJobsPi.Enable {
+		t.Fatal("expected the testers not in the suite to be disabled")
+	}
+	if !reflect.DeepEqual(cfg.TesterOrder, order) {
+		t.Fatalf("expected tester order %q, got %q", order, cfg.TesterOrder)
+	}
+}
+
+func TestLoadSuiteInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		suites      map[string]string
+		expectedErr string
+	}{
+		{
+			name:        "unknown field",
+			suites:      map[string]string{"suite.yaml": "name: a\ntesterz: []\n"},
+			expectedErr: "unknown field",
+		},
+		{
+			name:        "unknown tester",
+			suites:      map[string]string{"suite.yaml": "testers:\n- name: hello\n"},
+			expectedErr: `unknown tester "hello"`,
+		},
+		{
+			name:        "unknown param",
+			suites:      map[string]string{"suite.yaml": "testers:\n- name: configmaps\n  params:\n    hello: 1\n"},
+			expectedErr: `invalid tester "configmaps" params`,
+		},
+		{
+			name:        "wrong param type",
+			suites:      map[string]string{"suite.yaml": "testers:\n- name: configmaps\n  params:\n    objects: hello\n"},
+			expectedErr: `invalid tester "configmaps" params`,
+		},
+		{
+			name:        "add-on in config",
+			suites:      map[string]string{"suite.yaml": "config:\n  add_on_configmaps:\n    enable: true\ntesters:\n- name: configmaps\n"},
+			expectedErr: `config "add_on_configmaps" not allowed`,
+		},
+		{
+			name:        "missing dependency",
+			suites:      map[string]string{"suite.yaml": "testers:\n- name: configmaps\n  depends_on:\n  - secrets\n"},
+			expectedErr: `depends on "secrets" not in the suite`,
+		},
+		{
+			name:        "dependency cycle",
+			suites:      map[string]string{"suite.yaml": "testers:\n- name: configmaps\n  depends_on:\n  - secrets\n- name: secrets\n  depends_on:\n  - configmaps\n"},
+			expectedErr: "dependency cycle",
+		},
+		{
+			name: "extends cycle",
+			suites: map[string]string{
+				"suite.yaml": "extends:\n- base.yaml\ntesters:\n- name: configmaps\n",
+				"base.yaml":  "extends:\n- suite.yaml\ntesters:\n- name: secrets\n",
+			},
+			expectedErr: "extends itself",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSuites(t, tt.suites)
+			_, err := LoadSuite(filepath.Join(dir, "suite.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestSortTesters(t *testing.T) {
+	cfg := NewDefault()
+	cfg.TesterOrder = []string{"secrets", "cron-jobs-echo"}
+	ts := &tester{cfg: cfg, statusKeys: make(map[k8s_tester.Tester]string)}
+	for _, name := range []string{"configmaps", cronJobsEchoStatusKey, "secrets", "csrs"} {
+		cur := &fakeTester{}
+		ts.testers = append(ts.testers, cur)
+		ts.statusKeys[cur] = name
+	}
+	ts.sortTesters()
+
+	names := make([]string, 0, len(ts.testers))
+	for _, cur := range ts.testers {
+		names = append(names, ts.statusKey(cur))
+	}
+	if expected := []string{"secrets", "cron-jobs-echo", "configmaps", "csrs"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected order %q, got %q", expected, names)
+	}
+}
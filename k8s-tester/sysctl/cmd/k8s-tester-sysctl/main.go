@@ -0,0 +1,165 @@
+// k8s-tester-sysctl validates the kernel parameters on every node.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/sysctl"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-sysctl",
+	Short:      "Kubernetes node sysctl tester",
+	SuggestFor: []string{"sysctl"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt             bool
+	logLevel           string
+	logOutputs         []string
+	minimumNodes       int
+	namespace          string
+	kubectlDownloadURL string
+	kubectlPath        string
+	kubeconfigPath     string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumNodes, "minimum-nodes", sysctl.DefaultMinimumNodes, "minimum number of Kubernetes nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-sysctl failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	profiles string
+	timeout  time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&profiles, "profiles", "", `minimum expected kernel parameters in JSON keyed by AMI family (e.g., '{"default":{"net.core.somaxconn":4096}}'), empty for the defaults`)
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", sysctl.DefaultTimeout, "timeout to wait for all nodes to report the kernel parameters")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &sysctl.Config{
+		Prompt:       prompt,
+		Logger:       lg,
+		LogWriter:    logWriter,
+		MinimumNodes: minimumNodes,
+		Namespace:    namespace,
+		Client:       cli,
+		Timeout:      timeout,
+	}
+	if profiles != "" {
+		if err := json.Unmarshal([]byte(profiles), &cfg.Profiles); err != nil {
+			lg.Panic("failed to parse --profiles", zap.Error(err))
+		}
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := sysctl.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-sysctl apply' success\n")
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &sysctl.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := sysctl.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-sysctl delete' success\n")
+}
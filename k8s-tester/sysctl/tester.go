@@ -0,0 +1,541 @@
+// Package sysctl deploys a DaemonSet that reads the kernel parameters on every Linux node,
+// and validates them against the expected values of the node AMI family,
+// in order to catch AMI regressions that only show under load
+// (e.g., conntrack table full, too many open files, inotify watch exhaustion).
+package sysctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumNodes is the minimum number of Kubernetes nodes required for installing this addon.
+	MinimumNodes int `json:"minimum_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Profiles is the minimum expected value of each kernel parameter, keyed by AMI family
+	// ("al2", "al2023", "bottlerocket", "ubuntu") and then by the sysctl key.
+	// The "default" profile applies to all nodes, and the AMI family profile overrides it.
+	// e.g., {"default":{"net.core.somaxconn":4096},"bottlerocket":{"fs.inotify.max_user_watches":65536}}
+	Profiles map[string]map[string]int64 `json:"profiles"`
+	// Timeout is the timeout to wait for all nodes to report the kernel parameters.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// NodeAMIFamilies is the AMI family of each checked node, keyed by node name.
+	NodeAMIFamilies map[string]string `json:"node_ami_families" read-only:"true"`
+	// Violations is the list of kernel parameters below the expected values.
+	Violations []string `json:"violations" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumNodes == 0 {
+		cfg.MinimumNodes = DefaultMinimumNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if len(cfg.Profiles) == 0 {
+		cfg.Profiles = DefaultProfiles()
+	}
+	for family, profile := range cfg.Profiles {
+		for key := range profile {
+			if key == "" || strings.ContainsAny(key, "/ \t'\"") {
+				return fmt.Errorf("invalid sysctl key %q in profile %q", key, family)
+			}
+		}
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumNodes int = 1
+	DefaultTimeout          = 5 * time.Minute
+
+	// DefaultProfileName is the profile that applies to all AMI families.
+	DefaultProfileName = "default"
+)
+
+// DefaultProfiles returns the minimum kernel parameters that the EKS optimized AMIs configure,
+// or the upstream kernel defaults, whichever is expected on a node under load.
+// ref. https://github.com/awslabs/amazon-eks-ami/blob/main/templates/al2/runtime/sysctl.conf
+func DefaultProfiles() map[string]map[string]int64 {
+	return map[string]map[string]int64{
+		DefaultProfileName: {
+			"net.netfilter.nf_conntrack_max": 131072,
+			"fs.file-max":                    65536,
+			"fs.nr_open":                     1048576,
+			"fs.inotify.max_user_watches":    524288,
+			"fs.inotify.max_user_instances":  8192,
+			"net.core.somaxconn":             4096,
+		},
+	}
+}
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:       false,
+		Prompt:       false,
+		MinimumNodes: DefaultMinimumNodes,
+		Namespace:    pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Profiles:     DefaultProfiles(),
+		Timeout:      DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	daemonSetName  = "sysctl"
+	daemonSetLabel = "sysctl"
+	busyboxImage   = "public.ecr.aws/hudsonbay/busybox:latest"
+
+	sysctlLinePrefix = "sysctl "
+	sysctlDoneLine   = "sysctl-done"
+	sysctlMissing    = "missing"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	if ts.cfg.MinimumNodes > 0 {
+		if nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient()); len(nodes) < ts.cfg.MinimumNodes || err != nil {
+			return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
+		}
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createDaemonSet(); err != nil {
+		return err
+	}
+
+	return ts.checkNodes()
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteDaemonSet(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		daemonSetName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete DaemonSet (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+// sysctlKeys returns the sorted keys of all profiles.
+func (ts *tester) sysctlKeys() []string {
+	seen := make(map[string]struct{})
+	for _, profile := range ts.cfg.Profiles {
+		for key := range profile {
+			seen[key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readScript prints each kernel parameter as "sysctl <key>=<value>" from "/proc/sys",
+// and keeps the Pod running so that the logs can be read.
+func readScript(keys []string) string {
+	return fmt.Sprintf(`for k in %s; do
+  p=/proc/sys/$(echo $k | tr . /)
+  if [ -r $p ]; then echo "%s$k=$(cat $p)"; else echo "%s$k=%s"; fi
+done
+echo %s
+while true; do sleep 3600; done
+`, strings.Join(keys, " "), sysctlLinePrefix, sysctlLinePrefix, sysctlMissing, sysctlDoneLine)
+}
+
+func (ts *tester) createDaemonSet() error {
+	keys := ts.sysctlKeys()
+	ts.cfg.Logger.Info("creating sysctl DaemonSet", zap.Strings("keys", keys))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		DaemonSets(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.DaemonSet{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      daemonSetName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: apps_v1.DaemonSetSpec{
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": daemonSetLabel,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": daemonSetLabel,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							// "net.*" parameters are per network namespace,
+							// so read them from the host network namespace
+							HostNetwork: true,
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+							Tolerations: []core_v1.Toleration{
+								{Operator: core_v1.TolerationOpExists},
+							},
+							Containers: []core_v1.Container{
+								{
+									Name:            "sysctl",
+									Image:           busyboxImage,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Command:         []string{"sh", "-c", readScript(keys)},
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create sysctl DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created sysctl DaemonSet")
+	return nil
+}
+
+// checkNodes waits until every DaemonSet Pod reports the kernel parameters,
+// and validates them against the profile of the node AMI family.
+func (ts *tester) checkNodes() error {
+	ts.cfg.Logger.Info("waiting for nodes to report kernel parameters", zap.String("timeout", ts.cfg.TimeoutString))
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("check nodes aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		ds, err := ts.cfg.Client.KubernetesClient().
+			AppsV1().
+			DaemonSets(ts.cfg.Namespace).
+			Get(ctx, daemonSetName, meta_v1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get sysctl DaemonSet; retrying", zap.Error(err))
+			continue
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pods, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Pods(ts.cfg.Namespace).
+			List(ctx, meta_v1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + daemonSetLabel})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list sysctl Pods; retrying", zap.Error(err))
+			continue
+		}
+
+		nodeValues := make(map[string]map[string]string)
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != core_v1.PodRunning {
+				continue
+			}
+			values, done, err := ts.readPodValues(pod.Name)
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to read sysctl Pod logs", zap.String("pod", pod.Name), zap.Error(err))
+				continue
+			}
+			if done {
+				nodeValues[pod.Spec.NodeName] = values
+			}
+		}
+		ts.cfg.Logger.Info("checked sysctl Pods",
+			zap.Int32("desired", ds.Status.DesiredNumberScheduled),
+			zap.Int("reported", len(nodeValues)),
+			zap.String("elapsed", time.Since(retryStart).String()),
+		)
+		if ds.Status.DesiredNumberScheduled == 0 || len(nodeValues) < int(ds.Status.DesiredNumberScheduled) {
+			continue
+		}
+
+		return ts.validate(nodeValues)
+	}
+	return fmt.Errorf("nodes did not report kernel parameters within %v", ts.cfg.Timeout)
+}
+
+func (ts *tester) readPodValues(podName string) (values map[string]string, done bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rc, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Pods(ts.cfg.Namespace).
+		GetLogs(podName, &core_v1.PodLogOptions{Container: "sysctl"}).
+		Stream(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, false, err
+	}
+	values, done = parseValues(string(b))
+	return values, done, nil
+}
+
+// parseValues parses the "sysctl <key>=<value>" lines of the DaemonSet Pod logs,
+// and returns true if all parameters were printed.
+func parseValues(logs string) (values map[string]string, done bool) {
+	values = make(map[string]string)
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if line == sysctlDoneLine {
+			done = true
+			continue
+		}
+		if !strings.HasPrefix(line, sysctlLinePrefix) {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(line, sysctlLinePrefix), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		values[kv[0]] = strings.TrimSpace(kv[1])
+	}
+	return values, done
+}
+
+func (ts *tester) validate(nodeValues map[string]map[string]string) error {
+	nodes, err := client.ListNodes(ts.cfg.Client.KubernetesClient())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	families := make(map[string]string)
+	for _, node := range nodes {
+		families[node.Name] = amiFamily(node.Status.NodeInfo.OSImage)
+	}
+
+	nodeNames := make([]string, 0, len(nodeValues))
+	for name := range nodeValues {
+		nodeNames = append(nodeNames, name)
+	}
+	sort.Strings(nodeNames)
+
+	ts.cfg.NodeAMIFamilies = make(map[string]string)
+	ts.cfg.Violations = make([]string, 0)
+	for _, name := range nodeNames {
+		family := families[name]
+		ts.cfg.NodeAMIFamilies[name] = family
+		vs := checkValues(expectations(ts.cfg.Profiles, family), nodeValues[name])
+		for _, v := range vs {
+			ts.cfg.Violations = append(ts.cfg.Violations, fmt.Sprintf("node %q (%s): %s", name, family, v))
+		}
+		ts.cfg.Logger.Info("checked node kernel parameters",
+			zap.String("node-name", name),
+			zap.String("ami-family", family),
+			zap.Any("values", nodeValues[name]),
+			zap.Int("violations", len(vs)),
+		)
+	}
+
+	if len(ts.cfg.Violations) > 0 {
+		fmt.Fprintf(ts.cfg.LogWriter, "\nkernel parameters below the expected values:\n\n")
+		for _, v := range ts.cfg.Violations {
+			fmt.Fprintf(ts.cfg.LogWriter, "  %s\n", v)
+		}
+		fmt.Fprintln(ts.cfg.LogWriter)
+		return fmt.Errorf("%d kernel parameter(s) below the expected values on %d node(s)", len(ts.cfg.Violations), len(nodeNames))
+	}
+	ts.cfg.Logger.Info("all nodes have the expected kernel parameters", zap.Int("nodes", len(nodeNames)))
+	return nil
+}
+
+// amiFamily returns the AMI family from the node OS image
+// (e.g., "Amazon Linux 2", "Amazon Linux 2023.4.20240416", "Bottlerocket OS 1.19.4 (aws-k8s-1.29)").
+func amiFamily(osImage string) string {
+	s := strings.ToLower(osImage)
+	switch {
+	case strings.Contains(s, "bottlerocket"):
+		return "bottlerocket"
+	case strings.Contains(s, "amazon linux 2023"):
+		return "al2023"
+	case strings.Contains(s, "amazon linux 2"):
+		return "al2"
+	case strings.Contains(s, "ubuntu"):
+		return "ubuntu"
+	}
+	return DefaultProfileName
+}
+
+// expectations returns the "default" profile overridden by the AMI family profile.
+func expectations(profiles map[string]map[string]int64, family string) map[string]int64 {
+	exp := make(map[string]int64)
+	for k, v := range profiles[DefaultProfileName] {
+		exp[k] = v
+	}
+	for k, v := range profiles[family] {
+		exp[k] = v
+	}
+	return exp
+}
+
+// checkValues returns the kernel parameters below the expected minimum values.
+func checkValues(exp map[string]int64, values map[string]string) (violations []string) {
+	keys := make([]string, 0, len(exp))
+	for k := range exp {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sv, ok := values[k]
+		if !ok || sv == sysctlMissing {
+			violations = append(violations, fmt.Sprintf("%s missing (expected >= %d)", k, exp[k]))
+			continue
+		}
+		v, err := strconv.ParseInt(sv, 10, 64)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("%s=%q not an integer (expected >= %d)", k, sv, exp[k]))
+			continue
+		}
+		if v < exp[k] {
+			violations = append(violations, fmt.Sprintf("%s=%d (expected >= %d)", k, v, exp[k]))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,74 @@
+package sysctl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseValues(t *testing.T) {
+	logs := `sysctl fs.file-max=9223372036854775807
+sysctl net.core.somaxconn=4096
+sysctl net.netfilter.nf_conntrack_max=missing
+sysctl-done
+`
+	values, done := parseValues(logs)
+	if !done {
+		t.Fatal("expected done")
+	}
+	exp := map[string]string{
+		"fs.file-max":                    "9223372036854775807",
+		"net.core.somaxconn":             "4096",
+		"net.netfilter.nf_conntrack_max": "missing",
+	}
+	if !reflect.DeepEqual(values, exp) {
+		t.Fatalf("expected %v, got %v", exp, values)
+	}
+
+	if _, done = parseValues("sysctl fs.file-max=1\n"); done {
+		t.Fatal("unexpected done")
+	}
+}
+
+func TestCheckValues(t *testing.T) {
+	profiles := map[string]map[string]int64{
+		DefaultProfileName: {"fs.file-max": 65536, "net.core.somaxconn": 4096, "fs.nr_open": 1048576},
+		"bottlerocket":     {"net.core.somaxconn": 128},
+	}
+	values := map[string]string{
+		"fs.file-max":        "65536",
+		"net.core.somaxconn": "1024",
+		"fs.nr_open":         "missing",
+	}
+
+	vs := checkValues(expectations(profiles, "al2"), values)
+	exp := []string{
+		"fs.nr_open missing (expected >= 1048576)",
+		"net.core.somaxconn=1024 (expected >= 4096)",
+	}
+	if !reflect.DeepEqual(vs, exp) {
+		t.Fatalf("expected %v, got %v", exp, vs)
+	}
+
+	vs = checkValues(expectations(profiles, "bottlerocket"), values)
+	exp = []string{
+		"fs.nr_open missing (expected >= 1048576)",
+	}
+	if !reflect.DeepEqual(vs, exp) {
+		t.Fatalf("expected %v, got %v", exp, vs)
+	}
+}
+
+func TestAMIFamily(t *testing.T) {
+	tt := map[string]string{
+		"Amazon Linux 2":                        "al2",
+		"Amazon Linux 2023.4.20240416":          "al2023",
+		"Bottlerocket OS 1.19.4 (aws-k8s-1.29)": "bottlerocket",
+		"Ubuntu 22.04.4 LTS":                    "ubuntu",
+		"Windows Server 2019 Datacenter":        DefaultProfileName,
+	}
+	for osImage, exp := range tt {
+		if got := amiFamily(osImage); got != exp {
+			t.Fatalf("%q: expected %q, got %q", osImage, exp, got)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package sysdig
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+)
+
+func TestRender(t *testing.T) {
+	cfg := NewDefault()
+	cfg.Namespace = "test-namespace"
+	cfg.AccessKey = "test-access-key"
+	cfg.CollectorEndpoint = "collector.sysdigcloud.com"
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := New(cfg).(*tester).Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	fake.AssertGolden(t, filepath.Join("testdata", "render.golden.yaml"), buf.Bytes())
+}
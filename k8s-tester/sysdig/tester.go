@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -82,6 +83,25 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 }
 
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
 type tester struct {
 	cfg *Config
 }
@@ -155,7 +175,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -166,8 +187,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 }
 
 // https://github.com/sysdiglabs/charts/blob/master/charts/sysdig/values.yaml
-func (ts *tester) createHelmSysdig() error {
-	values := map[string]interface{}{
+func (ts *tester) helmValues() map[string]interface{} {
+	return map[string]interface{}{
 		"sysdig": map[string]interface{}{
 			"accessKey": ts.cfg.AccessKey,
 		},
@@ -175,7 +196,9 @@ func (ts *tester) createHelmSysdig() error {
 			"collectorEndpoint": ts.cfg.CollectorEndpoint,
 		},
 	}
+}
 
+func (ts *tester) createHelmSysdig() error {
 	getAllArgs := []string{
 		ts.cfg.Client.Config().KubectlPath,
 		"--kubeconfig=" + ts.cfg.Client.Config().KubeconfigPath,
@@ -225,7 +248,7 @@ func (ts *tester) createHelmSysdig() error {
 		ChartRepoURL:   ts.cfg.HelmChartRepoURL,
 		ChartName:      chartName,
 		ReleaseName:    chartName,
-		Values:         values,
+		Values:         ts.helmValues(),
 		LogFunc: func(format string, v ...interface{}) {
 			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
 		},
@@ -3,56 +3,111 @@
 package k8s_tester
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/signal"
 	"path"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/adot"
+	argo_workflows "github.com/aws/aws-k8s-tester/k8s-tester/argo-workflows"
+	artifacts_s3 "github.com/aws/aws-k8s-tester/k8s-tester/artifacts-s3"
+	auth_refresh "github.com/aws/aws-k8s-tester/k8s-tester/auth-refresh"
+	cert_rotation "github.com/aws/aws-k8s-tester/k8s-tester/cert-rotation"
 	cloudwatch_agent "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-agent"
+	cloudwatch_metrics "github.com/aws/aws-k8s-tester/k8s-tester/cloudwatch-metrics"
+	cluster_pause "github.com/aws/aws-k8s-tester/k8s-tester/cluster-pause"
 	"github.com/aws/aws-k8s-tester/k8s-tester/clusterloader"
 	cni "github.com/aws/aws-k8s-tester/k8s-tester/cni"
 	"github.com/aws/aws-k8s-tester/k8s-tester/configmaps"
 	"github.com/aws/aws-k8s-tester/k8s-tester/conformance"
+	cross_cluster "github.com/aws/aws-k8s-tester/k8s-tester/cross-cluster"
 	csi_ebs "github.com/aws/aws-k8s-tester/k8s-tester/csi-ebs"
+	csi_s3 "github.com/aws/aws-k8s-tester/k8s-tester/csi-s3"
 	"github.com/aws/aws-k8s-tester/k8s-tester/csrs"
+	daemonset_rollout "github.com/aws/aws-k8s-tester/k8s-tester/daemonset-rollout"
+	"github.com/aws/aws-k8s-tester/k8s-tester/dns"
+	emr_on_eks "github.com/aws/aws-k8s-tester/k8s-tester/emr-on-eks"
+	"github.com/aws/aws-k8s-tester/k8s-tester/endpointslices"
+	event_flood "github.com/aws/aws-k8s-tester/k8s-tester/event-flood"
 	falco "github.com/aws/aws-k8s-tester/k8s-tester/falco"
 	"github.com/aws/aws-k8s-tester/k8s-tester/falcon"
+	"github.com/aws/aws-k8s-tester/k8s-tester/fargate"
 	fluent_bit "github.com/aws/aws-k8s-tester/k8s-tester/fluent-bit"
+	hollow_nodes "github.com/aws/aws-k8s-tester/k8s-tester/hollow-nodes"
+	hpa_cloudwatch "github.com/aws/aws-k8s-tester/k8s-tester/hpa-cloudwatch"
+	iam_preflight "github.com/aws/aws-k8s-tester/k8s-tester/iam-preflight"
+	image_prepull "github.com/aws/aws-k8s-tester/k8s-tester/image-prepull"
+	"github.com/aws/aws-k8s-tester/k8s-tester/irsa"
+	istio_ambient "github.com/aws/aws-k8s-tester/k8s-tester/istio-ambient"
 	jobs_echo "github.com/aws/aws-k8s-tester/k8s-tester/jobs-echo"
 	jobs_pi "github.com/aws/aws-k8s-tester/k8s-tester/jobs-pi"
+	"github.com/aws/aws-k8s-tester/k8s-tester/karpenter"
+	keda_sqs "github.com/aws/aws-k8s-tester/k8s-tester/keda-sqs"
+	kube_state_metrics "github.com/aws/aws-k8s-tester/k8s-tester/kube-state-metrics"
 	kubernetes_dashboard "github.com/aws/aws-k8s-tester/k8s-tester/kubernetes-dashboard"
+	"github.com/aws/aws-k8s-tester/k8s-tester/kueue"
 	metrics_server "github.com/aws/aws-k8s-tester/k8s-tester/metrics-server"
+	multi_container "github.com/aws/aws-k8s-tester/k8s-tester/multi-container"
+	network_policy "github.com/aws/aws-k8s-tester/k8s-tester/network-policy"
+	"github.com/aws/aws-k8s-tester/k8s-tester/neuron"
 	nlb_guestbook "github.com/aws/aws-k8s-tester/k8s-tester/nlb-guestbook"
 	nlb_hello_world "github.com/aws/aws-k8s-tester/k8s-tester/nlb-hello-world"
+	node_problem_detector "github.com/aws/aws-k8s-tester/k8s-tester/node-problem-detector"
+	node_tag_labels "github.com/aws/aws-k8s-tester/k8s-tester/node-tag-labels"
+	nvidia_gpu "github.com/aws/aws-k8s-tester/k8s-tester/nvidia-gpu"
+	opensearch_results "github.com/aws/aws-k8s-tester/k8s-tester/opensearch-results"
+	"github.com/aws/aws-k8s-tester/k8s-tester/pdb"
 	php_apache "github.com/aws/aws-k8s-tester/k8s-tester/php-apache"
+	port_exhaustion "github.com/aws/aws-k8s-tester/k8s-tester/port-exhaustion"
+	probe_storm "github.com/aws/aws-k8s-tester/k8s-tester/probe-storm"
+	prometheus_grafana "github.com/aws/aws-k8s-tester/k8s-tester/prometheus-grafana"
+	pv_reclaim "github.com/aws/aws-k8s-tester/k8s-tester/pv-reclaim"
+	runtime_restart "github.com/aws/aws-k8s-tester/k8s-tester/runtime-restart"
 	"github.com/aws/aws-k8s-tester/k8s-tester/secrets"
+	static_pod "github.com/aws/aws-k8s-tester/k8s-tester/static-pod"
 	"github.com/aws/aws-k8s-tester/k8s-tester/stress"
 	stress_in_cluster "github.com/aws/aws-k8s-tester/k8s-tester/stress/in-cluster"
+	"github.com/aws/aws-k8s-tester/k8s-tester/sysctl"
 	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
 	"github.com/aws/aws-k8s-tester/k8s-tester/version"
+	"github.com/aws/aws-k8s-tester/k8s-tester/windows"
 	"github.com/aws/aws-k8s-tester/k8s-tester/wordpress"
 	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/aws/aws-k8s-tester/utils/rand"
 	"github.com/dustin/go-humanize"
-	"github.com/manifoldco/promptui"
 	"go.uber.org/zap"
 )
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := NewTester(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	return ts
+}
+
+// NewTester validates the configuration, and returns the tester of all enabled add-ons
+// to embed in other Go programs. The creation is stopped when "ctx" is done,
+// so the callers handle the OS signals by canceling "ctx".
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
 	if err := cfg.ValidateAndSetDefaults(); err != nil {
-		panic(fmt.Errorf("failed to validate config %v", err))
+		return nil, fmt.Errorf("failed to validate config %v", err)
 	}
 
 	lg, logWriter, logFile, err := log.NewWithStderrWriter(cfg.LogLevel, cfg.LogOutputs)
 	if err != nil {
-		panic(fmt.Errorf("failed to create logger %v", err))
+		return nil, fmt.Errorf("failed to create logger %v", err)
 	}
 	_ = zap.ReplaceGlobals(lg)
 
@@ -61,39 +116,60 @@ func New(cfg *Config) k8s_tester.Tester {
 
 		stopCreationCh:     make(chan struct{}),
 		stopCreationChOnce: new(sync.Once),
-		osSig:              make(chan os.Signal),
 		deleteMu:           new(sync.Mutex),
 
-		cfg:       cfg,
-		logger:    lg,
-		logWriter: logWriter,
-		logFile:   logFile,
-		testers:   make([]k8s_tester.Tester, 0),
+		cfg:        cfg,
+		logger:     lg,
+		logWriter:  logWriter,
+		logFile:    logFile,
+		testers:    make([]k8s_tester.Tester, 0),
+		stopcs:     make(map[k8s_tester.Tester]*chan struct{}),
+		statusKeys: make(map[k8s_tester.Tester]string),
 	}
-	signal.Notify(ts.osSig, syscall.SIGTERM, syscall.SIGINT)
 
 	fmt.Fprint(logWriter, ts.color("\n\n\n[yellow]*********************************\n"))
 	fmt.Fprintln(logWriter, "😎 🙏 🚶 ✔️ 👍")
 	fmt.Fprintf(logWriter, ts.color("[light_green]New k8s-tester %q [default](%q)\n\n"), cfg.ConfigPath, version.Version())
 
+	ts.apiCalls = client.NewAPICalls(cfg.APIQPSBudget, cfg.APIBurstBudget)
+	ts.setAPICallsTester("")
 	ts.cli, err = client.New(&client.Config{
 		Logger:             lg,
 		KubectlDownloadURL: cfg.KubectlDownloadURL,
 		KubectlPath:        cfg.KubectlPath,
 		KubeconfigPath:     cfg.KubeconfigPath,
 		KubeconfigContext:  cfg.KubeconfigContext,
+		ConfigMode:         cfg.ClientConfigMode,
+		EKS:                cfg.clientEKS(),
 		Clients:            cfg.Clients,
 		ClientQPS:          cfg.ClientQPS,
 		ClientBurst:        cfg.ClientBurst,
 		ClientTimeout:      cfg.ClientTimeout,
+		RequestTimeout:     cfg.ClientRequestTimeout,
+		RetryBackoff:       cfg.clientRetryBackoff(),
+		APICalls:           ts.apiCalls,
 	})
 	if err != nil {
-		lg.Panic("failed to create client", zap.Error(err))
+		return nil, fmt.Errorf("failed to create client %v", err)
 	}
 
-	ts.createTesters()
+	if err = ts.createTestersNoPanic(); err != nil {
+		return nil, err
+	}
+	for _, cur := range ts.testers {
+		if _, ok := cfg.TesterStatus[ts.statusKey(cur)]; !ok {
+			cfg.SetTesterStatus(ts.statusKey(cur), TesterStatusNotStarted, nil)
+		}
+	}
+	cfg.Sync()
 
-	return ts
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			ts.stopCreationChOnce.Do(func() { close(ts.stopCreationCh) })
+		}()
+	}
+	return ts, nil
 }
 
 type tester struct {
@@ -101,17 +177,64 @@ type tester struct {
 
 	stopCreationCh     chan struct{}
 	stopCreationChOnce *sync.Once
-	osSig              chan os.Signal
 	deleteMu           *sync.Mutex
 	logger             *zap.Logger
 	logWriter          io.Writer
 	logFile            *os.File
 	cli                client.Client
+	// apiCalls counts the API calls of each tester, and enforces "APIQPSBudget".
+	apiCalls *client.APICalls
 
 	cfg *Config
 
 	// tester order is defined as https://github.com/aws/aws-k8s-tester/blob/v1.5.9/eks/eks.go#L617
 	testers []k8s_tester.Tester
+	// stopcs is the "Stopc" field of each tester config,
+	// to stop a timed out "Apply" without stopping the other testers.
+	stopcs map[k8s_tester.Tester]*chan struct{}
+	// statusKeys is the key of the tester in "TesterStatus", if not "Name".
+	statusKeys map[k8s_tester.Tester]string
+
+	// started is the time when "Apply" started.
+	started time.Time
+	// results is the list of "Apply" results for the reports.
+	results []testResult
+	// skipped is the testers skipped by the prompt (see "runPrompt").
+	skipped map[k8s_tester.Tester]struct{}
+}
+
+// createTestersNoPanic calls "createTesters", and returns the panic of
+// the add-on constructors (e.g., failed to create the AWS session) as an error.
+func (ts *tester) createTestersNoPanic() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to create testers (%v)", r)
+		}
+	}()
+	ts.createTesters()
+	ts.sortTesters()
+	return nil
+}
+
+// sortTesters reorders the testers as "TesterOrder",
+// followed by the testers not listed in the default order.
+func (ts *tester) sortTesters() {
+	if len(ts.cfg.TesterOrder) == 0 {
+		return
+	}
+	rank := make(map[string]int, len(ts.cfg.TesterOrder))
+	for i, name := range ts.cfg.TesterOrder {
+		rank[name] = i
+	}
+	rankOf := func(cur k8s_tester.Tester) int {
+		if r, ok := rank[ts.statusKey(cur)]; ok {
+			return r
+		}
+		return len(rank)
+	}
+	sort.SliceStable(ts.testers, func(i, j int) bool {
+		return rankOf(ts.testers[i]) < rankOf(ts.testers[j])
+	})
 }
 
 func (ts *tester) createTesters() {
@@ -124,157 +247,437 @@ func (ts *tester) createTesters() {
 		ts.cfg.AddOnCloudwatchAgent.Logger = ts.logger
 		ts.cfg.AddOnCloudwatchAgent.LogWriter = ts.logWriter
 		ts.cfg.AddOnCloudwatchAgent.Client = ts.cli
-		ts.testers = append(ts.testers, cloudwatch_agent.New(ts.cfg.AddOnCloudwatchAgent))
+		ts.addTester(cloudwatch_agent.New(ts.cfg.AddOnCloudwatchAgent), &ts.cfg.AddOnCloudwatchAgent.Stopc)
 	}
 	if ts.cfg.AddOnFluentBit != nil && ts.cfg.AddOnFluentBit.Enable {
 		ts.cfg.AddOnFluentBit.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnFluentBit.Logger = ts.logger
 		ts.cfg.AddOnFluentBit.LogWriter = ts.logWriter
 		ts.cfg.AddOnFluentBit.Client = ts.cli
-		ts.testers = append(ts.testers, fluent_bit.New(ts.cfg.AddOnFluentBit))
+		ts.addTester(fluent_bit.New(ts.cfg.AddOnFluentBit), &ts.cfg.AddOnFluentBit.Stopc)
 	}
 	if ts.cfg.AddOnMetricsServer != nil && ts.cfg.AddOnMetricsServer.Enable {
 		ts.cfg.AddOnMetricsServer.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnMetricsServer.Logger = ts.logger
 		ts.cfg.AddOnMetricsServer.LogWriter = ts.logWriter
 		ts.cfg.AddOnMetricsServer.Client = ts.cli
-		ts.testers = append(ts.testers, metrics_server.New(ts.cfg.AddOnMetricsServer))
+		ts.addTester(metrics_server.New(ts.cfg.AddOnMetricsServer), &ts.cfg.AddOnMetricsServer.Stopc)
 	}
 	if ts.cfg.AddOnCNI != nil && ts.cfg.AddOnCNI.Enable {
 		ts.cfg.AddOnCNI.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnCNI.Logger = ts.logger
 		ts.cfg.AddOnCNI.LogWriter = ts.logWriter
 		ts.cfg.AddOnCNI.Client = ts.cli
-		ts.testers = append(ts.testers, cni.New(ts.cfg.AddOnCNI))
+		ts.addTester(cni.New(ts.cfg.AddOnCNI), &ts.cfg.AddOnCNI.Stopc)
 	}
 	if ts.cfg.AddOnConformance != nil && ts.cfg.AddOnConformance.Enable {
 		ts.cfg.AddOnConformance.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnConformance.Logger = ts.logger
 		ts.cfg.AddOnConformance.LogWriter = ts.logWriter
 		ts.cfg.AddOnConformance.Client = ts.cli
-		ts.testers = append(ts.testers, conformance.New(ts.cfg.AddOnConformance))
+		ts.addTester(conformance.New(ts.cfg.AddOnConformance), &ts.cfg.AddOnConformance.Stopc)
 	}
 	if ts.cfg.AddOnCSIEBS != nil && ts.cfg.AddOnCSIEBS.Enable {
 		ts.cfg.AddOnCSIEBS.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnCSIEBS.Logger = ts.logger
 		ts.cfg.AddOnCSIEBS.LogWriter = ts.logWriter
 		ts.cfg.AddOnCSIEBS.Client = ts.cli
-		ts.testers = append(ts.testers, csi_ebs.New(ts.cfg.AddOnCSIEBS))
+		ts.addTester(csi_ebs.New(ts.cfg.AddOnCSIEBS), &ts.cfg.AddOnCSIEBS.Stopc)
 	}
 	if ts.cfg.AddOnKubernetesDashboard != nil && ts.cfg.AddOnKubernetesDashboard.Enable {
 		ts.cfg.AddOnKubernetesDashboard.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnKubernetesDashboard.Logger = ts.logger
 		ts.cfg.AddOnKubernetesDashboard.LogWriter = ts.logWriter
 		ts.cfg.AddOnKubernetesDashboard.Client = ts.cli
-		ts.testers = append(ts.testers, kubernetes_dashboard.New(ts.cfg.AddOnKubernetesDashboard))
+		ts.addTester(kubernetes_dashboard.New(ts.cfg.AddOnKubernetesDashboard), &ts.cfg.AddOnKubernetesDashboard.Stopc)
 	}
 	if ts.cfg.AddOnPHPApache != nil && ts.cfg.AddOnPHPApache.Enable {
 		ts.cfg.AddOnPHPApache.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnPHPApache.Logger = ts.logger
 		ts.cfg.AddOnPHPApache.LogWriter = ts.logWriter
 		ts.cfg.AddOnPHPApache.Client = ts.cli
-		ts.testers = append(ts.testers, php_apache.New(ts.cfg.AddOnPHPApache))
+		ts.addTester(php_apache.New(ts.cfg.AddOnPHPApache), &ts.cfg.AddOnPHPApache.Stopc)
 	}
 	if ts.cfg.AddOnNLBGuestbook != nil && ts.cfg.AddOnNLBGuestbook.Enable {
 		ts.cfg.AddOnNLBGuestbook.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnNLBGuestbook.Logger = ts.logger
 		ts.cfg.AddOnNLBGuestbook.LogWriter = ts.logWriter
 		ts.cfg.AddOnNLBGuestbook.Client = ts.cli
-		ts.testers = append(ts.testers, nlb_guestbook.New(ts.cfg.AddOnNLBGuestbook))
+		ts.addTester(nlb_guestbook.New(ts.cfg.AddOnNLBGuestbook), &ts.cfg.AddOnNLBGuestbook.Stopc)
 	}
 	if ts.cfg.AddOnNLBHelloWorld != nil && ts.cfg.AddOnNLBHelloWorld.Enable {
 		ts.cfg.AddOnNLBHelloWorld.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnNLBHelloWorld.Logger = ts.logger
 		ts.cfg.AddOnNLBHelloWorld.LogWriter = ts.logWriter
 		ts.cfg.AddOnNLBHelloWorld.Client = ts.cli
-		ts.testers = append(ts.testers, nlb_hello_world.New(ts.cfg.AddOnNLBHelloWorld))
+		ts.addTester(nlb_hello_world.New(ts.cfg.AddOnNLBHelloWorld), &ts.cfg.AddOnNLBHelloWorld.Stopc)
 	}
 	if ts.cfg.AddOnWordpress != nil && ts.cfg.AddOnWordpress.Enable {
 		ts.cfg.AddOnWordpress.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnWordpress.Logger = ts.logger
 		ts.cfg.AddOnWordpress.LogWriter = ts.logWriter
 		ts.cfg.AddOnWordpress.Client = ts.cli
-		ts.testers = append(ts.testers, wordpress.New(ts.cfg.AddOnWordpress))
+		ts.addTester(wordpress.New(ts.cfg.AddOnWordpress), &ts.cfg.AddOnWordpress.Stopc)
 	}
 	if ts.cfg.AddOnJobsPi != nil && ts.cfg.AddOnJobsPi.Enable {
 		ts.cfg.AddOnJobsPi.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnJobsPi.Logger = ts.logger
 		ts.cfg.AddOnJobsPi.LogWriter = ts.logWriter
 		ts.cfg.AddOnJobsPi.Client = ts.cli
-		ts.testers = append(ts.testers, jobs_pi.New(ts.cfg.AddOnJobsPi))
+		ts.addTester(jobs_pi.New(ts.cfg.AddOnJobsPi), &ts.cfg.AddOnJobsPi.Stopc)
 	}
 	if ts.cfg.AddOnJobsEcho != nil && ts.cfg.AddOnJobsEcho.Enable {
 		ts.cfg.AddOnJobsEcho.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnJobsEcho.Logger = ts.logger
 		ts.cfg.AddOnJobsEcho.LogWriter = ts.logWriter
 		ts.cfg.AddOnJobsEcho.Client = ts.cli
-		ts.testers = append(ts.testers, jobs_echo.New(ts.cfg.AddOnJobsEcho))
+		ts.addTester(jobs_echo.New(ts.cfg.AddOnJobsEcho), &ts.cfg.AddOnJobsEcho.Stopc)
 	}
 	if ts.cfg.AddOnCronJobsEcho != nil && ts.cfg.AddOnCronJobsEcho.Enable {
 		ts.cfg.AddOnCronJobsEcho.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnCronJobsEcho.Logger = ts.logger
 		ts.cfg.AddOnCronJobsEcho.LogWriter = ts.logWriter
 		ts.cfg.AddOnCronJobsEcho.Client = ts.cli
-		ts.testers = append(ts.testers, jobs_echo.New(ts.cfg.AddOnCronJobsEcho))
+		cur := jobs_echo.New(ts.cfg.AddOnCronJobsEcho)
+		ts.addTester(cur, &ts.cfg.AddOnCronJobsEcho.Stopc)
+		ts.statusKeys[cur] = cronJobsEchoStatusKey
 	}
 	if ts.cfg.AddOnCSRs != nil && ts.cfg.AddOnCSRs.Enable {
 		ts.cfg.AddOnCSRs.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnCSRs.Logger = ts.logger
 		ts.cfg.AddOnCSRs.LogWriter = ts.logWriter
 		ts.cfg.AddOnCSRs.Client = ts.cli
-		ts.testers = append(ts.testers, csrs.New(ts.cfg.AddOnCSRs))
+		ts.addTester(csrs.New(ts.cfg.AddOnCSRs), &ts.cfg.AddOnCSRs.Stopc)
 	}
 	if ts.cfg.AddOnConfigmaps != nil && ts.cfg.AddOnConfigmaps.Enable {
 		ts.cfg.AddOnConfigmaps.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnConfigmaps.Logger = ts.logger
 		ts.cfg.AddOnConfigmaps.LogWriter = ts.logWriter
 		ts.cfg.AddOnConfigmaps.Client = ts.cli
-		ts.testers = append(ts.testers, configmaps.New(ts.cfg.AddOnConfigmaps))
+		ts.addTester(configmaps.New(ts.cfg.AddOnConfigmaps), &ts.cfg.AddOnConfigmaps.Stopc)
 	}
 	if ts.cfg.AddOnSecrets != nil && ts.cfg.AddOnSecrets.Enable {
 		ts.cfg.AddOnSecrets.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnSecrets.Logger = ts.logger
 		ts.cfg.AddOnSecrets.LogWriter = ts.logWriter
 		ts.cfg.AddOnSecrets.Client = ts.cli
-		ts.testers = append(ts.testers, secrets.New(ts.cfg.AddOnSecrets))
+		ts.addTester(secrets.New(ts.cfg.AddOnSecrets), &ts.cfg.AddOnSecrets.Stopc)
 	}
 	if ts.cfg.AddOnClusterloader != nil && ts.cfg.AddOnClusterloader.Enable {
 		ts.cfg.AddOnClusterloader.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnClusterloader.Logger = ts.logger
 		ts.cfg.AddOnClusterloader.LogWriter = ts.logWriter
 		ts.cfg.AddOnClusterloader.Client = ts.cli
-		ts.testers = append(ts.testers, clusterloader.New(ts.cfg.AddOnClusterloader))
+		ts.addTester(clusterloader.New(ts.cfg.AddOnClusterloader), &ts.cfg.AddOnClusterloader.Stopc)
 	}
 	if ts.cfg.AddOnStress != nil && ts.cfg.AddOnStress.Enable {
 		ts.cfg.AddOnStress.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnStress.Logger = ts.logger
 		ts.cfg.AddOnStress.LogWriter = ts.logWriter
 		ts.cfg.AddOnStress.Client = ts.cli
-		ts.testers = append(ts.testers, stress.New(ts.cfg.AddOnStress))
+		ts.addTester(stress.New(ts.cfg.AddOnStress), &ts.cfg.AddOnStress.Stopc)
 	}
 	if ts.cfg.AddOnStressInCluster != nil && ts.cfg.AddOnStressInCluster.Enable {
 		ts.cfg.AddOnStressInCluster.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnStressInCluster.Logger = ts.logger
 		ts.cfg.AddOnStressInCluster.LogWriter = ts.logWriter
 		ts.cfg.AddOnStressInCluster.Client = ts.cli
-		ts.testers = append(ts.testers, stress_in_cluster.New(ts.cfg.AddOnStressInCluster))
+		ts.addTester(stress_in_cluster.New(ts.cfg.AddOnStressInCluster), &ts.cfg.AddOnStressInCluster.Stopc)
 	}
 	if ts.cfg.AddOnFalco != nil && ts.cfg.AddOnFalco.Enable {
 		ts.cfg.AddOnFalco.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnFalco.Logger = ts.logger
 		ts.cfg.AddOnFalco.LogWriter = ts.logWriter
 		ts.cfg.AddOnFalco.Client = ts.cli
-		ts.testers = append(ts.testers, falco.New(ts.cfg.AddOnFalco))
+		ts.addTester(falco.New(ts.cfg.AddOnFalco), &ts.cfg.AddOnFalco.Stopc)
 	}
 	if ts.cfg.AddOnFalcon != nil && ts.cfg.AddOnFalcon.Enable {
 		ts.cfg.AddOnFalcon.Stopc = ts.stopCreationCh
 		ts.cfg.AddOnFalcon.Logger = ts.logger
 		ts.cfg.AddOnFalcon.LogWriter = ts.logWriter
 		ts.cfg.AddOnFalcon.Client = ts.cli
-		ts.testers = append(ts.testers, falcon.New(ts.cfg.AddOnFalcon))
+		ts.addTester(falcon.New(ts.cfg.AddOnFalcon), &ts.cfg.AddOnFalcon.Stopc)
+	}
+	if ts.cfg.AddOnHPACloudWatch != nil && ts.cfg.AddOnHPACloudWatch.Enable {
+		ts.cfg.AddOnHPACloudWatch.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnHPACloudWatch.Logger = ts.logger
+		ts.cfg.AddOnHPACloudWatch.LogWriter = ts.logWriter
+		ts.cfg.AddOnHPACloudWatch.Client = ts.cli
+		ts.addTester(hpa_cloudwatch.New(ts.cfg.AddOnHPACloudWatch), &ts.cfg.AddOnHPACloudWatch.Stopc)
+	}
+	if ts.cfg.AddOnKEDASQS != nil && ts.cfg.AddOnKEDASQS.Enable {
+		ts.cfg.AddOnKEDASQS.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnKEDASQS.Logger = ts.logger
+		ts.cfg.AddOnKEDASQS.LogWriter = ts.logWriter
+		ts.cfg.AddOnKEDASQS.Client = ts.cli
+		ts.addTester(keda_sqs.New(ts.cfg.AddOnKEDASQS), &ts.cfg.AddOnKEDASQS.Stopc)
+	}
+	if ts.cfg.AddOnCSIS3 != nil && ts.cfg.AddOnCSIS3.Enable {
+		ts.cfg.AddOnCSIS3.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnCSIS3.Logger = ts.logger
+		ts.cfg.AddOnCSIS3.LogWriter = ts.logWriter
+		ts.cfg.AddOnCSIS3.Client = ts.cli
+		ts.addTester(csi_s3.New(ts.cfg.AddOnCSIS3), &ts.cfg.AddOnCSIS3.Stopc)
+	}
+	if ts.cfg.AddOnEMROnEKS != nil && ts.cfg.AddOnEMROnEKS.Enable {
+		ts.cfg.AddOnEMROnEKS.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnEMROnEKS.Logger = ts.logger
+		ts.cfg.AddOnEMROnEKS.LogWriter = ts.logWriter
+		ts.cfg.AddOnEMROnEKS.Client = ts.cli
+		ts.addTester(emr_on_eks.New(ts.cfg.AddOnEMROnEKS), &ts.cfg.AddOnEMROnEKS.Stopc)
+	}
+	if ts.cfg.AddOnKarpenter != nil && ts.cfg.AddOnKarpenter.Enable {
+		ts.cfg.AddOnKarpenter.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnKarpenter.Logger = ts.logger
+		ts.cfg.AddOnKarpenter.LogWriter = ts.logWriter
+		ts.cfg.AddOnKarpenter.Client = ts.cli
+		ts.addTester(karpenter.New(ts.cfg.AddOnKarpenter), &ts.cfg.AddOnKarpenter.Stopc)
+	}
+	if ts.cfg.AddOnIstioAmbient != nil && ts.cfg.AddOnIstioAmbient.Enable {
+		ts.cfg.AddOnIstioAmbient.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnIstioAmbient.Logger = ts.logger
+		ts.cfg.AddOnIstioAmbient.LogWriter = ts.logWriter
+		ts.cfg.AddOnIstioAmbient.Client = ts.cli
+		ts.addTester(istio_ambient.New(ts.cfg.AddOnIstioAmbient), &ts.cfg.AddOnIstioAmbient.Stopc)
+	}
+	if ts.cfg.AddOnArgoWorkflows != nil && ts.cfg.AddOnArgoWorkflows.Enable {
+		ts.cfg.AddOnArgoWorkflows.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnArgoWorkflows.Logger = ts.logger
+		ts.cfg.AddOnArgoWorkflows.LogWriter = ts.logWriter
+		ts.cfg.AddOnArgoWorkflows.Client = ts.cli
+		ts.addTester(argo_workflows.New(ts.cfg.AddOnArgoWorkflows), &ts.cfg.AddOnArgoWorkflows.Stopc)
+	}
+	if ts.cfg.AddOnImagePrepull != nil && ts.cfg.AddOnImagePrepull.Enable {
+		ts.cfg.AddOnImagePrepull.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnImagePrepull.Logger = ts.logger
+		ts.cfg.AddOnImagePrepull.LogWriter = ts.logWriter
+		ts.cfg.AddOnImagePrepull.Client = ts.cli
+		ts.addTester(image_prepull.New(ts.cfg.AddOnImagePrepull), &ts.cfg.AddOnImagePrepull.Stopc)
+	}
+	if ts.cfg.AddOnPVReclaim != nil && ts.cfg.AddOnPVReclaim.Enable {
+		ts.cfg.AddOnPVReclaim.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnPVReclaim.Logger = ts.logger
+		ts.cfg.AddOnPVReclaim.LogWriter = ts.logWriter
+		ts.cfg.AddOnPVReclaim.Client = ts.cli
+		ts.addTester(pv_reclaim.New(ts.cfg.AddOnPVReclaim), &ts.cfg.AddOnPVReclaim.Stopc)
+	}
+	if ts.cfg.AddOnSysctl != nil && ts.cfg.AddOnSysctl.Enable {
+		ts.cfg.AddOnSysctl.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnSysctl.Logger = ts.logger
+		ts.cfg.AddOnSysctl.LogWriter = ts.logWriter
+		ts.cfg.AddOnSysctl.Client = ts.cli
+		ts.addTester(sysctl.New(ts.cfg.AddOnSysctl), &ts.cfg.AddOnSysctl.Stopc)
+	}
+	if ts.cfg.AddOnNetworkPolicy != nil && ts.cfg.AddOnNetworkPolicy.Enable {
+		ts.cfg.AddOnNetworkPolicy.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnNetworkPolicy.Logger = ts.logger
+		ts.cfg.AddOnNetworkPolicy.LogWriter = ts.logWriter
+		ts.cfg.AddOnNetworkPolicy.Client = ts.cli
+		ts.addTester(network_policy.New(ts.cfg.AddOnNetworkPolicy), &ts.cfg.AddOnNetworkPolicy.Stopc)
+	}
+	if ts.cfg.AddOnHollowNodes != nil && ts.cfg.AddOnHollowNodes.Enable {
+		ts.cfg.AddOnHollowNodes.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnHollowNodes.Logger = ts.logger
+		ts.cfg.AddOnHollowNodes.LogWriter = ts.logWriter
+		ts.cfg.AddOnHollowNodes.Client = ts.cli
+		ts.addTester(hollow_nodes.New(ts.cfg.AddOnHollowNodes), &ts.cfg.AddOnHollowNodes.Stopc)
+	}
+	if ts.cfg.AddOnDNS != nil && ts.cfg.AddOnDNS.Enable {
+		ts.cfg.AddOnDNS.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnDNS.Logger = ts.logger
+		ts.cfg.AddOnDNS.LogWriter = ts.logWriter
+		ts.cfg.AddOnDNS.Client = ts.cli
+		ts.addTester(dns.New(ts.cfg.AddOnDNS), &ts.cfg.AddOnDNS.Stopc)
+	}
+	if ts.cfg.AddOnIRSA != nil && ts.cfg.AddOnIRSA.Enable {
+		ts.cfg.AddOnIRSA.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnIRSA.Logger = ts.logger
+		ts.cfg.AddOnIRSA.LogWriter = ts.logWriter
+		ts.cfg.AddOnIRSA.Client = ts.cli
+		ts.addTester(irsa.New(ts.cfg.AddOnIRSA), &ts.cfg.AddOnIRSA.Stopc)
+	}
+	if ts.cfg.AddOnFargate != nil && ts.cfg.AddOnFargate.Enable {
+		ts.cfg.AddOnFargate.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnFargate.Logger = ts.logger
+		ts.cfg.AddOnFargate.LogWriter = ts.logWriter
+		ts.cfg.AddOnFargate.Client = ts.cli
+		ts.addTester(fargate.New(ts.cfg.AddOnFargate), &ts.cfg.AddOnFargate.Stopc)
+	}
+	if ts.cfg.AddOnEndpointSlices != nil && ts.cfg.AddOnEndpointSlices.Enable {
+		ts.cfg.AddOnEndpointSlices.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnEndpointSlices.Logger = ts.logger
+		ts.cfg.AddOnEndpointSlices.LogWriter = ts.logWriter
+		ts.cfg.AddOnEndpointSlices.Client = ts.cli
+		ts.addTester(endpointslices.New(ts.cfg.AddOnEndpointSlices), &ts.cfg.AddOnEndpointSlices.Stopc)
+	}
+	if ts.cfg.AddOnPrometheusGrafana != nil && ts.cfg.AddOnPrometheusGrafana.Enable {
+		ts.cfg.AddOnPrometheusGrafana.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnPrometheusGrafana.Logger = ts.logger
+		ts.cfg.AddOnPrometheusGrafana.LogWriter = ts.logWriter
+		ts.cfg.AddOnPrometheusGrafana.Client = ts.cli
+		ts.addTester(prometheus_grafana.New(ts.cfg.AddOnPrometheusGrafana), &ts.cfg.AddOnPrometheusGrafana.Stopc)
+	}
+	if ts.cfg.AddOnPortExhaustion != nil && ts.cfg.AddOnPortExhaustion.Enable {
+		ts.cfg.AddOnPortExhaustion.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnPortExhaustion.Logger = ts.logger
+		ts.cfg.AddOnPortExhaustion.LogWriter = ts.logWriter
+		ts.cfg.AddOnPortExhaustion.Client = ts.cli
+		ts.addTester(port_exhaustion.New(ts.cfg.AddOnPortExhaustion), &ts.cfg.AddOnPortExhaustion.Stopc)
+	}
+	if ts.cfg.AddOnRuntimeRestart != nil && ts.cfg.AddOnRuntimeRestart.Enable {
+		ts.cfg.AddOnRuntimeRestart.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnRuntimeRestart.Logger = ts.logger
+		ts.cfg.AddOnRuntimeRestart.LogWriter = ts.logWriter
+		ts.cfg.AddOnRuntimeRestart.Client = ts.cli
+		ts.addTester(runtime_restart.New(ts.cfg.AddOnRuntimeRestart), &ts.cfg.AddOnRuntimeRestart.Stopc)
+	}
+	if ts.cfg.AddOnCertRotation != nil && ts.cfg.AddOnCertRotation.Enable {
+		ts.cfg.AddOnCertRotation.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnCertRotation.Logger = ts.logger
+		ts.cfg.AddOnCertRotation.LogWriter = ts.logWriter
+		ts.cfg.AddOnCertRotation.Client = ts.cli
+		ts.addTester(cert_rotation.New(ts.cfg.AddOnCertRotation), &ts.cfg.AddOnCertRotation.Stopc)
+	}
+	if ts.cfg.AddOnKubeStateMetrics != nil && ts.cfg.AddOnKubeStateMetrics.Enable {
+		ts.cfg.AddOnKubeStateMetrics.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnKubeStateMetrics.Logger = ts.logger
+		ts.cfg.AddOnKubeStateMetrics.LogWriter = ts.logWriter
+		ts.cfg.AddOnKubeStateMetrics.Client = ts.cli
+		ts.addTester(kube_state_metrics.New(ts.cfg.AddOnKubeStateMetrics), &ts.cfg.AddOnKubeStateMetrics.Stopc)
+	}
+	if ts.cfg.AddOnADOT != nil && ts.cfg.AddOnADOT.Enable {
+		ts.cfg.AddOnADOT.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnADOT.Logger = ts.logger
+		ts.cfg.AddOnADOT.LogWriter = ts.logWriter
+		ts.cfg.AddOnADOT.Client = ts.cli
+		ts.addTester(adot.New(ts.cfg.AddOnADOT), &ts.cfg.AddOnADOT.Stopc)
+	}
+	if ts.cfg.AddOnNodeProblemDetector != nil && ts.cfg.AddOnNodeProblemDetector.Enable {
+		ts.cfg.AddOnNodeProblemDetector.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnNodeProblemDetector.Logger = ts.logger
+		ts.cfg.AddOnNodeProblemDetector.LogWriter = ts.logWriter
+		ts.cfg.AddOnNodeProblemDetector.Client = ts.cli
+		ts.addTester(node_problem_detector.New(ts.cfg.AddOnNodeProblemDetector), &ts.cfg.AddOnNodeProblemDetector.Stopc)
+	}
+	if ts.cfg.AddOnPDB != nil && ts.cfg.AddOnPDB.Enable {
+		ts.cfg.AddOnPDB.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnPDB.Logger = ts.logger
+		ts.cfg.AddOnPDB.LogWriter = ts.logWriter
+		ts.cfg.AddOnPDB.Client = ts.cli
+		ts.addTester(pdb.New(ts.cfg.AddOnPDB), &ts.cfg.AddOnPDB.Stopc)
+	}
+	if ts.cfg.AddOnDaemonSetRollout != nil && ts.cfg.AddOnDaemonSetRollout.Enable {
+		ts.cfg.AddOnDaemonSetRollout.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnDaemonSetRollout.Logger = ts.logger
+		ts.cfg.AddOnDaemonSetRollout.LogWriter = ts.logWriter
+		ts.cfg.AddOnDaemonSetRollout.Client = ts.cli
+		ts.addTester(daemonset_rollout.New(ts.cfg.AddOnDaemonSetRollout), &ts.cfg.AddOnDaemonSetRollout.Stopc)
+	}
+	if ts.cfg.AddOnWindows != nil && ts.cfg.AddOnWindows.Enable {
+		ts.cfg.AddOnWindows.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnWindows.Logger = ts.logger
+		ts.cfg.AddOnWindows.LogWriter = ts.logWriter
+		ts.cfg.AddOnWindows.Client = ts.cli
+		ts.addTester(windows.New(ts.cfg.AddOnWindows), &ts.cfg.AddOnWindows.Stopc)
+	}
+	if ts.cfg.AddOnNVIDIAGPU != nil && ts.cfg.AddOnNVIDIAGPU.Enable {
+		ts.cfg.AddOnNVIDIAGPU.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnNVIDIAGPU.Logger = ts.logger
+		ts.cfg.AddOnNVIDIAGPU.LogWriter = ts.logWriter
+		ts.cfg.AddOnNVIDIAGPU.Client = ts.cli
+		ts.addTester(nvidia_gpu.New(ts.cfg.AddOnNVIDIAGPU), &ts.cfg.AddOnNVIDIAGPU.Stopc)
+	}
+	if ts.cfg.AddOnKueue != nil && ts.cfg.AddOnKueue.Enable {
+		ts.cfg.AddOnKueue.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnKueue.Logger = ts.logger
+		ts.cfg.AddOnKueue.LogWriter = ts.logWriter
+		ts.cfg.AddOnKueue.Client = ts.cli
+		ts.addTester(kueue.New(ts.cfg.AddOnKueue), &ts.cfg.AddOnKueue.Stopc)
+	}
+	if ts.cfg.AddOnNeuron != nil && ts.cfg.AddOnNeuron.Enable {
+		ts.cfg.AddOnNeuron.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnNeuron.Logger = ts.logger
+		ts.cfg.AddOnNeuron.LogWriter = ts.logWriter
+		ts.cfg.AddOnNeuron.Client = ts.cli
+		ts.addTester(neuron.New(ts.cfg.AddOnNeuron), &ts.cfg.AddOnNeuron.Stopc)
+	}
+	if ts.cfg.AddOnMultiContainer != nil && ts.cfg.AddOnMultiContainer.Enable {
+		ts.cfg.AddOnMultiContainer.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnMultiContainer.Logger = ts.logger
+		ts.cfg.AddOnMultiContainer.LogWriter = ts.logWriter
+		ts.cfg.AddOnMultiContainer.Client = ts.cli
+		ts.addTester(multi_container.New(ts.cfg.AddOnMultiContainer), &ts.cfg.AddOnMultiContainer.Stopc)
+	}
+	if ts.cfg.AddOnNodeTagLabels != nil && ts.cfg.AddOnNodeTagLabels.Enable {
+		ts.cfg.AddOnNodeTagLabels.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnNodeTagLabels.Logger = ts.logger
+		ts.cfg.AddOnNodeTagLabels.LogWriter = ts.logWriter
+		ts.cfg.AddOnNodeTagLabels.Client = ts.cli
+		ts.addTester(node_tag_labels.New(ts.cfg.AddOnNodeTagLabels), &ts.cfg.AddOnNodeTagLabels.Stopc)
+	}
+	if ts.cfg.AddOnClusterPause != nil && ts.cfg.AddOnClusterPause.Enable {
+		ts.cfg.AddOnClusterPause.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnClusterPause.Logger = ts.logger
+		ts.cfg.AddOnClusterPause.LogWriter = ts.logWriter
+		ts.cfg.AddOnClusterPause.Client = ts.cli
+		ts.addTester(cluster_pause.New(ts.cfg.AddOnClusterPause), &ts.cfg.AddOnClusterPause.Stopc)
+	}
+
+	if ts.cfg.AddOnCrossCluster != nil && ts.cfg.AddOnCrossCluster.Enable {
+		ts.cfg.AddOnCrossCluster.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnCrossCluster.Logger = ts.logger
+		ts.cfg.AddOnCrossCluster.LogWriter = ts.logWriter
+		ts.cfg.AddOnCrossCluster.Client = ts.cli
+		ts.addTester(cross_cluster.New(ts.cfg.AddOnCrossCluster), &ts.cfg.AddOnCrossCluster.Stopc)
+	}
+	if ts.cfg.AddOnAuthRefresh != nil && ts.cfg.AddOnAuthRefresh.Enable {
+		ts.cfg.AddOnAuthRefresh.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnAuthRefresh.Logger = ts.logger
+		ts.cfg.AddOnAuthRefresh.LogWriter = ts.logWriter
+		ts.cfg.AddOnAuthRefresh.Client = ts.cli
+		ts.addTester(auth_refresh.New(ts.cfg.AddOnAuthRefresh), &ts.cfg.AddOnAuthRefresh.Stopc)
+	}
+	if ts.cfg.AddOnProbeStorm != nil && ts.cfg.AddOnProbeStorm.Enable {
+		ts.cfg.AddOnProbeStorm.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnProbeStorm.Logger = ts.logger
+		ts.cfg.AddOnProbeStorm.LogWriter = ts.logWriter
+		ts.cfg.AddOnProbeStorm.Client = ts.cli
+		ts.addTester(probe_storm.New(ts.cfg.AddOnProbeStorm), &ts.cfg.AddOnProbeStorm.Stopc)
+	}
+	if ts.cfg.AddOnEventFlood != nil && ts.cfg.AddOnEventFlood.Enable {
+		ts.cfg.AddOnEventFlood.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnEventFlood.Logger = ts.logger
+		ts.cfg.AddOnEventFlood.LogWriter = ts.logWriter
+		ts.cfg.AddOnEventFlood.Client = ts.cli
+		ts.addTester(event_flood.New(ts.cfg.AddOnEventFlood), &ts.cfg.AddOnEventFlood.Stopc)
+	}
+	if ts.cfg.AddOnStaticPod != nil && ts.cfg.AddOnStaticPod.Enable {
+		ts.cfg.AddOnStaticPod.Stopc = ts.stopCreationCh
+		ts.cfg.AddOnStaticPod.Logger = ts.logger
+		ts.cfg.AddOnStaticPod.LogWriter = ts.logWriter
+		ts.cfg.AddOnStaticPod.Client = ts.cli
+		ts.addTester(static_pod.New(ts.cfg.AddOnStaticPod), &ts.cfg.AddOnStaticPod.Stopc)
 	}
 }
 
+// addTester appends the tester, with the "Stopc" field of its config.
+func (ts *tester) addTester(cur k8s_tester.Tester, stopc *chan struct{}) {
+	ts.testers = append(ts.testers, cur)
+	ts.stopcs[cur] = stopc
+}
+
+// cronJobsEchoStatusKey is the "TesterStatus" key of the CronJob "jobs-echo" tester,
+// since the Job and the CronJob testers have the same "Name".
+const cronJobsEchoStatusKey = "cron-jobs-echo"
+
+// statusKey returns the key of the tester in "TesterStatus" and in the reports.
+func (ts *tester) statusKey(cur k8s_tester.Tester) string {
+	if key, ok := ts.statusKeys[cur]; ok {
+		return key
+	}
+	return cur.Name()
+}
+
 var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
 
 func (ts *tester) Name() string { return pkgName }
@@ -291,12 +694,69 @@ func (ts *tester) Apply() (err error) {
 		return fmt.Errorf("failed to validate minimum nodes requirement %d (nodes %v, error %v)", ts.cfg.MinimumNodes, len(nodes), err)
 	}
 	ts.cfg.TotalNodes = len(nodes)
+	if network, nerr := client.DetectClusterNetwork(ts.logger, ts.cli.KubernetesClient()); nerr != nil {
+		ts.logger.Warn("failed to detect cluster network", zap.Error(nerr))
+	} else {
+		ts.cfg.IPFamilies = make([]string, 0, len(network.IPFamilies))
+		for _, f := range network.IPFamilies {
+			ts.cfg.IPFamilies = append(ts.cfg.IPFamilies, string(f))
+		}
+		ts.cfg.ServiceCIDR = network.ServiceCIDR
+	}
 	ts.cfg.Sync()
 
+	if ts.cfg.IAMPreflight != nil && ts.cfg.IAMPreflight.Enable {
+		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+		fmt.Fprintf(ts.logWriter, ts.color("[light_green]IAM preflight [default](%q)\n"), ts.cfg.ConfigPath)
+		ts.cfg.IAMPreflight.Logger = ts.logger
+		ts.cfg.IAMPreflight.LogWriter = ts.logWriter
+		perr := iam_preflight.Run(ts.cfg.IAMPreflight, ts.cfg.RequiredIAMActions())
+		ts.cfg.Sync()
+		if perr != nil {
+			return fmt.Errorf("IAM preflight failed (%v)", perr)
+		}
+	}
+
 	now := time.Now()
+	ts.started = now
+	ts.results = make([]testResult, 0, len(ts.testers))
+	ts.reportStatus(false, nil)
 	defer func() {
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(ts.logWriter, ts.color("[light_green]Apply.defer [default](%q)\n"), ts.cfg.ConfigPath)
+		ts.syncAPICalls()
+		writeAPICalls(ts.logWriter, ts.cfg.APICalls, ts.cfg.APICallsTotal)
+		if rerr := ts.writeReports(); rerr != nil {
+			ts.logger.Warn("failed to write reports", zap.Error(rerr))
+		} else {
+			ts.logger.Info("wrote reports",
+				zap.String("junit", ts.cfg.ReportJUnitPath),
+				zap.String("tap", ts.cfg.ReportTAPPath),
+				zap.String("results", ts.cfg.ReportResultsPath),
+				zap.String("html", ts.cfg.ReportHTMLPath),
+			)
+		}
+		if ts.cfg.CloudWatchMetrics != nil && ts.cfg.CloudWatchMetrics.Enable {
+			ts.cfg.CloudWatchMetrics.Logger = ts.logger
+			if merr := cloudwatch_metrics.Publish(ts.cfg.CloudWatchMetrics, ts.cfg.ClusterName, ts.metricDatums()); merr != nil {
+				ts.logger.Warn("failed to publish CloudWatch metrics", zap.Error(merr))
+			}
+		}
+		if ts.cfg.OpenSearchResults != nil && ts.cfg.OpenSearchResults.Enable {
+			ts.cfg.OpenSearchResults.Logger = ts.logger
+			if oerr := opensearch_results.Index(ts.cfg.OpenSearchResults, ts.resultDocuments()); oerr != nil {
+				ts.logger.Warn("failed to index results to OpenSearch", zap.Error(oerr))
+			}
+		}
+		if ts.cfg.ArtifactsS3 != nil && ts.cfg.ArtifactsS3.Enable {
+			ts.cfg.Sync()
+			ts.logFile.Sync()
+			ts.cfg.ArtifactsS3.Logger = ts.logger
+			if aerr := artifacts_s3.Upload(ts.cfg.ArtifactsS3, ts.cfg.ClusterName, ts.cfg.RunID, ts.artifactPaths()); aerr != nil {
+				ts.logger.Warn("failed to upload artifacts to S3", zap.Error(aerr))
+			}
+		}
+		ts.reportStatus(true, err)
 		fmt.Fprintf(ts.logWriter, "\n\n# to uninstall add-ons\nk8s-tester delete --path %s\n\n", ts.cfg.ConfigPath)
 		ts.cfg.Sync()
 		ts.logFile.Sync()
@@ -324,6 +784,16 @@ func (ts *tester) Apply() (err error) {
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprint(ts.logWriter, ts.color("🔥 💀 👽 😱 😡 ⛈   (-_-) [light_magenta]Apply FAIL\n"))
 		fmt.Fprintf(ts.logWriter, "\n\n# to uninstall add-ons\nk8s-tester delete --path %s\n\n", ts.cfg.ConfigPath)
+		if ts.cfg.Resume {
+			ts.logger.Warn("Apply failed; keeping applied testers to resume",
+				zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
+				zap.Error(err),
+			)
+			fmt.Fprintf(ts.logWriter, "\n\n# to resume add-ons\nk8s-tester apply --resume --path %s\n\n", ts.cfg.ConfigPath)
+			ts.logger.Sugar().Infof("Apply.defer end (%s, %s)", ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
+			ts.logFile.Sync()
+			return
+		}
 		ts.logger.Warn("Apply failed; reverting resource creation",
 			zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
 			zap.Error(err),
@@ -348,22 +818,70 @@ func (ts *tester) Apply() (err error) {
 		if !cur.Enabled() {
 			continue
 		}
+		if ts.isSkipped(cur) {
+			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+			fmt.Fprintf(ts.logWriter, ts.color("[light_green]testers[%02d].Apply [cyan]%q [default]skipped by prompt (%q)\n"), idx, cur.Name(), ts.cfg.ConfigPath)
+			ts.results = append(ts.results, testResult{name: ts.statusKey(cur), skipped: true, skipNote: "skipped by prompt"})
+			continue
+		}
+		if ts.cfg.Resume && ts.cfg.GetTesterStatus(ts.statusKey(cur)) == TesterStatusApplied {
+			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+			fmt.Fprintf(ts.logWriter, ts.color("[light_green]testers[%02d].Apply [cyan]%q [default]already applied; skipping (%q)\n"), idx, cur.Name(), ts.cfg.ConfigPath)
+			ts.results = append(ts.results, testResult{name: ts.statusKey(cur), skipped: true, skipNote: "already applied"})
+			continue
+		}
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(ts.logWriter, ts.color("[light_green]testers[%02d].Apply [cyan]%q [default](%q, %q)\n"), idx, cur.Name(), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
-		err = catchInterrupt(
-			ts.logger,
-			ts.stopCreationCh,
-			ts.stopCreationChOnce,
-			ts.osSig,
-			cur.Apply,
-			cur.Name(),
-		)
+		seed := rand.DeriveSeed(ts.cfg.Seed, cur.Name())
+		ts.logger.Info("seeding tester", zap.String("tester", cur.Name()), zap.Int64("run-seed", ts.cfg.Seed), zap.Int64("seed", seed))
+		rand.Seed(seed)
+		ts.setAPICallsTester(ts.statusKey(cur))
+		applyStart := time.Now()
+		err = ts.runHooks(ts.statusKey(cur), HookPhasePreApply)
+		if err == nil {
+			err = ts.applyWithPolicy(cur)
+		}
+		if err == nil {
+			err = ts.runHooks(ts.statusKey(cur), HookPhasePostApply)
+		}
+		ts.setAPICallsTester("")
+		ts.syncAPICalls()
+		ts.results = append(ts.results, testResult{name: ts.statusKey(cur), took: time.Since(applyStart), err: err})
+		if err != nil {
+			ts.cfg.SetTesterStatus(ts.statusKey(cur), TesterStatusFailed, err)
+		} else {
+			ts.cfg.SetTesterStatus(ts.statusKey(cur), TesterStatusApplied, nil)
+		}
 		ts.cfg.Sync()
 		if err != nil {
 			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 			fmt.Fprintf(ts.logWriter, ts.color("[light_magenta]✗ [default]k8s-tester[%02d].Apply [light_magenta]FAIL [default](%v)\n"), idx, err)
+			for _, next := range ts.testers[idx+1:] {
+				if next.Enabled() {
+					ts.results = append(ts.results, testResult{name: ts.statusKey(next), skipped: true, skipNote: fmt.Sprintf("not run due to %q failure", cur.Name())})
+				}
+			}
 			return err
 		}
+
+		if ts.cfg.SteadyState != nil && ts.cfg.SteadyState.Enable && ts.hasNextEnabled(idx) {
+			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+			fmt.Fprintf(ts.logWriter, ts.color("[light_green]SteadyState.Wait [default]after [cyan]%q [default](%q)\n"), cur.Name(), ts.cfg.ConfigPath)
+			ts.cfg.SteadyState.Stopc = ts.stopCreationCh
+			ts.cfg.SteadyState.Logger = ts.logger
+			ts.cfg.SteadyState.LogWriter = ts.logWriter
+			ts.cfg.SteadyState.Client = ts.cli
+			err = ts.cfg.SteadyState.Wait(cur.Name())
+			ts.cfg.Sync()
+			if err != nil {
+				for _, next := range ts.testers[idx+1:] {
+					if next.Enabled() {
+						ts.results = append(ts.results, testResult{name: ts.statusKey(next), skipped: true, skipNote: fmt.Sprintf("not run due to steady state timeout after %q", cur.Name())})
+					}
+				}
+				return err
+			}
+		}
 	}
 
 	fmt.Fprint(ts.logWriter, ts.color("\n\n\n[yellow]*********************************\n"))
@@ -371,6 +889,16 @@ func (ts *tester) Apply() (err error) {
 	return nil
 }
 
+// hasNextEnabled returns true if an enabled tester runs after "testers[idx]".
+func (ts *tester) hasNextEnabled(idx int) bool {
+	for _, next := range ts.testers[idx+1:] {
+		if next.Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
 func (ts *tester) Delete() error {
 	if ok := ts.runPrompt("delete"); !ok {
 		return errors.New("cancelled")
@@ -391,13 +919,25 @@ func (ts *tester) delete() error {
 		if !cur.Enabled() {
 			continue
 		}
+		if ts.isSkipped(cur) {
+			fmt.Fprintf(ts.logWriter, ts.color("[light_blue]testers[%02d].Delete [cyan]%q [default]skipped by prompt (%q)\n"), idx, cur.Name(), ts.cfg.ConfigPath)
+			continue
+		}
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(ts.logWriter, ts.color("[light_blue]testers[%02d].Delete [cyan]%q [default](%q, %q)\n"), idx, cur.Name(), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
-		if err := cur.Delete(); err != nil {
+		ts.setAPICallsTester(ts.statusKey(cur))
+		err := cur.Delete()
+		ts.setAPICallsTester("")
+		ts.syncAPICalls()
+		if err != nil {
 			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 			fmt.Fprintf(ts.logWriter, ts.color("[light_magenta]✗ [default]k8s-tester[%02d].Delete [light_magenta]FAIL [default](%v)\n"), idx, err)
 			errs = append(errs, err.Error())
+			ts.cfg.SetTesterStatus(ts.statusKey(cur), TesterStatusFailed, err)
+		} else {
+			ts.cfg.SetTesterStatus(ts.statusKey(cur), TesterStatusDeleted, nil)
 		}
+		ts.cfg.Sync()
 	}
 
 	if len(errs) == 0 {
@@ -427,29 +967,103 @@ func (ts *tester) delete() error {
 	return nil
 }
 
-func (ts *tester) runPrompt(action string) (ok bool) {
-	if ts.cfg.Prompt {
-		msg := fmt.Sprintf("Ready to %q resources, should we continue?", action)
-		prompt := promptui.Select{
-			Label: msg,
-			Items: []string{
-				"No, cancel it!",
-				fmt.Sprintf("Yes, let's %q!", action),
-			},
+// applyWithPolicy applies the tester with its timeout and retry policy (see "GetTesterPolicy"),
+// looked up by its status key so that the CronJob "jobs-echo" tester has its own policy.
+// Each failed attempt is cleaned up with "Delete" before the next retry,
+// and a timed out attempt is cleaned up even if no retry is left.
+// A timed out "Apply" is stopped and waited for before "Delete",
+// so "Delete" and the next attempt never run concurrently with it.
+func (ts *tester) applyWithPolicy(cur k8s_tester.Tester) (err error) {
+	timeout, retries := ts.cfg.GetTesterPolicy(ts.statusKey(cur))
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			ts.logger.Warn("retrying tester",
+				zap.String("tester", cur.Name()),
+				zap.Int("attempt", attempt+1),
+				zap.Int("retries", retries),
+				zap.Error(err),
+			)
+			if derr := cur.Delete(); derr != nil {
+				ts.logger.Warn("failed to delete tester before retry", zap.String("tester", cur.Name()), zap.Error(derr))
+			}
 		}
-		idx, answer, err := prompt.Run()
-		if err != nil {
-			panic(err)
+
+		timedOut := false
+		run := cur.Apply
+		if timeout > 0 {
+			run = func() (rerr error) {
+				timedOut, rerr = ts.applyWithTimeout(cur, timeout)
+				return rerr
+			}
+		}
+		err = catchInterrupt(
+			ts.logger,
+			ts.stopCreationCh,
+			run,
+			cur.Name(),
+		)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ts.stopCreationCh:
+			// interrupted, no retry
+			return err
+		default:
 		}
-		if idx != 1 {
-			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
-			return false
+
+		if timedOut && attempt == retries {
+			ts.logger.Warn("tester timed out; deleting its resources",
+				zap.String("tester", cur.Name()),
+				zap.Duration("timeout", timeout),
+			)
+			if derr := cur.Delete(); derr != nil {
+				ts.logger.Warn("failed to delete timed out tester", zap.String("tester", cur.Name()), zap.Error(derr))
+			}
 		}
 	}
-	return true
+	if retries > 0 {
+		err = fmt.Errorf("%v (failed after %d attempts)", err, retries+1)
+	}
+	return err
 }
 
-func catchInterrupt(lg *zap.Logger, stopc chan struct{}, stopcCloseOnce *sync.Once, osSigCh chan os.Signal, run func() error, name string) (err error) {
+// applyWithTimeout runs "Apply" with its own stop channel in place of the tester "Stopc",
+// which is closed on timeout or when the creation is stopped.
+// It returns only after "Apply" returns, even on timeout.
+func (ts *tester) applyWithTimeout(cur k8s_tester.Tester, timeout time.Duration) (timedOut bool, err error) {
+	attemptStopc := make(chan struct{})
+	if stopc := ts.stopcs[cur]; stopc != nil {
+		*stopc = attemptStopc
+		defer func() { *stopc = ts.stopCreationCh }()
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- cur.Apply()
+	}()
+	select {
+	case err = <-errc:
+		return false, err
+	case <-ts.stopCreationCh:
+		close(attemptStopc)
+		return false, <-errc
+	case <-time.After(timeout):
+	}
+
+	ts.logger.Warn("tester timed out; stopping and waiting for its Apply to return",
+		zap.String("tester", cur.Name()),
+		zap.Duration("timeout", timeout),
+	)
+	close(attemptStopc)
+	if aerr := <-errc; aerr != nil {
+		return true, fmt.Errorf("timed out after %v (%v)", timeout, aerr)
+	}
+	return true, fmt.Errorf("timed out after %v", timeout)
+}
+
+func catchInterrupt(lg *zap.Logger, stopc chan struct{}, run func() error, name string) (err error) {
 	errc := make(chan error)
 	go func() {
 		errc <- run()
@@ -461,12 +1075,6 @@ func catchInterrupt(lg *zap.Logger, stopc chan struct{}, stopcCloseOnce *sync.On
 		lg.Info("interrupted; stopc received, errc received", zap.Error(rerr))
 		err = fmt.Errorf("stopc returned, stopc open %v, run function returned %v (%q)", ok, rerr, name)
 
-	case osSig := <-osSigCh:
-		stopcCloseOnce.Do(func() { close(stopc) })
-		rerr := <-errc
-		lg.Info("OS signal received, errc received", zap.String("signal", osSig.String()), zap.Error(rerr))
-		err = fmt.Errorf("received os signal %v, closed stopc, run function returned %v (%q)", osSig, rerr, name)
-
 	case err = <-errc:
 		if err != nil {
 			err = fmt.Errorf("run function returned %v (%q)", err, name)
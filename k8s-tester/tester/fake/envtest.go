@@ -0,0 +1,57 @@
+//go:build envtest
+// +build envtest
+
+package fake
+
+import (
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/client"
+	apiextensions_apiserver_client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	k8s_client "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// EnvtestClient implements "client.Client" against a local kube-apiserver and etcd
+// started by envtest, for the tests that need the server-side validation or defaulting.
+// Requires "KUBEBUILDER_ASSETS" (e.g., "setup-envtest use -p path").
+type EnvtestClient struct {
+	cli    k8s_client.Interface
+	extCli apiextensions_apiserver_client.Interface
+}
+
+var _ client.Client = &EnvtestClient{}
+
+// NewEnvtestClient starts a new envtest control plane and stops it on the test cleanup.
+func NewEnvtestClient(t testing.TB) *EnvtestClient {
+	t.Helper()
+	env := &envtest.Environment{}
+	restCfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("failed to start envtest (%v)", err)
+	}
+	t.Cleanup(func() {
+		if err := env.Stop(); err != nil {
+			t.Logf("failed to stop envtest (%v)", err)
+		}
+	})
+	cli, err := k8s_client.NewForConfig(restCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extCli, err := apiextensions_apiserver_client.NewForConfig(restCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &EnvtestClient{cli: cli, extCli: extCli}
+}
+
+func (c *EnvtestClient) KubernetesClient() k8s_client.Interface { return c.cli }
+
+func (c *EnvtestClient) APIExtensionsClient() apiextensions_apiserver_client.Interface {
+	return c.extCli
+}
+
+func (c *EnvtestClient) Config() client.Config {
+	return client.Config{KubectlPath: "kubectl"}
+}
@@ -0,0 +1,157 @@
+// Package fake implements the fake Kubernetes client and the golden file helpers
+// to render and test the manifests of the testers without a cluster.
+package fake
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/client"
+	apiextensions_apiserver_client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiextensions_apiserver_fake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apiextensions_apiserver_scheme "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/scheme"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8s_client "k8s.io/client-go/kubernetes"
+	k8s_fake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8s_testing "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+// UpdateGoldenEnv is the environment variable to overwrite the golden files
+// with the rendered output, instead of comparing them.
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// Redacted replaces the credentials in the rendered output,
+// so that the manifests can be shared and checked in.
+const Redacted = "REDACTED"
+
+// Client implements "client.Client" with the fake clientsets
+// that record the actions and track the objects in memory.
+type Client struct {
+	Clientset              *k8s_fake.Clientset
+	APIExtensionsClientset *apiextensions_apiserver_fake.Clientset
+}
+
+var _ client.Client = &Client{}
+
+// NewClient returns a new fake client with the objects pre-loaded.
+func NewClient(objs ...runtime.Object) *Client {
+	cs := k8s_fake.NewSimpleClientset(objs...)
+	// the API server ignores the namespace of the cluster-scoped objects
+	// (e.g., "ClusterRole"), while the object tracker rejects it,
+	// so track a copy without the namespace
+	cs.PrependReactor("create", "*", func(a k8s_testing.Action) (bool, runtime.Object, error) {
+		ca := a.(k8s_testing.CreateAction)
+		if ca.GetNamespace() != "" || ca.GetSubresource() != "" {
+			return false, nil, nil
+		}
+		obj := ca.GetObject().DeepCopyObject()
+		m, err := meta.Accessor(obj)
+		if err != nil || m.GetNamespace() == "" {
+			return false, nil, nil
+		}
+		m.SetNamespace("")
+		return true, obj, cs.Tracker().Create(ca.GetResource(), obj, "")
+	})
+	return &Client{
+		Clientset:              cs,
+		APIExtensionsClientset: apiextensions_apiserver_fake.NewSimpleClientset(),
+	}
+}
+
+func (c *Client) KubernetesClient() k8s_client.Interface { return c.Clientset }
+
+func (c *Client) APIExtensionsClient() apiextensions_apiserver_client.Interface {
+	return c.APIExtensionsClientset
+}
+
+func (c *Client) Config() client.Config {
+	return client.Config{KubectlPath: "kubectl"}
+}
+
+// Created returns the objects created via the client, in the order of creation.
+// The objects are as passed by the caller, not as stored by the tracker,
+// so the manifests are rendered as the testers define them.
+func (c *Client) Created() []runtime.Object {
+	var objs []runtime.Object
+	for _, a := range c.Clientset.Actions() {
+		ca, ok := a.(k8s_testing.CreateAction)
+		if !ok || ca.GetSubresource() != "" {
+			continue
+		}
+		objs = append(objs, ca.GetObject())
+	}
+	for _, a := range c.APIExtensionsClientset.Actions() {
+		ca, ok := a.(k8s_testing.CreateAction)
+		if !ok || ca.GetSubresource() != "" {
+			continue
+		}
+		objs = append(objs, ca.GetObject())
+	}
+	return objs
+}
+
+// Dump writes the objects created via the client as YAML documents.
+// The kind is set from the client scheme if the tester does not set it.
+func Dump(w io.Writer, c *Client) error {
+	for _, obj := range c.Created() {
+		obj = obj.DeepCopyObject()
+		for _, sc := range []*runtime.Scheme{scheme.Scheme, apiextensions_apiserver_scheme.Scheme} {
+			if !obj.GetObjectKind().GroupVersionKind().Empty() {
+				break
+			}
+			gvks, _, err := sc.ObjectKinds(obj)
+			if err == nil && len(gvks) > 0 {
+				obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+			}
+		}
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to encode %T (%v)", obj, err)
+		}
+		if _, err = fmt.Fprintf(w, "---\n%s", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteValues writes the Helm chart values as a YAML document,
+// with the chart name in the leading comment.
+func WriteValues(w io.Writer, chartName string, values map[string]interface{}) error {
+	b, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode %q values (%v)", chartName, err)
+	}
+	_, err = fmt.Fprintf(w, "---\n# Helm chart %q values\n%s", chartName, b)
+	return err
+}
+
+// AssertGolden compares the output with the golden file.
+// If "UPDATE_GOLDEN" is "true", it overwrites the golden file instead.
+func AssertGolden(t testing.TB, goldenPath string, got []byte) {
+	t.Helper()
+	if os.Getenv(UpdateGoldenEnv) == "true" {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden file %q (%v); run with %s=true to create", goldenPath, err, UpdateGoldenEnv)
+	}
+	if !bytes.Equal(expected, got) {
+		t.Fatalf("rendered output does not match %q (run with %s=true to update)\n\nexpected:\n%s\n\ngot:\n%s", goldenPath, UpdateGoldenEnv, expected, got)
+	}
+}
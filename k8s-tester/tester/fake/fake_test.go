@@ -0,0 +1,60 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDump(t *testing.T) {
+	existing := &core_v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "existing"}}
+	cli := NewClient(existing)
+
+	// no TypeMeta, to test the kind is set from the scheme
+	_, err := cli.KubernetesClient().CoreV1().ConfigMaps("test").Create(
+		context.Background(),
+		&core_v1.ConfigMap{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hello", Namespace: "test"},
+			Data:       map[string]string{"a": "b"},
+		},
+		meta_v1.CreateOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.KubernetesClient().CoreV1().Namespaces().Get(context.Background(), "existing", meta_v1.GetOptions{}); err != nil {
+		t.Fatalf("pre-loaded object not found (%v)", err)
+	}
+
+	if n := len(cli.Created()); n != 1 {
+		t.Fatalf("expected 1 created object, got %d", n)
+	}
+	var buf bytes.Buffer
+	if err = Dump(&buf, cli); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"---\n", "apiVersion: v1\n", "kind: ConfigMap\n", "name: hello\n", "a: b\n"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("dump missing %q\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "existing") {
+		t.Fatalf("dump must not include the pre-loaded objects\n%s", out)
+	}
+}
+
+func TestWriteValues(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteValues(&buf, "hello", map[string]interface{}{"service": map[string]interface{}{"port": 8080}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "---\n# Helm chart \"hello\" values\nservice:\n  port: 8080\n"
+	if buf.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, buf.String())
+	}
+}
@@ -1,6 +1,37 @@
 // Package tester defines Kubernetes "tester client" interface without "cluster provisioner" dependency.
+//
+// The interfaces of this package and the "NewTester" constructors of the add-on packages
+// are stable, to embed the testers in other Go programs (e.g., release qualification tools)
+// instead of running the CLIs. Each add-on package exports:
+//
+//   - "Config" for the tester options, with "NewDefault" for the default options
+//   - "NewTester" that validates the options and returns the "Tester", or an error,
+//     without panics; the waits of the tester are aborted when its context is done
+//   - "New" that returns the "Tester" for the validated options, as used by the k8s-tester CLI
+//
+// For example:
+//
+//	cfg := metrics_server.NewDefault()
+//	cfg.Logger, cfg.Client = lg, cli
+//	ts, err := metrics_server.NewTester(ctx, cfg)
+//	if err != nil {
+//		return err
+//	}
+//	if err = ts.Apply(); err != nil {
+//		return err
+//	}
+//	return ts.Delete()
 package tester
 
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"go.uber.org/zap"
+)
+
 // Tester defines Kubernetes tester interface.
 type Tester interface {
 	// Name returns the name of the tester.
@@ -12,3 +43,42 @@ type Tester interface {
 	// Delete removes all resources for the installed test case.
 	Delete() error
 }
+
+// Renderer is implemented by the testers that can render
+// the manifests and the Helm values they would install, without a cluster.
+type Renderer interface {
+	// Render writes the manifests and the Helm values as YAML documents.
+	Render(w io.Writer) error
+}
+
+// CheckOptions returns an error if the options required by all testers are not set.
+func CheckOptions(lg *zap.Logger, cli client.Client) error {
+	if lg == nil {
+		return errors.New("nil Logger")
+	}
+	if cli == nil {
+		return errors.New("nil Client")
+	}
+	return nil
+}
+
+// Stopc returns the channel that is closed when "ctx" is done or "stopc" is closed,
+// to abort the tester waits with the context. It returns "stopc" as is, if "ctx" is never done.
+// Cancel "ctx" after the tester is done, to release the resources.
+func Stopc(ctx context.Context, stopc chan struct{}) chan struct{} {
+	if ctx.Done() == nil {
+		if stopc == nil {
+			stopc = make(chan struct{})
+		}
+		return stopc
+	}
+	merged := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopc:
+		}
+		close(merged)
+	}()
+	return merged
+}
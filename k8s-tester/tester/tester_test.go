@@ -0,0 +1,52 @@
+package tester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestStopc(t *testing.T) {
+	stopc := make(chan struct{})
+	if got := Stopc(context.Background(), stopc); got != stopc {
+		t.Fatal("expected the same channel for the context never done")
+	}
+	if Stopc(context.Background(), nil) == nil {
+		t.Fatal("expected a new channel")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	merged := Stopc(ctx, stopc)
+	select {
+	case <-merged:
+		t.Fatal("unexpected closed channel")
+	default:
+	}
+	cancel()
+	select {
+	case <-merged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the channel to be closed on cancel")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	merged = Stopc(ctx, stopc)
+	close(stopc)
+	select {
+	case <-merged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the channel to be closed with stopc")
+	}
+}
+
+func TestCheckOptions(t *testing.T) {
+	if err := CheckOptions(nil, nil); err == nil {
+		t.Fatal("expected error for nil Logger")
+	}
+	if err := CheckOptions(zap.NewExample(), nil); err == nil {
+		t.Fatal("expected error for nil Client")
+	}
+}
@@ -0,0 +1,157 @@
+package k8s_tester
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"go.uber.org/zap"
+)
+
+// fakeTester blocks "Apply" until its "Stopc" is closed for the first "blocks" attempts,
+// and records whether "Apply" and "Delete" ever overlapped.
+type fakeTester struct {
+	stopc chan struct{}
+
+	blocks int
+	fails  int
+
+	mu         sync.Mutex
+	running    bool
+	overlapped bool
+	applies    int
+	deletes    int
+}
+
+func (ft *fakeTester) Name() string { return "fake" }
+
+func (ft *fakeTester) Enabled() bool { return true }
+
+func (ft *fakeTester) Apply() error {
+	ft.mu.Lock()
+	if ft.running {
+		ft.overlapped = true
+	}
+	ft.running = true
+	ft.applies++
+	attempt := ft.applies
+	stopc := ft.stopc
+	ft.mu.Unlock()
+
+	defer func() {
+		// give a leaked "Apply" time to overlap with "Delete"
+		time.Sleep(50 * time.Millisecond)
+		ft.mu.Lock()
+		ft.running = false
+		ft.mu.Unlock()
+	}()
+
+	if attempt <= ft.blocks {
+		<-stopc
+		return errors.New("stopped")
+	}
+	if attempt <= ft.blocks+ft.fails {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func (ft *fakeTester) Delete() error {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	if ft.running {
+		ft.overlapped = true
+	}
+	ft.deletes++
+	return nil
+}
+
+func newFakeTesterRunner(ft *fakeTester, timeout time.Duration, retries int) *tester {
+	cfg := NewDefault()
+	cfg.TimeoutPerTester = timeout
+	cfg.Retries = retries
+	ts := &tester{
+		stopCreationCh:     make(chan struct{}),
+		stopCreationChOnce: new(sync.Once),
+		logger:             zap.NewNop(),
+		cfg:                cfg,
+		stopcs:             make(map[k8s_tester.Tester]*chan struct{}),
+		statusKeys:         make(map[k8s_tester.Tester]string),
+	}
+	ft.stopc = ts.stopCreationCh
+	ts.addTester(ft, &ft.stopc)
+	return ts
+}
+
+func TestApplyWithPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  int
+		fails   int
+		timeout time.Duration
+		retries int
+
+		expectedErr     string
+		expectedApplies int
+		expectedDeletes int
+	}{
+		{name: "success", expectedApplies: 1},
+		{name: "timeout-retry", blocks: 1, timeout: 100 * time.Millisecond, retries: 1, expectedApplies: 2, expectedDeletes: 1},
+		{name: "timeout-no-retry", blocks: 1, timeout: 100 * time.Millisecond, expectedErr: "timed out after", expectedApplies: 1, expectedDeletes: 1},
+		{name: "timeout-retries-exhausted", blocks: 3, timeout: 100 * time.Millisecond, retries: 2, expectedErr: "failed after 3 attempts", expectedApplies: 3, expectedDeletes: 3},
+		{name: "fail-retry", fails: 1, retries: 1, expectedApplies: 2, expectedDeletes: 1},
+		{name: "fail-no-retry", fails: 1, expectedErr: "failed", expectedApplies: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &fakeTester{blocks: tt.blocks, fails: tt.fails}
+			ts := newFakeTesterRunner(ft, tt.timeout, tt.retries)
+
+			err := ts.applyWithPolicy(ft)
+			if tt.expectedErr == "" && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedErr)) {
+				t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+			}
+
+			ft.mu.Lock()
+			defer ft.mu.Unlock()
+			if ft.overlapped {
+				t.Fatal("Apply overlapped with another Apply or Delete")
+			}
+			if ft.running {
+				t.Fatal("Apply still running after applyWithPolicy returned")
+			}
+			if ft.applies != tt.expectedApplies {
+				t.Fatalf("expected %d applies, got %d", tt.expectedApplies, ft.applies)
+			}
+			if ft.deletes != tt.expectedDeletes {
+				t.Fatalf("expected %d deletes, got %d", tt.expectedDeletes, ft.deletes)
+			}
+			if ft.stopc != ts.stopCreationCh {
+				t.Fatal("tester Stopc not restored")
+			}
+		})
+	}
+}
+
+func TestStatusKey(t *testing.T) {
+	job, cron := &fakeTester{}, &fakeTester{}
+	ts := newFakeTesterRunner(job, 0, 0)
+	ts.addTester(cron, &cron.stopc)
+	ts.statusKeys[cron] = cronJobsEchoStatusKey
+
+	if key := ts.statusKey(job); key != job.Name() {
+		t.Fatalf("expected %q, got %q", job.Name(), key)
+	}
+	if key := ts.statusKey(cron); key != cronJobsEchoStatusKey {
+		t.Fatalf("expected %q, got %q", cronJobsEchoStatusKey, key)
+	}
+	if cron.Name() != job.Name() {
+		t.Fatalf("expected the same name, got %q and %q", cron.Name(), job.Name())
+	}
+}
@@ -0,0 +1,27 @@
+package vault
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the Helm values, against a fake client.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := fake.WriteValues(w, chartName, rt.helmValues()); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -0,0 +1,163 @@
+// k8s-tester-windows tests the Windows node workloads.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	"github.com/aws/aws-k8s-tester/k8s-tester/windows"
+	"github.com/aws/aws-k8s-tester/utils/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var rootCmd = &cobra.Command{
+	Use:        "k8s-tester-windows",
+	Short:      "Kubernetes Windows node tester",
+	SuggestFor: []string{"windows"},
+}
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	prompt              bool
+	logLevel            string
+	logOutputs          []string
+	minimumWindowsNodes int
+	namespace           string
+	kubectlDownloadURL  string
+	kubectlPath         string
+	kubeconfigPath      string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&prompt, "prompt", true, "'true' to enable prompt mode")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLogLevel, "Logging level")
+	rootCmd.PersistentFlags().StringSliceVar(&logOutputs, "log-outputs", []string{"stderr"}, "Additional logger outputs")
+	rootCmd.PersistentFlags().IntVar(&minimumWindowsNodes, "minimum-windows-nodes", windows.DefaultMinimumWindowsNodes, "minimum number of Windows nodes required for installing this addon")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "test-namespace", "'true' to auto-generate path for create config/cluster, overwrites existing --path value")
+	rootCmd.PersistentFlags().StringVar(&kubectlDownloadURL, "kubectl-download-url", client.DefaultKubectlDownloadURL(), "kubectl download URL")
+	rootCmd.PersistentFlags().StringVar(&kubectlPath, "kubectl-path", client.DefaultKubectlPath(), "kubectl path")
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig-path", "", "KUBECONFIG path")
+
+	rootCmd.AddCommand(
+		newApply(),
+		newDelete(),
+	)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "k8s-tester-windows failed %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+var (
+	image    string
+	replicas int32
+	timeout  time.Duration
+)
+
+func newApply() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply tests",
+		Run:   createApplyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&image, "image", windows.DefaultImage, "IIS image of the Deployment Pods, matching the Windows Server version of the nodes")
+	cmd.PersistentFlags().Int32Var(&replicas, "replicas", windows.DefaultReplicas, "number of the Deployment replicas")
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", windows.DefaultTimeout, "timeout for the Deployment to be available and for the Service to respond")
+	return cmd
+}
+
+func createApplyFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &windows.Config{
+		Prompt:              prompt,
+		Logger:              lg,
+		LogWriter:           logWriter,
+		MinimumWindowsNodes: minimumWindowsNodes,
+		Namespace:           namespace,
+		Client:              cli,
+		Image:               image,
+		Replicas:            replicas,
+		Timeout:             timeout,
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		lg.Panic("failed to validate config", zap.Error(err))
+	}
+
+	ts := windows.New(cfg)
+	if err := ts.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to apply (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-windows apply' success (%d Windows nodes, HTTP latency %v)\n", len(cfg.WindowsNodes), cfg.HTTPLatency)
+}
+
+func newDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+		Run:   createDeleteFunc,
+	}
+	return cmd
+}
+
+func createDeleteFunc(cmd *cobra.Command, args []string) {
+	lg, logWriter, _, err := log.NewWithStderrWriter(logLevel, logOutputs)
+	if err != nil {
+		panic(err)
+	}
+	_ = zap.ReplaceGlobals(lg)
+
+	cli, err := client.New(&client.Config{
+		Logger:             lg,
+		KubectlDownloadURL: kubectlDownloadURL,
+		KubectlPath:        kubectlPath,
+		KubeconfigPath:     kubeconfigPath,
+	})
+	if err != nil {
+		lg.Panic("failed to create client", zap.Error(err))
+	}
+
+	cfg := &windows.Config{
+		Prompt:    prompt,
+		Logger:    lg,
+		LogWriter: logWriter,
+		Namespace: namespace,
+		Client:    cli,
+	}
+
+	ts := windows.New(cfg)
+	if err := ts.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete (%v)\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'k8s-tester-windows delete' success\n")
+}
@@ -0,0 +1,30 @@
+package windows
+
+import (
+	"io"
+
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/k8s-tester/tester/fake"
+	"go.uber.org/zap"
+)
+
+var _ k8s_tester.Renderer = &tester{}
+
+// Render writes the Windows IIS Deployment and its Service against a fake client.
+func (ts *tester) Render(w io.Writer) error {
+	cli := fake.NewClient()
+	cfg := *ts.cfg
+	cfg.Client = cli
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	rt := &tester{cfg: &cfg}
+
+	if err := rt.createDeployment(); err != nil {
+		return err
+	}
+	if err := rt.createService(); err != nil {
+		return err
+	}
+	return fake.Dump(w, cli)
+}
@@ -0,0 +1,449 @@
+// Package windows detects the Windows nodes of a mixed-OS cluster, deploys an
+// IIS Deployment onto them, exposes it via a Service, and validates the HTTP
+// reachability of the Service.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/windows-support.html
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/client"
+	k8s_tester "github.com/aws/aws-k8s-tester/k8s-tester/tester"
+	"github.com/aws/aws-k8s-tester/utils/rand"
+	utils_time "github.com/aws/aws-k8s-tester/utils/time"
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type Config struct {
+	Enable bool `json:"enable"`
+	Prompt bool `json:"-"`
+
+	Stopc     chan struct{} `json:"-"`
+	Logger    *zap.Logger   `json:"-"`
+	LogWriter io.Writer     `json:"-"`
+	Client    client.Client `json:"-"`
+
+	// MinimumWindowsNodes is the minimum number of Windows nodes required for installing this addon.
+	MinimumWindowsNodes int `json:"minimum_windows_nodes"`
+	// Namespace to create test resources.
+	Namespace string `json:"namespace"`
+
+	// Image is the IIS image of the Deployment Pods.
+	// Must match the Windows Server version of the nodes.
+	// ref. https://hub.docker.com/_/microsoft-windows-servercore-iis
+	Image string `json:"image"`
+	// Replicas is the number of the Deployment replicas.
+	Replicas int32 `json:"replicas"`
+	// Timeout is the timeout for the Deployment to be available, and for the
+	// Service to respond. Windows images are large, so the first pull takes minutes.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout_string" read-only:"true"`
+
+	// WindowsNodes is the names of the Windows nodes found.
+	WindowsNodes []string `json:"windows_nodes" read-only:"true"`
+	// HTTPLatency is the time from the Service creation to the first successful response.
+	HTTPLatency       time.Duration `json:"http_latency" read-only:"true"`
+	HTTPLatencyString string        `json:"http_latency_string" read-only:"true"`
+}
+
+func (cfg *Config) ValidateAndSetDefaults() error {
+	if cfg.MinimumWindowsNodes == 0 {
+		cfg.MinimumWindowsNodes = DefaultMinimumWindowsNodes
+	}
+	if cfg.Namespace == "" {
+		return errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.Replicas == 0 {
+		cfg.Replicas = DefaultReplicas
+	}
+	if cfg.Timeout == time.Duration(0) {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.TimeoutString = cfg.Timeout.String()
+	return nil
+}
+
+const (
+	DefaultMinimumWindowsNodes int   = 1
+	DefaultImage                     = "mcr.microsoft.com/windows/servercore/iis:windowsservercore-ltsc2019"
+	DefaultReplicas            int32 = 2
+	DefaultTimeout                   = 20 * time.Minute
+)
+
+func NewDefault() *Config {
+	return &Config{
+		Enable:              false,
+		Prompt:              false,
+		MinimumWindowsNodes: DefaultMinimumWindowsNodes,
+		Namespace:           pkgName + "-" + rand.String(10) + "-" + utils_time.GetTS(10),
+		Image:               DefaultImage,
+		Replicas:            DefaultReplicas,
+		Timeout:             DefaultTimeout,
+	}
+}
+
+func New(cfg *Config) k8s_tester.Tester {
+	return &tester{
+		cfg: cfg,
+	}
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	return New(cfg), nil
+}
+
+type tester struct {
+	cfg *Config
+}
+
+var pkgName = path.Base(reflect.TypeOf(tester{}).PkgPath())
+
+func Env() string {
+	return "ADD_ON_" + strings.ToUpper(strings.Replace(pkgName, "-", "_", -1))
+}
+
+func (ts *tester) Name() string { return pkgName }
+
+func (ts *tester) Enabled() bool { return ts.cfg.Enable }
+
+const (
+	deploymentName = "windows-iis"
+	serviceName    = "windows-iis"
+	appLabel       = "app.kubernetes.io/name"
+
+	osLabel = "kubernetes.io/os"
+)
+
+func (ts *tester) Apply() error {
+	if ok := ts.runPrompt("apply"); !ok {
+		return errors.New("cancelled")
+	}
+
+	nodes, err := client.ListNodesWithOptions(
+		ts.cfg.Client.KubernetesClient(),
+		meta_v1.ListOptions{LabelSelector: osLabel + "=windows"},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list Windows nodes (%v)", err)
+	}
+	ts.cfg.WindowsNodes = make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ts.cfg.WindowsNodes = append(ts.cfg.WindowsNodes, node.Name)
+	}
+	ts.cfg.Logger.Info("found Windows nodes", zap.Strings("nodes", ts.cfg.WindowsNodes))
+	if len(nodes) < ts.cfg.MinimumWindowsNodes {
+		return fmt.Errorf("failed to validate minimum Windows nodes requirement %d (Windows nodes %d)", ts.cfg.MinimumWindowsNodes, len(nodes))
+	}
+
+	if err := client.CreateNamespace(ts.cfg.Logger, ts.cfg.Client.KubernetesClient(), ts.cfg.Namespace); err != nil {
+		return err
+	}
+
+	if err := ts.createDeployment(); err != nil {
+		return err
+	}
+	if err := ts.waitForDeployment(); err != nil {
+		return err
+	}
+
+	serviceStart := time.Now()
+	if err := ts.createService(); err != nil {
+		return err
+	}
+	if err := ts.checkHTTP(); err != nil {
+		return err
+	}
+	ts.cfg.HTTPLatency = time.Since(serviceStart)
+	ts.cfg.HTTPLatencyString = ts.cfg.HTTPLatency.String()
+
+	ts.cfg.Logger.Info("Windows Service reachable",
+		zap.Int("windows-nodes", len(ts.cfg.WindowsNodes)),
+		zap.String("http-latency", ts.cfg.HTTPLatencyString),
+	)
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if ok := ts.runPrompt("delete"); !ok {
+		return errors.New("cancelled")
+	}
+
+	var errs []string
+
+	if err := client.DeleteService(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		serviceName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Service (%v)", err))
+	}
+
+	if err := client.DeleteDeployment(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		deploymentName,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Deployment (%v)", err))
+	}
+
+	if err := client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.Client.KubernetesClient(),
+		ts.cfg.Namespace,
+		client.DefaultNamespaceDeletionInterval,
+		client.DefaultNamespaceDeletionTimeout,
+		client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (ts *tester) runPrompt(action string) (ok bool) {
+	if ts.cfg.Prompt {
+		msg := fmt.Sprintf("Ready to %q resources for the namespace %q, should we continue?", action, ts.cfg.Namespace)
+		prompt := promptui.Select{
+			Label: msg,
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %q!", action),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
+		}
+		if idx != 1 {
+			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tester) createDeployment() error {
+	ts.cfg.Logger.Info("creating Deployment", zap.String("image", ts.cfg.Image), zap.Int32("replicas", ts.cfg.Replicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		AppsV1().
+		Deployments(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&apps_v1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: ts.cfg.Namespace,
+					Labels: map[string]string{
+						appLabel: deploymentName,
+					},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Replicas: &ts.cfg.Replicas,
+					Selector: &meta_v1.LabelSelector{
+						MatchLabels: map[string]string{
+							appLabel: deploymentName,
+						},
+					},
+					Template: core_v1.PodTemplateSpec{
+						ObjectMeta: meta_v1.ObjectMeta{
+							Labels: map[string]string{
+								appLabel: deploymentName,
+							},
+						},
+						Spec: core_v1.PodSpec{
+							RestartPolicy: core_v1.RestartPolicyAlways,
+							Containers: []core_v1.Container{
+								{
+									Name:            deploymentName,
+									Image:           ts.cfg.Image,
+									ImagePullPolicy: core_v1.PullIfNotPresent,
+									Ports: []core_v1.ContainerPort{
+										{
+											Protocol:      core_v1.ProtocolTCP,
+											ContainerPort: 80,
+										},
+									},
+									ReadinessProbe: &core_v1.Probe{
+										ProbeHandler: core_v1.ProbeHandler{
+											HTTPGet: &core_v1.HTTPGetAction{
+												Path: "/",
+												Port: intstr.FromInt(80),
+											},
+										},
+										PeriodSeconds: 10,
+									},
+								},
+							},
+							NodeSelector: map[string]string{
+								osLabel: "windows",
+							},
+							// Windows node groups are commonly tainted,
+							// to keep the Linux workloads off them
+							// ref. https://docs.aws.amazon.com/eks/latest/userguide/windows-support.html
+							Tolerations: []core_v1.Toleration{
+								{
+									Key:      "os",
+									Operator: core_v1.TolerationOpEqual,
+									Value:    "windows",
+									Effect:   core_v1.TaintEffectNoSchedule,
+								},
+							},
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Deployment")
+	return nil
+}
+
+func (ts *tester) waitForDeployment() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.cfg.Timeout)
+	_, err := client.WaitForDeploymentAvailables(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.Client.KubernetesClient(),
+		time.Minute,
+		20*time.Second,
+		ts.cfg.Namespace,
+		deploymentName,
+		ts.cfg.Replicas,
+	)
+	cancel()
+	return err
+}
+
+func (ts *tester) createService() error {
+	ts.cfg.Logger.Info("creating Service")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.Client.KubernetesClient().
+		CoreV1().
+		Services(ts.cfg.Namespace).
+		Create(
+			ctx,
+			&core_v1.Service{
+				TypeMeta: meta_v1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      serviceName,
+					Namespace: ts.cfg.Namespace,
+				},
+				Spec: core_v1.ServiceSpec{
+					Selector: map[string]string{
+						appLabel: deploymentName,
+					},
+					Type: core_v1.ServiceTypeClusterIP,
+					Ports: []core_v1.ServicePort{
+						{
+							Name:       "http",
+							Protocol:   core_v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+						},
+					},
+				},
+			},
+			meta_v1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Service (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Service")
+	return nil
+}
+
+// checkHTTP requests the IIS default page through the API server Service proxy,
+// until the Service responds with the page.
+// ref. https://kubernetes.io/docs/tasks/access-application-cluster/access-cluster-services/#manually-constructing-apiserver-proxy-urls
+func (ts *tester) checkHTTP() error {
+	ts.cfg.Logger.Info("checking Service HTTP reachability")
+	retryStart := time.Now()
+	for time.Since(retryStart) < ts.cfg.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("HTTP check aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		out, err := ts.cfg.Client.KubernetesClient().
+			CoreV1().
+			Services(ts.cfg.Namespace).
+			ProxyGet("http", serviceName, "80", "/", nil).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Service; retrying", zap.Error(err))
+			continue
+		}
+		if !isIISPage(string(out)) {
+			ts.cfg.Logger.Warn("unexpected Service response; retrying", zap.Int("bytes", len(out)))
+			continue
+		}
+		ts.cfg.Logger.Info("Service responded with IIS page", zap.Int("bytes", len(out)))
+		return nil
+	}
+	return fmt.Errorf("Service %q not reachable within %v", serviceName, ts.cfg.Timeout)
+}
+
+// isIISPage returns true if the body is the IIS default page
+// (e.g., "<title>IIS Windows Server</title>").
+func isIISPage(body string) bool {
+	return strings.Contains(body, "IIS Windows")
+}
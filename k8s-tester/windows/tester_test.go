@@ -0,0 +1,20 @@
+package windows
+
+import "testing"
+
+func TestIsIISPage(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected bool
+	}{
+		{body: "<html><head><title>IIS Windows Server</title></head></html>", expected: true},
+		{body: "<html><head><title>IIS Windows</title></head></html>", expected: true},
+		{body: "<html><head><title>Welcome to nginx!</title></head></html>", expected: false},
+		{body: "", expected: false},
+	}
+	for i, tt := range tests {
+		if got := isIISPage(tt.body); got != tt.expected {
+			t.Fatalf("#%d: expected %v, got %v", i, tt.expected, got)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -93,6 +94,14 @@ func NewDefault() *Config {
 }
 
 func New(cfg *Config) k8s_tester.Tester {
+	ts, err := newTester(cfg)
+	if err != nil {
+		cfg.Logger.Panic("failed to create tester", zap.Error(err))
+	}
+	return ts
+}
+
+func newTester(cfg *Config) (*tester, error) {
 	awsCfg := aws_v1.Config{
 		Logger:        cfg.Logger,
 		DebugAPICalls: cfg.Logger.Core().Enabled(zapcore.DebugLevel),
@@ -101,7 +110,7 @@ func New(cfg *Config) k8s_tester.Tester {
 	}
 	awsSession, stsOutput, _, err := aws_v1.New(&awsCfg)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	cfg.ELB2API = elbv2.New(awsSession)
 	if cfg.AccountID == "" && stsOutput.Account != nil {
@@ -110,7 +119,30 @@ func New(cfg *Config) k8s_tester.Tester {
 
 	return &tester{
 		cfg: cfg,
+	}, nil
+}
+
+// NewTester validates the configuration, and returns the tester to embed in other Go programs.
+// The waits of the tester are aborted when "ctx" is done.
+func NewTester(ctx context.Context, cfg *Config) (k8s_tester.Tester, error) {
+	if cfg == nil {
+		return nil, errors.New("nil Config")
+	}
+	if err := k8s_tester.CheckOptions(cfg.Logger, cfg.Client); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		return nil, err
 	}
+	if cfg.LogWriter == nil {
+		cfg.LogWriter = os.Stderr
+	}
+	cfg.Stopc = k8s_tester.Stopc(ctx, cfg.Stopc)
+	ts, err := newTester(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 type tester struct {
@@ -216,7 +248,8 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 		}
 		idx, answer, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			ts.cfg.Logger.Warn("prompt failed", zap.Error(err))
+			return false
 		}
 		if idx != 1 {
 			fmt.Printf("cancelled %q [index %d, answer %q]\n", action, idx, answer)
@@ -226,9 +259,9 @@ func (ts *tester) runPrompt(action string) (ok bool) {
 	return true
 }
 
-func (ts *tester) installChart() error {
-	// https://github.com/helm/charts/blob/master/stable/wordpress/values.yaml
-	values := map[string]interface{}{
+// https://github.com/helm/charts/blob/master/stable/wordpress/values.yaml
+func (ts *tester) helmValues() map[string]interface{} {
+	return map[string]interface{}{
 		"wordpressUsername": ts.cfg.UserName,
 		"wordpressPassword": ts.cfg.Password,
 		"persistence": map[string]interface{}{
@@ -257,7 +290,9 @@ func (ts *tester) installChart() error {
 			},
 		},
 	}
+}
 
+func (ts *tester) installChart() error {
 	return helm.Install(helm.InstallConfig{
 		Logger:         ts.cfg.Logger,
 		LogWriter:      ts.cfg.LogWriter,
@@ -268,7 +303,7 @@ func (ts *tester) installChart() error {
 		ChartRepoURL:   chartRepoURL,
 		ChartName:      chartName,
 		ReleaseName:    chartName,
-		Values:         values,
+		Values:         ts.helmValues(),
 		LogFunc: func(format string, v ...interface{}) {
 			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
 		},
@@ -0,0 +1,67 @@
+package clusterlogs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cloudformationtypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"k8s.io/klog/v2"
+)
+
+// DumpStackEvents writes the events of each stack to "[DIR]/cloudformation/[STACK].events.txt",
+// oldest first. Stacks that do not exist (e.g., on a partially failed "Up") are skipped.
+func DumpStackEvents(client *cloudformation.Client, stackNames []string, dir string) error {
+	for _, stackName := range stackNames {
+		out, err := client.DescribeStacks(context.TODO(), &cloudformation.DescribeStacksInput{
+			StackName: aws.String(stackName),
+		})
+		if err != nil {
+			// CloudFormation returns a ValidationError for a stack that does not exist
+			klog.Infof("skipping events of stack %s: %v", stackName, err)
+			continue
+		}
+		var events []cloudformationtypes.StackEvent
+		paginator := cloudformation.NewDescribeStackEventsPaginator(client, &cloudformation.DescribeStackEventsInput{
+			StackName: out.Stacks[0].StackId,
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(context.TODO())
+			if err != nil {
+				return fmt.Errorf("failed to describe events of stack %s: %v", stackName, err)
+			}
+			events = append(events, page.StackEvents...)
+		}
+		path := filepath.Join(dir, cloudFormationDir, stackName+".events.txt")
+		if err := writeFile(path, formatStackEvents(events)); err != nil {
+			return err
+		}
+		klog.Infof("dumped %d event(s) of stack %s: %s", len(events), stackName, path)
+	}
+	return nil
+}
+
+// formatStackEvents returns the events as a table, oldest first.
+// DescribeStackEvents returns the most recent events first.
+func formatStackEvents(events []cloudformationtypes.StackEvent) []byte {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIMESTAMP\tLOGICAL ID\tTYPE\tSTATUS\tREASON")
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			aws.ToTime(e.Timestamp).UTC().Format(time.RFC3339),
+			aws.ToString(e.LogicalResourceId),
+			aws.ToString(e.ResourceType),
+			e.ResourceStatus,
+			aws.ToString(e.ResourceStatusReason),
+		)
+	}
+	w.Flush()
+	return buf.Bytes()
+}
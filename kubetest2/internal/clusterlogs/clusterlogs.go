@@ -0,0 +1,31 @@
+// Package clusterlogs writes the diagnostics of a cluster to the artifacts directory,
+// for the "DumpClusterLogs" of the deployers.
+package clusterlogs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DirName is the directory of the cluster logs in the artifacts directory
+	DirName = "cluster-logs"
+
+	controlPlaneDir   = "control-plane"
+	kubeSystemDir     = "kube-system"
+	cloudFormationDir = "cloudformation"
+)
+
+// writeFile writes the file, creating its directory
+func writeFile(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// fileName returns a file name for a log stream or a pod container, without path separators
+func fileName(parts ...string) string {
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(strings.Join(parts, "_"))
+}
@@ -0,0 +1,88 @@
+package clusterlogs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudformationtypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_formatStackEvents(t *testing.T) {
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// most recent first, as returned by DescribeStackEvents
+	events := []cloudformationtypes.StackEvent{
+		{
+			Timestamp:            aws.Time(started.Add(time.Minute)),
+			LogicalResourceId:    aws.String("VPC"),
+			ResourceType:         aws.String("AWS::EC2::VPC"),
+			ResourceStatus:       cloudformationtypes.ResourceStatusCreateFailed,
+			ResourceStatusReason: aws.String("limit exceeded"),
+		},
+		{
+			Timestamp:         aws.Time(started),
+			LogicalResourceId: aws.String("kubetest2-eksapi-abc"),
+			ResourceType:      aws.String("AWS::CloudFormation::Stack"),
+			ResourceStatus:    cloudformationtypes.ResourceStatusCreateInProgress,
+		},
+	}
+	lines := strings.Split(strings.TrimSpace(string(formatStackEvents(events))), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "TIMESTAMP"))
+	assert.Contains(t, lines[1], "2024-01-01T00:00:00Z")
+	assert.Contains(t, lines[1], "CREATE_IN_PROGRESS")
+	assert.Contains(t, lines[2], "CREATE_FAILED")
+	assert.Contains(t, lines[2], "limit exceeded")
+}
+
+func Test_fileName(t *testing.T) {
+	assert.Equal(t, "kube-apiserver-audit-abc", fileName("kube-apiserver-audit-abc"))
+	assert.Equal(t, "aws-node-xyz_aws-node", fileName("aws-node-xyz", "aws-node"))
+	assert.Equal(t, "a_b_c", fileName("a/b", "c"))
+}
+
+func Test_controlPlaneLogGroup(t *testing.T) {
+	assert.Equal(t, "/aws/eks/kubetest2-eksapi-abc/cluster", controlPlaneLogGroup("kubetest2-eksapi-abc"))
+}
+
+func TestDumpKubeSystem(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns-abc", Namespace: kubeSystemNamespace},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "coredns", RestartCount: 1}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "other"}},
+			},
+		},
+	)
+	dir := t.TempDir()
+	assert.NoError(t, DumpKubeSystem(clientset, dir))
+	for _, name := range []string{
+		"nodes.yaml",
+		"daemonsets.yaml",
+		"deployments.yaml",
+		"events.yaml",
+		"pods.yaml",
+		"logs/coredns-abc_coredns.log",
+		"logs/coredns-abc_coredns.previous.log",
+	} {
+		assert.FileExists(t, filepath.Join(dir, kubeSystemDir, name))
+	}
+	assert.NoFileExists(t, filepath.Join(dir, kubeSystemDir, "logs", "other_other.log"))
+	b, err := os.ReadFile(filepath.Join(dir, kubeSystemDir, "nodes.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "node-1")
+}
@@ -0,0 +1,100 @@
+package clusterlogs
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"k8s.io/klog/v2"
+)
+
+// maxControlPlaneLogBytes is the maximum size of a dumped control plane log stream,
+// the audit log of a long test run can be several GBs
+const maxControlPlaneLogBytes = 64 * 1024 * 1024
+
+// controlPlaneLogGroup returns the CloudWatch log group of the EKS control plane logs
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/control-plane-logs.html
+func controlPlaneLogGroup(clusterName string) string {
+	return fmt.Sprintf("/aws/eks/%s/cluster", clusterName)
+}
+
+// DumpControlPlaneLogs writes the EKS control plane log streams (kube-apiserver, audit, authenticator,
+// kube-controller-manager, kube-scheduler) since the given time to "[DIR]/control-plane/[STREAM].log".
+// Nothing is written if the control plane logging is not enabled on the cluster.
+// A zero "since" dumps the log streams from the beginning.
+func DumpControlPlaneLogs(region string, clusterName string, since time.Time, dir string) error {
+	// the CloudWatch Logs client of the v2 SDK is not a dependency of this module yet
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{Region: aws.String(region)},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create AWS session: %v", err)
+	}
+	client := cloudwatchlogs.New(sess)
+	logGroup := controlPlaneLogGroup(clusterName)
+	var streams []string
+	err = client.DescribeLogStreamsPages(&cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(logGroup),
+	}, func(page *cloudwatchlogs.DescribeLogStreamsOutput, lastPage bool) bool {
+		for _, stream := range page.LogStreams {
+			// skip the streams without events since the given time
+			if !since.IsZero() && aws.Int64Value(stream.LastIngestionTime) < since.UnixMilli() {
+				continue
+			}
+			streams = append(streams, aws.StringValue(stream.LogStreamName))
+		}
+		return true
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException {
+			klog.Infof("control plane log group %s does not exist, control plane logging is not enabled", logGroup)
+			return nil
+		}
+		return fmt.Errorf("failed to describe log streams of %s: %v", logGroup, err)
+	}
+	for _, stream := range streams {
+		path := filepath.Join(dir, controlPlaneDir, fileName(stream)+".log")
+		if err := dumpLogStream(client, logGroup, stream, since, path); err != nil {
+			return err
+		}
+	}
+	klog.Infof("dumped %d control plane log stream(s) to: %s", len(streams), filepath.Join(dir, controlPlaneDir))
+	return nil
+}
+
+func dumpLogStream(client *cloudwatchlogs.CloudWatchLogs, logGroup string, stream string, since time.Time, path string) error {
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(stream),
+		StartFromHead: aws.Bool(true),
+	}
+	if !since.IsZero() {
+		input.StartTime = aws.Int64(since.UnixMilli())
+	}
+	var buf bytes.Buffer
+	for {
+		out, err := client.GetLogEvents(input)
+		if err != nil {
+			return fmt.Errorf("failed to get events of log stream %s: %v", stream, err)
+		}
+		for _, e := range out.Events {
+			fmt.Fprintf(&buf, "%s %s\n", time.UnixMilli(aws.Int64Value(e.Timestamp)).UTC().Format(time.RFC3339Nano), aws.StringValue(e.Message))
+		}
+		if buf.Len() >= maxControlPlaneLogBytes {
+			fmt.Fprintf(&buf, "... truncated at %d bytes, see the log stream %s in the log group %s\n", buf.Len(), stream, logGroup)
+			break
+		}
+		// the forward token stays the same at the end of the stream
+		if out.NextForwardToken == nil || aws.StringValue(out.NextForwardToken) == aws.StringValue(input.NextToken) {
+			break
+		}
+		input.NextToken = out.NextForwardToken
+	}
+	return writeFile(path, buf.Bytes())
+}
@@ -0,0 +1,103 @@
+package clusterlogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const kubeSystemNamespace = "kube-system"
+
+// maxContainerLogLines is the maximum number of lines of a dumped container log, the most recent lines are kept
+const maxContainerLogLines int64 = 10000
+
+// NewClientset returns a clientset for the kubeconfig
+func NewClientset(kubeconfigPath string) (kubernetes.Interface, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// DumpKubeSystem writes the nodes and the kube-system pods, daemonsets, deployments, and events
+// in YAML (like "kubectl get -o yaml"), and the logs of the kube-system containers
+// (like "kubectl logs", with "--previous" for the restarted containers) to "[DIR]/kube-system".
+func DumpKubeSystem(clientset kubernetes.Interface, dir string) error {
+	ctx := context.TODO()
+	dir = filepath.Join(dir, kubeSystemDir)
+	var errs []error
+	dumpList := func(name string, list runtime.Object, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %v", name, err))
+			return
+		}
+		b, err := yaml.Marshal(list)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to marshal %s: %v", name, err))
+			return
+		}
+		if err := writeFile(filepath.Join(dir, name+".yaml"), b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	dumpList("nodes", nodes, err)
+	daemonSets, err := clientset.AppsV1().DaemonSets(kubeSystemNamespace).List(ctx, metav1.ListOptions{})
+	dumpList("daemonsets", daemonSets, err)
+	deployments, err := clientset.AppsV1().Deployments(kubeSystemNamespace).List(ctx, metav1.ListOptions{})
+	dumpList("deployments", deployments, err)
+	events, err := clientset.CoreV1().Events(kubeSystemNamespace).List(ctx, metav1.ListOptions{})
+	dumpList("events", events, err)
+	pods, err := clientset.CoreV1().Pods(kubeSystemNamespace).List(ctx, metav1.ListOptions{})
+	dumpList("pods", pods, err)
+	if err != nil {
+		return errors.Join(errs...)
+	}
+
+	for _, pod := range pods.Items {
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if err := dumpContainerLogs(ctx, clientset, pod.Name, status.Name, false, dir); err != nil {
+				errs = append(errs, err)
+			}
+			if status.RestartCount > 0 {
+				if err := dumpContainerLogs(ctx, clientset, pod.Name, status.Name, true, dir); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	klog.Infof("dumped %d kube-system pod(s) to: %s", len(pods.Items), dir)
+	return nil
+}
+
+func dumpContainerLogs(ctx context.Context, clientset kubernetes.Interface, pod string, container string, previous bool, dir string) error {
+	tailLines := maxContainerLogLines
+	b, err := clientset.CoreV1().Pods(kubeSystemNamespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		Timestamps: true,
+		TailLines:  &tailLines,
+	}).Do(ctx).Raw()
+	if err != nil {
+		return fmt.Errorf("failed to get logs of %s/%s: %v", pod, container, err)
+	}
+	name := fileName(pod, container)
+	if previous {
+		name += ".previous"
+	}
+	return writeFile(filepath.Join(dir, "logs", name+".log"), b)
+}
@@ -0,0 +1,47 @@
+package eksapi
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// autoModeComputeTypeLabel is set on the nodes launched by EKS Auto Mode
+	autoModeComputeTypeLabel = "eks.amazonaws.com/compute-type"
+	autoModeComputeType      = "auto"
+	// autoModeNodePoolLabel is the NodePool that launched an EKS Auto Mode node
+	autoModeNodePoolLabel = "karpenter.sh/nodepool"
+)
+
+// autoModeNodes returns the names of the nodes launched by EKS Auto Mode for the NodePool,
+// and the names of any other nodes
+func autoModeNodes(nodes []corev1.Node, nodePool string) (autoNodes []string, otherNodes []string) {
+	for _, node := range nodes {
+		if node.Labels[autoModeComputeTypeLabel] == autoModeComputeType && node.Labels[autoModeNodePoolLabel] == nodePool {
+			autoNodes = append(autoNodes, node.Name)
+		} else {
+			otherNodes = append(otherNodes, node.Name)
+		}
+	}
+	return autoNodes, otherNodes
+}
+
+// verifyAutoModeNodes verifies that the ready nodes were launched by EKS Auto Mode from the NodePool,
+// and not by a nodegroup
+func (k *k8sClient) verifyAutoModeNodes(nodePool string, nodeCount int) error {
+	nodes, err := k.getReadyNodes()
+	if err != nil {
+		return fmt.Errorf("failed to get ready nodes: %v", err)
+	}
+	autoNodes, otherNodes := autoModeNodes(nodes, nodePool)
+	if len(otherNodes) > 0 {
+		klog.Warningf("%d ready node(s) were not launched by EKS Auto Mode NodePool %s: %v", len(otherNodes), nodePool, otherNodes)
+	}
+	if len(autoNodes) < nodeCount {
+		return fmt.Errorf("expected %d node(s) launched by EKS Auto Mode NodePool %s, but found %d: %v", nodeCount, nodePool, len(autoNodes), autoNodes)
+	}
+	klog.Infof("%d node(s) were launched by EKS Auto Mode NodePool %s: %v", len(autoNodes), nodePool, autoNodes)
+	return nil
+}
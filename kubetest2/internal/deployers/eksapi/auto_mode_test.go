@@ -0,0 +1,24 @@
+package eksapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_autoModeNodes(t *testing.T) {
+	node := func(name string, labels map[string]string) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	nodes := []corev1.Node{
+		node("auto-1", map[string]string{autoModeComputeTypeLabel: autoModeComputeType, autoModeNodePoolLabel: "test-pool"}),
+		node("auto-system", map[string]string{autoModeComputeTypeLabel: autoModeComputeType, autoModeNodePoolLabel: "system"}),
+		node("nodegroup-1", map[string]string{"eks.amazonaws.com/nodegroup": "ng"}),
+		node("auto-2", map[string]string{autoModeComputeTypeLabel: autoModeComputeType, autoModeNodePoolLabel: "test-pool"}),
+	}
+	autoNodes, otherNodes := autoModeNodes(nodes, "test-pool")
+	assert.Equal(t, []string{"auto-1", "auto-2"}, autoNodes)
+	assert.Equal(t, []string{"auto-system", "nodegroup-1"}, otherNodes)
+}
@@ -37,6 +37,7 @@ type Cluster struct {
 	arn                      string
 	name                     string
 	cidr                     string
+	ipFamily                 string
 }
 
 func (m *ClusterManager) getOrCreateCluster(infra *Infrastructure, opts *deployerOptions) (*Cluster, error) {
@@ -47,7 +48,7 @@ func (m *ClusterManager) getOrCreateCluster(infra *Infrastructure, opts *deploye
 			Name: aws.String(m.resourceID),
 			ResourcesVpcConfig: &ekstypes.VpcConfigRequest{
 				EndpointPrivateAccess: aws.Bool(true),
-				EndpointPublicAccess:  aws.Bool(true),
+				EndpointPublicAccess:  aws.Bool(!opts.PrivateEndpoint),
 				SubnetIds:             append(infra.subnetsPublic, infra.subnetsPrivate...),
 			},
 			RoleArn: aws.String(infra.clusterRoleARN),
@@ -56,6 +57,16 @@ func (m *ClusterManager) getOrCreateCluster(infra *Infrastructure, opts *deploye
 			},
 			Version: aws.String(opts.KubernetesVersion),
 		}
+		if opts.ControlPlaneLogging {
+			input.Logging = &ekstypes.Logging{
+				ClusterLogging: []ekstypes.LogSetup{
+					{
+						Enabled: aws.Bool(true),
+						Types:   ekstypes.LogTypeApi.Values(),
+					},
+				},
+			}
+		}
 		if opts.AutoMode {
 			input.ComputeConfig = &ekstypes.ComputeConfigRequest{
 				// we don't enable any of the default node pools, we'll create our own
@@ -125,6 +136,7 @@ func (m *ClusterManager) waitForClusterActive(clusterName string) (*Cluster, err
 		certificateAuthorityData: *out.Cluster.CertificateAuthority.Data,
 		cidr:                     cidr,
 		endpoint:                 *out.Cluster.Endpoint,
+		ipFamily:                 string(out.Cluster.KubernetesNetworkConfig.IpFamily),
 		name:                     *out.Cluster.Name,
 		securityGroupId:          *out.Cluster.ResourcesVpcConfig.ClusterSecurityGroupId,
 	}, nil
@@ -8,6 +8,8 @@ import (
 
 	"github.com/aws/aws-k8s-tester/kubetest2/internal"
 	"github.com/aws/aws-k8s-tester/kubetest2/internal/awssdk"
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/clusterlogs"
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/kubeconfig"
 	"github.com/aws/aws-k8s-tester/kubetest2/internal/metrics"
 	"github.com/aws/aws-k8s-tester/kubetest2/internal/util"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/spf13/pflag"
 	"golang.org/x/exp/slices"
 	"k8s.io/klog"
+	"sigs.k8s.io/kubetest2/pkg/artifacts"
 	"sigs.k8s.io/kubetest2/pkg/types"
 )
 
@@ -42,6 +45,7 @@ type deployer struct {
 	addonManager         *AddonManager
 	nodeManager          *nodeManager
 	logManager           *logManager
+	bastionManager       *bastionManager
 	staticClusterManager *StaticClusterManager
 
 	awsClients *awsClients
@@ -51,6 +55,11 @@ type deployer struct {
 
 	k8sClient *k8sClient
 
+	// tokenRefresher refreshes the kubeconfig token file until "Down"
+	tokenRefresher kubeconfig.TokenRefresher
+	// endpointTunnel is the tunnel to the private cluster endpoint until "Down", if --private-endpoint
+	endpointTunnel *endpointTunnel
+
 	initTime time.Time
 }
 
@@ -60,23 +69,33 @@ type deployerOptions struct {
 	AMIType                     string   `flag:"ami-type" desc:"AMI type for managed nodes"`
 	AutoMode                    bool     `flag:"auto-mode" desc:"Enable EKS Auto Mode"`
 	CapacityReservation         bool     `flag:"capacity-reservation" desc:"Use capacity reservation for the unmanaged nodegroup"`
+	CapacityType                string   `flag:"capacity-type" desc:"Capacity type of the managed nodegroups. Allowed values: ['ON_DEMAND', 'SPOT'], default to ON_DEMAND"`
 	ClusterRoleServicePrincipal string   `flag:"cluster-role-service-principal" desc:"Additional service principal that can assume the cluster role"`
+	ControlPlaneLogging         bool     `flag:"control-plane-logging" desc:"Enable all EKS control plane log types in CloudWatch Logs, which are collected into the artifacts directory by DumpClusterLogs"`
 	EFA                         bool     `flag:"efa" desc:"Create EFA interfaces on the node of an unmanaged nodegroup. Requires --unmanaged-nodes."`
 	EKSEndpointURL              string   `flag:"endpoint-url" desc:"Endpoint URL for the EKS API"`
 	EmitMetrics                 bool     `flag:"emit-metrics" desc:"Record and emit metrics to CloudWatch"`
 	ExpectedAMI                 string   `flag:"expected-ami" desc:"Expected AMI of nodes. Up will fail if the actual nodes are not utilizing the expected AMI. Defaults to --ami if defined."`
+	ExportInfra                 bool     `flag:"export-infra" desc:"Export an inventory of the created resources and their CloudFormation templates to the run directory, even if Up fails"`
 	// TODO: remove this once it's no longer used in downstream jobs
 	GenerateSSHKey      bool          `flag:"generate-ssh-key" desc:"Generate an SSH key to use for tests. The generated key should not be used in production, as it will not have a passphrase."`
 	InstanceTypes       []string      `flag:"instance-types" desc:"Node instance types. Cannot be used with --instance-type-archs"`
 	InstanceTypeArchs   []string      `flag:"instance-type-archs" desc:"Use default node instance types for specific architectures. Cannot be used with --instance-types"`
 	IPFamily            string        `flag:"ip-family" desc:"IP family for the cluster (ipv4 or ipv6)"`
 	KubeconfigPath      string        `flag:"kubeconfig" desc:"Path to kubeconfig"`
+	KubeconfigAuth      string        `flag:"kubeconfig-auth" desc:"Auth mode of the written kubeconfig. Allowed values: ['aws-cli', 'aws-iam-authenticator', 'token-file'], default to aws-cli"`
 	KubernetesVersion   string        `flag:"kubernetes-version" desc:"cluster Kubernetes version"`
+	LaunchTemplate      bool          `flag:"launch-template" desc:"Create the managed nodegroups from a generated launch template. With --ami, the nodes use the custom AMI and the generated --user-data-format user data"`
 	LogBucket           string        `flag:"log-bucket" desc:"S3 bucket for storing logs for each run. If empty, logs will not be stored."`
 	NodeCreationTimeout time.Duration `flag:"node-creation-timeout" desc:"Time to wait for nodes to be created/launched. This should consider instance availability."`
 	NodeReadyTimeout    time.Duration `flag:"node-ready-timeout" desc:"Time to wait for all nodes to become ready"`
 	Nodes               int           `flag:"nodes" desc:"number of nodes to launch in cluster"`
+	NodeLabels          []string      `flag:"node-labels" desc:"Kubernetes labels of the managed nodegroup nodes, in the form 'key=value'"`
+	NodeLogsSampleSize  int           `flag:"node-logs-sample-size" desc:"Number of instances to collect node logs (cloud-init, kubelet, containerd) from via SSM into the artifacts directory when Up fails or on Down. Negative to disable, default to 3"`
 	NodeNameStrategy    string        `flag:"node-name-strategy" desc:"Specifies the naming strategy for node. Allowed values: ['SessionName', 'EC2PrivateDNSName'], default to EC2PrivateDNSName"`
+	NodeTaints          []string      `flag:"node-taints" desc:"Taints of the managed nodegroup nodes, in the form 'key=value:effect' or 'key:effect', where effect is one of ['NoSchedule', 'PreferNoSchedule', 'NoExecute']"`
+	NodeVersionSkews    []int         `flag:"node-version-skews" desc:"Minor version skews of the managed nodegroups relative to --kubernetes-version, for version skew testing. One nodegroup of --nodes nodes is created per skew, e.g. '0,1,2' creates nodegroups at N, N-1, and N-2"`
+	PrivateEndpoint     bool          `flag:"private-endpoint" desc:"Create the cluster with only the private API endpoint enabled. The deployer and the tester reach the API server through an SSM port forwarding session to a bastion instance in the cluster VPC, which requires the 'aws' CLI and the 'session-manager-plugin' on the PATH"`
 	Region              string        `flag:"region" desc:"AWS region for EKS cluster"`
 	StaticClusterName   string        `flag:"static-cluster-name" desc:"Optional when re-use existing cluster and node group by querying the kubeconfig and run test"`
 	TuneVPCCNI          bool          `flag:"tune-vpc-cni" desc:"Apply tuning parameters to the VPC CNI DaemonSet"`
@@ -127,6 +146,7 @@ func (d *deployer) Init() error {
 	d.addonManager = NewAddonManager(d.awsClients)
 	d.nodeManager = NewNodeManager(d.awsClients, resourceID)
 	d.logManager = NewLogManager(d.awsClients, resourceID)
+	d.bastionManager = NewBastionManager(d.awsClients, resourceID)
 	if d.deployerOptions.StaticClusterName != "" {
 		d.staticClusterManager = NewStaticClusterManager(&d.deployerOptions)
 	}
@@ -143,15 +163,36 @@ func (d *deployer) Build() error {
 	return nil
 }
 
-// DumpClusterLogs is a no-op
+// DumpClusterLogs writes the control plane logs, the kube-system resources and logs, the CloudFormation stack events,
+// and the node logs to the artifacts directory. Failures are logged, since the dump is best effort.
 func (d *deployer) DumpClusterLogs() error {
+	dir := filepath.Join(artifacts.BaseDir(), clusterlogs.DirName)
+	clusterName := d.StaticClusterName
+	if clusterName == "" {
+		clusterName = d.infraManager.resourceID
+		if err := clusterlogs.DumpStackEvents(d.awsClients.CFN(), d.stackNames(), dir); err != nil {
+			klog.Warningf("failed to dump stack events: %v", err)
+		}
+		if err := d.logManager.collectNodeLogs(&d.deployerOptions, deployerPhaseDump, artifacts.BaseDir()); err != nil {
+			klog.Warningf("failed to collect node logs: %v", err)
+		}
+	}
+	if err := clusterlogs.DumpControlPlaneLogs(d.awsClients.EKS().Options().Region, clusterName, d.initTime, dir); err != nil {
+		klog.Warningf("failed to dump control plane logs: %v", err)
+	}
+	// the kubernetes client is not created if "Up" failed before the cluster was active
+	if d.k8sClient != nil {
+		if err := clusterlogs.DumpKubeSystem(d.k8sClient.clientset, dir); err != nil {
+			klog.Warningf("failed to dump kube-system: %v", err)
+		}
+	}
 	return nil
 }
 
 func (d *deployer) Kubeconfig() (string, error) {
 	if d.KubeconfigPath == "" {
 		kubeconfigPath := filepath.Join(d.commonOptions.RunDir(), "kubeconfig")
-		err := writeKubeconfig(d.cluster, kubeconfigPath)
+		err := d.writeKubeconfig(d.cluster, kubeconfigPath)
 		if err != nil {
 			klog.Warningf("failed to write kubeconfig: %v", err)
 			return "", err
@@ -162,9 +203,30 @@ func (d *deployer) Kubeconfig() (string, error) {
 }
 
 func (d *deployer) Up() error {
+	if err := d.up(); err != nil {
+		if d.StaticClusterName == "" {
+			if err := d.logManager.collectNodeLogs(&d.deployerOptions, deployerPhaseUp, artifacts.BaseDir()); err != nil {
+				klog.Warningf("failed to collect node logs: %v", err)
+				// don't return err, this isn't critical
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *deployer) up() error {
 	if err := d.verifyUpFlags(); err != nil {
 		return fmt.Errorf("up flags are invalid: %v", err)
 	}
+	if d.ExportInfra && d.StaticClusterName == "" {
+		defer func() {
+			if err := d.exportInfra(); err != nil {
+				klog.Warningf("failed to export infrastructure: %v", err)
+				// don't return err, this isn't critical
+			}
+		}()
+	}
 	if d.deployerOptions.StaticClusterName == "" {
 		if infra, err := d.infraManager.createInfrastructureStack(&d.deployerOptions); err != nil {
 			return err
@@ -177,6 +239,16 @@ func (d *deployer) Up() error {
 		return err
 	}
 	d.cluster = cluster
+	if d.PrivateEndpoint {
+		instanceID, err := d.bastionManager.createBastion(d.infra, d.cluster)
+		if err != nil {
+			return err
+		}
+		d.endpointTunnel, err = startEndpointTunnel(instanceID, d.cluster.endpoint)
+		if err != nil {
+			return err
+		}
+	}
 	kubeconfig, err := d.Kubeconfig()
 	if err != nil {
 		return err
@@ -206,6 +278,11 @@ func (d *deployer) Up() error {
 	if err := d.addonManager.createAddons(d.infra, d.cluster, &d.deployerOptions); err != nil {
 		return err
 	}
+	if d.IPFamily == string(ekstypes.IpFamilyIpv6) && !d.AutoMode {
+		if err := d.k8sClient.configureVPCCNIForIPv6(); err != nil {
+			return fmt.Errorf("failed to configure VPC CNI for IPv6: %v", err)
+		}
+	}
 	if d.deployerOptions.TuneVPCCNI {
 		if err := d.k8sClient.tuneVPCCNI(); err != nil {
 			return err
@@ -214,9 +291,31 @@ func (d *deployer) Up() error {
 	if err := d.nodeManager.createNodes(d.infra, d.cluster, &d.deployerOptions, d.k8sClient); err != nil {
 		return err
 	}
-	if err := d.k8sClient.waitForReadyNodes(d.Nodes, d.NodeReadyTimeout); err != nil {
+	expectedNodes := d.Nodes
+	if len(d.NodeVersionSkews) > 0 {
+		expectedNodes *= len(d.NodeVersionSkews)
+		nodeVersions, err := nodegroupVersions(d.KubernetesVersion, d.NodeVersionSkews)
+		if err != nil {
+			return err
+		}
+		if err := writeVersionSkewMetadata(artifacts.BaseDir(), d.KubernetesVersion, d.NodeVersionSkews, nodeVersions); err != nil {
+			klog.Warningf("failed to record version skew in metadata: %v", err)
+			// don't return err, this isn't critical
+		}
+	}
+	if err := d.k8sClient.waitForReadyNodes(expectedNodes, d.NodeReadyTimeout); err != nil {
 		return err
 	}
+	if d.AutoMode {
+		if err := d.k8sClient.verifyAutoModeNodes(d.nodeManager.resourceID, expectedNodes); err != nil {
+			return err
+		}
+	}
+	if d.IPFamily == string(ekstypes.IpFamilyIpv6) {
+		if err := d.k8sClient.verifyIPv6(!d.AutoMode, d.NodeReadyTimeout); err != nil {
+			return err
+		}
+	}
 	if d.EmitMetrics {
 		if err := d.k8sClient.emitNodeMetrics(d.metrics, d.awsClients.EC2()); err != nil {
 			return err
@@ -229,6 +328,22 @@ func (d *deployer) Up() error {
 	return nil
 }
 
+func (d *deployer) exportInfra() error {
+	return exportInfra(d.awsClients, d.infraManager.resourceID, d.stackNames(), filepath.Join(d.commonOptions.RunDir(), "infra"))
+}
+
+// stackNames returns the names of the CloudFormation stacks created by the deployer for the flags
+func (d *deployer) stackNames() []string {
+	stackNames := []string{d.infraManager.resourceID}
+	if d.UnmanagedNodes {
+		stackNames = append(stackNames, d.nodeManager.getUnmanagedNodegroupStackName())
+	}
+	if d.PrivateEndpoint {
+		stackNames = append(stackNames, d.bastionManager.getBastionStackName())
+	}
+	return stackNames
+}
+
 func (d *deployer) verifyUpFlags() error {
 	if d.KubernetesVersion == "" {
 		klog.Infof("--kubernetes-version is empty, attempting to detect it...")
@@ -242,6 +357,9 @@ func (d *deployer) verifyUpFlags() error {
 	if d.Nodes < 0 {
 		return fmt.Errorf("number of nodes must be greater than zero")
 	}
+	if err := kubeconfig.ValidateAuthMode(d.KubeconfigAuth); err != nil {
+		return err
+	}
 	if d.Nodes == 0 {
 		d.Nodes = 3
 		klog.Infof("Using default number of nodes: %d", d.Nodes)
@@ -249,6 +367,8 @@ func (d *deployer) verifyUpFlags() error {
 	if d.IPFamily == "" {
 		d.IPFamily = string(ekstypes.IpFamilyIpv4)
 		klog.Infof("Using default IP family: %s", d.IPFamily)
+	} else if !slices.Contains(supportedIPFamilies, d.IPFamily) {
+		return fmt.Errorf("--ip-family must be one of the following values: %v", supportedIPFamilies)
 	}
 	if d.NodeCreationTimeout == 0 {
 		d.NodeCreationTimeout = time.Minute * 20
@@ -256,6 +376,14 @@ func (d *deployer) verifyUpFlags() error {
 	if d.NodeReadyTimeout == 0 {
 		d.NodeReadyTimeout = time.Minute * 5
 	}
+	if d.PrivateEndpoint {
+		if d.StaticClusterName != "" {
+			return fmt.Errorf("--private-endpoint should not be provided with --static-cluster-name")
+		}
+		if err := verifyPrivateEndpointBinaries(); err != nil {
+			return fmt.Errorf("--private-endpoint requires the 'aws' CLI and the 'session-manager-plugin': %v", err)
+		}
+	}
 	if d.StaticClusterName != "" {
 		klog.Infof("Skip configuration for static cluster")
 		return nil
@@ -263,7 +391,20 @@ func (d *deployer) verifyUpFlags() error {
 	if len(d.InstanceTypes) > 0 && len(d.InstanceTypeArchs) > 0 {
 		return fmt.Errorf("--instance-types and --instance-type-archs are mutually exclusive")
 	}
-	if d.UnmanagedNodes {
+	if d.AutoMode {
+		if d.UnmanagedNodes {
+			return fmt.Errorf("--auto-mode and --unmanaged-nodes are mutually exclusive")
+		}
+		if d.AMI != "" || d.AMIType != "" {
+			return fmt.Errorf("--ami and --ami-type should not be provided with --auto-mode")
+		}
+		if d.EFA {
+			return fmt.Errorf("--efa requires --unmanaged-nodes")
+		}
+		if d.TuneVPCCNI {
+			return fmt.Errorf("--tune-vpc-cni should not be provided with --auto-mode, the VPC CNI is managed by EKS Auto Mode")
+		}
+	} else if d.UnmanagedNodes {
 		if d.AMI == "" {
 			return fmt.Errorf("--ami must be specified for --unmanaged-nodes")
 		}
@@ -285,15 +426,51 @@ func (d *deployer) verifyUpFlags() error {
 		if d.EFA && len(d.InstanceTypes) != 1 {
 			return fmt.Errorf("--efa requires a single instance type")
 		}
+	} else if d.LaunchTemplate && d.AMI != "" {
+		if d.AMIType != "" {
+			return fmt.Errorf("--ami-type should not be provided with --launch-template and --ami")
+		}
+		if d.UserDataFormat == "" {
+			d.UserDataFormat = "nodeadm"
+			klog.Infof("Using default user data format: %s", d.UserDataFormat)
+		}
 	} else {
 		if d.AMI != "" {
-			return fmt.Errorf("--ami should not be provided without --unmanaged-nodes")
+			return fmt.Errorf("--ami should not be provided without --unmanaged-nodes or --launch-template")
 		}
 		if d.AMIType == "" {
 			d.AMIType = "AL2023_x86_64_STANDARD"
 			klog.Infof("Using default AMI type: %s", d.AMIType)
 		}
 	}
+	if d.UnmanagedNodes || d.AutoMode {
+		if d.LaunchTemplate || d.CapacityType != "" || len(d.NodeTaints) > 0 || len(d.NodeLabels) > 0 {
+			return fmt.Errorf("--launch-template, --capacity-type, --node-taints, and --node-labels require managed nodegroups")
+		}
+	} else {
+		if d.CapacityType == "" {
+			d.CapacityType = string(ekstypes.CapacityTypesOnDemand)
+		} else if !slices.Contains(supportedCapacityTypes, d.CapacityType) {
+			return fmt.Errorf("--capacity-type must be one of the following values: %v", supportedCapacityTypes)
+		}
+		if _, err := parseNodeTaints(d.NodeTaints); err != nil {
+			return fmt.Errorf("--node-taints is invalid: %v", err)
+		}
+		if _, err := parseNodeLabels(d.NodeLabels); err != nil {
+			return fmt.Errorf("--node-labels is invalid: %v", err)
+		}
+	}
+	if len(d.NodeVersionSkews) > 0 {
+		if d.UnmanagedNodes || d.AutoMode {
+			return fmt.Errorf("--node-version-skews requires managed nodegroups")
+		}
+		if d.AMI != "" {
+			return fmt.Errorf("--node-version-skews should not be provided with --ami, the custom AMI determines the node version")
+		}
+		if _, err := nodegroupVersions(d.KubernetesVersion, d.NodeVersionSkews); err != nil {
+			return fmt.Errorf("--node-version-skews is invalid: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -314,6 +491,8 @@ func (d *deployer) IsUp() (up bool, err error) {
 }
 
 func (d *deployer) Down() error {
+	defer d.tokenRefresher.Stop()
+	defer d.endpointTunnel.stop()
 	if err := d.logManager.gatherLogsFromNodes(d.k8sClient, &d.deployerOptions, deployerPhaseDown); err != nil {
 		klog.Warningf("failed to gather logs from nodes: %v", err)
 		// don't return err, this isn't critical
@@ -321,13 +500,21 @@ func (d *deployer) Down() error {
 	if d.deployerOptions.StaticClusterName != "" {
 		return d.staticClusterManager.TearDownNodeForStaticCluster()
 	}
-	return deleteResources(d.infraManager, d.clusterManager, d.nodeManager)
+	if err := d.logManager.collectNodeLogs(&d.deployerOptions, deployerPhaseDown, artifacts.BaseDir()); err != nil {
+		klog.Warningf("failed to collect node logs: %v", err)
+		// don't return err, this isn't critical
+	}
+	return deleteResources(d.infraManager, d.clusterManager, d.nodeManager, d.bastionManager)
 }
 
-func deleteResources(im *InfrastructureManager, cm *ClusterManager, nm *nodeManager) error {
+func deleteResources(im *InfrastructureManager, cm *ClusterManager, nm *nodeManager, bm *bastionManager) error {
 	if err := nm.deleteNodes(); err != nil {
 		return err
 	}
+	// the bastion is in the EKS-managed cluster security group, which is deleted with the cluster
+	if err := bm.deleteBastion(); err != nil {
+		return err
+	}
 	// the EKS-managed cluster security group may be associated with a leaked ENI
 	// so we need to make sure we've deleted leaked ENIs before we delete the cluster
 	// otherwise, the cluster security group will be left behind and will block deletion of our VPC
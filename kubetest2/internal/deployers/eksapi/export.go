@@ -0,0 +1,165 @@
+package eksapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cloudformationtypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"k8s.io/klog"
+)
+
+// infraInventory is the inventory of the resources created by the deployer,
+// written to "[RUN_DIR]/infra/inventory.json" along with the CloudFormation templates,
+// for audits and for reproducing a failed environment manually.
+type infraInventory struct {
+	ResourceID string              `json:"resourceID"`
+	Exported   time.Time           `json:"exported"`
+	Cluster    *inventoryResource  `json:"cluster,omitempty"`
+	Nodegroups []inventoryResource `json:"nodegroups,omitempty"`
+	Stacks     []inventoryStack    `json:"stacks"`
+}
+
+type inventoryResource struct {
+	Name string `json:"name"`
+	ARN  string `json:"arn"`
+}
+
+type inventoryStack struct {
+	Name      string                   `json:"name"`
+	ID        string                   `json:"id"`
+	Template  string                   `json:"template"`
+	Resources []inventoryStackResource `json:"resources"`
+}
+
+type inventoryStackResource struct {
+	LogicalID  string `json:"logicalID"`
+	PhysicalID string `json:"physicalID"`
+	Type       string `json:"type"`
+	Status     string `json:"status"`
+}
+
+// exportInfra writes the inventory of the cluster, its managed nodegroups, and the stacks
+// created for the resource ID to the directory. Resources that do not exist
+// (e.g., on a partially failed "Up") are omitted.
+func exportInfra(clients *awsClients, resourceID string, stackNames []string, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	inv := infraInventory{
+		ResourceID: resourceID,
+		Exported:   time.Now().UTC(),
+	}
+
+	clusterOut, err := clients.EKS().DescribeCluster(context.TODO(), &eks.DescribeClusterInput{
+		Name: aws.String(resourceID),
+	})
+	if err != nil {
+		var notFound *ekstypes.ResourceNotFoundException
+		if !errors.As(err, &notFound) {
+			return fmt.Errorf("failed to describe cluster: %v", err)
+		}
+	} else {
+		inv.Cluster = &inventoryResource{Name: resourceID, ARN: aws.ToString(clusterOut.Cluster.Arn)}
+
+		// a cluster with node version skews has a nodegroup per version (see "nodegroupName")
+		paginator := eks.NewListNodegroupsPaginator(clients.EKS(), &eks.ListNodegroupsInput{
+			ClusterName: aws.String(resourceID),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(context.TODO())
+			if err != nil {
+				return fmt.Errorf("failed to list nodegroups: %v", err)
+			}
+			for _, name := range page.Nodegroups {
+				nodegroupOut, err := clients.EKS().DescribeNodegroup(context.TODO(), &eks.DescribeNodegroupInput{
+					ClusterName:   aws.String(resourceID),
+					NodegroupName: aws.String(name),
+				})
+				if err != nil {
+					var notFound *ekstypes.ResourceNotFoundException
+					if !errors.As(err, &notFound) {
+						return fmt.Errorf("failed to describe nodegroup %s: %v", name, err)
+					}
+					continue
+				}
+				inv.Nodegroups = append(inv.Nodegroups, inventoryResource{Name: name, ARN: aws.ToString(nodegroupOut.Nodegroup.NodegroupArn)})
+			}
+		}
+	}
+
+	for _, stackName := range stackNames {
+		stack, err := exportStack(clients.CFN(), stackName, dir)
+		if err != nil {
+			return err
+		}
+		if stack != nil {
+			inv.Stacks = append(inv.Stacks, *stack)
+		}
+	}
+
+	b, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	inventoryPath := filepath.Join(dir, "inventory.json")
+	if err := os.WriteFile(inventoryPath, b, 0644); err != nil {
+		return err
+	}
+	klog.Infof("exported infrastructure inventory: %s", inventoryPath)
+	return nil
+}
+
+// exportStack writes the original template of the stack to the directory,
+// and returns its resources, or nil if the stack does not exist.
+func exportStack(client *cloudformation.Client, stackName string, dir string) (*inventoryStack, error) {
+	out, err := client.DescribeStacks(context.TODO(), &cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		// CloudFormation returns a ValidationError for a stack that does not exist
+		klog.Infof("skipping export of stack %s: %v", stackName, err)
+		return nil, nil
+	}
+	stack := inventoryStack{
+		Name:     stackName,
+		ID:       aws.ToString(out.Stacks[0].StackId),
+		Template: stackName + ".template",
+	}
+	templateOut, err := client.GetTemplate(context.TODO(), &cloudformation.GetTemplateInput{
+		StackName:     out.Stacks[0].StackId,
+		TemplateStage: cloudformationtypes.TemplateStageOriginal,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template of stack %s: %v", stackName, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, stack.Template), []byte(aws.ToString(templateOut.TemplateBody)), 0644); err != nil {
+		return nil, err
+	}
+	paginator := cloudformation.NewListStackResourcesPaginator(client, &cloudformation.ListStackResourcesInput{
+		StackName: out.Stacks[0].StackId,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources of stack %s: %v", stackName, err)
+		}
+		for _, r := range page.StackResourceSummaries {
+			stack.Resources = append(stack.Resources, inventoryStackResource{
+				LogicalID:  aws.ToString(r.LogicalResourceId),
+				PhysicalID: aws.ToString(r.PhysicalResourceId),
+				Type:       aws.ToString(r.ResourceType),
+				Status:     string(r.ResourceStatus),
+			})
+		}
+	}
+	return &stack, nil
+}
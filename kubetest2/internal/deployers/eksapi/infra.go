@@ -136,6 +136,12 @@ func (m *InfrastructureManager) createInfrastructureStack(opts *deployerOptions)
 			ParameterValue: aws.String(opts.ClusterRoleServicePrincipal),
 		})
 	}
+	if opts.AutoMode {
+		input.Parameters = append(input.Parameters, cloudformationtypes.Parameter{
+			ParameterKey:   aws.String("AutoMode"),
+			ParameterValue: aws.String("true"),
+		})
+	}
 	if opts.EKSEndpointURL != "" {
 		input.Tags = []cloudformationtypes.Tag{
 			{
@@ -0,0 +1,96 @@
+package eksapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+var supportedIPFamilies = []string{"ipv4", "ipv6"}
+
+// coreDNSLabelSelector selects the CoreDNS pods, which are the first pod network pods on the nodes
+const coreDNSLabelSelector = "k8s-app=kube-dns"
+
+func isIPv6(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.To4() == nil
+}
+
+// nodesWithoutIPv6 returns the names of the nodes without an IPv6 InternalIP
+func nodesWithoutIPv6(nodes []corev1.Node) []string {
+	var names []string
+	for _, node := range nodes {
+		hasIPv6 := false
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == corev1.NodeInternalIP && isIPv6(addr.Address) {
+				hasIPv6 = true
+				break
+			}
+		}
+		if !hasIPv6 {
+			names = append(names, node.Name)
+		}
+	}
+	return names
+}
+
+// podsWithoutIPv6 returns the names of the running pods on the pod network whose primary IP is not IPv6
+func podsWithoutIPv6(pods []corev1.Pod) []string {
+	var names []string
+	for _, pod := range pods {
+		if pod.Spec.HostNetwork || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if !isIPv6(pod.Status.PodIP) {
+			names = append(names, fmt.Sprintf("%s (%s)", pod.Name, pod.Status.PodIP))
+		}
+	}
+	return names
+}
+
+// verifyIPv6 verifies that the ready nodes have IPv6 addresses, and, if verifyPods,
+// that the CoreDNS pods get IPv6 addresses from the VPC CNI once they're running.
+// EKS Auto Mode doesn't run CoreDNS pods, so the pods can't be verified there.
+func (k *k8sClient) verifyIPv6(verifyPods bool, timeout time.Duration) error {
+	nodes, err := k.getReadyNodes()
+	if err != nil {
+		return fmt.Errorf("failed to get ready nodes: %v", err)
+	}
+	if names := nodesWithoutIPv6(nodes); len(names) > 0 {
+		return fmt.Errorf("%d node(s) do not have an IPv6 InternalIP: %v", len(names), names)
+	}
+	klog.Infof("%d node(s) have IPv6 addresses", len(nodes))
+	if !verifyPods {
+		return nil
+	}
+	klog.Infof("waiting up to %v for CoreDNS pods to be running with IPv6 addresses...", timeout)
+	var running []corev1.Pod
+	err = wait.PollUntilContextTimeout(context.Background(), 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		pods, err := k.clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{LabelSelector: coreDNSLabelSelector})
+		if err != nil {
+			klog.Warningf("failed to list CoreDNS pods: %v", err)
+			return false, nil
+		}
+		running = nil
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodRunning {
+				running = append(running, pod)
+			}
+		}
+		return len(running) > 0 && len(running) == len(pods.Items), nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for CoreDNS pods to be running: %v", err)
+	}
+	if names := podsWithoutIPv6(running); len(names) > 0 {
+		return fmt.Errorf("%d pod(s) do not have an IPv6 address: %v", len(names), names)
+	}
+	klog.Infof("%d CoreDNS pod(s) have IPv6 addresses", len(running))
+	return nil
+}
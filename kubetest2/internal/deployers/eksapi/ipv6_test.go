@@ -0,0 +1,43 @@
+package eksapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_nodesWithoutIPv6(t *testing.T) {
+	node := func(name string, ips ...string) corev1.Node {
+		n := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for _, ip := range ips {
+			n.Status.Addresses = append(n.Status.Addresses, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: ip})
+		}
+		return n
+	}
+	nodes := []corev1.Node{
+		node("dual-stack", "10.0.0.1", "2600:1f14::1"),
+		node("ipv4", "10.0.0.2"),
+		node("ipv6", "2600:1f14::3"),
+		node("none"),
+	}
+	assert.Equal(t, []string{"ipv4", "none"}, nodesWithoutIPv6(nodes))
+}
+
+func Test_podsWithoutIPv6(t *testing.T) {
+	pod := func(name string, ip string, phase corev1.PodPhase, hostNetwork bool) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.PodSpec{HostNetwork: hostNetwork},
+			Status:     corev1.PodStatus{Phase: phase, PodIP: ip},
+		}
+	}
+	pods := []corev1.Pod{
+		pod("coredns-1", "2600:1f14::10", corev1.PodRunning, false),
+		pod("coredns-2", "10.0.0.10", corev1.PodRunning, false),
+		pod("aws-node", "10.0.0.1", corev1.PodRunning, true),
+		pod("pending", "", corev1.PodPending, false),
+	}
+	assert.Equal(t, []string{"coredns-2 (10.0.0.10)"}, podsWithoutIPv6(pods))
+}
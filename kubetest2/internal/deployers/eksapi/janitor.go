@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/kubetest2/internal/awssdk"
@@ -16,7 +19,11 @@ import (
 	"k8s.io/klog/v2"
 )
 
-func NewJanitor(maxResourceAge time.Duration, emitMetrics bool) *janitor {
+// NewJanitor returns a janitor that deletes the resources older than maxResourceAge. The orphaned resources
+// created outside of the stacks use the age of their type in maxResourceAgeByType, or maxResourceAge.
+// With dryRun, nothing is deleted and the report lists the resources that would be deleted.
+// With emitMetrics, the estimated hourly cost of the resources about to be deleted is emitted to CloudWatch.
+func NewJanitor(maxResourceAge time.Duration, maxResourceAgeByType map[string]time.Duration, emitMetrics bool, dryRun bool) *janitor {
 	awsConfig := awssdk.NewConfig()
 	var metricRegistry metrics.MetricRegistry
 	if emitMetrics {
@@ -25,15 +32,24 @@ func NewJanitor(maxResourceAge time.Duration, emitMetrics bool) *janitor {
 		metricRegistry = metrics.NewNoopMetricRegistry()
 	}
 	return &janitor{
-		maxResourceAge: maxResourceAge,
-		awsConfig:      awsConfig,
-		cfnClient:      cloudformation.NewFromConfig(awsConfig),
-		metrics:        metricRegistry,
+		maxResourceAge:       maxResourceAge,
+		maxResourceAgeByType: maxResourceAgeByType,
+		emitMetrics:          emitMetrics,
+		dryRun:               dryRun,
+		awsConfig:            awsConfig,
+		cfnClient:            cloudformation.NewFromConfig(awsConfig),
+		metrics:              metricRegistry,
 	}
 }
 
 type janitor struct {
-	maxResourceAge time.Duration
+	maxResourceAge       time.Duration
+	maxResourceAgeByType map[string]time.Duration
+	emitMetrics          bool
+	dryRun               bool
+	report               []sweepRecord
+	// costs are the estimated hourly costs of the swept resources by type, only estimated with emitMetrics
+	costs map[string]*leakCost
 
 	awsConfig aws.Config
 	cfnClient *cloudformation.Client
@@ -45,6 +61,8 @@ func (j *janitor) Sweep(ctx context.Context) error {
 	cfnClient := cloudformation.NewFromConfig(awsConfig)
 	stacks := cloudformation.NewDescribeStacksPaginator(cfnClient, &cloudformation.DescribeStacksInput{})
 	var errs []error
+	// the resource IDs of the stacks that are not swept, their orphaned resources are kept
+	liveOwners := make(map[string]bool)
 	for stacks.HasMorePages() {
 		page, err := stacks.NextPage(ctx)
 		if err != nil {
@@ -61,24 +79,136 @@ func (j *janitor) Sweep(ctx context.Context) error {
 			resourceAge := time.Since(*stack.CreationTime)
 			if resourceAge < j.maxResourceAge {
 				klog.Infof("skipping resources (%v old): %s", resourceAge, resourceID)
+				liveOwners[resourceID] = true
+				j.record("stack", resourceID, resourceID, resourceAge, fmt.Sprintf("skipped: younger than %v", j.maxResourceAge))
 				continue
 			}
 			clients := j.awsClientsForStack(stack)
+			if j.emitMetrics {
+				if err := j.estimateStackCost(ctx, clients, resourceID); err != nil {
+					klog.Warningf("failed to estimate the cost of resources: %s: %v", resourceID, err)
+				}
+			}
+			if j.dryRun {
+				j.record("stack", resourceID, resourceID, resourceAge, "would delete")
+				continue
+			}
 			infraManager := NewInfrastructureManager(clients, resourceID, j.metrics)
 			clusterManager := NewClusterManager(clients, resourceID)
 			nodeManager := NewNodeManager(clients, resourceID)
+			bastionManager := NewBastionManager(clients, resourceID)
 			klog.Infof("deleting resources (%v old): %s", resourceAge, resourceID)
-			if err := deleteResources(infraManager, clusterManager, nodeManager); err != nil {
+			if err := deleteResources(infraManager, clusterManager, nodeManager, bastionManager); err != nil {
 				errs = append(errs, fmt.Errorf("failed to delete resources: %s: %v", resourceID, err))
+				j.record("stack", resourceID, resourceID, resourceAge, fmt.Sprintf("failed: %v", err))
+			} else {
+				j.record("stack", resourceID, resourceID, resourceAge, "deleted")
 			}
 		}
 	}
+	if err := j.sweepOrphans(ctx, newAWSClients(awsConfig, ""), liveOwners); err != nil {
+		errs = append(errs, err)
+	}
+	j.writeReport(os.Stdout)
+	// a dry run only reports the estimated cost, the resources are not deleted
+	if j.emitMetrics && !j.dryRun {
+		j.recordCosts()
+	}
+	if err := j.metrics.Emit(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to emit metrics: %v", err))
+	}
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
 	return nil
 }
 
+// sweepOrphans deletes the resources of the deployer clusters that were created outside of the stacks,
+// and outlived their cluster (e.g., ENIs and security groups of the load balancers, OIDC providers of the tests)
+func (j *janitor) sweepOrphans(ctx context.Context, clients *awsClients, liveOwners map[string]bool) error {
+	var errs []error
+	now := time.Now()
+	for _, sweeper := range orphanSweepers(clients) {
+		maxResourceAge, ok := j.maxResourceAgeByType[sweeper.resourceType]
+		if !ok {
+			maxResourceAge = j.maxResourceAge
+		}
+		resources, err := sweeper.list(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s resources: %v", sweeper.resourceType, err))
+			continue
+		}
+		for _, r := range resources {
+			if r.owner == "" {
+				continue
+			}
+			var age time.Duration
+			if !r.created.IsZero() {
+				age = now.Sub(r.created)
+			}
+			if sweep, reason := shouldSweep(r, maxResourceAge, liveOwners, now); !sweep {
+				j.record(sweeper.resourceType, r.id, r.owner, age, "skipped: "+reason)
+				continue
+			}
+			if j.emitMetrics && sweeper.resourceType == orphanTypeElasticIP {
+				j.addCost(costTypeElasticIP, 1, elasticIPHourlyPrice)
+			}
+			if j.dryRun {
+				j.record(sweeper.resourceType, r.id, r.owner, age, "would delete")
+				continue
+			}
+			klog.Infof("deleting orphaned %s of %s: %s", sweeper.resourceType, r.owner, r.id)
+			if err := sweeper.delete(ctx, r.id); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete %s: %s: %v", sweeper.resourceType, r.id, err))
+				j.record(sweeper.resourceType, r.id, r.owner, age, fmt.Sprintf("failed: %v", err))
+				continue
+			}
+			j.record(sweeper.resourceType, r.id, r.owner, age, "deleted")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sweepRecord is a line of the janitor report
+type sweepRecord struct {
+	resourceType string
+	id           string
+	owner        string
+	// age is zero if unknown
+	age    time.Duration
+	action string
+}
+
+func (j *janitor) record(resourceType string, id string, owner string, age time.Duration, action string) {
+	j.report = append(j.report, sweepRecord{
+		resourceType: resourceType,
+		id:           id,
+		owner:        owner,
+		age:          age,
+		action:       action,
+	})
+}
+
+// writeReport writes the resources seen by the sweep, and what was done with them
+func (j *janitor) writeReport(w io.Writer) {
+	if j.dryRun {
+		fmt.Fprintln(w, "DRY RUN, no resources were deleted")
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tID\tOWNER\tAGE\tACTION")
+	for _, r := range j.report {
+		age := "unknown"
+		if r.age > 0 {
+			age = r.age.Round(time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.resourceType, r.id, r.owner, age, r.action)
+	}
+	tw.Flush()
+	if len(j.costs) > 0 {
+		fmt.Fprintf(w, "estimated hourly cost of the swept resources: $%.4f\n", j.totalHourlyCost())
+	}
+}
+
 func (j *janitor) awsClientsForStack(stack cloudformationtypes.Stack) *awsClients {
 	var eksEndpointURL string
 	for _, tag := range stack.Tags {
@@ -0,0 +1,190 @@
+package eksapi
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/metrics"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+var (
+	janitorMetricNamespace = path.Join(DeployerMetricNamespace, "janitor")
+	// janitorLeakedResourcesHourlyCost is the estimated hourly cost in USD of the resources swept by the janitor,
+	// recorded once per resource type with the "ResourceType" dimension, and once in total without dimensions
+	janitorLeakedResourcesHourlyCost = &metrics.MetricSpec{
+		Namespace: janitorMetricNamespace,
+		Metric:    "LeakedResourcesHourlyCost",
+		Unit:      cloudwatchtypes.StandardUnitNone,
+	}
+	janitorLeakedResources = &metrics.MetricSpec{
+		Namespace: janitorMetricNamespace,
+		Metric:    "LeakedResources",
+		Unit:      cloudwatchtypes.StandardUnitCount,
+	}
+)
+
+// Types of the priced resources, used as the "ResourceType" dimension of the cost metrics
+const (
+	costTypeInstance   = "instance"
+	costTypeNATGateway = "nat-gateway"
+	costTypeElasticIP  = orphanTypeElasticIP
+)
+
+// The estimated prices are the us-east-1 on-demand prices in USD, to track the trend of the waste rather than to bill it
+const (
+	natGatewayHourlyPrice = 0.045
+	// elasticIPHourlyPrice is the price of a public IPv4 address, whether or not it is associated
+	elasticIPHourlyPrice = 0.005
+	// vCPUHourlyPrice is the price per vCPU of the general purpose instances,
+	// used for the instance types missing from instanceHourlyPrices
+	vCPUHourlyPrice = 0.048
+)
+
+// instanceHourlyPrices are the Linux on-demand prices of the instance types commonly used by the tests
+var instanceHourlyPrices = map[string]float64{
+	"t3.medium":     0.0416,
+	"t3.large":      0.0832,
+	"c5.large":      0.085,
+	"c5.xlarge":     0.17,
+	"m5.large":      0.096,
+	"m5.xlarge":     0.192,
+	"m5.2xlarge":    0.384,
+	"m6i.large":     0.096,
+	"m6i.xlarge":    0.192,
+	"m7i.large":     0.1008,
+	"m7i.xlarge":    0.2016,
+	"m6g.large":     0.077,
+	"m7g.large":     0.0816,
+	"m7g.xlarge":    0.1632,
+	"g4dn.xlarge":   0.526,
+	"g5.xlarge":     1.006,
+	"g5.2xlarge":    1.212,
+	"inf2.xlarge":   0.7582,
+	"trn1.2xlarge":  1.3438,
+	"trn1.32xlarge": 21.50,
+	"p4d.24xlarge":  32.7726,
+	"p5.48xlarge":   98.32,
+}
+
+// instanceHourlyPrice returns the estimated hourly price of an instance type with the number of vCPUs
+func instanceHourlyPrice(instanceType string, vCPUs int32) float64 {
+	if price, ok := instanceHourlyPrices[instanceType]; ok {
+		return price
+	}
+	return float64(vCPUs) * vCPUHourlyPrice
+}
+
+// leakCost is the estimated hourly cost of the swept resources of a type
+type leakCost struct {
+	count      int
+	hourlyCost float64
+}
+
+// addCost adds the estimated hourly cost of the swept resources of a type
+func (j *janitor) addCost(resourceType string, count int, hourlyCost float64) {
+	if count == 0 {
+		return
+	}
+	if j.costs == nil {
+		j.costs = make(map[string]*leakCost)
+	}
+	c, ok := j.costs[resourceType]
+	if !ok {
+		c = &leakCost{}
+		j.costs[resourceType] = c
+	}
+	c.count += count
+	c.hourlyCost += hourlyCost
+}
+
+// totalHourlyCost returns the estimated hourly cost of all the swept resources
+func (j *janitor) totalHourlyCost() float64 {
+	var total float64
+	for _, c := range j.costs {
+		total += c.hourlyCost
+	}
+	return total
+}
+
+// recordCosts records the cost metrics of the swept resources
+func (j *janitor) recordCosts() {
+	for resourceType, c := range j.costs {
+		dimensions := map[string]string{"ResourceType": resourceType}
+		j.metrics.Record(janitorLeakedResources, float64(c.count), dimensions)
+		j.metrics.Record(janitorLeakedResourcesHourlyCost, c.hourlyCost, dimensions)
+	}
+	j.metrics.Record(janitorLeakedResourcesHourlyCost, j.totalHourlyCost(), nil)
+}
+
+// estimateStackCost adds the estimated hourly cost of the running instances of the cluster,
+// and of the NAT gateways and Elastic IPs of the infrastructure stack, before they are deleted
+func (j *janitor) estimateStackCost(ctx context.Context, clients *awsClients, resourceID string) error {
+	instances := ec2.NewDescribeInstancesPaginator(clients.EC2(), &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []string{clusterTagKeyPrefix + resourceID},
+			},
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []string{string(ec2types.InstanceStateNameRunning)},
+			},
+		},
+	})
+	var instanceCount int
+	var instanceCost float64
+	for instances.HasMorePages() {
+		page, err := instances.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe instances: %v", err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				var vCPUs int32
+				if instance.CpuOptions != nil {
+					vCPUs = aws.ToInt32(instance.CpuOptions.CoreCount) * aws.ToInt32(instance.CpuOptions.ThreadsPerCore)
+				}
+				instanceCount++
+				instanceCost += instanceHourlyPrice(string(instance.InstanceType), vCPUs)
+			}
+		}
+	}
+	j.addCost(costTypeInstance, instanceCount, instanceCost)
+
+	stackFilter := ec2types.Filter{
+		Name:   aws.String("tag:aws:cloudformation:stack-name"),
+		Values: []string{resourceID},
+	}
+	natGateways := ec2.NewDescribeNatGatewaysPaginator(clients.EC2(), &ec2.DescribeNatGatewaysInput{
+		Filter: []ec2types.Filter{
+			stackFilter,
+			{
+				Name:   aws.String("state"),
+				Values: []string{string(ec2types.NatGatewayStateAvailable)},
+			},
+		},
+	})
+	var natGatewayCount int
+	for natGateways.HasMorePages() {
+		page, err := natGateways.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe NAT gateways: %v", err)
+		}
+		natGatewayCount += len(page.NatGateways)
+	}
+	j.addCost(costTypeNATGateway, natGatewayCount, float64(natGatewayCount)*natGatewayHourlyPrice)
+
+	addresses, err := clients.EC2().DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		Filters: []ec2types.Filter{stackFilter},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe addresses: %v", err)
+	}
+	j.addCost(costTypeElasticIP, len(addresses.Addresses), float64(len(addresses.Addresses))*elasticIPHourlyPrice)
+	return nil
+}
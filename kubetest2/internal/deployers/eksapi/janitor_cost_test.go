@@ -0,0 +1,63 @@
+package eksapi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedMetric struct {
+	spec       *metrics.MetricSpec
+	value      float64
+	dimensions map[string]string
+}
+
+type fakeMetricRegistry struct {
+	recorded []recordedMetric
+}
+
+func (r *fakeMetricRegistry) Record(spec *metrics.MetricSpec, value float64, dimensions map[string]string) {
+	r.recorded = append(r.recorded, recordedMetric{spec: spec, value: value, dimensions: dimensions})
+}
+
+func (r *fakeMetricRegistry) Emit() error {
+	return nil
+}
+
+func Test_instanceHourlyPrice(t *testing.T) {
+	assert.Equal(t, 0.192, instanceHourlyPrice("m5.xlarge", 4))
+	// unknown instance types are priced by vCPU
+	assert.InDelta(t, 0.384, instanceHourlyPrice("m9z.2xlarge", 8), 1e-9)
+	assert.Equal(t, 0.0, instanceHourlyPrice("m9z.2xlarge", 0))
+}
+
+func Test_janitorCosts(t *testing.T) {
+	registry := &fakeMetricRegistry{}
+	j := &janitor{emitMetrics: true, metrics: registry}
+	j.addCost(costTypeInstance, 2, 0.384)
+	j.addCost(costTypeNATGateway, 0, 0)
+	j.addCost(costTypeNATGateway, 2, 2*natGatewayHourlyPrice)
+	j.addCost(costTypeElasticIP, 1, elasticIPHourlyPrice)
+	j.addCost(costTypeElasticIP, 1, elasticIPHourlyPrice)
+	assert.Len(t, j.costs, 3)
+	assert.Equal(t, 2, j.costs[costTypeElasticIP].count)
+	assert.InDelta(t, 0.484, j.totalHourlyCost(), 1e-9)
+
+	j.recordCosts()
+	assert.Len(t, registry.recorded, 7)
+	total := registry.recorded[len(registry.recorded)-1]
+	assert.Equal(t, janitorLeakedResourcesHourlyCost, total.spec)
+	assert.Nil(t, total.dimensions)
+	assert.InDelta(t, 0.484, total.value, 1e-9)
+	for _, m := range registry.recorded[:6] {
+		if m.dimensions["ResourceType"] == costTypeNATGateway && m.spec == janitorLeakedResources {
+			assert.Equal(t, 2.0, m.value)
+		}
+	}
+
+	var buf bytes.Buffer
+	j.writeReport(&buf)
+	assert.Contains(t, buf.String(), "estimated hourly cost of the swept resources: $0.4840")
+}
@@ -0,0 +1,283 @@
+package eksapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// Types of the orphaned resources swept by the janitor, used as the keys of the per-type maximum resource ages
+const (
+	orphanTypeNetworkInterface = "network-interface"
+	orphanTypeSecurityGroup    = "security-group"
+	orphanTypeElasticIP        = "elastic-ip"
+	orphanTypeLaunchTemplate   = "launch-template"
+	orphanTypeOIDCProvider     = "oidc-provider"
+)
+
+// OrphanResourceTypes are the types of the resources created outside of the CloudFormation stacks
+// (e.g., by EKS, the VPC CNI, or the load balancer controller) swept by the janitor, in the order of deletion
+var OrphanResourceTypes = []string{
+	orphanTypeNetworkInterface,
+	orphanTypeSecurityGroup,
+	orphanTypeElasticIP,
+	orphanTypeLaunchTemplate,
+	orphanTypeOIDCProvider,
+}
+
+const (
+	// clusterTagKeyPrefix is the prefix of the cluster ownership tag added by the deployer, EKS,
+	// and the Kubernetes controllers, e.g. "kubernetes.io/cluster/kubetest2-eksapi-abc: owned"
+	clusterTagKeyPrefix = "kubernetes.io/cluster/"
+	// eksClusterNameTagKey is added by EKS to the resources it creates for the cluster (e.g., the cluster security group)
+	eksClusterNameTagKey = "aws:eks:cluster-name"
+	// vpcCNIClusterNameTagKey is added by the VPC CNI to the ENIs it creates
+	vpcCNIClusterNameTagKey = "cluster.k8s.amazonaws.com/name"
+)
+
+// orphanedResource is a resource that may have outlived the deployer run that created it
+type orphanedResource struct {
+	id string
+	// owner is the resource ID of the deployer run, from the cluster tags
+	owner string
+	// created is the creation time of the resource, zero if the API does not return it
+	created time.Time
+}
+
+type orphanSweeper struct {
+	resourceType string
+	list         func(ctx context.Context) ([]orphanedResource, error)
+	delete       func(ctx context.Context, id string) error
+}
+
+// ownerFromTags returns the resource ID of the deployer run that owns a resource with the tags,
+// or an empty string if the resource was not created for a deployer cluster
+func ownerFromTags(tags map[string]string) string {
+	for key, value := range tags {
+		if name, ok := strings.CutPrefix(key, clusterTagKeyPrefix); ok && strings.HasPrefix(name, ResourcePrefix) {
+			return name
+		}
+		if (key == eksClusterNameTagKey || key == vpcCNIClusterNameTagKey) && strings.HasPrefix(value, ResourcePrefix) {
+			return value
+		}
+	}
+	return ""
+}
+
+// shouldSweep returns true if the resource should be deleted, or the reason to keep it.
+// A resource is kept while its owner is alive (i.e., its stack is not swept yet),
+// or if it is younger than the maximum age. Resources without a creation time
+// are only kept while their owner is alive.
+func shouldSweep(r orphanedResource, maxResourceAge time.Duration, liveOwners map[string]bool, now time.Time) (bool, string) {
+	if liveOwners[r.owner] {
+		return false, fmt.Sprintf("owner %s is alive", r.owner)
+	}
+	if !r.created.IsZero() {
+		if age := now.Sub(r.created); age < maxResourceAge {
+			return false, fmt.Sprintf("%v old, younger than %v", age.Round(time.Second), maxResourceAge)
+		}
+	}
+	return true, ""
+}
+
+// ParseMaxResourceAges parses the per-type maximum resource ages, in the form "type=duration,..."
+// (e.g., "network-interface=30m,oidc-provider=24h"). The types are OrphanResourceTypes.
+func ParseMaxResourceAges(s string) (map[string]time.Duration, error) {
+	ages := make(map[string]time.Duration)
+	if s == "" {
+		return ages, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		resourceType, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid maximum resource age %q, expected 'type=duration'", pair)
+		}
+		resourceType = strings.TrimSpace(resourceType)
+		known := false
+		for _, t := range OrphanResourceTypes {
+			if t == resourceType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown resource type %q, expected one of %v", resourceType, OrphanResourceTypes)
+		}
+		age, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid maximum resource age for %s: %v", resourceType, err)
+		}
+		ages[resourceType] = age
+	}
+	return ages, nil
+}
+
+func ec2TagMap(tags []ec2types.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		m[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return m
+}
+
+// clusterTagFilter matches the resources with a cluster ownership tag of a deployer cluster
+var clusterTagFilter = ec2types.Filter{
+	Name:   aws.String("tag-key"),
+	Values: []string{clusterTagKeyPrefix + ResourcePrefix + "*"},
+}
+
+func orphanSweepers(clients *awsClients) []orphanSweeper {
+	return []orphanSweeper{
+		{
+			resourceType: orphanTypeNetworkInterface,
+			list: func(ctx context.Context) ([]orphanedResource, error) {
+				paginator := ec2.NewDescribeNetworkInterfacesPaginator(clients.EC2(), &ec2.DescribeNetworkInterfacesInput{
+					Filters: []ec2types.Filter{
+						{
+							Name:   aws.String("status"),
+							Values: []string{string(ec2types.NetworkInterfaceStatusAvailable)},
+						},
+						{
+							Name:   aws.String("tag-key"),
+							Values: []string{vpcCNIClusterNameTagKey, clusterTagKeyPrefix + ResourcePrefix + "*"},
+						},
+					},
+				})
+				var resources []orphanedResource
+				for paginator.HasMorePages() {
+					page, err := paginator.NextPage(ctx)
+					if err != nil {
+						return nil, err
+					}
+					for _, eni := range page.NetworkInterfaces {
+						tags := ec2TagMap(eni.TagSet)
+						r := orphanedResource{id: aws.ToString(eni.NetworkInterfaceId), owner: ownerFromTags(tags)}
+						// the VPC CNI records the creation time of its ENIs
+						if created, err := time.Parse(time.RFC3339, tags[vpcCNIENITagKey]); err == nil {
+							r.created = created
+						}
+						resources = append(resources, r)
+					}
+				}
+				return resources, nil
+			},
+			delete: func(ctx context.Context, id string) error {
+				_, err := clients.EC2().DeleteNetworkInterface(ctx, &ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: aws.String(id)})
+				return err
+			},
+		},
+		{
+			resourceType: orphanTypeSecurityGroup,
+			list: func(ctx context.Context) ([]orphanedResource, error) {
+				paginator := ec2.NewDescribeSecurityGroupsPaginator(clients.EC2(), &ec2.DescribeSecurityGroupsInput{
+					Filters: []ec2types.Filter{clusterTagFilter},
+				})
+				var resources []orphanedResource
+				for paginator.HasMorePages() {
+					page, err := paginator.NextPage(ctx)
+					if err != nil {
+						return nil, err
+					}
+					for _, sg := range page.SecurityGroups {
+						resources = append(resources, orphanedResource{id: aws.ToString(sg.GroupId), owner: ownerFromTags(ec2TagMap(sg.Tags))})
+					}
+				}
+				return resources, nil
+			},
+			delete: func(ctx context.Context, id string) error {
+				_, err := clients.EC2().DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(id)})
+				return err
+			},
+		},
+		{
+			resourceType: orphanTypeElasticIP,
+			list: func(ctx context.Context) ([]orphanedResource, error) {
+				out, err := clients.EC2().DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+					Filters: []ec2types.Filter{clusterTagFilter},
+				})
+				if err != nil {
+					return nil, err
+				}
+				var resources []orphanedResource
+				for _, address := range out.Addresses {
+					// an associated address is still in use
+					if address.AssociationId != nil {
+						continue
+					}
+					resources = append(resources, orphanedResource{id: aws.ToString(address.AllocationId), owner: ownerFromTags(ec2TagMap(address.Tags))})
+				}
+				return resources, nil
+			},
+			delete: func(ctx context.Context, id string) error {
+				_, err := clients.EC2().ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: aws.String(id)})
+				return err
+			},
+		},
+		{
+			resourceType: orphanTypeLaunchTemplate,
+			list: func(ctx context.Context) ([]orphanedResource, error) {
+				paginator := ec2.NewDescribeLaunchTemplatesPaginator(clients.EC2(), &ec2.DescribeLaunchTemplatesInput{
+					Filters: []ec2types.Filter{clusterTagFilter},
+				})
+				var resources []orphanedResource
+				for paginator.HasMorePages() {
+					page, err := paginator.NextPage(ctx)
+					if err != nil {
+						return nil, err
+					}
+					for _, lt := range page.LaunchTemplates {
+						resources = append(resources, orphanedResource{
+							id:      aws.ToString(lt.LaunchTemplateId),
+							owner:   ownerFromTags(ec2TagMap(lt.Tags)),
+							created: aws.ToTime(lt.CreateTime),
+						})
+					}
+				}
+				return resources, nil
+			},
+			delete: func(ctx context.Context, id string) error {
+				_, err := clients.EC2().DeleteLaunchTemplate(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateId: aws.String(id)})
+				return err
+			},
+		},
+		{
+			resourceType: orphanTypeOIDCProvider,
+			list: func(ctx context.Context) ([]orphanedResource, error) {
+				out, err := clients.IAM().ListOpenIDConnectProviders(ctx, &iam.ListOpenIDConnectProvidersInput{})
+				if err != nil {
+					return nil, err
+				}
+				var resources []orphanedResource
+				for _, provider := range out.OpenIDConnectProviderList {
+					// the tags are only returned by GetOpenIDConnectProvider
+					providerOut, err := clients.IAM().GetOpenIDConnectProvider(ctx, &iam.GetOpenIDConnectProviderInput{
+						OpenIDConnectProviderArn: provider.Arn,
+					})
+					if err != nil {
+						return nil, fmt.Errorf("failed to get OIDC provider %s: %v", aws.ToString(provider.Arn), err)
+					}
+					tags := make(map[string]string, len(providerOut.Tags))
+					for _, tag := range providerOut.Tags {
+						tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+					}
+					resources = append(resources, orphanedResource{
+						id:      aws.ToString(provider.Arn),
+						owner:   ownerFromTags(tags),
+						created: aws.ToTime(providerOut.CreateDate),
+					})
+				}
+				return resources, nil
+			},
+			delete: func(ctx context.Context, id string) error {
+				_, err := clients.IAM().DeleteOpenIDConnectProvider(ctx, &iam.DeleteOpenIDConnectProviderInput{OpenIDConnectProviderArn: aws.String(id)})
+				return err
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package eksapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ownerFromTags(t *testing.T) {
+	assert.Equal(t, "kubetest2-eksapi-abc", ownerFromTags(map[string]string{
+		"Name": "foo",
+		"kubernetes.io/cluster/kubetest2-eksapi-abc": "owned",
+	}))
+	assert.Equal(t, "kubetest2-eksapi-abc", ownerFromTags(map[string]string{eksClusterNameTagKey: "kubetest2-eksapi-abc"}))
+	assert.Equal(t, "kubetest2-eksapi-abc", ownerFromTags(map[string]string{vpcCNIClusterNameTagKey: "kubetest2-eksapi-abc"}))
+	assert.Equal(t, "", ownerFromTags(map[string]string{"kubernetes.io/cluster/production": "owned"}))
+	assert.Equal(t, "", ownerFromTags(map[string]string{vpcCNIClusterNameTagKey: "production"}))
+	assert.Equal(t, "", ownerFromTags(nil))
+}
+
+func Test_shouldSweep(t *testing.T) {
+	now := time.Now()
+	liveOwners := map[string]bool{"kubetest2-eksapi-live": true}
+	sweep, reason := shouldSweep(orphanedResource{id: "eni-1", owner: "kubetest2-eksapi-live", created: now.Add(-24 * time.Hour)}, time.Hour, liveOwners, now)
+	assert.False(t, sweep)
+	assert.Contains(t, reason, "alive")
+	sweep, reason = shouldSweep(orphanedResource{id: "lt-1", owner: "kubetest2-eksapi-gone", created: now.Add(-time.Minute)}, time.Hour, liveOwners, now)
+	assert.False(t, sweep)
+	assert.Contains(t, reason, "younger than 1h0m0s")
+	sweep, _ = shouldSweep(orphanedResource{id: "lt-2", owner: "kubetest2-eksapi-gone", created: now.Add(-2 * time.Hour)}, time.Hour, liveOwners, now)
+	assert.True(t, sweep)
+	// the creation time of security groups is unknown
+	sweep, _ = shouldSweep(orphanedResource{id: "sg-1", owner: "kubetest2-eksapi-gone"}, time.Hour, liveOwners, now)
+	assert.True(t, sweep)
+}
+
+func Test_ParseMaxResourceAges(t *testing.T) {
+	ages, err := ParseMaxResourceAges("")
+	assert.NoError(t, err)
+	assert.Empty(t, ages)
+	ages, err = ParseMaxResourceAges("network-interface=30m, oidc-provider=24h")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		orphanTypeNetworkInterface: 30 * time.Minute,
+		orphanTypeOIDCProvider:     24 * time.Hour,
+	}, ages)
+	for _, s := range []string{"network-interface", "subnet=1h", "security-group=soon"} {
+		_, err = ParseMaxResourceAges(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func Test_janitorReport(t *testing.T) {
+	j := &janitor{dryRun: true}
+	j.record("stack", "kubetest2-eksapi-abc", "kubetest2-eksapi-abc", 4*time.Hour, "would delete")
+	j.record(orphanTypeSecurityGroup, "sg-1", "kubetest2-eksapi-abc", 0, "would delete")
+	var buf bytes.Buffer
+	j.writeReport(&buf)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[0], "DRY RUN")
+	assert.Contains(t, lines[2], "4h0m0s")
+	assert.Contains(t, lines[3], "unknown")
+}
@@ -1,79 +1,36 @@
 package eksapi
 
 import (
-	"bytes"
-	"os"
-	"text/template"
+	"context"
 	"fmt"
+	"path/filepath"
 
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/kubeconfig"
 	"k8s.io/klog"
 )
 
-const kubeconfigPerm = 0666
-
-var kubeconfigTemplate = `---
-apiVersion: v1
-kind: Config
-clusters:
-- cluster:
-    certificate-authority-data: {{ .ClusterCertificateAuthority }}
-    server: {{ .ClusterEndpoint }}
-  name: {{ .ClusterARN }}
-contexts:
-- context:
-    cluster: {{ .ClusterARN }}
-    user: {{ .ClusterARN }}
-  name: {{ .ClusterARN }}
-current-context: {{ .ClusterARN }}
-preferences: {}
-users:
-- name: {{ .ClusterARN }}
-  user:
-    exec:
-      apiVersion: client.authentication.k8s.io/v1beta1
-      command: aws
-      args:
-      - eks
-      - get-token
-      - --cluster-name
-      - {{ .ClusterName }}
-`
-
-type kubeconfigTemplateParameters struct {
-	ClusterCertificateAuthority string
-	ClusterARN                  string
-	ClusterEndpoint             string
-	ClusterName                 string
-}
-
-func writeKubeconfig(cluster *Cluster, kubeconfigPath string) error {
+func (d *deployer) writeKubeconfig(cluster *Cluster, kubeconfigPath string) error {
 	if cluster == nil {
 		return fmt.Errorf("Cluster is nil, you might need set --static-cluster-name or set --up to initial cluster resrouces")
 	}
-	klog.Infof("writing kubeconfig to %s for cluster: %s", kubeconfigPath, cluster.arn)
-	templateParams := kubeconfigTemplateParameters{
+	klog.Infof("writing kubeconfig to %s for cluster: %s (auth: %s)", kubeconfigPath, cluster.arn, d.KubeconfigAuth)
+	params := kubeconfig.Parameters{
 		ClusterCertificateAuthority: cluster.certificateAuthorityData,
 		ClusterARN:                  cluster.arn,
 		ClusterEndpoint:             cluster.endpoint,
 		ClusterName:                 cluster.name,
+		AuthMode:                    d.KubeconfigAuth,
 	}
-
-	kubeconfig := bytes.Buffer{}
-
-	t, err := template.New("kubeconfig").Parse(kubeconfigTemplate)
-	if err != nil {
-		return err
-	}
-	err = t.Execute(&kubeconfig, templateParams)
-	if err != nil {
-		return err
+	if d.endpointTunnel != nil {
+		params.ClusterEndpoint = d.endpointTunnel.endpoint()
+		params.TLSServerName = d.endpointTunnel.host
 	}
-
-	err = os.WriteFile(kubeconfigPath, kubeconfig.Bytes(), kubeconfigPerm)
-	if err != nil {
-		return err
+	if d.KubeconfigAuth == kubeconfig.AuthTokenFile {
+		params.TokenFile = filepath.Join(filepath.Dir(kubeconfigPath), "token")
+		if err := kubeconfig.WriteTokenFile(context.TODO(), d.awsClients.STS(), cluster.name, params.TokenFile); err != nil {
+			return err
+		}
+		d.tokenRefresher.Start(d.awsClients.STS(), cluster.name, params.TokenFile)
 	}
-
-	klog.Infof("wrote kubeconfig: %s\n%s", kubeconfigPath, kubeconfig.String())
-	return nil
+	return kubeconfig.Write(params, kubeconfigPath)
 }
@@ -0,0 +1,177 @@
+package eksapi
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"k8s.io/klog/v2"
+)
+
+// managedNodegroupDiskSize is the root volume size (GiB) of the managed nodegroup nodes
+const managedNodegroupDiskSize = 100
+
+var supportedCapacityTypes = []string{string(ekstypes.CapacityTypesOnDemand), string(ekstypes.CapacityTypesSpot)}
+
+// taintEffects maps the Kubernetes taint effects to the EKS taint effects
+var taintEffects = map[string]ekstypes.TaintEffect{
+	"NoSchedule":       ekstypes.TaintEffectNoSchedule,
+	"PreferNoSchedule": ekstypes.TaintEffectPreferNoSchedule,
+	"NoExecute":        ekstypes.TaintEffectNoExecute,
+}
+
+// parseNodeTaints parses taints in the form 'key=value:effect' or 'key:effect'
+func parseNodeTaints(taints []string) ([]ekstypes.Taint, error) {
+	var parsed []ekstypes.Taint
+	for _, taint := range taints {
+		keyValue, effect, ok := strings.Cut(taint, ":")
+		if !ok {
+			return nil, fmt.Errorf("taint '%s' has no effect, expected 'key=value:effect'", taint)
+		}
+		eksEffect, ok := taintEffects[effect]
+		if !ok {
+			return nil, fmt.Errorf("taint '%s' has unknown effect '%s', expected one of: NoSchedule, PreferNoSchedule, NoExecute", taint, effect)
+		}
+		key, value, _ := strings.Cut(keyValue, "=")
+		if key == "" {
+			return nil, fmt.Errorf("taint '%s' has no key", taint)
+		}
+		t := ekstypes.Taint{
+			Key:    aws.String(key),
+			Effect: eksEffect,
+		}
+		if value != "" {
+			t.Value = aws.String(value)
+		}
+		parsed = append(parsed, t)
+	}
+	return parsed, nil
+}
+
+// parseNodeLabels parses labels in the form 'key=value'
+func parseNodeLabels(labels []string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]string)
+	for _, label := range labels {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("label '%s' is invalid, expected 'key=value'", label)
+		}
+		parsed[key] = value
+	}
+	return parsed, nil
+}
+
+// managedNodegroupUserData returns the user data of a managed nodegroup launch template,
+// which must be a multi-part MIME document unless it's not a MIME part (e.g. Bottlerocket)
+func managedNodegroupUserData(userData string, userDataIsMimePart bool) string {
+	if !userDataIsMimePart {
+		return userData
+	}
+	return fmt.Sprintf(`Content-Type: multipart/mixed; boundary="BOUNDARY"
+MIME-Version: 1.0
+
+--BOUNDARY
+%s
+--BOUNDARY--
+`, userData)
+}
+
+// createLaunchTemplate creates the launch template of a managed nodegroup.
+// The user data is only set for a custom AMI, otherwise EKS generates it.
+func (m *nodeManager) createLaunchTemplate(cluster *Cluster, opts *deployerOptions, name string) (*ekstypes.LaunchTemplateSpecification, error) {
+	volumeMountPath := "/dev/xvda"
+	if opts.UserDataFormat == "bottlerocket" {
+		volumeMountPath = "/dev/xvdb"
+	}
+	data := ec2types.RequestLaunchTemplateData{
+		BlockDeviceMappings: []ec2types.LaunchTemplateBlockDeviceMappingRequest{
+			{
+				DeviceName: aws.String(volumeMountPath),
+				Ebs: &ec2types.LaunchTemplateEbsBlockDeviceRequest{
+					VolumeSize:          aws.Int32(managedNodegroupDiskSize),
+					VolumeType:          ec2types.VolumeTypeGp3,
+					DeleteOnTermination: aws.Bool(true),
+				},
+			},
+		},
+		MetadataOptions: &ec2types.LaunchTemplateInstanceMetadataOptionsRequest{
+			HttpTokens: ec2types.LaunchTemplateHttpTokensStateRequired,
+			// pods without host networking need an extra hop
+			HttpPutResponseHopLimit: aws.Int32(2),
+		},
+	}
+	if opts.AMI != "" {
+		userData, userDataIsMimePart, err := generateUserData(opts.UserDataFormat, cluster)
+		if err != nil {
+			return nil, err
+		}
+		data.ImageId = aws.String(opts.AMI)
+		data.UserData = aws.String(base64.StdEncoding.EncodeToString([]byte(managedNodegroupUserData(userData, userDataIsMimePart))))
+	}
+	klog.Infof("creating launch template %s...", name)
+	out, err := m.clients.EC2().CreateLaunchTemplate(context.TODO(), &ec2.CreateLaunchTemplateInput{
+		LaunchTemplateName: aws.String(name),
+		LaunchTemplateData: &data,
+		TagSpecifications: []ec2types.TagSpecification{
+			{
+				ResourceType: ec2types.ResourceTypeLaunchTemplate,
+				Tags: []ec2types.Tag{
+					{
+						Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", m.resourceID)),
+						Value: aws.String("owned"),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create launch template: %v", err)
+	}
+	klog.Infof("created launch template: %s", aws.ToString(out.LaunchTemplate.LaunchTemplateId))
+	return &ekstypes.LaunchTemplateSpecification{
+		Id:      out.LaunchTemplate.LaunchTemplateId,
+		Version: aws.String(fmt.Sprint(aws.ToInt64(out.LaunchTemplate.LatestVersionNumber))),
+	}, nil
+}
+
+// deleteLaunchTemplates deletes the launch templates of the managed nodegroups
+func (m *nodeManager) deleteLaunchTemplates() error {
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(m.clients.EC2(), &ec2.DescribeLaunchTemplatesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("launch-template-name"),
+				Values: []string{m.resourceID + "*"},
+			},
+		},
+	})
+	var ids []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return fmt.Errorf("failed to describe launch templates: %v", err)
+		}
+		for _, lt := range page.LaunchTemplates {
+			ids = append(ids, aws.ToString(lt.LaunchTemplateId))
+		}
+	}
+	for _, id := range ids {
+		klog.Infof("deleting launch template: %s", id)
+		if _, err := m.clients.EC2().DeleteLaunchTemplate(context.TODO(), &ec2.DeleteLaunchTemplateInput{
+			LaunchTemplateId: aws.String(id),
+		}); err != nil {
+			return fmt.Errorf("failed to delete launch template %s: %v", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		klog.Infof("deleted %d launch template(s): %v", len(ids), ids)
+	}
+	return nil
+}
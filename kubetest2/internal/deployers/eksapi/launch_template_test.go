@@ -0,0 +1,50 @@
+package eksapi
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseNodeTaints(t *testing.T) {
+	taints, err := parseNodeTaints([]string{"dedicated=gpu:NoSchedule", "spot:PreferNoSchedule"})
+	assert.NoError(t, err)
+	assert.Equal(t, []ekstypes.Taint{
+		{Key: aws.String("dedicated"), Value: aws.String("gpu"), Effect: ekstypes.TaintEffectNoSchedule},
+		{Key: aws.String("spot"), Effect: ekstypes.TaintEffectPreferNoSchedule},
+	}, taints)
+
+	for _, invalid := range []string{"dedicated=gpu", "dedicated=gpu:Never", "=gpu:NoExecute"} {
+		_, err := parseNodeTaints([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_parseNodeLabels(t *testing.T) {
+	labels, err := parseNodeLabels([]string{"team=sig-node", "empty="})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "sig-node", "empty": ""}, labels)
+
+	labels, err = parseNodeLabels(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, labels)
+
+	for _, invalid := range []string{"team", "=sig-node"} {
+		_, err := parseNodeLabels([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_managedNodegroupUserData(t *testing.T) {
+	assert.Equal(t, "[settings]", managedNodegroupUserData("[settings]", false))
+	assert.Equal(t, `Content-Type: multipart/mixed; boundary="BOUNDARY"
+MIME-Version: 1.0
+
+--BOUNDARY
+Content-Type: application/node.eks.aws
+
+--BOUNDARY--
+`, managedNodegroupUserData("Content-Type: application/node.eks.aws\n", true))
+}
@@ -29,6 +29,7 @@ type deployerPhase string
 const (
 	deployerPhaseUp   = "up"
 	deployerPhaseDown = "down"
+	deployerPhaseDump = "dump"
 )
 
 func NewLogManager(clients *awsClients, resourceID string) *logManager {
@@ -117,7 +117,11 @@ func (m *nodeManager) resolveInstanceTypes(opts *deployerOptions) (err error) {
 				}
 				instanceTypes = append(instanceTypes, instanceTypesForArch...)
 			}
-		} else if opts.UnmanagedNodes {
+		} else if opts.AutoMode {
+			// EKS Auto Mode chooses the AMI, so there's no AMI architecture to go by
+			klog.Infof("choosing x86_64 instance types for EKS Auto Mode...")
+			instanceTypes = defaultInstanceTypes_x86_64
+		} else if opts.UnmanagedNodes || opts.AMI != "" {
 			klog.Infof("choosing instance types based on AMI architecture...")
 			if out, err := m.clients.EC2().DescribeImages(context.TODO(), &ec2.DescribeImagesInput{
 				ImageIds: []string{opts.AMI},
@@ -266,14 +270,36 @@ func (m *nodeManager) createPlaceholderDeployment(opts *deployerOptions, k8sClie
 }
 
 func (m *nodeManager) createManagedNodegroup(infra *Infrastructure, cluster *Cluster, opts *deployerOptions) error {
-	klog.Infof("creating nodegroup...")
+	versions, err := nodegroupVersions(opts.KubernetesVersion, opts.NodeVersionSkews)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		name := nodegroupName(m.resourceID, version, len(opts.NodeVersionSkews) > 0)
+		if err := m.createManagedNodegroupWithVersion(infra, cluster, opts, name, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *nodeManager) createManagedNodegroupWithVersion(infra *Infrastructure, cluster *Cluster, opts *deployerOptions, name string, version string) error {
+	klog.Infof("creating nodegroup %s with version %s...", name, version)
+	taints, err := parseNodeTaints(opts.NodeTaints)
+	if err != nil {
+		return err
+	}
+	labels, err := parseNodeLabels(opts.NodeLabels)
+	if err != nil {
+		return err
+	}
 	input := eks.CreateNodegroupInput{
 		ClusterName:   aws.String(m.resourceID),
-		NodegroupName: aws.String(m.resourceID),
+		NodegroupName: aws.String(name),
 		NodeRole:      aws.String(infra.nodeRoleARN),
 		Subnets:       infra.subnets(),
-		DiskSize:      aws.Int32(100),
-		CapacityType:  ekstypes.CapacityTypesOnDemand,
+		DiskSize:      aws.Int32(managedNodegroupDiskSize),
+		CapacityType:  ekstypes.CapacityTypes(opts.CapacityType),
 		ScalingConfig: &ekstypes.NodegroupScalingConfig{
 			MinSize:     aws.Int32(int32(opts.Nodes)),
 			MaxSize:     aws.Int32(int32(opts.Nodes)),
@@ -281,6 +307,24 @@ func (m *nodeManager) createManagedNodegroup(infra *Infrastructure, cluster *Clu
 		},
 		AmiType:       ekstypes.AMITypes(opts.AMIType),
 		InstanceTypes: opts.InstanceTypes,
+		Taints:        taints,
+		Labels:        labels,
+	}
+	if len(opts.NodeVersionSkews) > 0 {
+		input.Version = aws.String(version)
+	}
+	if opts.LaunchTemplate {
+		launchTemplate, err := m.createLaunchTemplate(cluster, opts, name)
+		if err != nil {
+			return err
+		}
+		input.LaunchTemplate = launchTemplate
+		// the disk size is set in the launch template
+		input.DiskSize = nil
+		if opts.AMI != "" {
+			// the AMI type is custom
+			input.AmiType = ""
+		}
 	}
 	out, err := m.clients.EKS().CreateNodegroup(context.TODO(), &input)
 	if err != nil {
@@ -521,20 +565,62 @@ func (m *nodeManager) deleteNodes() error {
 	if err := m.deleteUnmanagedNodegroup(); err != nil {
 		return err
 	}
-	return m.deleteManagedNodegroup()
+	if err := m.deleteManagedNodegroup(); err != nil {
+		return err
+	}
+	return m.deleteLaunchTemplates()
 }
 
 func (m *nodeManager) deleteManagedNodegroup() error {
+	// nodegroups of the version skew mode are suffixed with the version
+	names, err := m.listManagedNodegroups()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := m.deleteManagedNodegroupByName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listManagedNodegroups returns the names of the nodegroups created by this deployer.
+// The resource ID is returned if the cluster does not exist, in case the nodegroup outlived it.
+func (m *nodeManager) listManagedNodegroups() ([]string, error) {
+	var names []string
+	paginator := eks.NewListNodegroupsPaginator(m.clients.EKS(), &eks.ListNodegroupsInput{
+		ClusterName: aws.String(m.resourceID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			var notFound *ekstypes.ResourceNotFoundException
+			if errors.As(err, &notFound) {
+				return []string{m.resourceID}, nil
+			}
+			return nil, fmt.Errorf("failed to list nodegroups: %v", err)
+		}
+		for _, name := range page.Nodegroups {
+			if strings.HasPrefix(name, m.resourceID) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func (m *nodeManager) deleteManagedNodegroupByName(name string) error {
 	input := eks.DeleteNodegroupInput{
 		ClusterName:   aws.String(m.resourceID),
-		NodegroupName: aws.String(m.resourceID),
+		NodegroupName: aws.String(name),
 	}
-	klog.Infof("deleting nodegroup...")
+	klog.Infof("deleting nodegroup %s...", name)
 	out, err := m.clients.EKS().DeleteNodegroup(context.TODO(), &input)
 	if err != nil {
 		var notFound *ekstypes.ResourceNotFoundException
 		if errors.As(err, &notFound) {
-			klog.Infof("nodegroup does not exist: %s", m.resourceID)
+			klog.Infof("nodegroup does not exist: %s", name)
 			return nil
 		}
 		return fmt.Errorf("failed to delete nodegroup: %v", err)
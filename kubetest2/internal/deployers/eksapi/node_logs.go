@@ -0,0 +1,144 @@
+package eksapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"k8s.io/klog/v2"
+)
+
+// defaultNodeLogsSampleSize is the default number of instances to collect the node logs from
+const defaultNodeLogsSampleSize = 3
+
+// ssmOutputLimit is the maximum number of characters of the command output returned by GetCommandInvocation
+const ssmOutputLimit = 24000
+
+type nodeLogSource struct {
+	fileName string
+	command  string
+}
+
+// nodeLogSources are collected with one command each, so that each output can use the full ssmOutputLimit.
+// the most recent output is kept, since the failures are usually at the end
+var nodeLogSources = []nodeLogSource{
+	{fileName: "cloud-init-output.log", command: "tail -c %d /var/log/cloud-init-output.log 2>&1"},
+	{fileName: "cloud-init.log", command: "tail -c %d /var/log/cloud-init.log 2>&1"},
+	{fileName: "kubelet.log", command: "journalctl -u kubelet --no-pager -o short-iso 2>&1 | tail -c %d"},
+	{fileName: "containerd.log", command: "journalctl -u containerd --no-pager -o short-iso 2>&1 | tail -c %d"},
+}
+
+// sampleInstances returns up to sampleSize instance IDs in a stable order
+func sampleInstances(instanceIds []string, sampleSize int) []string {
+	sorted := append([]string{}, instanceIds...)
+	sort.Strings(sorted)
+	if sampleSize < len(sorted) {
+		sorted = sorted[:sampleSize]
+	}
+	return sorted
+}
+
+// nodeLogPath returns the path of the log file for an instance in the artifacts directory
+func nodeLogPath(artifactsDir string, phase deployerPhase, instanceId string, fileName string) string {
+	return filepath.Join(artifactsDir, "node-logs", string(phase), instanceId, fileName)
+}
+
+// getClusterInstanceIDs returns the running instances tagged for the cluster, whether or not they joined the cluster
+func (m *logManager) getClusterInstanceIDs() ([]string, error) {
+	var instanceIds []string
+	paginator := ec2.NewDescribeInstancesPaginator(m.clients.EC2(), &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []string{fmt.Sprintf("kubernetes.io/cluster/%s", m.resourceID)},
+			},
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []string{string(ec2types.InstanceStateNameRunning)},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range out.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceIds = append(instanceIds, aws.ToString(instance.InstanceId))
+			}
+		}
+	}
+	return instanceIds, nil
+}
+
+// collectNodeLogs writes the cloud-init, kubelet, and containerd logs of a sample of the cluster instances
+// to the artifacts directory using SSM, to triage the nodes that failed to join the cluster
+func (m *logManager) collectNodeLogs(opts *deployerOptions, phase deployerPhase, artifactsDir string) error {
+	sampleSize := opts.NodeLogsSampleSize
+	if sampleSize == 0 {
+		sampleSize = defaultNodeLogsSampleSize
+	}
+	if sampleSize < 0 {
+		klog.Info("--node-logs-sample-size is negative, no node logs will be collected")
+		return nil
+	}
+	if opts.AutoMode {
+		klog.Info("EKS Auto Mode instances do not allow SSM commands, no node logs will be collected")
+		return nil
+	}
+	allInstanceIds, err := m.getClusterInstanceIDs()
+	if err != nil {
+		return fmt.Errorf("failed to list cluster instances: %v", err)
+	}
+	if len(allInstanceIds) == 0 {
+		klog.Info("no cluster instances to collect node logs from")
+		return nil
+	}
+	instanceIds := sampleInstances(allInstanceIds, sampleSize)
+	klog.Infof("collecting node logs from %d of %d instance(s): %v", len(instanceIds), len(allInstanceIds), instanceIds)
+	var errs []error
+	for _, source := range nodeLogSources {
+		command, err := m.clients.SSM().SendCommand(context.TODO(), &ssm.SendCommandInput{
+			DocumentName: aws.String("AWS-RunShellScript"),
+			InstanceIds:  instanceIds,
+			Parameters: map[string][]string{
+				"commands": {fmt.Sprintf(source.command, ssmOutputLimit)},
+			},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to send command for %s: %v", source.fileName, err))
+			continue
+		}
+		for _, instanceId := range instanceIds {
+			out, err := ssm.NewCommandExecutedWaiter(m.clients.SSM()).WaitForOutput(context.TODO(), &ssm.GetCommandInvocationInput{
+				CommandId:  command.Command.CommandId,
+				InstanceId: aws.String(instanceId),
+			}, logCollectorSsmDocumentTimeout)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to collect %s from %s: %v", source.fileName, instanceId, err))
+				continue
+			}
+			path := nodeLogPath(artifactsDir, phase, instanceId, source.fileName)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := os.WriteFile(path, []byte(aws.ToString(out.StandardOutputContent)), 0644); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	klog.Infof("collected node logs to: %s", filepath.Join(artifactsDir, "node-logs", string(phase)))
+	return nil
+}
@@ -0,0 +1,19 @@
+package eksapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sampleInstances(t *testing.T) {
+	instanceIds := []string{"i-3", "i-1", "i-2"}
+	assert.Equal(t, []string{"i-1", "i-2"}, sampleInstances(instanceIds, 2))
+	assert.Equal(t, []string{"i-1", "i-2", "i-3"}, sampleInstances(instanceIds, 5))
+	// the input is not modified
+	assert.Equal(t, []string{"i-3", "i-1", "i-2"}, instanceIds)
+}
+
+func Test_nodeLogPath(t *testing.T) {
+	assert.Equal(t, "/artifacts/node-logs/up/i-1/kubelet.log", nodeLogPath("/artifacts", deployerPhaseUp, "i-1", "kubelet.log"))
+}
@@ -0,0 +1,290 @@
+package eksapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cloudformationtypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/deployers/eksapi/templates"
+)
+
+const (
+	bastionOnlineTimeout = time.Minute * 10
+	tunnelReadyTimeout   = time.Minute * 2
+
+	// portForwardingDocument forwards a local port to a remote host through the SSM target
+	// ref. https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-sessions-start.html#sessions-remote-port-forwarding
+	portForwardingDocument = "AWS-StartPortForwardingSessionToRemoteHost"
+)
+
+// privateEndpointBinaries are required on the PATH to start the SSM port forwarding session
+var privateEndpointBinaries = []string{"aws", "session-manager-plugin"}
+
+// bastionManager manages the bastion instance in the cluster VPC,
+// which is the SSM target to reach the private cluster endpoint.
+type bastionManager struct {
+	clients    *awsClients
+	resourceID string
+}
+
+func NewBastionManager(clients *awsClients, resourceID string) *bastionManager {
+	return &bastionManager{
+		clients:    clients,
+		resourceID: resourceID,
+	}
+}
+
+func (m *bastionManager) getBastionStackName() string {
+	return fmt.Sprintf("%s-bastion", m.resourceID)
+}
+
+// createBastion creates the bastion stack, and returns the bastion instance ID once it's online in SSM.
+// The bastion is in the cluster security group, which is allowed to reach the private cluster endpoint.
+func (m *bastionManager) createBastion(infra *Infrastructure, cluster *Cluster) (string, error) {
+	stackName := m.getBastionStackName()
+	klog.Infof("creating bastion stack...")
+	input := cloudformation.CreateStackInput{
+		StackName:    aws.String(stackName),
+		TemplateBody: aws.String(templates.Bastion),
+		Capabilities: []cloudformationtypes.Capability{cloudformationtypes.CapabilityCapabilityIam},
+		Parameters: []cloudformationtypes.Parameter{
+			{
+				ParameterKey:   aws.String("ResourceId"),
+				ParameterValue: aws.String(m.resourceID),
+			},
+			{
+				ParameterKey:   aws.String("SubnetId"),
+				ParameterValue: aws.String(infra.subnetsPrivate[0]),
+			},
+			{
+				ParameterKey:   aws.String("SecurityGroup"),
+				ParameterValue: aws.String(cluster.securityGroupId),
+			},
+			{
+				ParameterKey:   aws.String("NodeRoleName"),
+				ParameterValue: aws.String(infra.nodeRoleName),
+			},
+		},
+	}
+	out, err := m.clients.CFN().CreateStack(context.TODO(), &input)
+	if err != nil {
+		return "", err
+	}
+	klog.Infof("waiting for bastion stack to be created: %s", *out.StackId)
+	stack, err := cloudformation.NewStackCreateCompleteWaiter(m.clients.CFN()).
+		WaitForOutput(context.TODO(),
+			&cloudformation.DescribeStacksInput{
+				StackName: out.StackId,
+			},
+			infraStackCreationTimeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for bastion stack creation: %w", err)
+	}
+	var instanceID string
+	for _, output := range stack.Stacks[0].Outputs {
+		if aws.ToString(output.OutputKey) == "InstanceId" {
+			instanceID = aws.ToString(output.OutputValue)
+		}
+	}
+	if instanceID == "" {
+		return "", fmt.Errorf("bastion stack has no InstanceId output: %s", stackName)
+	}
+	klog.Infof("created bastion stack: %s (instance: %s)", *out.StackId, instanceID)
+	if err := m.waitForBastionOnline(instanceID); err != nil {
+		return "", err
+	}
+	return instanceID, nil
+}
+
+// waitForBastionOnline waits for the SSM agent of the bastion to register and report online.
+func (m *bastionManager) waitForBastionOnline(instanceID string) error {
+	klog.Infof("waiting up to %v for bastion to be online in SSM: %s", bastionOnlineTimeout, instanceID)
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Second, bastionOnlineTimeout, true, func(ctx context.Context) (bool, error) {
+		out, err := m.clients.SSM().DescribeInstanceInformation(ctx, &ssm.DescribeInstanceInformationInput{
+			Filters: []ssmtypes.InstanceInformationStringFilter{
+				{
+					Key:    aws.String("InstanceIds"),
+					Values: []string{instanceID},
+				},
+			},
+		})
+		if err != nil {
+			klog.Warningf("failed to describe bastion instance information: %v", err)
+			return false, nil
+		}
+		for _, info := range out.InstanceInformationList {
+			if info.PingStatus == ssmtypes.PingStatusOnline {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for bastion to be online in SSM: %s: %v", instanceID, err)
+	}
+	klog.Infof("bastion is online in SSM: %s", instanceID)
+	return nil
+}
+
+func (m *bastionManager) deleteBastion() error {
+	stackName := m.getBastionStackName()
+	input := cloudformation.DeleteStackInput{
+		StackName: aws.String(stackName),
+	}
+	klog.Infof("deleting bastion stack: %s", stackName)
+	_, err := m.clients.CFN().DeleteStack(context.TODO(), &input)
+	if err != nil {
+		var notFound *cloudformationtypes.StackNotFoundException
+		if errors.As(err, &notFound) {
+			klog.Infof("bastion stack does not exist: %s", stackName)
+			return nil
+		}
+		return fmt.Errorf("failed to delete bastion stack: %w", err)
+	}
+	klog.Infof("waiting for bastion stack to be deleted: %s", stackName)
+	err = cloudformation.NewStackDeleteCompleteWaiter(m.clients.CFN()).
+		Wait(context.TODO(),
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(stackName),
+			},
+			infraStackDeletionTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to wait for bastion stack deletion: %w", err)
+	}
+	klog.Infof("deleted bastion stack: %s", stackName)
+	return nil
+}
+
+// verifyPrivateEndpointBinaries returns an error if any binary required for the tunnel is not on the PATH
+func verifyPrivateEndpointBinaries() error {
+	var missing []string
+	for _, bin := range privateEndpointBinaries {
+		if _, err := exec.LookPath(bin); err != nil {
+			missing = append(missing, bin)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("binaries not found on the PATH: %v", missing)
+	}
+	return nil
+}
+
+// endpointHost returns the host of the cluster endpoint URL
+func endpointHost(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse cluster endpoint %q: %v", endpoint, err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("cluster endpoint has no host: %q", endpoint)
+	}
+	return u.Hostname(), nil
+}
+
+// portForwardingArgs returns the AWS CLI arguments to forward the local port to port 443 of the host through the instance
+func portForwardingArgs(instanceID string, host string, localPort int) []string {
+	return []string{
+		"ssm", "start-session",
+		"--target", instanceID,
+		"--document-name", portForwardingDocument,
+		"--parameters", fmt.Sprintf("host=%s,portNumber=443,localPortNumber=%d", host, localPort),
+	}
+}
+
+// endpointTunnel is an SSM port forwarding session from a local port to the private cluster endpoint.
+// The API server certificate is issued for the endpoint host, so the clients must verify it with the TLS server name.
+type endpointTunnel struct {
+	host      string
+	localPort int
+	cmd       *exec.Cmd
+}
+
+// startEndpointTunnel starts the port forwarding session to the cluster endpoint through the bastion,
+// and waits for the local port to accept connections.
+func startEndpointTunnel(instanceID string, clusterEndpoint string) (*endpointTunnel, error) {
+	host, err := endpointHost(clusterEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	localPort, err := freeLocalPort()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find a free local port: %v", err)
+	}
+	t := &endpointTunnel{
+		host:      host,
+		localPort: localPort,
+		cmd:       exec.Command("aws", portForwardingArgs(instanceID, host, localPort)...),
+	}
+	t.cmd.Stdout = os.Stdout
+	t.cmd.Stderr = os.Stderr
+	klog.Infof("starting tunnel to private cluster endpoint %s through bastion %s on local port %d", host, instanceID, localPort)
+	if err := t.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start port forwarding session: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- t.cmd.Wait()
+	}()
+	err = wait.PollUntilContextTimeout(context.Background(), 2*time.Second, tunnelReadyTimeout, true, func(ctx context.Context) (bool, error) {
+		select {
+		case err := <-exited:
+			return false, fmt.Errorf("port forwarding session exited: %v", err)
+		default:
+		}
+		conn, err := net.DialTimeout("tcp", t.localAddress(), time.Second)
+		if err != nil {
+			return false, nil
+		}
+		conn.Close()
+		return true, nil
+	})
+	if err != nil {
+		t.stop()
+		return nil, fmt.Errorf("tunnel to private cluster endpoint is not ready: %v", err)
+	}
+	klog.Infof("tunnel to private cluster endpoint is ready: %s", t.endpoint())
+	return t, nil
+}
+
+func freeLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (t *endpointTunnel) localAddress() string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(t.localPort))
+}
+
+// endpoint returns the local endpoint URL of the tunnel, to use in place of the cluster endpoint
+func (t *endpointTunnel) endpoint() string {
+	return "https://" + t.localAddress()
+}
+
+// stop terminates the port forwarding session. It is a no-op if the tunnel is nil.
+func (t *endpointTunnel) stop() {
+	if t == nil || t.cmd.Process == nil {
+		return
+	}
+	klog.Infof("stopping tunnel to private cluster endpoint: %s", t.host)
+	if err := t.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		klog.Warningf("failed to stop port forwarding session: %v", err)
+	}
+}
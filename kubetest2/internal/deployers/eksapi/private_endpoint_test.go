@@ -0,0 +1,37 @@
+package eksapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_endpointHost(t *testing.T) {
+	host, err := endpointHost("https://ABCDEF0123456789.gr7.us-west-2.eks.amazonaws.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "ABCDEF0123456789.gr7.us-west-2.eks.amazonaws.com", host)
+
+	host, err = endpointHost("https://example.com:443/")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", host)
+
+	_, err = endpointHost("not-a-url")
+	assert.Error(t, err)
+}
+
+func Test_portForwardingArgs(t *testing.T) {
+	assert.Equal(t, []string{
+		"ssm", "start-session",
+		"--target", "i-0123456789abcdef0",
+		"--document-name", "AWS-StartPortForwardingSessionToRemoteHost",
+		"--parameters", "host=example.com,portNumber=443,localPortNumber=30443",
+	}, portForwardingArgs("i-0123456789abcdef0", "example.com", 30443))
+}
+
+func Test_endpointTunnel(t *testing.T) {
+	tunnel := &endpointTunnel{host: "example.com", localPort: 30443}
+	assert.Equal(t, "https://127.0.0.1:30443", tunnel.endpoint())
+
+	var stopped *endpointTunnel
+	stopped.stop()
+}
@@ -0,0 +1,77 @@
+package eksapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/util"
+)
+
+// maxNodeVersionSkew is the maximum number of minor versions
+// the kubelet may be older than the control plane.
+// https://kubernetes.io/releases/version-skew-policy/#kubelet
+const maxNodeVersionSkew = 3
+
+// skewedVersion returns the "major.minor" version that is skew minor versions older than the version.
+func skewedVersion(version string, skew int) (string, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("malformed version: '%s'", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed minor version: '%s': %v", version, err)
+	}
+	if skew < 0 || skew > maxNodeVersionSkew {
+		return "", fmt.Errorf("version skew must be between 0 and %d: %d", maxNodeVersionSkew, skew)
+	}
+	if minor-skew < 0 {
+		return "", fmt.Errorf("version %s cannot be skewed by %d minor versions", version, skew)
+	}
+	return fmt.Sprintf("%s.%d", parts[0], minor-skew), nil
+}
+
+// nodegroupVersions returns the Kubernetes versions of the managed nodegroups, in the order of the skews.
+// Without skews, a single nodegroup uses the cluster version.
+func nodegroupVersions(kubernetesVersion string, skews []int) ([]string, error) {
+	if len(skews) == 0 {
+		return []string{kubernetesVersion}, nil
+	}
+	var versions []string
+	seen := make(map[int]bool)
+	for _, skew := range skews {
+		if seen[skew] {
+			return nil, fmt.Errorf("duplicate version skew: %d", skew)
+		}
+		seen[skew] = true
+		version, err := skewedVersion(kubernetesVersion, skew)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// nodegroupName returns the name of the managed nodegroup for the version.
+// The nodegroup of a single-version cluster is named after the resource ID.
+func nodegroupName(resourceID string, version string, skewed bool) string {
+	if !skewed {
+		return resourceID
+	}
+	return resourceID + "-" + strings.ReplaceAll(version, ".", "-")
+}
+
+// writeVersionSkewMetadata records the control plane and node versions in the metadata.json of the artifacts directory.
+func writeVersionSkewMetadata(artifactsDir string, kubernetesVersion string, skews []int, nodeVersions []string) error {
+	var skewStrings []string
+	for _, skew := range skews {
+		skewStrings = append(skewStrings, strconv.Itoa(skew))
+	}
+	return util.WriteMetadata(artifactsDir, map[string]string{
+		"cluster-kubernetes-version": kubernetesVersion,
+		"node-kubernetes-versions":   strings.Join(nodeVersions, ","),
+		"node-version-skews":         strings.Join(skewStrings, ","),
+	})
+}
@@ -0,0 +1,58 @@
+package eksapi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_skewedVersion(t *testing.T) {
+	version, err := skewedVersion("1.30", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.28", version)
+	version, err = skewedVersion("1.30", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.30", version)
+	_, err = skewedVersion("1.30", maxNodeVersionSkew+1)
+	assert.Error(t, err)
+	_, err = skewedVersion("1.30", -1)
+	assert.Error(t, err)
+	_, err = skewedVersion("1", 1)
+	assert.Error(t, err)
+}
+
+func Test_nodegroupVersions(t *testing.T) {
+	versions, err := nodegroupVersions("1.30", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.30"}, versions)
+	versions, err = nodegroupVersions("1.30", []int{0, 1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.30", "1.29", "1.28"}, versions)
+	_, err = nodegroupVersions("1.30", []int{1, 1})
+	assert.Error(t, err)
+}
+
+func Test_nodegroupName(t *testing.T) {
+	assert.Equal(t, "kubetest2-eksapi-abc", nodegroupName("kubetest2-eksapi-abc", "1.30", false))
+	assert.Equal(t, "kubetest2-eksapi-abc-1-29", nodegroupName("kubetest2-eksapi-abc", "1.29", true))
+}
+
+func Test_writeVersionSkewMetadata(t *testing.T) {
+	artifactsDir := t.TempDir()
+	metadataPath := filepath.Join(artifactsDir, "metadata.json")
+	assert.NoError(t, os.WriteFile(metadataPath, []byte(`{"deployer-version":"v1"}`), 0644))
+	assert.NoError(t, writeVersionSkewMetadata(artifactsDir, "1.30", []int{0, 2}, []string{"1.30", "1.28"}))
+	b, err := os.ReadFile(metadataPath)
+	assert.NoError(t, err)
+	var meta map[string]string
+	assert.NoError(t, json.Unmarshal(b, &meta))
+	assert.Equal(t, map[string]string{
+		"deployer-version":           "v1",
+		"cluster-kubernetes-version": "1.30",
+		"node-kubernetes-versions":   "1.30,1.28",
+		"node-version-skews":         "0,2",
+	}, meta)
+}
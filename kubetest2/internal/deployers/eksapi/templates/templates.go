@@ -11,6 +11,9 @@ var Infrastructure string
 //go:embed unmanaged-nodegroup-efa.yaml
 var UnmanagedNodegroupEFA string
 
+//go:embed bastion.yaml
+var Bastion string
+
 var (
 	//go:embed unmanaged-nodegroup.yaml.template
 	unmanagedNodegroupTemplate string
@@ -58,6 +61,7 @@ type UserDataTemplateData struct {
 	CertificateAuthority string
 	CIDR                 string
 	APIServerEndpoint    string
+	IPFamily             string
 }
 
 var (
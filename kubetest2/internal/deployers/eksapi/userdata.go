@@ -29,6 +29,7 @@ func generateUserData(format string, cluster *Cluster) (string, bool, error) {
 		CertificateAuthority: cluster.certificateAuthorityData,
 		CIDR:                 cluster.cidr,
 		Name:                 cluster.name,
+		IPFamily:             cluster.ipFamily,
 	}); err != nil {
 		return "", false, err
 	}
@@ -22,6 +22,17 @@ MIME-Version: 1.0
   --apiserver-endpoint https://example.com
 `
 
+const bootstrapShIPv6UserData = `Content-Type: text/x-shellscript; charset="us-ascii"
+MIME-Version: 1.0
+
+#!/usr/bin/env bash
+/etc/eks/bootstrap.sh cluster \
+  --b64-cluster-ca certificateAuthority \
+  --apiserver-endpoint https://example.com \
+  --ip-family ipv6 \
+  --service-ipv6-cidr fd00:10:100::/108
+`
+
 const nodeadmUserData = `Content-Type: application/node.eks.aws
 MIME-Version: 1.0
 
@@ -78,3 +89,12 @@ func Test_generateUserData(t *testing.T) {
 		})
 	}
 }
+
+func Test_generateUserDataIPv6(t *testing.T) {
+	ipv6Cluster := cluster
+	ipv6Cluster.cidr = "fd00:10:100::/108"
+	ipv6Cluster.ipFamily = "ipv6"
+	actual, _, err := generateUserData("bootstrap.sh", &ipv6Cluster)
+	assert.NoError(t, err)
+	assert.Equal(t, bootstrapShIPv6UserData, actual)
+}
@@ -37,10 +37,50 @@ const vpcCNIDaemonSetPatch = `{
 	}
 }`
 
+// vpcCNIIPv6DaemonSetPatch configures the VPC CNI to assign IPv6 addresses to pods,
+// which requires prefix delegation
+const vpcCNIIPv6DaemonSetPatch = `{
+	"spec": {
+		"template": {
+			"spec": {
+				"containers": [
+					{
+						"name": "aws-node",
+						"env": [
+							{
+								"name": "ENABLE_IPv6",
+								"value": "true"
+							},
+							{
+								"name": "ENABLE_IPv4",
+								"value": "false"
+							},
+							{
+								"name": "ENABLE_PREFIX_DELEGATION",
+								"value": "true"
+							}
+						]
+					}
+				]
+			}
+		}
+	}
+}`
+
 // tuneVPCCNI applies configuration to the VPC CNI DaemonSet that helps prevent test flakiness
 func (k *k8sClient) tuneVPCCNI() error {
+	return k.patchVPCCNI(vpcCNIDaemonSetPatch)
+}
+
+// configureVPCCNIForIPv6 makes sure the VPC CNI DaemonSet runs in IPv6 mode,
+// in case it was not configured for the IPv6 cluster (e.g. a self-managed VPC CNI)
+func (k *k8sClient) configureVPCCNIForIPv6() error {
+	return k.patchVPCCNI(vpcCNIIPv6DaemonSetPatch)
+}
+
+func (k *k8sClient) patchVPCCNI(daemonSetPatch string) error {
 	var patch bytes.Buffer
-	if err := json.Compact(&patch, []byte(vpcCNIDaemonSetPatch)); err != nil {
+	if err := json.Compact(&patch, []byte(daemonSetPatch)); err != nil {
 		return err
 	}
 	_, err := k.clientset.AppsV1().DaemonSets("kube-system").Patch(context.TODO(), "aws-node", types.StrategicMergePatchType, patch.Bytes(), metav1.PatchOptions{})
@@ -10,4 +10,7 @@ func Test_validVPCCNIDaemonSetPatch(t *testing.T) {
 	if err := json.Unmarshal([]byte(vpcCNIDaemonSetPatch), &j); err != nil {
 		t.Error(err)
 	}
+	if err := json.Unmarshal([]byte(vpcCNIIPv6DaemonSetPatch), &j); err != nil {
+		t.Error(err)
+	}
 }
@@ -2,8 +2,13 @@ package eksctl
 
 import (
 	"bytes"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 	"text/template"
+
+	"sigs.k8s.io/yaml"
 )
 
 const configYAMLTemplate = `
@@ -17,7 +22,7 @@ metadata:
   version: "{{.KubernetesVersion}}"
   {{- end}}
 managedNodeGroups:
-  - name: managed
+  - name: "{{.NodegroupName}}"
     {{- if .AMI}}
     ami: "{{.AMI}}"
     {{- end}}
@@ -27,6 +32,24 @@ managedNodeGroups:
       {{- range $instanceType := .InstanceTypes}}
       - "{{$instanceType}}"
       {{- end}}
+    {{- end}}
+    {{- if .InstanceSelectorEnabled}}
+    instanceSelector:
+      {{- if gt .InstanceSelectorVCPUs 0}}
+      vCPUs: {{.InstanceSelectorVCPUs}}
+      {{- end}}
+      {{- if .InstanceSelectorMemory}}
+      memory: "{{.InstanceSelectorMemory}}"
+      {{- end}}
+      {{- if .InstanceSelectorCPUArchitecture}}
+      cpuArchitecture: "{{.InstanceSelectorCPUArchitecture}}"
+      {{- end}}
+      {{- if gt .InstanceSelectorGPUs 0}}
+      gpus: {{.InstanceSelectorGPUs}}
+      {{- end}}
+    {{- end}}
+    {{- if .Spot}}
+    spot: true
     {{- end}}
 	{{- if gt .Nodes 0}}
     minSize: {{.Nodes}}
@@ -43,15 +66,17 @@ managedNodeGroups:
 
 type clusterConfigTemplateParams struct {
 	UpOptions
-	ClusterName string
-	Region      string
+	ClusterName   string
+	Region        string
+	NodegroupName string
 }
 
 func (d *deployer) RenderClusterConfig() ([]byte, error) {
 	templateParams := clusterConfigTemplateParams{
-		UpOptions:   *d.UpOptions,
-		ClusterName: d.commonOptions.RunID(),
-		Region:      d.awsConfig.Region,
+		UpOptions:     *d.UpOptions,
+		ClusterName:   d.commonOptions.RunID(),
+		Region:        d.awsConfig.Region,
+		NodegroupName: managedNodegroupName,
 	}
 	log.Printf("rendering cluster config template with params: %+v", templateParams)
 	t, err := template.New("configYAML").Parse(configYAMLTemplate)
@@ -63,5 +88,128 @@ func (d *deployer) RenderClusterConfig() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	if d.ClusterConfigPath == "" {
+		return buf.Bytes(), nil
+	}
+	overlay, err := os.ReadFile(d.ClusterConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --cluster-config-path: %v", err)
+	}
+	log.Printf("merging cluster config from %s", d.ClusterConfigPath)
+	return mergeClusterConfig(buf.Bytes(), overlay)
+}
+
+// identityFields are the fields of the ClusterConfig that the deployer relies on
+// to manage the cluster, and that can't be changed by the overlay.
+var identityFields = [][]string{
+	{"apiVersion"},
+	{"kind"},
+	{"metadata", "name"},
+	{"metadata", "region"},
+}
+
+// mergeClusterConfig merges the overlay ClusterConfig onto the generated ClusterConfig.
+// The overlay may be a full ClusterConfig or a partial one, merged with the JSON merge patch
+// semantics (RFC 7386): objects are merged recursively, a null value removes the field,
+// and other values replace the generated ones.
+// Lists of named objects (e.g. "managedNodeGroups") are merged by "name" instead of replaced,
+// and the objects with new names are appended.
+func mergeClusterConfig(generated []byte, overlay []byte) ([]byte, error) {
+	var base, patch map[string]interface{}
+	if err := yaml.Unmarshal(generated, &base); err != nil {
+		return nil, fmt.Errorf("failed to parse generated cluster config: %v", err)
+	}
+	if err := yaml.Unmarshal(overlay, &patch); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster config overlay: %v", err)
+	}
+	if len(patch) == 0 {
+		return nil, fmt.Errorf("cluster config overlay is empty")
+	}
+	for _, field := range identityFields {
+		overlayValue, ok := lookupField(patch, field)
+		if !ok {
+			continue
+		}
+		baseValue, _ := lookupField(base, field)
+		if overlayValue != baseValue {
+			return nil, fmt.Errorf("cluster config overlay cannot change %s from %v to %v", strings.Join(field, "."), baseValue, overlayValue)
+		}
+	}
+	return yaml.Marshal(mergeValue(base, patch))
+}
+
+// mergeValue returns the overlay value merged onto the base value.
+func mergeValue(base interface{}, overlay interface{}) interface{} {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		b, _ := base.(map[string]interface{})
+		merged := make(map[string]interface{}, len(b)+len(o))
+		for k, v := range b {
+			merged[k] = v
+		}
+		for k, v := range o {
+			if v == nil {
+				delete(merged, k)
+				continue
+			}
+			merged[k] = mergeValue(merged[k], v)
+		}
+		return merged
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok || !isNamedList(b) || !isNamedList(o) {
+			return o
+		}
+		merged := make([]interface{}, len(b))
+		copy(merged, b)
+		for _, item := range o {
+			name := item.(map[string]interface{})["name"]
+			found := false
+			for i := range merged {
+				if merged[i].(map[string]interface{})["name"] == name {
+					merged[i] = mergeValue(merged[i], item)
+					found = true
+					break
+				}
+			}
+			if !found {
+				merged = append(merged, mergeValue(nil, item))
+			}
+		}
+		return merged
+	default:
+		return overlay
+	}
+}
+
+// isNamedList returns true if all items of the non-empty list are objects with a string "name".
+func isNamedList(l []interface{}) bool {
+	if len(l) == 0 {
+		return false
+	}
+	for _, item := range l {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupField returns the value of the nested field, if any.
+func lookupField(obj map[string]interface{}, field []string) (interface{}, bool) {
+	var cur interface{} = obj
+	for _, key := range field {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
 }
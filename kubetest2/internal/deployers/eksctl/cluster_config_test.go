@@ -0,0 +1,115 @@
+package eksctl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+const generatedClusterConfig = `
+---
+apiVersion: eksctl.io/v1alpha5
+kind: ClusterConfig
+metadata:
+  name: "kt2-abc"
+  region: "us-west-2"
+  version: "1.31"
+managedNodeGroups:
+  - name: "managed"
+    amiFamily: AmazonLinux2
+    instanceTypes:
+      - "m5.large"
+    minSize: 4
+    maxSize: 4
+    desiredCapacity: 4
+`
+
+func Test_mergeClusterConfig(t *testing.T) {
+	overlay := `
+metadata:
+  tags:
+    team: node
+iam:
+  withOIDC: true
+managedNodeGroups:
+  - name: managed
+    amiFamily: AmazonLinux2023
+    instanceTypes:
+      - m6i.large
+    minSize: null
+  - name: gpu
+    instanceTypes:
+      - g5.xlarge
+addons:
+  - name: vpc-cni
+`
+	merged, err := mergeClusterConfig([]byte(generatedClusterConfig), []byte(overlay))
+	assert.NoError(t, err)
+	var actual map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(merged, &actual))
+	var expected map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+apiVersion: eksctl.io/v1alpha5
+kind: ClusterConfig
+metadata:
+  name: kt2-abc
+  region: us-west-2
+  version: "1.31"
+  tags:
+    team: node
+iam:
+  withOIDC: true
+managedNodeGroups:
+  - name: managed
+    amiFamily: AmazonLinux2023
+    instanceTypes:
+      - m6i.large
+    maxSize: 4
+    desiredCapacity: 4
+  - name: gpu
+    instanceTypes:
+      - g5.xlarge
+addons:
+  - name: vpc-cni
+`), &expected))
+	assert.Equal(t, expected, actual)
+}
+
+func Test_mergeClusterConfig_fullConfig(t *testing.T) {
+	overlay := `
+apiVersion: eksctl.io/v1alpha5
+kind: ClusterConfig
+metadata:
+  name: kt2-abc
+  region: us-west-2
+  version: "1.32"
+managedNodeGroups: []
+nodeGroups:
+  - name: self-managed
+    desiredCapacity: 2
+`
+	merged, err := mergeClusterConfig([]byte(generatedClusterConfig), []byte(overlay))
+	assert.NoError(t, err)
+	var actual map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(merged, &actual))
+	assert.Equal(t, "1.32", actual["metadata"].(map[string]interface{})["version"])
+	assert.Empty(t, actual["managedNodeGroups"])
+	assert.Len(t, actual["nodeGroups"], 1)
+}
+
+func Test_mergeClusterConfig_identity(t *testing.T) {
+	for _, overlay := range []string{
+		"metadata:\n  name: other\n",
+		"metadata:\n  region: us-east-1\n",
+		"metadata:\n  name: null\n",
+		"kind: Other\n",
+	} {
+		_, err := mergeClusterConfig([]byte(generatedClusterConfig), []byte(overlay))
+		assert.Error(t, err, overlay)
+	}
+	_, err := mergeClusterConfig([]byte(generatedClusterConfig), []byte(""))
+	assert.Error(t, err)
+	_, err = mergeClusterConfig([]byte(generatedClusterConfig), []byte("metadata: ["))
+	assert.Error(t, err)
+}
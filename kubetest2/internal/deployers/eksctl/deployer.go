@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-k8s-tester/kubetest2/internal"
 	"github.com/aws/aws-k8s-tester/kubetest2/internal/awssdk"
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/kubeconfig"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/octago/sflags/gen/gpflag"
@@ -24,6 +25,10 @@ type deployer struct {
 	awsConfig      aws.Config
 	eksClient      *eks.Client
 	KubeconfigPath string `flag:"kubeconfig" desc:"Path to kubeconfig"`
+	KubeconfigAuth string `flag:"kubeconfig-auth" desc:"Auth mode of the written kubeconfig. Allowed values: ['aws-cli', 'aws-iam-authenticator', 'token-file']. If empty, the kubeconfig written by eksctl is used"`
+
+	// tokenRefresher refreshes the kubeconfig token file until "Down"
+	tokenRefresher kubeconfig.TokenRefresher
 }
 
 // NewDeployer implements deployer.New for EKS using eksctl
@@ -39,10 +44,6 @@ func NewDeployer(opts types.Options) (types.Deployer, *pflag.FlagSet) {
 	return d, bindFlags(d)
 }
 
-func (d *deployer) DumpClusterLogs() error {
-	return nil
-}
-
 func (d *deployer) Kubeconfig() (string, error) {
 	if d.KubeconfigPath != "" {
 		return d.KubeconfigPath, nil
@@ -6,6 +6,7 @@ import (
 )
 
 func (d *deployer) Down() error {
+	defer d.tokenRefresher.Stop()
 	klog.Infof("deleting cluster %s", d.commonOptions.RunID())
 	err := util.ExecuteCommand("eksctl", "delete", "cluster", "--name", d.commonOptions.RunID(), "--wait")
 	if err != nil {
@@ -0,0 +1,75 @@
+package eksctl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/clusterlogs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"k8s.io/klog"
+	"sigs.k8s.io/kubetest2/pkg/artifacts"
+)
+
+// eksctlClusterNameTag is the tag of the CloudFormation stacks created by eksctl for a cluster
+const eksctlClusterNameTag = "alpha.eksctl.io/cluster-name"
+
+// DumpClusterLogs writes the control plane logs, the kube-system resources and logs, and the events
+// of the eksctl CloudFormation stacks to the artifacts directory. Failures are logged, since the dump is best effort.
+// The control plane logs are only available if enabled in the cluster config (e.g. "cloudWatch.clusterLogging"
+// in --cluster-config-path).
+func (d *deployer) DumpClusterLogs() error {
+	clusterName := d.commonOptions.RunID()
+	dir := filepath.Join(artifacts.BaseDir(), clusterlogs.DirName)
+	cfnClient := cloudformation.NewFromConfig(d.awsConfig)
+	if stackNames, err := eksctlStackNames(cfnClient, clusterName); err != nil {
+		klog.Warningf("failed to list eksctl stacks: %v", err)
+	} else if err := clusterlogs.DumpStackEvents(cfnClient, stackNames, dir); err != nil {
+		klog.Warningf("failed to dump stack events: %v", err)
+	}
+	if err := clusterlogs.DumpControlPlaneLogs(d.awsConfig.Region, clusterName, time.Time{}, dir); err != nil {
+		klog.Warningf("failed to dump control plane logs: %v", err)
+	}
+	kubeconfigPath, err := d.Kubeconfig()
+	if err != nil {
+		klog.Warningf("failed to get kubeconfig: %v", err)
+		return nil
+	}
+	// eksctl writes the kubeconfig once the cluster is created
+	if _, err := os.Stat(kubeconfigPath); err != nil {
+		klog.Infof("skipping kube-system dump, no kubeconfig: %v", err)
+		return nil
+	}
+	clientset, err := clusterlogs.NewClientset(kubeconfigPath)
+	if err != nil {
+		klog.Warningf("failed to create kubernetes client: %v", err)
+		return nil
+	}
+	if err := clusterlogs.DumpKubeSystem(clientset, dir); err != nil {
+		klog.Warningf("failed to dump kube-system: %v", err)
+	}
+	return nil
+}
+
+// eksctlStackNames returns the names of the stacks created by eksctl for the cluster
+func eksctlStackNames(client *cloudformation.Client, clusterName string) ([]string, error) {
+	var stackNames []string
+	paginator := cloudformation.NewDescribeStacksPaginator(client, &cloudformation.DescribeStacksInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, stack := range page.Stacks {
+			for _, tag := range stack.Tags {
+				if aws.ToString(tag.Key) == eksctlClusterNameTag && aws.ToString(tag.Value) == clusterName {
+					stackNames = append(stackNames, aws.ToString(stack.StackName))
+					break
+				}
+			}
+		}
+	}
+	return stackNames, nil
+}
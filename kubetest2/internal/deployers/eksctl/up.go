@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/aws/aws-k8s-tester/kubetest2/internal/kubeconfig"
 	"github.com/aws/aws-k8s-tester/kubetest2/internal/util"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"k8s.io/klog"
+	"sigs.k8s.io/kubetest2/pkg/artifacts"
 )
 
 type UpOptions struct {
@@ -18,8 +23,25 @@ type UpOptions struct {
 	Nodes             int      `flag:"nodes" desc:"number of nodes to launch in cluster"`
 	AMI               string   `flag:"ami" desc:"Node AMI"`
 	InstanceTypes     []string `flag:"instance-types" desc:"Node instance types"`
+	Spot              bool     `flag:"spot" desc:"Use Spot capacity for the managed nodegroup"`
+
+	InstanceSelectorVCPUs           int    `flag:"instance-selector-vcpus" desc:"Number of vCPUs to select the node instance types by. Cannot be used with --instance-types"`
+	InstanceSelectorMemory          string `flag:"instance-selector-memory" desc:"Memory (e.g. '16' or '16GiB') to select the node instance types by. Cannot be used with --instance-types"`
+	InstanceSelectorCPUArchitecture string `flag:"instance-selector-cpu-architecture" desc:"CPU architecture to select the node instance types by. Allowed values: ['x86_64', 'arm64']. Cannot be used with --instance-types"`
+	InstanceSelectorGPUs            int    `flag:"instance-selector-gpus" desc:"Number of GPUs to select the node instance types by. Cannot be used with --instance-types"`
+
+	ClusterConfigPath string `flag:"cluster-config-path" desc:"Path to an eksctl ClusterConfig YAML, full or partial, merged onto the generated cluster config before creating the cluster. Lists of named objects (e.g. managedNodeGroups) are merged by name"`
 }
 
+// InstanceSelectorEnabled returns true if the node instance types are selected by eksctl
+// from the vCPU, memory, CPU architecture or GPU criteria.
+func (o UpOptions) InstanceSelectorEnabled() bool {
+	return o.InstanceSelectorVCPUs > 0 || o.InstanceSelectorMemory != "" || o.InstanceSelectorCPUArchitecture != "" || o.InstanceSelectorGPUs > 0
+}
+
+// managedNodegroupName is the name of the managed nodegroup in the cluster config.
+const managedNodegroupName = "managed"
+
 func (d *deployer) verifyUpFlags() error {
 	if d.KubernetesVersion == "" {
 		klog.Infof("--kubernetes-version is empty, attempting to detect it...")
@@ -33,6 +55,25 @@ func (d *deployer) verifyUpFlags() error {
 	if d.Nodes < 0 {
 		return fmt.Errorf("number of nodes must be greater than zero")
 	}
+	if d.InstanceSelectorVCPUs < 0 || d.InstanceSelectorGPUs < 0 {
+		return fmt.Errorf("--instance-selector-vcpus and --instance-selector-gpus must not be negative")
+	}
+	switch d.InstanceSelectorCPUArchitecture {
+	case "", "x86_64", "arm64":
+	default:
+		return fmt.Errorf("unknown --instance-selector-cpu-architecture: %s", d.InstanceSelectorCPUArchitecture)
+	}
+	if d.InstanceSelectorEnabled() && len(d.InstanceTypes) > 0 {
+		return fmt.Errorf("--instance-types cannot be used with the --instance-selector-* flags")
+	}
+	if err := kubeconfig.ValidateAuthMode(d.KubeconfigAuth); err != nil {
+		return err
+	}
+	if d.ClusterConfigPath != "" {
+		if _, err := os.Stat(d.ClusterConfigPath); err != nil {
+			return fmt.Errorf("--cluster-config-path is invalid: %v", err)
+		}
+	}
 	if d.Nodes == 0 {
 		d.Nodes = 4
 		klog.V(2).Infof("Using default number of nodes: %d", d.Nodes)
@@ -45,7 +86,7 @@ func (d *deployer) Up() error {
 		return fmt.Errorf("up flags are invalid: %v", err)
 	}
 	klog.Infof("creating cluster: %s", d.commonOptions.RunID())
-	kubeconfig, err := d.Kubeconfig()
+	kubeconfigPath, err := d.Kubeconfig()
 	if err != nil {
 		return err
 	}
@@ -69,15 +110,71 @@ func (d *deployer) Up() error {
 		"--install-nvidia-plugin=false",
 		"--install-neuron-plugin=false",
 		"--config-file", clusterConfigFile.Name(),
-		"--kubeconfig", kubeconfig,
+		"--kubeconfig", kubeconfigPath,
 	}
 	err = util.ExecuteCommand("eksctl", args...)
 	if err != nil {
 		return fmt.Errorf("failed to create cluster: %v", err)
 	}
+	if err := d.writeNodegroupMetadata(); err != nil {
+		klog.Warningf("failed to record nodegroup in metadata: %v", err)
+		// don't return err, this isn't critical
+	}
+	if d.KubeconfigAuth != "" {
+		if err := d.writeKubeconfig(kubeconfigPath); err != nil {
+			return fmt.Errorf("failed to write kubeconfig: %v", err)
+		}
+	}
 	return nil
 }
 
+// writeKubeconfig overwrites the kubeconfig written by eksctl with the selected auth mode.
+func (d *deployer) writeKubeconfig(kubeconfigPath string) error {
+	clusterName := d.commonOptions.RunID()
+	result, err := d.eksClient.DescribeCluster(context.TODO(), &eks.DescribeClusterInput{
+		Name: aws.String(clusterName),
+	})
+	if err != nil {
+		return err
+	}
+	klog.Infof("writing kubeconfig to %s for cluster: %s (auth: %s)", kubeconfigPath, clusterName, d.KubeconfigAuth)
+	params := kubeconfig.Parameters{
+		ClusterCertificateAuthority: aws.ToString(result.Cluster.CertificateAuthority.Data),
+		ClusterARN:                  aws.ToString(result.Cluster.Arn),
+		ClusterEndpoint:             aws.ToString(result.Cluster.Endpoint),
+		ClusterName:                 clusterName,
+		AuthMode:                    d.KubeconfigAuth,
+	}
+	if d.KubeconfigAuth == kubeconfig.AuthTokenFile {
+		stsClient := sts.NewFromConfig(d.awsConfig)
+		params.TokenFile = filepath.Join(filepath.Dir(kubeconfigPath), "token")
+		if err := kubeconfig.WriteTokenFile(context.TODO(), stsClient, clusterName, params.TokenFile); err != nil {
+			return err
+		}
+		d.tokenRefresher.Start(stsClient, clusterName, params.TokenFile)
+	}
+	return kubeconfig.Write(params, kubeconfigPath)
+}
+
+// writeNodegroupMetadata records the instance types and the capacity type of the managed nodegroup
+// (e.g. the instance types chosen by the instance selector) in the metadata.json of the artifacts directory,
+// to correlate the results with the instance types.
+func (d *deployer) writeNodegroupMetadata() error {
+	result, err := d.eksClient.DescribeNodegroup(context.TODO(), &eks.DescribeNodegroupInput{
+		ClusterName:   aws.String(d.commonOptions.RunID()),
+		NodegroupName: aws.String(managedNodegroupName),
+	})
+	if err != nil {
+		return err
+	}
+	instanceTypes := strings.Join(result.Nodegroup.InstanceTypes, ",")
+	klog.Infof("nodegroup %s uses instance types: %s (capacity type: %s)", managedNodegroupName, instanceTypes, result.Nodegroup.CapacityType)
+	return util.WriteMetadata(artifacts.BaseDir(), map[string]string{
+		"node-instance-types": instanceTypes,
+		"node-capacity-type":  string(result.Nodegroup.CapacityType),
+	})
+}
+
 func (d *deployer) IsUp() (up bool, err error) {
 	result, err := d.eksClient.DescribeCluster(context.TODO(), &eks.DescribeClusterInput{
 		Name: aws.String(d.commonOptions.RunID()),
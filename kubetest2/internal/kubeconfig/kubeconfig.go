@@ -0,0 +1,136 @@
+// Package kubeconfig writes the kubeconfig of an EKS cluster with the selected auth mode,
+// since the binaries available for the exec plugins differ across CI images.
+package kubeconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/template"
+
+	"k8s.io/klog"
+)
+
+// Auth modes of the kubeconfig user.
+const (
+	// AuthAWSCLI uses the "aws eks get-token" exec plugin.
+	AuthAWSCLI = "aws-cli"
+	// AuthIAMAuthenticator uses the "aws-iam-authenticator token" exec plugin.
+	AuthIAMAuthenticator = "aws-iam-authenticator"
+	// AuthTokenFile uses a token file written (and refreshed) by the deployer,
+	// for the environments without any of the exec plugin binaries.
+	AuthTokenFile = "token-file"
+)
+
+// SupportedAuthModes is the list of the supported auth modes.
+var SupportedAuthModes = []string{AuthAWSCLI, AuthIAMAuthenticator, AuthTokenFile}
+
+// ValidateAuthMode returns an error if the auth mode is not supported.
+// An empty auth mode is valid, and defaults to AuthAWSCLI.
+func ValidateAuthMode(mode string) error {
+	if mode == "" {
+		return nil
+	}
+	for _, m := range SupportedAuthModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported kubeconfig auth mode %q, must be one of %v", mode, SupportedAuthModes)
+}
+
+const kubeconfigPerm = 0666
+
+var kubeconfigTemplate = `---
+apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: {{ .ClusterCertificateAuthority }}
+    server: {{ .ClusterEndpoint }}
+{{- if .TLSServerName }}
+    tls-server-name: {{ .TLSServerName }}
+{{- end }}
+  name: {{ .ClusterARN }}
+contexts:
+- context:
+    cluster: {{ .ClusterARN }}
+    user: {{ .ClusterARN }}
+  name: {{ .ClusterARN }}
+current-context: {{ .ClusterARN }}
+preferences: {}
+users:
+- name: {{ .ClusterARN }}
+  user:
+{{- if eq .AuthMode "token-file" }}
+    tokenFile: {{ .TokenFile }}
+{{- else if eq .AuthMode "aws-iam-authenticator" }}
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws-iam-authenticator
+      args:
+      - token
+      - --cluster-id
+      - {{ .ClusterName }}
+{{- else }}
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+      args:
+      - eks
+      - get-token
+      - --cluster-name
+      - {{ .ClusterName }}
+{{- end }}
+`
+
+// Parameters is the cluster information to write the kubeconfig.
+type Parameters struct {
+	ClusterCertificateAuthority string
+	ClusterARN                  string
+	ClusterEndpoint             string
+	ClusterName                 string
+	// TLSServerName is the server name to verify the API server certificate,
+	// when ClusterEndpoint is a tunnel to the cluster endpoint.
+	TLSServerName string
+
+	// AuthMode is the kubeconfig auth mode. Defaults to AuthAWSCLI.
+	AuthMode string
+	// TokenFile is the token file path, required for AuthTokenFile.
+	TokenFile string
+}
+
+// Render returns the kubeconfig contents.
+func Render(p Parameters) ([]byte, error) {
+	if err := ValidateAuthMode(p.AuthMode); err != nil {
+		return nil, err
+	}
+	if p.AuthMode == "" {
+		p.AuthMode = AuthAWSCLI
+	}
+	if p.AuthMode == AuthTokenFile && p.TokenFile == "" {
+		return nil, fmt.Errorf("token file is required for kubeconfig auth mode %q", AuthTokenFile)
+	}
+	t, err := template.New("kubeconfig").Parse(kubeconfigTemplate)
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig := bytes.Buffer{}
+	if err := t.Execute(&kubeconfig, p); err != nil {
+		return nil, err
+	}
+	return kubeconfig.Bytes(), nil
+}
+
+// Write renders and writes the kubeconfig to the path.
+func Write(p Parameters, kubeconfigPath string) error {
+	kubeconfig, err := Render(p)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(kubeconfigPath, kubeconfig, kubeconfigPerm); err != nil {
+		return err
+	}
+	klog.Infof("wrote kubeconfig: %s\n%s", kubeconfigPath, string(kubeconfig))
+	return nil
+}
@@ -0,0 +1,89 @@
+package kubeconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Render(t *testing.T) {
+	params := Parameters{
+		ClusterCertificateAuthority: "mock-ca",
+		ClusterARN:                  "mock-arn",
+		ClusterEndpoint:             "https://mock-endpoint",
+		ClusterName:                 "mock-name",
+	}
+	cases := []struct {
+		authMode    string
+		tokenFile   string
+		contains    []string
+		notContains []string
+	}{
+		{
+			authMode:    "",
+			contains:    []string{"command: aws\n", "- get-token", "- mock-name"},
+			notContains: []string{"tokenFile"},
+		},
+		{
+			authMode:    AuthAWSCLI,
+			contains:    []string{"command: aws\n", "- get-token", "- mock-name"},
+			notContains: []string{"tokenFile"},
+		},
+		{
+			authMode:    AuthIAMAuthenticator,
+			contains:    []string{"command: aws-iam-authenticator", "- --cluster-id", "- mock-name"},
+			notContains: []string{"get-token", "tokenFile"},
+		},
+		{
+			authMode:    AuthTokenFile,
+			tokenFile:   "/tmp/token",
+			contains:    []string{"tokenFile: /tmp/token"},
+			notContains: []string{"exec:"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.authMode, func(t *testing.T) {
+			p := params
+			p.AuthMode = c.authMode
+			p.TokenFile = c.tokenFile
+			kubeconfig, err := Render(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range c.contains {
+				assert.Contains(t, string(kubeconfig), s)
+			}
+			for _, s := range c.notContains {
+				assert.NotContains(t, string(kubeconfig), s)
+			}
+			assert.Contains(t, string(kubeconfig), "server: https://mock-endpoint")
+		})
+	}
+}
+
+func Test_RenderTLSServerName(t *testing.T) {
+	p := Parameters{
+		ClusterCertificateAuthority: "mock-ca",
+		ClusterARN:                  "mock-arn",
+		ClusterEndpoint:             "https://127.0.0.1:8443",
+		ClusterName:                 "mock-name",
+	}
+	kubeconfig, err := Render(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, string(kubeconfig), "tls-server-name")
+	p.TLSServerName = "mock-endpoint"
+	kubeconfig, err = Render(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(kubeconfig), "    server: https://127.0.0.1:8443\n    tls-server-name: mock-endpoint\n")
+}
+
+func Test_RenderInvalid(t *testing.T) {
+	_, err := Render(Parameters{AuthMode: "unknown"})
+	assert.Error(t, err)
+	_, err = Render(Parameters{AuthMode: AuthTokenFile})
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package kubeconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"k8s.io/klog"
+)
+
+const (
+	tokenPrefix = "k8s-aws-v1."
+	// clusterIDHeader is the header signed into the token to bind it to the cluster.
+	clusterIDHeader = "x-k8s-aws-id"
+	tokenFilePerm   = 0600
+
+	// TokenRefreshInterval is the interval to refresh the token file.
+	// The tokens are valid for 15 minutes.
+	TokenRefreshInterval = 10 * time.Minute
+)
+
+// GetToken returns the EKS authentication token, the presigned STS GetCallerIdentity URL,
+// in the same way as "aws eks get-token" and "aws-iam-authenticator token".
+// ref. https://github.com/kubernetes-sigs/aws-iam-authenticator#api-authorization-from-outside-a-cluster
+func GetToken(ctx context.Context, client *sts.Client, clusterName string) (string, error) {
+	presignClient := sts.NewPresignClient(client)
+	req, err := presignClient.PresignGetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}, func(o *sts.PresignOptions) {
+		o.ClientOptions = append(o.ClientOptions, func(so *sts.Options) {
+			so.APIOptions = append(so.APIOptions,
+				smithyhttp.AddHeaderValue(clusterIDHeader, clusterName),
+				smithyhttp.AddHeaderValue("X-Amz-Expires", "60"),
+			)
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to presign GetCallerIdentity: %v", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(req.URL)), nil
+}
+
+// WriteTokenFile writes a new token to the token file.
+// The kubeconfig "tokenFile" is re-read by the clients, so the refreshed token is picked up.
+func WriteTokenFile(ctx context.Context, client *sts.Client, clusterName string, tokenFile string) error {
+	token, err := GetToken(ctx, client, clusterName)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tokenFile, []byte(token), tokenFilePerm)
+}
+
+// RefreshTokenFile refreshes the token file every TokenRefreshInterval until the context is done.
+func RefreshTokenFile(ctx context.Context, client *sts.Client, clusterName string, tokenFile string) {
+	ticker := time.NewTicker(TokenRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := WriteTokenFile(ctx, client, clusterName, tokenFile); err != nil {
+			klog.Warningf("failed to refresh token file %s: %v", tokenFile, err)
+			continue
+		}
+		klog.V(2).Infof("refreshed token file: %s", tokenFile)
+	}
+}
+
+// TokenRefresher runs RefreshTokenFile in the background until it is stopped.
+// It is started at most once, no matter how many times the kubeconfig is written.
+type TokenRefresher struct {
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+// Start starts refreshing the token file, if not already started.
+func (r *TokenRefresher) Start(client *sts.Client, clusterName string, tokenFile string) {
+	r.once.Do(func() {
+		var ctx context.Context
+		ctx, r.cancel = context.WithCancel(context.Background())
+		go RefreshTokenFile(ctx, client, clusterName, tokenFile)
+	})
+}
+
+// Stop stops refreshing the token file. It is a no-op if never started.
+func (r *TokenRefresher) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
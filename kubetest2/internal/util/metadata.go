@@ -0,0 +1,45 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+
+	"k8s.io/klog"
+	"sigs.k8s.io/kubetest2/pkg/metadata"
+)
+
+// WriteMetadata records the values in the metadata.json of the artifacts directory,
+// merged with the existing metadata (e.g. the deployer version written by kubetest2).
+func WriteMetadata(artifactsDir string, values map[string]string) error {
+	if err := os.MkdirAll(artifactsDir, os.ModePerm); err != nil {
+		return err
+	}
+	metadataPath := filepath.Join(artifactsDir, "metadata.json")
+	var meta *metadata.CustomJSON
+	if f, err := os.Open(metadataPath); err == nil {
+		meta, err = metadata.NewCustomJSON(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	} else if os.IsNotExist(err) {
+		meta, _ = metadata.NewCustomJSON(nil)
+	} else {
+		return err
+	}
+	for k, v := range values {
+		if err := meta.Add(k, v); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(metadataPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := meta.Write(f); err != nil {
+		return err
+	}
+	klog.Infof("recorded metadata: %s", metadataPath)
+	return nil
+}
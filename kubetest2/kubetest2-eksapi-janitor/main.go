@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/kubetest2/internal/deployers/eksapi"
@@ -12,10 +13,18 @@ import (
 func main() {
 	var maxResourceAge time.Duration
 	flag.DurationVar(&maxResourceAge, "max-resource-age", time.Hour*3, "Maximum resource age")
+	var maxResourceAgeByType string
+	flag.StringVar(&maxResourceAgeByType, "max-resource-age-by-type", "", "Maximum age of the orphaned resources created outside of the stacks, by type, in the form 'type=duration,...'. Defaults to --max-resource-age. Types: "+strings.Join(eksapi.OrphanResourceTypes, ", "))
 	var emitMetrics bool
-	flag.BoolVar(&emitMetrics, "emit-metrics", false, "Send metrics to CloudWatch")
+	flag.BoolVar(&emitMetrics, "emit-metrics", false, "Send metrics to CloudWatch, including the estimated hourly cost of the resources to delete")
+	var dryRun bool
+	flag.BoolVar(&dryRun, "dry-run", false, "Report the resources that would be deleted, without deleting them")
 	flag.Parse()
-	j := eksapi.NewJanitor(maxResourceAge, emitMetrics)
+	maxResourceAges, err := eksapi.ParseMaxResourceAges(maxResourceAgeByType)
+	if err != nil {
+		klog.Fatalf("--max-resource-age-by-type is invalid: %v", err)
+	}
+	j := eksapi.NewJanitor(maxResourceAge, maxResourceAges, emitMetrics, dryRun)
 	if err := j.Sweep(context.Background()); err != nil {
 		klog.Fatalf("failed to sweep resources: %v", err)
 	}
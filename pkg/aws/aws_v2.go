@@ -8,6 +8,8 @@ import (
 
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	config_v2 "github.com/aws/aws-sdk-go-v2/config"
+	stscreds_v2 "github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	sts_v2 "github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/aws/smithy-go/logging"
 	"go.uber.org/zap"
 )
@@ -68,6 +70,14 @@ func NewV2(cfg *Config) (awsCfg aws_v2.Config, err error) {
 	if err != nil {
 		return aws_v2.Config{}, fmt.Errorf("failed to load config %v", err)
 	}
+	if cfg.AssumeRoleARN != "" {
+		cfg.Logger.Info("assuming role", zap.String("role-arn", cfg.AssumeRoleARN))
+		awsCfg.Credentials = aws_v2.NewCredentialsCache(stscreds_v2.NewAssumeRoleProvider(sts_v2.NewFromConfig(awsCfg), cfg.AssumeRoleARN, func(o *stscreds_v2.AssumeRoleOptions) {
+			if cfg.AssumeRoleDuration > 0 {
+				o.Duration = cfg.AssumeRoleDuration
+			}
+		}))
+	}
 
 	return awsCfg, nil
 }
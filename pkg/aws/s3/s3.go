@@ -149,7 +149,11 @@ func Upload(
 	s3API s3iface.S3API,
 	bucket string,
 	s3Key string,
-	fpath string) error {
+	fpath string,
+	opts ...OpOption) error {
+
+	ret := Op{}
+	ret.applyOpts(opts)
 
 	if !fileutil.Exist(fpath) {
 		return fmt.Errorf("file %q does not exist; failed to upload to %s/%s", fpath, bucket, s3Key)
@@ -174,7 +178,7 @@ func Upload(
 	defer rf.Close()
 
 	for i := 0; i < 5; i++ {
-		_, err = s3API.PutObject(&s3.PutObjectInput{
+		input := &s3.PutObjectInput{
 			Bucket: aws.String(bucket),
 			Key:    aws.String(s3Key),
 
@@ -188,7 +192,12 @@ func Upload(
 				"Kind": aws.String("aws-k8s-tester"),
 				"User": aws.String(user.Get()),
 			},
-		})
+		}
+		if ret.sseKMSKeyID != "" {
+			input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+			input.SSEKMSKeyId = aws.String(ret.sseKMSKeyID)
+		}
+		_, err = s3API.PutObject(input)
 		if err == nil {
 			lg.Info("uploaded",
 				zap.String("s3-bucket", bucket),
@@ -669,9 +678,10 @@ func DownloadDir(lg *zap.Logger, s3API s3iface.S3API, bucket string, s3Dir strin
 
 // Op represents a SSH operation.
 type Op struct {
-	verbose   bool
-	overwrite bool
-	timeout   time.Duration
+	verbose     bool
+	overwrite   bool
+	timeout     time.Duration
+	sseKMSKeyID string
 }
 
 // OpOption configures archiver operations.
@@ -692,6 +702,12 @@ func WithTimeout(timeout time.Duration) OpOption {
 	return func(op *Op) { op.timeout = timeout }
 }
 
+// WithSSEKMSKeyID configures the KMS key to encrypt uploaded objects with.
+// ref. https://docs.aws.amazon.com/AmazonS3/latest/userguide/UsingKMSEncryption.html
+func WithSSEKMSKeyID(keyID string) OpOption {
+	return func(op *Op) { op.sseKMSKeyID = keyID }
+}
+
 func (op *Op) applyOpts(opts []OpOption) {
 	for _, opt := range opts {
 		opt(op)
@@ -0,0 +1,252 @@
+// Package artifacts implements the retention of the test run artifacts,
+// in the local directories and under the S3 prefixes.
+//
+// A run is a top-level entry of the artifacts directory, or the "directory"
+// of the S3 prefix at the run depth (e.g., "k8s-tester/[CLUSTER_NAME]/[RUN_ID]/"
+// at the depth 2 with the "artifacts_s3" upload layout), so that the repeated
+// runs of a long-lived cluster are pruned one by one.
+//
+//	k8s-tester/my-cluster-1/abcd1234/manifest.json
+//	k8s-tester/my-cluster-1/abcd1234/logs/k8s-tester.log
+//	k8s-tester/my-cluster-1/efgh5678/manifest.json
+//	k8s-tester/my-cluster-2/ijkl9012/manifest.json
+package artifacts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+// Policy is the retention policy of the runs.
+// A run is pruned if it is older than "MaxAge",
+// or if it is not one of the "KeepCount" most recent runs.
+type Policy struct {
+	// MaxAge is the maximum age of the runs to keep.
+	// Zero to not prune by age.
+	MaxAge time.Duration `json:"max_age"`
+	// KeepCount is the number of the most recent runs to keep.
+	// Zero to not prune by count.
+	KeepCount int `json:"keep_count"`
+}
+
+// Validate validates the policy.
+func (p Policy) Validate() error {
+	if p.MaxAge < 0 {
+		return fmt.Errorf("negative MaxAge %v", p.MaxAge)
+	}
+	if p.KeepCount < 0 {
+		return fmt.Errorf("negative KeepCount %d", p.KeepCount)
+	}
+	if p.MaxAge == 0 && p.KeepCount == 0 {
+		return errors.New("empty policy; set MaxAge or KeepCount")
+	}
+	return nil
+}
+
+// Run is the artifacts of a test run.
+type Run struct {
+	// Name is the directory name, or the S3 prefix elements of the run
+	// relative to the listed prefix (e.g., "[CLUSTER_NAME]/[RUN_ID]").
+	Name string `json:"name"`
+	// Path is the local path or the S3 prefix of the run.
+	Path string `json:"path"`
+	// Modified is the most recent modification time of the run files.
+	Modified time.Time `json:"modified"`
+	// Files is the number of the run files.
+	Files int `json:"files"`
+	// Size is the total size of the run files in bytes.
+	Size int64 `json:"size"`
+
+	keys []string
+}
+
+// Select returns the runs to keep and the runs to prune,
+// both in the descending order of the modification time.
+func Select(runs []Run, policy Policy, now time.Time) (keep []Run, prune []Run) {
+	sorted := make([]Run, len(runs))
+	copy(sorted, runs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Modified.After(sorted[j].Modified) })
+	for i, r := range sorted {
+		switch {
+		case policy.KeepCount > 0 && i >= policy.KeepCount:
+			prune = append(prune, r)
+		case policy.MaxAge > 0 && now.Sub(r.Modified) > policy.MaxAge:
+			prune = append(prune, r)
+		default:
+			keep = append(keep, r)
+		}
+	}
+	return keep, prune
+}
+
+// ListLocal lists the runs in the local directory.
+func ListLocal(dir string) ([]Run, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]Run, 0, len(entries))
+	for _, e := range entries {
+		r := Run{Name: e.Name(), Path: filepath.Join(dir, e.Name())}
+		err = filepath.Walk(r.Path, func(_ string, info os.FileInfo, ferr error) error {
+			if ferr != nil {
+				return ferr
+			}
+			if info.ModTime().After(r.Modified) {
+				r.Modified = info.ModTime()
+			}
+			if !info.IsDir() {
+				r.Files++
+				r.Size += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, nil
+}
+
+// PruneLocal removes the runs in the local directory by the policy,
+// and returns the pruned runs. Set "dryRun" to only return the runs to prune.
+func PruneLocal(lg *zap.Logger, dir string, policy Policy, dryRun bool) ([]Run, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if abs == filepath.Dir(abs) {
+		return nil, fmt.Errorf("refusing to prune the root directory %q", dir)
+	}
+	runs, err := ListLocal(abs)
+	if err != nil {
+		return nil, err
+	}
+	_, prune := Select(runs, policy, time.Now())
+	for _, r := range prune {
+		if dryRun {
+			lg.Info("would prune run", zap.String("path", r.Path), zap.Time("modified", r.Modified))
+			continue
+		}
+		if err = os.RemoveAll(r.Path); err != nil {
+			return nil, fmt.Errorf("failed to remove %q (%v)", r.Path, err)
+		}
+		lg.Info("pruned run", zap.String("path", r.Path), zap.Time("modified", r.Modified), zap.Int64("size", r.Size))
+	}
+	return prune, nil
+}
+
+// ListS3 lists the runs under the S3 prefix, where a run is the first "depth"
+// elements of the keys relative to the prefix (e.g., 2 for "[CLUSTER_NAME]/[RUN_ID]/").
+// The objects above the run depth (e.g., a shared index) are not runs.
+func ListS3(s3API s3iface.S3API, bucket string, prefix string, depth int) ([]Run, error) {
+	if depth < 1 {
+		return nil, fmt.Errorf("invalid depth %d", depth)
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	runs := make(map[string]*Run)
+	err := s3API.ListObjectsV2Pages(
+		&s3.ListObjectsV2Input{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(prefix),
+		},
+		func(resp *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, obj := range resp.Contents {
+				key := aws.StringValue(obj.Key)
+				elems := strings.Split(strings.TrimPrefix(key, prefix), "/")
+				if len(elems) <= depth {
+					continue
+				}
+				name := strings.Join(elems[:depth], "/")
+				if strings.Contains("/"+name+"/", "//") {
+					continue
+				}
+				r, ok := runs[name]
+				if !ok {
+					r = &Run{Name: name, Path: prefix + name + "/"}
+					runs[name] = r
+				}
+				if modified := aws.TimeValue(obj.LastModified); modified.After(r.Modified) {
+					r.Modified = modified
+				}
+				r.Files++
+				r.Size += aws.Int64Value(obj.Size)
+				r.keys = append(r.keys, key)
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list s3://%s/%s (%v)", bucket, prefix, err)
+	}
+	ret := make([]Run, 0, len(runs))
+	for _, r := range runs {
+		ret = append(ret, *r)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+// maxDeleteObjects is the maximum number of keys of an S3 "DeleteObjects" call.
+const maxDeleteObjects = 1000
+
+// PruneS3 deletes the runs at the "depth" under the S3 prefix by the policy,
+// and returns the pruned runs. Set "dryRun" to only return the runs to prune.
+func PruneS3(lg *zap.Logger, s3API s3iface.S3API, bucket string, prefix string, depth int, policy Policy, dryRun bool) ([]Run, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if bucket == "" {
+		return nil, errors.New("empty bucket")
+	}
+	runs, err := ListS3(s3API, bucket, prefix, depth)
+	if err != nil {
+		return nil, err
+	}
+	_, prune := Select(runs, policy, time.Now())
+	for _, r := range prune {
+		if dryRun {
+			lg.Info("would prune run", zap.String("bucket", bucket), zap.String("prefix", r.Path), zap.Time("modified", r.Modified))
+			continue
+		}
+		for start := 0; start < len(r.keys); start += maxDeleteObjects {
+			end := start + maxDeleteObjects
+			if end > len(r.keys) {
+				end = len(r.keys)
+			}
+			objs := make([]*s3.ObjectIdentifier, 0, end-start)
+			for _, key := range r.keys[start:end] {
+				objs = append(objs, &s3.ObjectIdentifier{Key: aws.String(key)})
+			}
+			out, err := s3API.DeleteObjects(&s3.DeleteObjectsInput{
+				Bucket: aws.String(bucket),
+				Delete: &s3.Delete{Objects: objs, Quiet: aws.Bool(true)},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete s3://%s/%s (%v)", bucket, r.Path, err)
+			}
+			if len(out.Errors) > 0 {
+				return nil, fmt.Errorf("failed to delete %d objects of s3://%s/%s (%s)", len(out.Errors), bucket, r.Path, aws.StringValue(out.Errors[0].Message))
+			}
+		}
+		lg.Info("pruned run", zap.String("bucket", bucket), zap.String("prefix", r.Path), zap.Time("modified", r.Modified), zap.Int64("size", r.Size))
+	}
+	return prune, nil
+}
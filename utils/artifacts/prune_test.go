@@ -0,0 +1,255 @@
+package artifacts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+func names(runs []Run) []string {
+	ns := make([]string, 0, len(runs))
+	for _, r := range runs {
+		ns = append(ns, r.Name)
+	}
+	return ns
+}
+
+func TestSelect(t *testing.T) {
+	now := time.Now()
+	runs := []Run{
+		{Name: "c", Modified: now.Add(-3 * time.Hour)},
+		{Name: "a", Modified: now.Add(-1 * time.Hour)},
+		{Name: "d", Modified: now.Add(-4 * time.Hour)},
+		{Name: "b", Modified: now.Add(-2 * time.Hour)},
+	}
+	tests := []struct {
+		policy        Policy
+		expectedKeep  []string
+		expectedPrune []string
+	}{
+		{Policy{KeepCount: 2}, []string{"a", "b"}, []string{"c", "d"}},
+		{Policy{MaxAge: 150 * time.Minute}, []string{"a", "b"}, []string{"c", "d"}},
+		{Policy{MaxAge: 150 * time.Minute, KeepCount: 1}, []string{"a"}, []string{"b", "c", "d"}},
+		{Policy{KeepCount: 10}, []string{"a", "b", "c", "d"}, []string{}},
+	}
+	for _, tt := range tests {
+		keep, prune := Select(runs, tt.policy, now)
+		if !reflect.DeepEqual(names(keep), tt.expectedKeep) || !reflect.DeepEqual(names(prune), tt.expectedPrune) {
+			t.Fatalf("%+v: unexpected keep %v, prune %v", tt.policy, names(keep), names(prune))
+		}
+	}
+	if runs[0].Name != "c" {
+		t.Fatal("expected the runs to be unchanged")
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	if err := (Policy{}).Validate(); err == nil {
+		t.Fatal("expected error for empty policy")
+	}
+	if err := (Policy{KeepCount: -1}).Validate(); err == nil {
+		t.Fatal("expected error for negative KeepCount")
+	}
+	if err := (Policy{MaxAge: time.Hour}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneLocal(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "artifacts-prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	for i, name := range []string{"run-1", "run-2", "run-3"} {
+		p := filepath.Join(dir, name, "logs", "k8s-tester.log")
+		if err = os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(p, []byte("hello"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		modified := now.Add(-time.Duration(3-i) * 24 * time.Hour)
+		for _, fp := range []string{p, filepath.Dir(p), filepath.Join(dir, name)} {
+			if err = os.Chtimes(fp, modified, modified); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	runs, err := ListLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 || runs[0].Files != 1 || runs[0].Size != 5 {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+
+	policy := Policy{MaxAge: 36 * time.Hour}
+	pruned, err := PruneLocal(zap.NewExample(), dir, policy, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(pruned), []string{"run-2", "run-1"}) {
+		t.Fatalf("unexpected pruned runs %v", names(pruned))
+	}
+	if _, err = os.Stat(filepath.Join(dir, "run-1")); err != nil {
+		t.Fatalf("expected dry run to keep the run (%v)", err)
+	}
+
+	if _, err = PruneLocal(zap.NewExample(), dir, policy, false); err != nil {
+		t.Fatal(err)
+	}
+	runs, err = ListLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(runs), []string{"run-3"}) {
+		t.Fatalf("unexpected runs %v", names(runs))
+	}
+}
+
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string]time.Time
+}
+
+func (f *fakeS3) ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error {
+	out := &s3.ListObjectsV2Output{}
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasPrefix(k, aws.StringValue(input.Prefix)) {
+			out.Contents = append(out.Contents, &s3.Object{Key: aws.String(k), LastModified: aws.Time(f.objects[k]), Size: aws.Int64(5)})
+		}
+	}
+	fn(out, true)
+	return nil
+}
+
+func (f *fakeS3) DeleteObjects(input *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error) {
+	for _, obj := range input.Delete.Objects {
+		delete(f.objects, aws.StringValue(obj.Key))
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+func TestPruneS3(t *testing.T) {
+	now := time.Now()
+	fake := &fakeS3{objects: map[string]time.Time{
+		"k8s-tester/index.json":                      now,
+		"k8s-tester/cluster-1/manifest.json":         now.Add(-72 * time.Hour),
+		"k8s-tester/cluster-1/logs/k8s-tester.log":   now.Add(-73 * time.Hour),
+		"k8s-tester/cluster-2/manifest.json":         now.Add(-48 * time.Hour),
+		"k8s-tester/cluster-3/manifest.json":         now.Add(-time.Hour),
+		"k8s-tester/cluster-3/reports/junit.xml":     now.Add(-time.Hour),
+		"other/cluster-4/manifest.json":              now.Add(-96 * time.Hour),
+		"k8s-tester-other/cluster-5/manifest.json":   now.Add(-96 * time.Hour),
+		"k8s-tester-other/cluster-5/logs/tester.log": now.Add(-96 * time.Hour),
+	}}
+
+	runs, err := ListS3(fake, "hello", "/k8s-tester/", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(runs), []string{"cluster-1", "cluster-2", "cluster-3"}) {
+		t.Fatalf("unexpected runs %v", names(runs))
+	}
+	if runs[0].Path != "k8s-tester/cluster-1/" || runs[0].Files != 2 || runs[0].Size != 10 {
+		t.Fatalf("unexpected run %+v", runs[0])
+	}
+
+	pruned, err := PruneS3(zap.NewExample(), fake, "hello", "k8s-tester", 1, Policy{KeepCount: 1}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(pruned), []string{"cluster-2", "cluster-1"}) {
+		t.Fatalf("unexpected pruned runs %v", names(pruned))
+	}
+	var remaining []string
+	for k := range fake.objects {
+		remaining = append(remaining, k)
+	}
+	sort.Strings(remaining)
+	expected := []string{
+		"k8s-tester-other/cluster-5/logs/tester.log",
+		"k8s-tester-other/cluster-5/manifest.json",
+		"k8s-tester/cluster-3/manifest.json",
+		"k8s-tester/cluster-3/reports/junit.xml",
+		"k8s-tester/index.json",
+		"other/cluster-4/manifest.json",
+	}
+	if !reflect.DeepEqual(remaining, expected) {
+		t.Fatalf("unexpected remaining objects %v", remaining)
+	}
+}
+
+func TestPruneS3RunDepth(t *testing.T) {
+	now := time.Now()
+	fake := &fakeS3{objects: map[string]time.Time{
+		"k8s-tester/index.json":                          now,
+		"k8s-tester/cluster-1/manifest.json":             now.Add(-96 * time.Hour),
+		"k8s-tester/cluster-1/run-a/manifest.json":       now.Add(-72 * time.Hour),
+		"k8s-tester/cluster-1/run-a/logs/tester.log":     now.Add(-73 * time.Hour),
+		"k8s-tester/cluster-1/run-b/manifest.json":       now.Add(-48 * time.Hour),
+		"k8s-tester/cluster-1/run-c/manifest.json":       now.Add(-time.Hour),
+		"k8s-tester/cluster-1/run-c/reports/junit.xml":   now.Add(-time.Hour),
+		"k8s-tester/cluster-2/run-d/manifest.json":       now.Add(-2 * time.Hour),
+		"k8s-tester/cluster-2//manifest.json":            now.Add(-96 * time.Hour),
+		"k8s-tester-other/cluster-1/run-e/manifest.json": now.Add(-96 * time.Hour),
+	}}
+
+	if _, err := ListS3(fake, "hello", "k8s-tester", 0); err == nil {
+		t.Fatal("expected error for zero depth")
+	}
+	runs, err := ListS3(fake, "hello", "k8s-tester", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(runs), []string{"cluster-1/run-a", "cluster-1/run-b", "cluster-1/run-c", "cluster-2/run-d"}) {
+		t.Fatalf("unexpected runs %v", names(runs))
+	}
+	if runs[0].Path != "k8s-tester/cluster-1/run-a/" || runs[0].Files != 2 || runs[0].Size != 10 {
+		t.Fatalf("unexpected run %+v", runs[0])
+	}
+
+	pruned, err := PruneS3(zap.NewExample(), fake, "hello", "k8s-tester", 2, Policy{MaxAge: 36 * time.Hour}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(pruned), []string{"cluster-1/run-b", "cluster-1/run-a"}) {
+		t.Fatalf("unexpected pruned runs %v", names(pruned))
+	}
+	var remaining []string
+	for k := range fake.objects {
+		remaining = append(remaining, k)
+	}
+	sort.Strings(remaining)
+	expected := []string{
+		"k8s-tester-other/cluster-1/run-e/manifest.json",
+		"k8s-tester/cluster-1/manifest.json",
+		"k8s-tester/cluster-1/run-c/manifest.json",
+		"k8s-tester/cluster-1/run-c/reports/junit.xml",
+		"k8s-tester/cluster-2//manifest.json",
+		"k8s-tester/cluster-2/run-d/manifest.json",
+		"k8s-tester/index.json",
+	}
+	if !reflect.DeepEqual(remaining, expected) {
+		t.Fatalf("unexpected remaining objects %v", remaining)
+	}
+}
@@ -0,0 +1,236 @@
+// Package s3 manages the S3 bucket and the artifact layout of a test run,
+// shared by the "eks" and "k8s-tester" uploads.
+//
+// The artifacts of a run are uploaded under "[RUN_PREFIX]/[NAME]" by default,
+// or under "[RUN_PREFIX]/[COMPONENT]/[NAME]" with "ComponentLayout",
+// and the run prefix has a "manifest.json" index of all uploaded objects.
+//
+//	my-cluster/manifest.json
+//	my-cluster/config/aws-k8s-tester-eks.config.yaml
+//	my-cluster/logs/aws-k8s-tester-eks.log
+//	my-cluster/reports/junit.xml
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+// Standard components of the artifact layout.
+const (
+	// ComponentConfig is for the tester configuration files.
+	ComponentConfig = "config"
+	// ComponentLogs is for the tester and cluster log files.
+	ComponentLogs = "logs"
+	// ComponentReports is for the JUnit, TAP, HTML, and IAM preflight reports.
+	ComponentReports = "reports"
+	// ComponentResults is for the test results (e.g., sonobuoy, clusterloader).
+	ComponentResults = "results"
+	// ComponentInfra is for the exported inventory of the created resources.
+	ComponentInfra = "infra"
+)
+
+// ManifestName is the name of the index file under the run prefix.
+const ManifestName = "manifest.json"
+
+// MinLifecycleExpirationDays is the minimum lifecycle expiration of the run buckets.
+const MinLifecycleExpirationDays = 3
+
+// Config configures the run bucket manager.
+type Config struct {
+	Logger *zap.Logger
+	S3API  s3iface.S3API
+
+	Region string
+	// BucketName is the S3 bucket of the run.
+	BucketName string
+	// RunPrefix is the S3 key prefix of the run (e.g., cluster name).
+	RunPrefix string
+	// KMSKeyID is the KMS key ID or ARN to encrypt the objects with.
+	// Leave empty to use the bucket default encryption.
+	KMSKeyID string
+	// ComponentLayout is true to upload the artifacts under "[RUN_PREFIX]/[COMPONENT]/[NAME]",
+	// and to expire only the objects under "[RUN_PREFIX]/". False to keep the flat
+	// "[RUN_PREFIX]/[NAME]" layout and the "[RUN_PREFIX]" lifecycle rule of the previous releases.
+	ComponentLayout bool
+}
+
+// Entry is an uploaded object in the manifest.
+type Entry struct {
+	Component string    `json:"component"`
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	Uploaded  time.Time `json:"uploaded"`
+}
+
+// Manifest is the index of the uploaded objects of a run.
+type Manifest struct {
+	Bucket    string    `json:"bucket"`
+	RunPrefix string    `json:"run_prefix"`
+	Updated   time.Time `json:"updated"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Manager uploads the run artifacts in the standard layout,
+// and keeps track of the uploaded objects for the manifest.
+type Manager struct {
+	cfg Config
+
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// New creates a new run bucket manager.
+func New(cfg Config) (*Manager, error) {
+	if cfg.Logger == nil {
+		return nil, errors.New("nil Logger")
+	}
+	if cfg.S3API == nil {
+		return nil, errors.New("nil S3API")
+	}
+	if cfg.BucketName == "" {
+		return nil, errors.New("empty BucketName")
+	}
+	cfg.RunPrefix = strings.Trim(cfg.RunPrefix, "/")
+	return &Manager{cfg: cfg, entries: make(map[string]Entry)}, nil
+}
+
+// CreateBucket creates the run bucket with the lifecycle expiration on the run prefix.
+// Zero "expirationDays" disables the expiration, and otherwise it is at least
+// "MinLifecycleExpirationDays". The lifecycle is not updated if the bucket already exists.
+func (m *Manager) CreateBucket(expirationDays int64) error {
+	if expirationDays > 0 && expirationDays < MinLifecycleExpirationDays {
+		expirationDays = MinLifecycleExpirationDays
+	}
+	lifecyclePrefix := m.cfg.RunPrefix
+	if m.cfg.ComponentLayout && lifecyclePrefix != "" {
+		lifecyclePrefix += "/"
+	}
+	return aws_s3.CreateBucket(m.cfg.Logger, m.cfg.S3API, m.cfg.BucketName, m.cfg.Region, lifecyclePrefix, expirationDays)
+}
+
+// DeleteBucket empties and deletes the run bucket.
+func (m *Manager) DeleteBucket() error {
+	if err := aws_s3.EmptyBucket(m.cfg.Logger, m.cfg.S3API, m.cfg.BucketName); err != nil {
+		return err
+	}
+	return aws_s3.DeleteBucket(m.cfg.Logger, m.cfg.S3API, m.cfg.BucketName)
+}
+
+// Key returns the S3 key of the artifact. The component is only
+// part of the key with "ComponentLayout".
+func (m *Manager) Key(component string, name string) string {
+	if !m.cfg.ComponentLayout {
+		return path.Join(m.cfg.RunPrefix, filepath.ToSlash(name))
+	}
+	return path.Join(m.cfg.RunPrefix, component, filepath.ToSlash(name))
+}
+
+// ManifestKey returns the S3 key of the manifest.
+func (m *Manager) ManifestKey() string {
+	return path.Join(m.cfg.RunPrefix, ManifestName)
+}
+
+// Upload uploads the local file as "name" under the component,
+// and returns the S3 key. A file uploaded twice is recorded once.
+func (m *Manager) Upload(component string, name string, fpath string) (string, error) {
+	info, err := os.Stat(fpath)
+	if err != nil {
+		return "", err
+	}
+	key := m.Key(component, name)
+	opts := []aws_s3.OpOption{aws_s3.WithTimeout(5 * time.Minute)}
+	if m.cfg.KMSKeyID != "" {
+		opts = append(opts, aws_s3.WithSSEKMSKeyID(m.cfg.KMSKeyID))
+	}
+	if err = aws_s3.Upload(m.cfg.Logger, m.cfg.S3API, m.cfg.BucketName, key, fpath, opts...); err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	m.entries[key] = Entry{Component: component, Key: key, Size: info.Size(), Uploaded: time.Now().UTC()}
+	m.mu.Unlock()
+	return key, nil
+}
+
+// UploadDir uploads the directory recursively under the component,
+// keeping the paths relative to the parent of the directory.
+func (m *Manager) UploadDir(component string, dir string) (keys []string, err error) {
+	parent := filepath.Dir(dir)
+	err = filepath.Walk(dir, func(fpath string, info os.FileInfo, ferr error) error {
+		if ferr != nil {
+			return ferr
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(parent, fpath)
+		if err != nil {
+			return err
+		}
+		key, err := m.Upload(component, rel, fpath)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	return keys, err
+}
+
+// Manifest returns the manifest of the uploaded objects, sorted by key.
+func (m *Manager) Manifest() Manifest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mf := Manifest{
+		Bucket:    m.cfg.BucketName,
+		RunPrefix: m.cfg.RunPrefix,
+		Updated:   time.Now().UTC(),
+		Entries:   make([]Entry, 0, len(m.entries)),
+	}
+	for _, e := range m.entries {
+		mf.Entries = append(mf.Entries, e)
+	}
+	sort.Slice(mf.Entries, func(i, j int) bool { return mf.Entries[i].Key < mf.Entries[j].Key })
+	return mf
+}
+
+// WriteManifest uploads the manifest of the uploaded objects,
+// overwriting the previous one, and returns its S3 key.
+func (m *Manager) WriteManifest() (string, error) {
+	b, err := json.MarshalIndent(m.Manifest(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	key := m.ManifestKey()
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(m.cfg.BucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+		ACL:         aws.String("private"),
+	}
+	if m.cfg.KMSKeyID != "" {
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		input.SSEKMSKeyId = aws.String(m.cfg.KMSKeyID)
+	}
+	if _, err = m.cfg.S3API.PutObject(input); err != nil {
+		return "", fmt.Errorf("failed to upload manifest %q (%v)", key, err)
+	}
+	m.cfg.Logger.Info("uploaded manifest", zap.String("bucket", m.cfg.BucketName), zap.String("key", key))
+	return key, nil
+}
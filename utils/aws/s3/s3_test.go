@@ -0,0 +1,111 @@
+package s3
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string][]byte
+}
+
+func (f *fakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	b, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[aws.StringValue(input.Key)] = b
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestManager(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "s3-manager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	results := filepath.Join(dir, "results")
+	if err = os.MkdirAll(filepath.Join(results, "plugins"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{
+		filepath.Join(dir, "a.log"),
+		filepath.Join(results, "e2e.log"),
+		filepath.Join(results, "plugins", "junit.xml"),
+	} {
+		if err = ioutil.WriteFile(p, []byte("hello"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	m, err := New(Config{Logger: zap.NewExample(), S3API: fake, BucketName: "hello", RunPrefix: "/my-cluster/", ComponentLayout: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Upload(ComponentLogs, "a.log", filepath.Join(dir, "a.log")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Upload(ComponentLogs, "a.log", filepath.Join(dir, "a.log")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.UploadDir(ComponentResults, results); err != nil {
+		t.Fatal(err)
+	}
+	key, err := m.WriteManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "my-cluster/manifest.json" {
+		t.Fatalf("unexpected manifest key %q", key)
+	}
+
+	var mf Manifest
+	if err = json.Unmarshal(fake.objects[key], &mf); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range mf.Entries {
+		keys = append(keys, e.Key)
+	}
+	expected := []string{
+		"my-cluster/logs/a.log",
+		"my-cluster/results/results/e2e.log",
+		"my-cluster/results/results/plugins/junit.xml",
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+	if mf.Bucket != "hello" || mf.RunPrefix != "my-cluster" {
+		t.Fatalf("unexpected manifest %+v", mf)
+	}
+}
+
+func TestManagerKey(t *testing.T) {
+	tests := []struct {
+		componentLayout bool
+		expected        string
+	}{
+		{false, "my-cluster/results/e2e.log"},
+		{true, "my-cluster/results/results/e2e.log"},
+	}
+	for i, tt := range tests {
+		m, err := New(Config{Logger: zap.NewExample(), S3API: &fakeS3{}, BucketName: "hello", RunPrefix: "my-cluster", ComponentLayout: tt.componentLayout})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key := m.Key(ComponentResults, filepath.Join("results", "e2e.log")); key != tt.expected {
+			t.Fatalf("#%d: expected %q, got %q", i, tt.expected, key)
+		}
+	}
+}
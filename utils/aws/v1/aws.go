@@ -11,6 +11,7 @@ import (
 
 	"github.com/aws/aws-k8s-tester/utils/file"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -37,6 +38,12 @@ type Config struct {
 	ResolverURL string
 	// SigningName is the API signing name.
 	SigningName string
+
+	// AssumeRoleARN is the IAM role to assume with the default credentials, if not empty.
+	// The assumed role credentials are refreshed before the expiry.
+	AssumeRoleARN string
+	// AssumeRoleDuration is the duration of the assumed role credentials (default 15 minutes).
+	AssumeRoleDuration time.Duration
 }
 
 // New creates a new AWS session.
@@ -79,7 +86,6 @@ func New(cfg *Config) (ss *session.Session, stsOutput *sts.GetCallerIdentityOutp
 	}
 	if file.Exist(awsCredsPath) {
 		cfg.Logger.Info("creating session from AWS cred file", zap.String("path", awsCredsPath))
-		// TODO: support temporary credentials with refresh mechanism
 	} else {
 		cfg.Logger.Info("cannot find AWS cred file", zap.String("path", awsCredsPath))
 		if os.Getenv("AWS_ACCESS_KEY_ID") == "" ||
@@ -196,6 +202,34 @@ func New(cfg *Config) (ss *session.Session, stsOutput *sts.GetCallerIdentityOutp
 	if err != nil {
 		return nil, nil, "", err
 	}
+
+	// refresh the temporary credentials before the expiry,
+	// for the runs longer than the credential lifetime
+	creds := ss.Config.Credentials
+	if cfg.AssumeRoleARN != "" {
+		cfg.Logger.Info("assuming role", zap.String("role-arn", cfg.AssumeRoleARN))
+		creds = stscreds.NewCredentials(ss, cfg.AssumeRoleARN, func(p *stscreds.AssumeRoleProvider) {
+			if cfg.AssumeRoleDuration > 0 {
+				p.Duration = cfg.AssumeRoleDuration
+			}
+		})
+	}
+	ss.Config.Credentials = NewRefreshingCredentials(cfg.Logger, creds, DefaultCredentialsExpiryWindow)
+	if cfg.AssumeRoleARN != "" {
+		// the caller identity above is of the default credentials,
+		// thus check the role can be assumed, and return its identity
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		stsOutput, err = sts.New(ss).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+		cancel()
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to get sts caller identity of the assumed role %q (%v)", cfg.AssumeRoleARN, err)
+		}
+		cfg.Logger.Info("assumed role",
+			zap.String("role-arn", cfg.AssumeRoleARN),
+			zap.String("account-id", aws.StringValue(stsOutput.Account)),
+			zap.String("arn", aws.StringValue(stsOutput.Arn)),
+		)
+	}
 	return ss, stsOutput, awsCredsPath, err
 }
 
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultCredentialsExpiryWindow is the default duration before the expiry
+	// of the temporary credentials (e.g., assumed role) to refresh them.
+	DefaultCredentialsExpiryWindow = 5 * time.Minute
+	// minCredentialsRefreshInterval limits the refresh retries, when the source
+	// returns the credentials that are still within the expiry window
+	// (e.g., EC2 instance metadata rotates the credentials only near the expiry).
+	minCredentialsRefreshInterval = time.Minute
+)
+
+// NewRefreshingCredentials wraps the credentials to refresh them "window" before
+// their expiry, and logs the refresh events, so that the long-running tests do not
+// sign the requests with the expired credentials. The credentials without the expiry
+// (e.g., static keys) are retrieved once.
+func NewRefreshingCredentials(lg *zap.Logger, creds *credentials.Credentials, window time.Duration) *credentials.Credentials {
+	if window <= 0 {
+		window = DefaultCredentialsExpiryWindow
+	}
+	return credentials.NewCredentials(&refreshingProvider{lg: lg, creds: creds, window: window})
+}
+
+type refreshingProvider struct {
+	lg     *zap.Logger
+	creds  *credentials.Credentials
+	window time.Duration
+
+	mu          sync.Mutex
+	expiresAt   time.Time
+	retrievedAt time.Time
+	retrievals  int
+}
+
+func (p *refreshingProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if exp, err := p.creds.ExpiresAt(); err == nil && !exp.IsZero() && time.Until(exp) < p.window {
+		p.creds.Expire()
+	}
+	v, err := p.creds.Get()
+	if err != nil {
+		p.lg.Warn("failed to retrieve AWS credentials", zap.Int("retrievals", p.retrievals), zap.Error(err))
+		return v, err
+	}
+	p.expiresAt = time.Time{}
+	if exp, err := p.creds.ExpiresAt(); err == nil {
+		p.expiresAt = exp
+	}
+	p.retrievedAt = time.Now()
+	p.retrievals++
+
+	fields := []zap.Field{
+		zap.String("provider", v.ProviderName),
+		zap.Int("retrievals", p.retrievals),
+	}
+	if !p.expiresAt.IsZero() {
+		fields = append(fields, zap.Time("expires-at", p.expiresAt), zap.String("expires-in", time.Until(p.expiresAt).Round(time.Second).String()))
+	}
+	if p.retrievals > 1 {
+		p.lg.Info("refreshed AWS credentials", fields...)
+	} else {
+		p.lg.Info("retrieved AWS credentials", fields...)
+	}
+	return v, nil
+}
+
+func (p *refreshingProvider) IsExpired() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.retrievals == 0 {
+		return true
+	}
+	if p.expiresAt.IsZero() {
+		return p.creds.IsExpired()
+	}
+	if time.Since(p.retrievedAt) < minCredentialsRefreshInterval {
+		return !time.Now().Before(p.expiresAt)
+	}
+	return time.Until(p.expiresAt) < p.window
+}
+
+// ExpiresAt implements "credentials.Expirer", and returns the zero time
+// if the credentials do not expire.
+func (p *refreshingProvider) ExpiresAt() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.expiresAt
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"go.uber.org/zap"
+)
+
+// expiringProvider returns new credentials that expire in "ttl".
+type expiringProvider struct {
+	ttl       time.Duration
+	retrieved int
+	credentials.Expiry
+}
+
+func (p *expiringProvider) Retrieve() (credentials.Value, error) {
+	p.retrieved++
+	p.SetExpiration(time.Now().Add(p.ttl), 0)
+	return credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN", ProviderName: "expiring"}, nil
+}
+
+func TestRefreshingCredentials(t *testing.T) {
+	p := &expiringProvider{ttl: 2 * time.Minute}
+	rp := &refreshingProvider{lg: zap.NewExample(), creds: credentials.NewCredentials(p), window: 5 * time.Minute}
+	creds := credentials.NewCredentials(rp)
+	for i := 0; i < 3; i++ {
+		if _, err := creds.Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the credentials within the expiry window are not refreshed on every call
+	if p.retrieved != 1 {
+		t.Fatalf("expected 1 retrieval, got %d", p.retrieved)
+	}
+
+	// refreshed within the expiry window, after the minimum refresh interval
+	p.ttl = time.Hour
+	rp.retrievedAt = time.Now().Add(-2 * minCredentialsRefreshInterval)
+	if _, err := creds.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if p.retrieved != 2 || rp.retrievals != 2 {
+		t.Fatalf("expected 2 retrievals, got %d (%d)", p.retrieved, rp.retrievals)
+	}
+	exp, err := creds.ExpiresAt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(exp) < 55*time.Minute {
+		t.Fatalf("expected refreshed credentials expiring in an hour, got %v", exp)
+	}
+
+	// not refreshed outside the expiry window
+	rp.retrievedAt = time.Now().Add(-2 * minCredentialsRefreshInterval)
+	if _, err := creds.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if p.retrieved != 2 {
+		t.Fatalf("expected 2 retrievals, got %d", p.retrieved)
+	}
+}
+
+func TestRefreshingCredentialsStatic(t *testing.T) {
+	creds := NewRefreshingCredentials(zap.NewExample(), credentials.NewStaticCredentials("AKID", "SECRET", ""), 0)
+	v, err := creds.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.AccessKeyID != "AKID" || creds.IsExpired() {
+		t.Fatalf("unexpected credentials %+v (expired %v)", v, creds.IsExpired())
+	}
+}
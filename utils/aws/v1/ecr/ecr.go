@@ -45,6 +45,18 @@ func (repo *Repository) IsEmpty() bool {
 		repo.ImageTag == ""
 }
 
+// ImageURI returns the repository URI + ":" + image tag, without calling ECR.
+func (repo *Repository) ImageURI() string {
+	// e.g. 602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.6.3
+	ecrHost := "amazonaws.com"
+	switch repo.Partition {
+	case endpoints.AwsCnPartitionID:
+		ecrHost = "amazonaws.com.cn"
+	default:
+	}
+	return fmt.Sprintf("%s.dkr.ecr.%s.%s/%s:%s", repo.AccountID, repo.Region, ecrHost, repo.Name, repo.ImageTag)
+}
+
 // Describe checks if the specified repository exists, and returns the repository URI + ":" + image tag.
 // It returns "true" for "exists" if the repository exists.
 // This method succeeds if and only if the ECR image exists and the caller is able to verify via "ecr:DescribeImages".
@@ -65,14 +77,7 @@ func (repo *Repository) Describe(lg *zap.Logger, svc ecriface.ECRAPI) (img strin
 		return "", false, errors.New("empty field for describe ECR image")
 	}
 
-	// e.g. 602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.6.3
-	ecrHost := "amazonaws.com"
-	switch repo.Partition {
-	case endpoints.AwsCnPartitionID:
-		ecrHost = "amazonaws.com.cn"
-	default:
-	}
-	img = fmt.Sprintf("%s.dkr.ecr.%s.%s/%s:%s", repo.AccountID, repo.Region, ecrHost, repo.Name, repo.ImageTag)
+	img = repo.ImageURI()
 
 	lg.Info("describing an ECR repository",
 		zap.String("repo-account-id", repo.AccountID),
@@ -9,3 +9,10 @@ func TestIsEmpty(t *testing.T) {
 		t.Fatal("unexpected repo.IsEmpty")
 	}
 }
+
+func TestImageURI(t *testing.T) {
+	repo := &Repository{Partition: "aws-cn", AccountID: "123", Region: "cn-north-1", Name: "my-app", ImageTag: "latest"}
+	if img := repo.ImageURI(); img != "123.dkr.ecr.cn-north-1.amazonaws.com.cn/my-app:latest" {
+		t.Fatalf("unexpected image URI %q", img)
+	}
+}
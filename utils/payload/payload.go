@@ -0,0 +1,194 @@
+// Package payload implements the object content generator for apiserver tests,
+// with binary data, unicode edge cases, and max-size keys and values.
+package payload
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-k8s-tester/utils/rand"
+)
+
+const (
+	// KindASCII is the random ASCII key and value (default).
+	KindASCII = "ascii"
+	// KindBinary is the random binary value, including NUL and non-UTF-8 bytes.
+	KindBinary = "binary"
+	// KindUnicode is the UTF-8 value with unicode edge cases
+	// (e.g., zero-width joiner, bidi override, BOM, combining marks, 4-byte runes).
+	KindUnicode = "unicode"
+	// KindMaxKey is the key with the maximum length allowed by the apiserver.
+	KindMaxKey = "max-key"
+	// KindMaxValue is the value with the maximum total data size allowed by the apiserver.
+	KindMaxValue = "max-value"
+	// KindInvalidKey is the key with the characters rejected by the apiserver.
+	KindInvalidKey = "invalid-key"
+	// KindOversize is the value larger than the maximum data size allowed by the apiserver.
+	KindOversize = "oversize"
+	// KindMixed cycles through all the other kinds.
+	KindMixed = "mixed"
+)
+
+// Kinds is the list of all payload kinds except "mixed".
+var Kinds = []string{
+	KindASCII,
+	KindBinary,
+	KindUnicode,
+	KindMaxKey,
+	KindMaxValue,
+	KindInvalidKey,
+	KindOversize,
+}
+
+const (
+	// MaxKeyLength is the maximum length of a configmap or secret data key.
+	// ref. k8s.io/apimachinery/pkg/util/validation.IsConfigMapKey
+	MaxKeyLength = 253
+	// MaxDataSize is the maximum total size of a configmap or secret data.
+	// ref. k8s.io/api/core/v1.MaxSecretSize
+	MaxDataSize = 1024 * 1024
+)
+
+// Valid returns an error if the kind is not supported.
+func Valid(kind string) error {
+	if kind == KindMixed {
+		return nil
+	}
+	for _, k := range Kinds {
+		if kind == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown payload kind %q (must be one of %q or %q)", kind, Kinds, KindMixed)
+}
+
+// Payload is the generated data key and value.
+type Payload struct {
+	// Kind is the payload kind (never "mixed").
+	Kind string
+	// Key is the data key.
+	Key string
+	// Value is the data value.
+	Value []byte
+	// ExpectInvalid is true if the apiserver is expected to reject the payload.
+	ExpectInvalid bool
+}
+
+// UTF8 returns true if the value is valid UTF-8, and can be stored
+// in the configmap "data" field rather than "binaryData".
+func (p Payload) UTF8() bool {
+	return utf8.Valid(p.Value)
+}
+
+// Generate generates the i-th payload with the given key prefix and value size.
+func Generate(kind string, prefix string, i int, size int) Payload {
+	if kind == KindMixed {
+		kind = Kinds[i%len(Kinds)]
+	}
+	p := Payload{Kind: kind, Key: fmt.Sprintf("%s%d", prefix, i)}
+	switch kind {
+	case KindBinary:
+		p.Value = binaryValue(size)
+	case KindUnicode:
+		p.Value = []byte(unicodeValue(size))
+	case KindMaxKey:
+		p.Key += "." + strings.Repeat("k", MaxKeyLength-len(p.Key)-1)
+		p.Value = rand.Bytes(size)
+	case KindMaxValue:
+		p.Value = rand.Bytes(MaxDataSize - len(p.Key))
+	case KindInvalidKey:
+		p.Key += "/" + unicodeEdgeCases[i%len(unicodeEdgeCases)]
+		p.Value = rand.Bytes(size)
+		p.ExpectInvalid = true
+	case KindOversize:
+		p.Value = rand.Bytes(MaxDataSize + 1)
+		p.ExpectInvalid = true
+	default:
+		p.Value = rand.Bytes(size)
+	}
+	return p
+}
+
+func binaryValue(size int) []byte {
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = byte(rand.Intn(256))
+	}
+	if size > 0 {
+		// always include NUL and an invalid UTF-8 start byte
+		b[0] = 0x00
+	}
+	if size > 1 {
+		b[size-1] = 0xff
+	}
+	return b
+}
+
+var unicodeEdgeCases = []string{
+	"\u0000",       // NUL
+	"\u200d",       // zero-width joiner
+	"\u202e",       // right-to-left override
+	"\ufeff",       // byte order mark
+	"e\u0301",      // combining acute accent
+	"\U0001F600",   // 4-byte emoji
+	"\U0010FFFF",   // maximum code point
+	"\ufffd",       // replacement character
+	"\u00a0",       // no-break space
+	"\u4e2d\u6587", // CJK
+}
+
+// unicodeValue returns the UTF-8 string of the unicode edge cases,
+// truncated at the rune boundary to fit in the size.
+func unicodeValue(size int) string {
+	var sb strings.Builder
+	for sb.Len() < size {
+		sb.WriteString(unicodeEdgeCases[rand.Intn(len(unicodeEdgeCases))])
+	}
+	s := sb.String()
+	for len(s) > size || !utf8.ValidString(s) {
+		_, n := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-n]
+	}
+	return s
+}
+
+// ValidationSummary is the server-side validation results of the payloads.
+type ValidationSummary struct {
+	// Rejected is the number of the invalid payloads rejected by the apiserver as expected.
+	Rejected int `json:"rejected" read-only:"true"`
+	// UnexpectedRejected is the number of the valid payloads rejected by the apiserver validation.
+	UnexpectedRejected int `json:"unexpected_rejected" read-only:"true"`
+	// UnexpectedAccepted is the number of the invalid payloads accepted by the apiserver.
+	UnexpectedAccepted int `json:"unexpected_accepted" read-only:"true"`
+	// RejectedByKind is the number of the payloads rejected by the apiserver validation, by kind.
+	RejectedByKind map[string]int `json:"rejected_by_kind" read-only:"true"`
+}
+
+// Observe records the write result of the payload.
+// "invalid" is true if the apiserver rejected the write with a validation error.
+func (s *ValidationSummary) Observe(p Payload, invalid bool, accepted bool) {
+	if invalid {
+		if s.RejectedByKind == nil {
+			s.RejectedByKind = make(map[string]int)
+		}
+		s.RejectedByKind[p.Kind]++
+		if p.ExpectInvalid {
+			s.Rejected++
+		} else {
+			s.UnexpectedRejected++
+		}
+		return
+	}
+	if accepted && p.ExpectInvalid {
+		s.UnexpectedAccepted++
+	}
+}
+
+// Err returns an error if the apiserver validation did not behave as expected.
+func (s ValidationSummary) Err() error {
+	if s.UnexpectedRejected == 0 && s.UnexpectedAccepted == 0 {
+		return nil
+	}
+	return fmt.Errorf("unexpected server-side validation results (%d valid payloads rejected, %d invalid payloads accepted)", s.UnexpectedRejected, s.UnexpectedAccepted)
+}
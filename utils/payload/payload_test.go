@@ -0,0 +1,76 @@
+package payload
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGenerate(t *testing.T) {
+	for i, kind := range Kinds {
+		p := Generate(KindMixed, "data", i, 100)
+		if p.Kind != kind {
+			t.Fatalf("#%d: expected kind %q, got %q", i, kind, p.Kind)
+		}
+		if !strings.HasPrefix(p.Key, "data") {
+			t.Fatalf("#%d: unexpected key %q", i, p.Key)
+		}
+		switch kind {
+		case KindASCII:
+			if len(p.Value) != 100 || !p.UTF8() {
+				t.Fatalf("#%d: unexpected value %q", i, p.Value)
+			}
+		case KindBinary:
+			if len(p.Value) != 100 || p.UTF8() {
+				t.Fatalf("#%d: expected non UTF-8 value, got %q", i, p.Value)
+			}
+		case KindUnicode:
+			if len(p.Value) > 100 || !p.UTF8() || utf8.RuneCount(p.Value) == len(p.Value) {
+				t.Fatalf("#%d: expected multi-byte UTF-8 value, got %q", i, p.Value)
+			}
+		case KindMaxKey:
+			if len(p.Key) != MaxKeyLength {
+				t.Fatalf("#%d: expected key length %d, got %d", i, MaxKeyLength, len(p.Key))
+			}
+		case KindMaxValue:
+			if len(p.Key)+len(p.Value) != MaxDataSize || p.ExpectInvalid {
+				t.Fatalf("#%d: expected data size %d, got %d", i, MaxDataSize, len(p.Key)+len(p.Value))
+			}
+		case KindInvalidKey:
+			if !strings.Contains(p.Key, "/") || !p.ExpectInvalid {
+				t.Fatalf("#%d: unexpected invalid key payload %+v", i, p)
+			}
+		case KindOversize:
+			if len(p.Value) <= MaxDataSize || !p.ExpectInvalid {
+				t.Fatalf("#%d: unexpected oversize payload length %d", i, len(p.Value))
+			}
+		}
+	}
+
+	if err := Valid(KindMixed); err != nil {
+		t.Fatal(err)
+	}
+	if err := Valid("unknown"); err == nil {
+		t.Fatal("expected error for unknown kind")
+	}
+}
+
+func TestValidationSummary(t *testing.T) {
+	var s ValidationSummary
+	s.Observe(Payload{Kind: KindASCII}, false, true)
+	s.Observe(Payload{Kind: KindOversize, ExpectInvalid: true}, true, false)
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	s.Observe(Payload{Kind: KindUnicode}, true, false)
+	s.Observe(Payload{Kind: KindInvalidKey, ExpectInvalid: true}, false, true)
+	if s.Rejected != 1 || s.UnexpectedRejected != 1 || s.UnexpectedAccepted != 1 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if s.RejectedByKind[KindUnicode] != 1 || s.RejectedByKind[KindOversize] != 1 {
+		t.Fatalf("unexpected rejected by kind %v", s.RejectedByKind)
+	}
+	if err := s.Err(); err == nil {
+		t.Fatal("expected error")
+	}
+}
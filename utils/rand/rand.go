@@ -1,23 +1,79 @@
 // Package rand implements random utilities.
+//
+// All values are generated from a package-level source, which can be reset
+// with "Seed" to reproduce the same sequence of random values in a test run.
 package rand
 
 import (
 	"encoding/hex"
+	"hash/fnv"
 	"math/rand"
+	"sync"
 	"time"
 )
 
 const ll = "0123456789abcdefghijklmnopqrstuvwxyz"
 
+var (
+	mu  sync.Mutex
+	src = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// NewSeed returns a new non-zero seed from the current time.
+func NewSeed() int64 {
+	s := time.Now().UnixNano()
+	if s == 0 {
+		s = 1
+	}
+	return s
+}
+
+// Seed resets the package source with the seed.
+func Seed(seed int64) {
+	mu.Lock()
+	src = rand.New(rand.NewSource(seed))
+	mu.Unlock()
+}
+
+// DeriveSeed returns the seed derived from the run seed and the name,
+// so that each tester gets the same values regardless of the other testers.
+func DeriveSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return seed ^ int64(h.Sum64())
+}
+
+// Intn returns a random number in [0, n).
+func Intn(n int) int {
+	mu.Lock()
+	defer mu.Unlock()
+	return src.Intn(n)
+}
+
+// Int63n returns a random number in [0, n).
+func Int63n(n int64) int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return src.Int63n(n)
+}
+
+// Float64 returns a random number in [0.0, 1.0).
+func Float64() float64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return src.Float64()
+}
+
 func String(n int) string {
+	mu.Lock()
+	defer mu.Unlock()
+
 	b := make([]byte, n)
 	for i := range b {
-		rand.Seed(time.Now().UnixNano())
-		b[i] = ll[rand.Intn(len(ll))]
+		b[i] = ll[src.Intn(len(ll))]
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	pfx := randoms[rand.Intn(len(randoms))]
+	pfx := randoms[src.Intn(len(randoms))]
 	s := pfx + string(b)
 	if len(s) > n {
 		s = s[:n]
@@ -20,3 +20,23 @@ func TestRand(t *testing.T) {
 	fmt.Println(Hex(32))
 	fmt.Println(hex.EncodeToString(Bytes(32)))
 }
+
+func TestSeed(t *testing.T) {
+	Seed(42)
+	a := String(20)
+	n := Intn(1000)
+	Seed(42)
+	if b := String(20); a != b {
+		t.Fatalf("expected %q, got %q", a, b)
+	}
+	if m := Intn(1000); n != m {
+		t.Fatalf("expected %d, got %d", n, m)
+	}
+
+	if DeriveSeed(42, "configmaps") == DeriveSeed(42, "secrets") {
+		t.Fatal("expected different derived seeds")
+	}
+	if DeriveSeed(42, "configmaps") != DeriveSeed(42, "configmaps") {
+		t.Fatal("expected same derived seeds")
+	}
+}